@@ -0,0 +1,341 @@
+// Package accounting is the entry point for integrators that want to post
+// documents without assembling the lower-level packages themselves.
+//
+// PostSalesInvoice takes a customer, a date and item rows and runs the
+// steps of submitting a Sales Invoice in ERPNext: party defaults, item
+// defaults, price list rates, tax template selection, the tax and totals
+// calculation, the payment schedule, naming, and posting to the ledger.
+// Each step is the package that implements it (party, taxcalc, pricelist,
+// paymentterms, naming, salesinvoice, ledger); this package only wires
+// them together in the order ERPNext's validate() and on_submit() do.
+//
+// Maps to: SalesInvoice.validate() / on_submit() in
+// erpnext/accounts/doctype/sales_invoice/sales_invoice.py
+package accounting
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/ledger"
+	"github.com/senguttuvang/erpnext-go/naming"
+	"github.com/senguttuvang/erpnext-go/party"
+	"github.com/senguttuvang/erpnext-go/paymentterms"
+	"github.com/senguttuvang/erpnext-go/pricelist"
+	"github.com/senguttuvang/erpnext-go/salesinvoice"
+	"github.com/senguttuvang/erpnext-go/taxcalc"
+	"github.com/senguttuvang/erpnext-go/territory"
+)
+
+// Posting errors
+var (
+	ErrNoName        = errors.New("invoice has no name and no naming series is configured")
+	ErrPartyDisabled = errors.New("party is disabled")
+	ErrNoItems       = errors.New("invoice has no items")
+)
+
+// Transactor runs a unit of work atomically. A database adapter begins a
+// transaction and runs fn with an Engine and a naming Registry whose
+// stores write in that transaction, committing only if fn succeeds, so
+// the naming series counter and the GL and payment ledger entries are
+// written together or not at all. The Engine is otherwise configured as
+// the Accounting's; a nil Registry means documents must come named.
+type Transactor interface {
+	InTransaction(ctx context.Context, fn func(ctx context.Context, engine *ledger.Engine, names *naming.Registry) error) error
+}
+
+// Accounting holds the masters and ports documents are posted with.
+type Accounting struct {
+	Engine  *ledger.Engine
+	Parties *party.PartyAccountResolver
+
+	Items        taxcalc.ItemMaster        // Optional; item rows must carry their accounts when nil
+	Prices       *pricelist.Resolver       // Optional; item rows must carry their rates when nil
+	Territories  *territory.Tree           // Optional; territory defaults and tax rules on territory
+	TaxRules     []*taxcalc.TaxRule        // Optional
+	TaxTemplates []*taxcalc.TaxTemplate    // Optional
+	PaymentTerms paymentterms.Lookup       // Optional; invoices fall due on the posting date when nil
+	Names        *naming.Registry          // Optional; inputs must be named when nil. Unused with Transactions
+	Precision    taxcalc.PrecisionProvider // Optional; taxcalc defaults when nil
+	Transactions Transactor                // Optional; names and posts with Engine and Names, without rollback, when nil
+	Decisions    taxcalc.DecisionLog       // Optional; how taxes were chosen is not kept when nil
+}
+
+// SalesInvoiceInput is what an integrator knows about a sale. Empty
+// fields take the defaults ERPNext would fill in.
+type SalesInvoiceInput struct {
+	Name         string // Taken from the naming series when empty
+	NamingSeries string // The doctype's default series when empty
+
+	Company         string
+	CompanyCurrency string
+	Customer        string
+	PostingDate     time.Time
+	DueDate         *time.Time // Without payment terms; the posting date when nil
+
+	Currency          string  // Company currency when empty
+	ConversionRate    float64 // To company currency; 1 when zero
+	PriceList         string  // The territory's when empty
+	PLCConversionRate float64 // Price list to company currency, for price lists in another currency
+
+	TaxCategory     string // The customer's, else the territory's, when empty
+	BillingCountry  string
+	ShippingCountry string
+	PaymentTerms    string // The customer's or its group's when empty
+
+	DebitTo    string // The customer's receivable account when empty
+	CostCenter string
+	Project    string
+	Remarks    string
+
+	Items []*taxcalc.LineItem
+}
+
+// PostedInvoice is the result of posting an invoice.
+type PostedInvoice struct {
+	Invoice         *salesinvoice.SalesInvoice
+	GLEntries       []ledger.GLEntry // As stored, after merging and round-off
+	PaymentSchedule []paymentterms.Row
+}
+
+// PostSalesInvoice builds, calculates and posts a sales invoice in one
+// call. Everything that can fail on bad input is checked before anything
+// is written; naming and posting then run in one transaction, on the
+// engine and registry of the Transactor, when one is configured.
+func (a *Accounting) PostSalesInvoice(ctx context.Context, in SalesInvoiceInput) (*PostedInvoice, error) {
+	if len(in.Items) == 0 {
+		return nil, ErrNoItems
+	}
+	customer, err := a.Parties.Parties.GetParty(party.Customer, in.Customer)
+	if err != nil {
+		return nil, err
+	}
+	if customer.Disabled {
+		return nil, fmt.Errorf("%w: %s", ErrPartyDisabled, in.Customer)
+	}
+
+	inv, err := a.newSalesInvoice(in, customer)
+	if err != nil {
+		return nil, err
+	}
+	decisions, err := a.setItemsAndTaxes(inv, in, customer)
+	if err != nil {
+		return nil, err
+	}
+	if err := inv.Calculate(a.Precision); err != nil {
+		return nil, err
+	}
+	schedule, err := a.paymentSchedule(inv, in)
+	if err != nil {
+		return nil, err
+	}
+	due := paymentterms.DueDate(schedule)
+	inv.DueDate = &due
+	if _, err := inv.GetGLEntries(); err != nil {
+		return nil, err
+	}
+
+	post := func(ctx context.Context, engine *ledger.Engine, names *naming.Registry) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if inv.Name == "" {
+			if names == nil {
+				return ErrNoName
+			}
+			name, err := names.NewName(salesinvoice.VoucherType, naming.Options{Series: in.NamingSeries, Date: inv.PostingDate})
+			if err != nil {
+				return err
+			}
+			inv.Name = name
+		}
+		if err := inv.Submit(engine, a.Precision); err != nil {
+			return err
+		}
+		return a.recordDecisions(inv.Name, decisions)
+	}
+	if a.Transactions != nil {
+		err = a.Transactions.InTransaction(ctx, post)
+	} else {
+		err = post(ctx, a.Engine, a.Names)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	posted := &PostedInvoice{Invoice: inv, PaymentSchedule: schedule}
+	if a.Engine.GLStore != nil {
+		if posted.GLEntries, err = a.Engine.GLStore.GetByVoucher(salesinvoice.VoucherType, inv.Name); err != nil {
+			return posted, err
+		}
+	}
+	return posted, nil
+}
+
+// newSalesInvoice returns the invoice header with party defaults applied.
+//
+// Maps to: set_missing_values() in sales_invoice.py
+func (a *Accounting) newSalesInvoice(in SalesInvoiceInput, customer *party.Party) (*salesinvoice.SalesInvoice, error) {
+	inv := &salesinvoice.SalesInvoice{
+		Name:            in.Name,
+		Company:         in.Company,
+		Customer:        in.Customer,
+		PostingDate:     in.PostingDate,
+		DebitTo:         in.DebitTo,
+		CompanyCurrency: in.CompanyCurrency,
+		CostCenter:      in.CostCenter,
+		Project:         in.Project,
+		Remarks:         in.Remarks,
+		Status:          salesinvoice.Draft,
+		Document: taxcalc.Document{
+			Currency:       in.Currency,
+			ConversionRate: in.ConversionRate,
+			TaxCategory:    in.TaxCategory,
+			Items:          in.Items,
+		},
+	}
+	if inv.Currency == "" {
+		inv.Currency = inv.CompanyCurrency
+	}
+	if inv.ConversionRate == 0 {
+		inv.ConversionRate = 1
+	}
+	if inv.TaxCategory == "" {
+		inv.TaxCategory = customer.TaxCategory
+	}
+	if inv.TaxCategory == "" && a.Territories != nil {
+		inv.TaxCategory = a.Territories.TaxCategory(customer.Territory)
+	}
+	if inv.DebitTo == "" {
+		account, err := a.Parties.PartyAccount(party.Customer, in.Customer, in.Company)
+		if err != nil {
+			return nil, err
+		}
+		inv.DebitTo = account
+	}
+	return inv, nil
+}
+
+// setItemsAndTaxes fills item defaults and price list rates, then the
+// taxes of the template a tax rule selects, else the company's default
+// template for the tax category. It returns the decisions behind the
+// taxes, made before the invoice has its name.
+//
+// Maps to: set_missing_item_details() and set_taxes() in
+// controllers/accounts_controller.py
+func (a *Accounting) setItemsAndTaxes(inv *salesinvoice.SalesInvoice, in SalesInvoiceInput, customer *party.Party) ([]taxcalc.Decision, error) {
+	if a.Items != nil {
+		if err := inv.SetMissingItemDetails(a.Items); err != nil {
+			return nil, err
+		}
+	}
+
+	priceList := in.PriceList
+	if priceList == "" && a.Territories != nil {
+		priceList = a.Territories.PriceList(customer.Territory)
+	}
+	if a.Prices != nil && priceList != "" {
+		err := a.Prices.Apply(&inv.Document, pricelist.Args{
+			PriceList:         priceList,
+			Customer:          in.Customer,
+			Date:              in.PostingDate,
+			PLCConversionRate: in.PLCConversionRate,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(inv.Taxes) > 0 {
+		return nil, nil
+	}
+	args := taxcalc.TaxRuleArgs{
+		TaxType:         taxcalc.TaxRuleSales,
+		Company:         in.Company,
+		Date:            in.PostingDate,
+		Customer:        in.Customer,
+		BillingCountry:  in.BillingCountry,
+		ShippingCountry: in.ShippingCountry,
+		TaxCategory:     inv.TaxCategory,
+	}
+	groups, err := a.Parties.GroupPath(party.Customer, in.Customer)
+	if err != nil {
+		return nil, err
+	}
+	args.CustomerGroups = groups
+	if a.Territories != nil && customer.Territory != "" {
+		args.Territories = append([]string{customer.Territory}, a.Territories.Ancestors(customer.Territory)...)
+	}
+	rule, ruleDecision := taxcalc.ExplainTaxRule(a.TaxRules, args)
+	decisions := []taxcalc.Decision{ruleDecision}
+	if rule != nil {
+		if t := rule.Template(a.TaxTemplates); t != nil {
+			inv.TaxesAndCharges = t.Name
+			inv.Taxes = t.Rows()
+			return append(decisions, taxcalc.Decision{
+				Kind: taxcalc.DecisionTaxTemplate, Chosen: t.Name, Reason: "tax rule " + rule.Name,
+			}), nil
+		}
+	}
+	t, templateDecision := taxcalc.ExplainTaxTemplate(a.TaxTemplates, inv.Company, inv.TaxCategory)
+	if rule != nil {
+		templateDecision.Reason = fmt.Sprintf("%s; template %s of tax rule %s is missing or disabled", templateDecision.Reason, rule.TaxTemplate, rule.Name)
+	}
+	if t != nil {
+		inv.TaxesAndCharges = t.Name
+		inv.Taxes = t.Rows()
+	}
+	return append(decisions, templateDecision), nil
+}
+
+// recordDecisions logs the decisions of a named document.
+func (a *Accounting) recordDecisions(name string, decisions []taxcalc.Decision) error {
+	if a.Decisions == nil || len(decisions) == 0 {
+		return nil
+	}
+	now := time.Now()
+	for i := range decisions {
+		decisions[i].Document, decisions[i].At = name, now
+	}
+	return a.Decisions.Record(decisions...)
+}
+
+// paymentSchedule splits the receivable amount by the invoice's payment
+// terms.
+//
+// Maps to: set_payment_schedule() in controllers/accounts_controller.py
+func (a *Accounting) paymentSchedule(inv *salesinvoice.SalesInvoice, in SalesInvoiceInput) ([]paymentterms.Row, error) {
+	total, baseTotal := inv.GrandTotal, inv.BaseGrandTotal
+	if inv.RoundedTotal != 0 {
+		total, baseTotal = inv.RoundedTotal, inv.BaseRoundedTotal
+	}
+
+	terms := in.PaymentTerms
+	if terms == "" && a.PaymentTerms != nil {
+		var err error
+		if terms, err = a.Parties.PaymentTerms(party.Customer, in.Customer); err != nil {
+			return nil, err
+		}
+	}
+	if terms != "" && a.PaymentTerms == nil {
+		return nil, fmt.Errorf("%w: %s", paymentterms.ErrNotFound, terms)
+	}
+	if terms == "" {
+		due := inv.PostingDate
+		if in.DueDate != nil {
+			due = *in.DueDate
+		}
+		return paymentterms.Single(due, total, baseTotal), nil
+	}
+	template, err := a.PaymentTerms.GetTemplate(terms)
+	if err != nil {
+		return nil, err
+	}
+	precision := 2
+	if a.Precision != nil {
+		precision = a.Precision.GetPrecision("grand_total")
+	}
+	return template.Schedule(inv.PostingDate, total, baseTotal, precision)
+}
@@ -0,0 +1,259 @@
+package accounting
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/ledger"
+	"github.com/senguttuvang/erpnext-go/ledger/memstore"
+	"github.com/senguttuvang/erpnext-go/naming"
+	"github.com/senguttuvang/erpnext-go/party"
+	"github.com/senguttuvang/erpnext-go/paymentterms"
+	"github.com/senguttuvang/erpnext-go/pricelist"
+	"github.com/senguttuvang/erpnext-go/taxcalc"
+	"github.com/senguttuvang/erpnext-go/territory"
+)
+
+var errNotFound = errors.New("not found")
+
+type directory struct {
+	parties map[string]*party.Party
+	groups  map[string]*party.Group
+}
+
+func (d *directory) GetParty(partyType party.PartyType, name string) (*party.Party, error) {
+	if p, ok := d.parties[name]; ok && p.PartyType == partyType {
+		return p, nil
+	}
+	return nil, errNotFound
+}
+
+func (d *directory) GetGroup(partyType party.PartyType, name string) (*party.Group, error) {
+	if g, ok := d.groups[name]; ok && g.PartyType == partyType {
+		return g, nil
+	}
+	return nil, errNotFound
+}
+
+type items map[string]*taxcalc.ItemDefaults
+
+func (m items) GetItemDefaults(itemCode, company string) (*taxcalc.ItemDefaults, error) {
+	if d, ok := m[itemCode]; ok {
+		return d, nil
+	}
+	return nil, taxcalc.ErrItemNotFound
+}
+
+// transactor stages the GL entries of a unit of work in a store of its
+// own and writes them to the books only when the work succeeds. It counts
+// the units of work.
+type transactor struct {
+	books *ledger.Engine
+	names *naming.Registry
+	calls int
+}
+
+func (t *transactor) InTransaction(ctx context.Context, fn func(ctx context.Context, engine *ledger.Engine, names *naming.Registry) error) error {
+	t.calls++
+	staged := memstore.NewGLStore()
+	engine := &ledger.Engine{GLStore: staged, PaymentStore: t.books.PaymentStore}
+	if err := fn(ctx, engine, t.names); err != nil {
+		return err
+	}
+	return t.books.GLStore.SaveBatch(staged.All())
+}
+
+// failingLog refuses every decision.
+type failingLog struct{}
+
+func (failingLog) Record(...taxcalc.Decision) error {
+	return errors.ErrUnsupported
+}
+
+func newAccounting(t *testing.T) (*Accounting, *memstore.GLStore) {
+	t.Helper()
+	d := &directory{
+		groups: map[string]*party.Group{
+			"All Customer Groups": {Name: "All Customer Groups", PartyType: party.Customer},
+			"Commercial": {Name: "Commercial", PartyType: party.Customer, Parent: "All Customer Groups", PaymentTerms: "50-50",
+				Accounts: []party.PartyAccount{{Company: "ACME", Account: "Debtors - ACME"}}},
+		},
+		parties: map[string]*party.Party{
+			"Globex":  {Name: "Globex", PartyType: party.Customer, Group: "Commercial", Territory: "Tamil Nadu"},
+			"Initech": {Name: "Initech", PartyType: party.Customer, Group: "Commercial", Disabled: true},
+		},
+	}
+	tree, err := territory.NewTree(
+		territory.Territory{Name: "All Territories", DefaultPriceList: "Standard Selling"},
+		territory.Territory{Name: "India", Parent: "All Territories"},
+		territory.Territory{Name: "Tamil Nadu", Parent: "India", TaxCategory: "In-State"},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := naming.NewRegistry(memstore.NewSeries())
+	if err := names.RegisterDefaults(); err != nil {
+		t.Fatal(err)
+	}
+	glStore := memstore.NewGLStore()
+	return &Accounting{
+		Engine:  &ledger.Engine{GLStore: glStore, PaymentStore: memstore.NewPaymentStore()},
+		Parties: &party.PartyAccountResolver{Parties: d, Groups: d},
+		Items: items{
+			"WIDGET": {ItemCode: "WIDGET", StockUOM: "Nos", IncomeAccount: "Sales - ACME", CostCenter: "Main - ACME"},
+		},
+		Prices: &pricelist.Resolver{Store: &pricelist.MemoryStore{
+			PriceLists: map[string]*pricelist.PriceList{
+				"Standard Selling": {Name: "Standard Selling", Currency: "INR", Selling: true, Enabled: true},
+			},
+			Prices: []pricelist.ItemPrice{{ItemCode: "WIDGET", PriceList: "Standard Selling", PriceListRate: 500}},
+		}},
+		Territories: tree,
+		TaxRules: []*taxcalc.TaxRule{
+			{Name: "Commercial In-State", TaxType: taxcalc.TaxRuleSales, Company: "ACME", CustomerGroup: "All Customer Groups",
+				Territory: "India", TaxCategory: "In-State", TaxTemplate: "GST In-State"},
+		},
+		TaxTemplates: []*taxcalc.TaxTemplate{
+			{Name: "IGST", Company: "ACME", IsDefault: true, Taxes: []taxcalc.TaxRow{
+				{AccountHead: "IGST - ACME", ChargeType: taxcalc.OnNetTotal, Rate: 18},
+			}},
+			{Name: "GST In-State", Company: "ACME", TaxCategory: "In-State", Taxes: []taxcalc.TaxRow{
+				{AccountHead: "CGST - ACME", ChargeType: taxcalc.OnNetTotal, Rate: 9},
+				{AccountHead: "SGST - ACME", ChargeType: taxcalc.OnNetTotal, Rate: 9},
+			}},
+		},
+		PaymentTerms: paymentterms.MemoryStore{
+			"50-50": {Name: "50-50", Terms: []paymentterms.Term{
+				{PaymentTerm: "Advance", InvoicePortion: 50, DueDateBasedOn: paymentterms.DaysAfterInvoiceDate},
+				{PaymentTerm: "Balance", InvoicePortion: 50, DueDateBasedOn: paymentterms.DaysAfterInvoiceDate, CreditDays: 30},
+			}},
+		},
+		Names: names,
+	}, glStore
+}
+
+func newInput() SalesInvoiceInput {
+	return SalesInvoiceInput{
+		Company:         "ACME",
+		CompanyCurrency: "INR",
+		Customer:        "Globex",
+		PostingDate:     time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC),
+		Items:           []*taxcalc.LineItem{{ItemCode: "WIDGET", Qty: 2}},
+	}
+}
+
+func TestPostSalesInvoice(t *testing.T) {
+	a, glStore := newAccounting(t)
+	tx := &transactor{books: a.Engine, names: a.Names}
+	a.Transactions = tx
+	decisions := &taxcalc.MemoryDecisionLog{}
+	a.Decisions = decisions
+
+	posted, err := a.PostSalesInvoice(context.Background(), newInput())
+	if err != nil {
+		t.Fatalf("PostSalesInvoice() error = %v", err)
+	}
+	inv := posted.Invoice
+	if inv.Name != "ACC-SINV-2024-00001" || inv.DebitTo != "Debtors - ACME" || inv.TaxesAndCharges != "GST In-State" || tx.calls != 1 {
+		t.Errorf("invoice = %s to %s with %q, %d transactions", inv.Name, inv.DebitTo, inv.TaxesAndCharges, tx.calls)
+	}
+	if inv.NetTotal != 1000 || inv.GrandTotal != 1180 || inv.Items[0].Rate != 500 {
+		t.Errorf("totals = %v / %v at rate %v", inv.NetTotal, inv.GrandTotal, inv.Items[0].Rate)
+	}
+	if inv.DueDate == nil || !inv.DueDate.Equal(time.Date(2024, 2, 14, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("DueDate = %v", inv.DueDate)
+	}
+
+	if len(posted.PaymentSchedule) != 2 || posted.PaymentSchedule[0].PaymentAmount != 590 || posted.PaymentSchedule[1].PaymentAmount != 590 {
+		t.Errorf("PaymentSchedule = %+v", posted.PaymentSchedule)
+	}
+
+	want := map[string][2]float64{
+		"Debtors - ACME": {1180, 0},
+		"CGST - ACME":    {0, 90},
+		"SGST - ACME":    {0, 90},
+		"Sales - ACME":   {0, 1000},
+	}
+	if len(posted.GLEntries) != len(want) || len(glStore.All()) != len(want) {
+		t.Fatalf("GL entries = %+v", posted.GLEntries)
+	}
+	for _, e := range posted.GLEntries {
+		if w, ok := want[e.Account]; !ok || e.Debit != w[0] || e.Credit != w[1] || e.VoucherNo != inv.Name {
+			t.Errorf("%s %v/%v on %s", e.Account, e.Debit, e.Credit, e.VoucherNo)
+		}
+	}
+
+	logged := decisions.Decisions(inv.Name)
+	if len(logged) != 2 || logged[0].Chosen != "Commercial In-State" || logged[1].Chosen != "GST In-State" ||
+		logged[1].Reason != "tax rule Commercial In-State" || logged[0].At.IsZero() {
+		t.Errorf("decisions = %+v", logged)
+	}
+
+	// Without payment terms the invoice falls due on the given date
+	in := newInput()
+	in.Name = "SINV-MANUAL-1"
+	due := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	in.DueDate = &due
+	a.PaymentTerms = nil
+	a.Parties.Groups = nil
+	in.DebitTo = "Debtors - ACME"
+	posted, err = a.PostSalesInvoice(context.Background(), in)
+	if err != nil {
+		t.Fatalf("PostSalesInvoice(manual) error = %v", err)
+	}
+	if posted.Invoice.Name != "SINV-MANUAL-1" || len(posted.PaymentSchedule) != 1 || !posted.Invoice.DueDate.Equal(due) {
+		t.Errorf("manual invoice = %s, schedule %+v", posted.Invoice.Name, posted.PaymentSchedule)
+	}
+	if logged := decisions.Decisions("SINV-MANUAL-1"); len(logged) != 2 || logged[0].Chosen != "" || logged[1].Chosen != "GST In-State" {
+		t.Errorf("decisions without groups = %+v", logged)
+	}
+}
+
+func TestPostSalesInvoiceRollsBack(t *testing.T) {
+	a, glStore := newAccounting(t)
+	a.Transactions = &transactor{books: a.Engine, names: a.Names}
+	a.Decisions = failingLog{}
+	if _, err := a.PostSalesInvoice(context.Background(), newInput()); !errors.Is(err, errors.ErrUnsupported) {
+		t.Fatalf("PostSalesInvoice() error = %v", err)
+	}
+	if n := len(glStore.All()); n != 0 {
+		t.Errorf("%d GL entries written by a failed transaction", n)
+	}
+}
+
+func TestPostSalesInvoiceErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(a *Accounting, in *SalesInvoiceInput)
+		want  error
+	}{
+		{"no items", func(a *Accounting, in *SalesInvoiceInput) { in.Items = nil }, ErrNoItems},
+		{"disabled customer", func(a *Accounting, in *SalesInvoiceInput) { in.Customer = "Initech" }, ErrPartyDisabled},
+		{"unknown customer", func(a *Accounting, in *SalesInvoiceInput) { in.Customer = "Hooli" }, errNotFound},
+		{"unknown template", func(a *Accounting, in *SalesInvoiceInput) { in.PaymentTerms = "Net 90" }, paymentterms.ErrNotFound},
+		{"no price", func(a *Accounting, in *SalesInvoiceInput) { in.Items[0].ItemCode = "GADGET" }, taxcalc.ErrItemNotFound},
+		{"no naming", func(a *Accounting, in *SalesInvoiceInput) { a.Names = nil }, ErrNoName},
+		{"cancelled", func(a *Accounting, in *SalesInvoiceInput) {}, context.Canceled},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, glStore := newAccounting(t)
+			in := newInput()
+			tt.setup(a, &in)
+			ctx := context.Background()
+			if tt.want == context.Canceled {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithCancel(ctx)
+				cancel()
+			}
+			if _, err := a.PostSalesInvoice(ctx, in); !errors.Is(err, tt.want) {
+				t.Errorf("PostSalesInvoice() error = %v, want %v", err, tt.want)
+			}
+			if n := len(glStore.All()); n != 0 {
+				t.Errorf("%d GL entries written", n)
+			}
+		})
+	}
+}
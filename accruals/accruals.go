@@ -0,0 +1,469 @@
+// Package accruals posts end-of-day accruals and revalues foreign-currency
+// bank balances.
+//
+// An Accrual books a recurring charge or income that has been earned but
+// not yet settled: bank interest, facility or commitment fees. It posts a
+// fixed amount, or interest at an annual rate on the balance of an
+// account, every day or at each month end. A Revaluation restates the
+// company-currency value of a foreign-currency bank account at the day's
+// exchange rate and books the difference to exchange gain or loss.
+//
+// Either can be reversing: the next day's run posts the mirror image of
+// the previous day's voucher, so the books carry the accrual or the
+// revalued balance only overnight and the settlement or the next
+// revaluation starts from the original figures. A Prepaid amortizes an
+// expense paid in advance over the months it covers. The Runner is driven by
+// the jobs subsystem; see Task.
+//
+// Maps to: the Exchange Rate Revaluation doctype
+// (erpnext/accounts/doctype/exchange_rate_revaluation) and accrual
+// Journal Entries with Reversal Of set
+// (erpnext/accounts/doctype/journal_entry)
+package accruals
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/ledger"
+)
+
+// VoucherType is the voucher type of accrual and revaluation postings.
+const VoucherType = "Journal Entry"
+
+// daysInYear is the day count basis of interest accruals (Actual/365).
+const daysInYear = 365
+
+// Accrual errors
+var (
+	ErrInvalidAccrual = errors.New("invalid accrual")
+	ErrNoExchangeRate = errors.New("exchange rate not found")
+	ErrNotConfigured  = errors.New("accrual runner is missing balances or exchange rates")
+)
+
+// Frequency is how often an accrual posts.
+type Frequency string
+
+const (
+	Daily   Frequency = "Daily"
+	Monthly Frequency = "Monthly" // On the last day of each month
+)
+
+// Accrual is a recurring posting configured for a company.
+type Accrual struct {
+	Name    string
+	Company string
+
+	DebitAccount  string // e.g. Accrued Interest Receivable, or Bank Charges
+	CreditAccount string // e.g. Interest Income, or Accrued Liabilities
+	CostCenter    string
+
+	Frequency Frequency
+	From      time.Time
+	Until     time.Time // Zero for no end
+
+	Amount float64 // Posted each period
+	// Rate, when set, replaces Amount with interest at this annual
+	// percentage on the company-currency balance of RateAccount at the end
+	// of the previous day, for the days of the period.
+	Rate        float64
+	RateAccount string
+
+	Reversing bool // Reverse on the next day
+	Remarks   string
+}
+
+// Validate checks the accrual's configuration.
+func (a *Accrual) Validate() error {
+	switch {
+	case a.Name == "" || a.Company == "":
+		return fmt.Errorf("%w: name and company are required", ErrInvalidAccrual)
+	case a.DebitAccount == "" || a.CreditAccount == "":
+		return fmt.Errorf("%w: %s: debit and credit accounts are required", ErrInvalidAccrual, a.Name)
+	case a.Frequency != Daily && a.Frequency != Monthly:
+		return fmt.Errorf("%w: %s: unknown frequency %q", ErrInvalidAccrual, a.Name, a.Frequency)
+	case a.Rate == 0 && a.Amount <= 0, a.Rate < 0:
+		return fmt.Errorf("%w: %s: amount or rate must be greater than zero", ErrInvalidAccrual, a.Name)
+	case a.Rate > 0 && a.RateAccount == "":
+		return fmt.Errorf("%w: %s: rate account is required", ErrInvalidAccrual, a.Name)
+	case !a.Until.IsZero() && ledger.CivilDate(a.Until).Before(ledger.CivilDate(a.From)):
+		return fmt.Errorf("%w: %s: ends before it starts", ErrInvalidAccrual, a.Name)
+	}
+	return nil
+}
+
+// Due reports whether the accrual posts on date.
+func (a *Accrual) Due(date time.Time) bool {
+	date = ledger.CivilDate(date)
+	if date.Before(ledger.CivilDate(a.From)) || !a.Until.IsZero() && date.After(ledger.CivilDate(a.Until)) {
+		return false
+	}
+	return a.Frequency == Daily || date.AddDate(0, 0, 1).Day() == 1
+}
+
+// periodDays returns the days an accrual posted on date covers, from the
+// later of the period start and From.
+func (a *Accrual) periodDays(date time.Time) int {
+	date = ledger.CivilDate(date)
+	if a.Frequency == Daily {
+		return 1
+	}
+	start := date.AddDate(0, 0, 1-date.Day())
+	if from := ledger.CivilDate(a.From); from.After(start) {
+		start = from
+	}
+	return int(date.Sub(start).Hours()/24) + 1
+}
+
+// Revaluation restates a foreign-currency account at the closing rate.
+type Revaluation struct {
+	Company         string
+	Account         string // A bank account kept in a foreign currency
+	AccountCurrency string
+	CompanyCurrency string
+	GainLossAccount string // Exchange Gain/Loss
+	CostCenter      string
+	Reversing       bool // Reverse on the next day
+}
+
+// Balances returns account balances.
+type Balances interface {
+	// Balance returns the debit-minus-credit balance of an account at the
+	// end of a day, in account currency and in company currency.
+	// Cancelled entries do not count.
+	Balance(company, account string, asOf time.Time) (inAccountCurrency, inCompanyCurrency float64, err error)
+}
+
+// GLBalances computes balances by scanning GL entries, such as
+// memstore.GLStore.All.
+type GLBalances func() []ledger.GLEntry
+
+// Balance implements Balances.
+func (g GLBalances) Balance(company, account string, asOf time.Time) (float64, float64, error) {
+	asOf = ledger.CivilDate(asOf)
+	var inAccount, inCompany float64
+	entries := g()
+	cancelled := ledger.CancelledEntries(entries)
+	for i, e := range entries {
+		if cancelled[i] || e.Company != company || e.Account != account || ledger.CivilDate(e.PostingDate).After(asOf) {
+			continue
+		}
+		inAccount += e.DebitInAccountCurrency - e.CreditInAccountCurrency
+		inCompany += e.Debit - e.Credit
+	}
+	return ledger.Flt(inAccount, 2), ledger.Flt(inCompany, 2), nil
+}
+
+// RateSource returns exchange rates. pricelist.RateSource implementations
+// satisfy it.
+//
+// Maps to: get_exchange_rate() in erpnext/setup/utils.py (Currency Exchange)
+type RateSource interface {
+	ExchangeRate(from, to string, date time.Time) (float64, error)
+}
+
+// Posting is a voucher the Runner posted.
+type Posting struct {
+	VoucherNo string
+	Date      time.Time
+	Amount    float64 // Debit total in company currency
+	Reversal  bool
+}
+
+// Runner posts the end-of-day accruals and revaluations.
+type Runner struct {
+	Engine       *ledger.Engine
+	Accruals     []Accrual
+	Revaluations []Revaluation
+	Prepaids     []Prepaid
+	Balances     Balances   // Required for rate accruals and revaluations
+	Rates        RateSource // Required for revaluations
+}
+
+// RunDay posts the day's work in order: the reversals of the previous
+// day's reversing vouchers, the accruals due on date, then the
+// revaluations at date, so revaluations see the day's accruals and
+// start from the reversed balance. Vouchers have names derived from the
+// configuration and the date, and a voucher already in the GL store is
+// not posted again, so a day can be run twice. Errors are collected; one
+// failing posting does not stop the others.
+func (r *Runner) RunDay(date time.Time) ([]Posting, error) {
+	date = ledger.CivilDate(date)
+	var posted []Posting
+	var errs []error
+	collect := func(p *Posting, err error) {
+		if err != nil {
+			errs = append(errs, err)
+		} else if p != nil {
+			posted = append(posted, *p)
+		}
+	}
+	for _, key := range r.steps(date) {
+		collect(r.step(key, date))
+	}
+	return posted, errors.Join(errs...)
+}
+
+// Step keys
+const (
+	stepReverse = "reverse:"
+	stepAccrue  = "accrue:"
+	stepRevalue = "revalue:"
+
+	stepAmortize  = "amortize:"
+	stepTerminate = "terminate:"
+)
+
+// steps returns the keys of the postings RunDay makes on date, in order.
+func (r *Runner) steps(date time.Time) []string {
+	var keys []string
+	for _, voucherNo := range r.reversing(date.AddDate(0, 0, -1)) {
+		keys = append(keys, stepReverse+voucherNo)
+	}
+	for _, a := range r.Accruals {
+		keys = append(keys, stepAccrue+a.Name)
+	}
+	for i := range r.Prepaids {
+		keys = append(keys, r.Prepaids[i].steps(date)...)
+	}
+	for _, v := range r.Revaluations {
+		keys = append(keys, stepRevalue+v.Account)
+	}
+	return keys
+}
+
+// step makes the posting of a step key.
+func (r *Runner) step(key string, date time.Time) (*Posting, error) {
+	switch {
+	case strings.HasPrefix(key, stepReverse):
+		return r.Reverse(strings.TrimPrefix(key, stepReverse), date)
+	case strings.HasPrefix(key, stepAccrue):
+		name := strings.TrimPrefix(key, stepAccrue)
+		for i := range r.Accruals {
+			if r.Accruals[i].Name == name {
+				return r.Accrue(&r.Accruals[i], date)
+			}
+		}
+		return nil, fmt.Errorf("%w: %s not configured", ErrInvalidAccrual, name)
+	case strings.HasPrefix(key, stepRevalue):
+		account := strings.TrimPrefix(key, stepRevalue)
+		for i := range r.Revaluations {
+			if r.Revaluations[i].Account == account {
+				return r.Revalue(&r.Revaluations[i], date)
+			}
+		}
+		return nil, fmt.Errorf("%w: revaluation of %s not configured", ErrInvalidAccrual, account)
+	case strings.HasPrefix(key, stepAmortize):
+		voucherNo := strings.TrimPrefix(key, stepAmortize)
+		sep := strings.LastIndex(voucherNo, "/")
+		name, n := voucherNo[:max(sep, 0)], voucherNo[sep+1:]
+		i, err := strconv.Atoi(n)
+		if p := r.prepaid(name); p != nil && err == nil {
+			return r.Amortize(p, i, date)
+		}
+		return nil, fmt.Errorf("%w: %s not configured", ErrInvalidAccrual, voucherNo)
+	case strings.HasPrefix(key, stepTerminate):
+		name := strings.TrimPrefix(key, stepTerminate)
+		if p := r.prepaid(name); p != nil {
+			return r.Terminate(p, date)
+		}
+		return nil, fmt.Errorf("%w: %s not configured", ErrInvalidAccrual, name)
+	}
+	return nil, fmt.Errorf("%w: step %q", ErrInvalidAccrual, key)
+}
+
+// prepaid returns the prepaid item with a name, or nil.
+func (r *Runner) prepaid(name string) *Prepaid {
+	for i := range r.Prepaids {
+		if r.Prepaids[i].Name == name {
+			return &r.Prepaids[i]
+		}
+	}
+	return nil
+}
+
+// reversing returns the vouchers of reversing accruals and revaluations
+// that would have been posted on date.
+func (r *Runner) reversing(date time.Time) []string {
+	var vouchers []string
+	for i := range r.Accruals {
+		if a := &r.Accruals[i]; a.Reversing && a.Due(date) {
+			vouchers = append(vouchers, AccrualVoucher(a, date))
+		}
+	}
+	for i := range r.Revaluations {
+		if v := &r.Revaluations[i]; v.Reversing {
+			vouchers = append(vouchers, RevaluationVoucher(v, date))
+		}
+	}
+	return vouchers
+}
+
+// AccrualVoucher returns the voucher number of an accrual posted on date.
+func AccrualVoucher(a *Accrual, date time.Time) string {
+	return a.Name + "/" + date.Format(ledger.DateLayout)
+}
+
+// RevaluationVoucher returns the voucher number of a revaluation on date.
+func RevaluationVoucher(v *Revaluation, date time.Time) string {
+	return "Revaluation/" + v.Account + "/" + date.Format(ledger.DateLayout)
+}
+
+// ReversalVoucher returns the voucher number of the reversal of a voucher.
+func ReversalVoucher(voucherNo string) string {
+	return voucherNo + "/Reversal"
+}
+
+// Accrue posts an accrual if it is due on date and not yet posted. It
+// returns nil when there is nothing to post.
+func (r *Runner) Accrue(a *Accrual, date time.Time) (*Posting, error) {
+	if err := a.Validate(); err != nil {
+		return nil, err
+	}
+	if !a.Due(date) {
+		return nil, nil
+	}
+	voucherNo := AccrualVoucher(a, date)
+	if done, err := r.posted(voucherNo); done || err != nil {
+		return nil, err
+	}
+
+	amount := a.Amount
+	if a.Rate > 0 {
+		if r.Balances == nil {
+			return nil, ErrNotConfigured
+		}
+		_, balance, err := r.Balances.Balance(a.Company, a.RateAccount, date.AddDate(0, 0, -1))
+		if err != nil {
+			return nil, err
+		}
+		amount = balance * a.Rate / 100 * float64(a.periodDays(date)) / daysInYear
+	}
+	amount = ledger.Flt(amount, 2)
+	if amount <= 0 {
+		return nil, nil
+	}
+
+	remarks := a.Remarks
+	if remarks == "" {
+		remarks = "Accrual: " + a.Name
+	}
+	glMap, err := ledger.NewEntryBuilder().
+		Company(a.Company).PostingDate(date).ForVoucher(VoucherType, voucherNo).Remarks(remarks).
+		Account(a.DebitAccount).Debit(amount).CostCenter(a.CostCenter).
+		Account(a.CreditAccount).Credit(amount).CostCenter(a.CostCenter).
+		Build()
+	if err != nil {
+		return nil, err
+	}
+	if err := r.Engine.MakeGLEntries(glMap, ledger.DefaultPostingOptions()); err != nil {
+		return nil, fmt.Errorf("%s: %w", voucherNo, err)
+	}
+	return &Posting{VoucherNo: voucherNo, Date: date, Amount: amount}, nil
+}
+
+// Revalue restates a foreign-currency account at the exchange rate of
+// date, posting the change in its company-currency value against the gain
+// or loss account. It returns nil when the value is unchanged or the
+// revaluation is already posted.
+//
+// Maps to: ExchangeRateRevaluation.make_jv_for_revaluation() in
+// exchange_rate_revaluation.py
+func (r *Runner) Revalue(v *Revaluation, date time.Time) (*Posting, error) {
+	voucherNo := RevaluationVoucher(v, date)
+	if done, err := r.posted(voucherNo); done || err != nil {
+		return nil, err
+	}
+	if r.Balances == nil || r.Rates == nil {
+		return nil, ErrNotConfigured
+	}
+	inAccount, inCompany, err := r.Balances.Balance(v.Company, v.Account, date)
+	if err != nil {
+		return nil, err
+	}
+	rate, err := r.Rates.ExchangeRate(v.AccountCurrency, v.CompanyCurrency, date)
+	if err != nil {
+		return nil, err
+	}
+	if rate <= 0 {
+		return nil, fmt.Errorf("%w: %s to %s on %s", ErrNoExchangeRate, v.AccountCurrency, v.CompanyCurrency, date.Format(ledger.DateLayout))
+	}
+	gain := ledger.Flt(ledger.Flt(inAccount*rate, 2)-inCompany, 2)
+	if gain == 0 {
+		return nil, nil
+	}
+
+	remarks := fmt.Sprintf("Exchange rate revaluation of %s at %g", v.Account, rate)
+	glMap, err := ledger.NewEntryBuilder().
+		Company(v.Company).PostingDate(date).ForVoucher(VoucherType, voucherNo).Remarks(remarks).
+		Account(v.Account).Debit(max(gain, 0)).Credit(max(-gain, 0)).CostCenter(v.CostCenter).
+		Account(v.GainLossAccount).Debit(max(-gain, 0)).Credit(max(gain, 0)).CostCenter(v.CostCenter).
+		Build()
+	if err != nil {
+		return nil, err
+	}
+	// Only the company-currency value of the bank account changes
+	glMap[0].AccountCurrency = v.AccountCurrency
+	glMap[0].DebitInAccountCurrency, glMap[0].CreditInAccountCurrency = 0, 0
+	if err := r.Engine.MakeGLEntries(glMap, ledger.DefaultPostingOptions()); err != nil {
+		return nil, fmt.Errorf("%s: %w", voucherNo, err)
+	}
+	return &Posting{VoucherNo: voucherNo, Date: date, Amount: max(gain, -gain)}, nil
+}
+
+// Reverse posts the mirror image of a voucher on date, unless the voucher
+// was not posted, is cancelled or is already reversed. It returns nil when
+// there is nothing to reverse.
+func (r *Runner) Reverse(voucherNo string, date time.Time) (*Posting, error) {
+	reversalNo := ReversalVoucher(voucherNo)
+	if done, err := r.posted(reversalNo); done || err != nil {
+		return nil, err
+	}
+	entries, err := r.Engine.GLStore.GetByVoucher(VoucherType, voucherNo)
+	if err != nil {
+		return nil, err
+	}
+	var glMap []ledger.GLEntry
+	amount := 0.0
+	cancelled := ledger.CancelledEntries(entries)
+	for i, e := range entries {
+		if cancelled[i] {
+			continue
+		}
+		rev := e.Copy()
+		rev.Name = ""
+		rev.PostingDate, rev.TransactionDate = date, date
+		rev.VoucherNo = reversalNo
+		rev.Debit, rev.Credit = e.Credit, e.Debit
+		rev.DebitInAccountCurrency, rev.CreditInAccountCurrency = e.CreditInAccountCurrency, e.DebitInAccountCurrency
+		rev.DebitInTransactionCurrency, rev.CreditInTransactionCurrency = e.CreditInTransactionCurrency, e.DebitInTransactionCurrency
+		rev.DebitInReportingCurrency, rev.CreditInReportingCurrency = e.CreditInReportingCurrency, e.DebitInReportingCurrency
+		rev.Remarks = "Reversal of " + voucherNo
+		glMap = append(glMap, rev)
+		amount += rev.Debit
+	}
+	if len(glMap) == 0 {
+		return nil, nil
+	}
+	if err := r.Engine.MakeGLEntries(glMap, ledger.DefaultPostingOptions()); err != nil {
+		return nil, fmt.Errorf("%s: %w", reversalNo, err)
+	}
+	return &Posting{VoucherNo: reversalNo, Date: date, Amount: ledger.Flt(amount, 2), Reversal: true}, nil
+}
+
+// posted reports whether a live voucher is in the GL store.
+func (r *Runner) posted(voucherNo string) (bool, error) {
+	entries, err := r.Engine.GLStore.GetByVoucher(VoucherType, voucherNo)
+	if err != nil {
+		return false, err
+	}
+	for _, cancelled := range ledger.CancelledEntries(entries) {
+		if !cancelled {
+			return true, nil
+		}
+	}
+	return false, nil
+}
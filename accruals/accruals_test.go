@@ -0,0 +1,271 @@
+package accruals
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/jobs"
+	"github.com/senguttuvang/erpnext-go/ledger"
+	"github.com/senguttuvang/erpnext-go/ledger/memstore"
+)
+
+func date(s string) time.Time {
+	t, err := ledger.ParseDate(s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// rates are USD to INR rates by date.
+type rates map[string]float64
+
+func (r rates) ExchangeRate(from, to string, d time.Time) (float64, error) {
+	rate, ok := r[d.Format(ledger.DateLayout)]
+	if !ok || from != "USD" || to != "INR" {
+		return 0, fmt.Errorf("%w: %s", ErrNoExchangeRate, d.Format(ledger.DateLayout))
+	}
+	return rate, nil
+}
+
+func newRunner(t *testing.T) (*Runner, *memstore.GLStore) {
+	t.Helper()
+	glStore := memstore.NewGLStore()
+	opening := []ledger.GLEntry{
+		{PostingDate: date("2024-05-01"), Account: "Bank USD - ACME", AccountCurrency: "USD", Company: "ACME",
+			Debit: 83000, DebitInAccountCurrency: 1000, VoucherType: "Journal Entry", VoucherNo: "JV-1"},
+		{PostingDate: date("2024-05-01"), Account: "Savings - ACME", Company: "ACME",
+			Debit: 100000, DebitInAccountCurrency: 100000, VoucherType: "Journal Entry", VoucherNo: "JV-1"},
+		{PostingDate: date("2024-05-01"), Account: "Capital - ACME", Company: "ACME",
+			Credit: 183000, CreditInAccountCurrency: 183000, VoucherType: "Journal Entry", VoucherNo: "JV-1"},
+	}
+	if err := glStore.SaveBatch(opening); err != nil {
+		t.Fatal(err)
+	}
+	return &Runner{
+		Engine: &ledger.Engine{GLStore: glStore, PaymentStore: memstore.NewPaymentStore()},
+		Accruals: []Accrual{
+			{Name: "Savings Interest", Company: "ACME", DebitAccount: "Accrued Interest - ACME", CreditAccount: "Interest Income - ACME",
+				Frequency: Daily, From: date("2024-05-01"), Rate: 3.65, RateAccount: "Savings - ACME"},
+			{Name: "Facility Fee", Company: "ACME", DebitAccount: "Bank Charges - ACME", CreditAccount: "Accrued Liabilities - ACME",
+				Frequency: Monthly, From: date("2024-05-01"), Amount: 1500, Reversing: true},
+		},
+		Revaluations: []Revaluation{
+			{Company: "ACME", Account: "Bank USD - ACME", AccountCurrency: "USD", CompanyCurrency: "INR",
+				GainLossAccount: "Exchange Gain/Loss - ACME", Reversing: true},
+		},
+		Balances: GLBalances(glStore.All),
+		Rates:    rates{"2024-05-31": 84, "2024-06-01": 83.5},
+	}, glStore
+}
+
+func balance(entries []ledger.GLEntry, account string) float64 {
+	total := 0.0
+	for _, e := range entries {
+		if e.Account == account && !e.IsCancelled {
+			total += e.Debit - e.Credit
+		}
+	}
+	return ledger.Flt(total, 2)
+}
+
+func TestRunDay(t *testing.T) {
+	r, glStore := newRunner(t)
+
+	posted, err := r.RunDay(date("2024-05-31"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Posting{
+		{VoucherNo: "Savings Interest/2024-05-31", Date: date("2024-05-31"), Amount: 10}, // 100000 × 3.65% / 365
+		{VoucherNo: "Facility Fee/2024-05-31", Date: date("2024-05-31"), Amount: 1500},
+		{VoucherNo: "Revaluation/Bank USD - ACME/2024-05-31", Date: date("2024-05-31"), Amount: 1000}, // 1000 × 84 - 83000
+	}
+	if fmt.Sprint(posted) != fmt.Sprint(want) {
+		t.Errorf("posted %v, want %v", posted, want)
+	}
+	if again, err := r.RunDay(date("2024-05-31")); err != nil || len(again) != 0 {
+		t.Errorf("second run posted %v, %v", again, err)
+	}
+
+	posted, err = r.RunDay(date("2024-06-01"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = []Posting{
+		{VoucherNo: "Facility Fee/2024-05-31/Reversal", Date: date("2024-06-01"), Amount: 1500, Reversal: true},
+		{VoucherNo: "Revaluation/Bank USD - ACME/2024-05-31/Reversal", Date: date("2024-06-01"), Amount: 1000, Reversal: true},
+		{VoucherNo: "Savings Interest/2024-06-01", Date: date("2024-06-01"), Amount: 10},
+		{VoucherNo: "Revaluation/Bank USD - ACME/2024-06-01", Date: date("2024-06-01"), Amount: 500}, // 1000 × 83.5 - 83000
+	}
+	if fmt.Sprint(posted) != fmt.Sprint(want) {
+		t.Errorf("posted %v, want %v", posted, want)
+	}
+
+	all := glStore.All()
+	for account, want := range map[string]float64{
+		"Bank USD - ACME":           83500,
+		"Exchange Gain/Loss - ACME": -500,
+		"Bank Charges - ACME":       0,
+		"Accrued Interest - ACME":   20,
+	} {
+		if got := balance(all, account); got != want {
+			t.Errorf("%s = %v, want %v", account, got, want)
+		}
+	}
+	inUSD, _, _ := r.Balances.Balance("ACME", "Bank USD - ACME", date("2024-06-01"))
+	if inUSD != 1000 {
+		t.Errorf("revaluation changed the USD balance to %v", inUSD)
+	}
+
+	// A missing rate fails the revaluation only
+	posted, err = r.RunDay(date("2024-06-02"))
+	if !errors.Is(err, ErrNoExchangeRate) || len(posted) != 2 {
+		t.Errorf("posted %v, %v", posted, err)
+	}
+}
+
+func TestCancelledAccrual(t *testing.T) {
+	r, glStore := newRunner(t)
+	// Cancelled, with the reversals saved live as older stores hold them
+	no := "Facility Fee/2024-04-30"
+	build := func(debit, credit float64) []ledger.GLEntry {
+		glMap, err := ledger.NewEntryBuilder().
+			Company("ACME").PostingDate(date("2024-04-30")).ForVoucher(VoucherType, no).
+			Account("Bank Charges - ACME").Debit(debit).Credit(credit).
+			Account("Accrued Liabilities - ACME").Debit(credit).Credit(debit).
+			Build()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return glMap
+	}
+	accrual := build(1500, 0)
+	reversals := build(0, 1500)
+	accrual[0].IsCancelled, accrual[1].IsCancelled = true, true
+	if err := glStore.SaveBatch(append(accrual, reversals...)); err != nil {
+		t.Fatal(err)
+	}
+
+	if done, err := r.posted(no); done || err != nil {
+		t.Errorf("posted() = %v, %v for a cancelled accrual", done, err)
+	}
+	if p, err := r.Reverse(no, date("2024-05-01")); p != nil || err != nil {
+		t.Errorf("Reverse() = %+v, %v; a cancelled accrual has nothing to reverse", p, err)
+	}
+	if _, got, _ := r.Balances.Balance("ACME", "Bank Charges - ACME", date("2024-05-01")); got != 0 {
+		t.Errorf("bank charges = %v, want 0", got)
+	}
+}
+
+func TestAccrual(t *testing.T) {
+	fee := Accrual{Name: "Fee", Company: "ACME", DebitAccount: "A", CreditAccount: "B", Frequency: Monthly,
+		From: date("2024-02-10"), Until: date("2024-04-30"), Amount: 100}
+	if err := fee.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	for d, due := range map[string]bool{"2024-01-31": false, "2024-02-28": false, "2024-02-29": true, "2024-03-15": false, "2024-04-30": true, "2024-05-31": false} {
+		if fee.Due(date(d)) != due {
+			t.Errorf("Due(%s) = %v", d, !due)
+		}
+	}
+	if days := fee.periodDays(date("2024-02-29")); days != 20 {
+		t.Errorf("first period has %d days", days)
+	}
+
+	bad := fee
+	bad.Frequency = "Weekly"
+	if err := bad.Validate(); !errors.Is(err, ErrInvalidAccrual) {
+		t.Errorf("Validate() = %v", err)
+	}
+	bad = fee
+	bad.Rate, bad.Amount = 5, 0
+	if err := bad.Validate(); !errors.Is(err, ErrInvalidAccrual) {
+		t.Errorf("rate without account: %v", err)
+	}
+}
+
+func TestTask(t *testing.T) {
+	r, glStore := newRunner(t)
+	jr := &jobs.Runner{Queue: jobs.NewMemoryQueue(10), Store: &jobs.MemoryStore{}, Tasks: map[string]jobs.Task{Task: r}}
+	ctx := context.Background()
+	for _, d := range []string{"2024-05-31", "2024-06-01"} {
+		job, err := Submit(ctx, jr, date(d))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := jr.Run(ctx, job.ID); err != nil {
+			t.Fatal(err)
+		}
+		if job, _ = jr.Store.Get(job.ID); job.Status != jobs.Completed {
+			t.Errorf("%s: job %s: %+v", d, job.Status, job.Chunks)
+		}
+	}
+	if got := balance(glStore.All(), "Bank USD - ACME"); got != 83500 {
+		t.Errorf("Bank USD - ACME = %v", got)
+	}
+}
+
+func TestPrepaid(t *testing.T) {
+	glStore := memstore.NewGLStore()
+	r := &Runner{
+		Engine: &ledger.Engine{GLStore: glStore, PaymentStore: memstore.NewPaymentStore()},
+		Prepaids: []Prepaid{
+			{Name: "Insurance 2024", Company: "ACME", PrepaidAccount: "Prepaid Expenses - ACME", ExpenseAccount: "Insurance - ACME",
+				CostCenter: "Main - ACME", Amount: 1000, From: date("2024-01-15"), Months: 3},
+		},
+	}
+	p := &r.Prepaids[0]
+	if got := p.InstallmentDate(2); !got.Equal(date("2024-02-29")) {
+		t.Errorf("second installment due %v", got)
+	}
+	if got := p.Installment(3); got != 333.34 {
+		t.Errorf("last installment %v", got)
+	}
+
+	// The run on January 31 was missed; the next month end catches up
+	posted, err := r.RunDay(date("2024-02-29"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Posting{
+		{VoucherNo: "Insurance 2024/1", Date: date("2024-02-29"), Amount: 333.33},
+		{VoucherNo: "Insurance 2024/2", Date: date("2024-02-29"), Amount: 333.33},
+	}
+	if fmt.Sprint(posted) != fmt.Sprint(want) {
+		t.Errorf("posted %v, want %v", posted, want)
+	}
+	if again, err := r.RunDay(date("2024-03-01")); err != nil || len(again) != 0 {
+		t.Errorf("second run posted %v, %v", again, err)
+	}
+
+	// Cancelled in March: the rest is expensed instead of the last installment
+	p.TerminatedOn = date("2024-03-20")
+	posted, err = r.RunDay(date("2024-03-31"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = []Posting{{VoucherNo: "Insurance 2024/Termination", Date: date("2024-03-31"), Amount: 333.34}}
+	if fmt.Sprint(posted) != fmt.Sprint(want) {
+		t.Errorf("posted %v, want %v", posted, want)
+	}
+	all := glStore.All()
+	if got := balance(all, "Insurance - ACME"); got != 1000 {
+		t.Errorf("expensed %v", got)
+	}
+	if got := balance(all, "Prepaid Expenses - ACME"); got != -1000 {
+		t.Errorf("prepaid account moved by %v", got)
+	}
+	if again, err := r.RunDay(date("2024-04-30")); err != nil || len(again) != 0 {
+		t.Errorf("after termination posted %v, %v", again, err)
+	}
+
+	bad := *p
+	bad.Months = 0
+	if err := bad.Validate(); !errors.Is(err, ErrInvalidAccrual) {
+		t.Errorf("Validate() = %v", err)
+	}
+}
@@ -0,0 +1,186 @@
+package accruals
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/ledger"
+)
+
+// DeferredExpense is the voucher subtype of prepaid amortization, the
+// expense side of the deferred revenue the recognition package books.
+const DeferredExpense = "Deferred Expense"
+
+// Prepaid is an expense paid in advance, such as an annual insurance
+// premium or software subscription, booked to a prepaid asset account and
+// amortized to expense in equal monthly installments. Installment i is
+// due on the last day of the i-th month counted from the month of From.
+// Each installment is rounded to the cent and the last one takes the
+// rounding difference, so the installments add up to Amount.
+//
+// Maps to: deferred expense on Purchase Invoice Items
+// (enable_deferred_expense, service_start_date, service_end_date) and
+// book_deferred_income_or_expense() in
+// erpnext/accounts/deferred_revenue.py
+type Prepaid struct {
+	Name    string
+	Company string
+
+	PrepaidAccount string // Asset the payment was booked to, e.g. Prepaid Expenses
+	ExpenseAccount string
+	CostCenter     string
+
+	Amount float64
+	From   time.Time // Start of the service period
+	Months int
+
+	// TerminatedOn, when set, ends the arrangement early: installments due
+	// after it are not posted and the balance left on the prepaid account
+	// is expensed on that day.
+	TerminatedOn time.Time
+
+	Remarks string
+}
+
+// Validate checks the prepaid item's configuration.
+func (p *Prepaid) Validate() error {
+	switch {
+	case p.Name == "" || p.Company == "":
+		return fmt.Errorf("%w: name and company are required", ErrInvalidAccrual)
+	case p.PrepaidAccount == "" || p.ExpenseAccount == "":
+		return fmt.Errorf("%w: %s: prepaid and expense accounts are required", ErrInvalidAccrual, p.Name)
+	case p.Amount <= 0:
+		return fmt.Errorf("%w: %s: amount must be greater than zero", ErrInvalidAccrual, p.Name)
+	case p.Months <= 0:
+		return fmt.Errorf("%w: %s: months must be greater than zero", ErrInvalidAccrual, p.Name)
+	case p.From.IsZero():
+		return fmt.Errorf("%w: %s: start date is required", ErrInvalidAccrual, p.Name)
+	case !p.TerminatedOn.IsZero() && ledger.CivilDate(p.TerminatedOn).Before(ledger.CivilDate(p.From)):
+		return fmt.Errorf("%w: %s: terminated before it starts", ErrInvalidAccrual, p.Name)
+	}
+	return nil
+}
+
+// InstallmentDate returns the month end installment i, counted from 1, is
+// due on.
+func (p *Prepaid) InstallmentDate(i int) time.Time {
+	from := ledger.CivilDate(p.From)
+	first := from.AddDate(0, 0, 1-from.Day())
+	return first.AddDate(0, i, -1)
+}
+
+// Installment returns the amount of installment i.
+func (p *Prepaid) Installment(i int) float64 {
+	monthly := ledger.Flt(p.Amount/float64(p.Months), 2)
+	if i == p.Months {
+		return ledger.Flt(p.Amount-monthly*float64(p.Months-1), 2)
+	}
+	return monthly
+}
+
+// installments returns how many installments are due by date, stopping at
+// the termination date.
+func (p *Prepaid) installments(date time.Time) int {
+	date = ledger.CivilDate(date)
+	if !p.TerminatedOn.IsZero() && ledger.CivilDate(p.TerminatedOn).Before(date) {
+		date = ledger.CivilDate(p.TerminatedOn)
+	}
+	n := 0
+	for n < p.Months && !p.InstallmentDate(n+1).After(date) {
+		n++
+	}
+	return n
+}
+
+// terminating reports whether the termination posting is due by date.
+func (p *Prepaid) terminating(date time.Time) bool {
+	return !p.TerminatedOn.IsZero() && !ledger.CivilDate(p.TerminatedOn).After(ledger.CivilDate(date)) &&
+		p.installments(p.TerminatedOn) < p.Months
+}
+
+// PrepaidVoucher returns the voucher number of installment i of a prepaid
+// item.
+func PrepaidVoucher(p *Prepaid, i int) string {
+	return p.Name + "/" + strconv.Itoa(i)
+}
+
+// TerminationVoucher returns the voucher number that expenses what is left
+// of a prepaid item terminated early.
+func TerminationVoucher(p *Prepaid) string {
+	return p.Name + "/Termination"
+}
+
+// steps returns the step keys of the installments due by date and of
+// the termination. Installments already posted are skipped when their
+// step runs, so a run after the scheduler missed some month ends catches
+// up on them.
+func (p *Prepaid) steps(date time.Time) []string {
+	var keys []string
+	for i := 1; i <= p.installments(date); i++ {
+		keys = append(keys, stepAmortize+PrepaidVoucher(p, i))
+	}
+	if p.terminating(date) {
+		keys = append(keys, stepTerminate+p.Name)
+	}
+	return keys
+}
+
+// Amortize posts installment i of a prepaid item if it is due by date and
+// not yet posted. An installment caught up after its month end is posted
+// on date, as the month it belongs to may be closed; its remarks name
+// that month. It returns nil when there is nothing to post.
+func (r *Runner) Amortize(p *Prepaid, i int, date time.Time) (*Posting, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	if i < 1 || i > p.installments(date) {
+		return nil, nil
+	}
+	voucherNo := PrepaidVoucher(p, i)
+	remarks := fmt.Sprintf("Amortization %d of %d for %s", i, p.Months, p.InstallmentDate(i).Format("January 2006"))
+	return r.expense(p, voucherNo, p.Installment(i), date, remarks)
+}
+
+// Terminate expenses what is left of a prepaid item terminated early: the
+// installments due after the termination date. It posts once, on date, when
+// date is on or after the termination date.
+func (r *Runner) Terminate(p *Prepaid, date time.Time) (*Posting, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	if !p.terminating(date) {
+		return nil, nil
+	}
+	var amount float64
+	for i := p.installments(p.TerminatedOn) + 1; i <= p.Months; i++ {
+		amount += p.Installment(i)
+	}
+	remarks := "Early termination on " + p.TerminatedOn.Format(ledger.DateLayout)
+	return r.expense(p, TerminationVoucher(p), ledger.Flt(amount, 2), date, remarks)
+}
+
+// expense moves amount from a prepaid item's asset account to its expense
+// account under voucherNo, unless that voucher is already posted.
+func (r *Runner) expense(p *Prepaid, voucherNo string, amount float64, date time.Time, remarks string) (*Posting, error) {
+	if done, err := r.posted(voucherNo); done || err != nil || amount <= 0 {
+		return nil, err
+	}
+	if p.Remarks != "" {
+		remarks = p.Remarks + ": " + remarks
+	}
+	date = ledger.CivilDate(date)
+	glMap, err := ledger.NewEntryBuilder().
+		Company(p.Company).PostingDate(date).ForVoucher(VoucherType, voucherNo).Remarks(remarks).
+		Account(p.ExpenseAccount).Debit(amount).CostCenter(p.CostCenter).
+		Account(p.PrepaidAccount).Credit(amount).
+		Build()
+	if err != nil {
+		return nil, err
+	}
+	glMap[0].VoucherSubtype, glMap[1].VoucherSubtype = DeferredExpense, DeferredExpense
+	if err := r.Engine.MakeGLEntries(glMap, ledger.DefaultPostingOptions()); err != nil {
+		return nil, fmt.Errorf("%s: %w", voucherNo, err)
+	}
+	return &Posting{VoucherNo: voucherNo, Date: date, Amount: amount}, nil
+}
@@ -0,0 +1,60 @@
+package accruals
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/jobs"
+	"github.com/senguttuvang/erpnext-go/ledger"
+)
+
+// Task is the job type of the end-of-day run. The application registers
+// a Runner under it with the jobs.Runner and submits a job each night,
+// after the day's business, with Submit.
+//
+// Maps to: the daily scheduler events in erpnext/hooks.py
+const Task = "end-of-day-accruals"
+
+// Params are the parameters of a Task job.
+type Params struct {
+	Date string `json:"date"` // Business day to close, as YYYY-MM-DD
+}
+
+// Submit queues the end-of-day run for date.
+func Submit(ctx context.Context, runner *jobs.Runner, date time.Time) (*jobs.Job, error) {
+	return runner.Submit(ctx, Task, Params{Date: date.Format(ledger.DateLayout)})
+}
+
+// Plan implements jobs.Task with one chunk per posting RunDay would make,
+// in the same order, so a failed posting can be retried on its own.
+func (r *Runner) Plan(_ context.Context, params json.RawMessage) ([]string, error) {
+	date, err := parseParams(params)
+	if err != nil {
+		return nil, err
+	}
+	return r.steps(date), nil
+}
+
+// Run implements jobs.Task.
+func (r *Runner) Run(_ context.Context, params json.RawMessage, chunk string) error {
+	date, err := parseParams(params)
+	if err != nil {
+		return err
+	}
+	_, err = r.step(chunk, date)
+	return err
+}
+
+func parseParams(raw json.RawMessage) (time.Time, error) {
+	var p Params
+	if err := json.Unmarshal(raw, &p); err != nil {
+		return time.Time{}, err
+	}
+	date, err := ledger.ParseDate(p.Date)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: date %q", ErrInvalidAccrual, p.Date)
+	}
+	return date, nil
+}
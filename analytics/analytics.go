@@ -0,0 +1,271 @@
+// Package analytics builds the Sales Analytics and Purchase Analytics
+// pivots: quantities or amounts of posted invoices by item, item group,
+// party or territory, in monthly to yearly buckets, for trend charts.
+//
+// Pivots implement reportio.Report, so they download like every other
+// report; Series returns the values of one row for a chart.
+//
+// Migrated from: erpnext/selling/report/sales_analytics/sales_analytics.py
+// and erpnext/buying/report/purchase_analytics/purchase_analytics.py
+package analytics
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/daterange"
+	"github.com/senguttuvang/erpnext-go/ledger"
+	"github.com/senguttuvang/erpnext-go/party"
+	"github.com/senguttuvang/erpnext-go/register"
+	"github.com/senguttuvang/erpnext-go/reportio"
+)
+
+// Analytics errors
+var (
+	ErrUnknownTreeType  = errors.New("unknown analytics tree type")
+	ErrUnknownValueType = errors.New("unknown analytics value type")
+)
+
+// TreeType is what the rows of a pivot are.
+type TreeType string
+
+const (
+	ByItem      TreeType = "Item"
+	ByItemGroup TreeType = "Item Group"
+	ByParty     TreeType = "Party" // Customers in the sales pivot, suppliers in the purchase pivot
+	ByTerritory TreeType = "Territory"
+)
+
+// ValueType is what the cells of a pivot add up.
+type ValueType string
+
+const (
+	Quantity ValueType = "Quantity" // In stock units
+	Value    ValueType = "Value"    // Net amount in company currency
+)
+
+// ItemGroupLookup resolves the Item Group of items.
+type ItemGroupLookup interface {
+	GetItemGroup(itemCode string) (string, error)
+}
+
+// Options selects and shapes a pivot.
+type Options struct {
+	Kind        register.Kind // Sales or purchase invoices
+	Company     string
+	Period      daterange.Range // Must be bounded
+	Periodicity daterange.Periodicity
+	YearStart   time.Month // First month of the fiscal year; January when zero
+	TreeType    TreeType
+	ValueType   ValueType
+
+	Items   ItemGroupLookup   // Needed for ByItemGroup
+	Parties party.PartyLookup // Needed for ByTerritory
+}
+
+// Row is one row of a pivot.
+type Row struct {
+	Key    string
+	Values []float64 // One per bucket
+	Total  float64
+}
+
+// Pivot is a computed analytics report.
+type Pivot struct {
+	Kind      register.Kind
+	TreeType  TreeType
+	ValueType ValueType
+	Buckets   []daterange.Range
+	Lines     []Row // By total, largest first, then by key
+}
+
+// Generate pivots the items of the submitted invoices of a company posted
+// in the period.
+//
+// Maps to: Analytics.run() in sales_analytics.py
+func Generate(invoices []register.Invoice, opts Options) (*Pivot, error) {
+	switch opts.ValueType {
+	case Quantity, Value:
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownValueType, opts.ValueType)
+	}
+	yearStart := opts.YearStart
+	if yearStart == 0 {
+		yearStart = time.January
+	}
+	buckets, err := daterange.Split(opts.Period, opts.Periodicity, yearStart)
+	if err != nil {
+		return nil, err
+	}
+	key, err := opts.keyFunc()
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Pivot{Kind: opts.Kind, TreeType: opts.TreeType, ValueType: opts.ValueType, Buckets: buckets}
+	index := map[string]int{}
+	for i := range invoices {
+		inv := &invoices[i]
+		if inv.Cancelled || inv.Company != opts.Company || !opts.Period.Contains(inv.PostingDate) {
+			continue
+		}
+		b := bucketOf(buckets, inv.PostingDate)
+		for _, item := range inv.Items {
+			k, err := key(inv, item.ItemCode)
+			if err != nil {
+				return nil, err
+			}
+			j, ok := index[k]
+			if !ok {
+				j = len(p.Lines)
+				index[k] = j
+				p.Lines = append(p.Lines, Row{Key: k, Values: make([]float64, len(buckets))})
+			}
+			v, precision := item.BaseNetAmount, 2
+			if opts.ValueType == Quantity {
+				v, precision = stockQty(item.StockQty, item.Qty, item.ConversionFactor), 3
+			}
+			row := &p.Lines[j]
+			row.Values[b] = ledger.Flt(row.Values[b]+v, precision)
+			row.Total = ledger.Flt(row.Total+v, precision)
+		}
+	}
+	slices.SortStableFunc(p.Lines, func(a, b Row) int {
+		if c := cmp.Compare(b.Total, a.Total); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Key, b.Key)
+	})
+	return p, nil
+}
+
+// keyFunc returns the function giving the row of an invoice item.
+func (o *Options) keyFunc() (func(inv *register.Invoice, itemCode string) (string, error), error) {
+	switch o.TreeType {
+	case ByItem:
+		return func(_ *register.Invoice, itemCode string) (string, error) { return itemCode, nil }, nil
+	case ByParty:
+		return func(inv *register.Invoice, _ string) (string, error) { return inv.Party, nil }, nil
+	case ByItemGroup:
+		groups := map[string]string{}
+		return func(_ *register.Invoice, itemCode string) (string, error) {
+			if g, ok := groups[itemCode]; ok {
+				return g, nil
+			}
+			g, err := o.Items.GetItemGroup(itemCode)
+			if err != nil {
+				return "", fmt.Errorf("item group of %s: %w", itemCode, err)
+			}
+			groups[itemCode] = g
+			return g, nil
+		}, nil
+	case ByTerritory:
+		if o.Kind == register.Purchase {
+			return nil, fmt.Errorf("%w: suppliers have no territory", ErrUnknownTreeType)
+		}
+		territories := map[string]string{}
+		return func(inv *register.Invoice, _ string) (string, error) {
+			if t, ok := territories[inv.Party]; ok {
+				return t, nil
+			}
+			c, err := o.Parties.GetParty(party.Customer, inv.Party)
+			if err != nil {
+				return "", fmt.Errorf("customer %s: %w", inv.Party, err)
+			}
+			territories[inv.Party] = c.Territory
+			return c.Territory, nil
+		}, nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnknownTreeType, o.TreeType)
+}
+
+// bucketOf returns the index of the bucket containing t.
+func bucketOf(buckets []daterange.Range, t time.Time) int {
+	for i, b := range buckets {
+		if b.Contains(t) {
+			return i
+		}
+	}
+	return len(buckets) - 1
+}
+
+// stockQty returns an item's quantity in stock units.
+func stockQty(stock, qty, factor float64) float64 {
+	switch {
+	case stock != 0:
+		return stock
+	case factor != 0:
+		return qty * factor
+	}
+	return qty
+}
+
+// Series returns the bucket values of a row, or nil if there is none.
+func (p *Pivot) Series(key string) []float64 {
+	for _, r := range p.Lines {
+		if r.Key == key {
+			return r.Values
+		}
+	}
+	return nil
+}
+
+// Label returns the column label of a bucket: "Jan 2024" for a month,
+// "Apr 2024 - Jun 2024" for longer buckets.
+func Label(b daterange.Range) string {
+	first, last := b.Start.Format("Jan 2006"), b.Last().Format("Jan 2006")
+	if first == last {
+		return first
+	}
+	return first + " - " + last
+}
+
+// Title implements reportio.Titled.
+func (p *Pivot) Title() string {
+	if p.Kind == register.Purchase {
+		return "Purchase Analytics"
+	}
+	return "Sales Analytics"
+}
+
+// Columns implements reportio.Report: the row key, a column per bucket
+// and the total.
+func (p *Pivot) Columns() []reportio.Column {
+	label, options := string(p.TreeType), string(p.TreeType)
+	if p.TreeType == ByParty {
+		label, options = "Customer", "Customer"
+		if p.Kind == register.Purchase {
+			label, options = "Supplier", "Supplier"
+		}
+	}
+	fieldtype, precision := reportio.Currency, 0
+	if p.ValueType == Quantity {
+		fieldtype, precision = reportio.Float, 3
+	}
+	cols := []reportio.Column{{Fieldname: "entity", Label: label, Fieldtype: reportio.Link, Options: options}}
+	for _, b := range p.Buckets {
+		cols = append(cols, reportio.Column{
+			Fieldname: strings.ToLower(b.Start.Format("Jan_2006")), Label: Label(b), Fieldtype: fieldtype, Precision: precision,
+		})
+	}
+	return append(cols, reportio.Column{Fieldname: "total", Label: "Total", Fieldtype: fieldtype, Precision: precision})
+}
+
+// Rows implements reportio.Report.
+func (p *Pivot) Rows(fn func([]any) error) error {
+	for _, r := range p.Lines {
+		row := make([]any, 0, len(r.Values)+2)
+		row = append(row, r.Key)
+		for _, v := range r.Values {
+			row = append(row, v)
+		}
+		if err := fn(append(row, r.Total)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
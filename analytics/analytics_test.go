@@ -0,0 +1,96 @@
+package analytics
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/daterange"
+	"github.com/senguttuvang/erpnext-go/party"
+	"github.com/senguttuvang/erpnext-go/register"
+	"github.com/senguttuvang/erpnext-go/taxcalc"
+)
+
+type groups map[string]string
+
+func (g groups) GetItemGroup(itemCode string) (string, error) { return g[itemCode], nil }
+
+type customers map[string]string // customer -> territory
+
+func (c customers) GetParty(partyType party.PartyType, name string) (*party.Party, error) {
+	return &party.Party{Name: name, PartyType: partyType, Territory: c[name]}, nil
+}
+
+func invoice(name, customer string, month time.Month, items ...*taxcalc.LineItem) register.Invoice {
+	for _, item := range items {
+		item.BaseNetAmount = item.Qty * item.Rate
+	}
+	return register.Invoice{
+		VoucherType: "Sales Invoice", Name: name, Company: "ACME", Party: customer,
+		PostingDate: time.Date(2024, month, 15, 0, 0, 0, 0, time.UTC),
+		Document:    taxcalc.Document{Items: items},
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	invoices := []register.Invoice{
+		invoice("SINV-1", "Globex", time.April, &taxcalc.LineItem{ItemCode: "PEN", Qty: 10, Rate: 2}, &taxcalc.LineItem{ItemCode: "INK", Qty: 1, Rate: 8}),
+		invoice("SINV-2", "Initech", time.May, &taxcalc.LineItem{ItemCode: "PEN", Qty: 5, Rate: 2}),
+		invoice("SINV-3", "Globex", time.July, &taxcalc.LineItem{ItemCode: "PAD", Qty: 2, Rate: 30, ConversionFactor: 10}),
+		invoice("SINV-4", "Globex", time.March, &taxcalc.LineItem{ItemCode: "PEN", Qty: 99, Rate: 2}), // Before the period
+	}
+	cancelled := invoice("SINV-5", "Globex", time.April, &taxcalc.LineItem{ItemCode: "PEN", Qty: 99, Rate: 2})
+	cancelled.Cancelled = true
+	invoices = append(invoices, cancelled)
+
+	opts := Options{
+		Kind: register.Sales, Company: "ACME", Periodicity: daterange.Quarterly, YearStart: time.April,
+		Period:   daterange.Inclusive(time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, 3, 31, 0, 0, 0, 0, time.UTC)),
+		TreeType: ByItem, ValueType: Quantity,
+	}
+	p, err := Generate(invoices, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.Buckets) != 4 || Label(p.Buckets[0]) != "Apr 2024 - Jun 2024" {
+		t.Fatalf("buckets = %v", p.Buckets)
+	}
+	if got := p.Series("PAD"); got[1] != 20 || p.Lines[0].Key != "PAD" {
+		t.Errorf("PAD = %v, rows %+v", got, p.Lines)
+	}
+	if got := p.Series("PEN"); got[0] != 15 || p.Lines[1].Total != 15 {
+		t.Errorf("PEN = %v", got)
+	}
+
+	opts.TreeType, opts.ValueType, opts.Periodicity = ByItemGroup, Value, daterange.Monthly
+	opts.Items = groups{"PEN": "Stationery", "INK": "Stationery", "PAD": "Paper"}
+	p, err = Generate(invoices, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.Buckets) != 12 || p.Lines[0].Key != "Paper" || p.Lines[1].Values[0] != 28 || p.Lines[1].Values[1] != 10 {
+		t.Errorf("item groups = %+v", p.Lines)
+	}
+	cols := p.Columns()
+	if len(cols) != 14 || cols[1].Label != "Apr 2024" || cols[1].Fieldname != "apr_2024" || cols[13].Fieldname != "total" {
+		t.Errorf("columns = %+v", cols)
+	}
+
+	opts.TreeType, opts.Parties = ByTerritory, customers{"Globex": "North", "Initech": "South"}
+	p, err = Generate(invoices, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.Lines) != 2 || p.Lines[0].Key != "North" || p.Lines[0].Total != 88 || p.Lines[1].Total != 10 {
+		t.Errorf("territories = %+v", p.Lines)
+	}
+
+	opts.Kind = register.Purchase
+	if _, err := Generate(invoices, opts); !errors.Is(err, ErrUnknownTreeType) {
+		t.Errorf("purchase by territory error = %v", err)
+	}
+	opts.TreeType, opts.ValueType = ByParty, "Weight"
+	if _, err := Generate(invoices, opts); !errors.Is(err, ErrUnknownValueType) {
+		t.Errorf("unknown value type error = %v", err)
+	}
+}
@@ -0,0 +1,112 @@
+// Package apikey authenticates integrations by API key and confines each
+// key to the part of the books it was issued for.
+//
+// A key's Scope names the companies, voucher types and account subtrees
+// it may post to. The HTTP layer checks every GL map against the scope
+// before the ledger Engine runs, so a POS bridge holding a key for sales
+// invoices into its own company cannot post a journal entry to the bank.
+//
+// Maps to: the api_key and api_secret of a Frappe User, with User
+// Permissions on Company and Account narrowed to the key
+package apikey
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/senguttuvang/erpnext-go/ledger"
+)
+
+// Key errors
+var (
+	ErrUnknownKey  = errors.New("unknown or disabled API key")
+	ErrWrongSecret = errors.New("wrong API secret")
+)
+
+// Scope is what a key may post. An empty list leaves that side open.
+type Scope struct {
+	Companies    []string
+	VoucherTypes []string
+	Accounts     []string // Roots of the account subtrees, groups or ledgers
+}
+
+// Key is an API key of an integration.
+type Key struct {
+	ID         string // The api_key, sent with every request
+	Name       string // Who holds it, recorded as the user of its postings
+	SecretHash string // Hex SHA-256 of the api_secret; see Hash
+	Scope      Scope
+	Disabled   bool
+}
+
+// Hash returns the hex SHA-256 of a secret, as Key.SecretHash holds it.
+// Only the hash is stored, so a leaked key store does not leak secrets.
+func Hash(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// Verify checks a secret against the key's hash in constant time.
+func (k *Key) Verify(secret string) error {
+	if subtle.ConstantTimeCompare([]byte(Hash(secret)), []byte(k.SecretHash)) != 1 {
+		return fmt.Errorf("%w: %s", ErrWrongSecret, k.ID)
+	}
+	return nil
+}
+
+// Check returns an error wrapping ledger.ErrNotPermitted unless every
+// entry of a GL map lies within the scope. Account subtrees are resolved
+// in tree, which may be nil for a scope without accounts.
+func (s Scope) Check(glMap []ledger.GLEntry, tree *ledger.AccountTree) error {
+	for _, e := range glMap {
+		if len(s.Companies) > 0 && !slices.Contains(s.Companies, e.Company) {
+			return fmt.Errorf("%w: company %s is outside the API key's scope", ledger.ErrNotPermitted, e.Company)
+		}
+		if len(s.VoucherTypes) > 0 && !slices.Contains(s.VoucherTypes, e.VoucherType) {
+			return fmt.Errorf("%w: voucher type %s is outside the API key's scope", ledger.ErrNotPermitted, e.VoucherType)
+		}
+		if len(s.Accounts) > 0 && (tree == nil || !slices.ContainsFunc(s.Accounts, func(root string) bool {
+			return tree.IsDescendant(e.Account, root)
+		})) {
+			return fmt.Errorf("%w: account %s is outside the API key's scope", ledger.ErrNotPermitted, e.Account)
+		}
+	}
+	return nil
+}
+
+// Keys looks up API keys by ID.
+type Keys interface {
+	// Key returns the key with the ID, or an error wrapping ErrUnknownKey.
+	Key(id string) (*Key, error)
+}
+
+// MemoryStore is a Keys held in memory, by ID.
+type MemoryStore map[string]*Key
+
+// Key implements Keys.
+func (s MemoryStore) Key(id string) (*Key, error) {
+	k, ok := s[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownKey, id)
+	}
+	return k, nil
+}
+
+// Authenticate returns the key of an ID and secret.
+func Authenticate(keys Keys, id, secret string) (*Key, error) {
+	k, err := keys.Key(id)
+	if err != nil {
+		return nil, err
+	}
+	if k.Disabled {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownKey, id)
+	}
+	if err := k.Verify(secret); err != nil {
+		return nil, err
+	}
+	return k, nil
+}
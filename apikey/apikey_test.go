@@ -0,0 +1,162 @@
+package apikey
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/ledger"
+	"github.com/senguttuvang/erpnext-go/ledger/memstore"
+)
+
+func testTree(t *testing.T) *ledger.AccountTree {
+	t.Helper()
+	tree, err := ledger.NewAccountTree(
+		ledger.Account{Name: "Assets - A", IsGroup: true},
+		ledger.Account{Name: "Debtors - A", ParentAccount: "Assets - A"},
+		ledger.Account{Name: "Bank - A", ParentAccount: "Assets - A"},
+		ledger.Account{Name: "Income - A", IsGroup: true},
+		ledger.Account{Name: "Sales - A", ParentAccount: "Income - A"},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tree
+}
+
+func voucher(voucherType, debitAccount, creditAccount string) []ledger.GLEntry {
+	glMap := []ledger.GLEntry{
+		{Account: debitAccount, Debit: 100, DebitInAccountCurrency: 100},
+		{Account: creditAccount, Credit: 100, CreditInAccountCurrency: 100},
+	}
+	for i := range glMap {
+		glMap[i].PostingDate = time.Date(2024, 6, 15, 0, 0, 0, 0, time.UTC)
+		glMap[i].Company, glMap[i].VoucherType, glMap[i].VoucherNo = "A", voucherType, "V-1"
+	}
+	return glMap
+}
+
+func TestScopeCheck(t *testing.T) {
+	tree := testTree(t)
+	scope := Scope{Companies: []string{"A"}, VoucherTypes: []string{"Sales Invoice"}, Accounts: []string{"Debtors - A", "Income - A"}}
+	tests := []struct {
+		name  string
+		glMap []ledger.GLEntry
+		ok    bool
+	}{
+		{"in scope", voucher("Sales Invoice", "Debtors - A", "Sales - A"), true},
+		{"voucher type", voucher("Journal Entry", "Debtors - A", "Sales - A"), false},
+		{"account outside the subtrees", voucher("Sales Invoice", "Bank - A", "Sales - A"), false},
+	}
+	for _, tt := range tests {
+		if err := scope.Check(tt.glMap, tree); (err == nil) != tt.ok || (err != nil && !errors.Is(err, ledger.ErrNotPermitted)) {
+			t.Errorf("%s: err = %v", tt.name, err)
+		}
+	}
+	other := voucher("Sales Invoice", "Debtors - A", "Sales - A")
+	other[0].Company = "B"
+	if err := scope.Check(other, tree); !errors.Is(err, ledger.ErrNotPermitted) {
+		t.Errorf("other company: err = %v", err)
+	}
+	if err := (Scope{}).Check(voucher("Journal Entry", "Bank - A", "Sales - A"), nil); err != nil {
+		t.Errorf("empty scope: err = %v", err)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	store := memstore.NewGLStore()
+	keys := MemoryStore{
+		"pos": {ID: "pos", Name: "POS Bridge", SecretHash: Hash("s3cret"), Scope: Scope{
+			Companies: []string{"A"}, VoucherTypes: []string{"Sales Invoice"}, Accounts: []string{"Debtors - A", "Income - A"},
+		}},
+		"old": {ID: "old", Name: "Retired", SecretHash: Hash("s3cret"), Disabled: true},
+	}
+	server := httptest.NewServer(Handler(keys, &ledger.Engine{GLStore: store}, testTree(t)))
+	defer server.Close()
+
+	send := func(credentials string, in postRequest) int {
+		t.Helper()
+		body, err := json.Marshal(in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req, err := http.NewRequest(http.MethodPost, server.URL+"/gl-entries", bytes.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if credentials != "" {
+			req.Header.Set("Authorization", "token "+credentials)
+		}
+		resp, err := server.Client().Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	post := func(credentials string, glMap []ledger.GLEntry) int {
+		t.Helper()
+		return send(credentials, postRequest{Entries: glMap})
+	}
+
+	sale := voucher("Sales Invoice", "Debtors - A", "Sales - A")
+	for _, tt := range []struct {
+		name, credentials string
+		glMap             []ledger.GLEntry
+		want              int
+	}{
+		{"no key", "", sale, http.StatusUnauthorized},
+		{"wrong secret", "pos:guess", sale, http.StatusUnauthorized},
+		{"disabled key", "old:s3cret", sale, http.StatusUnauthorized},
+		{"journal entry", "pos:s3cret", voucher("Journal Entry", "Debtors - A", "Sales - A"), http.StatusForbidden},
+		{"bank account", "pos:s3cret", voucher("Sales Invoice", "Bank - A", "Sales - A"), http.StatusForbidden},
+	} {
+		if got := post(tt.credentials, tt.glMap); got != tt.want {
+			t.Errorf("%s: status %d, want %d", tt.name, got, tt.want)
+		}
+	}
+	if n := len(store.All()); n != 0 {
+		t.Fatalf("%d entries posted by rejected requests", n)
+	}
+
+	if got := post("pos:s3cret", sale); got != http.StatusOK {
+		t.Fatalf("sale: status %d", got)
+	}
+	if n := len(store.All()); n != 2 {
+		t.Errorf("%d entries after the sale, want 2", n)
+	}
+
+	// A cancellation is checked against the entries it would reverse, not
+	// the ones sent
+	cash := voucher("Sales Invoice", "Bank - A", "Sales - A")
+	disguised := voucher("Sales Invoice", "Debtors - A", "Sales - A")
+	for i := range cash {
+		cash[i].VoucherNo, disguised[i].VoucherNo = "V-2", "V-2"
+	}
+	if err := store.SaveBatch(cash); err != nil {
+		t.Fatal(err)
+	}
+	if got := send("pos:s3cret", postRequest{Entries: disguised, Cancel: true}); got != http.StatusForbidden {
+		t.Errorf("cancelling a sale into the bank: status %d", got)
+	}
+	unknown := voucher("Sales Invoice", "Debtors - A", "Sales - A")
+	for i := range unknown {
+		unknown[i].VoucherNo = "V-404"
+	}
+	if got := send("pos:s3cret", postRequest{Entries: unknown, Cancel: true}); got != http.StatusNotFound {
+		t.Errorf("cancelling an unknown voucher: status %d", got)
+	}
+	if n := len(store.All()); n != 4 {
+		t.Fatalf("%d entries after rejected cancellations, want 4", n)
+	}
+	if got := send("pos:s3cret", postRequest{Entries: sale, Cancel: true}); got != http.StatusOK {
+		t.Errorf("cancelling the sale: status %d", got)
+	}
+	if n := len(store.All()); n != 6 {
+		t.Errorf("%d entries after the cancellation, want 6", n)
+	}
+}
@@ -0,0 +1,128 @@
+package apikey
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/senguttuvang/erpnext-go/errcode"
+	"github.com/senguttuvang/erpnext-go/ledger"
+)
+
+// maxBody bounds the size of a posting request.
+const maxBody = 4 << 20
+
+type contextKey struct{}
+
+// FromContext returns the key a request was authenticated with by
+// Middleware.
+func FromContext(ctx context.Context) (*Key, bool) {
+	k, ok := ctx.Value(contextKey{}).(*Key)
+	return k, ok
+}
+
+// Middleware authenticates every request by its Authorization header,
+// "token <api_key>:<api_secret>" as in Frappe, and passes the key on in
+// the request's context. Requests without a valid key get 401.
+func Middleware(keys Keys, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		credentials, ok := strings.CutPrefix(req.Header.Get("Authorization"), "token ")
+		id, secret, found := strings.Cut(credentials, ":")
+		if !ok || !found {
+			writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "missing API key"})
+			return
+		}
+		k, err := Authenticate(keys, id, secret)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		next.ServeHTTP(w, req.WithContext(context.WithValue(req.Context(), contextKey{}, k)))
+	})
+}
+
+// postRequest is the body of a posting: the GL map of a voucher, to post
+// or to cancel.
+type postRequest struct {
+	Entries []ledger.GLEntry `json:"entries"`
+	Cancel  bool             `json:"cancel"`
+}
+
+// Handler serves the posting API for integrations:
+//
+//	POST /gl-entries    post or cancel a GL map: {"entries": [...], "cancel": false}
+//
+// Requests authenticate through Middleware. The GL map is checked against
+// the key's scope, with account subtrees resolved in tree, before the
+// engine sees it; the engine then posts as the key's Name, so its
+// Authorizer applies on top. A cancellation names its voucher by the first
+// entry of the map; the entries stored for that voucher, which are what
+// the engine reverses, are checked and posted in place of the map, and a
+// voucher with none is not found. Mount it under a prefix with
+// http.StripPrefix.
+func Handler(keys Keys, engine *ledger.Engine, tree *ledger.AccountTree) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /gl-entries", func(w http.ResponseWriter, req *http.Request) {
+		k, _ := FromContext(req.Context())
+		var in postRequest
+		if err := json.NewDecoder(http.MaxBytesReader(w, req.Body, maxBody)).Decode(&in); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		glMap := in.Entries
+		if in.Cancel && len(glMap) > 0 {
+			// A cancellation reverses what is stored, whatever the request says
+			stored, err := engine.GLStore.GetByVoucher(glMap[0].VoucherType, glMap[0].VoucherNo)
+			if err != nil {
+				writeError(w, err)
+				return
+			}
+			if len(stored) == 0 {
+				writeError(w, fmt.Errorf("%w: %s %s", ledger.ErrVoucherNotFound, glMap[0].VoucherType, glMap[0].VoucherNo))
+				return
+			}
+			glMap = stored
+		}
+		if err := k.Scope.Check(glMap, tree); err != nil {
+			writeError(w, err)
+			return
+		}
+		opts := ledger.DefaultPostingOptions()
+		opts.Cancel, opts.User = in.Cancel, k.Name
+		warnings, err := engine.Post(glMap, opts)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		messages := make([]string, len(warnings))
+		for i, warning := range warnings {
+			messages[i] = warning.String()
+		}
+		writeJSON(w, http.StatusOK, map[string][]string{"warnings": messages})
+	})
+	return Middleware(keys, mux)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, ErrUnknownKey), errors.Is(err, ErrWrongSecret):
+		status = http.StatusUnauthorized
+	case errors.Is(err, ledger.ErrNotPermitted):
+		status = http.StatusForbidden
+	case errors.Is(err, ledger.ErrVoucherNotFound):
+		status = http.StatusNotFound
+	case errcode.Of(err) != "":
+		status = http.StatusUnprocessableEntity // A posting the ledger rejects
+	}
+	writeJSON(w, status, map[string]string{"error": err.Error(), "code": errcode.Of(err)})
+}
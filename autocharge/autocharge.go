@@ -0,0 +1,195 @@
+// Package autocharge collects invoices from customers' saved payment
+// methods on their due dates.
+//
+// A customer who signs a mandate with a payment gateway (a card on file, a
+// direct debit mandate) lets the company charge them without asking each
+// time. The Collector runs daily: for every sales invoice due by the run
+// date whose customer has a mandate, it charges the outstanding amount
+// through the PaymentGateway port and posts a Payment Entry for the
+// money collected. A failed charge is retried on the days of the
+// RetryPolicy; once the retries are spent, or the gateway declines the
+// mandate outright, the invoice passes to dunning, which raises an
+// invoice.dunning event at each level of the DunningPolicy the invoice
+// reaches. Notification rules turn these events into reminders.
+//
+// Maps to: the GoCardless Mandate doctype and the Stripe and Braintree
+// integrations in erpnext/erpnext_integrations, and the Dunning Type
+// levels of erpnext/accounts/doctype/dunning
+package autocharge
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Collection errors
+var (
+	// ErrDeclined is wrapped by gateways when a charge is refused for good,
+	// such as a cancelled mandate or a closed account; it is not retried.
+	ErrDeclined         = errors.New("charge declined")
+	ErrNotConfigured    = errors.New("collector is missing its engine, gateway, mandates, store or names")
+	ErrInvalidRetryDays = errors.New("retry days must be positive and increasing")
+)
+
+// Mandate is a customer's permission to charge a saved payment method.
+//
+// Maps to: GoCardless Mandate (mandate, customer, disabled)
+type Mandate struct {
+	Name      string
+	Company   string
+	Customer  string
+	Gateway   string // Payment gateway, e.g. "Stripe"
+	Currency  string // Currency the mandate charges in
+	Reference string // The gateway's token or mandate ID
+	Disabled  bool
+}
+
+// Mandates finds the mandate a customer signed with a company.
+type Mandates interface {
+	// MandateFor returns the customer's mandate, or nil when there is none.
+	MandateFor(company, customer string) (*Mandate, error)
+}
+
+// ChargeRequest asks a gateway to collect an invoice.
+type ChargeRequest struct {
+	Mandate  *Mandate
+	Invoice  string
+	Amount   float64
+	Currency string
+
+	// IdempotencyKey is the same for every try of one attempt, so a run
+	// repeated after a crash does not charge the customer twice.
+	IdempotencyKey string
+}
+
+// PaymentGateway is the port to a payment provider.
+type PaymentGateway interface {
+	// Charge collects the amount and returns the gateway's transaction ID.
+	// Errors wrapping ErrDeclined are final; others are retried.
+	Charge(ctx context.Context, req ChargeRequest) (string, error)
+}
+
+// Status is where the collection of an invoice stands.
+type Status string
+
+const (
+	Retrying Status = "Retrying" // A charge failed; another is due at NextAttempt
+	Charged  Status = "Charged"  // Collected, but the payment entry is not posted yet
+	Paid     Status = "Paid"     // Collected and posted
+	Failed   Status = "Failed"   // Out of attempts; dunning has taken over
+)
+
+// Collection is the auto-charge record of an invoice.
+type Collection struct {
+	Invoice     string
+	Company     string
+	Customer    string
+	Account     string // Receivable account of the invoice
+	Mandate     string
+	Gateway     string  // Payment gateway account charged through
+	Amount      float64 // Charged, or to charge
+	DueDate     time.Time
+	Status      Status
+	Attempts    int
+	LastAttempt time.Time
+	NextAttempt time.Time
+	LastError   string
+
+	TransactionID string // Set once charged
+	PaymentEntry  string // Set once posted
+	DunningLevel  int    // Levels of the DunningPolicy raised so far
+}
+
+// Store keeps collections.
+type Store interface {
+	// Get returns the collection of an invoice, or nil when none started.
+	Get(invoice string) (*Collection, error)
+
+	// Save records a collection, replacing the invoice's earlier record.
+	Save(c *Collection) error
+
+	// List returns the collections with a status, in invoice order.
+	List(status Status) ([]Collection, error)
+}
+
+// RetryPolicy says when a failed charge is tried again.
+type RetryPolicy struct {
+	Days []int // Days after the due date of each retry, e.g. 3, 7, 14
+}
+
+// Validate checks that the retry days are positive and increasing.
+func (p RetryPolicy) Validate() error {
+	for i, d := range p.Days {
+		if d <= 0 || i > 0 && d <= p.Days[i-1] {
+			return ErrInvalidRetryDays
+		}
+	}
+	return nil
+}
+
+// DunningLevel is a step of escalation for an invoice that could not be
+// collected.
+//
+// Maps to: Dunning Type
+type DunningLevel struct {
+	Name string // e.g. "First Notice", "Final Notice"
+	Days int    // Days after the due date the level is reached
+}
+
+// DunningPolicy is the escalation of uncollected invoices, mildest first.
+type DunningPolicy []DunningLevel
+
+// level returns how many levels an invoice overdue by days has reached.
+func (p DunningPolicy) level(days int) int {
+	n := 0
+	for n < len(p) && p[n].Days <= days {
+		n++
+	}
+	return n
+}
+
+// MemoryStore is an in-memory Store.
+type MemoryStore struct {
+	mu          sync.Mutex
+	collections map[string]Collection
+}
+
+// Get implements Store.
+func (s *MemoryStore) Get(invoice string) (*Collection, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.collections[invoice]
+	if !ok {
+		return nil, nil
+	}
+	return &c, nil
+}
+
+// Save implements Store.
+func (s *MemoryStore) Save(c *Collection) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.collections == nil {
+		s.collections = make(map[string]Collection)
+	}
+	s.collections[c.Invoice] = *c
+	return nil
+}
+
+// List implements Store.
+func (s *MemoryStore) List(status Status) ([]Collection, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var result []Collection
+	for _, c := range s.collections {
+		if c.Status == status {
+			result = append(result, c)
+		}
+	}
+	slices.SortFunc(result, func(a, b Collection) int { return strings.Compare(a.Invoice, b.Invoice) })
+	return result, nil
+}
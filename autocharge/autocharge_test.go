@@ -0,0 +1,191 @@
+package autocharge
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/ledger"
+	"github.com/senguttuvang/erpnext-go/ledger/memstore"
+	"github.com/senguttuvang/erpnext-go/modeofpayment"
+	"github.com/senguttuvang/erpnext-go/naming"
+)
+
+const debtors = "Debtors - ACME"
+
+func date(m time.Month, d int) time.Time {
+	return time.Date(2024, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+type mandates map[string]*Mandate
+
+func (m mandates) MandateFor(company, customer string) (*Mandate, error) {
+	return m[customer], nil
+}
+
+// gateway fails each customer's charges with the queued errors, then
+// succeeds.
+type gateway struct {
+	failures map[string][]error
+	requests []ChargeRequest
+}
+
+func (g *gateway) Charge(ctx context.Context, req ChargeRequest) (string, error) {
+	g.requests = append(g.requests, req)
+	if errs := g.failures[req.Mandate.Customer]; len(errs) > 0 {
+		g.failures[req.Mandate.Customer] = errs[1:]
+		return "", errs[0]
+	}
+	return fmt.Sprintf("ch_%d", len(g.requests)), nil
+}
+
+func postInvoice(t *testing.T, engine *ledger.Engine, name, customer string, amount float64, due time.Time) {
+	t.Helper()
+	receivable := ledger.GLEntry{
+		PostingDate: due.AddDate(0, 0, -30), Account: debtors, PartyType: "Customer", Party: customer,
+		VoucherType: "Sales Invoice", VoucherNo: name, AgainstVoucherType: "Sales Invoice", AgainstVoucher: name,
+		Company: "ACME", Debit: amount, DebitInAccountCurrency: amount, DueDate: &due,
+	}
+	income := ledger.GLEntry{
+		PostingDate: due.AddDate(0, 0, -30), Account: "Sales - ACME", VoucherType: "Sales Invoice", VoucherNo: name,
+		Company: "ACME", Credit: amount, CreditInAccountCurrency: amount,
+	}
+	if err := engine.MakeGLEntries([]ledger.GLEntry{receivable, income}, ledger.DefaultPostingOptions()); err != nil {
+		t.Fatalf("post %s: %v", name, err)
+	}
+}
+
+func TestCollector(t *testing.T) {
+	payments := memstore.NewPaymentStore()
+	var events []ledger.Event
+	bus := &ledger.EventBus{}
+	bus.Subscribe(func(e ledger.Event) {
+		if e.Type == ledger.EventChargeFailed || e.Type == ledger.EventInvoiceDunning {
+			events = append(events, e)
+		}
+	})
+	engine := &ledger.Engine{GLStore: memstore.NewGLStore(), PaymentStore: payments, Events: bus}
+	names := naming.NewRegistry(memstore.NewSeries())
+	if err := names.RegisterDefaults(); err != nil {
+		t.Fatal(err)
+	}
+	stripe := &modeofpayment.PaymentGatewayAccount{
+		Name: "Stripe - INR", PaymentGateway: "Stripe", Company: "ACME", PaymentAccount: "Stripe Clearing - ACME",
+		Currency: "INR", ModeOfPayment: "Card",
+	}
+	gw := &gateway{failures: map[string][]error{
+		"Birla Ltd": {errors.New("gateway timeout")},
+		"Chola Co":  {fmt.Errorf("%w: card reported stolen", ErrDeclined)},
+	}}
+	c := &Collector{
+		Engine: engine, Gateway: gw, Store: &MemoryStore{}, Names: names,
+		GatewayAccounts: []*modeofpayment.PaymentGatewayAccount{stripe},
+		Mandates: mandates{
+			"Arvind Mills": {Name: "MD-1", Company: "ACME", Customer: "Arvind Mills", Gateway: "Stripe", Currency: "INR"},
+			"Birla Ltd":    {Name: "MD-2", Company: "ACME", Customer: "Birla Ltd", Gateway: "Stripe", Currency: "INR"},
+			"Chola Co":     {Name: "MD-3", Company: "ACME", Customer: "Chola Co", Gateway: "Stripe", Currency: "INR"},
+		},
+		Retry:   RetryPolicy{Days: []int{3, 7}},
+		Dunning: DunningPolicy{{Name: "First Notice", Days: 1}, {Name: "Final Notice", Days: 15}},
+	}
+	postInvoice(t, engine, "SINV-1", "Arvind Mills", 1000, date(4, 10))
+	postInvoice(t, engine, "SINV-2", "Birla Ltd", 2000, date(4, 10))
+	postInvoice(t, engine, "SINV-3", "Chola Co", 3000, date(4, 10))
+	postInvoice(t, engine, "SINV-4", "Deccan Traders", 4000, date(4, 10)) // No mandate
+	postInvoice(t, engine, "SINV-5", "Arvind Mills", 500, date(4, 20))
+	ctx := context.Background()
+	run := func(day time.Time) []Collection {
+		t.Helper()
+		changed, err := c.Run(ctx, payments.All(), day)
+		if err != nil {
+			t.Fatalf("Run(%s) = %v", day.Format(time.DateOnly), err)
+		}
+		return changed
+	}
+	outstanding := func(invoice string) float64 {
+		entries, _ := payments.GetByAgainstVoucher("Sales Invoice", invoice)
+		return ledger.OutstandingAmount(entries, "Sales Invoice", invoice)
+	}
+
+	// Due date: one paid, one to retry, one declined
+	changed := run(date(4, 10))
+	if len(changed) != 3 || len(gw.requests) != 3 {
+		t.Fatalf("changed %+v, requests %+v", changed, gw.requests)
+	}
+	if col := changed[0]; col.Status != Paid || col.PaymentEntry != "ACC-PAY-2024-00001" || col.TransactionID != "ch_1" || outstanding("SINV-1") != 0 {
+		t.Errorf("SINV-1 = %+v", col)
+	}
+	if col := changed[1]; col.Status != Retrying || !col.NextAttempt.Equal(date(4, 13)) || col.LastError != "gateway timeout" {
+		t.Errorf("SINV-2 = %+v", col)
+	}
+	if col := changed[2]; col.Status != Failed || col.DunningLevel != 0 || !col.NextAttempt.IsZero() {
+		t.Errorf("SINV-3 = %+v", col)
+	}
+	if len(events) != 2 || events[0].VoucherNo != "SINV-2" || events[1].Data["attempt"] != 1 {
+		t.Errorf("events = %+v", events)
+	}
+
+	// The next day the declined invoice is dunned; nothing is charged
+	events = nil
+	changed = run(date(4, 11))
+	if len(changed) != 1 || changed[0].DunningLevel != 1 || len(gw.requests) != 3 {
+		t.Errorf("changed %+v", changed)
+	}
+	if len(events) != 1 || events[0].Type != ledger.EventInvoiceDunning || events[0].Data["level"] != "First Notice" || events[0].Amount != 3000 {
+		t.Errorf("events = %+v", events)
+	}
+	if changed = run(date(4, 12)); len(changed) != 0 {
+		t.Errorf("changed %+v", changed)
+	}
+
+	// The retry succeeds under a new idempotency key
+	changed = run(date(4, 13))
+	if len(changed) != 1 || changed[0].Status != Paid || changed[0].Attempts != 2 || outstanding("SINV-2") != 0 {
+		t.Errorf("changed %+v", changed)
+	}
+	if gw.requests[1].IdempotencyKey != "SINV-2-1" || gw.requests[3].IdempotencyKey != "SINV-2-2" || gw.requests[3].Amount != 2000 {
+		t.Errorf("requests = %+v", gw.requests)
+	}
+
+	// A charge that cannot be posted is posted by a later run, not charged again
+	stripe.PaymentAccount = ""
+	events = nil
+	changed, err := c.Run(ctx, payments.All(), date(4, 25))
+	if err == nil || len(changed) != 2 || changed[0].Invoice != "SINV-3" || changed[0].DunningLevel != 2 || changed[1].Status != Charged {
+		t.Fatalf("changed %+v, err %v", changed, err)
+	}
+	if len(events) != 1 || events[0].Data["level"] != "Final Notice" {
+		t.Errorf("events = %+v", events)
+	}
+	stripe.PaymentAccount = "Stripe Clearing - ACME"
+	changed = run(date(4, 26))
+	if len(changed) != 1 || changed[0].Invoice != "SINV-5" || changed[0].Status != Paid || len(gw.requests) != 5 || outstanding("SINV-5") != 0 {
+		t.Errorf("changed %+v, requests %d", changed, len(gw.requests))
+	}
+	if outstanding("SINV-4") != 4000 {
+		t.Error("invoice without a mandate was collected")
+	}
+
+	// A withdrawn mandate fails the retries left
+	postInvoice(t, engine, "SINV-6", "Birla Ltd", 700, date(5, 1))
+	gw.failures["Birla Ltd"] = []error{errors.New("insufficient funds")}
+	run(date(5, 1))
+	c.Mandates.(mandates)["Birla Ltd"].Disabled = true
+	changed = run(date(5, 4))
+	if len(changed) != 1 || changed[0].Status != Failed || changed[0].LastError != "mandate withdrawn" || changed[0].DunningLevel != 1 {
+		t.Errorf("changed %+v", changed)
+	}
+}
+
+func TestRetryPolicyValidate(t *testing.T) {
+	for _, days := range [][]int{{0}, {3, 3}, {7, 3}} {
+		if err := (RetryPolicy{Days: days}).Validate(); !errors.Is(err, ErrInvalidRetryDays) {
+			t.Errorf("Validate(%v) = %v", days, err)
+		}
+	}
+	if _, err := (&Collector{}).Run(context.Background(), nil, time.Now()); !errors.Is(err, ErrNotConfigured) {
+		t.Errorf("Run() unconfigured = %v", err)
+	}
+}
@@ -0,0 +1,274 @@
+package autocharge
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/daterange"
+	"github.com/senguttuvang/erpnext-go/ledger"
+	"github.com/senguttuvang/erpnext-go/modeofpayment"
+	"github.com/senguttuvang/erpnext-go/naming"
+	"github.com/senguttuvang/erpnext-go/paymententry"
+)
+
+// invoiceType is the voucher type collected.
+const invoiceType = "Sales Invoice"
+
+// Collector charges due invoices and escalates those it cannot collect.
+type Collector struct {
+	Engine          *ledger.Engine // Posts the payment entries; its Events receive failures and dunning
+	Gateway         PaymentGateway
+	Mandates        Mandates
+	Store           Store
+	GatewayAccounts []*modeofpayment.PaymentGatewayAccount // Where each gateway settles
+	Names           *naming.Registry                       // Names the payment entries
+	Modes           modeofpayment.Lookup                   // Optional; deducts the mode of payment's fee when set
+	Retry           RetryPolicy
+	Dunning         DunningPolicy
+}
+
+// invoice is a sales invoice and what is outstanding on it.
+type invoice struct {
+	entry       ledger.PaymentLedgerEntry // The invoice's own entry
+	outstanding float64
+}
+
+// Run collects the invoices due on or before asOf from the payment ledger
+// entries, and returns the collections it changed. It first posts the
+// payment entries of charges an earlier run collected but could not post.
+// Then, for each customer invoice with an outstanding balance whose
+// customer has an enabled mandate, it charges the outstanding amount when
+// an attempt is due, and escalates invoices out of attempts to the next
+// dunning level they have reached. Errors are collected; one failing
+// invoice does not stop the others.
+func (c *Collector) Run(ctx context.Context, entries []ledger.PaymentLedgerEntry, asOf time.Time) ([]Collection, error) {
+	if c.Engine == nil || c.Gateway == nil || c.Mandates == nil || c.Store == nil || c.Names == nil {
+		return nil, ErrNotConfigured
+	}
+	if err := c.Retry.Validate(); err != nil {
+		return nil, err
+	}
+	asOf = daterange.Civil(asOf)
+	invoices, order := salesInvoices(entries)
+
+	var changed []Collection
+	var errs []error
+	save := func(col *Collection, err error) {
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", col.Invoice, err))
+		}
+		if err := c.Store.Save(col); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", col.Invoice, err))
+			return
+		}
+		changed = append(changed, *col)
+	}
+
+	charged, err := c.Store.List(Charged)
+	if err != nil {
+		return nil, err
+	}
+	for i := range charged {
+		col := &charged[i]
+		save(col, c.post(col, invoices[col.Invoice], asOf))
+	}
+
+	for _, name := range order {
+		inv := invoices[name]
+		if inv.outstanding <= 0 || inv.entry.DueDate == nil || daterange.Civil(*inv.entry.DueDate).After(asOf) {
+			continue
+		}
+		col, err := c.Store.Get(name)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+			continue
+		}
+		if col != nil && (col.Status == Paid || col.Status == Charged) {
+			continue
+		}
+		if col != nil && col.Status == Failed {
+			if c.escalate(col, inv, asOf) {
+				save(col, nil)
+			}
+			continue
+		}
+		if col != nil && col.NextAttempt.After(asOf) {
+			continue
+		}
+
+		mandate, err := c.Mandates.MandateFor(inv.entry.Company, inv.entry.Party)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+			continue
+		}
+		if col == nil {
+			if mandate == nil || mandate.Disabled {
+				continue
+			}
+			col = &Collection{
+				Invoice: name, Company: inv.entry.Company, Customer: inv.entry.Party,
+				Account: inv.entry.Account, DueDate: daterange.Civil(*inv.entry.DueDate),
+			}
+		}
+		if mandate == nil || mandate.Disabled {
+			// Withdrawn since the last attempt
+			col.Status, col.LastError = Failed, "mandate withdrawn"
+			c.escalate(col, inv, asOf)
+			save(col, nil)
+			continue
+		}
+		gw, err := modeofpayment.SelectGatewayAccount(c.GatewayAccounts, modeofpayment.GatewayQuery{
+			Company: col.Company, Currency: mandate.Currency, PaymentGateway: mandate.Gateway,
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+			continue
+		}
+		c.charge(ctx, col, mandate, gw, inv, asOf)
+		var postErr error
+		if col.Status == Charged {
+			postErr = c.post(col, inv, asOf)
+		}
+		save(col, postErr)
+	}
+	return changed, errors.Join(errs...)
+}
+
+// salesInvoices groups payment ledger entries by customer invoice, in
+// ledger order, with the outstanding amount of each.
+func salesInvoices(entries []ledger.PaymentLedgerEntry) (map[string]*invoice, []string) {
+	invoices := make(map[string]*invoice)
+	var order []string
+	for _, e := range entries {
+		if e.Delinked || e.AgainstVoucherType != invoiceType || e.PartyType != "Customer" {
+			continue
+		}
+		inv, ok := invoices[e.AgainstVoucherNo]
+		if !ok {
+			inv = &invoice{}
+			invoices[e.AgainstVoucherNo] = inv
+			order = append(order, e.AgainstVoucherNo)
+		}
+		if e.VoucherType == invoiceType && e.VoucherNo == e.AgainstVoucherNo {
+			inv.entry = e
+		}
+		inv.outstanding = ledger.Flt(inv.outstanding+e.Amount, 2)
+	}
+	return invoices, order
+}
+
+// charge makes one attempt to collect the invoice. A failure schedules the
+// next retry after asOf, or fails the collection when there is none left
+// or the gateway declined for good.
+func (c *Collector) charge(ctx context.Context, col *Collection, mandate *Mandate, gw *modeofpayment.PaymentGatewayAccount, inv *invoice, asOf time.Time) {
+	col.Mandate, col.Gateway, col.Amount = mandate.Name, gw.Name, inv.outstanding
+	col.Attempts++
+	col.LastAttempt = asOf
+
+	id, err := c.Gateway.Charge(ctx, ChargeRequest{
+		Mandate: mandate, Invoice: col.Invoice, Amount: col.Amount, Currency: gw.Currency,
+		IdempotencyKey: fmt.Sprintf("%s-%d", col.Invoice, col.Attempts),
+	})
+	if err == nil {
+		col.Status, col.TransactionID, col.LastError = Charged, id, ""
+		return
+	}
+
+	col.LastError = err.Error()
+	c.publish(ledger.EventChargeFailed, col, inv, asOf, map[string]any{"attempt": col.Attempts, "reason": col.LastError})
+	col.Status, col.NextAttempt = Failed, time.Time{}
+	if !errors.Is(err, ErrDeclined) {
+		for _, d := range c.Retry.Days {
+			if next := col.DueDate.AddDate(0, 0, d); next.After(asOf) {
+				col.Status, col.NextAttempt = Retrying, next
+				break
+			}
+		}
+	}
+	if col.Status == Failed {
+		c.escalate(col, inv, asOf)
+	}
+}
+
+// post books the payment entry of a charged collection. The charge is
+// allocated to the invoice up to what is still outstanding on it; the
+// rest is an advance.
+func (c *Collector) post(col *Collection, inv *invoice, asOf time.Time) error {
+	var gw *modeofpayment.PaymentGatewayAccount
+	for _, g := range c.GatewayAccounts {
+		if g.Name == col.Gateway {
+			gw = g
+		}
+	}
+	if gw == nil {
+		return fmt.Errorf("%w: gateway account %s", ErrNotConfigured, col.Gateway)
+	}
+	name, err := c.Names.NewName(paymententry.VoucherType, naming.Options{Date: asOf})
+	if err != nil {
+		return err
+	}
+
+	pe := &paymententry.PaymentEntry{
+		Name: name, Company: col.Company, PostingDate: asOf, PaymentType: paymententry.Receive,
+		ModeOfPayment: gw.ModeOfPayment, PartyType: "Customer", Party: col.Customer,
+		PaidFrom: col.Account, PaidTo: gw.PaymentAccount, Currency: gw.Currency,
+		PaidAmount: col.Amount, ReferenceNo: col.TransactionID, ReferenceDate: &asOf,
+		Remarks: fmt.Sprintf("Charged to mandate %s for %s", col.Mandate, col.Invoice),
+	}
+	if outstanding := invoiceOutstanding(inv); outstanding > 0 {
+		pe.References = []paymententry.Reference{{
+			ReferenceDoctype: invoiceType, ReferenceName: col.Invoice, DueDate: &col.DueDate,
+			OutstandingAmount: outstanding, AllocatedAmount: min(col.Amount, outstanding),
+		}}
+	}
+	if c.Modes != nil {
+		if err := pe.ApplyModeOfPaymentFee(c.Modes); err != nil {
+			return err
+		}
+	}
+	if err := pe.Submit(c.Engine); err != nil {
+		col.LastError = err.Error()
+		return err
+	}
+	col.Status, col.PaymentEntry, col.LastError = Paid, name, ""
+	return nil
+}
+
+func invoiceOutstanding(inv *invoice) float64 {
+	if inv == nil {
+		return 0
+	}
+	return inv.outstanding
+}
+
+// escalate raises the highest dunning level a failed collection has
+// reached and not yet raised. It reports whether it raised one.
+//
+// Maps to: creating a Dunning of the next Dunning Type for an overdue
+// invoice
+func (c *Collector) escalate(col *Collection, inv *invoice, asOf time.Time) bool {
+	days := daterange.New(col.DueDate, asOf).Days()
+	level := c.Dunning.level(days)
+	if level <= col.DunningLevel {
+		return false
+	}
+	col.DunningLevel = level
+	c.publish(ledger.EventInvoiceDunning, col, inv, asOf, map[string]any{
+		"level": c.Dunning[level-1].Name, "dunning_level": level, "days_overdue": days,
+		"attempts": col.Attempts, "reason": col.LastError,
+	})
+	return true
+}
+
+func (c *Collector) publish(eventType string, col *Collection, inv *invoice, asOf time.Time, data map[string]any) {
+	if c.Engine.Events == nil {
+		return
+	}
+	c.Engine.Events.Publish(ledger.Event{
+		Type: eventType, Time: asOf, Company: col.Company,
+		VoucherType: invoiceType, VoucherNo: col.Invoice, PostingDate: inv.entry.PostingDate,
+		PartyType: "Customer", Party: col.Customer, Amount: inv.outstanding, Data: data,
+	})
+}
@@ -0,0 +1,260 @@
+// Package autoreport runs reports on a schedule and emails the results.
+//
+// Each AutoReport names a report, its filters, a cron schedule, an output
+// format and its recipients: fixed addresses and the users holding given
+// roles in the report's company. The Runner generates due reports,
+// renders them through reportio and hands them to a Mailer.
+//
+// Migrated from: frappe/email/doctype/auto_email_report/auto_email_report.py
+package autoreport
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/reportio"
+)
+
+// Auto report errors
+var (
+	ErrNoRecipients  = errors.New("auto report has no recipients")
+	ErrUnknownReport = errors.New("report not found")
+)
+
+// AutoReport configures one scheduled report.
+//
+// Maps to: Auto Email Report doctype
+type AutoReport struct {
+	Name     string
+	Report   string // Report name known to the Generator
+	Company  string
+	Filters  map[string]string
+	Schedule Schedule
+	Format   reportio.Format
+
+	Recipients []string // Email addresses
+	Roles      []string // Users with these roles in Company also receive it
+
+	Description string // Email body
+	SendIfData  bool   // Skip the email when the report has no rows
+	Enabled     bool
+}
+
+// Generator runs reports by name.
+type Generator interface {
+	Generate(ctx context.Context, report, company string, filters map[string]string) (reportio.Report, error)
+}
+
+// Generators is a Generator over report functions keyed by report name.
+type Generators map[string]func(ctx context.Context, company string, filters map[string]string) (reportio.Report, error)
+
+// Generate implements Generator.
+func (g Generators) Generate(ctx context.Context, report, company string, filters map[string]string) (reportio.Report, error) {
+	fn, ok := g[report]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownReport, report)
+	}
+	return fn(ctx, company, filters)
+}
+
+// RecipientLookup resolves the email addresses of users with a role.
+type RecipientLookup interface {
+	UsersWithRole(company, role string) ([]string, error)
+}
+
+// Attachment is a file attached to a message.
+type Attachment struct {
+	Filename    string
+	ContentType string
+	Data        []byte
+}
+
+// Message is an email to send.
+type Message struct {
+	To          []string
+	Subject     string
+	Body        string
+	Attachments []Attachment
+}
+
+// Mailer sends email.
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// Runner sends auto reports when they fall due.
+type Runner struct {
+	Reports    []AutoReport
+	Generator  Generator
+	Mailer     Mailer
+	Recipients RecipientLookup // Optional; required when reports use Roles
+
+	mu      sync.Mutex
+	lastRun map[string]time.Time // Report name -> last scheduled time handled
+}
+
+// RunDue sends every enabled report whose next scheduled time after its
+// last run is at or before now. A report that has not run yet is due only
+// when its schedule matches the current minute, so starting the runner
+// does not replay missed runs. Errors are collected; one failing report
+// does not stop the others, and a report that failed stays due, so the
+// next call retries the same run.
+//
+// Maps to: send_daily() / send_monthly() scheduler events in
+// auto_email_report.py
+func (r *Runner) RunDue(ctx context.Context, now time.Time) error {
+	var errs []error
+	for _, report := range r.Reports {
+		if !report.Enabled {
+			continue
+		}
+		due, ok := r.due(report, now)
+		if !ok {
+			continue
+		}
+		handled := due
+		if err := r.Send(ctx, report, due); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", report.Name, err))
+			handled = due.Add(-time.Minute) // The schedule next falls due at due again
+		}
+		r.mu.Lock()
+		r.lastRun[report.Name] = handled
+		r.mu.Unlock()
+	}
+	return errors.Join(errs...)
+}
+
+func (r *Runner) due(report AutoReport, now time.Time) (time.Time, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.lastRun == nil {
+		r.lastRun = make(map[string]time.Time)
+	}
+	last, ok := r.lastRun[report.Name]
+	if !ok {
+		last = now.Add(-time.Minute)
+	}
+	next := report.Schedule.Next(last)
+	if next.IsZero() || next.After(now) {
+		return time.Time{}, false
+	}
+	return next, true
+}
+
+// Start calls RunDue every interval until ctx is cancelled. Errors are
+// passed to onError, which may be nil.
+func (r *Runner) Start(ctx context.Context, interval time.Duration, onError func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if err := r.RunDue(ctx, now); err != nil && onError != nil {
+				onError(err)
+			}
+		}
+	}
+}
+
+// Send generates a report and emails it now, regardless of its schedule.
+// at is the time used in the subject and attachment name.
+//
+// Maps to: AutoEmailReport.send()
+func (r *Runner) Send(ctx context.Context, report AutoReport, at time.Time) error {
+	to, err := r.recipients(report)
+	if err != nil {
+		return err
+	}
+
+	result, err := r.Generator.Generate(ctx, report.Report, report.Company, report.Filters)
+	if err != nil {
+		return err
+	}
+	format := report.Format
+	if format == "" {
+		format = reportio.XLSX
+	}
+	counted := &countingReport{Report: result}
+	var buf bytes.Buffer
+	if err := reportio.Write(&buf, format, counted); err != nil {
+		return err
+	}
+	if report.SendIfData && counted.rows == 0 {
+		return nil
+	}
+
+	date := at.Format("2006-01-02")
+	return r.Mailer.Send(ctx, Message{
+		To:      to,
+		Subject: fmt.Sprintf("%s: %s (%s)", report.Name, report.Company, date),
+		Body:    report.Description,
+		Attachments: []Attachment{{
+			Filename:    fmt.Sprintf("%s-%s.%s", fileName(report.Name), date, format),
+			ContentType: format.ContentType(),
+			Data:        buf.Bytes(),
+		}},
+	})
+}
+
+// recipients merges the fixed addresses with the users holding the
+// report's roles, without duplicates.
+func (r *Runner) recipients(report AutoReport) ([]string, error) {
+	to := slices.Clone(report.Recipients)
+	for _, role := range report.Roles {
+		if r.Recipients == nil {
+			return nil, fmt.Errorf("%w: no recipient lookup for role %s", ErrNoRecipients, role)
+		}
+		users, err := r.Recipients.UsersWithRole(report.Company, role)
+		if err != nil {
+			return nil, err
+		}
+		to = append(to, users...)
+	}
+	slices.Sort(to)
+	to = slices.Compact(to)
+	if len(to) == 0 {
+		return nil, ErrNoRecipients
+	}
+	return to, nil
+}
+
+// countingReport counts the rows written.
+type countingReport struct {
+	reportio.Report
+	rows int
+}
+
+func (c *countingReport) Rows(fn func([]any) error) error {
+	return c.Report.Rows(func(row []any) error {
+		c.rows++
+		return fn(row)
+	})
+}
+
+func (c *countingReport) Title() string {
+	if t, ok := c.Report.(reportio.Titled); ok {
+		return t.Title()
+	}
+	return ""
+}
+
+// fileName turns a report name into a file name.
+func fileName(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == ' ':
+			return '_'
+		case strings.ContainsRune(`/\:*?"<>|`, r):
+			return -1
+		}
+		return r
+	}, name)
+}
@@ -0,0 +1,183 @@
+package autoreport
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/reportio"
+)
+
+func at(s string) time.Time {
+	t, err := time.Parse("2006-01-02 15:04", s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestScheduleNext(t *testing.T) {
+	tests := []struct {
+		expr string
+		from string
+		want string
+	}{
+		{"@daily", "2024-04-15 10:30", "2024-04-16 00:00"},
+		{"*/15 * * * *", "2024-04-15 10:31", "2024-04-15 10:45"},
+		{"30 8 * * 1-5", "2024-04-19 09:00", "2024-04-22 08:30"}, // Friday -> Monday
+		{"0 7 1 * *", "2024-04-15 10:00", "2024-05-01 07:00"},
+		{"0 0 31 * *", "2024-04-01 00:00", "2024-05-31 00:00"}, // April has no 31st
+		{"0 9 1,15 * *", "2024-04-01 09:00", "2024-04-15 09:00"},
+		{"0 6 1 * 0", "2024-04-02 00:00", "2024-04-07 06:00"}, // 1st or any Sunday
+		{"0 0 * * 7", "2024-04-15 00:00", "2024-04-21 00:00"}, // 7 is Sunday
+		{"0 0 29 2 *", "2024-03-01 00:00", "2028-02-29 00:00"},
+	}
+	for _, tt := range tests {
+		s, err := ParseSchedule(tt.expr)
+		if err != nil {
+			t.Fatalf("ParseSchedule(%q) error = %v", tt.expr, err)
+		}
+		if got := s.Next(at(tt.from)); !got.Equal(at(tt.want)) {
+			t.Errorf("%q after %s = %s, want %s", tt.expr, tt.from, got.Format("2006-01-02 15:04"), tt.want)
+		}
+	}
+
+	// India is half an hour off the hour of UTC
+	kolkata, err := time.LoadLocation("Asia/Kolkata")
+	if err != nil {
+		t.Skip(err)
+	}
+	from := time.Date(2024, 4, 15, 9, 45, 0, 0, kolkata)
+	if got, want := MustParseSchedule("0 11 * * *").Next(from), time.Date(2024, 4, 15, 11, 0, 0, 0, kolkata); !got.Equal(want) {
+		t.Errorf("0 11 * * * after %s = %s, want %s", from, got, want)
+	}
+}
+
+func TestParseScheduleErrors(t *testing.T) {
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "5-1 * * * *", "*/0 * * * *", "a * * * *"} {
+		if _, err := ParseSchedule(expr); !errors.Is(err, ErrInvalidSchedule) {
+			t.Errorf("ParseSchedule(%q) error = %v, want ErrInvalidSchedule", expr, err)
+		}
+	}
+}
+
+type mockMailer struct {
+	sent []Message
+	err  error // Returned, and nothing sent, when set
+}
+
+func (m *mockMailer) Send(ctx context.Context, msg Message) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+type mockRoles map[string][]string
+
+func (m mockRoles) UsersWithRole(company, role string) ([]string, error) {
+	return m[company+"/"+role], nil
+}
+
+func receivables(rows int) func(context.Context, string, map[string]string) (reportio.Report, error) {
+	return func(ctx context.Context, company string, filters map[string]string) (reportio.Report, error) {
+		t := &reportio.Table{Name: "Accounts Receivable", Cols: []reportio.Column{
+			{Fieldname: "party", Label: "Customer", Fieldtype: reportio.Data},
+			{Fieldname: "outstanding", Label: "Outstanding", Fieldtype: reportio.Currency},
+		}}
+		for range rows {
+			t.Data = append(t.Data, []any{filters["customer_group"], 100.0})
+		}
+		return t, nil
+	}
+}
+
+func TestRunDue(t *testing.T) {
+	mailer := &mockMailer{}
+	runner := &Runner{
+		Reports: []AutoReport{
+			{
+				Name: "Daily Receivables", Report: "Accounts Receivable", Company: "ACME",
+				Filters: map[string]string{"customer_group": "Retail"}, Schedule: MustParseSchedule("0 8 * * *"),
+				Format: reportio.CSV, Recipients: []string{"cfo@acme.test", "ar@acme.test"}, Roles: []string{"Accounts Manager"},
+				Enabled: true,
+			},
+			{
+				Name: "Empty Report", Report: "Empty", Company: "ACME", Schedule: MustParseSchedule("0 8 * * *"),
+				Recipients: []string{"cfo@acme.test"}, SendIfData: true, Enabled: true,
+			},
+			{
+				Name: "Disabled", Report: "Accounts Receivable", Company: "ACME", Schedule: MustParseSchedule("* * * * *"),
+				Recipients: []string{"cfo@acme.test"},
+			},
+		},
+		Generator:  Generators{"Accounts Receivable": receivables(2), "Empty": receivables(0)},
+		Mailer:     mailer,
+		Recipients: mockRoles{"ACME/Accounts Manager": {"ar@acme.test", "manager@acme.test"}},
+	}
+	ctx := context.Background()
+
+	if err := runner.RunDue(ctx, at("2024-04-15 07:59")); err != nil || len(mailer.sent) != 0 {
+		t.Fatalf("before schedule: err = %v, sent %d", err, len(mailer.sent))
+	}
+	if err := runner.RunDue(ctx, at("2024-04-15 08:00")); err != nil {
+		t.Fatal(err)
+	}
+	if len(mailer.sent) != 1 {
+		t.Fatalf("sent %d messages, want 1", len(mailer.sent))
+	}
+	msg := mailer.sent[0]
+	if strings.Join(msg.To, ",") != "ar@acme.test,cfo@acme.test,manager@acme.test" {
+		t.Errorf("To = %v", msg.To)
+	}
+	if msg.Subject != "Daily Receivables: ACME (2024-04-15)" {
+		t.Errorf("Subject = %s", msg.Subject)
+	}
+	a := msg.Attachments[0]
+	if a.Filename != "Daily_Receivables-2024-04-15.csv" || a.ContentType != reportio.CSV.ContentType() {
+		t.Errorf("attachment %s (%s)", a.Filename, a.ContentType)
+	}
+	if string(a.Data) != "Customer,Outstanding\nRetail,100.00\nRetail,100.00\n" {
+		t.Errorf("attachment data:\n%s", a.Data)
+	}
+
+	// Not sent again the same day, sent again the next
+	runner.RunDue(ctx, at("2024-04-15 08:01"))
+	runner.RunDue(ctx, at("2024-04-15 20:00"))
+	if len(mailer.sent) != 1 {
+		t.Errorf("sent %d messages on the same day, want 1", len(mailer.sent))
+	}
+	runner.RunDue(ctx, at("2024-04-16 08:00"))
+	if len(mailer.sent) != 2 {
+		t.Errorf("sent %d messages after the next run, want 2", len(mailer.sent))
+	}
+
+	// A run that failed to send is retried until it is sent
+	mailer.err = errors.New("mail server down")
+	if err := runner.RunDue(ctx, at("2024-04-17 08:00")); !errors.Is(err, mailer.err) {
+		t.Errorf("RunDue() with the mail server down = %v", err)
+	}
+	mailer.err = nil
+	if err := runner.RunDue(ctx, at("2024-04-17 08:05")); err != nil || len(mailer.sent) != 3 {
+		t.Fatalf("retry: err = %v, sent %d", err, len(mailer.sent))
+	}
+	if msg := mailer.sent[2]; msg.Subject != "Daily Receivables: ACME (2024-04-17)" {
+		t.Errorf("retried Subject = %s", msg.Subject)
+	}
+}
+
+func TestSendErrors(t *testing.T) {
+	runner := &Runner{Generator: Generators{}, Mailer: &mockMailer{}}
+	report := AutoReport{Name: "R", Report: "Missing", Schedule: MustParseSchedule("@daily"), Recipients: []string{"a@acme.test"}}
+
+	if err := runner.Send(context.Background(), report, at("2024-04-15 00:00")); !errors.Is(err, ErrUnknownReport) {
+		t.Errorf("Expected ErrUnknownReport, got %v", err)
+	}
+	report.Recipients = nil
+	if err := runner.Send(context.Background(), report, at("2024-04-15 00:00")); !errors.Is(err, ErrNoRecipients) {
+		t.Errorf("Expected ErrNoRecipients, got %v", err)
+	}
+}
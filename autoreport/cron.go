@@ -0,0 +1,165 @@
+package autoreport
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidSchedule is returned for a malformed cron expression.
+var ErrInvalidSchedule = errors.New("invalid schedule")
+
+// Schedule is a parsed cron expression: minute, hour, day of month, month
+// and day of week. Fields accept "*", numbers, ranges ("1-5"), lists
+// ("1,15") and steps ("*/15"). The descriptors @hourly, @daily, @weekly and
+// @monthly are also accepted.
+type Schedule struct {
+	expr                          string
+	minute, hour, dom, month, dow uint64 // Bit sets of allowed values
+	domAny, dowAny                bool
+}
+
+var descriptors = map[string]string{
+	"@hourly":  "0 * * * *",
+	"@daily":   "0 0 * * *",
+	"@weekly":  "0 0 * * 0",
+	"@monthly": "0 0 1 * *",
+}
+
+// ParseSchedule parses a cron expression.
+func ParseSchedule(expr string) (Schedule, error) {
+	s := Schedule{expr: expr}
+	if d, ok := descriptors[expr]; ok {
+		expr = d
+	}
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return s, fmt.Errorf("%w: %q needs 5 fields", ErrInvalidSchedule, s.expr)
+	}
+
+	var err error
+	parse := func(field string, min, max int) uint64 {
+		if err != nil {
+			return 0
+		}
+		var bits uint64
+		bits, err = parseField(field, min, max)
+		if err != nil {
+			err = fmt.Errorf("%w: %q: %v", ErrInvalidSchedule, s.expr, err)
+		}
+		return bits
+	}
+	s.minute = parse(fields[0], 0, 59)
+	s.hour = parse(fields[1], 0, 23)
+	s.dom = parse(fields[2], 1, 31)
+	s.month = parse(fields[3], 1, 12)
+	s.dow = parse(fields[4], 0, 7)
+	if err != nil {
+		return s, err
+	}
+	if s.dow&(1<<7) != 0 { // 7 is also Sunday
+		s.dow |= 1
+	}
+	s.domAny = fields[2] == "*"
+	s.dowAny = fields[4] == "*"
+	return s, nil
+}
+
+// MustParseSchedule is like ParseSchedule but panics on error.
+func MustParseSchedule(expr string) Schedule {
+	s, err := ParseSchedule(expr)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// String returns the expression the schedule was parsed from.
+func (s Schedule) String() string {
+	return s.expr
+}
+
+// Next returns the first time after t that matches the schedule, in t's
+// location, or the zero time if there is none within five years.
+//
+// Steps are taken on the wall clock of t's location: Truncate rounds the
+// absolute time, which in a zone half an hour off UTC lands mid-hour.
+func (s Schedule) Next(t time.Time) time.Time {
+	t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute()+1, 0, 0, t.Location())
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		switch {
+		case !has(s.month, int(t.Month())):
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+		case !s.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+		case !has(s.hour, t.Hour()):
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+		case !has(s.minute, t.Minute()):
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// dayMatches applies cron's rule that when both day fields are restricted,
+// either may match.
+func (s Schedule) dayMatches(t time.Time) bool {
+	dom, dow := has(s.dom, t.Day()), has(s.dow, int(t.Weekday()))
+	switch {
+	case s.domAny && s.dowAny:
+		return true
+	case s.domAny:
+		return dow
+	case s.dowAny:
+		return dom
+	}
+	return dom || dow
+}
+
+func has(bits uint64, v int) bool {
+	return bits&(1<<uint(v)) != 0
+}
+
+func parseField(field string, min, max int) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		rng, stepText, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepText)
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("bad step %q", part)
+			}
+			step = n
+		}
+
+		lo, hi := min, max
+		if rng != "*" {
+			from, to, isRange := strings.Cut(rng, "-")
+			var err error
+			if lo, err = strconv.Atoi(from); err != nil {
+				return 0, fmt.Errorf("bad value %q", part)
+			}
+			hi = lo
+			if isRange {
+				if hi, err = strconv.Atoi(to); err != nil {
+					return 0, fmt.Errorf("bad range %q", part)
+				}
+			} else if hasStep {
+				hi = max
+			}
+		}
+		if lo < min || hi > max || lo > hi {
+			return 0, fmt.Errorf("%q out of range %d-%d", part, min, max)
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
@@ -0,0 +1,289 @@
+// Package backdate analyzes what a backdated voucher would change before
+// it is accepted: periods already closed, statements already issued for
+// the period and stock valuations that depend on the date. Without the
+// analysis, a voucher dated in the past silently changes figures that
+// were already reported or used.
+//
+// Postings into a closed period are blocking; the other impacts are
+// listed for the user to confirm, and stock reposting can be queued as
+// background jobs.
+//
+// Migrated from: the backdated entry handling of
+// erpnext/stock/stock_ledger.py (repost_future_sle) and
+// validate_against_pcv() in erpnext/accounts/general_ledger.py
+package backdate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/daterange"
+	"github.com/senguttuvang/erpnext-go/jobs"
+	"github.com/senguttuvang/erpnext-go/ledger"
+)
+
+// ErrBlocked is returned when a backdated voucher may not be posted.
+var ErrBlocked = errors.New("backdated voucher blocked")
+
+// RepostTask is the job type that reposts stock valuation. The
+// application registers a task for it with the jobs.Runner.
+//
+// Maps to: Repost Item Valuation doctype
+const RepostTask = "repost-item-valuation"
+
+// Kind classifies an impact.
+type Kind string
+
+const (
+	ClosedPeriod    Kind = "Closed Period"    // Accounting period, book closing or Period Closing Voucher
+	FrozenAccounts  Kind = "Frozen Accounts"  // Before the accounts frozen till date
+	IssuedStatement Kind = "Issued Statement" // A statement or report was issued covering the date
+	StockValuation  Kind = "Stock Valuation"  // Later stock transactions are valued from this one
+)
+
+// Impact is one thing a backdated voucher changes.
+type Impact struct {
+	Kind     Kind
+	Subject  string // The period, statement or voucher affected
+	Date     time.Time
+	Detail   string
+	Blocking bool
+	Item     ItemWarehouse // For stock valuation impacts
+}
+
+// ItemWarehouse is a stock item moved by a voucher.
+type ItemWarehouse struct {
+	ItemCode  string
+	Warehouse string
+}
+
+// Voucher is the voucher to be posted.
+type Voucher struct {
+	VoucherType string
+	VoucherNo   string
+	Company     string
+	PostingDate time.Time
+	Items       []ItemWarehouse // Stock moved; empty for vouchers without stock
+}
+
+// IssuedReport is a statement or report generated and sent out.
+type IssuedReport struct {
+	Name     string // e.g. "Balance Sheet March 2024"
+	Period   daterange.Range
+	IssuedAt time.Time
+}
+
+// StatementLog lists the statements and reports issued for a company.
+// Production implementations read the auto report history and the
+// statements filed with authorities.
+type StatementLog interface {
+	IssuedCovering(company string, date time.Time) ([]IssuedReport, error)
+}
+
+// StockTransaction is a stock ledger entry of an item and warehouse.
+type StockTransaction struct {
+	VoucherType string
+	VoucherNo   string
+	ItemWarehouse
+	PostingDate time.Time
+}
+
+// StockLedger finds the stock transactions valued after a date. Production
+// implementations query Stock Ledger Entry.
+type StockLedger interface {
+	// TransactionsAfter returns the transactions of the items in their
+	// warehouses posted after the date.
+	TransactionsAfter(company string, items []ItemWarehouse, date time.Time) ([]StockTransaction, error)
+}
+
+// Analyzer lists the impacts of backdated vouchers. Every source is
+// optional; impacts of a missing source are not looked for.
+type Analyzer struct {
+	Periods    ledger.AccountingPeriodChecker
+	Company    ledger.CompanySettings
+	Statements StatementLog
+	Stock      StockLedger
+}
+
+// Report is the analysis of one voucher.
+type Report struct {
+	Voucher   Voucher
+	Backdated bool // Entries of the company are posted after the voucher's date
+	Impacts   []Impact
+}
+
+// Blocking returns the impacts that keep the voucher from being posted.
+func (r *Report) Blocking() []Impact {
+	var out []Impact
+	for _, i := range r.Impacts {
+		if i.Blocking {
+			out = append(out, i)
+		}
+	}
+	return out
+}
+
+// Err returns an error wrapping ErrBlocked that lists the blocking
+// impacts, or nil if there are none.
+func (r *Report) Err() error {
+	blocking := r.Blocking()
+	if len(blocking) == 0 {
+		return nil
+	}
+	reasons := make([]string, len(blocking))
+	for i, b := range blocking {
+		reasons[i] = b.Detail
+	}
+	return fmt.Errorf("%w: %s %s: %s", ErrBlocked, r.Voucher.VoucherType, r.Voucher.VoucherNo, strings.Join(reasons, "; "))
+}
+
+// Analyze lists the impacts of posting v, given the GL entries already
+// posted for its company. Cancelled entries and the voucher's own entries
+// are ignored.
+func (a *Analyzer) Analyze(v Voucher, gl []ledger.GLEntry) (*Report, error) {
+	report := &Report{Voucher: v}
+	date := ledger.CivilDate(v.PostingDate)
+
+	var lastClosing *ledger.GLEntry
+	cancelled := ledger.CancelledEntries(gl)
+	for i := range gl {
+		e := &gl[i]
+		if cancelled[i] || e.Company != v.Company || (e.VoucherType == v.VoucherType && e.VoucherNo == v.VoucherNo) {
+			continue
+		}
+		posted := ledger.CivilDate(e.PostingDate)
+		if posted.After(date) {
+			report.Backdated = true
+		}
+		if e.VoucherType == ledger.PeriodClosingVoucher && !posted.Before(date) &&
+			(lastClosing == nil || posted.After(ledger.CivilDate(lastClosing.PostingDate))) {
+			lastClosing = e
+		}
+	}
+	if lastClosing != nil {
+		report.add(Impact{
+			Kind: ClosedPeriod, Subject: lastClosing.VoucherNo, Date: lastClosing.PostingDate, Blocking: true,
+			Detail: fmt.Sprintf("%s %s closed the books on %s", ledger.PeriodClosingVoucher, lastClosing.VoucherNo, lastClosing.PostingDate.Format(ledger.DateLayout)),
+		})
+	}
+
+	if err := a.closedPeriods(report); err != nil {
+		return nil, err
+	}
+	if a.Statements != nil {
+		issued, err := a.Statements.IssuedCovering(v.Company, v.PostingDate)
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range issued {
+			report.add(Impact{
+				Kind: IssuedStatement, Subject: s.Name, Date: s.IssuedAt,
+				Detail: fmt.Sprintf("%s for %s was issued on %s", s.Name, s.Period, s.IssuedAt.Format(ledger.DateLayout)),
+			})
+		}
+	}
+	if a.Stock != nil && len(v.Items) > 0 {
+		later, err := a.Stock.TransactionsAfter(v.Company, v.Items, v.PostingDate)
+		if err != nil {
+			return nil, err
+		}
+		if len(later) > 0 {
+			report.Backdated = true
+		}
+		for _, t := range later {
+			report.add(Impact{
+				Kind: StockValuation, Subject: t.VoucherType + " " + t.VoucherNo, Date: t.PostingDate, Item: t.ItemWarehouse,
+				Detail: fmt.Sprintf("valuation of %s in %s on %s", t.ItemCode, t.Warehouse, t.PostingDate.Format(ledger.DateLayout)),
+			})
+		}
+	}
+	return report, nil
+}
+
+// closedPeriods adds the closed accounting period, book closing and
+// frozen accounts impacts.
+func (a *Analyzer) closedPeriods(report *Report) error {
+	v := report.Voucher
+	if a.Periods != nil {
+		closed, err := a.Periods.IsDocumentTypeClosed(v.Company, v.VoucherType, v.PostingDate)
+		if err != nil {
+			return err
+		}
+		if closed {
+			msg, err := a.Periods.GetClosedPeriodMessage(v.Company, v.VoucherType, v.PostingDate)
+			if err != nil {
+				return err
+			}
+			report.add(Impact{Kind: ClosedPeriod, Subject: v.VoucherType, Date: v.PostingDate, Detail: msg, Blocking: true})
+		}
+	}
+	if a.Company == nil {
+		return nil
+	}
+	date := ledger.CivilDate(v.PostingDate)
+	closing, err := a.Company.GetBookClosingDate(v.Company)
+	if err != nil {
+		return err
+	}
+	if closing != nil && !date.After(ledger.CivilDate(*closing)) {
+		report.add(Impact{
+			Kind: ClosedPeriod, Subject: v.Company, Date: *closing, Blocking: true,
+			Detail: fmt.Sprintf("books are closed till %s", closing.Format(ledger.DateLayout)),
+		})
+	}
+	frozen, err := a.Company.GetAccountsFrozenTillDate(v.Company)
+	if err != nil {
+		return err
+	}
+	if frozen != nil && date.Before(ledger.CivilDate(*frozen)) {
+		// Users allowed to bypass the freeze may still post
+		report.add(Impact{
+			Kind: FrozenAccounts, Subject: v.Company, Date: *frozen,
+			Detail: fmt.Sprintf("accounts are frozen till %s", frozen.Format(ledger.DateLayout)),
+		})
+	}
+	return nil
+}
+
+func (r *Report) add(i Impact) { r.Impacts = append(r.Impacts, i) }
+
+// RepostParams are the parameters of a RepostTask job.
+type RepostParams struct {
+	Company     string `json:"company"`
+	ItemCode    string `json:"item_code"`
+	Warehouse   string `json:"warehouse"`
+	PostingDate string `json:"posting_date"` // Repost from this date
+	VoucherType string `json:"voucher_type"` // The backdated voucher
+	VoucherNo   string `json:"voucher_no"`
+}
+
+// QueueReposts submits a RepostTask job for each item and warehouse of
+// the voucher whose later transactions the report lists, reposting from
+// the voucher's date. It returns the jobs submitted.
+//
+// Maps to: create_repost_item_valuation_entry() in stock_ledger.py
+func (r *Report) QueueReposts(ctx context.Context, runner *jobs.Runner) ([]*jobs.Job, error) {
+	var pending []ItemWarehouse
+	for _, i := range r.Impacts {
+		if i.Kind == StockValuation && !slices.Contains(pending, i.Item) {
+			pending = append(pending, i.Item)
+		}
+	}
+	var submitted []*jobs.Job
+	for _, iw := range pending {
+		job, err := runner.Submit(ctx, RepostTask, RepostParams{
+			Company: r.Voucher.Company, ItemCode: iw.ItemCode, Warehouse: iw.Warehouse,
+			PostingDate: r.Voucher.PostingDate.Format(ledger.DateLayout),
+			VoucherType: r.Voucher.VoucherType, VoucherNo: r.Voucher.VoucherNo,
+		})
+		if err != nil {
+			return submitted, err
+		}
+		submitted = append(submitted, job)
+	}
+	return submitted, nil
+}
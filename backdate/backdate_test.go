@@ -0,0 +1,132 @@
+package backdate
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/daterange"
+	"github.com/senguttuvang/erpnext-go/jobs"
+	"github.com/senguttuvang/erpnext-go/ledger"
+	"github.com/senguttuvang/erpnext-go/ledger/memstore"
+)
+
+func date(s string) time.Time {
+	t, err := ledger.ParseDate(s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+type statements []IssuedReport
+
+func (s statements) IssuedCovering(_ string, d time.Time) ([]IssuedReport, error) {
+	var out []IssuedReport
+	for _, r := range s {
+		if r.Period.Contains(d) {
+			out = append(out, r)
+		}
+	}
+	return out, nil
+}
+
+type stock []StockTransaction
+
+func (s stock) TransactionsAfter(_ string, items []ItemWarehouse, d time.Time) ([]StockTransaction, error) {
+	var out []StockTransaction
+	for _, t := range s {
+		for _, iw := range items {
+			if t.ItemWarehouse == iw && t.PostingDate.After(d) {
+				out = append(out, t)
+			}
+		}
+	}
+	return out, nil
+}
+
+func TestAnalyze(t *testing.T) {
+	gl := []ledger.GLEntry{
+		{Company: "Acme", VoucherType: "Sales Invoice", VoucherNo: "SI-9", PostingDate: date("2024-04-20")},
+		{Company: "Acme", VoucherType: ledger.PeriodClosingVoucher, VoucherNo: "PCV-2023", PostingDate: date("2024-03-31")},
+		// A cancelled closing, its reversal saved live as older stores hold it
+		{Company: "Acme", VoucherType: ledger.PeriodClosingVoucher, VoucherNo: "PCV-2024", PostingDate: date("2024-06-30"),
+			Account: "Sales", Debit: 100, IsCancelled: true},
+		{Company: "Acme", VoucherType: ledger.PeriodClosingVoucher, VoucherNo: "PCV-2024", PostingDate: date("2024-06-30"),
+			Account: "Sales", Credit: 100},
+	}
+	frozen := date("2024-04-05")
+	a := &Analyzer{
+		Company: &memstore.Company{AccountsFrozenTill: &frozen},
+		Statements: statements{
+			{Name: "VAT Return Q1", Period: daterange.Inclusive(date("2024-01-01"), date("2024-03-31")), IssuedAt: date("2024-04-10")},
+			{Name: "Balance Sheet April", Period: daterange.Inclusive(date("2024-04-01"), date("2024-04-30")), IssuedAt: date("2024-05-02")},
+		},
+		Stock: stock{
+			{VoucherType: "Delivery Note", VoucherNo: "DN-4", ItemWarehouse: ItemWarehouse{"BOLT", "Stores"}, PostingDate: date("2024-04-12")},
+			{VoucherType: "Delivery Note", VoucherNo: "DN-5", ItemWarehouse: ItemWarehouse{"BOLT", "Stores"}, PostingDate: date("2024-04-15")},
+			{VoucherType: "Delivery Note", VoucherNo: "DN-6", ItemWarehouse: ItemWarehouse{"NUT", "Stores"}, PostingDate: date("2024-04-15")},
+		},
+	}
+
+	// A receipt backdated into April, after the close
+	receipt := Voucher{VoucherType: "Purchase Receipt", VoucherNo: "PR-7", Company: "Acme", PostingDate: date("2024-04-08"),
+		Items: []ItemWarehouse{{"BOLT", "Stores"}}}
+	report, err := a.Analyze(receipt, gl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.Backdated || report.Err() != nil {
+		t.Fatalf("backdated %v, err %v", report.Backdated, report.Err())
+	}
+	var kinds []string
+	for _, i := range report.Impacts {
+		kinds = append(kinds, string(i.Kind)+" "+i.Subject)
+	}
+	want := "Issued Statement Balance Sheet April, Stock Valuation Delivery Note DN-4, Stock Valuation Delivery Note DN-5"
+	if got := strings.Join(kinds, ", "); got != want {
+		t.Errorf("impacts = %s\nwant %s", got, want)
+	}
+
+	// Reposting is queued once per item and warehouse
+	runner := &jobs.Runner{Queue: jobs.NewMemoryQueue(10), Store: &jobs.MemoryStore{}, Tasks: map[string]jobs.Task{
+		RepostTask: jobs.TaskFunc(func(context.Context, json.RawMessage) error { return nil }),
+	}}
+	queued, err := report.QueueReposts(context.Background(), runner)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var params RepostParams
+	if len(queued) != 1 || json.Unmarshal(queued[0].Params, &params) != nil || params.ItemCode != "BOLT" || params.PostingDate != "2024-04-08" {
+		t.Errorf("queued %d jobs: %+v", len(queued), params)
+	}
+
+	// Before the Period Closing Voucher and the freeze, the voucher is blocked
+	receipt.PostingDate = date("2024-03-30")
+	report, err = a.Analyze(receipt, gl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if blocking := report.Blocking(); len(blocking) != 1 || blocking[0].Subject != "PCV-2023" {
+		t.Errorf("blocking = %+v", blocking)
+	}
+	if err := report.Err(); !errors.Is(err, ErrBlocked) {
+		t.Errorf("err = %v, want ErrBlocked", err)
+	}
+	var frozenImpact bool
+	for _, i := range report.Impacts {
+		frozenImpact = frozenImpact || i.Kind == FrozenAccounts
+	}
+	if !frozenImpact {
+		t.Errorf("impacts = %+v, want frozen accounts", report.Impacts)
+	}
+
+	// A voucher dated after everything changes nothing
+	report, err = a.Analyze(Voucher{VoucherType: "Journal Entry", VoucherNo: "JV-1", Company: "Acme", PostingDate: date("2024-06-01")}, gl)
+	if err != nil || report.Backdated || len(report.Impacts) != 0 {
+		t.Errorf("report = %+v, %v", report, err)
+	}
+}
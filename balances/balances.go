@@ -0,0 +1,236 @@
+// Package balances keeps a per-account, per-day balance table up to date
+// from ledger events, so that running balances and dashboards read
+// pre-aggregated figures instead of scanning every GL entry.
+//
+// A Projection subscribes to the engine's events. For each gl.posted or
+// gl.cancelled event it reloads the voucher's entries from the GL store
+// and replaces the voucher's previous contribution to the table, so
+// replayed or duplicated events do no harm. Cancelled entries do not
+// count, as in the reports that scan the GL.
+//
+// Maps to: the Account Closing Balance doctype and the balance snapshots
+// planned for erpnext/accounts/report/general_ledger
+package balances
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/daterange"
+	"github.com/senguttuvang/erpnext-go/ledger"
+)
+
+// Day is the movement of one account on one day, in company currency.
+type Day struct {
+	Company string
+	Account string
+	Date    time.Time // Civil date (UTC midnight)
+	Debit   float64
+	Credit  float64
+}
+
+// Net returns Debit - Credit.
+func (d Day) Net() float64 {
+	return ledger.Flt(d.Debit-d.Credit, 2)
+}
+
+type accountKey struct{ company, account string }
+
+type voucherKey struct{ voucherType, voucherNo string }
+
+// Projection maintains the daily balance table.
+type Projection struct {
+	Source  ledger.GLEntryStore
+	OnError func(error) // Optional; receives errors of queued events
+
+	mu       sync.RWMutex
+	days     map[accountKey][]Day // Sorted by date
+	vouchers map[voucherKey][]Day // Each voucher's contribution
+	queue    chan ledger.Event
+	dropped  int
+}
+
+// NewProjection returns an empty projection over a GL store. Publish
+// queues up to buffer events for Run.
+func NewProjection(source ledger.GLEntryStore, buffer int) *Projection {
+	return &Projection{
+		Source:   source,
+		days:     make(map[accountKey][]Day),
+		vouchers: make(map[voucherKey][]Day),
+		queue:    make(chan ledger.Event, buffer),
+	}
+}
+
+// Publish implements ledger.EventPublisher. It never blocks: when the
+// queue is full the event is dropped and Stale reports true until the
+// table is rebuilt.
+func (p *Projection) Publish(event ledger.Event) {
+	if event.Type != ledger.EventGLPosted && event.Type != ledger.EventGLCancelled {
+		return
+	}
+	select {
+	case p.queue <- event:
+	default:
+		p.mu.Lock()
+		p.dropped++
+		p.mu.Unlock()
+	}
+}
+
+// Run applies queued events until ctx is cancelled.
+func (p *Projection) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event := <-p.queue:
+			if err := p.Apply(event); err != nil && p.OnError != nil {
+				p.OnError(err)
+			}
+		}
+	}
+}
+
+// Stale reports whether events were dropped since the last Rebuild.
+func (p *Projection) Stale() bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.dropped > 0
+}
+
+// Apply brings the table up to date with the voucher of an event.
+func (p *Projection) Apply(event ledger.Event) error {
+	entries, err := p.Source.GetByVoucher(event.VoucherType, event.VoucherNo)
+	if err != nil {
+		return err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.replace(voucherKey{event.VoucherType, event.VoucherNo}, entries)
+	return nil
+}
+
+// Rebuild replaces the table with one computed from all entries, as after
+// a restart or when Stale.
+func (p *Projection) Rebuild(entries []ledger.GLEntry) {
+	byVoucher := make(map[voucherKey][]ledger.GLEntry)
+	var order []voucherKey
+	for _, e := range entries {
+		k := voucherKey{e.VoucherType, e.VoucherNo}
+		if _, ok := byVoucher[k]; !ok {
+			order = append(order, k)
+		}
+		byVoucher[k] = append(byVoucher[k], e)
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.days = make(map[accountKey][]Day)
+	p.vouchers = make(map[voucherKey][]Day)
+	p.dropped = 0
+	for _, k := range order {
+		p.replace(k, byVoucher[k])
+	}
+}
+
+// replace swaps a voucher's contribution for one computed from entries.
+// Cancelled entries and their reversals contribute nothing, whether or
+// not the store flagged the reversals too.
+func (p *Projection) replace(k voucherKey, entries []ledger.GLEntry) {
+	for _, d := range p.vouchers[k] {
+		p.add(d, -1)
+	}
+	var contribution []Day
+	cancelled := ledger.CancelledEntries(entries)
+	for i, e := range entries {
+		if cancelled[i] {
+			continue
+		}
+		d := Day{Company: e.Company, Account: e.Account, Date: ledger.CivilDate(e.PostingDate), Debit: e.Debit, Credit: e.Credit}
+		i := slices.IndexFunc(contribution, func(c Day) bool {
+			return c.Company == d.Company && c.Account == d.Account && c.Date.Equal(d.Date)
+		})
+		if i < 0 {
+			contribution = append(contribution, d)
+		} else {
+			contribution[i].Debit += d.Debit
+			contribution[i].Credit += d.Credit
+		}
+	}
+	for _, d := range contribution {
+		p.add(d, 1)
+	}
+	if len(contribution) == 0 {
+		delete(p.vouchers, k)
+	} else {
+		p.vouchers[k] = contribution
+	}
+}
+
+// add adds sign times a day's movement to the table.
+func (p *Projection) add(d Day, sign float64) {
+	key := accountKey{d.Company, d.Account}
+	days := p.days[key]
+	i, found := slices.BinarySearchFunc(days, d.Date, func(x Day, t time.Time) int { return x.Date.Compare(t) })
+	if !found {
+		days = slices.Insert(days, i, Day{Company: d.Company, Account: d.Account, Date: d.Date})
+	}
+	days[i].Debit = ledger.Flt(days[i].Debit+sign*d.Debit, 6)
+	days[i].Credit = ledger.Flt(days[i].Credit+sign*d.Credit, 6)
+	if days[i].Debit == 0 && days[i].Credit == 0 {
+		days = slices.Delete(days, i, i+1)
+	}
+	if len(days) == 0 {
+		delete(p.days, key)
+	} else {
+		p.days[key] = days
+	}
+}
+
+// Days returns an account's daily movements from from to to inclusive,
+// oldest first. Zero dates leave that end open.
+func (p *Projection) Days(company, account string, from, to time.Time) []Day {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	period := daterange.Inclusive(from, to)
+	var out []Day
+	for _, d := range p.days[accountKey{company, account}] {
+		if period.Contains(d.Date) {
+			out = append(out, d)
+		}
+	}
+	return out
+}
+
+// Balance returns an account's debit and credit totals and balance (debit
+// minus credit) up to and including asOf.
+//
+// Maps to: get_balance_on(account, date) in accounts/utils.py
+func (p *Projection) Balance(company, account string, asOf time.Time) (debit, credit, balance float64) {
+	for _, d := range p.Days(company, account, time.Time{}, asOf) {
+		debit += d.Debit
+		credit += d.Credit
+	}
+	return ledger.Flt(debit, 2), ledger.Flt(credit, 2), ledger.Flt(debit-credit, 2)
+}
+
+// RunningBalance pairs each day with the account's balance at its end.
+type RunningBalance struct {
+	Day
+	Balance float64
+}
+
+// Running returns the daily movements from from to to with the balance
+// after each day, starting from the balance brought forward.
+func (p *Projection) Running(company, account string, from, to time.Time) (opening float64, days []RunningBalance) {
+	if !from.IsZero() {
+		_, _, opening = p.Balance(company, account, ledger.CivilDate(from).AddDate(0, 0, -1))
+	}
+	balance := opening
+	for _, d := range p.Days(company, account, from, to) {
+		balance = ledger.Flt(balance+d.Debit-d.Credit, 2)
+		days = append(days, RunningBalance{Day: d, Balance: balance})
+	}
+	return opening, days
+}
@@ -0,0 +1,162 @@
+package balances
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/ledger"
+	"github.com/senguttuvang/erpnext-go/ledger/memstore"
+)
+
+func date(s string) time.Time {
+	t, _ := time.Parse(ledger.DateLayout, s)
+	return t
+}
+
+func journal(no, day string, amount float64) []ledger.GLEntry {
+	return []ledger.GLEntry{
+		{Company: "ABC", Account: "Expenses - ABC", Debit: amount, DebitInAccountCurrency: amount,
+			VoucherType: "Journal Entry", VoucherNo: no, PostingDate: date(day)},
+		{Company: "ABC", Account: "Cash - ABC", Credit: amount, CreditInAccountCurrency: amount,
+			VoucherType: "Journal Entry", VoucherNo: no, PostingDate: date(day)},
+	}
+}
+
+// scan computes the balance the slow way, for comparison.
+func scan(store *memstore.GLStore, account string, asOf time.Time) float64 {
+	var balance float64
+	entries := store.All()
+	cancelled := ledger.CancelledEntries(entries)
+	for i, e := range entries {
+		if e.Account == account && !cancelled[i] && !e.PostingDate.After(asOf) {
+			balance += e.Debit - e.Credit
+		}
+	}
+	return ledger.Flt(balance, 2)
+}
+
+func TestProjectionFollowsEngine(t *testing.T) {
+	store := memstore.NewGLStore()
+	p := NewProjection(store, 0)
+	bus := &ledger.EventBus{}
+	bus.Subscribe(func(e ledger.Event) {
+		if err := p.Apply(e); err != nil {
+			t.Error(err)
+		}
+	})
+	engine := &ledger.Engine{GLStore: store, Events: bus}
+
+	post := func(no, day string, amount float64) {
+		if err := engine.MakeGLEntries(journal(no, day, amount), ledger.DefaultPostingOptions()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	post("JV-001", "2026-03-01", 100)
+	post("JV-002", "2026-03-01", 50)
+	post("JV-003", "2026-03-04", 25)
+
+	days := p.Days("ABC", "Expenses - ABC", time.Time{}, time.Time{})
+	if len(days) != 2 || days[0].Debit != 150 || days[1].Debit != 25 {
+		t.Fatalf("days = %+v", days)
+	}
+	if _, _, bal := p.Balance("ABC", "Cash - ABC", date("2026-03-03")); bal != -150 {
+		t.Errorf("cash on 3 March = %v, want -150", bal)
+	}
+
+	cancel := ledger.DefaultPostingOptions()
+	cancel.Cancel = true
+	if err := engine.MakeGLEntries(journal("JV-002", "2026-03-01", 50), cancel); err != nil {
+		t.Fatal(err)
+	}
+	// A redelivered event must not count twice.
+	if err := p.Apply(ledger.Event{Type: ledger.EventGLPosted, VoucherType: "Journal Entry", VoucherNo: "JV-001"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, account := range []string{"Expenses - ABC", "Cash - ABC"} {
+		for _, day := range []string{"2026-02-28", "2026-03-01", "2026-03-04"} {
+			_, _, got := p.Balance("ABC", account, date(day))
+			if want := scan(store, account, date(day)); got != want {
+				t.Errorf("%s on %s = %v, scan gives %v", account, day, got, want)
+			}
+		}
+	}
+
+	opening, running := p.Running("ABC", "Expenses - ABC", date("2026-03-02"), date("2026-03-31"))
+	if want := scan(store, "Expenses - ABC", date("2026-03-01")); opening != want {
+		t.Errorf("opening = %v, want %v", opening, want)
+	}
+	if len(running) != 1 || running[0].Balance != opening+25 {
+		t.Errorf("running = %+v", running)
+	}
+}
+
+func TestRebuildAndQueue(t *testing.T) {
+	store := memstore.NewGLStore()
+	if err := store.SaveBatch(append(journal("JV-001", "2026-03-01", 100), journal("JV-002", "2026-03-02", 40)...)); err != nil {
+		t.Fatal(err)
+	}
+	p := NewProjection(store, 1)
+	p.Publish(ledger.Event{Type: ledger.EventGLPosted, VoucherType: "Journal Entry", VoucherNo: "JV-001"})
+	p.Publish(ledger.Event{Type: ledger.EventGLPosted, VoucherType: "Journal Entry", VoucherNo: "JV-002"})
+	if !p.Stale() {
+		t.Fatal("dropped event not reported")
+	}
+
+	p.Rebuild(store.All())
+	if p.Stale() {
+		t.Error("still stale after rebuild")
+	}
+	if _, _, bal := p.Balance("ABC", "Expenses - ABC", date("2026-03-31")); bal != 140 {
+		t.Errorf("balance = %v, want 140", bal)
+	}
+
+	// The queued event for JV-001 replays onto the rebuilt table harmlessly.
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	p.OnError = func(err error) { t.Error(err) }
+	go func() { p.Run(ctx); close(done) }()
+	for len(p.queue) > 0 {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	<-done
+	if _, _, bal := p.Balance("ABC", "Expenses - ABC", date("2026-03-31")); bal != 140 {
+		t.Errorf("balance after replay = %v, want 140", bal)
+	}
+}
+
+func TestCancelledVoucherNetsToZero(t *testing.T) {
+	store := memstore.NewGLStore()
+	p := NewProjection(store, 0)
+	bus := &ledger.EventBus{}
+	bus.Subscribe(func(e ledger.Event) {
+		if err := p.Apply(e); err != nil {
+			t.Error(err)
+		}
+	})
+	engine := &ledger.Engine{GLStore: store, Events: bus}
+	if err := engine.MakeGLEntries(journal("JV-001", "2026-03-01", 100), ledger.DefaultPostingOptions()); err != nil {
+		t.Fatal(err)
+	}
+	cancel := ledger.DefaultPostingOptions()
+	cancel.Cancel = true
+	if err := engine.MakeGLEntries(journal("JV-001", "2026-03-01", 100), cancel); err != nil {
+		t.Fatal(err)
+	}
+	for _, account := range []string{"Expenses - ABC", "Cash - ABC"} {
+		if _, _, got := p.Balance("ABC", account, date("2026-03-31")); got != 0 {
+			t.Errorf("%s after cancelling = %v, want 0", account, got)
+		}
+	}
+
+	// Reversals a store kept live do not count either
+	legacy := store.All()
+	for i := range legacy {
+		legacy[i].IsCancelled = i < 2
+	}
+	p.Rebuild(legacy)
+	if _, _, got := p.Balance("ABC", "Expenses - ABC", date("2026-03-31")); got != 0 {
+		t.Errorf("expenses over live reversals = %v, want 0", got)
+	}
+}
@@ -0,0 +1,201 @@
+// Package bankfeed takes bank notifications as they arrive and settles the
+// invoices they pay without anyone keying them in.
+//
+// A bank posts a webhook for each credit or debit on the company's
+// account. The bank's Mapper turns its payload into BankTransactions; the
+// Processor stores each one, scores it against the open invoices of the
+// company, and when the best candidate is certain enough posts a Payment
+// Entry allocated to it. Transactions it cannot settle wait in the review
+// queue, with their candidates, until someone accepts one or dismisses
+// the transaction. Handler serves the webhook and the review queue over
+// HTTP.
+//
+// Amounts are in company currency: a bank account in another currency is
+// not supported.
+//
+// Maps to: Bank Transaction (erpnext/accounts/doctype/bank_transaction)
+// and the auto-matching of the Bank Reconciliation Tool
+// (erpnext/accounts/doctype/bank_reconciliation_tool)
+package bankfeed
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Bank feed errors
+var (
+	ErrNotConfigured    = errors.New("bank feed is missing its engine, names, store or invoices")
+	ErrUnknownBank      = errors.New("unknown bank")
+	ErrInvalidBank      = errors.New("invalid bank")
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	ErrInvalidPayload   = errors.New("invalid bank payload")
+	ErrNotFound         = errors.New("bank transaction not found")
+	ErrNotInReview      = errors.New("bank transaction is not awaiting review")
+	ErrNoCandidate      = errors.New("invoice is not a candidate of the bank transaction")
+)
+
+// Status is where a bank transaction is in reconciliation.
+type Status string
+
+const (
+	Processing   Status = "Processing"   // Being matched or posted; claimed by one caller
+	Unreconciled Status = "Unreconciled" // Waiting in the review queue
+	Reconciled   Status = "Reconciled"   // Settled by a payment entry
+	Dismissed    Status = "Dismissed"    // Not an invoice payment, such as bank charges
+)
+
+// Bank is a bank that notifies the company of the transactions on one of
+// its accounts.
+type Bank struct {
+	Name    string // Path segment the bank posts its webhooks to
+	Company string
+	Account string // GL account of the bank account
+	Secret  string // Webhooks must carry a valid signature keyed by it
+	Mapper  Mapper
+}
+
+// Validate checks that the bank can receive webhooks. A bank without a
+// secret is refused rather than trusted, since anyone could otherwise post
+// it transactions that settle invoices.
+func (b *Bank) Validate() error {
+	switch {
+	case b.Name == "" || b.Company == "" || b.Account == "" || b.Mapper == nil:
+		return fmt.Errorf("%w: %s needs a name, company, account and mapper", ErrInvalidBank, b.Name)
+	case b.Secret == "":
+		return fmt.Errorf("%w: %s has no webhook secret", ErrInvalidBank, b.Name)
+	}
+	return nil
+}
+
+// BankTransaction is one credit or debit on a bank account.
+//
+// Maps to: Bank Transaction (date, deposit, withdrawal, reference_number,
+// description, bank_party_name, status)
+type BankTransaction struct {
+	Name        string // Bank and the bank's own ID, unique per transaction
+	ID          string // The bank's ID of the transaction
+	Bank        string
+	Company     string
+	Account     string // GL account of the bank account
+	Date        time.Time
+	Deposit     float64 // Money in
+	Withdrawal  float64 // Money out
+	Reference   string
+	Description string
+	PartyName   string // Name of the payer or payee as the bank reports it
+
+	Status       Status
+	Candidates   []Match // Best first
+	PaymentEntry string  // Set once reconciled
+	LastError    string  // Why a match was not posted
+}
+
+// Amount returns the money the transaction moved, whichever the way.
+func (t *BankTransaction) Amount() float64 {
+	return max(t.Deposit, t.Withdrawal)
+}
+
+// Store keeps bank transactions.
+type Store interface {
+	// Get returns a transaction by name, or nil when it was never received.
+	Get(name string) (*BankTransaction, error)
+
+	// Create records a transaction received for the first time. It
+	// records nothing and reports false when one of the name exists, so
+	// of two deliveries of a notification only one goes on to process it.
+	Create(t *BankTransaction) (bool, error)
+
+	// Save records a transaction, replacing the earlier record of its name.
+	Save(t *BankTransaction) error
+
+	// SaveIf is Save when the recorded transaction of the name still has
+	// status from, and reports whether it saved. It claims a transaction
+	// for one caller, as a row update with the status in its condition
+	// would.
+	SaveIf(t *BankTransaction, from Status) (bool, error)
+
+	// List returns the transactions with a status, in date and name order.
+	List(status Status) ([]BankTransaction, error)
+}
+
+// MemoryStore is an in-memory Store.
+type MemoryStore struct {
+	mu           sync.Mutex
+	transactions map[string]BankTransaction
+}
+
+// Get implements Store.
+func (s *MemoryStore) Get(name string) (*BankTransaction, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, ok := s.transactions[name]
+	if !ok {
+		return nil, nil
+	}
+	t.Candidates = slices.Clone(t.Candidates)
+	return &t, nil
+}
+
+// Create implements Store.
+func (s *MemoryStore) Create(t *BankTransaction) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.transactions[t.Name]; ok {
+		return false, nil
+	}
+	s.save(t)
+	return true, nil
+}
+
+// Save implements Store.
+func (s *MemoryStore) Save(t *BankTransaction) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.save(t)
+	return nil
+}
+
+// SaveIf implements Store.
+func (s *MemoryStore) SaveIf(t *BankTransaction, from Status) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if saved, ok := s.transactions[t.Name]; !ok || saved.Status != from {
+		return false, nil
+	}
+	s.save(t)
+	return true, nil
+}
+
+func (s *MemoryStore) save(t *BankTransaction) {
+	if s.transactions == nil {
+		s.transactions = make(map[string]BankTransaction)
+	}
+	saved := *t
+	saved.Candidates = slices.Clone(t.Candidates)
+	s.transactions[t.Name] = saved
+}
+
+// List implements Store.
+func (s *MemoryStore) List(status Status) ([]BankTransaction, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var result []BankTransaction
+	for _, t := range s.transactions {
+		if t.Status == status {
+			t.Candidates = slices.Clone(t.Candidates)
+			result = append(result, t)
+		}
+	}
+	slices.SortFunc(result, func(a, b BankTransaction) int {
+		if c := a.Date.Compare(b.Date); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+	return result, nil
+}
@@ -0,0 +1,323 @@
+package bankfeed
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/ledger"
+	"github.com/senguttuvang/erpnext-go/ledger/memstore"
+	"github.com/senguttuvang/erpnext-go/naming"
+	"github.com/senguttuvang/erpnext-go/webhooks"
+)
+
+func date(m time.Month, d int) time.Time {
+	return time.Date(2024, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+func postInvoice(t *testing.T, engine *ledger.Engine, voucherType, name, party string, amount float64, on time.Time) {
+	t.Helper()
+	partyType, account, other := "Customer", "Debtors - ACME", "Sales - ACME"
+	if voucherType == "Purchase Invoice" {
+		partyType, account, other, amount = "Supplier", "Creditors - ACME", "Purchases - ACME", -amount
+	}
+	party1 := ledger.GLEntry{
+		PostingDate: on, Account: account, PartyType: partyType, Party: party,
+		VoucherType: voucherType, VoucherNo: name, AgainstVoucherType: voucherType, AgainstVoucher: name, Company: "ACME",
+	}
+	other1 := ledger.GLEntry{PostingDate: on, Account: other, VoucherType: voucherType, VoucherNo: name, Company: "ACME"}
+	if amount > 0 {
+		party1.Debit, party1.DebitInAccountCurrency = amount, amount
+		other1.Credit, other1.CreditInAccountCurrency = amount, amount
+	} else {
+		party1.Credit, party1.CreditInAccountCurrency = -amount, -amount
+		other1.Debit, other1.DebitInAccountCurrency = -amount, -amount
+	}
+	if err := engine.MakeGLEntries([]ledger.GLEntry{party1, other1}, ledger.DefaultPostingOptions()); err != nil {
+		t.Fatalf("post %s: %v", name, err)
+	}
+}
+
+func setup(t *testing.T) (*Processor, *memstore.PaymentStore) {
+	t.Helper()
+	payments := memstore.NewPaymentStore()
+	engine := &ledger.Engine{GLStore: memstore.NewGLStore(), PaymentStore: payments}
+	names := naming.NewRegistry(memstore.NewSeries())
+	if err := names.RegisterDefaults(); err != nil {
+		t.Fatal(err)
+	}
+	postInvoice(t, engine, "Sales Invoice", "SINV-1", "Arvind Mills Ltd", 1000, date(3, 1))
+	postInvoice(t, engine, "Sales Invoice", "SINV-2", "Arvind Mills Ltd", 1000, date(3, 5))
+	postInvoice(t, engine, "Sales Invoice", "SINV-3", "Birla Ltd", 2500, date(3, 8))
+	postInvoice(t, engine, "Purchase Invoice", "PINV-1", "Chola Supplies", 400, date(3, 9))
+	p := &Processor{
+		Engine: engine, Names: names, Store: &MemoryStore{},
+		Invoices: LedgerInvoices(func(string) ([]ledger.PaymentLedgerEntry, error) { return payments.All(), nil }),
+		Banks: []Bank{{
+			Name: "hdfc", Company: "ACME", Account: "HDFC - ACME", Secret: "s3cret",
+			Mapper: JSONMapper{
+				Transactions: "data.transactions", ID: "id", Date: "value_date", Amount: "amount",
+				Direction: "type", Credit: "CR", Reference: "utr", Description: "narration", Party: "counterparty.name",
+			},
+		}},
+	}
+	return p, payments
+}
+
+const payload = `{"data": {"transactions": [
+	{"id": "T1", "value_date": "2024-03-20", "amount": "1000.00", "type": "CR", "utr": "UTR1", "narration": "NEFT SINV-2 ARVIND MILLS", "counterparty": {"name": "ARVIND MILLS LTD."}},
+	{"id": "T2", "value_date": "2024-03-21", "amount": 1000, "type": "CR", "narration": "NEFT", "counterparty": {"name": "Arvind Mills Ltd"}},
+	{"id": "T3", "value_date": "2024-03-22", "amount": 400, "type": "DR", "narration": "PINV-1", "counterparty": {"name": "Chola Supplies"}},
+	{"id": "T4", "value_date": "2024-03-22", "amount": 35.40, "type": "DR", "narration": "SMS charges"}
+]}}`
+
+func TestReceive(t *testing.T) {
+	p, payments := setup(t)
+	body := []byte(payload)
+	if _, err := p.Receive("hdfc", body, "forged"); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("Receive() forged = %v", err)
+	}
+	p.Banks[0].Secret = ""
+	if _, err := p.Receive("hdfc", body, ""); !errors.Is(err, ErrInvalidBank) {
+		t.Fatalf("Receive() without a secret = %v", err)
+	}
+	p.Banks[0].Secret = "s3cret"
+	got, err := p.Receive("hdfc", body, webhooks.Sign("s3cret", body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 4 {
+		t.Fatalf("Receive() = %d transactions", len(got))
+	}
+
+	// Amount, invoice number and party: posted
+	if tx := got[0]; tx.Status != Reconciled || tx.PaymentEntry == "" || tx.Candidates[0].VoucherNo != "SINV-2" || tx.Candidates[0].Score != 1 {
+		t.Errorf("T1 = %+v", tx)
+	}
+	outstanding := func(voucherType, name string) float64 {
+		entries, _ := payments.GetByAgainstVoucher(voucherType, name)
+		return ledger.OutstandingAmount(entries, voucherType, name)
+	}
+	if o := outstanding("Sales Invoice", "SINV-2"); o != 0 {
+		t.Errorf("SINV-2 outstanding = %v", o)
+	}
+	// Amount and party without the invoice number: reviewed
+	if tx := got[1]; tx.Status != Unreconciled || len(tx.Candidates) != 1 || tx.Candidates[0].VoucherNo != "SINV-1" {
+		t.Errorf("T2 = %+v", tx)
+	}
+	// A withdrawal pays the supplier
+	if tx := got[2]; tx.Status != Reconciled || tx.Withdrawal != 400 {
+		t.Errorf("T3 = %+v", tx)
+	}
+	if o := outstanding("Purchase Invoice", "PINV-1"); o != 0 {
+		t.Errorf("PINV-1 outstanding = %v", o)
+	}
+	if tx := got[3]; tx.Status != Unreconciled || len(tx.Candidates) != 0 {
+		t.Errorf("T4 = %+v", tx)
+	}
+
+	// A resent notification is not posted again
+	again, err := p.Receive("hdfc", body, webhooks.Sign("s3cret", body))
+	if err != nil || again[0].PaymentEntry != got[0].PaymentEntry {
+		t.Errorf("Receive() again = %+v, %v", again, err)
+	}
+	if review, _ := p.Review(); len(review) != 2 {
+		t.Errorf("Review() = %+v", review)
+	}
+
+	if _, err := p.Accept("hdfc-T2", "SINV-3"); !errors.Is(err, ErrNoCandidate) {
+		t.Errorf("Accept() other invoice = %v", err)
+	}
+	if tx, err := p.Accept("hdfc-T2", "SINV-1"); err != nil || tx.Status != Reconciled {
+		t.Errorf("Accept() = %+v, %v", tx, err)
+	}
+	if o := outstanding("Sales Invoice", "SINV-1"); o != 0 {
+		t.Errorf("SINV-1 outstanding = %v", o)
+	}
+	if _, err := p.Accept("hdfc-T2", "SINV-1"); !errors.Is(err, ErrNotInReview) {
+		t.Errorf("Accept() twice = %v", err)
+	}
+	if tx, err := p.Dismiss("hdfc-T4"); err != nil || tx.Status != Dismissed {
+		t.Errorf("Dismiss() = %+v, %v", tx, err)
+	}
+	if review, _ := p.Review(); len(review) != 0 {
+		t.Errorf("Review() after = %+v", review)
+	}
+}
+
+// racingStore holds each Get until a second caller reads the same
+// transaction, or a moment passes, so that two callers racing see the same
+// state.
+type racingStore struct {
+	Store
+	mu      sync.Mutex
+	waiting map[string]chan struct{}
+}
+
+func (s *racingStore) Get(name string) (*BankTransaction, error) {
+	t, err := s.Store.Get(name)
+	s.mu.Lock()
+	ch, second := s.waiting[name]
+	if second {
+		delete(s.waiting, name)
+		close(ch)
+	} else {
+		ch = make(chan struct{})
+		s.waiting[name] = ch
+	}
+	s.mu.Unlock()
+	if !second {
+		select {
+		case <-ch:
+		case <-time.After(50 * time.Millisecond):
+			s.mu.Lock()
+			if s.waiting[name] == ch {
+				delete(s.waiting, name)
+			}
+			s.mu.Unlock()
+		}
+	}
+	return t, err
+}
+
+func TestReceiveConcurrently(t *testing.T) {
+	p, payments := setup(t)
+	p.Store = &racingStore{Store: p.Store, waiting: map[string]chan struct{}{}}
+	body := []byte(payload)
+	signature := webhooks.Sign("s3cret", body)
+
+	// A notification delivered twice at once, then T2 accepted twice at once
+	var wg sync.WaitGroup
+	for range 2 {
+		wg.Go(func() {
+			if _, err := p.Receive("hdfc", body, signature); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+	wg.Wait()
+	var accepted atomic.Int32
+	for range 2 {
+		wg.Go(func() {
+			_, err := p.Accept("hdfc-T2", "SINV-1")
+			switch {
+			case err == nil:
+				accepted.Add(1)
+			case !errors.Is(err, ErrNotInReview):
+				t.Error(err)
+			}
+		})
+	}
+	wg.Wait()
+	if accepted.Load() != 1 {
+		t.Errorf("accepted %d times", accepted.Load())
+	}
+
+	// T1, T2 and T3 are each paid once
+	entries := map[string]bool{}
+	for _, e := range payments.All() {
+		if e.VoucherType == "Payment Entry" {
+			entries[e.VoucherNo] = true
+		}
+	}
+	if len(entries) != 3 {
+		t.Errorf("%d payment entries: %v", len(entries), entries)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	p, _ := setup(t)
+	// Reviewers authenticate with a token
+	reviewers := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if req.Header.Get("Authorization") != "token reviewer" {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, req)
+		})
+	}
+	srv := httptest.NewServer(Handler(p, reviewers))
+	defer srv.Close()
+
+	send := func(method, path, body, signature, token string) *http.Response {
+		t.Helper()
+		req, _ := http.NewRequest(method, srv.URL+path, strings.NewReader(body))
+		req.Header.Set(SignatureHeader, signature)
+		if token != "" {
+			req.Header.Set("Authorization", "token "+token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+	post := func(path, body, signature string) *http.Response {
+		t.Helper()
+		return send(http.MethodPost, path, body, signature, "")
+	}
+	if resp := post("/banks/icici", payload, ""); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("unknown bank = %d", resp.StatusCode)
+	}
+	if resp := post("/banks/hdfc", payload, "forged"); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("forged = %d", resp.StatusCode)
+	}
+	if resp := post("/banks/hdfc", `{"data": {}}`, webhooks.Sign("s3cret", []byte(`{"data": {}}`))); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("bad payload = %d", resp.StatusCode)
+	}
+	resp := post("/banks/hdfc", payload, webhooks.Sign("s3cret", []byte(payload)))
+	var got []BankTransaction
+	if err := json.NewDecoder(resp.Body).Decode(&got); err != nil || resp.StatusCode != http.StatusOK || len(got) != 4 {
+		t.Fatalf("webhook = %d, %+v, %v", resp.StatusCode, got, err)
+	}
+
+	if resp := send(http.MethodGet, "/review", "", "", ""); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("review without a token = %d", resp.StatusCode)
+	}
+	if resp := post("/review/hdfc-T2/accept", `{"voucher_no": "SINV-1"}`, ""); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("accept without a token = %d", resp.StatusCode)
+	}
+	resp = send(http.MethodGet, "/review", "", "", "reviewer")
+	var review []BankTransaction
+	if err := json.NewDecoder(resp.Body).Decode(&review); err != nil || len(review) != 2 {
+		t.Fatalf("review = %+v, %v", review, err)
+	}
+	if resp := send(http.MethodPost, "/review/hdfc-T2/accept", `{"voucher_no": "SINV-1"}`, "", "reviewer"); resp.StatusCode != http.StatusOK {
+		t.Errorf("accept = %d", resp.StatusCode)
+	}
+	if resp := send(http.MethodPost, "/review/hdfc-T2/dismiss", "", "", "reviewer"); resp.StatusCode != http.StatusConflict {
+		t.Errorf("dismiss reconciled = %d", resp.StatusCode)
+	}
+
+	// Without a middleware the review queue is not served
+	bare := httptest.NewServer(Handler(p, nil))
+	defer bare.Close()
+	resp, err := http.Get(bare.URL + "/review")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("review without a middleware = %d", resp.StatusCode)
+	}
+}
+
+func TestJSONMapperSigned(t *testing.T) {
+	m := JSONMapper{ID: "ref", Date: "date", Amount: "amt", DateLayout: "02/01/2006"}
+	got, err := m.Map([]byte(`{"ref": "X9", "date": "05/04/2024", "amt": -12.5}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].Withdrawal != 12.5 || got[0].Deposit != 0 || !got[0].Date.Equal(date(4, 5)) {
+		t.Errorf("Map() = %+v", got)
+	}
+	if _, err := m.Map([]byte(`{"date": "05/04/2024", "amt": 1}`)); !errors.Is(err, ErrInvalidPayload) {
+		t.Errorf("Map() without id = %v", err)
+	}
+}
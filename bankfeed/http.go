@@ -0,0 +1,106 @@
+package bankfeed
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+)
+
+// SignatureHeader carries the base64 HMAC-SHA256 of a webhook body keyed
+// by the bank's secret, as webhooks.Sign computes it.
+const SignatureHeader = "X-Bank-Signature"
+
+// maxBody bounds the size of a notification.
+const maxBody = 1 << 20
+
+// Handler serves the bank feed API:
+//
+//	POST /banks/{bank}                  a bank's notification webhook
+//	GET  /review                        transactions waiting for review
+//	POST /review/{name}/accept          settle with a candidate: {"voucher_no": "..."}
+//	POST /review/{name}/dismiss         take out of review without posting
+//
+// Webhooks authenticate by their signature. The review queue settles
+// invoices on a person's say, so its routes are only served through
+// review, a middleware that authenticates them, such as one wrapping
+// apikey.Middleware; with a nil review they are not served at all.
+//
+// Mount it under a prefix with http.StripPrefix.
+func Handler(p *Processor, review func(http.Handler) http.Handler) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /banks/{bank}", func(w http.ResponseWriter, req *http.Request) {
+		body, err := io.ReadAll(http.MaxBytesReader(w, req.Body, maxBody))
+		if err != nil {
+			writeJSON(w, http.StatusRequestEntityTooLarge, map[string]string{"error": err.Error()})
+			return
+		}
+		transactions, err := p.Receive(req.PathValue("bank"), body, req.Header.Get(SignatureHeader))
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, transactions)
+	})
+	if review == nil {
+		return mux
+	}
+
+	queue := http.NewServeMux()
+	queue.HandleFunc("GET /review", func(w http.ResponseWriter, req *http.Request) {
+		transactions, err := p.Review()
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, transactions)
+	})
+	queue.HandleFunc("POST /review/{name}/accept", func(w http.ResponseWriter, req *http.Request) {
+		var in struct {
+			VoucherNo string `json:"voucher_no"`
+		}
+		if err := json.NewDecoder(http.MaxBytesReader(w, req.Body, maxBody)).Decode(&in); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		t, err := p.Accept(req.PathValue("name"), in.VoucherNo)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, t)
+	})
+	queue.HandleFunc("POST /review/{name}/dismiss", func(w http.ResponseWriter, req *http.Request) {
+		t, err := p.Dismiss(req.PathValue("name"))
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, t)
+	})
+	reviewed := review(queue)
+	mux.Handle("/review", reviewed)
+	mux.Handle("/review/", reviewed)
+	return mux
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, ErrUnknownBank), errors.Is(err, ErrNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, ErrInvalidSignature):
+		status = http.StatusUnauthorized
+	case errors.Is(err, ErrInvalidPayload), errors.Is(err, ErrNoCandidate):
+		status = http.StatusBadRequest
+	case errors.Is(err, ErrNotInReview):
+		status = http.StatusConflict
+	}
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
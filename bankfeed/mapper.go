@@ -0,0 +1,144 @@
+package bankfeed
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Mapper turns a bank's notification payload into the transactions it
+// reports. Company, account and status are set by the Processor.
+type Mapper interface {
+	Map(body []byte) ([]BankTransaction, error)
+}
+
+// MapperFunc adapts a function to a Mapper.
+type MapperFunc func(body []byte) ([]BankTransaction, error)
+
+// Map implements Mapper.
+func (f MapperFunc) Map(body []byte) ([]BankTransaction, error) {
+	return f(body)
+}
+
+// JSONMapper maps JSON payloads by the paths of their fields, which covers
+// most banks without code. Paths are dot separated object keys, such as
+// "txn.amount". Amounts may be JSON numbers or strings.
+type JSONMapper struct {
+	Transactions string // Path of the array of transactions; the payload is one transaction when empty
+
+	ID          string
+	Date        string
+	Amount      string // Signed, positive for money in, unless Direction is set
+	Direction   string // Optional path of the credit or debit indicator; Amount is then unsigned
+	Credit      string // Value of Direction for money in, e.g. "CR"
+	Reference   string // Optional
+	Description string // Optional
+	Party       string // Optional
+
+	DateLayout string // Layout of Date; time.DateOnly when empty
+}
+
+// Map implements Mapper.
+func (m JSONMapper) Map(body []byte) ([]BankTransaction, error) {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var payload any
+	if err := dec.Decode(&payload); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPayload, err)
+	}
+	items := []any{payload}
+	if m.Transactions != "" {
+		list, ok := lookup(payload, m.Transactions).([]any)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s is not an array", ErrInvalidPayload, m.Transactions)
+		}
+		items = list
+	}
+
+	result := make([]BankTransaction, 0, len(items))
+	for i, item := range items {
+		t, err := m.transaction(item)
+		if err != nil {
+			return nil, fmt.Errorf("%w: transaction %d: %v", ErrInvalidPayload, i+1, err)
+		}
+		result = append(result, t)
+	}
+	return result, nil
+}
+
+func (m JSONMapper) transaction(item any) (BankTransaction, error) {
+	t := BankTransaction{
+		ID:          text(lookup(item, m.ID)),
+		Reference:   text(lookup(item, m.Reference)),
+		Description: text(lookup(item, m.Description)),
+		PartyName:   text(lookup(item, m.Party)),
+	}
+	if t.ID == "" {
+		return t, fmt.Errorf("no %s", m.ID)
+	}
+	layout := m.DateLayout
+	if layout == "" {
+		layout = time.DateOnly
+	}
+	date, err := time.Parse(layout, text(lookup(item, m.Date)))
+	if err != nil {
+		return t, err
+	}
+	t.Date = date
+	amount, err := strconv.ParseFloat(text(lookup(item, m.Amount)), 64)
+	if err != nil {
+		return t, fmt.Errorf("%s: %w", m.Amount, err)
+	}
+	if m.Direction != "" {
+		amount = abs(amount)
+		if !strings.EqualFold(text(lookup(item, m.Direction)), m.Credit) {
+			amount = -amount
+		}
+	}
+	if amount >= 0 {
+		t.Deposit = amount
+	} else {
+		t.Withdrawal = -amount
+	}
+	return t, nil
+}
+
+// lookup returns the value at a dotted path of a decoded JSON value, or
+// nil when there is none.
+func lookup(v any, path string) any {
+	if path == "" {
+		return nil
+	}
+	for key := range strings.SplitSeq(path, ".") {
+		obj, ok := v.(map[string]any)
+		if !ok {
+			return nil
+		}
+		v = obj[key]
+	}
+	return v
+}
+
+// text returns a decoded JSON scalar as a string.
+func text(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return strings.TrimSpace(v)
+	case json.Number:
+		return v.String()
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func abs(f float64) float64 {
+	if f < 0 {
+		return -f
+	}
+	return f
+}
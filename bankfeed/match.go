@@ -0,0 +1,135 @@
+package bankfeed
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+
+	"github.com/senguttuvang/erpnext-go/ledger"
+	"github.com/senguttuvang/erpnext-go/reconciliation"
+)
+
+// Scores of the evidence that a transaction pays an invoice. They add up
+// to at most 1: the amount and the invoice number together reach
+// DefaultThreshold; the amount and the party alone do not, as a customer
+// often owes the same amount on several invoices.
+const (
+	ScoreAmount       = 0.5 // Amount equals the outstanding amount
+	ScoreReference    = 0.4 // Reference or description quotes the invoice number
+	ScorePartyExact   = 0.3 // Bank's party name is the party's
+	ScorePartyPartial = 0.2 // One of the names contains the other
+
+	DefaultThreshold = 0.9
+	MaxCandidates    = 5
+)
+
+// Match is an open invoice a bank transaction may pay, and how likely.
+type Match struct {
+	VoucherType string
+	VoucherNo   string
+	PartyType   string
+	Party       string
+	Account     string // Receivable or payable account of the invoice
+	Outstanding float64
+	Score       float64
+	Reasons     []string // The evidence that scored, e.g. "amount", "reference"
+}
+
+// Invoices finds the open invoices a bank transaction may pay.
+type Invoices interface {
+	// Outstanding returns the company's invoices with an amount outstanding
+	// as of the date.
+	Outstanding(company string, filter reconciliation.Filter) ([]reconciliation.Open, error)
+}
+
+// LedgerInvoices finds the open invoices in the payment ledger entries the
+// function returns for a company.
+type LedgerInvoices func(company string) ([]ledger.PaymentLedgerEntry, error)
+
+// Outstanding implements Invoices.
+func (f LedgerInvoices) Outstanding(company string, filter reconciliation.Filter) ([]reconciliation.Open, error) {
+	entries, err := f(company)
+	if err != nil {
+		return nil, err
+	}
+	return reconciliation.Outstanding(entries, filter), nil
+}
+
+// Candidates scores the open invoices against a transaction and returns
+// those with any evidence, best first and oldest first among equals. A
+// deposit is matched with customer invoices, a withdrawal with supplier
+// invoices.
+func Candidates(t *BankTransaction, open []reconciliation.Open) []Match {
+	partyType := "Customer"
+	if t.Withdrawal > 0 {
+		partyType = "Supplier"
+	}
+	text := strings.ToLower(t.Reference + " " + t.Description)
+	payer := normalize(t.PartyName)
+
+	var matches []Match
+	for _, o := range open {
+		if o.PartyType != partyType {
+			continue
+		}
+		m := Match{
+			VoucherType: o.VoucherType, VoucherNo: o.VoucherNo, PartyType: o.PartyType, Party: o.Party,
+			Account: o.Account, Outstanding: o.Outstanding,
+		}
+		if ledger.Flt(t.Amount(), 2) == ledger.Flt(o.Outstanding, 2) {
+			m.Score += ScoreAmount
+			m.Reasons = append(m.Reasons, "amount")
+		}
+		if strings.Contains(text, strings.ToLower(o.VoucherNo)) {
+			m.Score += ScoreReference
+			m.Reasons = append(m.Reasons, "reference")
+		}
+		if party := normalize(o.Party); payer != "" && party != "" {
+			switch {
+			case payer == party:
+				m.Score += ScorePartyExact
+				m.Reasons = append(m.Reasons, "party")
+			case strings.Contains(payer, party) || strings.Contains(party, payer):
+				m.Score += ScorePartyPartial
+				m.Reasons = append(m.Reasons, "party")
+			}
+		}
+		if m.Score > 0 {
+			m.Score = min(ledger.Flt(m.Score, 2), 1)
+			matches = append(matches, m)
+		}
+	}
+	// Open invoices come oldest first; a stable sort keeps it among equals
+	slices.SortStableFunc(matches, func(a, b Match) int { return cmp.Compare(b.Score, a.Score) })
+	if len(matches) > MaxCandidates {
+		matches = matches[:MaxCandidates]
+	}
+	return matches
+}
+
+// certain returns the candidate to post without review: the best, when it
+// reaches the threshold and no other candidate scores as well.
+func certain(matches []Match, threshold float64) *Match {
+	if len(matches) == 0 || matches[0].Score < threshold {
+		return nil
+	}
+	if len(matches) > 1 && matches[1].Score == matches[0].Score {
+		return nil
+	}
+	return &matches[0]
+}
+
+// normalize lowercases a name and drops punctuation and extra spaces, so
+// that "ARVIND MILLS LTD." and "Arvind Mills Ltd" compare equal.
+func normalize(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			b.WriteRune(r)
+		case r == ' ' || r == '\t':
+			b.WriteRune(' ')
+		}
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
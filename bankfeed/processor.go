@@ -0,0 +1,219 @@
+package bankfeed
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/senguttuvang/erpnext-go/daterange"
+	"github.com/senguttuvang/erpnext-go/ledger"
+	"github.com/senguttuvang/erpnext-go/naming"
+	"github.com/senguttuvang/erpnext-go/paymententry"
+	"github.com/senguttuvang/erpnext-go/reconciliation"
+	"github.com/senguttuvang/erpnext-go/webhooks"
+)
+
+// Processor receives bank notifications, settles the invoices they
+// certainly pay and queues the rest for review.
+type Processor struct {
+	Engine    *ledger.Engine // Posts the payment entries
+	Names     *naming.Registry
+	Store     Store
+	Invoices  Invoices
+	Banks     []Bank
+	Threshold float64 // Score from which a match is posted; DefaultThreshold when zero
+}
+
+// Bank returns the named bank, or nil.
+func (p *Processor) Bank(name string) *Bank {
+	for i := range p.Banks {
+		if p.Banks[i].Name == name {
+			return &p.Banks[i]
+		}
+	}
+	return nil
+}
+
+// Receive handles one webhook of a bank: it checks the bank is valid and
+// the signature, maps the payload and processes each transaction. A
+// transaction received before, as banks resend notifications they are not
+// sure arrived, is returned as it stands and not processed again, even
+// when the deliveries race.
+//
+// Only failing to read or store the transactions is an error; a
+// transaction that failed so is left in review. A match that could not be
+// posted leaves its transaction in review, with the reason in LastError.
+func (p *Processor) Receive(bankName string, body []byte, signature string) ([]BankTransaction, error) {
+	if p.Engine == nil || p.Names == nil || p.Store == nil || p.Invoices == nil {
+		return nil, ErrNotConfigured
+	}
+	bank := p.Bank(bankName)
+	if bank == nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownBank, bankName)
+	}
+	if err := bank.Validate(); err != nil {
+		return nil, err
+	}
+	if !webhooks.Verify(bank.Secret, body, signature) {
+		return nil, ErrInvalidSignature
+	}
+	mapped, err := bank.Mapper.Map(body)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]BankTransaction, 0, len(mapped))
+	for _, t := range mapped {
+		t.Name = bank.Name + "-" + t.ID
+		t.Bank, t.Company, t.Account = bank.Name, bank.Company, bank.Account
+		t.Date = daterange.Civil(t.Date)
+		t.Status = Processing
+		created, err := p.Store.Create(&t)
+		if err != nil {
+			return result, err
+		}
+		if !created {
+			saved, err := p.Store.Get(t.Name)
+			if err != nil {
+				return result, err
+			}
+			result = append(result, *saved)
+			continue
+		}
+		if err := p.process(&t); err != nil {
+			t.Status, t.LastError = Unreconciled, err.Error()
+			return result, errors.Join(err, p.Store.Save(&t))
+		}
+		result = append(result, t)
+	}
+	return result, nil
+}
+
+// process matches a new transaction, claimed by Receive, posts it when
+// the match is certain, and saves it.
+func (p *Processor) process(t *BankTransaction) error {
+	t.Status = Unreconciled
+	if t.Amount() == 0 {
+		return p.Store.Save(t)
+	}
+	open, err := p.Invoices.Outstanding(t.Company, reconciliation.Filter{Company: t.Company, AsOf: t.Date})
+	if err != nil {
+		return err
+	}
+	t.Candidates = Candidates(t, open)
+	if m := certain(t.Candidates, cmp.Or(p.Threshold, DefaultThreshold)); m != nil {
+		if err := p.post(t, m); err != nil {
+			t.LastError = err.Error()
+		}
+	}
+	return p.Store.Save(t)
+}
+
+// Review returns the transactions waiting for review, oldest first.
+func (p *Processor) Review() ([]BankTransaction, error) {
+	if p.Store == nil {
+		return nil, ErrNotConfigured
+	}
+	return p.Store.List(Unreconciled)
+}
+
+// Accept settles a transaction in review with one of its candidates,
+// chosen by voucher number, and posts its payment entry. The transaction
+// is claimed before posting, so of two callers accepting it only one
+// posts; the other gets ErrNotInReview. A posting that fails puts it back
+// in review.
+func (p *Processor) Accept(name, voucherNo string) (*BankTransaction, error) {
+	t, err := p.inReview(name)
+	if err != nil {
+		return nil, err
+	}
+	i := slices.IndexFunc(t.Candidates, func(m Match) bool { return m.VoucherNo == voucherNo })
+	if i < 0 {
+		return nil, fmt.Errorf("%w: %s, %s", ErrNoCandidate, voucherNo, name)
+	}
+	t.Status = Processing
+	if err := p.claim(t); err != nil {
+		return nil, err
+	}
+	if err := p.post(t, &t.Candidates[i]); err != nil {
+		t.Status, t.LastError = Unreconciled, err.Error()
+		return nil, errors.Join(err, p.Store.Save(t))
+	}
+	return t, p.Store.Save(t)
+}
+
+// Dismiss takes a transaction out of review without posting anything, for
+// money that pays no invoice, such as bank charges or interest.
+func (p *Processor) Dismiss(name string) (*BankTransaction, error) {
+	t, err := p.inReview(name)
+	if err != nil {
+		return nil, err
+	}
+	t.Status, t.LastError = Dismissed, ""
+	if err := p.claim(t); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// claim saves a transaction read in review, unless someone else took it
+// out of review since.
+func (p *Processor) claim(t *BankTransaction) error {
+	saved, err := p.Store.SaveIf(t, Unreconciled)
+	if err != nil {
+		return err
+	}
+	if !saved {
+		return fmt.Errorf("%w: %s", ErrNotInReview, t.Name)
+	}
+	return nil
+}
+
+func (p *Processor) inReview(name string) (*BankTransaction, error) {
+	if p.Engine == nil || p.Names == nil || p.Store == nil {
+		return nil, ErrNotConfigured
+	}
+	t, err := p.Store.Get(name)
+	if err != nil {
+		return nil, err
+	}
+	if t == nil {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	if t.Status != Unreconciled {
+		return nil, fmt.Errorf("%w: %s is %s", ErrNotInReview, name, t.Status)
+	}
+	return t, nil
+}
+
+// post books the payment entry of a transaction allocated to the matched
+// invoice, up to its outstanding amount; the rest is an advance from, or
+// to, the party.
+func (p *Processor) post(t *BankTransaction, m *Match) error {
+	name, err := p.Names.NewName(paymententry.VoucherType, naming.Options{Date: t.Date})
+	if err != nil {
+		return err
+	}
+	date := t.Date
+	pe := &paymententry.PaymentEntry{
+		Name: name, Company: t.Company, PostingDate: t.Date, PaymentType: paymententry.Receive,
+		PartyType: m.PartyType, Party: m.Party, PaidFrom: m.Account, PaidTo: t.Account,
+		PaidAmount: t.Amount(), ReferenceNo: cmp.Or(t.Reference, t.ID), ReferenceDate: &date,
+		Remarks: fmt.Sprintf("Bank transaction %s matched to %s", t.Name, m.VoucherNo),
+		References: []paymententry.Reference{{
+			ReferenceDoctype: m.VoucherType, ReferenceName: m.VoucherNo,
+			OutstandingAmount: m.Outstanding, AllocatedAmount: min(t.Amount(), m.Outstanding),
+		}},
+	}
+	if t.Withdrawal > 0 {
+		// Payables are negative in the payment ledger
+		pe.PaymentType, pe.PaidFrom, pe.PaidTo = paymententry.Pay, t.Account, m.Account
+		pe.References[0].OutstandingAmount = -m.Outstanding
+	}
+	if err := pe.Submit(p.Engine); err != nil {
+		return err
+	}
+	t.Status, t.PaymentEntry, t.LastError = Reconciled, name, ""
+	return nil
+}
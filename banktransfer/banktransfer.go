@@ -0,0 +1,252 @@
+// Package banktransfer builds contra entries and internal fund transfers:
+// money the company moves between its own bank and cash accounts.
+//
+// A Transfer deposits cash into the bank, withdraws it, or moves it
+// between two banks. When the money arrives the day it leaves, it posts
+// one voucher debiting the receiving account and crediting the paying
+// one. When it spends time in transit, such as a wire that settles the
+// next day, it posts two: the withdrawal into an in-transit account, and
+// the arrival out of it, so the in-transit balance shows money that has
+// left one bank and not reached the other.
+//
+// Each bank side appears on a bank statement. Expectations lists the
+// statement lines the transfer should produce, for reconciliation, and
+// Clear records the date the bank cleared each side; a transfer is
+// reconciled once both its bank sides are cleared. Cash accounts have no
+// statement and need no clearance. Amounts are in company currency.
+//
+// Maps to: Journal Entry with voucher_type "Contra Entry"
+// (erpnext/accounts/doctype/journal_entry), Payment Entry with
+// payment_type "Internal Transfer", and the clearance_date set by Bank
+// Reconciliation Tool (erpnext/accounts/doctype/bank_reconciliation_tool)
+package banktransfer
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/ledger"
+)
+
+// Voucher type and subtype of transfer postings
+const (
+	VoucherType    = "Journal Entry"
+	VoucherSubtype = "Contra Entry"
+)
+
+// Transfer errors
+var (
+	ErrInvalidTransfer = errors.New("invalid transfer")
+	ErrNotBankSide     = errors.New("account is not a bank side of the transfer")
+	ErrEarlyClearance  = errors.New("clearance date is before the posting date")
+)
+
+// AccountType is the kind of account at an end of a transfer.
+//
+// Maps to: Account.account_type
+type AccountType string
+
+const (
+	Bank AccountType = "Bank"
+	Cash AccountType = "Cash"
+)
+
+// Account is an end of a transfer.
+type Account struct {
+	Name string
+	Type AccountType
+}
+
+// Transfer moves money between two of the company's accounts.
+type Transfer struct {
+	Name        string // Voucher of the withdrawal, or of the whole transfer
+	Company     string
+	PostingDate time.Time
+	From        Account
+	To          Account
+	Amount      float64
+	CostCenter  string
+
+	// Optional. When set, the money is booked to the in-transit account
+	// on PostingDate and reaches To on ArrivalDate, under ArrivalName.
+	InTransit   string
+	ArrivalName string
+	ArrivalDate time.Time
+
+	ReferenceNo string // Cheque, slip or wire reference
+	Remarks     string
+
+	// Set by Clear
+	FromClearance *time.Time
+	ToClearance   *time.Time
+}
+
+// Voucher is the GL map of one voucher of a transfer.
+type Voucher struct {
+	VoucherNo   string
+	PostingDate time.Time
+	GLMap       []ledger.GLEntry
+}
+
+// Validate checks the transfer's accounts, amount and dates.
+func (t *Transfer) Validate() error {
+	switch {
+	case t.Name == "" || t.Company == "":
+		return fmt.Errorf("%w: name and company are required", ErrInvalidTransfer)
+	case t.From.Name == "" || t.To.Name == "":
+		return fmt.Errorf("%w: %s: from and to accounts are required", ErrInvalidTransfer, t.Name)
+	case t.From.Name == t.To.Name:
+		return fmt.Errorf("%w: %s: from and to accounts are the same", ErrInvalidTransfer, t.Name)
+	case !t.From.Type.valid() || !t.To.Type.valid():
+		return fmt.Errorf("%w: %s: accounts must be bank or cash accounts", ErrInvalidTransfer, t.Name)
+	case t.Amount <= 0:
+		return fmt.Errorf("%w: %s: amount must be greater than zero", ErrInvalidTransfer, t.Name)
+	}
+	if t.InTransit == "" {
+		return nil
+	}
+	switch {
+	case t.InTransit == t.From.Name || t.InTransit == t.To.Name:
+		return fmt.Errorf("%w: %s: in-transit account must differ from the from and to accounts", ErrInvalidTransfer, t.Name)
+	case t.ArrivalName == "" || t.ArrivalName == t.Name:
+		return fmt.Errorf("%w: %s: the arrival needs its own voucher name", ErrInvalidTransfer, t.Name)
+	case ledger.CivilDate(t.ArrivalDate).Before(ledger.CivilDate(t.PostingDate)):
+		return fmt.Errorf("%w: %s: arrives before it leaves", ErrInvalidTransfer, t.Name)
+	}
+	return nil
+}
+
+func (a AccountType) valid() bool {
+	return a == Bank || a == Cash
+}
+
+// Vouchers validates the transfer and builds its vouchers: one for a
+// contra entry, the withdrawal and the arrival for money in transit.
+//
+// Maps to: JournalEntry.build_gl_map() for a Contra Entry
+func (t *Transfer) Vouchers() ([]Voucher, error) {
+	if err := t.Validate(); err != nil {
+		return nil, err
+	}
+	if t.InTransit == "" {
+		return []Voucher{t.voucher(t.Name, t.PostingDate, t.From.Name, t.To.Name)}, nil
+	}
+	return []Voucher{
+		t.voucher(t.Name, t.PostingDate, t.From.Name, t.InTransit),
+		t.voucher(t.ArrivalName, t.ArrivalDate, t.InTransit, t.To.Name),
+	}, nil
+}
+
+// voucher credits from and debits to.
+func (t *Transfer) voucher(name string, date time.Time, from, to string) Voucher {
+	remarks := t.Remarks
+	if remarks == "" {
+		remarks = fmt.Sprintf("Transfer of %.2f from %s to %s", t.Amount, t.From.Name, t.To.Name)
+	}
+	entry := ledger.GLEntry{
+		PostingDate:             date,
+		TransactionDate:         date,
+		VoucherType:             VoucherType,
+		VoucherNo:               name,
+		VoucherSubtype:          VoucherSubtype,
+		Company:                 t.Company,
+		CostCenter:              t.CostCenter,
+		Remarks:                 remarks,
+		TransactionExchangeRate: 1,
+		IsOpening:               ledger.IsOpeningNo,
+		IsAdvance:               ledger.IsAdvanceNo,
+	}
+	credit, debit := entry, entry
+	credit.Account, credit.Against = from, to
+	credit.Credit, credit.CreditInAccountCurrency, credit.CreditInTransactionCurrency = t.Amount, t.Amount, t.Amount
+	debit.Account, debit.Against = to, from
+	debit.Debit, debit.DebitInAccountCurrency, debit.DebitInTransactionCurrency = t.Amount, t.Amount, t.Amount
+	return Voucher{VoucherNo: name, PostingDate: date, GLMap: []ledger.GLEntry{debit, credit}}
+}
+
+// Post posts the transfer's vouchers through the engine.
+func (t *Transfer) Post(engine *ledger.Engine) error {
+	vouchers, err := t.Vouchers()
+	if err != nil {
+		return err
+	}
+	for _, v := range vouchers {
+		if err := engine.MakeGLEntries(v.GLMap, ledger.DefaultPostingOptions()); err != nil {
+			return fmt.Errorf("%s: %w", v.VoucherNo, err)
+		}
+	}
+	return nil
+}
+
+// Expectation is a line a transfer should produce on a bank statement.
+//
+// Maps to: the Bank Transaction a voucher is reconciled with
+type Expectation struct {
+	Account     string // The bank account
+	Date        time.Time
+	Deposit     float64
+	Withdrawal  float64
+	ReferenceNo string
+	VoucherType string
+	VoucherNo   string // The voucher to reconcile the line with
+}
+
+// Expectations returns the statement lines of the transfer's bank sides:
+// the withdrawal from a paying bank and the deposit into a receiving
+// one, on the date each voucher posts.
+func (t *Transfer) Expectations() []Expectation {
+	var lines []Expectation
+	if t.From.Type == Bank {
+		lines = append(lines, Expectation{
+			Account: t.From.Name, Date: t.PostingDate, Withdrawal: t.Amount,
+			ReferenceNo: t.ReferenceNo, VoucherType: VoucherType, VoucherNo: t.Name,
+		})
+	}
+	if t.To.Type == Bank {
+		name, date := t.arrival()
+		lines = append(lines, Expectation{
+			Account: t.To.Name, Date: date, Deposit: t.Amount,
+			ReferenceNo: t.ReferenceNo, VoucherType: VoucherType, VoucherNo: name,
+		})
+	}
+	return lines
+}
+
+// arrival returns the voucher and date that book the money into To.
+func (t *Transfer) arrival() (string, time.Time) {
+	if t.InTransit != "" {
+		return t.ArrivalName, t.ArrivalDate
+	}
+	return t.Name, t.PostingDate
+}
+
+// Clear records the date the bank cleared the side of the transfer on
+// account. A nil date removes the clearance, as blanking the clearance
+// date in Bank Reconciliation does.
+//
+// Maps to: update_clearance_date() in bank_reconciliation_tool.py
+func (t *Transfer) Clear(account string, date *time.Time) error {
+	var side **time.Time
+	var posted time.Time
+	switch {
+	case account == t.From.Name && t.From.Type == Bank:
+		side, posted = &t.FromClearance, t.PostingDate
+	case account == t.To.Name && t.To.Type == Bank:
+		side = &t.ToClearance
+		_, posted = t.arrival()
+	default:
+		return fmt.Errorf("%w: %s on %s", ErrNotBankSide, account, t.Name)
+	}
+	if date != nil && ledger.CivilDate(*date).Before(ledger.CivilDate(posted)) {
+		return fmt.Errorf("%w: %s on %s cleared %s, posted %s", ErrEarlyClearance, account, t.Name,
+			date.Format(time.DateOnly), posted.Format(time.DateOnly))
+	}
+	*side = date
+	return nil
+}
+
+// Reconciled reports whether every bank side of the transfer is cleared.
+func (t *Transfer) Reconciled() bool {
+	return (t.From.Type != Bank || t.FromClearance != nil) && (t.To.Type != Bank || t.ToClearance != nil)
+}
@@ -0,0 +1,130 @@
+package banktransfer
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/ledger"
+	"github.com/senguttuvang/erpnext-go/ledger/memstore"
+)
+
+var (
+	hdfc = Account{Name: "HDFC - ACME", Type: Bank}
+	sbi  = Account{Name: "SBI - ACME", Type: Bank}
+	cash = Account{Name: "Cash - ACME", Type: Cash}
+)
+
+func date(d int) time.Time {
+	return time.Date(2024, 4, d, 0, 0, 0, 0, time.UTC)
+}
+
+func on(d int) *time.Time {
+	t := date(d)
+	return &t
+}
+
+func balances(entries []ledger.GLEntry) map[string]float64 {
+	result := make(map[string]float64)
+	for _, e := range entries {
+		result[e.Account] += e.Debit - e.Credit
+	}
+	return result
+}
+
+func TestContraEntry(t *testing.T) {
+	glStore := memstore.NewGLStore()
+	engine := &ledger.Engine{GLStore: glStore}
+
+	withdrawal := &Transfer{Name: "JV-0001", Company: "ACME", PostingDate: date(1), From: hdfc, To: cash, Amount: 5000, ReferenceNo: "CHQ-104"}
+	if err := withdrawal.Post(engine); err != nil {
+		t.Fatal(err)
+	}
+	got := balances(glStore.All())
+	if got[hdfc.Name] != -5000 || got[cash.Name] != 5000 || len(glStore.All()) != 2 {
+		t.Errorf("balances = %v", got)
+	}
+	if e := glStore.All()[0]; e.VoucherSubtype != VoucherSubtype || e.Against != hdfc.Name || e.Remarks != "Transfer of 5000.00 from HDFC - ACME to Cash - ACME" {
+		t.Errorf("entry = %+v", e)
+	}
+
+	// Only the bank side is on a statement, and only it needs clearing
+	lines := withdrawal.Expectations()
+	if len(lines) != 1 || lines[0].Account != hdfc.Name || lines[0].Withdrawal != 5000 || lines[0].VoucherNo != "JV-0001" || lines[0].ReferenceNo != "CHQ-104" {
+		t.Errorf("expectations = %+v", lines)
+	}
+	if err := withdrawal.Clear(cash.Name, on(2)); !errors.Is(err, ErrNotBankSide) {
+		t.Errorf("Clear(cash) = %v", err)
+	}
+	if err := withdrawal.Clear(hdfc.Name, on(2)); err != nil || !withdrawal.Reconciled() {
+		t.Errorf("Clear() = %v, reconciled %v", err, withdrawal.Reconciled())
+	}
+}
+
+func TestTransferInTransit(t *testing.T) {
+	glStore := memstore.NewGLStore()
+	engine := &ledger.Engine{GLStore: glStore}
+
+	wire := &Transfer{
+		Name: "JV-0002", Company: "ACME", PostingDate: date(5), From: hdfc, To: sbi, Amount: 250000,
+		InTransit: "Funds in Transit - ACME", ArrivalName: "JV-0003", ArrivalDate: date(8),
+	}
+	vouchers, err := wire.Vouchers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(vouchers) != 2 || vouchers[0].VoucherNo != "JV-0002" || vouchers[1].VoucherNo != "JV-0003" || !vouchers[1].PostingDate.Equal(date(8)) {
+		t.Fatalf("vouchers = %+v", vouchers)
+	}
+	if got := balances(vouchers[0].GLMap); got[hdfc.Name] != -250000 || got[wire.InTransit] != 250000 {
+		t.Errorf("withdrawal = %v", got)
+	}
+	if err := wire.Post(engine); err != nil {
+		t.Fatal(err)
+	}
+	got := balances(glStore.All())
+	if got[hdfc.Name] != -250000 || got[sbi.Name] != 250000 || got[wire.InTransit] != 0 {
+		t.Errorf("balances = %v", got)
+	}
+
+	lines := wire.Expectations()
+	if len(lines) != 2 || lines[0].Withdrawal != 250000 || !lines[0].Date.Equal(date(5)) ||
+		lines[1].Account != sbi.Name || lines[1].Deposit != 250000 || !lines[1].Date.Equal(date(8)) || lines[1].VoucherNo != "JV-0003" {
+		t.Errorf("expectations = %+v", lines)
+	}
+
+	// Both sides must clear, each no earlier than it posted
+	if err := wire.Clear(sbi.Name, on(6)); !errors.Is(err, ErrEarlyClearance) {
+		t.Errorf("early Clear() = %v", err)
+	}
+	if err := wire.Clear(hdfc.Name, on(6)); err != nil || wire.Reconciled() {
+		t.Errorf("Clear(from) = %v, reconciled %v", err, wire.Reconciled())
+	}
+	if err := wire.Clear(sbi.Name, on(8)); err != nil || !wire.Reconciled() {
+		t.Errorf("Clear(to) = %v, reconciled %v", err, wire.Reconciled())
+	}
+	if err := wire.Clear(sbi.Name, nil); err != nil || wire.Reconciled() || wire.ToClearance != nil {
+		t.Errorf("uncleared = %v, reconciled %v", err, wire.Reconciled())
+	}
+}
+
+func TestValidate(t *testing.T) {
+	for name, change := range map[string]func(*Transfer){
+		"no name":          func(tr *Transfer) { tr.Name = "" },
+		"same account":     func(tr *Transfer) { tr.To = hdfc },
+		"not bank or cash": func(tr *Transfer) { tr.To.Type = "Receivable" },
+		"zero amount":      func(tr *Transfer) { tr.Amount = 0 },
+		"transit is from":  func(tr *Transfer) { tr.InTransit = hdfc.Name },
+		"no arrival name":  func(tr *Transfer) { tr.ArrivalName = "" },
+		"arrives early":    func(tr *Transfer) { tr.ArrivalDate = date(4) },
+	} {
+		tr := &Transfer{
+			Name: "JV-0002", Company: "ACME", PostingDate: date(5), From: hdfc, To: sbi, Amount: 100,
+			InTransit: "Funds in Transit - ACME", ArrivalName: "JV-0003", ArrivalDate: date(8),
+		}
+		change(tr)
+		if _, err := tr.Vouchers(); !errors.Is(err, ErrInvalidTransfer) {
+			t.Errorf("%s: Vouchers() = %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,183 @@
+// Package billing invoices delivered goods and billable hours in bulk.
+//
+// A billing run gathers the documents of a period that have not been
+// billed, delivery notes and timesheets, and groups them by customer: one
+// invoice per customer, per project, or per document, as the customer
+// prefers. Each group becomes a sales invoice built and calculated through
+// the taxcalc pipeline with the customer's tax template. The run saves the
+// invoices as drafts for review, or submits and posts them, and marks each
+// document billed by its invoice so no later run bills it again. Task runs
+// it as a background job, one chunk per customer.
+//
+// Maps to: the "Create Sales Invoice" bulk transaction of Delivery Note
+// and Timesheet (erpnext/utilities/bulk_transaction.py), with the
+// grouping of make_sales_invoice() in delivery_note.py
+package billing
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/daterange"
+	"github.com/senguttuvang/erpnext-go/salesinvoice"
+)
+
+// Billing errors
+var (
+	ErrNotConfigured   = errors.New("biller is missing its source, customers, names or invoice store")
+	ErrUnknownCustomer = errors.New("unknown customer")
+	ErrInvalidRun      = errors.New("invalid billing run")
+)
+
+// SourceType is the kind of document billed.
+type SourceType string
+
+const (
+	DeliveryNote SourceType = "Delivery Note"
+	Timesheet    SourceType = "Timesheet"
+)
+
+// Billable is a delivery note or timesheet with lines still to bill.
+type Billable struct {
+	Type     SourceType
+	Name     string
+	Company  string
+	Customer string
+	Date     time.Time // Posting date of the delivery note, or end of the timesheet
+	Project  string
+	Lines    []Line
+}
+
+// Line is one billable line: an item delivered, or an activity's hours at
+// its billing rate.
+type Line struct {
+	ItemCode      string
+	Description   string
+	Qty           float64 // Units delivered, or hours
+	UOM           string
+	Rate          float64 // Selling rate, or billing rate per hour
+	IncomeAccount string
+	CostCenter    string
+}
+
+// Ref identifies a billed document.
+type Ref struct {
+	Type SourceType
+	Name string
+}
+
+// Source gives the documents to bill and records what billed them.
+type Source interface {
+	// Unbilled returns the company's unbilled documents dated in the
+	// period.
+	Unbilled(company string, period daterange.Range) ([]Billable, error)
+
+	// MarkBilled records the invoice billing a document.
+	MarkBilled(ref Ref, invoice string) error
+}
+
+// Grouping is how a customer's documents are gathered into invoices.
+type Grouping string
+
+const (
+	PerCustomer Grouping = "Customer" // One invoice for the run
+	PerProject  Grouping = "Project"  // One invoice per project
+	PerDocument Grouping = "Document" // One invoice per delivery note or timesheet
+)
+
+// Customer is what a billing run needs to know of a customer.
+type Customer struct {
+	Name        string
+	DebitTo     string   // Receivable account
+	Grouping    Grouping // The biller's default when empty
+	TaxCategory string   // Selects the sales tax template
+	CreditDays  int      // Due date is the posting date plus these days
+}
+
+// Customers looks customers up.
+type Customers interface {
+	// Customer returns a company's customer, or nil when there is none.
+	Customer(company, name string) (*Customer, error)
+}
+
+// InvoiceStore keeps the invoices a run makes.
+type InvoiceStore interface {
+	Save(inv *salesinvoice.SalesInvoice) error
+}
+
+// MemorySource is an in-memory Source.
+type MemorySource struct {
+	mu     sync.Mutex
+	docs   []Billable
+	billed map[Ref]string
+}
+
+// Add adds unbilled documents.
+func (s *MemorySource) Add(docs ...Billable) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.docs = append(s.docs, docs...)
+}
+
+// Unbilled implements Source, in the order the documents were added.
+func (s *MemorySource) Unbilled(company string, period daterange.Range) ([]Billable, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var result []Billable
+	for _, d := range s.docs {
+		if _, done := s.billed[Ref{d.Type, d.Name}]; !done && d.Company == company && period.Contains(d.Date) {
+			result = append(result, d)
+		}
+	}
+	return result, nil
+}
+
+// MarkBilled implements Source.
+func (s *MemorySource) MarkBilled(ref Ref, invoice string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.billed == nil {
+		s.billed = make(map[Ref]string)
+	}
+	s.billed[ref] = invoice
+	return nil
+}
+
+// BilledBy returns the invoice billing a document, "" when unbilled.
+func (s *MemorySource) BilledBy(ref Ref) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.billed[ref]
+}
+
+// MemoryInvoices is an in-memory InvoiceStore.
+type MemoryInvoices struct {
+	mu       sync.Mutex
+	invoices map[string]*salesinvoice.SalesInvoice
+}
+
+// Save implements InvoiceStore.
+func (s *MemoryInvoices) Save(inv *salesinvoice.SalesInvoice) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.invoices == nil {
+		s.invoices = make(map[string]*salesinvoice.SalesInvoice)
+	}
+	s.invoices[inv.Name] = inv
+	return nil
+}
+
+// All returns the saved invoices in name order.
+func (s *MemoryInvoices) All() []*salesinvoice.SalesInvoice {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result := make([]*salesinvoice.SalesInvoice, 0, len(s.invoices))
+	for _, inv := range s.invoices {
+		result = append(result, inv)
+	}
+	slices.SortFunc(result, func(a, b *salesinvoice.SalesInvoice) int { return strings.Compare(a.Name, b.Name) })
+	return result
+}
@@ -0,0 +1,205 @@
+package billing
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/daterange"
+	"github.com/senguttuvang/erpnext-go/jobs"
+	"github.com/senguttuvang/erpnext-go/ledger"
+	"github.com/senguttuvang/erpnext-go/ledger/memstore"
+	"github.com/senguttuvang/erpnext-go/naming"
+	"github.com/senguttuvang/erpnext-go/salesinvoice"
+	"github.com/senguttuvang/erpnext-go/taxcalc"
+)
+
+func date(m time.Month, d int) time.Time {
+	return time.Date(2024, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+type customers map[string]*Customer
+
+func (c customers) Customer(company, name string) (*Customer, error) {
+	return c[name], nil
+}
+
+func hours(name, customer, project string, on time.Time, qty float64) Billable {
+	return Billable{
+		Type: Timesheet, Name: name, Company: "ACME", Customer: customer, Date: on, Project: project,
+		Lines: []Line{{ItemCode: "Consulting", Qty: qty, UOM: "Hour", Rate: 100, IncomeAccount: "Service Income - ACME", CostCenter: "Main - ACME"}},
+	}
+}
+
+func delivery(name, customer string, on time.Time, qty float64) Billable {
+	return Billable{
+		Type: DeliveryNote, Name: name, Company: "ACME", Customer: customer, Date: on,
+		Lines: []Line{{ItemCode: "Widget", Qty: qty, Rate: 25, IncomeAccount: "Sales - ACME", CostCenter: "Main - ACME"}},
+	}
+}
+
+func newBiller(t *testing.T) (*Biller, *MemorySource, *memstore.GLStore) {
+	t.Helper()
+	names := naming.NewRegistry(memstore.NewSeries())
+	if err := names.RegisterDefaults(); err != nil {
+		t.Fatal(err)
+	}
+	glStore := memstore.NewGLStore()
+	source := &MemorySource{}
+	source.Add(
+		hours("TS-1", "Globex", "Migration", date(5, 3), 10),
+		delivery("DN-1", "Globex", date(5, 6), 4),
+		hours("TS-2", "Globex", "Support", date(5, 10), 2),
+		hours("TS-3", "Initech", "", date(5, 12), 5),
+		delivery("DN-2", "Initech", date(5, 20), 8),
+		delivery("DN-3", "Umbrella", date(5, 21), 1),
+		delivery("DN-4", "Globex", date(6, 2), 100), // Next period
+	)
+	b := &Biller{
+		Source: source, Invoices: &MemoryInvoices{}, Names: names,
+		Engine: &ledger.Engine{GLStore: glStore, PaymentStore: memstore.NewPaymentStore()},
+		Customers: customers{
+			"Globex":   {Name: "Globex", DebitTo: "Debtors - ACME", Grouping: PerProject, TaxCategory: "Domestic", CreditDays: 30},
+			"Initech":  {Name: "Initech", DebitTo: "Debtors - ACME", TaxCategory: "Domestic"},
+			"Umbrella": {Name: "Umbrella", DebitTo: "Debtors - ACME", TaxCategory: "Export"},
+		},
+		TaxTemplates: []*taxcalc.TaxTemplate{
+			{Name: "GST 10%", Company: "ACME", TaxCategory: "Domestic", Taxes: []taxcalc.TaxRow{{AccountHead: "GST - ACME", ChargeType: taxcalc.OnNetTotal, Rate: 10}}},
+		},
+	}
+	return b, source, glStore
+}
+
+func TestBill(t *testing.T) {
+	b, source, glStore := newBiller(t)
+	run := Run{Company: "ACME", Period: daterange.Inclusive(date(5, 1), date(5, 31)), PostingDate: date(5, 31)}
+
+	drafts, err := b.Drafts(run)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Globex by project (the delivery note has none), Initech in one
+	// invoice, Umbrella untaxed
+	type summary struct {
+		customer, project string
+		sources           int
+		grandTotal        float64
+	}
+	want := []summary{
+		{"Globex", "Migration", 1, 1100},
+		{"Globex", "", 1, 110},
+		{"Globex", "Support", 1, 220},
+		{"Initech", "", 2, 770},
+		{"Umbrella", "", 1, 25},
+	}
+	if len(drafts) != len(want) {
+		t.Fatalf("Drafts() = %d invoices", len(drafts))
+	}
+	for i, d := range drafts {
+		got := summary{d.Invoice.Customer, d.Invoice.Project, len(d.Sources), d.Invoice.GrandTotal}
+		if got != want[i] {
+			t.Errorf("draft %d = %+v, want %+v", i, got, want[i])
+		}
+	}
+	if due := drafts[0].Invoice.DueDate; !due.Equal(date(6, 30)) {
+		t.Errorf("due date = %v", due)
+	}
+
+	run.Submit = true
+	made, err := b.Bill(run)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(made) != 5 || made[3].Invoice.Status != salesinvoice.Submitted || made[3].Invoice.Name == "" {
+		t.Fatalf("Bill() = %+v", made)
+	}
+	if got := source.BilledBy(Ref{DeliveryNote, "DN-2"}); got != made[3].Invoice.Name {
+		t.Errorf("DN-2 billed by %q", got)
+	}
+	debtors := 0.0
+	for _, e := range glStore.All() {
+		if e.Account == "Debtors - ACME" {
+			debtors += e.Debit - e.Credit
+		}
+	}
+	if debtors != 1100+110+220+770+25 {
+		t.Errorf("debtors = %v", debtors)
+	}
+
+	// Nothing is left to bill in the period
+	if again, err := b.Bill(run); err != nil || len(again) != 0 {
+		t.Errorf("Bill() again = %+v, %v", again, err)
+	}
+}
+
+// closedBooks refuses every posting.
+type closedBooks struct{}
+
+func (closedBooks) AllowPosting(voucherType, voucherNo string, cancel bool) error {
+	return ledger.ErrNotPermitted
+}
+
+func TestBillSubmitFails(t *testing.T) {
+	b, source, glStore := newBiller(t)
+	b.Engine.Gate = closedBooks{}
+	run := Run{Company: "ACME", Period: daterange.Inclusive(date(5, 1), date(5, 31)), PostingDate: date(5, 31), Submit: true}
+	made, err := b.Bill(run)
+	if !errors.Is(err, ledger.ErrNotPermitted) || len(made) != 0 || len(glStore.All()) != 0 {
+		t.Fatalf("Bill() = %d invoices, %v", len(made), err)
+	}
+	// The invoices are kept as drafts billing their documents, so the next
+	// run does not bill them again
+	drafts := b.Invoices.(*MemoryInvoices).All()
+	if len(drafts) != 5 || drafts[0].Status != salesinvoice.Draft {
+		t.Fatalf("saved %d invoices", len(drafts))
+	}
+	if got := source.BilledBy(Ref{DeliveryNote, "DN-2"}); got == "" {
+		t.Error("DN-2 left unbilled")
+	}
+	b.Engine.Gate = nil
+	if again, err := b.Bill(run); err != nil || len(again) != 0 {
+		t.Errorf("Bill() again = %+v, %v", again, err)
+	}
+}
+
+func TestBillErrors(t *testing.T) {
+	b, source, _ := newBiller(t)
+	source.Add(delivery("DN-9", "Hooli", date(5, 9), 1))
+	run := Run{Company: "ACME", Period: daterange.Inclusive(date(5, 1), date(5, 31)), PostingDate: date(5, 31)}
+	if _, err := b.Drafts(run); !errors.Is(err, ErrUnknownCustomer) {
+		t.Errorf("Drafts() unknown customer = %v", err)
+	}
+	if _, err := b.Drafts(Run{Company: "ACME"}); !errors.Is(err, ErrInvalidRun) {
+		t.Errorf("Drafts() without posting date = %v", err)
+	}
+	b.Engine = nil
+	run.Submit = true
+	if _, err := b.Bill(run); !errors.Is(err, ErrNotConfigured) {
+		t.Errorf("Bill() without engine = %v", err)
+	}
+}
+
+func TestTask(t *testing.T) {
+	b, source, _ := newBiller(t)
+	runner := &jobs.Runner{Queue: jobs.NewMemoryQueue(10), Store: &jobs.MemoryStore{}, Tasks: map[string]jobs.Task{Task: b}}
+	ctx := context.Background()
+	job, err := Submit(ctx, runner, Params{Company: "ACME", From: "2024-05-01", To: "2024-05-31", PostingDate: "2024-05-31"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := runner.Run(ctx, job.ID); err != nil {
+		t.Fatal(err)
+	}
+	job, _ = runner.Store.Get(job.ID)
+	if job.Status != jobs.Completed || len(job.Chunks) != 3 || job.Chunks[0].Key != "Globex" {
+		t.Errorf("job = %s %+v", job.Status, job.Chunks)
+	}
+	invoices := b.Invoices.(*MemoryInvoices).All()
+	if len(invoices) != 5 || invoices[0].Status != salesinvoice.Draft {
+		t.Errorf("invoices = %d, first %s", len(invoices), invoices[0].Status)
+	}
+	if source.BilledBy(Ref{DeliveryNote, "DN-4"}) != "" {
+		t.Error("DN-4 of June was billed")
+	}
+}
@@ -0,0 +1,198 @@
+package billing
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/daterange"
+	"github.com/senguttuvang/erpnext-go/ledger"
+	"github.com/senguttuvang/erpnext-go/naming"
+	"github.com/senguttuvang/erpnext-go/salesinvoice"
+	"github.com/senguttuvang/erpnext-go/taxcalc"
+)
+
+// Biller makes the invoices of billing runs.
+type Biller struct {
+	Source       Source
+	Customers    Customers
+	Invoices     InvoiceStore
+	Names        *naming.Registry
+	Engine       *ledger.Engine // Posts submitted invoices; only needed to submit
+	TaxTemplates []*taxcalc.TaxTemplate
+	Precision    taxcalc.PrecisionProvider
+	Grouping     Grouping // For customers without their own; PerCustomer when empty
+}
+
+// Run is one billing run.
+type Run struct {
+	Company     string
+	Period      daterange.Range // Documents dated in it are billed
+	PostingDate time.Time
+	Customer    string // Optional; bills only this customer
+	Submit      bool   // Submit and post the invoices; they are left as drafts otherwise
+}
+
+// Draft is an invoice of a run and the documents it bills.
+type Draft struct {
+	Invoice *salesinvoice.SalesInvoice
+	Sources []Ref
+}
+
+// Drafts builds the invoices of a run without naming, saving or posting
+// them: each customer's unbilled documents grouped as the customer
+// prefers, calculated with the customer's taxes. Invoices are in order of
+// customer, then of their first document's date.
+func (b *Biller) Drafts(run Run) ([]Draft, error) {
+	if b.Source == nil || b.Customers == nil {
+		return nil, ErrNotConfigured
+	}
+	if run.Company == "" || run.PostingDate.IsZero() {
+		return nil, fmt.Errorf("%w: company and posting date are required", ErrInvalidRun)
+	}
+	docs, err := b.Source.Unbilled(run.Company, run.Period)
+	if err != nil {
+		return nil, err
+	}
+	docs = slices.DeleteFunc(docs, func(d Billable) bool { return run.Customer != "" && d.Customer != run.Customer })
+	slices.SortStableFunc(docs, func(a, b Billable) int {
+		return cmp.Or(strings.Compare(a.Customer, b.Customer), a.Date.Compare(b.Date))
+	})
+
+	var drafts []Draft
+	for len(docs) > 0 {
+		n := 1
+		for n < len(docs) && docs[n].Customer == docs[0].Customer {
+			n++
+		}
+		group := docs[:n]
+		docs = docs[n:]
+		customer, err := b.Customers.Customer(run.Company, group[0].Customer)
+		if err != nil {
+			return nil, err
+		}
+		if customer == nil {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownCustomer, group[0].Customer)
+		}
+		for _, invoiceDocs := range b.group(customer, group) {
+			d, err := b.draft(run, customer, invoiceDocs)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", customer.Name, err)
+			}
+			drafts = append(drafts, d)
+		}
+	}
+	return drafts, nil
+}
+
+// Bill drafts a run and makes its invoices: each is named, saved, its
+// documents marked billed by it, and then submitted when the run says so.
+// It returns the invoices made. Errors are collected; one invoice failing
+// does not stop the others. An invoice that could not be saved leaves its
+// documents unbilled for the next run; one that could not be submitted is
+// kept as a draft that still bills them, to be submitted by hand.
+func (b *Biller) Bill(run Run) ([]Draft, error) {
+	if b.Invoices == nil || b.Names == nil || run.Submit && b.Engine == nil {
+		return nil, ErrNotConfigured
+	}
+	drafts, err := b.Drafts(run)
+	if err != nil {
+		return nil, err
+	}
+	var made []Draft
+	var errs []error
+	for _, d := range drafts {
+		if err := b.make(run, d); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", d.Invoice.Customer, err))
+			continue
+		}
+		made = append(made, d)
+	}
+	return made, errors.Join(errs...)
+}
+
+func (b *Biller) make(run Run, d Draft) error {
+	inv := d.Invoice
+	name, err := b.Names.NewName(salesinvoice.VoucherType, naming.Options{Date: run.PostingDate})
+	if err != nil {
+		return err
+	}
+	inv.Name = name
+	// Saved and marked before it posts, so an invoice that fails to submit
+	// stays a draft billing its documents rather than being billed again
+	if err := b.Invoices.Save(inv); err != nil {
+		return err
+	}
+	for _, ref := range d.Sources {
+		if err := b.Source.MarkBilled(ref, inv.Name); err != nil {
+			return err
+		}
+	}
+	if !run.Submit {
+		return nil
+	}
+	if err := inv.Submit(b.Engine, b.Precision); err != nil {
+		return fmt.Errorf("%s left as a draft: %w", inv.Name, err)
+	}
+	return b.Invoices.Save(inv)
+}
+
+// group splits a customer's documents into the invoices of its grouping.
+func (b *Biller) group(c *Customer, docs []Billable) [][]Billable {
+	switch cmp.Or(c.Grouping, b.Grouping, PerCustomer) {
+	case PerDocument:
+		groups := make([][]Billable, len(docs))
+		for i := range docs {
+			groups[i] = docs[i : i+1]
+		}
+		return groups
+	case PerProject:
+		var groups [][]Billable
+		for _, d := range docs {
+			i := slices.IndexFunc(groups, func(g []Billable) bool { return g[0].Project == d.Project })
+			if i < 0 {
+				groups = append(groups, nil)
+				i = len(groups) - 1
+			}
+			groups[i] = append(groups[i], d)
+		}
+		return groups
+	default:
+		return [][]Billable{docs}
+	}
+}
+
+// draft builds and calculates the invoice of a group of documents.
+func (b *Biller) draft(run Run, c *Customer, docs []Billable) (Draft, error) {
+	date := daterange.Civil(run.PostingDate)
+	due := date.AddDate(0, 0, c.CreditDays)
+	inv := &salesinvoice.SalesInvoice{
+		Company: run.Company, Customer: c.Name, PostingDate: date, DueDate: &due, DebitTo: c.DebitTo,
+		Status:   salesinvoice.Draft,
+		Document: taxcalc.Document{ConversionRate: 1, TaxCategory: c.TaxCategory},
+	}
+	d := Draft{Invoice: inv}
+	names := make([]string, len(docs))
+	for i, doc := range docs {
+		d.Sources = append(d.Sources, Ref{doc.Type, doc.Name})
+		names[i] = doc.Name
+		for _, l := range doc.Lines {
+			inv.Items = append(inv.Items, &taxcalc.LineItem{
+				ItemCode: l.ItemCode, Description: l.Description, Qty: l.Qty, UOM: l.UOM, Rate: l.Rate,
+				IncomeAccount: l.IncomeAccount, CostCenter: l.CostCenter,
+			})
+		}
+	}
+	if !slices.ContainsFunc(docs, func(doc Billable) bool { return doc.Project != docs[0].Project }) {
+		inv.Project = docs[0].Project
+	}
+	inv.Remarks = "Billing of " + strings.Join(names, ", ")
+	inv.SetTaxes(b.TaxTemplates)
+	if err := inv.Calculate(b.Precision); err != nil {
+		return d, err
+	}
+	return d, nil
+}
@@ -0,0 +1,86 @@
+package billing
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/daterange"
+	"github.com/senguttuvang/erpnext-go/jobs"
+	"github.com/senguttuvang/erpnext-go/ledger"
+)
+
+// Task is the job type of billing runs. The application registers a
+// Biller under it with the jobs.Runner and queues runs with Submit.
+const Task = "billing-run"
+
+// Params are the parameters of a Task job. Dates are YYYY-MM-DD; From and
+// To are both included.
+type Params struct {
+	Company     string `json:"company"`
+	From        string `json:"from"`
+	To          string `json:"to"`
+	PostingDate string `json:"posting_date"`
+	Submit      bool   `json:"submit"`
+}
+
+// Submit queues a billing run.
+func Submit(ctx context.Context, runner *jobs.Runner, params Params) (*jobs.Job, error) {
+	return runner.Submit(ctx, Task, params)
+}
+
+// Plan implements jobs.Task with one chunk per customer to bill, so a
+// customer whose invoices fail can be retried on their own.
+func (b *Biller) Plan(_ context.Context, params json.RawMessage) ([]string, error) {
+	run, err := parseParams(params)
+	if err != nil {
+		return nil, err
+	}
+	drafts, err := b.Drafts(run)
+	if err != nil {
+		return nil, err
+	}
+	var customers []string
+	for _, d := range drafts {
+		if !slices.Contains(customers, d.Invoice.Customer) {
+			customers = append(customers, d.Invoice.Customer)
+		}
+	}
+	return customers, nil
+}
+
+// Run implements jobs.Task, billing one customer. Documents billed by an
+// earlier attempt are no longer unbilled, so a retry only makes the
+// invoices that failed.
+func (b *Biller) Run(_ context.Context, params json.RawMessage, customer string) error {
+	run, err := parseParams(params)
+	if err != nil {
+		return err
+	}
+	run.Customer = customer
+	_, err = b.Bill(run)
+	return err
+}
+
+func parseParams(raw json.RawMessage) (Run, error) {
+	var p Params
+	if err := json.Unmarshal(raw, &p); err != nil {
+		return Run{}, err
+	}
+	run := Run{Company: p.Company, Submit: p.Submit}
+	var dates [3]time.Time
+	for i, s := range []string{p.From, p.To, p.PostingDate} {
+		if s == "" && i < 2 {
+			continue // Unbounded
+		}
+		d, err := ledger.ParseDate(s)
+		if err != nil {
+			return Run{}, fmt.Errorf("%w: date %q", ErrInvalidRun, s)
+		}
+		dates[i] = d
+	}
+	run.Period, run.PostingDate = daterange.Inclusive(dates[0], dates[1]), dates[2]
+	return run, nil
+}
@@ -0,0 +1,111 @@
+// Package branch keeps the books of a company with several branches, each
+// reporting its own profit and trial balance.
+//
+// A branch is an accounting dimension carried on GL entries as the custom
+// field Fieldname; add it to ledger.Engine.MergeFields so that entries of
+// different branches are not merged. Each branch has a default cost center
+// and bank account: ApplyDefaults fills the cost center of a branch's
+// entries that have none. Transfer moves money between branches through
+// the head office clearing account, so that each branch balances on its
+// own. TrialBalance and Statements report a branch as if it were a
+// company; ProfitAndLossReport sets the branches side by side.
+//
+// Maps to: the Branch doctype added as an Accounting Dimension, with the
+// dimension defaults of Accounting Dimension Detail
+package branch
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/senguttuvang/erpnext-go/ledger"
+)
+
+// Fieldname is the custom field of GL entries holding their branch.
+const Fieldname = "branch"
+
+// Branch errors
+var (
+	ErrInvalidBranch = errors.New("invalid branch")
+	ErrUnknownBranch = errors.New("unknown branch")
+	ErrNotConfigured = errors.New("branch accounting is not configured")
+)
+
+// Branch is an office of a company that keeps its own accounts.
+type Branch struct {
+	Name        string
+	Company     string
+	CostCenter  string // Default cost center of the branch's entries
+	BankAccount string // Default account its receipts and payments go through
+}
+
+// Validate checks the branch.
+func (b *Branch) Validate() error {
+	if b.Name == "" || b.Company == "" {
+		return fmt.Errorf("%w: name and company are required", ErrInvalidBranch)
+	}
+	return nil
+}
+
+// Branches are the branches of a deployment.
+type Branches []Branch
+
+// Get returns the named branch, or nil.
+func (bs Branches) Get(name string) *Branch {
+	for i := range bs {
+		if bs[i].Name == name {
+			return &bs[i]
+		}
+	}
+	return nil
+}
+
+// Of returns the branch of a GL entry, "" when it has none.
+func Of(e ledger.GLEntry) string {
+	return e.Custom.String(Fieldname)
+}
+
+// Set sets the branch of a GL entry.
+func Set(e *ledger.GLEntry, branch string) {
+	e.Custom.Set(Fieldname, branch)
+}
+
+// ApplyDefaults returns a copy of glMap in which the entries of a branch
+// without a cost center take the branch's default. Entries without a
+// branch are left as they are; a branch that is unknown or of another
+// company is an error.
+//
+// Maps to: the default dimension values of Accounting Dimension Detail,
+// applied when the row's value is empty
+func (bs Branches) ApplyDefaults(glMap []ledger.GLEntry) ([]ledger.GLEntry, error) {
+	out := make([]ledger.GLEntry, len(glMap))
+	for i, e := range glMap {
+		out[i] = e
+		name := Of(e)
+		if name == "" {
+			continue
+		}
+		b := bs.Get(name)
+		if b == nil {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownBranch, name)
+		}
+		if b.Company != e.Company {
+			return nil, fmt.Errorf("%w: %s belongs to %s, not %s", ErrInvalidBranch, name, b.Company, e.Company)
+		}
+		if out[i].CostCenter == "" {
+			out[i].CostCenter = b.CostCenter
+		}
+	}
+	return out, nil
+}
+
+// Entries returns the entries of a branch.
+func Entries(gl []ledger.GLEntry, branch string) []ledger.GLEntry {
+	var out []ledger.GLEntry
+	for _, e := range gl {
+		if Of(e) == branch {
+			out = append(out, e)
+		}
+	}
+	return out
+}
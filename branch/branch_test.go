@@ -0,0 +1,155 @@
+package branch
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/ledger"
+	"github.com/senguttuvang/erpnext-go/ledger/memstore"
+	"github.com/senguttuvang/erpnext-go/reportio"
+	"github.com/senguttuvang/erpnext-go/statement"
+)
+
+var branches = Branches{
+	{Name: "Chennai", Company: "ABC", CostCenter: "Chennai - ABC", BankAccount: "HDFC Chennai - ABC"},
+	{Name: "Pune", Company: "ABC", CostCenter: "Pune - ABC", BankAccount: "HDFC Pune - ABC"},
+	{Name: "Lagos", Company: "XYZ"},
+}
+
+var accounts = []ledger.Account{
+	{Name: "HDFC Chennai - ABC", Company: "ABC", RootType: "Asset"},
+	{Name: "HDFC Pune - ABC", Company: "ABC", RootType: "Asset"},
+	{Name: "Head Office Clearing - ABC", Company: "ABC", RootType: "Liability"},
+	{Name: "Sales - ABC", Company: "ABC", RootType: "Income"},
+	{Name: "Rent - ABC", Company: "ABC", RootType: "Expense"},
+}
+
+func date(d int) time.Time {
+	return time.Date(2026, 4, d, 0, 0, 0, 0, time.UTC)
+}
+
+func entry(branch, account string, debit, credit float64, voucherNo string, day int) ledger.GLEntry {
+	e := ledger.GLEntry{
+		PostingDate: date(day), Company: "ABC", Account: account, VoucherType: "Journal Entry", VoucherNo: voucherNo,
+		Debit: debit, Credit: credit, DebitInAccountCurrency: debit, CreditInAccountCurrency: credit,
+	}
+	if branch != "" {
+		Set(&e, branch)
+	}
+	return e
+}
+
+func TestApplyDefaults(t *testing.T) {
+	glMap := []ledger.GLEntry{
+		entry("Chennai", "Sales - ABC", 0, 100, "JV-1", 1),
+		entry("Chennai", "HDFC Chennai - ABC", 100, 0, "JV-1", 1),
+		entry("", "Rent - ABC", 10, 0, "JV-1", 1),
+	}
+	glMap[1].CostCenter = "Main - ABC"
+	out, err := branches.ApplyDefaults(glMap)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out[0].CostCenter != "Chennai - ABC" || out[1].CostCenter != "Main - ABC" || out[2].CostCenter != "" || glMap[0].CostCenter != "" {
+		t.Errorf("ApplyDefaults() = %+v", out)
+	}
+
+	glMap[0].Custom.Set(Fieldname, "Lagos")
+	if _, err := branches.ApplyDefaults(glMap); !errors.Is(err, ErrInvalidBranch) {
+		t.Errorf("other company = %v", err)
+	}
+}
+
+func TestTransferAndReports(t *testing.T) {
+	glStore := memstore.NewGLStore()
+	engine := &ledger.Engine{GLStore: glStore}
+	transfer := Transfer{Name: "JV-T1", Company: "ABC", PostingDate: date(5), From: "Chennai", To: "Pune", Amount: 400, ClearingAccount: "Head Office Clearing - ABC"}
+	if err := transfer.Post(engine, branches); !errors.Is(err, ErrNotConfigured) {
+		t.Fatalf("Post() without merge field = %v", err)
+	}
+	engine.MergeFields = []string{Fieldname}
+
+	post := func(glMap ...ledger.GLEntry) {
+		t.Helper()
+		if err := engine.MakeGLEntries(glMap, ledger.DefaultPostingOptions()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	post(entry("Chennai", "HDFC Chennai - ABC", 1000, 0, "JV-1", 1), entry("Chennai", "Sales - ABC", 0, 1000, "JV-1", 1))
+	post(entry("Pune", "HDFC Pune - ABC", 500, 0, "JV-2", 2), entry("Pune", "Sales - ABC", 0, 500, "JV-2", 2))
+	post(entry("Pune", "Rent - ABC", 300, 0, "JV-3", 3), entry("Pune", "HDFC Pune - ABC", 0, 300, "JV-3", 3))
+	if err := transfer.Post(engine, branches); err != nil {
+		t.Fatal(err)
+	}
+	entries, _ := glStore.GetByVoucher(VoucherType, "JV-T1")
+	if len(entries) != 4 || entries[1].Account != "HDFC Chennai - ABC" || entries[1].CostCenter != "Chennai - ABC" || Of(entries[3]) != "Pune" {
+		t.Errorf("transfer entries = %+v", entries)
+	}
+
+	// Each branch balances; the clearing account nets out for the company
+	f := ledger.GLFilter{Company: "ABC", From: date(1), To: date(30)}
+	var clearing float64
+	for _, b := range []string{"Chennai", "Pune"} {
+		rows, err := TrialBalance(glStore.All(), b, f, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var debit, credit float64
+		for _, r := range rows {
+			debit += r.ClosingDebit
+			credit += r.ClosingCredit
+			if r.Account == "Head Office Clearing - ABC" {
+				clearing += r.ClosingDebit - r.ClosingCredit
+			}
+		}
+		if ledger.Flt(debit-credit, 2) != 0 {
+			t.Errorf("%s trial balance is out by %.2f: %+v", b, debit-credit, rows)
+		}
+	}
+	if clearing != 0 {
+		t.Errorf("clearing nets to %.2f", clearing)
+	}
+
+	s, err := Statement(statement.ProfitAndLoss(), accounts, glStore.All(), "Pune", f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l, _ := s.Line("NetProfit"); l.Value != 200 {
+		t.Errorf("Pune net profit = %.2f, want 200", l.Value)
+	}
+
+	report, err := ProfitAndLossReport(accounts, glStore.All(), []string{"Chennai", "Pune"}, f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := reportio.WriteCSV(&buf, report); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"Line,Chennai,Pune,Total", "Net Profit / Loss,1000.00,200.00,1200.00", "Net Margin %,100.00,40.00,80.00"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("CSV lacks %q:\n%s", want, buf.String())
+		}
+	}
+}
+
+func TestTransferValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		t    Transfer
+		want error
+	}{
+		{"to itself", Transfer{Name: "T", Company: "ABC", ClearingAccount: "HO", From: "Pune", To: "Pune", Amount: 1}, ErrInvalidBranch},
+		{"unknown", Transfer{Name: "T", Company: "ABC", ClearingAccount: "HO", From: "Pune", To: "Delhi", Amount: 1}, ErrUnknownBranch},
+		{"other company", Transfer{Name: "T", Company: "ABC", ClearingAccount: "HO", From: "Pune", To: "Lagos", Amount: 1}, ErrInvalidBranch},
+		{"no amount", Transfer{Name: "T", Company: "ABC", ClearingAccount: "HO", From: "Pune", To: "Chennai"}, ErrInvalidBranch},
+	}
+	for _, tt := range tests {
+		if err := tt.t.Validate(branches); !errors.Is(err, tt.want) {
+			t.Errorf("%s: Validate() = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package branch
+
+import (
+	"github.com/senguttuvang/erpnext-go/columnar"
+	"github.com/senguttuvang/erpnext-go/ledger"
+	"github.com/senguttuvang/erpnext-go/reportio"
+	"github.com/senguttuvang/erpnext-go/statement"
+)
+
+// TrialBalance returns the trial balance of a branch: the opening balance,
+// movement and closing balance of every account it has entries on.
+//
+// Maps to: the trial balance report filtered by the Branch dimension
+func TrialBalance(gl []ledger.GLEntry, branch string, f ledger.GLFilter, tree *ledger.AccountTree) ([]columnar.TrialBalanceRow, error) {
+	return columnar.FromEntries(Entries(gl, branch)).TrialBalance(f, tree)
+}
+
+// Statement fills a financial statement layout with the entries of a
+// branch, as statement.Generate does for a company.
+func Statement(layout *statement.Layout, accounts []ledger.Account, gl []ledger.GLEntry, branch string, f ledger.GLFilter) (*statement.Statement, error) {
+	return statement.Generate(layout, accounts, Entries(gl, branch), f)
+}
+
+// ProfitAndLossReport fills the profit and loss layout for each branch and
+// sets them side by side, one column per branch, with a total column. The
+// total is the statement of all the branches' entries together, so
+// formula lines such as margins are right for it too. Entries without a
+// branch are in none of the columns.
+//
+// Maps to: the Profit and Loss Statement report with the Branch dimension
+// as its columns
+func ProfitAndLossReport(accounts []ledger.Account, gl []ledger.GLEntry, branches []string, f ledger.GLFilter) (reportio.Report, error) {
+	layout := statement.ProfitAndLoss()
+	cols := []reportio.Column{{Fieldname: "line", Label: "Line", Fieldtype: reportio.Data}}
+	statements := make([]*statement.Statement, 0, len(branches)+1)
+	var all []ledger.GLEntry
+	for _, b := range branches {
+		entries := Entries(gl, b)
+		all = append(all, entries...)
+		s, err := statement.Generate(layout, accounts, entries, f)
+		if err != nil {
+			return nil, err
+		}
+		statements = append(statements, s)
+		cols = append(cols, reportio.Column{Fieldname: b, Label: b, Fieldtype: reportio.Currency})
+	}
+	total, err := statement.Generate(layout, accounts, all, f)
+	if err != nil {
+		return nil, err
+	}
+	statements = append(statements, total)
+	cols = append(cols, reportio.Column{Fieldname: "total", Label: "Total", Fieldtype: reportio.Currency})
+
+	table := &reportio.Table{Name: "Profit and Loss by Branch", Cols: cols}
+	for i, line := range total.Lines {
+		row := []any{line.Label}
+		for _, s := range statements {
+			if line.Heading {
+				row = append(row, nil)
+			} else {
+				row = append(row, s.Lines[i].Value)
+			}
+		}
+		table.Data = append(table.Data, row)
+	}
+	return table, nil
+}
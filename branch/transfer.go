@@ -0,0 +1,124 @@
+package branch
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/ledger"
+)
+
+// Voucher type and subtype of inter-branch transfers
+const (
+	VoucherType    = "Journal Entry"
+	VoucherSubtype = "Inter Branch Transfer"
+)
+
+// Transfer moves money from one branch's bank account to another's. Each
+// branch books its side against the head office clearing account, so the
+// branch sending owes head office less and the one receiving owes it
+// more, and both stay balanced; for the company the clearing account nets
+// to nothing.
+//
+// Maps to: a Journal Entry between branch dimensions through a clearing
+// account, as head office and branch accounting is set up in ERPNext
+type Transfer struct {
+	Name            string
+	Company         string
+	PostingDate     time.Time
+	From            string // Branch paying
+	To              string // Branch receiving
+	Amount          float64
+	FromAccount     string // The paying branch's bank account when empty
+	ToAccount       string // The receiving branch's bank account when empty
+	ClearingAccount string // Head office clearing account
+	Remarks         string
+}
+
+// Validate checks the transfer against the branches.
+func (t *Transfer) Validate(branches Branches) error {
+	switch {
+	case t.Name == "" || t.Company == "" || t.ClearingAccount == "":
+		return fmt.Errorf("%w: name, company and clearing account are required", ErrInvalidBranch)
+	case t.From == t.To:
+		return fmt.Errorf("%w: %s: transfer from %s to itself", ErrInvalidBranch, t.Name, t.From)
+	case t.Amount <= 0:
+		return fmt.Errorf("%w: %s: amount must be greater than zero", ErrInvalidBranch, t.Name)
+	}
+	for _, name := range []string{t.From, t.To} {
+		b := branches.Get(name)
+		if b == nil {
+			return fmt.Errorf("%w: %s", ErrUnknownBranch, name)
+		}
+		if b.Company != t.Company {
+			return fmt.Errorf("%w: %s belongs to %s, not %s", ErrInvalidBranch, name, b.Company, t.Company)
+		}
+	}
+	if t.account(branches.Get(t.From), t.FromAccount) == "" || t.account(branches.Get(t.To), t.ToAccount) == "" {
+		return fmt.Errorf("%w: %s: no bank account for %s or %s", ErrInvalidBranch, t.Name, t.From, t.To)
+	}
+	return nil
+}
+
+// GLMap returns the transfer's GL entries, with the branches' default bank
+// accounts and cost centers. The transfer must be valid.
+func (t *Transfer) GLMap(branches Branches) []ledger.GLEntry {
+	date := ledger.CivilDate(t.PostingDate)
+	remarks := t.Remarks
+	if remarks == "" {
+		remarks = fmt.Sprintf("Transfer from %s to %s", t.From, t.To)
+	}
+	from, to := branches.Get(t.From), branches.Get(t.To)
+
+	fromClearing := t.newGLEntry(date, from, t.ClearingAccount, remarks)
+	fromClearing.Debit, fromClearing.DebitInAccountCurrency, fromClearing.DebitInTransactionCurrency = t.Amount, t.Amount, t.Amount
+	fromBank := t.newGLEntry(date, from, t.account(from, t.FromAccount), remarks)
+	fromBank.Credit, fromBank.CreditInAccountCurrency, fromBank.CreditInTransactionCurrency = t.Amount, t.Amount, t.Amount
+	toBank := t.newGLEntry(date, to, t.account(to, t.ToAccount), remarks)
+	toBank.Debit, toBank.DebitInAccountCurrency, toBank.DebitInTransactionCurrency = t.Amount, t.Amount, t.Amount
+	toClearing := t.newGLEntry(date, to, t.ClearingAccount, remarks)
+	toClearing.Credit, toClearing.CreditInAccountCurrency, toClearing.CreditInTransactionCurrency = t.Amount, t.Amount, t.Amount
+	return []ledger.GLEntry{fromClearing, fromBank, toBank, toClearing}
+}
+
+// Post validates the transfer and posts it. The engine must keep branches
+// apart when merging, or the two clearing entries would cancel out.
+func (t *Transfer) Post(engine *ledger.Engine, branches Branches) error {
+	if !slices.Contains(engine.MergeFields, Fieldname) {
+		return fmt.Errorf("%w: engine does not merge by %s", ErrNotConfigured, Fieldname)
+	}
+	if err := t.Validate(branches); err != nil {
+		return err
+	}
+	if err := engine.MakeGLEntries(t.GLMap(branches), ledger.DefaultPostingOptions()); err != nil {
+		return fmt.Errorf("%s: %w", t.Name, err)
+	}
+	return nil
+}
+
+func (t *Transfer) account(b *Branch, account string) string {
+	if b == nil {
+		return account
+	}
+	return cmp.Or(account, b.BankAccount)
+}
+
+func (t *Transfer) newGLEntry(date time.Time, b *Branch, account, remarks string) ledger.GLEntry {
+	e := ledger.GLEntry{
+		PostingDate:             date,
+		TransactionDate:         date,
+		Account:                 account,
+		VoucherType:             VoucherType,
+		VoucherNo:               t.Name,
+		VoucherSubtype:          VoucherSubtype,
+		Company:                 t.Company,
+		CostCenter:              b.CostCenter,
+		TransactionExchangeRate: 1,
+		IsOpening:               ledger.IsOpeningNo,
+		IsAdvance:               ledger.IsAdvanceNo,
+		Remarks:                 remarks,
+	}
+	Set(&e, b.Name)
+	return e
+}
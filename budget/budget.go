@@ -0,0 +1,273 @@
+// Package budget enforces budgets of expense accounts per cost center or
+// project, counting not only what the ledger has booked but also what
+// open material requests and purchase orders have committed.
+//
+// Validator implements ledger.BudgetValidator for the Engine; buying
+// documents are checked with ValidateCommitment before their commitment
+// is saved.
+//
+// Migrated from: erpnext/accounts/doctype/budget/budget.py
+package budget
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/daterange"
+	"github.com/senguttuvang/erpnext-go/ledger"
+)
+
+// Budget errors
+var (
+	ErrInvalidBudget = errors.New("invalid budget")
+	ErrNotFound      = errors.New("commitment not found")
+)
+
+// Against is the dimension a budget is set for.
+type Against string
+
+const (
+	CostCenter Against = "Cost Center"
+	Project    Against = "Project"
+)
+
+// Line is the budget of one expense account.
+type Line struct {
+	Account string
+	Amount  float64
+}
+
+// Budget limits the spending of a cost center or project over a fiscal
+// year. Commitments count against it only for the document types it
+// applies to.
+//
+// Maps to: Budget doctype
+type Budget struct {
+	Name       string
+	Company    string
+	FiscalYear string
+	Period     daterange.Range // The fiscal year
+	Against    Against
+	Dimension  string // The cost center or project
+	Accounts   []Line
+
+	ApplicableOnMaterialRequest     bool
+	ActionIfMaterialRequestExceeded ledger.Severity // Stop when empty
+	ApplicableOnPurchaseOrder       bool
+	ActionIfPurchaseOrderExceeded   ledger.Severity // Stop when empty
+}
+
+// Validate checks the budget.
+//
+// Maps to: Budget.validate()
+func (b *Budget) Validate() error {
+	if b.Against != CostCenter && b.Against != Project {
+		return fmt.Errorf("%w: %s: budget against %q", ErrInvalidBudget, b.Name, b.Against)
+	}
+	if b.Dimension == "" {
+		return fmt.Errorf("%w: %s: %s is required", ErrInvalidBudget, b.Name, b.Against)
+	}
+	seen := map[string]bool{}
+	for _, l := range b.Accounts {
+		if seen[l.Account] {
+			return fmt.Errorf("%w: %s: account %s entered more than once", ErrInvalidBudget, b.Name, l.Account)
+		}
+		if l.Amount < 0 {
+			return fmt.Errorf("%w: %s: negative budget for %s", ErrInvalidBudget, b.Name, l.Account)
+		}
+		seen[l.Account] = true
+	}
+	return nil
+}
+
+// amount returns the budget of an account, if the budget covers it.
+func (b *Budget) amount(account string) (float64, bool) {
+	for _, l := range b.Accounts {
+		if l.Account == account {
+			return l.Amount, true
+		}
+	}
+	return 0, false
+}
+
+// covers reports whether spending of a company on a date by dimensions
+// falls under the budget.
+func (b *Budget) covers(company string, date time.Time, costCenter, project string) bool {
+	dimension := costCenter
+	if b.Against == Project {
+		dimension = project
+	}
+	return b.Company == company && b.Dimension == dimension && b.Period.Contains(date)
+}
+
+// appliesTo reports whether commitments of a kind count against the budget.
+func (b *Budget) appliesTo(kind Kind) bool {
+	switch kind {
+	case MaterialRequest:
+		return b.ApplicableOnMaterialRequest
+	case PurchaseOrder:
+		return b.ApplicableOnPurchaseOrder
+	}
+	return false
+}
+
+// action returns what exceeding the budget does to a document of a kind.
+func (b *Budget) action(kind Kind) ledger.Severity {
+	a := b.ActionIfPurchaseOrderExceeded
+	if kind == MaterialRequest {
+		a = b.ActionIfMaterialRequestExceeded
+	}
+	if a == "" {
+		return ledger.SeverityError
+	}
+	return a
+}
+
+// Actuals returns the expense booked to an account.
+type Actuals interface {
+	// ActualExpense returns the net debit booked to the account by the
+	// company in the period, for the cost center or project.
+	ActualExpense(company, account string, against Against, dimension string, period daterange.Range) (float64, error)
+}
+
+// Entries computes actual expense from GL entries.
+type Entries []ledger.GLEntry
+
+// ActualExpense implements Actuals.
+func (es Entries) ActualExpense(company, account string, against Against, dimension string, period daterange.Range) (float64, error) {
+	var total float64
+	cancelled := ledger.CancelledEntries(es)
+	for i, e := range es {
+		d := e.CostCenter
+		if against == Project {
+			d = e.Project
+		}
+		if !cancelled[i] && e.Company == company && e.Account == account && d == dimension && period.Contains(e.PostingDate) {
+			total += e.Debit - e.Credit
+		}
+	}
+	return ledger.Flt(total, 2), nil
+}
+
+// Validator checks spending against budgets. Commitments is optional;
+// without it only actual expense counts.
+//
+// Maps to: validate_expense_against_budget() in budget.py
+type Validator struct {
+	Budgets     []Budget
+	Actuals     Actuals
+	Commitments CommitmentStore
+}
+
+// spend is new spending on one account under one budget.
+type spend struct {
+	budget  *Budget
+	account string
+	amount  float64
+}
+
+// Validate implements ledger.BudgetValidator: the debits of the entries
+// are added to what each budget covering them has spent and committed.
+// A budget exceeded returns a *ledger.BudgetExceededError; the Engine's
+// ValidationPolicy decides whether it stops the posting. For project
+// budgets the error's CostCenter is the project.
+func (v *Validator) Validate(entries []ledger.GLEntry) error {
+	var spends []spend
+	cancelled := ledger.CancelledEntries(entries)
+	for i, e := range entries {
+		if cancelled[i] {
+			continue
+		}
+		for i := range v.Budgets {
+			b := &v.Budgets[i]
+			if _, ok := b.amount(e.Account); !ok || !b.covers(e.Company, e.PostingDate, e.CostCenter, e.Project) {
+				continue
+			}
+			j := 0
+			for j < len(spends) && (spends[j].budget != b || spends[j].account != e.Account) {
+				j++
+			}
+			if j == len(spends) {
+				spends = append(spends, spend{budget: b, account: e.Account})
+			}
+			spends[j].amount += e.Debit - e.Credit
+		}
+	}
+	for _, s := range spends {
+		if ledger.Flt(s.amount, 2) <= 0 {
+			continue
+		}
+		if err := v.check(s.budget, s.account, s.amount, "", ""); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ValidateCommitment checks a material request or purchase order line
+// before it is saved: the line is added to what its budget has spent and
+// committed, not counting earlier lines of the same document. Exceeding
+// the budget returns an error or a warning, as the budget's action for
+// the document type says.
+//
+// Maps to: validate_expense_against_budget() called from the
+// Material Request and Purchase Order controllers
+func (v *Validator) ValidateCommitment(c Commitment) ([]ledger.Warning, error) {
+	var warnings []ledger.Warning
+	for i := range v.Budgets {
+		b := &v.Budgets[i]
+		if _, ok := b.amount(c.Account); !ok || !b.appliesTo(c.Kind) || !b.covers(c.Company, c.Date, c.CostCenter, c.Project) {
+			continue
+		}
+		err := v.check(b, c.Account, c.Outstanding(), c.Kind, c.DocName)
+		if err == nil {
+			continue
+		}
+		if !errors.Is(err, ledger.ErrBudgetExceeded) {
+			return warnings, err
+		}
+		switch b.action(c.Kind) {
+		case ledger.SeverityIgnore:
+		case ledger.SeverityWarn:
+			warnings = append(warnings, ledger.Warning{Check: ledger.CheckBudget, Err: err})
+		default:
+			return warnings, err
+		}
+	}
+	return warnings, nil
+}
+
+// check returns a *ledger.BudgetExceededError if the actual expense, the
+// commitments the budget applies to and amount exceed the budget of an
+// account. Commitments of the document skipDoc of kind skipKind are left
+// out, since amount replaces them.
+func (v *Validator) check(b *Budget, account string, amount float64, skipKind Kind, skipDoc string) error {
+	limit, _ := b.amount(account)
+	actual, err := v.Actuals.ActualExpense(b.Company, account, b.Against, b.Dimension, b.Period)
+	if err != nil {
+		return err
+	}
+	var committed float64
+	if v.Commitments != nil {
+		open, err := v.Commitments.Open(b.Company, b.Period)
+		if err != nil {
+			return err
+		}
+		for _, c := range open {
+			if c.Account != account || !b.appliesTo(c.Kind) || !b.covers(c.Company, c.Date, c.CostCenter, c.Project) ||
+				(c.Kind == skipKind && c.DocName == skipDoc) {
+				continue
+			}
+			committed += c.Outstanding()
+		}
+	}
+	total := ledger.Flt(actual+committed+amount, 2)
+	if total <= limit {
+		return nil
+	}
+	return &ledger.BudgetExceededError{
+		Account: account, CostCenter: b.Dimension, Budget: limit,
+		Actual: total, Committed: ledger.Flt(committed, 2), Variance: ledger.Flt(total-limit, 2),
+	}
+}
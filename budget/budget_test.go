@@ -0,0 +1,134 @@
+package budget
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/daterange"
+	"github.com/senguttuvang/erpnext-go/ledger"
+	"github.com/senguttuvang/erpnext-go/ledger/memstore"
+)
+
+var (
+	fy     = daterange.Inclusive(time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, 3, 31, 0, 0, 0, 0, time.UTC))
+	june   = time.Date(2024, 6, 10, 0, 0, 0, 0, time.UTC)
+	travel = Budget{
+		Name: "Sales Travel 2024", Company: "Acme", FiscalYear: "2024-25", Period: fy,
+		Against: CostCenter, Dimension: "Sales - A",
+		Accounts:                    []Line{{Account: "Travel - A", Amount: 10000}},
+		ApplicableOnMaterialRequest: true, ActionIfMaterialRequestExceeded: ledger.SeverityWarn,
+		ApplicableOnPurchaseOrder: true,
+	}
+)
+
+func expense(voucher string, amount float64) []ledger.GLEntry {
+	return []ledger.GLEntry{
+		{PostingDate: june, Company: "Acme", VoucherType: "Journal Entry", VoucherNo: voucher, Account: "Travel - A", CostCenter: "Sales - A", Debit: amount, DebitInAccountCurrency: amount},
+		{PostingDate: june, Company: "Acme", VoucherType: "Journal Entry", VoucherNo: voucher, Account: "Bank - A", Credit: amount, CreditInAccountCurrency: amount},
+	}
+}
+
+func TestValidatorCountsCommitments(t *testing.T) {
+	store := memstore.NewGLStore()
+	commitments := NewMemoryCommitments()
+	v := &Validator{Budgets: []Budget{travel}, Actuals: glActuals{store}, Commitments: commitments}
+	engine := &ledger.Engine{GLStore: store, PaymentStore: memstore.NewPaymentStore(), Budget: v}
+
+	if err := engine.MakeGLEntries(expense("JV-1", 6000), ledger.DefaultPostingOptions()); err != nil {
+		t.Fatal(err)
+	}
+
+	// A purchase order for 3,000 fits; a second for 2,000 does not
+	po := Commitment{Kind: PurchaseOrder, DocName: "PO-1", Company: "Acme", Date: june, Account: "Travel - A", CostCenter: "Sales - A", Amount: 3000}
+	if warnings, err := v.ValidateCommitment(po); err != nil || len(warnings) != 0 {
+		t.Fatalf("PO-1: %v, %v", warnings, err)
+	}
+	commitments.Save(po)
+	po2 := po
+	po2.DocName, po2.Amount = "PO-2", 2000
+	_, err := v.ValidateCommitment(po2)
+	var exceeded *ledger.BudgetExceededError
+	if !errors.As(err, &exceeded) || exceeded.Actual != 11000 || exceeded.Committed != 3000 || exceeded.Variance != 1000 {
+		t.Fatalf("PO-2: %v", err)
+	}
+
+	// Revalidating PO-1 replaces its own commitment instead of adding to it
+	if _, err := v.ValidateCommitment(po); err != nil {
+		t.Fatalf("PO-1 again: %v", err)
+	}
+
+	// Requests only warn
+	mr := Commitment{Kind: MaterialRequest, DocName: "MR-1", Company: "Acme", Date: june, Account: "Travel - A", CostCenter: "Sales - A", Amount: 1500}
+	if warnings, err := v.ValidateCommitment(mr); err != nil || len(warnings) != 1 || warnings[0].Check != ledger.CheckBudget {
+		t.Fatalf("MR-1: %v, %v", warnings, err)
+	}
+
+	// Booking actual expense counts the open order
+	if err := engine.MakeGLEntries(expense("JV-2", 1500), ledger.DefaultPostingOptions()); !errors.Is(err, ledger.ErrBudgetExceeded) {
+		t.Fatalf("JV-2: %v, want ErrBudgetExceeded", err)
+	}
+
+	// Billing the order consumes its commitment, so the invoice fits
+	if err := commitments.Consume(PurchaseOrder, "PO-1", "Travel - A", 3000); err != nil {
+		t.Fatal(err)
+	}
+	if err := engine.MakeGLEntries(expense("PI-1", 3000), ledger.DefaultPostingOptions()); err != nil {
+		t.Fatalf("PI-1: %v", err)
+	}
+	if open, _ := commitments.Open("Acme", fy); len(open) != 0 {
+		t.Errorf("open commitments = %+v", open)
+	}
+	if err := commitments.Consume(PurchaseOrder, "PO-9", "Travel - A", 1); !errors.Is(err, ErrNotFound) {
+		t.Errorf("err = %v, want ErrNotFound", err)
+	}
+
+	// Other cost centers and credits are not budgeted
+	other := expense("JV-3", 5000)
+	other[0].CostCenter = "Main - A"
+	if err := engine.MakeGLEntries(other, ledger.DefaultPostingOptions()); err != nil {
+		t.Errorf("JV-3: %v", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	b := travel
+	if err := b.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	b.Accounts = append(b.Accounts, Line{Account: "Travel - A", Amount: 1})
+	if err := b.Validate(); !errors.Is(err, ErrInvalidBudget) {
+		t.Errorf("duplicate account: %v", err)
+	}
+	b = travel
+	b.Against = "Department"
+	if err := b.Validate(); !errors.Is(err, ErrInvalidBudget) {
+		t.Errorf("unknown dimension: %v", err)
+	}
+}
+
+func TestEntriesSkipCancelledPairs(t *testing.T) {
+	// JV-1 cancelled, with its reversal saved live as older stores hold it
+	cancelled := expense("JV-1", 4000)
+	reversal := expense("JV-1", 4000)
+	for i := range cancelled {
+		cancelled[i].IsCancelled = true
+		reversal[i].Debit, reversal[i].Credit = reversal[i].Credit, reversal[i].Debit
+	}
+	var entries Entries
+	entries = append(entries, cancelled...)
+	entries = append(entries, reversal...)
+	entries = append(entries, expense("JV-2", 3000)...)
+
+	got, err := entries.ActualExpense("Acme", "Travel - A", CostCenter, "Sales - A", fy)
+	if err != nil || got != 3000 {
+		t.Errorf("ActualExpense() = %v, %v, want 3000", got, err)
+	}
+}
+
+// glActuals reads actual expense from a GL store as it grows.
+type glActuals struct{ store *memstore.GLStore }
+
+func (a glActuals) ActualExpense(company, account string, against Against, dimension string, period daterange.Range) (float64, error) {
+	return Entries(a.store.All()).ActualExpense(company, account, against, dimension, period)
+}
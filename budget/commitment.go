@@ -0,0 +1,132 @@
+package budget
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/daterange"
+	"github.com/senguttuvang/erpnext-go/ledger"
+)
+
+// Kind is the document type of a commitment.
+type Kind string
+
+const (
+	MaterialRequest Kind = "Material Request"
+	PurchaseOrder   Kind = "Purchase Order"
+)
+
+// Commitment is spending a submitted material request or purchase order
+// line has promised but the ledger has not booked yet. Consumed grows as
+// the request is ordered or the order is billed.
+type Commitment struct {
+	Kind       Kind
+	DocName    string
+	Company    string
+	Date       time.Time // Transaction date of the document
+	Account    string    // Expense account of the line
+	CostCenter string
+	Project    string
+	Amount     float64 // Company currency
+	Consumed   float64 // Ordered (for a request) or billed (for an order)
+}
+
+// Outstanding returns the amount still committed.
+//
+// Python equivalent:
+//
+//	get_requested_amount(): sum(stock_qty - ordered_qty) * rate
+//	get_ordered_amount():   sum(amount - billed_amt)
+func (c Commitment) Outstanding() float64 {
+	return ledger.Flt(max(c.Amount-c.Consumed, 0), 2)
+}
+
+// key identifies a commitment line.
+func (c Commitment) key() [5]string {
+	return [5]string{string(c.Kind), c.DocName, c.Account, c.CostCenter, c.Project}
+}
+
+// CommitmentStore keeps the commitments of submitted buying documents.
+// Consume the commitment of an order before posting the invoice that
+// bills it, so that the amount is not counted twice.
+type CommitmentStore interface {
+	// Save creates or replaces a commitment line.
+	Save(c Commitment) error
+	// Consume adds amount to the consumed amount of the lines of a
+	// document booked to account, in order, up to their amounts. It
+	// returns ErrNotFound if the document has no line for the account.
+	Consume(kind Kind, docName, account string, amount float64) error
+	// Release removes the lines of a cancelled or closed document.
+	Release(kind Kind, docName string) error
+	// Open returns the lines of a company dated in the period that are
+	// still outstanding.
+	Open(company string, period daterange.Range) ([]Commitment, error)
+}
+
+// MemoryCommitments is an in-memory CommitmentStore.
+type MemoryCommitments struct {
+	mu    sync.Mutex
+	lines []Commitment
+}
+
+// NewMemoryCommitments returns an empty store.
+func NewMemoryCommitments() *MemoryCommitments {
+	return &MemoryCommitments{}
+}
+
+// Save implements CommitmentStore.
+func (s *MemoryCommitments) Save(c Commitment) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := slices.IndexFunc(s.lines, func(l Commitment) bool { return l.key() == c.key() })
+	if i < 0 {
+		s.lines = append(s.lines, c)
+	} else {
+		s.lines[i] = c
+	}
+	return nil
+}
+
+// Consume implements CommitmentStore.
+func (s *MemoryCommitments) Consume(kind Kind, docName, account string, amount float64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	found := false
+	for i := range s.lines {
+		l := &s.lines[i]
+		if l.Kind != kind || l.DocName != docName || l.Account != account {
+			continue
+		}
+		found = true
+		take := min(amount, l.Outstanding())
+		l.Consumed = ledger.Flt(l.Consumed+take, 2)
+		amount = ledger.Flt(amount-take, 2)
+	}
+	if !found {
+		return fmt.Errorf("%w: %s %s for %s", ErrNotFound, kind, docName, account)
+	}
+	return nil
+}
+
+// Release implements CommitmentStore.
+func (s *MemoryCommitments) Release(kind Kind, docName string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lines = slices.DeleteFunc(s.lines, func(l Commitment) bool { return l.Kind == kind && l.DocName == docName })
+	return nil
+}
+
+// Open implements CommitmentStore.
+func (s *MemoryCommitments) Open(company string, period daterange.Range) ([]Commitment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []Commitment
+	for _, l := range s.lines {
+		if l.Company == company && period.Contains(l.Date) && l.Outstanding() > 0 {
+			out = append(out, l)
+		}
+	}
+	return out, nil
+}
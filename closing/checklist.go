@@ -0,0 +1,241 @@
+// Package closing runs the period-close checklist and posts the Period
+// Closing Voucher.
+//
+// Before a period is closed, a checklist of automated checks looks for
+// work left undone: bank lines not reconciled, suspense accounts with a
+// balance, advances not allocated, deferred revenue or expense schedules
+// not yet booked. Each check reports findings; findings of a blocking
+// check stop the Period Closing Voucher from posting. Sweep rules clear
+// the suspense accounts the checklist flags by journal entries linked to
+// the vouchers they clear. A closed period is reopened only with a reason
+// and an authorizer other than the requester: the closing voucher is
+// reversed, the reopening logged on its timeline, and the re-close runs
+// the checklist again.
+//
+// Migrated from: erpnext/accounts/doctype/period_closing_voucher/period_closing_voucher.py
+// (ERPNext has no checklist; its close relies on the accountant's own)
+package closing
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/daterange"
+	"github.com/senguttuvang/erpnext-go/ledger"
+	"github.com/senguttuvang/erpnext-go/reconciliation"
+)
+
+// ErrCloseBlocked is returned when blocking checks have findings.
+var ErrCloseBlocked = errors.New("period close blocked by checklist")
+
+// Severity decides whether a check's findings block the close.
+type Severity string
+
+const (
+	Blocker Severity = "Blocker"
+	Warning Severity = "Warning"
+)
+
+// Scope is the period being closed and the ledgers the checks read.
+type Scope struct {
+	Company string
+	Period  daterange.Range // Must have an end
+	GL      []ledger.GLEntry
+	PLE     []ledger.PaymentLedgerEntry
+}
+
+// End returns the last day of the period.
+func (s Scope) End() time.Time { return s.Period.Last() }
+
+// Finding is one problem a check found.
+type Finding struct {
+	Subject string  // Account, voucher or document the finding is about
+	Amount  float64 // Company currency; 0 when not applicable
+	Detail  string
+}
+
+// Check is one automated check of the checklist.
+type Check interface {
+	Name() string
+	Run(scope Scope) ([]Finding, error)
+}
+
+// Item is a check with the severity of its findings.
+type Item struct {
+	Check    Check
+	Severity Severity
+}
+
+// Checklist is the ordered list of checks run before a close.
+type Checklist struct {
+	Items []Item
+}
+
+// Result is the outcome of one check.
+type Result struct {
+	Check    string
+	Severity Severity
+	Findings []Finding
+}
+
+// Passed reports whether the check found nothing.
+func (r Result) Passed() bool { return len(r.Findings) == 0 }
+
+// Report is the outcome of a checklist run.
+type Report struct {
+	Company string
+	Period  daterange.Range
+	Results []Result
+}
+
+// Blockers returns the results of blocking checks with findings.
+func (r *Report) Blockers() []Result {
+	var out []Result
+	for _, res := range r.Results {
+		if res.Severity == Blocker && !res.Passed() {
+			out = append(out, res)
+		}
+	}
+	return out
+}
+
+// Err returns an error wrapping ErrCloseBlocked that names the blocking
+// checks, or nil if there are none.
+func (r *Report) Err() error {
+	blockers := r.Blockers()
+	if len(blockers) == 0 {
+		return nil
+	}
+	names := make([]string, len(blockers))
+	for i, b := range blockers {
+		names[i] = fmt.Sprintf("%s (%d)", b.Check, len(b.Findings))
+	}
+	return fmt.Errorf("%w: %s", ErrCloseBlocked, strings.Join(names, ", "))
+}
+
+// Run runs every check in order. A check that fails to run stops the
+// checklist; findings are not errors.
+func (c *Checklist) Run(scope Scope) (*Report, error) {
+	if scope.Period.End.IsZero() {
+		return nil, fmt.Errorf("period to close has no end: %s", scope.Period)
+	}
+	report := &Report{Company: scope.Company, Period: scope.Period}
+	for _, item := range c.Items {
+		findings, err := item.Check.Run(scope)
+		if err != nil {
+			return nil, fmt.Errorf("check %s: %w", item.Check.Name(), err)
+		}
+		report.Results = append(report.Results, Result{Check: item.Check.Name(), Severity: item.Severity, Findings: findings})
+	}
+	return report, nil
+}
+
+// SuspenseBalances finds suspense and clearing accounts that do not net to
+// zero at the end of the period: amounts parked there were never moved to
+// their proper account.
+type SuspenseBalances struct {
+	Accounts []string
+}
+
+func (SuspenseBalances) Name() string { return "Suspense accounts balanced" }
+
+func (c SuspenseBalances) Run(scope Scope) ([]Finding, error) {
+	upTo := daterange.Range{End: scope.Period.End}
+	balances := map[string]float64{}
+	cancelled := ledger.CancelledEntries(scope.GL)
+	for i, e := range scope.GL {
+		if e.Company == scope.Company && !cancelled[i] && upTo.Contains(e.PostingDate) {
+			balances[e.Account] += e.Debit - e.Credit
+		}
+	}
+	var findings []Finding
+	for _, account := range c.Accounts {
+		if b := ledger.Flt(balances[account], 2); b != 0 {
+			findings = append(findings, Finding{Subject: account, Amount: b, Detail: fmt.Sprintf("balance %.2f", b)})
+		}
+	}
+	return findings, nil
+}
+
+// UnallocatedAdvances finds payments and advances not allocated to
+// invoices by the end of the period.
+type UnallocatedAdvances struct {
+	PartyType string // Optional
+}
+
+func (UnallocatedAdvances) Name() string { return "Advances allocated" }
+
+func (c UnallocatedAdvances) Run(scope Scope) ([]Finding, error) {
+	rows := reconciliation.Unallocated(scope.PLE, reconciliation.Filter{
+		Company: scope.Company, PartyType: c.PartyType, AsOf: scope.End(),
+	})
+	findings := make([]Finding, len(rows))
+	for i, r := range rows {
+		findings[i] = Finding{
+			Subject: r.VoucherType + " " + r.VoucherNo, Amount: r.Unallocated,
+			Detail: fmt.Sprintf("%s %s, %d days old", r.PartyType, r.Party, r.Age),
+		}
+	}
+	return findings, nil
+}
+
+// BankLine is a bank statement line not matched with a voucher.
+type BankLine struct {
+	BankAccount string
+	Date        time.Time
+	Reference   string
+	Amount      float64 // Deposit positive, withdrawal negative
+}
+
+// BankLines lists unreconciled bank statement lines. Production
+// implementations query Bank Transaction.
+type BankLines interface {
+	UnreconciledBankLines(company string, asOf time.Time) ([]BankLine, error)
+}
+
+// UnreconciledBankLines finds bank statement lines dated in or before the
+// period that are not matched with a voucher.
+type UnreconciledBankLines struct {
+	Source BankLines
+}
+
+func (UnreconciledBankLines) Name() string { return "Bank statements reconciled" }
+
+func (c UnreconciledBankLines) Run(scope Scope) ([]Finding, error) {
+	lines, err := c.Source.UnreconciledBankLines(scope.Company, scope.End())
+	if err != nil {
+		return nil, err
+	}
+	findings := make([]Finding, len(lines))
+	for i, l := range lines {
+		findings[i] = Finding{
+			Subject: l.BankAccount, Amount: l.Amount,
+			Detail: fmt.Sprintf("%s %s", l.Date.Format(ledger.DateLayout), l.Reference),
+		}
+	}
+	return findings, nil
+}
+
+// DeferredSchedules lists deferred revenue and expense bookings due by a
+// date that have not been posted. Production implementations read the
+// invoice items with deferred revenue or expense enabled.
+type DeferredSchedules interface {
+	PendingDeferredBookings(company string, upTo time.Time) ([]Finding, error)
+}
+
+// PendingDeferredBookings finds deferred revenue and expense due in or
+// before the period that has not been booked.
+//
+// Maps to: the check of process_deferred_accounting.py, which books these
+// entries up to the period end
+type PendingDeferredBookings struct {
+	Source DeferredSchedules
+}
+
+func (PendingDeferredBookings) Name() string { return "Deferred schedules booked" }
+
+func (c PendingDeferredBookings) Run(scope Scope) ([]Finding, error) {
+	return c.Source.PendingDeferredBookings(scope.Company, scope.End())
+}
@@ -0,0 +1,345 @@
+package closing
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/daterange"
+	"github.com/senguttuvang/erpnext-go/ledger"
+	"github.com/senguttuvang/erpnext-go/ledger/memstore"
+	"github.com/senguttuvang/erpnext-go/timeline"
+)
+
+var (
+	jan1  = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	dec31 = time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC)
+	year  = daterange.Inclusive(jan1, dec31)
+)
+
+type bankLines []BankLine
+
+func (b bankLines) UnreconciledBankLines(string, time.Time) ([]BankLine, error) { return b, nil }
+
+type deferred []Finding
+
+func (d deferred) PendingDeferredBookings(string, time.Time) ([]Finding, error) { return d, nil }
+
+func gle(date time.Time, voucher, account, costCenter string, debit, credit float64) ledger.GLEntry {
+	return ledger.GLEntry{
+		PostingDate: date, VoucherType: "Journal Entry", VoucherNo: voucher, Company: "Acme",
+		Account: account, CostCenter: costCenter, Debit: debit, Credit: credit,
+		DebitInAccountCurrency: debit, CreditInAccountCurrency: credit,
+	}
+}
+
+// cancelledJournal returns a journal debiting one account and crediting
+// another, cancelled, with the reversals saved live as older stores hold
+// them.
+func cancelledJournal(date time.Time, voucher, debitAccount, creditAccount, costCenter string, amount float64) []ledger.GLEntry {
+	debit, credit := gle(date, voucher, debitAccount, costCenter, amount, 0), gle(date, voucher, creditAccount, costCenter, 0, amount)
+	debit.IsCancelled, credit.IsCancelled = true, true
+	return []ledger.GLEntry{
+		debit, credit,
+		gle(date, voucher, debitAccount, costCenter, 0, amount), gle(date, voucher, creditAccount, costCenter, amount, 0),
+	}
+}
+
+func TestChecklist(t *testing.T) {
+	gl := []ledger.GLEntry{
+		gle(jan1.AddDate(0, 3, 0), "JV-1", "Suspense - A", "", 100, 0),
+		gle(jan1.AddDate(0, 3, 0), "JV-1", "Bank - A", "", 0, 100),
+		gle(dec31.AddDate(0, 0, 1), "JV-2", "Suspense - A", "", 0, 100), // Cleared only next year
+		gle(dec31.AddDate(0, 0, 1), "JV-2", "Bank - A", "", 100, 0),
+	}
+	gl = append(gl, cancelledJournal(jan1.AddDate(0, 5, 0), "JV-9", "Suspense - A", "Bank - A", "", 70)...)
+	ple := []ledger.PaymentLedgerEntry{{
+		PostingDate: jan1.AddDate(0, 10, 0), Company: "Acme", Account: "Debtors - A", PartyType: "Customer", Party: "Globex",
+		VoucherType: "Payment Entry", VoucherNo: "PE-1", AgainstVoucherType: "Payment Entry", AgainstVoucherNo: "PE-1", Amount: -250,
+	}}
+	checklist := &Checklist{Items: []Item{
+		{SuspenseBalances{Accounts: []string{"Suspense - A"}}, Blocker},
+		{UnallocatedAdvances{}, Warning},
+		{UnreconciledBankLines{bankLines{{BankAccount: "Acme Bank", Date: dec31, Reference: "CHQ 88", Amount: -40}}}, Blocker},
+		{PendingDeferredBookings{deferred(nil)}, Blocker},
+	}}
+
+	report, err := checklist.Run(Scope{Company: "Acme", Period: year, GL: gl, PLE: ple})
+	if err != nil {
+		t.Fatal(err)
+	}
+	suspense, advances, bank, schedules := report.Results[0], report.Results[1], report.Results[2], report.Results[3]
+	if len(suspense.Findings) != 1 || suspense.Findings[0].Amount != 100 {
+		t.Errorf("suspense findings = %+v", suspense.Findings)
+	}
+	if len(advances.Findings) != 1 || advances.Findings[0].Amount != 250 || advances.Findings[0].Subject != "Payment Entry PE-1" {
+		t.Errorf("advance findings = %+v", advances.Findings)
+	}
+	if len(bank.Findings) != 1 || bank.Findings[0].Detail != "2024-12-31 CHQ 88" {
+		t.Errorf("bank findings = %+v", bank.Findings)
+	}
+	if !schedules.Passed() {
+		t.Errorf("deferred findings = %+v", schedules.Findings)
+	}
+
+	// The unallocated advance only warns
+	if blockers := report.Blockers(); len(blockers) != 2 {
+		t.Errorf("blockers = %+v", blockers)
+	}
+	err = report.Err()
+	if !errors.Is(err, ErrCloseBlocked) || err.Error() != "period close blocked by checklist: Suspense accounts balanced (1), Bank statements reconciled (1)" {
+		t.Errorf("err = %v", err)
+	}
+
+	if _, err := checklist.Run(Scope{Company: "Acme", Period: daterange.Range{Start: jan1}}); err == nil {
+		t.Error("open-ended period ran")
+	}
+}
+
+func TestPeriodClosingVoucher(t *testing.T) {
+	accounts := memstore.NewAccounts(
+		ledger.Account{Name: "Bank - A", RootType: "Asset"},
+		ledger.Account{Name: "Sales - A", RootType: "Income"},
+		ledger.Account{Name: "Rent - A", RootType: "Expense"},
+		ledger.Account{Name: "Retained Earnings - A", RootType: "Equity"},
+		ledger.Account{Name: "Debtors - A", RootType: "Asset"},
+	)
+	store := memstore.NewGLStore()
+	engine := &ledger.Engine{Accounts: accounts, GLStore: store, PaymentStore: memstore.NewPaymentStore()}
+	gl := []ledger.GLEntry{
+		gle(jan1.AddDate(0, 1, 0), "JV-1", "Bank - A", "", 1000, 0),
+		gle(jan1.AddDate(0, 1, 0), "JV-1", "Sales - A", "Main - A", 0, 700),
+		gle(jan1.AddDate(0, 1, 0), "JV-1", "Sales - A", "Shop - A", 0, 300),
+		gle(jan1.AddDate(0, 2, 0), "JV-2", "Rent - A", "Main - A", 400, 0),
+		gle(jan1.AddDate(0, 2, 0), "JV-2", "Bank - A", "", 0, 400),
+		gle(jan1.AddDate(-1, 0, 0), "JV-0", "Sales - A", "Main - A", 0, 50), // Previous year
+		gle(jan1.AddDate(-1, 0, 0), "JV-0", "Bank - A", "", 50, 0),
+	}
+	gl = append(gl, cancelledJournal(jan1.AddDate(0, 3, 0), "JV-9", "Bank - A", "Sales - A", "Main - A", 900)...)
+	pcv := &PeriodClosingVoucher{
+		Name: "PCV-2024", Company: "Acme", PostingDate: dec31, FiscalYear: "2024",
+		Period: year, ClosingAccount: "Retained Earnings - A",
+	}
+
+	glMap, err := pcv.GetGLEntries(gl, accounts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct {
+		account, costCenter string
+		debit, credit       float64
+	}{
+		{"Rent - A", "Main - A", 0, 400},
+		{"Sales - A", "Main - A", 700, 0},
+		{"Sales - A", "Shop - A", 300, 0},
+		{"Retained Earnings - A", "Main - A", 0, 300},
+		{"Retained Earnings - A", "Shop - A", 0, 300},
+	}
+	if len(glMap) != len(want) {
+		t.Fatalf("got %d entries, want %d: %+v", len(glMap), len(want), glMap)
+	}
+	for i, w := range want {
+		e := glMap[i]
+		if e.Account != w.account || e.CostCenter != w.costCenter || e.Debit != w.debit || e.Credit != w.credit {
+			t.Errorf("entry %d = %s/%s %.2f/%.2f, want %+v", i, e.Account, e.CostCenter, e.Debit, e.Credit, w)
+		}
+	}
+
+	// A suspense balance blocks the close and nothing is posted
+	blocked := append(gl, gle(dec31, "JV-3", "Suspense - A", "", 10, 0), gle(dec31, "JV-3", "Bank - A", "", 0, 10))
+	checklist := &Checklist{Items: []Item{{SuspenseBalances{Accounts: []string{"Suspense - A"}}, Blocker}}}
+	if _, err := pcv.Submit(engine, checklist, blocked, nil); !errors.Is(err, ErrCloseBlocked) {
+		t.Fatalf("err = %v, want ErrCloseBlocked", err)
+	}
+	if n := len(store.All()); n != 0 {
+		t.Fatalf("blocked close posted %d entries", n)
+	}
+
+	report, err := pcv.Submit(engine, checklist, gl, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.Results[0].Passed() {
+		t.Errorf("findings = %+v", report.Results[0].Findings)
+	}
+	var balance float64
+	for _, e := range store.All() {
+		balance += e.Debit - e.Credit
+		if e.VoucherType != ledger.PeriodClosingVoucher || e.VoucherNo != "PCV-2024" {
+			t.Errorf("posted %s %s", e.VoucherType, e.VoucherNo)
+		}
+	}
+	if n := len(store.All()); n != 5 || ledger.Flt(balance, 2) != 0 {
+		t.Errorf("posted %d entries, balance %.2f", n, balance)
+	}
+
+	// Closing into an asset account is refused
+	pcv.ClosingAccount = "Bank - A"
+	if _, err := pcv.GetGLEntries(gl, accounts); !errors.Is(err, ErrInvalidClosingAccount) {
+		t.Errorf("err = %v, want ErrInvalidClosingAccount", err)
+	}
+	pcv.ClosingAccount = "Retained Earnings - A"
+	pcv.Period = daterange.Inclusive(dec31.AddDate(1, 0, 0), dec31.AddDate(1, 0, 0))
+	if _, err := pcv.GetGLEntries(gl, accounts); !errors.Is(err, ErrNothingToClose) {
+		t.Errorf("err = %v, want ErrNothingToClose", err)
+	}
+}
+
+func TestSweep(t *testing.T) {
+	accounts := memstore.NewAccounts(
+		ledger.Account{Name: "Bank - A", RootType: "Asset"},
+		ledger.Account{Name: "Suspense - A", RootType: "Liability"},
+		ledger.Account{Name: "Debtors - A", RootType: "Asset"},
+		ledger.Account{Name: "Bank Charges - A", RootType: "Expense"},
+	)
+	store := memstore.NewGLStore()
+	engine := &ledger.Engine{Accounts: accounts, GLStore: store, PaymentStore: memstore.NewPaymentStore()}
+	deposit := func(voucher, remarks string, amount float64) []ledger.GLEntry {
+		bank, suspense := gle(jan1, voucher, "Bank - A", "", amount, 0), gle(jan1, voucher, "Suspense - A", "", 0, amount)
+		bank.Remarks, suspense.Remarks = remarks, remarks
+		return []ledger.GLEntry{bank, suspense}
+	}
+	var gl []ledger.GLEntry
+	gl = append(gl, deposit("JV-1", "Transfer ref INV-0042 Globex", 500)...)
+	gl = append(gl, deposit("JV-2", "Unknown deposit", 2000)...)
+	gl = append(gl, deposit("JV-3", "Card fee reversal", 3.5)...)
+	gl = append(gl, cancelledJournal(jan1, "JV-9", "Bank - A", "Suspense - A", "", 80)...)
+
+	rules := []*SweepRule{
+		{Name: "Globex receipts", Company: "Acme", SuspenseAccount: "Suspense - A", TargetAccount: "Debtors - A",
+			ReferencePattern: `INV-\d+`, TargetPartyType: "Customer", TargetParty: "Globex"},
+		{Name: "Small amounts", Company: "Acme", SuspenseAccount: "Suspense - A", TargetAccount: "Bank Charges - A",
+			CostCenter: "Main - A", MaxAmount: 10},
+	}
+
+	items := OpenSuspenseItems(gl, "Acme", []string{"Suspense - A"}, dec31)
+	if len(items) != 3 || items[0].Amount != -500 || items[0].Remarks != "Transfer ref INV-0042 Globex" {
+		t.Fatalf("open items = %+v", items)
+	}
+	preview, err := Match(rules, items)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(preview) != 2 || preview[0].Rule != "Globex receipts" || preview[1].Rule != "Small amounts" || preview[0].VoucherNo != "" {
+		t.Fatalf("preview = %+v", preview)
+	}
+
+	sweeper := &Sweeper{Engine: engine, Rules: rules}
+	cleared, err := sweeper.Sweep(gl, "Acme", dec31)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cleared) != 2 || cleared[0].VoucherNo != "Globex receipts/JV-1" {
+		t.Fatalf("cleared = %+v", cleared)
+	}
+	posted := store.All()
+	for _, e := range posted {
+		if e.VoucherSubtype != SweepSubtype {
+			t.Errorf("%s subtype = %q", e.VoucherNo, e.VoucherSubtype)
+		}
+		if e.Account == "Suspense - A" && (e.AgainstVoucherType != "Journal Entry" || e.AgainstVoucher != e.VoucherNo[len(e.VoucherNo)-4:]) {
+			t.Errorf("%s is against %s %s", e.VoucherNo, e.AgainstVoucherType, e.AgainstVoucher)
+		}
+		if e.Account == "Debtors - A" && (e.Party != "Globex" || e.Debit != 0 || e.Credit != 500) {
+			t.Errorf("debtors line = %+v", e)
+		}
+	}
+
+	// Only the unknown deposit stays open, and a second sweep posts nothing
+	gl = append(gl, posted...)
+	if items := OpenSuspenseItems(gl, "Acme", []string{"Suspense - A"}, dec31); len(items) != 1 || items[0].VoucherNo != "JV-2" {
+		t.Errorf("open after sweep = %+v", items)
+	}
+	if again, err := sweeper.Sweep(gl, "Acme", dec31); err != nil || len(again) != 0 {
+		t.Errorf("second sweep = %+v, %v", again, err)
+	}
+
+	bad := []*SweepRule{{Name: "Bad", SuspenseAccount: "Suspense - A", TargetAccount: "Bank - A", ReferencePattern: "("}}
+	if _, err := Match(bad, items); !errors.Is(err, ErrInvalidSweepRule) {
+		t.Errorf("err = %v, want ErrInvalidSweepRule", err)
+	}
+}
+
+func TestReopen(t *testing.T) {
+	accounts := memstore.NewAccounts(
+		ledger.Account{Name: "Bank - A", RootType: "Asset"},
+		ledger.Account{Name: "Sales - A", RootType: "Income"},
+		ledger.Account{Name: "Suspense - A", RootType: "Liability"},
+		ledger.Account{Name: "Retained Earnings - A", RootType: "Equity"},
+	)
+	store := memstore.NewGLStore()
+	engine := &ledger.Engine{Accounts: accounts, GLStore: store, PaymentStore: memstore.NewPaymentStore()}
+	notes := &timeline.MemoryStore{}
+	audit := &timeline.Service{Attachments: notes, Comments: notes}
+	gl := []ledger.GLEntry{
+		gle(jan1.AddDate(0, 1, 0), "JV-1", "Bank - A", "", 1000, 0),
+		gle(jan1.AddDate(0, 1, 0), "JV-1", "Sales - A", "Main - A", 0, 1000),
+	}
+	pcv := &PeriodClosingVoucher{
+		Name: "PCV-2024", Company: "Acme", PostingDate: dec31, FiscalYear: "2024",
+		Period: year, ClosingAccount: "Retained Earnings - A",
+	}
+	if _, err := pcv.Submit(engine, nil, gl, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	req := ReopenRequest{Reason: "Late supplier credit note", RequestedBy: "accountant", AuthorizedBy: "accountant"}
+	if _, err := pcv.Reopen(engine, audit, req); !errors.Is(err, ErrNoAuthorizer) {
+		t.Errorf("self-authorized reopen = %v", err)
+	}
+	if _, err := pcv.Reopen(engine, audit, ReopenRequest{Reason: " ", RequestedBy: "accountant", AuthorizedBy: "controller"}); !errors.Is(err, ErrNoReason) {
+		t.Errorf("reopen without reason = %v", err)
+	}
+	if _, err := pcv.Reopen(engine, nil, req); err == nil {
+		t.Error("reopened without an audit trail")
+	}
+	req.AuthorizedBy = "controller"
+	reopening, err := pcv.Reopen(engine, audit, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"Retained Earnings - A", "Sales - A"}; !slices.Equal(reopening.Accounts, want) {
+		t.Errorf("accounts = %v, want %v", reopening.Accounts, want)
+	}
+	if len(closedAccounts(store.All())) != 0 {
+		t.Errorf("closing entries left after reopen: %+v", store.All())
+	}
+	if _, err := pcv.Reopen(engine, audit, req); !errors.Is(err, ErrNotClosed) {
+		t.Errorf("second reopen = %v", err)
+	}
+
+	// A correction booked while open, then the re-close runs the checklist
+	gl = append(gl, gle(jan1.AddDate(0, 11, 0), "JV-2", "Suspense - A", "", 50, 0), gle(jan1.AddDate(0, 11, 0), "JV-2", "Sales - A", "Main - A", 50, 0))
+	checklist := &Checklist{Items: []Item{{SuspenseBalances{Accounts: []string{"Suspense - A"}}, Blocker}}}
+	amended := pcv.Amend()
+	if amended.Name != "PCV-2024-1" || amended.AmendedFrom != "PCV-2024" {
+		t.Errorf("amended = %s from %s", amended.Name, amended.AmendedFrom)
+	}
+	if _, err := amended.Reclose(engine, audit, nil, gl, nil); !errors.Is(err, ErrChecklistRequired) {
+		t.Errorf("re-close without checklist = %v", err)
+	}
+	if _, err := pcv.Reclose(engine, audit, checklist, gl, nil); !errors.Is(err, ErrNotReopened) {
+		t.Errorf("re-close of the original = %v", err)
+	}
+	if _, err := amended.Reclose(engine, audit, checklist, gl, nil); !errors.Is(err, ErrCloseBlocked) {
+		t.Errorf("re-close with suspense balance = %v", err)
+	}
+	gl = append(gl, gle(dec31, "JV-3", "Suspense - A", "", 0, 50), gle(dec31, "JV-3", "Bank - A", "", 50, 0))
+	if _, err := amended.Reclose(engine, audit, checklist, gl, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := closedAccounts(store.All()); len(got) != 2 {
+		t.Errorf("closed after re-close = %v", got)
+	}
+
+	events, _ := audit.Timeline(pcv.AuditRef())
+	if len(events) != 2 || !strings.Contains(events[0].Comment.Content, "authorized by controller: Late supplier credit note") ||
+		events[0].Comment.Owner != "accountant" || events[1].Comment.Content != "Period 2024-01-01 to 2024-12-31 closed again by PCV-2024-1" {
+		for _, e := range events {
+			t.Logf("%+v", *e.Comment)
+		}
+		t.Error("audit trail")
+	}
+}
@@ -0,0 +1,160 @@
+package closing
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/daterange"
+	"github.com/senguttuvang/erpnext-go/ledger"
+)
+
+// Period Closing Voucher errors
+var (
+	ErrInvalidClosingAccount = errors.New("closing account must be of type Liability or Equity")
+	ErrNothingToClose        = errors.New("no income or expense balance to close")
+)
+
+// PeriodClosingVoucher closes the income and expense accounts of a period
+// into a liability or equity account, usually retained earnings.
+//
+// Maps to: erpnext/accounts/doctype/period_closing_voucher/period_closing_voucher.json
+type PeriodClosingVoucher struct {
+	Name           string
+	Company        string
+	PostingDate    time.Time // Last day of the period
+	FiscalYear     string
+	Period         daterange.Range
+	ClosingAccount string
+	Remarks        string
+	AmendedFrom    string // The voucher reversed by a reopening this one closes again
+}
+
+// balanceKey groups the income and expense balances to close.
+type balanceKey struct{ account, costCenter string }
+
+// GetGLEntries builds the closing GL map: each income and expense account
+// balance of the period, by cost center, is reversed, and the net profit
+// or loss is booked to the closing account per cost center. Cancelled
+// entries, their reversals and earlier closing vouchers are ignored.
+//
+// Maps to: PeriodClosingVoucher.make_gl_entries(),
+// get_gle_for_pl_account() and get_gle_for_closing_account()
+func (v *PeriodClosingVoucher) GetGLEntries(entries []ledger.GLEntry, accounts ledger.AccountLookup) ([]ledger.GLEntry, error) {
+	closing, err := accounts.GetAccount(v.ClosingAccount)
+	if err != nil {
+		return nil, fmt.Errorf("closing account %s: %w", v.ClosingAccount, err)
+	}
+	if closing.RootType != "Liability" && closing.RootType != "Equity" {
+		return nil, fmt.Errorf("%w: %s is %s", ErrInvalidClosingAccount, v.ClosingAccount, closing.RootType)
+	}
+
+	rootTypes := map[string]string{}
+	balances := map[balanceKey]float64{}
+	cancelled := ledger.CancelledEntries(entries)
+	for i, e := range entries {
+		if cancelled[i] || e.Company != v.Company || e.VoucherType == ledger.PeriodClosingVoucher || !v.Period.Contains(e.PostingDate) {
+			continue
+		}
+		rootType, ok := rootTypes[e.Account]
+		if !ok {
+			acc, err := accounts.GetAccount(e.Account)
+			if err != nil {
+				return nil, fmt.Errorf("account %s: %w", e.Account, err)
+			}
+			rootType = acc.RootType
+			rootTypes[e.Account] = rootType
+		}
+		if rootType == "Income" || rootType == "Expense" {
+			balances[balanceKey{e.Account, e.CostCenter}] += e.Debit - e.Credit
+		}
+	}
+
+	keys := make([]balanceKey, 0, len(balances))
+	for k, b := range balances {
+		if ledger.Flt(b, 2) != 0 {
+			keys = append(keys, k)
+		}
+	}
+	if len(keys) == 0 {
+		return nil, ErrNothingToClose
+	}
+	slices.SortFunc(keys, func(a, b balanceKey) int {
+		if c := strings.Compare(a.account, b.account); c != 0 {
+			return c
+		}
+		return strings.Compare(a.costCenter, b.costCenter)
+	})
+
+	var glMap []ledger.GLEntry
+	net := map[string]float64{} // Cost center -> debit balance closed
+	var costCenters []string
+	for _, k := range keys {
+		b := ledger.Flt(balances[k], 2)
+		glMap = append(glMap, v.newGLEntry(k.account, k.costCenter, -b))
+		if _, ok := net[k.costCenter]; !ok {
+			costCenters = append(costCenters, k.costCenter)
+		}
+		net[k.costCenter] += b
+	}
+	slices.Sort(costCenters)
+	for _, cc := range costCenters {
+		if b := ledger.Flt(net[cc], 2); b != 0 {
+			glMap = append(glMap, v.newGLEntry(v.ClosingAccount, cc, b))
+		}
+	}
+	return glMap, nil
+}
+
+// newGLEntry returns a closing entry debiting amount to account, or
+// crediting it when negative.
+func (v *PeriodClosingVoucher) newGLEntry(account, costCenter string, amount float64) ledger.GLEntry {
+	e := ledger.GLEntry{
+		PostingDate:     v.PostingDate,
+		TransactionDate: v.PostingDate,
+		VoucherType:     ledger.PeriodClosingVoucher,
+		VoucherNo:       v.Name,
+		Company:         v.Company,
+		FiscalYear:      v.FiscalYear,
+		Account:         account,
+		CostCenter:      costCenter,
+		Remarks:         v.Remarks,
+		IsOpening:       ledger.IsOpeningNo,
+		IsAdvance:       ledger.IsAdvanceNo,
+	}
+	if amount > 0 {
+		e.Debit, e.DebitInAccountCurrency = amount, amount
+	} else {
+		e.Credit, e.CreditInAccountCurrency = -amount, -amount
+	}
+	return e
+}
+
+// Submit runs the checklist over the period and, unless a blocking check
+// has findings, posts the closing entries. The checklist report is
+// returned in either case; a blocked close returns an error wrapping
+// ErrCloseBlocked. A nil checklist skips the checks.
+//
+// Maps to: PeriodClosingVoucher.on_submit()
+func (v *PeriodClosingVoucher) Submit(engine *ledger.Engine, checklist *Checklist, gl []ledger.GLEntry, ple []ledger.PaymentLedgerEntry) (*Report, error) {
+	var report *Report
+	if checklist != nil {
+		var err error
+		report, err = checklist.Run(Scope{Company: v.Company, Period: v.Period, GL: gl, PLE: ple})
+		if err != nil {
+			return nil, err
+		}
+		if err := report.Err(); err != nil {
+			return report, err
+		}
+	}
+	glMap, err := v.GetGLEntries(gl, engine.Accounts)
+	if err != nil {
+		return report, err
+	}
+	opts := ledger.DefaultPostingOptions()
+	opts.MergeEntries = false
+	return report, engine.MakeGLEntries(glMap, opts)
+}
@@ -0,0 +1,166 @@
+package closing
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/daterange"
+	"github.com/senguttuvang/erpnext-go/ledger"
+	"github.com/senguttuvang/erpnext-go/naming"
+	"github.com/senguttuvang/erpnext-go/timeline"
+)
+
+// Reopen errors
+var (
+	ErrNoReason          = errors.New("a reason is required to reopen a period")
+	ErrNoAuthorizer      = errors.New("reopening a period must be authorized by someone other than the requester")
+	ErrNoAuditTrail      = errors.New("reopening a period requires an audit trail")
+	ErrNotClosed         = errors.New("period closing voucher has no closing entries to reverse")
+	ErrNotReopened       = errors.New("period closing voucher does not re-close a reopened period")
+	ErrChecklistRequired = errors.New("re-closing a reopened period requires the close checklist")
+)
+
+// ReopenRequest asks for a closed period to be reopened. The requester
+// cannot authorize their own request.
+type ReopenRequest struct {
+	Reason       string
+	RequestedBy  string
+	AuthorizedBy string
+	At           time.Time // Defaults to now
+}
+
+// Reopening records a closed period being reopened.
+type Reopening struct {
+	Voucher      string // The Period Closing Voucher reversed
+	Company      string
+	Period       daterange.Range
+	Reason       string
+	RequestedBy  string
+	AuthorizedBy string
+	At           time.Time
+	Accounts     []string // Accounts whose closing entries were reversed
+}
+
+// AuditRef is the timeline document reopenings and re-closes of a voucher
+// are logged against.
+func (v *PeriodClosingVoucher) AuditRef() timeline.Ref {
+	return timeline.Ref{DocType: ledger.PeriodClosingVoucher, Name: v.Name}
+}
+
+// Reopen reopens the period of a posted closing voucher: it cancels the
+// voucher, which reverses its entries for every account it closed, and
+// logs who asked, who authorized and why on the voucher's timeline. The
+// period is closed again by Reclose.
+//
+// Maps to: PeriodClosingVoucher.on_cancel(), with the reason and
+// authorization ERPNext does not ask for
+func (v *PeriodClosingVoucher) Reopen(engine *ledger.Engine, audit *timeline.Service, req ReopenRequest) (*Reopening, error) {
+	if strings.TrimSpace(req.Reason) == "" {
+		return nil, ErrNoReason
+	}
+	if req.AuthorizedBy == "" || req.AuthorizedBy == req.RequestedBy {
+		return nil, ErrNoAuthorizer
+	}
+	if audit == nil {
+		return nil, ErrNoAuditTrail
+	}
+	entries, err := engine.GLStore.GetByVoucher(ledger.PeriodClosingVoucher, v.Name)
+	if err != nil {
+		return nil, err
+	}
+	accounts := closedAccounts(entries)
+	if len(accounts) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrNotClosed, v.Name)
+	}
+
+	opts := ledger.DefaultPostingOptions()
+	opts.Cancel, opts.User = true, req.AuthorizedBy
+	if err := engine.MakeGLEntries(entries, opts); err != nil {
+		return nil, err
+	}
+	r := &Reopening{
+		Voucher: v.Name, Company: v.Company, Period: v.Period,
+		Reason: req.Reason, RequestedBy: req.RequestedBy, AuthorizedBy: req.AuthorizedBy,
+		At: req.At, Accounts: accounts,
+	}
+	if r.At.IsZero() {
+		r.At = time.Now()
+	}
+	note := fmt.Sprintf("Period %s reopened at the request of %s, authorized by %s: %s\nClosing entries reversed for %s",
+		v.periodName(), r.RequestedBy, r.AuthorizedBy, strings.TrimSpace(r.Reason), strings.Join(accounts, ", "))
+	if _, err := audit.Comment(v.AuditRef(), timeline.Info, note, r.RequestedBy); err != nil {
+		return r, fmt.Errorf("%s reversed but not logged: %w", v.Name, err)
+	}
+	return r, nil
+}
+
+// Amend returns the voucher that closes a reopened period again, named as
+// the amendment of this one.
+//
+// Maps to: the Amend action of a cancelled Period Closing Voucher
+func (v *PeriodClosingVoucher) Amend() *PeriodClosingVoucher {
+	amended := *v
+	amended.Name = naming.AmendedName(v.Name, v.AmendedFrom != "")
+	amended.AmendedFrom = v.Name
+	return &amended
+}
+
+// Reclose closes a reopened period again under the voucher Amend
+// returned. Unlike Submit the checklist is required: whatever was booked
+// while the period was open is checked as it was at the first close. The
+// re-close is logged on the timeline of the reversed voucher.
+func (v *PeriodClosingVoucher) Reclose(engine *ledger.Engine, audit *timeline.Service, checklist *Checklist, gl []ledger.GLEntry, ple []ledger.PaymentLedgerEntry) (*Report, error) {
+	if v.AmendedFrom == "" {
+		return nil, fmt.Errorf("%w: %s", ErrNotReopened, v.Name)
+	}
+	if checklist == nil {
+		return nil, ErrChecklistRequired
+	}
+	if audit == nil {
+		return nil, ErrNoAuditTrail
+	}
+	previous, err := engine.GLStore.GetByVoucher(ledger.PeriodClosingVoucher, v.AmendedFrom)
+	if err != nil {
+		return nil, err
+	}
+	if len(closedAccounts(previous)) > 0 {
+		return nil, fmt.Errorf("%w: %s is still posted", ErrNotReopened, v.AmendedFrom)
+	}
+	report, err := v.Submit(engine, checklist, gl, ple)
+	if err != nil {
+		return report, err
+	}
+	from := v.AuditRef()
+	from.Name = v.AmendedFrom
+	note := fmt.Sprintf("Period %s closed again by %s", v.periodName(), v.Name)
+	if _, err := audit.Comment(from, timeline.Info, note, ""); err != nil {
+		return report, fmt.Errorf("%s posted but not logged: %w", v.Name, err)
+	}
+	return report, nil
+}
+
+// periodName describes the period for the audit trail.
+func (v *PeriodClosingVoucher) periodName() string {
+	return v.Period.Start.Format(ledger.DateLayout) + " to " + v.Period.Last().Format(ledger.DateLayout)
+}
+
+// closedAccounts returns, in order, the accounts a closing voucher's
+// entries still book a balance to. The entries of a reopened voucher and
+// their reversals net to nothing.
+func closedAccounts(entries []ledger.GLEntry) []string {
+	balances := map[string]float64{}
+	for _, e := range entries {
+		balances[e.Account] += e.Debit - e.Credit
+	}
+	var accounts []string
+	for account, b := range balances {
+		if ledger.Flt(b, 2) != 0 {
+			accounts = append(accounts, account)
+		}
+	}
+	slices.Sort(accounts)
+	return accounts
+}
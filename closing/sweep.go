@@ -0,0 +1,230 @@
+package closing
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/daterange"
+	"github.com/senguttuvang/erpnext-go/ledger"
+)
+
+// ErrInvalidSweepRule is returned for a sweep rule that cannot be applied.
+var ErrInvalidSweepRule = errors.New("invalid suspense sweep rule")
+
+// SweepSubtype is the voucher subtype of the journal entries that clear
+// suspense accounts, so that they can be told apart from manual journals.
+const SweepSubtype = "Suspense Clearing"
+
+// SweepRule moves amounts parked on a suspense or clearing account to the
+// account they belong to. An open suspense item matches when every
+// criterion set on the rule matches.
+type SweepRule struct {
+	Name            string
+	Company         string
+	SuspenseAccount string
+	TargetAccount   string
+	CostCenter      string // Booked on the target line; needed for income and expense targets
+
+	// Criteria
+	ReferencePattern string  // Regular expression searched in the voucher number and remarks
+	PartyType        string  // Optional
+	Party            string  // Optional
+	MinAmount        float64 // Lower bound of the absolute amount; 0 for none
+	MaxAmount        float64 // Upper bound of the absolute amount; 0 for none
+
+	// Party of the target line, when the target is a receivable or payable
+	TargetPartyType string
+	TargetParty     string
+
+	reference *regexp.Regexp
+}
+
+// Validate checks the rule and compiles its reference pattern.
+func (r *SweepRule) Validate() error {
+	switch {
+	case r.Name == "":
+		return fmt.Errorf("%w: name is required", ErrInvalidSweepRule)
+	case r.SuspenseAccount == "" || r.TargetAccount == "":
+		return fmt.Errorf("%w: %s: suspense and target accounts are required", ErrInvalidSweepRule, r.Name)
+	case r.SuspenseAccount == r.TargetAccount:
+		return fmt.Errorf("%w: %s: target is the suspense account", ErrInvalidSweepRule, r.Name)
+	case r.MinAmount < 0 || r.MaxAmount < 0 || (r.MaxAmount > 0 && r.MinAmount > r.MaxAmount):
+		return fmt.Errorf("%w: %s: amount bounds %.2f to %.2f", ErrInvalidSweepRule, r.Name, r.MinAmount, r.MaxAmount)
+	case (r.TargetPartyType == "") != (r.TargetParty == ""):
+		return fmt.Errorf("%w: %s: target party type and party go together", ErrInvalidSweepRule, r.Name)
+	}
+	r.reference = nil
+	if r.ReferencePattern != "" {
+		re, err := regexp.Compile(r.ReferencePattern)
+		if err != nil {
+			return fmt.Errorf("%w: %s: %v", ErrInvalidSweepRule, r.Name, err)
+		}
+		r.reference = re
+	}
+	return nil
+}
+
+// Matches reports whether a validated rule applies to an open item.
+func (r *SweepRule) Matches(item SuspenseItem) bool {
+	amount := math.Abs(item.Amount)
+	return item.Company == r.Company && item.Account == r.SuspenseAccount &&
+		(r.reference == nil || r.reference.MatchString(item.VoucherNo) || r.reference.MatchString(item.Remarks)) &&
+		(r.PartyType == "" || item.PartyType == r.PartyType) &&
+		(r.Party == "" || item.Party == r.Party) &&
+		(r.MinAmount == 0 || amount >= r.MinAmount) &&
+		(r.MaxAmount == 0 || amount <= r.MaxAmount)
+}
+
+// SuspenseItem is the balance one voucher left on a suspense account.
+type SuspenseItem struct {
+	Company     string
+	Account     string
+	VoucherType string
+	VoucherNo   string
+	PostingDate time.Time
+	PartyType   string
+	Party       string
+	Remarks     string
+	Amount      float64 // Debit positive, credit negative
+}
+
+// OpenSuspenseItems lists, per voucher, the balances left on the suspense
+// accounts up to asOf. Clearing entries are linked to the voucher they
+// clear through their against voucher, so a cleared voucher nets to zero
+// and is not listed. Items are ordered by posting date.
+func OpenSuspenseItems(entries []ledger.GLEntry, company string, accounts []string, asOf time.Time) []SuspenseItem {
+	upTo := daterange.Inclusive(time.Time{}, asOf)
+	type key struct{ account, voucherType, voucherNo string }
+	items := map[key]*SuspenseItem{}
+	var order []key
+	cancelled := ledger.CancelledEntries(entries)
+	for i, e := range entries {
+		if cancelled[i] || e.Company != company || !slices.Contains(accounts, e.Account) || !upTo.Contains(e.PostingDate) {
+			continue
+		}
+		voucherType, voucherNo := e.VoucherType, e.VoucherNo
+		if e.AgainstVoucher != "" {
+			voucherType, voucherNo = e.AgainstVoucherType, e.AgainstVoucher
+		}
+		k := key{e.Account, voucherType, voucherNo}
+		item, ok := items[k]
+		if !ok {
+			item = &SuspenseItem{Company: company, Account: e.Account, VoucherType: voucherType, VoucherNo: voucherNo, PostingDate: e.PostingDate}
+			items[k] = item
+			order = append(order, k)
+		}
+		if e.VoucherType == voucherType && e.VoucherNo == voucherNo {
+			item.PostingDate = e.PostingDate
+			if item.PartyType == "" {
+				item.PartyType, item.Party = e.PartyType, e.Party
+			}
+			if item.Remarks == "" {
+				item.Remarks = e.Remarks
+			}
+		}
+		item.Amount += e.Debit - e.Credit
+	}
+	var open []SuspenseItem
+	for _, k := range order {
+		if item := items[k]; ledger.Flt(item.Amount, 2) != 0 {
+			item.Amount = ledger.Flt(item.Amount, 2)
+			open = append(open, *item)
+		}
+	}
+	slices.SortStableFunc(open, func(a, b SuspenseItem) int { return a.PostingDate.Compare(b.PostingDate) })
+	return open
+}
+
+// Clearance is a suspense item matched by a rule, and once posted the
+// journal entry that cleared it.
+type Clearance struct {
+	Rule      string
+	Item      SuspenseItem
+	VoucherNo string // Empty until posted
+}
+
+// Match assigns each open item to the first rule, in order, that matches
+// it. Items no rule matches are left out. Nothing is posted, so Match can
+// be used to preview a sweep.
+func Match(rules []*SweepRule, items []SuspenseItem) ([]Clearance, error) {
+	for _, r := range rules {
+		if err := r.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	var out []Clearance
+	for _, item := range items {
+		for _, r := range rules {
+			if r.Matches(item) {
+				out = append(out, Clearance{Rule: r.Name, Item: item})
+				break
+			}
+		}
+	}
+	return out, nil
+}
+
+// Sweeper posts the journal entries that clear suspense items.
+type Sweeper struct {
+	Engine *ledger.Engine
+	Rules  []*SweepRule
+}
+
+// Sweep matches the open items of the suspense accounts of the rules up
+// to date and posts a journal entry for each item matched, dated date.
+// Each journal reverses the item on the suspense account against the
+// voucher that left it, and books it to the rule's target account. It
+// returns the clearances posted; on error, those posted before it.
+func (s *Sweeper) Sweep(entries []ledger.GLEntry, company string, date time.Time) ([]Clearance, error) {
+	var accounts []string
+	for _, r := range s.Rules {
+		if r.Company == company && !slices.Contains(accounts, r.SuspenseAccount) {
+			accounts = append(accounts, r.SuspenseAccount)
+		}
+	}
+	clearances, err := Match(s.Rules, OpenSuspenseItems(entries, company, accounts, date))
+	if err != nil {
+		return nil, err
+	}
+	rules := map[string]*SweepRule{}
+	for _, r := range s.Rules {
+		rules[r.Name] = r
+	}
+	for i := range clearances {
+		c := &clearances[i]
+		voucherNo := c.Rule + "/" + c.Item.VoucherNo
+		if err := s.Engine.MakeGLEntries(sweepGLMap(rules[c.Rule], c.Item, voucherNo, date), ledger.DefaultPostingOptions()); err != nil {
+			return clearances[:i], fmt.Errorf("clear %s %s: %w", c.Item.VoucherType, c.Item.VoucherNo, err)
+		}
+		c.VoucherNo = voucherNo
+	}
+	return clearances, nil
+}
+
+// sweepGLMap builds the journal clearing an item by a rule.
+func sweepGLMap(r *SweepRule, item SuspenseItem, voucherNo string, date time.Time) []ledger.GLEntry {
+	remarks := fmt.Sprintf("Cleared %s %s from %s by rule %s", item.VoucherType, item.VoucherNo, item.Account, r.Name)
+	entry := func(account string, amount float64) ledger.GLEntry {
+		e := ledger.GLEntry{
+			PostingDate: date, TransactionDate: date, Company: item.Company, Account: account,
+			VoucherType: "Journal Entry", VoucherNo: voucherNo, VoucherSubtype: SweepSubtype, Remarks: remarks,
+			IsOpening: ledger.IsOpeningNo, IsAdvance: ledger.IsAdvanceNo,
+		}
+		if amount > 0 {
+			e.Debit, e.DebitInAccountCurrency = amount, amount
+		} else {
+			e.Credit, e.CreditInAccountCurrency = -amount, -amount
+		}
+		return e
+	}
+	suspense := entry(item.Account, -item.Amount)
+	suspense.AgainstVoucherType, suspense.AgainstVoucher = item.VoucherType, item.VoucherNo
+	target := entry(r.TargetAccount, item.Amount)
+	target.CostCenter = r.CostCenter
+	target.PartyType, target.Party = r.TargetPartyType, r.TargetParty
+	return []ledger.GLEntry{suspense, target}
+}
@@ -0,0 +1,82 @@
+package coa
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/ledger"
+)
+
+func day(d int) time.Time {
+	return time.Date(2024, 4, d, 0, 0, 0, 0, time.UTC)
+}
+
+func TestTreeWithBalances(t *testing.T) {
+	accounts := []ledger.Account{
+		{Name: "Assets", Company: "ACME", IsGroup: true},
+		{Name: "Current Assets", Company: "ACME", IsGroup: true, ParentAccount: "Assets"},
+		{Name: "Cash", Company: "ACME", ParentAccount: "Current Assets"},
+		{Name: "Bank", Company: "ACME", ParentAccount: "Current Assets"},
+		{Name: "Income", Company: "ACME", IsGroup: true},
+		{Name: "Sales", Company: "ACME", ParentAccount: "Income"},
+		{Name: "Other Co Cash", Company: "Globex"},
+	}
+	entry := func(d int, account string, debit, credit float64) ledger.GLEntry {
+		return ledger.GLEntry{PostingDate: day(d), Company: "ACME", Account: account, Debit: debit, Credit: credit, VoucherType: "Journal Entry", VoucherNo: "JV"}
+	}
+	// A cancelled voucher whose reversal a store kept live
+	cancelled, reversal := entry(2, "Bank", 1000, 0), entry(2, "Bank", 0, 1000)
+	cancelled.IsCancelled = true
+	cancelled.VoucherNo, reversal.VoucherNo = "JV-C", "JV-C"
+	entries := []ledger.GLEntry{
+		entry(1, "Cash", 100, 0), entry(1, "Sales", 0, 100),
+		entry(2, "Bank", 250.5, 0), entry(2, "Sales", 0, 250.5),
+		cancelled, reversal,
+		entry(10, "Cash", 50, 0), entry(10, "Sales", 0, 50),
+	}
+
+	roots, err := TreeWithBalances(accounts, entries, day(5), ledger.GLFilter{Company: "ACME"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(roots) != 2 || roots[0].Name != "Assets" || roots[1].Name != "Income" {
+		t.Fatalf("roots = %v", roots)
+	}
+	current := roots[0].Children[0]
+	if current.Name != "Current Assets" || current.Children[0].Name != "Bank" || current.Children[1].Name != "Cash" {
+		t.Errorf("children not sorted: %+v", current.Children)
+	}
+	for name, want := range map[string]float64{"Assets": 350.5, "Current Assets": 350.5, "Cash": 100, "Bank": 250.5, "Income": -350.5} {
+		if n := Find(roots, name); n == nil || n.Balance != want {
+			t.Errorf("%s balance = %+v, want %v", name, n, want)
+		}
+	}
+	if n := Find(roots, "Income"); n.Credit != 350.5 || n.Debit != 0 {
+		t.Errorf("income = %v / %v", n.Debit, n.Credit)
+	}
+
+	// Later entries count when asOf moves forward
+	roots, _ = TreeWithBalances(accounts, entries, day(30), ledger.GLFilter{Company: "ACME"})
+	if n := Find(roots, "Assets"); n.Balance != 400.5 {
+		t.Errorf("assets on 30th = %v", n.Balance)
+	}
+
+	// Opening balances and period closing vouchers are part of the balance
+	opening, closing := entry(1, "Cash", 500, 0), entry(30, "Sales", 400.5, 0)
+	opening.IsOpening, closing.VoucherType = ledger.IsOpeningYes, ledger.PeriodClosingVoucher
+	roots, _ = TreeWithBalances(accounts, append(slices.Clone(entries), opening, closing), day(30), ledger.GLFilter{Company: "ACME"})
+	if n := Find(roots, "Cash"); n.Balance != 650 {
+		t.Errorf("cash with an opening entry = %v, want 650", n.Balance)
+	}
+	if n := Find(roots, "Income"); n.Balance != 0 {
+		t.Errorf("income after closing = %v, want 0", n.Balance)
+	}
+
+	// Entries to accounts outside the chart are reported
+	entries = append(entries, entry(1, "Suspense", 1, 0))
+	if _, err := TreeWithBalances(accounts, entries, day(5), ledger.GLFilter{Company: "ACME"}); !errors.Is(err, ledger.ErrAccountNotInTree) {
+		t.Errorf("unknown account: err = %v", err)
+	}
+}
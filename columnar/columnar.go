@@ -0,0 +1,404 @@
+// Package columnar holds GL entries column by column for analytics.
+//
+// Reports such as the trial balance and the financial statements read a
+// handful of fields of every entry and sum two of them. Scanning
+// []ledger.GLEntry for that touches whole 400-byte structs and compares
+// strings per entry; a Ledger instead keeps each field in its own slice,
+// with strings dictionary-encoded to small integer codes and dates as day
+// numbers. A filter is resolved to codes once and then applied as tight
+// loops over the columns, narrowing a selection vector, and the sums are
+// accumulated into arrays indexed by account code.
+//
+// A Ledger is read-only once built and safe for concurrent readers. It is
+// filled from entries held in memory with FromEntries or Append; readers
+// of other formats, such as Parquet files, append the rows they decode.
+//
+// Maps to: the GL queries of erpnext/accounts/report/trial_balance and
+// financial_statements.py, which the database aggregates with SUM ... GROUP
+// BY account
+package columnar
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/daterange"
+	"github.com/senguttuvang/erpnext-go/ledger"
+)
+
+// Row flags
+const (
+	flagCancelled uint8 = 1 << iota
+	flagOpening
+	flagPeriodClosing
+)
+
+// dict encodes the distinct strings of a column as codes in order of
+// first appearance.
+type dict struct {
+	values []string
+	codes  map[string]uint32
+}
+
+func (d *dict) code(s string) uint32 {
+	if c, ok := d.codes[s]; ok {
+		return c
+	}
+	if d.codes == nil {
+		d.codes = make(map[string]uint32)
+	}
+	c := uint32(len(d.values))
+	d.values = append(d.values, s)
+	d.codes[s] = c
+	return c
+}
+
+// column is a dictionary-encoded string column.
+type column struct {
+	dict
+	rows []uint32
+}
+
+func (c *column) append(s string) { c.rows = append(c.rows, c.code(s)) }
+
+// Ledger is a set of GL entries stored as columns.
+type Ledger struct {
+	company, account, costCenter column
+	partyType, party             column
+	voucherType, voucherNo       column
+
+	date          []int32 // Civil days since 1970-01-01
+	debit, credit []float64
+	flags         []uint8
+}
+
+// FromEntries returns a Ledger of entries.
+func FromEntries(entries []ledger.GLEntry) *Ledger {
+	l := &Ledger{}
+	l.Grow(len(entries))
+	for i := range entries {
+		l.Append(&entries[i])
+	}
+	return l
+}
+
+// Grow makes room for n more entries.
+func (l *Ledger) Grow(n int) {
+	for _, c := range l.columns() {
+		c.rows = slices.Grow(c.rows, n)
+	}
+	l.date = slices.Grow(l.date, n)
+	l.debit = slices.Grow(l.debit, n)
+	l.credit = slices.Grow(l.credit, n)
+	l.flags = slices.Grow(l.flags, n)
+}
+
+// Append adds an entry. Only the fields reports filter and sum on are
+// kept, with amounts in company currency.
+func (l *Ledger) Append(e *ledger.GLEntry) {
+	l.company.append(e.Company)
+	l.account.append(e.Account)
+	l.costCenter.append(e.CostCenter)
+	l.partyType.append(e.PartyType)
+	l.party.append(e.Party)
+	l.voucherType.append(e.VoucherType)
+	l.voucherNo.append(e.VoucherNo)
+	l.date = append(l.date, day(e.PostingDate))
+	l.debit = append(l.debit, e.Debit)
+	l.credit = append(l.credit, e.Credit)
+	var flags uint8
+	if e.IsCancelled {
+		flags |= flagCancelled
+	}
+	if e.IsOpening == ledger.IsOpeningYes {
+		flags |= flagOpening
+	}
+	if e.VoucherType == ledger.PeriodClosingVoucher {
+		flags |= flagPeriodClosing
+	}
+	l.flags = append(l.flags, flags)
+}
+
+// Len returns the number of entries.
+func (l *Ledger) Len() int { return len(l.date) }
+
+func (l *Ledger) columns() []*column {
+	return []*column{&l.company, &l.account, &l.costCenter, &l.partyType, &l.party, &l.voucherType, &l.voucherNo}
+}
+
+// Totals are the debits and credits of an account, in company currency.
+type Totals struct {
+	Debit  float64
+	Credit float64
+}
+
+// Balance returns Debit - Credit.
+func (t Totals) Balance() float64 { return ledger.Flt(t.Debit-t.Credit, 2) }
+
+// Totals sums the entries matching the filter by account, as
+// ledger.QueryGL would select them. Accounts without matching entries are
+// omitted.
+func (l *Ledger) Totals(f ledger.GLFilter, tree *ledger.AccountTree) (map[string]Totals, error) {
+	sel, err := l.selectRows(f, tree, true)
+	if err != nil {
+		return nil, err
+	}
+	debit, credit, seen := l.sumByAccount(sel)
+	out := make(map[string]Totals)
+	for code, ok := range seen {
+		if ok {
+			out[l.account.values[code]] = Totals{Debit: ledger.Flt(debit[code], 2), Credit: ledger.Flt(credit[code], 2)}
+		}
+	}
+	return out, nil
+}
+
+// Balances returns debit minus credit by account of the entries matching
+// the filter, as statement.FromBalances takes them.
+func (l *Ledger) Balances(f ledger.GLFilter, tree *ledger.AccountTree) (map[string]float64, error) {
+	totals, err := l.Totals(f, tree)
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string]float64, len(totals))
+	for account, t := range totals {
+		out[account] = t.Balance()
+	}
+	return out, nil
+}
+
+// TrialBalanceRow is an account's line on the trial balance. Opening and
+// closing balances are shown on their debit or credit side.
+type TrialBalanceRow struct {
+	Account                     string
+	OpeningDebit, OpeningCredit float64
+	Debit, Credit               float64 // Movement of the period
+	ClosingDebit, ClosingCredit float64
+}
+
+// TrialBalance returns the opening balance, the movement in the period
+// f.From to f.To and the closing balance of every account with entries
+// matching the other fields of the filter, ordered by account. Entries
+// before the period and opening entries make up the opening balance, as
+// in the report; f.IncludeOpening is ignored.
+//
+// Maps to: get_rows() and calculate_values() in trial_balance.py
+func (l *Ledger) TrialBalance(f ledger.GLFilter, tree *ledger.AccountTree) ([]TrialBalanceRow, error) {
+	sel, err := l.selectRows(f, tree, false)
+	if err != nil {
+		return nil, err
+	}
+	from, to := bounds(f)
+	n := len(l.account.values)
+	opening, debit, credit := make([]float64, n), make([]float64, n), make([]float64, n)
+	seen := make([]bool, n)
+	for _, i := range sel {
+		a, d := l.account.rows[i], l.date[i]
+		switch {
+		case d > to:
+			continue
+		case d < from || l.flags[i]&flagOpening != 0:
+			opening[a] += l.debit[i] - l.credit[i]
+		default:
+			debit[a] += l.debit[i]
+			credit[a] += l.credit[i]
+		}
+		seen[a] = true
+	}
+
+	var rows []TrialBalanceRow
+	for a, ok := range seen {
+		if !ok {
+			continue
+		}
+		row := TrialBalanceRow{Account: l.account.values[a], Debit: ledger.Flt(debit[a], 2), Credit: ledger.Flt(credit[a], 2)}
+		row.OpeningDebit, row.OpeningCredit = sides(opening[a])
+		row.ClosingDebit, row.ClosingCredit = sides(opening[a] + debit[a] - credit[a])
+		rows = append(rows, row)
+	}
+	slices.SortFunc(rows, func(a, b TrialBalanceRow) int { return cmp.Compare(a.Account, b.Account) })
+	return rows, nil
+}
+
+// sides splits a debit-minus-credit balance into its debit or credit side.
+func sides(balance float64) (debit, credit float64) {
+	balance = ledger.Flt(balance, 2)
+	if balance >= 0 {
+		return balance, 0
+	}
+	return 0, -balance
+}
+
+// sumByAccount sums the selected rows into arrays indexed by account code.
+func (l *Ledger) sumByAccount(sel []uint32) (debit, credit []float64, seen []bool) {
+	n := len(l.account.values)
+	debit, credit, seen = make([]float64, n), make([]float64, n), make([]bool, n)
+	for _, i := range sel {
+		a := l.account.rows[i]
+		debit[a] += l.debit[i]
+		credit[a] += l.credit[i]
+		seen[a] = true
+	}
+	return debit, credit, seen
+}
+
+// selectRows returns the indexes of the rows matching the filter. Each
+// condition is one pass over its column, narrowing the selection. The
+// period is only applied when withPeriod is set.
+func (l *Ledger) selectRows(f ledger.GLFilter, tree *ledger.AccountTree, withPeriod bool) ([]uint32, error) {
+	var accounts []bool // By account code
+	if f.Account != "" {
+		names := []string{f.Account}
+		if tree != nil {
+			if !tree.Has(f.Account) {
+				return nil, fmt.Errorf("%w: %s", ledger.ErrAccountNotInTree, f.Account)
+			}
+			names = append(names, tree.Descendants(f.Account)...)
+		}
+		accounts = make([]bool, len(l.account.values))
+		for _, name := range names {
+			if c, ok := l.account.codes[name]; ok {
+				accounts[c] = true
+			}
+		}
+	}
+
+	// Flags and dates first: they need no lookup and drop the most rows
+	var skip uint8
+	var reversals []bool
+	if !f.IncludeCancelled {
+		skip |= flagCancelled
+		reversals = l.liveReversals()
+	}
+	if !f.IncludeOpening && withPeriod {
+		skip |= flagOpening
+	}
+	if !f.IncludePeriodClosing {
+		skip |= flagPeriodClosing
+	}
+	from, to := int32(math.MinInt32), int32(math.MaxInt32)
+	if withPeriod {
+		from, to = bounds(f)
+	}
+	sel := make([]uint32, 0, l.Len())
+	for i, flags := range l.flags {
+		if flags&skip == 0 && l.date[i] >= from && l.date[i] <= to && (reversals == nil || !reversals[i]) {
+			sel = append(sel, uint32(i))
+		}
+	}
+
+	for _, cond := range []struct {
+		c     *column
+		value string
+	}{
+		{&l.company, f.Company}, {&l.partyType, f.PartyType}, {&l.party, f.Party},
+		{&l.voucherType, f.VoucherType}, {&l.voucherNo, f.VoucherNo}, {&l.costCenter, f.CostCenter},
+	} {
+		if cond.value == "" {
+			continue
+		}
+		code, ok := cond.c.codes[cond.value]
+		if !ok {
+			return nil, nil
+		}
+		sel = keepEqual(sel, cond.c.rows, code)
+	}
+	if accounts != nil {
+		sel = keepIn(sel, l.account.rows, accounts)
+	}
+	return sel, nil
+}
+
+// liveReversals marks the rows that reverse a cancelled row without being
+// flagged themselves, as stores written before the engine flagged its
+// reversals hold them, or returns nil when there are none. Rows are
+// paired as ledger.CancelledEntries pairs entries, on the columns kept.
+func (l *Ledger) liveReversals() []bool {
+	type line struct {
+		codes         [7]uint32
+		date          int32
+		debit, credit float64
+	}
+	lineOf := func(i int) line {
+		var codes [7]uint32
+		for c, col := range l.columns() {
+			codes[c] = col.rows[i]
+		}
+		return line{codes, l.date[i], ledger.Flt(l.debit[i], 2), ledger.Flt(l.credit[i], 2)}
+	}
+	mirror := func(x line) line {
+		x.debit, x.credit = x.credit, x.debit
+		return x
+	}
+
+	unpaired := map[line]int{}
+	for i, flags := range l.flags {
+		if flags&flagCancelled == 0 {
+			continue
+		}
+		if x := lineOf(i); unpaired[mirror(x)] > 0 {
+			unpaired[mirror(x)]--
+		} else {
+			unpaired[x]++
+		}
+	}
+	if len(unpaired) == 0 {
+		return nil
+	}
+	var reversals []bool
+	for i, flags := range l.flags {
+		if flags&flagCancelled != 0 {
+			continue
+		}
+		if m := mirror(lineOf(i)); unpaired[m] > 0 {
+			unpaired[m]--
+			if reversals == nil {
+				reversals = make([]bool, len(l.flags))
+			}
+			reversals[i] = true
+		}
+	}
+	return reversals
+}
+
+// keepEqual narrows sel in place to the rows whose code is code.
+func keepEqual(sel, rows []uint32, code uint32) []uint32 {
+	out := sel[:0]
+	for _, i := range sel {
+		if rows[i] == code {
+			out = append(out, i)
+		}
+	}
+	return out
+}
+
+// keepIn narrows sel in place to the rows whose code is in set.
+func keepIn(sel, rows []uint32, set []bool) []uint32 {
+	out := sel[:0]
+	for _, i := range sel {
+		if set[rows[i]] {
+			out = append(out, i)
+		}
+	}
+	return out
+}
+
+// bounds returns the filter's period as inclusive day numbers.
+func bounds(f ledger.GLFilter) (from, to int32) {
+	from, to = math.MinInt32, math.MaxInt32
+	if !f.From.IsZero() {
+		from = day(f.From)
+	}
+	if !f.To.IsZero() {
+		to = day(f.To)
+	}
+	return from, to
+}
+
+// day returns the civil day number of t.
+func day(t time.Time) int32 {
+	return int32(daterange.Civil(t).Unix() / 86400)
+}
@@ -0,0 +1,159 @@
+package columnar
+
+import (
+	"testing"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/ledger"
+	"github.com/senguttuvang/erpnext-go/perf"
+)
+
+func date(s string) time.Time {
+	t, err := ledger.ParseDate(s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// scanTotals sums the entries QueryGL selects, the row-wise reference.
+func scanTotals(t testing.TB, entries []ledger.GLEntry, f ledger.GLFilter, tree *ledger.AccountTree) map[string]Totals {
+	matched, err := ledger.QueryGL(entries, f, tree)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := make(map[string]Totals)
+	for _, e := range matched {
+		tot := out[e.Account]
+		tot.Debit += e.Debit
+		tot.Credit += e.Credit
+		out[e.Account] = tot
+	}
+	for a, tot := range out {
+		out[a] = Totals{Debit: ledger.Flt(tot.Debit, 2), Credit: ledger.Flt(tot.Credit, 2)}
+	}
+	return out
+}
+
+func TestTotalsMatchQueryGL(t *testing.T) {
+	entries := perf.GenerateGLMap(4000)
+	// The first voucher cancelled, and the last cancelled with its
+	// reversals saved live, as older stores hold them
+	last := entries[len(entries)-1].VoucherNo
+	for i := range entries {
+		switch entries[i].VoucherNo {
+		case entries[0].VoucherNo:
+			entries[i].IsCancelled = true
+		case last:
+			reversal := entries[i].Copy()
+			reversal.Debit, reversal.Credit = entries[i].Credit, entries[i].Debit
+			entries[i].IsCancelled = true
+			entries = append(entries, reversal)
+		}
+	}
+	entries[1].IsOpening = ledger.IsOpeningYes
+	l := FromEntries(entries)
+	if l.Len() != len(entries) {
+		t.Fatalf("Len = %d, want %d", l.Len(), len(entries))
+	}
+
+	filters := []ledger.GLFilter{
+		{},
+		{Company: "ACME Industries Pvt Ltd", From: date("2024-06-01"), To: date("2024-08-31")},
+		{Account: "Debtors - ACME", PartyType: "Customer", Party: "Customer 007"},
+		{VoucherType: "Sales Invoice", VoucherNo: "SINV-2024-000010"},
+		{IncludeCancelled: true, ReportFlags: ledger.ReportFlags{IncludeOpening: true}},
+		{Company: "Other"},
+	}
+	for _, f := range filters {
+		got, err := l.Totals(f, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := scanTotals(t, entries, f, nil)
+		if len(got) != len(want) {
+			t.Errorf("%+v: %d accounts, want %d", f, len(got), len(want))
+		}
+		for account, w := range want {
+			if got[account] != w {
+				t.Errorf("%+v: %s = %+v, want %+v", f, account, got[account], w)
+			}
+		}
+	}
+}
+
+func TestTrialBalance(t *testing.T) {
+	tree, err := ledger.NewAccountTree(
+		ledger.Account{Name: "Assets", IsGroup: true},
+		ledger.Account{Name: "Cash", ParentAccount: "Assets"},
+		ledger.Account{Name: "Bank", ParentAccount: "Assets"},
+		ledger.Account{Name: "Capital"},
+		ledger.Account{Name: "Sales"},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry := func(d, account string, debit, credit float64) ledger.GLEntry {
+		return ledger.GLEntry{Company: "Acme", PostingDate: date(d), Account: account, VoucherType: "Journal Entry", Debit: debit, Credit: credit}
+	}
+	opening := entry("2024-04-01", "Cash", 500, 0)
+	opening.IsOpening = ledger.IsOpeningYes
+	openingCapital := entry("2024-04-01", "Capital", 0, 500)
+	openingCapital.IsOpening = ledger.IsOpeningYes
+	cancelled := entry("2024-04-05", "Cash", 999, 0)
+	cancelled.IsCancelled = true
+	l := FromEntries([]ledger.GLEntry{
+		opening, openingCapital, cancelled,
+		entry("2024-03-20", "Cash", 100, 0), entry("2024-03-20", "Sales", 0, 100), // Before the period
+		entry("2024-04-10", "Bank", 300, 0), entry("2024-04-10", "Sales", 0, 300),
+		entry("2024-04-12", "Cash", 0, 50), entry("2024-04-12", "Bank", 50, 0),
+		entry("2024-05-02", "Bank", 70, 0), entry("2024-05-02", "Sales", 0, 70), // After the period
+	})
+
+	rows, err := l.TrialBalance(ledger.GLFilter{Company: "Acme", From: date("2024-04-01"), To: date("2024-04-30")}, tree)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []TrialBalanceRow{
+		{Account: "Bank", Debit: 350, ClosingDebit: 350},
+		{Account: "Capital", OpeningCredit: 500, ClosingCredit: 500},
+		{Account: "Cash", OpeningDebit: 600, Credit: 50, ClosingDebit: 550},
+		{Account: "Sales", OpeningCredit: 100, Credit: 300, ClosingCredit: 400},
+	}
+	if len(rows) != len(want) {
+		t.Fatalf("rows = %+v", rows)
+	}
+	for i := range want {
+		if rows[i] != want[i] {
+			t.Errorf("row %d = %+v, want %+v", i, rows[i], want[i])
+		}
+	}
+
+	// A group account selects its descendants
+	balances, err := l.Balances(ledger.GLFilter{Account: "Assets", To: date("2024-04-30")}, tree)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(balances) != 2 || balances["Cash"] != 50 || balances["Bank"] != 350 {
+		t.Errorf("asset balances = %v", balances)
+	}
+}
+
+func BenchmarkTotals(b *testing.B) {
+	entries := perf.GenerateGLMap(200_000)
+	f := ledger.GLFilter{Company: "ACME Industries Pvt Ltd", From: date("2024-06-01"), To: date("2024-12-31")}
+	b.Run("QueryGL", func(b *testing.B) {
+		for b.Loop() {
+			scanTotals(b, entries, f, nil)
+		}
+	})
+	b.Run("Columnar", func(b *testing.B) {
+		l := FromEntries(entries)
+		b.ResetTimer()
+		for b.Loop() {
+			if _, err := l.Totals(f, nil); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,328 @@
+// Package conformance is a fixture-driven suite that checks a ledger
+// Engine or tax Calculator configuration against the behaviour of ERPNext.
+//
+// Each fixture is a Case: the input of an ERPNext scenario, a GL map to
+// post or a document to calculate, and the output expected for it. The
+// expected outputs are worked out by hand from the ERPNext code each case
+// names as its Source; they are not taken from ERPNext's own test suite
+// or from a running ERPNext, so a passing run shows agreement on these
+// scenarios, not certification against ERPNext. Run feeds every case to the Subject under test and compares the
+// canonical JSON of what it produced with that of the expected output,
+// byte for byte. Adapter and fork authors run the embedded Cases against
+// their own stores, engines and precision settings; Load reads further
+// fixtures kept alongside their code.
+//
+// Only the fields ERPNext and this module agree on are compared: accounts,
+// parties, cost centers, amounts and the cancelled flag for GL entries,
+// totals and tax rows for documents. Amounts are compared at two
+// decimals.
+//
+// Maps to: make_gl_entries() and calculate_taxes_and_totals, in the form
+// of the expected GL entries and totals ERPNext's tests assert
+package conformance
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/senguttuvang/erpnext-go/ledger"
+	"github.com/senguttuvang/erpnext-go/ledger/memstore"
+	"github.com/senguttuvang/erpnext-go/taxcalc"
+)
+
+// Conformance errors
+var (
+	ErrInvalidCase = errors.New("invalid conformance case")
+	ErrMismatch    = errors.New("output differs from the expected output")
+)
+
+//go:embed fixtures/*.json
+var fixtures embed.FS
+
+// Case is one fixture: a GL case or a tax case.
+type Case struct {
+	Name        string `json:"name"`
+	Source      string `json:"source"` // The ERPNext code the expected output is worked out from
+	Description string `json:"description"`
+
+	GL  *GLCase  `json:"gl,omitempty"`
+	Tax *TaxCase `json:"tax,omitempty"`
+}
+
+// GLCase posts GL maps through an Engine and expects the entries stored
+// for the voucher afterwards, in the order they were saved.
+type GLCase struct {
+	Steps    []Step   `json:"steps"`
+	Expected []GLLine `json:"expected"`
+}
+
+// Step is one posting of a GL case. A cancellation without entries
+// cancels the GL map of the step before.
+type Step struct {
+	Entries      []ledger.GLEntry `json:"entries"`
+	Cancel       bool             `json:"cancel"`
+	MergeEntries *bool            `json:"merge_entries,omitempty"` // True when absent, as in make_gl_entries
+}
+
+// GLLine is the canonical form of a GL entry.
+type GLLine struct {
+	Account                 string  `json:"account"`
+	PartyType               string  `json:"party_type,omitempty"`
+	Party                   string  `json:"party,omitempty"`
+	CostCenter              string  `json:"cost_center,omitempty"`
+	Debit                   float64 `json:"debit"`
+	Credit                  float64 `json:"credit"`
+	DebitInAccountCurrency  float64 `json:"debit_in_account_currency"`
+	CreditInAccountCurrency float64 `json:"credit_in_account_currency"`
+	IsCancelled             bool    `json:"is_cancelled"`
+}
+
+// TaxCase calculates a document and expects its totals.
+type TaxCase struct {
+	Document  *taxcalc.Document    `json:"document"`
+	Precision taxcalc.PrecisionMap `json:"precision,omitempty"`
+	Expected  *Totals              `json:"expected"`
+}
+
+// Totals is the canonical form of a calculated document.
+type Totals struct {
+	Total      float64   `json:"total"`
+	NetTotal   float64   `json:"net_total"`
+	GrandTotal float64   `json:"grand_total"`
+	Taxes      []TaxLine `json:"taxes"`
+}
+
+// TaxLine is the canonical form of a tax row.
+type TaxLine struct {
+	AccountHead string  `json:"account_head"`
+	TaxAmount   float64 `json:"tax_amount"`
+	Total       float64 `json:"total"`
+}
+
+// Validate checks that the case has exactly one kind, with input and an
+// expected output.
+func (c *Case) Validate() error {
+	switch {
+	case c.Name == "":
+		return fmt.Errorf("%w: no name", ErrInvalidCase)
+	case (c.GL == nil) == (c.Tax == nil):
+		return fmt.Errorf("%w: %s must be either a GL or a tax case", ErrInvalidCase, c.Name)
+	case c.GL != nil && (len(c.GL.Steps) == 0 || len(c.GL.Steps[0].Entries) == 0 || c.GL.Expected == nil):
+		return fmt.Errorf("%w: %s needs a first step with entries and expected entries", ErrInvalidCase, c.Name)
+	case c.Tax != nil && (c.Tax.Document == nil || c.Tax.Expected == nil):
+		return fmt.Errorf("%w: %s needs a document and expected totals", ErrInvalidCase, c.Name)
+	}
+	return nil
+}
+
+// Cases returns the embedded suite, ordered by name.
+func Cases() ([]Case, error) {
+	return Load(fixtures)
+}
+
+// Load reads every .json file of a file system as a Case, ordered by
+// name.
+func Load(fsys fs.FS) ([]Case, error) {
+	var cases []Case
+	err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || path.Ext(name) != ".json" {
+			return err
+		}
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return err
+		}
+		var c Case
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&c); err != nil {
+			return fmt.Errorf("%w: %s: %v", ErrInvalidCase, name, err)
+		}
+		if err := c.Validate(); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		cases = append(cases, c)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	slices.SortFunc(cases, func(a, b Case) int { return strings.Compare(a.Name, b.Name) })
+	return cases, nil
+}
+
+// Subject is the configuration under test. A nil field falls back to the
+// module's own: an Engine over an in-memory store, and a Calculator with
+// the case's precision.
+type Subject struct {
+	// NewEngine returns a fresh engine for each GL case. Entries are read
+	// back from its GLStore.
+	NewEngine func() *ledger.Engine
+
+	// Calculate calculates the document of a tax case in place.
+	Calculate func(doc *taxcalc.Document, precision taxcalc.PrecisionProvider) error
+}
+
+// Failure is a case the subject did not conform on. Want and Got are the
+// canonical JSON compared, Got nil when the subject failed with Err.
+type Failure struct {
+	Case      string
+	Err       error
+	Want, Got []byte
+}
+
+// Report is the outcome of a run.
+type Report struct {
+	Passed   int
+	Failures []Failure
+}
+
+// OK reports whether every case conformed.
+func (r Report) OK() bool {
+	return len(r.Failures) == 0
+}
+
+func (r Report) String() string {
+	return fmt.Sprintf("conformance: %d passed, %d failed", r.Passed, len(r.Failures))
+}
+
+// Run runs the cases against the subject.
+func Run(s Subject, cases []Case) Report {
+	var report Report
+	for _, c := range cases {
+		var want, got []byte
+		var err error
+		if c.GL != nil {
+			want, got, err = s.runGL(c.GL)
+		} else {
+			want, got, err = s.runTax(c.Tax)
+		}
+		if err == nil && !bytes.Equal(want, got) {
+			err = fmt.Errorf("%w: %s", ErrMismatch, c.Name)
+		}
+		if err != nil {
+			report.Failures = append(report.Failures, Failure{Case: c.Name, Err: err, Want: want, Got: got})
+			continue
+		}
+		report.Passed++
+	}
+	return report
+}
+
+func (s Subject) runGL(c *GLCase) (want, got []byte, err error) {
+	want, err = canonical(roundLines(c.Expected))
+	if err != nil {
+		return nil, nil, err
+	}
+	engine := &ledger.Engine{GLStore: memstore.NewGLStore()}
+	if s.NewEngine != nil {
+		engine = s.NewEngine()
+	}
+	var previous []ledger.GLEntry
+	for _, step := range c.Steps {
+		entries := step.Entries
+		if step.Cancel && len(entries) == 0 {
+			entries = previous
+		}
+		opts := ledger.DefaultPostingOptions()
+		opts.Cancel = step.Cancel
+		if step.MergeEntries != nil {
+			opts.MergeEntries = *step.MergeEntries
+		}
+		// The engine may process a GL map in place; keep the fixture intact
+		glMap := make([]ledger.GLEntry, len(entries))
+		for i := range entries {
+			glMap[i] = entries[i].Copy()
+		}
+		if err := engine.MakeGLEntries(glMap, opts); err != nil {
+			return want, nil, err
+		}
+		previous = entries
+	}
+
+	first := c.Steps[0].Entries[0]
+	stored, err := engine.GLStore.GetByVoucher(first.VoucherType, first.VoucherNo)
+	if err != nil {
+		return want, nil, err
+	}
+	lines := make([]GLLine, len(stored))
+	for i, e := range stored {
+		lines[i] = GLLine{
+			Account: e.Account, PartyType: e.PartyType, Party: e.Party, CostCenter: e.CostCenter,
+			Debit: e.Debit, Credit: e.Credit,
+			DebitInAccountCurrency: e.DebitInAccountCurrency, CreditInAccountCurrency: e.CreditInAccountCurrency,
+			IsCancelled: e.IsCancelled,
+		}
+	}
+	got, err = canonical(roundLines(lines))
+	return want, got, err
+}
+
+func (s Subject) runTax(c *TaxCase) (want, got []byte, err error) {
+	want, err = canonical(roundTotals(*c.Expected))
+	if err != nil {
+		return nil, nil, err
+	}
+	// Round trip the document so the fixture is never calculated in place
+	data, err := json.Marshal(c.Document)
+	if err != nil {
+		return want, nil, err
+	}
+	var doc taxcalc.Document
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return want, nil, err
+	}
+	var precision taxcalc.PrecisionProvider
+	if c.Precision != nil {
+		precision = c.Precision
+	}
+	calculate := func(doc *taxcalc.Document, precision taxcalc.PrecisionProvider) error {
+		return taxcalc.NewCalculator(doc, precision).Calculate()
+	}
+	if s.Calculate != nil {
+		calculate = s.Calculate
+	}
+	if err := calculate(&doc, precision); err != nil {
+		return want, nil, err
+	}
+
+	totals := Totals{Total: doc.Total, NetTotal: doc.NetTotal, GrandTotal: doc.GrandTotal, Taxes: []TaxLine{}}
+	for _, tax := range doc.Taxes {
+		totals.Taxes = append(totals.Taxes, TaxLine{AccountHead: tax.AccountHead, TaxAmount: tax.TaxAmount, Total: tax.Total})
+	}
+	got, err = canonical(roundTotals(totals))
+	return want, got, err
+}
+
+// canonical returns the compact JSON compared by Run.
+func canonical(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func roundLines(lines []GLLine) []GLLine {
+	out := make([]GLLine, len(lines))
+	for i, l := range lines {
+		l.Debit, l.Credit = ledger.Flt(l.Debit, 2), ledger.Flt(l.Credit, 2)
+		l.DebitInAccountCurrency, l.CreditInAccountCurrency = ledger.Flt(l.DebitInAccountCurrency, 2), ledger.Flt(l.CreditInAccountCurrency, 2)
+		out[i] = l
+	}
+	return out
+}
+
+func roundTotals(t Totals) Totals {
+	t.Total, t.NetTotal, t.GrandTotal = ledger.Flt(t.Total, 2), ledger.Flt(t.NetTotal, 2), ledger.Flt(t.GrandTotal, 2)
+	taxes := make([]TaxLine, len(t.Taxes))
+	for i, tax := range t.Taxes {
+		tax.TaxAmount, tax.Total = ledger.Flt(tax.TaxAmount, 2), ledger.Flt(tax.Total, 2)
+		taxes[i] = tax
+	}
+	t.Taxes = taxes
+	return t
+}
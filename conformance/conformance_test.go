@@ -0,0 +1,103 @@
+package conformance
+
+import (
+	"errors"
+	"testing"
+	"testing/fstest"
+
+	"github.com/senguttuvang/erpnext-go/ledger"
+	"github.com/senguttuvang/erpnext-go/ledger/memstore"
+	"github.com/senguttuvang/erpnext-go/taxcalc"
+)
+
+func TestSuite(t *testing.T) {
+	cases, err := Cases()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cases) == 0 {
+		t.Fatal("no cases embedded")
+	}
+	report := Run(Subject{}, cases)
+	for _, f := range report.Failures {
+		t.Errorf("%s: %v\nwant %s\n got %s", f.Case, f.Err, f.Want, f.Got)
+	}
+	if report.Passed != len(cases) {
+		t.Errorf("%v of %d cases", report, len(cases))
+	}
+}
+
+func TestRunReportsNonConformingSubject(t *testing.T) {
+	cases, err := Cases()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A fork that never merges entries and drops deducted taxes
+	subject := Subject{
+		NewEngine: func() *ledger.Engine {
+			return &ledger.Engine{GLStore: memstore.NewGLStore()}
+		},
+		Calculate: func(doc *taxcalc.Document, precision taxcalc.PrecisionProvider) error {
+			for _, tax := range doc.Taxes {
+				if tax.AddDeductTax == taxcalc.Deduct {
+					tax.AddDeductTax = taxcalc.Add
+				}
+			}
+			return taxcalc.NewCalculator(doc, precision).Calculate()
+		},
+	}
+	no := false
+	for _, c := range cases {
+		if c.GL == nil {
+			continue
+		}
+		for j := range c.GL.Steps {
+			c.GL.Steps[j].MergeEntries = &no
+		}
+	}
+	report := Run(subject, cases)
+	if report.OK() {
+		t.Fatal("non-conforming subject passed")
+	}
+	failed := map[string]bool{}
+	for _, f := range report.Failures {
+		if !errors.Is(f.Err, ErrMismatch) {
+			t.Errorf("%s: err = %v, want ErrMismatch", f.Case, f.Err)
+		}
+		failed[f.Case] = true
+	}
+	for _, name := range []string{"gl_merge_similar_entries", "tax_deducted"} {
+		if !failed[name] {
+			t.Errorf("%s passed", name)
+		}
+	}
+	if failed["tax_on_net_total"] {
+		t.Error("tax_on_net_total failed")
+	}
+}
+
+func TestLoad(t *testing.T) {
+	valid := `{"name": "b", "tax": {"document": {"Items": [{"ItemCode": "X", "Qty": 1, "Rate": 10}]}, "expected": {"total": 10, "net_total": 10, "grand_total": 10, "taxes": []}}}`
+	cases, err := Load(fstest.MapFS{
+		"b.json":     {Data: []byte(valid)},
+		"a/a.json":   {Data: []byte(`{"name": "a", "tax": {"document": {"Items": []}, "expected": {"taxes": []}}}`)},
+		"README.txt": {Data: []byte("not a case")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cases) != 2 || cases[0].Name != "a" || cases[1].Name != "b" {
+		t.Errorf("cases = %+v", cases)
+	}
+
+	for name, data := range map[string]string{
+		"no kind":       `{"name": "c"}`,
+		"both kinds":    `{"name": "c", "gl": {}, "tax": {}}`,
+		"no expected":   `{"name": "c", "tax": {"document": {}}}`,
+		"unknown field": `{"name": "c", "tax": {"document": {}, "expected": {}}, "extra": 1}`,
+	} {
+		if _, err := Load(fstest.MapFS{"c.json": {Data: []byte(data)}}); !errors.Is(err, ErrInvalidCase) {
+			t.Errorf("%s: err = %v, want ErrInvalidCase", name, err)
+		}
+	}
+}
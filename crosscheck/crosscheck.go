@@ -0,0 +1,180 @@
+// Package crosscheck reconciles taxcalc and ledger on randomized invoices.
+//
+// Each generated Sales Invoice is calculated, turned into a GL map by the
+// salesinvoice controller and posted through a ledger.Engine backed by
+// in-memory stores. The harness then verifies that the document totals and
+// the ledger agree: the receivable equals the grand total, income equals the
+// net total, every tax account matches its tax row, and the posted entries
+// balance. It is meant to be run from tests, CI jobs and ad hoc tools
+// whenever either package changes.
+package crosscheck
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/senguttuvang/erpnext-go/ledger"
+	"github.com/senguttuvang/erpnext-go/ledger/memstore"
+	"github.com/senguttuvang/erpnext-go/numeric"
+	"github.com/senguttuvang/erpnext-go/salesinvoice"
+	"github.com/senguttuvang/erpnext-go/taxcalc"
+)
+
+// ErrMismatch indicates that taxcalc totals and the GL map disagree.
+var ErrMismatch = errors.New("document totals and GL entries do not reconcile")
+
+// RoundOffAccount receives rounding differences when posting generated invoices.
+const RoundOffAccount = "Round Off - XC"
+
+// Failure records one invoice that did not reconcile.
+type Failure struct {
+	Index   int
+	Invoice *salesinvoice.SalesInvoice
+	Err     error
+}
+
+// Report summarizes a randomized run.
+type Report struct {
+	Seed     uint64
+	Checked  int
+	Skipped  int // Invoices rejected by validation (e.g., zero totals)
+	Failures []Failure
+}
+
+// OK returns true if every checked invoice reconciled.
+func (r Report) OK() bool {
+	return len(r.Failures) == 0
+}
+
+func (r Report) String() string {
+	return fmt.Sprintf("crosscheck seed=%d: %d checked, %d skipped, %d failed",
+		r.Seed, r.Checked, r.Skipped, len(r.Failures))
+}
+
+// Run generates n invoices from seed and verifies each of them.
+// The same seed and config always produce the same invoices, so a failure
+// can be replayed with Generator.Invoice.
+func Run(seed uint64, n int, cfg Config) Report {
+	gen := NewGenerator(seed, cfg)
+	report := Report{Seed: seed}
+
+	for i := 0; i < n; i++ {
+		inv := gen.Invoice(i)
+		err := Verify(inv)
+		switch {
+		case err == nil:
+			report.Checked++
+		case errors.Is(err, errSkip):
+			report.Skipped++
+		default:
+			report.Checked++
+			report.Failures = append(report.Failures, Failure{Index: i, Invoice: inv, Err: err})
+		}
+	}
+
+	return report
+}
+
+// errSkip marks invoices that are legitimately not postable.
+var errSkip = errors.New("invoice not postable")
+
+// Verify calculates and posts a single invoice and reconciles the result.
+func Verify(inv *salesinvoice.SalesInvoice) error {
+	if err := inv.Calculate(nil); err != nil {
+		return fmt.Errorf("%w: %v", errSkip, err)
+	}
+	if inv.BaseGrandTotal == 0 {
+		return errSkip
+	}
+
+	glMap, err := inv.GetGLEntries()
+	if err != nil {
+		return err
+	}
+
+	if err := reconcileTotals(inv, glMap); err != nil {
+		return err
+	}
+
+	// Post through the engine and verify what was persisted
+	store := memstore.NewGLStore()
+	engine := &ledger.Engine{
+		GLStore: store,
+		Company: &memstore.Company{DefaultCurrency: inv.CompanyCurrency, RoundOffAccount: RoundOffAccount},
+	}
+	if err := engine.MakeGLEntries(glMap, ledger.DefaultPostingOptions()); err != nil {
+		return fmt.Errorf("%w: posting failed: %v", ErrMismatch, err)
+	}
+
+	posted := ledger.GLMap(store.All())
+	if !posted.IsBalanced() {
+		return fmt.Errorf("%w: posted entries debit %.2f != credit %.2f",
+			ErrMismatch, posted.TotalDebit(), posted.TotalCredit())
+	}
+	if got := accountBalance(posted, inv.DebitTo); !equal(got, inv.BaseGrandTotal) {
+		return fmt.Errorf("%w: posted receivable %.2f != grand total %.2f", ErrMismatch, got, inv.BaseGrandTotal)
+	}
+
+	return nil
+}
+
+// reconcileTotals compares the GL map built by the controller with the
+// calculated document, before any engine processing.
+func reconcileTotals(inv *salesinvoice.SalesInvoice, glMap []ledger.GLEntry) error {
+	var errs []error
+	mismatch := func(what string, expected, actual float64) {
+		if !equal(expected, actual) {
+			errs = append(errs, fmt.Errorf("%w: %s is %.2f, expected %.2f", ErrMismatch, what, actual, expected))
+		}
+	}
+
+	mismatch("receivable", inv.BaseGrandTotal, accountBalance(glMap, inv.DebitTo))
+
+	var income, expectedIncome float64
+	incomeAccounts := make(map[string]bool)
+	for _, item := range inv.Items {
+		expectedIncome += item.BaseNetAmount
+		incomeAccounts[item.IncomeAccount] = true
+	}
+	for account := range incomeAccounts {
+		income -= accountBalance(glMap, account)
+	}
+	mismatch("income", expectedIncome, income)
+
+	expectedTax := make(map[string]float64)
+	for _, tax := range inv.Taxes {
+		if tax.AddDeductTax == taxcalc.Deduct {
+			expectedTax[tax.AccountHead] -= tax.BaseTaxAmountAfterDiscountAmount
+		} else {
+			expectedTax[tax.AccountHead] += tax.BaseTaxAmountAfterDiscountAmount
+		}
+	}
+	for account, expected := range expectedTax {
+		mismatch("tax "+account, expected, -accountBalance(glMap, account))
+	}
+
+	// The controller leaves rounding differences to the engine's round-off
+	// entry; they must stay within the Sales Invoice allowance.
+	if diff := ledger.GLMap(glMap).TotalDebit() - ledger.GLMap(glMap).TotalCredit(); math.Abs(diff) >= 0.5 {
+		errs = append(errs, fmt.Errorf("%w: GL map out of balance by %.2f", ErrMismatch, diff))
+	}
+
+	return errors.Join(errs...)
+}
+
+// accountBalance returns debit minus credit for an account.
+func accountBalance(glMap []ledger.GLEntry, account string) float64 {
+	var balance float64
+	for _, e := range glMap {
+		if e.Account == account {
+			balance += e.Debit - e.Credit
+		}
+	}
+	return balance
+}
+
+// equal compares currency amounts at cent precision.
+func equal(a, b float64) bool {
+	return numeric.Equal(a, b, 2)
+}
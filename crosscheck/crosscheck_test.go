@@ -0,0 +1,37 @@
+package crosscheck
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	report := Run(20240401, 500, DefaultConfig())
+	t.Log(report)
+
+	for _, f := range report.Failures {
+		t.Errorf("invoice %d (%s, %s @ %.4f): %v",
+			f.Index, f.Invoice.Name, f.Invoice.Currency, f.Invoice.ConversionRate, f.Err)
+	}
+	if report.Checked < 400 {
+		t.Errorf("Expected at least 400 checked invoices, got %d", report.Checked)
+	}
+}
+
+func TestGenerator_Deterministic(t *testing.T) {
+	a := NewGenerator(7, Config{}).Invoice(42)
+	b := NewGenerator(7, Config{}).Invoice(42)
+
+	if a.Name != b.Name || len(a.Items) != len(b.Items) || a.Items[0].Rate != b.Items[0].Rate {
+		t.Errorf("Same seed and index produced different invoices")
+	}
+}
+
+func TestVerify_DetectsMismatch(t *testing.T) {
+	inv := NewGenerator(1, Config{MaxTaxes: 1}).Invoice(0)
+	inv.Items[0].IncomeAccount = inv.DebitTo // income posted against the receivable
+
+	if err := Verify(inv); !errors.Is(err, ErrMismatch) {
+		t.Errorf("Expected ErrMismatch, got %v", err)
+	}
+}
@@ -0,0 +1,142 @@
+package crosscheck
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/numeric"
+	"github.com/senguttuvang/erpnext-go/salesinvoice"
+	"github.com/senguttuvang/erpnext-go/taxcalc"
+)
+
+// Config bounds the shape of generated invoices.
+type Config struct {
+	MaxItems       int      // Maximum item rows per invoice
+	MaxTaxes       int      // Maximum tax rows per invoice
+	MaxRate        float64  // Maximum item rate in transaction currency
+	Currencies     []string // Transaction currencies; the first is the company currency
+	AllowDeduct    bool     // Generate deducted tax rows
+	IncomeAccounts []string
+}
+
+// DefaultConfig returns a config covering all charge types and multi-currency.
+func DefaultConfig() Config {
+	return Config{
+		MaxItems:       6,
+		MaxTaxes:       4,
+		MaxRate:        5000,
+		Currencies:     []string{"INR", "USD", "EUR"},
+		AllowDeduct:    true,
+		IncomeAccounts: []string{"Sales - XC", "Service Income - XC"},
+	}
+}
+
+// Generator produces deterministic random invoices.
+type Generator struct {
+	seed uint64
+	cfg  Config
+}
+
+// NewGenerator creates a generator. Zero config fields take their defaults.
+func NewGenerator(seed uint64, cfg Config) *Generator {
+	def := DefaultConfig()
+	if cfg.MaxItems <= 0 {
+		cfg.MaxItems = def.MaxItems
+	}
+	if cfg.MaxTaxes < 0 {
+		cfg.MaxTaxes = 0
+	}
+	if cfg.MaxRate <= 0 {
+		cfg.MaxRate = def.MaxRate
+	}
+	if len(cfg.Currencies) == 0 {
+		cfg.Currencies = def.Currencies
+	}
+	if len(cfg.IncomeAccounts) == 0 {
+		cfg.IncomeAccounts = def.IncomeAccounts
+	}
+	return &Generator{seed: seed, cfg: cfg}
+}
+
+var chargeTypes = []taxcalc.ChargeType{
+	taxcalc.OnNetTotal,
+	taxcalc.Actual,
+	taxcalc.OnPreviousRowAmount,
+	taxcalc.OnPreviousRowTotal,
+	taxcalc.OnItemQuantity,
+}
+
+// Invoice returns the i-th invoice of the sequence. Each invoice has its own
+// random stream, so any single invoice can be regenerated independently.
+func (g *Generator) Invoice(i int) *salesinvoice.SalesInvoice {
+	rnd := rand.New(rand.NewPCG(g.seed, uint64(i)))
+	companyCurrency := g.cfg.Currencies[0]
+
+	inv := &salesinvoice.SalesInvoice{
+		Name:            fmt.Sprintf("XC-SINV-%d-%05d", g.seed, i),
+		Company:         "Crosscheck Co",
+		Customer:        fmt.Sprintf("Customer %d", rnd.IntN(50)),
+		PostingDate:     time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC).AddDate(0, 0, rnd.IntN(366)),
+		DebitTo:         "Debtors - XC",
+		CompanyCurrency: companyCurrency,
+		CostCenter:      "Main - XC",
+	}
+
+	inv.Currency = g.cfg.Currencies[rnd.IntN(len(g.cfg.Currencies))]
+	inv.ConversionRate = 1
+	if inv.Currency != companyCurrency {
+		inv.ConversionRate = roundTo(0.01+rnd.Float64()*120, 4)
+	}
+
+	for n := 1 + rnd.IntN(g.cfg.MaxItems); n > 0; n-- {
+		item := &taxcalc.LineItem{
+			ItemCode:      fmt.Sprintf("ITEM-%03d", rnd.IntN(20)),
+			Qty:           roundTo(rnd.Float64()*100, rnd.IntN(4)),
+			IncomeAccount: g.cfg.IncomeAccounts[rnd.IntN(len(g.cfg.IncomeAccounts))],
+		}
+		if rnd.IntN(2) == 0 {
+			item.PriceListRate = roundTo(rnd.Float64()*g.cfg.MaxRate, 2)
+			item.DiscountPercentage = roundTo(rnd.Float64()*100, rnd.IntN(3))
+		} else {
+			item.Rate = roundTo(rnd.Float64()*g.cfg.MaxRate, 2)
+		}
+		inv.Items = append(inv.Items, item)
+	}
+
+	for n := rnd.IntN(g.cfg.MaxTaxes + 1); n > 0; n-- {
+		idx := len(inv.Taxes)
+		tax := &taxcalc.TaxRow{
+			AccountHead: fmt.Sprintf("Tax %d - XC", idx+1),
+			ChargeType:  chargeTypes[rnd.IntN(len(chargeTypes))],
+			Rate:        roundTo(rnd.Float64()*30, 2),
+		}
+		switch tax.ChargeType {
+		case taxcalc.OnPreviousRowAmount, taxcalc.OnPreviousRowTotal:
+			if idx == 0 {
+				tax.ChargeType = taxcalc.OnNetTotal
+			} else {
+				tax.RowID = 1 + rnd.IntN(idx)
+			}
+		case taxcalc.Actual:
+			tax.Rate = roundTo(rnd.Float64()*500, 2)
+		}
+		if g.cfg.AllowDeduct && rnd.IntN(5) == 0 {
+			tax.AddDeductTax = taxcalc.Deduct
+		}
+		inv.Taxes = append(inv.Taxes, tax)
+	}
+
+	if rnd.IntN(4) == 0 {
+		inv.AdditionalDiscountPercentage = roundTo(rnd.Float64()*20, 2)
+		if rnd.IntN(2) == 0 {
+			inv.ApplyDiscountOn = taxcalc.DiscountOnNetTotal
+		}
+	}
+
+	return inv
+}
+
+func roundTo(v float64, precision int) float64 {
+	return numeric.Round(v, precision)
+}
@@ -0,0 +1,182 @@
+// Package custom carries the custom fields of a deployment through the
+// ledger and documents without changing their structs.
+//
+// ERPNext sites add fields to GL Entry, Sales Invoice Item and other
+// doctypes through Customize Form. Here such values ride along in a
+// Fields map on GLEntry, LineItem and Document: posting copies them to the
+// GL entries, merging can be told to keep entries apart when they differ
+// (ledger.Engine.MergeFields), and stores persist them as JSON.
+//
+// Maps to: the Custom Field doctype and frappe/custom/doctype/customize_form
+package custom
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Fields holds custom field values by fieldname. Values are those JSON
+// can represent: strings, numbers, booleans, nil, and slices and maps of
+// them; time.Time is stored as it is and marshalled as RFC 3339.
+type Fields map[string]any
+
+// Set stores a value, creating the map if needed.
+func (f *Fields) Set(name string, value any) {
+	if *f == nil {
+		*f = make(Fields)
+	}
+	(*f)[name] = value
+}
+
+// String returns a field as a string: strings as they are, numbers and
+// booleans formatted, and "" when the field is missing or nil.
+func (f Fields) String(name string) string {
+	switch v := f[name].(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case time.Time:
+		return v.Format(time.RFC3339)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// Float returns a numeric field. It reports false when the field is
+// missing or not a number; numeric strings do not count.
+func (f Fields) Float(name string) (float64, bool) {
+	switch v := f[name].(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case json.Number:
+		n, err := v.Float64()
+		return n, err == nil
+	}
+	return 0, false
+}
+
+// Int returns an integer field. It reports false when the field is
+// missing, not a number or has a fraction.
+func (f Fields) Int(name string) (int64, bool) {
+	switch v := f[name].(type) {
+	case int:
+		return int64(v), true
+	case int64:
+		return v, true
+	case json.Number:
+		n, err := v.Int64()
+		return n, err == nil
+	}
+	if n, ok := f.Float(name); ok && n == float64(int64(n)) {
+		return int64(n), true
+	}
+	return 0, false
+}
+
+// Bool returns a boolean field. Frappe's Check fields, which hold 0 or 1,
+// count too.
+func (f Fields) Bool(name string) bool {
+	if b, ok := f[name].(bool); ok {
+		return b
+	}
+	n, ok := f.Int(name)
+	return ok && n != 0
+}
+
+// Time returns a date or datetime field, held either as a time.Time or as
+// a string in "2006-01-02", "2006-01-02 15:04:05" or RFC 3339 form.
+func (f Fields) Time(name string) (time.Time, bool) {
+	switch v := f[name].(type) {
+	case time.Time:
+		return v, true
+	case string:
+		for _, layout := range []string{"2006-01-02", "2006-01-02 15:04:05", time.RFC3339} {
+			if t, err := time.Parse(layout, v); err == nil {
+				return t, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// Clone returns a shallow copy, or nil for an empty map.
+func (f Fields) Clone() Fields {
+	if len(f) == 0 {
+		return nil
+	}
+	return maps.Clone(f)
+}
+
+// Merge returns the fields of base overlaid with those of over, as when an
+// item's GL entry takes the document's custom fields and its own. It
+// returns nil when both are empty.
+func Merge(base, over Fields) Fields {
+	if len(over) == 0 {
+		return base.Clone()
+	}
+	out := make(Fields, len(base)+len(over))
+	maps.Copy(out, base)
+	maps.Copy(out, over)
+	return out
+}
+
+// Key returns a string that is equal for two maps exactly when the named
+// fields have equal values, for use in merge keys. It is "" when none of
+// the fields are set, and does not allocate then.
+func (f Fields) Key(names []string) string {
+	set := false
+	for _, name := range names {
+		if _, ok := f[name]; ok {
+			set = true
+			break
+		}
+	}
+	if !set {
+		return ""
+	}
+	var b strings.Builder
+	for i, name := range names {
+		if i > 0 {
+			b.WriteByte(0x1f)
+		}
+		v, ok := f[name]
+		if !ok {
+			continue
+		}
+		// Numbers compare by value whether decoded from JSON or not; the
+		// prefix and quoting keep a missing field, "", nil and 0 apart
+		if n, isNum := f.Float(name); isNum {
+			b.WriteString("n" + strconv.FormatFloat(n, 'g', -1, 64))
+		} else {
+			b.WriteString(strconv.Quote(fmt.Sprintf("%T:%v", v, v)))
+		}
+	}
+	return b.String()
+}
+
+// UnmarshalJSON decodes numbers as json.Number, so that large integers
+// such as IDs keep their precision. The accessors read both forms.
+func (f *Fields) UnmarshalJSON(data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var m map[string]any
+	if err := dec.Decode(&m); err != nil {
+		return err
+	}
+	*f = m
+	return nil
+}
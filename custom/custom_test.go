@@ -0,0 +1,95 @@
+package custom
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestAccessors(t *testing.T) {
+	var f Fields
+	f.Set("region", "North")
+	f.Set("batch", 42)
+	f.Set("weight", 1.5)
+	f.Set("is_export", 1)
+	f.Set("ship_date", "2026-03-01")
+
+	if f.String("region") != "North" || f.String("batch") != "42" || f.String("missing") != "" {
+		t.Errorf("String = %q %q", f.String("region"), f.String("batch"))
+	}
+	if n, ok := f.Int("batch"); !ok || n != 42 {
+		t.Errorf("Int(batch) = %v %v", n, ok)
+	}
+	if _, ok := f.Int("weight"); ok {
+		t.Error("Int accepted a fraction")
+	}
+	if w, ok := f.Float("weight"); !ok || w != 1.5 {
+		t.Errorf("Float(weight) = %v %v", w, ok)
+	}
+	if _, ok := f.Float("region"); ok {
+		t.Error("Float accepted a string")
+	}
+	if !f.Bool("is_export") || f.Bool("region") {
+		t.Error("Bool")
+	}
+	if d, ok := f.Time("ship_date"); !ok || !d.Equal(time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Time = %v %v", d, ok)
+	}
+}
+
+func TestJSONKeepsIntegers(t *testing.T) {
+	var f Fields
+	if err := json.Unmarshal([]byte(`{"external_id": 9007199254740993, "rate": 2.5, "tag": "x"}`), &f); err != nil {
+		t.Fatal(err)
+	}
+	if n, ok := f.Int("external_id"); !ok || n != 9007199254740993 {
+		t.Errorf("external_id = %v %v", n, ok)
+	}
+	data, err := json.Marshal(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"external_id":9007199254740993,"rate":2.5,"tag":"x"}` {
+		t.Errorf("marshalled %s", data)
+	}
+}
+
+func TestKey(t *testing.T) {
+	names := []string{"region", "batch"}
+	var decoded Fields
+	if err := json.Unmarshal([]byte(`{"region": "North", "batch": 7}`), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		a, b Fields
+		same bool
+	}{
+		{nil, Fields{"other": 1}, true},
+		{Fields{"region": "North", "batch": 7}, decoded, true},
+		{Fields{"region": "North"}, Fields{"region": "South"}, false},
+		{Fields{"region": ""}, nil, false},
+		{Fields{"batch": 0}, Fields{"batch": "0"}, false},
+	} {
+		if got := tc.a.Key(names) == tc.b.Key(names); got != tc.same {
+			t.Errorf("Key(%v) == Key(%v) is %v", tc.a, tc.b, got)
+		}
+	}
+	if k := (Fields{"other": 1}).Key(names); k != "" {
+		t.Errorf("key of unrelated fields = %q", k)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	base := Fields{"region": "North", "channel": "Web"}
+	got := Merge(base, Fields{"region": "South"})
+	if got.String("region") != "South" || got.String("channel") != "Web" {
+		t.Errorf("Merge = %v", got)
+	}
+	got["channel"] = "Retail"
+	if base.String("channel") != "Web" {
+		t.Error("Merge shares the base map")
+	}
+	if Merge(nil, nil) != nil {
+		t.Error("Merge of empty maps is not nil")
+	}
+}
@@ -0,0 +1,173 @@
+// Package cutover posts the vouchers of a legacy system into the ledger
+// during a phased cut-over.
+//
+// Legacy vouchers name their accounts by the old system's codes. At
+// posting time a mapping table translates each code to an account of the
+// new chart; a code for a customer's or supplier's own ledger account maps
+// to the control account with the party set, so the history lands in the
+// payment ledger too. A voucher with a code the table does not map is held
+// back whole and reported, rather than posted with the difference parked
+// in a suspense account: once the mapping is completed, posting the held
+// vouchers again finishes the cut-over with nothing to clear.
+//
+// Migrated from: the Opening Invoice Creation Tool and Chart of Accounts
+// Importer of ERPNext, which need the mapping done before anything posts
+package cutover
+
+import (
+	"errors"
+	"slices"
+	"strings"
+
+	"github.com/senguttuvang/erpnext-go/ledger"
+)
+
+// ErrNotConfigured is returned when the poster has no engine or mapping.
+var ErrNotConfigured = errors.New("cut-over poster is missing its engine or mapping")
+
+// LegacyAccountField is the custom field of posted entries that keeps the
+// legacy code they were mapped from.
+const LegacyAccountField = "legacy_account"
+
+// Target is the new chart's side of a legacy account code.
+type Target struct {
+	Account   string
+	PartyType string // Set with Party when the code is a party's own account, mapped to a control account
+	Party     string
+}
+
+// Mapping translates legacy account codes.
+type Mapping interface {
+	// Target returns the target of a company's legacy code, and false when
+	// the code is not mapped.
+	Target(company, code string) (Target, bool, error)
+}
+
+// Table is a Mapping of codes that mean the same in every company.
+type Table map[string]Target
+
+// Target implements Mapping.
+func (t Table) Target(_, code string) (Target, bool, error) {
+	target, ok := t[code]
+	return target, ok, nil
+}
+
+// Poster posts legacy vouchers through the engine.
+type Poster struct {
+	Engine  *ledger.Engine
+	Mapping Mapping
+	User    string // Posting user, checked by the engine's authorizer
+}
+
+// Held is a voucher not posted because of codes the mapping lacks.
+type Held struct {
+	Voucher ledger.VoucherRef
+	Codes   []string
+}
+
+// Failed is a mapped voucher the engine rejected.
+type Failed struct {
+	Voucher ledger.VoucherRef
+	Err     error
+}
+
+// Unmapped is a legacy code the mapping lacks, with what it holds back.
+type Unmapped struct {
+	Code     string
+	Vouchers int
+	Debit    float64
+	Credit   float64
+}
+
+// Report is the outcome of posting a batch of legacy vouchers.
+type Report struct {
+	Posted   []ledger.VoucherRef
+	Held     []Held
+	Failed   []Failed
+	Unmapped []Unmapped // By code
+}
+
+// Complete reports whether every voucher was posted.
+func (r *Report) Complete() bool {
+	return len(r.Held) == 0 && len(r.Failed) == 0
+}
+
+// Post maps and posts each voucher, given as its GL map with legacy codes
+// as accounts. Vouchers with unmapped codes are held and those the engine
+// rejects are reported; neither stops the batch. An error is returned
+// only when the mapping cannot be read.
+func (p *Poster) Post(vouchers [][]ledger.GLEntry) (*Report, error) {
+	if p.Engine == nil || p.Mapping == nil {
+		return nil, ErrNotConfigured
+	}
+	report := &Report{}
+	unmapped := map[string]*Unmapped{}
+	for _, voucher := range vouchers {
+		if len(voucher) == 0 {
+			continue
+		}
+		ref := ledger.VoucherRef{VoucherType: voucher[0].VoucherType, VoucherNo: voucher[0].VoucherNo, Company: voucher[0].Company}
+		glMap, missing, err := p.mapVoucher(voucher)
+		if err != nil {
+			return report, err
+		}
+		if len(missing) > 0 {
+			report.Held = append(report.Held, Held{Voucher: ref, Codes: missing})
+			for _, code := range missing {
+				u := unmapped[code]
+				if u == nil {
+					u = &Unmapped{Code: code}
+					unmapped[code] = u
+				}
+				u.Vouchers++
+				for _, e := range voucher {
+					if e.Account == code {
+						u.Debit += e.Debit
+						u.Credit += e.Credit
+					}
+				}
+			}
+			continue
+		}
+		opts := ledger.DefaultPostingOptions()
+		opts.User = p.User
+		if err := p.Engine.MakeGLEntries(glMap, opts); err != nil {
+			report.Failed = append(report.Failed, Failed{Voucher: ref, Err: err})
+			continue
+		}
+		report.Posted = append(report.Posted, ref)
+	}
+	for _, u := range unmapped {
+		u.Debit, u.Credit = ledger.Flt(u.Debit, 2), ledger.Flt(u.Credit, 2)
+		report.Unmapped = append(report.Unmapped, *u)
+	}
+	slices.SortFunc(report.Unmapped, func(a, b Unmapped) int { return strings.Compare(a.Code, b.Code) })
+	return report, nil
+}
+
+// mapVoucher returns a copy of a voucher with its codes mapped, or the
+// codes it could not map, in order of appearance.
+func (p *Poster) mapVoucher(voucher []ledger.GLEntry) ([]ledger.GLEntry, []string, error) {
+	glMap := make([]ledger.GLEntry, len(voucher))
+	var missing []string
+	for i, e := range voucher {
+		target, ok, err := p.Mapping.Target(e.Company, e.Account)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !ok {
+			if !slices.Contains(missing, e.Account) {
+				missing = append(missing, e.Account)
+			}
+			continue
+		}
+		mapped := e.Copy()
+		mapped.Custom.Set(LegacyAccountField, e.Account)
+		mapped.Account = target.Account
+		if target.Party != "" {
+			mapped.PartyType, mapped.Party = target.PartyType, target.Party
+		}
+		glMap[i] = mapped
+	}
+	return glMap, missing, nil
+}
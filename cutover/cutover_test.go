@@ -0,0 +1,84 @@
+package cutover
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/ledger"
+	"github.com/senguttuvang/erpnext-go/ledger/memstore"
+)
+
+func entry(voucher, code string, debit, credit float64) ledger.GLEntry {
+	return ledger.GLEntry{
+		PostingDate: time.Date(2023, 12, 31, 0, 0, 0, 0, time.UTC), VoucherType: "Journal Entry", VoucherNo: voucher,
+		Company: "Acme", Account: code, CostCenter: "Main - A", Debit: debit, Credit: credit,
+		DebitInAccountCurrency: debit, CreditInAccountCurrency: credit,
+	}
+}
+
+func TestPost(t *testing.T) {
+	store := memstore.NewGLStore()
+	payments := memstore.NewPaymentStore()
+	engine := &ledger.Engine{
+		Accounts: memstore.NewAccounts(
+			ledger.Account{Name: "Bank - A", RootType: "Asset"},
+			ledger.Account{Name: "Debtors - A", RootType: "Asset", PartyType: "Customer"},
+			ledger.Account{Name: "Sales - A", RootType: "Income"},
+			ledger.Account{Name: "Rent - A", RootType: "Expense"},
+		),
+		GLStore: store, PaymentStore: payments,
+	}
+	table := Table{
+		"1000":     {Account: "Bank - A"},
+		"1200-042": {Account: "Debtors - A", PartyType: "Customer", Party: "Globex"},
+		"4000":     {Account: "Sales - A"},
+		"1200":     {Account: "Debtors - A"}, // Control account without a party
+	}
+	p := &Poster{Engine: engine, Mapping: table}
+	vouchers := [][]ledger.GLEntry{
+		{entry("L-1", "1200-042", 500, 0), entry("L-1", "4000", 0, 500)},
+		{entry("L-2", "6100", 120, 0), entry("L-2", "1000", 0, 120)},
+		{entry("L-3", "6100", 80, 0), entry("L-3", "6200", 20, 0), entry("L-3", "1000", 0, 100)},
+		{entry("L-4", "1200", 70, 0), entry("L-4", "4000", 0, 70)},
+	}
+
+	report, err := p.Post(vouchers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Posted) != 1 || report.Posted[0].VoucherNo != "L-1" || report.Complete() {
+		t.Errorf("posted = %+v", report.Posted)
+	}
+	if len(report.Held) != 2 || report.Held[1].Voucher.VoucherNo != "L-3" || len(report.Held[1].Codes) != 2 {
+		t.Errorf("held = %+v", report.Held)
+	}
+	want := []Unmapped{{Code: "6100", Vouchers: 2, Debit: 200}, {Code: "6200", Vouchers: 1, Debit: 20}}
+	if len(report.Unmapped) != 2 || report.Unmapped[0] != want[0] || report.Unmapped[1] != want[1] {
+		t.Errorf("unmapped = %+v", report.Unmapped)
+	}
+	if len(report.Failed) != 1 || !errors.Is(report.Failed[0].Err, ledger.ErrPartyRequired) {
+		t.Errorf("failed = %+v", report.Failed)
+	}
+
+	entries := store.All()
+	if len(entries) != 2 || entries[0].Account != "Debtors - A" || entries[0].Party != "Globex" ||
+		entries[0].Custom.String(LegacyAccountField) != "1200-042" {
+		t.Errorf("posted entries = %+v", entries)
+	}
+	if ple := payments.All(); len(ple) != 1 || ple[0].Party != "Globex" || ple[0].Amount != 500 {
+		t.Errorf("payment ledger = %+v", ple)
+	}
+
+	// The next phase maps the expense codes and posts what was held
+	table["6100"] = Target{Account: "Rent - A"}
+	table["6200"] = Target{Account: "Rent - A"}
+	report, err = p.Post(vouchers[1:3])
+	if err != nil || !report.Complete() || len(report.Posted) != 2 || len(report.Unmapped) != 0 {
+		t.Errorf("second phase = %+v, %v", report, err)
+	}
+
+	if _, err := (&Poster{Mapping: table}).Post(vouchers); !errors.Is(err, ErrNotConfigured) {
+		t.Errorf("Post() without engine = %v", err)
+	}
+}
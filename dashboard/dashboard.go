@@ -0,0 +1,210 @@
+// Package dashboard records the daily values of the accounts dashboard's
+// KPIs, so its charts can show trends instead of only today's figure.
+//
+// A KPI is a set of accounts and how to read them: the balance at the
+// end of the day, as for receivables, payables and cash, or the movement
+// since the first of the month, as for revenue month to date. A
+// Snapshotter computes every KPI of a company for a day from a
+// balances.Projection and stores the values in a TimeSeriesStore; the
+// Task runs it nightly, or over a range of past days to backfill.
+//
+// Maps to: the Dashboard Chart and Number Card doctypes of Frappe, with
+// the values of the accounts workspace cards kept per day instead of
+// recomputed on each view
+package dashboard
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/balances"
+	"github.com/senguttuvang/erpnext-go/daterange"
+	"github.com/senguttuvang/erpnext-go/ledger"
+)
+
+// Dashboard errors
+var (
+	ErrInvalidKPI    = errors.New("invalid dashboard KPI")
+	ErrInvalidPeriod = errors.New("invalid dashboard snapshot period")
+)
+
+// Names of the default KPIs
+const (
+	Receivables = "Accounts Receivable"
+	Payables    = "Accounts Payable"
+	Cash        = "Cash"
+	RevenueMTD  = "Revenue (MTD)"
+)
+
+// Measure is how a KPI reads its accounts.
+type Measure string
+
+const (
+	ClosingBalance Measure = "Balance"       // At the end of the day
+	MonthToDate    Measure = "Month to Date" // Movement from the first of the month
+)
+
+// KPI is a figure the dashboard charts.
+type KPI struct {
+	Name     string
+	Accounts []string // Ledger accounts, summed
+	Measure  Measure
+
+	// CreditNormal reports the figure as credit minus debit, so payables
+	// and revenue chart as positive amounts.
+	CreditNormal bool
+}
+
+// Validate checks the KPI.
+func (k *KPI) Validate() error {
+	switch {
+	case k.Name == "" || len(k.Accounts) == 0:
+		return fmt.Errorf("%w: name and accounts are required", ErrInvalidKPI)
+	case k.Measure != ClosingBalance && k.Measure != MonthToDate:
+		return fmt.Errorf("%w: %s: unknown measure %q", ErrInvalidKPI, k.Name, k.Measure)
+	}
+	return nil
+}
+
+// DefaultKPIs returns the KPIs of the accounts dashboard over a company's
+// receivable, payable, cash and bank, and income accounts.
+func DefaultKPIs(receivable, payable, cash, income []string) []KPI {
+	return []KPI{
+		{Name: Receivables, Accounts: receivable, Measure: ClosingBalance},
+		{Name: Payables, Accounts: payable, Measure: ClosingBalance, CreditNormal: true},
+		{Name: Cash, Accounts: cash, Measure: ClosingBalance},
+		{Name: RevenueMTD, Accounts: income, Measure: MonthToDate, CreditNormal: true},
+	}
+}
+
+// Point is the value of a KPI on a day, in company currency.
+type Point struct {
+	Company string
+	KPI     string
+	Date    time.Time // Civil date
+	Value   float64
+}
+
+// TimeSeriesStore keeps KPI values by day.
+type TimeSeriesStore interface {
+	// Save creates or replaces the values of a company's KPIs on their
+	// days, so a day can be snapshot again.
+	Save(points ...Point) error
+
+	// Series returns the values of a KPI in a period, oldest first.
+	Series(company, kpi string, period daterange.Range) ([]Point, error)
+}
+
+// Balances returns account balances as of a day. balances.Projection
+// implements it.
+type Balances interface {
+	Balance(company, account string, asOf time.Time) (debit, credit, balance float64)
+}
+
+// Snapshotter computes and stores the KPI values of a day.
+type Snapshotter struct {
+	Balances Balances
+	Store    TimeSeriesStore
+	KPIs     []KPI
+}
+
+// Compute returns the value of every KPI of a company at the end of date.
+func (s *Snapshotter) Compute(company string, date time.Time) ([]Point, error) {
+	date = ledger.CivilDate(date)
+	points := make([]Point, 0, len(s.KPIs))
+	for _, k := range s.KPIs {
+		if err := k.Validate(); err != nil {
+			return nil, err
+		}
+		var value float64
+		for _, account := range k.Accounts {
+			_, _, balance := s.Balances.Balance(company, account, date)
+			if k.Measure == MonthToDate {
+				monthStart := date.AddDate(0, 0, 1-date.Day())
+				_, _, opening := s.Balances.Balance(company, account, monthStart.AddDate(0, 0, -1))
+				balance -= opening
+			}
+			value += balance
+		}
+		if k.CreditNormal {
+			value = -value
+		}
+		points = append(points, Point{Company: company, KPI: k.Name, Date: date, Value: ledger.Flt(value, 2)})
+	}
+	return points, nil
+}
+
+// Snapshot computes the KPI values of a company on date and stores them.
+func (s *Snapshotter) Snapshot(company string, date time.Time) ([]Point, error) {
+	points, err := s.Compute(company, date)
+	if err != nil {
+		return nil, err
+	}
+	return points, s.Store.Save(points...)
+}
+
+// Backfill stores the KPI values of every day of a bounded period,
+// computed from historical GL entries rather than from s.Balances, as
+// when the dashboard is set up for a company with years of books. It
+// returns the points stored.
+func (s *Snapshotter) Backfill(entries []ledger.GLEntry, company string, period daterange.Range) (int, error) {
+	if !period.Bounded() {
+		return 0, fmt.Errorf("%w: %s is not bounded", ErrInvalidPeriod, period)
+	}
+	history := balances.NewProjection(nil, 0)
+	history.Rebuild(entries)
+	past := &Snapshotter{Balances: history, Store: s.Store, KPIs: s.KPIs}
+	var n int
+	for d := period.Start; d.Before(period.End); d = d.AddDate(0, 0, 1) {
+		points, err := past.Snapshot(company, d)
+		if err != nil {
+			return n, err
+		}
+		n += len(points)
+	}
+	return n, nil
+}
+
+// MemoryTimeSeries is an in-memory TimeSeriesStore.
+type MemoryTimeSeries struct {
+	mu     sync.Mutex
+	points map[[2]string][]Point // By company and KPI, sorted by date
+}
+
+// Save implements TimeSeriesStore.
+func (s *MemoryTimeSeries) Save(points ...Point) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.points == nil {
+		s.points = make(map[[2]string][]Point)
+	}
+	for _, p := range points {
+		p.Date = ledger.CivilDate(p.Date)
+		k := [2]string{p.Company, p.KPI}
+		series := s.points[k]
+		i, found := slices.BinarySearchFunc(series, p.Date, func(q Point, d time.Time) int { return q.Date.Compare(d) })
+		if found {
+			series[i] = p
+		} else {
+			series = slices.Insert(series, i, p)
+		}
+		s.points[k] = series
+	}
+	return nil
+}
+
+// Series implements TimeSeriesStore.
+func (s *MemoryTimeSeries) Series(company, kpi string, period daterange.Range) ([]Point, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []Point
+	for _, p := range s.points[[2]string{company, kpi}] {
+		if period.Contains(p.Date) {
+			out = append(out, p)
+		}
+	}
+	return out, nil
+}
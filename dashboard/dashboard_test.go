@@ -0,0 +1,111 @@
+package dashboard
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/balances"
+	"github.com/senguttuvang/erpnext-go/daterange"
+	"github.com/senguttuvang/erpnext-go/jobs"
+	"github.com/senguttuvang/erpnext-go/ledger"
+)
+
+func date(s string) time.Time {
+	t, err := ledger.ParseDate(s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func entries() []ledger.GLEntry {
+	entry := func(d, voucherNo, account string, debit, credit float64) ledger.GLEntry {
+		return ledger.GLEntry{PostingDate: date(d), Company: "ACME", Account: account, VoucherType: "Journal Entry", VoucherNo: voucherNo,
+			Debit: debit, Credit: credit}
+	}
+	return []ledger.GLEntry{
+		entry("2024-01-30", "SINV-1", "Debtors - ACME", 1000, 0),
+		entry("2024-01-30", "SINV-1", "Sales - ACME", 0, 1000),
+		entry("2024-02-01", "SINV-2", "Debtors - ACME", 500, 0),
+		entry("2024-02-01", "SINV-2", "Sales - ACME", 0, 500),
+		entry("2024-02-02", "PE-1", "Bank - ACME", 1000, 0),
+		entry("2024-02-02", "PE-1", "Debtors - ACME", 0, 1000),
+		entry("2024-02-02", "PINV-1", "Purchases - ACME", 300, 0),
+		entry("2024-02-02", "PINV-1", "Creditors - ACME", 0, 300),
+	}
+}
+
+func snapshotter(store TimeSeriesStore) *Snapshotter {
+	return &Snapshotter{
+		Store: store,
+		KPIs:  DefaultKPIs([]string{"Debtors - ACME"}, []string{"Creditors - ACME"}, []string{"Bank - ACME"}, []string{"Sales - ACME"}),
+	}
+}
+
+func values(t *testing.T, store TimeSeriesStore, kpi string) []float64 {
+	t.Helper()
+	series, err := store.Series("ACME", kpi, daterange.Inclusive(date("2024-01-30"), date("2024-02-02")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := make([]float64, len(series))
+	for i, p := range series {
+		out[i] = p.Value
+	}
+	return out
+}
+
+func TestBackfill(t *testing.T) {
+	store := &MemoryTimeSeries{}
+	s := snapshotter(store)
+	n, err := s.Backfill(entries(), "ACME", daterange.Inclusive(date("2024-01-30"), date("2024-02-02")))
+	if err != nil || n != 16 {
+		t.Fatalf("Backfill() = %d, %v", n, err)
+	}
+	for kpi, want := range map[string][]float64{
+		Receivables: {1000, 1000, 1500, 500},
+		Payables:    {0, 0, 0, 300},
+		Cash:        {0, 0, 0, 1000},
+		RevenueMTD:  {1000, 1000, 500, 500}, // Restarts on 1 February
+	} {
+		if got := values(t, store, kpi); !slices.Equal(got, want) {
+			t.Errorf("%s = %v, want %v", kpi, got, want)
+		}
+	}
+
+	if _, err := s.Backfill(nil, "ACME", daterange.Range{Start: date("2024-01-01")}); !errors.Is(err, ErrInvalidPeriod) {
+		t.Errorf("unbounded backfill: %v", err)
+	}
+	s.KPIs[0].Measure = "Average"
+	if _, err := s.Compute("ACME", date("2024-02-01")); !errors.Is(err, ErrInvalidKPI) {
+		t.Errorf("unknown measure: %v", err)
+	}
+}
+
+func TestTask(t *testing.T) {
+	projection := balances.NewProjection(nil, 0)
+	projection.Rebuild(entries())
+	store := &MemoryTimeSeries{}
+	s := snapshotter(store)
+	s.Balances = projection
+
+	runner := &jobs.Runner{Queue: jobs.NewMemoryQueue(10), Store: &jobs.MemoryStore{}, Tasks: map[string]jobs.Task{Task: s}}
+	ctx := context.Background()
+	job, err := Submit(ctx, runner, "ACME", date("2024-02-01"), date("2024-02-02"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := runner.Run(ctx, job.ID); err != nil {
+		t.Fatal(err)
+	}
+	// Snapshotting a day again replaces its values
+	if _, err := s.Snapshot("ACME", date("2024-02-02")); err != nil {
+		t.Fatal(err)
+	}
+	if got := values(t, store, Receivables); !slices.Equal(got, []float64{1500, 500}) {
+		t.Errorf("receivables = %v", got)
+	}
+}
@@ -0,0 +1,74 @@
+package dashboard
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/daterange"
+	"github.com/senguttuvang/erpnext-go/jobs"
+	"github.com/senguttuvang/erpnext-go/ledger"
+)
+
+// Task is the job type of dashboard snapshots. The application registers
+// a Snapshotter under it with the jobs.Runner and submits a job for the
+// day each night, after the day's business, or for a range of past days
+// to fill gaps left by missed runs.
+const Task = "accounts-dashboard-snapshot"
+
+// Params are the parameters of a Task job. Dates are YYYY-MM-DD; From and
+// To are both included.
+type Params struct {
+	Company string `json:"company"`
+	From    string `json:"from"`
+	To      string `json:"to"`
+}
+
+// Submit queues the snapshots of a company from from to to.
+func Submit(ctx context.Context, runner *jobs.Runner, company string, from, to time.Time) (*jobs.Job, error) {
+	return runner.Submit(ctx, Task, Params{Company: company, From: from.Format(ledger.DateLayout), To: to.Format(ledger.DateLayout)})
+}
+
+// Plan implements jobs.Task with one chunk per day.
+func (s *Snapshotter) Plan(_ context.Context, params json.RawMessage) ([]string, error) {
+	_, period, err := parseParams(params)
+	if err != nil {
+		return nil, err
+	}
+	var days []string
+	for d := period.Start; d.Before(period.End); d = d.AddDate(0, 0, 1) {
+		days = append(days, d.Format(ledger.DateLayout))
+	}
+	return days, nil
+}
+
+// Run implements jobs.Task, snapshotting one day.
+func (s *Snapshotter) Run(_ context.Context, params json.RawMessage, day string) error {
+	company, _, err := parseParams(params)
+	if err != nil {
+		return err
+	}
+	date, err := ledger.ParseDate(day)
+	if err != nil {
+		return fmt.Errorf("%w: chunk %q", ErrInvalidPeriod, day)
+	}
+	_, err = s.Snapshot(company, date)
+	return err
+}
+
+func parseParams(raw json.RawMessage) (string, daterange.Range, error) {
+	var p Params
+	if err := json.Unmarshal(raw, &p); err != nil {
+		return "", daterange.Range{}, err
+	}
+	from, err := ledger.ParseDate(p.From)
+	if err != nil {
+		return "", daterange.Range{}, fmt.Errorf("%w: date %q", ErrInvalidPeriod, p.From)
+	}
+	to, err := ledger.ParseDate(p.To)
+	if err != nil {
+		return "", daterange.Range{}, fmt.Errorf("%w: date %q", ErrInvalidPeriod, p.To)
+	}
+	return p.Company, daterange.Inclusive(from, to), nil
+}
@@ -0,0 +1,129 @@
+// Package daterange gives date filters one meaning everywhere.
+//
+// A Range is a half-open span of civil dates, [Start, End): it contains
+// Start and stops before End, so consecutive months or periods share their
+// boundary without overlapping, and "up to the end of March" is simply End
+// = 1 April. ERPNext's from_date/to_date pairs are inclusive at both ends;
+// Inclusive converts them, and Last converts back for display.
+//
+// Dates are compared as civil dates, midnight UTC of their own wall-clock
+// day, so a posting at 23:30 IST on 31 March belongs to March whatever the
+// zone of the boundary (see ledger.CivilDate).
+package daterange
+
+import (
+	"fmt"
+	"time"
+)
+
+// Civil returns midnight UTC of the calendar day t falls on in its own
+// location, or the zero time for a zero t.
+func Civil(t time.Time) time.Time {
+	if t.IsZero() {
+		return t
+	}
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// Range is the half-open span of civil dates [Start, End). A zero Start or
+// End leaves that side unbounded.
+type Range struct {
+	Start time.Time // First day in the range
+	End   time.Time // First day after the range
+}
+
+// New returns the range [start, end) of civil dates.
+func New(start, end time.Time) Range {
+	return Range{Start: Civil(start), End: Civil(end)}
+}
+
+// Inclusive returns the range from from to to, both included, as ERPNext's
+// from_date and to_date filters mean. A zero from or to leaves that side
+// unbounded.
+func Inclusive(from, to time.Time) Range {
+	r := Range{Start: Civil(from)}
+	if !to.IsZero() {
+		r.End = Civil(to).AddDate(0, 0, 1)
+	}
+	return r
+}
+
+// On returns the range of the single day t falls on.
+func On(t time.Time) Range {
+	return Inclusive(t, t)
+}
+
+// Compare returns -1 if t falls before the range, +1 if on or after its
+// end, and 0 if within it.
+func (r Range) Compare(t time.Time) int {
+	d := Civil(t)
+	switch {
+	case !r.Start.IsZero() && d.Before(r.Start):
+		return -1
+	case !r.End.IsZero() && !d.Before(r.End):
+		return 1
+	}
+	return 0
+}
+
+// Contains reports whether t falls within the range.
+func (r Range) Contains(t time.Time) bool {
+	return r.Compare(t) == 0
+}
+
+// Last returns the last day of the range, the to_date of the inclusive
+// form, or the zero time when the end is unbounded.
+func (r Range) Last() time.Time {
+	if r.End.IsZero() {
+		return time.Time{}
+	}
+	return r.End.AddDate(0, 0, -1)
+}
+
+// Bounded reports whether both ends are set.
+func (r Range) Bounded() bool {
+	return !r.Start.IsZero() && !r.End.IsZero()
+}
+
+// Empty reports whether the range contains no day.
+func (r Range) Empty() bool {
+	return r.Bounded() && !r.Start.Before(r.End)
+}
+
+// Days returns the number of days in a bounded range, 0 if it is empty or
+// unbounded.
+func (r Range) Days() int {
+	if !r.Bounded() || r.Empty() {
+		return 0
+	}
+	return int(r.End.Sub(r.Start).Hours()/24 + 0.5)
+}
+
+// Intersect returns the days in both ranges. The result may be Empty.
+func (r Range) Intersect(o Range) Range {
+	out := r
+	if out.Start.IsZero() || o.Start.After(out.Start) {
+		out.Start = o.Start
+	}
+	if out.End.IsZero() || (!o.End.IsZero() && o.End.Before(out.End)) {
+		out.End = o.End
+	}
+	return out
+}
+
+// Overlaps reports whether the ranges have a day in common.
+func (r Range) Overlaps(o Range) bool {
+	return !r.Intersect(o).Empty()
+}
+
+// String formats the range as "[2024-04-01, 2025-04-01)", with "..." for
+// an unbounded side.
+func (r Range) String() string {
+	format := func(t time.Time) string {
+		if t.IsZero() {
+			return "..."
+		}
+		return t.Format("2006-01-02")
+	}
+	return fmt.Sprintf("[%s, %s)", format(r.Start), format(r.End))
+}
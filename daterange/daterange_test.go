@@ -0,0 +1,116 @@
+package daterange
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func date(s string) time.Time {
+	t, _ := time.Parse("2006-01-02", s)
+	return t
+}
+
+func TestInclusiveIsHalfOpen(t *testing.T) {
+	ist := time.FixedZone("IST", 5*3600+1800)
+	march := Inclusive(date("2026-03-01"), date("2026-03-31"))
+	if march != New(date("2026-03-01"), date("2026-04-01")) {
+		t.Fatalf("Inclusive = %s", march)
+	}
+	for _, tc := range []struct {
+		t    time.Time
+		want int
+	}{
+		{date("2026-02-28"), -1},
+		{date("2026-03-01"), 0},
+		{time.Date(2026, 3, 31, 23, 30, 0, 0, ist), 0}, // Late on the last day, in another zone
+		{date("2026-04-01"), 1},
+	} {
+		if got := march.Compare(tc.t); got != tc.want {
+			t.Errorf("Compare(%v) = %d, want %d", tc.t, got, tc.want)
+		}
+	}
+	if !march.Last().Equal(date("2026-03-31")) || march.Days() != 31 {
+		t.Errorf("Last = %v, Days = %d", march.Last(), march.Days())
+	}
+	if open := Inclusive(time.Time{}, date("2026-03-31")); !open.Contains(date("1999-01-01")) || open.Contains(date("2026-04-01")) {
+		t.Errorf("open start: %s", open)
+	}
+	if !(Range{}).Contains(date("2026-01-01")) {
+		t.Error("unbounded range excludes a date")
+	}
+}
+
+func TestIntersect(t *testing.T) {
+	q1 := Inclusive(date("2026-01-01"), date("2026-03-31"))
+	if got := q1.Intersect(Month(date("2026-03-15"))); got != Month(date("2026-03-01")) {
+		t.Errorf("Intersect = %s", got)
+	}
+	if q1.Overlaps(Month(date("2026-04-01"))) {
+		t.Error("adjacent ranges overlap")
+	}
+	if got := q1.Intersect(Range{Start: date("2026-02-01")}); got.Start != date("2026-02-01") || got.End != q1.End {
+		t.Errorf("Intersect with open range = %s", got)
+	}
+}
+
+func TestPeriods(t *testing.T) {
+	if got := Quarter(date("2026-05-20")); got != Inclusive(date("2026-04-01"), date("2026-06-30")) {
+		t.Errorf("Quarter = %s", got)
+	}
+	// Indian fiscal year: Q4 is January to March.
+	if got := PeriodOf(date("2026-02-10"), Quarterly, time.April); got != Inclusive(date("2026-01-01"), date("2026-03-31")) {
+		t.Errorf("fiscal quarter = %s", got)
+	}
+	if got := PeriodOf(date("2026-02-10"), HalfYearly, time.April); got != Inclusive(date("2025-10-01"), date("2026-03-31")) {
+		t.Errorf("fiscal half = %s", got)
+	}
+
+	parts, err := Split(Inclusive(date("2026-02-15"), date("2026-07-10")), Quarterly, time.January)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Range{
+		Inclusive(date("2026-02-15"), date("2026-03-31")),
+		Inclusive(date("2026-04-01"), date("2026-06-30")),
+		Inclusive(date("2026-07-01"), date("2026-07-10")),
+	}
+	if len(parts) != len(want) {
+		t.Fatalf("Split = %v", parts)
+	}
+	for i := range want {
+		if parts[i] != want[i] {
+			t.Errorf("part %d = %s, want %s", i, parts[i], want[i])
+		}
+	}
+	if _, err := Split(Range{Start: date("2026-01-01")}, Monthly, time.January); err == nil {
+		t.Error("split an unbounded range")
+	}
+}
+
+type fiscalYears struct{}
+
+func (fiscalYears) GetFiscalYear(d time.Time, company string) (string, error) {
+	if d.Before(date("2025-04-01")) || !d.Before(date("2026-04-01")) {
+		return "", errors.New("no fiscal year")
+	}
+	return "2025-2026", nil
+}
+
+func (fiscalYears) GetFiscalYearDates(fy, company string) (time.Time, time.Time, error) {
+	return date("2025-04-01"), date("2026-03-31"), nil
+}
+
+func TestFiscalPeriod(t *testing.T) {
+	name, year, err := FiscalYear(fiscalYears{}, date("2026-03-31"), "ABC")
+	if err != nil || name != "2025-2026" || year != New(date("2025-04-01"), date("2026-04-01")) {
+		t.Fatalf("FiscalYear = %s %s %v", name, year, err)
+	}
+	q, err := FiscalPeriod(fiscalYears{}, date("2025-11-05"), "ABC", Quarterly)
+	if err != nil || q != Inclusive(date("2025-10-01"), date("2025-12-31")) {
+		t.Errorf("FiscalPeriod = %s %v", q, err)
+	}
+	if _, err := FiscalPeriod(fiscalYears{}, date("2027-01-01"), "ABC", Monthly); err == nil {
+		t.Error("date outside any fiscal year accepted")
+	}
+}
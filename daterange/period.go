@@ -0,0 +1,123 @@
+package daterange
+
+import (
+	"fmt"
+	"time"
+)
+
+// Periodicity is the length of the buckets of a periodic report.
+//
+// Maps to: the periodicity filter of financial_statements.py
+type Periodicity string
+
+const (
+	Monthly    Periodicity = "Monthly"
+	Quarterly  Periodicity = "Quarterly"
+	HalfYearly Periodicity = "Half-Yearly"
+	Yearly     Periodicity = "Yearly"
+)
+
+// Months returns the number of months in a bucket, or 0 for an unknown
+// periodicity.
+func (p Periodicity) Months() int {
+	switch p {
+	case Monthly:
+		return 1
+	case Quarterly:
+		return 3
+	case HalfYearly:
+		return 6
+	case Yearly:
+		return 12
+	}
+	return 0
+}
+
+// Month returns the calendar month t falls in.
+func Month(t time.Time) Range {
+	return PeriodOf(t, Monthly, time.January)
+}
+
+// Quarter returns the calendar quarter t falls in.
+func Quarter(t time.Time) Range {
+	return PeriodOf(t, Quarterly, time.January)
+}
+
+// PeriodOf returns the bucket of a periodicity that t falls in, counting
+// buckets from the first day of yearStart, the month the fiscal year
+// starts in: with April, the first quarter runs from April to June. An
+// unknown periodicity is treated as Monthly.
+func PeriodOf(t time.Time, p Periodicity, yearStart time.Month) Range {
+	n := p.Months()
+	if n == 0 {
+		n = 1
+	}
+	d := Civil(t)
+	offset := (int(d.Month()) - int(yearStart) + 12) % 12 // Months into the fiscal year
+	start := time.Date(d.Year(), d.Month()-time.Month(offset%n), 1, 0, 0, 0, 0, time.UTC)
+	return Range{Start: start, End: start.AddDate(0, n, 0)}
+}
+
+// Split cuts a bounded range into the buckets of a periodicity, aligned to
+// yearStart as in PeriodOf. The first and last buckets are clipped to the
+// range.
+//
+// Maps to: get_period_list() in financial_statements.py
+func Split(r Range, p Periodicity, yearStart time.Month) ([]Range, error) {
+	if !r.Bounded() {
+		return nil, fmt.Errorf("cannot split unbounded range %s", r)
+	}
+	if p.Months() == 0 {
+		return nil, fmt.Errorf("unknown periodicity %q", p)
+	}
+	var out []Range
+	for cur := r.Start; cur.Before(r.End); {
+		bucket := PeriodOf(cur, p, yearStart).Intersect(r)
+		out = append(out, bucket)
+		cur = bucket.End
+	}
+	return out, nil
+}
+
+// FiscalYears resolves fiscal years. ledger.FiscalYearLookup satisfies it.
+type FiscalYears interface {
+	GetFiscalYear(date time.Time, company string) (string, error)
+	GetFiscalYearDates(fiscalYear string, company string) (start, end time.Time, err error)
+}
+
+// FiscalYear returns the name and range of the fiscal year a date falls in
+// for a company. The lookup's end date is inclusive, as in the Fiscal Year
+// doctype.
+//
+// Maps to: get_fiscal_year(date, company=company) in accounts/utils.py
+func FiscalYear(lookup FiscalYears, date time.Time, company string) (string, Range, error) {
+	name, err := lookup.GetFiscalYear(date, company)
+	if err != nil {
+		return "", Range{}, err
+	}
+	start, end, err := lookup.GetFiscalYearDates(name, company)
+	if err != nil {
+		return "", Range{}, err
+	}
+	return name, Inclusive(start, end), nil
+}
+
+// FiscalPeriod returns the bucket of a periodicity within the fiscal year
+// that a date falls in, clipped to the fiscal year, so that short or long
+// fiscal years still get whole buckets.
+func FiscalPeriod(lookup FiscalYears, date time.Time, company string, p Periodicity) (Range, error) {
+	_, year, err := FiscalYear(lookup, date, company)
+	if err != nil {
+		return Range{}, err
+	}
+	periods, err := Split(year, p, year.Start.Month())
+	if err != nil {
+		return Range{}, err
+	}
+	for _, r := range periods {
+		if r.Contains(date) {
+			return r, nil
+		}
+	}
+	return Range{}, fmt.Errorf("%s is outside fiscal year %s", Civil(date).Format("2006-01-02"), year)
+}
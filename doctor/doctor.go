@@ -0,0 +1,348 @@
+// Package doctor checks a company's accounting setup for the
+// misconfigurations that make postings fail later: no round-off account,
+// no fiscal year for today, disabled default accounts, and accounting
+// dimension filters that block the defaults the engine posts with.
+//
+// Each finding says what is wrong and how to fix it. Fixes that only fill
+// in missing setup, such as creating the next fiscal year or pointing the
+// round-off account at the chart's Round Off account, are safe and can be
+// applied through a Fixer; everything else is left to the user.
+//
+// Run is the body of a `doctor` command: it audits, optionally applies the
+// safe fixes, and prints the report.
+package doctor
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/ledger"
+)
+
+// ErrNoFixer is returned when fixes are requested without a Fixer.
+var ErrNoFixer = errors.New("no fixer configured to apply fixes")
+
+// Check names a health check.
+type Check string
+
+const (
+	CheckRoundOffAccount Check = "round_off_account"
+	CheckFiscalYear      Check = "fiscal_year"
+	CheckDefaultAccount  Check = "default_account"
+	CheckDimensionFilter Check = "dimension_filter"
+)
+
+// Level is how serious a finding is.
+type Level string
+
+const (
+	Error   Level = "error"   // Postings will fail
+	Warning Level = "warning" // Some postings will fail or need manual input
+)
+
+// Finding is a problem found by a check and how to fix it.
+type Finding struct {
+	Check   Check
+	Level   Level
+	Problem string
+	Fix     string // What to do about it
+
+	apply func(Fixer) error // Set for safe fixes
+}
+
+// Safe reports whether the fix can be applied automatically.
+func (f Finding) Safe() bool {
+	return f.apply != nil
+}
+
+// Fixer writes the setup changes of safe fixes.
+type Fixer interface {
+	// SetRoundOffAccount sets the company's round-off account.
+	SetRoundOffAccount(company, account string) error
+
+	// CreateFiscalYear creates a fiscal year; end is inclusive.
+	CreateFiscalYear(company, name string, start, end time.Time) error
+}
+
+// DimensionFilter limits the values of an accounting dimension that may
+// be posted to some accounts.
+//
+// Maps to: Accounting Dimension Filter doctype
+type DimensionFilter struct {
+	Name      string
+	Dimension string // e.g. "Cost Center"
+	Accounts  []string
+	Restrict  bool     // Values are blocked, rather than the only ones allowed
+	Values    []string // Dimension values allowed or blocked
+}
+
+// Allows reports whether the filter lets a dimension value be posted to an
+// account.
+func (f *DimensionFilter) Allows(account, value string) bool {
+	if !slices.Contains(f.Accounts, account) {
+		return true
+	}
+	return slices.Contains(f.Values, value) != f.Restrict
+}
+
+// Auditor runs the health checks for one company.
+type Auditor struct {
+	Company string
+	Today   time.Time // time.Now() when zero
+
+	Chart       []ledger.Account // The company's accounts
+	Settings    ledger.CompanySettings
+	FiscalYears ledger.FiscalYearLookup
+
+	// Other default accounts by setting name, such as
+	// "Default Receivable Account"
+	DefaultAccounts map[string]string
+
+	// Company default values by dimension, such as "Cost Center": "Main - ACME"
+	DefaultDimensions map[string]string
+	DimensionFilters  []DimensionFilter
+
+	Fixer Fixer // Optional; needed to apply fixes
+}
+
+// Report is the outcome of a health check.
+type Report struct {
+	Company  string
+	Findings []Finding // Still to fix
+	Fixed    []Finding // Fixed by Fix
+}
+
+// OK reports whether no errors are left.
+func (r *Report) OK() bool {
+	return !slices.ContainsFunc(r.Findings, func(f Finding) bool { return f.Level == Error })
+}
+
+// String formats the report for a terminal.
+func (r *Report) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Accounting health check for %s\n", r.Company)
+	for _, f := range r.Fixed {
+		fmt.Fprintf(&b, "fixed    %s: %s\n         %s\n", f.Check, f.Problem, f.Fix)
+	}
+	for _, f := range r.Findings {
+		fmt.Fprintf(&b, "%-8s %s: %s\n         fix: %s", f.Level, f.Check, f.Problem, f.Fix)
+		if f.Safe() {
+			b.WriteString(" (safe; apply with --fix)")
+		}
+		b.WriteString("\n")
+	}
+	if len(r.Findings) == 0 {
+		b.WriteString("no problems found\n")
+	}
+	return b.String()
+}
+
+// Audit runs every check.
+func (a *Auditor) Audit() (*Report, error) {
+	r := &Report{Company: a.Company}
+	for _, check := range []func() ([]Finding, error){a.checkRoundOffAccount, a.checkFiscalYear, a.checkDefaultAccounts, a.checkDimensionFilters} {
+		findings, err := check()
+		if err != nil {
+			return nil, err
+		}
+		r.Findings = append(r.Findings, findings...)
+	}
+	return r, nil
+}
+
+// Fix applies the report's safe fixes and moves them to Fixed. It stops
+// at the first fix that fails.
+func (a *Auditor) Fix(r *Report) error {
+	if !slices.ContainsFunc(r.Findings, Finding.Safe) {
+		return nil
+	}
+	if a.Fixer == nil {
+		return ErrNoFixer
+	}
+	var left []Finding
+	for i, f := range r.Findings {
+		if !f.Safe() {
+			left = append(left, f)
+			continue
+		}
+		if err := f.apply(a.Fixer); err != nil {
+			r.Findings = append(left, r.Findings[i:]...)
+			return fmt.Errorf("%s: %w", f.Check, err)
+		}
+		r.Fixed = append(r.Fixed, f)
+	}
+	r.Findings = left
+	return nil
+}
+
+// Run audits the company, applies the safe fixes when fix is set, and
+// writes the report to w.
+func (a *Auditor) Run(w io.Writer, fix bool) (*Report, error) {
+	r, err := a.Audit()
+	if err != nil {
+		return nil, err
+	}
+	if fix {
+		err = a.Fix(r)
+	}
+	if _, werr := io.WriteString(w, r.String()); err == nil {
+		err = werr
+	}
+	return r, err
+}
+
+func (a *Auditor) today() time.Time {
+	if a.Today.IsZero() {
+		return ledger.CivilDate(time.Now())
+	}
+	return ledger.CivilDate(a.Today)
+}
+
+// account returns an account of the chart, or nil.
+func (a *Auditor) account(name string) *ledger.Account {
+	i := slices.IndexFunc(a.Chart, func(acc ledger.Account) bool { return acc.Name == name })
+	if i < 0 {
+		return nil
+	}
+	return &a.Chart[i]
+}
+
+// unusable says why an account cannot take postings, or "" if it can.
+func (a *Auditor) unusable(name string) string {
+	acc := a.account(name)
+	switch {
+	case acc == nil:
+		return "is not in the chart of accounts"
+	case acc.Disabled:
+		return "is disabled"
+	case acc.IsGroup:
+		return "is a group account"
+	}
+	return ""
+}
+
+// checkRoundOffAccount checks that rounding differences have an account
+// to go to.
+//
+// Maps to: the round_off_account check of make_round_off_gle() in
+// general_ledger.py
+func (a *Auditor) checkRoundOffAccount() ([]Finding, error) {
+	account, err := a.Settings.GetRoundOffAccount(a.Company)
+	if err != nil {
+		return nil, err
+	}
+	problem := "no round-off account is set"
+	if account != "" {
+		why := a.unusable(account)
+		if why == "" {
+			return nil, nil
+		}
+		problem = fmt.Sprintf("round-off account %s %s", account, why)
+	}
+
+	f := Finding{Check: CheckRoundOffAccount, Level: Error, Problem: problem,
+		Fix: "create a Round Off expense account and set it as the company's round-off account"}
+	i := slices.IndexFunc(a.Chart, func(acc ledger.Account) bool {
+		return acc.AccountName == "Round Off" && acc.Company == a.Company && !acc.IsGroup && !acc.Disabled
+	})
+	if i >= 0 {
+		candidate := a.Chart[i].Name
+		f.Fix = fmt.Sprintf("set %s as the round-off account", candidate)
+		f.apply = func(fx Fixer) error { return fx.SetRoundOffAccount(a.Company, candidate) }
+	}
+	return []Finding{f}, nil
+}
+
+// checkFiscalYear checks that today falls in a fiscal year. When an
+// earlier year is known, the fix creates the year covering today with the
+// same start month.
+//
+// Maps to: the "Fiscal Year ... not found" error of get_fiscal_years() in
+// accounts/utils.py
+func (a *Auditor) checkFiscalYear() ([]Finding, error) {
+	today := a.today()
+	if _, err := a.FiscalYears.GetFiscalYear(today, a.Company); err == nil {
+		return nil, nil
+	}
+
+	f := Finding{Check: CheckFiscalYear, Level: Error,
+		Problem: fmt.Sprintf("no fiscal year covers %s", today.Format(ledger.DateLayout)),
+		Fix:     "create a fiscal year covering today"}
+	for back := 1; back <= 10; back++ {
+		name, err := a.FiscalYears.GetFiscalYear(today.AddDate(-back, 0, 0), a.Company)
+		if err != nil {
+			continue
+		}
+		start, _, err := a.FiscalYears.GetFiscalYearDates(name, a.Company)
+		if err != nil {
+			return nil, err
+		}
+		start = ledger.CivilDate(start).AddDate(back, 0, 0)
+		end := start.AddDate(1, 0, -1)
+		next := fmt.Sprintf("%d-%d", start.Year(), end.Year())
+		if start.Year() == end.Year() {
+			next = fmt.Sprint(start.Year())
+		}
+		f.Fix = fmt.Sprintf("create fiscal year %s from %s to %s", next, start.Format(ledger.DateLayout), end.Format(ledger.DateLayout))
+		f.apply = func(fx Fixer) error { return fx.CreateFiscalYear(a.Company, next, start, end) }
+		break
+	}
+	return []Finding{f}, nil
+}
+
+// checkDefaultAccounts checks that the default accounts can take
+// postings.
+func (a *Auditor) checkDefaultAccounts() ([]Finding, error) {
+	var findings []Finding
+	for _, setting := range slices.Sorted(maps.Keys(a.DefaultAccounts)) {
+		account := a.DefaultAccounts[setting]
+		if account == "" {
+			continue
+		}
+		if why := a.unusable(account); why != "" {
+			findings = append(findings, Finding{Check: CheckDefaultAccount, Level: Error,
+				Problem: fmt.Sprintf("%s %s %s", setting, account, why),
+				Fix:     fmt.Sprintf("enable %s as a ledger account or choose another %s", account, setting)})
+		}
+	}
+	return findings, nil
+}
+
+// checkDimensionFilters checks that no filter blocks the default dimension
+// values on the accounts it covers. Round-off entries carry the round-off
+// cost center.
+//
+// Maps to: validate_allowed_dimensions() in general_ledger.py
+func (a *Auditor) checkDimensionFilters() ([]Finding, error) {
+	roundOff, err := a.Settings.GetRoundOffAccount(a.Company)
+	if err != nil {
+		return nil, err
+	}
+	roundOffCostCenter, err := a.Settings.GetRoundOffCostCenter(a.Company)
+	if err != nil {
+		return nil, err
+	}
+
+	var findings []Finding
+	for i := range a.DimensionFilters {
+		filter := &a.DimensionFilters[i]
+		for _, account := range filter.Accounts {
+			value, what := a.DefaultDimensions[filter.Dimension], "default"
+			if account == roundOff && filter.Dimension == "Cost Center" && roundOffCostCenter != "" {
+				value, what = roundOffCostCenter, "round-off"
+			}
+			if value == "" || filter.Allows(account, value) {
+				continue
+			}
+			findings = append(findings, Finding{Check: CheckDimensionFilter, Level: Warning,
+				Problem: fmt.Sprintf("filter %s blocks the %s %s %s on %s", filter.Name, what, filter.Dimension, value, account),
+				Fix:     fmt.Sprintf("allow %s in %s or remove %s from it", value, filter.Name, account)})
+		}
+	}
+	return findings, nil
+}
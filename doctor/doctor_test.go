@@ -0,0 +1,184 @@
+package doctor
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/ledger"
+	"github.com/senguttuvang/erpnext-go/ledger/memstore"
+)
+
+func date(s string) time.Time {
+	t, err := ledger.ParseDate(s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// setup is an in-memory company setup that is also the Fixer.
+type setup struct {
+	company *memstore.Company
+	years   map[string][2]time.Time
+}
+
+func (s *setup) GetFiscalYear(d time.Time, company string) (string, error) {
+	for name, r := range s.years {
+		if !d.Before(r[0]) && !d.After(r[1]) {
+			return name, nil
+		}
+	}
+	return "", ledger.ErrFiscalYearNotFound
+}
+
+func (s *setup) GetFiscalYearDates(name, company string) (time.Time, time.Time, error) {
+	r, ok := s.years[name]
+	if !ok {
+		return time.Time{}, time.Time{}, ledger.ErrFiscalYearNotFound
+	}
+	return r[0], r[1], nil
+}
+
+func (s *setup) SetRoundOffAccount(company, account string) error {
+	s.company.RoundOffAccount = account
+	return nil
+}
+
+func (s *setup) CreateFiscalYear(company, name string, start, end time.Time) error {
+	if _, ok := s.years[name]; ok {
+		return fmt.Errorf("fiscal year %s exists", name)
+	}
+	s.years[name] = [2]time.Time{start, end}
+	return nil
+}
+
+func newAuditor() (*Auditor, *setup) {
+	s := &setup{
+		company: &memstore.Company{DefaultCurrency: "INR", RoundOffCostCenter: "Main - ACME"},
+		years:   map[string][2]time.Time{"2024-2025": {date("2024-04-01"), date("2025-03-31")}},
+	}
+	return &Auditor{
+		Company: "ACME",
+		Today:   date("2026-06-15"),
+		Chart: []ledger.Account{
+			{Name: "Round Off - ACME", AccountName: "Round Off", Company: "ACME", RootType: "Expense"},
+			{Name: "Debtors - ACME", AccountName: "Debtors", Company: "ACME", RootType: "Asset", Disabled: true},
+			{Name: "Creditors - ACME", AccountName: "Creditors", Company: "ACME", RootType: "Liability"},
+			{Name: "Accounts Receivable - ACME", AccountName: "Accounts Receivable", Company: "ACME", IsGroup: true},
+		},
+		Settings:    s.company,
+		FiscalYears: s,
+		DefaultAccounts: map[string]string{
+			"Default Receivable Account": "Debtors - ACME",
+			"Default Payable Account":    "Creditors - ACME",
+			"Default Income Account":     "Sales - ACME",
+		},
+		DefaultDimensions: map[string]string{"Cost Center": "Main - ACME"},
+		DimensionFilters: []DimensionFilter{
+			{Name: "Branch only", Dimension: "Cost Center", Accounts: []string{"Round Off - ACME"}, Values: []string{"Branch - ACME"}},
+			{Name: "No HQ", Dimension: "Cost Center", Accounts: []string{"Creditors - ACME"}, Restrict: true, Values: []string{"HQ - ACME"}},
+		},
+		Fixer: s,
+	}, s
+}
+
+func TestAudit(t *testing.T) {
+	a, s := newAuditor()
+	r, err := a.Audit()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct {
+		check Check
+		level Level
+		safe  bool
+		has   string
+	}{
+		{CheckRoundOffAccount, Error, true, "set Round Off - ACME as the round-off account"},
+		{CheckFiscalYear, Error, true, "create fiscal year 2026-2027 from 2026-04-01 to 2027-03-31"},
+		{CheckDefaultAccount, Error, false, "Default Income Account Sales - ACME is not in the chart"},
+		{CheckDefaultAccount, Error, false, "Default Receivable Account Debtors - ACME is disabled"},
+		{CheckDimensionFilter, Warning, false, "filter Branch only blocks the default Cost Center Main - ACME on Round Off - ACME"},
+	}
+	if len(r.Findings) != len(want) {
+		t.Fatalf("findings:\n%s", r)
+	}
+	for i, w := range want {
+		f := r.Findings[i]
+		if f.Check != w.check || f.Level != w.level || f.Safe() != w.safe || !strings.Contains(f.Problem+" "+f.Fix, w.has) {
+			t.Errorf("finding %d = %+v, want %+v", i, f, w)
+		}
+	}
+	if r.OK() {
+		t.Error("OK() with errors")
+	}
+
+	// Fixing sets the round-off account, which makes its cost center the
+	// round-off one, and creates this year
+	var out strings.Builder
+	if r, err = a.Run(&out, true); err != nil {
+		t.Fatal(err)
+	}
+	if s.company.RoundOffAccount != "Round Off - ACME" || len(r.Fixed) != 2 || len(r.Findings) != 3 {
+		t.Fatalf("after fixing:\n%s", out.String())
+	}
+	if _, ok := s.years["2026-2027"]; !ok {
+		t.Error("fiscal year not created")
+	}
+	if !strings.Contains(out.String(), "fixed    fiscal_year") {
+		t.Errorf("report:\n%s", out.String())
+	}
+
+	r, err = a.Audit()
+	if err != nil || len(r.Findings) != 3 || !strings.Contains(r.Findings[2].Problem, "blocks the round-off Cost Center") {
+		t.Fatalf("re-audit:\n%s", r)
+	}
+	a.DefaultAccounts = nil
+	a.DimensionFilters[0].Values = append(a.DimensionFilters[0].Values, "Main - ACME")
+	if r, _ = a.Audit(); !r.OK() || len(r.Findings) != 0 || !strings.Contains(r.String(), "no problems found") {
+		t.Errorf("healthy setup:\n%s", r)
+	}
+}
+
+func TestFixWithoutFixer(t *testing.T) {
+	a, _ := newAuditor()
+	a.Fixer = nil
+	var out strings.Builder
+	if _, err := a.Run(&out, true); !errors.Is(err, ErrNoFixer) {
+		t.Errorf("Run() error = %v", err)
+	}
+	if !strings.Contains(out.String(), "(safe; apply with --fix)") {
+		t.Errorf("report:\n%s", out.String())
+	}
+
+	// Without an earlier fiscal year or a Round Off account nothing is safe
+	a.Chart = a.Chart[1:]
+	a.Today = date("2040-01-01")
+	r, err := a.Run(&out, true)
+	if err != nil || r.Findings[0].Safe() || r.Findings[1].Safe() {
+		t.Errorf("Run() = %v\n%s", err, r)
+	}
+}
+
+func TestDimensionFilterAllows(t *testing.T) {
+	allow := DimensionFilter{Accounts: []string{"Sales"}, Values: []string{"North"}}
+	restrict := DimensionFilter{Accounts: []string{"Sales"}, Restrict: true, Values: []string{"North"}}
+	for _, tt := range []struct {
+		f              DimensionFilter
+		account, value string
+		want           bool
+	}{
+		{allow, "Sales", "North", true},
+		{allow, "Sales", "South", false},
+		{allow, "Rent", "South", true},
+		{restrict, "Sales", "North", false},
+		{restrict, "Sales", "South", true},
+	} {
+		if got := tt.f.Allows(tt.account, tt.value); got != tt.want {
+			t.Errorf("%+v.Allows(%s, %s) = %v", tt.f, tt.account, tt.value, got)
+		}
+	}
+}
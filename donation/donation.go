@@ -0,0 +1,245 @@
+// Package donation receives the donations of a nonprofit: it posts each to
+// income through the Engine, numbers its receipt from a statutory series,
+// and summarises every donor's year for tax relief, as the Form 10BD
+// statement of India's section 80G and the schedule of a UK Gift Aid
+// claim.
+//
+// Receipts are numbered only once the donation is posted, so the series
+// has no gaps for donations that failed to post. Donations booked to a
+// fund carry it on their GL entries (see package fund).
+//
+// Maps to: the Donor and Donation doctypes of erpnext/non_profit and the
+// Tax Exemption 80G Certificate of erpnext/regional/india
+package donation
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/fund"
+	"github.com/senguttuvang/erpnext-go/ledger"
+	"github.com/senguttuvang/erpnext-go/naming"
+)
+
+// VoucherType is the voucher type of donation postings.
+const VoucherType = "Donation"
+
+// ReceiptDoctype names receipts in a naming.Registry; ReceiptSeries is
+// its default series, numbered per fiscal year as section 80G requires.
+const (
+	ReceiptDoctype = "Donation Receipt"
+	ReceiptSeries  = "NPO-RCPT-.FY.-.#####"
+)
+
+// Donation errors
+var (
+	ErrInvalidDonation = errors.New("invalid donation")
+	ErrUnknownDonor    = errors.New("unknown donor")
+	ErrIncompleteDonor = errors.New("donor details are incomplete")
+)
+
+// Mode is how a donation was received, as Form 10BD classifies it.
+type Mode string
+
+const (
+	Cash       Mode = "Cash"
+	Kind       Mode = "Kind"
+	Electronic Mode = "Electronic modes including account payee cheque/draft"
+	OtherMode  Mode = "Others"
+)
+
+// Purpose is what a donation was given for, as Form 10BD classifies it.
+type Purpose string
+
+const (
+	Corpus        Purpose = "Corpus"
+	SpecificGrant Purpose = "Specific grant"
+	OtherPurpose  Purpose = "Others"
+)
+
+// Donor is a person or organisation giving to the nonprofit.
+//
+// Maps to: Donor doctype
+type Donor struct {
+	Name      string
+	DonorName string
+	Email     string
+	Address   []string
+
+	// India: the identification Form 10BD reports
+	IDType   IDType
+	IDNumber string
+
+	// UK: the details a Gift Aid claim reports, and the declaration
+	// allowing it
+	Title       string
+	FirstName   string
+	LastName    string
+	HouseNumber string // House name or number
+	Postcode    string // "X" for donors living abroad
+	GiftAid     *Declaration
+}
+
+// Declaration is a donor's Gift Aid declaration.
+type Declaration struct {
+	Made       time.Time
+	CoversPast bool      // Also covers the four years before it was made
+	Cancelled  time.Time // Zero while in force
+}
+
+// Covers reports whether the declaration covers a donation on date.
+func (d *Declaration) Covers(date time.Time) bool {
+	if d == nil {
+		return false
+	}
+	date = ledger.CivilDate(date)
+	from := ledger.CivilDate(d.Made)
+	if d.CoversPast {
+		from = from.AddDate(-4, 0, 0)
+	}
+	return !date.Before(from) && (d.Cancelled.IsZero() || date.Before(ledger.CivilDate(d.Cancelled)))
+}
+
+// Donors are the donors of a deployment.
+type Donors []Donor
+
+// Get returns the named donor, or nil.
+func (ds Donors) Get(name string) *Donor {
+	for i := range ds {
+		if ds[i].Name == name {
+			return &ds[i]
+		}
+	}
+	return nil
+}
+
+// Donation is a gift received from a donor.
+//
+// Maps to: Donation doctype
+type Donation struct {
+	Name           string
+	Company        string
+	Donor          string
+	Date           time.Time
+	Amount         float64 // Company currency
+	Mode           Mode
+	Purpose        Purpose
+	ReferenceNo    string // Cheque or transaction reference
+	SponsoredEvent bool   // Sponsorship of an event the donor takes part in
+
+	PaidTo        string // Bank or cash account; for gifts in kind, the asset received
+	IncomeAccount string
+	CostCenter    string
+	Fund          string // Optional; the fund the donation is booked to
+
+	ReceiptNo string // Set by Submit
+}
+
+// Validate checks the donation.
+func (d *Donation) Validate() error {
+	switch {
+	case d.Name == "" || d.Company == "" || d.Donor == "":
+		return fmt.Errorf("%w: name, company and donor are required", ErrInvalidDonation)
+	case d.Date.IsZero():
+		return fmt.Errorf("%w: %s: date is required", ErrInvalidDonation, d.Name)
+	case d.Amount <= 0:
+		return fmt.Errorf("%w: %s: amount must be greater than zero", ErrInvalidDonation, d.Name)
+	case d.PaidTo == "" || d.IncomeAccount == "":
+		return fmt.Errorf("%w: %s: paid to and income accounts are required", ErrInvalidDonation, d.Name)
+	}
+	switch d.Mode {
+	case Cash, Kind, Electronic, OtherMode:
+	default:
+		return fmt.Errorf("%w: %s: mode %q", ErrInvalidDonation, d.Name, d.Mode)
+	}
+	switch d.Purpose {
+	case Corpus, SpecificGrant, OtherPurpose:
+	default:
+		return fmt.Errorf("%w: %s: purpose %q", ErrInvalidDonation, d.Name, d.Purpose)
+	}
+	return nil
+}
+
+// GLMap returns the donation's GL entries: a debit of the account it was
+// paid to and a credit of income, both in the donation's fund.
+func (d *Donation) GLMap() []ledger.GLEntry {
+	date := ledger.CivilDate(d.Date)
+	remarks := fmt.Sprintf("Donation from %s", d.Donor)
+	if d.ReferenceNo != "" {
+		remarks += ", reference " + d.ReferenceNo
+	}
+	paid := d.newGLEntry(date, d.PaidTo, d.IncomeAccount, remarks)
+	paid.Debit, paid.DebitInAccountCurrency, paid.DebitInTransactionCurrency = d.Amount, d.Amount, d.Amount
+	income := d.newGLEntry(date, d.IncomeAccount, d.Donor, remarks)
+	income.Credit, income.CreditInAccountCurrency, income.CreditInTransactionCurrency = d.Amount, d.Amount, d.Amount
+	return []ledger.GLEntry{paid, income}
+}
+
+// Submit posts the donation and numbers its receipt from the
+// ReceiptDoctype series of names, in the fiscal year of the donation when
+// the engine has FiscalYears and the calendar year otherwise. A donation
+// posted before whose receipt could not be numbered is only numbered; a
+// donation with a receipt is left as it is.
+//
+// Maps to: Donation.on_submit()
+func (d *Donation) Submit(engine *ledger.Engine, names *naming.Registry) error {
+	if d.ReceiptNo != "" {
+		return nil
+	}
+	if err := d.Validate(); err != nil {
+		return err
+	}
+	posted, err := engine.GLStore.GetByVoucher(VoucherType, d.Name)
+	if err != nil {
+		return err
+	}
+	if !hasLive(posted) {
+		if err := engine.MakeGLEntries(d.GLMap(), ledger.DefaultPostingOptions()); err != nil {
+			return fmt.Errorf("%s: %w", d.Name, err)
+		}
+	}
+
+	fy := ledger.CivilDate(d.Date).Format("2006")
+	if engine.FiscalYears != nil {
+		if fy, err = engine.FiscalYears.GetFiscalYear(d.Date, d.Company); err != nil {
+			return fmt.Errorf("%s: %w", d.Name, err)
+		}
+	}
+	receipt, err := names.NewName(ReceiptDoctype, naming.Options{Date: d.Date, Values: map[string]string{"FY": fy}})
+	if err != nil {
+		return fmt.Errorf("%s: %w", d.Name, err)
+	}
+	d.ReceiptNo = receipt
+	return nil
+}
+
+func hasLive(entries []ledger.GLEntry) bool {
+	for _, cancelled := range ledger.CancelledEntries(entries) {
+		if !cancelled {
+			return true
+		}
+	}
+	return false
+}
+
+func (d *Donation) newGLEntry(date time.Time, account, against, remarks string) ledger.GLEntry {
+	e := ledger.GLEntry{
+		PostingDate:             date,
+		TransactionDate:         date,
+		Account:                 account,
+		Against:                 against,
+		VoucherType:             VoucherType,
+		VoucherNo:               d.Name,
+		Company:                 d.Company,
+		CostCenter:              d.CostCenter,
+		TransactionExchangeRate: 1,
+		IsOpening:               ledger.IsOpeningNo,
+		IsAdvance:               ledger.IsAdvanceNo,
+		Remarks:                 remarks,
+	}
+	if d.Fund != "" {
+		fund.Set(&e, d.Fund)
+	}
+	return e
+}
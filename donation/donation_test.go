@@ -0,0 +1,203 @@
+package donation
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/daterange"
+	"github.com/senguttuvang/erpnext-go/fund"
+	"github.com/senguttuvang/erpnext-go/ledger"
+	"github.com/senguttuvang/erpnext-go/ledger/memstore"
+	"github.com/senguttuvang/erpnext-go/naming"
+	"github.com/senguttuvang/erpnext-go/reportio"
+)
+
+func date(y int, m time.Month, d int) time.Time {
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// indianYears is the April to March financial year.
+type indianYears struct{}
+
+func (indianYears) GetFiscalYear(d time.Time, company string) (string, error) {
+	y := d.Year()
+	if d.Month() < time.April {
+		y--
+	}
+	return fmt.Sprintf("%d-%02d", y, (y+1)%100), nil
+}
+
+func (indianYears) GetFiscalYearDates(string, string) (time.Time, time.Time, error) {
+	return time.Time{}, time.Time{}, nil
+}
+
+func setup(t *testing.T) (*ledger.Engine, *memstore.GLStore, *naming.Registry) {
+	t.Helper()
+	glStore := memstore.NewGLStore()
+	names := naming.NewRegistry(memstore.NewSeries())
+	if err := names.Register(ReceiptDoctype, ReceiptSeries); err != nil {
+		t.Fatal(err)
+	}
+	return &ledger.Engine{GLStore: glStore, FiscalYears: indianYears{}}, glStore, names
+}
+
+func donation(name, donor string, amount float64, mode Mode, on time.Time) Donation {
+	return Donation{
+		Name: name, Company: "Seva", Donor: donor, Date: on, Amount: amount, Mode: mode, Purpose: OtherPurpose,
+		PaidTo: "Bank - SV", IncomeAccount: "Donations - SV", CostCenter: "Main - SV",
+	}
+}
+
+func TestSubmit(t *testing.T) {
+	engine, glStore, names := setup(t)
+	d := donation("DTN-1", "Meera Iyer", 5000, Electronic, date(2024, 3, 20))
+	d.ReferenceNo, d.Fund = "UTR-88", "Schools"
+	if err := d.Submit(engine, names); err != nil {
+		t.Fatal(err)
+	}
+	entries, _ := glStore.GetByVoucher(VoucherType, "DTN-1")
+	if d.ReceiptNo != "NPO-RCPT-2023-24-00001" || len(entries) != 2 {
+		t.Fatalf("receipt %s, entries %+v", d.ReceiptNo, entries)
+	}
+	if e := entries[1]; e.Account != "Donations - SV" || e.Credit != 5000 || fund.Of(e) != "Schools" || e.Remarks != "Donation from Meera Iyer, reference UTR-88" {
+		t.Errorf("income entry = %+v", e)
+	}
+	if err := d.Submit(engine, names); err != nil || d.ReceiptNo != "NPO-RCPT-2023-24-00001" {
+		t.Errorf("second Submit() = %v, %s", err, d.ReceiptNo)
+	}
+
+	// The new financial year starts the series again; a failed posting
+	// takes no number
+	bad := donation("DTN-2", "Meera Iyer", 100, Cash, date(2024, 4, 2))
+	bad.IncomeAccount = ""
+	if err := bad.Submit(engine, names); !errors.Is(err, ErrInvalidDonation) || bad.ReceiptNo != "" {
+		t.Errorf("invalid Submit() = %v, %s", err, bad.ReceiptNo)
+	}
+	next := donation("DTN-3", "Meera Iyer", 100, Cash, date(2024, 4, 2))
+	if err := next.Submit(engine, names); err != nil || next.ReceiptNo != "NPO-RCPT-2024-25-00001" {
+		t.Errorf("Submit() = %v, %s", err, next.ReceiptNo)
+	}
+
+	// Posted but not numbered: numbering again does not post again
+	unnumbered := donation("DTN-4", "Meera Iyer", 100, Cash, date(2024, 4, 3))
+	if err := unnumbered.Submit(engine, naming.NewRegistry(memstore.NewSeries())); !errors.Is(err, naming.ErrNoSeries) {
+		t.Fatalf("Submit() without series = %v", err)
+	}
+	if err := unnumbered.Submit(engine, names); err != nil || unnumbered.ReceiptNo != "NPO-RCPT-2024-25-00002" {
+		t.Errorf("Submit() = %v, %s", err, unnumbered.ReceiptNo)
+	}
+	if entries, _ := glStore.GetByVoucher(VoucherType, "DTN-4"); len(entries) != 2 {
+		t.Errorf("DTN-4 posted %d entries", len(entries))
+	}
+
+	// Cancelled with its reversals saved live, as older stores hold them:
+	// submitting again posts it again
+	again := donation("DTN-5", "Meera Iyer", 100, Cash, date(2024, 4, 4))
+	cancelled := again.GLMap()
+	reversals := again.GLMap()
+	for i := range cancelled {
+		cancelled[i].IsCancelled = true
+		reversals[i].Debit, reversals[i].Credit = cancelled[i].Credit, cancelled[i].Debit
+	}
+	if err := glStore.SaveBatch(append(cancelled, reversals...)); err != nil {
+		t.Fatal(err)
+	}
+	if err := again.Submit(engine, names); err != nil {
+		t.Fatal(err)
+	}
+	if entries, _ := glStore.GetByVoucher(VoucherType, "DTN-5"); len(entries) != 6 {
+		t.Errorf("DTN-5 has %d entries, want 6", len(entries))
+	}
+}
+
+func TestForm10BD(t *testing.T) {
+	donors := Donors{
+		{Name: "Meera Iyer", DonorName: "Meera Iyer", Address: []string{"12 MG Road", "Pune"}, IDType: PAN, IDNumber: "ABCPI1234K"},
+		{Name: "Ravi Rao", DonorName: "Ravi Rao"},
+	}
+	org := Organization{Company: "Seva", Name: "Seva Trust", PAN: "AAATS0001A", Registration80G: "AAATS0001AF20214", RegistrationDate: date(2021, 5, 28)}
+	donations := []Donation{
+		donation("DTN-1", "Meera Iyer", 5000, Electronic, date(2024, 5, 1)),
+		donation("DTN-2", "Meera Iyer", 7000, Electronic, date(2024, 9, 1)),
+		donation("DTN-3", "Meera Iyer", 1500, Cash, date(2024, 6, 1)),
+		donation("DTN-4", "Meera Iyer", 2500, Cash, date(2024, 7, 1)),      // Cash above the limit
+		donation("DTN-5", "Meera Iyer", 900, Kind, date(2024, 7, 1)),       // In kind
+		donation("DTN-6", "Meera Iyer", 800, Electronic, date(2025, 4, 1)), // Next year
+		donation("DTN-7", "Ravi Rao", 1000, Electronic, date(2024, 8, 1)),
+		donation("DTN-8", "Meera Iyer", 600, Electronic, date(2024, 8, 1)), // Not submitted
+	}
+	for i := range donations[:7] {
+		donations[i].ReceiptNo = "R-" + donations[i].Name
+	}
+	donations[1].Purpose = Corpus
+
+	rows, err := Form10BD(donations, donors, org, daterange.Inclusive(date(2024, 4, 1), date(2025, 3, 31)))
+	if !errors.Is(err, ErrIncompleteDonor) || !strings.Contains(err.Error(), "Ravi Rao") {
+		t.Errorf("Form10BD() error = %v", err)
+	}
+	if len(rows) != 3 || rows[0].Amount != 5000 || rows[1].Purpose != Corpus || rows[1].Amount != 7000 || rows[2].Mode != Cash || rows[2].Amount != 1500 {
+		t.Fatalf("Form10BD() = %+v", rows)
+	}
+
+	var buf bytes.Buffer
+	if err := reportio.WriteCSV(&buf, Form10BDReport(rows)); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), `1,ABCPI1234K,Section 80G,AAATS0001AF20214,2021-05-28,Meera Iyer,"12 MG Road, Pune",Others,Electronic modes including account payee cheque/draft,5000.00`) {
+		t.Errorf("CSV = %s", buf.String())
+	}
+
+	receipt, err := NewReceipt(&donations[3], donors.Get("Meera Iyer"), org, "INR")
+	if err != nil || receipt.Eligible80G || receipt.Number != "R-DTN-4" {
+		t.Errorf("NewReceipt() = %+v, %v", receipt, err)
+	}
+	if _, err := NewReceipt(&donations[7], donors.Get("Meera Iyer"), org, "INR"); !errors.Is(err, ErrInvalidDonation) {
+		t.Errorf("NewReceipt() unsubmitted = %v", err)
+	}
+}
+
+func TestGiftAidClaim(t *testing.T) {
+	donors := Donors{
+		{Name: "Jane Smith", Title: "Mrs", FirstName: "Jane", LastName: "Smith", HouseNumber: "14", Postcode: "ox1 2ab",
+			GiftAid: &Declaration{Made: date(2024, 6, 1), CoversPast: true}},
+		{Name: "Tom Brown", FirstName: "Tom", LastName: "Brown", HouseNumber: "3", Postcode: "LS1 4AP",
+			GiftAid: &Declaration{Made: date(2024, 5, 1), Cancelled: date(2024, 9, 1)}},
+		{Name: "Ann Lee", FirstName: "Ann", LastName: "Lee",
+			GiftAid: &Declaration{Made: date(2024, 1, 1)}},
+		{Name: "Sam Green", FirstName: "Sam", LastName: "Green", HouseNumber: "7", Postcode: "M1 1AA"}, // No declaration
+	}
+	donations := []Donation{
+		donation("DTN-1", "Jane Smith", 100, Electronic, date(2024, 4, 10)), // Before the declaration, covered by it
+		donation("DTN-2", "Tom Brown", 40, Cash, date(2024, 6, 15)),
+		donation("DTN-3", "Tom Brown", 60, Cash, date(2024, 10, 1)), // After cancellation
+		donation("DTN-4", "Ann Lee", 20, Cash, date(2024, 6, 1)),
+		donation("DTN-5", "Sam Green", 500, Electronic, date(2024, 6, 1)),
+		donation("DTN-6", "Jane Smith", 300, Kind, date(2024, 7, 1)),
+	}
+	for i := range donations {
+		donations[i].ReceiptNo = "R-" + donations[i].Name
+	}
+	donations[1].SponsoredEvent = true
+
+	claim, err := NewGiftAidClaim(donations, donors, "Seva", daterange.Inclusive(date(2024, 4, 6), date(2025, 4, 5)))
+	if !errors.Is(err, ErrIncompleteDonor) || !strings.Contains(err.Error(), "Ann Lee") {
+		t.Errorf("NewGiftAidClaim() error = %v", err)
+	}
+	if len(claim.Rows) != 2 || claim.Total != 140 || claim.TaxReclaimed != 35 {
+		t.Fatalf("claim = %+v", claim)
+	}
+
+	var buf bytes.Buffer
+	if err := reportio.WriteCSV(&buf, GiftAidReport(claim)); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"Mrs,Jane,Smith,14,OX1 2AB,,,10/04/24,100.00", ",Tom,Brown,3,LS1 4AP,,Yes,15/06/24,40.00"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("CSV lacks %q: %s", want, buf.String())
+		}
+	}
+}
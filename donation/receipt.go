@@ -0,0 +1,61 @@
+package donation
+
+import (
+	"fmt"
+	"time"
+)
+
+// CashLimit80G is the largest cash donation section 80G allows a
+// deduction for.
+const CashLimit80G = 2000
+
+// Organization is the nonprofit as its receipts and returns name it.
+type Organization struct {
+	Company          string
+	Name             string
+	Address          []string
+	PAN              string    // India
+	Registration80G  string    // India: unique registration number under section 80G
+	RegistrationDate time.Time // India: date the registration number was issued
+	CharityReference string    // UK: HMRC charity reference
+}
+
+// Receipt is the acknowledgement of a donation given to its donor.
+//
+// Maps to: the Donation print format and Tax Exemption 80G Certificate
+type Receipt struct {
+	Number       string
+	Donation     string
+	Date         time.Time
+	Organization Organization
+	Donor        Donor
+	Amount       float64
+	Currency     string
+	Mode         Mode
+	Purpose      Purpose
+	ReferenceNo  string
+	Fund         string
+	Eligible80G  bool // Whether the receipt may be printed as an 80G certificate
+}
+
+// Eligible80G reports whether a donation qualifies for a deduction under
+// section 80G: gifts in kind do not, nor do cash donations above
+// CashLimit80G.
+func Eligible80G(d *Donation) bool {
+	return d.Mode != Kind && !(d.Mode == Cash && d.Amount > CashLimit80G)
+}
+
+// NewReceipt returns the receipt of a submitted donation.
+func NewReceipt(d *Donation, donor *Donor, org Organization, currency string) (*Receipt, error) {
+	if d.ReceiptNo == "" {
+		return nil, fmt.Errorf("%w: %s has not been submitted", ErrInvalidDonation, d.Name)
+	}
+	if donor == nil || donor.Name != d.Donor {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownDonor, d.Donor)
+	}
+	return &Receipt{
+		Number: d.ReceiptNo, Donation: d.Name, Date: d.Date, Organization: org, Donor: *donor,
+		Amount: d.Amount, Currency: currency, Mode: d.Mode, Purpose: d.Purpose, ReferenceNo: d.ReferenceNo,
+		Fund: d.Fund, Eligible80G: org.Registration80G != "" && Eligible80G(d),
+	}, nil
+}
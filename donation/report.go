@@ -0,0 +1,232 @@
+package donation
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/daterange"
+	"github.com/senguttuvang/erpnext-go/ledger"
+	"github.com/senguttuvang/erpnext-go/reportio"
+)
+
+// IDType is the identification of an Indian donor.
+type IDType string
+
+const (
+	PAN            IDType = "PAN"
+	Aadhaar        IDType = "Aadhaar Number"
+	TaxID          IDType = "Tax Identification Number"
+	Passport       IDType = "Passport number"
+	VoterID        IDType = "Elector's photo identity number"
+	DrivingLicence IDType = "Driving License number"
+	RationCard     IDType = "Ration card number"
+)
+
+// idCodes are the codes Form 10BD reports identification by.
+var idCodes = map[IDType]string{
+	PAN: "1", Aadhaar: "2", TaxID: "3", Passport: "4", VoterID: "5", DrivingLicence: "6", RationCard: "7",
+}
+
+// Form10BDRow is one line of the statement of donations: a donor's
+// donations of one purpose received in one mode over the financial year.
+type Form10BDRow struct {
+	IDCode      string
+	IDNumber    string
+	SectionCode string
+	URN         string // The organisation's 80G registration
+	URNDate     time.Time
+	DonorName   string
+	Address     string
+	Purpose     Purpose
+	Mode        Mode
+	Amount      float64
+}
+
+// Form10BD returns the statement of the donations a company received in
+// the financial year period that qualify under section 80G, by donor,
+// purpose and mode, in the order of each donor's first donation. Donations
+// not yet submitted are left out. Donors without identification are
+// reported as ErrIncompleteDonor and left out; the rows of the others are
+// still returned.
+//
+// Maps to: Form 10BD (rule 18AB), as the 80G certificates of
+// erpnext/regional/india report them
+func Form10BD(donations []Donation, donors Donors, org Organization, period daterange.Range) ([]Form10BDRow, error) {
+	var rows []Form10BDRow
+	var errs []error
+	skipped := map[string]bool{}
+	for i := range donations {
+		d := &donations[i]
+		if d.ReceiptNo == "" || d.Company != org.Company || !period.Contains(d.Date) || !Eligible80G(d) || skipped[d.Donor] {
+			continue
+		}
+		donor := donors.Get(d.Donor)
+		var err error
+		if donor == nil {
+			err = fmt.Errorf("%w: %s", ErrUnknownDonor, d.Donor)
+		} else if idCodes[donor.IDType] == "" || donor.IDNumber == "" {
+			err = fmt.Errorf("%w: %s has no identification", ErrIncompleteDonor, d.Donor)
+		}
+		if err != nil {
+			errs = append(errs, err)
+			skipped[d.Donor] = true
+			continue
+		}
+		j := 0
+		for j < len(rows) && (rows[j].IDNumber != donor.IDNumber || rows[j].Purpose != d.Purpose || rows[j].Mode != d.Mode) {
+			j++
+		}
+		if j == len(rows) {
+			rows = append(rows, Form10BDRow{
+				IDCode: idCodes[donor.IDType], IDNumber: donor.IDNumber, SectionCode: "Section 80G",
+				URN: org.Registration80G, URNDate: org.RegistrationDate, DonorName: donor.DonorName,
+				Address: strings.Join(donor.Address, ", "), Purpose: d.Purpose, Mode: d.Mode,
+			})
+		}
+		rows[j].Amount = ledger.Flt(rows[j].Amount+d.Amount, 2)
+	}
+	return rows, errors.Join(errs...)
+}
+
+// Form10BDReport presents Form 10BD rows as a downloadable report.
+func Form10BDReport(rows []Form10BDRow) reportio.Report {
+	return form10BD(rows)
+}
+
+type form10BD []Form10BDRow
+
+func (form10BD) Title() string { return "Form 10BD" }
+
+func (form10BD) Columns() []reportio.Column {
+	return []reportio.Column{
+		{Fieldname: "id_code", Label: "ID Code", Fieldtype: reportio.Data},
+		{Fieldname: "unique_identification_number", Label: "Unique Identification Number", Fieldtype: reportio.Data},
+		{Fieldname: "section_code", Label: "Section Code", Fieldtype: reportio.Data},
+		{Fieldname: "unique_registration_number", Label: "Unique Registration Number (URN)", Fieldtype: reportio.Data},
+		{Fieldname: "date_of_issuance_of_urn", Label: "Date of Issuance of URN", Fieldtype: reportio.Date},
+		{Fieldname: "name_of_donor", Label: "Name of Donor", Fieldtype: reportio.Data},
+		{Fieldname: "address_of_donor", Label: "Address of Donor", Fieldtype: reportio.Data},
+		{Fieldname: "donation_type", Label: "Donation Type", Fieldtype: reportio.Data},
+		{Fieldname: "mode_of_receipt", Label: "Mode of Receipt", Fieldtype: reportio.Data},
+		{Fieldname: "amount_of_donation", Label: "Amount of Donation (Indian Rupees)", Fieldtype: reportio.Currency},
+	}
+}
+
+func (r form10BD) Rows(fn func([]any) error) error {
+	for _, row := range r {
+		if err := fn([]any{
+			row.IDCode, row.IDNumber, row.SectionCode, row.URN, row.URNDate, row.DonorName, row.Address,
+			string(row.Purpose), string(row.Mode), row.Amount,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GiftAidRate is the tax a charity reclaims on a Gift Aid donation: basic
+// rate tax of 20% on the gross gift, a quarter of the donation.
+const GiftAidRate = 0.25
+
+// GiftAidRow is one donation of a Gift Aid claim schedule.
+type GiftAidRow struct {
+	Title       string
+	FirstName   string
+	LastName    string
+	HouseNumber string
+	Postcode    string
+	Sponsored   bool
+	Date        time.Time
+	Amount      float64
+}
+
+// GiftAidClaim is the schedule of donations a charity claims Gift Aid on.
+type GiftAidClaim struct {
+	Rows         []GiftAidRow
+	Total        float64 // Donations claimed on
+	TaxReclaimed float64
+}
+
+// NewGiftAidClaim returns the claim of a company's submitted donations in
+// period from donors whose declaration covers them, one row per donation
+// in the order given. Gifts in kind do not qualify. Donors without the
+// name, house and postcode HMRC asks for are reported as
+// ErrIncompleteDonor and left out; the claim of the others is still
+// returned.
+//
+// Maps to: the Gift Aid schedule spreadsheet of HMRC Charities Online
+func NewGiftAidClaim(donations []Donation, donors Donors, company string, period daterange.Range) (*GiftAidClaim, error) {
+	claim := &GiftAidClaim{}
+	var errs []error
+	skipped := map[string]bool{}
+	for i := range donations {
+		d := &donations[i]
+		if d.ReceiptNo == "" || d.Company != company || !period.Contains(d.Date) || d.Mode == Kind || skipped[d.Donor] {
+			continue
+		}
+		donor := donors.Get(d.Donor)
+		if donor == nil {
+			errs = append(errs, fmt.Errorf("%w: %s", ErrUnknownDonor, d.Donor))
+			skipped[d.Donor] = true
+			continue
+		}
+		if !donor.GiftAid.Covers(d.Date) {
+			continue
+		}
+		if donor.FirstName == "" || donor.LastName == "" || donor.HouseNumber == "" || donor.Postcode == "" {
+			errs = append(errs, fmt.Errorf("%w: %s needs a name, house and postcode for Gift Aid", ErrIncompleteDonor, d.Donor))
+			skipped[d.Donor] = true
+			continue
+		}
+		claim.Rows = append(claim.Rows, GiftAidRow{
+			Title: donor.Title, FirstName: donor.FirstName, LastName: donor.LastName, HouseNumber: donor.HouseNumber,
+			Postcode: strings.ToUpper(donor.Postcode), Sponsored: d.SponsoredEvent, Date: ledger.CivilDate(d.Date), Amount: d.Amount,
+		})
+		claim.Total += d.Amount
+	}
+	claim.Total = ledger.Flt(claim.Total, 2)
+	claim.TaxReclaimed = ledger.Flt(claim.Total*GiftAidRate, 2)
+	return claim, errors.Join(errs...)
+}
+
+// GiftAidReport presents a claim as its schedule, with dates as HMRC
+// expects them (DD/MM/YY).
+func GiftAidReport(claim *GiftAidClaim) reportio.Report {
+	return giftAid{claim: claim}
+}
+
+type giftAid struct{ claim *GiftAidClaim }
+
+func (giftAid) Title() string { return "Gift Aid Schedule" }
+
+func (giftAid) Columns() []reportio.Column {
+	return []reportio.Column{
+		{Fieldname: "title", Label: "Title", Fieldtype: reportio.Data},
+		{Fieldname: "first_name", Label: "First name or initial", Fieldtype: reportio.Data},
+		{Fieldname: "last_name", Label: "Last name", Fieldtype: reportio.Data},
+		{Fieldname: "house", Label: "House name or number", Fieldtype: reportio.Data},
+		{Fieldname: "postcode", Label: "Postcode", Fieldtype: reportio.Data},
+		{Fieldname: "aggregated", Label: "Aggregated donations", Fieldtype: reportio.Data},
+		{Fieldname: "sponsored", Label: "Sponsored event", Fieldtype: reportio.Data},
+		{Fieldname: "date", Label: "Donation date", Fieldtype: reportio.Data},
+		{Fieldname: "amount", Label: "Amount", Fieldtype: reportio.Currency},
+	}
+}
+
+func (r giftAid) Rows(fn func([]any) error) error {
+	for _, row := range r.claim.Rows {
+		sponsored := ""
+		if row.Sponsored {
+			sponsored = "Yes"
+		}
+		if err := fn([]any{
+			row.Title, row.FirstName, row.LastName, row.HouseNumber, row.Postcode, "", sponsored,
+			row.Date.Format("02/01/06"), row.Amount,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+// Package elimination tags the GL entries of inter-company transactions
+// and nets them across the companies of a group, so that consolidation
+// can eliminate them without a mapping of accounts kept by hand.
+//
+// An entry is tagged with the counterparty company in the custom field
+// Fieldname; add it to ledger.Engine.MergeFields so that entries with
+// different counterparties are not merged. Tagger tags a GL map whose
+// party is an internal customer or supplier before it is posted.
+// Eliminate sums the tagged balances of each pair of companies: when both
+// sides booked the same transactions, the receivable of one cancels the
+// payable of the other and the income of one the expense of the other,
+// and what is left is a difference to investigate, such as goods in
+// transit. Adjustments are the entries consolidation adds to remove the
+// tagged balances.
+//
+// Maps to: the inter-company references of Sales Invoice, Purchase
+// Invoice and Journal Entry (inter_company_invoice_reference,
+// inter_company_journal_entry_reference) and the Customer and Supplier
+// represents_company field, carried to the GL for consolidation
+package elimination
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/senguttuvang/erpnext-go/ledger"
+)
+
+// Fieldname is the custom field of GL entries holding the counterparty
+// company of an inter-company transaction.
+const Fieldname = "inter_company"
+
+// Elimination errors
+var (
+	ErrSameCompany   = errors.New("counterparty is the company itself")
+	ErrNotConfigured = errors.New("elimination is not configured")
+)
+
+// Of returns the counterparty company of a GL entry, "" when it is not
+// inter-company.
+func Of(e ledger.GLEntry) string {
+	return e.Custom.String(Fieldname)
+}
+
+// Tag marks a GL entry as inter-company with a counterparty.
+func Tag(e *ledger.GLEntry, counterparty string) {
+	e.Custom.Set(Fieldname, counterparty)
+}
+
+// InternalParties resolves the company a party represents.
+//
+// Maps to: Customer.is_internal_customer and Supplier.is_internal_supplier
+// with their represents_company
+type InternalParties interface {
+	// RepresentsCompany returns the company of the group a party is, or
+	// "" when the party is external.
+	RepresentsCompany(partyType, party string) (string, error)
+}
+
+// Tagger tags the entries of inter-company vouchers.
+type Tagger struct {
+	Parties  InternalParties
+	Accounts ledger.AccountLookup
+}
+
+// Tag tags a GL map booked with an internal party. The counterparty is
+// the company the first internal party of the map represents; the entries
+// tagged are those with a party and those on income and expense accounts,
+// the balances the counterparty books the other side of. Cash, bank and
+// tax entries are not. A map without an internal party is returned
+// unchanged; entries already tagged are left as they are.
+func (t *Tagger) Tag(glMap []ledger.GLEntry) ([]ledger.GLEntry, error) {
+	if t.Parties == nil || t.Accounts == nil {
+		return nil, ErrNotConfigured
+	}
+	var counterparty string
+	for _, e := range glMap {
+		if e.Party == "" {
+			continue
+		}
+		company, err := t.Parties.RepresentsCompany(e.PartyType, e.Party)
+		if err != nil {
+			return nil, err
+		}
+		if company == e.Company {
+			return nil, fmt.Errorf("%w: %s %s of %s", ErrSameCompany, e.PartyType, e.Party, e.Company)
+		}
+		if company != "" {
+			counterparty = company
+			break
+		}
+	}
+	if counterparty == "" {
+		return glMap, nil
+	}
+
+	out := make([]ledger.GLEntry, len(glMap))
+	for i, e := range glMap {
+		out[i] = e
+		if Of(e) != "" {
+			continue
+		}
+		acc, err := t.Accounts.GetAccount(e.Account)
+		if err != nil {
+			return nil, fmt.Errorf("account %s: %w", e.Account, err)
+		}
+		if e.Party != "" || profitAndLoss(acc.RootType) {
+			out[i].Custom = e.Custom.Clone()
+			Tag(&out[i], counterparty)
+		}
+	}
+	return out, nil
+}
+
+func profitAndLoss(rootType string) bool {
+	return rootType == "Income" || rootType == "Expense"
+}
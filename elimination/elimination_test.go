@@ -0,0 +1,153 @@
+package elimination
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/daterange"
+	"github.com/senguttuvang/erpnext-go/ledger"
+	"github.com/senguttuvang/erpnext-go/ledger/memstore"
+	"github.com/senguttuvang/erpnext-go/reportio"
+)
+
+func date(m time.Month, d int) time.Time {
+	return time.Date(2024, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// internal maps parties to the companies they represent.
+type internal map[string]string
+
+func (p internal) RepresentsCompany(partyType, party string) (string, error) {
+	return p[party], nil
+}
+
+func accounts() *memstore.Accounts {
+	return memstore.NewAccounts(
+		ledger.Account{Name: "Debtors - IN", RootType: "Asset"},
+		ledger.Account{Name: "Sales - IN", RootType: "Income"},
+		ledger.Account{Name: "Output Tax - IN", RootType: "Liability"},
+		ledger.Account{Name: "Bank - IN", RootType: "Asset"},
+		ledger.Account{Name: "Creditors - UK", RootType: "Liability"},
+		ledger.Account{Name: "Cost of Services - UK", RootType: "Expense"},
+		ledger.Account{Name: "Bank - UK", RootType: "Asset"},
+	)
+}
+
+func entry(company, account, party string, debit, credit float64, voucherNo string, on time.Time) ledger.GLEntry {
+	e := ledger.GLEntry{
+		PostingDate: on, Company: company, Account: account, VoucherType: "Journal Entry", VoucherNo: voucherNo,
+		Debit: debit, Credit: credit, DebitInAccountCurrency: debit, CreditInAccountCurrency: credit,
+	}
+	if party != "" {
+		e.PartyType, e.Party = "Customer", party
+		if company == "Acme UK" {
+			e.PartyType = "Supplier"
+		}
+	}
+	return e
+}
+
+func TestTagAndEliminate(t *testing.T) {
+	tagger := &Tagger{Parties: internal{"Acme UK Ltd": "Acme UK", "Acme India Pvt": "Acme India"}, Accounts: accounts()}
+	glStore := memstore.NewGLStore()
+	engine := &ledger.Engine{GLStore: glStore, MergeFields: []string{Fieldname}}
+	post := func(glMap ...ledger.GLEntry) {
+		t.Helper()
+		tagged, err := tagger.Tag(glMap)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := engine.MakeGLEntries(tagged, ledger.DefaultPostingOptions()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// India bills the UK company for services, with tax; the UK books it
+	// net of the tax it reclaims elsewhere
+	post(
+		entry("Acme India", "Debtors - IN", "Acme UK Ltd", 1180, 0, "SINV-1", date(3, 10)),
+		entry("Acme India", "Sales - IN", "", 0, 1000, "SINV-1", date(3, 10)),
+		entry("Acme India", "Output Tax - IN", "", 0, 180, "SINV-1", date(3, 10)),
+	)
+	post(
+		entry("Acme UK", "Cost of Services - UK", "", 1000, 0, "PINV-1", date(3, 12)),
+		entry("Acme UK", "Creditors - UK", "Acme India Pvt", 0, 1000, "PINV-1", date(3, 12)),
+		entry("Acme UK", "Bank - UK", "", 0, 0, "PINV-1", date(3, 12)),
+	)
+	// An external sale is not tagged
+	post(
+		entry("Acme India", "Debtors - IN", "Globex", 500, 0, "SINV-2", date(3, 15)),
+		entry("Acme India", "Sales - IN", "", 0, 500, "SINV-2", date(3, 15)),
+	)
+	for _, e := range glStore.All() {
+		want := map[string]string{"Debtors - IN": "Acme UK", "Sales - IN": "Acme UK", "Creditors - UK": "Acme India", "Cost of Services - UK": "Acme India"}[e.Account]
+		if e.VoucherNo == "SINV-2" {
+			want = ""
+		}
+		if Of(e) != want {
+			t.Errorf("%s %s tagged %q, want %q", e.VoucherNo, e.Account, Of(e), want)
+		}
+	}
+
+	pairs, err := Eliminate(glStore.All(), accounts(), daterange.Inclusive(date(3, 1), date(3, 31)), ledger.ReportFlags{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pairs) != 1 || pairs[0].Companies != [2]string{"Acme India", "Acme UK"} || len(pairs[0].Balances) != 4 || pairs[0].Difference != 180 {
+		t.Fatalf("Eliminate() = %+v", pairs)
+	}
+	if b := pairs[0].Balances[0]; b.Company != "Acme India" || b.Account != "Debtors - IN" || b.Balance != 1180 {
+		t.Errorf("first balance = %+v", b)
+	}
+	adjustments := Adjustments(pairs)
+	if len(adjustments) != 4 || adjustments[0].Credit != 1180 || adjustments[1] != (Adjustment{Company: "Acme India", Account: "Sales - IN", Debit: 1000}) {
+		t.Errorf("Adjustments() = %+v", adjustments)
+	}
+
+	// Income of an earlier period is not eliminated again
+	pairs, _ = Eliminate(glStore.All(), accounts(), daterange.Inclusive(date(4, 1), date(4, 30)), ledger.ReportFlags{})
+	if len(pairs[0].Balances) != 2 || pairs[0].Difference != 1180-1000 {
+		t.Errorf("April = %+v", pairs)
+	}
+
+	var buf bytes.Buffer
+	if err := reportio.WriteCSV(&buf, Report(pairs)); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "Acme India,Acme UK,Difference,,180.00,0.00") {
+		t.Errorf("CSV = %s", buf.String())
+	}
+
+	// Nor is an opening balance booked in the period, unless asked for
+	opening := entry("Acme UK", "Creditors - UK", "Acme India Pvt", 0, 180, "JV-1", date(4, 1))
+	opening.IsOpening = ledger.IsOpeningYes
+	contra := entry("Acme UK", "Bank - UK", "", 180, 0, "JV-1", date(4, 1))
+	contra.IsOpening = ledger.IsOpeningYes
+	post(opening, contra)
+	april := daterange.Inclusive(date(4, 1), date(4, 30))
+	pairs, _ = Eliminate(glStore.All(), accounts(), april, ledger.ReportFlags{})
+	if pairs[0].Difference != 180 {
+		t.Errorf("April without opening entries = %+v", pairs)
+	}
+	pairs, _ = Eliminate(glStore.All(), accounts(), april, ledger.ReportFlags{IncludeOpening: true})
+	if pairs[0].Difference != 0 {
+		t.Errorf("April with opening entries = %+v", pairs)
+	}
+	pairs, _ = Eliminate(glStore.All(), accounts(), daterange.Inclusive(date(5, 1), date(5, 31)), ledger.ReportFlags{})
+	if pairs[0].Difference != 0 {
+		t.Errorf("May = %+v", pairs)
+	}
+}
+
+func TestTagErrors(t *testing.T) {
+	tagger := &Tagger{Parties: internal{"Acme UK Ltd": "Acme UK"}, Accounts: accounts()}
+	if _, err := tagger.Tag([]ledger.GLEntry{entry("Acme UK", "Creditors - UK", "Acme UK Ltd", 0, 10, "JV-1", date(1, 1))}); !errors.Is(err, ErrSameCompany) {
+		t.Errorf("Tag() own company = %v", err)
+	}
+	if _, err := (&Tagger{}).Tag(nil); !errors.Is(err, ErrNotConfigured) {
+		t.Errorf("Tag() unconfigured = %v", err)
+	}
+}
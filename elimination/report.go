@@ -0,0 +1,181 @@
+package elimination
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+
+	"github.com/senguttuvang/erpnext-go/daterange"
+	"github.com/senguttuvang/erpnext-go/ledger"
+	"github.com/senguttuvang/erpnext-go/reportio"
+)
+
+// Balance is what a company's tagged entries with one counterparty add up
+// to on an account.
+type Balance struct {
+	Company      string
+	Counterparty string
+	Account      string
+	RootType     string
+	Balance      float64 // Debit less credit
+}
+
+// Pair is the inter-company balances between two companies.
+type Pair struct {
+	Companies  [2]string // In name order
+	Balances   []Balance
+	Difference float64 // Zero when both sides booked the same; debit less credit
+}
+
+// Eliminate nets the tagged balances of each pair of companies over
+// period: balance sheet accounts up to its end, income and expense within
+// it. Amounts are in the reporting currency for entries that carry a
+// reporting exchange rate and in company currency otherwise, so the
+// companies of a group should share one of the two. Pairs are ordered by
+// company names, balances by company and account. Opening and period
+// closing entries of the period count only as flags say, so they are not
+// matched as inter-company activity by default; cancelled entries count,
+// against their reversals.
+//
+// Maps to: the intercompany elimination a consolidated financial
+// statement needs, from the tags rather than an account mapping
+func Eliminate(gl []ledger.GLEntry, accounts ledger.AccountLookup, period daterange.Range, flags ledger.ReportFlags) ([]Pair, error) {
+	type key struct{ company, counterparty, account string }
+	sums := map[key]*Balance{}
+	var order []key
+	rootTypes := map[string]string{}
+	for _, e := range gl {
+		counterparty := Of(e)
+		if counterparty == "" || period.Compare(e.PostingDate) > 0 ||
+			period.Contains(e.PostingDate) && !flags.Counts(&e) {
+			continue
+		}
+		rootType, ok := rootTypes[e.Account]
+		if !ok {
+			acc, err := accounts.GetAccount(e.Account)
+			if err != nil {
+				return nil, err
+			}
+			rootType, rootTypes[e.Account] = acc.RootType, acc.RootType
+		}
+		if profitAndLoss(rootType) && period.Compare(e.PostingDate) < 0 {
+			continue
+		}
+		k := key{e.Company, counterparty, e.Account}
+		b, ok := sums[k]
+		if !ok {
+			b = &Balance{Company: e.Company, Counterparty: counterparty, Account: e.Account, RootType: rootType}
+			sums[k] = b
+			order = append(order, k)
+		}
+		b.Balance += amount(e)
+	}
+
+	pairs := map[[2]string]*Pair{}
+	var pairOrder [][2]string
+	for _, k := range order {
+		b := sums[k]
+		b.Balance = ledger.Flt(b.Balance, 2)
+		companies := [2]string{b.Company, b.Counterparty}
+		if companies[0] > companies[1] {
+			companies[0], companies[1] = companies[1], companies[0]
+		}
+		p, ok := pairs[companies]
+		if !ok {
+			p = &Pair{Companies: companies}
+			pairs[companies] = p
+			pairOrder = append(pairOrder, companies)
+		}
+		p.Balances = append(p.Balances, *b)
+		p.Difference += b.Balance
+	}
+	slices.SortFunc(pairOrder, func(a, b [2]string) int {
+		return cmp.Or(strings.Compare(a[0], b[0]), strings.Compare(a[1], b[1]))
+	})
+
+	out := make([]Pair, 0, len(pairOrder))
+	for _, companies := range pairOrder {
+		p := pairs[companies]
+		p.Difference = ledger.Flt(p.Difference, 2)
+		slices.SortFunc(p.Balances, func(a, b Balance) int {
+			return cmp.Or(strings.Compare(a.Company, b.Company), strings.Compare(a.Account, b.Account))
+		})
+		out = append(out, *p)
+	}
+	return out, nil
+}
+
+// amount is the debit less credit of an entry in the reporting currency,
+// or in company currency when the entry has no reporting rate.
+func amount(e ledger.GLEntry) float64 {
+	if e.ReportingCurrencyExchangeRate != 0 {
+		return e.DebitInReportingCurrency - e.CreditInReportingCurrency
+	}
+	return e.Debit - e.Credit
+}
+
+// Adjustment is an amount consolidation adds to a company's account to
+// eliminate it.
+type Adjustment struct {
+	Company string
+	Account string
+	Debit   float64
+	Credit  float64
+}
+
+// Adjustments returns the entries that remove the tagged balances of the
+// pairs from the sum of the companies' trial balances. The difference of
+// a pair that does not net to zero is left in the consolidated accounts,
+// where Report shows it.
+func Adjustments(pairs []Pair) []Adjustment {
+	var out []Adjustment
+	for _, p := range pairs {
+		for _, b := range p.Balances {
+			a := Adjustment{Company: b.Company, Account: b.Account}
+			if b.Balance > 0 {
+				a.Credit = b.Balance
+			} else if b.Balance < 0 {
+				a.Debit = -b.Balance
+			} else {
+				continue
+			}
+			out = append(out, a)
+		}
+	}
+	return out
+}
+
+// Report presents the pairs as a downloadable report: each tagged balance,
+// then the difference of its pair.
+func Report(pairs []Pair) reportio.Report {
+	return report(pairs)
+}
+
+type report []Pair
+
+func (report) Title() string { return "Inter-Company Elimination" }
+
+func (report) Columns() []reportio.Column {
+	return []reportio.Column{
+		{Fieldname: "company", Label: "Company", Fieldtype: reportio.Link, Options: "Company"},
+		{Fieldname: "counterparty", Label: "Counterparty", Fieldtype: reportio.Link, Options: "Company"},
+		{Fieldname: "account", Label: "Account", Fieldtype: reportio.Link, Options: "Account"},
+		{Fieldname: "root_type", Label: "Root Type", Fieldtype: reportio.Data},
+		{Fieldname: "balance", Label: "Balance", Fieldtype: reportio.Currency},
+		{Fieldname: "elimination", Label: "Elimination", Fieldtype: reportio.Currency},
+	}
+}
+
+func (r report) Rows(fn func([]any) error) error {
+	for _, p := range r {
+		for _, b := range p.Balances {
+			if err := fn([]any{b.Company, b.Counterparty, b.Account, b.RootType, b.Balance, -b.Balance}); err != nil {
+				return err
+			}
+		}
+		if err := fn([]any{p.Companies[0], p.Companies[1], "Difference", "", p.Difference, 0.0}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,261 @@
+package erpimport
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/senguttuvang/erpnext-go/ledger"
+	"github.com/senguttuvang/erpnext-go/numeric"
+)
+
+// Invariant violations found in imported history
+var (
+	ErrUnbalancedVoucher = errors.New("voucher debits and credits do not balance")
+	ErrInvalidEntry      = errors.New("entry is missing mandatory fields")
+	ErrNegativeAmount    = errors.New("entry has a negative amount")
+	ErrPaymentLedger     = errors.New("payment ledger does not match GL")
+	ErrProblemsFound     = errors.New("import found invalid vouchers")
+)
+
+// DefaultBatchSize is the number of entries saved per store call.
+const DefaultBatchSize = 1000
+
+// Problem is a voucher that failed validation and was not loaded.
+type Problem struct {
+	VoucherType string
+	VoucherNo   string
+	Err         error
+}
+
+// Report summarises an import.
+type Report struct {
+	Vouchers             int
+	GLEntries            int
+	PaymentLedgerEntries int
+	Problems             []Problem
+}
+
+// Importer validates ledger history from a Source and loads it into the
+// stores. Vouchers that fail validation, or whose payment ledger entries
+// disagree with their GL, are skipped and reported together with their
+// payment ledger entries; with Strict set, any problem fails the import
+// after validation and nothing is loaded.
+type Importer struct {
+	Source       Source
+	GLStore      ledger.GLEntryStore
+	PaymentStore ledger.PaymentLedgerStore
+	Accounts     ledger.AccountLookup // Optional; resolves Against names outside the voucher
+	BatchSize    int
+
+	Strict bool
+	DryRun bool // Validate only
+}
+
+// Run performs the import.
+func (im *Importer) Run() (*Report, error) {
+	if im.Strict && !im.DryRun {
+		// Validate everything first so a strict import is all or nothing
+		dry := *im
+		dry.DryRun = true
+		report, err := dry.Run()
+		if err != nil {
+			return report, err
+		}
+		if len(report.Problems) > 0 {
+			return report, fmt.Errorf("%w: %d vouchers", ErrProblemsFound, len(report.Problems))
+		}
+	}
+
+	report := &Report{}
+	batchSize := im.BatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultBatchSize
+	}
+
+	// Party movements the live payment ledger records for each voucher, read
+	// first so a voucher that disagrees is rejected before any of it is saved
+	pleTotals := make(map[ledger.VoucherRef]map[partyKey]float64)
+	err := im.Source.PaymentLedgerEntries(func(e ledger.PaymentLedgerEntry) error {
+		if e.Delinked {
+			return nil
+		}
+		ref := ledger.VoucherRef{VoucherType: e.VoucherType, VoucherNo: e.VoucherNo}
+		if pleTotals[ref] == nil {
+			pleTotals[ref] = make(map[partyKey]float64)
+		}
+		pleTotals[ref][partyKeyOf(ref, e.Account, e.PartyType, e.Party)] += e.Amount
+		return nil
+	})
+	if err != nil {
+		return report, err
+	}
+	rejected := make(map[ledger.VoucherRef]bool)
+	reject := func(ref ledger.VoucherRef, err error) {
+		report.Problems = append(report.Problems, Problem{VoucherType: ref.VoucherType, VoucherNo: ref.VoucherNo, Err: err})
+		rejected[ref] = true
+	}
+
+	var batch []ledger.GLEntry
+	flush := func() error {
+		if len(batch) == 0 || im.DryRun {
+			batch = batch[:0]
+			return nil
+		}
+		err := im.GLStore.SaveBatch(batch)
+		batch = batch[:0]
+		return err
+	}
+
+	var voucher []ledger.GLEntry
+	closeVoucher := func() error {
+		if len(voucher) == 0 {
+			return nil
+		}
+		ref := ledger.VoucherRef{VoucherType: voucher[0].VoucherType, VoucherNo: voucher[0].VoucherNo}
+		ple := pleTotals[ref]
+		delete(pleTotals, ref)
+		report.Vouchers++
+		err := validateVoucher(voucher)
+		if err == nil {
+			partyTotals := make(map[partyKey]float64)
+			for _, e := range voucher {
+				if e.PartyType != "" && !e.IsCancelled {
+					partyTotals[partyKeyOf(ref, e.Account, e.PartyType, e.Party)] += e.Debit - e.Credit
+				}
+			}
+			err = checkPaymentLedger(ple, partyTotals)
+		}
+		if err != nil {
+			reject(ref, err)
+			voucher = voucher[:0]
+			return nil
+		}
+		ledger.ResolveAgainst(voucher, im.Accounts)
+		batch = append(batch, voucher...)
+		report.GLEntries += len(voucher)
+		voucher = voucher[:0]
+		if len(batch) >= batchSize {
+			return flush()
+		}
+		return nil
+	}
+
+	err = im.Source.GLEntries(func(e ledger.GLEntry) error {
+		if len(voucher) > 0 && (voucher[0].VoucherType != e.VoucherType || voucher[0].VoucherNo != e.VoucherNo) {
+			if err := closeVoucher(); err != nil {
+				return err
+			}
+		}
+		voucher = append(voucher, e)
+		return nil
+	})
+	if err == nil {
+		err = closeVoucher()
+	}
+	if err == nil {
+		err = flush()
+	}
+	if err != nil {
+		return report, err
+	}
+
+	// What is left in the payment ledger has no GL entries at all
+	orphans := slices.SortedFunc(maps.Keys(pleTotals), func(a, b ledger.VoucherRef) int {
+		return cmp.Or(strings.Compare(a.VoucherType, b.VoucherType), strings.Compare(a.VoucherNo, b.VoucherNo))
+	})
+	for _, ref := range orphans {
+		reject(ref, checkPaymentLedger(pleTotals[ref], nil))
+	}
+
+	// Payment ledger: load the entries of accepted vouchers
+	var pleBatch []ledger.PaymentLedgerEntry
+	flushPLE := func() error {
+		if len(pleBatch) == 0 || im.DryRun {
+			pleBatch = pleBatch[:0]
+			return nil
+		}
+		err := im.PaymentStore.SaveBatch(pleBatch)
+		pleBatch = pleBatch[:0]
+		return err
+	}
+	err = im.Source.PaymentLedgerEntries(func(e ledger.PaymentLedgerEntry) error {
+		if rejected[ledger.VoucherRef{VoucherType: e.VoucherType, VoucherNo: e.VoucherNo}] {
+			return nil
+		}
+		pleBatch = append(pleBatch, e)
+		report.PaymentLedgerEntries++
+		if len(pleBatch) >= batchSize {
+			return flushPLE()
+		}
+		return nil
+	})
+	if err == nil {
+		err = flushPLE()
+	}
+	if err != nil {
+		return report, err
+	}
+
+	return report, nil
+}
+
+// checkPaymentLedger compares a voucher's live payment ledger totals with
+// its GL party movements. Party accounts the payment ledger does not record
+// are not checked, as history from before the payment ledger has none.
+func checkPaymentLedger(ple, gl map[partyKey]float64) error {
+	keys := slices.SortedFunc(maps.Keys(ple), func(a, b partyKey) int {
+		return cmp.Or(strings.Compare(a.account, b.account), strings.Compare(a.partyType, b.partyType), strings.Compare(a.party, b.party))
+	})
+	for _, key := range keys {
+		if amount := ple[key]; !almostZero(amount - gl[key]) {
+			return fmt.Errorf("%w: %s %s on %s: ledger %.2f, GL %.2f", ErrPaymentLedger, key.partyType, key.party, key.account, amount, gl[key])
+		}
+	}
+	return nil
+}
+
+// partyKey identifies a voucher's movement on one party account.
+type partyKey struct {
+	ref       ledger.VoucherRef
+	account   string
+	partyType string
+	party     string
+}
+
+func partyKeyOf(ref ledger.VoucherRef, account, partyType, party string) partyKey {
+	return partyKey{ref: ref, account: account, partyType: partyType, party: party}
+}
+
+// validateVoucher checks the entries of one voucher: mandatory fields,
+// non-negative amounts, and balance. Cancelled entries are checked for
+// balance on their own, as ERPNext flags both the original and its reversal.
+func validateVoucher(entries []ledger.GLEntry) error {
+	var live, cancelled ledger.GLMap
+	for _, e := range entries {
+		switch {
+		case e.Account == "" || e.Company == "" || e.PostingDate.IsZero():
+			return fmt.Errorf("%w: %s", ErrInvalidEntry, e.Name)
+		case e.Debit < 0 || e.Credit < 0:
+			return fmt.Errorf("%w: %s", ErrNegativeAmount, e.Name)
+		}
+		if e.IsCancelled {
+			cancelled = append(cancelled, e)
+		} else {
+			live = append(live, e)
+		}
+	}
+	for _, m := range []ledger.GLMap{live, cancelled} {
+		if !m.IsBalanced() {
+			return fmt.Errorf("%w: debit %.2f, credit %.2f", ErrUnbalancedVoucher, m.TotalDebit(), m.TotalCredit())
+		}
+	}
+	return nil
+}
+
+func almostZero(v float64) bool {
+	return numeric.IsZero(v, 2)
+}
@@ -0,0 +1,319 @@
+package erpimport
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/senguttuvang/erpnext-go/ledger"
+	"github.com/senguttuvang/erpnext-go/ledger/memstore"
+)
+
+// fakeDriver serves canned rows keyed by table name.
+type fakeDriver struct {
+	tables map[string][][]driver.Value
+	query  []string
+}
+
+func (d *fakeDriver) Open(string) (driver.Conn, error) { return &fakeConn{d}, nil }
+
+type fakeConn struct{ d *fakeDriver }
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	c.d.query = append(c.d.query, query)
+	for table, rows := range c.d.tables {
+		if strings.Contains(query, "`"+table+"`") {
+			return &fakeStmt{rows: rows}, nil
+		}
+	}
+	return nil, errors.New("unknown table")
+}
+func (c *fakeConn) Close() error              { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error) { return nil, errors.New("not supported") }
+
+type fakeStmt struct{ rows [][]driver.Value }
+
+func (s *fakeStmt) Close() error  { return nil }
+func (s *fakeStmt) NumInput() int { return -1 }
+func (s *fakeStmt) Exec([]driver.Value) (driver.Result, error) {
+	return nil, errors.New("not supported")
+}
+func (s *fakeStmt) Query([]driver.Value) (driver.Rows, error) {
+	return &fakeRows{rows: s.rows}, nil
+}
+
+type fakeRows struct {
+	rows [][]driver.Value
+	next int
+}
+
+func (r *fakeRows) Columns() []string {
+	if len(r.rows) == 0 {
+		return nil
+	}
+	return make([]string, len(r.rows[0]))
+}
+func (r *fakeRows) Close() error { return nil }
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.next >= len(r.rows) {
+		return io.EOF
+	}
+	copy(dest, r.rows[r.next])
+	r.next++
+	return nil
+}
+
+// glRow builds a tabGL Entry row in glColumns order.
+func glRow(name, voucherNo, account, partyType, party string, debit, credit float64, cancelled int) []driver.Value {
+	values := map[string]driver.Value{
+		"name": name, "posting_date": "2024-04-01", "transaction_date": "2024-04-01 00:00:00",
+		"account": account, "party_type": partyType, "party": party,
+		"voucher_type": "Sales Invoice", "voucher_no": voucherNo, "against_voucher_type": "Sales Invoice",
+		"debit": debit, "credit": credit, "debit_in_account_currency": debit, "credit_in_account_currency": credit,
+		"company": "Acme", "is_opening": "No", "is_advance": "No", "is_cancelled": int64(cancelled),
+	}
+	if party != "" {
+		values["against_voucher"] = voucherNo
+	}
+	return columnValues(glColumns, values)
+}
+
+func pleRow(name, voucherNo, party string, amount float64) []driver.Value {
+	return columnValues(pleColumns, map[string]driver.Value{
+		"name": name, "posting_date": "2024-04-01", "company": "Acme", "account": "Debtors",
+		"party_type": "Customer", "party": party, "voucher_type": "Sales Invoice", "voucher_no": voucherNo,
+		"against_voucher_type": "Sales Invoice", "against_voucher_no": voucherNo,
+		"amount": amount, "amount_in_account_currency": amount, "delinked": int64(0),
+	})
+}
+
+func columnValues(columns []string, values map[string]driver.Value) []driver.Value {
+	row := make([]driver.Value, len(columns))
+	for i, c := range columns {
+		row[i] = values[c]
+	}
+	return row
+}
+
+func openFake(t *testing.T, d *fakeDriver) *sql.DB {
+	t.Helper()
+	name := "erpimport-" + t.Name()
+	sql.Register(name, d)
+	db, err := sql.Open(name, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestSQLSource(t *testing.T) {
+	d := &fakeDriver{tables: map[string][][]driver.Value{
+		"tabGL Entry": {
+			glRow("GLE-1", "SINV-1", "Debtors", "Customer", "CUST-1", 100, 0, 0),
+			glRow("GLE-2", "SINV-1", "Sales", "", "", 0, 100, 0),
+		},
+		"tabPayment Ledger Entry": {
+			pleRow("PLE-1", "SINV-1", "CUST-1", 100),
+		},
+	}}
+	src := &SQLSource{DB: openFake(t, d), Company: "Acme"}
+
+	var gl []ledger.GLEntry
+	if err := src.GLEntries(func(e ledger.GLEntry) error { gl = append(gl, e); return nil }); err != nil {
+		t.Fatal(err)
+	}
+	if len(gl) != 2 {
+		t.Fatalf("got %d GL entries, want 2", len(gl))
+	}
+	e := gl[0]
+	if e.Name != "GLE-1" || e.Account != "Debtors" || e.Party != "CUST-1" || e.Debit != 100 || e.AgainstVoucher != "SINV-1" {
+		t.Errorf("unexpected entry %+v", e)
+	}
+	if got := e.TransactionDate.Format(ledger.DateLayout); got != "2024-04-01" {
+		t.Errorf("TransactionDate = %s", got)
+	}
+	if e.DueDate != nil || e.IsOpening != ledger.IsOpeningNo || e.IsCancelled {
+		t.Errorf("unexpected flags %+v", e)
+	}
+	if !strings.Contains(d.query[0], "WHERE company = ?") {
+		t.Errorf("query not filtered by company: %s", d.query[0])
+	}
+
+	var ple []ledger.PaymentLedgerEntry
+	if err := src.PaymentLedgerEntries(func(e ledger.PaymentLedgerEntry) error { ple = append(ple, e); return nil }); err != nil {
+		t.Fatal(err)
+	}
+	if len(ple) != 1 || ple[0].Amount != 100 || ple[0].AgainstVoucherNo != "SINV-1" || ple[0].Delinked {
+		t.Errorf("unexpected payment ledger %+v", ple)
+	}
+}
+
+func TestSQLSourceInvalidNumber(t *testing.T) {
+	bad := glRow("GLE-1", "SINV-1", "Debtors", "Customer", "CUST-1", 0, 0, 0)
+	bad[15] = "abc" // debit
+	src := &SQLSource{DB: openFake(t, &fakeDriver{tables: map[string][][]driver.Value{"tabGL Entry": {bad}}})}
+
+	err := src.GLEntries(func(ledger.GLEntry) error { return nil })
+	if err == nil || !strings.Contains(err.Error(), "GLE-1") {
+		t.Errorf("expected conversion error naming the entry, got %v", err)
+	}
+}
+
+// sliceSource is an in-memory Source.
+type sliceSource struct {
+	gl  []ledger.GLEntry
+	ple []ledger.PaymentLedgerEntry
+}
+
+func (s *sliceSource) GLEntries(fn func(ledger.GLEntry) error) error {
+	for _, e := range s.gl {
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *sliceSource) PaymentLedgerEntries(fn func(ledger.PaymentLedgerEntry) error) error {
+	for _, e := range s.ple {
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func gle(voucherNo, account, party string, debit, credit float64) ledger.GLEntry {
+	date, _ := ledger.ParseDate("2024-04-01")
+	e := ledger.GLEntry{
+		Name: voucherNo + "-" + account, PostingDate: date, Company: "Acme", Account: account,
+		VoucherType: "Sales Invoice", VoucherNo: voucherNo, Debit: debit, Credit: credit,
+	}
+	if party != "" {
+		e.PartyType, e.Party = "Customer", party
+	}
+	return e
+}
+
+func ple(voucherNo, party string, amount float64) ledger.PaymentLedgerEntry {
+	return ledger.PaymentLedgerEntry{
+		Name: voucherNo + "-PLE", Company: "Acme", Account: "Debtors", PartyType: "Customer", Party: party,
+		VoucherType: "Sales Invoice", VoucherNo: voucherNo, AgainstVoucherType: "Sales Invoice",
+		AgainstVoucherNo: voucherNo, Amount: amount,
+	}
+}
+
+func history() *sliceSource {
+	missing := gle("SINV-3", "", "", 0, 50)
+	receivable, income := gle("SINV-1", "Debtors", "CUST-1", 100, 0), gle("SINV-1", "Sales", "", 0, 100)
+	receivable.Against, income.Against = "Sales", "CUST-1"
+	return &sliceSource{
+		gl: []ledger.GLEntry{
+			receivable,
+			income,
+			gle("SINV-2", "Debtors", "CUST-2", 200, 0),
+			gle("SINV-2", "Sales", "", 0, 150), // Unbalanced
+			gle("SINV-3", "Debtors", "CUST-1", 50, 0),
+			missing,
+			gle("SINV-4", "Debtors", "CUST-1", 80, 0),
+			gle("SINV-4", "Sales", "", 0, 80),
+		},
+		ple: []ledger.PaymentLedgerEntry{
+			ple("SINV-1", "CUST-1", 100),
+			ple("SINV-2", "CUST-2", 200),
+			ple("SINV-4", "CUST-1", 70), // Disagrees with GL
+			ple("SINV-5", "CUST-2", 40), // No GL entries
+		},
+	}
+}
+
+func TestImporterRun(t *testing.T) {
+	gl, pl := memstore.NewGLStore(), memstore.NewPaymentStore()
+	im := &Importer{Source: history(), GLStore: gl, PaymentStore: pl, BatchSize: 3}
+
+	report, err := im.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Vouchers != 4 || report.GLEntries != 2 || report.PaymentLedgerEntries != 1 {
+		t.Errorf("unexpected counts %+v", report)
+	}
+	// SINV-4 disagrees with its payment ledger and is loaded neither way
+	if len(gl.All()) != 2 || len(pl.All()) != 1 {
+		t.Errorf("loaded %d GL and %d payment ledger entries, want 2 and 1", len(gl.All()), len(pl.All()))
+	}
+	if entries, _ := gl.GetByVoucher("Sales Invoice", "SINV-4"); len(entries) != 0 {
+		t.Errorf("loaded SINV-4: %+v", entries)
+	}
+
+	entries, _ := gl.GetByVoucher("Sales Invoice", "SINV-1")
+	for _, e := range entries {
+		ref := e.AgainstRefs[0]
+		if e.Account == "Debtors" && ref != (ledger.AgainstRef{Kind: ledger.AgainstAccount, Name: "Sales"}) ||
+			e.Account == "Sales" && ref != (ledger.AgainstRef{Kind: ledger.AgainstParty, PartyType: "Customer", Name: "CUST-1"}) {
+			t.Errorf("%s: against refs %+v", e.Account, e.AgainstRefs)
+		}
+	}
+
+	want := map[string]error{
+		"SINV-2": ErrUnbalancedVoucher,
+		"SINV-3": ErrInvalidEntry,
+		"SINV-4": ErrPaymentLedger,
+		"SINV-5": ErrPaymentLedger,
+	}
+	if len(report.Problems) != len(want) {
+		t.Fatalf("got problems %+v", report.Problems)
+	}
+	for _, p := range report.Problems {
+		if !errors.Is(p.Err, want[p.VoucherNo]) {
+			t.Errorf("%s: got %v, want %v", p.VoucherNo, p.Err, want[p.VoucherNo])
+		}
+	}
+}
+
+func TestImporterCancelledVoucher(t *testing.T) {
+	original := []ledger.GLEntry{gle("SINV-1", "Debtors", "CUST-1", 100, 0), gle("SINV-1", "Sales", "", 0, 100)}
+	reversal := []ledger.GLEntry{gle("SINV-1", "Debtors", "CUST-1", 0, 100), gle("SINV-1", "Sales", "", 100, 0)}
+	var entries []ledger.GLEntry
+	for _, e := range append(original, reversal...) {
+		e.IsCancelled = true
+		entries = append(entries, e)
+	}
+	cancelled := ple("SINV-1", "CUST-1", 100)
+	cancelled.Delinked = true
+
+	report, err := (&Importer{
+		Source:  &sliceSource{gl: entries, ple: []ledger.PaymentLedgerEntry{cancelled}},
+		GLStore: memstore.NewGLStore(), PaymentStore: memstore.NewPaymentStore(),
+	}).Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Problems) != 0 || report.GLEntries != 4 {
+		t.Errorf("unexpected report %+v", report)
+	}
+}
+
+func TestImporterStrictAndDryRun(t *testing.T) {
+	gl, pl := memstore.NewGLStore(), memstore.NewPaymentStore()
+
+	report, err := (&Importer{Source: history(), GLStore: gl, PaymentStore: pl, DryRun: true}).Run()
+	if err != nil || len(report.Problems) != 4 {
+		t.Fatalf("dry run: %v, %+v", err, report)
+	}
+	if len(gl.All()) != 0 || len(pl.All()) != 0 {
+		t.Error("dry run loaded entries")
+	}
+
+	_, err = (&Importer{Source: history(), GLStore: gl, PaymentStore: pl, Strict: true}).Run()
+	if !errors.Is(err, ErrProblemsFound) {
+		t.Errorf("strict: got %v, want ErrProblemsFound", err)
+	}
+	if len(gl.All()) != 0 || len(pl.All()) != 0 {
+		t.Error("strict import loaded entries despite problems")
+	}
+}
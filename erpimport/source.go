@@ -0,0 +1,175 @@
+// Package erpimport migrates ledger history from an ERPNext site.
+//
+// It reads tabGL Entry and tabPayment Ledger Entry, maps the rows into the
+// ledger models, checks the same invariants the engine enforces on new
+// postings, and loads the entries into the Go stores in batches, so a
+// company can cut over with its full history.
+//
+// The importer works on any database/sql connection to the ERPNext
+// database; the caller registers the MySQL (or MariaDB) driver.
+package erpimport
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/ledger"
+)
+
+// Source streams ledger rows voucher by voucher.
+type Source interface {
+	// GLEntries calls fn for every GL entry, ordered by voucher.
+	GLEntries(fn func(ledger.GLEntry) error) error
+
+	// PaymentLedgerEntries calls fn for every payment ledger entry. An
+	// import reads them twice: once to check each voucher, once to load.
+	PaymentLedgerEntries(fn func(ledger.PaymentLedgerEntry) error) error
+}
+
+// SQLSource reads an ERPNext database.
+type SQLSource struct {
+	DB      *sql.DB
+	Company string // Optional; all companies when empty
+}
+
+var glColumns = []string{
+	"name", "posting_date", "transaction_date", "due_date", "account", "account_currency",
+	"party_type", "party", "against", "voucher_type", "voucher_no", "voucher_subtype", "voucher_detail_no",
+	"against_voucher_type", "against_voucher", "debit", "credit",
+	"debit_in_account_currency", "credit_in_account_currency", "transaction_currency", "transaction_exchange_rate",
+	"debit_in_transaction_currency", "credit_in_transaction_currency", "cost_center", "project",
+	"company", "fiscal_year", "finance_book", "is_opening", "is_advance", "is_cancelled", "remarks",
+}
+
+var pleColumns = []string{
+	"name", "posting_date", "company", "account", "party_type", "party", "voucher_type", "voucher_no",
+	"voucher_detail_no", "against_voucher_type", "against_voucher_no", "account_currency",
+	"amount", "amount_in_account_currency", "due_date", "finance_book", "delinked",
+}
+
+// GLEntries implements Source.
+func (s *SQLSource) GLEntries(fn func(ledger.GLEntry) error) error {
+	return s.query("tabGL Entry", glColumns, "voucher_type, voucher_no, creation, name", func(r *row) error {
+		e := ledger.GLEntry{
+			Name: r.str(), PostingDate: r.date(), TransactionDate: r.date(), DueDate: r.optDate(),
+			Account: r.str(), AccountCurrency: r.str(), PartyType: r.str(), Party: r.str(), Against: r.str(),
+			VoucherType: r.str(), VoucherNo: r.str(), VoucherSubtype: r.str(), VoucherDetailNo: r.str(),
+			AgainstVoucherType: r.str(), AgainstVoucher: r.str(), Debit: r.float(), Credit: r.float(),
+			DebitInAccountCurrency: r.float(), CreditInAccountCurrency: r.float(),
+			TransactionCurrency: r.str(), TransactionExchangeRate: r.float(),
+			DebitInTransactionCurrency: r.float(), CreditInTransactionCurrency: r.float(),
+			CostCenter: r.str(), Project: r.str(), Company: r.str(), FiscalYear: r.str(), FinanceBook: r.str(),
+			IsOpening: ledger.IsOpeningEntry(r.str()), IsAdvance: ledger.IsAdvanceEntry(r.str()),
+			IsCancelled: r.flag(), Remarks: r.str(),
+		}
+		if r.err != nil {
+			return fmt.Errorf("GL Entry %s: %w", e.Name, r.err)
+		}
+		return fn(e)
+	})
+}
+
+// PaymentLedgerEntries implements Source.
+func (s *SQLSource) PaymentLedgerEntries(fn func(ledger.PaymentLedgerEntry) error) error {
+	return s.query("tabPayment Ledger Entry", pleColumns, "voucher_type, voucher_no, creation, name", func(r *row) error {
+		e := ledger.PaymentLedgerEntry{
+			Name: r.str(), PostingDate: r.date(), Company: r.str(), Account: r.str(),
+			PartyType: r.str(), Party: r.str(), VoucherType: r.str(), VoucherNo: r.str(), VoucherDetailNo: r.str(),
+			AgainstVoucherType: r.str(), AgainstVoucherNo: r.str(), AccountCurrency: r.str(),
+			Amount: r.float(), AmountInAccountCurrency: r.float(), DueDate: r.optDate(),
+			FinanceBook: r.str(), Delinked: r.flag(),
+		}
+		if r.err != nil {
+			return fmt.Errorf("Payment Ledger Entry %s: %w", e.Name, r.err)
+		}
+		return fn(e)
+	})
+}
+
+func (s *SQLSource) query(table string, columns []string, orderBy string, scan func(*row) error) error {
+	query := fmt.Sprintf("SELECT `%s` FROM `%s`", strings.Join(columns, "`, `"), table)
+	var args []any
+	if s.Company != "" {
+		query += " WHERE company = ?"
+		args = append(args, s.Company)
+	}
+	query += " ORDER BY " + orderBy
+
+	rows, err := s.DB.Query(query, args...)
+	if err != nil {
+		return fmt.Errorf("query %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	values := make([]sql.NullString, len(columns))
+	dest := make([]any, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return fmt.Errorf("scan %s: %w", table, err)
+		}
+		if err := scan(&row{values: values}); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// row converts the scanned columns in order. Conversion errors are kept
+// in err so a whole row can be mapped in one expression.
+type row struct {
+	values []sql.NullString
+	next   int
+	err    error
+}
+
+func (r *row) str() string {
+	v := r.values[r.next]
+	r.next++
+	return v.String
+}
+
+func (r *row) float() float64 {
+	s := r.str()
+	if s == "" {
+		return 0
+	}
+	var f float64
+	if _, err := fmt.Sscan(s, &f); err != nil && r.err == nil {
+		r.err = fmt.Errorf("invalid number %q: %w", s, err)
+	}
+	return f
+}
+
+func (r *row) flag() bool {
+	return r.float() != 0
+}
+
+// date parses a DATE column. Drivers return "2006-01-02", or an RFC 3339
+// timestamp when dates are parsed into time.Time.
+func (r *row) date() time.Time {
+	s := r.str()
+	if s == "" {
+		return time.Time{}
+	}
+	if len(s) > len(ledger.DateLayout) {
+		s = s[:len(ledger.DateLayout)]
+	}
+	d, err := ledger.ParseDate(s)
+	if err != nil && r.err == nil {
+		r.err = err
+	}
+	return d
+}
+
+func (r *row) optDate() *time.Time {
+	d := r.date()
+	if d.IsZero() {
+		return nil
+	}
+	return &d
+}
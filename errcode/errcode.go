@@ -0,0 +1,99 @@
+// Package errcode assigns stable, machine-readable codes to errors.
+//
+// A code such as "ACC-GLE-0007" names one kind of failure and does not
+// change between releases or languages, so API clients can branch on it
+// instead of parsing messages. Typed errors report their code through a
+// Code method; packages register their sentinel errors, which cannot have
+// methods, with Register. Of finds the code of any error in the chain.
+//
+// Codes follow the ERPNext naming-series style: a module prefix, a
+// document or area, and a number (ACC-GLE for the general ledger, ACC-TAX
+// for tax calculation, ACC-MOP for modes of payment).
+package errcode
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+)
+
+// Coder is implemented by errors that carry a code.
+type Coder interface {
+	Code() string
+}
+
+// Entry describes a registered code.
+type Entry struct {
+	Code        string
+	Description string
+	Err         error // The sentinel error, or nil for a typed error
+}
+
+var (
+	mu      sync.RWMutex
+	byCode  = map[string]Entry{}
+	entries []Entry // Sentinels in registration order, for Of
+)
+
+// Register records a code. err is the sentinel error the code stands for,
+// or nil when a typed error reports the code itself. Register panics if
+// the code is already taken, since two errors sharing a code would break
+// every client that branches on it.
+func Register(code string, err error, description string) {
+	mu.Lock()
+	defer mu.Unlock()
+	if _, dup := byCode[code]; dup {
+		panic(fmt.Sprintf("errcode: duplicate code %s", code))
+	}
+	e := Entry{Code: code, Description: description, Err: err}
+	byCode[code] = e
+	if err != nil {
+		entries = append(entries, e)
+	}
+}
+
+// Of returns the code of an error: that of the outermost error in the
+// chain with a Code method, or else of the first registered sentinel the
+// error matches. It returns "" for errors without a code.
+func Of(err error) string {
+	if err == nil {
+		return ""
+	}
+	var c Coder
+	if errors.As(err, &c) {
+		if code := c.Code(); code != "" {
+			return code
+		}
+	}
+	mu.RLock()
+	defer mu.RUnlock()
+	for _, e := range entries {
+		if errors.Is(err, e.Err) {
+			return e.Code
+		}
+	}
+	return ""
+}
+
+// Lookup returns the entry of a code.
+func Lookup(code string) (Entry, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	e, ok := byCode[code]
+	return e, ok
+}
+
+// All returns every registered code, sorted, for documentation and for
+// clients that want the full list.
+func All() []Entry {
+	mu.RLock()
+	all := make([]Entry, 0, len(byCode))
+	for _, e := range byCode {
+		all = append(all, e)
+	}
+	mu.RUnlock()
+	slices.SortFunc(all, func(a, b Entry) int { return cmp.Compare(a.Code, b.Code) })
+	return all
+}
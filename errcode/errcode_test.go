@@ -0,0 +1,52 @@
+package errcode
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+type codedError struct{ err error }
+
+func (e *codedError) Error() string { return "coded: " + e.err.Error() }
+func (e *codedError) Unwrap() error { return e.err }
+func (e *codedError) Code() string  { return "TST-ERR-0002" }
+
+func TestOf(t *testing.T) {
+	sentinel := errors.New("sentinel")
+	Register("TST-ERR-0001", sentinel, "Test sentinel")
+	Register("TST-ERR-0002", nil, "Test typed error")
+
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{nil, ""},
+		{errors.New("other"), ""},
+		{sentinel, "TST-ERR-0001"},
+		{fmt.Errorf("posting: %w", sentinel), "TST-ERR-0001"},
+		{fmt.Errorf("posting: %w", &codedError{sentinel}), "TST-ERR-0002"},
+	}
+	for _, tt := range tests {
+		if got := Of(tt.err); got != tt.want {
+			t.Errorf("Of(%v) = %q, want %q", tt.err, got, tt.want)
+		}
+	}
+
+	if e, ok := Lookup("TST-ERR-0002"); !ok || e.Err != nil || e.Description != "Test typed error" {
+		t.Errorf("Lookup = %+v, %v", e, ok)
+	}
+	all := All()
+	for i := 1; i < len(all); i++ {
+		if all[i-1].Code >= all[i].Code {
+			t.Fatalf("All not sorted: %s before %s", all[i-1].Code, all[i].Code)
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("duplicate code registered")
+		}
+	}()
+	Register("TST-ERR-0001", errors.New("again"), "Duplicate")
+}
@@ -4,7 +4,8 @@ package validation
 import (
 	"errors"
 	"fmt"
-	"math"
+
+	"github.com/senguttuvang/erpnext-go/numeric"
 )
 
 type GLEntry struct {
@@ -34,7 +35,7 @@ func TotalCredit(entries []GLEntry) float64 {
 func IsBalanced(entries []GLEntry) bool {
 	debit := TotalDebit(entries)
 	credit := TotalCredit(entries)
-	return math.Abs(debit-credit) < epsilon
+	return numeric.Near(debit, credit, epsilon)
 }
 
 func Difference(entries []GLEntry) float64 {
@@ -1,7 +1,7 @@
 package validation
 
 import (
-	"math"
+	"github.com/senguttuvang/erpnext-go/numeric"
 )
 
 // GLEntry represents a General Ledger Entry.
@@ -77,9 +77,10 @@ func IsBalanced(entries []GLEntry) bool {
 	// Hint:
 	// 1. Get total debit using TotalDebit()
 	// 2. Get total credit using TotalCredit()
-	// 3. Compare using math.Abs(debit - credit) < epsilon
+	// 3. Compare using numeric.Near(debit, credit, epsilon), the same
+	//    helper the ledger and taxcalc packages compare amounts with
 
-	_ = math.Abs // This line is here so the import doesn't error
+	_ = numeric.Near // This line is here so the import doesn't error
 
 	return false // Replace this
 }
@@ -0,0 +1,348 @@
+// Package explorer is a read-only, line-oriented terminal browser of the
+// general ledger, for operators debugging postings on servers without a
+// web UI. It needs nothing but a terminal: commands are typed one per
+// line and listings are printed as aligned text, so it works over ssh,
+// in a container's shell and in a script.
+//
+// A session starts at the trial balance. Each listing numbers its rows,
+// and "open N" drills into row N: from the trial balance to an account's
+// GL entries, and from an entry to every entry of its voucher. "back"
+// returns to the previous listing, and "filter" narrows all of them by
+// company, dates, party, voucher type or cost center. Nothing is ever
+// written to the source.
+//
+// Maps to: the drill-down from Trial Balance to General Ledger to the
+// voucher in ERPNext's desk
+package explorer
+
+import (
+	"bufio"
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/columnar"
+	"github.com/senguttuvang/erpnext-go/ledger"
+)
+
+// ErrUnknownCommand is returned, and printed, for a line the explorer
+// does not understand.
+var ErrUnknownCommand = errors.New("unknown command")
+
+// Source is the ledger the explorer reads. parquet.Store implements it;
+// Entries adapts entries held in memory, such as those of a memstore or a
+// ledger snapshot. A database adapter implements Query with a SELECT.
+type Source interface {
+	Query(f ledger.GLFilter, tree *ledger.AccountTree) ([]ledger.GLEntry, error)
+}
+
+// Entries is a Source over entries held in memory.
+type Entries func() []ledger.GLEntry
+
+// Query implements Source.
+func (e Entries) Query(f ledger.GLFilter, tree *ledger.AccountTree) ([]ledger.GLEntry, error) {
+	return ledger.QueryGL(e(), f, tree)
+}
+
+// view is a listing the session can return to.
+type view struct {
+	kind    string // "tb", "gl" or "voucher"
+	account string // Of a gl view
+	voucher [2]string
+
+	rows [][]string // As printed, for drilling into
+	refs []func() view
+}
+
+// Explorer is a session over a source.
+type Explorer struct {
+	Source Source
+	Tree   *ledger.AccountTree // Optional; group accounts filter their descendants when set
+	Filter ledger.GLFilter
+
+	stack []view
+}
+
+// Run reads commands from in and prints to out until "quit", the end of
+// in or ctx is done. Errors of a command are printed and the session goes
+// on; only a failure to write to out ends it early.
+func (x *Explorer) Run(ctx context.Context, in io.Reader, out io.Writer) error {
+	if err := x.Exec("tb", out); err != nil {
+		fmt.Fprintln(out, "error:", err)
+	}
+	scanner := bufio.NewScanner(in)
+	for {
+		if _, err := fmt.Fprint(out, "> "); err != nil {
+			return err
+		}
+		if ctx.Err() != nil || !scanner.Scan() {
+			return scanner.Err()
+		}
+		line := strings.TrimSpace(scanner.Text())
+		if line == "quit" || line == "q" {
+			return nil
+		}
+		if err := x.Exec(line, out); err != nil {
+			if _, err := fmt.Fprintln(out, "error:", err); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// Exec runs one command line.
+func (x *Explorer) Exec(line string, out io.Writer) error {
+	cmd, args, _ := strings.Cut(strings.TrimSpace(line), " ")
+	args = strings.TrimSpace(args)
+	switch cmd {
+	case "":
+		return nil
+	case "help", "?":
+		_, err := io.WriteString(out, help)
+		return err
+	case "tb":
+		return x.show(view{kind: "tb"}, out)
+	case "gl":
+		if args == "" {
+			return fmt.Errorf("%w: gl needs an account", ErrUnknownCommand)
+		}
+		return x.show(view{kind: "gl", account: args}, out)
+	case "voucher":
+		voucherType, voucherNo, ok := strings.Cut(args, "|")
+		if !ok {
+			return fmt.Errorf("%w: voucher needs TYPE|NO, e.g. Sales Invoice|SINV-0001", ErrUnknownCommand)
+		}
+		return x.show(view{kind: "voucher", voucher: [2]string{strings.TrimSpace(voucherType), strings.TrimSpace(voucherNo)}}, out)
+	case "open":
+		if len(x.stack) == 0 {
+			return fmt.Errorf("%w: nothing to open", ErrUnknownCommand)
+		}
+		top := x.stack[len(x.stack)-1]
+		n, err := strconv.Atoi(args)
+		if err != nil || n < 1 || n > len(top.refs) {
+			return fmt.Errorf("%w: open takes a row number from 1 to %d", ErrUnknownCommand, len(top.refs))
+		}
+		return x.show(top.refs[n-1](), out)
+	case "back":
+		if len(x.stack) < 2 {
+			return fmt.Errorf("%w: already at the first listing", ErrUnknownCommand)
+		}
+		x.stack = x.stack[:len(x.stack)-1]
+		return x.redraw(out)
+	case "filter":
+		if err := x.setFilter(args); err != nil {
+			return err
+		}
+		fmt.Fprintln(out, "filter:", describe(x.Filter))
+		return x.redraw(out)
+	}
+	return fmt.Errorf("%w: %q; type help", ErrUnknownCommand, cmd)
+}
+
+const help = `tb                          trial balance
+gl ACCOUNT                  GL entries of an account
+voucher TYPE|NO             entries of a voucher, cancelled included
+open N                      drill into row N of the listing
+back                        return to the previous listing
+filter KEY=VALUE ...        company, from, to, party_type, party,
+                            voucher_type, cost_center, cancelled=yes
+filter clear                remove all filters
+quit
+`
+
+// show computes a view, prints it and makes it the current one.
+func (x *Explorer) show(v view, out io.Writer) error {
+	if err := x.load(&v); err != nil {
+		return err
+	}
+	x.stack = append(x.stack, v)
+	return render(v, out)
+}
+
+// redraw recomputes the current view, as after a filter changed.
+func (x *Explorer) redraw(out io.Writer) error {
+	if len(x.stack) == 0 {
+		return x.show(view{kind: "tb"}, out)
+	}
+	v := &x.stack[len(x.stack)-1]
+	if err := x.load(v); err != nil {
+		return err
+	}
+	return render(*v, out)
+}
+
+// load fills the rows of a view from the source.
+func (x *Explorer) load(v *view) error {
+	v.rows, v.refs = nil, nil
+	switch v.kind {
+	case "tb":
+		f := x.Filter
+		f.From, f.IncludeOpening = time.Time{}, true // Earlier and opening entries make the opening balance
+		entries, err := x.Source.Query(f, x.Tree)
+		if err != nil {
+			return err
+		}
+		tb, err := columnar.FromEntries(entries).TrialBalance(x.Filter, x.Tree)
+		if err != nil {
+			return err
+		}
+		v.rows = [][]string{{"#", "Account", "Opening Dr", "Opening Cr", "Debit", "Credit", "Closing Dr", "Closing Cr"}}
+		for _, r := range tb {
+			account := r.Account
+			v.refs = append(v.refs, func() view { return view{kind: "gl", account: account} })
+			v.rows = append(v.rows, []string{strconv.Itoa(len(v.refs)), r.Account, amount(r.OpeningDebit), amount(r.OpeningCredit),
+				amount(r.Debit), amount(r.Credit), amount(r.ClosingDebit), amount(r.ClosingCredit)})
+		}
+
+	case "gl", "voucher":
+		f := x.Filter
+		if v.kind == "gl" {
+			f.Account = v.account
+		} else {
+			f = ledger.GLFilter{VoucherType: v.voucher[0], VoucherNo: v.voucher[1], IncludeCancelled: true}
+			f.IncludeOpening, f.IncludePeriodClosing = true, true
+		}
+		entries, err := x.Source.Query(f, x.Tree)
+		if err != nil {
+			return err
+		}
+		v.rows = [][]string{{"#", "Date", "Account", "Debit", "Credit", "Balance", "Voucher", "Party", "Against", ""}}
+		var balance float64
+		cancelled := ledger.CancelledEntries(entries)
+		for i, e := range entries {
+			balance += e.Debit - e.Credit
+			ref := [2]string{e.VoucherType, e.VoucherNo}
+			v.refs = append(v.refs, func() view { return view{kind: "voucher", voucher: ref} })
+			flag := ""
+			if cancelled[i] {
+				flag = "cancelled"
+			}
+			v.rows = append(v.rows, []string{strconv.Itoa(len(v.refs)), e.PostingDate.Format(ledger.DateLayout), e.Account,
+				amount(e.Debit), amount(e.Credit), amount(balance), e.VoucherType + " " + e.VoucherNo,
+				strings.TrimSpace(e.PartyType + " " + e.Party), cmp.Or(e.AgainstVoucher, e.Against), flag})
+		}
+	}
+	return nil
+}
+
+// title is the heading of a view.
+func (v view) title() string {
+	switch v.kind {
+	case "gl":
+		return "General Ledger: " + v.account
+	case "voucher":
+		return v.voucher[0] + " " + v.voucher[1]
+	}
+	return "Trial Balance"
+}
+
+// render prints a view under its title, in aligned columns.
+func render(v view, out io.Writer) error {
+	fmt.Fprintf(out, "%s (%d rows)\n", v.title(), len(v.refs))
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	for _, row := range v.rows {
+		fmt.Fprintln(w, strings.Join(row, "\t")+"\t")
+	}
+	return w.Flush()
+}
+
+// amount formats an amount to the cent, blank when zero, as the desk
+// shows it.
+func amount(v float64) string {
+	if v == 0 {
+		return ""
+	}
+	return strconv.FormatFloat(ledger.Flt(v, 2), 'f', 2, 64)
+}
+
+// setFilter applies "key=value" pairs, or "clear". Values may contain
+// spaces up to the next key.
+func (x *Explorer) setFilter(args string) error {
+	if args == "clear" {
+		x.Filter = ledger.GLFilter{}
+		return nil
+	}
+	f := x.Filter
+	for _, pair := range splitPairs(args) {
+		key, value, _ := strings.Cut(pair, "=")
+		switch key {
+		case "company":
+			f.Company = value
+		case "party_type":
+			f.PartyType = value
+		case "party":
+			f.Party = value
+		case "voucher_type":
+			f.VoucherType = value
+		case "cost_center":
+			f.CostCenter = value
+		case "cancelled":
+			f.IncludeCancelled = value == "yes"
+		case "from", "to":
+			var d time.Time
+			if value != "" {
+				var err error
+				if d, err = ledger.ParseDate(value); err != nil {
+					return fmt.Errorf("%w: %s=%q is not a date", ErrUnknownCommand, key, value)
+				}
+			}
+			if key == "from" {
+				f.From = d
+			} else {
+				f.To = d
+			}
+		default:
+			return fmt.Errorf("%w: unknown filter %q", ErrUnknownCommand, key)
+		}
+	}
+	x.Filter = f
+	return nil
+}
+
+// splitPairs splits "party=Acme Corp from=2024-01-01" at the spaces that
+// start a new key.
+func splitPairs(s string) []string {
+	var pairs []string
+	for _, word := range strings.Fields(s) {
+		if strings.Contains(word, "=") || len(pairs) == 0 {
+			pairs = append(pairs, word)
+		} else {
+			pairs[len(pairs)-1] += " " + word
+		}
+	}
+	return pairs
+}
+
+// describe prints the fields of a filter that are set.
+func describe(f ledger.GLFilter) string {
+	var parts []string
+	add := func(key, value string) {
+		if value != "" {
+			parts = append(parts, key+"="+value)
+		}
+	}
+	add("company", f.Company)
+	if !f.From.IsZero() {
+		add("from", f.From.Format(ledger.DateLayout))
+	}
+	if !f.To.IsZero() {
+		add("to", f.To.Format(ledger.DateLayout))
+	}
+	add("party_type", f.PartyType)
+	add("party", f.Party)
+	add("voucher_type", f.VoucherType)
+	add("cost_center", f.CostCenter)
+	if f.IncludeCancelled {
+		add("cancelled", "yes")
+	}
+	if len(parts) == 0 {
+		return "none"
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,111 @@
+package explorer
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/ledger"
+)
+
+func entry(date, voucherNo, account string, debit, credit float64) ledger.GLEntry {
+	d, err := ledger.ParseDate(date)
+	if err != nil {
+		panic(err)
+	}
+	return ledger.GLEntry{PostingDate: d, Company: "Acme", VoucherType: "Sales Invoice", VoucherNo: voucherNo,
+		Account: account, Debit: debit, Credit: credit}
+}
+
+func fixture() []ledger.GLEntry {
+	cancelled := []ledger.GLEntry{
+		entry("2024-04-03", "SINV-3", "Debtors", 50, 0),
+		entry("2024-04-03", "SINV-3", "Sales", 0, 50),
+	}
+	cancelled[0].IsCancelled, cancelled[1].IsCancelled = true, true
+	return []ledger.GLEntry{
+		entry("2024-03-30", "SINV-1", "Debtors", 100, 0),
+		entry("2024-03-30", "SINV-1", "Sales", 0, 100),
+		entry("2024-04-02", "SINV-2", "Debtors", 250, 0),
+		entry("2024-04-02", "SINV-2", "Sales", 0, 250),
+		cancelled[0],
+		cancelled[1],
+		// The reversals, saved live as older stores hold them
+		entry("2024-04-03", "SINV-3", "Debtors", 0, 50),
+		entry("2024-04-03", "SINV-3", "Sales", 50, 0),
+	}
+}
+
+func TestExplorerDrillDown(t *testing.T) {
+	entries := fixture()
+	x := &Explorer{Source: Entries(func() []ledger.GLEntry { return entries })}
+	in := strings.NewReader(strings.Join([]string{
+		"filter from=2024-04-01",
+		"open 1", // Debtors
+		"open 1", // SINV-2
+		"back",   // Debtors again
+		"bogus",
+		"quit",
+		"tb", // Never read
+	}, "\n"))
+	var out strings.Builder
+	if err := x.Run(t.Context(), in, &out); err != nil {
+		t.Fatal(err)
+	}
+	got := out.String()
+	for _, want := range []string{
+		"Trial Balance (2 rows)",
+		"filter: from=2024-04-01",
+		"General Ledger: Debtors (1 rows)",
+		"Sales Invoice SINV-2 (2 rows)",
+		"250.00",
+		`error: unknown command: "bogus"`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output lacks %q:\n%s", want, got)
+		}
+	}
+	if strings.Count(got, "General Ledger: Debtors") != 2 {
+		t.Errorf("back did not redraw the account:\n%s", got)
+	}
+	if strings.Count(got, "Trial Balance") != 2 {
+		t.Errorf("the session ran past quit:\n%s", got)
+	}
+
+	// The opening balance is what was posted before the period
+	tb := x.stack[0].rows[1]
+	if tb[1] != "Debtors" || tb[2] != "100.00" || tb[4] != "250.00" || tb[6] != "350.00" {
+		t.Errorf("trial balance row = %q", tb)
+	}
+}
+
+func TestExplorerFilters(t *testing.T) {
+	entries := fixture()
+	x := &Explorer{Source: Entries(func() []ledger.GLEntry { return entries })}
+	var out strings.Builder
+
+	if err := x.Exec("filter party=Acme Corp Ltd cancelled=yes to=2024-04-30", &out); err != nil {
+		t.Fatal(err)
+	}
+	if x.Filter.Party != "Acme Corp Ltd" || !x.Filter.IncludeCancelled || !x.Filter.To.Equal(time.Date(2024, 4, 30, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("filter = %+v", x.Filter)
+	}
+	if err := x.Exec("filter clear", &out); err != nil || x.Filter != (ledger.GLFilter{}) {
+		t.Errorf("filter clear = %+v, %v", x.Filter, err)
+	}
+	for _, line := range []string{"filter from=someday", "filter colour=red", "open 9", "voucher SINV-1"} {
+		if err := x.Exec(line, &out); !errors.Is(err, ErrUnknownCommand) {
+			t.Errorf("%s: err = %v, want ErrUnknownCommand", line, err)
+		}
+	}
+
+	// A voucher shows its cancelled entries whatever the filter
+	out.Reset()
+	if err := x.Exec("voucher Sales Invoice|SINV-3", &out); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Count(out.String(), "cancelled") != 4 {
+		t.Errorf("voucher lacks its cancelled entries:\n%s", out.String())
+	}
+}
@@ -0,0 +1,56 @@
+// Command ledger-tui browses a general ledger in the terminal, read-only:
+// trial balance, an account's entries and a voucher's, with filters. It
+// reads a ledger snapshot or a Parquet dataset, so an operator can copy
+// either to a server without a web UI and debug postings there.
+//
+//	go run ./explorer/ledger-tui -snapshot ledger.json.gz
+//	go run ./explorer/ledger-tui -parquet /var/lib/erp/gl -company "Acme Ltd"
+//
+// Type help at the prompt for the commands. See package explorer.
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+
+	"github.com/senguttuvang/erpnext-go/explorer"
+	"github.com/senguttuvang/erpnext-go/ledger"
+	"github.com/senguttuvang/erpnext-go/parquet"
+)
+
+func main() {
+	snapshot := flag.String("snapshot", "", "ledger snapshot file (.json.gz) to read")
+	dataset := flag.String("parquet", "", "directory of a Parquet GL dataset to read")
+	company := flag.String("company", "", "initial company filter")
+	flag.Parse()
+
+	x := &explorer.Explorer{Filter: ledger.GLFilter{Company: *company}}
+	switch {
+	case *snapshot != "" && *dataset == "":
+		f, err := os.Open(*snapshot)
+		if err != nil {
+			log.Fatal(err)
+		}
+		snap, err := ledger.ImportLedgerSnapshot(f)
+		f.Close()
+		if err != nil {
+			log.Fatal(err)
+		}
+		if len(snap.Accounts) > 0 {
+			if x.Tree, err = ledger.NewAccountTree(snap.Accounts...); err != nil {
+				log.Fatal(err)
+			}
+		}
+		x.Source = explorer.Entries(func() []ledger.GLEntry { return snap.GLEntries })
+	case *dataset != "" && *snapshot == "":
+		x.Source = parquet.NewStore(os.DirFS(*dataset))
+	default:
+		log.Fatal("one of -snapshot or -parquet is required")
+	}
+
+	if err := x.Run(context.Background(), os.Stdin, os.Stdout); err != nil {
+		log.Fatal(err)
+	}
+}
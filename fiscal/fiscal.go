@@ -0,0 +1,296 @@
+// Package fiscal produces the signed receipt data that fiscal printer and
+// cash register regimes require of point of sale invoices.
+//
+// Where the law requires it, every receipt a till issues is signed by a
+// certified security device (a TSE in Germany, the RT printer in Italy)
+// before it is handed over, and the till is closed each day with a Z
+// report of what it took. A Device holds the counters of one till: the
+// receipt number, which never resets, and the number of the Z period and
+// of the receipt within it, which restart after each Z report. The
+// Fiscalizer turns a submitted POS invoice into a Receipt, renders it in
+// the regime's Format and has the Signer sign it; Close aggregates the
+// receipts of the period into a ZReport, signed too when the format has a
+// Z report layout. The caller persists the device after each call, as
+// counters only advance when a receipt or report is produced.
+//
+// Amounts are in the invoice's currency; returns are negative.
+//
+// Maps to: the fiscal printer and TSE integrations of ERPNext POS
+// (pos_awesome, erpnext_germany's fiskaly TSE, erpnext_italy's RT), which
+// ERPNext leaves to regional apps
+package fiscal
+
+import (
+	"cmp"
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/ledger"
+	"github.com/senguttuvang/erpnext-go/modeofpayment"
+	"github.com/senguttuvang/erpnext-go/salesinvoice"
+	"github.com/senguttuvang/erpnext-go/taxcalc"
+)
+
+// Fiscalization errors
+var (
+	ErrNotConfigured      = errors.New("fiscalizer is missing its format or signer")
+	ErrNotFiscalizable    = errors.New("invoice cannot be fiscalized")
+	ErrUnsupportedRate    = errors.New("VAT rate is not supported by the fiscal format")
+	ErrReceiptOutOfPeriod = errors.New("receipt does not belong to the period being closed")
+)
+
+// Device is a till registered with the fiscal authority, and its
+// counters. The zero value, with an ID, is a new device.
+type Device struct {
+	ID             string
+	ReceiptCounter int64 // Number of the last receipt; never reset
+	ZCounter       int64 // Number of the last Z report
+	PeriodCounter  int64 // Receipts since the last Z report
+}
+
+// Receipt is the fiscal content of a POS invoice.
+type Receipt struct {
+	Device    string
+	Invoice   string
+	Number    int64 // The device's receipt number
+	ZNumber   int64 // Z period the receipt falls in
+	DayNumber int64 // Number within the Z period
+	IssuedAt  time.Time
+	Return    bool
+
+	Rates    []RateTotal    // By VAT rate, highest first
+	Rounding float64        // Cash rounding of the total
+	Payments []PaymentTotal // By mode of payment, net of change
+	Total    float64        // Gross, after rounding
+}
+
+// RateTotal is what a receipt, or a Z report, took at one VAT rate.
+type RateTotal struct {
+	Rate  float64
+	Net   float64
+	VAT   float64
+	Gross float64
+}
+
+// PaymentTotal is what a receipt, or a Z report, took by one mode of
+// payment.
+type PaymentTotal struct {
+	ModeOfPayment string
+	Cash          bool
+	Amount        float64
+}
+
+// Payload is a receipt or report rendered for signing.
+type Payload struct {
+	Type string // Process type, e.g. "Kassenbeleg-V1"
+	Data []byte
+}
+
+// Signature is a security device's signature of a payload.
+type Signature struct {
+	Value     string // Base64
+	Algorithm string
+	Counter   int64     // The device's signature counter
+	Time      time.Time // By the device's clock
+}
+
+// SignedReceipt is a receipt with the data signed and its signature, to
+// print on the receipt and keep for audits.
+type SignedReceipt struct {
+	Receipt
+	Payload   Payload
+	Signature Signature
+}
+
+// Format renders receipts in the layout a regime signs.
+type Format interface {
+	Receipt(r *Receipt) (Payload, error)
+}
+
+// ZFormat is implemented by formats whose regime signs Z reports too.
+type ZFormat interface {
+	ZReport(z *ZReport) (Payload, error)
+}
+
+// Signer is the security device, or the cloud service standing in for
+// it, that signs payloads.
+type Signer interface {
+	Sign(ctx context.Context, p Payload) (Signature, error)
+}
+
+// Fiscalizer signs the receipts and Z reports of a regime.
+type Fiscalizer struct {
+	Format Format
+	Signer Signer
+}
+
+// Fiscalize makes the next receipt of the device from a submitted POS
+// invoice, renders it and signs it. The device's counters advance only
+// when the receipt is signed.
+func (f *Fiscalizer) Fiscalize(ctx context.Context, device *Device, inv *salesinvoice.SalesInvoice, issuedAt time.Time) (*SignedReceipt, error) {
+	if f.Format == nil || f.Signer == nil {
+		return nil, ErrNotConfigured
+	}
+	r, err := NewReceipt(inv)
+	if err != nil {
+		return nil, err
+	}
+	r.Device, r.IssuedAt = device.ID, issuedAt
+	r.Number, r.ZNumber, r.DayNumber = device.ReceiptCounter+1, device.ZCounter+1, device.PeriodCounter+1
+	payload, err := f.Format.Receipt(r)
+	if err != nil {
+		return nil, err
+	}
+	sig, err := f.Signer.Sign(ctx, payload)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", inv.Name, err)
+	}
+	device.ReceiptCounter, device.PeriodCounter = r.Number, r.DayNumber
+	return &SignedReceipt{Receipt: *r, Payload: payload, Signature: sig}, nil
+}
+
+// NewReceipt returns the fiscal content of a submitted POS invoice,
+// without its numbers. Items are grouped by their VAT rate, the sum of
+// the On Net Total rows that apply to them; any difference between the
+// groups' tax and the invoice's, from rounding, is taken up by the
+// largest group.
+func NewReceipt(inv *salesinvoice.SalesInvoice) (*Receipt, error) {
+	if !inv.IsPOS || inv.Status != salesinvoice.Submitted {
+		return nil, fmt.Errorf("%w: %s is not a submitted POS invoice", ErrNotFiscalizable, inv.Name)
+	}
+	r := &Receipt{Invoice: inv.Name, Return: inv.IsReturn}
+	for _, item := range inv.Items {
+		rate, err := vatRate(item, inv.Taxes)
+		if err != nil {
+			return nil, err
+		}
+		i := slices.IndexFunc(r.Rates, func(t RateTotal) bool { return t.Rate == rate })
+		if i < 0 {
+			r.Rates = append(r.Rates, RateTotal{Rate: rate})
+			i = len(r.Rates) - 1
+		}
+		r.Rates[i].Net += item.NetAmount
+	}
+	slices.SortFunc(r.Rates, func(a, b RateTotal) int { return cmp.Compare(b.Rate, a.Rate) })
+	largest, gross := 0, 0.0
+	for i := range r.Rates {
+		t := &r.Rates[i]
+		t.Net = ledger.Flt(t.Net, 2)
+		t.VAT = ledger.Flt(t.Net*t.Rate/100, 2)
+		t.Gross = ledger.Flt(t.Net+t.VAT, 2)
+		gross += t.Gross
+		if t.Gross > r.Rates[largest].Gross {
+			largest = i
+		}
+	}
+	if diff := ledger.Flt(inv.GrandTotal-gross, 2); diff != 0 && len(r.Rates) > 0 {
+		t := &r.Rates[largest]
+		t.VAT, t.Gross = ledger.Flt(t.VAT+diff, 2), ledger.Flt(t.Gross+diff, 2)
+	}
+	r.Total = inv.GrandTotal
+	if inv.RoundedTotal != 0 {
+		r.Total, r.Rounding = inv.RoundedTotal, inv.RoundingAdjustment
+	}
+
+	change := inv.ChangeAmount
+	for _, p := range inv.Payments {
+		amount := p.Amount
+		cash := p.Type == modeofpayment.Cash
+		if cash && change > 0 {
+			given := min(change, amount)
+			amount, change = amount-given, change-given
+		}
+		if amount == 0 {
+			continue
+		}
+		i := slices.IndexFunc(r.Payments, func(t PaymentTotal) bool { return t.ModeOfPayment == p.ModeOfPayment })
+		if i < 0 {
+			r.Payments = append(r.Payments, PaymentTotal{ModeOfPayment: p.ModeOfPayment, Cash: cash})
+			i = len(r.Payments) - 1
+		}
+		r.Payments[i].Amount = ledger.Flt(r.Payments[i].Amount+amount, 2)
+	}
+
+	if r.Return {
+		r.negate()
+	}
+	return r, nil
+}
+
+// negate turns a sale into the return of one.
+func (r *Receipt) negate() {
+	for i := range r.Rates {
+		t := &r.Rates[i]
+		t.Net, t.VAT, t.Gross = -t.Net, -t.VAT, -t.Gross
+	}
+	for i := range r.Payments {
+		r.Payments[i].Amount = -r.Payments[i].Amount
+	}
+	r.Rounding, r.Total = -r.Rounding, -r.Total
+}
+
+// vatRate returns the VAT rate of an item: the sum of the rates of the On
+// Net Total rows, each the item's own rate for the account when it has
+// one.
+func vatRate(item *taxcalc.LineItem, taxes []*taxcalc.TaxRow) (float64, error) {
+	taxMap, err := item.TaxMap()
+	if err != nil {
+		return 0, err
+	}
+	rate := 0.0
+	for _, tax := range taxes {
+		if tax.ChargeType != taxcalc.OnNetTotal {
+			continue
+		}
+		if r, ok := taxMap[tax.AccountHead]; ok {
+			rate += r
+		} else {
+			rate += tax.Rate
+		}
+	}
+	return rate, nil
+}
+
+// Ed25519Signer signs payloads in software with an Ed25519 key. It has no
+// certification of its own; it stands in for the security device in
+// tests and where a regime accepts software signing.
+type Ed25519Signer struct {
+	Key ed25519.PrivateKey
+	Now func() time.Time // time.Now when nil
+
+	mu      sync.Mutex
+	counter int64
+}
+
+// Sign implements Signer. The signature covers the counter and time it
+// reports as well as the payload, as a TSE's does.
+func (s *Ed25519Signer) Sign(_ context.Context, p Payload) (Signature, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now
+	if s.Now != nil {
+		now = s.Now
+	}
+	s.counter++
+	sig := Signature{Algorithm: "Ed25519", Counter: s.counter, Time: now().UTC()}
+	sig.Value = base64.StdEncoding.EncodeToString(ed25519.Sign(s.Key, signedMessage(p, sig)))
+	return sig, nil
+}
+
+// Verify reports whether sig is a signature of the payload by the key's
+// holder.
+func Verify(key ed25519.PublicKey, p Payload, sig Signature) bool {
+	value, err := base64.StdEncoding.DecodeString(sig.Value)
+	return err == nil && ed25519.Verify(key, signedMessage(p, sig), value)
+}
+
+func signedMessage(p Payload, sig Signature) []byte {
+	msg := fmt.Appendf(nil, "%d|%s|%s|", sig.Counter, sig.Time.Format(time.RFC3339), p.Type)
+	return append(msg, p.Data...)
+}
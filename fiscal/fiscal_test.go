@@ -0,0 +1,170 @@
+package fiscal
+
+import (
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/modeofpayment"
+	"github.com/senguttuvang/erpnext-go/salesinvoice"
+	"github.com/senguttuvang/erpnext-go/taxcalc"
+)
+
+var (
+	cash = &salesinvoice.Payment{ModeOfPayment: "Cash", Type: modeofpayment.Cash, Account: "Cash - BC"}
+	card = &salesinvoice.Payment{ModeOfPayment: "Card", Type: modeofpayment.Bank, Account: "Card Clearing - BC"}
+)
+
+// sale is a bakery café sale: coffee at the standard rate and cake to go
+// at the reduced one.
+func sale(t *testing.T, name string, coffees, cakes float64, paid *salesinvoice.Payment, amount float64) *salesinvoice.SalesInvoice {
+	t.Helper()
+	payment := *paid
+	payment.Amount = amount
+	inv := &salesinvoice.SalesInvoice{
+		Name: name, Company: "Bäckerei Café GmbH", PostingDate: time.Date(2024, 5, 2, 0, 0, 0, 0, time.UTC),
+		IsPOS: true, AccountForChangeAmount: "Cash - BC", Payments: []*salesinvoice.Payment{&payment},
+		Document: taxcalc.Document{
+			Currency: "EUR",
+			Taxes:    []*taxcalc.TaxRow{{AccountHead: "USt - BC", ChargeType: taxcalc.OnNetTotal, Rate: 19}},
+		},
+	}
+	if coffees > 0 {
+		inv.Items = append(inv.Items, &taxcalc.LineItem{ItemCode: "COFFEE", Qty: coffees, Rate: 3})
+	}
+	if cakes > 0 {
+		inv.Items = append(inv.Items, &taxcalc.LineItem{ItemCode: "CAKE", Qty: cakes, Rate: 4, ItemTaxMap: map[string]float64{"USt - BC": 7}})
+	}
+	if err := inv.Calculate(nil); err != nil {
+		t.Fatal(err)
+	}
+	inv.Status = salesinvoice.Submitted
+	return inv
+}
+
+func signer() (*Ed25519Signer, ed25519.PublicKey) {
+	public, private, _ := ed25519.GenerateKey(nil)
+	at := time.Date(2024, 5, 2, 9, 0, 0, 0, time.UTC)
+	return &Ed25519Signer{Key: private, Now: func() time.Time { at = at.Add(time.Minute); return at }}, public
+}
+
+func TestTSE(t *testing.T) {
+	s, public := signer()
+	f := &Fiscalizer{Format: TSE{}, Signer: s}
+	device := &Device{ID: "KASSE-1"}
+	ctx := context.Background()
+	issued := time.Date(2024, 5, 2, 9, 30, 0, 0, time.UTC)
+
+	first, err := f.Fiscalize(ctx, device, sale(t, "PSINV-1", 1, 1, cash, 10), issued)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(first.Payload.Data); got != "Beleg^3.57_4.28_0.00_0.00_0.00^7.85:Bar" || first.Payload.Type != "Kassenbeleg-V1" {
+		t.Errorf("payload = %s %s", first.Payload.Type, got)
+	}
+	if !Verify(public, first.Payload, first.Signature) || first.Signature.Counter != 1 {
+		t.Errorf("signature = %+v", first.Signature)
+	}
+	tampered := first.Payload
+	tampered.Data = []byte(strings.Replace(string(tampered.Data), "7.85", "6.85", 1))
+	if Verify(public, tampered, first.Signature) {
+		t.Error("tampered payload verified")
+	}
+
+	ret := sale(t, "PSINV-2", 1, 0, cash, 3.57)
+	ret.IsReturn = true
+	second, err := f.Fiscalize(ctx, device, ret, issued.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(second.Payload.Data); got != "Beleg^-3.57_0.00_0.00_0.00_0.00^-3.57:Bar" || second.Number != 2 {
+		t.Errorf("return = %d %s", second.Number, got)
+	}
+	if *device != (Device{ID: "KASSE-1", ReceiptCounter: 2, PeriodCounter: 2}) {
+		t.Errorf("device = %+v", device)
+	}
+
+	// Germany does not sign Z reports
+	z, err := f.Close(ctx, device, []SignedReceipt{*first, *second}, issued.Add(10*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if z.Signature != nil || z.Total != 4.28 || z.Receipts != 2 || z.Returns != 1 || z.Rates[0] != (RateTotal{Rate: 19, Net: 0, VAT: 0, Gross: 0}) {
+		t.Errorf("Z = %+v", z)
+	}
+
+	// Rates outside the VAT keys are refused, and the counters kept
+	odd := sale(t, "PSINV-3", 1, 0, cash, 3.57)
+	odd.Items[0].ItemTaxMap = map[string]float64{"USt - BC": 16}
+	if _, err := f.Fiscalize(ctx, device, odd, issued); !errors.Is(err, ErrUnsupportedRate) || device.ReceiptCounter != 2 {
+		t.Errorf("Fiscalize() 16%% = %v, device %+v", err, device)
+	}
+	draft := sale(t, "PSINV-4", 1, 0, cash, 3.57)
+	draft.Status = salesinvoice.Draft
+	if _, err := f.Fiscalize(ctx, device, draft, issued); !errors.Is(err, ErrNotFiscalizable) {
+		t.Errorf("Fiscalize() draft = %v", err)
+	}
+}
+
+func TestRT(t *testing.T) {
+	s, public := signer()
+	f := &Fiscalizer{Format: RT{}, Signer: s}
+	device := &Device{ID: "RT-99IEC012345", ReceiptCounter: 41, ZCounter: 11}
+	ctx := context.Background()
+	issued := time.Date(2024, 5, 2, 9, 30, 0, 0, time.UTC)
+
+	var receipts []SignedReceipt
+	for i, inv := range []*salesinvoice.SalesInvoice{
+		sale(t, "PSINV-1", 1, 1, cash, 10),
+		sale(t, "PSINV-2", 2, 0, card, 7.14),
+	} {
+		r, err := f.Fiscalize(ctx, device, inv, issued.Add(time.Duration(i)*time.Minute))
+		if err != nil {
+			t.Fatal(err)
+		}
+		receipts = append(receipts, *r)
+	}
+	want := "DC|RT-99IEC012345|0012-0002|2024-05-02T09:31:00|19.00:6.00:1.14|7.14|0.00|7.14|V"
+	if got := string(receipts[1].Payload.Data); got != want {
+		t.Errorf("record = %s, want %s", got, want)
+	}
+
+	if _, err := f.Close(ctx, device, receipts[:1], issued); !errors.Is(err, ErrReceiptOutOfPeriod) {
+		t.Errorf("Close() missing a receipt = %v", err)
+	}
+	z, err := f.Close(ctx, device, receipts, issued.Add(12*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if z.Number != 12 || z.FirstReceipt != 42 || z.LastReceipt != 43 || z.Total != 14.99 || len(z.Rates) != 2 {
+		t.Errorf("Z = %+v", z)
+	}
+	if z.Signature == nil || !Verify(public, *z.Payload, *z.Signature) {
+		t.Errorf("Z signature = %+v", z.Signature)
+	}
+	xml := string(z.Payload.Data)
+	for _, part := range []string{
+		"<NumeroChiusura>12</NumeroChiusura>",
+		"<Riepilogo><IVA><AliquotaIVA>19.00</AliquotaIVA><Imposta>1.71</Imposta></IVA><Ammontare>9.00</Ammontare></Riepilogo>",
+		"<TotaleContanti>7.85</TotaleContanti><TotaleElettronico>7.14</TotaleElettronico>",
+	} {
+		if !strings.Contains(xml, part) {
+			t.Errorf("Z XML lacks %s:\n%s", part, xml)
+		}
+	}
+
+	// The next period numbers from one again, and old receipts are refused
+	if *device != (Device{ID: "RT-99IEC012345", ReceiptCounter: 43, ZCounter: 12}) {
+		t.Errorf("device = %+v", device)
+	}
+	if _, err := f.Close(ctx, device, receipts, issued); !errors.Is(err, ErrReceiptOutOfPeriod) {
+		t.Errorf("Close() old receipts = %v", err)
+	}
+	next, _ := f.Fiscalize(ctx, device, sale(t, "PSINV-3", 1, 0, card, 3.57), issued.Add(24*time.Hour))
+	if !strings.Contains(string(next.Payload.Data), "|0013-0001|") {
+		t.Errorf("next record = %s", next.Payload.Data)
+	}
+}
@@ -0,0 +1,142 @@
+package fiscal
+
+import (
+	"encoding/xml"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultTSERates are the VAT rates of the five DSFinV-K VAT keys:
+// standard, reduced, the two flat rates for agriculture and forestry,
+// and zero.
+var DefaultTSERates = []float64{19, 7, 10.7, 5.5, 0}
+
+// TSE is the German KassenSichV format: the receipt is signed by the
+// technical security element as a "Kassenbeleg-V1" transaction, whose
+// process data lists the gross amount of each VAT key and the amounts
+// paid in cash and otherwise. Germany does not sign Z reports.
+//
+// Spec: DSFinV-K, Anhang I, processData of Kassenbeleg-V1
+type TSE struct {
+	Rates []float64 // Rates of the VAT keys in order; DefaultTSERates when nil
+}
+
+// Receipt implements Format.
+func (f TSE) Receipt(r *Receipt) (Payload, error) {
+	rates := f.Rates
+	if rates == nil {
+		rates = DefaultTSERates
+	}
+	gross := make([]string, len(rates))
+	for i := range gross {
+		gross[i] = amount(0)
+	}
+	for _, t := range r.Rates {
+		i := slices.Index(rates, t.Rate)
+		if i < 0 {
+			return Payload{}, fmt.Errorf("%w: %v%% on %s", ErrUnsupportedRate, t.Rate, r.Invoice)
+		}
+		gross[i] = amount(t.Gross)
+	}
+
+	cash, other := split(r.Payments)
+	var payments []string
+	if cash != 0 {
+		payments = append(payments, amount(cash)+":Bar")
+	}
+	if other != 0 {
+		payments = append(payments, amount(other)+":Unbar")
+	}
+	data := "Beleg^" + strings.Join(gross, "_") + "^" + strings.Join(payments, "_")
+	return Payload{Type: "Kassenbeleg-V1", Data: []byte(data)}, nil
+}
+
+// RT is the Italian registratore telematico format. Each commercial
+// document is numbered by its Z period and its place in it, as
+// "0012-0034", and recorded on one line; the daily closing is the
+// corrispettivi summary the RT transmits, by VAT rate.
+//
+// The record and summary follow the fields of the Agenzia delle Entrate
+// specifications in a simplified layout; the RT device or its
+// certified middleware produces the final transmission file.
+type RT struct{}
+
+// Receipt implements Format. The record is
+//
+//	DC|device|ZZZZ-NNNN|issued|rate:net:vat;...|total|cash|electronic|V or R
+//
+// with V for a sale and R for a return.
+func (RT) Receipt(r *Receipt) (Payload, error) {
+	rates := make([]string, len(r.Rates))
+	for i, t := range r.Rates {
+		rates[i] = amount(t.Rate) + ":" + amount(t.Net) + ":" + amount(t.VAT)
+	}
+	cash, electronic := split(r.Payments)
+	kind := "V"
+	if r.Return {
+		kind = "R"
+	}
+	fields := []string{
+		"DC", r.Device, fmt.Sprintf("%04d-%04d", r.ZNumber, r.DayNumber), r.IssuedAt.Format("2006-01-02T15:04:05"),
+		strings.Join(rates, ";"), amount(r.Total), amount(cash), amount(electronic), kind,
+	}
+	return Payload{Type: "DocumentoCommerciale", Data: []byte(strings.Join(fields, "|"))}, nil
+}
+
+// rtCorrispettivi is the daily summary of an RT.
+type rtCorrispettivi struct {
+	XMLName           xml.Name      `xml:"DatiCorrispettivi"`
+	Dispositivo       string        `xml:"Dispositivo"`
+	NumeroChiusura    int64         `xml:"NumeroChiusura"`
+	DataOraChiusura   string        `xml:"DataOraChiusura"`
+	NumeroDocumenti   int           `xml:"NumeroDocumenti"`
+	Resi              int           `xml:"Resi"`
+	Riepilogo         []rtRiepilogo `xml:"Riepilogo"`
+	TotaleContanti    string        `xml:"TotaleContanti"`
+	TotaleElettronico string        `xml:"TotaleElettronico"`
+	Arrotondamento    string        `xml:"Arrotondamento"`
+	Totale            string        `xml:"Totale"`
+}
+
+type rtRiepilogo struct {
+	AliquotaIVA string `xml:"IVA>AliquotaIVA"`
+	Imposta     string `xml:"IVA>Imposta"`
+	Ammontare   string `xml:"Ammontare"`
+}
+
+// ZReport implements ZFormat.
+func (RT) ZReport(z *ZReport) (Payload, error) {
+	doc := rtCorrispettivi{
+		Dispositivo: z.Device, NumeroChiusura: z.Number, DataOraChiusura: z.ClosedAt.Format(time.RFC3339),
+		NumeroDocumenti: z.Receipts, Resi: z.Returns, Arrotondamento: amount(z.Rounding), Totale: amount(z.Total),
+	}
+	cash, electronic := split(z.Payments)
+	doc.TotaleContanti, doc.TotaleElettronico = amount(cash), amount(electronic)
+	for _, t := range z.Rates {
+		doc.Riepilogo = append(doc.Riepilogo, rtRiepilogo{AliquotaIVA: amount(t.Rate), Imposta: amount(t.VAT), Ammontare: amount(t.Net)})
+	}
+	data, err := xml.Marshal(doc)
+	if err != nil {
+		return Payload{}, err
+	}
+	return Payload{Type: "Corrispettivi", Data: append([]byte(xml.Header), data...)}, nil
+}
+
+func amount(v float64) string {
+	return strconv.FormatFloat(v, 'f', 2, 64)
+}
+
+// split totals payments in cash and otherwise.
+func split(payments []PaymentTotal) (cash, other float64) {
+	for _, p := range payments {
+		if p.Cash {
+			cash += p.Amount
+		} else {
+			other += p.Amount
+		}
+	}
+	return cash, other
+}
@@ -0,0 +1,116 @@
+package fiscal
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/ledger"
+)
+
+// ZReport is the daily closing of a device: what it took over the period
+// since the last one, by VAT rate and by mode of payment.
+//
+// Maps to: the Z report (Kassenabschluss, chiusura giornaliera) printed
+// at the end of a POS shift
+type ZReport struct {
+	Device   string
+	Number   int64
+	ClosedAt time.Time
+
+	Receipts     int   // Receipts in the period, returns included
+	Returns      int   // Of which returns
+	FirstReceipt int64 // Device receipt numbers; zero when there were none
+	LastReceipt  int64
+
+	Rates    []RateTotal    // Highest rate first
+	Payments []PaymentTotal // By mode of payment, in order of first use
+	Rounding float64
+	Total    float64
+
+	Payload   *Payload   // Set when the format signs Z reports
+	Signature *Signature // Ditto
+}
+
+// Close closes the period of the device: it aggregates the receipts
+// fiscalized since its last Z report into the next one, signs it when the
+// format has a layout for Z reports, and starts a new period. Every
+// receipt of the period must be given, and none of another, so that the
+// report accounts for each number.
+func (f *Fiscalizer) Close(ctx context.Context, device *Device, receipts []SignedReceipt, closedAt time.Time) (*ZReport, error) {
+	if f.Format == nil || f.Signer == nil {
+		return nil, ErrNotConfigured
+	}
+	z := &ZReport{Device: device.ID, Number: device.ZCounter + 1, ClosedAt: closedAt}
+	seen := make([]bool, device.PeriodCounter)
+	for _, r := range receipts {
+		if r.Device != device.ID || r.ZNumber != z.Number || r.DayNumber < 1 || r.DayNumber > device.PeriodCounter || seen[r.DayNumber-1] {
+			return nil, fmt.Errorf("%w: receipt %d of %s, Z %d", ErrReceiptOutOfPeriod, r.Number, r.Device, r.ZNumber)
+		}
+		seen[r.DayNumber-1] = true
+		z.add(&r.Receipt)
+	}
+	if missing := slices.Index(seen, false); missing >= 0 {
+		return nil, fmt.Errorf("%w: receipt %d of Z %d is missing", ErrReceiptOutOfPeriod, missing+1, z.Number)
+	}
+	z.round()
+
+	if zf, ok := f.Format.(ZFormat); ok {
+		payload, err := zf.ZReport(z)
+		if err != nil {
+			return nil, err
+		}
+		sig, err := f.Signer.Sign(ctx, payload)
+		if err != nil {
+			return nil, fmt.Errorf("Z report %d: %w", z.Number, err)
+		}
+		z.Payload, z.Signature = &payload, &sig
+	}
+	device.ZCounter, device.PeriodCounter = z.Number, 0
+	return z, nil
+}
+
+func (z *ZReport) add(r *Receipt) {
+	z.Receipts++
+	if r.Return {
+		z.Returns++
+	}
+	if z.FirstReceipt == 0 || r.Number < z.FirstReceipt {
+		z.FirstReceipt = r.Number
+	}
+	z.LastReceipt = max(z.LastReceipt, r.Number)
+	for _, t := range r.Rates {
+		i := slices.IndexFunc(z.Rates, func(zt RateTotal) bool { return zt.Rate == t.Rate })
+		if i < 0 {
+			z.Rates = append(z.Rates, RateTotal{Rate: t.Rate})
+			i = len(z.Rates) - 1
+		}
+		z.Rates[i].Net += t.Net
+		z.Rates[i].VAT += t.VAT
+		z.Rates[i].Gross += t.Gross
+	}
+	for _, p := range r.Payments {
+		i := slices.IndexFunc(z.Payments, func(zp PaymentTotal) bool { return zp.ModeOfPayment == p.ModeOfPayment })
+		if i < 0 {
+			z.Payments = append(z.Payments, PaymentTotal{ModeOfPayment: p.ModeOfPayment, Cash: p.Cash})
+			i = len(z.Payments) - 1
+		}
+		z.Payments[i].Amount += p.Amount
+	}
+	z.Rounding += r.Rounding
+	z.Total += r.Total
+}
+
+func (z *ZReport) round() {
+	slices.SortFunc(z.Rates, func(a, b RateTotal) int { return cmp.Compare(b.Rate, a.Rate) })
+	for i := range z.Rates {
+		t := &z.Rates[i]
+		t.Net, t.VAT, t.Gross = ledger.Flt(t.Net, 2), ledger.Flt(t.VAT, 2), ledger.Flt(t.Gross, 2)
+	}
+	for i := range z.Payments {
+		z.Payments[i].Amount = ledger.Flt(z.Payments[i].Amount, 2)
+	}
+	z.Rounding, z.Total = ledger.Flt(z.Rounding, 2), ledger.Flt(z.Total, 2)
+}
@@ -0,0 +1,249 @@
+// Package fund keeps the books of a nonprofit by fund, so that money given
+// for a purpose is spent on it.
+//
+// A fund is an accounting dimension carried on GL entries as the custom
+// field Fieldname; add it to ledger.Engine.MergeFields so that entries of
+// different funds are not merged. The balance of a fund, its net assets,
+// is what its income and the transfers into it have credited to income and
+// equity accounts less what its expenses and the transfers out of it have
+// debited. Validator refuses postings that would spend a restricted fund
+// beyond its balance, Transfer moves net assets between funds, and
+// Statement reports the activities of each fund.
+//
+// Maps to: the Fund accounting dimension of an ERPNext nonprofit site
+// (Accounting Dimension doctype), with restrictions as in ASC 958 net
+// assets with and without donor restrictions
+package fund
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/senguttuvang/erpnext-go/daterange"
+	"github.com/senguttuvang/erpnext-go/ledger"
+)
+
+// Fieldname is the custom field of GL entries holding their fund.
+const Fieldname = "fund"
+
+// Fund errors
+var (
+	ErrInvalidFund      = errors.New("invalid fund")
+	ErrUnknownFund      = errors.New("unknown fund")
+	ErrInsufficientFund = errors.New("restricted fund balance is insufficient")
+	ErrNotConfigured    = errors.New("fund accounting is not configured")
+)
+
+// Restriction is how a fund's donors restricted its use.
+type Restriction string
+
+const (
+	Unrestricted          Restriction = "Unrestricted"
+	TemporarilyRestricted Restriction = "Temporarily Restricted" // Spent on its purpose, then released
+	PermanentlyRestricted Restriction = "Permanently Restricted" // An endowment; the principal is never spent
+)
+
+// Fund is a pool of net assets kept apart for a purpose.
+type Fund struct {
+	Name        string
+	Company     string
+	Restriction Restriction
+	Purpose     string
+}
+
+// Validate checks the fund.
+func (f *Fund) Validate() error {
+	if f.Name == "" || f.Company == "" {
+		return fmt.Errorf("%w: name and company are required", ErrInvalidFund)
+	}
+	switch f.Restriction {
+	case Unrestricted, TemporarilyRestricted, PermanentlyRestricted:
+		return nil
+	}
+	return fmt.Errorf("%w: %s: restriction %q", ErrInvalidFund, f.Name, f.Restriction)
+}
+
+// Restricted reports whether donors restricted the fund's use.
+func (f *Fund) Restricted() bool {
+	return f.Restriction != Unrestricted
+}
+
+// Funds are the funds of a deployment.
+type Funds []Fund
+
+// Get returns the named fund, or nil.
+func (fs Funds) Get(name string) *Fund {
+	for i := range fs {
+		if fs[i].Name == name {
+			return &fs[i]
+		}
+	}
+	return nil
+}
+
+// Of returns the fund of a GL entry, "" when it has none.
+func Of(e ledger.GLEntry) string {
+	return e.Custom.String(Fieldname)
+}
+
+// Set books a GL entry to a fund.
+func Set(e *ledger.GLEntry, fund string) {
+	e.Custom.Set(Fieldname, fund)
+}
+
+// netAssets reports whether entries on an account of a root type change a
+// fund's net assets. Assets and liabilities hold the fund's resources and
+// obligations; the balance is read from the other side.
+func netAssets(rootType string) bool {
+	return rootType == "Income" || rootType == "Expense" || rootType == "Equity"
+}
+
+// rootTypes caches the root types of accounts.
+type rootTypes struct {
+	accounts ledger.AccountLookup
+	cache    map[string]string
+}
+
+func (r *rootTypes) get(account string) (string, error) {
+	if t, ok := r.cache[account]; ok {
+		return t, nil
+	}
+	acc, err := r.accounts.GetAccount(account)
+	if err != nil {
+		return "", fmt.Errorf("account %s: %w", account, err)
+	}
+	if r.cache == nil {
+		r.cache = map[string]string{}
+	}
+	r.cache[account] = acc.RootType
+	return acc.RootType, nil
+}
+
+// Balances returns the net assets of each fund of a company from GL
+// entries posted in period, credit less debit. Entries without a fund and
+// period closing vouchers do not count. Cancelled entries do: they and
+// the reversals cancellation posted make nothing together.
+func Balances(gl []ledger.GLEntry, accounts ledger.AccountLookup, company string, period daterange.Range) (map[string]float64, error) {
+	types := &rootTypes{accounts: accounts}
+	balances := map[string]float64{}
+	for _, e := range gl {
+		fund := Of(e)
+		if fund == "" || e.Company != company || e.VoucherType == ledger.PeriodClosingVoucher || !period.Contains(e.PostingDate) {
+			continue
+		}
+		t, err := types.get(e.Account)
+		if err != nil {
+			return nil, err
+		}
+		if netAssets(t) {
+			balances[fund] += e.Credit - e.Debit
+		}
+	}
+	for fund, b := range balances {
+		balances[fund] = ledger.Flt(b, 2)
+	}
+	return balances, nil
+}
+
+// Validator keeps restricted funds from being overspent. Set it as the
+// Engine's Budget; Next, when set, is the budget validator it runs after
+// its own checks.
+//
+// Maps to: the validate() hook a nonprofit site adds to GL Entry for its
+// Fund dimension
+type Validator struct {
+	Funds    Funds
+	Accounts ledger.AccountLookup
+	GL       func() []ledger.GLEntry // GL entries posted, such as memstore.GLStore.All
+	Next     ledger.BudgetValidator  // Optional
+}
+
+// Validate implements ledger.BudgetValidator. Every fund an entry names
+// must exist for the entry's company. A voucher that lowers the net assets
+// of a restricted fund, by spending it or transferring out of it, may not
+// leave the fund below zero; a permanently restricted fund may not be
+// lowered at all. Entries of a voucher already in the ledger are its
+// cancellation, which reverses their effect on the funds.
+//
+// Errors of these checks wrap ErrUnknownFund, ErrInvalidFund or
+// ErrInsufficientFund and stop the posting whatever the Engine's
+// ValidationPolicy says of budgets.
+func (v *Validator) Validate(entries []ledger.GLEntry) error {
+	if err := v.validate(entries); err != nil {
+		return err
+	}
+	if v.Next != nil {
+		return v.Next.Validate(entries)
+	}
+	return nil
+}
+
+func (v *Validator) validate(entries []ledger.GLEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	if v.Accounts == nil || v.GL == nil {
+		return ErrNotConfigured
+	}
+	gl := v.GL()
+	sign := 1.0
+	cancelled := ledger.CancelledEntries(gl)
+	for i, e := range gl {
+		if !cancelled[i] && e.VoucherType == entries[0].VoucherType && e.VoucherNo == entries[0].VoucherNo {
+			sign = -1
+			break
+		}
+	}
+
+	types := &rootTypes{accounts: v.Accounts}
+	company := entries[0].Company
+	changes := map[string]float64{}
+	var order []string
+	cancelled = ledger.CancelledEntries(entries)
+	for i, e := range entries {
+		name := Of(e)
+		if name == "" || cancelled[i] {
+			continue
+		}
+		f := v.Funds.Get(name)
+		if f == nil {
+			return fmt.Errorf("%w: %s on %s", ErrUnknownFund, name, e.Account)
+		}
+		if f.Company != e.Company {
+			return fmt.Errorf("%w: %s belongs to %s, not %s", ErrInvalidFund, name, f.Company, e.Company)
+		}
+		t, err := types.get(e.Account)
+		if err != nil {
+			return err
+		}
+		if !netAssets(t) {
+			continue
+		}
+		if _, ok := changes[name]; !ok {
+			order = append(order, name)
+		}
+		changes[name] += sign * (e.Credit - e.Debit)
+	}
+
+	var balances map[string]float64
+	for _, name := range order {
+		f := v.Funds.Get(name)
+		change := ledger.Flt(changes[name], 2)
+		if !f.Restricted() || change >= 0 {
+			continue
+		}
+		if f.Restriction == PermanentlyRestricted {
+			return fmt.Errorf("%w: %s is permanently restricted and may not be spent", ErrInsufficientFund, name)
+		}
+		if balances == nil {
+			var err error
+			if balances, err = Balances(gl, v.Accounts, company, daterange.Range{}); err != nil {
+				return err
+			}
+		}
+		if after := ledger.Flt(balances[name]+change, 2); after < 0 {
+			return fmt.Errorf("%w: %s has %.2f, %.2f short", ErrInsufficientFund, name, balances[name], -after)
+		}
+	}
+	return nil
+}
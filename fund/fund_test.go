@@ -0,0 +1,216 @@
+package fund
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/daterange"
+	"github.com/senguttuvang/erpnext-go/ledger"
+	"github.com/senguttuvang/erpnext-go/ledger/memstore"
+	"github.com/senguttuvang/erpnext-go/reportio"
+)
+
+const (
+	bank             = "Bank - HOPE"
+	donations        = "Donations - HOPE"
+	programs         = "Program Expenses - HOPE"
+	netAssetsAccount = "Fund Balance - HOPE"
+)
+
+var funds = Funds{
+	{Name: "General", Company: "HOPE", Restriction: Unrestricted},
+	{Name: "Clean Water", Company: "HOPE", Restriction: TemporarilyRestricted, Purpose: "Wells in Marsabit"},
+	{Name: "Endowment", Company: "HOPE", Restriction: PermanentlyRestricted},
+}
+
+func date(m time.Month, d int) time.Time {
+	return time.Date(2024, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+func setup() (*ledger.Engine, *memstore.GLStore, *memstore.Accounts) {
+	accounts := memstore.NewAccounts(
+		ledger.Account{Name: bank, Company: "HOPE", RootType: "Asset"},
+		ledger.Account{Name: donations, Company: "HOPE", RootType: "Income"},
+		ledger.Account{Name: programs, Company: "HOPE", RootType: "Expense"},
+		ledger.Account{Name: netAssetsAccount, Company: "HOPE", RootType: "Equity"},
+	)
+	glStore := memstore.NewGLStore()
+	engine := &ledger.Engine{
+		GLStore: glStore, MergeFields: []string{Fieldname},
+		Budget: &Validator{Funds: funds, Accounts: accounts, GL: glStore.All},
+	}
+	return engine, glStore, accounts
+}
+
+// book posts a voucher moving amount from the credit account to the debit
+// account, both in fund.
+func book(engine *ledger.Engine, name, fund, debit, credit string, amount float64, on time.Time) error {
+	dr := ledger.GLEntry{PostingDate: on, Account: debit, VoucherType: "Journal Entry", VoucherNo: name, Company: "HOPE",
+		CostCenter: "Main - HOPE", Debit: amount, DebitInAccountCurrency: amount}
+	cr := ledger.GLEntry{PostingDate: on, Account: credit, VoucherType: "Journal Entry", VoucherNo: name, Company: "HOPE",
+		CostCenter: "Main - HOPE", Credit: amount, CreditInAccountCurrency: amount}
+	Set(&dr, fund)
+	Set(&cr, fund)
+	return engine.MakeGLEntries([]ledger.GLEntry{dr, cr}, ledger.DefaultPostingOptions())
+}
+
+func TestValidator(t *testing.T) {
+	engine, glStore, accounts := setup()
+	if err := book(engine, "JV-1", "Clean Water", bank, donations, 5000, date(1, 10)); err != nil {
+		t.Fatal(err)
+	}
+	if err := book(engine, "JV-2", "Clean Water", programs, bank, 3000, date(2, 1)); err != nil {
+		t.Fatalf("spending within the fund: %v", err)
+	}
+	if err := book(engine, "JV-3", "Clean Water", programs, bank, 2500, date(2, 15)); !errors.Is(err, ErrInsufficientFund) || !strings.Contains(err.Error(), "500.00 short") {
+		t.Errorf("overspending = %v", err)
+	}
+	if err := book(engine, "JV-4", "General", programs, bank, 2500, date(2, 15)); err != nil {
+		t.Errorf("unrestricted spending = %v", err)
+	}
+	if err := book(engine, "JV-5", "Endowment", bank, donations, 10000, date(1, 5)); err != nil {
+		t.Fatal(err)
+	}
+	if err := book(engine, "JV-6", "Endowment", programs, bank, 100, date(2, 15)); !errors.Is(err, ErrInsufficientFund) {
+		t.Errorf("spending the endowment = %v", err)
+	}
+	if err := book(engine, "JV-7", "Building", programs, bank, 100, date(2, 15)); !errors.Is(err, ErrUnknownFund) {
+		t.Errorf("unknown fund = %v", err)
+	}
+
+	// Cancelling spending gives the fund its money back; cancelling the
+	// donation already spent would overdraw it
+	cancel := ledger.DefaultPostingOptions()
+	cancel.Cancel = true
+	donation, _ := glStore.GetByVoucher("Journal Entry", "JV-1")
+	if err := engine.MakeGLEntries(donation, cancel); !errors.Is(err, ErrInsufficientFund) {
+		t.Errorf("cancelling the donation = %v", err)
+	}
+	spending, _ := glStore.GetByVoucher("Journal Entry", "JV-2")
+	if err := engine.MakeGLEntries(spending, cancel); err != nil {
+		t.Fatalf("cancelling the spending = %v", err)
+	}
+	balances, err := Balances(glStore.All(), accounts, "HOPE", daterange.Range{})
+	if err != nil || balances["Clean Water"] != 5000 || balances["General"] != -2500 || balances["Endowment"] != 10000 {
+		t.Errorf("Balances() = %v, %v", balances, err)
+	}
+
+	// A voucher cancelled with its reversals saved live, as older stores
+	// hold them, is posted anew when it is reposted
+	spent := []ledger.GLEntry{
+		{PostingDate: date(3, 1), Account: programs, VoucherType: "Journal Entry", VoucherNo: "JV-8", Company: "HOPE", Debit: 6000, IsCancelled: true},
+		{PostingDate: date(3, 1), Account: bank, VoucherType: "Journal Entry", VoucherNo: "JV-8", Company: "HOPE", Credit: 6000, IsCancelled: true},
+		{PostingDate: date(3, 1), Account: programs, VoucherType: "Journal Entry", VoucherNo: "JV-8", Company: "HOPE", Credit: 6000},
+		{PostingDate: date(3, 1), Account: bank, VoucherType: "Journal Entry", VoucherNo: "JV-8", Company: "HOPE", Debit: 6000},
+	}
+	for i := range spent {
+		Set(&spent[i], "Clean Water")
+	}
+	if err := glStore.SaveBatch(spent); err != nil {
+		t.Fatal(err)
+	}
+	if err := book(engine, "JV-8", "Clean Water", programs, bank, 6000, date(3, 1)); !errors.Is(err, ErrInsufficientFund) {
+		t.Errorf("reposting a cancelled overspending = %v", err)
+	}
+}
+
+func TestTransferAndStatement(t *testing.T) {
+	engine, glStore, accounts := setup()
+	for _, err := range []error{
+		book(engine, "JV-1", "Clean Water", bank, donations, 5000, date(1, 10)),
+		book(engine, "JV-2", "General", bank, donations, 8000, date(1, 20)),
+		book(engine, "JV-3", "Clean Water", programs, bank, 4000, date(2, 5)),
+	} {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The project is done: release what is left to general use
+	release := &Transfer{
+		Name: "JV-4", Company: "HOPE", PostingDate: date(3, 31), From: "Clean Water", To: "General",
+		Amount: 1000, Account: netAssetsAccount, CostCenter: "Main - HOPE",
+	}
+	if err := release.Post(engine, funds); err != nil {
+		t.Fatal(err)
+	}
+	entries, _ := glStore.GetByVoucher(VoucherType, "JV-4")
+	if len(entries) != 2 || Of(entries[0]) != "Clean Water" || entries[0].Debit != 1000 || Of(entries[1]) != "General" ||
+		entries[1].VoucherSubtype != VoucherSubtype || entries[1].Remarks != "Transfer from Clean Water to General" {
+		t.Errorf("transfer entries = %+v", entries)
+	}
+	again := *release
+	again.Name = "JV-5"
+	if err := again.Post(engine, funds); !errors.Is(err, ErrInsufficientFund) {
+		t.Errorf("releasing more than is left = %v", err)
+	}
+	fromEndowment := Transfer{Name: "JV-6", Company: "HOPE", From: "Endowment", To: "General", Amount: 1, Account: netAssetsAccount}
+	if err := fromEndowment.Validate(funds); !errors.Is(err, ErrInsufficientFund) {
+		t.Errorf("transfer out of the endowment = %v", err)
+	}
+	if err := release.Post(&ledger.Engine{GLStore: glStore}, funds); !errors.Is(err, ErrNotConfigured) {
+		t.Errorf("Post() merging funds = %v", err)
+	}
+
+	// Untagged spending is unrestricted activity
+	untagged := []ledger.GLEntry{
+		{PostingDate: date(3, 3), Account: programs, VoucherType: "Journal Entry", VoucherNo: "JV-7", Company: "HOPE",
+			CostCenter: "Main - HOPE", Debit: 300, DebitInAccountCurrency: 300},
+		{PostingDate: date(3, 3), Account: bank, VoucherType: "Journal Entry", VoucherNo: "JV-7", Company: "HOPE",
+			CostCenter: "Main - HOPE", Credit: 300, CreditInAccountCurrency: 300},
+	}
+	if err := engine.MakeGLEntries(untagged, ledger.DefaultPostingOptions()); err != nil {
+		t.Fatal(err)
+	}
+
+	// An opening entry within the period is not activity unless asked for
+	opening := ledger.GLEntry{PostingDate: date(2, 1), Account: netAssetsAccount, VoucherType: "Journal Entry", VoucherNo: "JV-8",
+		Company: "HOPE", Credit: 2000, CreditInAccountCurrency: 2000, IsOpening: ledger.IsOpeningYes}
+	Set(&opening, "Endowment")
+	gl := append(glStore.All(), opening)
+	q1 := daterange.Inclusive(date(2, 1), date(3, 31))
+	if rows, err := Statement(funds, gl, accounts, "HOPE", q1, ledger.ReportFlags{IncludeOpening: true}); err != nil || rows[2].Transfers != 2000 {
+		t.Errorf("with opening entries: %+v, %v", rows, err)
+	}
+
+	rows, err := Statement(funds, gl, accounts, "HOPE", q1, ledger.ReportFlags{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Row{
+		{Fund: "General", Restriction: Unrestricted, Opening: 8000, Transfers: 1000, Closing: 9000},
+		{Fund: "Clean Water", Restriction: TemporarilyRestricted, Opening: 5000, Expense: 4000, Transfers: -1000, Closing: 0},
+		{Fund: "Endowment", Restriction: PermanentlyRestricted},
+		{Fund: "", Restriction: Unrestricted, Expense: 300, Closing: -300},
+	}
+	if len(rows) != len(want) {
+		t.Fatalf("Statement() = %+v", rows)
+	}
+	for i := range want {
+		if rows[i] != want[i] {
+			t.Errorf("row %d = %+v, want %+v", i, rows[i], want[i])
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := reportio.WriteCSV(&buf, Report(rows)); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "Clean Water,Temporarily Restricted,5000.00,0.00,4000.00,-1000.00,0.00") {
+		t.Errorf("CSV = %s", buf.String())
+	}
+}
+
+func TestFundValidate(t *testing.T) {
+	for _, f := range []Fund{{Name: "X"}, {Name: "X", Company: "HOPE", Restriction: "Designated"}} {
+		if err := f.Validate(); !errors.Is(err, ErrInvalidFund) {
+			t.Errorf("Validate(%+v) = %v", f, err)
+		}
+	}
+	if err := (&Validator{}).Validate([]ledger.GLEntry{{}}); !errors.Is(err, ErrNotConfigured) {
+		t.Errorf("Validate() unconfigured = %v", err)
+	}
+}
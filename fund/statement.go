@@ -0,0 +1,129 @@
+package fund
+
+import (
+	"github.com/senguttuvang/erpnext-go/daterange"
+	"github.com/senguttuvang/erpnext-go/ledger"
+	"github.com/senguttuvang/erpnext-go/reportio"
+)
+
+// Row is the activity of one fund over a period.
+type Row struct {
+	Fund        string // "" for activity booked to no fund
+	Restriction Restriction
+	Opening     float64 // Net assets at the start of the period
+	Income      float64
+	Expense     float64
+	Transfers   float64 // Net of transfers in and out
+	Closing     float64
+}
+
+// Statement returns the statement of activities of a company's funds over
+// period, in the order of funds. Income and expense of the period booked
+// to no fund is reported last as unrestricted, without opening net assets,
+// when there is any; so is activity of funds not listed. Opening and
+// period closing entries of the period count only as flags say; opening
+// net assets are a balance as at the start, which every earlier entry
+// makes up. Cancelled entries count, against their reversals.
+//
+// Maps to: the Profit and Loss Statement and Balance Sheet of
+// erpnext/accounts/report filtered by the Fund dimension, side by side
+func Statement(funds Funds, gl []ledger.GLEntry, accounts ledger.AccountLookup, company string, period daterange.Range, flags ledger.ReportFlags) ([]Row, error) {
+	types := &rootTypes{accounts: accounts}
+	rows := map[string]*Row{}
+	var extra []string
+	row := func(fund string) *Row {
+		r, ok := rows[fund]
+		if !ok {
+			r = &Row{Fund: fund, Restriction: Unrestricted}
+			if f := funds.Get(fund); f != nil && f.Company == company {
+				r.Restriction = f.Restriction
+			} else {
+				extra = append(extra, fund)
+			}
+			rows[fund] = r
+		}
+		return r
+	}
+	for _, f := range funds {
+		if f.Company == company {
+			row(f.Name)
+		}
+	}
+
+	for _, e := range gl {
+		if e.Company != company || period.Compare(e.PostingDate) > 0 ||
+			period.Contains(e.PostingDate) && !flags.Counts(&e) {
+			continue
+		}
+		t, err := types.get(e.Account)
+		if err != nil {
+			return nil, err
+		}
+		fund := Of(e)
+		// Untagged equity is the general ledger's own; only its income and
+		// expense make up the unrestricted activity
+		if !netAssets(t) || fund == "" && (t == "Equity" || period.Compare(e.PostingDate) < 0) {
+			continue
+		}
+		r := row(fund)
+		amount := e.Credit - e.Debit
+		switch {
+		case period.Compare(e.PostingDate) < 0:
+			r.Opening += amount
+		case t == "Income":
+			r.Income += amount
+		case t == "Expense":
+			r.Expense -= amount
+		default:
+			r.Transfers += amount
+		}
+	}
+
+	var out []Row
+	for _, f := range funds {
+		if f.Company == company {
+			out = append(out, *rows[f.Name])
+		}
+	}
+	for _, fund := range extra {
+		out = append(out, *rows[fund])
+	}
+	for i := range out {
+		r := &out[i]
+		r.Opening, r.Income, r.Expense, r.Transfers = ledger.Flt(r.Opening, 2), ledger.Flt(r.Income, 2), ledger.Flt(r.Expense, 2), ledger.Flt(r.Transfers, 2)
+		r.Closing = ledger.Flt(r.Opening+r.Income-r.Expense+r.Transfers, 2)
+	}
+	return out, nil
+}
+
+// Report presents statement rows as a downloadable report.
+func Report(rows []Row) reportio.Report {
+	return report(rows)
+}
+
+type report []Row
+
+func (report) Title() string { return "Statement of Activities by Fund" }
+
+func (report) Columns() []reportio.Column {
+	return []reportio.Column{
+		{Fieldname: "fund", Label: "Fund", Fieldtype: reportio.Link, Options: "Fund"},
+		{Fieldname: "restriction", Label: "Restriction", Fieldtype: reportio.Data},
+		{Fieldname: "opening", Label: "Opening Net Assets", Fieldtype: reportio.Currency},
+		{Fieldname: "income", Label: "Income", Fieldtype: reportio.Currency},
+		{Fieldname: "expense", Label: "Expense", Fieldtype: reportio.Currency},
+		{Fieldname: "transfers", Label: "Transfers", Fieldtype: reportio.Currency},
+		{Fieldname: "closing", Label: "Closing Net Assets", Fieldtype: reportio.Currency},
+	}
+}
+
+func (r report) Rows(fn func([]any) error) error {
+	for _, row := range r {
+		if err := fn([]any{
+			row.Fund, string(row.Restriction), row.Opening, row.Income, row.Expense, row.Transfers, row.Closing,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
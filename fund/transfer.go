@@ -0,0 +1,110 @@
+package fund
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/ledger"
+)
+
+// Voucher type and subtype of inter-fund transfers
+const (
+	VoucherType    = "Journal Entry"
+	VoucherSubtype = "Inter Fund Transfer"
+)
+
+// Transfer moves net assets from one fund to another: releasing a
+// temporarily restricted fund whose purpose was met, or designating
+// unrestricted money for a purpose. Both sides are booked to one net
+// assets account; only the fund tells them apart.
+//
+// Maps to: a Journal Entry between fund dimensions on the fund balance
+// account
+type Transfer struct {
+	Name        string
+	Company     string
+	PostingDate time.Time
+	From        string // Fund debited
+	To          string // Fund credited
+	Amount      float64
+	Account     string // Net assets (fund balance) equity account
+	CostCenter  string
+	Remarks     string
+}
+
+// Validate checks the transfer against the funds. A permanently
+// restricted fund cannot be transferred out of.
+func (t *Transfer) Validate(funds Funds) error {
+	switch {
+	case t.Name == "" || t.Company == "" || t.Account == "":
+		return fmt.Errorf("%w: name, company and account are required", ErrInvalidFund)
+	case t.From == t.To:
+		return fmt.Errorf("%w: %s: transfer from %s to itself", ErrInvalidFund, t.Name, t.From)
+	case t.Amount <= 0:
+		return fmt.Errorf("%w: %s: amount must be greater than zero", ErrInvalidFund, t.Name)
+	}
+	for _, name := range []string{t.From, t.To} {
+		f := funds.Get(name)
+		if f == nil {
+			return fmt.Errorf("%w: %s", ErrUnknownFund, name)
+		}
+		if f.Company != t.Company {
+			return fmt.Errorf("%w: %s belongs to %s, not %s", ErrInvalidFund, name, f.Company, t.Company)
+		}
+	}
+	if funds.Get(t.From).Restriction == PermanentlyRestricted {
+		return fmt.Errorf("%w: %s is permanently restricted and may not be spent", ErrInsufficientFund, t.From)
+	}
+	return nil
+}
+
+// GLMap returns the transfer's GL entries: a debit of the net assets
+// account in the fund it leaves and a credit in the fund it joins.
+func (t *Transfer) GLMap() []ledger.GLEntry {
+	date := ledger.CivilDate(t.PostingDate)
+	remarks := t.Remarks
+	if remarks == "" {
+		remarks = fmt.Sprintf("Transfer from %s to %s", t.From, t.To)
+	}
+	from := t.newGLEntry(date, t.From, remarks)
+	from.Debit, from.DebitInAccountCurrency, from.DebitInTransactionCurrency = t.Amount, t.Amount, t.Amount
+	to := t.newGLEntry(date, t.To, remarks)
+	to.Credit, to.CreditInAccountCurrency, to.CreditInTransactionCurrency = t.Amount, t.Amount, t.Amount
+	return []ledger.GLEntry{from, to}
+}
+
+// Post validates the transfer and posts it. The engine must keep funds
+// apart when merging, or the two sides would cancel out; whether the fund
+// left may spend the amount is for the engine's Validator to decide.
+func (t *Transfer) Post(engine *ledger.Engine, funds Funds) error {
+	if !slices.Contains(engine.MergeFields, Fieldname) {
+		return fmt.Errorf("%w: engine does not merge by %s", ErrNotConfigured, Fieldname)
+	}
+	if err := t.Validate(funds); err != nil {
+		return err
+	}
+	if err := engine.MakeGLEntries(t.GLMap(), ledger.DefaultPostingOptions()); err != nil {
+		return fmt.Errorf("%s: %w", t.Name, err)
+	}
+	return nil
+}
+
+func (t *Transfer) newGLEntry(date time.Time, fund, remarks string) ledger.GLEntry {
+	e := ledger.GLEntry{
+		PostingDate:             date,
+		TransactionDate:         date,
+		Account:                 t.Account,
+		VoucherType:             VoucherType,
+		VoucherNo:               t.Name,
+		VoucherSubtype:          VoucherSubtype,
+		Company:                 t.Company,
+		CostCenter:              t.CostCenter,
+		TransactionExchangeRate: 1,
+		IsOpening:               ledger.IsOpeningNo,
+		IsAdvance:               ledger.IsAdvanceNo,
+		Remarks:                 remarks,
+	}
+	Set(&e, fund)
+	return e
+}
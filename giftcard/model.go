@@ -0,0 +1,100 @@
+// Package giftcard implements gift card and store credit accounting.
+//
+// Selling a gift card creates a liability: the cash is received but no
+// revenue is earned until the card is redeemed. Redemption settles a
+// customer's invoice against that liability, and unused balances are
+// released to income when a card expires (breakage).
+//
+// ERPNext has no gift card doctype; postings follow the Journal Entry
+// conventions of erpnext/accounts/doctype/journal_entry.
+package giftcard
+
+import (
+	"errors"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/ledger"
+)
+
+// VoucherType is the voucher type used on gift card GL entries.
+const VoucherType = "Gift Card"
+
+// Gift card errors
+var (
+	ErrCardNotFound        = errors.New("gift card not found")
+	ErrCardExists          = errors.New("gift card already exists")
+	ErrCardExpired         = errors.New("gift card has expired")
+	ErrCardInactive        = errors.New("gift card is not active")
+	ErrInsufficientBalance = errors.New("insufficient gift card balance")
+	ErrInvalidAmount       = errors.New("amount must be greater than zero")
+	ErrMissingAccount      = errors.New("gift card account is not configured")
+)
+
+// Status is the lifecycle state of a card.
+type Status string
+
+const (
+	Active   Status = "Active"
+	Redeemed Status = "Redeemed" // Balance fully used
+	Expired  Status = "Expired"
+)
+
+// TransactionType classifies a movement on a card.
+type TransactionType string
+
+const (
+	TypeIssue      TransactionType = "Issue"
+	TypeRedemption TransactionType = "Redemption"
+	TypeExpiry     TransactionType = "Expiry"
+)
+
+// Card is a gift card or store credit balance.
+type Card struct {
+	Code       string
+	Company    string
+	Customer   string // Optional; store credit is tied to a customer
+	Currency   string
+	IssueDate  time.Time
+	ExpiryDate *time.Time // Nil for cards that never expire
+
+	Amount  float64 // Face value at issue
+	Balance float64 // Remaining liability
+	Status  Status
+
+	Transactions []Transaction
+}
+
+// Transaction records a change in a card's balance.
+type Transaction struct {
+	Type        TransactionType
+	Date        time.Time
+	VoucherType string
+	VoucherNo   string
+	Amount      float64 // Signed change to the balance
+	Against     string  // Invoice settled by a redemption
+}
+
+// IsExpired returns true if the card cannot be used on the given date.
+// A card is valid through its expiry date.
+func (c *Card) IsExpired(date time.Time) bool {
+	if c.ExpiryDate == nil {
+		return false
+	}
+	return ledger.CivilDate(date).After(ledger.CivilDate(*c.ExpiryDate))
+}
+
+// Store abstracts gift card persistence.
+type Store interface {
+	// Get returns a card by code, or ErrCardNotFound.
+	Get(code string) (*Card, error)
+
+	// Save creates or replaces a card.
+	Save(card *Card) error
+}
+
+// Accounts configures the ledger accounts used for a company's cards.
+type Accounts struct {
+	Liability  string // Gift card liability (balance sheet)
+	Breakage   string // Income recognized from expired balances
+	CostCenter string
+}
@@ -0,0 +1,185 @@
+package giftcard
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/ledger"
+)
+
+// Service issues, redeems and expires gift cards, posting each movement
+// through the ledger engine.
+type Service struct {
+	Store    Store
+	Engine   *ledger.Engine
+	Accounts Accounts
+}
+
+// Redemption describes an invoice settled with a gift card.
+type Redemption struct {
+	Code        string
+	Date        time.Time
+	Amount      float64
+	Customer    string
+	DebitTo     string // Receivable account of the invoice
+	VoucherType string // Usually "Sales Invoice"
+	VoucherNo   string
+}
+
+// Issue sells a new card: the payment account is debited and the gift card
+// liability credited with the face value.
+func (s *Service) Issue(card *Card, paymentAccount string) error {
+	if card.Amount <= 0 {
+		return ErrInvalidAmount
+	}
+	if s.Accounts.Liability == "" {
+		return ErrMissingAccount
+	}
+	_, err := s.Store.Get(card.Code)
+	if err == nil {
+		return fmt.Errorf("%w: %s", ErrCardExists, card.Code)
+	}
+	if !errors.Is(err, ErrCardNotFound) {
+		return err
+	}
+
+	card.Amount = ledger.Flt(card.Amount, 2)
+	card.Balance = card.Amount
+	card.Status = Active
+	card.Transactions = nil
+	voucherNo := card.nextVoucherNo()
+
+	glMap := []ledger.GLEntry{
+		s.newGLEntry(card, card.IssueDate, voucherNo, paymentAccount, card.Amount, 0),
+		s.newGLEntry(card, card.IssueDate, voucherNo, s.Accounts.Liability, 0, card.Amount),
+	}
+	glMap[0].Against = s.Accounts.Liability
+	glMap[1].Against = paymentAccount
+
+	if err := s.Engine.MakeGLEntries(glMap, ledger.DefaultPostingOptions()); err != nil {
+		return err
+	}
+
+	card.record(Transaction{Type: TypeIssue, Date: card.IssueDate, VoucherType: VoucherType, VoucherNo: voucherNo, Amount: card.Amount})
+	return s.Store.Save(card)
+}
+
+// Redeem settles an invoice with a card: the liability is debited and the
+// customer's receivable credited against the invoice, reducing its
+// outstanding amount.
+func (s *Service) Redeem(r Redemption) error {
+	if r.Amount <= 0 {
+		return ErrInvalidAmount
+	}
+	card, err := s.Store.Get(r.Code)
+	if err != nil {
+		return err
+	}
+	if card.IsExpired(r.Date) {
+		return fmt.Errorf("%w: %s expired on %s", ErrCardExpired, card.Code, card.ExpiryDate.Format(ledger.DateLayout))
+	}
+	if card.Status != Active {
+		return fmt.Errorf("%w: %s is %s", ErrCardInactive, card.Code, card.Status)
+	}
+
+	amount := ledger.Flt(r.Amount, 2)
+	if amount > card.Balance {
+		return fmt.Errorf("%w: %s has %.2f, %.2f requested", ErrInsufficientBalance, card.Code, card.Balance, amount)
+	}
+
+	voucherNo := card.nextVoucherNo()
+	liability := s.newGLEntry(card, r.Date, voucherNo, s.Accounts.Liability, amount, 0)
+	liability.Against = r.Customer
+
+	receivable := s.newGLEntry(card, r.Date, voucherNo, r.DebitTo, 0, amount)
+	receivable.PartyType = "Customer"
+	receivable.Party = r.Customer
+	receivable.Against = s.Accounts.Liability
+	receivable.AgainstVoucherType = r.VoucherType
+	receivable.AgainstVoucher = r.VoucherNo
+
+	if err := s.Engine.MakeGLEntries([]ledger.GLEntry{liability, receivable}, ledger.DefaultPostingOptions()); err != nil {
+		return err
+	}
+
+	card.Balance = ledger.Flt(card.Balance-amount, 2)
+	if card.Balance == 0 {
+		card.Status = Redeemed
+	}
+	card.record(Transaction{
+		Type: TypeRedemption, Date: r.Date, VoucherType: VoucherType, VoucherNo: voucherNo,
+		Amount: -amount, Against: r.VoucherNo,
+	})
+	return s.Store.Save(card)
+}
+
+// Expire releases the remaining balance of a card that has expired as of
+// the given date to the breakage income account. Cards that have not
+// expired or have no balance left are left unchanged.
+func (s *Service) Expire(code string, asOf time.Time) error {
+	card, err := s.Store.Get(code)
+	if err != nil {
+		return err
+	}
+	if card.Status != Active || !card.IsExpired(asOf) {
+		return nil
+	}
+
+	if card.Balance > 0 {
+		if s.Accounts.Breakage == "" {
+			return ErrMissingAccount
+		}
+		voucherNo := card.nextVoucherNo()
+		glMap := []ledger.GLEntry{
+			s.newGLEntry(card, asOf, voucherNo, s.Accounts.Liability, card.Balance, 0),
+			s.newGLEntry(card, asOf, voucherNo, s.Accounts.Breakage, 0, card.Balance),
+		}
+		glMap[0].Against = s.Accounts.Breakage
+		glMap[1].Against = s.Accounts.Liability
+
+		if err := s.Engine.MakeGLEntries(glMap, ledger.DefaultPostingOptions()); err != nil {
+			return err
+		}
+		card.record(Transaction{Type: TypeExpiry, Date: asOf, VoucherType: VoucherType, VoucherNo: voucherNo, Amount: -card.Balance})
+		card.Balance = 0
+	}
+
+	card.Status = Expired
+	return s.Store.Save(card)
+}
+
+// newGLEntry returns a company-currency entry for a card movement.
+func (s *Service) newGLEntry(card *Card, date time.Time, voucherNo, account string, debit, credit float64) ledger.GLEntry {
+	return ledger.GLEntry{
+		PostingDate:                 date,
+		TransactionDate:             date,
+		Account:                     account,
+		AccountCurrency:             card.Currency,
+		VoucherType:                 VoucherType,
+		VoucherNo:                   voucherNo,
+		Company:                     card.Company,
+		CostCenter:                  s.Accounts.CostCenter,
+		Debit:                       debit,
+		Credit:                      credit,
+		DebitInAccountCurrency:      debit,
+		CreditInAccountCurrency:     credit,
+		TransactionCurrency:         card.Currency,
+		TransactionExchangeRate:     1,
+		DebitInTransactionCurrency:  debit,
+		CreditInTransactionCurrency: credit,
+		IsOpening:                   ledger.IsOpeningNo,
+		IsAdvance:                   ledger.IsAdvanceNo,
+		Remarks:                     "Gift card " + card.Code,
+	}
+}
+
+// nextVoucherNo numbers the card's movements: CODE/1 is the issue,
+// CODE/2 the first redemption, and so on.
+func (c *Card) nextVoucherNo() string {
+	return fmt.Sprintf("%s/%d", c.Code, len(c.Transactions)+1)
+}
+
+func (c *Card) record(t Transaction) {
+	c.Transactions = append(c.Transactions, t)
+}
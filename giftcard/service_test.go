@@ -0,0 +1,155 @@
+package giftcard
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/ledger"
+	"github.com/senguttuvang/erpnext-go/ledger/memstore"
+)
+
+// mockStore keeps cards in a map.
+type mockStore map[string]*Card
+
+func (m mockStore) Get(code string) (*Card, error) {
+	card, ok := m[code]
+	if !ok {
+		return nil, ErrCardNotFound
+	}
+	return card, nil
+}
+
+func (m mockStore) Save(card *Card) error {
+	m[card.Code] = card
+	return nil
+}
+
+func date(y int, m time.Month, d int) time.Time {
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+func newService() (*Service, *memstore.GLStore, *memstore.PaymentStore) {
+	glStore := memstore.NewGLStore()
+	paymentStore := memstore.NewPaymentStore()
+	return &Service{
+		Store:  mockStore{},
+		Engine: &ledger.Engine{GLStore: glStore, PaymentStore: paymentStore},
+		Accounts: Accounts{
+			Liability:  "Gift Card Liability - ACME",
+			Breakage:   "Gift Card Breakage - ACME",
+			CostCenter: "Main - ACME",
+		},
+	}, glStore, paymentStore
+}
+
+func balances(entries []ledger.GLEntry) map[string]float64 {
+	result := make(map[string]float64)
+	for _, e := range entries {
+		result[e.Account] += e.Debit - e.Credit
+	}
+	return result
+}
+
+func TestIssueRedeemExpire(t *testing.T) {
+	svc, glStore, paymentStore := newService()
+	expiry := date(2024, 12, 31)
+
+	card := &Card{Code: "GC-0001", Company: "ACME", Currency: "INR", IssueDate: date(2024, 1, 10), ExpiryDate: &expiry, Amount: 100}
+	if err := svc.Issue(card, "Cash - ACME"); err != nil {
+		t.Fatalf("Issue() error = %v", err)
+	}
+	if err := svc.Issue(&Card{Code: "GC-0001", Amount: 5}, "Cash - ACME"); !errors.Is(err, ErrCardExists) {
+		t.Errorf("Expected ErrCardExists, got %v", err)
+	}
+
+	redemption := Redemption{
+		Code: "GC-0001", Date: date(2024, 3, 1), Amount: 60,
+		Customer: "Acme Corporation", DebitTo: "Debtors - ACME",
+		VoucherType: "Sales Invoice", VoucherNo: "SINV-0001",
+	}
+	if err := svc.Redeem(redemption); err != nil {
+		t.Fatalf("Redeem() error = %v", err)
+	}
+	redemption.Amount = 50
+	if err := svc.Redeem(redemption); !errors.Is(err, ErrInsufficientBalance) {
+		t.Errorf("Expected ErrInsufficientBalance, got %v", err)
+	}
+
+	// Not yet expired: nothing happens
+	if err := svc.Expire("GC-0001", expiry); err != nil || card.Status != Active {
+		t.Fatalf("Expire() before expiry = %v, status %s", err, card.Status)
+	}
+	if err := svc.Expire("GC-0001", expiry.AddDate(0, 0, 1)); err != nil {
+		t.Fatalf("Expire() error = %v", err)
+	}
+
+	if card.Balance != 0 || card.Status != Expired || len(card.Transactions) != 3 {
+		t.Errorf("card = balance %.2f, %s, %d transactions", card.Balance, card.Status, len(card.Transactions))
+	}
+	if card.Transactions[1].VoucherNo != "GC-0001/2" || card.Transactions[1].Against != "SINV-0001" {
+		t.Errorf("redemption transaction = %+v", card.Transactions[1])
+	}
+
+	got := balances(glStore.All())
+	expected := map[string]float64{
+		"Cash - ACME":                100,
+		"Gift Card Liability - ACME": 0,
+		"Debtors - ACME":             -60,
+		"Gift Card Breakage - ACME":  -40,
+	}
+	for account, want := range expected {
+		if got[account] != want {
+			t.Errorf("%s balance = %.2f, want %.2f", account, got[account], want)
+		}
+	}
+
+	// The redemption reduces the invoice's outstanding in the payment ledger
+	ple := paymentStore.All()
+	if len(ple) != 1 || ple[0].AgainstVoucherNo != "SINV-0001" || ple[0].Amount != -60 {
+		t.Errorf("payment ledger = %+v", ple)
+	}
+
+	redemption.Amount = 10
+	if err := svc.Redeem(redemption); !errors.Is(err, ErrCardExpired) && !errors.Is(err, ErrCardInactive) {
+		t.Errorf("Expected redemption of expired card to fail, got %v", err)
+	}
+}
+
+func TestRedeem_Expired(t *testing.T) {
+	svc, _, _ := newService()
+	expiry := date(2024, 6, 30)
+	card := &Card{Code: "GC-0002", Company: "ACME", Currency: "INR", IssueDate: date(2024, 1, 1), ExpiryDate: &expiry, Amount: 50}
+	if err := svc.Issue(card, "Cash - ACME"); err != nil {
+		t.Fatalf("Issue() error = %v", err)
+	}
+
+	err := svc.Redeem(Redemption{Code: "GC-0002", Date: date(2024, 7, 1), Amount: 10, Customer: "Acme Corporation", DebitTo: "Debtors - ACME"})
+	if !errors.Is(err, ErrCardExpired) {
+		t.Errorf("Expected ErrCardExpired, got %v", err)
+	}
+	// Valid through the expiry date itself
+	err = svc.Redeem(Redemption{Code: "GC-0002", Date: expiry.Add(23 * time.Hour), Amount: 50, Customer: "Acme Corporation", DebitTo: "Debtors - ACME"})
+	if err != nil || card.Status != Redeemed {
+		t.Errorf("Redeem() on expiry date = %v, status %s", err, card.Status)
+	}
+}
+
+// offlineStore is a card store that cannot be reached.
+type offlineStore struct{ mockStore }
+
+func (offlineStore) Get(code string) (*Card, error) {
+	return nil, errors.New("card store offline")
+}
+
+func TestIssue_StoreFails(t *testing.T) {
+	svc, glStore, _ := newService()
+	svc.Store = offlineStore{mockStore{}}
+	card := &Card{Code: "GC-0003", Company: "ACME", Currency: "INR", IssueDate: date(2024, 1, 1), Amount: 50}
+	if err := svc.Issue(card, "Cash - ACME"); err == nil || errors.Is(err, ErrCardExists) {
+		t.Errorf("Issue() error = %v, want the store's", err)
+	}
+	if n := len(glStore.All()); n != 0 {
+		t.Errorf("issued with %d entries on an unreachable store", n)
+	}
+}
@@ -0,0 +1,268 @@
+// Package grossprofit builds the Gross Profit report: for each sales
+// invoice item, what it sold for against what it cost to buy, summed by
+// invoice, item, customer or territory.
+//
+// The buying cost of an item is its valuation rate at the time of sale,
+// from the stock ledger, or else its last purchase rate. Items with
+// neither are reported at zero cost, as ERPNext does.
+//
+// Migrated from: erpnext/accounts/report/gross_profit/gross_profit.py
+package grossprofit
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/daterange"
+	"github.com/senguttuvang/erpnext-go/ledger"
+	"github.com/senguttuvang/erpnext-go/party"
+	"github.com/senguttuvang/erpnext-go/reportio"
+	"github.com/senguttuvang/erpnext-go/salesinvoice"
+)
+
+// ErrUnknownGroupBy is returned for an unsupported grouping.
+var ErrUnknownGroupBy = errors.New("unknown gross profit grouping")
+
+// CostSource provides buying rates per stock unit, in company currency.
+// Production implementations read the stock ledger and the purchase
+// receipts and invoices.
+type CostSource interface {
+	// ValuationRate returns the item's valuation rate in a company on a
+	// date, and false if the item has no stock ledger entries by then.
+	ValuationRate(company, itemCode string, date time.Time) (float64, bool, error)
+
+	// LastPurchaseRate returns the rate of the item's latest purchase in a
+	// company, and false if it was never bought.
+	LastPurchaseRate(company, itemCode string) (float64, bool, error)
+}
+
+// Cost sources reported on each line.
+const (
+	SourceValuationRate    = "Valuation Rate"
+	SourceLastPurchaseRate = "Last Purchase Rate"
+)
+
+// Line is one invoice item with its buying cost.
+// Amounts are in company currency, quantities in stock units.
+type Line struct {
+	Invoice     string
+	PostingDate time.Time
+	Customer    string
+	Territory   string
+	ItemCode    string
+	Qty         float64
+
+	SellingRate   float64
+	SellingAmount float64 // Net of discounts and taxes
+	BuyingRate    float64
+	BuyingAmount  float64
+	GrossProfit   float64
+	CostSource    string // SourceValuationRate, SourceLastPurchaseRate or "" when unknown
+}
+
+// Filter selects the invoices of the report.
+type Filter struct {
+	Company string
+	Period  daterange.Range
+}
+
+// Lines costs every item of the submitted invoices matching the filter.
+// Customers' territories come from parties; it may be nil when the report
+// is not grouped by territory.
+//
+// Maps to: GrossProfitGenerator.process() in gross_profit.py
+func Lines(invoices []*salesinvoice.SalesInvoice, costs CostSource, parties party.PartyLookup, filter Filter) ([]Line, error) {
+	territories := map[string]string{}
+	var lines []Line
+	for _, inv := range invoices {
+		if inv.Status != salesinvoice.Submitted || inv.Company != filter.Company || !filter.Period.Contains(inv.PostingDate) {
+			continue
+		}
+		territory, ok := territories[inv.Customer]
+		if !ok && parties != nil {
+			p, err := parties.GetParty(party.Customer, inv.Customer)
+			if err != nil {
+				return nil, fmt.Errorf("customer %s: %w", inv.Customer, err)
+			}
+			territory = p.Territory
+			territories[inv.Customer] = territory
+		}
+		for _, item := range inv.Items {
+			l := Line{
+				Invoice:       inv.Name,
+				PostingDate:   inv.PostingDate,
+				Customer:      inv.Customer,
+				Territory:     territory,
+				ItemCode:      item.ItemCode,
+				Qty:           stockQty(item.StockQty, item.Qty, item.ConversionFactor),
+				SellingAmount: item.BaseNetAmount,
+			}
+			rate, source, err := buyingRate(costs, inv.Company, item.ItemCode, inv.PostingDate)
+			if err != nil {
+				return nil, err
+			}
+			l.BuyingRate, l.CostSource = rate, source
+			l.BuyingAmount = ledger.Flt(l.Qty*rate, 2)
+			l.GrossProfit = ledger.Flt(l.SellingAmount-l.BuyingAmount, 2)
+			if l.Qty != 0 {
+				l.SellingRate = ledger.Flt(l.SellingAmount/l.Qty, 2)
+			}
+			lines = append(lines, l)
+		}
+	}
+	return lines, nil
+}
+
+// stockQty returns an item's quantity in stock units.
+func stockQty(stock, qty, factor float64) float64 {
+	switch {
+	case stock != 0:
+		return stock
+	case factor != 0:
+		return qty * factor
+	}
+	return qty
+}
+
+// buyingRate returns the valuation rate, falling back to the last purchase
+// rate.
+//
+// Maps to: GrossProfitGenerator.get_buying_amount() and
+// get_last_purchase_rate() in gross_profit.py
+func buyingRate(costs CostSource, company, itemCode string, date time.Time) (float64, string, error) {
+	rate, ok, err := costs.ValuationRate(company, itemCode, date)
+	if err != nil {
+		return 0, "", fmt.Errorf("valuation rate of %s: %w", itemCode, err)
+	}
+	if ok {
+		return rate, SourceValuationRate, nil
+	}
+	rate, ok, err = costs.LastPurchaseRate(company, itemCode)
+	if err != nil {
+		return 0, "", fmt.Errorf("last purchase rate of %s: %w", itemCode, err)
+	}
+	if ok {
+		return rate, SourceLastPurchaseRate, nil
+	}
+	return 0, "", nil
+}
+
+// GroupBy is the grouping of the report.
+type GroupBy string
+
+const (
+	ByInvoice   GroupBy = "Invoice"
+	ByItem      GroupBy = "Item Code"
+	ByCustomer  GroupBy = "Customer"
+	ByTerritory GroupBy = "Territory"
+)
+
+// key returns the group of a line.
+func (g GroupBy) key(l *Line) string {
+	switch g {
+	case ByInvoice:
+		return l.Invoice
+	case ByItem:
+		return l.ItemCode
+	case ByCustomer:
+		return l.Customer
+	}
+	return l.Territory
+}
+
+// Row is the gross profit of one group.
+type Row struct {
+	Key           string
+	Qty           float64
+	SellingAmount float64
+	BuyingAmount  float64
+	GrossProfit   float64
+}
+
+// GrossProfitPercent returns the gross profit as a share of the selling
+// amount, 0 for a group that sold nothing.
+//
+// Python equivalent:
+//
+//	row.gross_profit_percent = flt((row.gross_profit / row.base_amount) * 100.0, precision)
+func (r Row) GrossProfitPercent() float64 {
+	if r.SellingAmount == 0 {
+		return 0
+	}
+	return ledger.Flt(r.GrossProfit/r.SellingAmount*100, 2)
+}
+
+// Report is the Gross Profit report for one grouping.
+type Report struct {
+	GroupBy GroupBy
+	Groups  []Row // In order of first appearance for invoices, else by key
+}
+
+// Group sums lines by invoice, item, customer or territory.
+//
+// Maps to: GrossProfitGenerator.get_average_rate_based_on_group_by()
+func Group(lines []Line, by GroupBy) (*Report, error) {
+	switch by {
+	case ByInvoice, ByItem, ByCustomer, ByTerritory:
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownGroupBy, by)
+	}
+	r := &Report{GroupBy: by}
+	index := map[string]int{}
+	for i := range lines {
+		l := &lines[i]
+		key := by.key(l)
+		j, ok := index[key]
+		if !ok {
+			j = len(r.Groups)
+			index[key] = j
+			r.Groups = append(r.Groups, Row{Key: key})
+		}
+		g := &r.Groups[j]
+		g.Qty = ledger.Flt(g.Qty+l.Qty, 3)
+		g.SellingAmount = ledger.Flt(g.SellingAmount+l.SellingAmount, 2)
+		g.BuyingAmount = ledger.Flt(g.BuyingAmount+l.BuyingAmount, 2)
+		g.GrossProfit = ledger.Flt(g.GrossProfit+l.GrossProfit, 2)
+	}
+	if by != ByInvoice {
+		slices.SortStableFunc(r.Groups, func(a, b Row) int { return strings.Compare(a.Key, b.Key) })
+	}
+	return r, nil
+}
+
+// Title implements reportio.Titled.
+func (r *Report) Title() string { return "Gross Profit" }
+
+// Columns implements reportio.Report.
+func (r *Report) Columns() []reportio.Column {
+	key := reportio.Column{Fieldname: "territory", Label: "Territory", Fieldtype: reportio.Link, Options: "Territory"}
+	switch r.GroupBy {
+	case ByInvoice:
+		key = reportio.Column{Fieldname: "sales_invoice", Label: "Sales Invoice", Fieldtype: reportio.Link, Options: salesinvoice.VoucherType}
+	case ByItem:
+		key = reportio.Column{Fieldname: "item_code", Label: "Item Code", Fieldtype: reportio.Link, Options: "Item"}
+	case ByCustomer:
+		key = reportio.Column{Fieldname: "customer", Label: "Customer", Fieldtype: reportio.Link, Options: "Customer"}
+	}
+	return []reportio.Column{
+		key,
+		{Fieldname: "qty", Label: "Qty", Fieldtype: reportio.Float, Precision: 3},
+		{Fieldname: "base_amount", Label: "Selling Amount", Fieldtype: reportio.Currency},
+		{Fieldname: "buying_amount", Label: "Buying Amount", Fieldtype: reportio.Currency},
+		{Fieldname: "gross_profit", Label: "Gross Profit", Fieldtype: reportio.Currency},
+		{Fieldname: "gross_profit_percent", Label: "Gross Profit %", Fieldtype: reportio.Float},
+	}
+}
+
+// Rows implements reportio.Report.
+func (r *Report) Rows(fn func([]any) error) error {
+	for _, g := range r.Groups {
+		if err := fn([]any{g.Key, g.Qty, g.SellingAmount, g.BuyingAmount, g.GrossProfit, g.GrossProfitPercent()}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package grossprofit
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/daterange"
+	"github.com/senguttuvang/erpnext-go/party"
+	"github.com/senguttuvang/erpnext-go/reportio"
+	"github.com/senguttuvang/erpnext-go/salesinvoice"
+	"github.com/senguttuvang/erpnext-go/taxcalc"
+)
+
+type costs struct {
+	valuation map[string]float64
+	purchase  map[string]float64
+}
+
+func (c costs) ValuationRate(company, itemCode string, date time.Time) (float64, bool, error) {
+	rate, ok := c.valuation[itemCode]
+	return rate, ok, nil
+}
+
+func (c costs) LastPurchaseRate(company, itemCode string) (float64, bool, error) {
+	rate, ok := c.purchase[itemCode]
+	return rate, ok, nil
+}
+
+type parties map[string]string // customer -> territory
+
+func (p parties) GetParty(partyType party.PartyType, name string) (*party.Party, error) {
+	territory, ok := p[name]
+	if !ok {
+		return nil, errors.New("party not found")
+	}
+	return &party.Party{Name: name, PartyType: partyType, Territory: territory}, nil
+}
+
+func invoice(t *testing.T, name, customer string, status salesinvoice.Status, items ...*taxcalc.LineItem) *salesinvoice.SalesInvoice {
+	t.Helper()
+	inv := &salesinvoice.SalesInvoice{
+		Name: name, Company: "ACME", Customer: customer, Status: status, CompanyCurrency: "USD",
+		PostingDate: time.Date(2024, 5, 10, 0, 0, 0, 0, time.UTC),
+		Document:    taxcalc.Document{Currency: "USD", Items: items},
+	}
+	if err := inv.Calculate(nil); err != nil {
+		t.Fatal(err)
+	}
+	return inv
+}
+
+func TestGrossProfit(t *testing.T) {
+	invoices := []*salesinvoice.SalesInvoice{
+		invoice(t, "SINV-2", "Globex", salesinvoice.Submitted,
+			&taxcalc.LineItem{ItemCode: "WIDGET", Qty: 10, Rate: 15},
+			&taxcalc.LineItem{ItemCode: "GADGET", Qty: 2, Rate: 40, ConversionFactor: 6}), // Boxes of 6
+		invoice(t, "SINV-1", "Initech", salesinvoice.Submitted,
+			&taxcalc.LineItem{ItemCode: "WIDGET", Qty: 4, Rate: 20},
+			&taxcalc.LineItem{ItemCode: "SERVICE", Qty: 1, Rate: 100}),
+		invoice(t, "SINV-3", "Initech", salesinvoice.Cancelled, &taxcalc.LineItem{ItemCode: "WIDGET", Qty: 100, Rate: 20}),
+	}
+	src := costs{valuation: map[string]float64{"WIDGET": 12}, purchase: map[string]float64{"WIDGET": 99, "GADGET": 5}}
+	lines, err := Lines(invoices, src, parties{"Globex": "North", "Initech": "South"}, Filter{Company: "ACME", Period: daterange.Inclusive(time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 5, 31, 0, 0, 0, 0, time.UTC))})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lines) != 4 {
+		t.Fatalf("lines = %+v", lines)
+	}
+	if l := lines[1]; l.Qty != 12 || l.BuyingAmount != 60 || l.SellingRate != 6.67 || l.CostSource != SourceLastPurchaseRate {
+		t.Errorf("GADGET line = %+v", l)
+	}
+	if l := lines[3]; l.BuyingAmount != 0 || l.GrossProfit != 100 || l.CostSource != "" {
+		t.Errorf("SERVICE line = %+v", l)
+	}
+
+	for _, tc := range []struct {
+		by   GroupBy
+		want []Row
+	}{
+		{ByInvoice, []Row{{"SINV-2", 22, 230, 180, 50}, {"SINV-1", 5, 180, 48, 132}}},
+		{ByItem, []Row{{"GADGET", 12, 80, 60, 20}, {"SERVICE", 1, 100, 0, 100}, {"WIDGET", 14, 230, 168, 62}}},
+		{ByTerritory, []Row{{"North", 22, 230, 180, 50}, {"South", 5, 180, 48, 132}}},
+	} {
+		r, err := Group(lines, tc.by)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(r.Groups) != len(tc.want) {
+			t.Fatalf("%s: groups = %+v", tc.by, r.Groups)
+		}
+		for i, want := range tc.want {
+			if r.Groups[i] != want {
+				t.Errorf("%s: group %d = %+v, want %+v", tc.by, i, r.Groups[i], want)
+			}
+		}
+	}
+
+	r, _ := Group(lines, ByCustomer)
+	if got := r.Groups[1].GrossProfitPercent(); got != 73.33 {
+		t.Errorf("Initech gross profit %% = %.2f, want 73.33", got)
+	}
+	var rows int
+	if err := r.Rows(func([]any) error { rows++; return nil }); err != nil || rows != 2 {
+		t.Errorf("Rows() = %d, %v", rows, err)
+	}
+	var _ reportio.Report = r
+	if _, err := Group(lines, "Warehouse"); !errors.Is(err, ErrUnknownGroupBy) {
+		t.Errorf("Group(Warehouse) error = %v", err)
+	}
+}
@@ -0,0 +1,60 @@
+package i18n
+
+import (
+	"github.com/senguttuvang/erpnext-go/ledger"
+	"github.com/senguttuvang/erpnext-go/taxcalc"
+)
+
+// Arabic catalog.
+var Arabic = Catalog{
+	ledger.CodeAccountDisabled:         "الحساب معطل",
+	ledger.CodeAccountFrozen:           "الحساب مجمد",
+	ledger.CodeAccountIsGroup:          "لا يمكن الترحيل إلى حساب مجموعة",
+	ledger.CodeMissingCostCenter:       "مركز التكلفة مطلوب لحساب الأرباح والخسائر",
+	ledger.CodeDebitCreditMismatch:     "المبالغ المدينة والدائنة غير متوازنة",
+	ledger.CodeInsufficientEntries:     "عدد قيود دفتر الأستاذ العام غير صحيح",
+	ledger.CodePeriodClosed:            "الفترة المحاسبية مغلقة",
+	ledger.CodeFiscalYearNotFound:      "لم يتم العثور على سنة مالية لهذا التاريخ",
+	ledger.CodeAccountsFrozenTill:      "الحسابات مجمدة حتى هذا التاريخ",
+	ledger.CodeBooksClosedTill:         "الدفاتر مغلقة حتى هذا التاريخ",
+	ledger.CodeInvalidPeriodDefinition: "تعريف الفترة المحاسبية غير صالح",
+	ledger.CodePeriodNotFound:          "لا توجد فترة محاسبية تشمل هذا التاريخ",
+	ledger.CodeBudgetExceeded:          "تم تجاوز الميزانية",
+	ledger.CodeInvalidAccountCurrency:  "عملة الحساب غير صالحة",
+	ledger.CodeCurrencyMismatch:        "عدم تطابق العملة",
+	ledger.CodeVoucherNotFound:         "لم يتم العثور على السند",
+	ledger.CodeVoucherAlreadyPosted:    "للسند قيود في دفتر الأستاذ العام بالفعل",
+	ledger.CodeRelinkNotSupported:      "لا يمكن للمخزن إعادة ربط المراجع بالسندات",
+	ledger.CodeAccountNotInTree:        "الحساب غير موجود في دليل الحسابات",
+	ledger.CodeAccountTreeCycle:        "دليل الحسابات يحتوي على حلقة",
+	ledger.CodeNotPermitted:            "غير مسموح",
+	ledger.CodeMissingAccount:          "الحساب مطلوب",
+	ledger.CodeInvalidSnapshot:         "لقطة دفتر الأستاذ تالفة أو ذات إصدار غير معروف",
+	ledger.CodeVoucherTypeNotAllowed:   "الحساب لا يقبل هذا النوع من القسائم",
+	ledger.CodePartyRequired:           "الحساب يتطلب طرفًا",
+	ledger.CodeNoOutbox:                "تم ضبط دفتر المدفوعات للكتابة لاحقًا دون تهيئة صندوق صادر",
+	ledger.CodeDoublePosting:           "السند مرحّل بالفعل إلى دفتر التمويل هذا؛ ألغه قبل ترحيله مرة أخرى",
+	ledger.CodeCancellationForbidden:   "سياسة الإلغاء تمنع إلغاء هذا السند",
+	ledger.CodeDeleteNotSupported:      "لا يستطيع مخزن الدفتر حذف القيود، لذا لا يمكن إلغاء هذا السند بالحذف",
+	ledger.CodeDimensionUnbalanced:     "يجب أن يتساوى المدين والدائن لكل قيمة من قيم البُعد المتوازن داخل السند",
+
+	ledger.CodeDisabledAccounts:          "لا يمكن إنشاء قيود محاسبية على حسابات معطلة: {accounts}",
+	ledger.CodeVoucherPeriodClosed:       "الفترة المحاسبية {period} مغلقة لـ {doctype} في {company} بتاريخ {date}",
+	ledger.CodeAccountBudgetExceeded:     "ميزانية {account} في {cost_center} هي {budget}؛ والفعلي {actual} يتجاوزها بمقدار {variance}",
+	ledger.CodeAccountsMissingCostCenter: "{voucher_type} {voucher_no}: مركز التكلفة مطلوب لحسابات الأرباح والخسائر {accounts}",
+	ledger.CodeGLEntryCount:              "عدد قيود دفتر الأستاذ العام غير صحيح: المتوقع {expected} على الأقل، والموجود {actual}. ربما اخترت حسابًا خاطئًا في المعاملة.",
+
+	taxcalc.CodeNoItems:                 "لا توجد أصناف للحساب",
+	taxcalc.CodeInvalidRowID:            "مرجع صف غير صالح في حساب الضريبة",
+	taxcalc.CodeZeroNetTotal:            "صافي الإجمالي صفر؛ لا يمكن توزيع الضريبة الفعلية",
+	taxcalc.CodeNegativeQuantity:        "لا يمكن أن تكون الكمية سالبة",
+	taxcalc.CodeInvalidDiscount:         "يجب أن تكون نسبة الخصم بين 0 و100",
+	taxcalc.CodeInvalidConversion:       "يجب أن يكون سعر التحويل أكبر من صفر",
+	taxcalc.CodeInvariantViolation:      "فشل التحقق من الحساب",
+	taxcalc.CodeItemNotFound:            "لم يتم العثور على الصنف",
+	taxcalc.CodeUOMConversionNotFound:   "لم يتم العثور على معامل تحويل وحدة القياس",
+	taxcalc.CodeItemTaxTemplateNotFound: "لم يتم العثور على قالب ضريبة الصنف",
+	taxcalc.CodeItemTaxTemplateDisabled: "قالب ضريبة الصنف معطل",
+	taxcalc.CodeNoChargeWeights:         "لم يتم تعيين أوزان الرسوم للتوزيع اليدوي",
+	taxcalc.CodeInvalidRequest:          "تعذر قراءة طلب الحساب",
+}
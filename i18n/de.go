@@ -0,0 +1,60 @@
+package i18n
+
+import (
+	"github.com/senguttuvang/erpnext-go/ledger"
+	"github.com/senguttuvang/erpnext-go/taxcalc"
+)
+
+// German catalog.
+var German = Catalog{
+	ledger.CodeAccountDisabled:         "Konto ist deaktiviert",
+	ledger.CodeAccountFrozen:           "Konto ist gesperrt",
+	ledger.CodeAccountIsGroup:          "Auf ein Gruppenkonto kann nicht gebucht werden",
+	ledger.CodeMissingCostCenter:       "Für ein Erfolgskonto ist eine Kostenstelle erforderlich",
+	ledger.CodeDebitCreditMismatch:     "Soll und Haben sind nicht ausgeglichen",
+	ledger.CodeInsufficientEntries:     "Falsche Anzahl von Hauptbucheinträgen",
+	ledger.CodePeriodClosed:            "Buchungsperiode ist geschlossen",
+	ledger.CodeFiscalYearNotFound:      "Kein Geschäftsjahr für das Datum gefunden",
+	ledger.CodeAccountsFrozenTill:      "Konten sind bis zu diesem Datum gesperrt",
+	ledger.CodeBooksClosedTill:         "Bücher sind bis zu diesem Datum abgeschlossen",
+	ledger.CodeInvalidPeriodDefinition: "Ungültige Definition der Buchungsperiode",
+	ledger.CodePeriodNotFound:          "Keine Buchungsperiode umfasst das Datum",
+	ledger.CodeBudgetExceeded:          "Budget überschritten",
+	ledger.CodeInvalidAccountCurrency:  "Ungültige Kontowährung",
+	ledger.CodeCurrencyMismatch:        "Währungen stimmen nicht überein",
+	ledger.CodeVoucherNotFound:         "Beleg nicht gefunden",
+	ledger.CodeVoucherAlreadyPosted:    "Der Beleg hat bereits Hauptbucheinträge",
+	ledger.CodeRelinkNotSupported:      "Der Speicher kann Belegverweise nicht neu verknüpfen",
+	ledger.CodeAccountNotInTree:        "Konto ist nicht im Kontenplan",
+	ledger.CodeAccountTreeCycle:        "Der Kontenplan enthält einen Zyklus",
+	ledger.CodeNotPermitted:            "Nicht erlaubt",
+	ledger.CodeMissingAccount:          "Ein Konto ist erforderlich",
+	ledger.CodeInvalidSnapshot:         "Der Hauptbuch-Snapshot ist beschädigt oder hat eine unbekannte Version",
+	ledger.CodeVoucherTypeNotAllowed:   "Das Konto akzeptiert diese Belegart nicht",
+	ledger.CodePartyRequired:           "Das Konto erfordert eine Partei",
+	ledger.CodeNoOutbox:                "Das Zahlungsbuch soll später geschrieben werden, aber es ist kein Ausgangsspeicher konfiguriert",
+	ledger.CodeDoublePosting:           "Der Beleg ist in diesem Finanzbuch bereits gebucht; stornieren Sie ihn vor einer erneuten Buchung",
+	ledger.CodeCancellationForbidden:   "Die Stornierungsrichtlinie verbietet die Stornierung dieses Belegs",
+	ledger.CodeDeleteNotSupported:      "Der Hauptbuchspeicher kann keine Buchungen löschen; der Beleg kann nicht durch Löschen storniert werden",
+	ledger.CodeDimensionUnbalanced:     "Soll und Haben jedes Werts einer ausgleichenden Dimension müssen im Beleg übereinstimmen",
+
+	ledger.CodeDisabledAccounts:          "Auf deaktivierte Konten kann nicht gebucht werden: {accounts}",
+	ledger.CodeVoucherPeriodClosed:       "Die Buchungsperiode {period} ist für {doctype} in {company} am {date} geschlossen",
+	ledger.CodeAccountBudgetExceeded:     "Das Budget für {account} in {cost_center} beträgt {budget}; der Ist-Wert {actual} überschreitet es um {variance}",
+	ledger.CodeAccountsMissingCostCenter: "{voucher_type} {voucher_no}: Für die Erfolgskonten {accounts} ist eine Kostenstelle erforderlich",
+	ledger.CodeGLEntryCount:              "Falsche Anzahl von Hauptbucheinträgen: mindestens {expected} erwartet, {actual} gefunden. Möglicherweise wurde in der Transaktion ein falsches Konto gewählt.",
+
+	taxcalc.CodeNoItems:                 "Keine Artikel zu berechnen",
+	taxcalc.CodeInvalidRowID:            "Ungültiger Zeilenverweis in der Steuerberechnung",
+	taxcalc.CodeZeroNetTotal:            "Die Nettosumme ist null; die tatsächliche Steuer kann nicht verteilt werden",
+	taxcalc.CodeNegativeQuantity:        "Die Menge darf nicht negativ sein",
+	taxcalc.CodeInvalidDiscount:         "Der Rabattsatz muss zwischen 0 und 100 liegen",
+	taxcalc.CodeInvalidConversion:       "Der Umrechnungskurs muss größer als null sein",
+	taxcalc.CodeInvariantViolation:      "Prüfung der Berechnung fehlgeschlagen",
+	taxcalc.CodeItemNotFound:            "Artikel nicht gefunden",
+	taxcalc.CodeUOMConversionNotFound:   "Umrechnungsfaktor der Maßeinheit nicht gefunden",
+	taxcalc.CodeItemTaxTemplateNotFound: "Artikelsteuervorlage nicht gefunden",
+	taxcalc.CodeItemTaxTemplateDisabled: "Artikelsteuervorlage ist deaktiviert",
+	taxcalc.CodeNoChargeWeights:         "Keine Gewichte für die manuelle Verteilung der Kosten festgelegt",
+	taxcalc.CodeInvalidRequest:          "Berechnungsanfrage konnte nicht gelesen werden",
+}
@@ -0,0 +1,61 @@
+package i18n
+
+import (
+	"github.com/senguttuvang/erpnext-go/ledger"
+	"github.com/senguttuvang/erpnext-go/taxcalc"
+)
+
+// English is the source catalog. Every code has an English message, and
+// the other catalogs translate these.
+var English = Catalog{
+	ledger.CodeAccountDisabled:         "Account is disabled",
+	ledger.CodeAccountFrozen:           "Account is frozen",
+	ledger.CodeAccountIsGroup:          "Cannot post to a group account",
+	ledger.CodeMissingCostCenter:       "Cost center is required for a profit and loss account",
+	ledger.CodeDebitCreditMismatch:     "Debit and credit amounts do not balance",
+	ledger.CodeInsufficientEntries:     "Incorrect number of General Ledger entries",
+	ledger.CodePeriodClosed:            "Accounting period is closed",
+	ledger.CodeFiscalYearNotFound:      "No fiscal year found for the date",
+	ledger.CodeAccountsFrozenTill:      "Accounts are frozen up to this date",
+	ledger.CodeBooksClosedTill:         "Books are closed up to this date",
+	ledger.CodeInvalidPeriodDefinition: "Invalid accounting period definition",
+	ledger.CodePeriodNotFound:          "No accounting period covers the date",
+	ledger.CodeBudgetExceeded:          "Budget exceeded",
+	ledger.CodeInvalidAccountCurrency:  "Invalid account currency",
+	ledger.CodeCurrencyMismatch:        "Currency mismatch",
+	ledger.CodeVoucherNotFound:         "Voucher not found",
+	ledger.CodeVoucherAlreadyPosted:    "Voucher already has General Ledger entries",
+	ledger.CodeRelinkNotSupported:      "Store cannot relink references against vouchers",
+	ledger.CodeAccountNotInTree:        "Account is not in the chart of accounts",
+	ledger.CodeAccountTreeCycle:        "Chart of accounts has a cycle",
+	ledger.CodeNotPermitted:            "Not permitted",
+	ledger.CodeMissingAccount:          "Account is required",
+	ledger.CodeInvalidSnapshot:         "Ledger snapshot is corrupt or of an unknown version",
+	ledger.CodeVoucherTypeNotAllowed:   "Account does not accept this voucher type",
+	ledger.CodePartyRequired:           "Account requires a party",
+	ledger.CodeNoOutbox:                "Payment ledger is set to be written later, but no outbox is configured",
+	ledger.CodeDoublePosting:           "Voucher is already posted into this finance book; cancel it before posting again",
+	ledger.CodeCancellationForbidden:   "Cancellation of this voucher is forbidden by the cancellation policy",
+	ledger.CodeDeleteNotSupported:      "The ledger store cannot delete entries, so this voucher cannot be cancelled by deletion",
+	ledger.CodeDimensionUnbalanced:     "Debits and credits of each value of a balancing dimension must be equal within the voucher",
+
+	ledger.CodeDisabledAccounts:          "Cannot create accounting entries against disabled accounts: {accounts}",
+	ledger.CodeVoucherPeriodClosed:       "Accounting period {period} is closed for {doctype} in {company} on {date}",
+	ledger.CodeAccountBudgetExceeded:     "Budget for {account} in {cost_center} is {budget}; actual {actual} exceeds it by {variance}",
+	ledger.CodeAccountsMissingCostCenter: "{voucher_type} {voucher_no}: cost center is required for profit and loss accounts {accounts}",
+	ledger.CodeGLEntryCount:              "Incorrect number of General Ledger entries: expected at least {expected}, found {actual}. You might have selected a wrong account in the transaction.",
+
+	taxcalc.CodeNoItems:                 "No items to calculate",
+	taxcalc.CodeInvalidRowID:            "Invalid row reference in tax calculation",
+	taxcalc.CodeZeroNetTotal:            "Net total is zero; cannot distribute actual tax",
+	taxcalc.CodeNegativeQuantity:        "Quantity cannot be negative",
+	taxcalc.CodeInvalidDiscount:         "Discount percentage must be between 0 and 100",
+	taxcalc.CodeInvalidConversion:       "Conversion rate must be greater than zero",
+	taxcalc.CodeInvariantViolation:      "Calculation check failed",
+	taxcalc.CodeItemNotFound:            "Item not found",
+	taxcalc.CodeUOMConversionNotFound:   "UOM conversion factor not found",
+	taxcalc.CodeItemTaxTemplateNotFound: "Item tax template not found",
+	taxcalc.CodeItemTaxTemplateDisabled: "Item tax template is disabled",
+	taxcalc.CodeNoChargeWeights:         "No charge weights set for manual distribution",
+	taxcalc.CodeInvalidRequest:          "Calculation request could not be read",
+}
@@ -0,0 +1,62 @@
+package i18n
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/senguttuvang/erpnext-go/errcode"
+	"github.com/senguttuvang/erpnext-go/ledger"
+)
+
+// CodeUnknown is reported for errors without a registered code.
+const CodeUnknown Code = "unknown"
+
+// FromError reduces an error to a Message keyed by its errcode code.
+// Typed ledger errors supply their fields as arguments; a
+// ledger.ValidationError keeps its account and details as untranslated
+// Detail. ok is false for errors without a code.
+func FromError(err error) (m Message, ok bool) {
+	code := Code(errcode.Of(err))
+	if code == "" {
+		return Message{Code: CodeUnknown}, false
+	}
+	m = Message{Code: code}
+
+	var disabled *ledger.DisabledAccountsError
+	var period *ledger.PeriodClosedError
+	var budget *ledger.BudgetExceededError
+	var costCenter *ledger.MissingCostCenterError
+	var count *ledger.GLEntryCountError
+	var validation *ledger.ValidationError
+	switch {
+	case errors.As(err, &disabled):
+		m.Args = map[string]string{"accounts": strings.Join(disabled.Accounts, ", ")}
+	case errors.As(err, &period):
+		m.Args = map[string]string{
+			"doctype": period.DocType, "company": period.Company, "date": period.PostingDate, "period": period.PeriodName,
+		}
+	case errors.As(err, &budget):
+		m.Args = map[string]string{
+			"account": budget.Account, "cost_center": budget.CostCenter, "budget": amount(budget.Budget),
+			"actual": amount(budget.Actual), "variance": amount(budget.Variance),
+		}
+	case errors.As(err, &costCenter):
+		m.Args = map[string]string{
+			"voucher_type": costCenter.VoucherType, "voucher_no": costCenter.VoucherNo,
+			"accounts": strings.Join(costCenter.Accounts, ", "),
+		}
+	case errors.As(err, &count):
+		m.Args = map[string]string{"expected": fmt.Sprint(count.Expected), "actual": fmt.Sprint(count.Actual)}
+	case errors.As(err, &validation):
+		m.Detail = validation.Details
+		if validation.Account != "" {
+			m.Detail = validation.Account + " - " + validation.Details
+		}
+	}
+	return m, true
+}
+
+func amount(v float64) string {
+	return fmt.Sprintf("%.2f", v)
+}
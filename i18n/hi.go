@@ -0,0 +1,60 @@
+package i18n
+
+import (
+	"github.com/senguttuvang/erpnext-go/ledger"
+	"github.com/senguttuvang/erpnext-go/taxcalc"
+)
+
+// Hindi catalog.
+var Hindi = Catalog{
+	ledger.CodeAccountDisabled:         "खाता अक्षम है",
+	ledger.CodeAccountFrozen:           "खाता फ़्रीज़ है",
+	ledger.CodeAccountIsGroup:          "समूह खाते में प्रविष्टि नहीं की जा सकती",
+	ledger.CodeMissingCostCenter:       "लाभ और हानि खाते के लिए लागत केंद्र आवश्यक है",
+	ledger.CodeDebitCreditMismatch:     "डेबिट और क्रेडिट राशियाँ बराबर नहीं हैं",
+	ledger.CodeInsufficientEntries:     "सामान्य खाता प्रविष्टियों की संख्या गलत है",
+	ledger.CodePeriodClosed:            "लेखा अवधि बंद है",
+	ledger.CodeFiscalYearNotFound:      "इस तिथि के लिए कोई वित्तीय वर्ष नहीं मिला",
+	ledger.CodeAccountsFrozenTill:      "इस तिथि तक खाते फ़्रीज़ हैं",
+	ledger.CodeBooksClosedTill:         "इस तिथि तक बहियाँ बंद हैं",
+	ledger.CodeInvalidPeriodDefinition: "लेखा अवधि की परिभाषा अमान्य है",
+	ledger.CodePeriodNotFound:          "कोई लेखा अवधि इस तिथि को शामिल नहीं करती",
+	ledger.CodeBudgetExceeded:          "बजट से अधिक",
+	ledger.CodeInvalidAccountCurrency:  "खाते की मुद्रा अमान्य है",
+	ledger.CodeCurrencyMismatch:        "मुद्रा मेल नहीं खाती",
+	ledger.CodeVoucherNotFound:         "वाउचर नहीं मिला",
+	ledger.CodeVoucherAlreadyPosted:    "वाउचर की सामान्य खाता प्रविष्टियाँ पहले से मौजूद हैं",
+	ledger.CodeRelinkNotSupported:      "स्टोर वाउचर के विरुद्ध संदर्भों को फिर से नहीं जोड़ सकता",
+	ledger.CodeAccountNotInTree:        "खाता खातों के चार्ट में नहीं है",
+	ledger.CodeAccountTreeCycle:        "खातों के चार्ट में चक्र है",
+	ledger.CodeNotPermitted:            "अनुमति नहीं है",
+	ledger.CodeMissingAccount:          "खाता आवश्यक है",
+	ledger.CodeInvalidSnapshot:         "लेजर स्नैपशॉट दूषित है या उसका संस्करण अज्ञात है",
+	ledger.CodeVoucherTypeNotAllowed:   "खाता इस वाउचर प्रकार को स्वीकार नहीं करता",
+	ledger.CodePartyRequired:           "खाते के लिए पार्टी आवश्यक है",
+	ledger.CodeNoOutbox:                "भुगतान लेजर बाद में लिखा जाना है, पर कोई आउटबॉक्स कॉन्फ़िगर नहीं है",
+	ledger.CodeDoublePosting:           "वाउचर इस वित्त बुक में पहले से पोस्ट है; दोबारा पोस्ट करने से पहले इसे रद्द करें",
+	ledger.CodeCancellationForbidden:   "रद्दीकरण नीति इस वाउचर को रद्द करने की अनुमति नहीं देती",
+	ledger.CodeDeleteNotSupported:      "लेजर स्टोर प्रविष्टियाँ हटा नहीं सकता, इसलिए इस वाउचर को हटाकर रद्द नहीं किया जा सकता",
+	ledger.CodeDimensionUnbalanced:     "संतुलन आयाम के प्रत्येक मान के नामे और जमा वाउचर के भीतर बराबर होने चाहिए",
+
+	ledger.CodeDisabledAccounts:          "अक्षम खातों में लेखा प्रविष्टियाँ नहीं बनाई जा सकतीं: {accounts}",
+	ledger.CodeVoucherPeriodClosed:       "{company} में {date} को {doctype} के लिए लेखा अवधि {period} बंद है",
+	ledger.CodeAccountBudgetExceeded:     "{cost_center} में {account} का बजट {budget} है; वास्तविक {actual} इससे {variance} अधिक है",
+	ledger.CodeAccountsMissingCostCenter: "{voucher_type} {voucher_no}: लाभ और हानि खातों {accounts} के लिए लागत केंद्र आवश्यक है",
+	ledger.CodeGLEntryCount:              "सामान्य खाता प्रविष्टियों की संख्या गलत है: कम से कम {expected} अपेक्षित, {actual} मिलीं। हो सकता है आपने लेनदेन में गलत खाता चुना हो।",
+
+	taxcalc.CodeNoItems:                 "गणना के लिए कोई आइटम नहीं",
+	taxcalc.CodeInvalidRowID:            "कर गणना में पंक्ति का संदर्भ अमान्य है",
+	taxcalc.CodeZeroNetTotal:            "शुद्ध योग शून्य है; वास्तविक कर वितरित नहीं किया जा सकता",
+	taxcalc.CodeNegativeQuantity:        "मात्रा ऋणात्मक नहीं हो सकती",
+	taxcalc.CodeInvalidDiscount:         "छूट प्रतिशत 0 और 100 के बीच होना चाहिए",
+	taxcalc.CodeInvalidConversion:       "विनिमय दर शून्य से अधिक होनी चाहिए",
+	taxcalc.CodeInvariantViolation:      "गणना की जाँच विफल रही",
+	taxcalc.CodeItemNotFound:            "आइटम नहीं मिला",
+	taxcalc.CodeUOMConversionNotFound:   "माप इकाई रूपांतरण कारक नहीं मिला",
+	taxcalc.CodeItemTaxTemplateNotFound: "आइटम कर टेम्पलेट नहीं मिला",
+	taxcalc.CodeItemTaxTemplateDisabled: "आइटम कर टेम्पलेट अक्षम है",
+	taxcalc.CodeNoChargeWeights:         "मैन्युअल वितरण के लिए शुल्क भार निर्धारित नहीं हैं",
+	taxcalc.CodeInvalidRequest:          "गणना अनुरोध पढ़ा नहीं जा सका",
+}
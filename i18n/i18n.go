@@ -0,0 +1,151 @@
+// Package i18n translates user-facing error messages.
+//
+// Errors from the ledger and taxcalc packages are reduced to their
+// errcode codes with named arguments, and each code has a message
+// template in every catalog. An HTTP API or CLI reports the code, which does not
+// change between releases or languages, together with the message in the
+// user's language:
+//
+//	msg := i18n.Default.Localize(i18n.Default.Match(r.Header.Get("Accept-Language")), err)
+//
+// Migrated from: frappe/translate.py (_() and the per-language
+// translations/*.csv files)
+package i18n
+
+import (
+	"strings"
+)
+
+// Code identifies an error message independent of language. Its values
+// are errcode codes such as "ACC-GLE-0007".
+type Code string
+
+// Message is an error reduced to its code and named arguments.
+type Message struct {
+	Code Code
+	Args map[string]string
+
+	// Detail is free text attached by the caller, such as the details of a
+	// ledger.ValidationError. It is appended untranslated.
+	Detail string
+}
+
+// Catalog holds the message templates of one language. Templates refer to
+// arguments by name in braces, as in "account {account} is frozen".
+type Catalog map[Code]string
+
+// Localized is a translated error message.
+type Localized struct {
+	Code     Code   `json:"code"`
+	Language string `json:"language"`
+	Message  string `json:"message"`
+}
+
+// Translator translates messages using a catalog per language.
+type Translator struct {
+	Catalogs map[string]Catalog // Keyed by language tag: "en", "hi", ...
+	Fallback string             // Language used for unknown languages and missing codes
+}
+
+// Default translates into English, Hindi, German and Arabic.
+var Default = &Translator{
+	Catalogs: map[string]Catalog{"en": English, "hi": Hindi, "de": German, "ar": Arabic},
+	Fallback: "en",
+}
+
+// Localize translates an error into a language. Errors without a code keep
+// their own text under CodeUnknown.
+func (t *Translator) Localize(lang string, err error) Localized {
+	m, ok := FromError(err)
+	if !ok {
+		return Localized{Code: CodeUnknown, Language: lang, Message: err.Error()}
+	}
+	text, lang := t.Translate(lang, m)
+	return Localized{Code: m.Code, Language: lang, Message: text}
+}
+
+// Translate renders a message in a language, falling back to the
+// Fallback language when the language or the code is missing. It returns
+// the text and the language actually used.
+//
+// Maps to: frappe._(msg, lang=...)
+func (t *Translator) Translate(lang string, m Message) (string, string) {
+	tmpl, ok := t.Catalogs[lang][m.Code]
+	if !ok {
+		lang = t.Fallback
+		tmpl, ok = t.Catalogs[lang][m.Code]
+	}
+	if !ok {
+		tmpl = string(m.Code)
+	}
+	text := expand(tmpl, m.Args)
+	if m.Detail != "" {
+		text += ": " + m.Detail
+	}
+	return text, lang
+}
+
+// Match picks the best supported language for an Accept-Language header,
+// honouring quality values and ignoring region subtags ("de-CH" matches
+// "de"). It returns the Fallback language when nothing matches.
+func (t *Translator) Match(acceptLanguage string) string {
+	best, bestQ := t.Fallback, 0.0
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			q = parseQ(v)
+		}
+		base, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+		if _, ok := t.Catalogs[base]; ok && q > bestQ {
+			best, bestQ = base, q
+		}
+	}
+	return best
+}
+
+// parseQ parses a quality value ("0.8"), returning 0 when it is malformed.
+func parseQ(v string) float64 {
+	whole, frac, _ := strings.Cut(v, ".")
+	if whole != "0" && whole != "1" {
+		return 0
+	}
+	q, scale := float64(whole[0]-'0'), 0.1
+	for _, c := range frac {
+		if c < '0' || c > '9' {
+			return 0
+		}
+		q += float64(c-'0') * scale
+		scale /= 10
+	}
+	return min(q, 1)
+}
+
+// expand replaces {name} in a template with the named argument. Unknown
+// names are left as they are.
+func expand(tmpl string, args map[string]string) string {
+	if len(args) == 0 || !strings.Contains(tmpl, "{") {
+		return tmpl
+	}
+	var b strings.Builder
+	for {
+		open := strings.IndexByte(tmpl, '{')
+		if open < 0 {
+			break
+		}
+		end := strings.IndexByte(tmpl[open:], '}')
+		if end < 0 {
+			break
+		}
+		name := tmpl[open+1 : open+end]
+		b.WriteString(tmpl[:open])
+		if v, ok := args[name]; ok {
+			b.WriteString(v)
+		} else {
+			b.WriteString(tmpl[open : open+end+1])
+		}
+		tmpl = tmpl[open+end+1:]
+	}
+	b.WriteString(tmpl)
+	return b.String()
+}
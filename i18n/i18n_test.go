@@ -0,0 +1,81 @@
+package i18n
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/senguttuvang/erpnext-go/errcode"
+	"github.com/senguttuvang/erpnext-go/ledger"
+	"github.com/senguttuvang/erpnext-go/taxcalc"
+)
+
+// TestCatalogs checks that every catalog translates every English message
+// and uses the same arguments.
+func TestCatalogs(t *testing.T) {
+	placeholder := regexp.MustCompile(`\{[a-z_]+\}`)
+	args := func(s string) []string {
+		found := placeholder.FindAllString(s, -1)
+		slices.Sort(found)
+		return found
+	}
+	for _, e := range errcode.All() {
+		if _, ok := English[Code(e.Code)]; !ok && (strings.HasPrefix(e.Code, "ACC-GLE-") || strings.HasPrefix(e.Code, "ACC-TAX-")) {
+			t.Errorf("no English message for %s", e.Code)
+		}
+	}
+	for lang, catalog := range Default.Catalogs {
+		if len(catalog) != len(English) {
+			t.Errorf("%s has %d messages, English %d", lang, len(catalog), len(English))
+		}
+		for code, en := range English {
+			if !slices.Equal(args(catalog[code]), args(en)) {
+				t.Errorf("%s %s: arguments %v, English %v", lang, code, args(catalog[code]), args(en))
+			}
+		}
+	}
+}
+
+func TestLocalize(t *testing.T) {
+	tests := []struct {
+		lang string
+		err  error
+		want Localized
+	}{
+		{"de", fmt.Errorf("submit: %w", taxcalc.ErrNegativeQuantity),
+			Localized{taxcalc.CodeNegativeQuantity, "de", "Die Menge darf nicht negativ sein"}},
+		{"en", &ledger.PeriodClosedError{Company: "ACME", DocType: "Sales Invoice", PostingDate: "2024-04-30", PeriodName: "Apr-24"},
+			Localized{ledger.CodeVoucherPeriodClosed, "en", "Accounting period Apr-24 is closed for Sales Invoice in ACME on 2024-04-30"}},
+		{"hi", &ledger.DisabledAccountsError{Accounts: []string{"Cash - A", "Bank - A"}},
+			Localized{ledger.CodeDisabledAccounts, "hi", "अक्षम खातों में लेखा प्रविष्टियाँ नहीं बनाई जा सकतीं: Cash - A, Bank - A"}},
+		{"ar", &ledger.BudgetExceededError{Account: "Rent", CostCenter: "Main", Budget: 100, Actual: 150, Variance: 50},
+			Localized{ledger.CodeAccountBudgetExceeded, "ar", "ميزانية Rent في Main هي 100.00؛ والفعلي 150.00 يتجاوزها بمقدار 50.00"}},
+		{"de", ledger.NewValidationError(ledger.ErrAccountFrozen, "Cash - A", "frozen by auditor"),
+			Localized{ledger.CodeAccountFrozen, "de", "Konto ist gesperrt: Cash - A - frozen by auditor"}},
+		{"fr", ledger.ErrNotPermitted, Localized{ledger.CodeNotPermitted, "en", "Not permitted"}},
+		{"de", errors.New("disk full"), Localized{CodeUnknown, "de", "disk full"}},
+	}
+	for _, tt := range tests {
+		if got := Default.Localize(tt.lang, tt.err); got != tt.want {
+			t.Errorf("Localize(%s, %v) = %+v, want %+v", tt.lang, tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	for header, want := range map[string]string{
+		"":                          "en",
+		"de-CH, de;q=0.9, en;q=0.8": "de",
+		"fr-FR, ar;q=0.5, hi;q=0.7": "hi",
+		"en;q=0.2, ar-SA":           "ar",
+		"ja, zh;q=0.9":              "en",
+		"hi;q=bad, de;q=0.1":        "de",
+	} {
+		if got := Default.Match(header); got != want {
+			t.Errorf("Match(%q) = %q, want %q", header, got, want)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package instrument
+
+import (
+	"slices"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/daterange"
+)
+
+// CashFlow is an expected bank movement from an instrument, for the
+// treasury cash forecast.
+type CashFlow struct {
+	Date        time.Time
+	Instrument  string
+	Kind        Kind
+	Company     string
+	Currency    string
+	Amount      float64 // Positive for inflows
+	Description string
+}
+
+// CashFlows returns the maturity proceeds of the active instruments that
+// fall due between from and to inclusive, in date order. Guarantees
+// without a margin are listed with a zero amount so their expiry still
+// shows in the forecast.
+func CashFlows(instruments []*Instrument, from, to time.Time) []CashFlow {
+	period := daterange.Inclusive(from, to)
+	var flows []CashFlow
+	for _, inst := range instruments {
+		due := daterange.Civil(inst.MaturityDate)
+		if inst.Status != Active || !period.Contains(due) {
+			continue
+		}
+		description := "Fixed deposit maturity"
+		if inst.Kind == BankGuarantee {
+			description = "Bank guarantee expiry"
+			if inst.Beneficiary != "" {
+				description += " (" + inst.Beneficiary + ")"
+			}
+		}
+		flows = append(flows, CashFlow{
+			Date: due, Instrument: inst.Name, Kind: inst.Kind, Company: inst.Company,
+			Currency: inst.Currency, Amount: inst.MaturityValue(), Description: description,
+		})
+	}
+	slices.SortStableFunc(flows, func(a, b CashFlow) int { return a.Date.Compare(b.Date) })
+	return flows
+}
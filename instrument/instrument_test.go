@@ -0,0 +1,172 @@
+package instrument
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/ledger"
+	"github.com/senguttuvang/erpnext-go/ledger/memstore"
+)
+
+// mockStore keeps instruments in a map.
+type mockStore map[string]*Instrument
+
+func (m mockStore) Get(name string) (*Instrument, error) {
+	inst, ok := m[name]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return inst, nil
+}
+
+func (m mockStore) Save(inst *Instrument) error {
+	m[inst.Name] = inst
+	return nil
+}
+
+func date(y int, m time.Month, d int) time.Time {
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+func newService() (*Service, *memstore.GLStore) {
+	glStore := memstore.NewGLStore()
+	return &Service{
+		Store:  mockStore{},
+		Engine: &ledger.Engine{GLStore: glStore, PaymentStore: memstore.NewPaymentStore()},
+		Accounts: Accounts{
+			Deposit:         "Fixed Deposits - ACME",
+			AccruedInterest: "Accrued Interest - ACME",
+			InterestIncome:  "Interest Income - ACME",
+			CostCenter:      "Main - ACME",
+		},
+	}, glStore
+}
+
+func balances(entries []ledger.GLEntry) map[string]float64 {
+	result := make(map[string]float64)
+	for _, e := range entries {
+		result[e.Account] = ledger.Flt(result[e.Account]+e.Debit-e.Credit, 2)
+	}
+	return result
+}
+
+func TestSchedule(t *testing.T) {
+	fd := &Instrument{Kind: FixedDeposit, Principal: 100000, Rate: 7.3, StartDate: date(2024, 1, 15), MaturityDate: date(2024, 4, 15)}
+	schedule := fd.Schedule()
+	// Simple interest: 100000 × 7.3% / 365 = 20 a day
+	want := []Accrual{
+		{Date: date(2024, 1, 31), Amount: 320},
+		{Date: date(2024, 2, 29), Amount: 580},
+		{Date: date(2024, 3, 31), Amount: 620},
+		{Date: date(2024, 4, 15), Amount: 300},
+	}
+	if len(schedule) != len(want) {
+		t.Fatalf("Schedule() = %+v", schedule)
+	}
+	for i := range want {
+		if !schedule[i].Date.Equal(want[i].Date) || schedule[i].Amount != want[i].Amount {
+			t.Errorf("row %d = %+v, want %+v", i, schedule[i], want[i])
+		}
+	}
+	if got := fd.MaturityValue(); got != 101820 {
+		t.Errorf("MaturityValue() = %v, want 101820", got)
+	}
+
+	// Quarterly compounding over one year gives the effective annual rate
+	fd = &Instrument{Kind: FixedDeposit, Principal: 100000, Rate: 8, Compounding: 4, StartDate: date(2023, 1, 1), MaturityDate: date(2024, 1, 1)}
+	total := 0.0
+	for _, row := range fd.Schedule() {
+		total += row.Amount
+	}
+	want8 := ledger.Flt(100000*(math.Pow(1.02, 4)-1), 2)
+	if ledger.Flt(total, 2) != want8 || fd.MaturityValue() != 100000+want8 {
+		t.Errorf("compound interest = %v, maturity %v, want %v", total, fd.MaturityValue(), want8)
+	}
+}
+
+func TestPlaceAccrueMature(t *testing.T) {
+	svc, glStore := newService()
+	fd := &Instrument{Name: "FD-0001", Kind: FixedDeposit, Company: "ACME", Bank: "HDFC", Currency: "INR",
+		Principal: 100000, Rate: 7.3, StartDate: date(2024, 1, 15), MaturityDate: date(2024, 4, 15)}
+	if err := svc.Place(fd, "Bank - ACME"); err != nil {
+		t.Fatalf("Place() error = %v", err)
+	}
+
+	posted, err := svc.AccrueInterest("FD-0001", date(2024, 3, 1))
+	if err != nil {
+		t.Fatalf("AccrueInterest() error = %v", err)
+	}
+	if len(posted) != 2 || posted[1].VoucherNo != "FD-0001/2" {
+		t.Errorf("posted = %+v", posted)
+	}
+	// Already posted rows are not posted again
+	if posted, _ := svc.AccrueInterest("FD-0001", date(2024, 3, 1)); len(posted) != 0 {
+		t.Errorf("second accrual posted %+v", posted)
+	}
+
+	if err := svc.Mature("FD-0001", date(2024, 4, 1), "Bank - ACME"); !errors.Is(err, ErrNotMatured) {
+		t.Errorf("early Mature() error = %v, want ErrNotMatured", err)
+	}
+	if err := svc.Mature("FD-0001", date(2024, 4, 15), "Bank - ACME"); err != nil {
+		t.Fatalf("Mature() error = %v", err)
+	}
+
+	got := balances(glStore.All())
+	want := map[string]float64{
+		"Bank - ACME":             1820,
+		"Fixed Deposits - ACME":   0,
+		"Accrued Interest - ACME": 0,
+		"Interest Income - ACME":  -1820,
+	}
+	for account, balance := range want {
+		if got[account] != balance {
+			t.Errorf("balance of %s = %v, want %v", account, got[account], balance)
+		}
+	}
+	if fd.Status != Matured || len(fd.Accruals) != 4 {
+		t.Errorf("after maturity: status %s, %d accruals", fd.Status, len(fd.Accruals))
+	}
+	if err := svc.Mature("FD-0001", date(2024, 4, 15), "Bank - ACME"); !errors.Is(err, ErrNotActive) {
+		t.Errorf("second Mature() error = %v, want ErrNotActive", err)
+	}
+}
+
+func TestGuaranteeMargin(t *testing.T) {
+	svc, glStore := newService()
+	bg := &Instrument{Name: "BG-0001", Kind: BankGuarantee, Company: "ACME", Bank: "HDFC", Currency: "INR",
+		Principal: 500000, Margin: 50000, Beneficiary: "Globex", StartDate: date(2024, 1, 1), MaturityDate: date(2024, 12, 31)}
+	if err := svc.Place(bg, "Bank - ACME"); err != nil {
+		t.Fatalf("Place() error = %v", err)
+	}
+	if got := balances(glStore.All())["Fixed Deposits - ACME"]; got != 50000 {
+		t.Errorf("margin held = %v, want 50000", got)
+	}
+	if err := svc.Mature("BG-0001", date(2024, 12, 31), "Bank - ACME"); err != nil {
+		t.Fatalf("Mature() error = %v", err)
+	}
+	if got := balances(glStore.All())["Bank - ACME"]; got != 0 {
+		t.Errorf("bank balance after release = %v, want 0", got)
+	}
+}
+
+func TestCashFlows(t *testing.T) {
+	instruments := []*Instrument{
+		{Name: "FD-2", Kind: FixedDeposit, Status: Active, Principal: 1000, Rate: 3.65, StartDate: date(2024, 1, 1), MaturityDate: date(2024, 3, 1)},
+		{Name: "BG-1", Kind: BankGuarantee, Status: Active, Principal: 5000, Beneficiary: "Globex", StartDate: date(2024, 1, 1), MaturityDate: date(2024, 2, 1)},
+		{Name: "FD-1", Kind: FixedDeposit, Status: Matured, Principal: 1000, StartDate: date(2024, 1, 1), MaturityDate: date(2024, 2, 1)},
+		{Name: "FD-3", Kind: FixedDeposit, Status: Active, Principal: 1000, StartDate: date(2024, 1, 1), MaturityDate: date(2024, 6, 1)},
+	}
+	flows := CashFlows(instruments, date(2024, 1, 1), date(2024, 3, 31))
+	if len(flows) != 2 {
+		t.Fatalf("CashFlows() = %+v", flows)
+	}
+	if flows[0].Instrument != "BG-1" || flows[0].Amount != 0 || flows[0].Description != "Bank guarantee expiry (Globex)" {
+		t.Errorf("flows[0] = %+v", flows[0])
+	}
+	// 60 days at 0.01 a day per 100
+	if flows[1].Instrument != "FD-2" || flows[1].Amount != 1006 {
+		t.Errorf("flows[1] = %+v", flows[1])
+	}
+}
@@ -0,0 +1,112 @@
+// Package instrument implements accounting for fixed deposits and bank
+// guarantees.
+//
+// Placing a fixed deposit moves cash from a bank account into a deposit
+// account. Interest is accrued to a receivable at each month end and on
+// maturity the bank pays back principal and interest. A bank guarantee is
+// a contingent liability and is not posted itself; the cash margin the bank
+// holds against it is, and follows the same life cycle as a deposit.
+//
+// ERPNext has no instrument doctype; postings follow the Journal Entry
+// conventions of erpnext/accounts/doctype/journal_entry.
+package instrument
+
+import (
+	"errors"
+	"time"
+)
+
+// VoucherType is the voucher type used on instrument GL entries.
+const VoucherType = "Treasury Instrument"
+
+// Instrument errors
+var (
+	ErrNotFound       = errors.New("instrument not found")
+	ErrExists         = errors.New("instrument already exists")
+	ErrInvalidAmount  = errors.New("amount must be greater than zero")
+	ErrInvalidTerm    = errors.New("maturity date must be after start date")
+	ErrNotActive      = errors.New("instrument is not active")
+	ErrNotMatured     = errors.New("instrument has not matured")
+	ErrMissingAccount = errors.New("instrument account is not configured")
+)
+
+// Kind distinguishes the instruments.
+type Kind string
+
+const (
+	FixedDeposit  Kind = "Fixed Deposit"
+	BankGuarantee Kind = "Bank Guarantee"
+)
+
+// Status is the lifecycle state of an instrument.
+type Status string
+
+const (
+	Draft   Status = "Draft"
+	Active  Status = "Active"  // Placed; interest accruing
+	Matured Status = "Matured" // Proceeds received or margin released
+)
+
+// Instrument is a fixed deposit or bank guarantee.
+type Instrument struct {
+	Name     string
+	Kind     Kind
+	Company  string
+	Bank     string
+	Currency string
+
+	Principal float64 // Deposit amount, or guarantee amount for a guarantee
+	Margin    float64 // Cash held by the bank against a guarantee
+
+	Rate         float64 // Annual interest rate in percent
+	Compounding  int     // Compounding periods per year; 0 for simple interest
+	StartDate    time.Time
+	MaturityDate time.Time
+
+	Beneficiary string // Party a guarantee is issued in favour of
+
+	Status   Status
+	Accruals []Accrual // Interest posted so far
+}
+
+// Accrual is one row of an interest schedule.
+type Accrual struct {
+	Date      time.Time
+	Amount    float64
+	VoucherNo string // Set once posted
+}
+
+// Funded returns the cash tied up in the instrument: the principal of a
+// deposit or the margin of a guarantee.
+func (i *Instrument) Funded() float64 {
+	if i.Kind == BankGuarantee {
+		return i.Margin
+	}
+	return i.Principal
+}
+
+// Accrued returns the interest accrued so far.
+func (i *Instrument) Accrued() float64 {
+	total := 0.0
+	for _, a := range i.Accruals {
+		total += a.Amount
+	}
+	return total
+}
+
+// Store abstracts instrument persistence.
+type Store interface {
+	// Get returns an instrument by name, or ErrNotFound.
+	Get(name string) (*Instrument, error)
+	// Save creates or replaces an instrument.
+	Save(inst *Instrument) error
+}
+
+// Accounts configures the ledger accounts used for a company's
+// instruments.
+type Accounts struct {
+	Deposit         string // Fixed deposits and guarantee margins (asset)
+	AccruedInterest string // Interest earned but not yet paid (asset)
+	InterestIncome  string
+	CostCenter      string
+}
@@ -0,0 +1,63 @@
+package instrument
+
+import (
+	"math"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/ledger"
+)
+
+// daysInYear is the day count basis (Actual/365).
+const daysInYear = 365
+
+// InterestTo returns the interest earned on the funded amount from the
+// start date to date, capped at the maturity date. Compound interest uses
+// fractional periods, so it accrues smoothly between compounding dates.
+func (i *Instrument) InterestTo(date time.Time) float64 {
+	start, end := ledger.CivilDate(i.StartDate), ledger.CivilDate(i.MaturityDate)
+	date = ledger.CivilDate(date)
+	if date.After(end) {
+		date = end
+	}
+	if !date.After(start) {
+		return 0
+	}
+	years := date.Sub(start).Hours() / 24 / daysInYear
+	rate := i.Rate / 100
+	if i.Compounding <= 0 {
+		return i.Funded() * rate * years
+	}
+	n := float64(i.Compounding)
+	return i.Funded() * (math.Pow(1+rate/n, n*years) - 1)
+}
+
+// MaturityValue returns the funded amount plus interest to maturity.
+func (i *Instrument) MaturityValue() float64 {
+	return ledger.Flt(i.Funded()+ledger.Flt(i.InterestTo(i.MaturityDate), 2), 2)
+}
+
+// Schedule returns the interest accruals from start to maturity: one at
+// each month end and one on the maturity date. Each amount is the rounded
+// interest to its date less the rounded interest to the previous one, so
+// the rows add up to the interest at maturity.
+func (i *Instrument) Schedule() []Accrual {
+	start, end := ledger.CivilDate(i.StartDate), ledger.CivilDate(i.MaturityDate)
+	var schedule []Accrual
+	prev := 0.0
+	add := func(date time.Time) {
+		total := ledger.Flt(i.InterestTo(date), 2)
+		if amount := ledger.Flt(total-prev, 2); amount != 0 {
+			schedule = append(schedule, Accrual{Date: date, Amount: amount})
+		}
+		prev = total
+	}
+	for monthEnd := endOfMonth(start); monthEnd.Before(end); monthEnd = endOfMonth(monthEnd.AddDate(0, 0, 1)) {
+		add(monthEnd)
+	}
+	add(end)
+	return schedule
+}
+
+func endOfMonth(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month()+1, 0, 0, 0, 0, 0, time.UTC)
+}
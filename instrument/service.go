@@ -0,0 +1,163 @@
+package instrument
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/ledger"
+)
+
+// Service places instruments, accrues their interest and settles them on
+// maturity, posting each step through the ledger engine.
+type Service struct {
+	Store    Store
+	Engine   *ledger.Engine
+	Accounts Accounts
+}
+
+// Place opens an instrument: the funded amount moves from the bank
+// account to the deposit account. A guarantee without a margin posts
+// nothing.
+func (s *Service) Place(inst *Instrument, bankAccount string) error {
+	if inst.Principal <= 0 || inst.Margin < 0 {
+		return ErrInvalidAmount
+	}
+	if !ledger.CivilDate(inst.MaturityDate).After(ledger.CivilDate(inst.StartDate)) {
+		return ErrInvalidTerm
+	}
+	if s.Accounts.Deposit == "" {
+		return ErrMissingAccount
+	}
+	if _, err := s.Store.Get(inst.Name); err == nil {
+		return fmt.Errorf("%w: %s", ErrExists, inst.Name)
+	}
+
+	inst.Principal = ledger.Flt(inst.Principal, 2)
+	inst.Margin = ledger.Flt(inst.Margin, 2)
+	inst.Accruals = nil
+	if funded := inst.Funded(); funded > 0 {
+		glMap := []ledger.GLEntry{
+			s.newGLEntry(inst, inst.StartDate, inst.Name+"/0", s.Accounts.Deposit, funded, 0),
+			s.newGLEntry(inst, inst.StartDate, inst.Name+"/0", bankAccount, 0, funded),
+		}
+		glMap[0].Against = bankAccount
+		glMap[1].Against = s.Accounts.Deposit
+		if err := s.Engine.MakeGLEntries(glMap, ledger.DefaultPostingOptions()); err != nil {
+			return err
+		}
+	}
+	inst.Status = Active
+	return s.Store.Save(inst)
+}
+
+// AccrueInterest posts every scheduled accrual dated on or before upTo
+// that has not been posted yet, debiting accrued interest and crediting
+// interest income. It returns the accruals posted.
+func (s *Service) AccrueInterest(name string, upTo time.Time) ([]Accrual, error) {
+	inst, err := s.Store.Get(name)
+	if err != nil {
+		return nil, err
+	}
+	if inst.Status != Active {
+		return nil, fmt.Errorf("%w: %s", ErrNotActive, name)
+	}
+	if s.Accounts.AccruedInterest == "" || s.Accounts.InterestIncome == "" {
+		return nil, ErrMissingAccount
+	}
+
+	var posted []Accrual
+	schedule := inst.Schedule()
+	for _, row := range schedule[min(len(inst.Accruals), len(schedule)):] {
+		if ledger.CivilDate(row.Date).After(ledger.CivilDate(upTo)) {
+			break
+		}
+		row.VoucherNo = fmt.Sprintf("%s/%d", inst.Name, len(inst.Accruals)+1)
+		glMap := []ledger.GLEntry{
+			s.newGLEntry(inst, row.Date, row.VoucherNo, s.Accounts.AccruedInterest, row.Amount, 0),
+			s.newGLEntry(inst, row.Date, row.VoucherNo, s.Accounts.InterestIncome, 0, row.Amount),
+		}
+		glMap[0].Against = s.Accounts.InterestIncome
+		glMap[1].Against = s.Accounts.AccruedInterest
+		if err := s.Engine.MakeGLEntries(glMap, ledger.DefaultPostingOptions()); err != nil {
+			return posted, err
+		}
+		inst.Accruals = append(inst.Accruals, row)
+		posted = append(posted, row)
+	}
+	if len(posted) > 0 {
+		if err := s.Store.Save(inst); err != nil {
+			return posted, err
+		}
+	}
+	return posted, nil
+}
+
+// Mature settles an instrument on or after its maturity date. Interest
+// still due is accrued first; the bank account then receives the funded
+// amount and the accrued interest.
+func (s *Service) Mature(name string, date time.Time, bankAccount string) error {
+	inst, err := s.Store.Get(name)
+	if err != nil {
+		return err
+	}
+	if inst.Status != Active {
+		return fmt.Errorf("%w: %s", ErrNotActive, name)
+	}
+	if ledger.CivilDate(date).Before(ledger.CivilDate(inst.MaturityDate)) {
+		return fmt.Errorf("%w: %s matures on %s", ErrNotMatured, name, inst.MaturityDate.Format(time.DateOnly))
+	}
+	if inst.Funded() > 0 && inst.Rate > 0 {
+		if _, err := s.AccrueInterest(name, inst.MaturityDate); err != nil {
+			return err
+		}
+		if inst, err = s.Store.Get(name); err != nil {
+			return err
+		}
+	}
+
+	funded, interest := inst.Funded(), ledger.Flt(inst.Accrued(), 2)
+	if proceeds := ledger.Flt(funded+interest, 2); proceeds > 0 {
+		voucherNo := fmt.Sprintf("%s/%d", inst.Name, len(inst.Accruals)+1)
+		glMap := []ledger.GLEntry{
+			s.newGLEntry(inst, date, voucherNo, bankAccount, proceeds, 0),
+			s.newGLEntry(inst, date, voucherNo, s.Accounts.Deposit, 0, funded),
+		}
+		glMap[0].Against = s.Accounts.Deposit
+		glMap[1].Against = bankAccount
+		if interest > 0 {
+			glMap = append(glMap, s.newGLEntry(inst, date, voucherNo, s.Accounts.AccruedInterest, 0, interest))
+			glMap[0].Against += ", " + s.Accounts.AccruedInterest
+			glMap[2].Against = bankAccount
+		}
+		if err := s.Engine.MakeGLEntries(glMap, ledger.DefaultPostingOptions()); err != nil {
+			return err
+		}
+	}
+	inst.Status = Matured
+	return s.Store.Save(inst)
+}
+
+// newGLEntry returns a company-currency entry for an instrument movement.
+func (s *Service) newGLEntry(inst *Instrument, date time.Time, voucherNo, account string, debit, credit float64) ledger.GLEntry {
+	return ledger.GLEntry{
+		PostingDate:                 date,
+		TransactionDate:             date,
+		Account:                     account,
+		AccountCurrency:             inst.Currency,
+		VoucherType:                 VoucherType,
+		VoucherNo:                   voucherNo,
+		Company:                     inst.Company,
+		CostCenter:                  s.Accounts.CostCenter,
+		Debit:                       debit,
+		Credit:                      credit,
+		DebitInAccountCurrency:      debit,
+		CreditInAccountCurrency:     credit,
+		TransactionCurrency:         inst.Currency,
+		TransactionExchangeRate:     1,
+		DebitInTransactionCurrency:  debit,
+		CreditInTransactionCurrency: credit,
+		IsOpening:                   ledger.IsOpeningNo,
+		IsAdvance:                   ledger.IsAdvanceNo,
+		Remarks:                     fmt.Sprintf("%s %s (%s)", inst.Kind, inst.Name, inst.Bank),
+	}
+}
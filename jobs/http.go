@@ -0,0 +1,85 @@
+package jobs
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+// jobView is a job as served over HTTP, with its progress.
+type jobView struct {
+	*Job
+	Progress Progress `json:"progress"`
+}
+
+// Handler serves the jobs API:
+//
+//	GET  /jobs?type=...        list jobs
+//	GET  /jobs/{id}            one job with its chunks, progress and time left
+//	POST /jobs/{id}/pause      pause after the current chunk
+//	POST /jobs/{id}/resume     resume a paused job
+//	POST /jobs/{id}/retry      retry the failed chunks of a failed job
+//	POST /jobs/{id}/cancel     cancel, interrupting the current chunk
+//
+// Mount it under a prefix with http.StripPrefix.
+func Handler(r *Runner) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /jobs", func(w http.ResponseWriter, req *http.Request) {
+		jobs, err := r.Store.List(req.URL.Query().Get("type"))
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		views := make([]jobView, len(jobs))
+		for i, job := range jobs {
+			views[i] = jobView{Job: job, Progress: job.ProgressAt(r.now())}
+			job.Chunks = nil // Listed without chunks; progress is enough
+		}
+		writeJSON(w, http.StatusOK, views)
+	})
+	mux.HandleFunc("GET /jobs/{id}", func(w http.ResponseWriter, req *http.Request) {
+		job, err := r.Store.Get(req.PathValue("id"))
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, jobView{Job: job, Progress: job.ProgressAt(r.now())})
+	})
+	action := func(fn func(req *http.Request, id string) error) http.HandlerFunc {
+		return func(w http.ResponseWriter, req *http.Request) {
+			id := req.PathValue("id")
+			if err := fn(req, id); err != nil {
+				writeError(w, err)
+				return
+			}
+			job, err := r.Store.Get(id)
+			if err != nil {
+				writeError(w, err)
+				return
+			}
+			writeJSON(w, http.StatusOK, jobView{Job: job, Progress: job.ProgressAt(r.now())})
+		}
+	}
+	mux.HandleFunc("POST /jobs/{id}/pause", action(func(_ *http.Request, id string) error { return r.Pause(id) }))
+	mux.HandleFunc("POST /jobs/{id}/cancel", action(func(_ *http.Request, id string) error { return r.Cancel(id) }))
+	mux.HandleFunc("POST /jobs/{id}/resume", action(func(req *http.Request, id string) error { return r.Resume(req.Context(), id) }))
+	mux.HandleFunc("POST /jobs/{id}/retry", action(func(req *http.Request, id string) error { return r.Retry(req.Context(), id) }))
+	return mux
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, ErrNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, ErrBadState):
+		status = http.StatusConflict
+	}
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,167 @@
+// Package jobs runs long operations in the background: mass
+// reconciliation, reposting, imports and the like.
+//
+// A Task splits its work into chunks. The Runner queues jobs, works
+// through their chunks and records each chunk's outcome in the Store, so a
+// job can be paused and resumed, survives a restart of the process, and
+// one failing chunk does not stop the rest. Failed chunks can be retried.
+// A chunk that scans many rows, such as a report on a big ledger, reports
+// them with ReportProgress, from which the time left is estimated, and
+// cancelling its job cancels the chunk's context.
+//
+// Maps to: frappe.enqueue() / RQ background jobs, and the chunked
+// processing of Process Payment Reconciliation
+// (erpnext/accounts/doctype/process_payment_reconciliation)
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"time"
+)
+
+// Job errors
+var (
+	ErrNotFound    = errors.New("job not found")
+	ErrUnknownTask = errors.New("unknown job type")
+	ErrBadState    = errors.New("job cannot do that in its current state")
+)
+
+// Status is the state of a job.
+type Status string
+
+const (
+	Queued    Status = "Queued"
+	Running   Status = "Running"
+	Paused    Status = "Paused"
+	Completed Status = "Completed"
+	Failed    Status = "Failed" // Planning failed, or some chunks failed
+	Cancelled Status = "Cancelled"
+)
+
+// ChunkStatus is the state of one chunk of a job.
+type ChunkStatus string
+
+const (
+	ChunkPending ChunkStatus = "Pending"
+	ChunkDone    ChunkStatus = "Done"
+	ChunkFailed  ChunkStatus = "Failed"
+)
+
+// Chunk is one unit of a job's work.
+type Chunk struct {
+	Key       string      `json:"key"`
+	Status    ChunkStatus `json:"status"`
+	Error     string      `json:"error,omitempty"`
+	Attempts  int         `json:"attempts"`
+	Rows      int64       `json:"rows,omitempty"`       // Scanned so far, as the task reported
+	RowsTotal int64       `json:"rows_total,omitempty"` // To scan; 0 when unknown
+}
+
+// Job is a queued or running operation.
+type Job struct {
+	ID       string          `json:"id"`
+	Type     string          `json:"type"`
+	Params   json.RawMessage `json:"params,omitempty"`
+	Status   Status          `json:"status"`
+	Chunks   []Chunk         `json:"chunks,omitempty"` // Nil until the task is planned
+	Error    string          `json:"error,omitempty"`  // Planning error
+	Created  time.Time       `json:"created"`
+	Started  time.Time       `json:"started,omitzero"`
+	Finished time.Time       `json:"finished,omitzero"`
+}
+
+// Progress summarises a job's chunks.
+type Progress struct {
+	Total      int     `json:"total"`
+	Done       int     `json:"done"`
+	Failed     int     `json:"failed"`
+	Pending    int     `json:"pending"`
+	Percent    float64 `json:"percent"`               // Chunks finished, done or failed, and the part scanned of pending ones
+	Rows       int64   `json:"rows,omitempty"`        // Scanned, over all chunks
+	ETASeconds float64 `json:"eta_seconds,omitempty"` // Estimated time left of a running job; 0 when unknown
+}
+
+// Progress returns the job's progress now.
+func (j *Job) Progress() Progress {
+	return j.ProgressAt(time.Now())
+}
+
+// ProgressAt returns the job's progress at a time. The estimate of the
+// time left assumes the job keeps the pace it had since it started.
+func (j *Job) ProgressAt(now time.Time) Progress {
+	p := Progress{Total: len(j.Chunks)}
+	var partial float64
+	for _, c := range j.Chunks {
+		p.Rows += c.Rows
+		switch c.Status {
+		case ChunkDone:
+			p.Done++
+		case ChunkFailed:
+			p.Failed++
+		default:
+			p.Pending++
+			if c.RowsTotal > 0 {
+				partial += min(float64(c.Rows)/float64(c.RowsTotal), 1)
+			}
+		}
+	}
+	if p.Total > 0 {
+		p.Percent = (float64(p.Done+p.Failed) + partial) * 100 / float64(p.Total)
+	} else if j.Status == Completed {
+		p.Percent = 100
+	}
+	if j.Status == Running && !j.Started.IsZero() && p.Percent > 0 && p.Percent < 100 {
+		elapsed := now.Sub(j.Started).Seconds()
+		p.ETASeconds = math.Round(elapsed * (100 - p.Percent) / p.Percent)
+	}
+	return p
+}
+
+// Task is a kind of job.
+type Task interface {
+	// Plan splits the work into chunk keys. It is called once, when the
+	// job first runs.
+	Plan(ctx context.Context, params json.RawMessage) ([]string, error)
+
+	// Run processes one chunk. It may be called again for a chunk that
+	// failed or was interrupted, so it should be idempotent.
+	Run(ctx context.Context, params json.RawMessage, chunk string) error
+}
+
+// TaskFunc is a Task with a single chunk.
+type TaskFunc func(ctx context.Context, params json.RawMessage) error
+
+// Plan implements Task.
+func (f TaskFunc) Plan(context.Context, json.RawMessage) ([]string, error) {
+	return []string{"all"}, nil
+}
+
+// Run implements Task.
+func (f TaskFunc) Run(ctx context.Context, params json.RawMessage, _ string) error {
+	return f(ctx, params)
+}
+
+// Queue hands job IDs to workers.
+type Queue interface {
+	// Push queues a job ID.
+	Push(ctx context.Context, id string) error
+	// Pop blocks until a job ID is available or ctx is done.
+	Pop(ctx context.Context) (string, error)
+}
+
+// Store persists jobs.
+type Store interface {
+	// Create saves a new job, assigning its ID if empty.
+	Create(job *Job) error
+	// Get returns a copy of a job, or ErrNotFound.
+	Get(id string) (*Job, error)
+	// Update applies fn to a job atomically and saves it unless fn
+	// returns an error.
+	Update(id string, fn func(*Job) error) error
+	// List returns the jobs of a type, or all jobs when typ is empty,
+	// oldest first.
+	List(typ string) ([]*Job, error)
+}
@@ -0,0 +1,242 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// partyTask reconciles one party per chunk, failing for "bad" and pausing
+// the job after the chunk named pauseAfter.
+type partyTask struct {
+	mu         sync.Mutex
+	ran        []string
+	pauseAfter string
+	runner     *Runner
+	jobID      string
+}
+
+func (p *partyTask) Plan(_ context.Context, params json.RawMessage) ([]string, error) {
+	var args struct{ Parties []string }
+	if err := json.Unmarshal(params, &args); err != nil {
+		return nil, err
+	}
+	return args.Parties, nil
+}
+
+func (p *partyTask) Run(_ context.Context, _ json.RawMessage, party string) error {
+	p.mu.Lock()
+	p.ran = append(p.ran, party)
+	p.mu.Unlock()
+	switch party {
+	case "bad":
+		return errors.New("no open invoices")
+	case "panic":
+		panic("boom")
+	case p.pauseAfter:
+		return p.runner.Pause(p.jobID)
+	}
+	return nil
+}
+
+func newRunner(task Task) *Runner {
+	return &Runner{
+		Queue: NewMemoryQueue(10),
+		Store: &MemoryStore{},
+		Tasks: map[string]Task{"Payment Reconciliation": task},
+	}
+}
+
+func TestRunPauseResumeRetry(t *testing.T) {
+	ctx := context.Background()
+	task := &partyTask{pauseAfter: "B"}
+	r := newRunner(task)
+	task.runner = r
+
+	params := map[string][]string{"Parties": {"A", "B", "bad", "panic", "C"}}
+	job, err := r.Submit(ctx, "Payment Reconciliation", params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	task.jobID = job.ID
+	if job.ID != "JOB-00001" {
+		t.Errorf("ID = %s", job.ID)
+	}
+
+	if err := r.Run(ctx, job.ID); err != nil {
+		t.Fatal(err)
+	}
+	got, _ := r.Store.Get(job.ID)
+	if got.Status != Paused || got.Progress().Done != 2 || got.Progress().Pending != 3 {
+		t.Fatalf("after pause: %s %+v", got.Status, got.Progress())
+	}
+
+	if err := r.Resume(ctx, job.ID); err != nil {
+		t.Fatal(err)
+	}
+	id, _ := r.Queue.Pop(ctx)
+	if err := r.Run(ctx, id); err != nil {
+		t.Fatal(err)
+	}
+	got, _ = r.Store.Get(job.ID)
+	p := got.Progress()
+	if got.Status != Failed || p.Done != 3 || p.Failed != 2 || p.Percent != 100 {
+		t.Fatalf("after resume: %s %+v", got.Status, p)
+	}
+	if got.Chunks[3].Error != "panic: boom" {
+		t.Errorf("panic chunk error = %q", got.Chunks[3].Error)
+	}
+	if strings.Join(task.ran, ",") != "A,B,bad,panic,C" {
+		t.Errorf("ran %v", task.ran)
+	}
+
+	// Retry runs only the failed chunks
+	task.ran = nil
+	if err := r.Retry(ctx, job.ID); err != nil {
+		t.Fatal(err)
+	}
+	id, _ = r.Queue.Pop(ctx)
+	r.Run(ctx, id)
+	if strings.Join(task.ran, ",") != "bad,panic" {
+		t.Errorf("retry ran %v", task.ran)
+	}
+	got, _ = r.Store.Get(job.ID)
+	if got.Chunks[2].Attempts != 2 {
+		t.Errorf("attempts = %d", got.Chunks[2].Attempts)
+	}
+
+	if err := r.Resume(ctx, job.ID); !errors.Is(err, ErrBadState) {
+		t.Errorf("Resume of failed job error = %v", err)
+	}
+	if _, err := r.Submit(ctx, "Repost", nil); !errors.Is(err, ErrUnknownTask) {
+		t.Errorf("unknown type error = %v", err)
+	}
+}
+
+func TestWorkersAndRecover(t *testing.T) {
+	var mu sync.Mutex
+	done := 0
+	r := newRunner(TaskFunc(func(context.Context, json.RawMessage) error {
+		mu.Lock()
+		done++
+		mu.Unlock()
+		return nil
+	}))
+	r.Workers = 2
+
+	// A job left running by a crashed process
+	r.Store.Create(&Job{Type: "Payment Reconciliation", Status: Running})
+	if err := r.Recover(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	for range 3 {
+		if _, err := r.Submit(ctx, "Payment Reconciliation", nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	go r.Start(ctx)
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		jobs, _ := r.Store.List("")
+		completed := 0
+		for _, j := range jobs {
+			if j.Status == Completed {
+				completed++
+			}
+		}
+		if completed == 4 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("only %d of 4 jobs completed", completed)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	mu.Lock()
+	defer mu.Unlock()
+	if done != 4 {
+		t.Errorf("ran %d jobs", done)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	r := newRunner(TaskFunc(func(context.Context, json.RawMessage) error { return nil }))
+	job, _ := r.Submit(context.Background(), "Payment Reconciliation", nil)
+	h := Handler(r)
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("POST", "/jobs/"+job.ID+"/pause", nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"status":"Paused"`) {
+		t.Errorf("pause: %d %s", rec.Code, rec.Body)
+	}
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("POST", "/jobs/"+job.ID+"/retry", nil))
+	if rec.Code != http.StatusConflict {
+		t.Errorf("retry of paused job: %d %s", rec.Code, rec.Body)
+	}
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/jobs/JOB-99999", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("missing job: %d", rec.Code)
+	}
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/jobs?type=Payment+Reconciliation", nil))
+	var list []struct {
+		ID       string
+		Progress Progress
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &list); err != nil || len(list) != 1 || list[0].ID != job.ID {
+		t.Errorf("list: %v %s", err, rec.Body)
+	}
+}
+
+func TestProgressAndCancel(t *testing.T) {
+	start := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	var r *Runner
+	var id string
+	var seen Progress
+	scan := TaskFunc(func(ctx context.Context, _ json.RawMessage) error {
+		for rows := int64(1000); rows <= 4000; rows += 1000 {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			ReportProgress(ctx, rows, 4000)
+			if rows == 1000 {
+				job, _ := r.Store.Get(id)
+				seen = job.ProgressAt(start.Add(time.Minute))
+				r.Cancel(id)
+			}
+		}
+		return nil
+	})
+	r = newRunner(scan)
+	r.Now = func() time.Time { return start }
+	job, _ := r.Submit(context.Background(), "Payment Reconciliation", nil)
+	id = job.ID
+	if err := r.Run(context.Background(), id); err != nil {
+		t.Fatal(err)
+	}
+
+	// A quarter scanned in a minute leaves three minutes
+	if seen.Rows != 1000 || seen.Percent != 25 || seen.ETASeconds != 180 {
+		t.Errorf("progress while running = %+v", seen)
+	}
+	job, _ = r.Store.Get(id)
+	if job.Status != Cancelled || job.Chunks[0].Status != ChunkPending || job.Chunks[0].Rows != 1000 {
+		t.Errorf("after cancel: %s %+v", job.Status, job.Chunks)
+	}
+
+	rec := httptest.NewRecorder()
+	Handler(r).ServeHTTP(rec, httptest.NewRequest("GET", "/jobs/"+id, nil))
+	if !strings.Contains(rec.Body.String(), `"rows":1000,"rows_total":4000`) {
+		t.Errorf("GET job: %s", rec.Body)
+	}
+}
@@ -0,0 +1,110 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+)
+
+// MemoryQueue is an in-process Queue.
+type MemoryQueue struct {
+	ch chan string
+}
+
+// NewMemoryQueue returns a queue holding up to size IDs.
+func NewMemoryQueue(size int) *MemoryQueue {
+	return &MemoryQueue{ch: make(chan string, size)}
+}
+
+// Push implements Queue.
+func (q *MemoryQueue) Push(ctx context.Context, id string) error {
+	select {
+	case q.ch <- id:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Pop implements Queue.
+func (q *MemoryQueue) Pop(ctx context.Context) (string, error) {
+	select {
+	case id := <-q.ch:
+		return id, nil
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+// MemoryStore is an in-memory Store.
+type MemoryStore struct {
+	mu   sync.Mutex
+	jobs map[string]*Job
+	ids  []string
+}
+
+// Create implements Store. IDs are numbered JOB-00001, JOB-00002, ...
+func (s *MemoryStore) Create(job *Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.jobs == nil {
+		s.jobs = make(map[string]*Job)
+	}
+	if job.ID == "" {
+		job.ID = fmt.Sprintf("JOB-%05d", len(s.ids)+1)
+	}
+	if _, ok := s.jobs[job.ID]; ok {
+		return fmt.Errorf("job %s already exists", job.ID)
+	}
+	s.jobs[job.ID] = clone(job)
+	s.ids = append(s.ids, job.ID)
+	return nil
+}
+
+// Get implements Store.
+func (s *MemoryStore) Get(id string) (*Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, ok := s.jobs[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+	return clone(job), nil
+}
+
+// Update implements Store.
+func (s *MemoryStore) Update(id string, fn func(*Job) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, ok := s.jobs[id]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+	updated := clone(job)
+	if err := fn(updated); err != nil {
+		return err
+	}
+	s.jobs[id] = updated
+	return nil
+}
+
+// List implements Store.
+func (s *MemoryStore) List(typ string) ([]*Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var jobs []*Job
+	for _, id := range s.ids {
+		if job := s.jobs[id]; typ == "" || job.Type == typ {
+			jobs = append(jobs, clone(job))
+		}
+	}
+	return jobs, nil
+}
+
+func clone(job *Job) *Job {
+	c := *job
+	c.Params = slices.Clone(job.Params)
+	c.Chunks = slices.Clone(job.Chunks)
+	return &c
+}
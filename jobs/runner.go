@@ -0,0 +1,319 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Runner queues jobs and works through them.
+type Runner struct {
+	Queue   Queue
+	Store   Store
+	Tasks   map[string]Task  // Job type -> task
+	Workers int              // Concurrent jobs; 1 when zero
+	OnError func(error)      // Optional; receives store and queue errors from workers
+	Now     func() time.Time // Defaults to time.Now
+
+	mu      sync.Mutex
+	running map[string]context.CancelFunc // Job ID -> cancels its current chunk
+}
+
+// Submit creates a job of a registered type and queues it. params is
+// marshalled to JSON and passed to the task.
+func (r *Runner) Submit(ctx context.Context, typ string, params any) (*Job, error) {
+	if _, ok := r.Tasks[typ]; !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownTask, typ)
+	}
+	raw, err := json.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+	job := &Job{Type: typ, Params: raw, Status: Queued, Created: r.now()}
+	if err := r.Store.Create(job); err != nil {
+		return nil, err
+	}
+	if err := r.Queue.Push(ctx, job.ID); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// Pause stops a queued or running job after its current chunk.
+func (r *Runner) Pause(id string) error {
+	return r.transition(id, Paused, Queued, Running)
+}
+
+// Cancel stops a job for good. A chunk running in this process has its
+// context cancelled; one running elsewhere sees the cancellation the next
+// time it reports progress. A chunk that stops on cancellation stays
+// pending, and one that finishes is recorded as usual.
+func (r *Runner) Cancel(id string) error {
+	if err := r.transition(id, Cancelled, Queued, Running, Paused); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	cancel := r.running[id]
+	r.mu.Unlock()
+	if cancel != nil {
+		cancel()
+	}
+	return nil
+}
+
+// Resume queues a paused job; it continues with its pending chunks.
+func (r *Runner) Resume(ctx context.Context, id string) error {
+	if err := r.transition(id, Queued, Paused); err != nil {
+		return err
+	}
+	return r.Queue.Push(ctx, id)
+}
+
+// Retry queues a failed job again, with its failed chunks pending. A job
+// that failed while planning is planned again.
+func (r *Runner) Retry(ctx context.Context, id string) error {
+	err := r.Store.Update(id, func(job *Job) error {
+		if job.Status != Failed {
+			return fmt.Errorf("%w: %s is %s", ErrBadState, id, job.Status)
+		}
+		for i := range job.Chunks {
+			if job.Chunks[i].Status == ChunkFailed {
+				job.Chunks[i].Status, job.Chunks[i].Error = ChunkPending, ""
+				job.Chunks[i].Rows, job.Chunks[i].RowsTotal = 0, 0
+			}
+		}
+		job.Status, job.Error, job.Finished = Queued, "", time.Time{}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return r.Queue.Push(ctx, id)
+}
+
+// Recover queues again the jobs left queued or running by a previous
+// process, for example after a crash. Call it before Start.
+func (r *Runner) Recover(ctx context.Context) error {
+	jobs, err := r.Store.List("")
+	if err != nil {
+		return err
+	}
+	for _, job := range jobs {
+		if job.Status != Queued && job.Status != Running {
+			continue
+		}
+		if err := r.transition(job.ID, Queued, Queued, Running); err != nil {
+			return err
+		}
+		if err := r.Queue.Push(ctx, job.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Start runs workers until ctx is cancelled.
+func (r *Runner) Start(ctx context.Context) {
+	var wg sync.WaitGroup
+	for range max(r.Workers, 1) {
+		wg.Go(func() {
+			for {
+				id, err := r.Queue.Pop(ctx)
+				if err != nil {
+					if ctx.Err() == nil {
+						r.report(err)
+					}
+					return
+				}
+				if err := r.Run(ctx, id); err != nil {
+					r.report(fmt.Errorf("job %s: %w", id, err))
+				}
+			}
+		})
+	}
+	wg.Wait()
+}
+
+// Run works through a queued job's pending chunks in the calling
+// goroutine. It stops early when the job is paused or cancelled, or ctx is
+// done; the job stays resumable. The returned error is a store error, not
+// a chunk's: chunk errors are recorded on the job.
+func (r *Runner) Run(ctx context.Context, id string) error {
+	var job *Job
+	err := r.Store.Update(id, func(j *Job) error {
+		if j.Status != Queued {
+			return errSkip
+		}
+		j.Status = Running
+		if j.Started.IsZero() {
+			j.Started = r.now()
+		}
+		job = j
+		return nil
+	})
+	if errors.Is(err, errSkip) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	task, ok := r.Tasks[job.Type]
+	if !ok {
+		return r.finish(id, fmt.Sprintf("%v: %s", ErrUnknownTask, job.Type))
+	}
+
+	if job.Chunks == nil {
+		keys, err := task.Plan(ctx, job.Params)
+		if err != nil {
+			return r.finish(id, err.Error())
+		}
+		chunks := make([]Chunk, len(keys))
+		for i, key := range keys {
+			chunks[i] = Chunk{Key: key, Status: ChunkPending}
+		}
+		if err := r.Store.Update(id, func(j *Job) error { j.Chunks = chunks; return nil }); err != nil {
+			return err
+		}
+		job.Chunks = chunks
+	}
+
+	for i, chunk := range job.Chunks {
+		if chunk.Status != ChunkPending {
+			continue
+		}
+		if ctx.Err() != nil {
+			// Interrupted: leave the job for Recover
+			return nil
+		}
+		current, err := r.Store.Get(id)
+		if err != nil {
+			return err
+		}
+		if current.Status != Running {
+			return nil // Paused or cancelled
+		}
+
+		chunkCtx, cancel := r.chunkContext(ctx, id, i)
+		runErr := runChunk(chunkCtx, task, job.Params, chunk.Key)
+		interrupted := chunkCtx.Err() != nil
+		r.mu.Lock()
+		delete(r.running, id)
+		r.mu.Unlock()
+		cancel()
+		if runErr != nil && interrupted {
+			return nil // Cancelled mid-chunk; it stays pending
+		}
+		err = r.Store.Update(id, func(j *Job) error {
+			c := &j.Chunks[i]
+			c.Attempts++
+			c.Status, c.Error = ChunkDone, ""
+			if runErr != nil {
+				c.Status, c.Error = ChunkFailed, runErr.Error()
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return r.finish(id, "")
+}
+
+// chunkContext returns the context of a job's chunk i: cancelled by
+// Cancel, and carrying the reporter that records the chunk's progress.
+func (r *Runner) chunkContext(ctx context.Context, id string, i int) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(ctx)
+	r.mu.Lock()
+	if r.running == nil {
+		r.running = make(map[string]context.CancelFunc)
+	}
+	r.running[id] = cancel
+	r.mu.Unlock()
+	report := func(rows, total int64) {
+		err := r.Store.Update(id, func(j *Job) error {
+			if j.Status == Cancelled {
+				cancel()
+			}
+			j.Chunks[i].Rows, j.Chunks[i].RowsTotal = rows, total
+			return nil
+		})
+		if err != nil {
+			r.report(fmt.Errorf("job %s: %w", id, err))
+		}
+	}
+	return context.WithValue(ctx, progressKey{}, progressFunc(report)), cancel
+}
+
+// progressKey is the context key of a chunk's progress reporter.
+type progressKey struct{}
+
+type progressFunc func(rows, total int64)
+
+// ReportProgress records how far the running chunk has got: rows scanned
+// out of total, 0 when the total is unknown. Tasks call it every few
+// thousand rows, as each call is a store update, and should stop when
+// their context is done: reporting is also how a chunk running in one
+// process learns that the job was cancelled from another. Outside a job
+// it does nothing.
+func ReportProgress(ctx context.Context, rows, total int64) {
+	if fn, ok := ctx.Value(progressKey{}).(progressFunc); ok {
+		fn(rows, total)
+	}
+}
+
+// errSkip tells Run a job is not queued.
+var errSkip = errors.New("skip")
+
+// finish marks a running job completed, or failed when planning failed or
+// any chunk failed.
+func (r *Runner) finish(id, planError string) error {
+	return r.Store.Update(id, func(j *Job) error {
+		if j.Status != Running {
+			return nil
+		}
+		j.Status, j.Error, j.Finished = Completed, planError, r.now()
+		if planError != "" || slices.ContainsFunc(j.Chunks, func(c Chunk) bool { return c.Status == ChunkFailed }) {
+			j.Status = Failed
+		}
+		return nil
+	})
+}
+
+// runChunk runs one chunk, turning a panic into an error so it fails only
+// that chunk.
+func runChunk(ctx context.Context, task Task, params json.RawMessage, key string) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("panic: %v", p)
+		}
+	}()
+	return task.Run(ctx, params, key)
+}
+
+// transition moves a job to status from one of the allowed states.
+func (r *Runner) transition(id string, to Status, from ...Status) error {
+	return r.Store.Update(id, func(j *Job) error {
+		if !slices.Contains(from, j.Status) {
+			return fmt.Errorf("%w: %s is %s", ErrBadState, id, j.Status)
+		}
+		j.Status = to
+		return nil
+	})
+}
+
+func (r *Runner) report(err error) {
+	if r.OnError != nil {
+		r.OnError(err)
+	}
+}
+
+func (r *Runner) now() time.Time {
+	if r.Now != nil {
+		return r.Now()
+	}
+	return time.Now()
+}
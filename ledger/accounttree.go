@@ -0,0 +1,91 @@
+package ledger
+
+import (
+	"fmt"
+	"slices"
+)
+
+// AccountTree is the chart of accounts as a tree, built from the
+// ParentAccount links of the accounts. It answers the hierarchy questions
+// of GL queries: which accounts sit below a group, and which child of a
+// group an account rolls up to.
+//
+// Maps to: the lft/rgt NestedSet columns of the Account doctype
+type AccountTree struct {
+	parent   map[string]string
+	children map[string][]string
+}
+
+// NewAccountTree builds the tree. Every parent must be one of the
+// accounts and the links must not form a cycle.
+func NewAccountTree(accounts ...Account) (*AccountTree, error) {
+	t := &AccountTree{
+		parent:   make(map[string]string, len(accounts)),
+		children: make(map[string][]string),
+	}
+	for _, acc := range accounts {
+		t.parent[acc.Name] = acc.ParentAccount
+	}
+	for _, acc := range accounts {
+		if acc.ParentAccount == "" {
+			continue
+		}
+		if _, ok := t.parent[acc.ParentAccount]; !ok {
+			return nil, fmt.Errorf("%w: %s (parent of %s)", ErrAccountNotInTree, acc.ParentAccount, acc.Name)
+		}
+		t.children[acc.ParentAccount] = append(t.children[acc.ParentAccount], acc.Name)
+	}
+	for name := range t.parent {
+		seen := 0
+		for p := t.parent[name]; p != ""; p = t.parent[p] {
+			if p == name || seen > len(accounts) {
+				return nil, fmt.Errorf("%w: %s", ErrAccountTreeCycle, name)
+			}
+			seen++
+		}
+	}
+	for _, c := range t.children {
+		slices.Sort(c)
+	}
+	return t, nil
+}
+
+// Has reports whether the account is in the tree.
+func (t *AccountTree) Has(name string) bool {
+	_, ok := t.parent[name]
+	return ok
+}
+
+// Children returns the immediate children of an account, sorted.
+func (t *AccountTree) Children(name string) []string {
+	return slices.Clone(t.children[name])
+}
+
+// Descendants returns the account and every account below it, parents
+// before children.
+//
+// Maps to: get_descendants_of("Account", name) plus the account itself
+func (t *AccountTree) Descendants(name string) []string {
+	out := []string{name}
+	for i := 0; i < len(out); i++ {
+		out = append(out, t.children[out[i]]...)
+	}
+	return out
+}
+
+// IsDescendant reports whether account is ancestor or lies below it.
+func (t *AccountTree) IsDescendant(account, ancestor string) bool {
+	return t.ChildOf(account, ancestor) != "" || account == ancestor
+}
+
+// ChildOf returns the immediate child of ancestor that account rolls up
+// to: account itself when it is a child, or the child above it. It
+// returns "" when account is not below ancestor.
+func (t *AccountTree) ChildOf(account, ancestor string) string {
+	for a := account; a != ""; a = t.parent[a] {
+		if t.parent[a] == ancestor {
+			return a
+		}
+	}
+	return ""
+}
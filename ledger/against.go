@@ -0,0 +1,127 @@
+package ledger
+
+import (
+	"slices"
+	"strings"
+)
+
+// AgainstKind says what a name in a GL entry's Against field refers to.
+type AgainstKind string
+
+const (
+	AgainstAccount AgainstKind = "Account"
+	AgainstParty   AgainstKind = "Party"
+	AgainstUnknown AgainstKind = "" // Neither the voucher nor the chart of accounts knows the name
+)
+
+// AgainstRef is one counterparty of a GL entry: a counter account or a
+// party.
+type AgainstRef struct {
+	Kind      AgainstKind
+	PartyType string // Set for AgainstParty
+	Name      string
+}
+
+// ParseAgainst splits an Against string into its names, trimmed and
+// without duplicates.
+func ParseAgainst(against string) []string {
+	var names []string
+	for _, name := range strings.Split(against, ",") {
+		name = strings.TrimSpace(name)
+		if name != "" && !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// FormatAgainst renders refs as the legacy comma-separated Against string.
+func FormatAgainst(refs []AgainstRef) string {
+	names := make([]string, len(refs))
+	for i, ref := range refs {
+		names[i] = ref.Name
+	}
+	return strings.Join(names, ", ")
+}
+
+// ResolveAgainst keeps Against and AgainstRefs in sync on every entry of a
+// voucher. Entries with AgainstRefs get their Against string rebuilt from
+// them. Otherwise the names in Against are resolved: a party of another
+// entry in the voucher is a party ref with its party type, an account of
+// the voucher or one known to accounts is an account ref, anything else is
+// AgainstUnknown. accounts may be nil.
+//
+// Maps to: the "against" column set by get_gl_dict() callers in
+// accounts_controller.py, which reports split with split(",")
+func ResolveAgainst(glMap []GLEntry, accounts AccountLookup) {
+	// All refs share one backing array, so posting allocates per voucher
+	// rather than per entry.
+	n := 0
+	for i := range glMap {
+		if len(glMap[i].AgainstRefs) == 0 && glMap[i].Against != "" {
+			n += strings.Count(glMap[i].Against, ",") + 1
+		}
+	}
+	var refs []AgainstRef
+	var index againstIndex
+	if n > 0 {
+		refs = make([]AgainstRef, 0, n)
+		index = newAgainstIndex(glMap)
+	}
+
+	for i := range glMap {
+		entry := &glMap[i]
+		if len(entry.AgainstRefs) > 0 {
+			entry.Against = FormatAgainst(entry.AgainstRefs)
+			continue
+		}
+		start := len(refs)
+		for rest := entry.Against; rest != ""; {
+			var name string
+			name, rest, _ = strings.Cut(rest, ",")
+			name = strings.TrimSpace(name)
+			if name == "" || slices.ContainsFunc(refs[start:], func(r AgainstRef) bool { return r.Name == name }) {
+				continue
+			}
+			refs = append(refs, index.resolve(name, accounts))
+		}
+		if len(refs) > start {
+			entry.AgainstRefs = refs[start:len(refs):len(refs)]
+		}
+	}
+}
+
+// againstIndex holds the parties and accounts of a voucher.
+type againstIndex struct {
+	parties  map[string]string // Party -> party type
+	accounts map[string]bool
+}
+
+func newAgainstIndex(glMap []GLEntry) againstIndex {
+	index := againstIndex{parties: make(map[string]string), accounts: make(map[string]bool)}
+	for i := range glMap {
+		e := &glMap[i]
+		if e.Party != "" {
+			if _, ok := index.parties[e.Party]; !ok {
+				index.parties[e.Party] = e.PartyType
+			}
+		}
+		index.accounts[e.Account] = true
+	}
+	return index
+}
+
+func (x againstIndex) resolve(name string, accounts AccountLookup) AgainstRef {
+	if partyType, ok := x.parties[name]; ok {
+		return AgainstRef{Kind: AgainstParty, PartyType: partyType, Name: name}
+	}
+	if x.accounts[name] {
+		return AgainstRef{Kind: AgainstAccount, Name: name}
+	}
+	if accounts != nil {
+		if acc, err := accounts.GetAccount(name); err == nil && acc != nil {
+			return AgainstRef{Kind: AgainstAccount, Name: name}
+		}
+	}
+	return AgainstRef{Kind: AgainstUnknown, Name: name}
+}
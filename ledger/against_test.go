@@ -0,0 +1,69 @@
+package ledger
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseAgainst(t *testing.T) {
+	got := ParseAgainst(" Sales - ABC,Output Tax - ABC, , Sales - ABC ")
+	if want := []string{"Sales - ABC", "Output Tax - ABC"}; !slices.Equal(got, want) {
+		t.Errorf("ParseAgainst() = %q, want %q", got, want)
+	}
+	if got := ParseAgainst(""); got != nil {
+		t.Errorf("ParseAgainst(\"\") = %q, want nil", got)
+	}
+}
+
+func TestEngineResolvesAgainst(t *testing.T) {
+	store := &mockGLStore{}
+	engine := &Engine{GLStore: store, Accounts: newMockAccountLookup()}
+
+	receivable := makeTestGLEntry("Debtors - ABC", 100, 0)
+	receivable.PartyType, receivable.Party = "Customer", "Acme"
+	receivable.Against = "Sales - ABC, Cash - ABC, Someone Else"
+	sales := makeTestGLEntry("Sales - ABC", 0, 60)
+	sales.Against = "Acme"
+	cash := makeTestGLEntry("Cash - ABC", 0, 40)
+	cash.AgainstRefs = []AgainstRef{{Kind: AgainstParty, PartyType: "Customer", Name: "Acme"}}
+	cash.Against = "stale"
+
+	if err := engine.MakeGLEntries([]GLEntry{receivable, sales, cash}, DefaultPostingOptions()); err != nil {
+		t.Fatal(err)
+	}
+	byAccount := make(map[string]GLEntry)
+	for _, e := range store.entries {
+		byAccount[e.Account] = e
+	}
+
+	want := []AgainstRef{
+		{Kind: AgainstAccount, Name: "Sales - ABC"},
+		{Kind: AgainstAccount, Name: "Cash - ABC"},
+		{Kind: AgainstUnknown, Name: "Someone Else"},
+	}
+	if got := byAccount["Debtors - ABC"].AgainstRefs; !slices.Equal(got, want) {
+		t.Errorf("receivable refs = %+v", got)
+	}
+	party := []AgainstRef{{Kind: AgainstParty, PartyType: "Customer", Name: "Acme"}}
+	if got := byAccount["Sales - ABC"].AgainstRefs; !slices.Equal(got, party) {
+		t.Errorf("sales refs = %+v", got)
+	}
+	if got := byAccount["Cash - ABC"]; got.Against != "Acme" || !slices.Equal(got.AgainstRefs, party) {
+		t.Errorf("cash Against = %q, refs = %+v", got.Against, got.AgainstRefs)
+	}
+}
+
+func TestResolveAgainstChartOfAccounts(t *testing.T) {
+	glMap := []GLEntry{{Account: "Bank - ABC", Against: "Debtors - ABC, Unknown - ABC"}}
+	ResolveAgainst(glMap, newMockAccountLookup())
+	want := []AgainstRef{{Kind: AgainstAccount, Name: "Debtors - ABC"}, {Kind: AgainstUnknown, Name: "Unknown - ABC"}}
+	if !slices.Equal(glMap[0].AgainstRefs, want) {
+		t.Errorf("refs = %+v", glMap[0].AgainstRefs)
+	}
+
+	glMap = []GLEntry{{Account: "Bank - ABC", Against: "Debtors - ABC"}}
+	ResolveAgainst(glMap, nil)
+	if glMap[0].AgainstRefs[0].Kind != AgainstUnknown {
+		t.Errorf("without accounts: refs = %+v", glMap[0].AgainstRefs)
+	}
+}
@@ -0,0 +1,216 @@
+package ledger
+
+import (
+	"fmt"
+	"time"
+)
+
+// RelinkAgainstVoucher moves the references of other vouchers from a
+// cancelled document to its amendment, in both the GL and the payment
+// ledger, so payments allocated to the original settle the amendment.
+//
+// ERPNext unlinks such payments when the original is cancelled and leaves
+// the user to reconcile them again; keeping the link avoids that step.
+func (e *Engine) RelinkAgainstVoucher(voucherType, from, to string) error {
+	relinkers, err := e.relinkers()
+	if err != nil {
+		return err
+	}
+	for _, relinker := range relinkers {
+		if err := relinker.RelinkAgainstVoucher(voucherType, from, to); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CanRelinkAgainstVoucher returns an error wrapping ErrRelinkNotSupported
+// when a store of the engine cannot relink. Amendments check it before
+// they post, so they never post and then fail to take over the original's
+// payments.
+func (e *Engine) CanRelinkAgainstVoucher() error {
+	_, err := e.relinkers()
+	return err
+}
+
+// relinkers returns the engine's stores as relinkers.
+func (e *Engine) relinkers() ([]AgainstVoucherRelinker, error) {
+	var relinkers []AgainstVoucherRelinker
+	for _, store := range []any{e.GLStore, e.PaymentStore} {
+		if store == nil {
+			continue
+		}
+		relinker, ok := store.(AgainstVoucherRelinker)
+		if !ok {
+			return nil, fmt.Errorf("%w: %T", ErrRelinkNotSupported, store)
+		}
+		relinkers = append(relinkers, relinker)
+	}
+	return relinkers, nil
+}
+
+// AmendmentPolicy is how Amend books the corrected GL map of a voucher
+// that is already posted.
+type AmendmentPolicy string
+
+const (
+	// AmendByRepost reverses every entry of the voucher and posts the
+	// corrected map in full (the default)
+	AmendByRepost AmendmentPolicy = "Reverse and Repost"
+	// AmendByAdjustment posts only the differences, as adjustment entries
+	// under the same voucher
+	AmendByAdjustment AmendmentPolicy = "Post Differences"
+)
+
+// DiffGLEntries returns the entries that take a voucher's GL from before
+// to after: for each posting date and merge key, what after books less
+// what before does, with debit and credit netted in every currency. Keys
+// that do not change are left out, so equal maps give no entries. Entries
+// take their other fields from after where it has the key, from before
+// otherwise.
+//
+// before may hold cancelled entries with their reversals; they net to
+// nothing, so the entries of a voucher as read from the GL store can be
+// passed as they are.
+func DiffGLEntries(before, after []GLEntry, customFields []string) []GLEntry {
+	type key struct {
+		date  time.Time
+		merge mergeKey
+	}
+	index := make(map[key]int)
+	var delta []GLEntry
+	add := func(e GLEntry, sign float64) {
+		k := key{e.PostingDate, getMergeKey(e, customFields)}
+		i, ok := index[k]
+		if !ok {
+			i = len(delta)
+			index[k] = i
+			d := e.Copy()
+			d.Name, d.IsCancelled = "", false
+			d.Debit, d.Credit = 0, 0
+			d.DebitInAccountCurrency, d.CreditInAccountCurrency = 0, 0
+			d.DebitInTransactionCurrency, d.CreditInTransactionCurrency = 0, 0
+			d.DebitInReportingCurrency, d.CreditInReportingCurrency = 0, 0
+			delta = append(delta, d)
+		}
+		d := &delta[i]
+		d.Debit += sign * (e.Debit - e.Credit)
+		d.DebitInAccountCurrency += sign * (e.DebitInAccountCurrency - e.CreditInAccountCurrency)
+		d.DebitInTransactionCurrency += sign * (e.DebitInTransactionCurrency - e.CreditInTransactionCurrency)
+		d.DebitInReportingCurrency += sign * (e.DebitInReportingCurrency - e.CreditInReportingCurrency)
+	}
+	for _, e := range after {
+		add(e, 1)
+	}
+	for _, e := range before {
+		add(e, -1)
+	}
+
+	result := delta[:0]
+	for _, d := range delta {
+		d.Debit = Flt(d.Debit, 2)
+		d.DebitInAccountCurrency = Flt(d.DebitInAccountCurrency, 2)
+		d.DebitInTransactionCurrency = Flt(d.DebitInTransactionCurrency, 2)
+		d.DebitInReportingCurrency = Flt(d.DebitInReportingCurrency, 2)
+		if d.Debit == 0 && d.DebitInAccountCurrency == 0 {
+			continue
+		}
+		togglePair(&d.DebitInReportingCurrency, &d.CreditInReportingCurrency)
+		result = append(result, d)
+	}
+	return ToggleDebitCreditIfNegative(result)
+}
+
+// AmendmentDiff returns the entries Amend would post under
+// AmendByAdjustment: the corrected map processed as Post would, less what
+// the voucher has in the GL. Nothing is saved.
+func (e *Engine) AmendmentDiff(glMap []GLEntry, opts PostingOptions) ([]GLEntry, []Warning, error) {
+	if len(glMap) == 0 || e.GLStore == nil {
+		return nil, nil, nil
+	}
+	glMap = NormalizeDates(glMap)
+
+	var warnings []Warning
+	processedMap, err := e.prepareGLMap(glMap, opts, &warnings)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := e.processDebitCreditDifference(&processedMap); err != nil {
+		return nil, nil, err
+	}
+	existing, err := e.GLStore.GetByVoucher(glMap[0].VoucherType, glMap[0].VoucherNo)
+	if err != nil {
+		return nil, nil, err
+	}
+	return DiffGLEntries(existing, processedMap, e.MergeFields), warnings, nil
+}
+
+// Amend books the corrected GL map of a voucher that is already posted,
+// as the engine's Amendments policy says. Under AmendByRepost the voucher
+// is cancelled and the map posted again, each through Post. Under
+// AmendByAdjustment only the differences from AmendmentDiff are saved,
+// after the permission, approval, budget and accounting period checks;
+// a correction that changes nothing saves nothing. Either way, the
+// voucher's GL then adds up to the corrected map.
+//
+// Maps to: the repost of a submitted voucher's GL entries in
+// repost_accounting_ledger.py, which reverses and reposts in full
+func (e *Engine) Amend(glMap []GLEntry, opts PostingOptions) ([]Warning, error) {
+	if len(glMap) == 0 {
+		return nil, nil
+	}
+	opts.Cancel = false
+
+	if e.Amendments != AmendByAdjustment {
+		cancel := opts
+		cancel.Cancel = true
+		warnings, err := e.Post(glMap, cancel)
+		if err != nil {
+			return nil, err
+		}
+		posted, err := e.Post(glMap, opts)
+		if err != nil {
+			return nil, err
+		}
+		return append(warnings, posted...), nil
+	}
+
+	delta, warnings, err := e.AmendmentDiff(glMap, opts)
+	if err != nil || len(delta) == 0 {
+		return warnings, err
+	}
+	if e.Auth != nil {
+		if err := e.Auth.Authorize(opts.User, ActionPost, delta[0].VoucherType, delta[0].Company); err != nil {
+			return nil, err
+		}
+	}
+	if e.Gate != nil {
+		if err := e.Gate.AllowPosting(delta[0].VoucherType, delta[0].VoucherNo, false); err != nil {
+			return nil, err
+		}
+	}
+	if e.Budget != nil && delta[0].VoucherType != PeriodClosingVoucher {
+		if err := e.Policy.apply(CheckBudget, e.Budget.Validate(delta), &warnings); err != nil {
+			return nil, err
+		}
+	}
+	if e.Periods != nil {
+		if err := e.validateAccountingPeriod(delta); err != nil {
+			return nil, err
+		}
+	}
+	// GL first, as in Post, so a delta the freezing date or the store
+	// refuses leaves no payment ledger entries behind
+	if _, err := e.saveEntries(delta, opts, true); err != nil {
+		return nil, err
+	}
+	if e.PaymentStore != nil && delta[0].VoucherType != PeriodClosingVoucher {
+		if err := e.createPaymentLedgerEntries(delta, opts); err != nil {
+			return nil, err
+		}
+	}
+	if e.Events != nil {
+		e.Events.Publish(voucherEvent(EventGLPosted, delta))
+	}
+	return warnings, nil
+}
@@ -0,0 +1,123 @@
+package ledger
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// balances sums the debit less credit of a voucher's entries by account.
+func balances(entries []GLEntry) map[string]float64 {
+	b := map[string]float64{}
+	for _, e := range entries {
+		b[e.Account] = Flt(b[e.Account]+e.Debit-e.Credit, 2)
+	}
+	return b
+}
+
+func TestDiffGLEntries(t *testing.T) {
+	before := []GLEntry{makeTestGLEntry("Debtors - ABC", 100, 0), makeTestGLEntry("Sales - ABC", 0, 100)}
+	if delta := DiffGLEntries(before, before, nil); len(delta) != 0 {
+		t.Errorf("equal maps = %+v", delta)
+	}
+
+	// A lower amount and a second income account
+	after := []GLEntry{makeTestGLEntry("Debtors - ABC", 90, 0), makeTestGLEntry("Sales - ABC", 0, 80), makeTestGLEntry("Service - ABC", 0, 10)}
+	delta := DiffGLEntries(before, after, nil)
+	if len(delta) != 3 || delta[0].Credit != 10 || delta[1].Debit != 20 || delta[2].Credit != 10 || delta[1].CreditInAccountCurrency != 0 {
+		t.Errorf("DiffGLEntries() = %+v", delta)
+	}
+
+	// A new posting date moves every entry
+	moved := []GLEntry{makeTestGLEntry("Debtors - ABC", 100, 0), makeTestGLEntry("Sales - ABC", 0, 100)}
+	for i := range moved {
+		moved[i].PostingDate = moved[i].PostingDate.AddDate(0, 0, 1)
+	}
+	if delta := DiffGLEntries(before, moved, nil); len(delta) != 4 {
+		t.Errorf("moved = %+v", delta)
+	}
+}
+
+func TestAmend(t *testing.T) {
+	original := []GLEntry{makeTestGLEntry("Debtors - ABC", 100, 0), makeTestGLEntry("Sales - ABC", 0, 100)}
+	corrected := []GLEntry{makeTestGLEntry("Debtors - ABC", 110, 0), makeTestGLEntry("Sales - ABC", 0, 110)}
+
+	for _, tt := range []struct {
+		policy AmendmentPolicy
+		saved  int // Entries in the GL after the amendment
+	}{
+		{"", 6}, // Original, reversal, corrected
+		{AmendByAdjustment, 4},
+	} {
+		glStore := &mockGLStore{}
+		engine := &Engine{Accounts: newMockAccountLookup(), Company: &mockCompanySettings{}, GLStore: glStore, Amendments: tt.policy}
+		if err := engine.MakeGLEntries(original, DefaultPostingOptions()); err != nil {
+			t.Fatal(err)
+		}
+
+		delta, _, err := engine.AmendmentDiff(corrected, DefaultPostingOptions())
+		if err != nil || len(delta) != 2 || delta[0].Debit != 10 || delta[1].Credit != 10 || len(glStore.entries) != 2 {
+			t.Fatalf("AmendmentDiff() = %+v, %v", delta, err)
+		}
+		if _, err := engine.Amend(corrected, DefaultPostingOptions()); err != nil {
+			t.Fatal(err)
+		}
+		if len(glStore.entries) != tt.saved {
+			t.Errorf("%q saved %d entries, want %d", tt.policy, len(glStore.entries), tt.saved)
+		}
+		if b := balances(glStore.entries); b["Debtors - ABC"] != 110 || b["Sales - ABC"] != -110 {
+			t.Errorf("%q balances = %v", tt.policy, b)
+		}
+
+		// Amending again without a change books nothing more
+		if tt.policy == AmendByAdjustment {
+			if _, err := engine.Amend(corrected, DefaultPostingOptions()); err != nil || len(glStore.entries) != tt.saved {
+				t.Errorf("unchanged Amend() = %v, %d entries", err, len(glStore.entries))
+			}
+		}
+	}
+}
+
+// frozenTill freezes accounts till a date.
+type frozenTill struct {
+	mockCompanySettings
+	date time.Time
+}
+
+func (c *frozenTill) GetAccountsFrozenTillDate(string) (*time.Time, error) {
+	return &c.date, nil
+}
+
+// paymentLog records the payment ledger entries saved.
+type paymentLog struct{ entries []PaymentLedgerEntry }
+
+func (p *paymentLog) Save(e *PaymentLedgerEntry) error { return p.SaveBatch([]PaymentLedgerEntry{*e}) }
+func (p *paymentLog) SaveBatch(entries []PaymentLedgerEntry) error {
+	p.entries = append(p.entries, entries...)
+	return nil
+}
+func (p *paymentLog) GetByVoucher(string, string) ([]PaymentLedgerEntry, error) { return nil, nil }
+func (p *paymentLog) Delink(string, string) error                               { return nil }
+
+func TestAmendByAdjustmentSaveFails(t *testing.T) {
+	receivable := func(amount float64) GLEntry {
+		e := makeTestGLEntry("Debtors - ABC", amount, 0)
+		e.PartyType, e.Party = "Customer", "Acme"
+		return e
+	}
+	payments := &paymentLog{}
+	engine := &Engine{Accounts: newMockAccountLookup(), Company: &mockCompanySettings{}, GLStore: &mockGLStore{},
+		PaymentStore: payments, Amendments: AmendByAdjustment}
+	if err := engine.MakeGLEntries([]GLEntry{receivable(100), makeTestGLEntry("Sales - ABC", 0, 100)}, DefaultPostingOptions()); err != nil {
+		t.Fatal(err)
+	}
+
+	engine.Company = &frozenTill{date: makeTestDate().AddDate(0, 0, 1)}
+	_, err := engine.Amend([]GLEntry{receivable(110), makeTestGLEntry("Sales - ABC", 0, 110)}, DefaultPostingOptions())
+	if !errors.Is(err, ErrAccountsFrozenTill) {
+		t.Fatalf("Amend() = %v, want ErrAccountsFrozenTill", err)
+	}
+	if len(payments.entries) != 1 {
+		t.Errorf("%d payment ledger entries after a refused amendment, want 1", len(payments.entries))
+	}
+}
@@ -0,0 +1,196 @@
+package ledger
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Anonymizer rewrites the party names, voucher numbers and remarks of
+// ledger entries to stable pseudonyms, so that fixtures and snapshots
+// taken from a live company can be shared. Amounts, dates, accounts and
+// the links between entries are kept: a party or voucher gets the same
+// pseudonym wherever it appears, including in Against lists and against
+// voucher references, so allocations and matching still reproduce.
+//
+// Pseudonyms depend only on the salt and the original value, so fixtures
+// anonymized separately with the same salt stay consistent with each
+// other. Keep the salt secret; with a known salt, common names can be
+// guessed back from their pseudonyms.
+type Anonymizer struct {
+	Salt string
+
+	parties map[string]string // Party name -> pseudonym, for Against lists
+}
+
+// NewAnonymizer returns an anonymizer using salt.
+func NewAnonymizer(salt string) *Anonymizer {
+	return &Anonymizer{Salt: salt, parties: map[string]string{}}
+}
+
+// Party returns the pseudonym of a party, e.g. "Customer-3fa2c1d0".
+func (a *Anonymizer) Party(partyType, name string) string {
+	if name == "" {
+		return ""
+	}
+	p := partyType + "-" + a.hash("party", partyType, name)
+	if a.parties == nil {
+		a.parties = map[string]string{}
+	}
+	a.parties[name] = p
+	return p
+}
+
+// Voucher returns the pseudonym of a voucher number. It starts with the
+// initials of the voucher type, e.g. "SI-9b41e07a" for a Sales Invoice.
+func (a *Anonymizer) Voucher(voucherType, voucherNo string) string {
+	if voucherNo == "" {
+		return ""
+	}
+	var prefix strings.Builder
+	for _, word := range strings.Fields(voucherType) {
+		prefix.WriteByte(word[0])
+	}
+	return prefix.String() + "-" + a.hash("voucher", voucherType, voucherNo)
+}
+
+// Remarks returns the pseudonym of a free-text remark. Equal remarks get
+// equal pseudonyms; the text itself, which often names people, is lost.
+func (a *Anonymizer) Remarks(remarks string) string {
+	if remarks == "" {
+		return ""
+	}
+	return "Remark " + a.hash("remarks", "", remarks)
+}
+
+// GLEntries returns anonymized copies of entries. Custom fields are
+// dropped, since their content is not known.
+func (a *Anonymizer) GLEntries(entries []GLEntry) []GLEntry {
+	out := slices.Clone(entries)
+	for i := range out {
+		e := &out[i]
+		e.Party = a.Party(e.PartyType, e.Party)
+		e.VoucherNo = a.Voucher(e.VoucherType, e.VoucherNo)
+		e.AgainstVoucher = a.Voucher(e.AgainstVoucherType, e.AgainstVoucher)
+		e.Remarks = a.Remarks(e.Remarks)
+		e.Custom = nil
+		e.AgainstRefs = slices.Clone(e.AgainstRefs)
+		for j := range e.AgainstRefs {
+			if ref := &e.AgainstRefs[j]; ref.Kind == AgainstParty {
+				ref.Name = a.Party(ref.PartyType, ref.Name)
+			}
+		}
+	}
+	// Against lists name parties without their type; replace the parties
+	// seen so far
+	for i := range out {
+		e := &out[i]
+		if e.Against == "" {
+			continue
+		}
+		names := strings.Split(e.Against, ",")
+		for j, name := range names {
+			if p, ok := a.parties[strings.TrimSpace(name)]; ok {
+				names[j] = strings.Replace(name, strings.TrimSpace(name), p, 1)
+			}
+		}
+		e.Against = strings.Join(names, ",")
+	}
+	return out
+}
+
+// PaymentLedgerEntries returns anonymized copies of entries.
+func (a *Anonymizer) PaymentLedgerEntries(entries []PaymentLedgerEntry) []PaymentLedgerEntry {
+	out := slices.Clone(entries)
+	for i := range out {
+		e := &out[i]
+		e.Party = a.Party(e.PartyType, e.Party)
+		e.VoucherNo = a.Voucher(e.VoucherType, e.VoucherNo)
+		e.AgainstVoucherNo = a.Voucher(e.AgainstVoucherType, e.AgainstVoucherNo)
+	}
+	return out
+}
+
+// Snapshot anonymizes the entries of a snapshot in place.
+func (a *Anonymizer) Snapshot(s *LedgerSnapshot) {
+	// Payment ledger first, so that parties only found there are known
+	// when the GL Against lists are rewritten
+	s.PaymentLedgerEntries = a.PaymentLedgerEntries(s.PaymentLedgerEntries)
+	s.GLEntries = a.GLEntries(s.GLEntries)
+	s.Anonymized = true
+}
+
+// PseudonymizeParty replaces a party's name with token in an entry: as
+// its party, in its Against list and party refs, and in its remarks,
+// which often repeat the name. Remarks are free text, so only whole-word
+// occurrences are replaced there: erasing "Ann" leaves "Annual" alone. It
+// reports whether the entry changed.
+// Unlike Anonymizer, which copies a ledger for sharing, it is applied to
+// the stored entries when a party's personal data is erased.
+func (e *GLEntry) PseudonymizeParty(partyType, name, token string) bool {
+	if name == "" {
+		return false
+	}
+	changed := false
+	if e.PartyType == partyType && e.Party == name {
+		e.Party, changed = token, true
+	}
+	for i := range e.AgainstRefs {
+		if ref := &e.AgainstRefs[i]; ref.Kind == AgainstParty && ref.PartyType == partyType && ref.Name == name {
+			ref.Name, changed = token, true
+		}
+	}
+	if e.Against != "" {
+		names := strings.Split(e.Against, ",")
+		for i, n := range names {
+			if strings.TrimSpace(n) == name {
+				names[i], changed = strings.Replace(n, name, token, 1), true
+			}
+		}
+		e.Against = strings.Join(names, ",")
+	}
+	if remarks, ok := replaceWord(e.Remarks, name, token); ok {
+		e.Remarks, changed = remarks, true
+	}
+	return changed
+}
+
+// replaceWord replaces the occurrences of word in s that are not part of
+// a longer word with token, and reports whether there were any.
+func replaceWord(s, word, token string) (string, bool) {
+	var b strings.Builder
+	replaced := false
+	for start := 0; ; {
+		i := strings.Index(s[start:], word)
+		if i < 0 {
+			b.WriteString(s[start:])
+			break
+		}
+		i += start
+		end := i + len(word)
+		before, _ := utf8.DecodeLastRuneInString(s[:i])
+		after, _ := utf8.DecodeRuneInString(s[end:])
+		b.WriteString(s[start:i])
+		if isWordRune(before) || isWordRune(after) {
+			b.WriteString(word)
+		} else {
+			b.WriteString(token)
+			replaced = true
+		}
+		start = end
+	}
+	return b.String(), replaced
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// hash returns 8 hex digits identifying value within its kind.
+func (a *Anonymizer) hash(kind, qualifier, value string) string {
+	sum := sha256.Sum256([]byte(a.Salt + "\x00" + kind + "\x00" + qualifier + "\x00" + value))
+	return hex.EncodeToString(sum[:4])
+}
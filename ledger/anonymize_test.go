@@ -0,0 +1,70 @@
+package ledger
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAnonymizer(t *testing.T) {
+	gl := []GLEntry{
+		{VoucherType: "Sales Invoice", VoucherNo: "SI-1", Account: "Debtors", PartyType: "Customer", Party: "Jane Doe",
+			Against: "Sales", AgainstVoucherType: "Sales Invoice", AgainstVoucher: "SI-1", Debit: 100, Remarks: "Order for Jane"},
+		{VoucherType: "Sales Invoice", VoucherNo: "SI-1", Account: "Sales", Against: "Jane Doe, Round Off",
+			AgainstRefs: []AgainstRef{{Kind: AgainstParty, PartyType: "Customer", Name: "Jane Doe"}}, Credit: 100, Remarks: "Order for Jane"},
+		{VoucherType: "Payment Entry", VoucherNo: "PE-1", Account: "Debtors", PartyType: "Customer", Party: "Jane Doe",
+			AgainstVoucherType: "Sales Invoice", AgainstVoucher: "SI-1", Credit: 60},
+	}
+	a := NewAnonymizer("salt")
+	out := a.GLEntries(gl)
+
+	jane := out[0].Party
+	if !strings.HasPrefix(jane, "Customer-") || out[2].Party != jane || out[1].AgainstRefs[0].Name != jane {
+		t.Errorf("parties = %q, %q, %q", jane, out[2].Party, out[1].AgainstRefs[0].Name)
+	}
+	if want := jane + ", Round Off"; out[1].Against != want {
+		t.Errorf("Against = %q, want %q", out[1].Against, want)
+	}
+	si := out[0].VoucherNo
+	if !strings.HasPrefix(si, "SI-") || si == "SI-1" || out[1].VoucherNo != si || out[0].AgainstVoucher != si || out[2].AgainstVoucher != si {
+		t.Errorf("voucher numbers = %q, %q, against %q, %q", si, out[1].VoucherNo, out[0].AgainstVoucher, out[2].AgainstVoucher)
+	}
+	if out[0].Remarks == "" || out[0].Remarks != out[1].Remarks || strings.Contains(out[0].Remarks, "Jane") || out[2].Remarks != "" {
+		t.Errorf("remarks = %q, %q, %q", out[0].Remarks, out[1].Remarks, out[2].Remarks)
+	}
+	if out[0].Debit != 100 || out[2].Credit != 60 || out[1].Account != "Sales" {
+		t.Errorf("amounts or accounts changed: %+v", out)
+	}
+	if gl[0].Party != "Jane Doe" || gl[1].AgainstRefs[0].Name != "Jane Doe" || gl[0].VoucherNo != "SI-1" {
+		t.Error("source entries were modified")
+	}
+
+	// Payment ledger entries anonymized later, or by another anonymizer
+	// with the same salt, still match
+	ple := NewAnonymizer("salt").PaymentLedgerEntries([]PaymentLedgerEntry{
+		{VoucherType: "Payment Entry", VoucherNo: "PE-1", PartyType: "Customer", Party: "Jane Doe", AgainstVoucherType: "Sales Invoice", AgainstVoucherNo: "SI-1"},
+	})
+	if ple[0].Party != jane || ple[0].VoucherNo != out[2].VoucherNo || ple[0].AgainstVoucherNo != si {
+		t.Errorf("payment ledger entry = %+v", ple[0])
+	}
+	if other := NewAnonymizer("pepper").Party("Customer", "Jane Doe"); other == jane {
+		t.Error("salt does not change pseudonyms")
+	}
+}
+
+func TestPseudonymizeParty(t *testing.T) {
+	e := GLEntry{PartyType: "Customer", Party: "Ann", Against: "Ann, Annex Ltd", Remarks: "Annual fee for Ann (Ann's account), AnnAnn"}
+	if !e.PseudonymizeParty("Customer", "Ann", "ERASED-1") {
+		t.Fatal("entry not changed")
+	}
+	if e.Party != "ERASED-1" || e.Against != "ERASED-1, Annex Ltd" {
+		t.Errorf("party %q, against %q", e.Party, e.Against)
+	}
+	if want := "Annual fee for ERASED-1 (ERASED-1's account), AnnAnn"; e.Remarks != want {
+		t.Errorf("remarks = %q, want %q", e.Remarks, want)
+	}
+
+	other := GLEntry{PartyType: "Customer", Party: "Bob", Remarks: "Annual fee"}
+	if other.PseudonymizeParty("Customer", "Ann", "ERASED-1") || other.Remarks != "Annual fee" {
+		t.Errorf("changed %+v", other)
+	}
+}
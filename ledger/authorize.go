@@ -0,0 +1,44 @@
+package ledger
+
+import "slices"
+
+// Action is an operation checked by an Authorizer.
+type Action string
+
+const (
+	ActionPost         Action = "post"
+	ActionCancel       Action = "cancel"
+	ActionBypassFrozen Action = "bypass-frozen" // Post before the accounts frozen till date
+)
+
+// ReportScope is the part of a company's books a user may see.
+type ReportScope struct {
+	CostCenters []string // Empty for all cost centers
+}
+
+// Allows reports whether an entry is within the scope. Entries without a
+// cost center, such as balance sheet postings, are only visible to users
+// with access to every cost center.
+func (s ReportScope) Allows(entry GLEntry) bool {
+	return len(s.CostCenters) == 0 || slices.Contains(s.CostCenters, entry.CostCenter)
+}
+
+// AuthorizedEntries returns the entries of a company that a user may see
+// in a report. With a nil Authorizer the entries are returned unchanged.
+// Report generators call it before aggregating.
+func AuthorizedEntries(auth Authorizer, user, company string, entries []GLEntry) ([]GLEntry, error) {
+	if auth == nil {
+		return entries, nil
+	}
+	scope, err := auth.ReportScope(user, company)
+	if err != nil {
+		return nil, err
+	}
+	var result []GLEntry
+	for _, e := range entries {
+		if e.Company == company && scope.Allows(e) {
+			result = append(result, e)
+		}
+	}
+	return result, nil
+}
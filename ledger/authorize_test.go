@@ -0,0 +1,130 @@
+package ledger
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+)
+
+// mockAuthorizer grants actions per user and limits report cost centers.
+type mockAuthorizer struct {
+	actions     map[string][]Action
+	costCenters map[string][]string
+}
+
+func (m *mockAuthorizer) Authorize(user string, action Action, voucherType, company string) error {
+	if !slices.Contains(m.actions[user], action) {
+		return fmt.Errorf("%w: %s cannot %s %s", ErrNotPermitted, user, action, voucherType)
+	}
+	return nil
+}
+
+func (m *mockAuthorizer) ReportScope(user, company string) (ReportScope, error) {
+	if _, ok := m.actions[user]; !ok {
+		return ReportScope{}, fmt.Errorf("%w: %s has no access to %s", ErrNotPermitted, user, company)
+	}
+	return ReportScope{CostCenters: m.costCenters[user]}, nil
+}
+
+// frozenCompany freezes accounts till the end of 2025.
+type frozenCompany struct{ mockCompanySettings }
+
+func (*frozenCompany) GetAccountsFrozenTillDate(company string) (*time.Time, error) {
+	d := time.Date(2025, 12, 31, 0, 0, 0, 0, time.UTC)
+	return &d, nil
+}
+
+func testAuthorizer() *mockAuthorizer {
+	return &mockAuthorizer{
+		actions: map[string][]Action{
+			"clerk":   {ActionPost},
+			"manager": {ActionPost, ActionCancel, ActionBypassFrozen},
+			"auditor": {},
+		},
+		costCenters: map[string][]string{"clerk": {"Retail - ABC"}},
+	}
+}
+
+func TestEngineAuthorizer(t *testing.T) {
+	store := &mockGLStore{}
+	engine := &Engine{GLStore: store, Auth: testAuthorizer()}
+	glMap := func() []GLEntry {
+		return []GLEntry{makeTestGLEntry("Debtors - ABC", 100, 0), makeTestGLEntry("Sales - ABC", 0, 100)}
+	}
+
+	post := DefaultPostingOptions()
+	post.User = "auditor"
+	if err := engine.MakeGLEntries(glMap(), post); !errors.Is(err, ErrNotPermitted) {
+		t.Errorf("auditor post: got %v, want ErrNotPermitted", err)
+	}
+	post.User = "clerk"
+	if err := engine.MakeGLEntries(glMap(), post); err != nil {
+		t.Fatalf("clerk post: %v", err)
+	}
+
+	cancel := post
+	cancel.Cancel = true
+	if err := engine.MakeGLEntries(glMap(), cancel); !errors.Is(err, ErrNotPermitted) {
+		t.Errorf("clerk cancel: got %v, want ErrNotPermitted", err)
+	}
+	cancel.User = "manager"
+	if err := engine.MakeGLEntries(glMap(), cancel); err != nil {
+		t.Errorf("manager cancel: %v", err)
+	}
+}
+
+func TestEngineAuthorizerFrozen(t *testing.T) {
+	engine := &Engine{GLStore: &mockGLStore{}, Company: &frozenCompany{}, Auth: testAuthorizer()}
+	glMap := func() []GLEntry {
+		entries := []GLEntry{makeTestGLEntry("Debtors - ABC", 100, 0), makeTestGLEntry("Sales - ABC", 0, 100)}
+		for i := range entries {
+			entries[i].PostingDate = time.Date(2025, 6, 30, 0, 0, 0, 0, time.UTC)
+		}
+		return entries
+	}
+
+	opts := DefaultPostingOptions()
+	opts.User = "clerk"
+	if err := engine.MakeGLEntries(glMap(), opts); !errors.Is(err, ErrAccountsFrozenTill) {
+		t.Errorf("clerk in frozen period: got %v, want ErrAccountsFrozenTill", err)
+	}
+	opts.User = "manager"
+	if err := engine.MakeGLEntries(glMap(), opts); err != nil {
+		t.Errorf("manager in frozen period: %v", err)
+	}
+}
+
+func TestAuthorizedEntries(t *testing.T) {
+	entries := []GLEntry{
+		{Company: "ABC Company", Account: "Sales - ABC", CostCenter: "Retail - ABC", Credit: 100},
+		{Company: "ABC Company", Account: "Sales - ABC", CostCenter: "Wholesale - ABC", Credit: 200},
+		{Company: "ABC Company", Account: "Debtors - ABC", Debit: 300},
+		{Company: "XYZ Company", Account: "Sales - XYZ", CostCenter: "Retail - ABC", Credit: 50},
+	}
+	auth := testAuthorizer()
+
+	tests := []struct {
+		user string
+		want int
+		err  error
+	}{
+		{"clerk", 1, nil},
+		{"manager", 3, nil},
+		{"stranger", 0, ErrNotPermitted},
+	}
+	for _, tt := range tests {
+		got, err := AuthorizedEntries(auth, tt.user, "ABC Company", entries)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("%s: error = %v, want %v", tt.user, err, tt.err)
+		}
+		if len(got) != tt.want {
+			t.Errorf("%s: got %d entries, want %d", tt.user, len(got), tt.want)
+		}
+	}
+
+	if got, _ := AuthorizedEntries(nil, "", "ABC Company", entries); len(got) != len(entries) {
+		t.Errorf("nil authorizer filtered entries")
+	}
+}
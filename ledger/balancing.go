@@ -0,0 +1,144 @@
+package ledger
+
+import (
+	"cmp"
+	"fmt"
+)
+
+// BalancingDimension is a dimension, such as the cost center or a branch,
+// whose debits and credits must agree within every voucher, so that each
+// of its values keeps a balance sheet of its own. ERPNext itself only
+// offsets dimensions, see makeAccDimensionsOffsettingEntry; this is the
+// balancing segment of other ERPs.
+type BalancingDimension struct {
+	// Field is "cost_center", "project", or the custom field of GL entries
+	// that holds the dimension, such as "branch". A custom field must be
+	// one of the engine's MergeFields, or merging would mix its values.
+	Field string
+
+	// ClearingAccount is the inter-dimension clearing account. The engine
+	// adds an entry to it for each value left unbalanced by a voucher,
+	// carrying that value; the clearing entries of a voucher net to
+	// nothing. When empty, unbalanced vouchers are rejected instead.
+	ClearingAccount string
+}
+
+// dimensionValue returns the value of a dimension on an entry.
+func dimensionValue(e *GLEntry, field string) string {
+	switch field {
+	case "cost_center":
+		return e.CostCenter
+	case "project":
+		return e.Project
+	}
+	return e.Custom.String(field)
+}
+
+// dimensionBalances returns the debit minus credit of each value of a
+// dimension within a GL map, and the values in the order they appear.
+func dimensionBalances(glMap []GLEntry, field string) (map[string]float64, []string) {
+	balances := map[string]float64{}
+	var order []string
+	for i := range glMap {
+		v := dimensionValue(&glMap[i], field)
+		if _, ok := balances[v]; !ok {
+			order = append(order, v)
+		}
+		balances[v] += Flt(glMap[i].Debit, 2) - Flt(glMap[i].Credit, 2)
+	}
+	return balances, order
+}
+
+// makeDimensionClearingEntries adds to glMap the clearing entries that
+// balance each of the engine's Balancing dimensions. Dimensions without
+// a clearing account are left to validateBalancingDimensions.
+func (e *Engine) makeDimensionClearingEntries(glMap *[]GLEntry) error {
+	for _, dim := range e.Balancing {
+		if dim.ClearingAccount == "" {
+			continue
+		}
+		balances, order := dimensionBalances(*glMap, dim.Field)
+		for _, v := range order {
+			diff := Flt(balances[v], 2)
+			if diff == 0 {
+				continue
+			}
+			entry, err := e.clearingEntry(&(*glMap)[0], dim, v, diff)
+			if err != nil {
+				return err
+			}
+			*glMap = append(*glMap, entry)
+		}
+	}
+	return nil
+}
+
+// clearingEntry returns the entry to a dimension's clearing account that
+// clears diff, the company currency debit minus credit of value in a
+// voucher. It takes the voucher's fields from voucher and carries value
+// in the dimension's field alone: the other dimensions, the party and
+// the currencies of the entries it clears are not its own. The clearing
+// account must be in the company currency.
+func (e *Engine) clearingEntry(voucher *GLEntry, dim BalancingDimension, value string, diff float64) (GLEntry, error) {
+	var currency, companyCurrency string
+	var err error
+	if e.Accounts != nil {
+		if currency, err = e.Accounts.GetAccountCurrency(dim.ClearingAccount); err != nil {
+			return GLEntry{}, err
+		}
+	}
+	if e.Company != nil {
+		if companyCurrency, err = e.Company.GetDefaultCurrency(voucher.Company); err != nil {
+			return GLEntry{}, err
+		}
+	}
+	if currency != "" && companyCurrency != "" && currency != companyCurrency {
+		return GLEntry{}, NewValidationError(ErrCurrencyMismatch, dim.ClearingAccount,
+			fmt.Sprintf("clearing account is in %s, not the company currency %s", currency, companyCurrency))
+	}
+
+	entry := GLEntry{
+		PostingDate:     voucher.PostingDate,
+		TransactionDate: voucher.TransactionDate,
+		Account:         dim.ClearingAccount,
+		AccountCurrency: cmp.Or(currency, companyCurrency),
+		VoucherType:     voucher.VoucherType,
+		VoucherNo:       voucher.VoucherNo,
+		VoucherSubtype:  voucher.VoucherSubtype,
+		Company:         voucher.Company,
+		FiscalYear:      voucher.FiscalYear,
+		FinanceBook:     voucher.FinanceBook,
+		IsOpening:       voucher.IsOpening,
+		IsAdvance:       IsAdvanceNo,
+		Remarks:         fmt.Sprintf("Clearing for Balancing Dimension - %s", dim.Field),
+	}
+	switch dim.Field {
+	case "cost_center":
+		entry.CostCenter = value
+	case "project":
+		entry.Project = value
+	default:
+		if value != "" {
+			entry.Custom.Set(dim.Field, value)
+		}
+	}
+	entry.Debit, entry.Credit = max(-diff, 0), max(diff, 0)
+	entry.DebitInAccountCurrency, entry.CreditInAccountCurrency = entry.Debit, entry.Credit
+	return entry, nil
+}
+
+// validateBalancingDimensions returns an error wrapping
+// ErrDimensionUnbalanced for the first value of a Balancing dimension
+// whose debits and credits differ.
+func (e *Engine) validateBalancingDimensions(glMap []GLEntry) error {
+	for _, dim := range e.Balancing {
+		balances, order := dimensionBalances(glMap, dim.Field)
+		for _, v := range order {
+			if diff := Flt(balances[v], 2); diff != 0 {
+				return fmt.Errorf("%w: %s %q of %s %s is out by %.2f",
+					ErrDimensionUnbalanced, dim.Field, v, glMap[0].VoucherType, glMap[0].VoucherNo, diff)
+			}
+		}
+	}
+	return nil
+}
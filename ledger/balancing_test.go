@@ -0,0 +1,100 @@
+package ledger
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestBalancingDimensions(t *testing.T) {
+	// Rent of the North office paid from head office cash
+	glMap := func() []GLEntry {
+		rent, cash := makeTestGLEntry("Rent - ABC", 100, 0), makeTestGLEntry("Cash - ABC", 0, 100)
+		rent.CostCenter, cash.CostCenter = "North - ABC", "Head Office - ABC"
+		rent.Custom.Set("branch", "North")
+		cash.Custom.Set("branch", "Head Office")
+		return []GLEntry{rent, cash}
+	}
+	balanced := func(t *testing.T, entries []GLEntry, field string) {
+		t.Helper()
+		balances, _ := dimensionBalances(entries, field)
+		for v, diff := range balances {
+			if Flt(diff, 2) != 0 {
+				t.Errorf("%s %q is out by %v", field, v, diff)
+			}
+		}
+	}
+
+	t.Run("clearing", func(t *testing.T) {
+		store := &mockGLStore{}
+		engine := &Engine{GLStore: store, Balancing: []BalancingDimension{{Field: "cost_center", ClearingAccount: "Inter Office Clearing - ABC"}}}
+		if err := engine.MakeGLEntries(glMap(), DefaultPostingOptions()); err != nil {
+			t.Fatal(err)
+		}
+		if len(store.entries) != 4 {
+			t.Fatalf("%d entries, want 4: %+v", len(store.entries), store.entries)
+		}
+		balanced(t, store.entries, "cost_center")
+		for _, e := range store.entries[2:] {
+			if e.Account != "Inter Office Clearing - ABC" {
+				t.Errorf("clearing entry on %s", e.Account)
+			}
+		}
+	})
+
+	t.Run("clearing entry", func(t *testing.T) {
+		// A euro bank account of a party's branch, paid into from head office
+		entries := glMap()
+		entries[0].Account, entries[0].AccountCurrency = "Bank EUR - ABC", "EUR"
+		entries[0].DebitInAccountCurrency, entries[0].PartyType, entries[0].Party = 90, "Customer", "Acme"
+		entries[0].Project = "Rollout"
+		accounts := newMockAccountLookup()
+		accounts.accounts["Inter Office Clearing - ABC"] = &Account{Name: "Inter Office Clearing - ABC", AccountCurrency: "USD"}
+		accounts.accounts["Bank EUR - ABC"] = &Account{Name: "Bank EUR - ABC", AccountCurrency: "EUR"}
+		store := &mockGLStore{}
+		dims := []BalancingDimension{{Field: "cost_center", ClearingAccount: "Inter Office Clearing - ABC"}}
+		engine := &Engine{GLStore: store, Accounts: accounts, Company: &mockCompanySettings{}, Balancing: dims}
+		if err := engine.MakeGLEntries(entries, DefaultPostingOptions()); err != nil {
+			t.Fatal(err)
+		}
+		clearing := store.entries[len(store.entries)-2]
+		if clearing.CostCenter != "North - ABC" || clearing.Credit != 100 || clearing.CreditInAccountCurrency != 100 ||
+			clearing.AccountCurrency != "USD" || clearing.Party != "" || clearing.Project != "" || clearing.Custom.String("branch") != "" {
+			t.Errorf("clearing entry = %+v", clearing)
+		}
+
+		accounts.accounts["Inter Office Clearing - ABC"].AccountCurrency = "EUR"
+		if err := engine.MakeGLEntries(glMap(), DefaultPostingOptions()); !errors.Is(err, ErrCurrencyMismatch) {
+			t.Errorf("clearing account in EUR: err = %v, want ErrCurrencyMismatch", err)
+		}
+	})
+
+	t.Run("reject", func(t *testing.T) {
+		store := &mockGLStore{}
+		engine := &Engine{GLStore: store, Balancing: []BalancingDimension{{Field: "cost_center"}}}
+		if err := engine.MakeGLEntries(glMap(), DefaultPostingOptions()); !errors.Is(err, ErrDimensionUnbalanced) {
+			t.Errorf("err = %v, want ErrDimensionUnbalanced", err)
+		}
+		if len(store.entries) != 0 {
+			t.Errorf("%d entries saved", len(store.entries))
+		}
+	})
+
+	t.Run("custom field", func(t *testing.T) {
+		// Without the field among the merge fields, the clearing entries
+		// of both branches merge into one and the check catches it
+		dims := []BalancingDimension{{Field: "branch", ClearingAccount: "Inter Branch Clearing - ABC"}}
+		same := glMap()
+		same[1].CostCenter = same[0].CostCenter
+		engine := &Engine{GLStore: &mockGLStore{}, Balancing: dims}
+		if err := engine.MakeGLEntries(same, DefaultPostingOptions()); !errors.Is(err, ErrDimensionUnbalanced) {
+			t.Errorf("without merge fields: err = %v, want ErrDimensionUnbalanced", err)
+		}
+
+		store := &mockGLStore{}
+		engine = &Engine{GLStore: store, Balancing: dims, MergeFields: []string{"branch"}}
+		if err := engine.MakeGLEntries(same, DefaultPostingOptions()); err != nil {
+			t.Fatal(err)
+		}
+		balanced(t, store.entries, "branch")
+	})
+}
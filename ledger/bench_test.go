@@ -0,0 +1,62 @@
+package ledger_test
+
+import (
+	"testing"
+
+	"github.com/senguttuvang/erpnext-go/ledger"
+	"github.com/senguttuvang/erpnext-go/perf"
+)
+
+// Benchmarks for the posting hot path. Run with:
+//
+//	go test -run=^$ -bench=. -benchmem ./ledger
+
+func benchmarkMergeSimilarEntries(b *testing.B, n int) {
+	glMap := perf.GenerateGLMap(n)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ledger.MergeSimilarEntries(glMap)
+	}
+}
+
+func BenchmarkMergeSimilarEntries_10k(b *testing.B)  { benchmarkMergeSimilarEntries(b, 10000) }
+func BenchmarkMergeSimilarEntries_100k(b *testing.B) { benchmarkMergeSimilarEntries(b, 100000) }
+
+type discardStore struct{}
+
+func (discardStore) Save(entry *ledger.GLEntry) error                  { return nil }
+func (discardStore) SaveBatch(entries []ledger.GLEntry) error          { return nil }
+func (discardStore) MarkCancelled(voucherType, voucherNo string) error { return nil }
+func (discardStore) GetByVoucher(voucherType, voucherNo string) ([]ledger.GLEntry, error) {
+	return nil, nil
+}
+
+func benchmarkMakeGLEntries(b *testing.B, n int) {
+	glMap := perf.GenerateGLMap(n)
+	engine := &ledger.Engine{GLStore: discardStore{}}
+	opts := ledger.DefaultPostingOptions()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := engine.MakeGLEntries(glMap, opts); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkMakeGLEntries_10k(b *testing.B)  { benchmarkMakeGLEntries(b, 10000) }
+func BenchmarkMakeGLEntries_100k(b *testing.B) { benchmarkMakeGLEntries(b, 100000) }
+
+func BenchmarkProcessGLMapInPlace_100k(b *testing.B) {
+	source := perf.GenerateGLMap(100000)
+	glMap := make([]ledger.GLEntry, len(source))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		copy(glMap, source)
+		b.StartTimer()
+		ledger.ProcessGLMapInPlace(glMap, true)
+	}
+}
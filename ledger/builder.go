@@ -0,0 +1,243 @@
+package ledger
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// EntryBuilder builds the GL map of a voucher line by line. Voucher-level
+// setters (Company, PostingDate, ForVoucher, Remarks, ...) apply to every
+// line; Account starts a new line and the setters after it apply to that
+// line only:
+//
+//	glMap, err := ledger.NewEntryBuilder().
+//		WithEngine(engine).
+//		Company("ACME").PostingDate(date).ForVoucher("Sales Invoice", "SINV-1").
+//		Account("Debtors - ACME").Debit(11800).Party("Customer", "Acme").
+//		Account("Sales - ACME").Credit(10000).CostCenter("Main - ACME").
+//		Account("Output Tax - ACME").Credit(1800).
+//		Build()
+//
+// Build fills what the struct literal would otherwise spell out: account
+// and transaction currency amounts, the account currency, the fiscal year
+// and Against. Vouchers are taken to be in the company currency.
+//
+// Maps to: get_gl_dict() in controllers/accounts_controller.py
+type EntryBuilder struct {
+	header GLEntry
+	lines  []builderLine
+
+	accounts    AccountLookup
+	company     CompanySettings
+	fiscalYears FiscalYearLookup
+}
+
+type builderLine struct {
+	entry GLEntry
+	rate  float64 // Company currency per unit of account currency; 0 if not set
+}
+
+// NewEntryBuilder returns an empty builder.
+func NewEntryBuilder() *EntryBuilder {
+	return &EntryBuilder{header: GLEntry{IsOpening: IsOpeningNo, IsAdvance: IsAdvanceNo}}
+}
+
+// WithEngine looks up account currencies, the company currency and fiscal
+// years through the engine's ports. Without it, lines are in the company
+// currency and the fiscal year is left empty.
+func (b *EntryBuilder) WithEngine(e *Engine) *EntryBuilder {
+	b.accounts, b.company, b.fiscalYears = e.Accounts, e.Company, e.FiscalYears
+	return b
+}
+
+// Company sets the company of every line.
+func (b *EntryBuilder) Company(company string) *EntryBuilder {
+	b.header.Company = company
+	return b
+}
+
+// PostingDate sets the posting and transaction date of every line.
+func (b *EntryBuilder) PostingDate(date time.Time) *EntryBuilder {
+	b.header.PostingDate, b.header.TransactionDate = date, date
+	return b
+}
+
+// ForVoucher sets the source document of every line.
+func (b *EntryBuilder) ForVoucher(voucherType, voucherNo string) *EntryBuilder {
+	b.header.VoucherType, b.header.VoucherNo = voucherType, voucherNo
+	return b
+}
+
+// Remarks sets the remarks of every line.
+func (b *EntryBuilder) Remarks(remarks string) *EntryBuilder {
+	b.header.Remarks = remarks
+	return b
+}
+
+// Opening marks every line as an opening entry.
+func (b *EntryBuilder) Opening() *EntryBuilder {
+	b.header.IsOpening = IsOpeningYes
+	return b
+}
+
+// Account starts a new line posting to account. The line takes the
+// voucher-level fields set so far and any set later.
+func (b *EntryBuilder) Account(account string) *EntryBuilder {
+	b.lines = append(b.lines, builderLine{entry: GLEntry{Account: account}})
+	return b
+}
+
+// line returns the current line, starting an unnamed one if Account has
+// not been called; Build reports it.
+func (b *EntryBuilder) line() *builderLine {
+	if len(b.lines) == 0 {
+		b.Account("")
+	}
+	return &b.lines[len(b.lines)-1]
+}
+
+// Debit sets the line's debit in company currency.
+func (b *EntryBuilder) Debit(amount float64) *EntryBuilder {
+	b.line().entry.Debit = amount
+	return b
+}
+
+// Credit sets the line's credit in company currency.
+func (b *EntryBuilder) Credit(amount float64) *EntryBuilder {
+	b.line().entry.Credit = amount
+	return b
+}
+
+// ExchangeRate sets the line's company currency per unit of account
+// currency, for accounts in a foreign currency.
+func (b *EntryBuilder) ExchangeRate(rate float64) *EntryBuilder {
+	b.line().rate = rate
+	return b
+}
+
+// Party sets the line's party.
+func (b *EntryBuilder) Party(partyType, party string) *EntryBuilder {
+	l := b.line()
+	l.entry.PartyType, l.entry.Party = partyType, party
+	return b
+}
+
+// CostCenter sets the line's cost center.
+func (b *EntryBuilder) CostCenter(costCenter string) *EntryBuilder {
+	b.line().entry.CostCenter = costCenter
+	return b
+}
+
+// Project sets the line's project.
+func (b *EntryBuilder) Project(project string) *EntryBuilder {
+	b.line().entry.Project = project
+	return b
+}
+
+// AgainstVoucher links the line to the document it settles.
+func (b *EntryBuilder) AgainstVoucher(voucherType, voucherNo string) *EntryBuilder {
+	l := b.line()
+	l.entry.AgainstVoucherType, l.entry.AgainstVoucher = voucherType, voucherNo
+	return b
+}
+
+// Against sets the line's counter accounts and parties instead of deriving
+// them.
+func (b *EntryBuilder) Against(against string) *EntryBuilder {
+	b.line().entry.Against = against
+	return b
+}
+
+// Build returns the GL map. Each line gets the voucher-level fields, its
+// account currency and amounts in it (converted at the line's exchange
+// rate when the account is in a foreign currency), amounts in the company
+// currency as the transaction currency, the fiscal year of the posting date
+// and, unless set, Against: the parties, or else accounts, of the lines on
+// the other side.
+func (b *EntryBuilder) Build() ([]GLEntry, error) {
+	companyCurrency := ""
+	if b.company != nil && b.header.Company != "" {
+		c, err := b.company.GetDefaultCurrency(b.header.Company)
+		if err != nil {
+			return nil, err
+		}
+		companyCurrency = c
+	}
+	fiscalYear := ""
+	if b.fiscalYears != nil && !b.header.PostingDate.IsZero() {
+		fy, err := b.fiscalYears.GetFiscalYear(b.header.PostingDate, b.header.Company)
+		if err != nil {
+			return nil, err
+		}
+		fiscalYear = fy
+	}
+
+	glMap := make([]GLEntry, len(b.lines))
+	for i, l := range b.lines {
+		if l.entry.Account == "" {
+			return nil, fmt.Errorf("%w: line %d", ErrMissingAccount, i+1)
+		}
+		entry := b.header
+		entry.Account = l.entry.Account
+		entry.Debit, entry.Credit = l.entry.Debit, l.entry.Credit
+		entry.PartyType, entry.Party = l.entry.PartyType, l.entry.Party
+		entry.CostCenter, entry.Project = l.entry.CostCenter, l.entry.Project
+		entry.AgainstVoucherType, entry.AgainstVoucher = l.entry.AgainstVoucherType, l.entry.AgainstVoucher
+		entry.Against = l.entry.Against
+		entry.FiscalYear = fiscalYear
+
+		entry.AccountCurrency = companyCurrency
+		if b.accounts != nil {
+			currency, err := b.accounts.GetAccountCurrency(entry.Account)
+			if err != nil {
+				return nil, err
+			}
+			if currency != "" {
+				entry.AccountCurrency = currency
+			}
+		}
+		rate := 1.0
+		if entry.AccountCurrency != companyCurrency && companyCurrency != "" {
+			if l.rate <= 0 {
+				return nil, NewValidationError(ErrCurrencyMismatch, entry.Account,
+					fmt.Sprintf("exchange rate needed to post %s to a %s account", companyCurrency, entry.AccountCurrency))
+			}
+			rate = l.rate
+		}
+		entry.DebitInAccountCurrency = Flt(entry.Debit/rate, 2)
+		entry.CreditInAccountCurrency = Flt(entry.Credit/rate, 2)
+		entry.TransactionCurrency, entry.TransactionExchangeRate = companyCurrency, 1
+		entry.DebitInTransactionCurrency, entry.CreditInTransactionCurrency = entry.Debit, entry.Credit
+		glMap[i] = entry
+	}
+
+	for i := range glMap {
+		if glMap[i].Against == "" {
+			glMap[i].Against = counterparts(glMap, glMap[i].Debit > glMap[i].Credit)
+		}
+	}
+	return glMap, nil
+}
+
+// counterparts lists, without duplicates, the party (or account, when
+// there is none) of each line on the credit side, or the debit side when
+// debit is false.
+func counterparts(glMap []GLEntry, debit bool) string {
+	var names []string
+	for i := range glMap {
+		e := &glMap[i]
+		if e.Debit == e.Credit || (e.Debit > e.Credit) == debit {
+			continue
+		}
+		name := e.Party
+		if name == "" {
+			name = e.Account
+		}
+		if !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	return strings.Join(names, ", ")
+}
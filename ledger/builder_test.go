@@ -0,0 +1,72 @@
+package ledger
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+type stubFiscalYears struct{}
+
+func (stubFiscalYears) GetFiscalYear(date time.Time, company string) (string, error) {
+	return "FY-" + date.Format("2006"), nil
+}
+
+func (stubFiscalYears) GetFiscalYearDates(string, string) (time.Time, time.Time, error) {
+	return time.Time{}, time.Time{}, nil
+}
+
+func TestEntryBuilder(t *testing.T) {
+	accounts := newMockAccountLookup()
+	accounts.accounts["Debtors EUR - ABC"] = &Account{Name: "Debtors EUR - ABC", AccountCurrency: "EUR"}
+	engine := &Engine{Accounts: accounts, Company: &mockCompanySettings{}, FiscalYears: stubFiscalYears{}}
+
+	glMap, err := NewEntryBuilder().WithEngine(engine).
+		Company("ABC Company").PostingDate(makeTestDate()).ForVoucher("Sales Invoice", "SINV-001").
+		Account("Debtors EUR - ABC").Debit(1100).ExchangeRate(1.1).Party("Customer", "Globex").
+		Account("Sales - ABC").Credit(1000).CostCenter("Main - ABC").
+		Account("Cash - ABC").Credit(100).
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(glMap) != 3 {
+		t.Fatalf("%d entries", len(glMap))
+	}
+	debtors, sales := glMap[0], glMap[1]
+	if debtors.AccountCurrency != "EUR" || debtors.DebitInAccountCurrency != 1000 || debtors.Debit != 1100 {
+		t.Errorf("debtors = %s %v / %v", debtors.AccountCurrency, debtors.DebitInAccountCurrency, debtors.Debit)
+	}
+	if sales.AccountCurrency != "USD" || sales.CreditInAccountCurrency != 1000 || sales.CostCenter != "Main - ABC" || glMap[2].CostCenter != "" {
+		t.Errorf("sales = %+v", sales)
+	}
+	if debtors.TransactionCurrency != "USD" || debtors.TransactionExchangeRate != 1 || debtors.DebitInTransactionCurrency != 1100 {
+		t.Errorf("debtors in transaction currency = %s %v at %v", debtors.TransactionCurrency, debtors.DebitInTransactionCurrency, debtors.TransactionExchangeRate)
+	}
+	if debtors.Against != "Sales - ABC, Cash - ABC" || sales.Against != "Globex" {
+		t.Errorf("against = %q / %q", debtors.Against, sales.Against)
+	}
+	for _, e := range glMap {
+		if e.FiscalYear != "FY-2026" || e.VoucherNo != "SINV-001" || e.Company != "ABC Company" || e.IsOpening != IsOpeningNo {
+			t.Errorf("voucher fields = %+v", e)
+		}
+	}
+	if !GLMap(glMap).IsBalanced() {
+		t.Error("not balanced")
+	}
+
+	// Foreign currency accounts need a rate; every line needs an account
+	if _, err := NewEntryBuilder().WithEngine(engine).Company("ABC Company").
+		Account("Debtors EUR - ABC").Debit(10).Build(); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("missing rate: err = %v", err)
+	}
+	if _, err := NewEntryBuilder().Debit(10).Build(); !errors.Is(err, ErrMissingAccount) {
+		t.Errorf("missing account: err = %v", err)
+	}
+
+	// Without an engine amounts are taken as they are
+	glMap, _ = NewEntryBuilder().Account("A").Debit(5).Account("B").Credit(5).Against("X").Build()
+	if glMap[0].DebitInAccountCurrency != 5 || glMap[0].Against != "B" || glMap[1].Against != "X" {
+		t.Errorf("no engine = %+v", glMap)
+	}
+}
@@ -0,0 +1,282 @@
+// calendar.go implements accounting calendars with custom period boundaries.
+// ERPNext buckets reports by calendar month, but retail and manufacturing
+// companies often close books on 4-4-5 week patterns or 13 equal periods.
+//
+// Maps to: erpnext/accounts/report/financial_statements.py get_period_list()
+package ledger
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/daterange"
+)
+
+// CalendarType identifies how a fiscal year is split into periods.
+type CalendarType string
+
+const (
+	CalendarMonthly  CalendarType = "Monthly"
+	Calendar445      CalendarType = "4-4-5"
+	Calendar454      CalendarType = "4-5-4"
+	Calendar544      CalendarType = "5-4-4"
+	Calendar13Period CalendarType = "13 Period"
+	CalendarCustom   CalendarType = "Custom"
+)
+
+// PeriodDefinition is a single reporting period within a fiscal year.
+// Both StartDate and EndDate are inclusive, matching ERPNext's
+// from_date/to_date convention on Fiscal Year and Accounting Period.
+type PeriodDefinition struct {
+	Name       string    // Display name (e.g., "P01 2024-2025", "Apr 2024")
+	FiscalYear string    // Fiscal year the period belongs to
+	Index      int       // 1-based position within the fiscal year
+	StartDate  time.Time // First day of the period
+	EndDate    time.Time // Last day of the period
+}
+
+// Range returns the period as a half-open date range.
+func (p PeriodDefinition) Range() daterange.Range {
+	return daterange.Inclusive(p.StartDate, p.EndDate)
+}
+
+// Contains returns true if the date falls within the period.
+func (p PeriodDefinition) Contains(date time.Time) bool {
+	return p.Range().Contains(date)
+}
+
+// AccountingCalendarLookup resolves the reporting periods of a fiscal year.
+// It complements FiscalYearLookup for companies that do not report on
+// calendar months.
+type AccountingCalendarLookup interface {
+	// GetPeriods returns the ordered periods of a fiscal year for a company.
+	GetPeriods(fiscalYear string, company string) ([]PeriodDefinition, error)
+}
+
+// MonthlyPeriods splits a fiscal year into calendar months.
+// The first and last periods are clipped to the fiscal year boundaries.
+func MonthlyPeriods(fiscalYear string, start, end time.Time) []PeriodDefinition {
+	start, end = CivilDate(start), CivilDate(end)
+
+	var periods []PeriodDefinition
+	for cur := start; !cur.After(end); {
+		next := time.Date(cur.Year(), cur.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+		last := next.AddDate(0, 0, -1)
+		if last.After(end) {
+			last = end
+		}
+		periods = append(periods, PeriodDefinition{
+			Name:       cur.Format("Jan 2006"),
+			FiscalYear: fiscalYear,
+			Index:      len(periods) + 1,
+			StartDate:  cur,
+			EndDate:    last,
+		})
+		cur = next
+	}
+	return periods
+}
+
+// WeekPatternPeriods splits a fiscal year into periods made of whole weeks.
+// The pattern is repeated until the fiscal year end; for a 4-4-5 calendar
+// pass []int{4, 4, 5}. The final period absorbs a short trailing stub so that
+// 53-week years are fully covered.
+func WeekPatternPeriods(fiscalYear string, start, end time.Time, pattern []int) ([]PeriodDefinition, error) {
+	if len(pattern) == 0 {
+		return nil, NewValidationError(ErrInvalidPeriodDefinition, "", "week pattern is empty")
+	}
+	for _, weeks := range pattern {
+		if weeks <= 0 {
+			return nil, NewValidationError(ErrInvalidPeriodDefinition, "", fmt.Sprintf("week count %d must be positive", weeks))
+		}
+	}
+
+	start, end = CivilDate(start), CivilDate(end)
+
+	var periods []PeriodDefinition
+	var lastWeeks int
+	for cur, i := start, 0; !cur.After(end); i++ {
+		lastWeeks = pattern[i%len(pattern)]
+		last := cur.AddDate(0, 0, lastWeeks*7-1)
+		if last.After(end) {
+			last = end
+		}
+		periods = append(periods, PeriodDefinition{
+			Name:       fmt.Sprintf("P%02d %s", len(periods)+1, fiscalYear),
+			FiscalYear: fiscalYear,
+			Index:      len(periods) + 1,
+			StartDate:  cur,
+			EndDate:    last,
+		})
+		cur = last.AddDate(0, 0, 1)
+	}
+
+	// Fold a trailing stub (e.g., the extra week of a 53-week year) into the
+	// previous period when it is less than half its intended length
+	if n := len(periods); n > 1 && periods[n-1].EndDate.Sub(periods[n-1].StartDate) < time.Duration(lastWeeks*7/2)*24*time.Hour {
+		periods[n-2].EndDate = periods[n-1].EndDate
+		periods = periods[:n-1]
+	}
+
+	return periods, nil
+}
+
+// PeriodsForCalendar builds the periods of a fiscal year for a standard
+// calendar type. Custom calendars must be supplied explicitly.
+func PeriodsForCalendar(calendar CalendarType, fiscalYear string, start, end time.Time) ([]PeriodDefinition, error) {
+	switch calendar {
+	case CalendarMonthly, "":
+		return MonthlyPeriods(fiscalYear, start, end), nil
+	case Calendar445:
+		return WeekPatternPeriods(fiscalYear, start, end, []int{4, 4, 5})
+	case Calendar454:
+		return WeekPatternPeriods(fiscalYear, start, end, []int{4, 5, 4})
+	case Calendar544:
+		return WeekPatternPeriods(fiscalYear, start, end, []int{5, 4, 4})
+	case Calendar13Period:
+		return WeekPatternPeriods(fiscalYear, start, end, []int{4})
+	default:
+		return nil, NewValidationError(
+			ErrInvalidPeriodDefinition,
+			"",
+			fmt.Sprintf("calendar type %q requires explicit period definitions", calendar),
+		)
+	}
+}
+
+// ValidatePeriods checks that periods are ordered, non-overlapping and
+// contiguous, so that every date in the fiscal year lands in exactly one bucket.
+func ValidatePeriods(periods []PeriodDefinition) error {
+	for i, p := range periods {
+		if p.EndDate.Before(p.StartDate) {
+			return NewValidationError(
+				ErrInvalidPeriodDefinition,
+				"",
+				fmt.Sprintf("period %s ends before it starts", p.Name),
+			)
+		}
+		if i == 0 {
+			continue
+		}
+		expected := CivilDate(periods[i-1].EndDate).AddDate(0, 0, 1)
+		if !CivilDate(p.StartDate).Equal(expected) {
+			return NewValidationError(
+				ErrInvalidPeriodDefinition,
+				"",
+				fmt.Sprintf("period %s should start on %s", p.Name, expected.Format(DateLayout)),
+			)
+		}
+	}
+	return nil
+}
+
+// FindPeriod returns the period containing the given date.
+func FindPeriod(periods []PeriodDefinition, date time.Time) (PeriodDefinition, error) {
+	idx := findPeriodIndex(periods, date)
+	if idx < 0 {
+		return PeriodDefinition{}, NewValidationError(
+			ErrPeriodNotFound,
+			"",
+			date.Format(DateLayout),
+		)
+	}
+	return periods[idx], nil
+}
+
+// findPeriodIndex binary-searches ordered periods, returning -1 if none match.
+func findPeriodIndex(periods []PeriodDefinition, date time.Time) int {
+	idx := sort.Search(len(periods), func(i int) bool {
+		return !CivilDate(periods[i].EndDate).Before(CivilDate(date))
+	})
+	if idx < len(periods) && periods[idx].Contains(date) {
+		return idx
+	}
+	return -1
+}
+
+// PeriodTotals holds the debit and credit totals of one account for one period.
+type PeriodTotals struct {
+	Period  PeriodDefinition
+	Account string
+	Debit   float64
+	Credit  float64
+}
+
+// Balance returns debit minus credit.
+func (t PeriodTotals) Balance() float64 {
+	return Flt(t.Debit-t.Credit, 2)
+}
+
+// BucketByPeriod aggregates GL entries per account and per period, which is
+// how financial statements build their period columns. Cancelled entries and
+// their reversals are skipped, and opening and period closing entries
+// unless flags count them; entries outside every period are reported
+// through an error.
+//
+// The result is keyed by account, each slice ordered like periods.
+func BucketByPeriod(entries []GLEntry, periods []PeriodDefinition, flags ReportFlags) (map[string][]PeriodTotals, error) {
+	if err := ValidatePeriods(periods); err != nil {
+		return nil, err
+	}
+
+	result := make(map[string][]PeriodTotals)
+	cancelled := CancelledEntries(entries)
+	for i, entry := range entries {
+		if cancelled[i] || !flags.Counts(&entry) {
+			continue
+		}
+
+		idx := findPeriodIndex(periods, entry.PostingDate)
+		if idx < 0 {
+			return nil, NewValidationError(
+				ErrPeriodNotFound,
+				entry.Account,
+				fmt.Sprintf("%s %s posted on %s", entry.VoucherType, entry.VoucherNo, entry.PostingDate.Format(DateLayout)),
+			)
+		}
+
+		buckets, ok := result[entry.Account]
+		if !ok {
+			buckets = make([]PeriodTotals, len(periods))
+			for i, p := range periods {
+				buckets[i] = PeriodTotals{Period: p, Account: entry.Account}
+			}
+			result[entry.Account] = buckets
+		}
+
+		buckets[idx].Debit += entry.Debit
+		buckets[idx].Credit += entry.Credit
+	}
+
+	return result, nil
+}
+
+// GetPeriod resolves the reporting period containing a date. The fiscal
+// year comes from FiscalYears; its periods come from Calendar when set,
+// otherwise the fiscal year is split into calendar months.
+func (e *Engine) GetPeriod(date time.Time, company string) (PeriodDefinition, error) {
+	if e.FiscalYears == nil {
+		return PeriodDefinition{}, ErrFiscalYearNotFound
+	}
+
+	date = CivilDate(date)
+	fiscalYear, err := e.FiscalYears.GetFiscalYear(date, company)
+	if err != nil {
+		return PeriodDefinition{}, err
+	}
+
+	var periods []PeriodDefinition
+	if e.Calendar != nil {
+		periods, err = e.Calendar.GetPeriods(fiscalYear, company)
+	} else {
+		var start, end time.Time
+		start, end, err = e.FiscalYears.GetFiscalYearDates(fiscalYear, company)
+		periods = MonthlyPeriods(fiscalYear, start, end)
+	}
+	if err != nil {
+		return PeriodDefinition{}, err
+	}
+
+	return FindPeriod(periods, date)
+}
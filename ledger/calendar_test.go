@@ -0,0 +1,173 @@
+package ledger
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func date(y int, m time.Month, d int) time.Time {
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// Tests for period generation
+
+func TestMonthlyPeriods(t *testing.T) {
+	periods := MonthlyPeriods("2024-2025", date(2024, 4, 1), date(2025, 3, 31))
+
+	if len(periods) != 12 {
+		t.Fatalf("Expected 12 periods, got %d", len(periods))
+	}
+	if !periods[0].EndDate.Equal(date(2024, 4, 30)) {
+		t.Errorf("First period ends %v, want 2024-04-30", periods[0].EndDate)
+	}
+	if !periods[10].EndDate.Equal(date(2025, 2, 28)) {
+		t.Errorf("February period ends %v, want 2025-02-28", periods[10].EndDate)
+	}
+	if err := ValidatePeriods(periods); err != nil {
+		t.Errorf("ValidatePeriods() error = %v", err)
+	}
+}
+
+func TestPeriodsForCalendar(t *testing.T) {
+	// Retail year of 52 weeks starting on a Sunday
+	start := date(2024, 2, 4)
+	end := date(2025, 2, 1)
+
+	tests := []struct {
+		name          string
+		calendar      CalendarType
+		expectedCount int
+		firstDays     int
+		thirdDays     int
+	}{
+		{"4-4-5", Calendar445, 12, 28, 35},
+		{"4-5-4", Calendar454, 12, 28, 28},
+		{"5-4-4", Calendar544, 12, 35, 28},
+		{"13 period", Calendar13Period, 13, 28, 28},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			periods, err := PeriodsForCalendar(tt.calendar, "FY2024", start, end)
+			if err != nil {
+				t.Fatalf("PeriodsForCalendar() error = %v", err)
+			}
+			if len(periods) != tt.expectedCount {
+				t.Fatalf("Expected %d periods, got %d", tt.expectedCount, len(periods))
+			}
+			if days := daysIn(periods[0]); days != tt.firstDays {
+				t.Errorf("First period has %d days, want %d", days, tt.firstDays)
+			}
+			if days := daysIn(periods[2]); days != tt.thirdDays {
+				t.Errorf("Third period has %d days, want %d", days, tt.thirdDays)
+			}
+			if !periods[len(periods)-1].EndDate.Equal(end) {
+				t.Errorf("Last period ends %v, want %v", periods[len(periods)-1].EndDate, end)
+			}
+			if err := ValidatePeriods(periods); err != nil {
+				t.Errorf("ValidatePeriods() error = %v", err)
+			}
+		})
+	}
+
+	t.Run("53_week_year_folds_into_last_period", func(t *testing.T) {
+		periods, err := PeriodsForCalendar(Calendar445, "FY2025", date(2025, 2, 2), date(2026, 2, 7))
+		if err != nil {
+			t.Fatalf("PeriodsForCalendar() error = %v", err)
+		}
+		if len(periods) != 12 {
+			t.Fatalf("Expected 12 periods, got %d", len(periods))
+		}
+		if days := daysIn(periods[11]); days != 42 {
+			t.Errorf("Last period has %d days, want 42", days)
+		}
+	})
+
+	t.Run("custom_requires_definitions", func(t *testing.T) {
+		_, err := PeriodsForCalendar(CalendarCustom, "FY2024", start, end)
+		if !errors.Is(err, ErrInvalidPeriodDefinition) {
+			t.Errorf("Expected ErrInvalidPeriodDefinition, got %v", err)
+		}
+	})
+}
+
+func daysIn(p PeriodDefinition) int {
+	return int(p.EndDate.Sub(p.StartDate).Hours()/24) + 1
+}
+
+func TestValidatePeriods_Gap(t *testing.T) {
+	periods := []PeriodDefinition{
+		{Name: "P1", StartDate: date(2024, 1, 1), EndDate: date(2024, 1, 28)},
+		{Name: "P2", StartDate: date(2024, 1, 30), EndDate: date(2024, 2, 25)},
+	}
+
+	if err := ValidatePeriods(periods); !errors.Is(err, ErrInvalidPeriodDefinition) {
+		t.Errorf("Expected ErrInvalidPeriodDefinition, got %v", err)
+	}
+}
+
+func TestFindPeriod(t *testing.T) {
+	periods, _ := PeriodsForCalendar(Calendar13Period, "FY2024", date(2024, 1, 1), date(2024, 12, 29))
+
+	p, err := FindPeriod(periods, time.Date(2024, 3, 1, 18, 30, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("FindPeriod() error = %v", err)
+	}
+	if p.Index != 3 {
+		t.Errorf("Expected period 3, got %d", p.Index)
+	}
+
+	if _, err := FindPeriod(periods, date(2025, 1, 5)); !errors.Is(err, ErrPeriodNotFound) {
+		t.Errorf("Expected ErrPeriodNotFound, got %v", err)
+	}
+}
+
+// Tests for BucketByPeriod
+
+func TestBucketByPeriod(t *testing.T) {
+	periods, _ := PeriodsForCalendar(Calendar445, "FY2024", date(2024, 2, 4), date(2025, 2, 1))
+
+	entries := []GLEntry{
+		{Account: "Sales - ABC", PostingDate: date(2024, 2, 10), Credit: 100},
+		{Account: "Sales - ABC", PostingDate: date(2024, 3, 2), Credit: 50}, // last day of P01
+		{Account: "Sales - ABC", PostingDate: date(2024, 3, 3), Credit: 25}, // first day of P02
+		{Account: "Sales - ABC", PostingDate: date(2024, 3, 4), Credit: 999, IsCancelled: true, VoucherNo: "SINV-9"},
+		{Account: "Sales - ABC", PostingDate: date(2024, 3, 4), Debit: 999, VoucherNo: "SINV-9"}, // Its reversal, kept live
+		{Account: "Debtors - ABC", PostingDate: date(2024, 2, 10), Debit: 175},
+		{Account: "Debtors - ABC", PostingDate: date(2024, 2, 4), Debit: 500, IsOpening: IsOpeningYes},
+		{Account: "Sales - ABC", PostingDate: date(2025, 2, 1), Debit: 175, VoucherType: PeriodClosingVoucher, VoucherNo: "PCV-1"},
+	}
+
+	buckets, err := BucketByPeriod(entries, periods, ReportFlags{})
+	if err != nil {
+		t.Fatalf("BucketByPeriod() error = %v", err)
+	}
+
+	sales := buckets["Sales - ABC"]
+	if len(sales) != 12 {
+		t.Fatalf("Expected 12 buckets, got %d", len(sales))
+	}
+	if sales[0].Credit != 150 {
+		t.Errorf("P01 credit = %.2f, want 150", sales[0].Credit)
+	}
+	if sales[1].Credit != 25 || sales[1].Debit != 0 {
+		t.Errorf("P02 credit = %.2f, want 25 (cancelled entry must be skipped)", sales[1].Credit)
+	}
+	if buckets["Debtors - ABC"][0].Balance() != 175 {
+		t.Errorf("Debtors P01 balance = %.2f, want 175", buckets["Debtors - ABC"][0].Balance())
+	}
+	if sales[11].Debit != 0 {
+		t.Errorf("P12 debit = %.2f, want the period closing voucher left out", sales[11].Debit)
+	}
+
+	buckets, err = BucketByPeriod(entries, periods, AsOfBalance)
+	if err != nil || buckets["Debtors - ABC"][0].Balance() != 675 || buckets["Sales - ABC"][11].Debit != 175 {
+		t.Errorf("with opening and closing entries: %v, %+v", err, buckets)
+	}
+
+	_, err = BucketByPeriod([]GLEntry{{Account: "Sales - ABC", PostingDate: date(2023, 1, 1)}}, periods, ReportFlags{})
+	if !errors.Is(err, ErrPeriodNotFound) {
+		t.Errorf("Expected ErrPeriodNotFound, got %v", err)
+	}
+}
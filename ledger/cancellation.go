@@ -0,0 +1,135 @@
+package ledger
+
+import (
+	"fmt"
+	"time"
+)
+
+// CancellationMethod is how the engine undoes a voucher's GL entries when
+// the voucher is cancelled.
+type CancellationMethod string
+
+const (
+	// CancelByReversal marks the entries cancelled and posts reversing
+	// entries under the same voucher (the default)
+	CancelByReversal CancellationMethod = "Reverse"
+	// CancelByDeletion removes the voucher's GL and payment ledger
+	// entries, where the law allows a cancelled document to leave no
+	// trace in the books
+	CancelByDeletion CancellationMethod = "Delete"
+	// CancelForbidden refuses every cancellation; the voucher is corrected
+	// by a new document, such as a credit note
+	CancelForbidden CancellationMethod = "Forbid"
+)
+
+// CancellationRule is how vouchers of a type may be cancelled.
+type CancellationRule struct {
+	Method CancellationMethod // CancelByReversal when empty
+
+	// MaxAgeDays, when set, refuses the cancellation of a voucher posted
+	// more than that many days before today.
+	MaxAgeDays int
+
+	// ForbidAfterClose refuses the cancellation of a voucher dated on or
+	// before the company's book closing date, or in an accounting period
+	// closed for its voucher type.
+	ForbidAfterClose bool
+}
+
+// CancellationPolicy sets how vouchers are cancelled, by voucher type, to
+// meet the statutory requirements of the country the books are kept in.
+//
+// Maps to: delete_linked_ledger_entries and the immutable ledger of
+// Accounts Settings, and the per-country restrictions on cancelling
+// submitted invoices of the regional apps (e.g. e-invoices in India)
+type CancellationPolicy struct {
+	Default      CancellationRule            // For voucher types without a rule of their own
+	VoucherTypes map[string]CancellationRule // By voucher type
+	Now          func() time.Time            // time.Now when nil; today is its civil date
+}
+
+// Rule returns the rule of a voucher type.
+func (p *CancellationPolicy) Rule(voucherType string) CancellationRule {
+	if p == nil {
+		return CancellationRule{}
+	}
+	if r, ok := p.VoucherTypes[voucherType]; ok {
+		return r
+	}
+	return p.Default
+}
+
+func (p *CancellationPolicy) today() time.Time {
+	if p != nil && p.Now != nil {
+		return CivilDate(p.Now())
+	}
+	return CivilDate(time.Now())
+}
+
+// VoucherDeleter is implemented by GL and payment ledger stores that can
+// remove a voucher's entries, for CancelByDeletion.
+type VoucherDeleter interface {
+	// DeleteVoucher removes every entry of a voucher, cancelled ones
+	// included, and returns the number removed.
+	DeleteVoucher(voucherType, voucherNo string) (int, error)
+}
+
+// checkCancellation returns an error wrapping ErrCancellationForbidden
+// unless the policy allows the voucher of glMap to be cancelled.
+func (e *Engine) checkCancellation(glMap []GLEntry, rule CancellationRule) error {
+	first := glMap[0]
+	voucher := first.VoucherType + " " + first.VoucherNo
+	if rule.Method == CancelForbidden {
+		return fmt.Errorf("%w: %s vouchers cannot be cancelled", ErrCancellationForbidden, first.VoucherType)
+	}
+	postingDate := CivilDate(first.PostingDate)
+	if rule.MaxAgeDays > 0 {
+		if age := int(e.Cancellations.today().Sub(postingDate).Hours() / 24); age > rule.MaxAgeDays {
+			return fmt.Errorf("%w: %s was posted %d days ago; the limit is %d", ErrCancellationForbidden, voucher, age, rule.MaxAgeDays)
+		}
+	}
+	if !rule.ForbidAfterClose {
+		return nil
+	}
+	if e.Company != nil {
+		closing, err := e.Company.GetBookClosingDate(first.Company)
+		if err != nil {
+			return err
+		}
+		if closing != nil && !postingDate.After(CivilDate(*closing)) {
+			return fmt.Errorf("%w: %s is dated in books closed till %s", ErrCancellationForbidden, voucher, closing.Format(DateLayout))
+		}
+	}
+	if err := e.validateAccountingPeriod(glMap); err != nil {
+		if _, ok := err.(*PeriodClosedError); ok {
+			return fmt.Errorf("%w: %s is dated in a closed period: %v", ErrCancellationForbidden, voucher, err)
+		}
+		return err
+	}
+	return nil
+}
+
+// deleteVoucher removes the GL and payment ledger entries of a voucher
+// cancelled by deletion. Both stores must support it, so the ledgers
+// never disagree about whether the voucher exists.
+func (e *Engine) deleteVoucher(voucherType, voucherNo string) error {
+	gl, ok := e.GLStore.(VoucherDeleter)
+	if !ok {
+		return fmt.Errorf("%w: %T", ErrDeleteNotSupported, e.GLStore)
+	}
+	var ple VoucherDeleter
+	if e.PaymentStore != nil {
+		if ple, ok = e.PaymentStore.(VoucherDeleter); !ok {
+			return fmt.Errorf("%w: %T", ErrDeleteNotSupported, e.PaymentStore)
+		}
+	}
+	if _, err := gl.DeleteVoucher(voucherType, voucherNo); err != nil {
+		return err
+	}
+	if ple != nil {
+		if _, err := ple.DeleteVoucher(voucherType, voucherNo); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package ledger
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+// deletingGLStore is a mockGLStore that can cancel by deletion.
+type deletingGLStore struct{ mockGLStore }
+
+func (m *deletingGLStore) DeleteVoucher(voucherType, voucherNo string) (int, error) {
+	n := len(m.entries)
+	m.entries = slices.DeleteFunc(m.entries, func(e GLEntry) bool {
+		return e.VoucherType == voucherType && e.VoucherNo == voucherNo
+	})
+	return n - len(m.entries), nil
+}
+
+// closedBooks closes the books on a date, for every company.
+type closedBooks struct {
+	mockCompanySettings
+	closing time.Time
+}
+
+func (c *closedBooks) GetBookClosingDate(company string) (*time.Time, error) {
+	return &c.closing, nil
+}
+
+func TestCancellationPolicy(t *testing.T) {
+	glMap := []GLEntry{makeTestGLEntry("Debtors - ABC", 100, 0), makeTestGLEntry("Sales - ABC", 0, 100)}
+	cancel := DefaultPostingOptions()
+	cancel.Cancel = true
+	today := func() time.Time { return makeTestDate().AddDate(0, 0, 40) }
+
+	tests := []struct {
+		name    string
+		policy  *CancellationPolicy
+		company CompanySettings
+		wantErr error
+		want    int // Entries left in the store
+	}{
+		{"no policy reverses", nil, nil, nil, 4},
+		{"delete", &CancellationPolicy{VoucherTypes: map[string]CancellationRule{
+			"Sales Invoice": {Method: CancelByDeletion},
+		}}, nil, nil, 0},
+		{"other voucher type", &CancellationPolicy{VoucherTypes: map[string]CancellationRule{
+			"Journal Entry": {Method: CancelForbidden},
+		}}, nil, nil, 4},
+		{"forbid", &CancellationPolicy{Default: CancellationRule{Method: CancelForbidden}}, nil, ErrCancellationForbidden, 2},
+		{"within age", &CancellationPolicy{Default: CancellationRule{MaxAgeDays: 40}, Now: today}, nil, nil, 4},
+		{"too old", &CancellationPolicy{Default: CancellationRule{MaxAgeDays: 30}, Now: today}, nil, ErrCancellationForbidden, 2},
+		{"books open", &CancellationPolicy{Default: CancellationRule{ForbidAfterClose: true}},
+			&closedBooks{closing: makeTestDate().AddDate(0, 0, -1)}, nil, 4},
+		{"books closed", &CancellationPolicy{Default: CancellationRule{Method: CancelByDeletion, ForbidAfterClose: true}},
+			&closedBooks{closing: makeTestDate()}, ErrCancellationForbidden, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &deletingGLStore{}
+			engine := &Engine{GLStore: store, Company: tt.company, Cancellations: tt.policy}
+			if err := engine.MakeGLEntries(slices.Clone(glMap), DefaultPostingOptions()); err != nil {
+				t.Fatal(err)
+			}
+			if err := engine.MakeGLEntries(slices.Clone(glMap), cancel); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("cancel: err = %v, want %v", err, tt.wantErr)
+			}
+			if len(store.entries) != tt.want {
+				t.Errorf("%d entries left, want %d", len(store.entries), tt.want)
+			}
+		})
+	}
+}
+
+func TestCancelByDeletionNeedsDeleter(t *testing.T) {
+	engine := &Engine{
+		GLStore:       &mockGLStore{},
+		Cancellations: &CancellationPolicy{Default: CancellationRule{Method: CancelByDeletion}},
+	}
+	glMap := []GLEntry{makeTestGLEntry("Debtors - ABC", 100, 0), makeTestGLEntry("Sales - ABC", 0, 100)}
+	if err := engine.MakeGLEntries(glMap, DefaultPostingOptions()); err != nil {
+		t.Fatal(err)
+	}
+	cancel := DefaultPostingOptions()
+	cancel.Cancel = true
+	if err := engine.MakeGLEntries(glMap, cancel); !errors.Is(err, ErrDeleteNotSupported) {
+		t.Errorf("err = %v, want ErrDeleteNotSupported", err)
+	}
+}
@@ -0,0 +1,99 @@
+package ledger
+
+import "github.com/senguttuvang/erpnext-go/errcode"
+
+// Error codes of the ledger package. Codes are part of the API: add new
+// ones, never renumber.
+const (
+	CodeAccountDisabled           = "ACC-GLE-0001"
+	CodeAccountFrozen             = "ACC-GLE-0002"
+	CodeAccountIsGroup            = "ACC-GLE-0003"
+	CodeDebitCreditMismatch       = "ACC-GLE-0004"
+	CodeInsufficientEntries       = "ACC-GLE-0005"
+	CodePeriodClosed              = "ACC-GLE-0006"
+	CodeDisabledAccounts          = "ACC-GLE-0007"
+	CodeVoucherPeriodClosed       = "ACC-GLE-0008"
+	CodeBudgetExceeded            = "ACC-GLE-0009"
+	CodeAccountBudgetExceeded     = "ACC-GLE-0010"
+	CodeGLEntryCount              = "ACC-GLE-0011"
+	CodeFiscalYearNotFound        = "ACC-GLE-0012"
+	CodeAccountsFrozenTill        = "ACC-GLE-0013"
+	CodeBooksClosedTill           = "ACC-GLE-0014"
+	CodeInvalidPeriodDefinition   = "ACC-GLE-0015"
+	CodePeriodNotFound            = "ACC-GLE-0016"
+	CodeInvalidAccountCurrency    = "ACC-GLE-0017"
+	CodeCurrencyMismatch          = "ACC-GLE-0018"
+	CodeVoucherNotFound           = "ACC-GLE-0019"
+	CodeVoucherAlreadyPosted      = "ACC-GLE-0020"
+	CodeRelinkNotSupported        = "ACC-GLE-0021"
+	CodeAccountNotInTree          = "ACC-GLE-0022"
+	CodeAccountTreeCycle          = "ACC-GLE-0023"
+	CodeNotPermitted              = "ACC-GLE-0024"
+	CodeMissingCostCenter         = "ACC-GLE-0025"
+	CodeAccountsMissingCostCenter = "ACC-GLE-0026"
+	CodeMissingAccount            = "ACC-GLE-0027"
+	CodeInvalidSnapshot           = "ACC-GLE-0028"
+	CodeVoucherTypeNotAllowed     = "ACC-GLE-0029"
+	CodePartyRequired             = "ACC-GLE-0030"
+	CodeNoOutbox                  = "ACC-GLE-0031"
+	CodeDoublePosting             = "ACC-GLE-0032"
+	CodeCancellationForbidden     = "ACC-GLE-0033"
+	CodeDeleteNotSupported        = "ACC-GLE-0034"
+	CodeDimensionUnbalanced       = "ACC-GLE-0035"
+)
+
+func init() {
+	errcode.Register(CodeAccountDisabled, ErrAccountDisabled, "Posting to a disabled account")
+	errcode.Register(CodeAccountFrozen, ErrAccountFrozen, "Posting to a frozen account")
+	errcode.Register(CodeAccountIsGroup, ErrAccountIsGroup, "Posting to a group account")
+	errcode.Register(CodeDebitCreditMismatch, ErrDebitCreditMismatch, "Debits and credits do not balance")
+	errcode.Register(CodeInsufficientEntries, ErrInsufficientEntries, "Too few GL entries")
+	errcode.Register(CodePeriodClosed, ErrPeriodClosed, "Accounting period closed")
+	errcode.Register(CodeDisabledAccounts, nil, "DisabledAccountsError: posting to disabled accounts")
+	errcode.Register(CodeVoucherPeriodClosed, nil, "PeriodClosedError: accounting period closed for a document type")
+	errcode.Register(CodeBudgetExceeded, ErrBudgetExceeded, "Budget exceeded")
+	errcode.Register(CodeAccountBudgetExceeded, nil, "BudgetExceededError: budget exceeded for an account and cost center")
+	errcode.Register(CodeGLEntryCount, nil, "GLEntryCountError: too few GL entries")
+	errcode.Register(CodeFiscalYearNotFound, ErrFiscalYearNotFound, "No fiscal year for the posting date")
+	errcode.Register(CodeAccountsFrozenTill, ErrAccountsFrozenTill, "Accounts frozen till the posting date")
+	errcode.Register(CodeBooksClosedTill, ErrBooksClosedTill, "Books closed till the posting date")
+	errcode.Register(CodeInvalidPeriodDefinition, ErrInvalidPeriodDefinition, "Invalid accounting period definition")
+	errcode.Register(CodePeriodNotFound, ErrPeriodNotFound, "No accounting period covers the date")
+	errcode.Register(CodeInvalidAccountCurrency, ErrInvalidAccountCurrency, "Invalid account currency")
+	errcode.Register(CodeCurrencyMismatch, ErrCurrencyMismatch, "Currency mismatch")
+	errcode.Register(CodeVoucherNotFound, ErrVoucherNotFound, "Voucher not found")
+	errcode.Register(CodeVoucherAlreadyPosted, ErrVoucherAlreadyPosted, "Voucher already has GL entries")
+	errcode.Register(CodeRelinkNotSupported, ErrRelinkNotSupported, "Store cannot relink against-voucher references")
+	errcode.Register(CodeAccountNotInTree, ErrAccountNotInTree, "Account not in the chart of accounts")
+	errcode.Register(CodeAccountTreeCycle, ErrAccountTreeCycle, "Chart of accounts has a cycle")
+	errcode.Register(CodeNotPermitted, ErrNotPermitted, "Not permitted")
+	errcode.Register(CodeMissingCostCenter, ErrMissingCostCenter, "Profit and loss entry without a cost center")
+	errcode.Register(CodeMissingAccount, ErrMissingAccount, "GL entry without an account")
+	errcode.Register(CodeInvalidSnapshot, ErrInvalidSnapshot, "Ledger snapshot is corrupt or of an unknown version")
+	errcode.Register(CodeVoucherTypeNotAllowed, ErrVoucherTypeNotAllowed, "Voucher type may not post to the account")
+	errcode.Register(CodePartyRequired, ErrPartyRequired, "Account requires a party of its party type")
+	errcode.Register(CodeNoOutbox, ErrNoOutbox, "Asynchronous payment ledger writes without an outbox")
+	errcode.Register(CodeDoublePosting, ErrDoublePosting, "Voucher posted twice into a finance book")
+	errcode.Register(CodeCancellationForbidden, ErrCancellationForbidden, "Cancellation forbidden by the cancellation policy")
+	errcode.Register(CodeDeleteNotSupported, ErrDeleteNotSupported, "Cancellation by deletion on a store that cannot delete")
+	errcode.Register(CodeDimensionUnbalanced, ErrDimensionUnbalanced, "Balancing dimension value does not balance within a voucher")
+	errcode.Register(CodeAccountsMissingCostCenter, nil, "MissingCostCenterError: profit and loss entries without a cost center")
+}
+
+// Code returns the code of the wrapped sentinel error.
+func (e *ValidationError) Code() string { return errcode.Of(e.Err) }
+
+// Code implements errcode.Coder.
+func (e *DisabledAccountsError) Code() string { return CodeDisabledAccounts }
+
+// Code implements errcode.Coder.
+func (e *PeriodClosedError) Code() string { return CodeVoucherPeriodClosed }
+
+// Code implements errcode.Coder.
+func (e *BudgetExceededError) Code() string { return CodeAccountBudgetExceeded }
+
+// Code implements errcode.Coder.
+func (e *GLEntryCountError) Code() string { return CodeGLEntryCount }
+
+// Code implements errcode.Coder.
+func (e *MissingCostCenterError) Code() string { return CodeAccountsMissingCostCenter }
@@ -0,0 +1,32 @@
+package ledger
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/senguttuvang/erpnext-go/errcode"
+)
+
+func TestErrorCodes(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{ErrAccountFrozen, CodeAccountFrozen},
+		{fmt.Errorf("post: %w", ErrBudgetExceeded), CodeBudgetExceeded},
+		{&DisabledAccountsError{Accounts: []string{"Cash"}}, "ACC-GLE-0007"},
+		{fmt.Errorf("post: %w", &BudgetExceededError{}), CodeAccountBudgetExceeded},
+		{&PeriodClosedError{}, CodeVoucherPeriodClosed},
+		{&GLEntryCountError{}, CodeGLEntryCount},
+		{&MissingCostCenterError{}, CodeAccountsMissingCostCenter},
+		{NewValidationError(ErrAccountIsGroup, "Assets", "group"), CodeAccountIsGroup},
+	}
+	for _, tt := range tests {
+		if got := errcode.Of(tt.err); got != tt.want {
+			t.Errorf("errcode.Of(%v) = %q, want %q", tt.err, got, tt.want)
+		}
+		if _, ok := errcode.Lookup(tt.want); !ok {
+			t.Errorf("%s not registered", tt.want)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+// dates.go implements civil (date-only) handling for posting dates.
+//
+// ERPNext stores posting_date as a DATE column, so a voucher always belongs
+// to the calendar day the user entered. A Go time.Time carries a location,
+// and comparing 00:30 IST on 1 April with a UTC period boundary would put
+// the voucher in March. Every date that takes part in period, freeze or
+// fiscal year checks is therefore normalized to midnight UTC of its own
+// wall-clock day before comparison.
+package ledger
+
+import (
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/daterange"
+)
+
+// DateLayout is the ISO date format used by ERPNext for date fields.
+const DateLayout = "2006-01-02"
+
+// CivilDate returns midnight UTC of the calendar day t falls on in its own
+// location. The wall-clock date is kept; the time of day and zone are dropped.
+//
+// Example: 2024-03-31 23:30 IST and 2024-03-31 00:15 PST both become
+// 2024-03-31 00:00 UTC.
+func CivilDate(t time.Time) time.Time {
+	return daterange.Civil(t)
+}
+
+// CivilDateIn returns the calendar day an instant falls on as seen from loc,
+// normalized like CivilDate. Use it when a timestamp (e.g., a UTC event time
+// from a bank feed) must be attributed to the company's local business day.
+func CivilDateIn(t time.Time, loc *time.Location) time.Time {
+	if t.IsZero() || loc == nil {
+		return CivilDate(t)
+	}
+	return CivilDate(t.In(loc))
+}
+
+// ParseDate parses an ERPNext date string (YYYY-MM-DD) into a civil date.
+func ParseDate(s string) (time.Time, error) {
+	return time.ParseInLocation(DateLayout, s, time.UTC)
+}
+
+// SameDay returns true if both times fall on the same civil date.
+func SameDay(a, b time.Time) bool {
+	return CivilDate(a).Equal(CivilDate(b))
+}
+
+// NormalizeDates returns a copy of the GL map with PostingDate,
+// TransactionDate and DueDate reduced to civil dates.
+func NormalizeDates(glMap []GLEntry) []GLEntry {
+	result := make([]GLEntry, len(glMap))
+	for i := range glMap {
+		result[i] = glMap[i].Copy()
+		normalizeEntryDates(&result[i])
+	}
+	return result
+}
+
+// normalizeEntryDates reduces the date fields of a single entry in place.
+func normalizeEntryDates(entry *GLEntry) {
+	entry.PostingDate = CivilDate(entry.PostingDate)
+	entry.TransactionDate = CivilDate(entry.TransactionDate)
+	if entry.DueDate != nil {
+		d := CivilDate(*entry.DueDate)
+		entry.DueDate = &d
+	}
+}
@@ -0,0 +1,114 @@
+package ledger
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCivilDate(t *testing.T) {
+	ist := time.FixedZone("IST", 5*3600+1800)
+	pst := time.FixedZone("PST", -8*3600)
+
+	tests := []struct {
+		name     string
+		input    time.Time
+		expected time.Time
+	}{
+		{"late_evening_ist", time.Date(2024, 3, 31, 23, 30, 0, 0, ist), date(2024, 3, 31)},
+		{"just_after_midnight_ist", time.Date(2024, 4, 1, 0, 30, 0, 0, ist), date(2024, 4, 1)},
+		{"late_evening_pst", time.Date(2024, 3, 31, 23, 59, 0, 0, pst), date(2024, 3, 31)},
+		{"already_civil", date(2024, 3, 31), date(2024, 3, 31)},
+		{"zero", time.Time{}, time.Time{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := CivilDate(tt.input)
+			if !got.Equal(tt.expected) || got.Location() != tt.expected.Location() {
+				t.Errorf("CivilDate(%v) = %v, want %v", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestCivilDateIn(t *testing.T) {
+	ist := time.FixedZone("IST", 5*3600+1800)
+
+	// 19:00 UTC on 31 March is already 1 April in India
+	got := CivilDateIn(time.Date(2024, 3, 31, 19, 0, 0, 0, time.UTC), ist)
+	if !got.Equal(date(2024, 4, 1)) {
+		t.Errorf("CivilDateIn() = %v, want 2024-04-01", got)
+	}
+}
+
+func TestParseDate(t *testing.T) {
+	got, err := ParseDate("2024-03-31")
+	if err != nil {
+		t.Fatalf("ParseDate() error = %v", err)
+	}
+	if !got.Equal(date(2024, 3, 31)) {
+		t.Errorf("ParseDate() = %v, want 2024-03-31", got)
+	}
+}
+
+// Periods defined in UTC must still contain vouchers entered in local time.
+func TestFindPeriod_LocalTimePostingDate(t *testing.T) {
+	ist := time.FixedZone("IST", 5*3600+1800)
+	periods := MonthlyPeriods("2023-2024", date(2023, 4, 1), date(2024, 3, 31))
+
+	p, err := FindPeriod(periods, time.Date(2024, 3, 31, 23, 30, 0, 0, ist))
+	if err != nil {
+		t.Fatalf("FindPeriod() error = %v", err)
+	}
+	if p.Name != "Mar 2024" {
+		t.Errorf("Expected Mar 2024, got %s", p.Name)
+	}
+}
+
+type frozenTillSettings struct {
+	mockCompanySettings
+	frozenTill time.Time
+}
+
+func (f *frozenTillSettings) GetAccountsFrozenTillDate(company string) (*time.Time, error) {
+	return &f.frozenTill, nil
+}
+
+func TestMakeGLEntries_NormalizesPostingDate(t *testing.T) {
+	ist := time.FixedZone("IST", 5*3600+1800)
+	glStore := &mockGLStore{}
+	engine := &Engine{
+		Accounts: newMockAccountLookup(),
+		// Frozen till 1 April (UTC) - a voucher dated 1 April in IST must pass
+		Company: &frozenTillSettings{frozenTill: date(2024, 4, 1)},
+		GLStore: glStore,
+	}
+
+	postingDate := time.Date(2024, 4, 1, 0, 30, 0, 0, ist)
+	glMap := []GLEntry{
+		{PostingDate: postingDate, Account: "Debtors - ABC", Debit: 100, DebitInAccountCurrency: 100, VoucherType: "Sales Invoice", VoucherNo: "SINV-001", Company: "ABC Company"},
+		{PostingDate: postingDate, Account: "Sales - ABC", Credit: 100, CreditInAccountCurrency: 100, VoucherType: "Sales Invoice", VoucherNo: "SINV-001", Company: "ABC Company"},
+	}
+
+	if err := engine.MakeGLEntries(glMap, DefaultPostingOptions()); err != nil {
+		t.Fatalf("MakeGLEntries() error = %v", err)
+	}
+	for _, e := range glStore.entries {
+		if !e.PostingDate.Equal(date(2024, 4, 1)) || e.PostingDate.Location() != time.UTC {
+			t.Errorf("Saved posting date = %v, want 2024-04-01 UTC", e.PostingDate)
+		}
+	}
+	if !glMap[0].PostingDate.Equal(postingDate) {
+		t.Errorf("Caller's GL map was modified")
+	}
+
+	// 31 March in IST is before the freeze date
+	glMap[0].PostingDate = time.Date(2024, 3, 31, 23, 30, 0, 0, ist)
+	glMap[1].PostingDate = glMap[0].PostingDate
+	glMap[0].VoucherNo, glMap[1].VoucherNo = "SINV-002", "SINV-002"
+	err := engine.MakeGLEntries(glMap, DefaultPostingOptions())
+	if !errors.Is(err, ErrAccountsFrozenTill) {
+		t.Errorf("Expected ErrAccountsFrozenTill, got %v", err)
+	}
+}
@@ -0,0 +1,105 @@
+package ledger
+
+import (
+	"cmp"
+	"slices"
+)
+
+// PostingKey identifies the posting of a voucher into a finance book. A
+// voucher has at most one live posting per key: posting it again without
+// cancelling it first books everything twice.
+type PostingKey struct {
+	Company     string
+	VoucherType string
+	VoucherNo   string
+	FinanceBook string
+}
+
+// postingKey returns the key of an entry's posting.
+func postingKey(e *GLEntry) PostingKey {
+	return PostingKey{e.Company, e.VoucherType, e.VoucherNo, e.FinanceBook}
+}
+
+// AdjustmentSaver is implemented by GL stores that enforce one live
+// posting per PostingKey, as the SQL stores do with a unique constraint.
+// The engine saves the reversals of a cancellation and the differences
+// of an AmendByAdjustment through it, since they change a posting rather
+// than add one; stores without it receive them through SaveBatch.
+type AdjustmentSaver interface {
+	// SaveAdjustment persists entries that adjust a voucher's existing
+	// posting.
+	SaveAdjustment(entries []GLEntry) error
+}
+
+// saveAdjustment saves entries that adjust an existing posting.
+func (e *Engine) saveAdjustment(entries []GLEntry) error {
+	if s, ok := e.GLStore.(AdjustmentSaver); ok {
+		return s.SaveAdjustment(entries)
+	}
+	return e.GLStore.SaveBatch(entries)
+}
+
+// DoublePosting is a voucher found posted more than once into a finance
+// book.
+type DoublePosting struct {
+	PostingKey
+	Duplicates []GLEntry // The live entries repeating one already posted
+}
+
+// FindDoublePostings scans GL entries for vouchers posted twice into one
+// finance book, such as by a repost racing a manual post, for stores that
+// cannot enforce the unique constraint themselves. Results are ordered by
+// key.
+//
+// Cancelled entries and their reversals are set aside first, as
+// CancelledEntries tells them; what is left of a key is the live posting. The engine merges a posting's lines,
+// so a live line repeated with the same merge fields and amounts means
+// the voucher was posted again. Vouchers posted without merging, and
+// amendments by adjustment that happen to add a line's exact amount
+// again, are reported too; review them before reversing anything.
+func FindDoublePostings(entries []GLEntry) []DoublePosting {
+	type line struct {
+		mergeKey
+		debit, credit float64
+	}
+	lineOf := func(e *GLEntry) line {
+		return line{getMergeKey(*e, nil), Flt(e.Debit, 2), Flt(e.Credit, 2)}
+	}
+
+	cancelled := CancelledEntries(entries)
+	byKey := map[PostingKey][]*GLEntry{}
+	for i := range entries {
+		if cancelled[i] {
+			continue
+		}
+		k := postingKey(&entries[i])
+		byKey[k] = append(byKey[k], &entries[i])
+	}
+
+	var found []DoublePosting
+	for k, live := range byKey {
+		byLine := map[line][]*GLEntry{}
+		for _, e := range live {
+			l := lineOf(e)
+			byLine[l] = append(byLine[l], e)
+		}
+		var duplicates []GLEntry
+		for _, e := range live {
+			if same := byLine[lineOf(e)]; len(same) > 1 && same[0] != e {
+				duplicates = append(duplicates, e.Copy())
+			}
+		}
+		if len(duplicates) > 0 {
+			found = append(found, DoublePosting{PostingKey: k, Duplicates: duplicates})
+		}
+	}
+	slices.SortFunc(found, func(a, b DoublePosting) int {
+		return cmp.Or(
+			cmp.Compare(a.Company, b.Company),
+			cmp.Compare(a.VoucherType, b.VoucherType),
+			cmp.Compare(a.VoucherNo, b.VoucherNo),
+			cmp.Compare(a.FinanceBook, b.FinanceBook),
+		)
+	})
+	return found
+}
@@ -0,0 +1,55 @@
+package ledger
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestFindDoublePostings(t *testing.T) {
+	glStore := &mockGLStore{}
+	engine := &Engine{Accounts: newMockAccountLookup(), Company: &mockCompanySettings{}, GLStore: glStore}
+	voucher := func(amount float64, financeBook string) []GLEntry {
+		glMap := []GLEntry{makeTestGLEntry("Debtors - ABC", amount, 0), makeTestGLEntry("Sales - ABC", 0, amount)}
+		for i := range glMap {
+			glMap[i].FinanceBook = financeBook
+		}
+		return glMap
+	}
+	post := func(glMap []GLEntry, cancel bool) {
+		t.Helper()
+		opts := DefaultPostingOptions()
+		opts.Cancel = cancel
+		if err := engine.MakeGLEntries(glMap, opts); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Posted, cancelled and posted again, and into a second book
+	post(voucher(100, ""), false)
+	post(voucher(100, ""), true)
+	post(voucher(100, ""), false)
+	post(voucher(100, "Tax Book"), false)
+	if found := FindDoublePostings(glStore.entries); len(found) != 0 {
+		t.Fatalf("FindDoublePostings() = %+v, want none", found)
+	}
+
+	// The mock store lets the same posting in twice
+	post(voucher(100, ""), false)
+	found := FindDoublePostings(glStore.entries)
+	if len(found) != 1 || found[0].FinanceBook != "" || found[0].VoucherNo != glStore.entries[0].VoucherNo || len(found[0].Duplicates) != 2 {
+		t.Fatalf("FindDoublePostings() = %+v", found)
+	}
+	for _, e := range found[0].Duplicates {
+		if e.IsCancelled {
+			t.Errorf("duplicate %+v is cancelled", e)
+		}
+	}
+
+	// Reversals saved live, as by engines before they were flagged, still
+	// set aside the lines they reverse
+	legacy := slices.Clone(glStore.entries)
+	legacy[2].IsCancelled, legacy[3].IsCancelled = false, false
+	if found := FindDoublePostings(legacy); len(found) != 1 || len(found[0].Duplicates) != 2 {
+		t.Errorf("FindDoublePostings() over live reversals = %+v, want one", found)
+	}
+}
@@ -8,7 +8,8 @@ package ledger
 
 import (
 	"fmt"
-	"strings"
+	"hash/maphash"
+	"sync"
 )
 
 // MakeGLEntries is the main entry point for posting GL entries.
@@ -31,72 +32,184 @@ import (
 //	            save_entries(gl_map, ...)
 //	        else:
 //	            make_reverse_gl_entries(gl_map, ...)
+//
+// Failures the engine's Policy downgrades to warnings are discarded; use
+// Post to receive them.
 func (e *Engine) MakeGLEntries(glMap []GLEntry, opts PostingOptions) error {
+	_, err := e.Post(glMap, opts)
+	return err
+}
+
+// Post is MakeGLEntries returning the warnings of checks the engine's
+// Policy downgraded, in the order the checks ran. The warnings are only
+// meaningful when err is nil.
+//
+// When the engine has a RunStore, every run that gets past the empty-map
+// check is recorded, failed ones included. A run that cannot be recorded
+// does not undo the posting; the failure is returned as a CheckPostingRun
+// warning.
+//
+// Maps to: frappe.msgprint() messages shown alongside a submitted voucher
+func (e *Engine) Post(glMap []GLEntry, opts PostingOptions) (warnings []Warning, err error) {
 	if len(glMap) == 0 {
-		return nil
+		return nil, nil
+	}
+	if e.Runs == nil {
+		return e.post(glMap, opts, &PostingRun{})
 	}
 
-	// Budget validation (if enabled)
-	if e.Budget != nil && glMap[0].VoucherType != "Period Closing Voucher" {
-		if err := e.Budget.Validate(glMap); err != nil {
-			return err
-		}
+	run := e.newPostingRun(glMap, opts)
+	warnings, err = e.post(glMap, opts, run)
+	run.finish(warnings, err)
+	if serr := e.Runs.SaveRun(run); serr != nil && err == nil {
+		warnings = append(warnings, Warning{Check: CheckPostingRun, Err: serr})
 	}
+	return warnings, err
+}
 
-	if !opts.Cancel {
-		// Add accounting dimension offsetting entries
-		if e.Dimensions != nil {
-			if err := e.makeAccDimensionsOffsettingEntry(&glMap); err != nil {
-				return err
-			}
+// post is Post without recording the run; it counts the saved entries in
+// run.
+func (e *Engine) post(glMap []GLEntry, opts PostingOptions, run *PostingRun) (warnings []Warning, err error) {
+
+	// Permission to post or cancel (if configured)
+	if e.Auth != nil {
+		action := ActionPost
+		if opts.Cancel {
+			action = ActionCancel
 		}
+		if err := e.Auth.Authorize(opts.User, action, glMap[0].VoucherType, glMap[0].Company); err != nil {
+			return nil, err
+		}
+	}
 
-		// Validate accounting period
-		if e.Periods != nil {
-			if err := e.validateAccountingPeriod(glMap); err != nil {
-				return err
-			}
+	// Workflow approval (if configured)
+	if e.Gate != nil {
+		if err := e.Gate.AllowPosting(glMap[0].VoucherType, glMap[0].VoucherNo, opts.Cancel); err != nil {
+			return nil, err
 		}
+	}
 
-		// Validate disabled accounts
-		if err := e.validateDisabledAccounts(glMap); err != nil {
-			return err
+	// Work on civil dates so period checks are independent of time zones
+	glMap = NormalizeDates(glMap)
+
+	// Budget validation (if enabled)
+	if e.Budget != nil && glMap[0].VoucherType != PeriodClosingVoucher {
+		if err := e.Policy.apply(CheckBudget, e.Budget.Validate(glMap), &warnings); err != nil {
+			return nil, err
 		}
+	}
 
-		// Process GL map (distribute, merge, toggle)
-		processedMap, err := e.ProcessGLMap(glMap, opts.MergeEntries, opts.FromRepost)
+	if !opts.Cancel {
+		processedMap, err := e.prepareGLMap(glMap, opts, &warnings)
 		if err != nil {
-			return err
+			return nil, err
 		}
 
-		// Validate we have enough entries
-		if len(processedMap) < 2 {
-			return &GLEntryCountError{
-				Expected: 2,
-				Actual:   len(processedMap),
-				Message:  "Incorrect number of General Ledger Entries found. You might have selected a wrong Account in the transaction.",
-			}
+		// Save GL entries first: the store refuses a double posting, which
+		// must not leave a second set of payment ledger entries behind
+		if run.EntriesSaved, err = e.saveEntries(processedMap, opts, false); err != nil {
+			return nil, err
 		}
 
 		// Create payment ledger entries (for AR/AP tracking)
-		if e.PaymentStore != nil && glMap[0].VoucherType != "Period Closing Voucher" {
+		if e.PaymentStore != nil && processedMap[0].VoucherType != PeriodClosingVoucher {
 			if err := e.createPaymentLedgerEntries(processedMap, opts); err != nil {
-				return err
+				return nil, err
 			}
 		}
-
-		// Save GL entries
-		if err := e.saveEntries(processedMap, opts); err != nil {
-			return err
+		if e.Events != nil {
+			e.Events.Publish(voucherEvent(EventGLPosted, processedMap))
 		}
 	} else {
-		// Cancellation - create reverse entries
-		if err := e.makeReverseGLEntries(glMap, opts); err != nil {
-			return err
+		// Cancellation - as the voucher type's cancellation rule says
+		rule := e.Cancellations.Rule(glMap[0].VoucherType)
+		if err := e.checkCancellation(glMap, rule); err != nil {
+			return nil, err
+		}
+		if rule.Method == CancelByDeletion {
+			if err := e.deleteVoucher(glMap[0].VoucherType, glMap[0].VoucherNo); err != nil {
+				return nil, err
+			}
+		} else if run.EntriesSaved, err = e.makeReverseGLEntries(glMap, opts); err != nil {
+			return nil, err
+		}
+		if e.References != nil {
+			if _, err := e.References.OnCancel(glMap[0].VoucherType, glMap[0].VoucherNo); err != nil {
+				return nil, err
+			}
+		}
+		if e.Events != nil {
+			e.Events.Publish(voucherEvent(EventGLCancelled, glMap))
 		}
 	}
 
-	return nil
+	return warnings, nil
+}
+
+// prepareGLMap runs the validations and processing of a posting up to,
+// but not including, saving: dimension offsetting and balancing, period
+// and account checks, merging and toggling. Failures downgraded by the Policy are
+// appended to warnings.
+func (e *Engine) prepareGLMap(glMap []GLEntry, opts PostingOptions, warnings *[]Warning) ([]GLEntry, error) {
+	// Add accounting dimension offsetting entries
+	if e.Dimensions != nil {
+		if err := e.makeAccDimensionsOffsettingEntry(&glMap); err != nil {
+			return nil, err
+		}
+	}
+
+	// Clear the imbalance of each balancing dimension value
+	balancing := len(e.Balancing) > 0 && glMap[0].VoucherType != PeriodClosingVoucher
+	if balancing {
+		if err := e.makeDimensionClearingEntries(&glMap); err != nil {
+			return nil, err
+		}
+	}
+
+	// Validate accounting period
+	if e.Periods != nil {
+		if err := e.validateAccountingPeriod(glMap); err != nil {
+			return nil, err
+		}
+	}
+
+	// Validate disabled accounts
+	if err := e.Policy.apply(CheckDisabledAccount, e.validateDisabledAccounts(glMap), warnings); err != nil {
+		return nil, err
+	}
+
+	// Validate the posting rules of the accounts
+	if err := e.validatePostingRules(glMap); err != nil {
+		return nil, err
+	}
+
+	// Validate cost centers on profit and loss entries
+	if err := e.Policy.apply(CheckCostCenter, e.validateCostCenters(glMap), warnings); err != nil {
+		return nil, err
+	}
+
+	// Process GL map (distribute, merge, toggle). glMap is our own
+	// normalized copy, so it can be processed without further copies.
+	processedMap := processGLMapInPlace(glMap, opts.MergeEntries, e.MergeFields)
+	ResolveAgainst(processedMap, e.Accounts)
+
+	// Validate balancing dimensions, after merging could have mixed them
+	if balancing {
+		if err := e.validateBalancingDimensions(processedMap); err != nil {
+			return nil, err
+		}
+	}
+
+	// Validate we have enough entries
+	if len(processedMap) < 2 {
+		return nil, &GLEntryCountError{
+			Expected: 2,
+			Actual:   len(processedMap),
+			Message:  "Incorrect number of General Ledger Entries found. You might have selected a wrong Account in the transaction.",
+		}
+	}
+
+	return processedMap, nil
 }
 
 // ProcessGLMap processes GL entries: distributes by cost center, merges
@@ -118,22 +231,38 @@ func (e *Engine) ProcessGLMap(glMap []GLEntry, mergeEntries bool, fromRepost boo
 		return []GLEntry{}, nil
 	}
 
-	result := make([]GLEntry, len(glMap))
-	copy(result, glMap)
-
 	// Cost center allocation distribution (skip for Period Closing Voucher)
 	// Note: This is a complex feature - simplified for initial implementation
 	// Full implementation would use CostCenterAllocationProvider interface
 
-	// Merge similar entries
+	// Merging already produces a fresh slice; only copy when it does not
 	if mergeEntries {
-		result = MergeSimilarEntries(result)
+		return ProcessGLMapInPlace(MergeSimilarEntriesBy(glMap, e.MergeFields), false), nil
 	}
 
-	// Toggle debit/credit if negative
-	result = ToggleDebitCreditIfNegative(result)
+	result := make([]GLEntry, len(glMap))
+	copy(result, glMap)
+	return ProcessGLMapInPlace(result, false), nil
+}
+
+// ProcessGLMapInPlace merges and normalizes glMap using its own backing
+// array, avoiding the copies ProcessGLMap makes to protect the caller.
+// It is meant for callers that own the slice, such as high-throughput
+// posting services: the contents of glMap are overwritten and the returned
+// slice aliases it.
+func ProcessGLMapInPlace(glMap []GLEntry, mergeEntries bool) []GLEntry {
+	return processGLMapInPlace(glMap, mergeEntries, nil)
+}
+
+// processGLMapInPlace is ProcessGLMapInPlace keeping entries apart whose
+// custom fields named in customFields differ.
+func processGLMapInPlace(glMap []GLEntry, mergeEntries bool, customFields []string) []GLEntry {
+	if mergeEntries {
+		glMap = mergeSimilarEntriesInto(glMap[:0], glMap, customFields)
+	}
 
-	return result, nil
+	// Toggle debit/credit if negative
+	return ToggleDebitCreditIfNegative(glMap)
 }
 
 // MergeSimilarEntries combines GL entries with the same merge key.
@@ -158,17 +287,58 @@ func (e *Engine) ProcessGLMap(glMap []GLEntry, mergeEntries bool, fromRepost boo
 //	    # Filter zero entries
 //	    return merged_gl_map
 func MergeSimilarEntries(glMap []GLEntry) []GLEntry {
+	return MergeSimilarEntriesBy(glMap, nil)
+}
+
+// MergeSimilarEntriesBy is MergeSimilarEntries with custom fields that
+// must also agree for entries to merge, as ERPNext adds accounting
+// dimensions to the merge properties. Other custom fields of a merged
+// entry are those of the first entry merged into it.
+//
+// Maps to: get_merge_properties(dimensions) in general_ledger.py
+func MergeSimilarEntriesBy(glMap []GLEntry, customFields []string) []GLEntry {
 	if len(glMap) == 0 {
 		return glMap
 	}
 
-	merged := make([]GLEntry, 0, len(glMap))
-	keyIndex := make(map[string]int) // merge key -> index in merged
+	return mergeSimilarEntriesInto(make([]GLEntry, 0, len(glMap)), glMap, customFields)
+}
 
-	for _, entry := range glMap {
-		key := getMergeKey(entry)
+// mergeScratch holds the lookup structures used while merging.
+// They are pooled so repeated postings reuse their memory.
+type mergeScratch struct {
+	keyIndex map[uint64]int // merge key hash -> index in merged
+	next     []int          // chains merged entries whose hashes collide
+}
 
-		if idx, exists := keyIndex[key]; exists {
+var mergeScratchPool = sync.Pool{
+	New: func() any {
+		return &mergeScratch{keyIndex: make(map[uint64]int)}
+	},
+}
+
+// mergeSimilarEntriesInto merges src into merged, which must be empty.
+// merged may share src's backing array (merged == src[:0]): entries are
+// only ever written at or before the position being read.
+func mergeSimilarEntriesInto(merged, src []GLEntry, customFields []string) []GLEntry {
+	scratch := mergeScratchPool.Get().(*mergeScratch)
+	defer func() {
+		clear(scratch.keyIndex)
+		scratch.next = scratch.next[:0]
+		mergeScratchPool.Put(scratch)
+	}()
+
+	for i := range src {
+		entry := &src[i]
+		key := getMergeKey(*entry, customFields)
+		hash := maphash.Comparable(mergeKeySeed, key)
+
+		idx, exists := scratch.keyIndex[hash]
+		for exists && idx >= 0 && getMergeKey(merged[idx], customFields) != key {
+			idx = scratch.next[idx]
+		}
+
+		if exists && idx >= 0 {
 			// Add to existing entry
 			merged[idx].Debit += entry.Debit
 			merged[idx].DebitInAccountCurrency += entry.DebitInAccountCurrency
@@ -177,17 +347,22 @@ func MergeSimilarEntries(glMap []GLEntry) []GLEntry {
 			merged[idx].CreditInAccountCurrency += entry.CreditInAccountCurrency
 			merged[idx].CreditInTransactionCurrency += entry.CreditInTransactionCurrency
 		} else {
-			// Add new entry
-			keyIndex[key] = len(merged)
-			merged = append(merged, entry)
+			// Add new entry, chaining any colliding entry behind it
+			head := -1
+			if exists {
+				head = scratch.keyIndex[hash]
+			}
+			scratch.keyIndex[hash] = len(merged)
+			scratch.next = append(scratch.next, head)
+			merged = append(merged, *entry)
 		}
 	}
 
-	// Filter zero entries (but keep Exchange Gain Or Loss journal entries)
-	result := make([]GLEntry, 0, len(merged))
-	for _, entry := range merged {
-		if Flt(entry.Debit, 2) != 0 || Flt(entry.Credit, 2) != 0 {
-			result = append(result, entry)
+	// Filter zero entries in place (but keep Exchange Gain Or Loss journal entries)
+	result := merged[:0]
+	for i := range merged {
+		if Flt(merged[i].Debit, 2) != 0 || Flt(merged[i].Credit, 2) != 0 {
+			result = append(result, merged[i])
 		}
 		// Note: In full implementation, also keep Exchange Gain Or Loss entries
 	}
@@ -195,25 +370,45 @@ func MergeSimilarEntries(glMap []GLEntry) []GLEntry {
 	return result
 }
 
+// mergeKey identifies GL entries that can be consolidated.
+// Merging hashes this struct instead of joining the fields into a string,
+// so bulk imports do not allocate a key per entry.
+type mergeKey struct {
+	Account            string
+	CostCenter         string
+	Party              string
+	PartyType          string
+	VoucherDetailNo    string
+	AgainstVoucher     string
+	AgainstVoucherType string
+	Project            string
+	FinanceBook        string
+	VoucherNo          string
+	Custom             string // Values of the merge's custom fields; see custom.Fields.Key
+}
+
+// mergeKeySeed seeds merge key hashing for the lifetime of the process.
+var mergeKeySeed = maphash.MakeSeed()
+
 // getMergeKey creates a unique key for merging GL entries.
 // Entries with the same key can be consolidated.
 //
 // Maps to: get_merge_key() in general_ledger.py (lines 349-354)
-func getMergeKey(entry GLEntry) string {
+func getMergeKey(entry GLEntry, customFields []string) mergeKey {
 	// Key fields that must match for merging
-	parts := []string{
-		entry.Account,
-		entry.CostCenter,
-		entry.Party,
-		entry.PartyType,
-		entry.VoucherDetailNo,
-		entry.AgainstVoucher,
-		entry.AgainstVoucherType,
-		entry.Project,
-		entry.FinanceBook,
-		entry.VoucherNo,
-	}
-	return strings.Join(parts, "|")
+	return mergeKey{
+		Account:            entry.Account,
+		CostCenter:         entry.CostCenter,
+		Party:              entry.Party,
+		PartyType:          entry.PartyType,
+		VoucherDetailNo:    entry.VoucherDetailNo,
+		AgainstVoucher:     entry.AgainstVoucher,
+		AgainstVoucherType: entry.AgainstVoucherType,
+		Project:            entry.Project,
+		FinanceBook:        entry.FinanceBook,
+		VoucherNo:          entry.VoucherNo,
+		Custom:             entry.Custom.Key(customFields),
+	}
 }
 
 // ToggleDebitCreditIfNegative normalizes negative amounts.
@@ -331,7 +526,7 @@ func (e *Engine) validateAccountingPeriod(glMap []GLEntry) error {
 		return &PeriodClosedError{
 			Company:     entry.Company,
 			DocType:     entry.VoucherType,
-			PostingDate: entry.PostingDate.Format("2006-01-02"),
+			PostingDate: entry.PostingDate.Format(DateLayout),
 			PeriodName:  msg,
 		}
 	}
@@ -389,26 +584,31 @@ func (e *Engine) makeAccDimensionsOffsettingEntry(glMap *[]GLEntry) error {
 	return nil
 }
 
-// saveEntries validates and persists GL entries.
+// saveEntries validates and persists GL entries. It returns the number
+// saved, including any round-off entry. Entries of an adjustment change
+// the voucher's existing posting; see AdjustmentSaver.
 //
 // Maps to: save_entries() in general_ledger.py (lines 406-421)
-func (e *Engine) saveEntries(glMap []GLEntry, opts PostingOptions) error {
+func (e *Engine) saveEntries(glMap []GLEntry, opts PostingOptions, adjustment bool) (int, error) {
 	if e.GLStore == nil {
-		return nil
+		return 0, nil
 	}
 
 	// Process debit/credit difference (rounding)
 	if err := e.processDebitCreditDifference(&glMap); err != nil {
-		return err
+		return 0, err
 	}
 
 	// Validate freezing date
-	if err := e.checkFreezingDate(glMap, opts.AdvAdj); err != nil {
-		return err
+	if err := e.checkFreezingDate(glMap, opts); err != nil {
+		return 0, err
 	}
 
 	// Save all entries
-	return e.GLStore.SaveBatch(glMap)
+	if adjustment {
+		return len(glMap), e.saveAdjustment(glMap)
+	}
+	return len(glMap), e.GLStore.SaveBatch(glMap)
 }
 
 // processDebitCreditDifference handles rounding differences.
@@ -507,9 +707,12 @@ func (e *Engine) makeRoundOffGLE(glMap *[]GLEntry, diff float64, precision int)
 	return nil
 }
 
-// checkFreezingDate validates against accounts frozen date.
-func (e *Engine) checkFreezingDate(glMap []GLEntry, advAdj bool) error {
-	if e.Company == nil || len(glMap) == 0 || advAdj {
+// checkFreezingDate validates against accounts frozen date. Users the
+// Authorizer allows to bypass the freeze may still post.
+//
+// Maps to: check_freezing_date() in general_ledger.py
+func (e *Engine) checkFreezingDate(glMap []GLEntry, opts PostingOptions) error {
+	if e.Company == nil || len(glMap) == 0 || opts.AdvAdj {
 		return nil
 	}
 
@@ -521,24 +724,28 @@ func (e *Engine) checkFreezingDate(glMap []GLEntry, advAdj bool) error {
 		return err
 	}
 
-	if frozenDate != nil && postingDate.Before(*frozenDate) {
+	if frozenDate != nil && CivilDate(postingDate).Before(CivilDate(*frozenDate)) {
+		if e.Auth != nil && e.Auth.Authorize(opts.User, ActionBypassFrozen, glMap[0].VoucherType, company) == nil {
+			return nil
+		}
 		return NewValidationError(
 			ErrAccountsFrozenTill,
 			"",
-			fmt.Sprintf("Accounts are frozen till %s", frozenDate.Format("2006-01-02")),
+			fmt.Sprintf("Accounts are frozen till %s", frozenDate.Format(DateLayout)),
 		)
 	}
 
 	return nil
 }
 
-// makeReverseGLEntries creates reversing entries for cancellation.
+// makeReverseGLEntries creates reversing entries for cancellation. It
+// returns the number of reversing entries saved.
 //
 // Maps to: make_reverse_gl_entries() in general_ledger.py
-func (e *Engine) makeReverseGLEntries(glMap []GLEntry, opts PostingOptions) error {
+func (e *Engine) makeReverseGLEntries(glMap []GLEntry, opts PostingOptions) (int, error) {
 	// Get existing entries for the voucher
 	if e.GLStore == nil || len(glMap) == 0 {
-		return nil
+		return 0, nil
 	}
 
 	voucherType := glMap[0].VoucherType
@@ -546,13 +753,30 @@ func (e *Engine) makeReverseGLEntries(glMap []GLEntry, opts PostingOptions) erro
 
 	existingEntries, err := e.GLStore.GetByVoucher(voucherType, voucherNo)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
 	// Create reversed entries
-	reversedEntries := make([]GLEntry, len(existingEntries))
-	for i, entry := range existingEntries {
+	reversedEntries := reverseEntries(existingEntries)
+
+	// Mark original entries as cancelled
+	if err := e.GLStore.MarkCancelled(voucherType, voucherNo); err != nil {
+		return 0, err
+	}
+
+	// Save reversed entries; they adjust the posting rather than add one
+	return len(reversedEntries), e.saveAdjustment(reversedEntries)
+}
+
+// reverseEntries returns copies of entries with debit and credit swapped.
+// The reversals are cancelled like the entries they reverse, so a report
+// that skips cancelled entries sees neither side of the voucher.
+func reverseEntries(entries []GLEntry) []GLEntry {
+	reversedEntries := make([]GLEntry, len(entries))
+	for i, entry := range entries {
 		reversed := entry.Copy()
+		reversed.Name = "" // A new entry; the store names it
+		reversed.IsCancelled = true
 		// Swap debit and credit
 		reversed.Debit, reversed.Credit = entry.Credit, entry.Debit
 		reversed.DebitInAccountCurrency, reversed.CreditInAccountCurrency =
@@ -562,14 +786,7 @@ func (e *Engine) makeReverseGLEntries(glMap []GLEntry, opts PostingOptions) erro
 		reversed.Remarks = "Cancelled: " + entry.Remarks
 		reversedEntries[i] = reversed
 	}
-
-	// Mark original entries as cancelled
-	if err := e.GLStore.MarkCancelled(voucherType, voucherNo); err != nil {
-		return err
-	}
-
-	// Save reversed entries
-	return e.GLStore.SaveBatch(reversedEntries)
+	return reversedEntries
 }
 
 // createPaymentLedgerEntries creates payment ledger entries for AR/AP tracking.
@@ -609,11 +826,13 @@ func (e *Engine) createPaymentLedgerEntries(glMap []GLEntry, opts PostingOptions
 		entries = append(entries, entry)
 	}
 
-	if len(entries) > 0 {
-		return e.PaymentStore.SaveBatch(entries)
+	if len(entries) == 0 {
+		return nil
 	}
-
-	return nil
+	if e.PaymentLedger == AsyncPaymentLedger {
+		return e.deferPaymentLedger(entries)
+	}
+	return e.PaymentStore.SaveBatch(entries)
 }
 
 // Helper functions
@@ -2,8 +2,11 @@ package ledger
 
 import (
 	"errors"
+	"slices"
 	"testing"
 	"time"
+
+	"github.com/senguttuvang/erpnext-go/custom"
 )
 
 // Mock implementations for testing
@@ -369,6 +372,39 @@ func TestMergeSimilarEntries(t *testing.T) {
 	}
 }
 
+func TestMergeSimilarEntriesByCustomFields(t *testing.T) {
+	north, south := makeTestGLEntry("Sales - ABC", 50, 0), makeTestGLEntry("Sales - ABC", 30, 0)
+	north.Custom = custom.Fields{"region": "North", "channel": "Web"}
+	south.Custom = custom.Fields{"region": "South"}
+	glMap := []GLEntry{north, south, makeTestGLEntry("Debtors - ABC", 0, 80)}
+
+	if merged := MergeSimilarEntries(glMap); len(merged) != 2 || merged[0].Custom.String("channel") != "Web" {
+		t.Errorf("without merge fields: %+v", merged)
+	}
+	if merged := MergeSimilarEntriesBy(glMap, []string{"region"}); len(merged) != 3 {
+		t.Errorf("entries of different regions merged: %d entries", len(merged))
+	}
+
+	// Posting carries the fields to the store and onto the reversal.
+	store := &mockGLStore{}
+	engine := &Engine{GLStore: store, MergeFields: []string{"region"}}
+	if err := engine.MakeGLEntries(glMap, DefaultPostingOptions()); err != nil {
+		t.Fatal(err)
+	}
+	cancel := DefaultPostingOptions()
+	cancel.Cancel = true
+	if err := engine.MakeGLEntries(glMap[:1], cancel); err != nil {
+		t.Fatal(err)
+	}
+	var regions []string
+	for _, e := range store.entries {
+		regions = append(regions, e.Custom.String("region"))
+	}
+	if want := []string{"North", "South", "", "North", "South", ""}; !slices.Equal(regions, want) {
+		t.Errorf("stored regions = %q, want %q", regions, want)
+	}
+}
+
 // Tests for ToggleDebitCreditIfNegative
 
 func TestToggleDebitCreditIfNegative(t *testing.T) {
@@ -539,6 +575,50 @@ func TestProcessGLMap(t *testing.T) {
 	}
 }
 
+func TestProcessGLMap_DoesNotModifyInput(t *testing.T) {
+	engine := &Engine{}
+	entries := []GLEntry{
+		makeTestGLEntry("Sales - ABC", -50, 0),
+		makeTestGLEntry("Debtors - ABC", 0, -50),
+	}
+
+	for _, merge := range []bool{true, false} {
+		if _, err := engine.ProcessGLMap(entries, merge, false); err != nil {
+			t.Fatalf("ProcessGLMap() error = %v", err)
+		}
+		if entries[0].Debit != -50 || entries[1].Credit != -50 {
+			t.Errorf("ProcessGLMap(merge=%v) modified its input", merge)
+		}
+	}
+}
+
+func TestProcessGLMapInPlace(t *testing.T) {
+	entries := []GLEntry{
+		makeTestGLEntry("Sales - ABC", 0, 30),
+		makeTestGLEntry("Debtors - ABC", 100, 0),
+		makeTestGLEntry("Sales - ABC", 0, 70),
+		makeTestGLEntry("Round Off - ABC", 5, 0),
+		makeTestGLEntry("Round Off - ABC", -5, 0),
+	}
+
+	expected, _ := (&Engine{}).ProcessGLMap(entries, true, false)
+	result := ProcessGLMapInPlace(entries, true)
+
+	if len(result) != len(expected) {
+		t.Fatalf("ProcessGLMapInPlace() count = %d, want %d", len(result), len(expected))
+	}
+	for i := range expected {
+		if result[i].Account != expected[i].Account || result[i].Debit != expected[i].Debit || result[i].Credit != expected[i].Credit {
+			t.Errorf("entry %d = %s %.2f/%.2f, want %s %.2f/%.2f", i,
+				result[i].Account, result[i].Debit, result[i].Credit,
+				expected[i].Account, expected[i].Debit, expected[i].Credit)
+		}
+	}
+	if &result[0] != &entries[0] {
+		t.Errorf("ProcessGLMapInPlace() did not reuse the input backing array")
+	}
+}
+
 // Tests for getDebitCreditDifference
 
 func TestGetDebitCreditDifference(t *testing.T) {
@@ -698,6 +778,18 @@ func TestRound(t *testing.T) {
 			precision: 2,
 			expected:  123.46,
 		},
+		{
+			name:      "negative round half away from zero",
+			value:     -123.455,
+			precision: 2,
+			expected:  -123.46,
+		},
+		{
+			name:      "negative rounding amount",
+			value:     -0.01,
+			precision: 2,
+			expected:  -0.01,
+		},
 		{
 			name:      "negative precision",
 			value:     123.456,
@@ -14,6 +14,10 @@ var (
 	ErrAccountDisabled = errors.New("account is disabled")
 	ErrAccountFrozen   = errors.New("account is frozen")
 	ErrAccountIsGroup  = errors.New("cannot post to group account")
+	ErrMissingAccount  = errors.New("account is required")
+	ErrMissingCostCenter = errors.New("cost center is required for profit and loss account")
+	ErrVoucherTypeNotAllowed = errors.New("voucher type may not post to account")
+	ErrPartyRequired         = errors.New("account requires a party")
 
 	// Balance validation errors
 	ErrDebitCreditMismatch = errors.New("debit and credit amounts do not balance")
@@ -24,6 +28,8 @@ var (
 	ErrFiscalYearNotFound  = errors.New("fiscal year not found for date")
 	ErrAccountsFrozenTill  = errors.New("accounts frozen till date")
 	ErrBooksClosedTill     = errors.New("books closed till date")
+	ErrInvalidPeriodDefinition = errors.New("invalid accounting period definition")
+	ErrPeriodNotFound          = errors.New("no accounting period covers date")
 
 	// Budget validation errors
 	ErrBudgetExceeded = errors.New("budget exceeded")
@@ -35,6 +41,26 @@ var (
 	// Voucher validation errors
 	ErrVoucherNotFound    = errors.New("voucher not found")
 	ErrVoucherAlreadyPosted = errors.New("voucher already has GL entries")
+	ErrRelinkNotSupported   = errors.New("store cannot relink against-voucher references")
+	ErrNoOutbox             = errors.New("asynchronous payment ledger writes need an outbox")
+	ErrDoublePosting        = errors.New("voucher already posted into the finance book")
+
+	// Cancellation policy errors
+	ErrCancellationForbidden = errors.New("voucher may not be cancelled")
+	ErrDeleteNotSupported    = errors.New("store cannot delete a voucher's entries")
+
+	// Dimension errors
+	ErrDimensionUnbalanced = errors.New("balancing dimension does not balance")
+
+	// Chart of accounts errors
+	ErrAccountNotInTree = errors.New("account not in chart of accounts")
+	ErrAccountTreeCycle = errors.New("chart of accounts has a cycle")
+
+	// Permission errors
+	ErrNotPermitted = errors.New("not permitted")
+
+	// Snapshot errors
+	ErrInvalidSnapshot = errors.New("invalid ledger snapshot")
 )
 
 // ValidationError wraps a sentinel error with additional context.
@@ -107,6 +133,7 @@ type BudgetExceededError struct {
 	CostCenter string
 	Budget     float64
 	Actual     float64
+	Committed  float64 // Part of Actual committed by open requests and orders
 	Variance   float64
 }
 
@@ -0,0 +1,80 @@
+package ledger
+
+import (
+	"sync"
+	"time"
+)
+
+// Event types published by the engine and document modules.
+const (
+	EventGLPosted       = "gl.posted"       // Published by MakeGLEntries
+	EventGLCancelled    = "gl.cancelled"    // Published by MakeGLEntries on cancel
+	EventInvoicePaid    = "invoice.paid"    // An invoice's outstanding reached zero
+	EventInvoiceOverdue = "invoice.overdue" // An invoice is unpaid past its due date
+	EventBudgetUsage    = "budget.usage"    // Spending against a budget was measured
+	EventChargeFailed   = "charge.failed"   // Charging a saved payment method failed
+	EventInvoiceDunning = "invoice.dunning" // An unpaid invoice reached a dunning level
+)
+
+// Event is something that happened in the books.
+type Event struct {
+	Type        string
+	Time        time.Time // When the event was published
+	Company     string
+	VoucherType string
+	VoucherNo   string
+	PostingDate time.Time
+	PartyType   string
+	Party       string
+	Amount      float64        // Voucher total (debit), or the amount concerned
+	Data        map[string]any // Event-specific details
+}
+
+// EventPublisher receives events. Publish must not block posting: slow
+// consumers queue the event and handle failures themselves.
+//
+// Maps to: frappe's doc_events hooks and run_notifications() on submit and
+// cancel
+type EventPublisher interface {
+	Publish(event Event)
+}
+
+// EventBus fans events out to subscribers in subscription order.
+type EventBus struct {
+	mu          sync.RWMutex
+	subscribers []func(Event)
+}
+
+// Subscribe registers a handler for every event.
+func (b *EventBus) Subscribe(fn func(Event)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subscribers = append(b.subscribers, fn)
+}
+
+// Publish implements EventPublisher.
+func (b *EventBus) Publish(event Event) {
+	b.mu.RLock()
+	subscribers := b.subscribers
+	b.mu.RUnlock()
+	for _, fn := range subscribers {
+		fn(event)
+	}
+}
+
+// voucherEvent describes a posted or cancelled voucher.
+func voucherEvent(eventType string, glMap []GLEntry) Event {
+	first := glMap[0]
+	event := Event{
+		Type: eventType, Time: time.Now(), Company: first.Company,
+		VoucherType: first.VoucherType, VoucherNo: first.VoucherNo, PostingDate: first.PostingDate,
+		Amount: Flt(GLMap(glMap).TotalDebit(), 2),
+	}
+	for _, e := range glMap {
+		if e.Party != "" {
+			event.PartyType, event.Party = e.PartyType, e.Party
+			break
+		}
+	}
+	return event
+}
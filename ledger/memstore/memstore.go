@@ -0,0 +1,523 @@
+// Package memstore provides in-memory adapters for the ledger ports and
+// the naming series store.
+//
+// These are "fakes" in the test double sense: working implementations
+// without a database, used by integration tests, verification harnesses
+// and tools that need a real Engine without MariaDB or PostgreSQL.
+// All adapters are safe for concurrent use.
+package memstore
+
+import (
+	"errors"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/ledger"
+)
+
+// ErrAccountNotFound is returned when an account is not registered.
+var ErrAccountNotFound = errors.New("account not found")
+
+// GLStore is an in-memory ledger.GLEntryStore.
+type GLStore struct {
+	mu      sync.RWMutex
+	entries []ledger.GLEntry
+}
+
+// NewGLStore creates an empty GL entry store.
+func NewGLStore() *GLStore {
+	return &GLStore{}
+}
+
+// Save appends a GL entry.
+func (s *GLStore) Save(entry *ledger.GLEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries = append(s.entries, entry.Copy())
+	return nil
+}
+
+// SaveBatch appends multiple GL entries.
+func (s *GLStore) SaveBatch(entries []ledger.GLEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range entries {
+		s.entries = append(s.entries, entries[i].Copy())
+	}
+	return nil
+}
+
+// GetByVoucher returns the entries of a voucher, including cancelled ones.
+func (s *GLStore) GetByVoucher(voucherType, voucherNo string) ([]ledger.GLEntry, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var result []ledger.GLEntry
+	for i := range s.entries {
+		if s.entries[i].VoucherType == voucherType && s.entries[i].VoucherNo == voucherNo {
+			result = append(result, s.entries[i].Copy())
+		}
+	}
+	return result, nil
+}
+
+// MarkCancelled flags all entries of a voucher as cancelled.
+func (s *GLStore) MarkCancelled(voucherType, voucherNo string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.entries {
+		if s.entries[i].VoucherType == voucherType && s.entries[i].VoucherNo == voucherNo {
+			s.entries[i].IsCancelled = true
+		}
+	}
+	return nil
+}
+
+// DeleteVoucher implements ledger.VoucherDeleter.
+func (s *GLStore) DeleteVoucher(voucherType, voucherNo string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := len(s.entries)
+	s.entries = slices.DeleteFunc(s.entries, func(e ledger.GLEntry) bool {
+		return e.VoucherType == voucherType && e.VoucherNo == voucherNo
+	})
+	return n - len(s.entries), nil
+}
+
+// RelinkAgainstVoucher implements ledger.AgainstVoucherRelinker.
+func (s *GLStore) RelinkAgainstVoucher(voucherType, from, to string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.entries {
+		e := &s.entries[i]
+		if !e.IsCancelled && e.AgainstVoucherType == voucherType && e.AgainstVoucher == from &&
+			!(e.VoucherType == voucherType && e.VoucherNo == from) {
+			e.AgainstVoucher = to
+		}
+	}
+	return nil
+}
+
+// UnlinkAgainstVoucher implements ledger.AgainstVoucherUnlinker.
+func (s *GLStore) UnlinkAgainstVoucher(voucherType, voucherNo string) ([]ledger.VoucherRef, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var affected []ledger.VoucherRef
+	for i := range s.entries {
+		e := &s.entries[i]
+		if !e.IsCancelled && e.AgainstVoucherType == voucherType && e.AgainstVoucher == voucherNo &&
+			!(e.VoucherType == voucherType && e.VoucherNo == voucherNo) {
+			e.AgainstVoucherType, e.AgainstVoucher = "", ""
+			affected = append(affected, ledger.VoucherRef{VoucherType: e.VoucherType, VoucherNo: e.VoucherNo, Company: e.Company})
+		}
+	}
+	return affected, nil
+}
+
+// RelinkParty implements ledger.PartyRelinker. Cancelled entries move
+// too, so the party's history stays complete.
+func (s *GLStore) RelinkParty(partyType, from, to string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for i := range s.entries {
+		e := &s.entries[i]
+		if e.PartyType == partyType && e.Party == from {
+			e.Party = to
+			n++
+		}
+	}
+	return n, nil
+}
+
+// PseudonymizeParty implements ledger.PartyPseudonymizer, cancelled
+// entries included.
+func (s *GLStore) PseudonymizeParty(partyType, name, token string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for i := range s.entries {
+		if s.entries[i].PseudonymizeParty(partyType, name, token) {
+			n++
+		}
+	}
+	return n, nil
+}
+
+// All returns a copy of every stored entry in insertion order.
+func (s *GLStore) All() []ledger.GLEntry {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	result := make([]ledger.GLEntry, len(s.entries))
+	for i := range s.entries {
+		result[i] = s.entries[i].Copy()
+	}
+	return result
+}
+
+// PaymentStore is an in-memory ledger.PaymentLedgerStore.
+type PaymentStore struct {
+	mu      sync.RWMutex
+	entries []ledger.PaymentLedgerEntry
+}
+
+// NewPaymentStore creates an empty payment ledger store.
+func NewPaymentStore() *PaymentStore {
+	return &PaymentStore{}
+}
+
+// Save appends a payment ledger entry.
+func (s *PaymentStore) Save(entry *ledger.PaymentLedgerEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries = append(s.entries, *entry)
+	return nil
+}
+
+// SaveBatch appends multiple payment ledger entries.
+func (s *PaymentStore) SaveBatch(entries []ledger.PaymentLedgerEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries = append(s.entries, entries...)
+	return nil
+}
+
+// GetByVoucher returns the payment ledger entries of a voucher.
+func (s *PaymentStore) GetByVoucher(voucherType, voucherNo string) ([]ledger.PaymentLedgerEntry, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var result []ledger.PaymentLedgerEntry
+	for _, e := range s.entries {
+		if e.VoucherType == voucherType && e.VoucherNo == voucherNo {
+			result = append(result, e)
+		}
+	}
+	return result, nil
+}
+
+// GetByAgainstVoucher returns the payment ledger entries booked against a
+// voucher, including the voucher's own entries.
+func (s *PaymentStore) GetByAgainstVoucher(voucherType, voucherNo string) ([]ledger.PaymentLedgerEntry, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var result []ledger.PaymentLedgerEntry
+	for _, e := range s.entries {
+		if e.AgainstVoucherType == voucherType && e.AgainstVoucherNo == voucherNo {
+			result = append(result, e)
+		}
+	}
+	return result, nil
+}
+
+// Delink marks the payment ledger entries of a voucher as delinked.
+func (s *PaymentStore) Delink(voucherType, voucherNo string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.entries {
+		if s.entries[i].VoucherType == voucherType && s.entries[i].VoucherNo == voucherNo {
+			s.entries[i].Delinked = true
+		}
+	}
+	return nil
+}
+
+// DeleteVoucher implements ledger.VoucherDeleter.
+func (s *PaymentStore) DeleteVoucher(voucherType, voucherNo string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := len(s.entries)
+	s.entries = slices.DeleteFunc(s.entries, func(e ledger.PaymentLedgerEntry) bool {
+		return e.VoucherType == voucherType && e.VoucherNo == voucherNo
+	})
+	return n - len(s.entries), nil
+}
+
+// RelinkAgainstVoucher implements ledger.AgainstVoucherRelinker.
+func (s *PaymentStore) RelinkAgainstVoucher(voucherType, from, to string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.entries {
+		e := &s.entries[i]
+		if !e.Delinked && e.AgainstVoucherType == voucherType && e.AgainstVoucherNo == from &&
+			!(e.VoucherType == voucherType && e.VoucherNo == from) {
+			e.AgainstVoucherNo = to
+		}
+	}
+	return nil
+}
+
+// UnlinkAgainstVoucher implements ledger.AgainstVoucherUnlinker.
+func (s *PaymentStore) UnlinkAgainstVoucher(voucherType, voucherNo string) ([]ledger.VoucherRef, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var affected []ledger.VoucherRef
+	for i := range s.entries {
+		e := &s.entries[i]
+		if !e.Delinked && e.AgainstVoucherType == voucherType && e.AgainstVoucherNo == voucherNo &&
+			!(e.VoucherType == voucherType && e.VoucherNo == voucherNo) {
+			e.AgainstVoucherType, e.AgainstVoucherNo = e.VoucherType, e.VoucherNo
+			affected = append(affected, ledger.VoucherRef{VoucherType: e.VoucherType, VoucherNo: e.VoucherNo, Company: e.Company})
+		}
+	}
+	return affected, nil
+}
+
+// RelinkParty implements ledger.PartyRelinker.
+func (s *PaymentStore) RelinkParty(partyType, from, to string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for i := range s.entries {
+		e := &s.entries[i]
+		if e.PartyType == partyType && e.Party == from {
+			e.Party = to
+			n++
+		}
+	}
+	return n, nil
+}
+
+// PseudonymizeParty implements ledger.PartyPseudonymizer.
+func (s *PaymentStore) PseudonymizeParty(partyType, name, token string) (int, error) {
+	return s.RelinkParty(partyType, name, token)
+}
+
+// All returns a copy of every stored payment ledger entry.
+func (s *PaymentStore) All() []ledger.PaymentLedgerEntry {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	result := make([]ledger.PaymentLedgerEntry, len(s.entries))
+	copy(result, s.entries)
+	return result
+}
+
+// Runs is an in-memory ledger.RunStore.
+type Runs struct {
+	mu   sync.RWMutex
+	runs []ledger.PostingRun
+}
+
+// NewRuns creates an empty posting run store.
+func NewRuns() *Runs {
+	return &Runs{}
+}
+
+// SaveRun appends a posting run.
+func (s *Runs) SaveRun(run *ledger.PostingRun) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.runs = append(s.runs, *run)
+	return nil
+}
+
+// Runs returns the runs of a voucher in the order they were saved.
+func (s *Runs) Runs(voucherType, voucherNo string) ([]ledger.PostingRun, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var result []ledger.PostingRun
+	for _, run := range s.runs {
+		if run.VoucherType == voucherType && run.VoucherNo == voucherNo {
+			result = append(result, run)
+		}
+	}
+	return result, nil
+}
+
+// Accounts is an in-memory ledger.AccountLookup over a fixed chart.
+type Accounts struct {
+	mu       sync.RWMutex
+	accounts map[string]ledger.Account
+}
+
+// NewAccounts creates an account lookup holding the given accounts.
+func NewAccounts(accounts ...ledger.Account) *Accounts {
+	a := &Accounts{accounts: make(map[string]ledger.Account)}
+	for _, acc := range accounts {
+		a.accounts[acc.Name] = acc
+	}
+	return a
+}
+
+// Add registers or replaces an account.
+func (a *Accounts) Add(acc ledger.Account) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.accounts[acc.Name] = acc
+}
+
+// GetAccount returns full account details.
+func (a *Accounts) GetAccount(name string) (*ledger.Account, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	acc, ok := a.accounts[name]
+	if !ok {
+		return nil, ErrAccountNotFound
+	}
+	return &acc, nil
+}
+
+// GetAccountCurrency returns the account's designated currency.
+func (a *Accounts) GetAccountCurrency(name string) (string, error) {
+	acc, err := a.GetAccount(name)
+	if err != nil {
+		return "", err
+	}
+	return acc.AccountCurrency, nil
+}
+
+// IsGroup returns true if the account is a group account.
+func (a *Accounts) IsGroup(name string) (bool, error) {
+	acc, err := a.GetAccount(name)
+	if err != nil {
+		return false, err
+	}
+	return acc.IsGroup, nil
+}
+
+// IsFrozen returns true if the account is frozen.
+func (a *Accounts) IsFrozen(name string) (bool, error) {
+	acc, err := a.GetAccount(name)
+	if err != nil {
+		return false, err
+	}
+	return acc.FreezeAccount, nil
+}
+
+// IsDisabled returns true if the account is disabled.
+func (a *Accounts) IsDisabled(name string) (bool, error) {
+	acc, err := a.GetAccount(name)
+	if err != nil {
+		return false, err
+	}
+	return acc.Disabled, nil
+}
+
+// GetBalanceMustBe returns the balance constraint of the account.
+func (a *Accounts) GetBalanceMustBe(name string) (string, error) {
+	acc, err := a.GetAccount(name)
+	if err != nil {
+		return "", err
+	}
+	return acc.BalanceMustBe, nil
+}
+
+// Company is a static ledger.CompanySettings shared by every company.
+type Company struct {
+	DefaultCurrency    string
+	RoundOffAccount    string
+	RoundOffCostCenter string
+	AccountsFrozenTill *time.Time
+	BookClosingDate    *time.Time
+}
+
+// GetDefaultCurrency returns the company's base currency.
+func (c *Company) GetDefaultCurrency(company string) (string, error) {
+	return c.DefaultCurrency, nil
+}
+
+// GetRoundOffAccount returns the round-off account.
+func (c *Company) GetRoundOffAccount(company string) (string, error) {
+	return c.RoundOffAccount, nil
+}
+
+// GetRoundOffCostCenter returns the round-off cost center.
+func (c *Company) GetRoundOffCostCenter(company string) (string, error) {
+	return c.RoundOffCostCenter, nil
+}
+
+// GetAccountsFrozenTillDate returns the accounts frozen till date, if any.
+func (c *Company) GetAccountsFrozenTillDate(company string) (*time.Time, error) {
+	return c.AccountsFrozenTill, nil
+}
+
+// GetBookClosingDate returns the books closing date, if any.
+func (c *Company) GetBookClosingDate(company string) (*time.Time, error) {
+	return c.BookClosingDate, nil
+}
+
+// Series is an in-memory naming.Store.
+type Series struct {
+	mu      sync.Mutex
+	current map[string]int
+}
+
+// NewSeries creates a store with every counter at zero.
+func NewSeries() *Series {
+	return &Series{current: make(map[string]int)}
+}
+
+// Next increments a prefix's counter and returns the new value.
+func (s *Series) Next(prefix string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.current[prefix]++
+	return s.current[prefix], nil
+}
+
+// Current returns the last value handed out for a prefix.
+func (s *Series) Current(prefix string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.current[prefix], nil
+}
+
+// SetCurrent resets a prefix's counter.
+func (s *Series) SetCurrent(prefix string, current int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.current[prefix] = current
+	return nil
+}
+
+// Outbox is an in-memory ledger.PaymentLedgerOutbox.
+type Outbox struct {
+	mu      sync.Mutex
+	pending []ledger.PendingPaymentLedger
+}
+
+// NewOutbox creates an empty outbox.
+func NewOutbox() *Outbox {
+	return &Outbox{}
+}
+
+// Defer appends a pending voucher.
+func (o *Outbox) Defer(p ledger.PendingPaymentLedger) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.pending = append(o.pending, p)
+	return nil
+}
+
+// Pending returns the oldest pending vouchers, in the order they were
+// deferred.
+func (o *Outbox) Pending(limit int) ([]ledger.PendingPaymentLedger, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	n := len(o.pending)
+	if limit > 0 {
+		n = min(n, limit)
+	}
+	return append([]ledger.PendingPaymentLedger(nil), o.pending[:n]...), nil
+}
+
+// Get returns a pending voucher, or nil.
+func (o *Outbox) Get(voucherType, voucherNo string) (*ledger.PendingPaymentLedger, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	for _, p := range o.pending {
+		if p.Voucher.VoucherType == voucherType && p.Voucher.VoucherNo == voucherNo {
+			return &p, nil
+		}
+	}
+	return nil, nil
+}
+
+// Done removes a pending voucher.
+func (o *Outbox) Done(voucherType, voucherNo string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.pending = slices.DeleteFunc(o.pending, func(p ledger.PendingPaymentLedger) bool {
+		return p.Voucher.VoucherType == voucherType && p.Voucher.VoucherNo == voucherNo
+	})
+	return nil
+}
@@ -0,0 +1,94 @@
+package memstore
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/ledger"
+)
+
+// Compile-time checks that the fakes satisfy the ledger ports.
+var (
+	_ ledger.GLEntryStore        = (*GLStore)(nil)
+	_ ledger.PaymentLedgerStore  = (*PaymentStore)(nil)
+	_ ledger.AccountLookup       = (*Accounts)(nil)
+	_ ledger.CompanySettings     = (*Company)(nil)
+	_ ledger.RunStore            = (*Runs)(nil)
+	_ ledger.PaymentLedgerOutbox = (*Outbox)(nil)
+
+	_ ledger.AgainstVoucherRelinker = (*GLStore)(nil)
+	_ ledger.AgainstVoucherRelinker = (*PaymentStore)(nil)
+	_ ledger.PartyPseudonymizer     = (*GLStore)(nil)
+	_ ledger.PartyPseudonymizer     = (*PaymentStore)(nil)
+)
+
+func TestGLStore(t *testing.T) {
+	store := NewGLStore()
+	due := time.Date(2024, 2, 15, 0, 0, 0, 0, time.UTC)
+
+	store.SaveBatch([]ledger.GLEntry{
+		{VoucherType: "Sales Invoice", VoucherNo: "SINV-001", Account: "Debtors - ABC", Debit: 100, DueDate: &due},
+		{VoucherType: "Sales Invoice", VoucherNo: "SINV-001", Account: "Sales - ABC", Credit: 100},
+	})
+	store.Save(&ledger.GLEntry{VoucherType: "Sales Invoice", VoucherNo: "SINV-002", Account: "Sales - ABC"})
+
+	entries, _ := store.GetByVoucher("Sales Invoice", "SINV-001")
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 entries, got %d", len(entries))
+	}
+
+	// Returned entries must not alias stored ones
+	*entries[0].DueDate = due.AddDate(0, 1, 0)
+	if again, _ := store.GetByVoucher("Sales Invoice", "SINV-001"); !again[0].DueDate.Equal(due) {
+		t.Errorf("Modifying a returned entry changed the store")
+	}
+
+	store.MarkCancelled("Sales Invoice", "SINV-001")
+	for _, e := range store.All() {
+		if e.IsCancelled != (e.VoucherNo == "SINV-001") {
+			t.Errorf("%s cancelled = %v", e.VoucherNo, e.IsCancelled)
+		}
+	}
+}
+
+func TestPaymentStore(t *testing.T) {
+	store := NewPaymentStore()
+	store.SaveBatch([]ledger.PaymentLedgerEntry{
+		{VoucherType: "Payment Entry", VoucherNo: "PE-001", AgainstVoucherType: "Sales Invoice", AgainstVoucherNo: "SINV-001", Amount: -100},
+	})
+
+	against, _ := store.GetByAgainstVoucher("Sales Invoice", "SINV-001")
+	if len(against) != 1 || against[0].VoucherNo != "PE-001" {
+		t.Errorf("GetByAgainstVoucher() = %+v", against)
+	}
+
+	store.Save(&ledger.PaymentLedgerEntry{VoucherType: "Sales Invoice", VoucherNo: "SINV-001", AgainstVoucherType: "Sales Invoice", AgainstVoucherNo: "SINV-001", Amount: 100})
+	store.RelinkAgainstVoucher("Sales Invoice", "SINV-001", "SINV-001-1")
+	for _, e := range store.All() {
+		want := map[string]string{"PE-001": "SINV-001-1", "SINV-001": "SINV-001"}[e.VoucherNo]
+		if e.AgainstVoucherNo != want {
+			t.Errorf("%s against %s, want %s", e.VoucherNo, e.AgainstVoucherNo, want)
+		}
+	}
+
+	store.Delink("Payment Entry", "PE-001")
+	entries, _ := store.GetByVoucher("Payment Entry", "PE-001")
+	if len(entries) != 1 || !entries[0].Delinked {
+		t.Errorf("Expected one delinked entry, got %+v", entries)
+	}
+}
+
+func TestAccounts(t *testing.T) {
+	accounts := NewAccounts(ledger.Account{Name: "Cash - ABC", AccountCurrency: "INR", Disabled: true})
+
+	if currency, _ := accounts.GetAccountCurrency("Cash - ABC"); currency != "INR" {
+		t.Errorf("GetAccountCurrency() = %q, want INR", currency)
+	}
+	if disabled, _ := accounts.IsDisabled("Cash - ABC"); !disabled {
+		t.Errorf("IsDisabled() = false, want true")
+	}
+	if _, err := accounts.GetAccount("Missing - ABC"); !errors.Is(err, ErrAccountNotFound) {
+		t.Errorf("Expected ErrAccountNotFound, got %v", err)
+	}
+}
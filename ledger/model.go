@@ -8,6 +8,9 @@ package ledger
 
 import (
 	"time"
+
+	"github.com/senguttuvang/erpnext-go/custom"
+	"github.com/senguttuvang/erpnext-go/numeric"
 )
 
 // IsOpeningEntry defines whether a GL entry is an opening balance entry.
@@ -50,7 +53,8 @@ type GLEntry struct {
 	Party     string // Party name
 
 	// Counter account for journal display
-	Against string // Comma-separated list of counter accounts
+	Against     string       // Comma-separated list of counter accounts and parties
+	AgainstRefs []AgainstRef // Against as typed refs; see ResolveAgainst
 
 	// Voucher (Source Document)
 	VoucherType    string // "Sales Invoice", "Journal Entry", etc.
@@ -94,6 +98,9 @@ type GLEntry struct {
 	IsCancelled bool           // Cancellation flag
 	Remarks     string         // Free-text remarks
 
+	// Custom fields of the deployment; see Engine.MergeFields
+	Custom custom.Fields
+
 	// Internal flags (not persisted, used during processing)
 	ToRename bool // Flag for renaming logic
 
@@ -116,6 +123,7 @@ type PostingOptions struct {
 	MergeEntries      bool   // Merge similar GL entries
 	UpdateOutstanding string // "Yes" or "No" - update AR/AP outstanding
 	FromRepost        bool   // True if reposting (e.g., valuation change)
+	User              string // Checked by the engine's Authorizer, if any
 }
 
 // DefaultPostingOptions returns standard posting options.
@@ -199,26 +207,18 @@ func (e *GLEntry) Copy() GLEntry {
 		d := *e.DueDate
 		copy.DueDate = &d
 	}
+	copy.Custom = e.Custom.Clone()
 	return copy
 }
 
 // Flt converts to float and optionally rounds.
 // Maps to: frappe.utils.flt() in Python
 func Flt(value float64, precision ...int) float64 {
-	if len(precision) > 0 {
-		return Round(value, precision[0])
-	}
-	return value
+	return numeric.Flt(value, precision...)
 }
 
-// Round rounds a value to the specified precision.
+// Round rounds a value to the specified precision, halves away from zero,
+// as numeric.Round does.
 func Round(value float64, precision int) float64 {
-	if precision < 0 {
-		return value
-	}
-	multiplier := 1.0
-	for i := 0; i < precision; i++ {
-		multiplier *= 10
-	}
-	return float64(int64(value*multiplier+0.5)) / multiplier
+	return numeric.Round(value, precision)
 }
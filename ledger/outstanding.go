@@ -0,0 +1,20 @@
+package ledger
+
+// OutstandingAmount returns the outstanding amount of a voucher in company
+// currency: the sum of the payment ledger entries against it, ignoring
+// delinked entries. Invoices are positive until paid; advances and credit
+// notes are negative until allocated or refunded.
+//
+// Maps to: get_outstanding_invoices() / the ple.amount aggregation in
+// accounts/utils.py
+func OutstandingAmount(entries []PaymentLedgerEntry, againstVoucherType, againstVoucherNo string) float64 {
+	var outstanding float64
+	for i := range entries {
+		e := &entries[i]
+		if e.Delinked || e.AgainstVoucherType != againstVoucherType || e.AgainstVoucherNo != againstVoucherNo {
+			continue
+		}
+		outstanding += e.Amount
+	}
+	return Flt(outstanding, 2)
+}
@@ -0,0 +1,19 @@
+package ledger
+
+import "testing"
+
+func TestOutstandingAmount(t *testing.T) {
+	entries := []PaymentLedgerEntry{
+		{VoucherType: "Sales Invoice", VoucherNo: "SINV-1", AgainstVoucherType: "Sales Invoice", AgainstVoucherNo: "SINV-1", Amount: 1180},
+		{VoucherType: "Payment Entry", VoucherNo: "PE-1", AgainstVoucherType: "Sales Invoice", AgainstVoucherNo: "SINV-1", Amount: -1000},
+		{VoucherType: "Payment Entry", VoucherNo: "PE-2", AgainstVoucherType: "Sales Invoice", AgainstVoucherNo: "SINV-1", Amount: -180, Delinked: true},
+		{VoucherType: "Payment Entry", VoucherNo: "PE-1", AgainstVoucherType: "Payment Entry", AgainstVoucherNo: "PE-1", Amount: -500},
+	}
+
+	if got := OutstandingAmount(entries, "Sales Invoice", "SINV-1"); got != 180 {
+		t.Errorf("invoice outstanding = %.2f, want 180", got)
+	}
+	if got := OutstandingAmount(entries, "Payment Entry", "PE-1"); got != -500 {
+		t.Errorf("advance outstanding = %.2f, want -500", got)
+	}
+}
@@ -0,0 +1,178 @@
+package ledger
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/jobs"
+)
+
+// WriteConcern is when the engine writes the payment ledger entries of a
+// voucher it posts.
+type WriteConcern int
+
+const (
+	// SyncPaymentLedger writes them with the GL entries, so outstanding
+	// amounts are current as soon as a voucher posts.
+	SyncPaymentLedger WriteConcern = iota
+
+	// AsyncPaymentLedger leaves them in the Outbox, with the voucher marked
+	// pending, for CatchUpPaymentLedger or the PaymentLedgerCatchUp job to
+	// write. High-throughput paths, such as bulk imports and POS sync,
+	// post faster; outstanding amounts lag until the catch-up runs.
+	AsyncPaymentLedger
+)
+
+// PendingPaymentLedger is a voucher whose payment ledger entries are not
+// written yet.
+type PendingPaymentLedger struct {
+	Voucher VoucherRef
+	Entries []PaymentLedgerEntry
+	Since   time.Time
+}
+
+// PaymentLedgerOutbox keeps the payment ledger entries of vouchers posted
+// with AsyncPaymentLedger until they are written. A voucher is pending,
+// the marker that its outstanding amounts are not final, for as long as
+// the outbox holds it.
+type PaymentLedgerOutbox interface {
+	// Defer keeps a voucher's entries.
+	Defer(p PendingPaymentLedger) error
+
+	// Pending returns up to limit vouchers, oldest first; all of them
+	// when limit is not positive.
+	Pending(limit int) ([]PendingPaymentLedger, error)
+
+	// Get returns a pending voucher, or nil.
+	Get(voucherType, voucherNo string) (*PendingPaymentLedger, error)
+
+	// Done removes a voucher once its entries are written.
+	Done(voucherType, voucherNo string) error
+}
+
+// deferPaymentLedger leaves the entries of a voucher in the outbox.
+func (e *Engine) deferPaymentLedger(entries []PaymentLedgerEntry) error {
+	if e.Outbox == nil {
+		return ErrNoOutbox
+	}
+	first := entries[0]
+	return e.Outbox.Defer(PendingPaymentLedger{
+		Voucher: VoucherRef{VoucherType: first.VoucherType, VoucherNo: first.VoucherNo, Company: first.Company},
+		Entries: entries,
+		Since:   time.Now(),
+	})
+}
+
+// PaymentLedgerPending reports whether a voucher's payment ledger entries
+// are still to be written.
+func (e *Engine) PaymentLedgerPending(voucherType, voucherNo string) (bool, error) {
+	if e.Outbox == nil {
+		return false, nil
+	}
+	p, err := e.Outbox.Get(voucherType, voucherNo)
+	return p != nil, err
+}
+
+// CatchUpPaymentLedger writes the entries of up to limit pending vouchers,
+// oldest first, and returns how many it wrote; all of them when limit is
+// not positive.
+func (e *Engine) CatchUpPaymentLedger(limit int) (int, error) {
+	if e.Outbox == nil {
+		return 0, nil
+	}
+	pending, err := e.Outbox.Pending(limit)
+	if err != nil {
+		return 0, err
+	}
+	for i, p := range pending {
+		if err := e.writePending(&p); err != nil {
+			return i, err
+		}
+	}
+	return len(pending), nil
+}
+
+// writePending writes the entries of a pending voucher and clears it. It
+// can be run again after a failure: entries already written are not
+// written twice. A voucher whose GL entries were never saved is dropped,
+// and the entries of one cancelled in the meantime are written delinked,
+// as the cancellation would have left them.
+func (e *Engine) writePending(p *PendingPaymentLedger) error {
+	ref := p.Voucher
+	written, err := e.PaymentStore.GetByVoucher(ref.VoucherType, ref.VoucherNo)
+	if err != nil {
+		return err
+	}
+	if len(written) == 0 {
+		gl, err := e.GLStore.GetByVoucher(ref.VoucherType, ref.VoucherNo)
+		if err != nil {
+			return err
+		}
+		if len(gl) > 0 {
+			entries := p.Entries
+			if gl[0].IsCancelled {
+				entries = make([]PaymentLedgerEntry, len(p.Entries))
+				for i, entry := range p.Entries {
+					entry.Delinked = true
+					entries[i] = entry
+				}
+			}
+			if err := e.PaymentStore.SaveBatch(entries); err != nil {
+				return err
+			}
+		}
+	}
+	return e.Outbox.Done(ref.VoucherType, ref.VoucherNo)
+}
+
+// PaymentLedgerCatchUp is the job type of the catch-up of payment ledger
+// entries written asynchronously. The application registers a
+// CatchUpTask under it with the jobs.Runner and submits one after bulk
+// postings, or on a timer; each pending voucher is a chunk.
+const PaymentLedgerCatchUp = "payment-ledger-catch-up"
+
+// CatchUpTask writes pending payment ledger entries as a job.
+type CatchUpTask struct {
+	Engine *Engine
+}
+
+// SubmitCatchUp queues a catch-up of every voucher pending when it runs.
+func SubmitCatchUp(ctx context.Context, runner *jobs.Runner) (*jobs.Job, error) {
+	return runner.Submit(ctx, PaymentLedgerCatchUp, struct{}{})
+}
+
+// Plan implements jobs.Task with a chunk per pending voucher.
+func (t *CatchUpTask) Plan(context.Context, json.RawMessage) ([]string, error) {
+	if t.Engine.Outbox == nil {
+		return nil, ErrNoOutbox
+	}
+	pending, err := t.Engine.Outbox.Pending(0)
+	if err != nil {
+		return nil, err
+	}
+	chunks := make([]string, len(pending))
+	for i, p := range pending {
+		chunks[i] = p.Voucher.VoucherType + "|" + p.Voucher.VoucherNo
+	}
+	return chunks, nil
+}
+
+// Run implements jobs.Task. A voucher caught up since the job was planned
+// is skipped.
+func (t *CatchUpTask) Run(ctx context.Context, _ json.RawMessage, chunk string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	voucherType, voucherNo, ok := strings.Cut(chunk, "|")
+	if !ok {
+		return fmt.Errorf("%w: chunk %q", ErrVoucherNotFound, chunk)
+	}
+	p, err := t.Engine.Outbox.Get(voucherType, voucherNo)
+	if err != nil || p == nil {
+		return err
+	}
+	return t.Engine.writePending(p)
+}
@@ -0,0 +1,85 @@
+package ledger_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/jobs"
+	"github.com/senguttuvang/erpnext-go/ledger"
+	"github.com/senguttuvang/erpnext-go/ledger/memstore"
+)
+
+func TestAsyncPaymentLedger(t *testing.T) {
+	payments := memstore.NewPaymentStore()
+	outbox := memstore.NewOutbox()
+	engine := &ledger.Engine{
+		Accounts: memstore.NewAccounts(
+			ledger.Account{Name: "Debtors - A", RootType: "Asset"},
+			ledger.Account{Name: "Sales - A", RootType: "Income"},
+		),
+		GLStore: memstore.NewGLStore(), PaymentStore: payments,
+		PaymentLedger: ledger.AsyncPaymentLedger,
+	}
+	invoice := func(no string) []ledger.GLEntry {
+		date := time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)
+		return []ledger.GLEntry{
+			{PostingDate: date, Company: "A", VoucherType: "Sales Invoice", VoucherNo: no, Account: "Debtors - A",
+				PartyType: "Customer", Party: "Globex", Debit: 100, DebitInAccountCurrency: 100},
+			{PostingDate: date, Company: "A", VoucherType: "Sales Invoice", VoucherNo: no, Account: "Sales - A", CostCenter: "Main - A",
+				Credit: 100, CreditInAccountCurrency: 100},
+		}
+	}
+	if err := engine.MakeGLEntries(invoice("SINV-1"), ledger.DefaultPostingOptions()); !errors.Is(err, ledger.ErrNoOutbox) {
+		t.Fatalf("without outbox: err = %v", err)
+	}
+
+	engine.Outbox = outbox
+	for _, no := range []string{"SINV-1", "SINV-2", "SINV-3"} {
+		if err := engine.MakeGLEntries(invoice(no), ledger.DefaultPostingOptions()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if pending, _ := engine.PaymentLedgerPending("Sales Invoice", "SINV-1"); !pending || len(payments.All()) != 0 {
+		t.Fatalf("pending = %v with %d entries written", pending, len(payments.All()))
+	}
+
+	// Cancelled before its entries were written
+	opts := ledger.DefaultPostingOptions()
+	opts.Cancel = true
+	if err := engine.MakeGLEntries(invoice("SINV-3"), opts); err != nil {
+		t.Fatal(err)
+	}
+
+	if n, err := engine.CatchUpPaymentLedger(1); err != nil || n != 1 {
+		t.Fatalf("CatchUpPaymentLedger(1) = %d, %v", n, err)
+	}
+	if pending, _ := engine.PaymentLedgerPending("Sales Invoice", "SINV-1"); pending || len(payments.All()) != 1 {
+		t.Errorf("SINV-1 pending = %v with %d entries written", pending, len(payments.All()))
+	}
+
+	runner := &jobs.Runner{
+		Queue: jobs.NewMemoryQueue(10), Store: &jobs.MemoryStore{},
+		Tasks: map[string]jobs.Task{ledger.PaymentLedgerCatchUp: &ledger.CatchUpTask{Engine: engine}},
+	}
+	job, err := ledger.SubmitCatchUp(context.Background(), runner)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := runner.Run(context.Background(), job.ID); err != nil {
+		t.Fatal(err)
+	}
+	if rest, _ := outbox.Pending(0); len(rest) != 0 {
+		t.Errorf("still pending: %+v", rest)
+	}
+	all := payments.All()
+	if len(all) != 3 || all[1].VoucherNo != "SINV-2" || all[1].Delinked || !all[2].Delinked {
+		t.Errorf("payment ledger = %+v", all)
+	}
+
+	// A second run writes nothing twice
+	if n, err := engine.CatchUpPaymentLedger(0); err != nil || n != 0 || len(payments.All()) != 3 {
+		t.Errorf("second catch-up = %d, %v", n, err)
+	}
+}
@@ -0,0 +1,128 @@
+package ledger
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// Check names a validation whose severity a ValidationPolicy can change.
+type Check string
+
+const (
+	CheckBudget          Check = "budget"           // Budget exceeded (BudgetExceededError)
+	CheckCostCenter      Check = "cost_center"      // Profit and loss entry without a cost center
+	CheckDisabledAccount Check = "disabled_account" // Posting to a disabled account
+
+	// CheckPostingRun is a posting that could not be recorded in the
+	// engine's RunStore. The posting stands, so it is always a warning.
+	CheckPostingRun Check = "posting_run"
+
+	// CheckEvents is an event about a posting that could not be
+	// published. The posting stands, so it is always a warning.
+	CheckEvents Check = "events"
+)
+
+// checkErrors are the errors each check may downgrade. Other errors from
+// the same step, such as a failing lookup, always stop the posting.
+var checkErrors = map[Check]error{
+	CheckBudget:          ErrBudgetExceeded,
+	CheckCostCenter:      ErrMissingCostCenter,
+	CheckDisabledAccount: ErrAccountDisabled,
+}
+
+// Severity is what a failed check does to the posting.
+type Severity string
+
+const (
+	SeverityError  Severity = "Stop"   // Reject the posting (the default)
+	SeverityWarn   Severity = "Warn"   // Post, and return the failure as a warning
+	SeverityIgnore Severity = "Ignore" // Post silently
+)
+
+// ValidationPolicy sets the severity of checks. Checks it does not list
+// are errors, so the zero policy rejects every failure.
+//
+// Maps to: frappe.throw() versus frappe.msgprint() in the validations, and
+// the Stop/Warn/Ignore actions of Budget
+type ValidationPolicy map[Check]Severity
+
+// Severity returns the severity of a check.
+func (p ValidationPolicy) Severity(c Check) Severity {
+	if s, ok := p[c]; ok {
+		return s
+	}
+	return SeverityError
+}
+
+// Warning is a failed check that the policy downgraded, returned with a
+// successful posting.
+type Warning struct {
+	Check Check
+	Err   error
+}
+
+func (w Warning) String() string {
+	return fmt.Sprintf("%s: %v", w.Check, w.Err)
+}
+
+// apply passes err through the policy: it returns err for an error,
+// records a warning or drops it.
+func (p ValidationPolicy) apply(c Check, err error, warnings *[]Warning) error {
+	if err == nil || !errors.Is(err, checkErrors[c]) {
+		return err
+	}
+	switch p.Severity(c) {
+	case SeverityWarn:
+		*warnings = append(*warnings, Warning{Check: c, Err: err})
+		return nil
+	case SeverityIgnore:
+		return nil
+	}
+	return err
+}
+
+// MissingCostCenterError lists profit and loss entries without a cost
+// center.
+type MissingCostCenterError struct {
+	VoucherType string
+	VoucherNo   string
+	Accounts    []string
+}
+
+func (e *MissingCostCenterError) Error() string {
+	return fmt.Sprintf("%s %s: cost center is required for profit and loss accounts %v", e.VoucherType, e.VoucherNo, e.Accounts)
+}
+
+func (e *MissingCostCenterError) Unwrap() error {
+	return ErrMissingCostCenter
+}
+
+// validateCostCenters checks that income and expense entries carry a cost
+// center. Period closing vouchers are exempt, and accounts without a root
+// type are not checked.
+//
+// Maps to: GLEntry.pl_must_have_cost_center() in gl_entry.py
+func (e *Engine) validateCostCenters(glMap []GLEntry) error {
+	if e.Accounts == nil || len(glMap) == 0 || glMap[0].VoucherType == PeriodClosingVoucher {
+		return nil
+	}
+	var missing []string
+	for i := range glMap {
+		entry := &glMap[i]
+		if entry.CostCenter != "" || entry.Account == "" {
+			continue
+		}
+		acc, err := e.Accounts.GetAccount(entry.Account)
+		if err != nil {
+			return err
+		}
+		if (acc.RootType == "Income" || acc.RootType == "Expense") && !slices.Contains(missing, entry.Account) {
+			missing = append(missing, entry.Account)
+		}
+	}
+	if len(missing) > 0 {
+		return &MissingCostCenterError{VoucherType: glMap[0].VoucherType, VoucherNo: glMap[0].VoucherNo, Accounts: missing}
+	}
+	return nil
+}
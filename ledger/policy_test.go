@@ -0,0 +1,75 @@
+package ledger
+
+import (
+	"errors"
+	"testing"
+)
+
+type stubBudget struct{ err error }
+
+func (b stubBudget) Validate([]GLEntry) error { return b.err }
+
+func TestValidationPolicy(t *testing.T) {
+	accounts := newMockAccountLookup()
+	accounts.accounts["Sales - ABC"].RootType = "Income"
+	budget := stubBudget{&BudgetExceededError{Account: "Sales - ABC", CostCenter: "Main - ABC", Budget: 50, Actual: 100, Variance: 50}}
+	entries := func() []GLEntry {
+		return []GLEntry{makeTestGLEntry("Debtors - ABC", 100, 0), makeTestGLEntry("Sales - ABC", 0, 100)}
+	}
+
+	// By default every check stops the posting
+	engine := &Engine{Accounts: accounts, Company: &mockCompanySettings{}, GLStore: &mockGLStore{}}
+	if _, err := engine.Post(entries(), DefaultPostingOptions()); !errors.Is(err, ErrMissingCostCenter) {
+		t.Errorf("missing cost center: err = %v", err)
+	}
+	engine.Budget = budget
+	if _, err := engine.Post(entries(), DefaultPostingOptions()); !errors.Is(err, ErrBudgetExceeded) {
+		t.Errorf("budget: err = %v", err)
+	}
+
+	// Downgraded checks post and report warnings in the order they ran
+	glStore := &mockGLStore{}
+	engine.GLStore = glStore
+	engine.Policy = ValidationPolicy{CheckBudget: SeverityWarn, CheckCostCenter: SeverityWarn}
+	warnings, err := engine.Post(entries(), DefaultPostingOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(warnings) != 2 || warnings[0].Check != CheckBudget || warnings[1].Check != CheckCostCenter || len(glStore.entries) != 2 {
+		t.Fatalf("warnings = %v, %d entries saved", warnings, len(glStore.entries))
+	}
+	var ccErr *MissingCostCenterError
+	if !errors.As(warnings[1].Err, &ccErr) || len(ccErr.Accounts) != 1 || ccErr.Accounts[0] != "Sales - ABC" {
+		t.Errorf("cost center warning = %v", warnings[1].Err)
+	}
+
+	// Ignored checks post silently; MakeGLEntries drops warnings
+	engine.Policy[CheckBudget] = SeverityIgnore
+	if warnings, err := engine.Post(entries(), DefaultPostingOptions()); err != nil || len(warnings) != 1 {
+		t.Errorf("ignore: warnings = %v, err = %v", warnings, err)
+	}
+	if err := engine.MakeGLEntries(entries(), DefaultPostingOptions()); err != nil {
+		t.Errorf("MakeGLEntries() error = %v", err)
+	}
+
+	// Other errors from a downgraded step still stop the posting
+	engine.Budget = stubBudget{errors.New("budget lookup failed")}
+	if _, err := engine.Post(entries(), DefaultPostingOptions()); err == nil {
+		t.Error("budget lookup failure posted")
+	}
+
+	// Entries with a cost center and period closing vouchers pass
+	engine.Budget, engine.Policy = nil, nil
+	ok := entries()
+	ok[1].CostCenter = "Main - ABC"
+	if warnings, err := engine.Post(ok, DefaultPostingOptions()); err != nil || len(warnings) != 0 {
+		t.Errorf("with cost center: warnings = %v, err = %v", warnings, err)
+	}
+	pcv := entries()
+	for i := range pcv {
+		pcv[i].VoucherType = PeriodClosingVoucher
+	}
+	if _, err := engine.Post(pcv, DefaultPostingOptions()); err != nil {
+		t.Errorf("period closing voucher: %v", err)
+	}
+}
@@ -42,6 +42,11 @@ type Account struct {
 	FreezeAccount   bool
 	BalanceMustBe   string // "Debit", "Credit", or ""
 	RootType        string // "Asset", "Liability", "Equity", "Income", "Expense"
+	ParentAccount   string // Group account above this one; empty for a root
+
+	// Posting rules, enforced by the engine on every posting
+	AllowedVoucherTypes []string // Only these voucher types may post; any when empty
+	PartyType           string   // Entries must name a party of this type, e.g. "Customer"
 }
 
 // CompanySettings abstracts company-level accounting configuration.
@@ -120,6 +125,37 @@ type PaymentLedgerStore interface {
 	Delink(voucherType, voucherNo string) error
 }
 
+// AgainstVoucherRelinker moves against-voucher links from one voucher to
+// another. GL and payment ledger stores implement it so that payments
+// allocated to a cancelled document follow its amendment.
+type AgainstVoucherRelinker interface {
+	// RelinkAgainstVoucher points every live entry of another voucher that
+	// is booked against (voucherType, from) at (voucherType, to) instead.
+	// The entries of voucher "from" itself are left alone.
+	RelinkAgainstVoucher(voucherType, from, to string) error
+}
+
+// PartyRelinker moves the entries of one party to another. GL and payment
+// ledger stores implement it so that merging duplicate customers or
+// suppliers keeps their history under the surviving record.
+//
+// Maps to: the Link field updates of frappe.rename_doc(merge=True)
+type PartyRelinker interface {
+	// RelinkParty sets the party of every entry booked to (partyType, from)
+	// to (partyType, to) and returns the number of entries changed.
+	RelinkParty(partyType, from, to string) (int, error)
+}
+
+// PartyPseudonymizer replaces a party's name with a pseudonym wherever a
+// store keeps it, for the erasure of a party's personal data. GL stores
+// rewrite GLEntry.PseudonymizeParty's fields; payment ledger stores the
+// party of their entries. Amounts, accounts and voucher links are kept.
+type PartyPseudonymizer interface {
+	// PseudonymizeParty replaces (partyType, name) with token and returns
+	// the number of entries changed.
+	PseudonymizeParty(partyType, name, token string) (int, error)
+}
+
 // BudgetValidator validates GL entries against budgets.
 // Maps to: BudgetValidation class in controllers/budget_controller.py
 type BudgetValidator interface {
@@ -128,6 +164,32 @@ type BudgetValidator interface {
 	Validate(entries []GLEntry) error
 }
 
+// PostingGate is consulted before a voucher is posted or cancelled, so
+// that approval workflows can hold back vouchers that are not yet approved.
+//
+// Maps to: validate_workflow() in frappe/model/workflow.py, which runs
+// before a workflow-controlled document is submitted or cancelled
+type PostingGate interface {
+	// AllowPosting returns an error if the voucher may not be posted
+	// (or, with cancel set, reversed) in its current state.
+	AllowPosting(voucherType, voucherNo string, cancel bool) error
+}
+
+// Authorizer decides what a user may do, so the engine and reports can
+// enforce access control instead of trusting the caller.
+//
+// Maps to: frappe.has_permission(), User Permissions on Company and Cost
+// Center, and the frozen_accounts_modifier role in Accounts Settings
+type Authorizer interface {
+	// Authorize returns an error wrapping ErrNotPermitted unless the user
+	// may take the action on vouchers of a type in a company.
+	Authorize(user string, action Action, voucherType, company string) error
+
+	// ReportScope returns the part of a company's books the user may see
+	// in reports, or an error wrapping ErrNotPermitted if none.
+	ReportScope(user, company string) (ReportScope, error)
+}
+
 // AccountingDimensionProvider retrieves accounting dimensions for offsetting.
 // Maps to: get_accounting_dimensions_for_offsetting_entry() in general_ledger.py
 type AccountingDimensionProvider interface {
@@ -154,6 +216,19 @@ type Engine struct {
 	PaymentStore      PaymentLedgerStore
 	Budget            BudgetValidator
 	Dimensions        AccountingDimensionProvider
+	Calendar          AccountingCalendarLookup // Optional; monthly periods when nil
+	Gate              PostingGate              // Optional; every voucher may post when nil
+	Auth              Authorizer               // Optional; the caller is trusted when nil
+	Events            EventPublisher           // Optional; receives gl.posted and gl.cancelled
+	References        *ReferenceManager        // Optional; cancellations unlink dependent records
+	Policy            ValidationPolicy         // Optional; every check is an error when nil
+	MergeFields       []string                 // Custom fields that must also agree for entries to merge
+	Runs              RunStore                 // Optional; posting runs are not recorded when nil
+	Amendments        AmendmentPolicy          // Optional; Amend reverses and reposts when empty
+	Cancellations     *CancellationPolicy      // Optional; every voucher is cancelled by reversal when nil
+	Balancing         []BalancingDimension     // Dimensions that must balance within every voucher
+	PaymentLedger     WriteConcern             // SyncPaymentLedger when zero
+	Outbox            PaymentLedgerOutbox      // Required by AsyncPaymentLedger
 }
 
 // NewEngine creates a new ledger engine with all dependencies.
@@ -0,0 +1,45 @@
+package ledger
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// validatePostingRules checks every entry against the posting rules of its
+// account: the voucher types it accepts and the party type it requires.
+// The first entry breaking a rule fails the posting with a ValidationError
+// wrapping ErrVoucherTypeNotAllowed or ErrPartyRequired.
+//
+// Maps to: GLEntry.validate_party() in gl_entry.py, which requires a party
+// on receivable and payable accounts; the rules make that, and which
+// vouchers may post to an account, explicit on the account
+func (e *Engine) validatePostingRules(glMap []GLEntry) error {
+	if e.Accounts == nil {
+		return nil
+	}
+	accounts := make(map[string]*Account)
+	for i := range glMap {
+		entry := &glMap[i]
+		if entry.Account == "" {
+			continue
+		}
+		acc, ok := accounts[entry.Account]
+		if !ok {
+			var err error
+			if acc, err = e.Accounts.GetAccount(entry.Account); err != nil {
+				return err
+			}
+			accounts[entry.Account] = acc
+		}
+		if len(acc.AllowedVoucherTypes) > 0 && !slices.Contains(acc.AllowedVoucherTypes, entry.VoucherType) {
+			return NewValidationError(ErrVoucherTypeNotAllowed, entry.Account, fmt.Sprintf(
+				"%s %s cannot post here; only %s may", entry.VoucherType, entry.VoucherNo, strings.Join(acc.AllowedVoucherTypes, ", ")))
+		}
+		if acc.PartyType != "" && (entry.PartyType != acc.PartyType || entry.Party == "") {
+			return NewValidationError(ErrPartyRequired, entry.Account, fmt.Sprintf(
+				"%s %s must name a %s", entry.VoucherType, entry.VoucherNo, acc.PartyType))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package ledger
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPostingRules(t *testing.T) {
+	accounts := newMockAccountLookup()
+	accounts.accounts["Debtors - ABC"].PartyType = "Customer"
+	accounts.accounts["Cash - ABC"].AllowedVoucherTypes = []string{"Payment Entry", "Journal Entry"}
+	engine := &Engine{Accounts: accounts, Company: &mockCompanySettings{}, GLStore: &mockGLStore{}}
+
+	sale := func(partyType, party string) []GLEntry {
+		debtors := makeTestGLEntry("Debtors - ABC", 100, 0)
+		debtors.PartyType, debtors.Party = partyType, party
+		return []GLEntry{debtors, makeTestGLEntry("Sales - ABC", 0, 100)}
+	}
+	if err := engine.MakeGLEntries(sale("Customer", "Globex"), DefaultPostingOptions()); err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct{ partyType, party string }{{"", ""}, {"Supplier", "Globex"}, {"Customer", ""}} {
+		err := engine.MakeGLEntries(sale(tt.partyType, tt.party), DefaultPostingOptions())
+		var v *ValidationError
+		if !errors.Is(err, ErrPartyRequired) || !errors.As(err, &v) || v.Account != "Debtors - ABC" {
+			t.Errorf("party %q %q: err = %v", tt.partyType, tt.party, err)
+		}
+	}
+
+	cashSale := []GLEntry{makeTestGLEntry("Cash - ABC", 100, 0), makeTestGLEntry("Sales - ABC", 0, 100)}
+	err := engine.MakeGLEntries(cashSale, DefaultPostingOptions())
+	if !errors.Is(err, ErrVoucherTypeNotAllowed) || err.Error() != "voucher type may not post to account: Cash - ABC - Sales Invoice SINV-001 cannot post here; only Payment Entry, Journal Entry may" {
+		t.Errorf("sales invoice to cash: err = %v", err)
+	}
+	for i := range cashSale {
+		cashSale[i].VoucherType, cashSale[i].VoucherNo = "Journal Entry", "JV-001"
+	}
+	if err := engine.MakeGLEntries(cashSale, DefaultPostingOptions()); err != nil {
+		t.Errorf("journal entry to cash: err = %v", err)
+	}
+}
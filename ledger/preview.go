@@ -0,0 +1,50 @@
+package ledger
+
+// PreviewGLEntries returns the GL entries a posting would save, without
+// saving anything: the map after dimension offsetting, merging, toggling
+// and the round-off entry, or for a cancellation the reversing entries.
+// It runs the same validations as Post, so a preview that succeeds shows
+// what submitting will book, and it returns the warnings Post would.
+//
+// Authorization and workflow approval are not checked, since a preview
+// changes nothing; neither are payment ledger entries built.
+//
+// Maps to: show_accounting_ledger_preview() in
+// controllers/stock_controller.py and the "Accounting Ledger" preview
+// button on submittable vouchers
+func (e *Engine) PreviewGLEntries(glMap []GLEntry, opts PostingOptions) ([]GLEntry, []Warning, error) {
+	if len(glMap) == 0 {
+		return nil, nil, nil
+	}
+	glMap = NormalizeDates(glMap)
+
+	var warnings []Warning
+	if e.Budget != nil && glMap[0].VoucherType != PeriodClosingVoucher {
+		if err := e.Policy.apply(CheckBudget, e.Budget.Validate(glMap), &warnings); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if opts.Cancel {
+		if e.GLStore == nil {
+			return nil, warnings, nil
+		}
+		existing, err := e.GLStore.GetByVoucher(glMap[0].VoucherType, glMap[0].VoucherNo)
+		if err != nil {
+			return nil, nil, err
+		}
+		return reverseEntries(existing), warnings, nil
+	}
+
+	processedMap, err := e.prepareGLMap(glMap, opts, &warnings)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := e.processDebitCreditDifference(&processedMap); err != nil {
+		return nil, nil, err
+	}
+	if err := e.checkFreezingDate(processedMap, opts); err != nil {
+		return nil, nil, err
+	}
+	return processedMap, warnings, nil
+}
@@ -0,0 +1,50 @@
+package ledger
+
+import "testing"
+
+func TestPreviewGLEntries(t *testing.T) {
+	glStore := &mockGLStore{}
+	engine := &Engine{Accounts: newMockAccountLookup(), Company: &mockCompanySettings{}, GLStore: glStore}
+
+	// Similar entries merge and the rounding difference gets a round-off
+	// entry, but nothing is saved
+	entries := []GLEntry{
+		makeTestGLEntry("Debtors - ABC", 60, 0),
+		makeTestGLEntry("Debtors - ABC", 40.01, 0),
+		makeTestGLEntry("Sales - ABC", 0, 100),
+	}
+	preview, warnings, err := engine.PreviewGLEntries(entries, DefaultPostingOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(preview) != 3 || Flt(preview[0].Debit, 2) != 100.01 || preview[2].Account != "Round Off - ABC" || preview[2].Credit != 0.01 {
+		t.Fatalf("preview = %+v", preview)
+	}
+	if len(warnings) != 0 || len(glStore.entries) != 0 || entries[1].Debit != 40.01 {
+		t.Errorf("preview had side effects: %d saved, warnings %v", len(glStore.entries), warnings)
+	}
+
+	// The preview matches what posting saves
+	if err := engine.MakeGLEntries(entries, DefaultPostingOptions()); err != nil {
+		t.Fatal(err)
+	}
+	if len(glStore.entries) != len(preview) {
+		t.Errorf("posted %d entries, previewed %d", len(glStore.entries), len(preview))
+	}
+
+	// A cancellation previews the reversal
+	opts := DefaultPostingOptions()
+	opts.Cancel = true
+	reversal, _, err := engine.PreviewGLEntries(entries[:1], opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reversal) != 3 || Flt(reversal[0].Credit, 2) != 100.01 || glStore.entries[0].IsCancelled {
+		t.Errorf("reversal = %+v", reversal)
+	}
+
+	// Validation failures are reported as they would be on posting
+	if _, _, err := engine.PreviewGLEntries([]GLEntry{makeTestGLEntry("Disabled Account - ABC", 10, 0), makeTestGLEntry("Sales - ABC", 0, 10)}, DefaultPostingOptions()); err == nil {
+		t.Error("disabled account previewed")
+	}
+}
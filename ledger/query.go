@@ -0,0 +1,185 @@
+package ledger
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/daterange"
+)
+
+// GLFilter selects GL entries. Zero fields match everything.
+//
+// Maps to: the filters of erpnext/accounts/report/general_ledger
+type GLFilter struct {
+	Company     string
+	Account     string // A group account matches every account below it
+	From, To    time.Time
+	PartyType   string
+	Party       string
+	VoucherType string
+	VoucherNo   string
+	CostCenter  string
+
+	IncludeCancelled bool // Otherwise cancelled entries and their reversals are skipped
+	ReportFlags           // Opening and period closing entries are skipped unless included
+}
+
+// CancelledEntries reports, for each of entries, whether it belongs to a
+// cancellation: flagged cancelled, or the live reversal of a cancelled
+// entry, as stores written before the engine flagged its reversals hold
+// them. Reports skip both, so a cancelled voucher shows nothing, and a
+// voucher amended by repost under the same number only its new entries.
+//
+// A reversal is told by mirroring a cancelled entry of its voucher that
+// no cancelled entry mirrors already; the earliest such live entry is
+// taken.
+//
+// Maps to: the is_cancelled = 0 condition of GL reports, which ERPNext
+// can apply per entry since make_reverse_gl_entries flags both sides
+func CancelledEntries(entries []GLEntry) []bool {
+	cancelled := make([]bool, len(entries))
+	unpaired := map[reversalLine]int{}
+	for i := range entries {
+		if !entries[i].IsCancelled {
+			continue
+		}
+		cancelled[i] = true
+		l := reversalLineOf(&entries[i])
+		if mirror := l.mirror(); unpaired[mirror] > 0 {
+			unpaired[mirror]--
+		} else {
+			unpaired[l]++
+		}
+	}
+	if len(unpaired) == 0 {
+		return cancelled
+	}
+	for i := range entries {
+		if entries[i].IsCancelled {
+			continue
+		}
+		if mirror := reversalLineOf(&entries[i]).mirror(); unpaired[mirror] > 0 {
+			unpaired[mirror]--
+			cancelled[i] = true
+		}
+	}
+	return cancelled
+}
+
+// reversalLine is what an entry and its reversal share, with the amounts
+// the reversal swaps.
+type reversalLine struct {
+	voucherType, company string
+	date                 time.Time
+	mergeKey
+	debit, credit float64
+}
+
+func reversalLineOf(e *GLEntry) reversalLine {
+	return reversalLine{e.VoucherType, e.Company, e.PostingDate, getMergeKey(*e, nil), Flt(e.Debit, 2), Flt(e.Credit, 2)}
+}
+
+// mirror returns the line of the reversal.
+func (l reversalLine) mirror() reversalLine {
+	l.debit, l.credit = l.credit, l.debit
+	return l
+}
+
+// QueryGL returns the entries matching the filter, in their original
+// order. Filtering on a group account needs the account tree; with a nil
+// tree only entries posted to Account itself match.
+//
+// Maps to: get_gl_entries() / get_conditions() in general_ledger.py,
+// where an account filter is widened with lft/rgt to its descendants
+func QueryGL(entries []GLEntry, f GLFilter, tree *AccountTree) ([]GLEntry, error) {
+	var accounts map[string]bool
+	if f.Account != "" {
+		accounts = map[string]bool{f.Account: true}
+		if tree != nil {
+			if !tree.Has(f.Account) {
+				return nil, fmt.Errorf("%w: %s", ErrAccountNotInTree, f.Account)
+			}
+			for _, name := range tree.Descendants(f.Account) {
+				accounts[name] = true
+			}
+		}
+	}
+	period := daterange.Inclusive(f.From, f.To)
+	var cancelled []bool
+	if !f.IncludeCancelled {
+		cancelled = CancelledEntries(entries)
+	}
+
+	var out []GLEntry
+	for i, e := range entries {
+		switch {
+		case cancelled != nil && cancelled[i],
+			!f.Counts(&e),
+			f.Company != "" && e.Company != f.Company,
+			accounts != nil && !accounts[e.Account],
+			!period.Contains(e.PostingDate),
+			f.PartyType != "" && e.PartyType != f.PartyType,
+			f.Party != "" && e.Party != f.Party,
+			f.VoucherType != "" && e.VoucherType != f.VoucherType,
+			f.VoucherNo != "" && e.VoucherNo != f.VoucherNo,
+			f.CostCenter != "" && e.CostCenter != f.CostCenter:
+			continue
+		}
+		out = append(out, e)
+	}
+	return out, nil
+}
+
+// GLGroup totals the entries that roll up to one child account.
+type GLGroup struct {
+	Account string // Immediate child of the queried account, or the account itself
+	Debit   float64
+	Credit  float64
+	Balance float64 // Debit - Credit
+	Entries []GLEntry
+}
+
+// GroupByChild groups entries under the immediate children of parent,
+// in the tree's child order. Entries posted to parent itself form a group
+// of their own, listed first; entries outside parent are dropped. Groups
+// without entries are omitted. With a nil tree, as in QueryGL, only the
+// entries posted to parent itself are grouped.
+//
+// Maps to: group_by "Group by Account" in general_ledger.py, applied one
+// level below the filtered account
+func GroupByChild(entries []GLEntry, parent string, tree *AccountTree) []GLGroup {
+	index := make(map[string]int)
+	order := []string{parent}
+	if tree != nil {
+		order = append(order, tree.Children(parent)...)
+	}
+	groups := make([]GLGroup, len(order))
+	for i, name := range order {
+		groups[i].Account = name
+		index[name] = i
+	}
+	for _, e := range entries {
+		key := parent
+		if e.Account != parent {
+			if tree == nil {
+				continue
+			}
+			key = tree.ChildOf(e.Account, parent)
+			if key == "" {
+				continue
+			}
+		}
+		g := &groups[index[key]]
+		g.Debit = Flt(g.Debit+e.Debit, 2)
+		g.Credit = Flt(g.Credit+e.Credit, 2)
+		g.Balance = Flt(g.Debit-g.Credit, 2)
+		g.Entries = append(g.Entries, e)
+	}
+	out := groups[:0]
+	for _, g := range groups {
+		if len(g.Entries) > 0 {
+			out = append(out, g)
+		}
+	}
+	return out
+}
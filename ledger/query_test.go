@@ -0,0 +1,162 @@
+package ledger
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+func chart(t *testing.T) *AccountTree {
+	t.Helper()
+	tree, err := NewAccountTree(
+		Account{Name: "Expenses - AC", IsGroup: true},
+		Account{Name: "Indirect Expenses - AC", ParentAccount: "Expenses - AC", IsGroup: true},
+		Account{Name: "Office Expenses - AC", ParentAccount: "Indirect Expenses - AC", IsGroup: true},
+		Account{Name: "Rent - AC", ParentAccount: "Office Expenses - AC"},
+		Account{Name: "Stationery - AC", ParentAccount: "Office Expenses - AC"},
+		Account{Name: "Travel - AC", ParentAccount: "Indirect Expenses - AC"},
+		Account{Name: "Cost of Goods Sold - AC", ParentAccount: "Expenses - AC"},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tree
+}
+
+func TestAccountTree(t *testing.T) {
+	tree := chart(t)
+	got := tree.Descendants("Indirect Expenses - AC")
+	if len(got) != 5 || got[0] != "Indirect Expenses - AC" {
+		t.Errorf("Descendants() = %v", got)
+	}
+	if c := tree.ChildOf("Rent - AC", "Indirect Expenses - AC"); c != "Office Expenses - AC" {
+		t.Errorf("ChildOf() = %q", c)
+	}
+	if tree.IsDescendant("Cost of Goods Sold - AC", "Indirect Expenses - AC") {
+		t.Error("COGS is not an indirect expense")
+	}
+
+	_, err := NewAccountTree(Account{Name: "A", ParentAccount: "B"}, Account{Name: "B", ParentAccount: "A"})
+	if !errors.Is(err, ErrAccountTreeCycle) {
+		t.Errorf("cycle error = %v", err)
+	}
+	_, err = NewAccountTree(Account{Name: "A", ParentAccount: "Missing"})
+	if !errors.Is(err, ErrAccountNotInTree) {
+		t.Errorf("missing parent error = %v", err)
+	}
+}
+
+func TestQueryGLByHierarchy(t *testing.T) {
+	tree := chart(t)
+	day := func(d int) time.Time { return time.Date(2024, 4, d, 0, 0, 0, 0, time.UTC) }
+	entries := []GLEntry{
+		{Account: "Rent - AC", Debit: 1000, PostingDate: day(1), Company: "AC"},
+		{Account: "Stationery - AC", Debit: 50, PostingDate: day(2), Company: "AC"},
+		{Account: "Travel - AC", Debit: 300, PostingDate: day(3), Company: "AC"},
+		{Account: "Travel - AC", Credit: 20, PostingDate: day(4), Company: "AC"},
+		{Account: "Cost of Goods Sold - AC", Debit: 700, PostingDate: day(2), Company: "AC"},
+		{Account: "Rent - AC", Debit: 999, PostingDate: day(2), Company: "AC", IsCancelled: true},
+		{Account: "Rent - AC", Debit: 5, PostingDate: day(30), Company: "AC"},
+	}
+
+	got, err := QueryGL(entries, GLFilter{Account: "Indirect Expenses - AC", To: day(15)}, tree)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 4 {
+		t.Fatalf("QueryGL() = %d entries, want 4", len(got))
+	}
+
+	groups := GroupByChild(got, "Indirect Expenses - AC", tree)
+	if len(groups) != 2 || groups[0].Account != "Office Expenses - AC" || groups[0].Balance != 1050 ||
+		groups[1].Account != "Travel - AC" || groups[1].Balance != 280 || len(groups[1].Entries) != 2 {
+		t.Errorf("GroupByChild() = %+v", groups)
+	}
+
+	if _, err := QueryGL(entries, GLFilter{Account: "Nope"}, tree); !errors.Is(err, ErrAccountNotInTree) {
+		t.Errorf("unknown account error = %v", err)
+	}
+	// Without a tree, only the account itself matches
+	if got, _ := QueryGL(entries, GLFilter{Account: "Indirect Expenses - AC"}, nil); len(got) != 0 {
+		t.Errorf("QueryGL without tree = %d entries", len(got))
+	}
+	parentOnly := []GLEntry{{Account: "Indirect Expenses - AC", Debit: 10}, entries[0]}
+	if groups := GroupByChild(parentOnly, "Indirect Expenses - AC", nil); len(groups) != 1 || groups[0].Balance != 10 {
+		t.Errorf("GroupByChild without tree = %+v", groups)
+	}
+}
+
+func TestQueryGLSkipsCancelledVouchers(t *testing.T) {
+	store := &mockGLStore{}
+	engine := &Engine{GLStore: store}
+	glMap := func() []GLEntry {
+		return []GLEntry{makeTestGLEntry("Debtors - ABC", 1000, 0), makeTestGLEntry("Sales - ABC", 0, 1000)}
+	}
+	if err := engine.MakeGLEntries(glMap(), DefaultPostingOptions()); err != nil {
+		t.Fatal(err)
+	}
+	cancel := DefaultPostingOptions()
+	cancel.Cancel = true
+	if err := engine.MakeGLEntries(glMap(), cancel); err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range store.entries {
+		if !e.IsCancelled {
+			t.Errorf("live entry after cancelling: %+v", e)
+		}
+	}
+	if got, _ := QueryGL(store.entries, GLFilter{Account: "Sales - ABC"}, nil); len(got) != 0 {
+		t.Errorf("QueryGL() = %+v, want nothing for a cancelled voucher", got)
+	}
+	if got, _ := QueryGL(store.entries, GLFilter{Account: "Sales - ABC", IncludeCancelled: true}, nil); len(got) != 2 {
+		t.Errorf("QueryGL(IncludeCancelled) = %d entries, want 2", len(got))
+	}
+
+	// A store written before reversals were flagged holds them live
+	legacy := slices.Clone(store.entries)
+	legacy[2].IsCancelled, legacy[3].IsCancelled = false, false
+	if got, _ := QueryGL(legacy, GLFilter{Account: "Sales - ABC"}, nil); len(got) != 0 {
+		t.Errorf("QueryGL() over live reversals = %+v, want nothing", got)
+	}
+
+	// Amended by repost, the voucher shows its new entries only
+	amended := glMap()
+	amended[0].Debit, amended[0].DebitInAccountCurrency = 1200, 1200
+	amended[1].Credit, amended[1].CreditInAccountCurrency = 1200, 1200
+	if err := engine.MakeGLEntries(glMap(), DefaultPostingOptions()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := engine.Amend(amended, DefaultPostingOptions()); err != nil {
+		t.Fatal(err)
+	}
+	for name, entries := range map[string][]GLEntry{"flagged": store.entries, "live reversals": append(slices.Clone(legacy), store.entries[4:]...)} {
+		got, _ := QueryGL(entries, GLFilter{Account: "Sales - ABC"}, nil)
+		if len(got) != 1 || got[0].Credit != 1200 {
+			t.Errorf("%s: QueryGL() after amending = %+v, want the 1200 credit", name, got)
+		}
+	}
+}
+
+func TestReportFlags(t *testing.T) {
+	entries := []GLEntry{
+		{Account: "Rent - AC", Debit: 100},
+		{Account: "Rent - AC", Debit: 40, IsOpening: IsOpeningYes},
+		{Account: "Rent - AC", Credit: 140, VoucherType: PeriodClosingVoucher},
+	}
+	tests := []struct {
+		flags ReportFlags
+		want  int
+	}{
+		{ReportFlags{}, 1},
+		{ReportFlags{IncludeOpening: true}, 2},
+		{ReportFlags{IncludePeriodClosing: true}, 2},
+		{ReportFlags{IncludeOpening: true, IncludePeriodClosing: true}, 3},
+	}
+	for _, tt := range tests {
+		got, _ := QueryGL(entries, GLFilter{Account: "Rent - AC", ReportFlags: tt.flags}, nil)
+		if len(got) != tt.want {
+			t.Errorf("QueryGL(%+v) = %d entries, want %d", tt.flags, len(got), tt.want)
+		}
+	}
+}
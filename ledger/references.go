@@ -0,0 +1,82 @@
+package ledger
+
+import (
+	"cmp"
+	"slices"
+)
+
+// AgainstVoucherUnlinker detaches entries booked against a voucher. GL
+// and payment ledger stores implement it so that cancelling a document
+// leaves no live entry pointing at it.
+type AgainstVoucherUnlinker interface {
+	// UnlinkAgainstVoucher detaches every live entry of another voucher
+	// that is booked against (voucherType, voucherNo) and returns the
+	// vouchers affected. Payment ledger entries are booked against their
+	// own voucher instead, which leaves the amount unallocated; GL entries
+	// lose their against-voucher link. The entries of the voucher itself
+	// are left alone.
+	UnlinkAgainstVoucher(voucherType, voucherNo string) ([]VoucherRef, error)
+}
+
+// ReferenceCleaner drops the links of dependent records to a cancelled
+// voucher: advance allocations, bank transaction matches and the like.
+type ReferenceCleaner interface {
+	// ClearReferences removes the references to (voucherType, voucherNo)
+	// and returns the records changed.
+	ClearReferences(voucherType, voucherNo string) ([]VoucherRef, error)
+}
+
+// ReferenceManager cascades a cancellation to the records that depend on
+// the cancelled voucher. Set it on the Engine to run on every
+// cancellation.
+//
+// A cascade frees the payments allocated to the voucher, so they can no
+// longer follow an amendment of it (see RelinkAgainstVoucher). Companies
+// that amend rather than re-allocate leave it unset.
+//
+// Maps to: unlink_ref_doc_from_payment_entries() and
+// update_accounting_ledgers_after_reference_removal() in
+// erpnext/accounts/utils.py, run on cancel when Accounts Settings
+// "Unlink Payment on Cancellation of Invoice" is set
+type ReferenceManager struct {
+	Stores   []AgainstVoucherUnlinker // Typically the GL and payment ledger stores
+	Cleaners []ReferenceCleaner
+}
+
+// NewReferenceManager returns a manager over the engine's stores that can
+// unlink, and the given cleaners.
+func NewReferenceManager(e *Engine, cleaners ...ReferenceCleaner) *ReferenceManager {
+	m := &ReferenceManager{Cleaners: cleaners}
+	for _, store := range []any{e.GLStore, e.PaymentStore} {
+		if u, ok := store.(AgainstVoucherUnlinker); ok {
+			m.Stores = append(m.Stores, u)
+		}
+	}
+	return m
+}
+
+// OnCancel unlinks every record that refers to a cancelled voucher and
+// returns the records affected, sorted and without duplicates. It stops
+// at the first error; the unlinking done so far is not undone, but
+// running it again completes it.
+func (m *ReferenceManager) OnCancel(voucherType, voucherNo string) ([]VoucherRef, error) {
+	var affected []VoucherRef
+	for _, s := range m.Stores {
+		refs, err := s.UnlinkAgainstVoucher(voucherType, voucherNo)
+		if err != nil {
+			return affected, err
+		}
+		affected = append(affected, refs...)
+	}
+	for _, c := range m.Cleaners {
+		refs, err := c.ClearReferences(voucherType, voucherNo)
+		if err != nil {
+			return affected, err
+		}
+		affected = append(affected, refs...)
+	}
+	slices.SortFunc(affected, func(a, b VoucherRef) int {
+		return cmp.Or(cmp.Compare(a.VoucherType, b.VoucherType), cmp.Compare(a.VoucherNo, b.VoucherNo), cmp.Compare(a.Company, b.Company))
+	})
+	return slices.Compact(affected), nil
+}
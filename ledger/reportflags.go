@@ -0,0 +1,38 @@
+package ledger
+
+// PeriodClosingVoucher is the voucher type that closes income and expense
+// accounts into retained earnings at year end.
+const PeriodClosingVoucher = "Period Closing Voucher"
+
+// ReportFlags selects which special entries a report counts. Every report
+// built on GL entries takes them, so the switches mean the same thing
+// everywhere. The zero value counts regular transactions only: opening
+// balances and year-end closing entries would otherwise show up as
+// movement of the period.
+//
+// Maps to: the show_opening_entries and include_default_book_entries /
+// period closing conditions of the financial statement reports
+type ReportFlags struct {
+	IncludeOpening       bool // Count IsOpening = Yes entries
+	IncludePeriodClosing bool // Count Period Closing Voucher entries
+}
+
+// AsOfBalance are the flags of a balance as at a date, such as a balance
+// sheet's. Every entry up to the date makes up that balance, so opening
+// entries and period closing vouchers always count; only reports of the
+// movement within a period leave them out.
+//
+// Maps to: get_balance_on(), which applies no opening or period closing
+// condition
+var AsOfBalance = ReportFlags{IncludeOpening: true, IncludePeriodClosing: true}
+
+// Counts reports whether an entry is counted under the flags.
+func (f ReportFlags) Counts(e *GLEntry) bool {
+	if e.IsOpening == IsOpeningYes && !f.IncludeOpening {
+		return false
+	}
+	if e.VoucherType == PeriodClosingVoucher && !f.IncludePeriodClosing {
+		return false
+	}
+	return true
+}
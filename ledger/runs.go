@@ -0,0 +1,76 @@
+package ledger
+
+import (
+	"maps"
+	"slices"
+	"time"
+)
+
+// EngineVersion identifies the posting behaviour recorded in PostingRuns.
+// Bump it when merging, rounding or validation changes what a posting
+// saves, so old runs can be told apart from new ones.
+const EngineVersion = 1
+
+// PostingRun records how a voucher was posted or cancelled: the options
+// and settings that shaped its entries and what came out. Looking up the
+// runs of a months-old voucher shows why it merged or rounded the way it
+// did.
+type PostingRun struct {
+	VoucherType string
+	VoucherNo   string
+	Company     string
+	Options     PostingOptions
+
+	EngineVersion int
+	MergeFields   []string         // Engine.MergeFields at the time
+	Policy        ValidationPolicy // Engine.Policy at the time
+
+	Started      time.Time
+	Duration     time.Duration
+	EntriesIn    int      // GL map handed to Post
+	EntriesSaved int      // After merging and round-off; reversals on cancel
+	Warnings     []string // Checks the policy downgraded
+	Error        string   // Why the run failed; empty on success
+}
+
+// Failed reports whether the run was rejected.
+func (r *PostingRun) Failed() bool {
+	return r.Error != ""
+}
+
+// RunStore keeps the posting runs of vouchers.
+type RunStore interface {
+	// SaveRun records a run.
+	SaveRun(run *PostingRun) error
+
+	// Runs returns the runs of a voucher, oldest first.
+	Runs(voucherType, voucherNo string) ([]PostingRun, error)
+}
+
+// newPostingRun starts the run of posting glMap.
+func (e *Engine) newPostingRun(glMap []GLEntry, opts PostingOptions) *PostingRun {
+	return &PostingRun{
+		VoucherType:   glMap[0].VoucherType,
+		VoucherNo:     glMap[0].VoucherNo,
+		Company:       glMap[0].Company,
+		Options:       opts,
+		EngineVersion: EngineVersion,
+		MergeFields:   slices.Clone(e.MergeFields),
+		Policy:        maps.Clone(e.Policy),
+		Started:       time.Now(),
+		EntriesIn:     len(glMap),
+	}
+}
+
+// finish records the outcome of the run.
+func (r *PostingRun) finish(warnings []Warning, err error) {
+	r.Duration = time.Since(r.Started)
+	if err != nil {
+		r.Error = err.Error()
+		r.EntriesSaved = 0
+		return
+	}
+	for _, w := range warnings {
+		r.Warnings = append(r.Warnings, w.String())
+	}
+}
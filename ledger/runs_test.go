@@ -0,0 +1,105 @@
+package ledger
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+type stubRuns struct {
+	runs []PostingRun
+	err  error
+}
+
+func (s *stubRuns) SaveRun(run *PostingRun) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.runs = append(s.runs, *run)
+	return nil
+}
+
+func (s *stubRuns) Runs(voucherType, voucherNo string) ([]PostingRun, error) {
+	var result []PostingRun
+	for _, run := range s.runs {
+		if run.VoucherType == voucherType && run.VoucherNo == voucherNo {
+			result = append(result, run)
+		}
+	}
+	return result, nil
+}
+
+func TestPostingRuns(t *testing.T) {
+	runs := &stubRuns{}
+	engine := &Engine{
+		Accounts:    newMockAccountLookup(),
+		Company:     &mockCompanySettings{},
+		GLStore:     &mockGLStore{},
+		Budget:      stubBudget{&BudgetExceededError{Account: "Sales - ABC", Budget: 50, Actual: 99.99}},
+		Policy:      ValidationPolicy{CheckBudget: SeverityWarn},
+		MergeFields: []string{"region"},
+		Runs:        runs,
+	}
+
+	// The two receivable rows merge and the 0.01 difference is rounded off
+	glMap := []GLEntry{
+		makeTestGLEntry("Debtors - ABC", 60, 0),
+		makeTestGLEntry("Debtors - ABC", 40, 0),
+		makeTestGLEntry("Sales - ABC", 0, 99.99),
+	}
+	if _, err := engine.Post(glMap, DefaultPostingOptions()); err != nil {
+		t.Fatal(err)
+	}
+	engine.Policy[CheckBudget] = SeverityError
+	engine.MergeFields[0] = "changed"
+
+	cancel := DefaultPostingOptions()
+	cancel.Cancel, cancel.User = true, "auditor@example.com"
+	engine.Budget = nil
+	if err := engine.MakeGLEntries(glMap, cancel); err != nil {
+		t.Fatal(err)
+	}
+
+	got, _ := runs.Runs("Sales Invoice", "SINV-001")
+	if len(got) != 2 {
+		t.Fatalf("%d runs recorded", len(got))
+	}
+	post, rev := got[0], got[1]
+	if post.Company != "ABC Company" || post.EngineVersion != EngineVersion || !post.Options.MergeEntries || post.Options.Cancel {
+		t.Errorf("posting run = %+v", post)
+	}
+	if post.EntriesIn != 3 || post.EntriesSaved != 3 || post.Failed() || post.Started.IsZero() || post.Duration < 0 {
+		t.Errorf("posting run counts = %+v", post)
+	}
+	if len(post.Warnings) != 1 || !strings.HasPrefix(post.Warnings[0], "budget: ") {
+		t.Errorf("posting run warnings = %q", post.Warnings)
+	}
+	// Settings are copied, not shared with the engine
+	if post.MergeFields[0] != "region" || post.Policy[CheckBudget] != SeverityWarn {
+		t.Errorf("posting run settings = %v, %v", post.MergeFields, post.Policy)
+	}
+	if !rev.Options.Cancel || rev.Options.User != "auditor@example.com" || rev.EntriesSaved != 3 {
+		t.Errorf("cancel run = %+v", rev)
+	}
+
+	// Failed runs are recorded with the error
+	engine.Budget = stubBudget{&BudgetExceededError{Account: "Sales - ABC", Budget: 50, Actual: 99.99}}
+	other := []GLEntry{makeTestGLEntry("Debtors - ABC", 10, 0), makeTestGLEntry("Sales - ABC", 0, 10)}
+	for i := range other {
+		other[i].VoucherNo = "SINV-002"
+	}
+	if _, err := engine.Post(other, DefaultPostingOptions()); !errors.Is(err, ErrBudgetExceeded) {
+		t.Fatalf("Post() error = %v", err)
+	}
+	if got, _ = runs.Runs("Sales Invoice", "SINV-002"); len(got) != 1 || !got[0].Failed() || got[0].EntriesSaved != 0 {
+		t.Errorf("failed run = %+v", got)
+	}
+
+	// A run that cannot be recorded leaves the posting in place
+	engine.Budget = nil
+	runs.err = errors.New("run store offline")
+	warnings, err := engine.Post(other, DefaultPostingOptions())
+	if err != nil || len(warnings) != 1 || warnings[0].Check != CheckPostingRun {
+		t.Errorf("Post() = %v, %v", warnings, err)
+	}
+}
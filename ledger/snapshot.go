@@ -0,0 +1,178 @@
+package ledger
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/daterange"
+)
+
+// SnapshotVersion is the format version written by ExportLedgerSnapshot.
+const SnapshotVersion = 1
+
+// LedgerSnapshot is the ledger of one company over a period, with the
+// accounts and settings needed to replay it: a reproducible case a user
+// can hand to support without giving access to the production database.
+type LedgerSnapshot struct {
+	Version    int
+	Company    string
+	From, To   time.Time // Inclusive; zero when open
+	Created    time.Time
+	Anonymized bool
+
+	Settings             SnapshotSettings
+	Accounts             []Account
+	GLEntries            []GLEntry
+	PaymentLedgerEntries []PaymentLedgerEntry
+}
+
+// SnapshotSettings are the company settings of a snapshot. They implement
+// CompanySettings, so a snapshot can be replayed through an Engine.
+type SnapshotSettings struct {
+	DefaultCurrency    string
+	RoundOffAccount    string
+	RoundOffCostCenter string
+	AccountsFrozenTill *time.Time
+	BookClosingDate    *time.Time
+}
+
+// GetDefaultCurrency implements CompanySettings.
+func (s SnapshotSettings) GetDefaultCurrency(string) (string, error) {
+	return s.DefaultCurrency, nil
+}
+
+// GetRoundOffAccount implements CompanySettings.
+func (s SnapshotSettings) GetRoundOffAccount(string) (string, error) {
+	return s.RoundOffAccount, nil
+}
+
+// GetRoundOffCostCenter implements CompanySettings.
+func (s SnapshotSettings) GetRoundOffCostCenter(string) (string, error) {
+	return s.RoundOffCostCenter, nil
+}
+
+// GetAccountsFrozenTillDate implements CompanySettings.
+func (s SnapshotSettings) GetAccountsFrozenTillDate(string) (*time.Time, error) {
+	return s.AccountsFrozenTill, nil
+}
+
+// GetBookClosingDate implements CompanySettings.
+func (s SnapshotSettings) GetBookClosingDate(string) (*time.Time, error) {
+	return s.BookClosingDate, nil
+}
+
+// SnapshotSource is the data a snapshot is cut from. Entries and accounts
+// of other companies are left out.
+type SnapshotSource struct {
+	GLEntries            []GLEntry
+	PaymentLedgerEntries []PaymentLedgerEntry
+	Accounts             []Account
+	Settings             CompanySettings // Optional
+}
+
+// SnapshotOptions select what goes into a snapshot.
+type SnapshotOptions struct {
+	Company  string
+	From, To time.Time // Inclusive posting dates; zero for an open end
+
+	// Anonymize rewrites party names, voucher numbers and remarks to
+	// stable pseudonyms and drops custom fields; see Anonymizer. Amounts,
+	// dates and accounts are kept, so that the case still reproduces.
+	Anonymize bool
+	Salt      string // Varies the pseudonyms; keep it secret to prevent guessing names
+}
+
+// ExportLedgerSnapshot writes the GL and payment ledger entries of a
+// company posted in a period, with its accounts and settings, as
+// gzip-compressed JSON. Entries before the period are not included, so a
+// period that does not start at the beginning of the books has no
+// opening balances.
+func ExportLedgerSnapshot(w io.Writer, src SnapshotSource, opts SnapshotOptions) error {
+	period := daterange.Inclusive(opts.From, opts.To)
+	snap := LedgerSnapshot{
+		Version: SnapshotVersion, Company: opts.Company, From: opts.From, To: opts.To,
+		Created: time.Now().UTC(), Anonymized: opts.Anonymize,
+	}
+	if src.Settings != nil {
+		if err := snap.Settings.load(src.Settings, opts.Company); err != nil {
+			return err
+		}
+	}
+	for _, a := range src.Accounts {
+		if a.Company == "" || a.Company == opts.Company {
+			snap.Accounts = append(snap.Accounts, a)
+		}
+	}
+	for _, e := range src.GLEntries {
+		if e.Company == opts.Company && period.Contains(e.PostingDate) {
+			snap.GLEntries = append(snap.GLEntries, e)
+		}
+	}
+	for _, e := range src.PaymentLedgerEntries {
+		if e.Company == opts.Company && period.Contains(e.PostingDate) {
+			snap.PaymentLedgerEntries = append(snap.PaymentLedgerEntries, e)
+		}
+	}
+	if opts.Anonymize {
+		NewAnonymizer(opts.Salt).Snapshot(&snap)
+	}
+
+	zw := gzip.NewWriter(w)
+	if err := json.NewEncoder(zw).Encode(&snap); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// ImportLedgerSnapshot reads a snapshot written by ExportLedgerSnapshot.
+func ImportLedgerSnapshot(r io.Reader) (*LedgerSnapshot, error) {
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSnapshot, err)
+	}
+	defer zr.Close()
+	var snap LedgerSnapshot
+	if err := json.NewDecoder(zr).Decode(&snap); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSnapshot, err)
+	}
+	if snap.Version != SnapshotVersion {
+		return nil, fmt.Errorf("%w: version %d, want %d", ErrInvalidSnapshot, snap.Version, SnapshotVersion)
+	}
+	return &snap, nil
+}
+
+// Load saves the snapshot's entries to the stores, which are normally
+// empty in-memory stores.
+func (s *LedgerSnapshot) Load(glStore GLEntryStore, paymentStore PaymentLedgerStore) error {
+	if len(s.GLEntries) > 0 {
+		if err := glStore.SaveBatch(s.GLEntries); err != nil {
+			return err
+		}
+	}
+	if len(s.PaymentLedgerEntries) > 0 {
+		return paymentStore.SaveBatch(s.PaymentLedgerEntries)
+	}
+	return nil
+}
+
+// load copies a company's settings.
+func (s *SnapshotSettings) load(settings CompanySettings, company string) error {
+	var err error
+	if s.DefaultCurrency, err = settings.GetDefaultCurrency(company); err != nil {
+		return err
+	}
+	if s.RoundOffAccount, err = settings.GetRoundOffAccount(company); err != nil {
+		return err
+	}
+	if s.RoundOffCostCenter, err = settings.GetRoundOffCostCenter(company); err != nil {
+		return err
+	}
+	if s.AccountsFrozenTill, err = settings.GetAccountsFrozenTillDate(company); err != nil {
+		return err
+	}
+	s.BookClosingDate, err = settings.GetBookClosingDate(company)
+	return err
+}
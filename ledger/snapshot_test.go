@@ -0,0 +1,86 @@
+package ledger_test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/ledger"
+	"github.com/senguttuvang/erpnext-go/ledger/memstore"
+)
+
+func TestLedgerSnapshot(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, 3, d, 0, 0, 0, 0, time.UTC) }
+	invoice := func(d int, company, no, customer string) []ledger.GLEntry {
+		return []ledger.GLEntry{
+			{PostingDate: day(d), Company: company, VoucherType: "Sales Invoice", VoucherNo: no, Account: "Debtors", PartyType: "Customer", Party: customer,
+				Against: "Sales", Debit: 100, DebitInAccountCurrency: 100, Remarks: "Order by " + customer},
+			{PostingDate: day(d), Company: company, VoucherType: "Sales Invoice", VoucherNo: no, Account: "Sales", Against: customer,
+				AgainstRefs: []ledger.AgainstRef{{Kind: ledger.AgainstParty, PartyType: "Customer", Name: customer}}, Credit: 100, CreditInAccountCurrency: 100},
+		}
+	}
+	var gl []ledger.GLEntry
+	gl = append(gl, invoice(1, "Acme", "SI-1", "Jane Doe")...)
+	gl = append(gl, invoice(5, "Acme", "SI-2", "Jane Doe")...)
+	gl = append(gl, invoice(5, "Globex", "SI-3", "John Roe")...)
+	gl = append(gl, invoice(20, "Acme", "SI-4", "John Roe")...) // After the period
+	ple := []ledger.PaymentLedgerEntry{{PostingDate: day(5), Company: "Acme", VoucherType: "Sales Invoice", VoucherNo: "SI-2", PartyType: "Customer", Party: "Jane Doe", Amount: 100}}
+
+	var buf bytes.Buffer
+	err := ledger.ExportLedgerSnapshot(&buf, ledger.SnapshotSource{
+		GLEntries: gl, PaymentLedgerEntries: ple,
+		Accounts: []ledger.Account{{Name: "Debtors", Company: "Acme"}, {Name: "Sales", Company: "Acme"}, {Name: "Debtors", Company: "Globex"}},
+		Settings: &memstore.Company{DefaultCurrency: "EUR", RoundOffAccount: "Round Off"},
+	}, ledger.SnapshotOptions{Company: "Acme", From: day(1), To: day(10), Anonymize: true, Salt: "s3cret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(buf.String(), "Jane") {
+		t.Fatal("snapshot is not compressed")
+	}
+	if gl[0].Party != "Jane Doe" || gl[1].AgainstRefs[0].Name != "Jane Doe" {
+		t.Fatal("export changed the source entries")
+	}
+
+	snap, err := ledger.ImportLedgerSnapshot(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !snap.Anonymized || len(snap.GLEntries) != 4 || len(snap.PaymentLedgerEntries) != 1 || len(snap.Accounts) != 2 {
+		t.Fatalf("snapshot has %d GL, %d PLE, %d accounts", len(snap.GLEntries), len(snap.PaymentLedgerEntries), len(snap.Accounts))
+	}
+	if currency, _ := snap.Settings.GetDefaultCurrency("Acme"); currency != "EUR" {
+		t.Errorf("currency = %q", currency)
+	}
+	party := snap.GLEntries[0].Party
+	if !strings.HasPrefix(party, "Customer-") || party == "Jane Doe" {
+		t.Errorf("party = %q, want a pseudonym", party)
+	}
+	for _, e := range snap.GLEntries {
+		if strings.Contains(e.Remarks, "Jane") || strings.Contains(e.Against, "Jane") {
+			t.Errorf("%s leaks names: %q, %q", e.VoucherNo, e.Remarks, e.Against)
+		}
+		if e.Account == "Sales" && (e.Against != party || e.AgainstRefs[0].Name != party) {
+			t.Errorf("%s is against %q %v, want %q", e.VoucherNo, e.Against, e.AgainstRefs, party)
+		}
+	}
+	if snap.PaymentLedgerEntries[0].Party != party {
+		t.Errorf("payment ledger party = %q, want %q", snap.PaymentLedgerEntries[0].Party, party)
+	}
+
+	// The snapshot loads into empty stores for replay
+	glStore, paymentStore := memstore.NewGLStore(), memstore.NewPaymentStore()
+	if err := snap.Load(glStore, paymentStore); err != nil {
+		t.Fatal(err)
+	}
+	si2 := ledger.NewAnonymizer("s3cret").Voucher("Sales Invoice", "SI-2")
+	if entries, _ := glStore.GetByVoucher("Sales Invoice", si2); len(entries) != 2 {
+		t.Errorf("loaded SI-2 entries = %d", len(entries))
+	}
+
+	if _, err := ledger.ImportLedgerSnapshot(strings.NewReader("not a snapshot")); !errors.Is(err, ledger.ErrInvalidSnapshot) {
+		t.Errorf("err = %v, want ErrInvalidSnapshot", err)
+	}
+}
@@ -0,0 +1,121 @@
+//go:build sqlite_integration
+
+// The tests in this file run the stores against a real SQLite database
+// through modernc.org/sqlite, which this module does not depend on. Run
+// them from a checkout that adds the driver:
+//
+//	go get modernc.org/sqlite
+//	go test -tags sqlite_integration ./ledger/sqlite
+
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	_ "modernc.org/sqlite"
+
+	"github.com/senguttuvang/erpnext-go/custom"
+	"github.com/senguttuvang/erpnext-go/ledger"
+)
+
+func openSQLite(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open(DriverName, DSN(filepath.Join(t.TempDir(), "books.db")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := Setup(t.Context(), db); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestSQLite(t *testing.T) {
+	db := openSQLite(t)
+	var mode string
+	if err := db.QueryRow("PRAGMA journal_mode").Scan(&mode); err != nil || mode != "wal" {
+		t.Errorf("journal_mode = %q, %v", mode, err)
+	}
+	// Setup is idempotent on a current database
+	if err := Setup(t.Context(), db); err != nil {
+		t.Fatal(err)
+	}
+
+	accounts := &Accounts{DB: db}
+	for _, acc := range []ledger.Account{
+		{Name: "Debtors - A", Company: "A", AccountCurrency: "INR", RootType: "Asset", PartyType: "Customer"},
+		{Name: "Sales - A", Company: "A", AccountCurrency: "INR", RootType: "Income"},
+	} {
+		if err := accounts.Put(acc); err != nil {
+			t.Fatal(err)
+		}
+	}
+	engine := &ledger.Engine{Accounts: accounts, GLStore: &GLStore{DB: db}, PaymentStore: &PaymentStore{DB: db}}
+	due := date("2024-07-15")
+	glMap := []ledger.GLEntry{
+		{Account: "Debtors - A", PartyType: "Customer", Party: "Acme", DueDate: &due, Debit: 1180, DebitInAccountCurrency: 1180,
+			AgainstVoucherType: "Sales Invoice", AgainstVoucher: "SINV-1", Custom: custom.Fields{"region": "North"}},
+		{Account: "Sales - A", Credit: 1180, CreditInAccountCurrency: 1180, CostCenter: "Main - A"},
+	}
+	for i := range glMap {
+		glMap[i].PostingDate, glMap[i].Company, glMap[i].AccountCurrency = date("2024-06-15"), "A", "INR"
+		glMap[i].VoucherType, glMap[i].VoucherNo = "Sales Invoice", "SINV-1"
+	}
+	posting := func() []ledger.GLEntry {
+		out := make([]ledger.GLEntry, len(glMap))
+		for i := range glMap {
+			out[i] = glMap[i].Copy()
+		}
+		return out
+	}
+	if err := engine.MakeGLEntries(posting(), ledger.DefaultPostingOptions()); err != nil {
+		t.Fatal(err)
+	}
+	if err := engine.MakeGLEntries(posting(), ledger.DefaultPostingOptions()); !errors.Is(err, ledger.ErrDoublePosting) {
+		t.Errorf("second post: err = %v, want ErrDoublePosting", err)
+	}
+
+	gl, err := engine.GLStore.GetByVoucher("Sales Invoice", "SINV-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(gl) != 2 || gl[0].Debit != 1180 || gl[0].DueDate == nil || !gl[0].DueDate.Equal(due) ||
+		gl[0].Custom.String("region") != "North" || gl[1].Custom != nil || gl[1].CostCenter != "Main - A" {
+		t.Fatalf("GL entries = %+v", gl)
+	}
+	ple, err := engine.PaymentStore.GetByVoucher("Sales Invoice", "SINV-1")
+	if err != nil || len(ple) != 1 || ple[0].Amount != 1180 {
+		t.Fatalf("payment ledger entries = %+v, %v", ple, err)
+	}
+
+	cancel := ledger.DefaultPostingOptions()
+	cancel.Cancel = true
+	if err := engine.MakeGLEntries(gl, cancel); err != nil {
+		t.Fatal(err)
+	}
+	if gl, err = engine.GLStore.GetByVoucher("Sales Invoice", "SINV-1"); err != nil || len(gl) != 4 ||
+		slices.ContainsFunc(gl, func(e ledger.GLEntry) bool { return !e.IsCancelled }) {
+		t.Errorf("after cancelling: %+v, %v", gl, err)
+	}
+	if err := engine.MakeGLEntries(posting(), ledger.DefaultPostingOptions()); err != nil {
+		t.Errorf("post after cancelling: %v", err)
+	}
+}
+
+func TestSQLiteNames(t *testing.T) {
+	store := &GLStore{DB: openSQLite(t)}
+	entry := ledger.GLEntry{PostingDate: date("2024-06-15"), Account: "Cash - A", Company: "A",
+		VoucherType: "Journal Entry", VoucherNo: "JV-1", Debit: 1}
+	if err := store.Save(&entry); err != nil {
+		t.Fatal(err)
+	}
+	again := entry
+	if err := store.Save(&again); err == nil {
+		t.Error("saved two entries with one name")
+	}
+}
@@ -0,0 +1,292 @@
+package sqlite
+
+import (
+	"cmp"
+	"database/sql"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/senguttuvang/erpnext-go/custom"
+	"github.com/senguttuvang/erpnext-go/ledger"
+)
+
+const glColumns = "name, posting_date, transaction_date, due_date, account, account_currency, party_type, party, against, " +
+	"voucher_type, voucher_no, voucher_subtype, voucher_detail_no, against_voucher_type, against_voucher, " +
+	"debit, credit, debit_in_account_currency, credit_in_account_currency, " +
+	"transaction_currency, transaction_exchange_rate, debit_in_transaction_currency, credit_in_transaction_currency, " +
+	"reporting_currency_exchange_rate, debit_in_reporting_currency, credit_in_reporting_currency, " +
+	"cost_center, project, company, fiscal_year, finance_book, is_opening, is_advance, is_cancelled, remarks, custom, against_refs"
+
+// insert returns an INSERT of every column of a list into table.
+func insert(table, columns string) string {
+	n := strings.Count(columns, ",") + 1
+	return "INSERT INTO " + table + " (" + columns + ") VALUES (" + strings.Repeat("?, ", n-1) + "?)"
+}
+
+var insertGL = insert("gl_entry", glColumns)
+
+// GLStore is a ledger.GLEntryStore over the gl_entry table. An entry
+// saved without a name is given a random one, as ERPNext names GL
+// entries by hash.
+//
+// A batch is a posting, and the gl_posting table holds one live posting
+// per voucher and finance book: a second SaveBatch of a voucher that is
+// not cancelled in between fails with ledger.ErrDoublePosting, whichever
+// process or connection it comes from. Reversals and amendments arrive
+// through SaveAdjustment and are not postings.
+type GLStore struct {
+	DB *sql.DB
+}
+
+// Save inserts a single GL entry. It does not register a posting.
+func (s *GLStore) Save(entry *ledger.GLEntry) error {
+	if err := setName(&entry.Name); err != nil {
+		return err
+	}
+	_, err := s.DB.Exec(insertGL, glValues(entry)...)
+	return err
+}
+
+// SaveBatch inserts the entries of a posting in one transaction, so a
+// voucher's entries are saved together or not at all, and registers the
+// posting of each voucher and finance book among them.
+func (s *GLStore) SaveBatch(entries []ledger.GLEntry) error {
+	return s.save(entries, true)
+}
+
+// SaveAdjustment inserts entries that reverse or amend a voucher's
+// posting, in one transaction. It implements ledger.AdjustmentSaver.
+func (s *GLStore) SaveAdjustment(entries []ledger.GLEntry) error {
+	return s.save(entries, false)
+}
+
+func (s *GLStore) save(entries []ledger.GLEntry, posting bool) error {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	stmt, err := tx.Prepare(insertGL)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	var keys []ledger.PostingKey
+	for i := range entries {
+		e := &entries[i]
+		if err := setName(&e.Name); err != nil {
+			return err
+		}
+		if _, err := stmt.Exec(glValues(e)...); err != nil {
+			return err
+		}
+		k := ledger.PostingKey{Company: e.Company, VoucherType: e.VoucherType, VoucherNo: e.VoucherNo, FinanceBook: e.FinanceBook}
+		if posting && !slices.Contains(keys, k) {
+			keys = append(keys, k)
+		}
+	}
+	for _, k := range keys {
+		_, err := tx.Exec("INSERT INTO gl_posting (company, voucher_type, voucher_no, finance_book, live) VALUES (?, ?, ?, ?, 1)",
+			k.Company, k.VoucherType, k.VoucherNo, k.FinanceBook)
+		if err != nil && strings.Contains(err.Error(), "UNIQUE constraint failed") {
+			return fmt.Errorf("%w: %s %s, finance book %q", ledger.ErrDoublePosting, k.VoucherType, k.VoucherNo, k.FinanceBook)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetByVoucher returns the entries of a voucher, including cancelled ones,
+// in the order they were saved.
+func (s *GLStore) GetByVoucher(voucherType, voucherNo string) ([]ledger.GLEntry, error) {
+	rows, err := s.DB.Query("SELECT "+glColumns+" FROM gl_entry WHERE voucher_type = ? AND voucher_no = ? ORDER BY rowid",
+		voucherType, voucherNo)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var result []ledger.GLEntry
+	for rows.Next() {
+		var e ledger.GLEntry
+		if err := rows.Scan(glFields(&e)...); err != nil {
+			return nil, err
+		}
+		result = append(result, e)
+	}
+	return result, rows.Err()
+}
+
+// MarkCancelled marks all entries of a voucher as cancelled, and its
+// postings with them, so the voucher may be posted again.
+func (s *GLStore) MarkCancelled(voucherType, voucherNo string) error {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec("UPDATE gl_entry SET is_cancelled = 1 WHERE voucher_type = ? AND voucher_no = ?", voucherType, voucherNo); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("UPDATE gl_posting SET live = NULL WHERE voucher_type = ? AND voucher_no = ?", voucherType, voucherNo); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// DeleteVoucher removes all entries of a voucher and its postings. It
+// implements ledger.VoucherDeleter.
+func (s *GLStore) DeleteVoucher(voucherType, voucherNo string) (int, error) {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	res, err := tx.Exec("DELETE FROM gl_entry WHERE voucher_type = ? AND voucher_no = ?", voucherType, voucherNo)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := tx.Exec("DELETE FROM gl_posting WHERE voucher_type = ? AND voucher_no = ?", voucherType, voucherNo); err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return int(n), tx.Commit()
+}
+
+// glValues returns the column values of an entry, in glColumns order.
+func glValues(e *ledger.GLEntry) []any {
+	return []any{
+		e.Name, dateValue(e.PostingDate), dateValue(e.TransactionDate), optionalDateValue(e.DueDate),
+		e.Account, e.AccountCurrency, e.PartyType, e.Party, e.Against,
+		e.VoucherType, e.VoucherNo, e.VoucherSubtype, e.VoucherDetailNo, e.AgainstVoucherType, e.AgainstVoucher,
+		e.Debit, e.Credit, e.DebitInAccountCurrency, e.CreditInAccountCurrency,
+		e.TransactionCurrency, e.TransactionExchangeRate, e.DebitInTransactionCurrency, e.CreditInTransactionCurrency,
+		e.ReportingCurrencyExchangeRate, e.DebitInReportingCurrency, e.CreditInReportingCurrency,
+		e.CostCenter, e.Project, e.Company, e.FiscalYear, e.FinanceBook,
+		string(cmp.Or(e.IsOpening, ledger.IsOpeningNo)), string(cmp.Or(e.IsAdvance, ledger.IsAdvanceNo)), e.IsCancelled, e.Remarks,
+		jsonText[custom.Fields]{&e.Custom}, jsonText[[]ledger.AgainstRef]{&e.AgainstRefs},
+	}
+}
+
+// glFields returns scan destinations for the columns of an entry, in
+// glColumns order.
+func glFields(e *ledger.GLEntry) []any {
+	return []any{
+		(*text)(&e.Name), (*day)(&e.PostingDate), (*day)(&e.TransactionDate), optionalDay{&e.DueDate},
+		(*text)(&e.Account), (*text)(&e.AccountCurrency), (*text)(&e.PartyType), (*text)(&e.Party), (*text)(&e.Against),
+		(*text)(&e.VoucherType), (*text)(&e.VoucherNo), (*text)(&e.VoucherSubtype), (*text)(&e.VoucherDetailNo),
+		(*text)(&e.AgainstVoucherType), (*text)(&e.AgainstVoucher),
+		(*number)(&e.Debit), (*number)(&e.Credit), (*number)(&e.DebitInAccountCurrency), (*number)(&e.CreditInAccountCurrency),
+		(*text)(&e.TransactionCurrency), (*number)(&e.TransactionExchangeRate),
+		(*number)(&e.DebitInTransactionCurrency), (*number)(&e.CreditInTransactionCurrency),
+		(*number)(&e.ReportingCurrencyExchangeRate), (*number)(&e.DebitInReportingCurrency), (*number)(&e.CreditInReportingCurrency),
+		(*text)(&e.CostCenter), (*text)(&e.Project), (*text)(&e.Company), (*text)(&e.FiscalYear), (*text)(&e.FinanceBook),
+		(*text)(&e.IsOpening), (*text)(&e.IsAdvance), &e.IsCancelled, (*text)(&e.Remarks),
+		jsonText[custom.Fields]{&e.Custom}, jsonText[[]ledger.AgainstRef]{&e.AgainstRefs},
+	}
+}
+
+const pleColumns = "name, posting_date, company, account, party_type, party, voucher_type, voucher_no, voucher_detail_no, " +
+	"against_voucher_type, against_voucher_no, account_currency, amount, amount_in_account_currency, due_date, finance_book, delinked"
+
+var insertPLE = insert("payment_ledger_entry", pleColumns)
+
+// PaymentStore is a ledger.PaymentLedgerStore over the
+// payment_ledger_entry table.
+type PaymentStore struct {
+	DB *sql.DB
+}
+
+// Save inserts a payment ledger entry.
+func (s *PaymentStore) Save(entry *ledger.PaymentLedgerEntry) error {
+	if err := setName(&entry.Name); err != nil {
+		return err
+	}
+	_, err := s.DB.Exec(insertPLE, pleValues(entry)...)
+	return err
+}
+
+// SaveBatch inserts payment ledger entries in one transaction.
+func (s *PaymentStore) SaveBatch(entries []ledger.PaymentLedgerEntry) error {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	stmt, err := tx.Prepare(insertPLE)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	for i := range entries {
+		if err := setName(&entries[i].Name); err != nil {
+			return err
+		}
+		if _, err := stmt.Exec(pleValues(&entries[i])...); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetByVoucher returns the entries of a voucher, including delinked ones,
+// in the order they were saved.
+func (s *PaymentStore) GetByVoucher(voucherType, voucherNo string) ([]ledger.PaymentLedgerEntry, error) {
+	rows, err := s.DB.Query("SELECT "+pleColumns+" FROM payment_ledger_entry WHERE voucher_type = ? AND voucher_no = ? ORDER BY rowid",
+		voucherType, voucherNo)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var result []ledger.PaymentLedgerEntry
+	for rows.Next() {
+		var e ledger.PaymentLedgerEntry
+		if err := rows.Scan(pleFields(&e)...); err != nil {
+			return nil, err
+		}
+		result = append(result, e)
+	}
+	return result, rows.Err()
+}
+
+// Delink marks all entries of a voucher as delinked.
+func (s *PaymentStore) Delink(voucherType, voucherNo string) error {
+	_, err := s.DB.Exec("UPDATE payment_ledger_entry SET delinked = 1 WHERE voucher_type = ? AND voucher_no = ?", voucherType, voucherNo)
+	return err
+}
+
+// DeleteVoucher removes all entries of a voucher. It implements
+// ledger.VoucherDeleter.
+func (s *PaymentStore) DeleteVoucher(voucherType, voucherNo string) (int, error) {
+	res, err := s.DB.Exec("DELETE FROM payment_ledger_entry WHERE voucher_type = ? AND voucher_no = ?", voucherType, voucherNo)
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
+
+// pleValues returns the column values of an entry, in pleColumns order.
+func pleValues(e *ledger.PaymentLedgerEntry) []any {
+	return []any{
+		e.Name, dateValue(e.PostingDate), e.Company, e.Account, e.PartyType, e.Party, e.VoucherType, e.VoucherNo, e.VoucherDetailNo,
+		e.AgainstVoucherType, e.AgainstVoucherNo, e.AccountCurrency, e.Amount, e.AmountInAccountCurrency,
+		optionalDateValue(e.DueDate), e.FinanceBook, e.Delinked,
+	}
+}
+
+// pleFields returns scan destinations for the columns of an entry, in
+// pleColumns order.
+func pleFields(e *ledger.PaymentLedgerEntry) []any {
+	return []any{
+		(*text)(&e.Name), (*day)(&e.PostingDate), (*text)(&e.Company), (*text)(&e.Account), (*text)(&e.PartyType), (*text)(&e.Party),
+		(*text)(&e.VoucherType), (*text)(&e.VoucherNo), (*text)(&e.VoucherDetailNo),
+		(*text)(&e.AgainstVoucherType), (*text)(&e.AgainstVoucherNo), (*text)(&e.AccountCurrency),
+		(*number)(&e.Amount), (*number)(&e.AmountInAccountCurrency), optionalDay{&e.DueDate}, (*text)(&e.FinanceBook), &e.Delinked,
+	}
+}
@@ -0,0 +1,272 @@
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/ledger"
+)
+
+// Lookup errors
+var (
+	ErrAccountNotFound = errors.New("account not found")
+	ErrCompanyNotFound = errors.New("company not found")
+)
+
+const accountColumns = "name, account_name, company, account_currency, parent_account, root_type, " +
+	"is_group, disabled, freeze_account, balance_must_be, allowed_voucher_types, party_type"
+
+// Accounts is a ledger.AccountLookup over the account table.
+type Accounts struct {
+	DB *sql.DB
+}
+
+// Put inserts an account, or replaces the one of the same name.
+func (a *Accounts) Put(acc ledger.Account) error {
+	return replace(a.DB, "DELETE FROM account WHERE name = ?", []any{acc.Name}, insert("account", accountColumns),
+		acc.Name, acc.AccountName, acc.Company, acc.AccountCurrency, acc.ParentAccount, acc.RootType,
+		acc.IsGroup, acc.Disabled, acc.FreezeAccount, acc.BalanceMustBe, strings.Join(acc.AllowedVoucherTypes, "\n"), acc.PartyType)
+}
+
+// GetAccount returns full account details.
+func (a *Accounts) GetAccount(name string) (*ledger.Account, error) {
+	var acc ledger.Account
+	var allowed text
+	err := a.DB.QueryRow("SELECT "+accountColumns+" FROM account WHERE name = ?", name).Scan(
+		(*text)(&acc.Name), (*text)(&acc.AccountName), (*text)(&acc.Company), (*text)(&acc.AccountCurrency),
+		(*text)(&acc.ParentAccount), (*text)(&acc.RootType), &acc.IsGroup, &acc.Disabled, &acc.FreezeAccount,
+		(*text)(&acc.BalanceMustBe), &allowed, (*text)(&acc.PartyType))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: %s", ErrAccountNotFound, name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if allowed != "" {
+		acc.AllowedVoucherTypes = strings.Split(string(allowed), "\n")
+	}
+	return &acc, nil
+}
+
+// GetAccountCurrency returns the account's designated currency.
+func (a *Accounts) GetAccountCurrency(name string) (string, error) {
+	acc, err := a.GetAccount(name)
+	if err != nil {
+		return "", err
+	}
+	return acc.AccountCurrency, nil
+}
+
+// IsGroup returns true if the account is a group account.
+func (a *Accounts) IsGroup(name string) (bool, error) {
+	acc, err := a.GetAccount(name)
+	if err != nil {
+		return false, err
+	}
+	return acc.IsGroup, nil
+}
+
+// IsFrozen returns true if the account is frozen.
+func (a *Accounts) IsFrozen(name string) (bool, error) {
+	acc, err := a.GetAccount(name)
+	if err != nil {
+		return false, err
+	}
+	return acc.FreezeAccount, nil
+}
+
+// IsDisabled returns true if the account is disabled.
+func (a *Accounts) IsDisabled(name string) (bool, error) {
+	acc, err := a.GetAccount(name)
+	if err != nil {
+		return false, err
+	}
+	return acc.Disabled, nil
+}
+
+// GetBalanceMustBe returns the balance constraint of the account.
+func (a *Accounts) GetBalanceMustBe(name string) (string, error) {
+	acc, err := a.GetAccount(name)
+	if err != nil {
+		return "", err
+	}
+	return acc.BalanceMustBe, nil
+}
+
+// Company is a row of the company table.
+type Company struct {
+	Name               string
+	DefaultCurrency    string
+	RoundOffAccount    string
+	RoundOffCostCenter string
+	AccountsFrozenTill *time.Time
+	BookClosingDate    *time.Time
+}
+
+const companyColumns = "name, default_currency, round_off_account, round_off_cost_center, accounts_frozen_till_date, book_closing_date"
+
+// Companies is a ledger.CompanySettings over the company table.
+type Companies struct {
+	DB *sql.DB
+}
+
+// Put inserts a company, or replaces the one of the same name.
+func (c *Companies) Put(co Company) error {
+	return replace(c.DB, "DELETE FROM company WHERE name = ?", []any{co.Name}, insert("company", companyColumns),
+		co.Name, co.DefaultCurrency, co.RoundOffAccount, co.RoundOffCostCenter,
+		optionalDateValue(co.AccountsFrozenTill), optionalDateValue(co.BookClosingDate))
+}
+
+// Get returns a company's settings.
+func (c *Companies) Get(name string) (*Company, error) {
+	var co Company
+	err := c.DB.QueryRow("SELECT "+companyColumns+" FROM company WHERE name = ?", name).Scan(
+		(*text)(&co.Name), (*text)(&co.DefaultCurrency), (*text)(&co.RoundOffAccount), (*text)(&co.RoundOffCostCenter),
+		optionalDay{&co.AccountsFrozenTill}, optionalDay{&co.BookClosingDate})
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: %s", ErrCompanyNotFound, name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &co, nil
+}
+
+// GetDefaultCurrency returns the company's base currency.
+func (c *Companies) GetDefaultCurrency(company string) (string, error) {
+	co, err := c.Get(company)
+	if err != nil {
+		return "", err
+	}
+	return co.DefaultCurrency, nil
+}
+
+// GetRoundOffAccount returns the round-off account.
+func (c *Companies) GetRoundOffAccount(company string) (string, error) {
+	co, err := c.Get(company)
+	if err != nil {
+		return "", err
+	}
+	return co.RoundOffAccount, nil
+}
+
+// GetRoundOffCostCenter returns the round-off cost center.
+func (c *Companies) GetRoundOffCostCenter(company string) (string, error) {
+	co, err := c.Get(company)
+	if err != nil {
+		return "", err
+	}
+	return co.RoundOffCostCenter, nil
+}
+
+// GetAccountsFrozenTillDate returns the accounts frozen till date, if any.
+func (c *Companies) GetAccountsFrozenTillDate(company string) (*time.Time, error) {
+	co, err := c.Get(company)
+	if err != nil {
+		return nil, err
+	}
+	return co.AccountsFrozenTill, nil
+}
+
+// GetBookClosingDate returns the books closing date, if any.
+func (c *Companies) GetBookClosingDate(company string) (*time.Time, error) {
+	co, err := c.Get(company)
+	if err != nil {
+		return nil, err
+	}
+	return co.BookClosingDate, nil
+}
+
+// FiscalYear is a row of the fiscal_year table. One with an empty
+// Company applies to every company.
+type FiscalYear struct {
+	Name       string
+	Company    string
+	Start, End time.Time
+}
+
+// FiscalYears is a ledger.FiscalYearLookup over the fiscal_year table.
+// A year of the company itself wins over one of every company.
+type FiscalYears struct {
+	DB *sql.DB
+}
+
+// Put inserts a fiscal year, or replaces the one of the same name and
+// company.
+func (f *FiscalYears) Put(fy FiscalYear) error {
+	return replace(f.DB, "DELETE FROM fiscal_year WHERE name = ? AND company = ?", []any{fy.Name, fy.Company},
+		insert("fiscal_year", "name, company, year_start_date, year_end_date"),
+		fy.Name, fy.Company, dateValue(fy.Start), dateValue(fy.End))
+}
+
+// years returns the fiscal years of a company, its own before those of
+// every company.
+func (f *FiscalYears) years(company string) ([]FiscalYear, error) {
+	rows, err := f.DB.Query("SELECT name, company, year_start_date, year_end_date FROM fiscal_year WHERE company = ? OR company = ''", company)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var own, shared []FiscalYear
+	for rows.Next() {
+		var fy FiscalYear
+		if err := rows.Scan((*text)(&fy.Name), (*text)(&fy.Company), (*day)(&fy.Start), (*day)(&fy.End)); err != nil {
+			return nil, err
+		}
+		if fy.Company == "" {
+			shared = append(shared, fy)
+		} else {
+			own = append(own, fy)
+		}
+	}
+	return append(own, shared...), rows.Err()
+}
+
+// GetFiscalYear returns the fiscal year a date falls in.
+func (f *FiscalYears) GetFiscalYear(date time.Time, company string) (string, error) {
+	years, err := f.years(company)
+	if err != nil {
+		return "", err
+	}
+	date = ledger.CivilDate(date)
+	for _, fy := range years {
+		if !date.Before(fy.Start) && !date.After(fy.End) {
+			return fy.Name, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %s %s", ledger.ErrFiscalYearNotFound, company, date.Format(ledger.DateLayout))
+}
+
+// GetFiscalYearDates returns the start and end dates of a fiscal year.
+func (f *FiscalYears) GetFiscalYearDates(fiscalYear, company string) (start, end time.Time, err error) {
+	years, err := f.years(company)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	for _, fy := range years {
+		if fy.Name == fiscalYear {
+			return fy.Start, fy.End, nil
+		}
+	}
+	return time.Time{}, time.Time{}, fmt.Errorf("%w: %s %s", ledger.ErrFiscalYearNotFound, company, fiscalYear)
+}
+
+// replace deletes a row and inserts its new version in one transaction.
+// It is portable where an upsert is not.
+func replace(db *sql.DB, del string, key []any, ins string, values ...any) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec(del, key...); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ins, values...); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
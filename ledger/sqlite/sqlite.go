@@ -0,0 +1,245 @@
+// Package sqlite provides SQLite adapters for the ledger ports, so a small
+// business can run the whole accounting engine as a single binary over a
+// local database file.
+//
+// The adapters use database/sql and leave the driver to the binary, which
+// keeps this module free of dependencies. A pure-Go driver such as
+// modernc.org/sqlite needs no C toolchain, so the binary still cross
+// compiles to one static file:
+//
+//	import _ "modernc.org/sqlite"
+//
+//	db, err := sql.Open(sqlite.DriverName, sqlite.DSN("books.db"))
+//	if err != nil { ... }
+//	if err := sqlite.Setup(ctx, db); err != nil { ... }
+//	engine := &ledger.Engine{
+//		GLStore:      &sqlite.GLStore{DB: db},
+//		PaymentStore: &sqlite.PaymentStore{DB: db},
+//		Accounts:     &sqlite.Accounts{DB: db},
+//		Company:      &sqlite.Companies{DB: db},
+//		FiscalYears:  &sqlite.FiscalYears{DB: db},
+//		...
+//	}
+//
+// The database runs in WAL mode, where readers do not block the writer
+// and the writer does not block readers, so reports can run while the
+// engine posts. Writers still go one at a time; a busy timeout makes a
+// second writer wait for the first instead of failing, and transactions
+// take the write lock when they begin so two of them never deadlock
+// upgrading a read lock.
+//
+// Tables follow the schema of package migrate, which Setup applies.
+// Custom fields and typed against refs of GL entries are kept as JSON.
+package sqlite
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/ledger"
+	"github.com/senguttuvang/erpnext-go/migrate"
+)
+
+// DriverName is the database/sql name modernc.org/sqlite registers.
+const DriverName = "sqlite"
+
+// MasterDataTable is the history table of the master-data migrations.
+const MasterDataTable = "master_data_migrations"
+
+// Pragma is a connection setting.
+type Pragma struct {
+	Name, Value string
+}
+
+// Pragmas are the settings of every connection to a store database: WAL
+// journaling, with NORMAL sync, which is durable across application
+// crashes and loses at most the last commits on power loss; a 5 second
+// wait for the write lock; and enforced foreign keys.
+var Pragmas = []Pragma{
+	{"journal_mode", "WAL"},
+	{"synchronous", "NORMAL"},
+	{"busy_timeout", "5000"},
+	{"foreign_keys", "ON"},
+}
+
+// DSN returns the data source name of a database file with Pragmas
+// applied to each connection the pool opens, and transactions beginning
+// IMMEDIATE, in the syntax of modernc.org/sqlite.
+func DSN(path string) string {
+	q := url.Values{}
+	for _, p := range Pragmas {
+		q.Add("_pragma", p.Name+"("+p.Value+")")
+	}
+	q.Set("_txlock", "immediate")
+	return "file:" + path + "?" + q.Encode()
+}
+
+// Setup applies Pragmas to the database and brings the ledger and master
+// data schemas up to date. Run it at start up; it does nothing on a
+// database that is already current. WAL mode persists in the file, but
+// the other settings hold per connection, so open the database with DSN
+// or the driver's equivalent too.
+func Setup(ctx context.Context, db *sql.DB) error {
+	for _, p := range Pragmas {
+		if _, err := db.ExecContext(ctx, "PRAGMA "+p.Name+" = "+p.Value); err != nil {
+			return fmt.Errorf("pragma %s: %w", p.Name, err)
+		}
+	}
+	if _, err := (&migrate.Migrator{DB: db, Migrations: migrate.Ledger}).Up(ctx, 0); err != nil {
+		return err
+	}
+	_, err := (&migrate.Migrator{DB: db, Migrations: migrate.MasterData, Table: MasterDataTable}).Up(ctx, 0)
+	return err
+}
+
+// newName returns a random document name for an entry without one. It
+// takes 128 bits, where ERPNext's 10 hex digits are checked against the
+// table before use, so names never collide in practice without a lookup.
+//
+// Python equivalent:
+//
+//	frappe.generate_hash(length=10)
+func newName() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("sqlite: entry name: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// setName gives an entry without a name a new one.
+func setName(name *string) error {
+	if *name != "" {
+		return nil
+	}
+	var err error
+	*name, err = newName()
+	return err
+}
+
+// dateValue is the column value of a date, NULL when zero.
+func dateValue(t time.Time) any {
+	if t.IsZero() {
+		return nil
+	}
+	return t.Format(ledger.DateLayout)
+}
+
+// optionalDateValue is the column value of an optional date.
+func optionalDateValue(t *time.Time) any {
+	if t == nil {
+		return nil
+	}
+	return dateValue(*t)
+}
+
+// text scans a text column, NULL as empty.
+type text string
+
+func (t *text) Scan(v any) error {
+	switch v := v.(type) {
+	case nil:
+		*t = ""
+	case string:
+		*t = text(v)
+	case []byte:
+		*t = text(v)
+	default:
+		return fmt.Errorf("sqlite: cannot scan %T into text", v)
+	}
+	return nil
+}
+
+// jsonText is a column holding a map or slice as JSON, NULL when it is
+// empty.
+type jsonText[T any] struct{ v *T }
+
+func (j jsonText[T]) Value() (driver.Value, error) {
+	data, err := json.Marshal(*j.v)
+	if err != nil {
+		return nil, err
+	}
+	switch string(data) {
+	case "null", "{}", "[]":
+		return nil, nil
+	}
+	return string(data), nil
+}
+
+func (j jsonText[T]) Scan(v any) error {
+	var t text
+	if err := t.Scan(v); err != nil {
+		return err
+	}
+	var zero T
+	*j.v = zero
+	if t == "" {
+		return nil
+	}
+	return json.Unmarshal([]byte(t), j.v)
+}
+
+// number scans a numeric column, NULL as zero.
+type number float64
+
+func (n *number) Scan(v any) error {
+	var f sql.NullFloat64
+	if err := f.Scan(v); err != nil {
+		return err
+	}
+	*n = number(f.Float64)
+	return nil
+}
+
+// day scans a date column, NULL as the zero time. Drivers return dates
+// as text or, for columns declared DATE, as a time.
+type day time.Time
+
+func (d *day) Scan(v any) error {
+	var s string
+	switch v := v.(type) {
+	case nil:
+		*d = day{}
+		return nil
+	case time.Time:
+		*d = day(ledger.CivilDate(v))
+		return nil
+	case string:
+		s = v
+	case []byte:
+		s = string(v)
+	default:
+		return fmt.Errorf("sqlite: cannot scan %T into a date", v)
+	}
+	if len(s) > len(ledger.DateLayout) {
+		s = s[:len(ledger.DateLayout)] // A timestamp written by another tool
+	}
+	t, err := ledger.ParseDate(s)
+	if err != nil {
+		return err
+	}
+	*d = day(t)
+	return nil
+}
+
+// optionalDay scans a nullable date column into a *time.Time.
+type optionalDay struct{ p **time.Time }
+
+func (o optionalDay) Scan(v any) error {
+	var d day
+	if err := d.Scan(v); err != nil {
+		return err
+	}
+	if *o.p = nil; !time.Time(d).IsZero() {
+		t := time.Time(d)
+		*o.p = &t
+	}
+	return nil
+}
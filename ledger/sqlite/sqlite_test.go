@@ -0,0 +1,479 @@
+package sqlite
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/custom"
+	"github.com/senguttuvang/erpnext-go/ledger"
+)
+
+// fakeDB is a database/sql driver over in-memory tables that understands
+// the statements the stores and the migrator issue: INSERT of listed
+// values, and SELECT, UPDATE and DELETE with conditions comparing a
+// column to a parameter or a quoted literal, joined by AND or OR. Other
+// statements are logged and ignored. Rows come back in insertion order,
+// transactions apply immediately, and entry names and live postings are
+// unique.
+type fakeDB struct {
+	mu     sync.Mutex
+	tables map[string][]map[string]driver.Value
+	log    []string
+}
+
+func (d *fakeDB) Open(string) (driver.Conn, error) { return &fakeConn{d: d}, nil }
+
+// fakeConn rolls a transaction back by restoring the tables as they were
+// when it began.
+type fakeConn struct {
+	d      *fakeDB
+	before map[string][]map[string]driver.Value
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) { return &fakeStmt{c.d, query}, nil }
+func (c *fakeConn) Close() error                              { return nil }
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	c.d.mu.Lock()
+	defer c.d.mu.Unlock()
+	c.before = map[string][]map[string]driver.Value{}
+	for table, rows := range c.d.tables {
+		for _, row := range rows {
+			c.before[table] = append(c.before[table], maps.Clone(row))
+		}
+	}
+	return c, nil
+}
+
+func (c *fakeConn) Commit() error {
+	c.before = nil
+	return nil
+}
+
+func (c *fakeConn) Rollback() error {
+	c.d.mu.Lock()
+	defer c.d.mu.Unlock()
+	c.d.tables, c.before = c.before, nil
+	return nil
+}
+
+type fakeStmt struct {
+	d     *fakeDB
+	query string
+}
+
+func (s *fakeStmt) Close() error  { return nil }
+func (s *fakeStmt) NumInput() int { return -1 }
+
+// where returns a predicate of a WHERE clause, consuming its arguments.
+func where(clause string, args []driver.Value) func(map[string]driver.Value) bool {
+	clause, _, _ = strings.Cut(clause, " ORDER BY ")
+	if clause == "" {
+		return func(map[string]driver.Value) bool { return true }
+	}
+	var alts [][][2]any // OR of ANDs of (column, value)
+	for _, alt := range strings.Split(clause, " OR ") {
+		var all [][2]any
+		for _, cond := range strings.Split(alt, " AND ") {
+			col, val, _ := strings.Cut(cond, " = ")
+			var v any = strings.Trim(val, "'")
+			if val == "?" {
+				v, args = args[0], args[1:]
+			}
+			all = append(all, [2]any{col, v})
+		}
+		alts = append(alts, all)
+	}
+	return func(row map[string]driver.Value) bool {
+		for _, all := range alts {
+			ok := true
+			for _, c := range all {
+				ok = ok && fmt.Sprint(row[c[0].(string)]) == fmt.Sprint(c[1])
+			}
+			if ok {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	d, q := s.d, s.query
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	var n int64
+	switch {
+	case strings.HasPrefix(q, "INSERT INTO ") && strings.Contains(q, " VALUES "):
+		table, rest, _ := strings.Cut(strings.TrimPrefix(q, "INSERT INTO "), " (")
+		cols, values, _ := strings.Cut(rest, ") VALUES (")
+		values = strings.TrimSuffix(values, ")")
+		row := map[string]driver.Value{}
+		for i, value := range strings.Split(values, ", ") {
+			var v driver.Value = int64(1) // The only literal inserted
+			if value == "?" {
+				v, args = args[0], args[1:]
+			}
+			row[strings.Split(cols, ", ")[i]] = v
+		}
+		for _, r := range d.tables[table] {
+			switch {
+			case strings.HasSuffix(table, "entry") && r["name"] == row["name"]:
+				return nil, fmt.Errorf("UNIQUE constraint failed: %s.name", table)
+			case table == "gl_posting" && row["live"] != nil && fmt.Sprint(r) == fmt.Sprint(row):
+				return nil, errors.New("UNIQUE constraint failed: gl_posting.company, gl_posting.voucher_type")
+			}
+		}
+		d.tables[table] = append(d.tables[table], row)
+		n = 1
+	case strings.HasPrefix(q, "UPDATE "):
+		table, rest, _ := strings.Cut(strings.TrimPrefix(q, "UPDATE "), " SET ")
+		set, cond, _ := strings.Cut(rest, " WHERE ")
+		col, val, _ := strings.Cut(set, " = ")
+		match := where(cond, args)
+		for _, row := range d.tables[table] {
+			if match(row) {
+				row[col] = val
+				if val == "NULL" {
+					row[col] = nil
+				}
+				n++
+			}
+		}
+	case strings.HasPrefix(q, "DELETE FROM "):
+		table, cond, _ := strings.Cut(strings.TrimPrefix(q, "DELETE FROM "), " WHERE ")
+		match := where(cond, args)
+		var kept []map[string]driver.Value
+		for _, row := range d.tables[table] {
+			if !match(row) {
+				kept = append(kept, row)
+			}
+		}
+		n = int64(len(d.tables[table]) - len(kept))
+		d.tables[table] = kept
+	default:
+		d.log = append(d.log, q)
+	}
+	return driver.RowsAffected(n), nil
+}
+
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	d := s.d
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	cols, rest, _ := strings.Cut(strings.TrimPrefix(s.query, "SELECT "), " FROM ")
+	table, cond, _ := strings.Cut(rest, " WHERE ")
+	table, _, _ = strings.Cut(table, " ")
+	match := where(cond, args)
+	r := &fakeRows{cols: strings.Split(cols, ", ")}
+	for _, row := range d.tables[table] {
+		if match(row) {
+			values := make([]driver.Value, len(r.cols))
+			for i, col := range r.cols {
+				values[i] = row[col]
+			}
+			r.rows = append(r.rows, values)
+		}
+	}
+	return r, nil
+}
+
+type fakeRows struct {
+	cols []string
+	rows [][]driver.Value
+	next int
+}
+
+func (r *fakeRows) Columns() []string { return r.cols }
+func (r *fakeRows) Close() error      { return nil }
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.next >= len(r.rows) {
+		return io.EOF
+	}
+	for i, v := range r.rows[r.next] {
+		if s, ok := v.(string); ok && s == "1" {
+			v = int64(1) // UPDATE ... SET flag = 1
+		}
+		dest[i] = v
+	}
+	r.next++
+	return nil
+}
+
+func openFake(t *testing.T) (*sql.DB, *fakeDB) {
+	t.Helper()
+	d := &fakeDB{tables: map[string][]map[string]driver.Value{}}
+	name := "sqlite-" + t.Name()
+	sql.Register(name, d)
+	db, err := sql.Open(name, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := Setup(t.Context(), db); err != nil {
+		t.Fatal(err)
+	}
+	return db, d
+}
+
+func date(s string) time.Time {
+	t, err := ledger.ParseDate(s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestSetup(t *testing.T) {
+	db, d := openFake(t)
+	ran := strings.Join(d.log, "\n")
+	for _, want := range []string{"PRAGMA journal_mode = WAL", "PRAGMA busy_timeout = 5000", "CREATE TABLE gl_entry", "CREATE TABLE account", "CREATE TABLE fiscal_year"} {
+		if !strings.Contains(ran, want) {
+			t.Errorf("Setup did not run %q", want)
+		}
+	}
+
+	// A second run finds the schema current
+	d.log = nil
+	if err := Setup(t.Context(), db); err != nil {
+		t.Fatal(err)
+	}
+	if ran := strings.Join(d.log, "\n"); strings.Contains(ran, "CREATE TABLE gl_entry") || strings.Contains(ran, "CREATE TABLE account") {
+		t.Errorf("Setup migrated a current database again:\n%s", ran)
+	}
+
+	q, err := url.ParseQuery(strings.SplitN(DSN("/var/lib/books.db"), "?", 2)[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if q.Get("_txlock") != "immediate" || len(q["_pragma"]) != len(Pragmas) || q["_pragma"][0] != "journal_mode(WAL)" {
+		t.Errorf("DSN query = %v", q)
+	}
+}
+
+func TestEngineOnSQLite(t *testing.T) {
+	db, _ := openFake(t)
+	accounts := &Accounts{DB: db}
+	for _, acc := range []ledger.Account{
+		{Name: "Debtors - A", Company: "A", AccountCurrency: "INR", RootType: "Asset", PartyType: "Customer"},
+		{Name: "Sales - A", Company: "A", AccountCurrency: "INR", RootType: "Income", AllowedVoucherTypes: []string{"Sales Invoice", "Journal Entry"}},
+	} {
+		if err := accounts.Put(acc); err != nil {
+			t.Fatal(err)
+		}
+	}
+	companies := &Companies{DB: db}
+	closed := date("2023-03-31")
+	if err := companies.Put(Company{Name: "A", DefaultCurrency: "INR", RoundOffAccount: "Round Off - A", BookClosingDate: &closed}); err != nil {
+		t.Fatal(err)
+	}
+	years := &FiscalYears{DB: db}
+	for _, fy := range []FiscalYear{
+		{Name: "2024", Start: date("2024-01-01"), End: date("2024-12-31")},
+		{Name: "2024-2025", Company: "A", Start: date("2024-04-01"), End: date("2025-03-31")},
+	} {
+		if err := years.Put(fy); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Master data round trips, and a company's own fiscal year wins
+	acc, err := accounts.GetAccount("Sales - A")
+	if err != nil || acc.RootType != "Income" || len(acc.AllowedVoucherTypes) != 2 {
+		t.Errorf("GetAccount = %+v, %v", acc, err)
+	}
+	if _, err := accounts.GetAccount("Nope"); !errors.Is(err, ErrAccountNotFound) {
+		t.Errorf("GetAccount(Nope) err = %v", err)
+	}
+	if got, err := companies.GetBookClosingDate("A"); err != nil || got == nil || !got.Equal(closed) {
+		t.Errorf("GetBookClosingDate = %v, %v", got, err)
+	}
+	if got, err := companies.GetAccountsFrozenTillDate("A"); err != nil || got != nil {
+		t.Errorf("GetAccountsFrozenTillDate = %v, %v", got, err)
+	}
+	if fy, err := years.GetFiscalYear(date("2024-06-15"), "A"); err != nil || fy != "2024-2025" {
+		t.Errorf("GetFiscalYear(A) = %q, %v", fy, err)
+	}
+	if fy, err := years.GetFiscalYear(date("2024-06-15"), "B"); err != nil || fy != "2024" {
+		t.Errorf("GetFiscalYear(B) = %q, %v", fy, err)
+	}
+	if _, err := years.GetFiscalYear(date("2026-01-01"), "A"); !errors.Is(err, ledger.ErrFiscalYearNotFound) {
+		t.Errorf("GetFiscalYear(2026) err = %v", err)
+	}
+
+	engine := &ledger.Engine{
+		Accounts: accounts, Company: companies, FiscalYears: years,
+		GLStore: &GLStore{DB: db}, PaymentStore: &PaymentStore{DB: db},
+	}
+	due := date("2024-07-15")
+	glMap := []ledger.GLEntry{
+		{PostingDate: date("2024-06-15"), DueDate: &due, Account: "Debtors - A", PartyType: "Customer", Party: "Acme",
+			Debit: 1180, DebitInAccountCurrency: 1180, AgainstVoucherType: "Sales Invoice", AgainstVoucher: "SINV-1"},
+		{PostingDate: date("2024-06-15"), Account: "Sales - A", Credit: 1180, CreditInAccountCurrency: 1180, CostCenter: "Main - A"},
+	}
+	for i := range glMap {
+		glMap[i].Company, glMap[i].VoucherType, glMap[i].VoucherNo = "A", "Sales Invoice", "SINV-1"
+		glMap[i].AccountCurrency, glMap[i].FiscalYear = "INR", "2024-2025"
+	}
+	if err := engine.MakeGLEntries(glMap, ledger.DefaultPostingOptions()); err != nil {
+		t.Fatal(err)
+	}
+
+	gl, err := engine.GLStore.GetByVoucher("Sales Invoice", "SINV-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(gl) != 2 || gl[0].Name == "" || gl[0].Debit != 1180 || !gl[0].PostingDate.Equal(date("2024-06-15")) ||
+		gl[0].DueDate == nil || !gl[0].DueDate.Equal(due) || gl[0].IsOpening != ledger.IsOpeningNo || gl[1].CostCenter != "Main - A" {
+		t.Fatalf("GL entries = %+v", gl)
+	}
+	ple, err := engine.PaymentStore.GetByVoucher("Sales Invoice", "SINV-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ple) != 1 || ple[0].Amount != 1180 || ple[0].Party != "Acme" || ple[0].Delinked {
+		t.Fatalf("payment ledger entries = %+v", ple)
+	}
+
+	cancel := ledger.DefaultPostingOptions()
+	cancel.Cancel = true
+	if err := engine.MakeGLEntries(gl, cancel); err != nil {
+		t.Fatal(err)
+	}
+	gl, err = engine.GLStore.GetByVoucher("Sales Invoice", "SINV-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The originals and the reversals netting them are all cancelled
+	var cancelled int
+	var net float64
+	for _, e := range gl {
+		if e.IsCancelled {
+			cancelled++
+		}
+		net += e.Debit - e.Credit
+	}
+	if len(gl) != 4 || cancelled != 4 || net != 0 {
+		t.Errorf("after cancelling: %d entries, %d cancelled, netting %v; want 4, 4, 0", len(gl), cancelled, net)
+	}
+
+	if err := engine.PaymentStore.Delink("Sales Invoice", "SINV-1"); err != nil {
+		t.Fatal(err)
+	}
+	if ple, err = engine.PaymentStore.GetByVoucher("Sales Invoice", "SINV-1"); err != nil || len(ple) == 0 || !ple[0].Delinked {
+		t.Errorf("payment ledger after Delink = %+v, %v", ple, err)
+	}
+}
+
+func TestDoublePosting(t *testing.T) {
+	db, _ := openFake(t)
+	store := &GLStore{DB: db}
+	engine := &ledger.Engine{GLStore: store, PaymentStore: &PaymentStore{DB: db}}
+	voucher := func(amount float64, financeBook string) []ledger.GLEntry {
+		glMap := []ledger.GLEntry{
+			{Account: "Debtors - A", PartyType: "Customer", Party: "Acme", Debit: amount, DebitInAccountCurrency: amount},
+			{Account: "Sales - A", Credit: amount, CreditInAccountCurrency: amount, CostCenter: "Main - A"},
+		}
+		for i := range glMap {
+			glMap[i].PostingDate, glMap[i].Company, glMap[i].FinanceBook = date("2024-06-15"), "A", financeBook
+			glMap[i].VoucherType, glMap[i].VoucherNo = "Sales Invoice", "SINV-1"
+		}
+		return glMap
+	}
+	count := func() int {
+		t.Helper()
+		gl, err := store.GetByVoucher("Sales Invoice", "SINV-1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return len(gl)
+	}
+
+	if err := engine.MakeGLEntries(voucher(100, ""), ledger.DefaultPostingOptions()); err != nil {
+		t.Fatal(err)
+	}
+	// A second post, as by a repost racing a manual one, saves nothing
+	if err := engine.MakeGLEntries(voucher(100, ""), ledger.DefaultPostingOptions()); !errors.Is(err, ledger.ErrDoublePosting) {
+		t.Fatalf("second post: err = %v, want ErrDoublePosting", err)
+	}
+	if n := count(); n != 2 {
+		t.Fatalf("%d entries after the rejected post, want 2", n)
+	}
+	if ple, err := engine.PaymentStore.GetByVoucher("Sales Invoice", "SINV-1"); err != nil || len(ple) != 1 {
+		t.Fatalf("%d payment ledger entries after the rejected post, want 1 (%v)", len(ple), err)
+	}
+	// Another finance book is another posting
+	if err := engine.MakeGLEntries(voucher(100, "Tax Book"), ledger.DefaultPostingOptions()); err != nil {
+		t.Fatal(err)
+	}
+
+	// Cancelling frees the voucher to post again, and amendments by
+	// adjustment or repost are not postings
+	cancel := ledger.DefaultPostingOptions()
+	cancel.Cancel = true
+	if err := engine.MakeGLEntries(voucher(100, ""), cancel); err != nil {
+		t.Fatal(err)
+	}
+	if err := engine.MakeGLEntries(voucher(120, ""), ledger.DefaultPostingOptions()); err != nil {
+		t.Fatalf("post after cancelling: %v", err)
+	}
+	if _, err := engine.Amend(voucher(150, ""), ledger.DefaultPostingOptions()); err != nil {
+		t.Fatalf("amend by repost: %v", err)
+	}
+	engine.Amendments = ledger.AmendByAdjustment
+	if _, err := engine.Amend(voucher(130, ""), ledger.DefaultPostingOptions()); err != nil {
+		t.Fatalf("amend by adjustment: %v", err)
+	}
+	if err := engine.MakeGLEntries(voucher(130, ""), ledger.DefaultPostingOptions()); !errors.Is(err, ledger.ErrDoublePosting) {
+		t.Errorf("post after amending: err = %v, want ErrDoublePosting", err)
+	}
+
+	// Cancelling by deletion leaves neither entries nor postings behind
+	engine.Cancellations = &ledger.CancellationPolicy{Default: ledger.CancellationRule{Method: ledger.CancelByDeletion}}
+	if err := engine.MakeGLEntries(voucher(130, ""), cancel); err != nil {
+		t.Fatal(err)
+	}
+	if n := count(); n != 0 {
+		t.Fatalf("%d entries after cancelling by deletion, want 0", n)
+	}
+	if err := engine.MakeGLEntries(voucher(130, ""), ledger.DefaultPostingOptions()); err != nil {
+		t.Errorf("post after deleting: %v", err)
+	}
+}
+
+func TestCustomFields(t *testing.T) {
+	db, _ := openFake(t)
+	store := &GLStore{DB: db}
+	entries := []ledger.GLEntry{
+		{PostingDate: date("2024-06-15"), Account: "Debtors - A", Company: "A", VoucherType: "Journal Entry", VoucherNo: "JV-1",
+			Debit: 100, Custom: custom.Fields{"region": "North"},
+			AgainstRefs: []ledger.AgainstRef{{Kind: ledger.AgainstParty, PartyType: "Customer", Name: "Acme"}}},
+		{PostingDate: date("2024-06-15"), Account: "Sales - A", Company: "A", VoucherType: "Journal Entry", VoucherNo: "JV-1", Credit: 100},
+	}
+	if err := store.SaveBatch(entries); err != nil {
+		t.Fatal(err)
+	}
+	got, err := store.GetByVoucher("Journal Entry", "JV-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0].Custom.String("region") != "North" || !slices.Equal(got[0].AgainstRefs, entries[0].AgainstRefs) {
+		t.Errorf("first entry = %+v", got[0])
+	}
+	if got[1].Custom != nil || got[1].AgainstRefs != nil {
+		t.Errorf("second entry has custom fields %v and refs %v", got[1].Custom, got[1].AgainstRefs)
+	}
+	if len(got[0].Name) != 32 || got[0].Name == got[1].Name {
+		t.Errorf("names %q and %q", got[0].Name, got[1].Name)
+	}
+}
@@ -0,0 +1,187 @@
+package loan
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/ledger"
+	"github.com/senguttuvang/erpnext-go/ledger/memstore"
+)
+
+// mockStore keeps loans in a map.
+type mockStore map[string]*Loan
+
+func (m mockStore) Get(name string) (*Loan, error) {
+	loan, ok := m[name]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return loan, nil
+}
+
+func (m mockStore) Save(loan *Loan) error {
+	m[loan.Name] = loan
+	return nil
+}
+
+func date(y int, m time.Month, d int) time.Time {
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+func newService(prefix string) (*Service, *memstore.GLStore) {
+	glStore := memstore.NewGLStore()
+	return &Service{
+		Store:  mockStore{},
+		Engine: &ledger.Engine{GLStore: glStore, PaymentStore: memstore.NewPaymentStore()},
+		Accounts: Accounts{
+			Loan:            prefix + " - ACME",
+			InterestAccrued: "Interest Accrued - ACME",
+			Interest:        "Interest - ACME",
+			CostCenter:      "Main - ACME",
+		},
+	}, glStore
+}
+
+func balances(entries []ledger.GLEntry) map[string]float64 {
+	result := make(map[string]float64)
+	for _, e := range entries {
+		result[e.Account] = ledger.Flt(result[e.Account]+e.Debit-e.Credit, 2)
+	}
+	return result
+}
+
+func sum(schedule []Installment) (principal, interest float64) {
+	for _, row := range schedule {
+		principal += row.Principal
+		interest += row.Interest
+	}
+	return ledger.Flt(principal, 2), ledger.Flt(interest, 2)
+}
+
+func TestBuildSchedule(t *testing.T) {
+	schedule := BuildSchedule(100000, 12, 12, ReducingBalance, date(2024, 1, 31))
+	if len(schedule) != 12 {
+		t.Fatalf("len = %d", len(schedule))
+	}
+	if schedule[0].EMI != 8884.88 || schedule[0].Interest != 1000 || schedule[0].Principal != 7884.88 {
+		t.Errorf("first row = %+v", schedule[0])
+	}
+	if !schedule[1].Date.Equal(date(2024, 2, 29)) || !schedule[2].Date.Equal(date(2024, 3, 31)) {
+		t.Errorf("dates = %v, %v", schedule[1].Date, schedule[2].Date)
+	}
+	last := schedule[11]
+	if last.Closing != 0 || last.Opening != last.Principal {
+		t.Errorf("last row = %+v", last)
+	}
+	if principal, _ := sum(schedule); principal != 100000 {
+		t.Errorf("principal repaid = %v", principal)
+	}
+
+	flat := BuildSchedule(12000, 10, 12, Flat, date(2024, 1, 1))
+	for _, row := range flat {
+		if row.EMI != 1100 || row.Interest != 100 {
+			t.Fatalf("flat row = %+v", row)
+		}
+	}
+}
+
+func TestLoanGiven(t *testing.T) {
+	svc, glStore := newService("Loans Given")
+	loan := &Loan{Name: "LN-0001", Direction: Given, Company: "ACME", PartyType: "Employee", Party: "EMP-001", Currency: "INR",
+		Amount: 12000, Rate: 12, Tenure: 3, DisbursalDate: date(2024, 1, 1)}
+	if err := svc.Disburse(loan, "Bank - ACME"); err != nil {
+		t.Fatalf("Disburse() error = %v", err)
+	}
+	if !loan.RepaymentStart.Equal(date(2024, 2, 1)) || len(loan.Schedule) != 3 {
+		t.Fatalf("schedule = %+v", loan.Schedule)
+	}
+
+	accrued, err := svc.AccrueInterest("LN-0001", date(2024, 2, 15))
+	if err != nil || len(accrued) != 1 || accrued[0].InterestVoucher != "LN-0001/I1" {
+		t.Fatalf("AccrueInterest() = %+v, %v", accrued, err)
+	}
+	for range 3 {
+		if _, err := svc.Repay("LN-0001", date(2024, 3, 1), "Bank - ACME"); err != nil {
+			t.Fatalf("Repay() error = %v", err)
+		}
+	}
+	if loan.Status != Closed || loan.Outstanding() != 0 {
+		t.Errorf("status %s, outstanding %v", loan.Status, loan.Outstanding())
+	}
+	if _, err := svc.Repay("LN-0001", date(2024, 5, 1), "Bank - ACME"); !errors.Is(err, ErrFullyRepaid) {
+		t.Errorf("Repay() on closed loan error = %v", err)
+	}
+
+	_, interest := sum(loan.Schedule)
+	got := balances(glStore.All())
+	want := map[string]float64{
+		"Bank - ACME":             interest,
+		"Loans Given - ACME":      0,
+		"Interest Accrued - ACME": 0,
+		"Interest - ACME":         -interest,
+	}
+	for account, balance := range want {
+		if got[account] != balance {
+			t.Errorf("balance of %s = %v, want %v", account, got[account], balance)
+		}
+	}
+	for _, e := range glStore.All() {
+		if e.Account == "Loans Given - ACME" && (e.Party != "EMP-001" || e.AgainstVoucher != "LN-0001") {
+			t.Errorf("loan entry not linked to party: %+v", e)
+		}
+	}
+}
+
+func TestLoanTakenPrepay(t *testing.T) {
+	svc, glStore := newService("Loans Taken")
+	loan := &Loan{Name: "LN-0002", Direction: Taken, Company: "ACME", PartyType: "Supplier", Party: "HDFC", Currency: "INR",
+		Amount: 100000, Rate: 12, Tenure: 12, DisbursalDate: date(2024, 1, 1)}
+	if err := svc.Disburse(loan, "Bank - ACME"); err != nil {
+		t.Fatalf("Disburse() error = %v", err)
+	}
+	if got := balances(glStore.All()); got["Bank - ACME"] != 100000 || got["Loans Taken - ACME"] != -100000 {
+		t.Errorf("after disbursal: %v", got)
+	}
+	if _, err := svc.Repay("LN-0002", date(2024, 2, 1), "Bank - ACME"); err != nil {
+		t.Fatal(err)
+	}
+	if got := balances(glStore.All())["Interest - ACME"]; got != 1000 {
+		t.Errorf("interest expense = %v, want 1000", got)
+	}
+
+	// Accrue the next installment, then prepay: its interest is kept
+	if _, err := svc.AccrueInterest("LN-0002", date(2024, 3, 1)); err != nil {
+		t.Fatal(err)
+	}
+	accruedInterest := loan.Schedule[1].Interest
+	emi := loan.Schedule[1].EMI
+	if err := svc.Prepay("LN-0002", date(2024, 2, 15), 50000, "Bank - ACME", ReduceEMI); err != nil {
+		t.Fatalf("Prepay() error = %v", err)
+	}
+	if len(loan.Schedule) != 12 || loan.Schedule[1].Interest != accruedInterest || loan.Schedule[2].EMI >= emi/2 {
+		t.Errorf("after ReduceEMI: %+v", loan.Schedule[1:3])
+	}
+	if principal, _ := sum(loan.Schedule); principal != 50000 {
+		t.Errorf("scheduled principal = %v, want 50000", principal)
+	}
+	if err := svc.Prepay("LN-0002", date(2024, 2, 15), 10000, "Bank - ACME", ReduceTenure); err != nil {
+		t.Fatal(err)
+	}
+	if len(loan.Schedule) >= 12 || loan.Schedule[len(loan.Schedule)-1].Closing != 0 {
+		t.Errorf("after ReduceTenure: %d installments", len(loan.Schedule))
+	}
+	if err := svc.Prepay("LN-0002", date(2024, 2, 15), 1e6, "Bank - ACME", ReduceEMI); !errors.Is(err, ErrExcessPayment) {
+		t.Errorf("excess Prepay() error = %v", err)
+	}
+
+	if err := svc.Restructure("LN-0002", 9, 24); err != nil {
+		t.Fatal(err)
+	}
+	if len(loan.Schedule) != 25 || loan.Rate != 9 {
+		t.Errorf("after Restructure: %d installments at %v%%", len(loan.Schedule), loan.Rate)
+	}
+	if got := balances(glStore.All())["Loans Taken - ACME"]; got != -loan.Outstanding() {
+		t.Errorf("loan liability = %v, outstanding %v", got, loan.Outstanding())
+	}
+}
@@ -0,0 +1,130 @@
+// Package loan implements accounting for loans taken and loans given.
+//
+// A loan is disbursed in one amount and repaid in equated monthly
+// installments (EMIs) following a repayment schedule. Interest is accrued
+// on each installment date and settled with the installment. Prepayments
+// and restructures rebuild the rest of the schedule from the principal
+// still outstanding.
+//
+// Migrated from: lending/loan_management/doctype/loan (the Lending app
+// split out of ERPNext v14)
+package loan
+
+import (
+	"errors"
+	"time"
+)
+
+// VoucherType is the voucher type used on loan GL entries.
+const VoucherType = "Loan"
+
+// Loan errors
+var (
+	ErrNotFound       = errors.New("loan not found")
+	ErrExists         = errors.New("loan already exists")
+	ErrInvalidAmount  = errors.New("amount must be greater than zero")
+	ErrInvalidTenure  = errors.New("tenure must be at least one month")
+	ErrNotDisbursed   = errors.New("loan is not disbursed")
+	ErrFullyRepaid    = errors.New("loan is fully repaid")
+	ErrExcessPayment  = errors.New("prepayment exceeds outstanding principal")
+	ErrMissingAccount = errors.New("loan account is not configured")
+)
+
+// Direction says which side of the loan the company is on.
+type Direction string
+
+const (
+	Taken Direction = "Taken" // Borrowing: the loan is a liability
+	Given Direction = "Given" // Lending: the loan is an asset
+)
+
+// Method is the interest calculation method.
+type Method string
+
+const (
+	// ReducingBalance charges interest on the principal outstanding, so
+	// each EMI carries less interest and more principal than the last.
+	ReducingBalance Method = "Reducing Balance"
+	// Flat charges interest on the original principal for the whole
+	// tenure, spread evenly over the installments.
+	Flat Method = "Flat"
+)
+
+// Status is the lifecycle state of a loan.
+type Status string
+
+const (
+	Sanctioned Status = "Sanctioned"
+	Disbursed  Status = "Disbursed"
+	Closed     Status = "Closed" // Fully repaid
+)
+
+// Loan is a loan taken from or given to a party.
+type Loan struct {
+	Name      string
+	Direction Direction
+	Company   string
+	PartyType string // e.g. "Supplier" for a lender, "Customer" or "Employee" for a borrower
+	Party     string
+	Currency  string
+
+	Amount         float64 // Principal sanctioned
+	Rate           float64 // Annual interest rate in percent
+	Tenure         int     // Number of monthly installments
+	Method         Method
+	DisbursalDate  time.Time
+	RepaymentStart time.Time // Date of the first installment
+
+	Status   Status
+	Schedule []Installment
+}
+
+// Installment is one row of a repayment schedule.
+type Installment struct {
+	Date      time.Time
+	Opening   float64 // Principal outstanding before the installment
+	Principal float64
+	Interest  float64
+	EMI       float64 // Principal + Interest
+	Closing   float64 // Principal outstanding after the installment
+
+	InterestVoucher  string // Set once the interest is accrued
+	RepaymentVoucher string // Set once the installment is paid
+}
+
+// Outstanding returns the principal not yet repaid.
+func (l *Loan) Outstanding() float64 {
+	for _, row := range l.Schedule {
+		if row.RepaymentVoucher == "" {
+			return row.Opening
+		}
+	}
+	return 0
+}
+
+// nextDue returns the index of the first unpaid installment, or -1.
+func (l *Loan) nextDue() int {
+	for i, row := range l.Schedule {
+		if row.RepaymentVoucher == "" {
+			return i
+		}
+	}
+	return -1
+}
+
+// Store abstracts loan persistence.
+type Store interface {
+	// Get returns a loan by name, or ErrNotFound.
+	Get(name string) (*Loan, error)
+	// Save creates or replaces a loan.
+	Save(loan *Loan) error
+}
+
+// Accounts configures the ledger accounts used for a company's loans of
+// one direction.
+type Accounts struct {
+	Loan            string // Loan liability (taken) or loan receivable (given)
+	InterestAccrued string // Interest payable (taken) or receivable (given)
+	Interest        string // Interest expense (taken) or income (given)
+	CostCenter      string
+}
@@ -0,0 +1,75 @@
+package loan
+
+import (
+	"math"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/ledger"
+)
+
+// BuildSchedule returns the repayment schedule of a principal repaid in
+// months installments, the first on first and the rest on the same day of
+// each following month. Amounts are rounded to 2 decimals; the last
+// installment absorbs the rounding so the principal is repaid exactly.
+//
+// Maps to: make_repayment_schedule() in loan.py
+func BuildSchedule(principal, rate float64, months int, method Method, first time.Time) []Installment {
+	if months <= 0 || principal <= 0 {
+		return nil
+	}
+	schedule := make([]Installment, months)
+	balance := ledger.Flt(principal, 2)
+
+	monthly := rate / 1200
+	emi := 0.0
+	flatInterest := 0.0
+	switch {
+	case method == Flat:
+		flatInterest = ledger.Flt(principal*rate/1200, 2)
+		emi = ledger.Flt(principal/float64(months), 2) + flatInterest
+	case monthly == 0:
+		emi = ledger.Flt(principal/float64(months), 2)
+	default:
+		emi = ledger.Flt(EMI(principal, rate, months), 2)
+	}
+
+	for i := range schedule {
+		row := &schedule[i]
+		row.Date = addMonths(ledger.CivilDate(first), i)
+		row.Opening = balance
+		if method == Flat {
+			row.Interest = flatInterest
+		} else {
+			row.Interest = ledger.Flt(balance*monthly, 2)
+		}
+		row.Principal = ledger.Flt(emi-row.Interest, 2)
+		if i == months-1 || row.Principal > balance {
+			row.Principal = balance
+		}
+		row.EMI = ledger.Flt(row.Principal+row.Interest, 2)
+		balance = ledger.Flt(balance-row.Principal, 2)
+		row.Closing = balance
+	}
+	return schedule
+}
+
+// EMI returns the equated monthly installment repaying principal with
+// reducing-balance interest at an annual rate over months installments.
+//
+// Maps to: get_monthly_repayment_amount() in loan.py
+func EMI(principal, rate float64, months int) float64 {
+	r := rate / 1200
+	if r == 0 {
+		return principal / float64(months)
+	}
+	f := math.Pow(1+r, float64(months))
+	return principal * r * f / (f - 1)
+}
+
+// addMonths adds n months to t, keeping to the last day of shorter months
+// (31 Jan + 1 month is 29 Feb, not 2 Mar).
+func addMonths(t time.Time, n int) time.Time {
+	y, m, d := t.Date()
+	last := time.Date(y, m+time.Month(n)+1, 0, 0, 0, 0, 0, time.UTC).Day()
+	return time.Date(y, m+time.Month(n), min(d, last), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,315 @@
+package loan
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/ledger"
+)
+
+// Service disburses loans, accrues their interest and books repayments,
+// posting each step through the ledger engine. Use one Service per
+// direction, since the accounts differ.
+type Service struct {
+	Store    Store
+	Engine   *ledger.Engine
+	Accounts Accounts
+}
+
+// Rescheduling says how a prepayment changes the remaining installments.
+type Rescheduling string
+
+const (
+	ReduceEMI    Rescheduling = "Reduce EMI"    // Keep the number of installments
+	ReduceTenure Rescheduling = "Reduce Tenure" // Keep the EMI, finish sooner
+)
+
+// Disburse pays out a sanctioned loan and builds its repayment schedule.
+// A loan given moves cash from the bank to the loan receivable; a loan
+// taken brings it in against the loan liability.
+//
+// Maps to: LoanDisbursement.make_gl_entries()
+func (s *Service) Disburse(loan *Loan, bankAccount string) error {
+	if loan.Amount <= 0 {
+		return ErrInvalidAmount
+	}
+	if loan.Tenure <= 0 {
+		return ErrInvalidTenure
+	}
+	if s.Accounts.Loan == "" {
+		return ErrMissingAccount
+	}
+	if _, err := s.Store.Get(loan.Name); err == nil {
+		return fmt.Errorf("%w: %s", ErrExists, loan.Name)
+	}
+
+	loan.Amount = ledger.Flt(loan.Amount, 2)
+	if loan.Method == "" {
+		loan.Method = ReducingBalance
+	}
+	if loan.RepaymentStart.IsZero() {
+		loan.RepaymentStart = addMonths(ledger.CivilDate(loan.DisbursalDate), 1)
+	}
+	loan.Schedule = BuildSchedule(loan.Amount, loan.Rate, loan.Tenure, loan.Method, loan.RepaymentStart)
+
+	err := s.post(loan, loan.DisbursalDate, loan.Name+"/D", []line{
+		{s.Accounts.Loan, loan.Amount, true},
+		{bankAccount, -loan.Amount, false},
+	})
+	if err != nil {
+		return err
+	}
+	loan.Status = Disbursed
+	return s.Store.Save(loan)
+}
+
+// AccrueInterest books the interest of every installment dated on or
+// before upTo that has not been accrued yet: income and a receivable for
+// a loan given, expense and a payable for a loan taken. It returns the
+// installments accrued.
+//
+// Maps to: make_accrual_interest_entry_for_term_loans() in
+// process_loan_interest_accrual.py
+func (s *Service) AccrueInterest(name string, upTo time.Time) ([]Installment, error) {
+	loan, err := s.disbursed(name)
+	if err != nil {
+		return nil, err
+	}
+	var accrued []Installment
+	for i := range loan.Schedule {
+		row := &loan.Schedule[i]
+		if row.Date.After(ledger.CivilDate(upTo)) {
+			break
+		}
+		if row.InterestVoucher != "" || row.Interest == 0 {
+			continue
+		}
+		if err := s.accrue(loan, i); err != nil {
+			return accrued, err
+		}
+		accrued = append(accrued, *row)
+	}
+	if len(accrued) > 0 {
+		if err := s.Store.Save(loan); err != nil {
+			return accrued, err
+		}
+	}
+	return accrued, nil
+}
+
+// Repay books the next unpaid installment, accruing its interest first if
+// needed: the EMI goes through the bank account, against the principal and
+// the accrued interest. The loan closes with its last installment.
+//
+// Maps to: LoanRepayment.make_gl_entries()
+func (s *Service) Repay(name string, date time.Time, bankAccount string) (Installment, error) {
+	loan, err := s.disbursed(name)
+	if err != nil {
+		return Installment{}, err
+	}
+	i := loan.nextDue()
+	row := &loan.Schedule[i]
+	if row.InterestVoucher == "" && row.Interest != 0 {
+		if err := s.accrue(loan, i); err != nil {
+			return Installment{}, err
+		}
+	}
+
+	voucherNo := fmt.Sprintf("%s/R%d", loan.Name, i+1)
+	err = s.post(loan, date, voucherNo, []line{
+		{bankAccount, row.EMI, false},
+		{s.Accounts.Loan, -row.Principal, true},
+		{s.Accounts.InterestAccrued, -row.Interest, true},
+	})
+	if err != nil {
+		return Installment{}, err
+	}
+	row.RepaymentVoucher = voucherNo
+	if loan.nextDue() < 0 {
+		loan.Status = Closed
+	}
+	return *row, s.Store.Save(loan)
+}
+
+// Prepay books a principal payment outside the schedule and rebuilds the
+// unpaid installments from the reduced principal. Interest already accrued
+// on the next installment is kept.
+//
+// Maps to: LoanRepayment with payment_type "Loan Prepayment" and
+// regenerate_repayment_schedule() in loan.py
+func (s *Service) Prepay(name string, date time.Time, amount float64, bankAccount string, how Rescheduling) error {
+	loan, err := s.disbursed(name)
+	if err != nil {
+		return err
+	}
+	amount = ledger.Flt(amount, 2)
+	if amount <= 0 {
+		return ErrInvalidAmount
+	}
+	outstanding := loan.Outstanding()
+	if amount > outstanding {
+		return fmt.Errorf("%w: %v > %v", ErrExcessPayment, amount, outstanding)
+	}
+
+	i := loan.nextDue()
+	voucherNo := fmt.Sprintf("%s/P%d", loan.Name, i+1)
+	err = s.post(loan, date, voucherNo, []line{
+		{bankAccount, amount, false},
+		{s.Accounts.Loan, -amount, true},
+	})
+	if err != nil {
+		return err
+	}
+
+	principal := ledger.Flt(outstanding-amount, 2)
+	months := len(loan.Schedule) - i
+	if how == ReduceTenure {
+		emi := loan.Schedule[i].EMI
+		for n := 1; n <= months; n++ {
+			if rows := BuildSchedule(principal, loan.Rate, n, loan.Method, loan.Schedule[i].Date); len(rows) > 0 && rows[0].EMI <= emi {
+				months = n
+				break
+			}
+		}
+	}
+	loan.reschedule(principal, months)
+	return s.Store.Save(loan)
+}
+
+// Restructure changes the rate and number of remaining installments of a
+// loan and rebuilds the unpaid installments. months of 0 keeps the
+// current number of remaining installments.
+//
+// Maps to: LoanRestructure.restructure_loan()
+func (s *Service) Restructure(name string, rate float64, months int) error {
+	loan, err := s.disbursed(name)
+	if err != nil {
+		return err
+	}
+	if months < 0 {
+		return ErrInvalidTenure
+	}
+	if months == 0 {
+		months = len(loan.Schedule) - loan.nextDue()
+	}
+	loan.Rate = rate
+	loan.reschedule(loan.Outstanding(), months)
+	return s.Store.Save(loan)
+}
+
+// reschedule replaces the unpaid installments with a schedule repaying
+// principal over months installments from the next due date.
+func (l *Loan) reschedule(principal float64, months int) {
+	i := l.nextDue()
+	old := l.Schedule[i:]
+	rows := BuildSchedule(principal, l.Rate, months, l.Method, old[0].Date)
+	if accrued := old[0]; accrued.InterestVoucher != "" {
+		if len(rows) == 0 {
+			rows = []Installment{{Date: accrued.Date}}
+		}
+		rows[0].Interest = accrued.Interest
+		rows[0].InterestVoucher = accrued.InterestVoucher
+		rows[0].EMI = ledger.Flt(rows[0].Principal+rows[0].Interest, 2)
+	}
+	l.Schedule = append(l.Schedule[:i:i], rows...)
+	l.Tenure = len(l.Schedule)
+	if l.nextDue() < 0 {
+		l.Status = Closed
+	}
+}
+
+func (s *Service) disbursed(name string) (*Loan, error) {
+	loan, err := s.Store.Get(name)
+	if err != nil {
+		return nil, err
+	}
+	switch loan.Status {
+	case Disbursed:
+		return loan, nil
+	case Closed:
+		return nil, fmt.Errorf("%w: %s", ErrFullyRepaid, name)
+	}
+	return nil, fmt.Errorf("%w: %s", ErrNotDisbursed, name)
+}
+
+// accrue posts the interest of installment i.
+func (s *Service) accrue(loan *Loan, i int) error {
+	if s.Accounts.InterestAccrued == "" || s.Accounts.Interest == "" {
+		return ErrMissingAccount
+	}
+	row := &loan.Schedule[i]
+	voucherNo := fmt.Sprintf("%s/I%d", loan.Name, i+1)
+	err := s.post(loan, row.Date, voucherNo, []line{
+		{s.Accounts.InterestAccrued, row.Interest, true},
+		{s.Accounts.Interest, -row.Interest, false},
+	})
+	if err != nil {
+		return err
+	}
+	row.InterestVoucher = voucherNo
+	return nil
+}
+
+// line is one GL line, with the amount signed as for a loan given
+// (positive is a debit). Loans taken post the opposite sides.
+type line struct {
+	account string
+	amount  float64
+	party   bool // Carries the loan's party and links to the loan
+}
+
+// post builds and posts a balanced GL map from lines, skipping zero
+// amounts. Each line is against the accounts on the other side.
+func (s *Service) post(loan *Loan, date time.Time, voucherNo string, lines []line) error {
+	if loan.Direction == Taken {
+		for i := range lines {
+			lines[i].amount = -lines[i].amount
+		}
+	}
+	against := func(debit bool) string {
+		var names []string
+		for _, l := range lines {
+			if l.amount != 0 && (l.amount > 0) != debit {
+				names = append(names, l.account)
+			}
+		}
+		return strings.Join(names, ", ")
+	}
+
+	var glMap []ledger.GLEntry
+	for _, l := range lines {
+		if l.amount == 0 {
+			continue
+		}
+		debit, credit := max(l.amount, 0), max(-l.amount, 0)
+		entry := ledger.GLEntry{
+			PostingDate:                 date,
+			TransactionDate:             date,
+			Account:                     l.account,
+			AccountCurrency:             loan.Currency,
+			Against:                     against(debit > 0),
+			VoucherType:                 VoucherType,
+			VoucherNo:                   voucherNo,
+			Company:                     loan.Company,
+			CostCenter:                  s.Accounts.CostCenter,
+			Debit:                       debit,
+			Credit:                      credit,
+			DebitInAccountCurrency:      debit,
+			CreditInAccountCurrency:     credit,
+			TransactionCurrency:         loan.Currency,
+			TransactionExchangeRate:     1,
+			DebitInTransactionCurrency:  debit,
+			CreditInTransactionCurrency: credit,
+			IsOpening:                   ledger.IsOpeningNo,
+			IsAdvance:                   ledger.IsAdvanceNo,
+			Remarks:                     "Loan " + loan.Name,
+		}
+		if l.party {
+			entry.PartyType, entry.Party = loan.PartyType, loan.Party
+			entry.AgainstVoucherType, entry.AgainstVoucher = VoucherType, loan.Name
+		}
+		glMap = append(glMap, entry)
+	}
+	return s.Engine.MakeGLEntries(glMap, ledger.DefaultPostingOptions())
+}
@@ -0,0 +1,140 @@
+package migrate
+
+// Ledger is the schema of the SQL GL and payment ledger stores, in
+// portable SQL. Tables and columns follow the ERPNext doctypes in
+// snake_case.
+var Ledger = []Migration{
+	{
+		Version: 1,
+		Name:    "create gl_entry",
+		Up: []string{`CREATE TABLE gl_entry (
+	name VARCHAR(140) PRIMARY KEY,
+	posting_date DATE NOT NULL,
+	transaction_date DATE,
+	due_date DATE,
+	account VARCHAR(140) NOT NULL,
+	account_currency VARCHAR(3),
+	party_type VARCHAR(140),
+	party VARCHAR(140),
+	against TEXT,
+	voucher_type VARCHAR(140) NOT NULL,
+	voucher_no VARCHAR(140) NOT NULL,
+	voucher_subtype VARCHAR(140),
+	voucher_detail_no VARCHAR(140),
+	against_voucher_type VARCHAR(140),
+	against_voucher VARCHAR(140),
+	debit DECIMAL(21,9) NOT NULL DEFAULT 0,
+	credit DECIMAL(21,9) NOT NULL DEFAULT 0,
+	debit_in_account_currency DECIMAL(21,9) NOT NULL DEFAULT 0,
+	credit_in_account_currency DECIMAL(21,9) NOT NULL DEFAULT 0,
+	transaction_currency VARCHAR(3),
+	transaction_exchange_rate DECIMAL(21,9),
+	debit_in_transaction_currency DECIMAL(21,9) NOT NULL DEFAULT 0,
+	credit_in_transaction_currency DECIMAL(21,9) NOT NULL DEFAULT 0,
+	cost_center VARCHAR(140),
+	project VARCHAR(140),
+	company VARCHAR(140) NOT NULL,
+	fiscal_year VARCHAR(140),
+	finance_book VARCHAR(140),
+	is_opening VARCHAR(3) NOT NULL DEFAULT 'No',
+	is_advance VARCHAR(3) NOT NULL DEFAULT 'No',
+	is_cancelled SMALLINT NOT NULL DEFAULT 0,
+	remarks TEXT
+)`,
+			`CREATE INDEX gl_entry_voucher ON gl_entry (voucher_type, voucher_no)`,
+			`CREATE INDEX gl_entry_account_date ON gl_entry (company, account, posting_date)`,
+		},
+		Down: []string{`DROP TABLE gl_entry`},
+	},
+	{
+		Version: 2,
+		Name:    "create payment_ledger_entry",
+		Up: []string{`CREATE TABLE payment_ledger_entry (
+	name VARCHAR(140) PRIMARY KEY,
+	posting_date DATE NOT NULL,
+	company VARCHAR(140) NOT NULL,
+	account VARCHAR(140) NOT NULL,
+	party_type VARCHAR(140) NOT NULL,
+	party VARCHAR(140) NOT NULL,
+	voucher_type VARCHAR(140) NOT NULL,
+	voucher_no VARCHAR(140) NOT NULL,
+	voucher_detail_no VARCHAR(140),
+	against_voucher_type VARCHAR(140),
+	against_voucher_no VARCHAR(140),
+	account_currency VARCHAR(3),
+	amount DECIMAL(21,9) NOT NULL DEFAULT 0,
+	amount_in_account_currency DECIMAL(21,9) NOT NULL DEFAULT 0,
+	due_date DATE,
+	finance_book VARCHAR(140),
+	delinked SMALLINT NOT NULL DEFAULT 0
+)`,
+			`CREATE INDEX payment_ledger_entry_against ON payment_ledger_entry (against_voucher_type, against_voucher_no)`,
+		},
+		Down: []string{`DROP TABLE payment_ledger_entry`},
+	},
+	{
+		// GLEntry.ReportingCurrencyExchangeRate and the amounts in it.
+		// Existing entries were posted before consolidation in a reporting
+		// currency, so they are taken at rate 1 from the company currency,
+		// as ERPNext's patch for the fields does.
+		Version: 3,
+		Name:    "add reporting currency amounts to gl_entry",
+		Up: []string{
+			`ALTER TABLE gl_entry ADD COLUMN reporting_currency_exchange_rate DECIMAL(21,9)`,
+			`ALTER TABLE gl_entry ADD COLUMN debit_in_reporting_currency DECIMAL(21,9) NOT NULL DEFAULT 0`,
+			`ALTER TABLE gl_entry ADD COLUMN credit_in_reporting_currency DECIMAL(21,9) NOT NULL DEFAULT 0`,
+			`UPDATE gl_entry SET reporting_currency_exchange_rate = 1, debit_in_reporting_currency = debit, credit_in_reporting_currency = credit WHERE reporting_currency_exchange_rate IS NULL`,
+		},
+		Down: []string{
+			`ALTER TABLE gl_entry DROP COLUMN credit_in_reporting_currency`,
+			`ALTER TABLE gl_entry DROP COLUMN debit_in_reporting_currency`,
+			`ALTER TABLE gl_entry DROP COLUMN reporting_currency_exchange_rate`,
+		},
+	},
+	{
+		// Set by bank reconciliation when the bank statement shows the
+		// entry; existing entries stay NULL, that is not yet cleared.
+		Version: 4,
+		Name:    "add clearance_date to gl_entry",
+		Up:      []string{`ALTER TABLE gl_entry ADD COLUMN clearance_date DATE`},
+		Down:    []string{`ALTER TABLE gl_entry DROP COLUMN clearance_date`},
+	},
+	{
+		// One row per posting of a voucher into a finance book. live is 1
+		// while the posting stands and NULL once it is cancelled; NULLs
+		// never collide in a unique key, so the constraint allows any
+		// number of cancelled postings but one live one, without the
+		// partial indexes MariaDB lacks. Vouchers without cancelled
+		// entries are taken as posted once; those cancelled and reposted
+		// before the table existed are left unregistered.
+		Version: 5,
+		Name:    "create gl_posting",
+		Up: []string{`CREATE TABLE gl_posting (
+	company VARCHAR(140) NOT NULL,
+	voucher_type VARCHAR(140) NOT NULL,
+	voucher_no VARCHAR(140) NOT NULL,
+	finance_book VARCHAR(140) NOT NULL DEFAULT '',
+	live SMALLINT,
+	UNIQUE (company, voucher_type, voucher_no, finance_book, live)
+)`,
+			`INSERT INTO gl_posting (company, voucher_type, voucher_no, finance_book, live)
+SELECT DISTINCT g.company, g.voucher_type, g.voucher_no, COALESCE(g.finance_book, ''), 1 FROM gl_entry g
+WHERE NOT EXISTS (SELECT 1 FROM gl_entry c WHERE c.voucher_type = g.voucher_type AND c.voucher_no = g.voucher_no AND c.is_cancelled = 1)`,
+		},
+		Down: []string{`DROP TABLE gl_posting`},
+	},
+	{
+		// GLEntry.Custom and GLEntry.AgainstRefs as JSON text, NULL when
+		// the entry has none, as existing entries are taken to.
+		Version: 6,
+		Name:    "add custom fields and against refs to gl_entry",
+		Up: []string{
+			`ALTER TABLE gl_entry ADD COLUMN custom TEXT`,
+			`ALTER TABLE gl_entry ADD COLUMN against_refs TEXT`,
+		},
+		Down: []string{
+			`ALTER TABLE gl_entry DROP COLUMN against_refs`,
+			`ALTER TABLE gl_entry DROP COLUMN custom`,
+		},
+	},
+}
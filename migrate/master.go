@@ -0,0 +1,56 @@
+package migrate
+
+// MasterData is the schema of the SQL master-data stores the posting
+// engine looks up: accounts, company settings and fiscal years. It is
+// versioned apart from Ledger, in its own history table, so either can
+// gain migrations without renumbering the other.
+var MasterData = []Migration{
+	{
+		Version: 1,
+		Name:    "create account",
+		Up: []string{`CREATE TABLE account (
+	name VARCHAR(140) PRIMARY KEY,
+	account_name VARCHAR(140),
+	company VARCHAR(140) NOT NULL,
+	account_currency VARCHAR(3),
+	parent_account VARCHAR(140),
+	root_type VARCHAR(140),
+	is_group SMALLINT NOT NULL DEFAULT 0,
+	disabled SMALLINT NOT NULL DEFAULT 0,
+	freeze_account SMALLINT NOT NULL DEFAULT 0,
+	balance_must_be VARCHAR(140),
+	allowed_voucher_types TEXT,
+	party_type VARCHAR(140)
+)`,
+			`CREATE INDEX account_company ON account (company)`,
+		},
+		Down: []string{`DROP TABLE account`},
+	},
+	{
+		Version: 2,
+		Name:    "create company",
+		Up: []string{`CREATE TABLE company (
+	name VARCHAR(140) PRIMARY KEY,
+	default_currency VARCHAR(3) NOT NULL,
+	round_off_account VARCHAR(140),
+	round_off_cost_center VARCHAR(140),
+	accounts_frozen_till_date DATE,
+	book_closing_date DATE
+)`},
+		Down: []string{`DROP TABLE company`},
+	},
+	{
+		// A fiscal year with an empty company applies to every company, as
+		// one without rows in its Companies table does in ERPNext.
+		Version: 3,
+		Name:    "create fiscal_year",
+		Up: []string{`CREATE TABLE fiscal_year (
+	name VARCHAR(140) NOT NULL,
+	company VARCHAR(140) NOT NULL DEFAULT '',
+	year_start_date DATE NOT NULL,
+	year_end_date DATE NOT NULL,
+	PRIMARY KEY (name, company)
+)`},
+		Down: []string{`DROP TABLE fiscal_year`},
+	},
+}
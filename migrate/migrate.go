@@ -0,0 +1,302 @@
+// Package migrate versions the schema of the SQL stores.
+//
+// A Migrator applies an ordered list of migrations to a database/sql
+// connection and records each one, with a checksum of its statements, in a
+// history table. Upgrading the engine in production is then a call to Up,
+// which refuses to run when an applied migration has been edited since
+// (Verify), and Down rolls back to an earlier version.
+//
+// Maps to: frappe/migrate.py and the patches.txt mechanism of ERPNext
+package migrate
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Migration errors
+var (
+	ErrChecksumMismatch = errors.New("applied migration has changed")
+	ErrUnknownVersion   = errors.New("database has a migration this build does not know")
+	ErrIrreversible     = errors.New("migration cannot be rolled back")
+	ErrBadMigrations    = errors.New("migrations out of order")
+)
+
+// Migration is one step of the schema. Up runs its statements and then
+// UpData, if any, in a single transaction; Down undoes them the same way.
+type Migration struct {
+	Version int64
+	Name    string
+	Up      []string
+	Down    []string
+
+	// UpData and DownData move data in Go, for changes SQL cannot express
+	// portably. They are not part of the checksum: change Name when
+	// changing what they do.
+	UpData   func(ctx context.Context, tx *sql.Tx) error
+	DownData func(ctx context.Context, tx *sql.Tx) error
+}
+
+// Checksum returns the hex SHA-256 of the migration's name and statements.
+func (m Migration) Checksum() string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%d\x00%s\x00", m.Version, m.Name)
+	for _, s := range m.Up {
+		fmt.Fprintf(h, "up\x00%s\x00", s)
+	}
+	for _, s := range m.Down {
+		fmt.Fprintf(h, "down\x00%s\x00", s)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func (m Migration) reversible() bool {
+	return len(m.Down) > 0 || m.DownData != nil
+}
+
+// State is a migration and whether it has been applied.
+type State struct {
+	Migration
+	Applied   bool
+	AppliedAt time.Time
+	Checksum  string // As recorded when applied
+}
+
+// Migrator applies migrations to a database.
+type Migrator struct {
+	DB         *sql.DB
+	Migrations []Migration
+	Table      string           // History table; DefaultTable when empty
+	Bind       func(int) string // Placeholder for the nth parameter; "?" when nil, Dollar for PostgreSQL
+	Now        func() time.Time // time.Now when nil
+}
+
+// DefaultTable is the history table used when Migrator.Table is empty.
+const DefaultTable = "schema_migrations"
+
+// Dollar binds parameters as $1, $2, ... for PostgreSQL.
+func Dollar(n int) string { return fmt.Sprintf("$%d", n) }
+
+func (m *Migrator) table() string {
+	if m.Table != "" {
+		return m.Table
+	}
+	return DefaultTable
+}
+
+func (m *Migrator) bind(n int) string {
+	if m.Bind != nil {
+		return m.Bind(n)
+	}
+	return "?"
+}
+
+func (m *Migrator) now() time.Time {
+	if m.Now != nil {
+		return m.Now()
+	}
+	return time.Now()
+}
+
+// init creates the history table and checks that the migrations are in
+// ascending version order.
+func (m *Migrator) init(ctx context.Context) error {
+	for i := 1; i < len(m.Migrations); i++ {
+		if m.Migrations[i].Version <= m.Migrations[i-1].Version {
+			return fmt.Errorf("%w: %d after %d", ErrBadMigrations, m.Migrations[i].Version, m.Migrations[i-1].Version)
+		}
+	}
+	_, err := m.DB.ExecContext(ctx, "CREATE TABLE IF NOT EXISTS "+m.table()+
+		" (version BIGINT PRIMARY KEY, name VARCHAR(255) NOT NULL, checksum CHAR(64) NOT NULL, applied_at VARCHAR(40) NOT NULL)")
+	return err
+}
+
+type applied struct {
+	name, checksum string
+	at             time.Time
+}
+
+func (m *Migrator) history(ctx context.Context) (map[int64]applied, error) {
+	rows, err := m.DB.QueryContext(ctx, "SELECT version, name, checksum, applied_at FROM "+m.table()+" ORDER BY version")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := make(map[int64]applied)
+	for rows.Next() {
+		var v int64
+		var a applied
+		var at string
+		if err := rows.Scan(&v, &a.name, &a.checksum, &at); err != nil {
+			return nil, err
+		}
+		a.at, _ = time.Parse(time.RFC3339, at)
+		out[v] = a
+	}
+	return out, rows.Err()
+}
+
+// Status lists every known migration with whether it has been applied.
+func (m *Migrator) Status(ctx context.Context) ([]State, error) {
+	if err := m.init(ctx); err != nil {
+		return nil, err
+	}
+	done, err := m.history(ctx)
+	if err != nil {
+		return nil, err
+	}
+	states := make([]State, len(m.Migrations))
+	for i, mig := range m.Migrations {
+		a, ok := done[mig.Version]
+		states[i] = State{Migration: mig, Applied: ok, AppliedAt: a.at, Checksum: a.checksum}
+	}
+	return states, nil
+}
+
+// Version returns the highest applied version, or 0.
+func (m *Migrator) Version(ctx context.Context) (int64, error) {
+	if err := m.init(ctx); err != nil {
+		return 0, err
+	}
+	done, err := m.history(ctx)
+	if err != nil {
+		return 0, err
+	}
+	var v int64
+	for version := range done {
+		v = max(v, version)
+	}
+	return v, nil
+}
+
+// Verify checks that every applied migration is known and unchanged.
+func (m *Migrator) Verify(ctx context.Context) error {
+	if err := m.init(ctx); err != nil {
+		return err
+	}
+	done, err := m.history(ctx)
+	if err != nil {
+		return err
+	}
+	return m.verify(done)
+}
+
+func (m *Migrator) verify(done map[int64]applied) error {
+	versions := make([]int64, 0, len(done))
+	for v := range done {
+		versions = append(versions, v)
+	}
+	slices.Sort(versions)
+	for _, v := range versions {
+		i := slices.IndexFunc(m.Migrations, func(mig Migration) bool { return mig.Version == v })
+		if i < 0 {
+			return fmt.Errorf("%w: %d %s", ErrUnknownVersion, v, done[v].name)
+		}
+		if sum := m.Migrations[i].Checksum(); sum != done[v].checksum {
+			return fmt.Errorf("%w: %d %s", ErrChecksumMismatch, v, m.Migrations[i].Name)
+		}
+	}
+	return nil
+}
+
+// Up applies the pending migrations up to and including version target,
+// or all of them when target is 0, each in its own transaction. It stops
+// at the first failure, leaving the earlier ones applied, and returns the
+// migrations it applied.
+func (m *Migrator) Up(ctx context.Context, target int64) ([]Migration, error) {
+	if err := m.init(ctx); err != nil {
+		return nil, err
+	}
+	done, err := m.history(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.verify(done); err != nil {
+		return nil, err
+	}
+	var ran []Migration
+	for _, mig := range m.Migrations {
+		if target != 0 && mig.Version > target {
+			break
+		}
+		if _, ok := done[mig.Version]; ok {
+			continue
+		}
+		err := m.inTx(ctx, mig.Up, mig.UpData, "INSERT INTO "+m.table()+" (version, name, checksum, applied_at) VALUES ("+
+			strings.Join([]string{m.bind(1), m.bind(2), m.bind(3), m.bind(4)}, ", ")+")",
+			mig.Version, mig.Name, mig.Checksum(), m.now().UTC().Format(time.RFC3339))
+		if err != nil {
+			return ran, fmt.Errorf("migration %d %s: %w", mig.Version, mig.Name, err)
+		}
+		ran = append(ran, mig)
+	}
+	return ran, nil
+}
+
+// Down rolls back the applied migrations above version target, newest
+// first, and returns them. A migration without Down or DownData stops the
+// rollback with ErrIrreversible before anything is changed.
+func (m *Migrator) Down(ctx context.Context, target int64) ([]Migration, error) {
+	if err := m.init(ctx); err != nil {
+		return nil, err
+	}
+	done, err := m.history(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.verify(done); err != nil {
+		return nil, err
+	}
+	var undo []Migration
+	for _, mig := range slices.Backward(m.Migrations) {
+		if _, ok := done[mig.Version]; !ok || mig.Version <= target {
+			continue
+		}
+		if !mig.reversible() {
+			return nil, fmt.Errorf("%w: %d %s", ErrIrreversible, mig.Version, mig.Name)
+		}
+		undo = append(undo, mig)
+	}
+	var ran []Migration
+	for _, mig := range undo {
+		err := m.inTx(ctx, mig.Down, mig.DownData, "DELETE FROM "+m.table()+" WHERE version = "+m.bind(1), mig.Version)
+		if err != nil {
+			return ran, fmt.Errorf("rollback %d %s: %w", mig.Version, mig.Name, err)
+		}
+		ran = append(ran, mig)
+	}
+	return ran, nil
+}
+
+// inTx runs statements, then data, then the history update in one
+// transaction. Databases that commit DDL implicitly, such as MySQL, cannot
+// roll back a half-applied schema change; keep such migrations to one
+// statement.
+func (m *Migrator) inTx(ctx context.Context, statements []string, data func(context.Context, *sql.Tx) error, record string, args ...any) error {
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, s := range statements {
+		if _, err := tx.ExecContext(ctx, s); err != nil {
+			return err
+		}
+	}
+	if data != nil {
+		if err := data(ctx, tx); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.ExecContext(ctx, record, args...); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
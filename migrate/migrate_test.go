@@ -0,0 +1,224 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeDB records the statements it runs and keeps the history table in
+// memory. Statements inside a transaction take effect on commit; one
+// containing FAIL returns an error.
+type fakeDB struct {
+	history map[int64][]driver.Value
+	log     []string
+
+	pending []func() // Effects of the open transaction
+	inTx    bool
+}
+
+func (d *fakeDB) Open(string) (driver.Conn, error) { return d, nil }
+func (d *fakeDB) Close() error                     { return nil }
+
+func (d *fakeDB) Begin() (driver.Tx, error) {
+	d.inTx, d.pending = true, nil
+	return d, nil
+}
+
+func (d *fakeDB) Commit() error {
+	for _, fn := range d.pending {
+		fn()
+	}
+	d.inTx, d.pending = false, nil
+	return nil
+}
+
+func (d *fakeDB) Rollback() error {
+	d.inTx, d.pending = false, nil
+	return nil
+}
+
+func (d *fakeDB) Prepare(query string) (driver.Stmt, error) { return &fakeStmt{d, query}, nil }
+
+func (d *fakeDB) apply(fn func()) {
+	if d.inTx {
+		d.pending = append(d.pending, fn)
+	} else {
+		fn()
+	}
+}
+
+type fakeStmt struct {
+	d     *fakeDB
+	query string
+}
+
+func (s *fakeStmt) Close() error  { return nil }
+func (s *fakeStmt) NumInput() int { return -1 }
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	q := s.query
+	switch {
+	case strings.Contains(q, "FAIL"):
+		return nil, errors.New("syntax error")
+	case strings.HasPrefix(q, "CREATE TABLE IF NOT EXISTS schema_migrations"):
+	case strings.HasPrefix(q, "INSERT INTO schema_migrations"):
+		s.d.apply(func() { s.d.history[args[0].(int64)] = args })
+	case strings.HasPrefix(q, "DELETE FROM schema_migrations"):
+		s.d.apply(func() { delete(s.d.history, args[0].(int64)) })
+	default:
+		s.d.apply(func() { s.d.log = append(s.d.log, q) })
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (s *fakeStmt) Query([]driver.Value) (driver.Rows, error) {
+	var rows [][]driver.Value
+	for _, v := range slices.Sorted(maps.Keys(s.d.history)) {
+		rows = append(rows, s.d.history[v])
+	}
+	return &fakeRows{rows: rows}, nil
+}
+
+type fakeRows struct {
+	rows [][]driver.Value
+	next int
+}
+
+func (r *fakeRows) Columns() []string { return []string{"version", "name", "checksum", "applied_at"} }
+func (r *fakeRows) Close() error      { return nil }
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.next >= len(r.rows) {
+		return io.EOF
+	}
+	copy(dest, r.rows[r.next])
+	r.next++
+	return nil
+}
+
+func openFake(t *testing.T) (*sql.DB, *fakeDB) {
+	t.Helper()
+	d := &fakeDB{history: make(map[int64][]driver.Value)}
+	name := "migrate-" + t.Name()
+	sql.Register(name, d)
+	db, err := sql.Open(name, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	return db, d
+}
+
+func names(ms []Migration) []string {
+	var out []string
+	for _, m := range ms {
+		out = append(out, m.Name)
+	}
+	return out
+}
+
+func TestLedgerUpAndDown(t *testing.T) {
+	db, fake := openFake(t)
+	ctx := context.Background()
+	now := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	m := &Migrator{DB: db, Migrations: Ledger, Now: func() time.Time { return now }}
+
+	ran, err := m.Up(ctx, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ran) != 2 {
+		t.Fatalf("applied %q", names(ran))
+	}
+	if ran, err = m.Up(ctx, 0); err != nil || len(ran) != 4 {
+		t.Fatalf("second Up applied %q, err %v", names(ran), err)
+	}
+	if v, _ := m.Version(ctx); v != 6 {
+		t.Errorf("version = %d, want 6", v)
+	}
+	if !slices.ContainsFunc(fake.log, func(q string) bool {
+		return strings.HasPrefix(q, "UPDATE gl_entry SET reporting_currency_exchange_rate = 1")
+	}) {
+		t.Error("reporting currency backfill did not run")
+	}
+	states, err := m.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !states[0].Applied || !states[0].AppliedAt.Equal(now) || states[0].Checksum != Ledger[0].Checksum() {
+		t.Errorf("state = %+v", states[0])
+	}
+
+	ran, err = m.Down(ctx, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"add custom fields and against refs to gl_entry", "create gl_posting", "add clearance_date to gl_entry", "add reporting currency amounts to gl_entry"}; !slices.Equal(names(ran), want) {
+		t.Errorf("rolled back %q, want %q", names(ran), want)
+	}
+	if v, _ := m.Version(ctx); v != 2 {
+		t.Errorf("version after Down = %d, want 2", v)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	db, _ := openFake(t)
+	ctx := context.Background()
+	migrations := []Migration{
+		{Version: 1, Name: "one", Up: []string{"CREATE TABLE a (x INT)"}},
+		{Version: 2, Name: "two", Up: []string{"CREATE TABLE b (x INT)"}},
+	}
+	if _, err := (&Migrator{DB: db, Migrations: migrations}).Up(ctx, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	edited := slices.Clone(migrations)
+	edited[1].Up = []string{"CREATE TABLE b (x BIGINT)"}
+	m := &Migrator{DB: db, Migrations: append(edited, Migration{Version: 3, Name: "three", Up: []string{"CREATE TABLE c (x INT)"}})}
+	if _, err := m.Up(ctx, 0); !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("Up over an edited migration: err = %v", err)
+	}
+	if err := (&Migrator{DB: db, Migrations: migrations[:1]}).Verify(ctx); !errors.Is(err, ErrUnknownVersion) {
+		t.Errorf("Verify with a missing migration: err = %v", err)
+	}
+	if _, err := (&Migrator{DB: db, Migrations: migrations}).Down(ctx, 0); !errors.Is(err, ErrIrreversible) {
+		t.Errorf("Down without Down statements: err = %v", err)
+	}
+	backwards := []Migration{migrations[1], migrations[0]}
+	if _, err := (&Migrator{DB: db, Migrations: backwards}).Up(ctx, 0); !errors.Is(err, ErrBadMigrations) {
+		t.Errorf("Up with unordered migrations: err = %v", err)
+	}
+}
+
+func TestFailedMigrationRollsBack(t *testing.T) {
+	db, fake := openFake(t)
+	ctx := context.Background()
+	var dataRan bool
+	m := &Migrator{DB: db, Migrations: []Migration{
+		{Version: 1, Name: "ok", Up: []string{"CREATE TABLE a (x INT)"},
+			UpData: func(ctx context.Context, tx *sql.Tx) error {
+				dataRan = true
+				_, err := tx.ExecContext(ctx, "INSERT INTO a VALUES (1)")
+				return err
+			}},
+		{Version: 2, Name: "broken", Up: []string{"CREATE TABLE b (x INT)", "FAIL"}},
+	}}
+	ran, err := m.Up(ctx, 0)
+	if err == nil || len(ran) != 1 || !dataRan {
+		t.Fatalf("ran %q, err %v, data %v", names(ran), err, dataRan)
+	}
+	if want := []string{"CREATE TABLE a (x INT)", "INSERT INTO a VALUES (1)"}; !slices.Equal(fake.log, want) {
+		t.Errorf("committed %q, want %q", fake.log, want)
+	}
+	if v, _ := m.Version(ctx); v != 1 {
+		t.Errorf("version = %d, want 1", v)
+	}
+}
@@ -0,0 +1,29 @@
+package modeofpayment
+
+import "github.com/senguttuvang/erpnext-go/errcode"
+
+// Error codes of the modeofpayment package. Codes are part of the API:
+// add new ones, never renumber.
+const (
+	CodeDuplicateCompany  = "ACC-MOP-0001"
+	CodeAccountMismatch   = "ACC-MOP-0002"
+	CodeModeInUse         = "ACC-MOP-0003"
+	CodeInvalidFee        = "ACC-MOP-0004"
+	CodeMissingFeeAccount = "ACC-MOP-0005"
+
+	CodeInvalidGatewayAccount = "ACC-MOP-0006"
+	CodeNoGatewayAccount      = "ACC-MOP-0007"
+)
+
+func init() {
+	errcode.Register(CodeDuplicateCompany, ErrDuplicateCompany, "Company entered more than once")
+	errcode.Register(CodeAccountMismatch, ErrAccountMismatch, "Account does not belong to the company")
+	errcode.Register(CodeModeInUse, ErrModeInUse, "Mode of payment used in POS profiles")
+	errcode.Register(CodeInvalidFee, ErrInvalidFee, "Mode of payment fee is negative or 100% or more")
+	errcode.Register(CodeMissingFeeAccount, ErrMissingFeeAccount, "Mode of payment with a fee has no fee account")
+	errcode.Register(CodeInvalidGatewayAccount, ErrInvalidGatewayAccount, "Payment gateway account is incomplete or its mode of payment does not match")
+	errcode.Register(CodeNoGatewayAccount, ErrNoGatewayAccount, "No payment gateway account for the company and currency")
+}
+
+// Code returns the code of the wrapped sentinel error.
+func (e *ValidationError) Code() string { return errcode.Of(e.Err) }
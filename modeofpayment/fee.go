@@ -0,0 +1,86 @@
+package modeofpayment
+
+import (
+	"fmt"
+	"math"
+)
+
+// Fee is the processing charge a provider keeps from payments made through
+// a mode, such as 2% on card receipts. The provider settles the payment
+// less the fee, which is booked as an expense.
+type Fee struct {
+	Percentage float64 // Of the payment amount
+	Fixed      float64 // Per payment, in company currency
+}
+
+// IsZero reports whether the mode charges no fee.
+func (f Fee) IsZero() bool {
+	return f.Percentage == 0 && f.Fixed == 0
+}
+
+// On returns the fee on a payment of amount in company currency, rounded
+// to 2 decimals and never more than the payment. Refunds (negative
+// amounts) carry no fee.
+func (f Fee) On(amount float64) float64 {
+	if amount <= 0 || f.IsZero() {
+		return 0
+	}
+	fee := amount*f.Percentage/100 + f.Fixed
+	return math.Min(math.Round(fee*100)/100, amount)
+}
+
+// Lookup fetches Mode of Payment masters by name.
+type Lookup interface {
+	GetModeOfPayment(name string) (*ModeOfPayment, error)
+}
+
+// AccountFor returns the accounts row of a company.
+func (m *ModeOfPayment) AccountFor(company string) (ModeOfPaymentAccount, bool) {
+	for _, a := range m.Accounts {
+		if a.Company == company {
+			return a, true
+		}
+	}
+	return ModeOfPaymentAccount{}, false
+}
+
+// FeeFor returns the fee on a payment in a company, with the account and
+// cost center to book it to. It is an error for a mode with a fee to have
+// no fee account for the company.
+func (m *ModeOfPayment) FeeFor(company string, amount float64) (fee float64, account ModeOfPaymentAccount, err error) {
+	fee = m.Fee.On(amount)
+	if fee == 0 {
+		return 0, ModeOfPaymentAccount{}, nil
+	}
+	account, ok := m.AccountFor(company)
+	if !ok || account.FeeAccount == "" {
+		return 0, account, &ValidationError{
+			Err:     ErrMissingFeeAccount,
+			Details: fmt.Sprintf("mode '%s' has no fee account for company '%s'", m.Name, company),
+		}
+	}
+	return fee, account, nil
+}
+
+// ValidateFee checks that the fee is not negative, that the percentage
+// is below 100 and that every company has an account to book the fee to.
+func (m *ModeOfPayment) ValidateFee() error {
+	if m.Fee.Percentage < 0 || m.Fee.Percentage >= 100 || m.Fee.Fixed < 0 {
+		return &ValidationError{
+			Err:     ErrInvalidFee,
+			Details: fmt.Sprintf("mode '%s' has fee %.2f%% + %.2f", m.Name, m.Fee.Percentage, m.Fee.Fixed),
+		}
+	}
+	if m.Fee.IsZero() {
+		return nil
+	}
+	for _, a := range m.Accounts {
+		if a.FeeAccount == "" {
+			return &ValidationError{
+				Err:     ErrMissingFeeAccount,
+				Details: fmt.Sprintf("mode '%s' has no fee account for company '%s'", m.Name, a.Company),
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package modeofpayment
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestFeeOn(t *testing.T) {
+	for _, tc := range []struct {
+		fee    Fee
+		amount float64
+		want   float64
+	}{
+		{Fee{Percentage: 2}, 1180, 23.6},
+		{Fee{Percentage: 2.9, Fixed: 0.30}, 10, 0.59},
+		{Fee{Fixed: 5}, 3, 3}, // Never more than the payment
+		{Fee{Percentage: 2}, -100, 0},
+		{Fee{}, 100, 0},
+	} {
+		if got := tc.fee.On(tc.amount); got != tc.want {
+			t.Errorf("%+v on %.2f = %.2f, want %.2f", tc.fee, tc.amount, got, tc.want)
+		}
+	}
+}
+
+func TestFeeFor(t *testing.T) {
+	card := &ModeOfPayment{
+		Name: "Credit Card", Type: Bank, Enabled: true, Fee: Fee{Percentage: 2},
+		Accounts: []ModeOfPaymentAccount{
+			{Company: "ACME", DefaultAccount: "Card Clearing - ACME", FeeAccount: "Card Fees - ACME", FeeCostCenter: "Main - ACME"},
+			{Company: "Globex", DefaultAccount: "Card Clearing - GX"},
+		},
+	}
+	fee, account, err := card.FeeFor("ACME", 500)
+	if err != nil || fee != 10 || account.FeeAccount != "Card Fees - ACME" {
+		t.Errorf("FeeFor(ACME) = %.2f %+v %v", fee, account, err)
+	}
+	if _, _, err := card.FeeFor("Globex", 500); !errors.Is(err, ErrMissingFeeAccount) {
+		t.Errorf("FeeFor(Globex) error = %v", err)
+	}
+	if err := card.ValidateFee(); !errors.Is(err, ErrMissingFeeAccount) {
+		t.Errorf("ValidateFee() error = %v", err)
+	}
+
+	card.Accounts = card.Accounts[:1]
+	if err := card.ValidateFee(); err != nil {
+		t.Errorf("ValidateFee() error = %v", err)
+	}
+	card.Fee.Percentage = 100
+	if err := card.ValidateFee(); !errors.Is(err, ErrInvalidFee) {
+		t.Errorf("ValidateFee() with 100%% = %v", err)
+	}
+}
@@ -0,0 +1,202 @@
+package modeofpayment
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// PaymentChannel is how a payment request reaches the payer.
+type PaymentChannel string
+
+const (
+	ChannelEmail PaymentChannel = "Email"
+	ChannelPhone PaymentChannel = "Phone"
+)
+
+// PaymentGatewayAccount links a payment gateway (Stripe, Razorpay, ...)
+// to the ledger account its collections settle into. The account fixes the
+// currency; the mode of payment is what payment entries made from the
+// gateway's collections are booked under.
+//
+// Maps to ERPNext's Payment Gateway Account doctype.
+// Migrated from: erpnext/accounts/doctype/payment_gateway_account/payment_gateway_account.py
+type PaymentGatewayAccount struct {
+	Name           string // "<gateway> - <currency>", see GatewayAccountName
+	PaymentGateway string
+	Company        string
+	PaymentAccount string // Bank or clearing account the gateway pays into
+	Currency       string // Currency of PaymentAccount
+	ModeOfPayment  string
+	PaymentChannel PaymentChannel // ChannelEmail when empty
+	IsDefault      bool           // Used when a payment request names no gateway
+	Message        string         // Template of the payment request message
+}
+
+// GatewayAccountLookup resolves the company and currency of ledger accounts.
+type GatewayAccountLookup interface {
+	AccountLookup
+
+	// GetAccountCurrency returns the account's designated currency.
+	GetAccountCurrency(accountName string) (string, error)
+}
+
+// GatewayAccountName returns the autoname of a payment gateway account.
+//
+// Python equivalent:
+//
+//	def autoname(self):
+//	    self.name = self.payment_gateway + " - " + self.currency
+func GatewayAccountName(gateway, currency string) string {
+	return gateway + " - " + currency
+}
+
+// Channel returns the payment channel, defaulting to email.
+func (g *PaymentGatewayAccount) Channel() PaymentChannel {
+	if g.PaymentChannel == "" {
+		return ChannelEmail
+	}
+	return g.PaymentChannel
+}
+
+// Validate sets the currency from the payment account and checks that the
+// account belongs to the company and that the mode of payment is enabled
+// and settles into the same account. A mode with no account for the
+// company is accepted.
+//
+// Python equivalent:
+//
+//	def validate(self):
+//	    self.currency = frappe.get_cached_value("Account", self.payment_account, "account_currency")
+//	    self.update_default_payment_gateway()
+//	    self.set_as_default_if_not_set()
+func (g *PaymentGatewayAccount) Validate(accounts GatewayAccountLookup, modes Lookup) error {
+	if g.PaymentGateway == "" || g.PaymentAccount == "" {
+		return &ValidationError{
+			Err:     ErrInvalidGatewayAccount,
+			Details: "payment gateway and payment account are mandatory",
+		}
+	}
+	company, err := accounts.GetAccountCompany(g.PaymentAccount)
+	if err != nil {
+		return fmt.Errorf("failed to lookup account %s: %w", g.PaymentAccount, err)
+	}
+	if company != g.Company {
+		return &ValidationError{
+			Err: ErrAccountMismatch,
+			Details: fmt.Sprintf("account '%s' belongs to '%s', not '%s' in gateway account '%s'",
+				g.PaymentAccount, company, g.Company, g.Name),
+		}
+	}
+	currency, err := accounts.GetAccountCurrency(g.PaymentAccount)
+	if err != nil {
+		return fmt.Errorf("failed to lookup account %s: %w", g.PaymentAccount, err)
+	}
+	g.Currency = currency
+	if g.Name == "" {
+		g.Name = GatewayAccountName(g.PaymentGateway, currency)
+	}
+
+	if g.ModeOfPayment == "" {
+		return nil
+	}
+	mode, err := modes.GetModeOfPayment(g.ModeOfPayment)
+	if err != nil {
+		return fmt.Errorf("failed to lookup mode of payment %s: %w", g.ModeOfPayment, err)
+	}
+	if !mode.Enabled {
+		return &ValidationError{
+			Err:     ErrInvalidGatewayAccount,
+			Details: fmt.Sprintf("mode of payment '%s' is disabled", mode.Name),
+		}
+	}
+	if a, ok := mode.AccountFor(g.Company); ok && a.DefaultAccount != "" && a.DefaultAccount != g.PaymentAccount {
+		return &ValidationError{
+			Err: ErrInvalidGatewayAccount,
+			Details: fmt.Sprintf("mode of payment '%s' settles into '%s', not '%s'",
+				mode.Name, a.DefaultAccount, g.PaymentAccount),
+		}
+	}
+	return nil
+}
+
+// SetDefaultGatewayAccount makes the named account the only default among
+// those of its company, and makes the first account of a company the
+// default when none is.
+//
+// Python equivalent:
+//
+//	def update_default_payment_gateway(self):
+//	    if self.is_default:
+//	        frappe.db.sql("update `tabPayment Gateway Account` set is_default = 0 where is_default = 1")
+//
+//	def set_as_default_if_not_set(self):
+//	    if not frappe.db.get_value("Payment Gateway Account", {"is_default": 1, "name": ("!=", self.name)}, "name"):
+//	        self.is_default = 1
+func SetDefaultGatewayAccount(accounts []*PaymentGatewayAccount, name string) {
+	var company string
+	for _, g := range accounts {
+		if g.Name == name {
+			company = g.Company
+			g.IsDefault = true
+		}
+	}
+	hasDefault := map[string]bool{}
+	for _, g := range accounts {
+		if g.IsDefault && g.Name != name && g.Company == company {
+			g.IsDefault = false
+		}
+		hasDefault[g.Company] = hasDefault[g.Company] || g.IsDefault
+	}
+	for _, g := range accounts {
+		if !hasDefault[g.Company] {
+			g.IsDefault, hasDefault[g.Company] = true, true
+		}
+	}
+}
+
+// GatewayQuery describes the payment a payment request collects.
+type GatewayQuery struct {
+	Company        string
+	Currency       string
+	PaymentGateway string         // Optional; any gateway when empty
+	ModeOfPayment  string         // Optional; any mode when empty
+	PaymentChannel PaymentChannel // Optional; any channel when empty
+}
+
+// SelectGatewayAccount picks the gateway account a payment request uses:
+// the one of the company in the request's currency matching the gateway,
+// mode and channel asked for, preferring the default and then the first
+// by name. It returns ErrNoGatewayAccount when none matches, since the
+// payer cannot be charged in a currency the account does not hold.
+//
+// Maps to: get_gateway_details() and get_payment_gateway_account() in
+// payment_request.py
+func SelectGatewayAccount(accounts []*PaymentGatewayAccount, q GatewayQuery) (*PaymentGatewayAccount, error) {
+	var candidates []*PaymentGatewayAccount
+	for _, g := range accounts {
+		switch {
+		case g.Company != q.Company, !strings.EqualFold(g.Currency, q.Currency),
+			q.PaymentGateway != "" && g.PaymentGateway != q.PaymentGateway,
+			q.ModeOfPayment != "" && g.ModeOfPayment != q.ModeOfPayment,
+			q.PaymentChannel != "" && g.Channel() != q.PaymentChannel:
+			continue
+		}
+		candidates = append(candidates, g)
+	}
+	if len(candidates) == 0 {
+		return nil, &ValidationError{
+			Err:     ErrNoGatewayAccount,
+			Details: fmt.Sprintf("no payment gateway account for company '%s' in %s", q.Company, q.Currency),
+		}
+	}
+	return slices.MinFunc(candidates, func(a, b *PaymentGatewayAccount) int {
+		if a.IsDefault != b.IsDefault {
+			if a.IsDefault {
+				return -1
+			}
+			return 1
+		}
+		return strings.Compare(a.Name, b.Name)
+	}), nil
+}
@@ -0,0 +1,88 @@
+package modeofpayment
+
+import (
+	"errors"
+	"testing"
+)
+
+type mockGatewayLookup struct {
+	mockAccountLookup
+	currencies map[string]string
+}
+
+func (m *mockGatewayLookup) GetAccountCurrency(accountName string) (string, error) {
+	return m.currencies[accountName], nil
+}
+
+type mockModes map[string]*ModeOfPayment
+
+func (m mockModes) GetModeOfPayment(name string) (*ModeOfPayment, error) {
+	if mode, ok := m[name]; ok {
+		return mode, nil
+	}
+	return nil, errors.New("mode of payment not found")
+}
+
+func TestPaymentGatewayAccountValidate(t *testing.T) {
+	accounts := &mockGatewayLookup{
+		mockAccountLookup: mockAccountLookup{accounts: map[string]string{"Stripe USD - A": "Company A", "Bank - B": "Company B"}},
+		currencies:        map[string]string{"Stripe USD - A": "USD", "Bank - B": "EUR"},
+	}
+	card := &ModeOfPayment{Name: "Card", Type: Bank, Enabled: true,
+		Accounts: []ModeOfPaymentAccount{{Company: "Company A", DefaultAccount: "Stripe USD - A"}}}
+	modes := mockModes{"Card": card}
+
+	g := &PaymentGatewayAccount{PaymentGateway: "Stripe", Company: "Company A", PaymentAccount: "Stripe USD - A", ModeOfPayment: "Card"}
+	if err := g.Validate(accounts, modes); err != nil {
+		t.Fatal(err)
+	}
+	if g.Currency != "USD" || g.Name != "Stripe - USD" || g.Channel() != ChannelEmail {
+		t.Errorf("got %+v", g)
+	}
+
+	wrongCompany := &PaymentGatewayAccount{PaymentGateway: "Stripe", Company: "Company A", PaymentAccount: "Bank - B"}
+	if err := wrongCompany.Validate(accounts, modes); !errors.Is(err, ErrAccountMismatch) {
+		t.Errorf("account of another company: %v", err)
+	}
+
+	card.Accounts[0].DefaultAccount = "Cash - A"
+	if err := g.Validate(accounts, modes); !errors.Is(err, ErrInvalidGatewayAccount) {
+		t.Errorf("mode settling elsewhere: %v", err)
+	}
+	card.Accounts[0].DefaultAccount, card.Enabled = "Stripe USD - A", false
+	if err := g.Validate(accounts, modes); !errors.Is(err, ErrInvalidGatewayAccount) {
+		t.Errorf("disabled mode: %v", err)
+	}
+}
+
+func TestSelectGatewayAccount(t *testing.T) {
+	accounts := []*PaymentGatewayAccount{
+		{Name: "Razorpay - INR", PaymentGateway: "Razorpay", Company: "A", Currency: "INR", ModeOfPayment: "UPI", PaymentChannel: ChannelPhone},
+		{Name: "Stripe - INR", PaymentGateway: "Stripe", Company: "A", Currency: "INR", ModeOfPayment: "Card"},
+		{Name: "Stripe - USD", PaymentGateway: "Stripe", Company: "A", Currency: "USD", ModeOfPayment: "Card"},
+		{Name: "PayPal - USD", PaymentGateway: "PayPal", Company: "B", Currency: "USD"},
+	}
+	SetDefaultGatewayAccount(accounts, "Stripe - INR")
+	if !accounts[1].IsDefault || accounts[0].IsDefault || !accounts[3].IsDefault {
+		t.Fatalf("defaults: %v %v %v %v", accounts[0].IsDefault, accounts[1].IsDefault, accounts[2].IsDefault, accounts[3].IsDefault)
+	}
+
+	for _, tc := range []struct {
+		q    GatewayQuery
+		want string
+	}{
+		{GatewayQuery{Company: "A", Currency: "INR"}, "Stripe - INR"},
+		{GatewayQuery{Company: "A", Currency: "INR", ModeOfPayment: "UPI"}, "Razorpay - INR"},
+		{GatewayQuery{Company: "A", Currency: "INR", PaymentChannel: ChannelPhone}, "Razorpay - INR"},
+		{GatewayQuery{Company: "A", Currency: "usd"}, "Stripe - USD"},
+		{GatewayQuery{Company: "B", Currency: "USD", PaymentGateway: "PayPal"}, "PayPal - USD"},
+	} {
+		g, err := SelectGatewayAccount(accounts, tc.q)
+		if err != nil || g.Name != tc.want {
+			t.Errorf("SelectGatewayAccount(%+v) = %v, %v; want %s", tc.q, g, err, tc.want)
+		}
+	}
+	if _, err := SelectGatewayAccount(accounts, GatewayQuery{Company: "B", Currency: "EUR"}); !errors.Is(err, ErrNoGatewayAccount) {
+		t.Errorf("no EUR account: %v", err)
+	}
+}
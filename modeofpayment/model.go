@@ -17,6 +17,8 @@ const (
 type ModeOfPaymentAccount struct {
 	Company        string
 	DefaultAccount string
+	FeeAccount     string // Expense account for the mode's fee; required when it has one
+	FeeCostCenter  string // Optional cost center for the fee
 }
 
 // ModeOfPayment represents a payment method master record.
@@ -26,6 +28,7 @@ type ModeOfPayment struct {
 	Type     PaymentType
 	Enabled  bool
 	Accounts []ModeOfPaymentAccount
+	Fee      Fee // Processing fee withheld from payments; none when zero
 }
 
 // AccountLookup abstracts database queries for account information.
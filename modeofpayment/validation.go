@@ -8,9 +8,14 @@ import (
 
 // Validation errors matching ERPNext's frappe.throw() messages.
 var (
-	ErrDuplicateCompany = errors.New("same company is entered more than once")
-	ErrAccountMismatch  = errors.New("account does not match with company")
-	ErrModeInUse        = errors.New("mode of payment is used in POS profiles")
+	ErrDuplicateCompany  = errors.New("same company is entered more than once")
+	ErrAccountMismatch   = errors.New("account does not match with company")
+	ErrModeInUse         = errors.New("mode of payment is used in POS profiles")
+	ErrInvalidFee        = errors.New("invalid mode of payment fee")
+	ErrMissingFeeAccount = errors.New("fee account is mandatory for mode of payment with a fee")
+
+	ErrInvalidGatewayAccount = errors.New("invalid payment gateway account")
+	ErrNoGatewayAccount      = errors.New("no payment gateway account found")
 )
 
 // ValidationError provides detailed error information.
@@ -126,5 +131,8 @@ func (m *ModeOfPayment) Validate(lookup AccountLookup, checker POSChecker) error
 	if err := m.ValidatePOSModeOfPayment(checker); err != nil {
 		return err
 	}
+	if err := m.ValidateFee(); err != nil {
+		return err
+	}
 	return nil
 }
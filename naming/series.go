@@ -0,0 +1,271 @@
+// Package naming generates document names from naming series.
+//
+// A naming series is a pattern such as "SINV-.YY.-.####": the parts
+// between dots are either literals, date or document tokens, or a run of
+// hashes that is replaced by the next value of a counter. Counters are kept
+// per prefix, the name produced so far, so "SINV-24-" and "SINV-25-" count
+// independently.
+//
+// Migrated from: frappe/model/naming.py (make_autoname, parse_naming_series,
+// getseries, _get_amended_name)
+// Maps to: tabSeries (name, current)
+package naming
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Naming errors
+var (
+	ErrInvalidSeries  = errors.New("invalid naming series")
+	ErrUnknownSeries  = errors.New("naming series is not registered for doctype")
+	ErrNoSeries       = errors.New("doctype has no naming series")
+	ErrInvalidCurrent = errors.New("series current value cannot be negative")
+)
+
+// DefaultDigits is the counter width used when a series has no hashes.
+const DefaultDigits = 5
+
+// Store persists series counters.
+//
+// Next must be atomic: two concurrent callers for the same prefix never
+// receive the same value. A database store runs it as
+// "UPDATE tabSeries SET current = current + 1 WHERE name = ?" (inserting
+// the row if missing) and reads the value back in the same transaction.
+type Store interface {
+	// Next increments the prefix's counter and returns the new value.
+	// Counters start at zero, so the first value is 1.
+	Next(prefix string) (int, error)
+
+	// Current returns the last value handed out, or zero.
+	Current(prefix string) (int, error)
+
+	// SetCurrent resets the counter; the next name uses current + 1.
+	SetCurrent(prefix string, current int) error
+}
+
+// Defaults are ERPNext's default series for the doctypes in this module.
+var Defaults = map[string]string{
+	"GL Entry":             "ACC-GLE-.YYYY.-.#####",
+	"Payment Ledger Entry": "ACC-PLE-.YYYY.-.#####",
+	"Sales Invoice":        "ACC-SINV-.YYYY.-",
+	"POS Invoice":          "ACC-PSINV-.YYYY.-",
+	"Payment Entry":        "ACC-PAY-.YYYY.-",
+	"Journal Entry":        "ACC-JV-.YYYY.-",
+	"Purchase Invoice":     "ACC-PINV-.YYYY.-",
+}
+
+// Options supplies the values a series may reference.
+type Options struct {
+	Series string    // Pattern to use; the doctype's default when empty
+	Date   time.Time // Resolves YY, YYYY, MM, DD; usually the posting date
+
+	// Values resolves any other part by name, e.g. "FY" (fiscal year),
+	// "ABBR" (company abbreviation) or a document field.
+	Values map[string]string
+}
+
+// Registry holds the series allowed for each doctype and hands out names.
+// It is safe for concurrent use; counters are serialised by the Store.
+type Registry struct {
+	store Store
+
+	mu     sync.RWMutex
+	series map[string][]string // Doctype -> patterns; the first is the default
+}
+
+// NewRegistry creates an empty registry over a counter store.
+func NewRegistry(store Store) *Registry {
+	return &Registry{store: store, series: make(map[string][]string)}
+}
+
+// Register sets the series a doctype may use. The first is the default.
+func (r *Registry) Register(doctype string, patterns ...string) error {
+	if len(patterns) == 0 {
+		return fmt.Errorf("%w: %s", ErrNoSeries, doctype)
+	}
+	normalized := make([]string, len(patterns))
+	for i, p := range patterns {
+		n, err := Normalize(p)
+		if err != nil {
+			return err
+		}
+		normalized[i] = n
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.series[doctype] = normalized
+	return nil
+}
+
+// RegisterDefaults registers the ERPNext default series.
+func (r *Registry) RegisterDefaults() error {
+	for doctype, pattern := range Defaults {
+		if err := r.Register(doctype, pattern); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Series returns the patterns registered for a doctype.
+func (r *Registry) Series(doctype string) []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]string(nil), r.series[doctype]...)
+}
+
+// NewName returns the next name for a doctype.
+func (r *Registry) NewName(doctype string, opts Options) (string, error) {
+	pattern, err := r.pattern(doctype, opts.Series)
+	if err != nil {
+		return "", err
+	}
+	return r.parse(pattern, opts, func(prefix string) (int, error) {
+		return r.store.Next(prefix)
+	})
+}
+
+// Prefix returns the counter prefix a new name would use, e.g. "SINV-24-"
+// for "SINV-.YY.-.####" in 2024. It is the key for Current and SetCurrent.
+func (r *Registry) Prefix(doctype string, opts Options) (string, error) {
+	pattern, err := r.pattern(doctype, opts.Series)
+	if err != nil {
+		return "", err
+	}
+	prefix := ""
+	_, err = r.parse(pattern, opts, func(p string) (int, error) {
+		if prefix == "" {
+			prefix = p
+		}
+		return 0, nil
+	})
+	return prefix, err
+}
+
+// Current returns the last value handed out for a prefix.
+func (r *Registry) Current(prefix string) (int, error) {
+	return r.store.Current(prefix)
+}
+
+// SetCurrent resets a prefix's counter, e.g. to continue numbering after a
+// migration. The next name uses current + 1.
+func (r *Registry) SetCurrent(prefix string, current int) error {
+	if current < 0 {
+		return fmt.Errorf("%w: %s", ErrInvalidCurrent, prefix)
+	}
+	return r.store.SetCurrent(prefix, current)
+}
+
+func (r *Registry) pattern(doctype, series string) (string, error) {
+	r.mu.RLock()
+	patterns := r.series[doctype]
+	r.mu.RUnlock()
+
+	if len(patterns) == 0 {
+		return "", fmt.Errorf("%w: %s", ErrNoSeries, doctype)
+	}
+	if series == "" {
+		return patterns[0], nil
+	}
+	normalized, err := Normalize(series)
+	if err != nil {
+		return "", err
+	}
+	for _, p := range patterns {
+		if p == normalized {
+			return p, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %s is not a series of %s", ErrUnknownSeries, series, doctype)
+}
+
+// parse builds a name from a normalized pattern, calling next for each run
+// of hashes with the name built so far.
+//
+// Python equivalent:
+//
+//	def parse_naming_series(parts, doc=None):
+//	    for e in parts:
+//	        if e.startswith("#"):
+//	            part = getseries(n, len(e))
+//	        elif e == "YY":
+//	            part = today.strftime("%y")
+//	        ...
+//	        n += part
+func (r *Registry) parse(pattern string, opts Options, next func(prefix string) (int, error)) (string, error) {
+	var name strings.Builder
+	for _, part := range strings.Split(pattern, ".") {
+		switch {
+		case strings.HasPrefix(part, "#"):
+			value, err := next(name.String())
+			if err != nil {
+				return "", err
+			}
+			fmt.Fprintf(&name, "%0*d", len(part), value)
+		case part == "YY":
+			name.WriteString(opts.Date.Format("06"))
+		case part == "YYYY":
+			name.WriteString(opts.Date.Format("2006"))
+		case part == "MM":
+			name.WriteString(opts.Date.Format("01"))
+		case part == "DD":
+			name.WriteString(opts.Date.Format("02"))
+		default:
+			if v, ok := opts.Values[part]; ok {
+				name.WriteString(v)
+			} else {
+				name.WriteString(part)
+			}
+		}
+	}
+	return name.String(), nil
+}
+
+// Normalize validates a series and appends the default counter when it has
+// none, so "SINV-.YY.-" becomes "SINV-.YY.-.#####".
+func Normalize(series string) (string, error) {
+	if series == "" {
+		return "", fmt.Errorf("%w: empty", ErrInvalidSeries)
+	}
+	if !strings.Contains(series, "#") {
+		series = strings.TrimSuffix(series, ".") + "." + strings.Repeat("#", DefaultDigits)
+	} else if !strings.Contains(series, ".") {
+		return "", fmt.Errorf("%w: %s (. missing)", ErrInvalidSeries, series)
+	}
+	for _, part := range strings.Split(series, ".") {
+		if strings.Contains(part, "#") && strings.Trim(part, "#") != "" {
+			return "", fmt.Errorf("%w: %s (hashes mixed with text)", ErrInvalidSeries, series)
+		}
+	}
+	return series, nil
+}
+
+// AmendedName returns the name of an amendment of a cancelled document:
+// "SINV-0001" becomes "SINV-0001-1", and amending "SINV-0001-1" (itself an
+// amendment) gives "SINV-0001-2".
+//
+// Python equivalent:
+//
+//	def _get_amended_name(doc):
+//	    am_id = 1
+//	    am_prefix = doc.amended_from
+//	    if frappe.db.get_value(doc.doctype, doc.amended_from, "amended_from"):
+//	        am_id = cint(doc.amended_from.split("-")[-1]) + 1
+//	        am_prefix = "-".join(doc.amended_from.split("-")[:-1])
+//	    return am_prefix + "-" + str(am_id)
+func AmendedName(amendedFrom string, isAmendment bool) string {
+	if isAmendment {
+		if i := strings.LastIndex(amendedFrom, "-"); i >= 0 {
+			id, _ := strconv.Atoi(amendedFrom[i+1:])
+			return fmt.Sprintf("%s-%d", amendedFrom[:i], id+1)
+		}
+	}
+	return amendedFrom + "-1"
+}
@@ -0,0 +1,191 @@
+package naming
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/ledger/memstore"
+)
+
+func newRegistry(t *testing.T) *Registry {
+	t.Helper()
+	r := NewRegistry(memstore.NewSeries())
+	if err := r.Register("Sales Invoice", "SINV-.YY.-", "SINV-RET-.YYYY.-.MM.-.###"); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Register("Payment Entry", "PE-.####"); err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func TestNewName(t *testing.T) {
+	r := newRegistry(t)
+	date := time.Date(2024, 4, 15, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		doctype string
+		opts    Options
+		want    string
+	}{
+		{"Sales Invoice", Options{Date: date}, "SINV-24-00001"},
+		{"Sales Invoice", Options{Date: date}, "SINV-24-00002"},
+		{"Sales Invoice", Options{Date: date.AddDate(1, 0, 0)}, "SINV-25-00001"},
+		{"Sales Invoice", Options{Series: "SINV-RET-.YYYY.-.MM.-.###", Date: date}, "SINV-RET-2024-04-001"},
+		{"Payment Entry", Options{}, "PE-0001"},
+		{"Payment Entry", Options{}, "PE-0002"},
+	}
+	for _, tt := range tests {
+		got, err := r.NewName(tt.doctype, tt.opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("NewName(%s) = %s, want %s", tt.doctype, got, tt.want)
+		}
+	}
+}
+
+func TestNewNameValues(t *testing.T) {
+	r := NewRegistry(memstore.NewSeries())
+	if err := r.Register("Journal Entry", "JV-.ABBR.-.FY.-.###"); err != nil {
+		t.Fatal(err)
+	}
+	got, err := r.NewName("Journal Entry", Options{Values: map[string]string{"ABBR": "AC", "FY": "2024-2025"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "JV-AC-2024-2025-001" {
+		t.Errorf("got %s", got)
+	}
+}
+
+func TestNewNameErrors(t *testing.T) {
+	r := newRegistry(t)
+	if _, err := r.NewName("Journal Entry", Options{}); !errors.Is(err, ErrNoSeries) {
+		t.Errorf("unregistered doctype: got %v", err)
+	}
+	if _, err := r.NewName("Payment Entry", Options{Series: "PAY-.####"}); !errors.Is(err, ErrUnknownSeries) {
+		t.Errorf("foreign series: got %v", err)
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		series string
+		want   string
+		err    bool
+	}{
+		{"SINV-.YY.-", "SINV-.YY.-.#####", false},
+		{"SINV-", "SINV-.#####", false},
+		{"PE-.####", "PE-.####", false},
+		{"PE-####", "", true},
+		{"PE-.##A#", "", true},
+		{"", "", true},
+	}
+	for _, tt := range tests {
+		got, err := Normalize(tt.series)
+		if (err != nil) != tt.err {
+			t.Errorf("Normalize(%q) error = %v", tt.series, err)
+			continue
+		}
+		if err != nil && !errors.Is(err, ErrInvalidSeries) {
+			t.Errorf("Normalize(%q) error = %v, want ErrInvalidSeries", tt.series, err)
+		}
+		if got != tt.want {
+			t.Errorf("Normalize(%q) = %q, want %q", tt.series, got, tt.want)
+		}
+	}
+}
+
+func TestCurrentValue(t *testing.T) {
+	r := newRegistry(t)
+	date := time.Date(2024, 4, 15, 0, 0, 0, 0, time.UTC)
+
+	prefix, err := r.Prefix("Sales Invoice", Options{Date: date})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if prefix != "SINV-24-" {
+		t.Fatalf("Prefix = %q", prefix)
+	}
+	if err := r.SetCurrent(prefix, 41); err != nil {
+		t.Fatal(err)
+	}
+	name, _ := r.NewName("Sales Invoice", Options{Date: date})
+	if name != "SINV-24-00042" {
+		t.Errorf("after SetCurrent got %s", name)
+	}
+	if current, _ := r.Current(prefix); current != 42 {
+		t.Errorf("Current = %d, want 42", current)
+	}
+	if err := r.SetCurrent(prefix, -1); !errors.Is(err, ErrInvalidCurrent) {
+		t.Errorf("negative current: got %v", err)
+	}
+}
+
+func TestNewNameConcurrent(t *testing.T) {
+	r := newRegistry(t)
+	const n = 100
+
+	var wg sync.WaitGroup
+	names := make(chan string, n)
+	for range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			name, err := r.NewName("Payment Entry", Options{})
+			if err != nil {
+				t.Error(err)
+			}
+			names <- name
+		}()
+	}
+	wg.Wait()
+	close(names)
+
+	seen := make(map[string]bool)
+	for name := range names {
+		if seen[name] {
+			t.Errorf("duplicate name %s", name)
+		}
+		seen[name] = true
+	}
+	if current, _ := r.Current("PE-"); current != n {
+		t.Errorf("Current = %d, want %d", current, n)
+	}
+}
+
+func TestAmendedName(t *testing.T) {
+	tests := []struct {
+		from        string
+		isAmendment bool
+		want        string
+	}{
+		{"SINV-24-00001", false, "SINV-24-00001-1"},
+		{"SINV-24-00001-1", true, "SINV-24-00001-2"},
+		{"SINV-24-00001-9", true, "SINV-24-00001-10"},
+		{"PE-0001", false, "PE-0001-1"},
+	}
+	for _, tt := range tests {
+		if got := AmendedName(tt.from, tt.isAmendment); got != tt.want {
+			t.Errorf("AmendedName(%q, %v) = %q, want %q", tt.from, tt.isAmendment, got, tt.want)
+		}
+	}
+}
+
+func TestRegisterDefaults(t *testing.T) {
+	r := NewRegistry(memstore.NewSeries())
+	if err := r.RegisterDefaults(); err != nil {
+		t.Fatal(err)
+	}
+	name, err := r.NewName("Sales Invoice", Options{Date: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name != "ACC-SINV-2024-00001" {
+		t.Errorf("got %s", name)
+	}
+}
@@ -0,0 +1,92 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/senguttuvang/erpnext-go/autoreport"
+)
+
+// Email sends notifications to the rule's recipients.
+type Email struct {
+	Mailer autoreport.Mailer
+}
+
+// Deliver implements Channel.
+func (c Email) Deliver(ctx context.Context, n Notification) error {
+	if len(n.Recipients) == 0 {
+		return autoreport.ErrNoRecipients
+	}
+	return c.Mailer.Send(ctx, autoreport.Message{To: n.Recipients, Subject: n.Subject, Body: n.Message})
+}
+
+// Webhook posts notifications as JSON to a URL.
+type Webhook struct {
+	URL    string
+	Client *http.Client // http.DefaultClient when nil
+}
+
+// webhookPayload is the JSON body posted by Webhook.
+type webhookPayload struct {
+	Rule        string  `json:"rule"`
+	Subject     string  `json:"subject"`
+	Message     string  `json:"message"`
+	Event       string  `json:"event"`
+	Company     string  `json:"company,omitempty"`
+	VoucherType string  `json:"voucher_type,omitempty"`
+	VoucherNo   string  `json:"voucher_no,omitempty"`
+	Amount      float64 `json:"amount"`
+}
+
+// Deliver implements Channel.
+func (c Webhook) Deliver(ctx context.Context, n Notification) error {
+	return postJSON(ctx, c.Client, c.URL, webhookPayload{
+		Rule: n.Rule, Subject: n.Subject, Message: n.Message,
+		Event: n.Event.Type, Company: n.Event.Company,
+		VoucherType: n.Event.VoucherType, VoucherNo: n.Event.VoucherNo, Amount: n.Event.Amount,
+	})
+}
+
+// Slack posts notifications to a Slack incoming webhook.
+//
+// Maps to: Slack Webhook URL channel in notification.py
+type Slack struct {
+	WebhookURL string
+	Client     *http.Client // http.DefaultClient when nil
+}
+
+// Deliver implements Channel.
+func (c Slack) Deliver(ctx context.Context, n Notification) error {
+	text := "*" + n.Subject + "*"
+	if n.Message != "" {
+		text += "\n" + n.Message
+	}
+	return postJSON(ctx, c.Client, c.WebhookURL, map[string]string{"text": text})
+}
+
+func postJSON(ctx context.Context, client *http.Client, url string, body any) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("POST %s: %s", url, resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,250 @@
+// Package notifications sends alerts when accounting events match rules.
+//
+// A Notifier subscribes to the ledger's EventPublisher. Each Rule names an
+// event type, a condition on the event, subject and message templates and
+// the channels to send through. Channels are pluggable: email, a generic
+// webhook and Slack are provided.
+//
+// Migrated from: frappe/email/doctype/notification/notification.py
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/ledger"
+	"github.com/senguttuvang/erpnext-go/party"
+)
+
+// Notification errors
+var (
+	ErrUnknownChannel = errors.New("notification channel not found")
+	ErrInvalidRule    = errors.New("invalid notification rule")
+)
+
+// Rule decides which events notify whom.
+//
+// Maps to: Notification doctype (event, condition, subject, message,
+// channel, recipients)
+type Rule struct {
+	Name      string
+	EventType string                  // ledger.EventGLPosted etc.
+	Condition func(ledger.Event) bool // Optional; every event of the type matches when nil
+
+	// Subject and Message are text/template sources executed with the
+	// ledger.Event, e.g. "{{.VoucherType}} {{.VoucherNo}} posted".
+	Subject string
+	Message string
+
+	Channels   []string // Channel names
+	Recipients []string // Addresses for channels that need them (email)
+	Disabled   bool
+}
+
+// Matches reports whether an event triggers the rule.
+func (r Rule) Matches(event ledger.Event) bool {
+	if r.Disabled || event.Type != r.EventType {
+		return false
+	}
+	return r.Condition == nil || r.Condition(event)
+}
+
+// Notification is a rendered alert handed to a channel.
+type Notification struct {
+	Rule       string
+	Subject    string
+	Message    string
+	Recipients []string
+	Event      ledger.Event
+}
+
+// Channel delivers notifications.
+type Channel interface {
+	Deliver(ctx context.Context, n Notification) error
+}
+
+// Notifier evaluates rules against events and dispatches the matches.
+type Notifier struct {
+	Rules    []Rule
+	Channels map[string]Channel // Channel name -> channel
+	OnError  func(error)        // Optional; receives delivery errors from Publish
+
+	wg sync.WaitGroup
+}
+
+// Publish implements ledger.EventPublisher, so a Notifier can be set as
+// Engine.Events or subscribed to an EventBus. Notifications are sent in
+// the background, so a slow channel does not hold up the posting that
+// raised the event; Wait blocks until they are sent.
+func (n *Notifier) Publish(event ledger.Event) {
+	n.wg.Add(1)
+	go func() {
+		defer n.wg.Done()
+		if err := n.Handle(context.Background(), event); err != nil && n.OnError != nil {
+			n.OnError(err)
+		}
+	}()
+}
+
+// Wait blocks until background notifications are sent.
+func (n *Notifier) Wait() {
+	n.wg.Wait()
+}
+
+// Handle sends every notification the event triggers. Errors are collected;
+// one failing channel does not stop the others.
+//
+// Maps to: evaluate_alert() in notification.py
+func (n *Notifier) Handle(ctx context.Context, event ledger.Event) error {
+	var errs []error
+	for _, rule := range n.Rules {
+		if !rule.Matches(event) {
+			continue
+		}
+		notification, err := rule.render(event)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for _, name := range rule.Channels {
+			channel, ok := n.Channels[name]
+			if !ok {
+				errs = append(errs, fmt.Errorf("%s: %w: %s", rule.Name, ErrUnknownChannel, name))
+				continue
+			}
+			if err := channel.Deliver(ctx, notification); err != nil {
+				errs = append(errs, fmt.Errorf("%s via %s: %w", rule.Name, name, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (r Rule) render(event ledger.Event) (Notification, error) {
+	subject, err := execute(r.Name+" subject", r.Subject, event)
+	if err != nil {
+		return Notification{}, err
+	}
+	message, err := execute(r.Name+" message", r.Message, event)
+	if err != nil {
+		return Notification{}, err
+	}
+	return Notification{
+		Rule: r.Name, Subject: subject, Message: message,
+		Recipients: r.Recipients, Event: event,
+	}, nil
+}
+
+func execute(name, text string, event ledger.Event) (string, error) {
+	tmpl, err := template.New(name).Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("%w: %s: %v", ErrInvalidRule, name, err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, event); err != nil {
+		return "", fmt.Errorf("%w: %s: %v", ErrInvalidRule, name, err)
+	}
+	return buf.String(), nil
+}
+
+// Built-in rules
+
+// LargeJournal notifies when a Journal Entry of at least amount is posted.
+func LargeJournal(amount float64, channels ...string) Rule {
+	return Rule{
+		Name:      "Large Journal Entry",
+		EventType: ledger.EventGLPosted,
+		Condition: func(e ledger.Event) bool {
+			return e.VoucherType == "Journal Entry" && e.Amount >= amount
+		},
+		Subject:  "Large journal {{.VoucherNo}} posted in {{.Company}}",
+		Message:  `Journal Entry {{.VoucherNo}} for {{printf "%.2f" .Amount}} was posted on {{.PostingDate.Format "2006-01-02"}}.`,
+		Channels: channels,
+	}
+}
+
+// InvoiceOverdue notifies when an invoice is unpaid more than days past
+// its due date. The events come from OverdueInvoices, run daily.
+func InvoiceOverdue(days int, channels ...string) Rule {
+	return Rule{
+		Name:      "Invoice Overdue",
+		EventType: ledger.EventInvoiceOverdue,
+		Condition: func(e ledger.Event) bool {
+			overdue, _ := e.Data["days_overdue"].(int)
+			return overdue > days
+		},
+		Subject:  "{{.VoucherType}} {{.VoucherNo}} is overdue",
+		Message:  `{{.VoucherType}} {{.VoucherNo}} for {{.Party}} has {{printf "%.2f" .Amount}} outstanding, {{index .Data "days_overdue"}} days past due.`,
+		Channels: channels,
+	}
+}
+
+// BudgetConsumed notifies when spending reaches ratio (0.8 for 80%) of a
+// budget. The events come from the budget controller via BudgetUsage.
+func BudgetConsumed(ratio float64, channels ...string) Rule {
+	return Rule{
+		Name:      "Budget Consumed",
+		EventType: ledger.EventBudgetUsage,
+		Condition: func(e ledger.Event) bool {
+			budget, _ := e.Data["budget"].(float64)
+			return budget > 0 && e.Amount/budget >= ratio
+		},
+		Subject:  `Budget for {{index .Data "account"}} at {{printf "%.0f" (index .Data "percent")}}%`,
+		Message:  `{{printf "%.2f" .Amount}} of {{printf "%.2f" (index .Data "budget")}} spent on {{index .Data "account"}} ({{index .Data "budget_against"}}).`,
+		Channels: channels,
+	}
+}
+
+// BudgetUsage builds a budget.usage event for spending against a budget.
+func BudgetUsage(company, account, budgetAgainst string, budget, actual float64) ledger.Event {
+	percent := 0.0
+	if budget > 0 {
+		percent = ledger.Flt(actual/budget*100, 2)
+	}
+	return ledger.Event{
+		Type: ledger.EventBudgetUsage, Time: time.Now(), Company: company, Amount: actual,
+		Data: map[string]any{
+			"account": account, "budget_against": budgetAgainst,
+			"budget": budget, "percent": percent,
+		},
+	}
+}
+
+// OverdueInvoices returns an invoice.overdue event for each invoice with
+// an outstanding balance whose due date is before asOf. Outstanding is the
+// sum of non-delinked payment ledger entries against the invoice; advances
+// and overpayments never count as overdue.
+//
+// Maps to: the daily "Days After" evaluation in notification.py
+// (trigger_daily_alerts) over Sales Invoice due_date
+func OverdueInvoices(entries []ledger.PaymentLedgerEntry, asOf time.Time) []ledger.Event {
+	events, _ := OverdueInvoicesByPolicy(entries, asOf, nil) // Without policies there is nothing to fail
+	return events
+}
+
+// OverdueInvoicesByPolicy is OverdueInvoices with the grace days and
+// interest of each party's overdue policy. Events carry the interest
+// accrued as of asOf in Data["interest"].
+func OverdueInvoicesByPolicy(entries []ledger.PaymentLedgerEntry, asOf time.Time, policies party.OverduePolicies) ([]ledger.Event, error) {
+	overdue, err := party.OverdueInvoices(entries, asOf, policies)
+	if err != nil {
+		return nil, err
+	}
+	var events []ledger.Event
+	for _, o := range overdue {
+		inv := o.Invoice
+		events = append(events, ledger.Event{
+			Type: ledger.EventInvoiceOverdue, Time: asOf, Company: inv.Company,
+			VoucherType: inv.VoucherType, VoucherNo: inv.VoucherNo,
+			PostingDate: inv.PostingDate, PartyType: inv.PartyType, Party: inv.Party,
+			Amount: o.Outstanding,
+			Data:   map[string]any{"days_overdue": o.DaysOverdue, "due_date": *inv.DueDate, "interest": o.Interest},
+		})
+	}
+	return events, nil
+}
@@ -0,0 +1,231 @@
+package notifications
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/autoreport"
+	"github.com/senguttuvang/erpnext-go/ledger"
+	"github.com/senguttuvang/erpnext-go/ledger/memstore"
+	"github.com/senguttuvang/erpnext-go/party"
+)
+
+type recorder struct {
+	mu   sync.Mutex
+	sent []Notification
+}
+
+func (r *recorder) Deliver(ctx context.Context, n Notification) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sent = append(r.sent, n)
+	return nil
+}
+
+// stalled holds every delivery until it is released.
+type stalled struct{ release chan struct{} }
+
+func (s stalled) Deliver(ctx context.Context, n Notification) error {
+	<-s.release
+	return nil
+}
+
+type mailer struct{ sent []autoreport.Message }
+
+func (m *mailer) Send(ctx context.Context, msg autoreport.Message) error {
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+func date(s string) time.Time {
+	t, _ := time.Parse(ledger.DateLayout, s)
+	return t
+}
+
+func journal(no string, amount float64) []ledger.GLEntry {
+	return []ledger.GLEntry{
+		{Company: "ABC", Account: "Expenses - ABC", Debit: amount, DebitInAccountCurrency: amount,
+			VoucherType: "Journal Entry", VoucherNo: no, PostingDate: date("2026-03-01")},
+		{Company: "ABC", Account: "Cash - ABC", Credit: amount, CreditInAccountCurrency: amount,
+			VoucherType: "Journal Entry", VoucherNo: no, PostingDate: date("2026-03-01")},
+	}
+}
+
+func TestLargeJournalFromEngine(t *testing.T) {
+	rec := &recorder{}
+	bus := &ledger.EventBus{}
+	notifier := &Notifier{
+		Rules:    []Rule{LargeJournal(50000, "audit")},
+		Channels: map[string]Channel{"audit": rec},
+		OnError:  func(err error) { t.Error(err) },
+	}
+	bus.Subscribe(notifier.Publish)
+	engine := &ledger.Engine{GLStore: memstore.NewGLStore(), Events: bus}
+
+	for no, amount := range map[string]float64{"JV-001": 1000, "JV-002": 75000} {
+		if err := engine.MakeGLEntries(journal(no, amount), ledger.DefaultPostingOptions()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	notifier.Wait()
+	if len(rec.sent) != 1 {
+		t.Fatalf("sent %d notifications, want 1", len(rec.sent))
+	}
+	n := rec.sent[0]
+	if n.Subject != "Large journal JV-002 posted in ABC" {
+		t.Errorf("subject = %q", n.Subject)
+	}
+	if n.Message != "Journal Entry JV-002 for 75000.00 was posted on 2026-03-01." {
+		t.Errorf("message = %q", n.Message)
+	}
+
+	cancel := ledger.DefaultPostingOptions()
+	cancel.Cancel = true
+	if err := engine.MakeGLEntries(journal("JV-002", 75000), cancel); err != nil {
+		t.Fatal(err)
+	}
+	notifier.Wait()
+	if len(rec.sent) != 1 {
+		t.Errorf("cancellation notified: %d", len(rec.sent))
+	}
+}
+
+func TestPublishDoesNotWait(t *testing.T) {
+	slow := stalled{release: make(chan struct{})}
+	notifier := &Notifier{
+		Rules:    []Rule{LargeJournal(50000, "slow")},
+		Channels: map[string]Channel{"slow": slow},
+		OnError:  func(err error) { t.Error(err) },
+	}
+	engine := &ledger.Engine{GLStore: memstore.NewGLStore(), Events: notifier}
+	// Posting returns while the channel is still delivering
+	if err := engine.MakeGLEntries(journal("JV-003", 90000), ledger.DefaultPostingOptions()); err != nil {
+		t.Fatal(err)
+	}
+	close(slow.release)
+	notifier.Wait()
+}
+
+func TestInvoiceOverdue(t *testing.T) {
+	due := date("2026-01-31")
+	entries := []ledger.PaymentLedgerEntry{
+		{Company: "ABC", PartyType: "Customer", Party: "Acme", VoucherType: "Sales Invoice", VoucherNo: "SINV-1",
+			AgainstVoucherType: "Sales Invoice", AgainstVoucherNo: "SINV-1", Amount: 1000, DueDate: &due},
+		{Company: "ABC", PartyType: "Customer", Party: "Acme", VoucherType: "Payment Entry", VoucherNo: "PE-1",
+			AgainstVoucherType: "Sales Invoice", AgainstVoucherNo: "SINV-1", Amount: -400},
+		// Fully paid
+		{Company: "ABC", PartyType: "Customer", Party: "Beta", VoucherType: "Sales Invoice", VoucherNo: "SINV-2",
+			AgainstVoucherType: "Sales Invoice", AgainstVoucherNo: "SINV-2", Amount: 500, DueDate: &due},
+		{Company: "ABC", PartyType: "Customer", Party: "Beta", VoucherType: "Payment Entry", VoucherNo: "PE-2",
+			AgainstVoucherType: "Sales Invoice", AgainstVoucherNo: "SINV-2", Amount: -500},
+		// Supplier invoice, credit balance
+		{Company: "ABC", PartyType: "Supplier", Party: "Gamma", VoucherType: "Purchase Invoice", VoucherNo: "PINV-1",
+			AgainstVoucherType: "Purchase Invoice", AgainstVoucherNo: "PINV-1", Amount: -800, DueDate: &due},
+	}
+
+	events := OverdueInvoices(entries, date("2026-03-02"))
+	if len(events) != 2 {
+		t.Fatalf("got %d events, want 2", len(events))
+	}
+	if e := events[0]; e.VoucherNo != "SINV-1" || e.Amount != 600 || e.Data["days_overdue"] != 30 {
+		t.Errorf("SINV-1 event = %+v", e)
+	}
+	if e := events[1]; e.VoucherNo != "PINV-1" || e.Amount != 800 {
+		t.Errorf("PINV-1 event = %+v", e)
+	}
+
+	m := &mailer{}
+	rule := InvoiceOverdue(29, "email")
+	rule.Recipients = []string{"ar@abc.test"}
+	n := &Notifier{Rules: []Rule{rule}, Channels: map[string]Channel{"email": Email{Mailer: m}}}
+	for _, e := range OverdueInvoices(entries, date("2026-03-01")) {
+		if err := n.Handle(context.Background(), e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(m.sent) != 0 {
+		t.Errorf("29 days overdue notified: %+v", m.sent)
+	}
+	for _, e := range events {
+		if err := n.Handle(context.Background(), e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(m.sent) != 2 || m.sent[0].Body != "Sales Invoice SINV-1 for Acme has 600.00 outstanding, 30 days past due." {
+		t.Errorf("sent = %+v", m.sent)
+	}
+
+	// Suppliers get 45 grace days, customers pay 12% after 10 days
+	policies := policyByType{
+		party.Customer: {GraceDays: 10, InterestRate: 12},
+		party.Supplier: {GraceDays: 45},
+	}
+	events, err := OverdueInvoicesByPolicy(entries, date("2026-03-02"), policies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 || events[0].VoucherNo != "SINV-1" || events[0].Data["interest"] != 3.95 {
+		t.Errorf("events by policy = %+v", events)
+	}
+}
+
+type policyByType map[party.PartyType]*party.OverduePolicy
+
+func (p policyByType) OverduePolicy(partyType party.PartyType, _ string) (*party.OverduePolicy, error) {
+	return p[partyType], nil
+}
+
+func TestBudgetConsumedSlack(t *testing.T) {
+	var texts []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]string
+		json.NewDecoder(r.Body).Decode(&body)
+		texts = append(texts, body["text"])
+	}))
+	defer srv.Close()
+
+	n := &Notifier{
+		Rules:    []Rule{BudgetConsumed(0.8, "slack")},
+		Channels: map[string]Channel{"slack": Slack{WebhookURL: srv.URL}},
+	}
+	ctx := context.Background()
+	if err := n.Handle(ctx, BudgetUsage("ABC", "Travel - ABC", "Sales - ABC", 10000, 7000)); err != nil {
+		t.Fatal(err)
+	}
+	if err := n.Handle(ctx, BudgetUsage("ABC", "Travel - ABC", "Sales - ABC", 10000, 8500)); err != nil {
+		t.Fatal(err)
+	}
+	if len(texts) != 1 || !strings.HasPrefix(texts[0], "*Budget for Travel - ABC at 85%*\n8500.00 of 10000.00") {
+		t.Errorf("slack texts = %q", texts)
+	}
+}
+
+func TestNotifierErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	rec := &recorder{}
+	n := &Notifier{
+		Rules: []Rule{
+			{Name: "all", EventType: ledger.EventGLPosted, Subject: "{{.VoucherNo}}", Channels: []string{"hook", "missing", "rec"}},
+			{Name: "bad", EventType: ledger.EventGLPosted, Subject: "{{.Nope}}", Channels: []string{"rec"}},
+		},
+		Channels: map[string]Channel{"hook": Webhook{URL: srv.URL}, "rec": rec},
+	}
+	err := n.Handle(context.Background(), ledger.Event{Type: ledger.EventGLPosted, VoucherNo: "JV-1"})
+	if !errors.Is(err, ErrUnknownChannel) || !errors.Is(err, ErrInvalidRule) || !strings.Contains(err.Error(), "502") {
+		t.Errorf("err = %v", err)
+	}
+	if len(rec.sent) != 1 || rec.sent[0].Subject != "JV-1" {
+		t.Errorf("recorder got %+v", rec.sent)
+	}
+}
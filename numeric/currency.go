@@ -0,0 +1,37 @@
+package numeric
+
+// DefaultCurrencyPrecision is the number of decimals of a currency without
+// an entry in the minor units table.
+const DefaultCurrencyPrecision = 2
+
+// minorUnits are the decimals of currencies that do not use two, from
+// ISO 4217.
+var minorUnits = map[string]int{
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0, "KRW": 0,
+	"PYG": 0, "RWF": 0, "UGX": 0, "UYI": 0, "VND": 0, "VUV": 0, "XAF": 0, "XOF": 0, "XPF": 0,
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+	"CLF": 4, "UYW": 4,
+}
+
+// CurrencyPrecision returns the number of decimals amounts in a currency
+// are kept to.
+//
+// Maps to: the number_format and smallest_currency_fraction_value of the
+// Currency doctype, via get_field_precision() for Currency fields
+func CurrencyPrecision(currency string) int {
+	if p, ok := minorUnits[currency]; ok {
+		return p
+	}
+	return DefaultCurrencyPrecision
+}
+
+// RoundCurrency rounds an amount to the decimals of its currency.
+func RoundCurrency(value float64, currency string) float64 {
+	return Round(value, CurrencyPrecision(currency))
+}
+
+// EqualCurrency reports whether two amounts in a currency are equal to
+// its smallest unit.
+func EqualCurrency(a, b float64, currency string) bool {
+	return Equal(a, b, CurrencyPrecision(currency))
+}
@@ -0,0 +1,100 @@
+// Package numeric rounds and compares amounts the same way everywhere.
+//
+// Amounts are float64, as in ERPNext, so two figures that print the same
+// can differ in their last bits: 0.1 + 0.2 is 0.30000000000000004, and
+// 1.005 is stored as 1.00499999999999989. Round corrects for that before
+// it rounds half away from zero, and it leaves values alone that are too
+// large to carry the requested decimals instead of overflowing. Compare
+// amounts at a precision with Equal, IsZero and Compare rather than with
+// == or an ad hoc epsilon.
+//
+// Maps to: frappe.utils.flt() and frappe.utils.rounded() in
+// frappe/utils/data.py
+package numeric
+
+import "math"
+
+// exact is the magnitude from which a float64 has no fractional part.
+const exact = 1 << 52
+
+// Round rounds value to precision decimals, halves away from zero. A
+// value within a few units in the last place of a half, as 1.005 is, is
+// taken as the half. NaN, infinities, a negative precision and values
+// too large for the precision come back unchanged.
+//
+// Python equivalent:
+//
+//	def rounded(num, precision=0):
+//	    multiplier = 10**precision
+//	    num = abs(num * multiplier)
+//	    floor_num = math.floor(num)
+//	    decimal_part = num - floor_num
+//	    epsilon = 2.0 ** (math.log(abs(num), 2) - 52.0)
+//	    if abs(decimal_part - 0.5) < epsilon:
+//	        num = floor_num + 1  # Half away from zero here; Frappe's legacy mode is banker's
+//	    else:
+//	        num = round(num)
+func Round(value float64, precision int) float64 {
+	if precision < 0 || math.IsNaN(value) || math.IsInf(value, 0) {
+		return value
+	}
+	if precision > 22 { // Beyond the exact powers of ten
+		return value
+	}
+	multiplier := math.Pow10(precision)
+	scaled := math.Abs(value * multiplier)
+	if scaled >= exact {
+		return value
+	}
+	whole := math.Floor(scaled)
+	epsilon := math.Pow(2, math.Log2(scaled)-52)
+	if math.Abs(scaled-whole-0.5) < 4*epsilon {
+		scaled = whole + 1
+	} else {
+		scaled = math.Round(scaled)
+	}
+	if scaled == 0 {
+		return 0 // Not -0, which prints with its sign
+	}
+	return math.Copysign(scaled/multiplier, value)
+}
+
+// Flt rounds value to precision when one is given.
+//
+// Maps to: frappe.utils.flt() in Python
+func Flt(value float64, precision ...int) float64 {
+	if len(precision) > 0 {
+		return Round(value, precision[0])
+	}
+	return value
+}
+
+// IsZero reports whether value rounds to zero at precision.
+func IsZero(value float64, precision int) bool {
+	return Round(value, precision) == 0
+}
+
+// Equal reports whether a and b are equal at precision: their difference
+// rounds to zero.
+func Equal(a, b float64, precision int) bool {
+	return IsZero(a-b, precision)
+}
+
+// Compare returns -1, 0 or +1 as a is less than, equal to or greater than
+// b at precision.
+func Compare(a, b float64, precision int) int {
+	switch d := Round(a-b, precision); {
+	case d < 0:
+		return -1
+	case d > 0:
+		return 1
+	}
+	return 0
+}
+
+// Near reports whether a and b differ by less than tolerance, for values
+// that are not amounts of a currency, such as rates and quantities
+// compared with a tolerance of their own.
+func Near(a, b, tolerance float64) bool {
+	return math.Abs(a-b) < tolerance
+}
@@ -0,0 +1,69 @@
+package numeric
+
+import (
+	"math"
+	"testing"
+)
+
+func TestRound(t *testing.T) {
+	tests := []struct {
+		value     float64
+		precision int
+		want      float64
+	}{
+		{1.005, 2, 1.01}, // Stored as 1.00499999999999989
+		{2.675, 2, 2.68},
+		{-1.005, 2, -1.01},
+		{0.125, 2, 0.13},
+		{1.0049, 2, 1},
+		{1234.5, 0, 1235},
+		{-0.001, 2, 0},
+		{0.1 + 0.2, 9, 0.3},
+		{12.3456, -1, 12.3456},
+		{1e300, 2, 1e300}, // Would overflow when scaled
+		{9007199254740993, 2, 9007199254740993},
+	}
+	for _, tt := range tests {
+		if got := Round(tt.value, tt.precision); got != tt.want {
+			t.Errorf("Round(%v, %d) = %v, want %v", tt.value, tt.precision, got, tt.want)
+		}
+	}
+	if got := Round(-0.001, 2); math.Signbit(got) {
+		t.Errorf("Round(-0.001, 2) = %v, want 0", got)
+	}
+	if got := Round(math.NaN(), 2); !math.IsNaN(got) {
+		t.Errorf("Round(NaN) = %v", got)
+	}
+	if got := Round(math.Inf(-1), 2); !math.IsInf(got, -1) {
+		t.Errorf("Round(-Inf) = %v", got)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	if !Equal(0.1+0.2, 0.3, 2) || Equal(100, 100.01, 2) || !IsZero(0.004, 2) || IsZero(0.005, 2) {
+		t.Error("Equal or IsZero at cents")
+	}
+	if Compare(100.004, 100, 2) != 0 || Compare(99.99, 100, 2) != -1 || Compare(100.01, 100, 2) != 1 {
+		t.Error("Compare at cents")
+	}
+	if !Near(100.00001, 100.00002, 0.0001) || Near(1, 1.1, 0.01) {
+		t.Error("Near")
+	}
+}
+
+func TestCurrency(t *testing.T) {
+	for currency, want := range map[string]int{"JPY": 0, "KWD": 3, "INR": 2, "": 2} {
+		if got := CurrencyPrecision(currency); got != want {
+			t.Errorf("CurrencyPrecision(%q) = %d, want %d", currency, got, want)
+		}
+	}
+	if got := RoundCurrency(1234.5, "JPY"); got != 1235 {
+		t.Errorf("RoundCurrency(JPY) = %v", got)
+	}
+	if got := RoundCurrency(1.2345, "BHD"); got != 1.235 {
+		t.Errorf("RoundCurrency(BHD) = %v", got)
+	}
+	if !EqualCurrency(10.0004, 10, "KWD") || EqualCurrency(10.0004, 10, "CLF") {
+		t.Error("EqualCurrency")
+	}
+}
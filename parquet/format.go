@@ -0,0 +1,293 @@
+package parquet
+
+// Physical types
+const (
+	typeBoolean   int32 = 0
+	typeInt32     int32 = 1
+	typeDouble    int32 = 5
+	typeByteArray int32 = 6
+)
+
+// Repetition types
+const (
+	repRequired int32 = 0
+	repOptional int32 = 1
+)
+
+// Converted types
+const (
+	convertedUTF8 int32 = 0
+	convertedDate int32 = 6
+)
+
+// Encodings
+const (
+	encPlain           int32 = 0
+	encPlainDictionary int32 = 2
+	encRLE             int32 = 3
+	encRLEDictionary   int32 = 8
+)
+
+// Page types
+const (
+	pageData       int32 = 0
+	pageDictionary int32 = 2
+)
+
+// magic starts and ends every Parquet file.
+const magic = "PAR1"
+
+// schemaElement is a node of the schema: the root group or a column.
+type schemaElement struct {
+	typ           int32 // -1 for groups
+	repetition    int32
+	name          string
+	numChildren   int32
+	convertedType int32 // -1 when not set
+}
+
+// columnChunk is a column of a row group.
+type columnChunk struct {
+	typ            int32
+	encodings      []int32
+	path           []string
+	codec          Compression
+	numValues      int64
+	uncompressed   int64
+	compressed     int64
+	dataPageOffset int64
+	dictPageOffset int64 // 0 when there is no dictionary page
+}
+
+type rowGroup struct {
+	columns   []columnChunk
+	totalSize int64
+	numRows   int64
+}
+
+type fileMetaData struct {
+	version   int32
+	schema    []schemaElement
+	numRows   int64
+	rowGroups []rowGroup
+	createdBy string
+}
+
+// pageHeader is the header of a data page (version 1) or dictionary page.
+type pageHeader struct {
+	typ          int32
+	uncompressed int32
+	compressed   int32
+	numValues    int32
+	encoding     int32
+}
+
+func (m *fileMetaData) encode() []byte {
+	w := &thriftWriter{}
+	w.begin(0)
+	w.i32(1, m.version)
+	w.list(2, tStruct, len(m.schema))
+	for _, s := range m.schema {
+		w.begin(0)
+		if s.typ >= 0 {
+			w.i32(1, s.typ)
+			w.i32(3, s.repetition)
+		}
+		w.str(4, s.name)
+		if s.typ < 0 {
+			w.i32(5, s.numChildren)
+		}
+		if s.convertedType >= 0 {
+			w.i32(6, s.convertedType)
+		}
+		w.end()
+	}
+	w.i64(3, m.numRows)
+	w.list(4, tStruct, len(m.rowGroups))
+	for _, rg := range m.rowGroups {
+		w.begin(0)
+		w.list(1, tStruct, len(rg.columns))
+		for _, c := range rg.columns {
+			w.begin(0)
+			w.i64(2, c.dataPageOffset)
+			w.begin(3)
+			w.i32(1, c.typ)
+			w.list(2, tI32, len(c.encodings))
+			for _, e := range c.encodings {
+				w.i32Elem(e)
+			}
+			w.list(3, tBinary, len(c.path))
+			for _, p := range c.path {
+				w.strElem(p)
+			}
+			w.i32(4, int32(c.codec))
+			w.i64(5, c.numValues)
+			w.i64(6, c.uncompressed)
+			w.i64(7, c.compressed)
+			w.i64(9, c.dataPageOffset)
+			w.end()
+			w.end()
+		}
+		w.i64(2, rg.totalSize)
+		w.i64(3, rg.numRows)
+		w.end()
+	}
+	w.str(6, m.createdBy)
+	w.end()
+	return w.buf
+}
+
+func decodeFileMetaData(data []byte) (*fileMetaData, error) {
+	r := &thriftReader{data: data}
+	m := &fileMetaData{}
+	r.readStruct(func(id int16, typ byte) {
+		switch {
+		case id == 1 && typ == tI32:
+			m.version = r.i32()
+		case id == 2 && typ == tList:
+			_, n := r.list()
+			for range n {
+				s := schemaElement{typ: -1, convertedType: -1}
+				r.readStruct(func(id int16, typ byte) {
+					switch {
+					case id == 1 && typ == tI32:
+						s.typ = r.i32()
+					case id == 3 && typ == tI32:
+						s.repetition = r.i32()
+					case id == 4 && typ == tBinary:
+						s.name = r.str()
+					case id == 5 && typ == tI32:
+						s.numChildren = r.i32()
+					case id == 6 && typ == tI32:
+						s.convertedType = r.i32()
+					default:
+						r.skip(typ)
+					}
+				})
+				m.schema = append(m.schema, s)
+			}
+		case id == 3 && typ == tI64:
+			m.numRows = r.varint()
+		case id == 4 && typ == tList:
+			_, n := r.list()
+			for range n {
+				m.rowGroups = append(m.rowGroups, decodeRowGroup(r))
+			}
+		case id == 6 && typ == tBinary:
+			m.createdBy = r.str()
+		default:
+			r.skip(typ)
+		}
+	})
+	return m, r.err
+}
+
+func decodeRowGroup(r *thriftReader) rowGroup {
+	var rg rowGroup
+	r.readStruct(func(id int16, typ byte) {
+		switch {
+		case id == 1 && typ == tList:
+			_, n := r.list()
+			for range n {
+				var c columnChunk
+				r.readStruct(func(id int16, typ byte) {
+					if id == 3 && typ == tStruct {
+						c = decodeColumnMetaData(r)
+					} else {
+						r.skip(typ)
+					}
+				})
+				rg.columns = append(rg.columns, c)
+			}
+		case id == 2 && typ == tI64:
+			rg.totalSize = r.varint()
+		case id == 3 && typ == tI64:
+			rg.numRows = r.varint()
+		default:
+			r.skip(typ)
+		}
+	})
+	return rg
+}
+
+func decodeColumnMetaData(r *thriftReader) columnChunk {
+	var c columnChunk
+	r.readStruct(func(id int16, typ byte) {
+		switch {
+		case id == 1 && typ == tI32:
+			c.typ = r.i32()
+		case id == 2 && typ == tList:
+			_, n := r.list()
+			for range n {
+				c.encodings = append(c.encodings, r.i32())
+			}
+		case id == 3 && typ == tList:
+			_, n := r.list()
+			for range n {
+				c.path = append(c.path, r.str())
+			}
+		case id == 4 && typ == tI32:
+			c.codec = Compression(r.i32())
+		case id == 5 && typ == tI64:
+			c.numValues = r.varint()
+		case id == 6 && typ == tI64:
+			c.uncompressed = r.varint()
+		case id == 7 && typ == tI64:
+			c.compressed = r.varint()
+		case id == 9 && typ == tI64:
+			c.dataPageOffset = r.varint()
+		case id == 11 && typ == tI64:
+			c.dictPageOffset = r.varint()
+		default:
+			r.skip(typ)
+		}
+	})
+	return c
+}
+
+func (h *pageHeader) encode() []byte {
+	w := &thriftWriter{}
+	w.begin(0)
+	w.i32(1, h.typ)
+	w.i32(2, h.uncompressed)
+	w.i32(3, h.compressed)
+	w.begin(5) // Data page header
+	w.i32(1, h.numValues)
+	w.i32(2, h.encoding)
+	w.i32(3, encRLE) // Definition levels
+	w.i32(4, encRLE) // Repetition levels
+	w.end()
+	w.end()
+	return w.buf
+}
+
+// decodePageHeader decodes the page header at the start of data and
+// returns its length.
+func decodePageHeader(data []byte) (*pageHeader, int, error) {
+	r := &thriftReader{data: data}
+	h := &pageHeader{typ: -1}
+	r.readStruct(func(id int16, typ byte) {
+		switch {
+		case id == 1 && typ == tI32:
+			h.typ = r.i32()
+		case id == 2 && typ == tI32:
+			h.uncompressed = r.i32()
+		case id == 3 && typ == tI32:
+			h.compressed = r.i32()
+		case (id == 5 || id == 7) && typ == tStruct: // Data page or dictionary page header
+			r.readStruct(func(id int16, typ byte) {
+				switch {
+				case id == 1 && typ == tI32:
+					h.numValues = r.i32()
+				case id == 2 && typ == tI32:
+					h.encoding = r.i32()
+				default:
+					r.skip(typ)
+				}
+			})
+		default:
+			r.skip(typ)
+		}
+	})
+	return h, r.pos, r.err
+}
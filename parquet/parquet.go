@@ -0,0 +1,331 @@
+// Package parquet writes GL entries as Apache Parquet files and reads them
+// back, so ledger data can be handed to data lakes, queried by DuckDB or
+// Spark, and loaded again to back the reports.
+//
+// Columns are the GL Entry doctype fields in snake_case, as in the SQL
+// schema of migrate.Ledger. Dates are DATE columns; transaction_date and
+// due_date are null when not set. WritePartitioned lays a dataset out in
+// Hive-style company=/fiscal_year= directories, which query engines
+// recognize as partitions.
+//
+// The format is implemented here on the standard library and covers what
+// ledger data needs: flat schemas, version 1 data pages, plain and
+// dictionary encodings, uncompressed or gzip pages. Files from other
+// writers are read when they stay within that subset; Snappy or Zstd
+// compression and version 2 data pages are reported as ErrUnsupported.
+package parquet
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/ledger"
+)
+
+// Errors
+var (
+	ErrInvalidFile = errors.New("invalid parquet file")
+	ErrUnsupported = errors.New("unsupported parquet feature")
+)
+
+// Compression is the codec of the pages, with its Parquet enum value.
+type Compression int32
+
+const (
+	Uncompressed Compression = 0
+	Gzip         Compression = 2
+)
+
+// DefaultRowGroupSize is the number of rows per row group when
+// Options.RowGroupSize is zero.
+const DefaultRowGroupSize = 100_000
+
+// createdBy identifies the writer in the file metadata.
+const createdBy = "erpnext-go parquet"
+
+// Options control writing.
+type Options struct {
+	RowGroupSize int
+	Compression  Compression
+}
+
+// kind is the logical type of a column.
+type kind int
+
+const (
+	kindString kind = iota
+	kindDouble
+	kindDate
+	kindBool
+)
+
+// field is a column of the GL entry schema.
+type field struct {
+	name     string
+	kind     kind
+	optional bool // Null for an empty value; only dates are optional
+
+	str  func(e *ledger.GLEntry) *string
+	f64  func(e *ledger.GLEntry) *float64
+	flag func(e *ledger.GLEntry) *bool
+
+	// Dates; ok is false for null
+	getDate func(e *ledger.GLEntry) (t time.Time, ok bool)
+	setDate func(e *ledger.GLEntry, t time.Time)
+}
+
+func stringField(name string, str func(e *ledger.GLEntry) *string) *field {
+	return &field{name: name, kind: kindString, str: str}
+}
+
+func doubleField(name string, f64 func(e *ledger.GLEntry) *float64) *field {
+	return &field{name: name, kind: kindDouble, f64: f64}
+}
+
+// fields are the columns, in file order.
+var fields = []*field{
+	stringField("name", func(e *ledger.GLEntry) *string { return &e.Name }),
+	{name: "posting_date", kind: kindDate,
+		getDate: func(e *ledger.GLEntry) (time.Time, bool) { return e.PostingDate, true },
+		setDate: func(e *ledger.GLEntry, t time.Time) { e.PostingDate = t }},
+	{name: "transaction_date", kind: kindDate, optional: true,
+		getDate: func(e *ledger.GLEntry) (time.Time, bool) { return e.TransactionDate, !e.TransactionDate.IsZero() },
+		setDate: func(e *ledger.GLEntry, t time.Time) { e.TransactionDate = t }},
+	{name: "due_date", kind: kindDate, optional: true,
+		getDate: func(e *ledger.GLEntry) (time.Time, bool) {
+			if e.DueDate == nil {
+				return time.Time{}, false
+			}
+			return *e.DueDate, true
+		},
+		setDate: func(e *ledger.GLEntry, t time.Time) { e.DueDate = &t }},
+	stringField("account", func(e *ledger.GLEntry) *string { return &e.Account }),
+	stringField("account_currency", func(e *ledger.GLEntry) *string { return &e.AccountCurrency }),
+	stringField("party_type", func(e *ledger.GLEntry) *string { return &e.PartyType }),
+	stringField("party", func(e *ledger.GLEntry) *string { return &e.Party }),
+	stringField("against", func(e *ledger.GLEntry) *string { return &e.Against }),
+	stringField("voucher_type", func(e *ledger.GLEntry) *string { return &e.VoucherType }),
+	stringField("voucher_no", func(e *ledger.GLEntry) *string { return &e.VoucherNo }),
+	stringField("voucher_subtype", func(e *ledger.GLEntry) *string { return &e.VoucherSubtype }),
+	stringField("voucher_detail_no", func(e *ledger.GLEntry) *string { return &e.VoucherDetailNo }),
+	stringField("against_voucher_type", func(e *ledger.GLEntry) *string { return &e.AgainstVoucherType }),
+	stringField("against_voucher", func(e *ledger.GLEntry) *string { return &e.AgainstVoucher }),
+	doubleField("debit", func(e *ledger.GLEntry) *float64 { return &e.Debit }),
+	doubleField("credit", func(e *ledger.GLEntry) *float64 { return &e.Credit }),
+	doubleField("debit_in_account_currency", func(e *ledger.GLEntry) *float64 { return &e.DebitInAccountCurrency }),
+	doubleField("credit_in_account_currency", func(e *ledger.GLEntry) *float64 { return &e.CreditInAccountCurrency }),
+	stringField("transaction_currency", func(e *ledger.GLEntry) *string { return &e.TransactionCurrency }),
+	doubleField("transaction_exchange_rate", func(e *ledger.GLEntry) *float64 { return &e.TransactionExchangeRate }),
+	doubleField("debit_in_transaction_currency", func(e *ledger.GLEntry) *float64 { return &e.DebitInTransactionCurrency }),
+	doubleField("credit_in_transaction_currency", func(e *ledger.GLEntry) *float64 { return &e.CreditInTransactionCurrency }),
+	doubleField("reporting_currency_exchange_rate", func(e *ledger.GLEntry) *float64 { return &e.ReportingCurrencyExchangeRate }),
+	doubleField("debit_in_reporting_currency", func(e *ledger.GLEntry) *float64 { return &e.DebitInReportingCurrency }),
+	doubleField("credit_in_reporting_currency", func(e *ledger.GLEntry) *float64 { return &e.CreditInReportingCurrency }),
+	stringField("cost_center", func(e *ledger.GLEntry) *string { return &e.CostCenter }),
+	stringField("project", func(e *ledger.GLEntry) *string { return &e.Project }),
+	stringField("company", func(e *ledger.GLEntry) *string { return &e.Company }),
+	stringField("fiscal_year", func(e *ledger.GLEntry) *string { return &e.FiscalYear }),
+	stringField("finance_book", func(e *ledger.GLEntry) *string { return &e.FinanceBook }),
+	stringField("is_opening", func(e *ledger.GLEntry) *string { return (*string)(&e.IsOpening) }),
+	stringField("is_advance", func(e *ledger.GLEntry) *string { return (*string)(&e.IsAdvance) }),
+	{name: "is_cancelled", kind: kindBool, flag: func(e *ledger.GLEntry) *bool { return &e.IsCancelled }},
+	stringField("remarks", func(e *ledger.GLEntry) *string { return &e.Remarks }),
+}
+
+// physical returns the Parquet type of the column.
+func (f *field) physical() int32 {
+	switch f.kind {
+	case kindDouble:
+		return typeDouble
+	case kindDate:
+		return typeInt32
+	case kindBool:
+		return typeBoolean
+	}
+	return typeByteArray
+}
+
+func (f *field) schema() schemaElement {
+	s := schemaElement{typ: f.physical(), repetition: repRequired, name: f.name, convertedType: -1}
+	if f.optional {
+		s.repetition = repOptional
+	}
+	switch f.kind {
+	case kindString:
+		s.convertedType = convertedUTF8
+	case kindDate:
+		s.convertedType = convertedDate
+	}
+	return s
+}
+
+// encode returns the data page of the column for entries: definition
+// levels for an optional column, then the plain-encoded values.
+func (f *field) encode(entries []ledger.GLEntry) []byte {
+	var levels []bool
+	if f.optional {
+		levels = make([]bool, len(entries))
+	}
+	var buf []byte
+	var bits byte // Booleans are bit-packed
+	for i := range entries {
+		e := &entries[i]
+		switch f.kind {
+		case kindString:
+			s := *f.str(e)
+			buf = binary.LittleEndian.AppendUint32(buf, uint32(len(s)))
+			buf = append(buf, s...)
+		case kindDouble:
+			buf = binary.LittleEndian.AppendUint64(buf, math.Float64bits(*f.f64(e)))
+		case kindDate:
+			t, ok := f.getDate(e)
+			if levels != nil {
+				levels[i] = ok
+			}
+			if ok {
+				buf = binary.LittleEndian.AppendUint32(buf, uint32(epochDay(t)))
+			}
+		case kindBool:
+			if *f.flag(e) {
+				bits |= 1 << (i % 8)
+			}
+			if i%8 == 7 || i == len(entries)-1 {
+				buf = append(buf, bits)
+				bits = 0
+			}
+		}
+	}
+	if levels == nil {
+		return buf
+	}
+	encoded := encodeLevels(levels)
+	page := binary.LittleEndian.AppendUint32(nil, uint32(len(encoded)))
+	page = append(page, encoded...)
+	return append(page, buf...)
+}
+
+// encodeLevels encodes definition levels of bit width 1 as RLE runs of
+// the RLE/bit-packing hybrid.
+func encodeLevels(levels []bool) []byte {
+	var buf []byte
+	for i := 0; i < len(levels); {
+		j := i
+		for j < len(levels) && levels[j] == levels[i] {
+			j++
+		}
+		buf = binary.AppendUvarint(buf, uint64(j-i)<<1)
+		if levels[i] {
+			buf = append(buf, 1)
+		} else {
+			buf = append(buf, 0)
+		}
+		i = j
+	}
+	return buf
+}
+
+// epochDay returns the days since 1970-01-01 of t's civil date.
+func epochDay(t time.Time) int32 {
+	return int32(ledger.CivilDate(t).Unix() / 86400)
+}
+
+// Write writes entries as a Parquet file.
+func Write(w io.Writer, entries []ledger.GLEntry, opts Options) error {
+	return write(w, entries, opts, fields)
+}
+
+// countingWriter tracks the offset in the file.
+type countingWriter struct {
+	w *bufio.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+func write(w io.Writer, entries []ledger.GLEntry, opts Options, cols []*field) error {
+	size := opts.RowGroupSize
+	if size <= 0 {
+		size = DefaultRowGroupSize
+	}
+	if opts.Compression != Uncompressed && opts.Compression != Gzip {
+		return fmt.Errorf("%w: compression codec %d", ErrUnsupported, opts.Compression)
+	}
+	cw := &countingWriter{w: bufio.NewWriter(w)}
+	if _, err := io.WriteString(cw, magic); err != nil {
+		return err
+	}
+
+	meta := fileMetaData{version: 1, numRows: int64(len(entries)), createdBy: createdBy}
+	meta.schema = append(meta.schema, schemaElement{typ: -1, name: "gl_entry", numChildren: int32(len(cols)), convertedType: -1})
+	for _, f := range cols {
+		meta.schema = append(meta.schema, f.schema())
+	}
+	for lo := 0; lo < len(entries); lo += size {
+		rows := entries[lo:min(lo+size, len(entries))]
+		rg := rowGroup{numRows: int64(len(rows))}
+		for _, f := range cols {
+			page := f.encode(rows)
+			body, err := compress(page, opts.Compression)
+			if err != nil {
+				return err
+			}
+			header := (&pageHeader{
+				typ: pageData, uncompressed: int32(len(page)), compressed: int32(len(body)),
+				numValues: int32(len(rows)), encoding: encPlain,
+			}).encode()
+			offset := cw.n
+			if _, err := cw.Write(header); err != nil {
+				return err
+			}
+			if _, err := cw.Write(body); err != nil {
+				return err
+			}
+			rg.columns = append(rg.columns, columnChunk{
+				typ: f.physical(), encodings: []int32{encPlain, encRLE}, path: []string{f.name},
+				codec: opts.Compression, numValues: int64(len(rows)),
+				uncompressed: int64(len(header) + len(page)), compressed: int64(len(header) + len(body)),
+				dataPageOffset: offset,
+			})
+			rg.totalSize += int64(len(header) + len(page))
+		}
+		meta.rowGroups = append(meta.rowGroups, rg)
+	}
+
+	footer := meta.encode()
+	if _, err := cw.Write(footer); err != nil {
+		return err
+	}
+	if _, err := cw.Write(binary.LittleEndian.AppendUint32(nil, uint32(len(footer)))); err != nil {
+		return err
+	}
+	if _, err := io.WriteString(cw, magic); err != nil {
+		return err
+	}
+	return cw.w.Flush()
+}
+
+func compress(page []byte, c Compression) ([]byte, error) {
+	if c == Uncompressed {
+		return page, nil
+	}
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(page); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
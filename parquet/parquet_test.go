@@ -0,0 +1,151 @@
+package parquet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"os"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/ledger"
+	"github.com/senguttuvang/erpnext-go/perf"
+)
+
+func date(s string) time.Time {
+	t, err := ledger.ParseDate(s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func sampleEntries() []ledger.GLEntry {
+	due := date("2024-05-31")
+	return []ledger.GLEntry{
+		{Name: "GLE-1", PostingDate: date("2024-05-01"), TransactionDate: date("2024-04-30"), DueDate: &due,
+			Account: "Debtors - ACME", AccountCurrency: "USD", PartyType: "Customer", Party: "Globex", Against: "Sales - ACME",
+			VoucherType: "Sales Invoice", VoucherNo: "SINV-1", VoucherDetailNo: "row-1",
+			Debit: 8300, DebitInAccountCurrency: 100, TransactionCurrency: "USD", TransactionExchangeRate: 83,
+			DebitInTransactionCurrency: 100, ReportingCurrencyExchangeRate: 1, DebitInReportingCurrency: 8300,
+			CostCenter: "Main - ACME", Project: "Rollout", Company: "ACME", FiscalYear: "2024-2025",
+			IsOpening: ledger.IsOpeningNo, IsAdvance: ledger.IsAdvanceNo, Remarks: "Order 7, \"urgent\""},
+		{Name: "GLE-2", PostingDate: date("2024-05-01"), Account: "Sales - ACME", Against: "Globex",
+			VoucherType: "Sales Invoice", VoucherNo: "SINV-0", Credit: 8300, CreditInAccountCurrency: 8300,
+			Company: "ACME", FiscalYear: "2024-2025", IsOpening: ledger.IsOpeningNo, IsAdvance: ledger.IsAdvanceNo, IsCancelled: true},
+		{Name: "GLE-3", PostingDate: date("2023-04-01"), Account: "Capital", VoucherType: "Journal Entry", VoucherNo: "JV-1",
+			Credit: 5000, Company: "Beta/Labs: EU", IsOpening: ledger.IsOpeningYes},
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	entries := sampleEntries()
+	for _, opts := range []Options{{}, {Compression: Gzip, RowGroupSize: 2}} {
+		var buf bytes.Buffer
+		if err := Write(&buf, entries, opts); err != nil {
+			t.Fatal(err)
+		}
+		data := buf.Bytes()
+		if string(data[:4]) != "PAR1" || string(data[len(data)-4:]) != "PAR1" {
+			t.Fatal("missing magic")
+		}
+		got, err := Read(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			t.Fatalf("%+v: %v", opts, err)
+		}
+		if !reflect.DeepEqual(got, entries) {
+			t.Errorf("%+v: read\n%+v\nwant\n%+v", opts, got, entries)
+		}
+	}
+
+	// A larger ledger, in several row groups
+	entries = perf.GenerateGLMap(5000)
+	var buf bytes.Buffer
+	if err := Write(&buf, entries, Options{RowGroupSize: 1024, Compression: Gzip}); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Read(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(entries) || got[4321].VoucherNo != entries[4321].VoucherNo || got[4321].Credit != entries[4321].Credit {
+		t.Errorf("read %d entries", len(got))
+	}
+}
+
+func TestPartitioned(t *testing.T) {
+	dir := t.TempDir()
+	files, err := WritePartitioned(dir, sampleEntries(), Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"company=ACME/fiscal_year=2024-2025/part-00000.parquet",
+		"company=Beta%2FLabs%3A EU/fiscal_year=__HIVE_DEFAULT_PARTITION__/part-00000.parquet",
+	}
+	if !slices.Equal(files, want) {
+		t.Fatalf("files = %q", files)
+	}
+
+	all, err := ReadPartitioned(os.DirFS(dir), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(all, sampleEntries()) {
+		t.Errorf("read\n%+v\nwant\n%+v", all, sampleEntries())
+	}
+	beta, err := ReadPartitioned(os.DirFS(dir), "Beta/Labs: EU")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(beta) != 1 || beta[0].VoucherNo != "JV-1" || beta[0].FiscalYear != "" {
+		t.Errorf("Beta entries = %+v", beta)
+	}
+}
+
+func TestDecoding(t *testing.T) {
+	// Dictionary-encoded strings, as other writers produce by default:
+	// a dictionary page, then indexes 1, 0, 1 bit-packed at width 1
+	dict, err := decodePlain(typeByteArray, append(
+		binary.LittleEndian.AppendUint32(nil, 4), append([]byte("Cash"),
+			append(binary.LittleEndian.AppendUint32(nil, 4), "Bank"...)...)...), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, err := decodeValues(typeByteArray, encRLEDictionary, []byte{1, 0x03, 0b101}, 3, dict)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(v.strs, []string{"Bank", "Cash", "Bank"}) {
+		t.Errorf("values = %q", v.strs)
+	}
+	if _, err := decodeValues(typeByteArray, encRLEDictionary, []byte{2, 0x02, 7}, 1, dict); !errors.Is(err, ErrInvalidFile) {
+		t.Errorf("index out of range: %v", err)
+	}
+
+	// RLE runs and bit-packed groups mixed
+	levels, err := decodeHybrid([]byte{0x06, 1, 0x03, 0b0110}, 1, 7)
+	if err != nil || !slices.Equal(levels, []uint32{1, 1, 1, 0, 1, 1, 0}) {
+		t.Errorf("levels = %v, %v", levels, err)
+	}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, sampleEntries(), Options{}); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+	for _, corrupt := range [][]byte{
+		[]byte("not parquet"),
+		data[:len(data)-1],
+		append(slices.Clone(data[:len(data)-8]), 0xff, 0xff, 0, 0, 'P', 'A', 'R', '1'),
+	} {
+		if _, err := Read(bytes.NewReader(corrupt), int64(len(corrupt))); !errors.Is(err, ErrInvalidFile) {
+			t.Errorf("corrupt file: %v", err)
+		}
+	}
+	if err := Write(&buf, nil, Options{Compression: 1}); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("snappy: %v", err)
+	}
+}
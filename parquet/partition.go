@@ -0,0 +1,195 @@
+package parquet
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/senguttuvang/erpnext-go/ledger"
+)
+
+// defaultPartition is Hive's directory name for an empty partition value.
+const defaultPartition = "__HIVE_DEFAULT_PARTITION__"
+
+// partitionFile is the name of the file in each partition directory.
+const partitionFile = "part-00000.parquet"
+
+// WritePartitioned writes entries under dir as a dataset partitioned by
+// company and fiscal year, one file per partition:
+//
+//	dir/company=ACME/fiscal_year=2024-2025/part-00000.parquet
+//
+// The partition columns are in the directory names only, as query engines
+// expect. Existing files of the same partitions are replaced. It returns
+// the paths of the files written, relative to dir.
+func WritePartitioned(dir string, entries []ledger.GLEntry, opts Options) ([]string, error) {
+	type partition struct{ company, fiscalYear string }
+	groups := make(map[partition][]ledger.GLEntry)
+	var order []partition
+	for _, e := range entries {
+		p := partition{e.Company, e.FiscalYear}
+		if _, ok := groups[p]; !ok {
+			order = append(order, p)
+		}
+		groups[p] = append(groups[p], e)
+	}
+	slices.SortFunc(order, func(a, b partition) int {
+		if c := strings.Compare(a.company, b.company); c != 0 {
+			return c
+		}
+		return strings.Compare(a.fiscalYear, b.fiscalYear)
+	})
+
+	cols := slices.DeleteFunc(slices.Clone(fields), func(f *field) bool {
+		return f.name == "company" || f.name == "fiscal_year"
+	})
+	var written []string
+	for _, p := range order {
+		rel := path.Join("company="+escapePartition(p.company), "fiscal_year="+escapePartition(p.fiscalYear), partitionFile)
+		name := filepath.Join(dir, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+			return written, err
+		}
+		f, err := os.Create(name)
+		if err != nil {
+			return written, err
+		}
+		err = write(f, groups[p], opts, cols)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return written, err
+		}
+		written = append(written, rel)
+	}
+	return written, nil
+}
+
+// ReadPartitioned reads the Parquet files of a dataset, such as one
+// written by WritePartitioned, in path order. Company and fiscal year are
+// taken from company= and fiscal_year= directories. With a company, only
+// its entries are returned and other company partitions are not read.
+func ReadPartitioned(fsys fs.FS, company string) ([]ledger.GLEntry, error) {
+	var entries []ledger.GLEntry
+	err := readDataset(fsys, func(values map[string]string) bool {
+		c, ok := values["company"]
+		return ok && company != "" && c != company
+	}, func(e *ledger.GLEntry) {
+		if company == "" || e.Company == company {
+			entries = append(entries, *e)
+		}
+	})
+	return entries, err
+}
+
+// readDataset passes the entries of the Parquet files under fsys to fn,
+// with their partition values set. Directories and files for whose
+// partition values prune returns true are not read.
+func readDataset(fsys fs.FS, prune func(values map[string]string) bool, fn func(e *ledger.GLEntry)) error {
+	return fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		dir := name
+		if !d.IsDir() {
+			dir = path.Dir(name)
+		}
+		values, err := partitionValues(dir)
+		if err != nil {
+			return err
+		}
+		if prune(values) {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() || !strings.HasSuffix(name, ".parquet") {
+			return nil
+		}
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return err
+		}
+		read, err := Read(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		for i := range read {
+			e := &read[i]
+			if c, ok := values["company"]; ok {
+				e.Company = c
+			}
+			if fy, ok := values["fiscal_year"]; ok {
+				e.FiscalYear = fy
+			}
+			fn(e)
+		}
+		return nil
+	})
+}
+
+// partitionValues returns the key=value directories of a path.
+func partitionValues(dir string) (map[string]string, error) {
+	values := make(map[string]string)
+	for _, part := range strings.Split(dir, "/") {
+		key, value, ok := strings.Cut(part, "=")
+		if !ok {
+			continue
+		}
+		v, err := unescapePartition(value)
+		if err != nil {
+			return nil, fmt.Errorf("%w: partition %s", ErrInvalidFile, part)
+		}
+		values[key] = v
+	}
+	return values, nil
+}
+
+// escapePartition escapes a partition value as Hive does, with %XX for
+// characters that are special in paths.
+func escapePartition(s string) string {
+	if s == "" {
+		return defaultPartition
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c < 0x20 || c == 0x7f || strings.IndexByte("\"#%'*/:=?\\{[]^", c) >= 0 {
+			fmt.Fprintf(&b, "%%%02X", c)
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+func unescapePartition(s string) (string, error) {
+	if s == defaultPartition {
+		return "", nil
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '%' {
+			b.WriteByte(s[i])
+			continue
+		}
+		if i+2 >= len(s) {
+			return "", strconv.ErrSyntax
+		}
+		c, err := strconv.ParseUint(s[i+1:i+3], 16, 8)
+		if err != nil {
+			return "", err
+		}
+		b.WriteByte(byte(c))
+		i += 2
+	}
+	return b.String(), nil
+}
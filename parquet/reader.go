@@ -0,0 +1,357 @@
+package parquet
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/ledger"
+)
+
+// Read reads the GL entries of a Parquet file of size bytes. Columns the
+// schema does not know are ignored and missing columns are left empty, so
+// files with a subset of the columns, such as a query result, can be read.
+func Read(r io.ReaderAt, size int64) ([]ledger.GLEntry, error) {
+	if size < int64(2*len(magic)+4) {
+		return nil, fmt.Errorf("%w: %d bytes", ErrInvalidFile, size)
+	}
+	tail := make([]byte, 4+len(magic))
+	if _, err := r.ReadAt(tail, size-int64(len(tail))); err != nil {
+		return nil, err
+	}
+	if string(tail[4:]) != magic {
+		return nil, fmt.Errorf("%w: no footer", ErrInvalidFile)
+	}
+	footerLen := int64(binary.LittleEndian.Uint32(tail))
+	if footerLen > size-int64(len(tail)+len(magic)) {
+		return nil, fmt.Errorf("%w: footer of %d bytes", ErrInvalidFile, footerLen)
+	}
+	footer := make([]byte, footerLen)
+	if _, err := r.ReadAt(footer, size-int64(len(tail))-footerLen); err != nil {
+		return nil, err
+	}
+	meta, err := decodeFileMetaData(footer)
+	if err != nil {
+		return nil, err
+	}
+
+	// Map the columns of the file to the fields of the schema
+	if len(meta.schema) == 0 || int(meta.schema[0].numChildren) != len(meta.schema)-1 {
+		return nil, fmt.Errorf("%w: nested schema", ErrUnsupported)
+	}
+	byName := make(map[string]*field, len(fields))
+	for _, f := range fields {
+		byName[f.name] = f
+	}
+	leaves := meta.schema[1:]
+	for _, s := range leaves {
+		if f := byName[s.name]; f != nil && s.typ != f.physical() {
+			return nil, fmt.Errorf("%w: column %s has type %d, want %d", ErrUnsupported, s.name, s.typ, f.physical())
+		}
+	}
+
+	entries := make([]ledger.GLEntry, 0, min(meta.numRows, size))
+	for _, rg := range meta.rowGroups {
+		if len(rg.columns) != len(leaves) {
+			return nil, fmt.Errorf("%w: row group has %d columns, schema %d", ErrInvalidFile, len(rg.columns), len(leaves))
+		}
+		if rg.numRows < 0 || rg.numRows > meta.numRows {
+			return nil, fmt.Errorf("%w: row group of %d rows", ErrInvalidFile, rg.numRows)
+		}
+		rows := make([]ledger.GLEntry, rg.numRows)
+		for i, c := range rg.columns {
+			f := byName[leaves[i].name]
+			if f == nil {
+				continue
+			}
+			if err := readColumn(r, size, c, f, leaves[i].repetition == repOptional, rows); err != nil {
+				return nil, fmt.Errorf("column %s: %w", f.name, err)
+			}
+		}
+		entries = append(entries, rows...)
+	}
+	return entries, nil
+}
+
+// values holds decoded values of one physical type.
+type values struct {
+	strs  []string
+	f64   []float64
+	i32   []int32
+	bools []bool
+}
+
+func (v *values) len() int {
+	return max(len(v.strs), len(v.f64), len(v.i32), len(v.bools))
+}
+
+// pick returns the dictionary values at the indexes.
+func (v *values) pick(idx []uint32) (*values, error) {
+	out := &values{}
+	n := uint32(v.len())
+	for _, i := range idx {
+		if i >= n {
+			return nil, fmt.Errorf("%w: dictionary index %d of %d", ErrInvalidFile, i, n)
+		}
+		switch {
+		case v.strs != nil:
+			out.strs = append(out.strs, v.strs[i])
+		case v.f64 != nil:
+			out.f64 = append(out.f64, v.f64[i])
+		case v.i32 != nil:
+			out.i32 = append(out.i32, v.i32[i])
+		case v.bools != nil:
+			out.bools = append(out.bools, v.bools[i])
+		}
+	}
+	return out, nil
+}
+
+// set assigns the kth value to the entry's field.
+func (f *field) set(e *ledger.GLEntry, v *values, k int) {
+	switch f.kind {
+	case kindString:
+		*f.str(e) = v.strs[k]
+	case kindDouble:
+		*f.f64(e) = v.f64[k]
+	case kindDate:
+		f.setDate(e, time.Unix(int64(v.i32[k])*86400, 0).UTC())
+	case kindBool:
+		*f.flag(e) = v.bools[k]
+	}
+}
+
+// readColumn reads a column chunk into rows.
+func readColumn(r io.ReaderAt, size int64, c columnChunk, f *field, optional bool, rows []ledger.GLEntry) error {
+	start := c.dataPageOffset
+	if c.dictPageOffset > 0 && c.dictPageOffset < start {
+		start = c.dictPageOffset
+	}
+	if start < int64(len(magic)) || c.compressed < 0 || start+c.compressed > size {
+		return fmt.Errorf("%w: chunk at %d of %d bytes", ErrInvalidFile, start, c.compressed)
+	}
+	buf := make([]byte, c.compressed)
+	if _, err := r.ReadAt(buf, start); err != nil {
+		return err
+	}
+
+	var dict *values
+	row := 0
+	for pos := 0; pos < len(buf) && row < len(rows); {
+		h, n, err := decodePageHeader(buf[pos:])
+		if err != nil {
+			return err
+		}
+		pos += n
+		if h.compressed < 0 || int(h.compressed) > len(buf)-pos || h.numValues < 0 {
+			return fmt.Errorf("%w: page of %d bytes", ErrInvalidFile, h.compressed)
+		}
+		data, err := decompress(buf[pos:pos+int(h.compressed)], c.codec, h.uncompressed)
+		if err != nil {
+			return err
+		}
+		pos += int(h.compressed)
+
+		switch h.typ {
+		case pageDictionary:
+			if dict, err = decodePlain(f.physical(), data, int(h.numValues)); err != nil {
+				return err
+			}
+		case pageData:
+			count := int(h.numValues)
+			if count > len(rows)-row {
+				return fmt.Errorf("%w: %d values for %d rows", ErrInvalidFile, row+count, len(rows))
+			}
+			present := make([]bool, count)
+			nonNull := count
+			if optional {
+				if len(data) < 4 {
+					return fmt.Errorf("%w: truncated levels", ErrInvalidFile)
+				}
+				n := int(binary.LittleEndian.Uint32(data))
+				if n > len(data)-4 {
+					return fmt.Errorf("%w: levels of %d bytes", ErrInvalidFile, n)
+				}
+				levels, err := decodeHybrid(data[4:4+n], 1, count)
+				if err != nil {
+					return err
+				}
+				data = data[4+n:]
+				nonNull = 0
+				for i, l := range levels {
+					present[i] = l == 1
+					if present[i] {
+						nonNull++
+					}
+				}
+			} else {
+				for i := range present {
+					present[i] = true
+				}
+			}
+			v, err := decodeValues(f.physical(), h.encoding, data, nonNull, dict)
+			if err != nil {
+				return err
+			}
+			k := 0
+			for _, ok := range present {
+				if ok {
+					f.set(&rows[row], v, k)
+					k++
+				}
+				row++
+			}
+		default:
+			return fmt.Errorf("%w: page type %d", ErrUnsupported, h.typ)
+		}
+	}
+	if row != len(rows) {
+		return fmt.Errorf("%w: %d values for %d rows", ErrInvalidFile, row, len(rows))
+	}
+	return nil
+}
+
+func decompress(data []byte, codec Compression, size int32) ([]byte, error) {
+	switch codec {
+	case Uncompressed:
+		return data, nil
+	case Gzip:
+		zr, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+		}
+		out, err := io.ReadAll(io.LimitReader(zr, int64(size)))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+		}
+		return out, nil
+	}
+	return nil, fmt.Errorf("%w: compression codec %d", ErrUnsupported, codec)
+}
+
+// decodeValues decodes n values of a data page.
+func decodeValues(typ, encoding int32, data []byte, n int, dict *values) (*values, error) {
+	switch encoding {
+	case encPlain:
+		return decodePlain(typ, data, n)
+	case encPlainDictionary, encRLEDictionary:
+		if dict == nil {
+			return nil, fmt.Errorf("%w: dictionary page missing", ErrInvalidFile)
+		}
+		if n == 0 {
+			return &values{}, nil
+		}
+		if len(data) == 0 {
+			return nil, fmt.Errorf("%w: truncated indexes", ErrInvalidFile)
+		}
+		idx, err := decodeHybrid(data[1:], int(data[0]), n)
+		if err != nil {
+			return nil, err
+		}
+		return dict.pick(idx)
+	}
+	return nil, fmt.Errorf("%w: encoding %d", ErrUnsupported, encoding)
+}
+
+// decodePlain decodes n plain-encoded values.
+func decodePlain(typ int32, data []byte, n int) (*values, error) {
+	short := fmt.Errorf("%w: %d values in %d bytes", ErrInvalidFile, n, len(data))
+	v := &values{}
+	switch typ {
+	case typeByteArray:
+		v.strs = make([]string, 0, min(n, len(data)/4))
+		for range n {
+			if len(data) < 4 {
+				return nil, short
+			}
+			l := int(binary.LittleEndian.Uint32(data))
+			if l > len(data)-4 {
+				return nil, short
+			}
+			v.strs = append(v.strs, string(data[4:4+l]))
+			data = data[4+l:]
+		}
+	case typeDouble:
+		if len(data) < 8*n {
+			return nil, short
+		}
+		v.f64 = make([]float64, n)
+		for i := range v.f64 {
+			v.f64[i] = math.Float64frombits(binary.LittleEndian.Uint64(data[8*i:]))
+		}
+	case typeInt32:
+		if len(data) < 4*n {
+			return nil, short
+		}
+		v.i32 = make([]int32, n)
+		for i := range v.i32 {
+			v.i32[i] = int32(binary.LittleEndian.Uint32(data[4*i:]))
+		}
+	case typeBoolean:
+		if len(data) < (n+7)/8 {
+			return nil, short
+		}
+		v.bools = make([]bool, n)
+		for i := range v.bools {
+			v.bools[i] = data[i/8]&(1<<(i%8)) != 0
+		}
+	default:
+		return nil, fmt.Errorf("%w: type %d", ErrUnsupported, typ)
+	}
+	return v, nil
+}
+
+// decodeHybrid decodes n values of the RLE/bit-packing hybrid encoding.
+func decodeHybrid(data []byte, bitWidth, n int) ([]uint32, error) {
+	if bitWidth > 32 {
+		return nil, fmt.Errorf("%w: bit width %d", ErrInvalidFile, bitWidth)
+	}
+	out := make([]uint32, 0, n)
+	byteWidth := (bitWidth + 7) / 8
+	for len(out) < n {
+		header, k := binary.Uvarint(data)
+		if k <= 0 {
+			return nil, fmt.Errorf("%w: truncated run", ErrInvalidFile)
+		}
+		data = data[k:]
+		if header&1 == 0 { // RLE run
+			if len(data) < byteWidth {
+				return nil, fmt.Errorf("%w: truncated run", ErrInvalidFile)
+			}
+			var v uint32
+			for i := range byteWidth {
+				v |= uint32(data[i]) << (8 * i)
+			}
+			data = data[byteWidth:]
+			for run := header >> 1; run > 0 && len(out) < n; run-- {
+				out = append(out, v)
+			}
+			continue
+		}
+		// Bit-packed groups of 8 values, least significant bit first
+		groups := header >> 1
+		if groups > uint64(len(data)) {
+			return nil, fmt.Errorf("%w: truncated run", ErrInvalidFile)
+		}
+		size := int(groups) * bitWidth
+		if size > len(data) {
+			return nil, fmt.Errorf("%w: truncated run", ErrInvalidFile)
+		}
+		for i := 0; i < int(groups)*8 && len(out) < n; i++ {
+			var v uint32
+			for b := range bitWidth {
+				bit := i*bitWidth + b
+				if data[bit/8]&(1<<(bit%8)) != 0 {
+					v |= 1 << b
+				}
+			}
+			out = append(out, v)
+		}
+		data = data[size:]
+	}
+	return out, nil
+}
@@ -0,0 +1,97 @@
+package parquet
+
+import (
+	"errors"
+	"io/fs"
+
+	"github.com/senguttuvang/erpnext-go/columnar"
+	"github.com/senguttuvang/erpnext-go/daterange"
+	"github.com/senguttuvang/erpnext-go/ledger"
+)
+
+// ErrReadOnly is returned by the write methods of a Store.
+var ErrReadOnly = errors.New("parquet dataset is read-only")
+
+// Store serves GL entries from a dataset written by WritePartitioned, so
+// reports can run over ledger exports without a database. Filters are
+// pushed down to the dataset layout: only the partitions of the filter's
+// company are read and, with FiscalYears set, fiscal years starting after
+// the filter's end date are skipped. Entries before the start date are
+// still read, as reports need them for opening balances.
+//
+// The dataset is read on every call; Ledger builds a columnar.Ledger for
+// callers that run several reports over the same data. Store implements
+// ledger.GLEntryStore for reading only: exports are not written back, so
+// Save, SaveBatch and MarkCancelled return ErrReadOnly.
+type Store struct {
+	FS          fs.FS
+	FiscalYears daterange.FiscalYears // Optional; fiscal years are not pruned when nil
+}
+
+// NewStore returns a Store over the dataset in fsys.
+func NewStore(fsys fs.FS) *Store {
+	return &Store{FS: fsys}
+}
+
+// Save implements ledger.GLEntryStore and returns ErrReadOnly.
+func (s *Store) Save(*ledger.GLEntry) error { return ErrReadOnly }
+
+// SaveBatch implements ledger.GLEntryStore and returns ErrReadOnly.
+func (s *Store) SaveBatch([]ledger.GLEntry) error { return ErrReadOnly }
+
+// MarkCancelled implements ledger.GLEntryStore and returns ErrReadOnly.
+func (s *Store) MarkCancelled(string, string) error { return ErrReadOnly }
+
+// GetByVoucher returns the entries of a voucher, including cancelled ones.
+// The dataset is not partitioned by voucher, so every file is read.
+func (s *Store) GetByVoucher(voucherType, voucherNo string) ([]ledger.GLEntry, error) {
+	var result []ledger.GLEntry
+	err := readDataset(s.FS, func(map[string]string) bool { return false }, func(e *ledger.GLEntry) {
+		if e.VoucherType == voucherType && e.VoucherNo == voucherNo {
+			result = append(result, *e)
+		}
+	})
+	return result, err
+}
+
+// Query returns the entries matching the filter, as ledger.QueryGL does
+// over the entries in memory.
+func (s *Store) Query(f ledger.GLFilter, tree *ledger.AccountTree) ([]ledger.GLEntry, error) {
+	var entries []ledger.GLEntry
+	err := readDataset(s.FS, s.prune(f), func(e *ledger.GLEntry) {
+		entries = append(entries, *e)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ledger.QueryGL(entries, f, tree)
+}
+
+// Ledger returns the entries of the partitions a filter needs as a
+// columnar.Ledger, for Totals, Balances and TrialBalance with that filter
+// or a narrower one.
+func (s *Store) Ledger(f ledger.GLFilter) (*columnar.Ledger, error) {
+	l := columnar.FromEntries(nil)
+	err := readDataset(s.FS, s.prune(f), l.Append)
+	if err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// prune returns the partition filter for f. A fiscal year the lookup does
+// not know is read, as are entries without a fiscal year.
+func (s *Store) prune(f ledger.GLFilter) func(map[string]string) bool {
+	return func(values map[string]string) bool {
+		company, ok := values["company"]
+		if ok && f.Company != "" && company != f.Company {
+			return true
+		}
+		fy := values["fiscal_year"]
+		if fy == "" || s.FiscalYears == nil || f.To.IsZero() {
+			return false
+		}
+		start, _, err := s.FiscalYears.GetFiscalYearDates(fy, company)
+		return err == nil && ledger.CivilDate(start).After(ledger.CivilDate(f.To))
+	}
+}
@@ -0,0 +1,94 @@
+package parquet
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/ledger"
+)
+
+type fiscalYears struct{}
+
+func (fiscalYears) GetFiscalYear(d time.Time, company string) (string, error) {
+	y := d.Year()
+	if d.Month() < time.April {
+		y--
+	}
+	return fmt.Sprintf("%d-%d", y, y+1), nil
+}
+
+func (fiscalYears) GetFiscalYearDates(fy, company string) (time.Time, time.Time, error) {
+	start, err := ledger.ParseDate(fy[:4] + "-04-01")
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	return start, start.AddDate(1, 0, -1), nil
+}
+
+// openedFS records the Parquet files opened.
+type openedFS struct {
+	fs.FS
+	opened []string
+}
+
+func (f *openedFS) Open(name string) (fs.File, error) {
+	if strings.HasSuffix(name, ".parquet") {
+		f.opened = append(f.opened, name)
+	}
+	return f.FS.Open(name)
+}
+
+func TestStore(t *testing.T) {
+	entries := sampleEntries()
+	entries = append(entries, ledger.GLEntry{Name: "GLE-4", PostingDate: date("2025-04-02"), Account: "Debtors - ACME",
+		PartyType: "Customer", Party: "Globex", VoucherType: "Sales Invoice", VoucherNo: "SINV-2", Debit: 100,
+		Company: "ACME", FiscalYear: "2025-2026"})
+	dir := t.TempDir()
+	if _, err := WritePartitioned(dir, entries, Options{}); err != nil {
+		t.Fatal(err)
+	}
+	fsys := &openedFS{FS: os.DirFS(dir)}
+	s := NewStore(fsys)
+	s.FiscalYears = fiscalYears{}
+
+	got, err := s.Query(ledger.GLFilter{Company: "ACME", Party: "Globex", To: date("2025-03-31")}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].Name != "GLE-1" || got[0].FiscalYear != "2024-2025" {
+		t.Errorf("entries = %+v", got)
+	}
+	if want := []string{"company=ACME/fiscal_year=2024-2025/part-00000.parquet"}; !slices.Equal(fsys.opened, want) {
+		t.Errorf("opened %q, want %q", fsys.opened, want)
+	}
+
+	fsys.opened = nil
+	l, err := s.Ledger(ledger.GLFilter{Company: "ACME"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	balances, err := l.Balances(ledger.GLFilter{Company: "ACME"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if balances["Debtors - ACME"] != 8400 || len(fsys.opened) != 2 {
+		t.Errorf("balances = %v from %q", balances, fsys.opened)
+	}
+
+	voucher, err := s.GetByVoucher("Sales Invoice", "SINV-0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(voucher) != 1 || !voucher[0].IsCancelled {
+		t.Errorf("voucher entries = %+v", voucher)
+	}
+	if err := s.SaveBatch(entries); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("SaveBatch: %v", err)
+	}
+}
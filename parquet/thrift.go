@@ -0,0 +1,224 @@
+package parquet
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// Thrift compact protocol types, as used in Parquet metadata.
+const (
+	tBoolTrue  byte = 1
+	tBoolFalse byte = 2
+	tByte      byte = 3
+	tI16       byte = 4
+	tI32       byte = 5
+	tI64       byte = 6
+	tDouble    byte = 7
+	tBinary    byte = 8
+	tList      byte = 9
+	tSet       byte = 10
+	tMap       byte = 11
+	tStruct    byte = 12
+)
+
+// thriftWriter encodes structs in the Thrift compact protocol.
+type thriftWriter struct {
+	buf  []byte
+	last []int16 // Last field ID of each open struct
+}
+
+func (w *thriftWriter) field(id int16, typ byte) {
+	last := &w.last[len(w.last)-1]
+	if delta := id - *last; delta > 0 && delta <= 15 {
+		w.buf = append(w.buf, byte(delta)<<4|typ)
+	} else {
+		w.buf = append(w.buf, typ)
+		w.buf = binary.AppendVarint(w.buf, int64(id))
+	}
+	*last = id
+}
+
+func (w *thriftWriter) i32(id int16, v int32) {
+	w.field(id, tI32)
+	w.buf = binary.AppendVarint(w.buf, int64(v))
+}
+
+func (w *thriftWriter) i64(id int16, v int64) {
+	w.field(id, tI64)
+	w.buf = binary.AppendVarint(w.buf, v)
+}
+
+func (w *thriftWriter) str(id int16, s string) {
+	w.field(id, tBinary)
+	w.buf = binary.AppendUvarint(w.buf, uint64(len(s)))
+	w.buf = append(w.buf, s...)
+}
+
+// list writes a list field header; the caller appends the elements.
+func (w *thriftWriter) list(id int16, elem byte, n int) {
+	w.field(id, tList)
+	if n < 15 {
+		w.buf = append(w.buf, byte(n)<<4|elem)
+	} else {
+		w.buf = append(w.buf, 0xf0|elem)
+		w.buf = binary.AppendUvarint(w.buf, uint64(n))
+	}
+}
+
+func (w *thriftWriter) i32Elem(v int32) { w.buf = binary.AppendVarint(w.buf, int64(v)) }
+
+func (w *thriftWriter) strElem(s string) {
+	w.buf = binary.AppendUvarint(w.buf, uint64(len(s)))
+	w.buf = append(w.buf, s...)
+}
+
+// begin opens a struct: a field of the enclosing struct when id > 0, a
+// list element or the top-level struct otherwise.
+func (w *thriftWriter) begin(id int16) {
+	if id > 0 {
+		w.field(id, tStruct)
+	}
+	w.last = append(w.last, 0)
+}
+
+func (w *thriftWriter) end() {
+	w.buf = append(w.buf, 0)
+	w.last = w.last[:len(w.last)-1]
+}
+
+// thriftReader decodes the Thrift compact protocol. The first error stops
+// decoding and is kept in err.
+type thriftReader struct {
+	data []byte
+	pos  int
+	err  error
+}
+
+func (r *thriftReader) fail(format string, args ...any) {
+	if r.err == nil {
+		r.err = fmt.Errorf("%w: metadata: "+format, append([]any{ErrInvalidFile}, args...)...)
+	}
+	r.pos = len(r.data)
+}
+
+func (r *thriftReader) byte() byte {
+	if r.pos >= len(r.data) {
+		r.fail("truncated")
+		return 0
+	}
+	b := r.data[r.pos]
+	r.pos++
+	return b
+}
+
+func (r *thriftReader) uvarint() uint64 {
+	v, n := binary.Uvarint(r.data[r.pos:])
+	if n <= 0 {
+		r.fail("bad varint")
+		return 0
+	}
+	r.pos += n
+	return v
+}
+
+func (r *thriftReader) varint() int64 {
+	v, n := binary.Varint(r.data[r.pos:])
+	if n <= 0 {
+		r.fail("bad varint")
+		return 0
+	}
+	r.pos += n
+	return v
+}
+
+func (r *thriftReader) i32() int32 { return int32(r.varint()) }
+
+func (r *thriftReader) str() string {
+	n := r.uvarint()
+	if n > uint64(len(r.data)-r.pos) {
+		r.fail("string of %d bytes", n)
+		return ""
+	}
+	s := string(r.data[r.pos : r.pos+int(n)])
+	r.pos += int(n)
+	return s
+}
+
+// list reads a list header.
+func (r *thriftReader) list() (elem byte, n int) {
+	b := r.byte()
+	elem, size := b&0x0f, uint64(b>>4)
+	if size == 15 {
+		size = r.uvarint()
+	}
+	if size > uint64(len(r.data)-r.pos) { // Every element takes a byte at least
+		r.fail("list of %d elements", size)
+		return elem, 0
+	}
+	return elem, int(size)
+}
+
+// readStruct calls fn with the ID and type of each field until the stop
+// field. fn must read the value or call skip.
+func (r *thriftReader) readStruct(fn func(id int16, typ byte)) {
+	var last int16
+	for r.err == nil {
+		b := r.byte()
+		if b == 0 {
+			return
+		}
+		typ := b & 0x0f
+		if delta := int16(b >> 4); delta != 0 {
+			last += delta
+		} else {
+			last = int16(r.varint())
+		}
+		fn(last, typ)
+	}
+}
+
+// skip reads and drops a value of type typ.
+func (r *thriftReader) skip(typ byte) {
+	switch typ {
+	case tBoolTrue, tBoolFalse:
+	case tByte:
+		r.byte()
+	case tI16, tI32, tI64:
+		r.varint()
+	case tDouble:
+		if r.pos+8 > len(r.data) {
+			r.fail("truncated")
+			return
+		}
+		r.pos += 8
+	case tBinary:
+		r.str()
+	case tList, tSet:
+		elem, n := r.list()
+		for range n {
+			if elem == tBoolTrue || elem == tBoolFalse {
+				r.byte() // Booleans in lists take a byte each
+				continue
+			}
+			r.skip(elem)
+		}
+	case tMap:
+		n := r.uvarint()
+		if n == 0 {
+			return
+		}
+		if n > uint64(len(r.data)-r.pos) {
+			r.fail("map of %d entries", n)
+			return
+		}
+		types := r.byte()
+		for range n {
+			r.skip(types >> 4)
+			r.skip(types & 0x0f)
+		}
+	case tStruct:
+		r.readStruct(func(_ int16, typ byte) { r.skip(typ) })
+	default:
+		r.fail("unknown type %d", typ)
+	}
+}
@@ -0,0 +1,288 @@
+package party
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/daterange"
+	"github.com/senguttuvang/erpnext-go/ledger"
+)
+
+// ErrUnboundedPeriod is returned for a days outstanding or credit period
+// report over a period without a start or an end.
+var ErrUnboundedPeriod = errors.New("report period must be bounded")
+
+// DaysOutstanding is the Days Sales Outstanding of customers, or the Days
+// Payable Outstanding of suppliers, over a period. Amounts are positive
+// for receivables and payables alike.
+type DaysOutstanding struct {
+	Period daterange.Range
+	Party  string // Empty for the total of every party
+
+	Invoiced    float64 // Invoices posted in the period
+	Outstanding float64 // Balance at the end of the period, net of advances
+
+	// Days is Outstanding over Invoiced times the days of the period, the
+	// textbook DSO or DPO; 0 when nothing was invoiced.
+	Days float64
+
+	// DaysToSettle is the days from invoice to settlement, averaged over
+	// the settlements posted in the period and weighted by the amount
+	// each settled: the DSO or DPO actually achieved.
+	DaysToSettle float64
+}
+
+// DaysOutstandingReport computes the days outstanding of a company's
+// parties of one type for each period: a row for every party with entries
+// up to the end of the period, ordered by party, after the total row.
+// Customers give DSO and suppliers DPO.
+//
+// Everything comes from the payment ledger. An invoice is an entry
+// against itself that adds to the balance; a settlement is any other
+// entry against an invoice that reduces it, so credit and debit notes
+// settle the invoices they are allocated to. Delinked entries are
+// skipped.
+//
+// Maps to: the receivable and payable balances of the Accounts Receivable
+// and Accounts Payable reports, turned into the turnover ratios of
+// financial statement analysis
+func DaysOutstandingReport(entries []ledger.PaymentLedgerEntry, company string, partyType PartyType, periods []daterange.Range) ([]DaysOutstanding, error) {
+	for _, p := range periods {
+		if !p.Bounded() {
+			return nil, fmt.Errorf("%w: %s", ErrUnboundedPeriod, p)
+		}
+	}
+	invoices := openItems(entries, company, partyType)
+	var out []DaysOutstanding
+	for _, period := range periods {
+		rows := map[string]*DaysOutstanding{}
+		row := func(party string) *DaysOutstanding {
+			r, ok := rows[party]
+			if !ok {
+				r = &DaysOutstanding{Period: period, Party: party}
+				rows[party] = r
+			}
+			return r
+		}
+		weightedDays := map[string]float64{}
+		settled := map[string]float64{}
+		for _, e := range entries {
+			if e.Delinked || e.Company != company || PartyType(e.PartyType) != partyType || period.Compare(e.PostingDate) > 0 {
+				continue
+			}
+			r := row(e.Party)
+			amount := partyType.balance(e.Amount)
+			r.Outstanding += amount
+			if period.Contains(e.PostingDate) && amount > 0 && e.VoucherType == e.AgainstVoucherType && e.VoucherNo == e.AgainstVoucherNo {
+				r.Invoiced += amount
+			}
+		}
+		for _, inv := range invoices {
+			for _, s := range inv.settlements {
+				if amount := -partyType.balance(s.Amount); amount > 0 && period.Contains(s.PostingDate) {
+					days := daterange.New(inv.entry.PostingDate, s.PostingDate).Days()
+					weightedDays[inv.entry.Party] += amount * float64(days)
+					settled[inv.entry.Party] += amount
+				}
+			}
+		}
+
+		total := DaysOutstanding{Period: period}
+		var totalWeighted, totalSettled float64
+		parties := make([]DaysOutstanding, 0, len(rows))
+		for name, r := range rows {
+			total.Invoiced += r.Invoiced
+			total.Outstanding += r.Outstanding
+			totalWeighted += weightedDays[name]
+			totalSettled += settled[name]
+			r.finish(weightedDays[name], settled[name])
+			parties = append(parties, *r)
+		}
+		total.finish(totalWeighted, totalSettled)
+		slices.SortFunc(parties, func(a, b DaysOutstanding) int { return cmp.Compare(a.Party, b.Party) })
+		out = append(out, total)
+		out = append(out, parties...)
+	}
+	return out, nil
+}
+
+// finish rounds the amounts and derives the days.
+func (d *DaysOutstanding) finish(weightedDays, settled float64) {
+	d.Invoiced, d.Outstanding = ledger.Flt(d.Invoiced, 2), ledger.Flt(d.Outstanding, 2)
+	if d.Invoiced > 0 {
+		d.Days = ledger.Flt(d.Outstanding/d.Invoiced*float64(d.Period.Days()), 1)
+	}
+	if settled > 0 {
+		d.DaysToSettle = ledger.Flt(weightedDays/settled, 1)
+	}
+}
+
+// CreditPeriod is how long an invoice took to settle against the credit
+// period agreed for it.
+type CreditPeriod struct {
+	Invoice ledger.PaymentLedgerEntry // The invoice's own entry
+
+	// AgreedDays is the interest-free credit period: the days from
+	// posting to the due date of the payment terms, plus the grace days
+	// of the party's overdue policy.
+	AgreedDays int
+
+	SettledOn  time.Time // Zero while outstanding
+	ActualDays int       // From posting to settlement, or to the end of the period while outstanding
+	DaysLate   int       // ActualDays beyond AgreedDays; 0 within the credit period
+}
+
+// CreditCompliance is how well a party kept to its credit periods.
+type CreditCompliance struct {
+	Party string
+
+	Invoices    []CreditPeriod // Ordered by due date
+	WithinTerms int            // Invoices not late
+	Rate        float64        // Percent of Invoices within terms
+
+	AverageAgreedDays float64
+	AverageActualDays float64
+}
+
+// CreditPeriodCompliance compares, for the invoices of a company's parties
+// of one type falling due in the period, the days each took to settle
+// with the credit period agreed for it, ordered by party. Invoices without
+// a due date have no agreed period and are left out; one still
+// outstanding at the end of the period counts the days up to then.
+//
+// An invoice is settled on the day the settlements against it, taken in
+// date order, first cover it. With nil policies, or for parties without
+// a policy, the credit period ends on the due date.
+//
+// Maps to: the payment terms status of the Payment Terms Status for Sales
+// Order report and the credit_days of a Payment Term, checked against the
+// payment ledger
+func CreditPeriodCompliance(entries []ledger.PaymentLedgerEntry, company string, partyType PartyType, period daterange.Range, policies OverduePolicies) ([]CreditCompliance, error) {
+	if !period.Bounded() {
+		return nil, fmt.Errorf("%w: %s", ErrUnboundedPeriod, period)
+	}
+	asOf := period.Last()
+	byParty := map[string]*CreditCompliance{}
+	found := map[string]*OverduePolicy{}
+	for _, inv := range openItems(entries, company, partyType) {
+		due := inv.entry.DueDate
+		if due == nil || !period.Contains(*due) {
+			continue
+		}
+		party := inv.entry.Party
+		policy, ok := found[party]
+		if !ok && policies != nil {
+			var err error
+			if policy, err = policies.OverduePolicy(partyType, party); err != nil {
+				return nil, err
+			}
+		}
+		found[party] = policy
+
+		posted := daterange.Civil(inv.entry.PostingDate)
+		c := CreditPeriod{
+			Invoice:    inv.entry,
+			AgreedDays: daterange.New(posted, *due).Days() + policy.graceDays(),
+		}
+		outstanding := partyType.balance(inv.entry.Amount)
+		for _, s := range inv.settlements {
+			if daterange.Civil(s.PostingDate).After(asOf) {
+				break
+			}
+			if outstanding += partyType.balance(s.Amount); ledger.Flt(outstanding, 2) <= 0 {
+				c.SettledOn = daterange.Civil(s.PostingDate)
+				break
+			}
+		}
+		end := c.SettledOn
+		if end.IsZero() {
+			end = asOf
+		}
+		c.ActualDays = daterange.New(posted, end).Days()
+		c.DaysLate = max(c.ActualDays-c.AgreedDays, 0)
+
+		cc, ok := byParty[party]
+		if !ok {
+			cc = &CreditCompliance{Party: party}
+			byParty[party] = cc
+		}
+		cc.Invoices = append(cc.Invoices, c)
+	}
+
+	out := make([]CreditCompliance, 0, len(byParty))
+	for _, cc := range byParty {
+		slices.SortStableFunc(cc.Invoices, func(a, b CreditPeriod) int {
+			return a.Invoice.DueDate.Compare(*b.Invoice.DueDate)
+		})
+		var agreed, actual int
+		for _, c := range cc.Invoices {
+			if c.DaysLate == 0 {
+				cc.WithinTerms++
+			}
+			agreed += c.AgreedDays
+			actual += c.ActualDays
+		}
+		n := float64(len(cc.Invoices))
+		cc.Rate = ledger.Flt(float64(cc.WithinTerms)/n*100, 2)
+		cc.AverageAgreedDays = ledger.Flt(float64(agreed)/n, 1)
+		cc.AverageActualDays = ledger.Flt(float64(actual)/n, 1)
+		out = append(out, *cc)
+	}
+	slices.SortFunc(out, func(a, b CreditCompliance) int { return cmp.Compare(a.Party, b.Party) })
+	return out, nil
+}
+
+// openItem is an invoice and the entries against it.
+type openItem struct {
+	entry       ledger.PaymentLedgerEntry
+	settlements []ledger.PaymentLedgerEntry // In date order
+}
+
+// openItems returns the invoices of a company's parties of one type, in
+// the order they appear, with the entries against them. Entries against
+// themselves that do not add to the balance are advances, not invoices.
+func openItems(entries []ledger.PaymentLedgerEntry, company string, partyType PartyType) []*openItem {
+	byVoucher := map[[2]string]*openItem{}
+	var order [][2]string
+	for _, e := range entries {
+		if e.Delinked || e.Company != company || PartyType(e.PartyType) != partyType || e.AgainstVoucherNo == "" {
+			continue
+		}
+		k := [2]string{e.AgainstVoucherType, e.AgainstVoucherNo}
+		item, ok := byVoucher[k]
+		if !ok {
+			item = &openItem{}
+			byVoucher[k] = item
+			order = append(order, k)
+		}
+		if e.VoucherType == e.AgainstVoucherType && e.VoucherNo == e.AgainstVoucherNo {
+			item.entry = e
+		} else {
+			item.settlements = append(item.settlements, e)
+		}
+	}
+	var items []*openItem
+	for _, k := range order {
+		item := byVoucher[k]
+		if item.entry.VoucherNo == "" || partyType.balance(item.entry.Amount) <= 0 {
+			continue
+		}
+		slices.SortStableFunc(item.settlements, func(a, b ledger.PaymentLedgerEntry) int {
+			return a.PostingDate.Compare(b.PostingDate)
+		})
+		items = append(items, item)
+	}
+	return items
+}
+
+// balance returns a payment ledger amount as it adds to the balance owed
+// by or to a party of the type: payable balances are credits.
+func (t PartyType) balance(amount float64) float64 {
+	if t.AccountType() == Payable {
+		return -amount
+	}
+	return amount
+}
@@ -0,0 +1,121 @@
+package party
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/senguttuvang/erpnext-go/daterange"
+	"github.com/senguttuvang/erpnext-go/ledger"
+)
+
+// gracePolicies gives every party the same overdue policy.
+type gracePolicies struct{ policy *OverduePolicy }
+
+func (g gracePolicies) OverduePolicy(PartyType, string) (*OverduePolicy, error) { return g.policy, nil }
+
+func creditPeriodEntries() []ledger.PaymentLedgerEntry {
+	invoice := func(partyType PartyType, party, voucherType, no, posted, due string, amount float64) ledger.PaymentLedgerEntry {
+		d := day(due)
+		return ledger.PaymentLedgerEntry{
+			PostingDate: day(posted), Company: "A", PartyType: string(partyType), Party: party,
+			VoucherType: voucherType, VoucherNo: no, AgainstVoucherType: voucherType, AgainstVoucherNo: no,
+			Amount: amount, DueDate: &d,
+		}
+	}
+	payment := func(inv ledger.PaymentLedgerEntry, no, posted string, amount float64) ledger.PaymentLedgerEntry {
+		return ledger.PaymentLedgerEntry{
+			PostingDate: day(posted), Company: "A", PartyType: inv.PartyType, Party: inv.Party,
+			VoucherType: "Payment Entry", VoucherNo: no, AgainstVoucherType: inv.VoucherType, AgainstVoucherNo: inv.VoucherNo,
+			Amount: amount,
+		}
+	}
+	sinv1 := invoice(Customer, "Acme", "Sales Invoice", "SINV-1", "2024-01-01", "2024-01-31", 1000)
+	sinv2 := invoice(Customer, "Acme", "Sales Invoice", "SINV-2", "2024-01-10", "2024-02-09", 500)
+	pinv := invoice(Supplier, "Parts Co", "Purchase Invoice", "PINV-1", "2024-01-05", "2024-01-15", -300)
+	delinked := payment(sinv2, "PE-9", "2024-01-11", -500)
+	delinked.Delinked = true
+	return []ledger.PaymentLedgerEntry{
+		sinv1, sinv2, pinv, delinked,
+		payment(sinv1, "PE-1", "2024-01-20", -600),
+		payment(sinv1, "PE-2", "2024-02-05", -400),
+		payment(sinv2, "PE-3", "2024-02-01", -500),
+		payment(pinv, "PE-4", "2024-01-15", 300),
+	}
+}
+
+func TestDaysOutstandingReport(t *testing.T) {
+	jan := daterange.Inclusive(day("2024-01-01"), day("2024-01-31"))
+	feb := daterange.Inclusive(day("2024-02-01"), day("2024-02-29"))
+	got, err := DaysOutstandingReport(creditPeriodEntries(), "A", Customer, []daterange.Range{jan, feb})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []DaysOutstanding{
+		{Period: jan, Invoiced: 1500, Outstanding: 900, Days: 18.6, DaysToSettle: 19},
+		{Period: jan, Party: "Acme", Invoiced: 1500, Outstanding: 900, Days: 18.6, DaysToSettle: 19},
+		{Period: feb, DaysToSettle: 27.8},
+		{Period: feb, Party: "Acme", DaysToSettle: 27.8},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d rows, want %d: %+v", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("row %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	// Payables are credits, and give DPO
+	dpo, err := DaysOutstandingReport(creditPeriodEntries(), "A", Supplier, []daterange.Range{jan})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dpo) != 2 || dpo[0].Invoiced != 300 || dpo[0].Outstanding != 0 || dpo[0].DaysToSettle != 10 {
+		t.Errorf("DPO = %+v", dpo)
+	}
+
+	if _, err := DaysOutstandingReport(nil, "A", Customer, []daterange.Range{{Start: day("2024-01-01")}}); !errors.Is(err, ErrUnboundedPeriod) {
+		t.Errorf("unbounded period: err = %v", err)
+	}
+}
+
+func TestCreditPeriodCompliance(t *testing.T) {
+	period := daterange.Inclusive(day("2024-01-01"), day("2024-02-29"))
+	got, err := CreditPeriodCompliance(creditPeriodEntries(), "A", Customer, period, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || len(got[0].Invoices) != 2 {
+		t.Fatalf("compliance = %+v", got)
+	}
+	acme := got[0]
+	late, onTime := acme.Invoices[0], acme.Invoices[1]
+	if late.Invoice.VoucherNo != "SINV-1" || late.AgreedDays != 30 || late.ActualDays != 35 || late.DaysLate != 5 ||
+		!late.SettledOn.Equal(day("2024-02-05")) {
+		t.Errorf("SINV-1 = %+v", late)
+	}
+	if onTime.Invoice.VoucherNo != "SINV-2" || onTime.ActualDays != 22 || onTime.DaysLate != 0 {
+		t.Errorf("SINV-2 = %+v", onTime)
+	}
+	if acme.WithinTerms != 1 || acme.Rate != 50 || acme.AverageAgreedDays != 30 || acme.AverageActualDays != 28.5 {
+		t.Errorf("Acme = %+v", acme)
+	}
+
+	// Grace days extend the interest-free credit period
+	got, err = CreditPeriodCompliance(creditPeriodEntries(), "A", Customer, period, gracePolicies{&OverduePolicy{GraceDays: 10}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got[0].WithinTerms != 2 || got[0].Invoices[0].AgreedDays != 40 {
+		t.Errorf("with grace days: %+v", got[0])
+	}
+
+	// An invoice still open at the end of the period is late by then
+	got, err = CreditPeriodCompliance(creditPeriodEntries(), "A", Customer, daterange.Inclusive(day("2024-01-01"), day("2024-02-03")), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := got[0].Invoices[0]; !c.SettledOn.IsZero() || c.ActualDays != 33 || c.DaysLate != 3 {
+		t.Errorf("open invoice = %+v", c)
+	}
+}
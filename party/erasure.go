@@ -0,0 +1,99 @@
+package party
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/ledger"
+)
+
+// ErrNoErasureReference is returned for an erasure without the request it
+// fulfils.
+var ErrNoErasureReference = errors.New("erasure needs the reference of the request it fulfils")
+
+// ErasureRequest is a data subject's request to erase their personal
+// data.
+type ErasureRequest struct {
+	Reference   string // The request it fulfils, e.g. a ticket number
+	RequestedBy string // Who carried it out
+	At          time.Time
+}
+
+// ErasureCertificate records that a party was pseudonymized. It names the
+// party only by its token, so it can be kept as long as the books.
+type ErasureCertificate struct {
+	PartyType   PartyType
+	Token       string // Name the party's records and entries now carry
+	Reference   string
+	RequestedBy string
+	ErasedAt    time.Time
+	Entries     int // Ledger entries rewritten, across all ledgers
+}
+
+// CertificateStore keeps erasure certificates.
+type CertificateStore interface {
+	SaveCertificate(c *ErasureCertificate) error
+}
+
+// Eraser carries out erasure requests. It replaces a party's name with a
+// stable token, the party pseudonym of Anonymizer, in the party master
+// and in every ledger. Amounts, accounts, dates and the links between
+// entries stay as they are, so balances, outstanding amounts, ageing and
+// the party statements built from the ledgers come out the same under
+// the token.
+//
+// Maps to: the Personal Data Deletion Request of Frappe, which anonymizes
+// the fields of the documents that name a data subject
+type Eraser struct {
+	Store        Store
+	Ledgers      []ledger.PartyPseudonymizer // GL and payment ledger stores
+	Anonymizer   *ledger.Anonymizer          // Its salt must stay secret
+	Certificates CertificateStore
+}
+
+// Erase pseudonymizes a party and records the certificate. The master
+// keeps the party's type, group, territory, tax category, accounts and
+// payment terms under the token; its name, tax ID and email are dropped.
+// Ledgers are rewritten before the master, so an erasure that fails part
+// way can be run again.
+func (e *Eraser) Erase(partyType PartyType, name string, req ErasureRequest) (*ErasureCertificate, error) {
+	if strings.TrimSpace(req.Reference) == "" {
+		return nil, ErrNoErasureReference
+	}
+	p, err := e.Store.GetParty(partyType, name)
+	if err != nil {
+		return nil, err
+	}
+	token := e.Anonymizer.Party(string(partyType), name)
+
+	c := &ErasureCertificate{
+		PartyType: partyType, Token: token,
+		Reference: req.Reference, RequestedBy: req.RequestedBy, ErasedAt: req.At,
+	}
+	if c.ErasedAt.IsZero() {
+		c.ErasedAt = time.Now()
+	}
+	for _, l := range e.Ledgers {
+		n, err := l.PseudonymizeParty(string(partyType), name, token)
+		if err != nil {
+			return nil, err
+		}
+		c.Entries += n
+	}
+
+	erased := *p
+	erased.Name, erased.PartyName = token, token
+	erased.TaxID, erased.Email = "", ""
+	if err := e.Store.SaveParty(&erased); err != nil {
+		return nil, err
+	}
+	if err := e.Store.DeleteParty(partyType, name); err != nil {
+		return nil, err
+	}
+	if err := e.Certificates.SaveCertificate(c); err != nil {
+		return nil, fmt.Errorf("%s erased but not certified: %w", token, err)
+	}
+	return c, nil
+}
@@ -0,0 +1,177 @@
+package party
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Party account errors
+var (
+	ErrNoPartyAccount = errors.New("no default party account")
+	ErrGroupCycle     = errors.New("party group hierarchy has a cycle")
+	ErrPartyNotFound  = errors.New("party not found")
+)
+
+// Group is a node of the Customer Group or Supplier Group tree. Defaults
+// set on a group apply to every party below it that does not set its own.
+//
+// Maps to: Customer Group and Supplier Group doctypes (nested set trees)
+type Group struct {
+	Name         string
+	PartyType    PartyType
+	Parent       string // Empty for the root ("All Customer Groups")
+	Accounts     []PartyAccount
+	PaymentTerms string
+	Overdue      *OverduePolicy // Inherited by subgroups that set none
+}
+
+// PartyLookup abstracts queries for Customer and Supplier masters.
+// GetParty returns an error wrapping ErrPartyNotFound for a party that
+// does not exist.
+type PartyLookup interface {
+	GetParty(partyType PartyType, name string) (*Party, error)
+}
+
+// GroupLookup abstracts queries for party groups.
+type GroupLookup interface {
+	GetGroup(partyType PartyType, name string) (*Group, error)
+}
+
+// CompanyAccounts returns a company's fallback party accounts.
+type CompanyAccounts interface {
+	// DefaultPartyAccount returns default_receivable_account for customers,
+	// default_payable_account for suppliers and the payable account for
+	// employees' expense claims.
+	DefaultPartyAccount(company string, partyType PartyType) (string, error)
+}
+
+// PartyAccountResolver finds the defaults documents use for a party: the
+// party's own setting, else the nearest group up the tree that has one,
+// else the company default.
+//
+// Maps to: get_party_account() and get_payment_terms_template() in
+// erpnext/accounts/party.py (which look at the immediate group only)
+type PartyAccountResolver struct {
+	Parties   PartyLookup
+	Groups    GroupLookup     // Optional; no inheritance when nil
+	Companies CompanyAccounts // Optional; no company fallback when nil
+}
+
+// PartyAccount returns the receivable or payable account of a party in a
+// company.
+//
+// Python equivalent:
+//
+//	def get_party_account(party_type, party=None, company=None):
+//	    account = frappe.db.get_value("Party Account",
+//	        {"parenttype": party_type, "parent": party, "company": company}, "account")
+//	    if not account and party_type in ["Customer", "Supplier"]:
+//	        party_group_doctype = "Customer Group" if party_type == "Customer" else "Supplier Group"
+//	        group = frappe.get_cached_value(party_type, party, scrub(party_group_doctype))
+//	        account = frappe.db.get_value("Party Account",
+//	            {"parenttype": party_group_doctype, "parent": group, "company": company}, "account")
+//	    if not account and party_type in ["Customer", "Supplier"]:
+//	        default_account_name = "default_receivable_account" if party_type == "Customer" else "default_payable_account"
+//	        account = frappe.get_cached_value("Company", company, default_account_name)
+//	    return account
+func (r *PartyAccountResolver) PartyAccount(partyType PartyType, name, company string) (string, error) {
+	a, err := r.accounts(partyType, name, company)
+	if err != nil {
+		return "", err
+	}
+	if a.Account == "" && r.Companies != nil {
+		if a.Account, err = r.Companies.DefaultPartyAccount(company, partyType); err != nil {
+			return "", err
+		}
+	}
+	if a.Account == "" {
+		return "", fmt.Errorf("%w: %s %s in %s", ErrNoPartyAccount, partyType, name, company)
+	}
+	return a.Account, nil
+}
+
+// AdvanceAccount returns the account for advances, set on the same party
+// or group row as its party account; empty when there is none.
+func (r *PartyAccountResolver) AdvanceAccount(partyType PartyType, name, company string) (string, error) {
+	a, err := r.accounts(partyType, name, company)
+	return a.AdvanceAccount, err
+}
+
+// PaymentTerms returns the payment terms template of a party, inherited
+// from its groups when the party has none.
+func (r *PartyAccountResolver) PaymentTerms(partyType PartyType, name string) (string, error) {
+	p, err := r.Parties.GetParty(partyType, name)
+	if err != nil {
+		return "", err
+	}
+	if p.PaymentTerms != "" {
+		return p.PaymentTerms, nil
+	}
+	var terms string
+	err = r.walkGroups(partyType, p.Group, func(g *Group) bool {
+		terms = g.PaymentTerms
+		return terms != ""
+	})
+	return terms, err
+}
+
+// GroupPath returns a party's group followed by its ancestors, as
+// taxcalc.TaxRuleArgs expects. Without a GroupLookup only the party's own
+// group is returned.
+func (r *PartyAccountResolver) GroupPath(partyType PartyType, name string) ([]string, error) {
+	p, err := r.Parties.GetParty(partyType, name)
+	if err != nil || p.Group == "" {
+		return nil, err
+	}
+	if r.Groups == nil {
+		return []string{p.Group}, nil
+	}
+	var groups []string
+	err = r.walkGroups(partyType, p.Group, func(g *Group) bool {
+		groups = append(groups, g.Name)
+		return false
+	})
+	return groups, err
+}
+
+// accounts returns the nearest PartyAccount row for the company.
+func (r *PartyAccountResolver) accounts(partyType PartyType, name, company string) (PartyAccount, error) {
+	p, err := r.Parties.GetParty(partyType, name)
+	if err != nil {
+		return PartyAccount{}, err
+	}
+	if a, ok := accountFor(p.Accounts, company); ok {
+		return a, nil
+	}
+	var found PartyAccount
+	err = r.walkGroups(partyType, p.Group, func(g *Group) bool {
+		var ok bool
+		found, ok = accountFor(g.Accounts, company)
+		return ok
+	})
+	return found, err
+}
+
+// walkGroups visits a group and its ancestors, nearest first, until visit
+// returns true.
+func (r *PartyAccountResolver) walkGroups(partyType PartyType, name string, visit func(*Group) bool) error {
+	if r.Groups == nil {
+		return nil
+	}
+	seen := make(map[string]bool)
+	for name != "" {
+		if seen[name] {
+			return fmt.Errorf("%w: %s", ErrGroupCycle, name)
+		}
+		seen[name] = true
+		g, err := r.Groups.GetGroup(partyType, name)
+		if err != nil {
+			return err
+		}
+		if visit(g) {
+			return nil
+		}
+		name = g.Parent
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package party
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+)
+
+type directory struct {
+	parties map[string]*Party
+	groups  map[string]*Group
+}
+
+var errNotFound = errors.New("not found")
+
+func (d *directory) GetParty(partyType PartyType, name string) (*Party, error) {
+	if p, ok := d.parties[name]; ok && p.PartyType == partyType {
+		return p, nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrPartyNotFound, name)
+}
+
+func (d *directory) GetGroup(partyType PartyType, name string) (*Group, error) {
+	if g, ok := d.groups[name]; ok && g.PartyType == partyType {
+		return g, nil
+	}
+	return nil, errNotFound
+}
+
+type companyDefaults map[PartyType]string
+
+func (c companyDefaults) DefaultPartyAccount(company string, partyType PartyType) (string, error) {
+	return c[partyType], nil
+}
+
+func testDirectory() *directory {
+	return &directory{
+		groups: map[string]*Group{
+			"All Customer Groups": {Name: "All Customer Groups", PartyType: Customer, PaymentTerms: "Net 30"},
+			"Export": {Name: "Export", PartyType: Customer, Parent: "All Customer Groups",
+				Accounts: []PartyAccount{{Company: "ACME", Account: "Debtors USD - ACME", AdvanceAccount: "Advances USD - ACME"}}},
+			"Export EU": {Name: "Export EU", PartyType: Customer, Parent: "Export", PaymentTerms: "Net 60"},
+			"Loop A":    {Name: "Loop A", PartyType: Customer, Parent: "Loop B"},
+			"Loop B":    {Name: "Loop B", PartyType: Customer, Parent: "Loop A"},
+		},
+		parties: map[string]*Party{
+			"Own":      {Name: "Own", PartyType: Customer, Group: "Export EU", PaymentTerms: "Due on Receipt", Accounts: []PartyAccount{{Company: "ACME", Account: "Debtors Own - ACME"}}},
+			"Berlin":   {Name: "Berlin", PartyType: Customer, Group: "Export EU"},
+			"Local":    {Name: "Local", PartyType: Customer, Group: "All Customer Groups"},
+			"Looped":   {Name: "Looped", PartyType: Customer, Group: "Loop A"},
+			"Supplier": {Name: "Supplier", PartyType: Supplier},
+			"Jane Doe": {Name: "Jane Doe", PartyType: Employee,
+				Accounts: []PartyAccount{{Company: "Globex", Account: "Payroll Payable - GX", AdvanceAccount: "Employee Advances - GX"}}},
+		},
+	}
+}
+
+func TestPartyAccountResolver(t *testing.T) {
+	d := testDirectory()
+	r := &PartyAccountResolver{Parties: d, Groups: d, Companies: companyDefaults{Customer: "Debtors - ACME"}}
+
+	tests := []struct {
+		party, company, want string
+	}{
+		{"Own", "ACME", "Debtors Own - ACME"},
+		{"Berlin", "ACME", "Debtors USD - ACME"}, // Inherited from Export, two levels up
+		{"Berlin", "Other Co", "Debtors - ACME"}, // No group row for the company
+		{"Local", "ACME", "Debtors - ACME"},
+	}
+	for _, tt := range tests {
+		got, err := r.PartyAccount(Customer, tt.party, tt.company)
+		if err != nil || got != tt.want {
+			t.Errorf("PartyAccount(%s, %s) = %q, %v; want %q", tt.party, tt.company, got, err, tt.want)
+		}
+	}
+
+	if got, _ := r.AdvanceAccount(Customer, "Berlin", "ACME"); got != "Advances USD - ACME" {
+		t.Errorf("AdvanceAccount(Berlin) = %q", got)
+	}
+	if _, err := r.PartyAccount(Supplier, "Supplier", "ACME"); !errors.Is(err, ErrNoPartyAccount) {
+		t.Errorf("supplier without default: got %v, want ErrNoPartyAccount", err)
+	}
+	if _, err := r.PartyAccount(Customer, "Looped", "ACME"); !errors.Is(err, ErrGroupCycle) {
+		t.Errorf("cyclic groups: got %v, want ErrGroupCycle", err)
+	}
+	if _, err := r.PartyAccount(Customer, "Nobody", "ACME"); !errors.Is(err, ErrPartyNotFound) {
+		t.Errorf("unknown party: got %v", err)
+	}
+}
+
+func TestEmployeeParty(t *testing.T) {
+	d := testDirectory()
+	r := &PartyAccountResolver{Parties: d, Groups: d, Companies: companyDefaults{Employee: "Expense Claims Payable - ACME"}}
+
+	if got, err := r.PartyAccount(Employee, "Jane Doe", "ACME"); err != nil || got != "Expense Claims Payable - ACME" {
+		t.Errorf("company default = %q, %v", got, err)
+	}
+	if got, _ := r.PartyAccount(Employee, "Jane Doe", "Globex"); got != "Payroll Payable - GX" {
+		t.Errorf("own account = %q", got)
+	}
+	if got, _ := r.AdvanceAccount(Employee, "Jane Doe", "Globex"); got != "Employee Advances - GX" {
+		t.Errorf("advance account = %q", got)
+	}
+	if Employee.AccountType() != Payable || Supplier.AccountType() != Payable || Customer.AccountType() != Receivable {
+		t.Errorf("account types = %s %s %s", Employee.AccountType(), Supplier.AccountType(), Customer.AccountType())
+	}
+}
+
+func TestPaymentTermsInheritance(t *testing.T) {
+	d := testDirectory()
+	r := &PartyAccountResolver{Parties: d, Groups: d}
+	for party, want := range map[string]string{"Own": "Due on Receipt", "Berlin": "Net 60", "Local": "Net 30"} {
+		if got, err := r.PaymentTerms(Customer, party); err != nil || got != want {
+			t.Errorf("PaymentTerms(%s) = %q, %v; want %q", party, got, err, want)
+		}
+	}
+	if got, _ := (&PartyAccountResolver{Parties: d}).PaymentTerms(Customer, "Berlin"); got != "" {
+		t.Errorf("without groups: %q", got)
+	}
+}
+
+func TestGroupPath(t *testing.T) {
+	d := testDirectory()
+	r := &PartyAccountResolver{Parties: d, Groups: d}
+	if got, err := r.GroupPath(Customer, "Berlin"); err != nil || !slices.Equal(got, []string{"Export EU", "Export", "All Customer Groups"}) {
+		t.Errorf("GroupPath(Berlin) = %q, %v", got, err)
+	}
+	if got, err := r.GroupPath(Supplier, "Supplier"); err != nil || got != nil {
+		t.Errorf("GroupPath(Supplier) = %q, %v", got, err)
+	}
+	if got, _ := (&PartyAccountResolver{Parties: d}).GroupPath(Customer, "Berlin"); !slices.Equal(got, []string{"Export EU"}) {
+		t.Errorf("without groups: %q", got)
+	}
+}
@@ -0,0 +1,115 @@
+// Package party implements the customer, supplier and employee masters
+// from ERPNext that accounting documents draw their defaults from.
+// Employees are parties of the payables side: their advances are debits
+// and the expense claims reimbursed to them credits, on the payable
+// account the company sets for employees.
+// Migrated from: erpnext/accounts/party.py
+package party
+
+// PartyType identifies the doctype of a party.
+type PartyType string
+
+const (
+	Customer PartyType = "Customer"
+	Supplier PartyType = "Supplier"
+	Employee PartyType = "Employee"
+)
+
+// Party account types
+const (
+	Receivable = "Receivable"
+	Payable    = "Payable"
+)
+
+// AccountType returns the type of the accounts a party type posts to:
+// Receivable for customers, Payable for suppliers and employees.
+//
+// Maps to: get_party_account_type() in erpnext/accounts/party.py
+func (t PartyType) AccountType() string {
+	if t == Customer {
+		return Receivable
+	}
+	return Payable
+}
+
+// Party is a customer, supplier or employee. Employees have no group or
+// territory.
+// Maps to: Customer, Supplier and Employee doctypes
+type Party struct {
+	Name        string
+	PartyType   PartyType
+	PartyName   string
+	TaxCategory string
+	TaxID       string
+	Email       string
+	Disabled    bool
+
+	TaxWithholdingCategory string // Suppliers whose payments are subject to TDS
+
+	Group        string         // Customer Group or Supplier Group
+	Territory    string         // Customers only
+	Accounts     []PartyAccount // Receivable/payable account per company
+	PaymentTerms string         // Payment Terms Template
+}
+
+// PartyAccount is a party's or group's default account in one company.
+// Maps to: Party Account child table
+type PartyAccount struct {
+	Company        string
+	Account        string // Receivable for customers, payable for suppliers and employees
+	AdvanceAccount string
+}
+
+// accountFor returns the account configured for a company, if any.
+func accountFor(accounts []PartyAccount, company string) (PartyAccount, bool) {
+	for _, a := range accounts {
+		if a.Company == company && a.Account != "" {
+			return a, true
+		}
+	}
+	return PartyAccount{}, false
+}
+
+// Address is a party address.
+// Maps to: frappe/contacts/doctype/address/address.json (with ERPNext's
+// tax_category custom field)
+type Address struct {
+	Name        string
+	AddressType string // Billing, Shipping, ...
+	Country     string
+	State       string
+	GSTIN       string
+	TaxCategory string
+}
+
+// AddressTaxCategorySource selects which address overrides the party's
+// tax category.
+// Maps to: Accounts Settings.determine_address_tax_category_from
+type AddressTaxCategorySource string
+
+const (
+	FromBillingAddress  AddressTaxCategorySource = "Billing Address"
+	FromShippingAddress AddressTaxCategorySource = "Shipping Address"
+)
+
+// TaxCategory returns the tax category of a transaction with the party.
+// The category of the configured address wins over the party's own; either
+// address may be nil.
+//
+// Maps to: get_address_tax_category() in erpnext/accounts/party.py
+func TaxCategory(p *Party, billing, shipping *Address, source AddressTaxCategorySource) string {
+	category := ""
+	if p != nil {
+		category = p.TaxCategory
+	}
+
+	address := billing
+	if source == FromShippingAddress {
+		address = shipping
+	}
+	if address != nil && address.TaxCategory != "" {
+		category = address.TaxCategory
+	}
+
+	return category
+}
@@ -0,0 +1,34 @@
+package party
+
+import "testing"
+
+func TestTaxCategory(t *testing.T) {
+	customer := &Party{Name: "Acme Corporation", PartyType: Customer, TaxCategory: "In-State"}
+	billing := &Address{Name: "Acme-Billing", TaxCategory: "Out-State"}
+	shipping := &Address{Name: "Acme-Shipping", TaxCategory: "Export"}
+	untagged := &Address{Name: "Acme-Office"}
+
+	tests := []struct {
+		name     string
+		party    *Party
+		billing  *Address
+		shipping *Address
+		source   AddressTaxCategorySource
+		want     string
+	}{
+		{"party only", customer, nil, nil, FromBillingAddress, "In-State"},
+		{"billing address wins", customer, billing, shipping, FromBillingAddress, "Out-State"},
+		{"shipping address wins", customer, billing, shipping, FromShippingAddress, "Export"},
+		{"default source is billing", customer, billing, shipping, "", "Out-State"},
+		{"untagged address keeps party", customer, untagged, nil, FromBillingAddress, "In-State"},
+		{"no party", nil, nil, shipping, FromShippingAddress, "Export"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := TaxCategory(tt.party, tt.billing, tt.shipping, tt.source); got != tt.want {
+				t.Errorf("TaxCategory() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,242 @@
+package party
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/senguttuvang/erpnext-go/ledger"
+)
+
+// Onboarding errors
+var (
+	ErrDuplicateParty = errors.New("possible duplicate party")
+	ErrPartyExists    = errors.New("party already exists")
+	ErrMergeTypes     = errors.New("cannot merge parties of different types")
+	ErrMergeSelf      = errors.New("cannot merge a party into itself")
+)
+
+// Store persists Customer and Supplier masters.
+type Store interface {
+	PartyLookup
+	ListParties(partyType PartyType) ([]*Party, error)
+	SaveParty(p *Party) error
+	DeleteParty(partyType PartyType, name string) error
+}
+
+// Match is an existing party that may be the same as a new one.
+type Match struct {
+	Party   *Party
+	Score   float64  // 0 to 1
+	Reasons []string // "tax_id", "email", "name" or "similar_name"
+}
+
+// Match score weights. A tax ID match alone reaches the default
+// threshold; a name needs the email to agree as well.
+const (
+	scoreTaxID       = 0.6
+	scoreName        = 0.3
+	scoreEmail       = 0.3
+	scoreSimilarName = 0.2 // Scaled by the share of name words in common
+
+	// DefaultDuplicateThreshold is the score from which Create refuses a
+	// party unless forced.
+	DefaultDuplicateThreshold = 0.6
+)
+
+// legalSuffixes are dropped when comparing names.
+var legalSuffixes = []string{
+	"co", "company", "corp", "corporation", "gmbh", "inc", "incorporated",
+	"limited", "llc", "llp", "ltd", "plc", "private", "pvt", "sa", "ag",
+}
+
+// NormalizeName reduces a party name to lower-case words without
+// punctuation or legal suffixes, so that "ACME Pvt. Ltd." and "Acme" agree.
+func NormalizeName(name string) string {
+	words := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '&'
+	})
+	for len(words) > 1 && slices.Contains(legalSuffixes, words[len(words)-1]) {
+		words = words[:len(words)-1]
+	}
+	return strings.Join(words, " ")
+}
+
+// normalizeTaxID drops spaces, dashes and case from a tax ID.
+func normalizeTaxID(id string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToUpper(r)
+		}
+		return -1
+	}, id)
+}
+
+// FindDuplicates scores the existing parties of the same type against a
+// candidate and returns those scoring above zero, best first.
+func FindDuplicates(candidate *Party, existing []*Party) []Match {
+	name := NormalizeName(partyName(candidate))
+	taxID := normalizeTaxID(candidate.TaxID)
+	email := strings.ToLower(strings.TrimSpace(candidate.Email))
+
+	var matches []Match
+	for _, p := range existing {
+		if p.PartyType != candidate.PartyType || p.Name == candidate.Name {
+			continue
+		}
+		m := Match{Party: p}
+		if taxID != "" && normalizeTaxID(p.TaxID) == taxID {
+			m.Score += scoreTaxID
+			m.Reasons = append(m.Reasons, "tax_id")
+		}
+		if email != "" && strings.EqualFold(strings.TrimSpace(p.Email), email) {
+			m.Score += scoreEmail
+			m.Reasons = append(m.Reasons, "email")
+		}
+		other := NormalizeName(partyName(p))
+		if name != "" && other == name {
+			m.Score += scoreName
+			m.Reasons = append(m.Reasons, "name")
+		} else if overlap := wordOverlap(name, other); overlap >= 0.5 {
+			m.Score += scoreSimilarName * overlap
+			m.Reasons = append(m.Reasons, "similar_name")
+		}
+		if m.Score > 0 {
+			m.Score = min(ledger.Flt(m.Score, 2), 1)
+			matches = append(matches, m)
+		}
+	}
+	slices.SortStableFunc(matches, func(a, b Match) int {
+		switch {
+		case a.Score > b.Score:
+			return -1
+		case a.Score < b.Score:
+			return 1
+		}
+		return strings.Compare(a.Party.Name, b.Party.Name)
+	})
+	return matches
+}
+
+// wordOverlap returns the share of distinct words two names have in
+// common (Jaccard similarity).
+func wordOverlap(a, b string) float64 {
+	wa, wb := strings.Fields(a), strings.Fields(b)
+	if len(wa) == 0 || len(wb) == 0 {
+		return 0
+	}
+	union := map[string]bool{}
+	for _, w := range wa {
+		union[w] = true
+	}
+	common := 0
+	for _, w := range wb {
+		if union[w] {
+			common++
+		}
+		union[w] = true
+	}
+	return float64(common) / float64(len(union))
+}
+
+func partyName(p *Party) string {
+	if p.PartyName != "" {
+		return p.PartyName
+	}
+	return p.Name
+}
+
+// Onboarding creates and merges Customer and Supplier masters.
+type Onboarding struct {
+	Store     Store
+	Threshold float64 // Duplicate score that blocks creation; DefaultDuplicateThreshold when zero
+
+	// Ledgers whose party references follow a merge: typically the GL
+	// and payment ledger stores.
+	Ledgers []ledger.PartyRelinker
+}
+
+// Create saves a new party. Unless force is set, it refuses with
+// ErrDuplicateParty when an existing party scores at or above the
+// threshold, returning the matches so the user can pick one or confirm.
+// Lower-scoring matches are returned with a successful creation.
+//
+// Maps to: Customer.validate() / Supplier.validate() together with the
+// duplicate warnings of the customer quick entry
+func (o *Onboarding) Create(p *Party, force bool) ([]Match, error) {
+	existing, err := o.Store.GetParty(p.PartyType, p.Name)
+	if err != nil && !errors.Is(err, ErrPartyNotFound) {
+		return nil, err
+	}
+	if err == nil && existing != nil {
+		return nil, fmt.Errorf("%w: %s %s", ErrPartyExists, p.PartyType, p.Name)
+	}
+	parties, err := o.Store.ListParties(p.PartyType)
+	if err != nil {
+		return nil, err
+	}
+	matches := FindDuplicates(p, parties)
+	threshold := o.Threshold
+	if threshold == 0 {
+		threshold = DefaultDuplicateThreshold
+	}
+	if !force && len(matches) > 0 && matches[0].Score >= threshold {
+		return matches, fmt.Errorf("%w: %s %s resembles %s", ErrDuplicateParty, p.PartyType, p.Name, matches[0].Party.Name)
+	}
+	if err := o.Store.SaveParty(p); err != nil {
+		return nil, err
+	}
+	return matches, nil
+}
+
+// Merge folds party from into party into: the ledgers' entries move to
+// into, into takes over from's accounts for companies it has none for and
+// any of its blank details, and from is deleted.
+//
+// Maps to: frappe.rename_doc(doctype, old, new, merge=True)
+func (o *Onboarding) Merge(partyType PartyType, from, into string) (*Party, error) {
+	if from == into {
+		return nil, fmt.Errorf("%w: %s %s", ErrMergeSelf, partyType, from)
+	}
+	src, err := o.Store.GetParty(partyType, from)
+	if err != nil {
+		return nil, err
+	}
+	dst, err := o.Store.GetParty(partyType, into)
+	if err != nil {
+		return nil, err
+	}
+	if src.PartyType != dst.PartyType {
+		return nil, fmt.Errorf("%w: %s and %s", ErrMergeTypes, src.PartyType, dst.PartyType)
+	}
+
+	for _, l := range o.Ledgers {
+		if _, err := l.RelinkParty(string(partyType), from, into); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, a := range src.Accounts {
+		if _, ok := accountFor(dst.Accounts, a.Company); !ok {
+			dst.Accounts = append(dst.Accounts, a)
+		}
+	}
+	for _, f := range []struct{ dst, src *string }{
+		{&dst.TaxID, &src.TaxID}, {&dst.Email, &src.Email}, {&dst.TaxCategory, &src.TaxCategory},
+		{&dst.Group, &src.Group}, {&dst.Territory, &src.Territory}, {&dst.PaymentTerms, &src.PaymentTerms},
+		{&dst.TaxWithholdingCategory, &src.TaxWithholdingCategory},
+	} {
+		if *f.dst == "" {
+			*f.dst = *f.src
+		}
+	}
+	if err := o.Store.SaveParty(dst); err != nil {
+		return nil, err
+	}
+	if err := o.Store.DeleteParty(partyType, from); err != nil {
+		return nil, err
+	}
+	return dst, nil
+}
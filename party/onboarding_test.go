@@ -0,0 +1,190 @@
+package party
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/ledger"
+	"github.com/senguttuvang/erpnext-go/ledger/memstore"
+)
+
+func (d *directory) ListParties(partyType PartyType) ([]*Party, error) {
+	var out []*Party
+	for _, p := range d.parties {
+		if p.PartyType == partyType {
+			out = append(out, p)
+		}
+	}
+	slices.SortFunc(out, func(a, b *Party) int { return strings.Compare(a.Name, b.Name) })
+	return out, nil
+}
+
+func (d *directory) SaveParty(p *Party) error {
+	d.parties[p.Name] = p
+	return nil
+}
+
+func (d *directory) DeleteParty(partyType PartyType, name string) error {
+	delete(d.parties, name)
+	return nil
+}
+
+func TestNormalizeName(t *testing.T) {
+	for in, want := range map[string]string{
+		"ACME Pvt. Ltd.":   "acme",
+		"  Globex,  Inc ":  "globex",
+		"Smith & Sons Co.": "smith & sons",
+		"Limited":          "limited",
+		"Müller GmbH":      "müller",
+		"Tata Consultancy": "tata consultancy",
+	} {
+		if got := NormalizeName(in); got != want {
+			t.Errorf("NormalizeName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestFindDuplicates(t *testing.T) {
+	existing := []*Party{
+		{Name: "CUST-1", PartyType: Customer, PartyName: "ACME Pvt Ltd", TaxID: "27AAACA1234A1Z5", Email: "ap@acme.in"},
+		{Name: "CUST-2", PartyType: Customer, PartyName: "Acme Traders"},
+		{Name: "CUST-3", PartyType: Customer, PartyName: "Globex"},
+		{Name: "SUPP-1", PartyType: Supplier, PartyName: "ACME"},
+	}
+	matches := FindDuplicates(&Party{Name: "CUST-9", PartyType: Customer, PartyName: "Acme Limited", TaxID: "27 aaaca1234a1z5", Email: "AP@acme.in"}, existing)
+	if len(matches) != 2 || matches[0].Party.Name != "CUST-1" || matches[0].Score != 1 ||
+		!slices.Equal(matches[0].Reasons, []string{"tax_id", "email", "name"}) {
+		t.Fatalf("matches = %+v", matches)
+	}
+	if matches[1].Party.Name != "CUST-2" || matches[1].Score != 0.1 || matches[1].Reasons[0] != "similar_name" {
+		t.Errorf("similar match = %+v", matches[1])
+	}
+}
+
+func TestOnboarding(t *testing.T) {
+	d := &directory{parties: map[string]*Party{
+		"CUST-1": {Name: "CUST-1", PartyType: Customer, PartyName: "ACME Pvt Ltd", TaxID: "27AAACA1234A1Z5",
+			Accounts: []PartyAccount{{Company: "ACME", Account: "Debtors - ACME"}}},
+	}}
+	gl, pl := memstore.NewGLStore(), memstore.NewPaymentStore()
+	o := &Onboarding{Store: d, Ledgers: []ledger.PartyRelinker{gl, pl}}
+
+	// A new party with the same tax ID is refused unless forced
+	dup := &Party{Name: "CUST-2", PartyType: Customer, PartyName: "Acme", TaxID: "27AAACA1234A1Z5", Email: "ap@acme.in",
+		Accounts: []PartyAccount{{Company: "ACME", Account: "Debtors USD - ACME"}, {Company: "ACME US", Account: "Debtors - AUS"}}}
+	matches, err := o.Create(dup, false)
+	if !errors.Is(err, ErrDuplicateParty) || len(matches) != 1 || d.parties["CUST-2"] != nil {
+		t.Fatalf("duplicate: %v, %+v", err, matches)
+	}
+	if _, err := o.Create(dup, true); err != nil || d.parties["CUST-2"] == nil {
+		t.Fatalf("forced: %v", err)
+	}
+	if _, err := o.Create(dup, true); !errors.Is(err, ErrPartyExists) {
+		t.Errorf("existing name: err = %v", err)
+	}
+	if matches, err := o.Create(&Party{Name: "CUST-3", PartyType: Customer, PartyName: "Acme Traders"}, false); err != nil || len(matches) != 2 {
+		t.Errorf("weak match: %v, %+v", err, matches)
+	}
+
+	// Merging moves ledger entries and fills in the survivor
+	date := time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)
+	gl.SaveBatch([]ledger.GLEntry{
+		{PostingDate: date, Account: "Debtors - ACME", PartyType: "Customer", Party: "CUST-2", VoucherNo: "SINV-1", Debit: 100},
+		{PostingDate: date, Account: "Debtors - ACME", PartyType: "Supplier", Party: "CUST-2", VoucherNo: "PINV-1", Credit: 5},
+	})
+	pl.Save(&ledger.PaymentLedgerEntry{PartyType: "Customer", Party: "CUST-2", VoucherNo: "SINV-1", Amount: 100})
+
+	merged, err := o.Merge(Customer, "CUST-2", "CUST-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.parties["CUST-2"] != nil || merged.Email != "ap@acme.in" || len(merged.Accounts) != 2 || merged.Accounts[0].Account != "Debtors - ACME" {
+		t.Errorf("merged = %+v", merged)
+	}
+	entries := gl.All()
+	if entries[0].Party != "CUST-1" || entries[1].Party != "CUST-2" || pl.All()[0].Party != "CUST-1" {
+		t.Errorf("ledgers not relinked: %s %s %s", entries[0].Party, entries[1].Party, pl.All()[0].Party)
+	}
+	if _, err := o.Merge(Customer, "CUST-2", "CUST-1"); err == nil {
+		t.Error("merged a deleted party")
+	}
+	if _, err := o.Merge(Customer, "CUST-1", "CUST-1"); !errors.Is(err, ErrMergeSelf) || d.parties["CUST-1"] == nil {
+		t.Errorf("merge into itself: err = %v", err)
+	}
+}
+
+// unreachable is a directory whose lookups fail.
+type unreachable struct{ *directory }
+
+func (unreachable) GetParty(partyType PartyType, name string) (*Party, error) {
+	return nil, errors.New("directory unavailable")
+}
+
+func TestCreateLookupFails(t *testing.T) {
+	d := &directory{parties: map[string]*Party{}}
+	o := &Onboarding{Store: unreachable{d}}
+	if _, err := o.Create(&Party{Name: "CUST-1", PartyType: Customer, PartyName: "ACME"}, true); err == nil || d.parties["CUST-1"] != nil {
+		t.Errorf("Create() = %v with an unreachable directory", err)
+	}
+}
+
+type certificates []*ErasureCertificate
+
+func (c *certificates) SaveCertificate(cert *ErasureCertificate) error {
+	*c = append(*c, cert)
+	return nil
+}
+
+func TestErase(t *testing.T) {
+	d := &directory{parties: map[string]*Party{
+		"Jane Doe": {Name: "Jane Doe", PartyType: Customer, PartyName: "Jane Doe", Email: "jane@example.com", TaxID: "DE123",
+			Group: "Retail", Accounts: []PartyAccount{{Company: "ACME", Account: "Debtors - ACME"}}},
+	}}
+	gl, pl := memstore.NewGLStore(), memstore.NewPaymentStore()
+	date := time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)
+	gl.SaveBatch([]ledger.GLEntry{
+		{PostingDate: date, Account: "Debtors - ACME", PartyType: "Customer", Party: "Jane Doe", VoucherNo: "SINV-1",
+			Against: "Sales - ACME", Debit: 100, Remarks: "Order by Jane Doe"},
+		{PostingDate: date, Account: "Sales - ACME", VoucherNo: "SINV-1", Against: "Jane Doe", Credit: 100,
+			AgainstRefs: []ledger.AgainstRef{{Kind: ledger.AgainstParty, PartyType: "Customer", Name: "Jane Doe"}}},
+		{PostingDate: date, Account: "Creditors - ACME", PartyType: "Supplier", Party: "Jane Doe Ltd", VoucherNo: "PINV-1", Credit: 5},
+	})
+	pl.Save(&ledger.PaymentLedgerEntry{PartyType: "Customer", Party: "Jane Doe", VoucherNo: "SINV-1", Amount: 100})
+	var certs certificates
+	e := &Eraser{
+		Store: d, Ledgers: []ledger.PartyPseudonymizer{gl, pl},
+		Anonymizer: ledger.NewAnonymizer("secret"), Certificates: &certs,
+	}
+
+	if _, err := e.Erase(Customer, "Jane Doe", ErasureRequest{}); !errors.Is(err, ErrNoErasureReference) {
+		t.Errorf("without reference: err = %v", err)
+	}
+	c, err := e.Erase(Customer, "Jane Doe", ErasureRequest{Reference: "DSR-7", RequestedBy: "dpo@acme.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	token := ledger.NewAnonymizer("secret").Party("Customer", "Jane Doe")
+	if c.Token != token || c.Entries != 3 || len(certs) != 1 || c.ErasedAt.IsZero() {
+		t.Errorf("certificate = %+v", c)
+	}
+
+	erased := d.parties[token]
+	if d.parties["Jane Doe"] != nil || erased == nil || erased.PartyName != token || erased.Email != "" || erased.TaxID != "" ||
+		erased.Group != "Retail" || erased.Accounts[0].Account != "Debtors - ACME" {
+		t.Errorf("master = %+v", erased)
+	}
+	entries := gl.All()
+	if entries[0].Party != token || entries[0].Debit != 100 || entries[0].Remarks != "Order by "+token ||
+		entries[1].Against != token || entries[1].AgainstRefs[0].Name != token || entries[1].Credit != 100 {
+		t.Errorf("GL = %+v", entries[:2])
+	}
+	if entries[2].Party != "Jane Doe Ltd" {
+		t.Errorf("another party changed: %+v", entries[2])
+	}
+	if p := pl.All()[0]; p.Party != token || p.Amount != 100 {
+		t.Errorf("payment ledger = %+v", p)
+	}
+}
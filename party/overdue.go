@@ -0,0 +1,201 @@
+package party
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/daterange"
+	"github.com/senguttuvang/erpnext-go/ledger"
+)
+
+// ErrInvalidOverduePolicy is returned for negative grace days or rates.
+var ErrInvalidOverduePolicy = errors.New("invalid overdue policy")
+
+// Compounding says how often overdue interest is added to the amount it
+// accrues on.
+type Compounding string
+
+const (
+	SimpleInterest  Compounding = ""
+	CompoundDaily   Compounding = "Daily"
+	CompoundMonthly Compounding = "Monthly"
+)
+
+// OverduePolicy says when an unpaid invoice counts as overdue and what
+// interest it bears. It is set on a Customer Group or Supplier Group and
+// applies to the parties below it.
+//
+// Grace days are interest-free: an invoice paid within them is never
+// overdue, and interest on a later payment accrues from the end of the
+// grace period.
+//
+// Maps to: the rate_of_interest of a Dunning Type and the overdue
+// handling in erpnext/accounts/doctype/dunning/dunning.py, keyed by
+// customer group instead of per dunning
+type OverduePolicy struct {
+	GraceDays    int
+	InterestRate float64 // Annual, in percent
+	Compounding  Compounding
+}
+
+// Validate checks the policy.
+func (p *OverduePolicy) Validate() error {
+	if p.GraceDays < 0 || p.InterestRate < 0 {
+		return fmt.Errorf("%w: grace days %d, interest rate %v", ErrInvalidOverduePolicy, p.GraceDays, p.InterestRate)
+	}
+	switch p.Compounding {
+	case SimpleInterest, CompoundDaily, CompoundMonthly:
+		return nil
+	}
+	return fmt.Errorf("%w: compounding %q", ErrInvalidOverduePolicy, p.Compounding)
+}
+
+// Overdue reports whether an invoice due on due is overdue on asOf. A nil
+// policy has no grace days.
+func (p *OverduePolicy) Overdue(due, asOf time.Time) bool {
+	return daterange.New(due, asOf).Days() > p.graceDays()
+}
+
+// Interest returns the overdue interest on outstanding for an invoice due
+// on due, as of asOf, rounded to the currency precision. Interest is
+// charged for the days after the grace period, on a 365-day year.
+//
+// Python equivalent (simple interest, as in dunning.py):
+//
+//	interest_per_year = flt(outstanding) * flt(rate_of_interest) / 100
+//	interest_amount = flt(interest_per_year * cint(overdue_days) / 365)
+func (p *OverduePolicy) Interest(outstanding float64, due, asOf time.Time) float64 {
+	if p == nil || p.InterestRate == 0 || outstanding <= 0 || !p.Overdue(due, asOf) {
+		return 0
+	}
+	start := daterange.Civil(due).AddDate(0, 0, p.GraceDays)
+	days := daterange.New(start, asOf).Days()
+	rate := p.InterestRate / 100
+	var interest float64
+	switch p.Compounding {
+	case CompoundDaily:
+		interest = outstanding * (math.Pow(1+rate/365, float64(days)) - 1)
+	case CompoundMonthly:
+		// Whole months compound; the days after the last full month
+		// bear simple interest on the compounded amount
+		months := 0
+		for !start.AddDate(0, months+1, 0).After(daterange.Civil(asOf)) {
+			months++
+		}
+		rest := daterange.New(start.AddDate(0, months, 0), asOf).Days()
+		interest = outstanding*math.Pow(1+rate/12, float64(months))*(1+rate*float64(rest)/365) - outstanding
+	default:
+		interest = outstanding * rate * float64(days) / 365
+	}
+	return ledger.Flt(interest, 2)
+}
+
+func (p *OverduePolicy) graceDays() int {
+	if p == nil {
+		return 0
+	}
+	return p.GraceDays
+}
+
+// OverduePolicy returns the overdue policy of the party's nearest group
+// that has one, or nil when none has.
+func (r *PartyAccountResolver) OverduePolicy(partyType PartyType, name string) (*OverduePolicy, error) {
+	p, err := r.Parties.GetParty(partyType, name)
+	if err != nil {
+		return nil, err
+	}
+	var policy *OverduePolicy
+	err = r.walkGroups(partyType, p.Group, func(g *Group) bool {
+		policy = g.Overdue
+		return policy != nil
+	})
+	return policy, err
+}
+
+// OverduePolicies finds the overdue policy of a party. PartyAccountResolver
+// implements it.
+type OverduePolicies interface {
+	OverduePolicy(partyType PartyType, name string) (*OverduePolicy, error)
+}
+
+// OverdueInvoice is an invoice unpaid past its due date and grace days.
+type OverdueInvoice struct {
+	Invoice     ledger.PaymentLedgerEntry // The invoice's own entry
+	Outstanding float64                   // Positive for receivables and payables alike
+	DaysOverdue int                       // Days past the due date, grace days included
+	Interest    float64
+}
+
+// OverdueInvoices returns the invoices with an outstanding balance that
+// are overdue on asOf under their party's policy. Outstanding is the sum
+// of non-delinked payment ledger entries against the invoice; advances
+// and overpayments never count as overdue. With nil policies, or for
+// parties without a policy, invoices are overdue the day after they are
+// due and bear no interest.
+//
+// Maps to: the Overdue status of get_status() in
+// erpnext/controllers/status_updater.py, with the grace days of the policy
+func OverdueInvoices(entries []ledger.PaymentLedgerEntry, asOf time.Time, policies OverduePolicies) ([]OverdueInvoice, error) {
+	type invoice struct {
+		entry       ledger.PaymentLedgerEntry
+		outstanding float64
+	}
+	invoices := make(map[string]*invoice)
+	var order []string
+	for _, e := range entries {
+		if e.Delinked {
+			continue
+		}
+		key := e.AgainstVoucherType + "\x00" + e.AgainstVoucherNo
+		inv, ok := invoices[key]
+		if !ok {
+			inv = &invoice{}
+			invoices[key] = inv
+			order = append(order, key)
+		}
+		if e.VoucherType == e.AgainstVoucherType && e.VoucherNo == e.AgainstVoucherNo {
+			inv.entry = e
+		}
+		inv.outstanding += e.Amount
+	}
+
+	found := make(map[string]*OverduePolicy) // Party type and name -> policy
+	var overdue []OverdueInvoice
+	for _, key := range order {
+		inv := invoices[key]
+		if inv.entry.VoucherNo == "" || inv.entry.DueDate == nil {
+			continue
+		}
+		outstanding := ledger.Flt(inv.outstanding, 2)
+		if PartyType(inv.entry.PartyType).AccountType() == Payable {
+			outstanding = -outstanding // Payable balances are credits
+		}
+		if outstanding <= 0 {
+			continue
+		}
+		var policy *OverduePolicy
+		if policies != nil {
+			partyKey := inv.entry.PartyType + "\x00" + inv.entry.Party
+			var ok bool
+			if policy, ok = found[partyKey]; !ok {
+				var err error
+				if policy, err = policies.OverduePolicy(PartyType(inv.entry.PartyType), inv.entry.Party); err != nil {
+					return nil, err
+				}
+				found[partyKey] = policy
+			}
+		}
+		due := *inv.entry.DueDate
+		if !policy.Overdue(due, asOf) {
+			continue
+		}
+		overdue = append(overdue, OverdueInvoice{
+			Invoice: inv.entry, Outstanding: outstanding,
+			DaysOverdue: daterange.New(due, asOf).Days(),
+			Interest:    policy.Interest(outstanding, due, asOf),
+		})
+	}
+	return overdue, nil
+}
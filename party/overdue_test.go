@@ -0,0 +1,142 @@
+package party
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/daterange"
+	"github.com/senguttuvang/erpnext-go/ledger"
+)
+
+func day(s string) time.Time {
+	t, err := ledger.ParseDate(s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestOverduePolicyInterest(t *testing.T) {
+	due := day("2024-01-31")
+	tests := []struct {
+		name   string
+		policy *OverduePolicy
+		asOf   string
+		want   float64
+	}{
+		{"within grace", &OverduePolicy{GraceDays: 10, InterestRate: 12}, "2024-02-10", 0},
+		{"simple", &OverduePolicy{GraceDays: 10, InterestRate: 12}, "2024-03-01", 6.58},
+		{"daily", &OverduePolicy{GraceDays: 10, InterestRate: 12, Compounding: CompoundDaily}, "2024-03-01", 6.60},
+		{"monthly", &OverduePolicy{GraceDays: 10, InterestRate: 12, Compounding: CompoundMonthly}, "2024-04-20", 23.45},
+		{"no rate", &OverduePolicy{GraceDays: 10}, "2024-04-20", 0},
+		{"no policy", nil, "2024-04-20", 0},
+	}
+	for _, tt := range tests {
+		if got := tt.policy.Interest(1000, due, day(tt.asOf)); got != tt.want {
+			t.Errorf("%s: interest = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	if err := (&OverduePolicy{GraceDays: -1}).Validate(); !errors.Is(err, ErrInvalidOverduePolicy) {
+		t.Errorf("negative grace days: %v", err)
+	}
+	if err := (&OverduePolicy{Compounding: "Yearly"}).Validate(); !errors.Is(err, ErrInvalidOverduePolicy) {
+		t.Errorf("unknown compounding: %v", err)
+	}
+}
+
+func TestOverdueInvoices(t *testing.T) {
+	d := testDirectory()
+	d.groups["Export"].Overdue = &OverduePolicy{GraceDays: 10, InterestRate: 12}
+	d.groups["All Customer Groups"].Overdue = &OverduePolicy{GraceDays: 40}
+	r := &PartyAccountResolver{Parties: d, Groups: d}
+
+	// Export EU inherits from Export, the nearest group with a policy
+	if p, err := r.OverduePolicy(Customer, "Berlin"); err != nil || p != d.groups["Export"].Overdue {
+		t.Errorf("Berlin policy = %+v, %v", p, err)
+	}
+
+	due := day("2024-01-31")
+	invoice := func(party, no string, amount float64) ledger.PaymentLedgerEntry {
+		return ledger.PaymentLedgerEntry{PartyType: "Customer", Party: party, VoucherType: "Sales Invoice", VoucherNo: no,
+			AgainstVoucherType: "Sales Invoice", AgainstVoucherNo: no, Amount: amount, DueDate: &due}
+	}
+	entries := []ledger.PaymentLedgerEntry{
+		invoice("Berlin", "SINV-1", 1500),
+		{PartyType: "Customer", Party: "Berlin", VoucherType: "Payment Entry", VoucherNo: "PE-1",
+			AgainstVoucherType: "Sales Invoice", AgainstVoucherNo: "SINV-1", Amount: -500},
+		invoice("Local", "SINV-2", 800), // Still within its 40 grace days
+		// An expense claim owed to an employee, less the advance set off
+		{PartyType: "Employee", Party: "Jane Doe", VoucherType: "Expense Claim", VoucherNo: "EXP-1",
+			AgainstVoucherType: "Expense Claim", AgainstVoucherNo: "EXP-1", Amount: -300, DueDate: &due},
+		{PartyType: "Employee", Party: "Jane Doe", VoucherType: "Journal Entry", VoucherNo: "JV-1",
+			AgainstVoucherType: "Expense Claim", AgainstVoucherNo: "EXP-1", Amount: 100},
+	}
+	overdue, err := OverdueInvoices(entries, day("2024-03-01"), r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(overdue) != 2 {
+		t.Fatalf("overdue = %+v", overdue)
+	}
+	if o := overdue[1]; o.Invoice.VoucherNo != "EXP-1" || o.Outstanding != 200 || o.Interest != 0 {
+		t.Errorf("EXP-1 = %+v", o)
+	}
+	if o := overdue[0]; o.Invoice.VoucherNo != "SINV-1" || o.Outstanding != 1000 || o.DaysOverdue != 30 || o.Interest != 6.58 {
+		t.Errorf("SINV-1 = %+v", o)
+	}
+
+	// Without policies every unpaid invoice past its due date is overdue
+	if overdue, _ := OverdueInvoices(entries, day("2024-03-01"), nil); len(overdue) != 3 || overdue[1].Interest != 0 {
+		t.Errorf("without policies = %+v", overdue)
+	}
+}
+
+func TestSupplierScorecards(t *testing.T) {
+	due := func(s string) *time.Time { d := day(s); return &d }
+	gl := []ledger.GLEntry{
+		{PostingDate: day("2024-01-05"), Company: "ACME", PartyType: "Supplier", Party: "Acme Supplies", VoucherType: PurchaseInvoice, VoucherNo: "PINV-1", Credit: 1000},
+		{PostingDate: day("2024-02-01"), Company: "ACME", PartyType: "Supplier", Party: "Acme Supplies", VoucherType: PurchaseInvoice, VoucherNo: "PINV-2", Credit: 500},
+		{PostingDate: day("2024-02-10"), Company: "ACME", PartyType: "Supplier", Party: "Acme Supplies", VoucherType: PurchaseInvoice, VoucherNo: "PINV-3",
+			VoucherSubtype: DebitNote, Debit: 100},
+		{PostingDate: day("2024-03-01"), Company: "ACME", PartyType: "Supplier", Party: "Acme Supplies", VoucherType: PurchaseInvoice, VoucherNo: "PINV-4", Credit: 200},
+		// Cancelled, with its reversal
+		{PostingDate: day("2024-03-05"), Company: "ACME", PartyType: "Supplier", Party: "Beta", VoucherType: PurchaseInvoice, VoucherNo: "PINV-5", Credit: 300, IsCancelled: true},
+		{PostingDate: day("2024-03-05"), Company: "ACME", PartyType: "Supplier", Party: "Beta", VoucherType: PurchaseInvoice, VoucherNo: "PINV-5", Debit: 300},
+	}
+	entry := func(date, voucherType, voucherNo, against string, amount float64, dueDate *time.Time) ledger.PaymentLedgerEntry {
+		return ledger.PaymentLedgerEntry{PostingDate: day(date), Company: "ACME", PartyType: "Supplier", Party: "Acme Supplies",
+			VoucherType: voucherType, VoucherNo: voucherNo, AgainstVoucherType: PurchaseInvoice, AgainstVoucherNo: against,
+			Amount: amount, DueDate: dueDate}
+	}
+	ple := []ledger.PaymentLedgerEntry{
+		entry("2024-01-05", PurchaseInvoice, "PINV-1", "PINV-1", -1000, due("2024-02-04")),
+		entry("2024-02-01", "Payment Entry", "PE-1", "PINV-1", 1000, nil), // On time, after 27 days
+		entry("2024-02-01", PurchaseInvoice, "PINV-2", "PINV-2", -500, due("2024-03-02")),
+		entry("2024-02-10", PurchaseInvoice, "PINV-3", "PINV-2", 100, nil), // Returned, not paid
+		entry("2024-03-12", "Payment Entry", "PE-2", "PINV-2", 400, nil),   // Late, after 40 days
+		entry("2024-03-01", PurchaseInvoice, "PINV-4", "PINV-4", -200, due("2024-04-01")),
+	}
+
+	q1 := daterange.Inclusive(day("2024-01-01"), day("2024-03-31"))
+	cards, err := SupplierScorecards(gl, ple, "ACME", q1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cards) != 2 || cards[1].Supplier != "Beta" || cards[1].Purchases != 0 {
+		t.Fatalf("scorecards = %+v", cards)
+	}
+	want := SupplierMetrics{
+		Supplier: "Acme Supplies", InvoicesDue: 2, PaidOnTime: 1, OnTimeRate: 50,
+		AverageDaysToPay: 30.7, // (1000 × 27 + 400 × 40) / 1400
+		Purchases:        1700, DebitNotes: 100, DebitNoteRatio: 5.88,
+	}
+	if cards[0] != want {
+		t.Errorf("Acme Supplies = %+v, want %+v", cards[0], want)
+	}
+
+	if _, err := SupplierScorecards(gl, ple, "ACME", daterange.Range{Start: day("2024-01-01")}); !errors.Is(err, ErrUnboundedScorecard) {
+		t.Errorf("unbounded period: %v", err)
+	}
+}
@@ -0,0 +1,148 @@
+package party
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/daterange"
+	"github.com/senguttuvang/erpnext-go/ledger"
+)
+
+// ErrUnboundedScorecard is returned for a scorecard period without a start
+// or an end.
+var ErrUnboundedScorecard = errors.New("supplier scorecard period must be bounded")
+
+// Voucher type and subtype of the supplier invoices a scorecard reads
+const (
+	PurchaseInvoice = "Purchase Invoice"
+	DebitNote       = "Debit Note" // Subtype of a return Purchase Invoice
+)
+
+// SupplierMetrics are the financial inputs of a supplier scorecard for a
+// period, read from the ledgers.
+//
+// Maps to: the variables of a Supplier Scorecard
+// (erpnext/buying/doctype/supplier_scorecard_variable), computed from the
+// payment ledger and GL instead of order and receipt documents
+type SupplierMetrics struct {
+	Supplier string
+
+	// Invoices due in the period, and those of them settled in full by
+	// their due date. One still open at the end of the period is late.
+	InvoicesDue int
+	PaidOnTime  int
+	OnTimeRate  float64 // Percent; 0 when none fell due
+
+	// AverageDaysToPay is the days from invoice to settlement, averaged
+	// over the payments and adjustments posted in the period and weighted
+	// by the amount each settled. Debit notes are not payments.
+	AverageDaysToPay float64
+
+	// Purchases are the supplier's invoices posted in the period, net of
+	// cancellations, and DebitNotes the returns against them, both in
+	// company currency.
+	Purchases      float64
+	DebitNotes     float64
+	DebitNoteRatio float64 // Percent of Purchases; 0 without purchases
+}
+
+// SupplierScorecards computes the metrics of every supplier with entries
+// of a company in the period, ordered by supplier. Settlements come from
+// the payment ledger, purchases and debit notes from the GL; debit notes
+// are the Purchase Invoice entries with the DebitNote subtype.
+//
+// Cancelled GL entries are counted with their reversals, so a cancelled
+// invoice nets to nothing; delinked payment ledger entries are skipped.
+func SupplierScorecards(gl []ledger.GLEntry, ple []ledger.PaymentLedgerEntry, company string, period daterange.Range) ([]SupplierMetrics, error) {
+	if !period.Bounded() {
+		return nil, fmt.Errorf("%w: %s", ErrUnboundedScorecard, period)
+	}
+	metrics := map[string]*SupplierMetrics{}
+	supplier := func(name string) *SupplierMetrics {
+		m, ok := metrics[name]
+		if !ok {
+			m = &SupplierMetrics{Supplier: name}
+			metrics[name] = m
+		}
+		return m
+	}
+
+	debitNotes := map[string]bool{}
+	for _, e := range gl {
+		if e.Company != company || PartyType(e.PartyType) != Supplier || e.VoucherType != PurchaseInvoice {
+			continue
+		}
+		if e.VoucherSubtype == DebitNote {
+			debitNotes[e.VoucherNo] = true
+		}
+		if !period.Contains(e.PostingDate) {
+			continue
+		}
+		m := supplier(e.Party)
+		if e.VoucherSubtype == DebitNote {
+			m.DebitNotes += e.Debit - e.Credit
+		} else {
+			m.Purchases += e.Credit - e.Debit
+		}
+	}
+
+	asOf := period.Last()
+	weightedDays := map[string]float64{}
+	settled := map[string]float64{}
+	for _, inv := range openItems(ple, company, Supplier) {
+		name := inv.entry.Party
+		// Debit notes reduce what is owed without paying it
+		outstanding := -inv.entry.Amount
+		var payments []ledger.PaymentLedgerEntry
+		for _, s := range inv.settlements {
+			if s.VoucherType == PurchaseInvoice && debitNotes[s.VoucherNo] {
+				outstanding -= s.Amount
+			} else {
+				payments = append(payments, s)
+			}
+		}
+		var paidOn time.Time
+		for _, s := range payments {
+			if daterange.Civil(s.PostingDate).After(asOf) {
+				break
+			}
+			outstanding -= s.Amount
+			if period.Contains(s.PostingDate) && s.Amount > 0 {
+				supplier(name)
+				days := daterange.New(inv.entry.PostingDate, s.PostingDate).Days()
+				weightedDays[name] += s.Amount * float64(days)
+				settled[name] += s.Amount
+			}
+			if paidOn.IsZero() && ledger.Flt(outstanding, 2) <= 0 {
+				paidOn = daterange.Civil(s.PostingDate)
+			}
+		}
+		if due := inv.entry.DueDate; due != nil && period.Contains(*due) {
+			m := supplier(name)
+			m.InvoicesDue++
+			if !paidOn.IsZero() && !paidOn.After(daterange.Civil(*due)) {
+				m.PaidOnTime++
+			}
+		}
+	}
+
+	out := make([]SupplierMetrics, 0, len(metrics))
+	for name, m := range metrics {
+		m.Purchases, m.DebitNotes = ledger.Flt(m.Purchases, 2), ledger.Flt(m.DebitNotes, 2)
+		if m.InvoicesDue > 0 {
+			m.OnTimeRate = ledger.Flt(float64(m.PaidOnTime)/float64(m.InvoicesDue)*100, 2)
+		}
+		if settled[name] > 0 {
+			m.AverageDaysToPay = ledger.Flt(weightedDays[name]/settled[name], 1)
+		}
+		if m.Purchases > 0 {
+			m.DebitNoteRatio = ledger.Flt(m.DebitNotes/m.Purchases*100, 2)
+		}
+		out = append(out, *m)
+	}
+	slices.SortFunc(out, func(a, b SupplierMetrics) int { return cmp.Compare(a.Supplier, b.Supplier) })
+	return out, nil
+}
@@ -0,0 +1,34 @@
+package paymententry
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/senguttuvang/erpnext-go/naming"
+)
+
+// Amend returns a draft copy of a cancelled payment, named as its
+// amendment. The references are kept, so once submitted the amendment
+// settles the same invoices; entries of other vouchers booked against the
+// original, such as an advance adjusted by a journal entry, move onto it.
+//
+// Maps to: the Amend action (frappe.model.copy_doc with amended_from) and
+// _get_amended_name() in frappe/model/naming.py
+func (pe *PaymentEntry) Amend() (*PaymentEntry, error) {
+	if pe.Status != Cancelled {
+		return nil, fmt.Errorf("%w: %s is %s", ErrNotCancelled, pe.Name, pe.Status)
+	}
+
+	amended := *pe
+	amended.References = slices.Clone(pe.References)
+	amended.Deductions = slices.Clone(pe.Deductions)
+	if pe.ReferenceDate != nil {
+		d := *pe.ReferenceDate
+		amended.ReferenceDate = &d
+	}
+	amended.Name = naming.AmendedName(pe.Name, pe.AmendedFrom != "")
+	amended.AmendedFrom = pe.Name
+	amended.Status = Draft
+	amended.Return = nil
+	return &amended, nil
+}
@@ -0,0 +1,143 @@
+package paymententry
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/ledger"
+)
+
+// ReturnVoucherType is the voucher type of the entry recording a returned
+// payment. ERPNext has no dedicated doctype; bounced cheques are booked with
+// a Journal Entry.
+const ReturnVoucherType = "Journal Entry"
+
+// ReturnReason is the bank's reason code for dishonoring a payment.
+type ReturnReason string
+
+const (
+	InsufficientFunds ReturnReason = "Insufficient Funds"
+	SignatureMismatch ReturnReason = "Signature Mismatch"
+	PaymentStopped    ReturnReason = "Payment Stopped by Drawer"
+	AccountClosed     ReturnReason = "Account Closed"
+	StaleCheque       ReturnReason = "Stale Cheque"
+	OtherReason       ReturnReason = "Other"
+)
+
+// Return records a dishonored payment and its charges.
+type Return struct {
+	Name   string // Voucher number of the return entry
+	Date   time.Time
+	Reason ReturnReason
+	Notes  string
+
+	// Charges the bank deducts for the returned instrument
+	BankCharges        float64
+	BankChargesAccount string
+
+	// Penalty charged to the party, booked as a new receivable
+	Penalty        float64
+	PenaltyAccount string // Income account
+}
+
+// Dishonor records that the bank returned a received payment. The
+// payment's GL entries are reversed on the return date, which restores the
+// outstanding of every invoice it settled and of its advance; bank charges
+// are expensed against the bank account, and a penalty, if any, is
+// receivable from the party against the return entry. The payment is then
+// marked as returned.
+//
+// The original entries are left in place, so the return is visible in the
+// ledger as its own voucher.
+func (pe *PaymentEntry) Dishonor(engine *ledger.Engine, ret Return) error {
+	switch {
+	case pe.Status == Returned:
+		return fmt.Errorf("%w: %s", ErrPaymentReturned, pe.Name)
+	case pe.Status != Submitted:
+		return fmt.Errorf("%w: %s", ErrNotSubmitted, pe.Name)
+	case pe.PaymentType != Receive:
+		return ErrNotReceipt
+	case ret.BankCharges < 0 || ret.Penalty < 0:
+		return ErrInvalidAmount
+	case (ret.BankCharges != 0 && ret.BankChargesAccount == "") || (ret.Penalty != 0 && ret.PenaltyAccount == ""):
+		return ErrMissingAccount
+	}
+	if ret.Reason == "" {
+		ret.Reason = OtherReason
+	}
+
+	original, err := engine.GLStore.GetByVoucher(VoucherType, pe.Name)
+	if err != nil {
+		return err
+	}
+
+	var glMap []ledger.GLEntry
+	for i := range original {
+		if original[i].IsCancelled {
+			continue
+		}
+		entry := pe.newReturnEntry(ret, original[i].Account)
+		entry.PartyType = original[i].PartyType
+		entry.Party = original[i].Party
+		entry.Against = original[i].Against
+		entry.AgainstVoucherType = original[i].AgainstVoucherType
+		entry.AgainstVoucher = original[i].AgainstVoucher
+		entry.DueDate = original[i].DueDate
+		entry.CostCenter = original[i].CostCenter
+		entry.IsAdvance = original[i].IsAdvance
+		setAmount(&entry, original[i].Credit, true)
+		setAmount(&entry, original[i].Debit, false)
+		glMap = append(glMap, entry)
+	}
+
+	if ret.BankCharges != 0 {
+		charges := pe.newReturnEntry(ret, ret.BankChargesAccount)
+		charges.Against = pe.PaidTo
+		setAmount(&charges, ret.BankCharges, true)
+
+		bank := pe.newReturnEntry(ret, pe.PaidTo)
+		bank.Against = ret.BankChargesAccount
+		setAmount(&bank, ret.BankCharges, false)
+
+		glMap = append(glMap, charges, bank)
+	}
+
+	if ret.Penalty != 0 {
+		receivable := pe.newReturnEntry(ret, pe.PaidFrom)
+		receivable.PartyType = pe.PartyType
+		receivable.Party = pe.Party
+		receivable.Against = ret.PenaltyAccount
+		receivable.AgainstVoucherType = ReturnVoucherType
+		receivable.AgainstVoucher = ret.Name
+		setAmount(&receivable, ret.Penalty, true)
+
+		income := pe.newReturnEntry(ret, ret.PenaltyAccount)
+		income.Against = pe.Party
+		setAmount(&income, ret.Penalty, false)
+
+		glMap = append(glMap, receivable, income)
+	}
+
+	if err := engine.MakeGLEntries(glMap, ledger.DefaultPostingOptions()); err != nil {
+		return err
+	}
+
+	pe.Status = Returned
+	pe.Return = &ret
+	return nil
+}
+
+// newReturnEntry returns an entry of the return voucher for an account.
+func (pe *PaymentEntry) newReturnEntry(ret Return, account string) ledger.GLEntry {
+	entry := pe.newGLEntry()
+	entry.VoucherType = ReturnVoucherType
+	entry.VoucherNo = ret.Name
+	entry.PostingDate = ret.Date
+	entry.TransactionDate = ret.Date
+	entry.Account = account
+	entry.Remarks = fmt.Sprintf("Payment %s returned: %s", pe.Name, ret.Reason)
+	if ret.Notes != "" {
+		entry.Remarks += " (" + ret.Notes + ")"
+	}
+	return entry
+}
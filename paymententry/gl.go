@@ -0,0 +1,318 @@
+package paymententry
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/ledger"
+	"github.com/senguttuvang/erpnext-go/modeofpayment"
+)
+
+// GetGLEntries validates the payment and builds its GL map.
+//
+// Maps to: build_gl_map() in payment_entry.py
+//
+// Python equivalent:
+//
+//	def build_gl_map(self):
+//	    gl_entries = []
+//	    self.add_party_gl_entries(gl_entries)
+//	    self.add_bank_gl_entries(gl_entries)
+//	    self.add_deductions_gl_entries(gl_entries)
+//	    self.add_tax_gl_entries(gl_entries)
+//	    return gl_entries
+func (pe *PaymentEntry) GetGLEntries() ([]ledger.GLEntry, error) {
+	if err := pe.Validate(); err != nil {
+		return nil, err
+	}
+
+	var glMap []ledger.GLEntry
+	glMap = pe.addPartyGLEntries(glMap)
+	glMap = pe.addBankGLEntries(glMap)
+	glMap = pe.addDeductionsGLEntries(glMap)
+	return glMap, nil
+}
+
+// Submit posts the payment's GL entries through the engine. An amendment
+// also takes over the entries booked against the payment it amends.
+//
+// Maps to: on_submit() -> make_gl_entries() in payment_entry.py
+func (pe *PaymentEntry) Submit(engine *ledger.Engine) error {
+	_, err := pe.Post(engine)
+	return err
+}
+
+// Post is Submit returning the warnings of the posting: those the engine's
+// Policy downgraded, and a ledger.CheckEvents warning when the invoices
+// the payment settled could not be looked up to publish invoice.paid. The
+// payment is submitted whenever err is nil.
+func (pe *PaymentEntry) Post(engine *ledger.Engine) ([]ledger.Warning, error) {
+	glMap, err := pe.GetGLEntries()
+	if err != nil {
+		return nil, err
+	}
+	if pe.AmendedFrom != "" {
+		if err := engine.CanRelinkAgainstVoucher(); err != nil {
+			return nil, err
+		}
+	}
+	warnings, err := engine.Post(glMap, ledger.DefaultPostingOptions())
+	if err != nil {
+		return nil, err
+	}
+	if pe.AmendedFrom != "" {
+		if err := engine.RelinkAgainstVoucher(VoucherType, pe.AmendedFrom, pe.Name); err != nil {
+			return nil, err
+		}
+	}
+	pe.Status = Submitted
+	if err := pe.publishPaid(engine); err != nil {
+		warnings = append(warnings, ledger.Warning{Check: ledger.CheckEvents, Err: err})
+	}
+	return warnings, nil
+}
+
+// publishPaid publishes invoice.paid for each referenced invoice the
+// payment settled in full. It needs an event publisher and a payment store
+// that can query by against voucher. An invoice whose outstanding cannot
+// be read is skipped, and its error returned once the rest are published.
+//
+// Maps to: the "Value Change" notification on Sales Invoice status -> Paid
+func (pe *PaymentEntry) publishPaid(engine *ledger.Engine) error {
+	pl, ok := engine.PaymentStore.(PaymentLedger)
+	if engine.Events == nil || !ok {
+		return nil
+	}
+	var errs []error
+	for _, ref := range pe.References {
+		if ref.ReferenceDoctype != "Sales Invoice" && ref.ReferenceDoctype != "Purchase Invoice" {
+			continue
+		}
+		amount, err := outstanding(pl, ref.ReferenceDoctype, ref.ReferenceName)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if ledger.Flt(amount, 2) != 0 {
+			continue
+		}
+		engine.Events.Publish(ledger.Event{
+			Type: ledger.EventInvoicePaid, Time: time.Now(), Company: pe.Company,
+			VoucherType: ref.ReferenceDoctype, VoucherNo: ref.ReferenceName, PostingDate: pe.PostingDate,
+			PartyType: pe.PartyType, Party: pe.Party, Amount: ref.AllocatedAmount,
+			Data: map[string]any{"payment_entry": pe.Name},
+		})
+	}
+	return errors.Join(errs...)
+}
+
+// Cancel reverses the payment's GL entries and delinks its payment ledger
+// entries, so every reference it settled is outstanding again. A returned
+// payment cannot be cancelled, as its return has already reversed it.
+//
+// Maps to: on_cancel() in payment_entry.py
+//
+// Python equivalent:
+//
+//	def on_cancel(self):
+//	    self.ignore_linked_doctypes = ("GL Entry", "Stock Ledger Entry", "Payment Ledger Entry", ...)
+//	    self.make_gl_entries(cancel=1)
+//	    self.delink_advance_entry_references()
+func (pe *PaymentEntry) Cancel(engine *ledger.Engine) error {
+	if pe.Status == Returned {
+		return fmt.Errorf("%w: %s", ErrPaymentReturned, pe.Name)
+	}
+
+	opts := ledger.DefaultPostingOptions()
+	opts.Cancel = true
+	if err := engine.MakeGLEntries([]ledger.GLEntry{pe.newGLEntry()}, opts); err != nil {
+		return err
+	}
+	if engine.PaymentStore != nil {
+		if err := engine.PaymentStore.Delink(VoucherType, pe.Name); err != nil {
+			return err
+		}
+	}
+	pe.Status = Cancelled
+	return nil
+}
+
+// newGLEntry returns an entry pre-filled with voucher-level fields.
+//
+// Maps to: get_gl_dict() in controllers/accounts_controller.py
+func (pe *PaymentEntry) newGLEntry() ledger.GLEntry {
+	return ledger.GLEntry{
+		PostingDate:             pe.PostingDate,
+		TransactionDate:         pe.PostingDate,
+		VoucherType:             VoucherType,
+		VoucherNo:               pe.Name,
+		Company:                 pe.Company,
+		CostCenter:              pe.CostCenter,
+		Project:                 pe.Project,
+		Remarks:                 pe.Remarks,
+		AccountCurrency:         pe.Currency,
+		TransactionCurrency:     pe.Currency,
+		TransactionExchangeRate: 1,
+		IsOpening:               ledger.IsOpeningNo,
+		IsAdvance:               ledger.IsAdvanceNo,
+	}
+}
+
+// setAmount books a company-currency amount on the debit or credit side.
+// Negative amounts are left for the engine to move to the other side.
+func setAmount(entry *ledger.GLEntry, amount float64, debit bool) {
+	if debit {
+		entry.Debit = amount
+		entry.DebitInAccountCurrency = amount
+		entry.DebitInTransactionCurrency = amount
+	} else {
+		entry.Credit = amount
+		entry.CreditInAccountCurrency = amount
+		entry.CreditInTransactionCurrency = amount
+	}
+}
+
+// addPartyGLEntries posts one party entry per allocation, against the
+// allocated voucher, and one for the unallocated amount against the payment
+// itself. Receipts credit the party account, payments debit it.
+//
+// Maps to: add_party_gl_entries() in payment_entry.py
+//
+// Python equivalent:
+//
+//	for d in self.get("references"):
+//	    dr_or_cr = "credit" if self.payment_type == "Receive" else "debit"
+//	    gle = party_gl_dict.copy()
+//	    gle.update({
+//	        dr_or_cr + "_in_account_currency": d.allocated_amount,
+//	        dr_or_cr: allocated_amount_in_company_currency,
+//	        "against_voucher_type": d.reference_doctype,
+//	        "against_voucher": d.reference_name,
+//	    })
+//	if self.unallocated_amount:
+//	    gle = party_gl_dict.copy()
+//	    gle.update({dr_or_cr + "_in_account_currency": self.unallocated_amount,
+//	        dr_or_cr: base_unallocated_amount})
+func (pe *PaymentEntry) addPartyGLEntries(glMap []ledger.GLEntry) []ledger.GLEntry {
+	if pe.Party == "" {
+		return glMap
+	}
+
+	party := pe.newGLEntry()
+	party.Account = pe.PartyAccount()
+	party.PartyType = pe.PartyType
+	party.Party = pe.Party
+	party.Against = pe.BankAccount()
+	debit := pe.PaymentType == Pay
+
+	for _, ref := range pe.References {
+		if ref.AllocatedAmount == 0 {
+			continue
+		}
+		entry := party
+		entry.AgainstVoucherType = ref.ReferenceDoctype
+		entry.AgainstVoucher = ref.ReferenceName
+		entry.DueDate = ref.DueDate
+		setAmount(&entry, ref.AllocatedAmount, debit)
+		glMap = append(glMap, entry)
+	}
+
+	if pe.UnallocatedAmount != 0 {
+		entry := party
+		entry.AgainstVoucherType = VoucherType
+		entry.AgainstVoucher = pe.Name
+		entry.IsAdvance = ledger.IsAdvanceYes
+		setAmount(&entry, pe.UnallocatedAmount, debit)
+		glMap = append(glMap, entry)
+	}
+
+	return glMap
+}
+
+// addBankGLEntries posts the money moving through the bank or cash account.
+//
+// Maps to: add_bank_gl_entries() in payment_entry.py
+func (pe *PaymentEntry) addBankGLEntries(glMap []ledger.GLEntry) []ledger.GLEntry {
+	against := pe.Party
+	if against == "" {
+		against = pe.PartyAccount()
+	}
+
+	if pe.PaymentType == Pay || pe.PaymentType == InternalTransfer {
+		entry := pe.newGLEntry()
+		entry.Account = pe.PaidFrom
+		entry.Against = against
+		if pe.PaymentType == InternalTransfer {
+			entry.Against = pe.PaidTo
+		}
+		setAmount(&entry, pe.PaidAmount, false)
+		glMap = append(glMap, entry)
+	}
+
+	if pe.PaymentType == Receive || pe.PaymentType == InternalTransfer {
+		entry := pe.newGLEntry()
+		entry.Account = pe.PaidTo
+		entry.Against = against
+		if pe.PaymentType == InternalTransfer {
+			entry.Against = pe.PaidFrom
+		}
+		setAmount(&entry, pe.ReceivedAmount, true)
+		glMap = append(glMap, entry)
+	}
+
+	return glMap
+}
+
+// ApplyModeOfPaymentFee withholds the processing fee of the payment's mode
+// of payment from a receipt: the bank receives the paid amount less the
+// fee, and the fee becomes a deduction on the company's fee account, so
+// the party is still credited in full. Calling it again replaces the fee.
+// Payments made and internal transfers carry no fee.
+//
+// Maps to: the Payment Entry Deduction rows users add for card or gateway
+// charges in ERPNext
+func (pe *PaymentEntry) ApplyModeOfPaymentFee(modes modeofpayment.Lookup) error {
+	if pe.PaymentType != Receive || pe.ModeOfPayment == "" {
+		return nil
+	}
+	mode, err := modes.GetModeOfPayment(pe.ModeOfPayment)
+	if err != nil {
+		return err
+	}
+	fee, account, err := mode.FeeFor(pe.Company, pe.PaidAmount)
+	if err != nil {
+		return err
+	}
+	if account.FeeAccount != "" {
+		pe.Deductions = slices.DeleteFunc(pe.Deductions, func(d Deduction) bool { return d.Account == account.FeeAccount })
+	}
+	pe.ReceivedAmount = ledger.Flt(pe.PaidAmount-fee, 2)
+	if fee != 0 {
+		pe.Deductions = append(pe.Deductions, Deduction{Account: account.FeeAccount, CostCenter: account.FeeCostCenter, Amount: fee})
+	}
+	return nil
+}
+
+// addDeductionsGLEntries debits each deduction account. A receipt's
+// deductions add to the amount settled with the party, a payment's reduce
+// it; negative deductions are credited.
+//
+// Maps to: add_deductions_gl_entries() in payment_entry.py
+func (pe *PaymentEntry) addDeductionsGLEntries(glMap []ledger.GLEntry) []ledger.GLEntry {
+	for _, d := range pe.Deductions {
+		if d.Amount == 0 {
+			continue
+		}
+		entry := pe.newGLEntry()
+		entry.Account = d.Account
+		entry.Against = pe.Party
+		if d.CostCenter != "" {
+			entry.CostCenter = d.CostCenter
+		}
+		setAmount(&entry, d.Amount, true)
+		glMap = append(glMap, entry)
+	}
+	return glMap
+}
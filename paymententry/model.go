@@ -0,0 +1,242 @@
+// Package paymententry implements the accounting side of ERPNext's
+// Payment Entry: money received from or paid to a party, allocated against
+// invoices, credit notes or earlier payments.
+// Migrated from: erpnext/accounts/doctype/payment_entry/payment_entry.py
+//
+// Amounts are in company currency. Each allocation posts a party GL entry
+// against its reference, so the payment ledger tracks what remains
+// outstanding on every voucher; any unallocated amount is booked against
+// the payment entry itself as an advance.
+package paymententry
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/ledger"
+)
+
+// VoucherType is the ERPNext doctype name used on GL entries.
+const VoucherType = "Payment Entry"
+
+// Validation errors matching frappe.throw() calls in payment_entry.py
+var (
+	ErrMissingParty       = errors.New("party type and party are mandatory")
+	ErrMissingAccount     = errors.New("paid from and paid to accounts are mandatory")
+	ErrInvalidAmount      = errors.New("paid amount must be greater than zero")
+	ErrOverAllocation     = errors.New("allocated amount cannot be greater than outstanding amount")
+	ErrAllocationExceeded = errors.New("total allocated amount cannot be greater than paid amount")
+	ErrNothingToRefund    = errors.New("nothing left to refund")
+	ErrRefundExceeded     = errors.New("refund amount cannot be greater than refundable amount")
+	ErrNotSubmitted       = errors.New("payment entry is not submitted")
+	ErrNotReceipt         = errors.New("only received payments can be returned")
+	ErrPaymentReturned    = errors.New("payment entry has been returned")
+	ErrNotCancelled       = errors.New("only a cancelled payment entry can be amended")
+
+	ErrMissingWriteOffAccount = errors.New("write off account is not set for the company")
+)
+
+// Status is the document state of a payment entry.
+type Status string
+
+const (
+	Draft     Status = "Draft"
+	Submitted Status = "Submitted"
+	Cancelled Status = "Cancelled"
+	Returned  Status = "Returned" // Dishonored by the bank after submission
+)
+
+// PaymentType is the direction of the payment.
+type PaymentType string
+
+const (
+	Receive          PaymentType = "Receive"
+	Pay              PaymentType = "Pay"
+	InternalTransfer PaymentType = "Internal Transfer"
+)
+
+// Reference allocates part of the payment against a voucher.
+//
+// A positive allocation moves the party account in the payment's direction:
+// a receipt credits it, a payment debits it. A receipt therefore reduces an
+// invoice's outstanding with a positive allocation and a credit note's with
+// a negative one, and the opposite holds for a payment.
+//
+// Maps to: Payment Entry Reference child table
+type Reference struct {
+	ReferenceDoctype  string
+	ReferenceName     string
+	DueDate           *time.Time
+	TotalAmount       float64
+	OutstandingAmount float64 // Payment ledger sign: receivables positive, payables negative
+	AllocatedAmount   float64
+}
+
+// Deduction is a charge or write-off taken out of the paid amount, such as
+// bank charges or exchange differences.
+//
+// Maps to: Payment Entry Deduction child table
+type Deduction struct {
+	Account    string
+	CostCenter string
+	Amount     float64
+}
+
+// PaymentEntry is a payment received from or made to a party.
+//
+// Maps to: erpnext/accounts/doctype/payment_entry/payment_entry.json
+type PaymentEntry struct {
+	Name          string
+	Company       string
+	PostingDate   time.Time
+	PaymentType   PaymentType
+	ModeOfPayment string
+
+	PartyType string // "Customer", "Supplier" or "Employee"
+	Party     string
+
+	// The party account is PaidFrom on receipts and PaidTo on payments;
+	// the other side is the bank or cash account
+	PaidFrom string
+	PaidTo   string
+	Currency string
+
+	PaidAmount     float64
+	ReceivedAmount float64 // Equal to PaidAmount in a single currency
+
+	References        []Reference
+	Deductions        []Deduction
+	UnallocatedAmount float64
+
+	CostCenter    string
+	Project       string
+	ReferenceNo   string // Cheque or transaction reference
+	ReferenceDate *time.Time
+	Remarks       string
+
+	Status      Status
+	AmendedFrom string  // Cancelled payment this one amends
+	Return      *Return // Set once the payment has been dishonored
+}
+
+// PartyAccount returns the receivable or payable account of the payment.
+func (pe *PaymentEntry) PartyAccount() string {
+	if pe.PaymentType == Receive {
+		return pe.PaidFrom
+	}
+	return pe.PaidTo
+}
+
+// BankAccount returns the bank or cash side of the payment.
+func (pe *PaymentEntry) BankAccount() string {
+	if pe.PaymentType == Receive {
+		return pe.PaidTo
+	}
+	return pe.PaidFrom
+}
+
+// TotalAllocatedAmount returns the sum of all allocations.
+func (pe *PaymentEntry) TotalAllocatedAmount() float64 {
+	var total float64
+	for _, ref := range pe.References {
+		total += ref.AllocatedAmount
+	}
+	return ledger.Flt(total, 2)
+}
+
+// TotalDeductions returns the sum of all deductions.
+func (pe *PaymentEntry) TotalDeductions() float64 {
+	var total float64
+	for _, d := range pe.Deductions {
+		total += d.Amount
+	}
+	return ledger.Flt(total, 2)
+}
+
+// SetUnallocatedAmount sets the part of the payment not allocated to any
+// reference. Deductions count towards the party on a receipt (the bank
+// receives less than is settled) and against it on a payment.
+//
+// Maps to: set_unallocated_amount() in payment_entry.py
+//
+// Python equivalent:
+//
+//	def set_unallocated_amount(self):
+//	    self.unallocated_amount = 0
+//	    if self.party:
+//	        total_deductions = sum(flt(d.amount) for d in self.get("deductions"))
+//	        if self.payment_type == "Receive" and self.base_total_allocated_amount < self.base_received_amount + total_deductions:
+//	            self.unallocated_amount = (self.base_received_amount + total_deductions
+//	                - self.base_total_allocated_amount) / self.source_exchange_rate
+//	            self.unallocated_amount -= included_taxes
+//	        elif self.payment_type == "Pay" and self.base_total_allocated_amount < self.base_paid_amount - total_deductions:
+//	            self.unallocated_amount = (self.base_paid_amount - (total_deductions
+//	                + self.base_total_allocated_amount)) / self.target_exchange_rate
+func (pe *PaymentEntry) SetUnallocatedAmount() {
+	pe.UnallocatedAmount = 0
+	if pe.Party == "" || pe.PaymentType == InternalTransfer {
+		return
+	}
+	if pe.PaymentType == Receive {
+		pe.UnallocatedAmount = ledger.Flt(pe.ReceivedAmount+pe.TotalDeductions()-pe.TotalAllocatedAmount(), 2)
+	} else {
+		pe.UnallocatedAmount = ledger.Flt(pe.PaidAmount-pe.TotalDeductions()-pe.TotalAllocatedAmount(), 2)
+	}
+}
+
+// Validate checks mandatory fields and allocations, then sets the
+// unallocated amount.
+//
+// Maps to: validate() in payment_entry.py
+func (pe *PaymentEntry) Validate() error {
+	if pe.PaymentType != InternalTransfer && (pe.PartyType == "" || pe.Party == "") {
+		return ErrMissingParty
+	}
+	if pe.PaidFrom == "" || pe.PaidTo == "" {
+		return ErrMissingAccount
+	}
+	if pe.PaidAmount <= 0 {
+		return ErrInvalidAmount
+	}
+	if pe.ReceivedAmount == 0 {
+		pe.ReceivedAmount = pe.PaidAmount
+	}
+
+	for i, ref := range pe.References {
+		if err := pe.validateAllocatedAmount(ref); err != nil {
+			return fmt.Errorf("%w: row %d (%s %s)", err, i+1, ref.ReferenceDoctype, ref.ReferenceName)
+		}
+	}
+
+	pe.SetUnallocatedAmount()
+	if pe.UnallocatedAmount < 0 {
+		return fmt.Errorf("%w: allocated %.2f, unallocated %.2f", ErrAllocationExceeded, pe.TotalAllocatedAmount(), pe.UnallocatedAmount)
+	}
+	return nil
+}
+
+// validateAllocatedAmount rejects allocations that would carry a reference
+// past zero. Allocations moving the outstanding away from zero reverse an
+// earlier settlement and are allowed.
+//
+// Maps to: validate_allocated_amount() in payment_entry.py
+func (pe *PaymentEntry) validateAllocatedAmount(ref Reference) error {
+	if ref.OutstandingAmount == 0 {
+		return nil
+	}
+	after := ledger.Flt(ref.OutstandingAmount+pe.outstandingEffect(ref.AllocatedAmount), 2)
+	if (ref.OutstandingAmount > 0 && after < 0) || (ref.OutstandingAmount < 0 && after > 0) {
+		return fmt.Errorf("%w: %.2f allocated, %.2f outstanding", ErrOverAllocation, ref.AllocatedAmount, ref.OutstandingAmount)
+	}
+	return nil
+}
+
+// outstandingEffect returns the change an allocation makes to the
+// reference's payment ledger balance (debit minus credit).
+func (pe *PaymentEntry) outstandingEffect(allocated float64) float64 {
+	if pe.PaymentType == Receive {
+		return -allocated
+	}
+	return allocated
+}
@@ -0,0 +1,548 @@
+package paymententry
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/ledger"
+	"github.com/senguttuvang/erpnext-go/ledger/memstore"
+	"github.com/senguttuvang/erpnext-go/modeofpayment"
+)
+
+const (
+	debtors = "Debtors - ACME"
+	bank    = "Bank - ACME"
+)
+
+func date(y int, m time.Month, d int) time.Time {
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+func newEngine() (*ledger.Engine, *memstore.GLStore, *memstore.PaymentStore) {
+	glStore := memstore.NewGLStore()
+	paymentStore := memstore.NewPaymentStore()
+	return &ledger.Engine{GLStore: glStore, PaymentStore: paymentStore}, glStore, paymentStore
+}
+
+// postInvoice books a receivable of amount against the invoice itself.
+func postInvoice(t *testing.T, engine *ledger.Engine, name string, amount float64) {
+	t.Helper()
+	receivable := ledger.GLEntry{
+		PostingDate: date(2024, 4, 1), Account: debtors, PartyType: "Customer", Party: "Acme Corporation",
+		VoucherType: "Sales Invoice", VoucherNo: name, AgainstVoucherType: "Sales Invoice", AgainstVoucher: name,
+		Company: "ACME", Debit: amount, DebitInAccountCurrency: amount,
+	}
+	income := ledger.GLEntry{
+		PostingDate: date(2024, 4, 1), Account: "Sales - ACME", VoucherType: "Sales Invoice", VoucherNo: name,
+		Company: "ACME", Credit: amount, CreditInAccountCurrency: amount,
+	}
+	if err := engine.MakeGLEntries([]ledger.GLEntry{receivable, income}, ledger.DefaultPostingOptions()); err != nil {
+		t.Fatalf("post %s: %v", name, err)
+	}
+}
+
+func balances(entries []ledger.GLEntry) map[string]float64 {
+	result := make(map[string]float64)
+	for _, e := range entries {
+		result[e.Account] += e.Debit - e.Credit
+	}
+	return result
+}
+
+func outstandingOf(t *testing.T, store *memstore.PaymentStore, voucherType, voucherNo string) float64 {
+	t.Helper()
+	amount, err := outstanding(store, voucherType, voucherNo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return amount
+}
+
+func newDeposit() *PaymentEntry {
+	return &PaymentEntry{
+		Name: "PE-0001", Company: "ACME", PostingDate: date(2024, 4, 5), PaymentType: Receive,
+		PartyType: "Customer", Party: "Acme Corporation", PaidFrom: debtors, PaidTo: bank, Currency: "INR",
+		PaidAmount: 1500,
+		References: []Reference{
+			{ReferenceDoctype: "Sales Invoice", ReferenceName: "SINV-0001", TotalAmount: 1180, OutstandingAmount: 1180, AllocatedAmount: 1000},
+		},
+	}
+}
+
+func TestGetGLEntries(t *testing.T) {
+	pe := newDeposit()
+	pe.Deductions = []Deduction{{Account: "Bank Charges - ACME", CostCenter: "Main - ACME", Amount: 10}}
+
+	glMap, err := pe.GetGLEntries()
+	if err != nil {
+		t.Fatalf("GetGLEntries() error = %v", err)
+	}
+	if pe.UnallocatedAmount != 510 {
+		t.Errorf("UnallocatedAmount = %.2f, want 510", pe.UnallocatedAmount)
+	}
+
+	got := balances(ledger.ToggleDebitCreditIfNegative(glMap))
+	want := map[string]float64{debtors: -1510, bank: 1500, "Bank Charges - ACME": 10}
+	for account, amount := range want {
+		if got[account] != amount {
+			t.Errorf("%s = %.2f, want %.2f", account, got[account], amount)
+		}
+	}
+	for _, e := range glMap {
+		if e.Account == debtors && e.AgainstVoucher == pe.Name && e.IsAdvance != ledger.IsAdvanceYes {
+			t.Errorf("unallocated entry should be an advance: %+v", e)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*PaymentEntry)
+		want   error
+	}{
+		{"missing party", func(pe *PaymentEntry) { pe.Party = "" }, ErrMissingParty},
+		{"missing account", func(pe *PaymentEntry) { pe.PaidTo = "" }, ErrMissingAccount},
+		{"zero amount", func(pe *PaymentEntry) { pe.PaidAmount = 0 }, ErrInvalidAmount},
+		{"over allocation", func(pe *PaymentEntry) { pe.References[0].AllocatedAmount = 1200 }, ErrOverAllocation},
+		{"allocation exceeds paid", func(pe *PaymentEntry) { pe.PaidAmount = 900 }, ErrAllocationExceeded},
+		{"credit note offsets invoice", func(pe *PaymentEntry) {
+			pe.References = append(pe.References, Reference{
+				ReferenceDoctype: "Sales Invoice", ReferenceName: "SINV-0002", OutstandingAmount: -200, AllocatedAmount: -200,
+			})
+		}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pe := newDeposit()
+			tt.modify(pe)
+			if err := pe.Validate(); !errors.Is(err, tt.want) {
+				t.Errorf("Validate() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+// mockCreditNotes books a return against the invoice.
+type mockCreditNotes struct {
+	engine *ledger.Engine
+	made   int
+}
+
+func (m *mockCreditNotes) MakeCreditNote(against Reference, amount float64, postingDate time.Time, reason string) (string, error) {
+	m.made++
+	name := fmt.Sprintf("SINV-RET-%d", m.made)
+	glMap := []ledger.GLEntry{
+		{PostingDate: postingDate, Account: debtors, PartyType: "Customer", Party: "Acme Corporation",
+			VoucherType: "Sales Invoice", VoucherNo: name, AgainstVoucherType: against.ReferenceDoctype, AgainstVoucher: against.ReferenceName,
+			Company: "ACME", Credit: amount, CreditInAccountCurrency: amount},
+		{PostingDate: postingDate, Account: "Sales Returns - ACME", VoucherType: "Sales Invoice", VoucherNo: name,
+			Company: "ACME", Debit: amount, DebitInAccountCurrency: amount},
+	}
+	return name, m.engine.MakeGLEntries(glMap, ledger.DefaultPostingOptions())
+}
+
+type modes map[string]*modeofpayment.ModeOfPayment
+
+func (m modes) GetModeOfPayment(name string) (*modeofpayment.ModeOfPayment, error) {
+	if mode, ok := m[name]; ok {
+		return mode, nil
+	}
+	return nil, errors.New("mode of payment not found")
+}
+
+func TestModeOfPaymentFee(t *testing.T) {
+	engine, glStore, paymentStore := newEngine()
+	postInvoice(t, engine, "SINV-0001", 1180)
+
+	card := &modeofpayment.ModeOfPayment{
+		Name: "Credit Card", Type: modeofpayment.Bank, Enabled: true, Fee: modeofpayment.Fee{Percentage: 2},
+		Accounts: []modeofpayment.ModeOfPaymentAccount{{Company: "ACME", DefaultAccount: bank, FeeAccount: "Card Fees - ACME", FeeCostCenter: "Main - ACME"}},
+	}
+	pe := newDeposit()
+	pe.ModeOfPayment = "Credit Card"
+	pe.PaidAmount = 1180
+	pe.References[0].AllocatedAmount = 1180
+	for range 2 { // Applying twice replaces the fee
+		if err := pe.ApplyModeOfPaymentFee(modes{"Credit Card": card}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if pe.ReceivedAmount != 1156.40 || len(pe.Deductions) != 1 || pe.Deductions[0].Amount != 23.60 {
+		t.Fatalf("received %.2f, deductions %+v", pe.ReceivedAmount, pe.Deductions)
+	}
+	if err := pe.Submit(engine); err != nil {
+		t.Fatal(err)
+	}
+	got := balances(glStore.All())
+	if ledger.Flt(got[bank], 2) != 1156.40 || got["Card Fees - ACME"] != 23.60 || got[debtors] != 0 {
+		t.Errorf("balances = %v", got)
+	}
+	if pe.UnallocatedAmount != 0 {
+		t.Errorf("unallocated = %.2f", pe.UnallocatedAmount)
+	}
+	if out := outstandingOf(t, paymentStore, "Sales Invoice", "SINV-0001"); out != 0 {
+		t.Errorf("invoice outstanding = %.2f, want 0", out)
+	}
+}
+
+func TestRefund(t *testing.T) {
+	engine, glStore, paymentStore := newEngine()
+	postInvoice(t, engine, "SINV-0001", 1180)
+
+	deposit := newDeposit()
+	if err := deposit.Submit(engine); err != nil {
+		t.Fatalf("Submit() error = %v", err)
+	}
+	if got := outstandingOf(t, paymentStore, "Sales Invoice", "SINV-0001"); got != 180 {
+		t.Errorf("invoice outstanding = %.2f, want 180", got)
+	}
+	if got := outstandingOf(t, paymentStore, VoucherType, "PE-0001"); got != -500 {
+		t.Errorf("advance outstanding = %.2f, want -500", got)
+	}
+
+	// Partial refund: the advance goes first, then the invoice allocation
+	refund, err := MakeRefund(deposit, RefundRequest{Name: "PE-0002", PostingDate: date(2024, 5, 1), Amount: 700}, paymentStore)
+	if err != nil {
+		t.Fatalf("MakeRefund() error = %v", err)
+	}
+	if refund.PaymentType != Pay || refund.PaidFrom != bank || refund.PaidTo != debtors {
+		t.Errorf("refund = %s from %s to %s", refund.PaymentType, refund.PaidFrom, refund.PaidTo)
+	}
+	if len(refund.References) != 2 || refund.References[0].AllocatedAmount != 500 || refund.References[1].AllocatedAmount != 200 {
+		t.Fatalf("refund references = %+v", refund.References)
+	}
+	if err := refund.Submit(engine); err != nil {
+		t.Fatalf("Submit(refund) error = %v", err)
+	}
+	if got := outstandingOf(t, paymentStore, VoucherType, "PE-0001"); got != 0 {
+		t.Errorf("advance outstanding after refund = %.2f, want 0", got)
+	}
+	if got := outstandingOf(t, paymentStore, "Sales Invoice", "SINV-0001"); got != 380 {
+		t.Errorf("invoice outstanding after refund = %.2f, want 380", got)
+	}
+
+	// Cancelling the refund restores both allocations
+	if err := refund.Cancel(engine); err != nil {
+		t.Fatalf("Cancel() error = %v", err)
+	}
+	if got := outstandingOf(t, paymentStore, VoucherType, "PE-0001"); got != -500 {
+		t.Errorf("advance outstanding after cancel = %.2f, want -500", got)
+	}
+	if got := outstandingOf(t, paymentStore, "Sales Invoice", "SINV-0001"); got != 180 {
+		t.Errorf("invoice outstanding after cancel = %.2f, want 180", got)
+	}
+
+	if _, err := MakeRefund(deposit, RefundRequest{Name: "PE-0003", Amount: 2000}, paymentStore); !errors.Is(err, ErrRefundExceeded) {
+		t.Errorf("Expected ErrRefundExceeded, got %v", err)
+	}
+
+	// A refund that does not validate makes no credit notes
+	notes := &mockCreditNotes{engine: engine}
+	noBank := *deposit
+	noBank.PaidTo = ""
+	if _, err := MakeRefund(&noBank, RefundRequest{Name: "PE-0003", PostingDate: date(2024, 5, 2), CreditNotes: notes}, paymentStore); !errors.Is(err, ErrMissingAccount) || notes.made != 0 {
+		t.Errorf("invalid refund = %v with %d notes made", err, notes.made)
+	}
+
+	// Full refund with credit notes: the invoice stays settled
+	refund, err = MakeRefund(deposit, RefundRequest{Name: "PE-0003", PostingDate: date(2024, 5, 2), CreditNotes: notes}, paymentStore)
+	if err != nil {
+		t.Fatalf("MakeRefund() error = %v", err)
+	}
+	if refund.PaidAmount != 1500 || notes.made != 1 || refund.UnallocatedAmount != 0 {
+		t.Errorf("refund paid %.2f, %d notes, unallocated %.2f", refund.PaidAmount, notes.made, refund.UnallocatedAmount)
+	}
+	if err := refund.Submit(engine); err != nil {
+		t.Fatalf("Submit(refund) error = %v", err)
+	}
+	if got := outstandingOf(t, paymentStore, "Sales Invoice", "SINV-0001"); got != 180 {
+		t.Errorf("invoice outstanding = %.2f, want 180", got)
+	}
+
+	if _, err := MakeRefund(deposit, RefundRequest{Name: "PE-0004"}, paymentStore); !errors.Is(err, ErrNothingToRefund) {
+		t.Errorf("Expected ErrNothingToRefund, got %v", err)
+	}
+
+	got := balances(glStore.All())
+	if got[bank] != 0 || got["Sales Returns - ACME"] != 1000 || got[debtors] != 180 {
+		t.Errorf("balances = %v", got)
+	}
+}
+
+func TestDishonor(t *testing.T) {
+	engine, glStore, paymentStore := newEngine()
+	postInvoice(t, engine, "SINV-0001", 1180)
+
+	deposit := newDeposit()
+	ret := Return{
+		Name: "JV-0001", Date: date(2024, 4, 12), Reason: InsufficientFunds,
+		BankCharges: 250, BankChargesAccount: "Bank Charges - ACME",
+		Penalty: 500, PenaltyAccount: "Cheque Return Charges - ACME",
+	}
+	if err := deposit.Dishonor(engine, ret); !errors.Is(err, ErrNotSubmitted) {
+		t.Errorf("Expected ErrNotSubmitted, got %v", err)
+	}
+	if err := deposit.Submit(engine); err != nil {
+		t.Fatalf("Submit() error = %v", err)
+	}
+	if err := deposit.Dishonor(engine, ret); err != nil {
+		t.Fatalf("Dishonor() error = %v", err)
+	}
+
+	if deposit.Status != Returned || deposit.Return == nil || deposit.Return.Reason != InsufficientFunds {
+		t.Errorf("status = %s, return = %+v", deposit.Status, deposit.Return)
+	}
+	if got := outstandingOf(t, paymentStore, "Sales Invoice", "SINV-0001"); got != 1180 {
+		t.Errorf("invoice outstanding = %.2f, want 1180", got)
+	}
+	if got := outstandingOf(t, paymentStore, VoucherType, "PE-0001"); got != 0 {
+		t.Errorf("advance outstanding = %.2f, want 0", got)
+	}
+	if got := outstandingOf(t, paymentStore, ReturnVoucherType, "JV-0001"); got != 500 {
+		t.Errorf("penalty outstanding = %.2f, want 500", got)
+	}
+
+	for _, e := range glStore.All() {
+		if e.VoucherNo == "JV-0001" && e.AgainstVoucher == "PE-0001" && e.IsAdvance != ledger.IsAdvanceYes {
+			t.Errorf("reversal of the advance: is_advance = %q", e.IsAdvance)
+		}
+	}
+
+	got := balances(glStore.All())
+	want := map[string]float64{
+		bank: -250, debtors: 1680, "Bank Charges - ACME": 250,
+		"Cheque Return Charges - ACME": -500, "Sales - ACME": -1180,
+	}
+	for account, amount := range want {
+		if got[account] != amount {
+			t.Errorf("%s = %.2f, want %.2f", account, got[account], amount)
+		}
+	}
+
+	if err := deposit.Dishonor(engine, ret); !errors.Is(err, ErrPaymentReturned) {
+		t.Errorf("Expected ErrPaymentReturned, got %v", err)
+	}
+	if err := deposit.Cancel(engine); !errors.Is(err, ErrPaymentReturned) {
+		t.Errorf("Expected ErrPaymentReturned on cancel, got %v", err)
+	}
+	if _, err := MakeRefund(deposit, RefundRequest{Name: "PE-0002"}, paymentStore); !errors.Is(err, ErrPaymentReturned) {
+		t.Errorf("Expected ErrPaymentReturned on refund, got %v", err)
+	}
+}
+
+func TestAmend(t *testing.T) {
+	engine, glStore, paymentStore := newEngine()
+	postInvoice(t, engine, "SINV-0001", 1180)
+
+	deposit := newDeposit()
+	if err := deposit.Submit(engine); err != nil {
+		t.Fatalf("Submit() error = %v", err)
+	}
+	if _, err := deposit.Amend(); !errors.Is(err, ErrNotCancelled) {
+		t.Errorf("Expected ErrNotCancelled, got %v", err)
+	}
+
+	// Part of the advance is adjusted by a journal entry against the payment
+	adjustment := []ledger.GLEntry{
+		{
+			PostingDate: date(2024, 4, 8), Account: debtors, PartyType: "Customer", Party: "Acme Corporation",
+			VoucherType: "Journal Entry", VoucherNo: "JV-0001", AgainstVoucherType: VoucherType, AgainstVoucher: "PE-0001",
+			Company: "ACME", Debit: 200, DebitInAccountCurrency: 200,
+		},
+		{
+			PostingDate: date(2024, 4, 8), Account: "Sales - ACME", VoucherType: "Journal Entry", VoucherNo: "JV-0001",
+			Company: "ACME", Credit: 200, CreditInAccountCurrency: 200,
+		},
+	}
+	if err := engine.MakeGLEntries(adjustment, ledger.DefaultPostingOptions()); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := deposit.Cancel(engine); err != nil {
+		t.Fatalf("Cancel() error = %v", err)
+	}
+	amended, err := deposit.Amend()
+	if err != nil {
+		t.Fatalf("Amend() error = %v", err)
+	}
+	if amended.Name != "PE-0001-1" || amended.AmendedFrom != "PE-0001" || amended.Status != Draft {
+		t.Errorf("unexpected amendment %s from %s (%s)", amended.Name, amended.AmendedFrom, amended.Status)
+	}
+	amended.References[0].AllocatedAmount = 1180
+	if deposit.References[0].AllocatedAmount != 1000 {
+		t.Fatal("amendment shares references with the original")
+	}
+	if err := amended.Submit(engine); err != nil {
+		t.Fatalf("Submit() amendment error = %v", err)
+	}
+
+	if got := outstandingOf(t, paymentStore, "Sales Invoice", "SINV-0001"); got != 0 {
+		t.Errorf("invoice outstanding = %.2f, want 0", got)
+	}
+	if got := outstandingOf(t, paymentStore, VoucherType, "PE-0001"); got != 0 {
+		t.Errorf("original advance outstanding = %.2f, want 0", got)
+	}
+	if got := outstandingOf(t, paymentStore, VoucherType, "PE-0001-1"); got != -120 {
+		t.Errorf("amended advance outstanding = %.2f, want -120", got)
+	}
+	entries, _ := glStore.GetByVoucher("Journal Entry", "JV-0001")
+	if entries[0].AgainstVoucher != "PE-0001-1" {
+		t.Errorf("journal entry still booked against %s", entries[0].AgainstVoucher)
+	}
+}
+
+func TestInvoicePaidEvent(t *testing.T) {
+	engine, _, _ := newEngine()
+	var paid []ledger.Event
+	bus := &ledger.EventBus{}
+	bus.Subscribe(func(e ledger.Event) {
+		if e.Type == ledger.EventInvoicePaid {
+			paid = append(paid, e)
+		}
+	})
+	engine.Events = bus
+	postInvoice(t, engine, "SINV-0001", 1000)
+
+	for i, amount := range []float64{400, 600} {
+		pe := newDeposit()
+		pe.Name = fmt.Sprintf("PE-%04d", i+1)
+		pe.PaidAmount = amount
+		pe.References[0].AllocatedAmount = amount
+		if err := pe.Submit(engine); err != nil {
+			t.Fatalf("Submit(%s) error = %v", pe.Name, err)
+		}
+		if i == 0 && len(paid) != 0 {
+			t.Errorf("partial payment published %+v", paid)
+		}
+	}
+	if len(paid) != 1 || paid[0].VoucherNo != "SINV-0001" || paid[0].Data["payment_entry"] != "PE-0002" {
+		t.Errorf("invoice.paid events = %+v", paid)
+	}
+}
+
+type writeOffSettings map[string]WriteOffSettings
+
+func (w writeOffSettings) GetWriteOffSettings(company string) (WriteOffSettings, error) {
+	return w[company], nil
+}
+
+func TestWriteOff(t *testing.T) {
+	engine, glStore, paymentStore := newEngine()
+	postInvoice(t, engine, "SINV-0001", 1180.40)
+	settings := writeOffSettings{"ACME": {Account: "Write Off - ACME", CostCenter: "Main - ACME", Limit: 1}}
+
+	pe := newDeposit()
+	pe.PaidAmount = 1180
+	pe.References[0].OutstandingAmount = 1180.40
+	pe.References[0].AllocatedAmount = 1180
+	for range 2 { // Applying twice writes off nothing more
+		if err := pe.ApplyWriteOff(settings); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if pe.References[0].AllocatedAmount != 1180.40 || len(pe.Deductions) != 1 || pe.Deductions[0].Amount != 0.40 || pe.UnallocatedAmount != 0 {
+		t.Fatalf("allocated %.2f, deductions %+v, unallocated %.2f", pe.References[0].AllocatedAmount, pe.Deductions, pe.UnallocatedAmount)
+	}
+	if err := pe.Submit(engine); err != nil {
+		t.Fatal(err)
+	}
+	got := balances(glStore.All())
+	if got[bank] != 1180 || got["Write Off - ACME"] != 0.40 || ledger.Flt(got[debtors], 2) != 0 {
+		t.Errorf("balances = %v", got)
+	}
+	if out := outstandingOf(t, paymentStore, "Sales Invoice", "SINV-0001"); out != 0 {
+		t.Errorf("invoice outstanding = %.2f, want 0", out)
+	}
+
+	// An underpaid supplier invoice is written off as a gain
+	bill := &PaymentEntry{
+		Name: "PE-0002", Company: "ACME", PaymentType: Pay, PartyType: "Supplier", Party: "Widgets Ltd",
+		PaidFrom: bank, PaidTo: "Creditors - ACME", PaidAmount: 499.50,
+		References: []Reference{{ReferenceDoctype: "Purchase Invoice", ReferenceName: "PINV-0001", OutstandingAmount: -500, AllocatedAmount: 499.50}},
+	}
+	if err := bill.ApplyWriteOff(settings); err != nil {
+		t.Fatal(err)
+	}
+	if bill.References[0].AllocatedAmount != 500 || len(bill.Deductions) != 1 || bill.Deductions[0].Amount != -0.50 || bill.UnallocatedAmount != 0 {
+		t.Errorf("allocated %.2f, deductions %+v, unallocated %.2f", bill.References[0].AllocatedAmount, bill.Deductions, bill.UnallocatedAmount)
+	}
+
+	for name, tt := range map[string]struct {
+		settings  WriteOffSettings
+		paid      float64
+		allocated float64
+		want      error
+	}{
+		"above the limit":   {WriteOffSettings{Account: "Write Off - ACME", Limit: 1}, 1100, 1100, nil},
+		"unallocated":       {WriteOffSettings{Account: "Write Off - ACME", Limit: 1}, 1200, 1179.50, nil},
+		"turned off":        {WriteOffSettings{Account: "Write Off - ACME"}, 1179.50, 1179.50, nil},
+		"no account":        {WriteOffSettings{Limit: 1}, 1179.50, 1179.50, ErrMissingWriteOffAccount},
+		"no account needed": {WriteOffSettings{Limit: 1}, 1100, 1100, nil},
+	} {
+		pe := newDeposit()
+		pe.PaidAmount = tt.paid
+		pe.References[0].AllocatedAmount = tt.allocated
+		if err := pe.ApplyWriteOff(writeOffSettings{"ACME": tt.settings}); !errors.Is(err, tt.want) || (tt.want == nil && err != nil) {
+			t.Errorf("%s: ApplyWriteOff() = %v, want %v", name, err, tt.want)
+		}
+		if len(pe.Deductions) != 0 || pe.References[0].AllocatedAmount != tt.allocated {
+			t.Errorf("%s: wrote off %+v", name, pe.Deductions)
+		}
+	}
+}
+
+// unreadableLedger is a payment store whose against voucher queries fail.
+type unreadableLedger struct{ *memstore.PaymentStore }
+
+func (unreadableLedger) GetByAgainstVoucher(voucherType, voucherNo string) ([]ledger.PaymentLedgerEntry, error) {
+	return nil, errors.New("payment ledger unavailable")
+}
+
+func TestInvoicePaidEventFails(t *testing.T) {
+	engine, glStore, paymentStore := newEngine()
+	engine.Events = &ledger.EventBus{}
+	postInvoice(t, engine, "SINV-0001", 1000)
+	engine.PaymentStore = unreadableLedger{paymentStore}
+
+	pe := newDeposit()
+	warnings, err := pe.Post(engine)
+	if err != nil {
+		t.Fatalf("Post() error = %v; the payment was posted", err)
+	}
+	if len(warnings) != 1 || warnings[0].Check != ledger.CheckEvents {
+		t.Errorf("warnings = %v", warnings)
+	}
+	if entries, _ := glStore.GetByVoucher(VoucherType, pe.Name); len(entries) == 0 || pe.Status != Submitted {
+		t.Errorf("payment not submitted: %s, %d entries", pe.Status, len(entries))
+	}
+}
+
+// plainPaymentStore is a payment store that cannot relink against vouchers.
+type plainPaymentStore struct{ ledger.PaymentLedgerStore }
+
+func TestAmendWithoutRelink(t *testing.T) {
+	engine, glStore, paymentStore := newEngine()
+	engine.PaymentStore = plainPaymentStore{paymentStore}
+	postInvoice(t, engine, "SINV-0001", 1000)
+	deposit := newDeposit()
+	if err := deposit.Submit(engine); err != nil {
+		t.Fatal(err)
+	}
+	if err := deposit.Cancel(engine); err != nil {
+		t.Fatal(err)
+	}
+	amended, err := deposit.Amend()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := amended.Submit(engine); !errors.Is(err, ledger.ErrRelinkNotSupported) {
+		t.Fatalf("Submit() error = %v, want ErrRelinkNotSupported", err)
+	}
+	if entries, _ := glStore.GetByVoucher(VoucherType, amended.Name); len(entries) != 0 {
+		t.Errorf("amendment posted %d entries before failing", len(entries))
+	}
+}
@@ -0,0 +1,194 @@
+package paymententry
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/ledger"
+)
+
+// PaymentLedger abstracts payment ledger queries by against voucher.
+// Maps to: the Payment Ledger Entry queries in accounts/utils.py
+type PaymentLedger interface {
+	// GetByAgainstVoucher returns the entries booked against a voucher.
+	GetByAgainstVoucher(voucherType, voucherNo string) ([]ledger.PaymentLedgerEntry, error)
+}
+
+// CreditNoteMaker creates and submits a credit (or debit) note returning
+// part of an invoice. The note is booked against the invoice, as with
+// return_against, so the invoice stays settled once the refund reopens it.
+//
+// Maps to: make_sales_return() / make_purchase_return() followed by submit
+type CreditNoteMaker interface {
+	MakeCreditNote(against Reference, amount float64, postingDate time.Time, reason string) (string, error)
+}
+
+// RefundRequest describes money returned to the party of a payment.
+type RefundRequest struct {
+	Name        string
+	PostingDate time.Time
+	Amount      float64 // Zero refunds everything still refundable
+	Account     string  // Bank or cash account; defaults to the original's
+	Reason      string
+
+	// Optional. When set, each refunded invoice allocation is matched by a
+	// credit note, so the invoice stays settled; otherwise the allocations
+	// are reversed and the invoices become outstanding again.
+	CreditNotes CreditNoteMaker
+}
+
+// MakeRefund builds the payment entry that refunds an earlier payment. The
+// unallocated advance is refunded first, then the invoice allocations in
+// reverse order. The refund runs in the opposite direction to the original,
+// so its allocations restore the outstanding each one had settled. The
+// result is validated but not submitted; the credit notes, which are,
+// are only made once it is valid.
+//
+// An allocation can only be reversed up to what payments still settle on
+// its invoice, so an allocation already refunded is not refunded twice.
+//
+// Maps to: get_payment_entry() for a refund (payment_type "Pay" to a
+// Customer) in payment_entry.py
+func MakeRefund(original *PaymentEntry, req RefundRequest, pl PaymentLedger) (*PaymentEntry, error) {
+	if original.Party == "" || original.PaymentType == InternalTransfer {
+		return nil, ErrMissingParty
+	}
+	if original.Status == Returned {
+		return nil, fmt.Errorf("%w: %s", ErrPaymentReturned, original.Name)
+	}
+
+	refund := &PaymentEntry{
+		Name:          req.Name,
+		Company:       original.Company,
+		PostingDate:   req.PostingDate,
+		PaymentType:   Pay,
+		ModeOfPayment: original.ModeOfPayment,
+		PartyType:     original.PartyType,
+		Party:         original.Party,
+		Currency:      original.Currency,
+		CostCenter:    original.CostCenter,
+		Project:       original.Project,
+		Remarks:       req.Reason,
+	}
+	if refund.Remarks == "" {
+		refund.Remarks = "Refund of " + original.Name
+	}
+	account := req.Account
+	if account == "" {
+		account = original.BankAccount()
+	}
+	if original.PaymentType == Pay {
+		refund.PaymentType = Receive
+		refund.PaidFrom, refund.PaidTo = original.PartyAccount(), account
+	} else {
+		refund.PaidFrom, refund.PaidTo = account, original.PartyAccount()
+	}
+
+	// Direction in which the original moved each outstanding
+	direction := original.outstandingEffect(1)
+
+	type refundable struct {
+		ref    Reference
+		amount float64
+	}
+	var candidates []refundable
+	var total float64
+
+	advance, err := outstanding(pl, VoucherType, original.Name)
+	if err != nil {
+		return nil, err
+	}
+	if amount := ledger.Flt(advance/direction, 2); amount > 0 {
+		candidates = append(candidates, refundable{
+			ref:    Reference{ReferenceDoctype: VoucherType, ReferenceName: original.Name, OutstandingAmount: advance},
+			amount: amount,
+		})
+		total += amount
+	}
+
+	for i := len(original.References) - 1; i >= 0; i-- {
+		ref := original.References[i]
+		if ref.AllocatedAmount <= 0 {
+			continue
+		}
+		entries, err := pl.GetByAgainstVoucher(ref.ReferenceDoctype, ref.ReferenceName)
+		if err != nil {
+			return nil, err
+		}
+		current := ledger.OutstandingAmount(entries, ref.ReferenceDoctype, ref.ReferenceName)
+		paid := ledger.Flt(paidAmount(entries)/direction, 2)
+		amount := ledger.Flt(min(ref.AllocatedAmount, paid), 2)
+		if amount <= 0 {
+			continue
+		}
+		ref.OutstandingAmount = current
+		candidates = append(candidates, refundable{ref: ref, amount: amount})
+		total += amount
+	}
+
+	total = ledger.Flt(total, 2)
+	if total == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrNothingToRefund, original.Name)
+	}
+	remaining := req.Amount
+	if remaining == 0 {
+		remaining = total
+	}
+	if remaining > total {
+		return nil, fmt.Errorf("%w: %.2f requested, %.2f refundable", ErrRefundExceeded, remaining, total)
+	}
+	refund.PaidAmount = ledger.Flt(remaining, 2)
+	refund.ReceivedAmount = refund.PaidAmount
+
+	var notes []refundable
+	for _, c := range candidates {
+		if remaining <= 0 {
+			break
+		}
+		amount := ledger.Flt(min(c.amount, remaining), 2)
+		remaining = ledger.Flt(remaining-amount, 2)
+
+		if req.CreditNotes != nil && c.ref.ReferenceDoctype != VoucherType {
+			notes = append(notes, refundable{ref: c.ref, amount: amount})
+		}
+		ref := c.ref
+		ref.AllocatedAmount = amount
+		refund.References = append(refund.References, ref)
+	}
+
+	if err := refund.Validate(); err != nil {
+		return nil, err
+	}
+
+	// Credit notes are submitted as they are made, so they are made last,
+	// once the refund is known to be valid
+	for _, n := range notes {
+		note, err := req.CreditNotes.MakeCreditNote(n.ref, n.amount, req.PostingDate, req.Reason)
+		if err != nil {
+			return nil, fmt.Errorf("credit note against %s: %w", n.ref.ReferenceName, err)
+		}
+		refund.Remarks += "; credit note " + note
+	}
+	return refund, nil
+}
+
+// outstanding returns a voucher's outstanding amount from the payment ledger.
+func outstanding(pl PaymentLedger, voucherType, voucherNo string) (float64, error) {
+	entries, err := pl.GetByAgainstVoucher(voucherType, voucherNo)
+	if err != nil {
+		return 0, err
+	}
+	return ledger.OutstandingAmount(entries, voucherType, voucherNo), nil
+}
+
+// paidAmount returns the net amount payment entries booked against a
+// voucher, in payment ledger sign.
+func paidAmount(entries []ledger.PaymentLedgerEntry) float64 {
+	var amount float64
+	for _, e := range entries {
+		if !e.Delinked && e.VoucherType == VoucherType {
+			amount += e.Amount
+		}
+	}
+	return amount
+}
@@ -0,0 +1,77 @@
+package paymententry
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/senguttuvang/erpnext-go/ledger"
+)
+
+// WriteOffSettings configure a company's automatic write-off of small
+// outstanding amounts, such as the few paise a customer leaves unpaid.
+// A zero Limit turns it off.
+//
+// Maps to: Company.write_off_account, Company.write_off_cost_center and the
+// write-off limit in Accounts Settings
+type WriteOffSettings struct {
+	Account    string
+	CostCenter string
+	Limit      float64 // Largest outstanding written off, in company currency
+}
+
+// WriteOffLookup fetches the write-off settings of a company.
+type WriteOffLookup interface {
+	GetWriteOffSettings(company string) (WriteOffSettings, error)
+}
+
+// ApplyWriteOff closes the invoices the payment leaves almost settled.
+// Each invoice allocation that leaves an outstanding no larger than the
+// company's limit is raised to settle the invoice in full, and the
+// difference is added as a deduction on the write-off account: a debit
+// for a receivable left short, a credit for a payable underpaid. Nothing
+// is written off while the payment has an unallocated amount, which
+// should be allocated instead. Calling it again writes off nothing more.
+//
+// Maps to: the "Write Off Difference Amount" action on Payment Entry,
+// applied automatically below the limit
+func (pe *PaymentEntry) ApplyWriteOff(settings WriteOffLookup) error {
+	if pe.Party == "" || pe.PaymentType == InternalTransfer {
+		return nil
+	}
+	s, err := settings.GetWriteOffSettings(pe.Company)
+	if err != nil || s.Limit <= 0 {
+		return err
+	}
+	if pe.ReceivedAmount == 0 {
+		pe.ReceivedAmount = pe.PaidAmount
+	}
+	pe.SetUnallocatedAmount()
+	if pe.UnallocatedAmount > 0 {
+		return nil
+	}
+
+	var total float64
+	for i := range pe.References {
+		ref := &pe.References[i]
+		if ref.ReferenceDoctype != "Sales Invoice" && ref.ReferenceDoctype != "Purchase Invoice" || ref.AllocatedAmount <= 0 {
+			continue
+		}
+		remaining := ledger.Flt(ref.OutstandingAmount+pe.outstandingEffect(ref.AllocatedAmount), 2)
+		if remaining == 0 || math.Abs(remaining) > s.Limit || (remaining > 0) != (ref.OutstandingAmount > 0) {
+			continue
+		}
+		if s.Account == "" {
+			return fmt.Errorf("%w: %s", ErrMissingWriteOffAccount, pe.Company)
+		}
+		// The extra allocation brings the outstanding to zero; its effect
+		// on the party is balanced by a deduction of the remainder
+		ref.AllocatedAmount = ledger.Flt(ref.AllocatedAmount-pe.outstandingEffect(remaining), 2)
+		total += remaining
+	}
+
+	if total = ledger.Flt(total, 2); total != 0 {
+		pe.Deductions = append(pe.Deductions, Deduction{Account: s.Account, CostCenter: s.CostCenter, Amount: total})
+		pe.SetUnallocatedAmount()
+	}
+	return nil
+}
@@ -0,0 +1,186 @@
+// Package paymentterms implements Payment Terms Templates and the payment
+// schedule they produce on an invoice: how much of the invoice falls due
+// on which date.
+//
+// Migrated from: erpnext/accounts/doctype/payment_terms_template and
+// get_payment_terms() / get_due_date() in controllers/accounts_controller.py
+package paymentterms
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/ledger"
+)
+
+// Payment terms errors
+var (
+	ErrNotFound        = errors.New("payment terms template not found")
+	ErrInvalidPortions = errors.New("invoice portions must add up to 100")
+	ErrInvalidTerm     = errors.New("invalid payment term")
+)
+
+// DueDateBasedOn selects how a term's due date is counted.
+type DueDateBasedOn string
+
+const (
+	DaysAfterInvoiceDate DueDateBasedOn = "Day(s) after invoice date"
+	DaysAfterMonthEnd    DueDateBasedOn = "Day(s) after the end of the invoice month"
+	MonthsAfterMonthEnd  DueDateBasedOn = "Month(s) after the end of the invoice month"
+)
+
+// Term is one instalment of a template.
+//
+// Maps to: Payment Terms Template Detail child table
+type Term struct {
+	PaymentTerm    string
+	Description    string
+	InvoicePortion float64 // Percent of the grand total
+	DueDateBasedOn DueDateBasedOn
+	CreditDays     int
+	CreditMonths   int
+}
+
+// Template is a set of terms whose portions add up to the whole invoice.
+//
+// Maps to: Payment Terms Template doctype
+type Template struct {
+	Name  string
+	Terms []Term
+}
+
+// Lookup abstracts queries for payment terms templates.
+type Lookup interface {
+	// GetTemplate returns a template by name, or an error wrapping
+	// ErrNotFound.
+	GetTemplate(name string) (*Template, error)
+}
+
+// Row is an instalment of an invoice's payment schedule.
+//
+// Maps to: Payment Schedule child table
+type Row struct {
+	PaymentTerm       string
+	Description       string
+	DueDate           time.Time
+	InvoicePortion    float64
+	PaymentAmount     float64 // Transaction currency
+	BasePaymentAmount float64 // Company currency
+}
+
+// Validate checks the terms and that their portions add up to 100.
+//
+// Maps to: validate_invoice_portion() in payment_terms_template.py
+func (t *Template) Validate() error {
+	total := 0.0
+	for i, term := range t.Terms {
+		switch term.DueDateBasedOn {
+		case DaysAfterInvoiceDate, DaysAfterMonthEnd, MonthsAfterMonthEnd:
+		default:
+			return fmt.Errorf("%w: row %d of %s: due date based on %q", ErrInvalidTerm, i+1, t.Name, term.DueDateBasedOn)
+		}
+		if term.InvoicePortion <= 0 || term.CreditDays < 0 || term.CreditMonths < 0 {
+			return fmt.Errorf("%w: row %d of %s", ErrInvalidTerm, i+1, t.Name)
+		}
+		total += term.InvoicePortion
+	}
+	if ledger.Flt(total, 2) != 100 {
+		return fmt.Errorf("%w: %s adds up to %g", ErrInvalidPortions, t.Name, ledger.Flt(total, 2))
+	}
+	return nil
+}
+
+// DueDate returns the date a term falls due for an invoice posted on
+// postingDate.
+//
+// Maps to: get_due_date() in controllers/accounts_controller.py
+//
+// Python equivalent:
+//
+//	if term.due_date_based_on == "Day(s) after invoice date":
+//	    due_date = add_days(posting_date, term.credit_days)
+//	elif term.due_date_based_on == "Day(s) after the end of the invoice month":
+//	    due_date = add_days(get_last_day(posting_date), term.credit_days)
+//	elif term.due_date_based_on == "Month(s) after the end of the invoice month":
+//	    due_date = get_last_day(add_months(posting_date, term.credit_months))
+func (term *Term) DueDate(postingDate time.Time) time.Time {
+	date := ledger.CivilDate(postingDate)
+	switch term.DueDateBasedOn {
+	case DaysAfterMonthEnd:
+		return lastDay(date, 0).AddDate(0, 0, term.CreditDays)
+	case MonthsAfterMonthEnd:
+		return lastDay(date, term.CreditMonths)
+	}
+	return date.AddDate(0, 0, term.CreditDays)
+}
+
+// lastDay returns the last day of the month months after date's.
+func lastDay(date time.Time, months int) time.Time {
+	return time.Date(date.Year(), date.Month()+time.Month(months)+1, 0, 0, 0, 0, 0, time.UTC)
+}
+
+// Schedule splits an invoice's grand total into the template's
+// instalments. Amounts are rounded to precision decimal places and the
+// last instalment takes the rounding difference, so the rows add up to
+// the totals exactly.
+//
+// Maps to: get_payment_terms() in controllers/accounts_controller.py
+func (t *Template) Schedule(postingDate time.Time, grandTotal, baseGrandTotal float64, precision int) ([]Row, error) {
+	if err := t.Validate(); err != nil {
+		return nil, err
+	}
+	rows := make([]Row, len(t.Terms))
+	var total, baseTotal float64
+	for i := range t.Terms {
+		term := &t.Terms[i]
+		rows[i] = Row{
+			PaymentTerm:       term.PaymentTerm,
+			Description:       term.Description,
+			DueDate:           term.DueDate(postingDate),
+			InvoicePortion:    term.InvoicePortion,
+			PaymentAmount:     ledger.Flt(grandTotal*term.InvoicePortion/100, precision),
+			BasePaymentAmount: ledger.Flt(baseGrandTotal*term.InvoicePortion/100, precision),
+		}
+		total += rows[i].PaymentAmount
+		baseTotal += rows[i].BasePaymentAmount
+	}
+	last := &rows[len(rows)-1]
+	last.PaymentAmount = ledger.Flt(last.PaymentAmount+grandTotal-total, precision)
+	last.BasePaymentAmount = ledger.Flt(last.BasePaymentAmount+baseGrandTotal-baseTotal, precision)
+	return rows, nil
+}
+
+// Single returns the schedule of an invoice without a template: the whole
+// amount due on dueDate.
+func Single(dueDate time.Time, grandTotal, baseGrandTotal float64) []Row {
+	return []Row{{
+		DueDate:           ledger.CivilDate(dueDate),
+		InvoicePortion:    100,
+		PaymentAmount:     grandTotal,
+		BasePaymentAmount: baseGrandTotal,
+	}}
+}
+
+// DueDate returns the due date of a schedule: the latest instalment's.
+func DueDate(schedule []Row) time.Time {
+	var due time.Time
+	for _, r := range schedule {
+		if r.DueDate.After(due) {
+			due = r.DueDate
+		}
+	}
+	return due
+}
+
+// MemoryStore is an in-memory Lookup.
+type MemoryStore map[string]*Template
+
+// GetTemplate implements Lookup.
+func (s MemoryStore) GetTemplate(name string) (*Template, error) {
+	t, ok := s[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	return t, nil
+}
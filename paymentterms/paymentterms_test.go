@@ -0,0 +1,99 @@
+package paymentterms
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/ledger"
+)
+
+func date(s string) time.Time {
+	t, err := ledger.ParseDate(s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestDueDate(t *testing.T) {
+	tests := []struct {
+		term   Term
+		posted string
+		want   string
+	}{
+		{Term{DueDateBasedOn: DaysAfterInvoiceDate, CreditDays: 30}, "2024-01-15", "2024-02-14"},
+		{Term{DueDateBasedOn: DaysAfterInvoiceDate}, "2024-01-15", "2024-01-15"},
+		{Term{DueDateBasedOn: DaysAfterMonthEnd, CreditDays: 10}, "2024-01-15", "2024-02-10"},
+		{Term{DueDateBasedOn: MonthsAfterMonthEnd, CreditMonths: 1}, "2024-01-31", "2024-02-29"},
+		{Term{DueDateBasedOn: MonthsAfterMonthEnd}, "2024-12-05", "2024-12-31"},
+	}
+	for _, tt := range tests {
+		if got := tt.term.DueDate(date(tt.posted)); !got.Equal(date(tt.want)) {
+			t.Errorf("%s %d/%d from %s = %s, want %s", tt.term.DueDateBasedOn, tt.term.CreditDays, tt.term.CreditMonths,
+				tt.posted, got.Format(ledger.DateLayout), tt.want)
+		}
+	}
+}
+
+func TestSchedule(t *testing.T) {
+	thirds := &Template{Name: "Thirds", Terms: []Term{
+		{PaymentTerm: "Advance", InvoicePortion: 33.33, DueDateBasedOn: DaysAfterInvoiceDate},
+		{PaymentTerm: "Delivery", InvoicePortion: 33.33, DueDateBasedOn: DaysAfterInvoiceDate, CreditDays: 30},
+		{PaymentTerm: "Balance", InvoicePortion: 33.34, DueDateBasedOn: DaysAfterMonthEnd, CreditDays: 45},
+	}}
+	rows, err := thirds.Schedule(date("2024-03-10"), 1000, 83000, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Row{
+		{PaymentTerm: "Advance", DueDate: date("2024-03-10"), InvoicePortion: 33.33, PaymentAmount: 333.3, BasePaymentAmount: 27663.9},
+		{PaymentTerm: "Delivery", DueDate: date("2024-04-09"), InvoicePortion: 33.33, PaymentAmount: 333.3, BasePaymentAmount: 27663.9},
+		{PaymentTerm: "Balance", DueDate: date("2024-05-15"), InvoicePortion: 33.34, PaymentAmount: 333.4, BasePaymentAmount: 27672.2},
+	}
+	if len(rows) != len(want) {
+		t.Fatalf("rows = %+v", rows)
+	}
+	for i, w := range want {
+		if rows[i] != w {
+			t.Errorf("row %d = %+v, want %+v", i, rows[i], w)
+		}
+	}
+	if got := DueDate(rows); !got.Equal(date("2024-05-15")) {
+		t.Errorf("DueDate() = %s", got.Format(ledger.DateLayout))
+	}
+
+	// The last instalment absorbs the rounding difference
+	rows, err = (&Template{Name: "Halves", Terms: []Term{
+		{InvoicePortion: 50, DueDateBasedOn: DaysAfterInvoiceDate},
+		{InvoicePortion: 50, DueDateBasedOn: DaysAfterInvoiceDate, CreditDays: 15},
+	}}).Schedule(date("2024-03-10"), 100.01, 100.01, 2)
+	if err != nil || rows[0].PaymentAmount != 50.01 || rows[1].PaymentAmount != 50 {
+		t.Errorf("Halves = %+v, %v", rows, err)
+	}
+
+	for name, bad := range map[string]struct {
+		tmpl *Template
+		want error
+	}{
+		"short":     {&Template{Name: "Short", Terms: []Term{{InvoicePortion: 90, DueDateBasedOn: DaysAfterInvoiceDate}}}, ErrInvalidPortions},
+		"empty":     {&Template{Name: "Empty"}, ErrInvalidPortions},
+		"no basis":  {&Template{Name: "No Basis", Terms: []Term{{InvoicePortion: 100}}}, ErrInvalidTerm},
+		"negative":  {&Template{Name: "Negative", Terms: []Term{{InvoicePortion: 100, DueDateBasedOn: DaysAfterInvoiceDate, CreditDays: -1}}}, ErrInvalidTerm},
+		"zero part": {&Template{Name: "Zero", Terms: []Term{{DueDateBasedOn: DaysAfterInvoiceDate}, {InvoicePortion: 100, DueDateBasedOn: DaysAfterInvoiceDate}}}, ErrInvalidTerm},
+	} {
+		if _, err := bad.tmpl.Schedule(date("2024-03-10"), 100, 100, 2); !errors.Is(err, bad.want) {
+			t.Errorf("%s: Schedule() error = %v, want %v", name, err, bad.want)
+		}
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	s := MemoryStore{"Net 30": {Name: "Net 30"}}
+	if tmpl, err := s.GetTemplate("Net 30"); err != nil || tmpl.Name != "Net 30" {
+		t.Errorf("GetTemplate(Net 30) = %v, %v", tmpl, err)
+	}
+	if _, err := s.GetTemplate("Net 90"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetTemplate(Net 90) error = %v", err)
+	}
+}
@@ -0,0 +1,105 @@
+package payqr
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+	"unicode/utf8"
+)
+
+// epcMaxBytes is the largest payload the EPC guidelines allow.
+const epcMaxBytes = 331
+
+// SEPAPayee is the beneficiary of a SEPA credit transfer.
+type SEPAPayee struct {
+	Name string
+	IBAN string
+	BIC  string // Optional within the EEA
+}
+
+// EPC returns an EPC069-12 ("GiroCode") payload for a SEPA credit
+// transfer. Amounts must be in EUR. A Reference that is a valid ISO 11649
+// creditor reference ("RF...") is sent as a structured reference;
+// otherwise Reference and Note are sent as remittance text.
+//
+// Spec: EPC069-12 v3.0, Quick Response Code guidelines
+func EPC(payee SEPAPayee, p Payment) (string, error) {
+	iban := compact(payee.IBAN)
+	if !validIBAN(iban) {
+		return "", fmt.Errorf("%w: IBAN %q", ErrInvalidPayee, payee.IBAN)
+	}
+	bic := compact(payee.BIC)
+	if n := len(bic); n != 0 && n != 8 && n != 11 {
+		return "", fmt.Errorf("%w: BIC %q", ErrInvalidPayee, payee.BIC)
+	}
+	if payee.Name == "" || utf8.RuneCountInString(payee.Name) > 70 {
+		return "", fmt.Errorf("%w: name must be 1-70 characters", ErrInvalidPayee)
+	}
+	if err := checkPayment(p, "EUR", 999999999.99); err != nil {
+		return "", err
+	}
+	if p.Amount > 0 && p.Amount < 0.01 {
+		return "", fmt.Errorf("%w: %v", ErrInvalidAmount, p.Amount)
+	}
+
+	amount := ""
+	if p.Amount > 0 {
+		amount = "EUR" + formatAmount(p.Amount)
+	}
+	structured, text := "", ""
+	if ref := compact(p.Reference); validCreditorRef(ref) {
+		structured, text = ref, p.Note
+	} else {
+		text = strings.TrimSpace(p.Reference + " " + p.Note)
+	}
+	lines := []string{
+		"BCD", "002", "1", "SCT",
+		bic, payee.Name, iban, amount,
+		"", // Purpose
+		structured,
+		truncate(text, 140),
+	}
+	payload := strings.TrimRight(strings.Join(lines, "\n"), "\n")
+	if len(payload) > epcMaxBytes {
+		return "", fmt.Errorf("%w: %d bytes, max %d", ErrTooLong, len(payload), epcMaxBytes)
+	}
+	return payload, nil
+}
+
+// compact upper-cases s and removes spaces.
+func compact(s string) string {
+	return strings.ToUpper(strings.ReplaceAll(s, " ", ""))
+}
+
+// validIBAN checks the length and ISO 7064 mod 97-10 check digits.
+func validIBAN(iban string) bool {
+	if len(iban) < 15 || len(iban) > 34 {
+		return false
+	}
+	return mod97(iban[4:] + iban[:4])
+}
+
+// validCreditorRef checks an ISO 11649 "RF" reference.
+func validCreditorRef(ref string) bool {
+	if len(ref) < 5 || len(ref) > 25 || !strings.HasPrefix(ref, "RF") {
+		return false
+	}
+	return mod97(ref[4:] + ref[:4])
+}
+
+// mod97 reports whether s, with letters replaced by 10-35, is 1 mod 97.
+func mod97(s string) bool {
+	var digits strings.Builder
+	for _, r := range s {
+		switch {
+		case r >= '0' && r <= '9':
+			digits.WriteRune(r)
+		case r >= 'A' && r <= 'Z':
+			fmt.Fprintf(&digits, "%d", r-'A'+10)
+		default:
+			return false
+		}
+	}
+	n, ok := new(big.Int).SetString(digits.String(), 10)
+	return ok && n.Mod(n, big.NewInt(97)).Int64() == 1
+}
@@ -0,0 +1,76 @@
+// Package payqr builds the payloads of payment QR codes printed on
+// invoices and payment requests: UPI intent links (India), EPC069-12 SEPA
+// credit transfer codes (euro area) and PromptPay EMVCo codes (Thailand).
+//
+// The payloads are plain strings; any QR encoder can render them.
+package payqr
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/senguttuvang/erpnext-go/salesinvoice"
+)
+
+// Payload errors
+var (
+	ErrInvalidPayee    = errors.New("invalid payee")
+	ErrInvalidAmount   = errors.New("invalid amount")
+	ErrInvalidCurrency = errors.New("currency not supported by scheme")
+	ErrTooLong         = errors.New("payload field too long")
+)
+
+// Payment is what the payer is asked to pay.
+type Payment struct {
+	Amount    float64 // Zero leaves the amount to the payer
+	Currency  string
+	Reference string // Invoice or payment request name
+	Note      string // Free text shown to the payer
+}
+
+// InvoicePayment returns the payment due on a sales invoice: its rounded
+// total, or grand total when not rounded, less any POS payments.
+func InvoicePayment(inv *salesinvoice.SalesInvoice) Payment {
+	total := inv.RoundedTotal
+	if total == 0 {
+		total = inv.GrandTotal
+	}
+	return Payment{
+		Amount:    math.Max(total-inv.PaidAmount+inv.ChangeAmount, 0),
+		Currency:  inv.Currency,
+		Reference: inv.Name,
+		Note:      "Payment for " + inv.Name,
+	}
+}
+
+// checkPayment validates the amount and currency of a payment for a
+// scheme. An empty currency is taken to be the scheme's.
+func checkPayment(p Payment, currency string, max float64) error {
+	if p.Currency != "" && p.Currency != currency {
+		return fmt.Errorf("%w: %s, need %s", ErrInvalidCurrency, p.Currency, currency)
+	}
+	if p.Amount < 0 || p.Amount > max || math.IsNaN(p.Amount) {
+		return fmt.Errorf("%w: %v", ErrInvalidAmount, p.Amount)
+	}
+	return nil
+}
+
+// formatAmount writes an amount with two decimals and no grouping.
+func formatAmount(amount float64) string {
+	return strconv.FormatFloat(amount, 'f', 2, 64)
+}
+
+// truncate cuts s to at most n runes.
+func truncate(s string, n int) string {
+	if r := []rune(s); len(r) > n {
+		return string(r[:n])
+	}
+	return s
+}
+
+func isDigits(s string) bool {
+	return s != "" && strings.Trim(s, "0123456789") == ""
+}
@@ -0,0 +1,99 @@
+package payqr
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/senguttuvang/erpnext-go/salesinvoice"
+	"github.com/senguttuvang/erpnext-go/taxcalc"
+)
+
+func TestUPI(t *testing.T) {
+	payee := UPIPayee{VPA: "acme@hdfcbank", Name: "Acme & Sons"}
+	got, err := UPI(payee, Payment{Amount: 1180, Currency: "INR", Reference: "SINV-0001", Note: "Payment for SINV-0001"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "upi://pay?pa=acme@hdfcbank&pn=Acme%20%26%20Sons&tr=SINV-0001&tn=Payment%20for%20SINV-0001&am=1180.00&cu=INR"
+	if got != want {
+		t.Errorf("UPI =\n%s\nwant\n%s", got, want)
+	}
+
+	if _, err := UPI(UPIPayee{VPA: "acme", Name: "Acme"}, Payment{}); !errors.Is(err, ErrInvalidPayee) {
+		t.Errorf("bad VPA: err = %v", err)
+	}
+	if _, err := UPI(payee, Payment{Amount: 10, Currency: "USD"}); !errors.Is(err, ErrInvalidCurrency) {
+		t.Errorf("USD: err = %v", err)
+	}
+}
+
+func TestEPC(t *testing.T) {
+	payee := SEPAPayee{Name: "Acme GmbH", IBAN: "DE89 3704 0044 0532 0130 00", BIC: "COBADEFFXXX"}
+	got, err := EPC(payee, Payment{Amount: 99.5, Currency: "EUR", Reference: "RF18 5390 0754 7034"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "BCD\n002\n1\nSCT\nCOBADEFFXXX\nAcme GmbH\nDE89370400440532013000\nEUR99.50\n\nRF18539007547034"
+	if got != want {
+		t.Errorf("EPC =\n%q\nwant\n%q", got, want)
+	}
+
+	got, err = EPC(SEPAPayee{Name: "Acme GmbH", IBAN: "DE89370400440532013000"}, Payment{Reference: "SINV-0001", Note: "Thank you"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = "BCD\n002\n1\nSCT\n\nAcme GmbH\nDE89370400440532013000\n\n\n\nSINV-0001 Thank you"
+	if got != want {
+		t.Errorf("EPC without amount =\n%q\nwant\n%q", got, want)
+	}
+
+	if _, err := EPC(SEPAPayee{Name: "Acme", IBAN: "DE88370400440532013000"}, Payment{}); !errors.Is(err, ErrInvalidPayee) {
+		t.Errorf("bad check digits: err = %v", err)
+	}
+	if _, err := EPC(payee, Payment{Amount: 1e10}); !errors.Is(err, ErrInvalidAmount) {
+		t.Errorf("too large: err = %v", err)
+	}
+}
+
+func TestPromptPay(t *testing.T) {
+	if got := crc16("123456789"); got != 0x29B1 {
+		t.Fatalf("crc16 check value = %04X, want 29B1", got)
+	}
+
+	// Payloads without the trailing four CRC digits
+	tests := []struct {
+		payee  PromptPayPayee
+		amount float64
+		want   string
+	}{
+		{PromptPayPayee{Phone: "080-123-4567"}, 0, "00020101021129370016A000000677010111011300668012345675802TH53037646304"},
+		{PromptPayPayee{Phone: "+66801234567"}, 4.22, "00020101021229370016A000000677010111011300668012345675802TH530376454044.226304"},
+		{PromptPayPayee{TaxID: "0-1234-56789-01-2"}, 0, "00020101021129370016A000000677010111021301234567890125802TH53037646304"},
+	}
+	for _, tt := range tests {
+		got, err := PromptPay(tt.payee, Payment{Amount: tt.amount})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := fmt.Sprintf("%s%04X", tt.want, crc16(tt.want)); got != want {
+			t.Errorf("PromptPay(%+v, %v) =\n%s\nwant\n%s", tt.payee, tt.amount, got, want)
+		}
+	}
+
+	if _, err := PromptPay(PromptPayPayee{Phone: "0801234567", TaxID: "0123456789012"}, Payment{}); !errors.Is(err, ErrInvalidPayee) {
+		t.Errorf("two proxies: err = %v", err)
+	}
+	if _, err := PromptPay(PromptPayPayee{Phone: "0801234567"}, Payment{Amount: 5, Currency: "INR"}); !errors.Is(err, ErrInvalidCurrency) {
+		t.Errorf("INR: err = %v", err)
+	}
+}
+
+func TestInvoicePayment(t *testing.T) {
+	inv := &salesinvoice.SalesInvoice{Name: "SINV-0001", IsPOS: true, PaidAmount: 500,
+		Document: taxcalc.Document{Currency: "INR", GrandTotal: 1179.6, RoundedTotal: 1180}}
+	p := InvoicePayment(inv)
+	if p.Amount != 680 || p.Currency != "INR" || p.Reference != "SINV-0001" {
+		t.Errorf("InvoicePayment = %+v", p)
+	}
+}
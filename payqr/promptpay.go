@@ -0,0 +1,114 @@
+package payqr
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// promptPayAID is the application ID of PromptPay merchant-presented codes.
+const promptPayAID = "A000000677010111"
+
+// PromptPayPayee is the receiving PromptPay proxy; set exactly one field.
+type PromptPayPayee struct {
+	Phone   string // Thai mobile number, e.g. "081-234-5678"
+	TaxID   string // 13-digit national ID or tax ID
+	EWallet string // 15-digit e-wallet ID
+}
+
+// PromptPay returns an EMVCo merchant-presented payload for a PromptPay
+// transfer. Amounts must be in THB; a zero amount gives a static code the
+// payer fills in.
+//
+// Spec: EMVCo QR Code Specification for Payment Systems (Merchant-Presented
+// Mode) v1.1, Thai QR Payment Standard
+func PromptPay(payee PromptPayPayee, p Payment) (string, error) {
+	tag, id, err := promptPayProxy(payee)
+	if err != nil {
+		return "", err
+	}
+	if err := checkPayment(p, "THB", 9999999999.99); err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	tlv(&b, "00", "01")
+	if p.Amount > 0 {
+		tlv(&b, "01", "12") // Dynamic: single use
+	} else {
+		tlv(&b, "01", "11") // Static: reusable
+	}
+	var account strings.Builder
+	tlv(&account, "00", promptPayAID)
+	tlv(&account, tag, id)
+	tlv(&b, "29", account.String())
+	tlv(&b, "58", "TH")
+	tlv(&b, "53", "764")
+	if p.Amount > 0 {
+		tlv(&b, "54", formatAmount(p.Amount))
+	}
+	b.WriteString("6304")
+	b.WriteString(fmt.Sprintf("%04X", crc16(b.String())))
+	return b.String(), nil
+}
+
+// promptPayProxy returns the merchant account sub-tag and formatted ID.
+func promptPayProxy(payee PromptPayPayee) (string, string, error) {
+	digits := func(s string) string {
+		return strings.NewReplacer("-", "", " ", "", "+", "").Replace(s)
+	}
+	phone, taxID, wallet := digits(payee.Phone), digits(payee.TaxID), digits(payee.EWallet)
+	set := 0
+	for _, s := range []string{phone, taxID, wallet} {
+		if s != "" {
+			set++
+		}
+	}
+	if set != 1 {
+		return "", "", fmt.Errorf("%w: set exactly one of phone, tax ID and e-wallet", ErrInvalidPayee)
+	}
+	switch {
+	case phone != "":
+		// 0812345678 or 66812345678 -> 0066812345678
+		phone = strings.TrimPrefix(strings.TrimPrefix(phone, "66"), "0")
+		if !isDigits(phone) || len(phone) != 9 {
+			return "", "", fmt.Errorf("%w: phone %q", ErrInvalidPayee, payee.Phone)
+		}
+		return "01", "0066" + phone, nil
+	case taxID != "":
+		if !isDigits(taxID) || len(taxID) != 13 {
+			return "", "", fmt.Errorf("%w: tax ID %q", ErrInvalidPayee, payee.TaxID)
+		}
+		return "02", taxID, nil
+	}
+	if !isDigits(wallet) || len(wallet) != 15 {
+		return "", "", fmt.Errorf("%w: e-wallet %q", ErrInvalidPayee, payee.EWallet)
+	}
+	return "03", wallet, nil
+}
+
+// tlv writes an EMVCo tag-length-value field.
+func tlv(b *strings.Builder, tag, value string) {
+	b.WriteString(tag)
+	if len(value) < 10 {
+		b.WriteByte('0')
+	}
+	b.WriteString(strconv.Itoa(len(value)))
+	b.WriteString(value)
+}
+
+// crc16 is CRC-16/CCITT-FALSE (polynomial 0x1021, initial value 0xFFFF).
+func crc16(s string) uint16 {
+	crc := uint16(0xFFFF)
+	for i := 0; i < len(s); i++ {
+		crc ^= uint16(s[i]) << 8
+		for range 8 {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
@@ -0,0 +1,54 @@
+package payqr
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// UPIPayee is the receiving side of a UPI payment.
+type UPIPayee struct {
+	VPA          string // Virtual payment address, e.g. "acme@hdfcbank"
+	Name         string
+	MerchantCode string // Optional merchant category code
+}
+
+// UPI returns a UPI deep link ("upi://pay?...") for the payment. Amounts
+// must be in INR.
+//
+// Spec: NPCI UPI Linking Specifications v1.6
+func UPI(payee UPIPayee, p Payment) (string, error) {
+	user, handle, ok := strings.Cut(payee.VPA, "@")
+	if !ok || user == "" || handle == "" || strings.ContainsAny(payee.VPA, " ?&") {
+		return "", fmt.Errorf("%w: VPA %q", ErrInvalidPayee, payee.VPA)
+	}
+	if payee.Name == "" {
+		return "", fmt.Errorf("%w: name is required", ErrInvalidPayee)
+	}
+	if err := checkPayment(p, "INR", 1e9); err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	b.WriteString("upi://pay?pa=" + payee.VPA)
+	param := func(key, value string) {
+		if value != "" {
+			b.WriteString("&" + key + "=" + upiEscape(value))
+		}
+	}
+	param("pn", payee.Name)
+	param("mc", payee.MerchantCode)
+	param("tr", p.Reference)
+	param("tn", truncate(p.Note, 80))
+	if p.Amount > 0 {
+		param("am", formatAmount(p.Amount))
+	}
+	param("cu", "INR")
+	return b.String(), nil
+}
+
+// upiEscape percent-encodes a parameter; spaces become %20, which more
+// UPI apps accept than "+".
+func upiEscape(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
@@ -0,0 +1,125 @@
+package peppol
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+// HTTPDoer is the part of *http.Client the client needs.
+type HTTPDoer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// APIError is an error response from the access point.
+type APIError struct {
+	StatusCode int
+	Code       string `json:"code"`
+	Message    string `json:"message"`
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("peppol: %d %s: %s", e.StatusCode, e.Code, e.Message)
+}
+
+// Client implements Transmitter over an access point's REST bridge:
+//
+//	POST {BaseURL}/transmissions      submits a document, answers with a receipt
+//	GET  {BaseURL}/transmissions/{id} answers with the current receipt
+//
+// Requests carry the API key as a bearer token. Receipts are JSON objects
+// with "id", "status" (one of the Status values) and "reason".
+type Client struct {
+	BaseURL string
+	APIKey  string
+	HTTP    HTTPDoer
+}
+
+var _ Transmitter = (*Client)(nil)
+
+// submission is the JSON body of a send request. The document is sent
+// Base64 encoded, as encoding/json encodes byte slices.
+type submission struct {
+	Sender       string `json:"sender"`
+	Receiver     string `json:"receiver"`
+	DocumentType string `json:"documentTypeId"`
+	Process      string `json:"processId"`
+	Invoice      string `json:"reference,omitempty"`
+	Document     []byte `json:"document"`
+}
+
+// Send implements Transmitter.
+func (c *Client) Send(ctx context.Context, env Envelope) (*Receipt, error) {
+	body := submission{
+		Sender:       env.Sender.String(),
+		Receiver:     env.Receiver.String(),
+		DocumentType: env.DocumentType,
+		Process:      env.Process,
+		Invoice:      env.Invoice,
+		Document:     env.Document,
+	}
+	receipt, err := c.do(ctx, http.MethodPost, "/transmissions", body)
+	if err != nil {
+		return nil, err
+	}
+	if receipt.ID == "" {
+		return nil, fmt.Errorf("%w: access point returned no transmission ID", ErrUnknownTransmission)
+	}
+	return receipt, nil
+}
+
+// Status implements Transmitter.
+func (c *Client) Status(ctx context.Context, id string) (*Receipt, error) {
+	return c.do(ctx, http.MethodGet, "/transmissions/"+url.PathEscape(id), nil)
+}
+
+// do sends an authorised request and decodes the receipt it answers with.
+func (c *Client) do(ctx context.Context, method, path string, in any) (*Receipt, error) {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return nil, err
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.BaseURL+path, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Authorization", "Bearer "+c.APIKey)
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound && method == http.MethodGet {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownTransmission, path)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		apiErr := &APIError{}
+		_ = json.NewDecoder(resp.Body).Decode(apiErr)
+		apiErr.StatusCode = resp.StatusCode
+		return nil, apiErr
+	}
+	var receipt Receipt
+	if err := json.NewDecoder(resp.Body).Decode(&receipt); err != nil {
+		return nil, err
+	}
+	switch receipt.Status {
+	case Pending, Delivered, Failed, Rejected:
+	default:
+		return nil, fmt.Errorf("peppol: unknown status %q for %s", receipt.Status, receipt.ID)
+	}
+	return &receipt, nil
+}
@@ -0,0 +1,276 @@
+// Package peppol sends UBL invoices over the Peppol network and tracks
+// their delivery.
+//
+// Transmitter is the port to an access point: it accepts a document for a
+// receiver and reports how its delivery went. Client implements it over
+// the REST API that AS4 bridges put in front of an access point; tests and
+// other providers substitute their own Transmitter. The Outbox sends
+// invoices through a Transmitter and keeps a Transmission per invoice in a
+// Store, so an invoice's delivery status can be looked up and refreshed.
+//
+// Spec: Peppol BIS Billing 3.0 and the Peppol AS4 profile
+package peppol
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Document types and process of Peppol BIS Billing 3.0
+const (
+	DocumentTypeInvoice    = "urn:oasis:names:specification:ubl:schema:xsd:Invoice-2::Invoice##urn:cen.eu:en16931:2017#compliant#urn:fdc:peppol.eu:2017:poacc:billing:3.0::2.1"
+	DocumentTypeCreditNote = "urn:oasis:names:specification:ubl:schema:xsd:CreditNote-2::CreditNote##urn:cen.eu:en16931:2017#compliant#urn:fdc:peppol.eu:2017:poacc:billing:3.0::2.1"
+	ProcessBilling         = "urn:fdc:peppol.eu:2017:poacc:billing:01:1.0"
+)
+
+// Transmission errors
+var (
+	ErrInvalidParticipant  = errors.New("invalid Peppol participant identifier")
+	ErrEmptyDocument       = errors.New("document is empty")
+	ErrAlreadySent         = errors.New("invoice has already been sent")
+	ErrUnknownTransmission = errors.New("unknown transmission")
+)
+
+// ParticipantID identifies a sender or receiver on the network, as an
+// ISO 6523 scheme code and the identifier within it, such as
+// "0088:5798000000001" for a GLN.
+type ParticipantID struct {
+	Scheme string // Four digits, e.g. "0088" (GLN) or "0192" (Norwegian organisation number)
+	Value  string
+}
+
+// ParseParticipantID parses "scheme:value".
+func ParseParticipantID(s string) (ParticipantID, error) {
+	scheme, value, _ := strings.Cut(s, ":")
+	p := ParticipantID{Scheme: scheme, Value: value}
+	return p, p.Validate()
+}
+
+// Validate checks the scheme code and that there is a value.
+func (p ParticipantID) Validate() error {
+	if len(p.Scheme) != 4 || strings.ContainsFunc(p.Scheme, func(r rune) bool { return r < '0' || r > '9' }) || p.Value == "" {
+		return fmt.Errorf("%w: %q", ErrInvalidParticipant, p.String())
+	}
+	return nil
+}
+
+func (p ParticipantID) String() string {
+	return p.Scheme + ":" + p.Value
+}
+
+// Envelope is a document to send.
+type Envelope struct {
+	Invoice      string // Sales invoice the document is for
+	Sender       ParticipantID
+	Receiver     ParticipantID
+	DocumentType string // DocumentTypeInvoice when empty
+	Process      string // ProcessBilling when empty
+	Document     []byte // UBL XML
+}
+
+// Status is where a transmission stands.
+type Status string
+
+const (
+	Pending   Status = "Pending"   // Accepted by the access point, not yet delivered
+	Delivered Status = "Delivered" // The receiver's access point acknowledged it
+	Failed    Status = "Failed"    // Could not be delivered
+	Rejected  Status = "Rejected"  // Delivered, and rejected by the receiver
+)
+
+// Final reports whether the status will not change any more.
+func (s Status) Final() bool {
+	return s != Pending
+}
+
+// Receipt is an access point's answer about a transmission.
+type Receipt struct {
+	ID     string `json:"id"` // Transmission ID given by the access point
+	Status Status `json:"status"`
+	Reason string `json:"reason,omitempty"` // Why it failed or was rejected
+}
+
+// Transmitter is the port to a Peppol access point.
+type Transmitter interface {
+	// Send hands a document to the access point.
+	Send(ctx context.Context, env Envelope) (*Receipt, error)
+
+	// Status returns the current state of a transmission.
+	Status(ctx context.Context, id string) (*Receipt, error)
+}
+
+// Transmission is the delivery record of one attempt to send an invoice.
+type Transmission struct {
+	ID           string // Access point transmission ID; empty when sending failed
+	Invoice      string
+	Receiver     ParticipantID
+	DocumentType string
+	Status       Status
+	Reason       string
+	SentAt       time.Time
+	UpdatedAt    time.Time
+}
+
+// Store keeps transmissions.
+type Store interface {
+	// Save records a new transmission or updates one by ID.
+	Save(t *Transmission) error
+
+	// ByInvoice returns the transmissions of an invoice, oldest first.
+	ByInvoice(invoice string) ([]Transmission, error)
+
+	// Pending returns the transmissions still awaiting delivery.
+	Pending() ([]Transmission, error)
+}
+
+// Outbox sends invoices and tracks their delivery.
+type Outbox struct {
+	Transmitter Transmitter
+	Store       Store
+	Sender      ParticipantID // The company's participant ID, used when an envelope has none
+}
+
+// Send transmits an invoice and records the transmission. An invoice that
+// is pending or was delivered is not sent again; one that failed or was
+// rejected may be. When the access point cannot be reached the failed
+// transmission is recorded and the error returned.
+func (o *Outbox) Send(ctx context.Context, env Envelope) (*Transmission, error) {
+	if env.Sender == (ParticipantID{}) {
+		env.Sender = o.Sender
+	}
+	if env.DocumentType == "" {
+		env.DocumentType = DocumentTypeInvoice
+	}
+	if env.Process == "" {
+		env.Process = ProcessBilling
+	}
+	if err := env.Sender.Validate(); err != nil {
+		return nil, err
+	}
+	if err := env.Receiver.Validate(); err != nil {
+		return nil, err
+	}
+	if len(env.Document) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrEmptyDocument, env.Invoice)
+	}
+	earlier, err := o.Store.ByInvoice(env.Invoice)
+	if err != nil {
+		return nil, err
+	}
+	if i := slices.IndexFunc(earlier, func(t Transmission) bool { return t.Status == Pending || t.Status == Delivered }); i >= 0 {
+		return nil, fmt.Errorf("%w: %s is %s as %s", ErrAlreadySent, env.Invoice, earlier[i].Status, earlier[i].ID)
+	}
+
+	now := time.Now()
+	t := &Transmission{
+		Invoice:      env.Invoice,
+		Receiver:     env.Receiver,
+		DocumentType: env.DocumentType,
+		SentAt:       now,
+		UpdatedAt:    now,
+	}
+	receipt, sendErr := o.Transmitter.Send(ctx, env)
+	if sendErr != nil {
+		t.Status, t.Reason = Failed, sendErr.Error()
+	} else {
+		t.ID, t.Status, t.Reason = receipt.ID, receipt.Status, receipt.Reason
+	}
+	if err := o.Store.Save(t); err != nil {
+		return nil, err
+	}
+	return t, sendErr
+}
+
+// Refresh asks the access point about every pending transmission and
+// records the changes. It returns the transmissions whose status changed.
+// A transmission the access point cannot report on stays pending and
+// Refresh goes on with the others, returning the first error.
+func (o *Outbox) Refresh(ctx context.Context) ([]Transmission, error) {
+	pending, err := o.Store.Pending()
+	if err != nil {
+		return nil, err
+	}
+	var changed []Transmission
+	var firstErr error
+	for _, t := range pending {
+		receipt, err := o.Transmitter.Status(ctx, t.ID)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("%s: %w", t.Invoice, err)
+			}
+			continue
+		}
+		if receipt.Status == t.Status {
+			continue
+		}
+		t.Status, t.Reason, t.UpdatedAt = receipt.Status, receipt.Reason, time.Now()
+		if err := o.Store.Save(&t); err != nil {
+			return changed, err
+		}
+		changed = append(changed, t)
+	}
+	return changed, firstErr
+}
+
+// Status returns the latest transmission of an invoice, or nil when it
+// was never sent.
+func (o *Outbox) Status(invoice string) (*Transmission, error) {
+	ts, err := o.Store.ByInvoice(invoice)
+	if err != nil || len(ts) == 0 {
+		return nil, err
+	}
+	return &ts[len(ts)-1], nil
+}
+
+// MemoryStore is an in-memory Store.
+type MemoryStore struct {
+	mu            sync.Mutex
+	transmissions []Transmission
+}
+
+// Save implements Store. Transmissions without an ID are always added.
+func (s *MemoryStore) Save(t *Transmission) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if t.ID != "" {
+		for i := range s.transmissions {
+			if s.transmissions[i].ID == t.ID {
+				s.transmissions[i] = *t
+				return nil
+			}
+		}
+	}
+	s.transmissions = append(s.transmissions, *t)
+	return nil
+}
+
+// ByInvoice implements Store.
+func (s *MemoryStore) ByInvoice(invoice string) ([]Transmission, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var result []Transmission
+	for _, t := range s.transmissions {
+		if t.Invoice == invoice {
+			result = append(result, t)
+		}
+	}
+	return result, nil
+}
+
+// Pending implements Store.
+func (s *MemoryStore) Pending() ([]Transmission, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var result []Transmission
+	for _, t := range s.transmissions {
+		if t.Status == Pending {
+			result = append(result, t)
+		}
+	}
+	return result, nil
+}
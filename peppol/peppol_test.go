@@ -0,0 +1,177 @@
+package peppol
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// accessPoint fakes an access point bridge. Documents are pending until
+// deliver is called; documents for receiver "0088:reject" are rejected.
+type accessPoint struct {
+	mu       sync.Mutex
+	receipts map[string]*Receipt
+	received []submission
+}
+
+func newAccessPoint(t *testing.T) (*accessPoint, *httptest.Server) {
+	t.Helper()
+	ap := &accessPoint{receipts: map[string]*Receipt{}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /transmissions", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer key-1" {
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(APIError{Code: "UNAUTHORIZED", Message: "bad API key"})
+			return
+		}
+		var s submission
+		if err := json.NewDecoder(r.Body).Decode(&s); err != nil || s.DocumentType != DocumentTypeInvoice || s.Process != ProcessBilling {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		ap.mu.Lock()
+		defer ap.mu.Unlock()
+		ap.received = append(ap.received, s)
+		receipt := &Receipt{ID: fmt.Sprintf("tx-%d", len(ap.received)), Status: Pending}
+		ap.receipts[receipt.ID] = receipt
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(receipt)
+	})
+	mux.HandleFunc("GET /transmissions/{id}", func(w http.ResponseWriter, r *http.Request) {
+		ap.mu.Lock()
+		defer ap.mu.Unlock()
+		receipt, ok := ap.receipts[r.PathValue("id")]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(receipt)
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return ap, server
+}
+
+// deliver settles the pending transmissions.
+func (ap *accessPoint) deliver() {
+	ap.mu.Lock()
+	defer ap.mu.Unlock()
+	for i, s := range ap.received {
+		receipt := ap.receipts[fmt.Sprintf("tx-%d", i+1)]
+		if receipt.Status != Pending {
+			continue
+		}
+		receipt.Status = Delivered
+		if s.Receiver == "0088:reject" {
+			receipt.Status, receipt.Reason = Rejected, "unknown purchase order"
+		}
+	}
+}
+
+func TestOutbox(t *testing.T) {
+	ap, server := newAccessPoint(t)
+	outbox := &Outbox{
+		Transmitter: &Client{BaseURL: server.URL, APIKey: "key-1", HTTP: server.Client()},
+		Store:       &MemoryStore{},
+		Sender:      ParticipantID{Scheme: "0088", Value: "5798000000001"},
+	}
+	ctx := context.Background()
+	buyer, _ := ParseParticipantID("0192:987654321")
+	doc := []byte(`<Invoice xmlns="urn:oasis:names:specification:ubl:schema:xsd:Invoice-2"/>`)
+
+	tx, err := outbox.Send(ctx, Envelope{Invoice: "SINV-1", Receiver: buyer, Document: doc})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tx.ID != "tx-1" || tx.Status != Pending || tx.Status.Final() {
+		t.Fatalf("transmission = %+v", tx)
+	}
+	if s := ap.received[0]; s.Sender != "0088:5798000000001" || s.Receiver != "0192:987654321" || s.Invoice != "SINV-1" || string(s.Document) != string(doc) {
+		t.Errorf("access point received %+v", s)
+	}
+	if _, err := outbox.Send(ctx, Envelope{Invoice: "SINV-1", Receiver: buyer, Document: doc}); !errors.Is(err, ErrAlreadySent) {
+		t.Errorf("second Send() = %v", err)
+	}
+	if _, err := outbox.Send(ctx, Envelope{Invoice: "SINV-2", Receiver: ParticipantID{Scheme: "0088", Value: "reject"}, Document: doc}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Nothing changes until the access point settles the transmissions
+	if changed, err := outbox.Refresh(ctx); err != nil || len(changed) != 0 {
+		t.Errorf("Refresh() = %v, %v", changed, err)
+	}
+	ap.deliver()
+	changed, err := outbox.Refresh(ctx)
+	if err != nil || len(changed) != 2 {
+		t.Fatalf("Refresh() = %v, %v", changed, err)
+	}
+	if st, _ := outbox.Status("SINV-1"); st.Status != Delivered {
+		t.Errorf("SINV-1 = %+v", st)
+	}
+	st, _ := outbox.Status("SINV-2")
+	if st.Status != Rejected || st.Reason != "unknown purchase order" || !st.Status.Final() {
+		t.Errorf("SINV-2 = %+v", st)
+	}
+	if pending, _ := outbox.Store.Pending(); len(pending) != 0 {
+		t.Errorf("still pending: %v", pending)
+	}
+
+	// A rejected invoice can be sent again once corrected
+	if _, err := outbox.Send(ctx, Envelope{Invoice: "SINV-2", Receiver: buyer, Document: doc}); err != nil {
+		t.Errorf("resending a rejected invoice: %v", err)
+	}
+	if all, _ := outbox.Store.ByInvoice("SINV-2"); len(all) != 2 || all[1].ID != "tx-3" {
+		t.Errorf("SINV-2 transmissions = %+v", all)
+	}
+	if st, err := outbox.Status("SINV-9"); st != nil || err != nil {
+		t.Errorf("Status() of an unsent invoice = %v, %v", st, err)
+	}
+}
+
+func TestOutboxFailures(t *testing.T) {
+	_, server := newAccessPoint(t)
+	outbox := &Outbox{
+		Transmitter: &Client{BaseURL: server.URL, APIKey: "wrong", HTTP: server.Client()},
+		Store:       &MemoryStore{},
+		Sender:      ParticipantID{Scheme: "0088", Value: "5798000000001"},
+	}
+	ctx := context.Background()
+	buyer := ParticipantID{Scheme: "0192", Value: "987654321"}
+
+	// A refused send is recorded as failed and may be retried
+	_, err := outbox.Send(ctx, Envelope{Invoice: "SINV-1", Receiver: buyer, Document: []byte("<Invoice/>")})
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized || apiErr.Code != "UNAUTHORIZED" {
+		t.Fatalf("Send() = %v", err)
+	}
+	if st, _ := outbox.Status("SINV-1"); st == nil || st.Status != Failed || st.ID != "" {
+		t.Errorf("failed transmission = %+v", st)
+	}
+	outbox.Transmitter.(*Client).APIKey = "key-1"
+	if _, err := outbox.Send(ctx, Envelope{Invoice: "SINV-1", Receiver: buyer, Document: []byte("<Invoice/>")}); err != nil {
+		t.Errorf("retry: %v", err)
+	}
+
+	// Transmissions the access point does not know stay pending
+	outbox.Store.Save(&Transmission{ID: "tx-lost", Invoice: "SINV-0", Status: Pending})
+	if _, err := outbox.Refresh(ctx); !errors.Is(err, ErrUnknownTransmission) {
+		t.Errorf("Refresh() = %v", err)
+	}
+
+	for _, env := range []Envelope{
+		{Invoice: "SINV-3", Receiver: ParticipantID{Scheme: "88", Value: "x"}, Document: []byte("x")},
+		{Invoice: "SINV-3", Receiver: buyer, Sender: ParticipantID{Scheme: "0088"}, Document: []byte("x")},
+	} {
+		if _, err := outbox.Send(ctx, env); !errors.Is(err, ErrInvalidParticipant) {
+			t.Errorf("Send(%+v) = %v", env, err)
+		}
+	}
+	if _, err := outbox.Send(ctx, Envelope{Invoice: "SINV-3", Receiver: buyer}); !errors.Is(err, ErrEmptyDocument) {
+		t.Errorf("Send() without document = %v", err)
+	}
+}
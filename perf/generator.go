@@ -0,0 +1,66 @@
+package perf
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/ledger"
+)
+
+// linesPerVoucher is the shape of a generated Sales Invoice:
+// one receivable line, two item income lines that merge, and one tax line.
+const linesPerVoucher = 4
+
+// GenerateGLMap builds a deterministic GL map of roughly n entries made of
+// balanced Sales Invoice postings, the typical shape of a bulk import.
+// Half of the income lines share a merge key with a sibling line.
+func GenerateGLMap(n int) []ledger.GLEntry {
+	vouchers := (n + linesPerVoucher - 1) / linesPerVoucher
+	glMap := make([]ledger.GLEntry, 0, vouchers*linesPerVoucher)
+	postingDate := time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)
+
+	for v := 0; v < vouchers; v++ {
+		voucherNo := fmt.Sprintf("SINV-2024-%06d", v+1)
+		customer := fmt.Sprintf("Customer %03d", v%250)
+		net := float64(1000 + (v%97)*10)
+		tax := ledger.Flt(net*0.18, 2)
+
+		base := ledger.GLEntry{
+			PostingDate:     postingDate.AddDate(0, 0, v%365),
+			AccountCurrency: "INR",
+			VoucherType:     "Sales Invoice",
+			VoucherNo:       voucherNo,
+			Company:         "ACME Industries Pvt Ltd",
+			CostCenter:      "Main - ACME",
+			FiscalYear:      "2024-2025",
+		}
+
+		receivable := base
+		receivable.Account = "Debtors - ACME"
+		receivable.PartyType = "Customer"
+		receivable.Party = customer
+		receivable.Against = "Sales - ACME, Output Tax GST - ACME"
+		receivable.Debit = net + tax
+		receivable.DebitInAccountCurrency = net + tax
+
+		item1 := base
+		item1.Account = "Sales - ACME"
+		item1.Against = customer
+		item1.Credit = net * 0.6
+		item1.CreditInAccountCurrency = net * 0.6
+
+		item2 := item1
+		item2.Credit = net - item1.Credit
+		item2.CreditInAccountCurrency = item2.Credit
+
+		taxLine := base
+		taxLine.Account = "Output Tax GST - ACME"
+		taxLine.Against = customer
+		taxLine.Credit = tax
+		taxLine.CreditInAccountCurrency = tax
+
+		glMap = append(glMap, receivable, item1, item2, taxLine)
+	}
+
+	return glMap
+}
@@ -0,0 +1,341 @@
+package perf
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"math/bits"
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/ledger"
+)
+
+// ErrInvalidLoad is returned for a load configuration that cannot run.
+var ErrInvalidLoad = errors.New("invalid load configuration")
+
+// Voucher kinds a load posts
+const (
+	KindInvoice = "Sales Invoice"
+	KindPayment = "Payment Entry"
+	KindJournal = "Journal Entry"
+)
+
+// Mix is the relative weight of each voucher kind in a load.
+type Mix struct {
+	Invoices int
+	Payments int // Against an earlier invoice of the load
+	Journals int
+}
+
+// DefaultMix is a typical trading company's day: most vouchers are
+// invoices, a third as many payments, and the odd journal.
+var DefaultMix = Mix{Invoices: 6, Payments: 3, Journals: 1}
+
+// LoadConfig shapes a load.
+type LoadConfig struct {
+	Company  string
+	Mix      Mix
+	Rate     float64       // Vouchers per second; as fast as the workers go when zero
+	Duration time.Duration // Until ctx is done when zero, for soak runs
+	Workers  int           // Concurrent posters; 1 when zero
+	Seed     uint64        // The same seed posts the same vouchers in the same order
+	Abbr     string        // Company abbreviation the accounts end in; "LOAD" when empty
+
+	// Progress, when set, receives the report so far every ProgressEvery,
+	// so a soak run shows whether latency or errors drift over hours.
+	Progress      func(LoadReport)
+	ProgressEvery time.Duration
+}
+
+// Validate checks the configuration.
+func (c *LoadConfig) Validate() error {
+	switch {
+	case c.Company == "":
+		return fmt.Errorf("%w: company is required", ErrInvalidLoad)
+	case c.Mix.Invoices < 0 || c.Mix.Payments < 0 || c.Mix.Journals < 0 || c.Mix.Invoices+c.Mix.Payments+c.Mix.Journals == 0:
+		return fmt.Errorf("%w: mix %+v", ErrInvalidLoad, c.Mix)
+	case c.Rate < 0 || c.Duration < 0 || c.Workers < 0:
+		return fmt.Errorf("%w: negative rate, duration or workers", ErrInvalidLoad)
+	case c.Progress != nil && c.ProgressEvery <= 0:
+		return fmt.Errorf("%w: progress interval is required", ErrInvalidLoad)
+	}
+	return nil
+}
+
+// LoadReport summarizes a load. Latencies are of MakeGLEntries calls,
+// errors included.
+type LoadReport struct {
+	Vouchers   int
+	Entries    int
+	Errors     int
+	ByKind     map[string]int
+	FirstError error
+
+	Elapsed    time.Duration
+	Throughput float64 // Vouchers per second
+	ErrorRate  float64 // Percent of vouchers
+	P50        time.Duration
+	P99        time.Duration
+	Max        time.Duration
+}
+
+func (r LoadReport) String() string {
+	return fmt.Sprintf("%d vouchers (%d entries) in %s: %.1f vouchers/s, p50 %s, p99 %s, max %s, %d errors (%.2f%%)",
+		r.Vouchers, r.Entries, r.Elapsed.Round(time.Millisecond), r.Throughput, r.P50, r.P99, r.Max, r.Errors, r.ErrorRate)
+}
+
+// load is the state of a running load.
+type load struct {
+	cfg     LoadConfig
+	engine  *ledger.Engine
+	started time.Time
+
+	next atomic.Int64 // Sequence of the next voucher
+
+	mu        sync.Mutex
+	invoices  []string // Posted, for payments to settle
+	latencies histogram
+	report    LoadReport
+}
+
+// RunLoad posts a synthetic mix of vouchers through engine at the
+// configured rate until the duration elapses or ctx is done, and reports
+// throughput, latency percentiles and errors. The engine's stores are
+// what is measured: pass the same engine the posting service runs with,
+// against a copy of production, to size hardware before a migration.
+//
+// Vouchers are balanced and carry the fields the engine validates, so
+// errors come from the stores and the engine's own checks, not from the
+// generator. They post to Debtors, Sales, Output Tax, Bank and Office
+// Expenses, and cost center Main, of the company's abbreviation. Voucher
+// numbers carry the seed, so loads with different seeds can share a
+// store that already holds data.
+func RunLoad(ctx context.Context, engine *ledger.Engine, cfg LoadConfig) (LoadReport, error) {
+	if err := cfg.Validate(); err != nil {
+		return LoadReport{}, err
+	}
+	if cfg.Duration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.Duration)
+		defer cancel()
+	}
+	l := &load{cfg: cfg, engine: engine, started: time.Now(), report: LoadReport{ByKind: map[string]int{}}}
+
+	// Tokens are issued against the clock rather than one per tick, so
+	// rates above the ticker's resolution hold too
+	tokens := make(chan struct{})
+	go func() {
+		defer close(tokens)
+		var tick <-chan time.Time
+		if cfg.Rate > 0 {
+			ticker := time.NewTicker(max(time.Duration(float64(time.Second)/cfg.Rate), time.Millisecond))
+			defer ticker.Stop()
+			tick = ticker.C
+		}
+		issued := 0
+		for {
+			due := issued + 1
+			if tick != nil {
+				select {
+				case <-ctx.Done():
+					return
+				case <-tick:
+				}
+				due = int(time.Since(l.started).Seconds() * cfg.Rate)
+			}
+			for ; issued < due; issued++ {
+				select {
+				case <-ctx.Done():
+					return
+				case tokens <- struct{}{}:
+				}
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for range max(cfg.Workers, 1) {
+		wg.Go(func() {
+			for range tokens {
+				l.post(l.next.Add(1))
+			}
+		})
+	}
+	done := make(chan struct{})
+	var reporter sync.WaitGroup
+	if cfg.Progress != nil {
+		reporter.Go(func() {
+			ticker := time.NewTicker(cfg.ProgressEvery)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-done:
+					return
+				case <-ticker.C:
+					cfg.Progress(l.snapshot())
+				}
+			}
+		})
+	}
+	wg.Wait()
+	close(done)
+	reporter.Wait() // No progress after the final report
+	return l.snapshot(), nil
+}
+
+// post generates and posts voucher seq.
+func (l *load) post(seq int64) {
+	kind, glMap := l.voucher(seq)
+	start := time.Now()
+	err := l.engine.MakeGLEntries(glMap, ledger.DefaultPostingOptions())
+	latency := time.Since(start)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err == nil && kind == KindInvoice {
+		l.invoices = append(l.invoices, glMap[0].VoucherNo)
+	}
+	l.latencies.add(latency)
+	l.report.Vouchers++
+	l.report.Entries += len(glMap)
+	l.report.ByKind[kind]++
+	if err != nil {
+		l.report.Errors++
+		if l.report.FirstError == nil {
+			l.report.FirstError = fmt.Errorf("%s: %w", glMap[0].VoucherNo, err)
+		}
+	}
+}
+
+// snapshot returns the report so far.
+func (l *load) snapshot() LoadReport {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	r := l.report
+	r.ByKind = make(map[string]int, len(l.report.ByKind))
+	for k, v := range l.report.ByKind {
+		r.ByKind[k] = v
+	}
+	r.Elapsed = time.Since(l.started)
+	if r.Elapsed > 0 {
+		r.Throughput = float64(r.Vouchers) / r.Elapsed.Seconds()
+	}
+	if r.Vouchers > 0 {
+		r.ErrorRate = float64(r.Errors) / float64(r.Vouchers) * 100
+	}
+	r.P50, r.P99, r.Max = l.latencies.percentile(50), l.latencies.percentile(99), l.latencies.max
+	return r
+}
+
+// subBits sets the precision of a histogram: each power of two is split
+// into 1<<subBits buckets, so a percentile is within 1/16 of the latency.
+const subBits = 4
+
+// histogram counts latencies in buckets of logarithmic width, so a soak
+// run of any length keeps fixed memory and a report does not sort.
+type histogram struct {
+	counts [64 << subBits]int64
+	n      int64
+	max    time.Duration
+}
+
+func (h *histogram) add(d time.Duration) {
+	d = max(d, 0)
+	h.counts[bucketOf(uint64(d))]++
+	h.n++
+	h.max = max(h.max, d)
+}
+
+// percentile returns the upper bound of the bucket holding the p-th
+// percentile, capped at the largest latency seen.
+func (h *histogram) percentile(p int) time.Duration {
+	if h.n == 0 {
+		return 0
+	}
+	rank := (h.n - 1) * int64(p) / 100
+	for i, c := range h.counts {
+		if rank -= c; rank < 0 {
+			return min(time.Duration(bucketLimit(i)), h.max)
+		}
+	}
+	return h.max
+}
+
+// bucketOf returns the bucket of v: values below 2<<subBits have one
+// each, larger ones share a bucket with those of the same leading bits.
+func bucketOf(v uint64) int {
+	shift := max(bits.Len64(v)-subBits-1, 0)
+	return shift<<subBits + int(v>>shift)
+}
+
+// bucketLimit returns the largest value in bucket i.
+func bucketLimit(i int) uint64 {
+	if i < 2<<subBits {
+		return uint64(i)
+	}
+	shift := i>>subBits - 1
+	lead := uint64(i&(1<<subBits-1) | 1<<subBits)
+	return (lead+1)<<shift - 1
+}
+
+// voucher generates voucher seq of the load: its kind by the mix, and its
+// GL map. A payment before any invoice is posted is an advance.
+func (l *load) voucher(seq int64) (string, []ledger.GLEntry) {
+	rnd := rand.New(rand.NewPCG(l.cfg.Seed, uint64(seq)))
+	mix := l.cfg.Mix
+	postingDate := time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC).AddDate(0, 0, int(seq%365))
+	customer := fmt.Sprintf("Customer %03d", rnd.IntN(250))
+	net := float64(100+rnd.IntN(9900)) + float64(rnd.IntN(100))/100
+	tax := ledger.Flt(net*0.18, 2)
+
+	base := ledger.GLEntry{
+		PostingDate:     postingDate,
+		AccountCurrency: "INR",
+		Company:         l.cfg.Company,
+		FiscalYear:      "2024-2025",
+	}
+	abbr := cmp.Or(l.cfg.Abbr, "LOAD")
+	entry := func(account string, debit, credit float64) ledger.GLEntry {
+		e := base
+		e.Account, e.Debit, e.Credit = account+" - "+abbr, debit, credit
+		e.DebitInAccountCurrency, e.CreditInAccountCurrency = debit, credit
+		return e
+	}
+	withVoucher := func(kind, prefix string, glMap ...ledger.GLEntry) (string, []ledger.GLEntry) {
+		voucherNo := fmt.Sprintf("LOAD-%s-%d-%08d", prefix, l.cfg.Seed, seq)
+		for i := range glMap {
+			glMap[i].VoucherType, glMap[i].VoucherNo = kind, voucherNo
+		}
+		return kind, glMap
+	}
+
+	switch pick := rnd.IntN(mix.Invoices + mix.Payments + mix.Journals); {
+	case pick < mix.Invoices:
+		receivable := entry("Debtors", net+tax, 0)
+		receivable.PartyType, receivable.Party = "Customer", customer
+		income := entry("Sales", 0, net)
+		income.CostCenter = "Main - " + abbr
+		return withVoucher(KindInvoice, "SINV", receivable, income, entry("Output Tax", 0, tax))
+
+	case pick < mix.Invoices+mix.Payments:
+		receivable := entry("Debtors", 0, net+tax)
+		receivable.PartyType, receivable.Party = "Customer", customer
+		l.mu.Lock()
+		if n := len(l.invoices); n > 0 {
+			// Settle an earlier invoice; which one matters less than that
+			// the payment ledger sees an allocation
+			receivable.AgainstVoucherType, receivable.AgainstVoucher = KindInvoice, l.invoices[rnd.IntN(n)]
+		}
+		l.mu.Unlock()
+		return withVoucher(KindPayment, "PE", entry("Bank", net+tax, 0), receivable)
+
+	default:
+		expense := entry("Office Expenses", net, 0)
+		expense.CostCenter = "Main - " + abbr
+		return withVoucher(KindJournal, "JV", expense, entry("Bank", 0, net))
+	}
+}
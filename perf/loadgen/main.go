@@ -0,0 +1,84 @@
+// Command loadgen posts a synthetic mix of invoices, payments and
+// journals through a ledger engine and reports throughput, p50 and p99
+// latency and the error rate, to size hardware for the posting service.
+//
+//	go run ./perf/loadgen -rate 500 -workers 8 -duration 1m
+//	go run ./perf/loadgen -soak -every 1m    # until interrupted
+//
+// It posts to in-memory stores, which measure the engine alone; a
+// deployment links its own stores into RunLoad the same way to measure
+// them. See perf.RunLoad.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/ledger"
+	"github.com/senguttuvang/erpnext-go/ledger/memstore"
+	"github.com/senguttuvang/erpnext-go/perf"
+)
+
+func main() {
+	cfg := perf.LoadConfig{Company: "Load Test Company", Mix: perf.DefaultMix}
+	flag.Float64Var(&cfg.Rate, "rate", 0, "vouchers per second; as fast as possible when 0")
+	flag.DurationVar(&cfg.Duration, "duration", 30*time.Second, "how long to post")
+	flag.IntVar(&cfg.Workers, "workers", 4, "concurrent posters")
+	flag.Uint64Var(&cfg.Seed, "seed", 1, "seed of the voucher generator")
+	mix := flag.String("mix", "6:3:1", "relative weights of invoices:payments:journals")
+	soak := flag.Bool("soak", false, "post until interrupted, reporting every -every")
+	every := flag.Duration("every", time.Minute, "progress interval of a soak run")
+	flag.Parse()
+
+	var err error
+	if cfg.Mix, err = parseMix(*mix); err != nil {
+		log.Fatal(err)
+	}
+	if *soak {
+		cfg.Duration = 0
+	}
+	if *soak || *every != time.Minute {
+		cfg.ProgressEvery = *every
+		cfg.Progress = func(r perf.LoadReport) { log.Print(r) }
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	engine := &ledger.Engine{GLStore: memstore.NewGLStore(), PaymentStore: memstore.NewPaymentStore()}
+	report, err := perf.RunLoad(ctx, engine, cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(report)
+	for _, kind := range []string{perf.KindInvoice, perf.KindPayment, perf.KindJournal} {
+		fmt.Printf("  %-14s %d\n", kind, report.ByKind[kind])
+	}
+	if report.FirstError != nil {
+		fmt.Println("first error:", report.FirstError)
+		os.Exit(1)
+	}
+}
+
+// parseMix parses invoices:payments:journals weights.
+func parseMix(s string) (perf.Mix, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) != 3 {
+		return perf.Mix{}, fmt.Errorf("%w: mix %q is not invoices:payments:journals", perf.ErrInvalidLoad, s)
+	}
+	var w [3]int
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			return perf.Mix{}, fmt.Errorf("%w: mix %q", perf.ErrInvalidLoad, s)
+		}
+		w[i] = n
+	}
+	return perf.Mix{Invoices: w[0], Payments: w[1], Journals: w[2]}, nil
+}
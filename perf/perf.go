@@ -0,0 +1,152 @@
+// Package perf measures the GL posting hot path against a performance budget.
+//
+// Bulk imports and high-volume posting services push tens of thousands of
+// entries through MergeSimilarEntries and MakeGLEntries at once. This package
+// generates realistic synthetic GL maps, times them and counts their
+// allocations, and checks the results against per-entry budgets so
+// regressions fail CI instead of surfacing in production.
+//
+// RunLoad, and the loadgen command built on it, drive a whole engine and
+// its stores with a mix of vouchers at a set rate instead, for sizing a
+// deployment and for soak runs.
+package perf
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/ledger"
+)
+
+// ErrBudgetExceeded is returned when a measurement exceeds its budget.
+var ErrBudgetExceeded = errors.New("performance budget exceeded")
+
+// Budget caps the cost of an operation, normalized per GL entry so that the
+// same budget applies to 10k and 100k entry maps.
+type Budget struct {
+	Name              string
+	MaxNsPerEntry     float64 // Wall-clock nanoseconds per input entry
+	MaxAllocsPerEntry float64 // Heap allocations per input entry
+}
+
+// DefaultBudgets are the budgets enforced for the posting hot path.
+// They are deliberately generous so they hold on shared CI runners; a
+// breach means a complexity or allocation regression, not noise.
+var DefaultBudgets = map[string]Budget{
+	"MergeSimilarEntries": {Name: "MergeSimilarEntries", MaxNsPerEntry: 2000, MaxAllocsPerEntry: 0.01},
+	"MakeGLEntries":       {Name: "MakeGLEntries", MaxNsPerEntry: 5000, MaxAllocsPerEntry: 0.05},
+	"ProcessGLMapInPlace": {Name: "ProcessGLMapInPlace", MaxNsPerEntry: 2000, MaxAllocsPerEntry: 0.001},
+}
+
+// Result is a single measurement.
+type Result struct {
+	Name           string
+	Entries        int
+	NsPerOp        int64
+	AllocsPerOp    int64
+	BytesPerOp     int64
+	NsPerEntry     float64
+	AllocsPerEntry float64
+}
+
+func (r Result) String() string {
+	return fmt.Sprintf(
+		"%s/%d: %s/op, %.1f ns/entry, %.3f allocs/entry, %d B/op",
+		r.Name, r.Entries, time.Duration(r.NsPerOp), r.NsPerEntry, r.AllocsPerEntry, r.BytesPerOp,
+	)
+}
+
+// Check compares the result against a budget.
+func (r Result) Check(b Budget) error {
+	if b.MaxNsPerEntry > 0 && r.NsPerEntry > b.MaxNsPerEntry {
+		return fmt.Errorf("%w: %s takes %.1f ns/entry (budget %.1f)", ErrBudgetExceeded, r.Name, r.NsPerEntry, b.MaxNsPerEntry)
+	}
+	if b.MaxAllocsPerEntry > 0 && r.AllocsPerEntry > b.MaxAllocsPerEntry {
+		return fmt.Errorf("%w: %s makes %.3f allocs/entry (budget %.3f)", ErrBudgetExceeded, r.Name, r.AllocsPerEntry, b.MaxAllocsPerEntry)
+	}
+	return nil
+}
+
+// measureTime is how long Measure runs fn for, as long as a benchmark runs
+// by default.
+const measureTime = time.Second
+
+// Measure times fn, which must process a GL map of n entries per call. It
+// calls fn repeatedly for about measureTime and reads the allocations from
+// the runtime's memory statistics, as the benchmark harness does, without
+// linking the testing package into the binaries that use it.
+func Measure(name string, n int, fn func()) Result {
+	fn() // Warm up caches and lazily built state
+	runtime.GC()
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	start := time.Now()
+	var runs int64
+	for runs == 0 || time.Since(start) < measureTime {
+		fn()
+		runs++
+	}
+	elapsed := time.Since(start)
+	runtime.ReadMemStats(&after)
+
+	r := Result{
+		Name:        name,
+		Entries:     n,
+		NsPerOp:     elapsed.Nanoseconds() / runs,
+		AllocsPerOp: int64(after.Mallocs-before.Mallocs) / runs,
+		BytesPerOp:  int64(after.TotalAlloc-before.TotalAlloc) / runs,
+	}
+	if n > 0 {
+		r.NsPerEntry = float64(r.NsPerOp) / float64(n)
+		r.AllocsPerEntry = float64(r.AllocsPerOp) / float64(n)
+	}
+	return r
+}
+
+// MeasureMergeSimilarEntries measures merging a synthetic map of n entries.
+func MeasureMergeSimilarEntries(n int) Result {
+	glMap := GenerateGLMap(n)
+	return Measure("MergeSimilarEntries", n, func() {
+		ledger.MergeSimilarEntries(glMap)
+	})
+}
+
+// MeasureProcessGLMapInPlace measures in-place processing of n entries.
+// The input is restored from a pristine copy before every run, so the
+// measurement includes one copy of the map but no allocation.
+func MeasureProcessGLMapInPlace(n int) Result {
+	source := GenerateGLMap(n)
+	glMap := make([]ledger.GLEntry, len(source))
+	return Measure("ProcessGLMapInPlace", n, func() {
+		copy(glMap, source)
+		ledger.ProcessGLMapInPlace(glMap, true)
+	})
+}
+
+// MeasureMakeGLEntries measures posting a synthetic map of n entries through
+// an engine backed by a discarding store.
+func MeasureMakeGLEntries(n int) Result {
+	glMap := GenerateGLMap(n)
+	engine := &ledger.Engine{GLStore: discardStore{}}
+	opts := ledger.DefaultPostingOptions()
+	return Measure("MakeGLEntries", n, func() {
+		if err := engine.MakeGLEntries(glMap, opts); err != nil {
+			panic(err)
+		}
+	})
+}
+
+// discardStore is a GLEntryStore that drops everything it is given, so that
+// measurements reflect engine cost only.
+type discardStore struct{}
+
+func (discardStore) Save(entry *ledger.GLEntry) error         { return nil }
+func (discardStore) SaveBatch(entries []ledger.GLEntry) error { return nil }
+func (discardStore) MarkCancelled(voucherType, voucherNo string) error {
+	return nil
+}
+func (discardStore) GetByVoucher(voucherType, voucherNo string) ([]ledger.GLEntry, error) {
+	return nil, nil
+}
@@ -0,0 +1,111 @@
+package perf
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/ledger"
+	"github.com/senguttuvang/erpnext-go/ledger/memstore"
+)
+
+func TestGenerateGLMap(t *testing.T) {
+	glMap := GenerateGLMap(10000)
+
+	if len(glMap) != 10000 {
+		t.Errorf("Expected 10000 entries, got %d", len(glMap))
+	}
+	if !ledger.GLMap(glMap).IsBalanced() {
+		t.Errorf("Generated GL map is not balanced")
+	}
+
+	merged := ledger.MergeSimilarEntries(glMap)
+	if len(merged) != 7500 {
+		t.Errorf("Expected 7500 entries after merge, got %d", len(merged))
+	}
+}
+
+func TestResultCheck(t *testing.T) {
+	r := Result{Name: "MergeSimilarEntries", NsPerEntry: 150, AllocsPerEntry: 0.01}
+
+	if err := r.Check(Budget{MaxNsPerEntry: 200, MaxAllocsPerEntry: 0.1}); err != nil {
+		t.Errorf("Check() unexpected error = %v", err)
+	}
+	if err := r.Check(Budget{MaxNsPerEntry: 100}); !errors.Is(err, ErrBudgetExceeded) {
+		t.Errorf("Expected ErrBudgetExceeded for time, got %v", err)
+	}
+	if err := r.Check(Budget{MaxAllocsPerEntry: 0.001}); !errors.Is(err, ErrBudgetExceeded) {
+		t.Errorf("Expected ErrBudgetExceeded for allocations, got %v", err)
+	}
+}
+
+// TestPerformanceBudget enforces DefaultBudgets on a 10k entry map.
+func TestPerformanceBudget(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping performance budget in short mode")
+	}
+
+	results := []Result{
+		MeasureMergeSimilarEntries(10000),
+		MeasureMakeGLEntries(10000),
+		MeasureProcessGLMapInPlace(10000),
+	}
+
+	for _, r := range results {
+		t.Log(r)
+		if err := r.Check(DefaultBudgets[r.Name]); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestRunLoad(t *testing.T) {
+	engine := &ledger.Engine{GLStore: memstore.NewGLStore(), PaymentStore: memstore.NewPaymentStore()}
+	var progress int
+	report, err := RunLoad(t.Context(), engine, LoadConfig{
+		Company: "ACME", Mix: DefaultMix, Rate: 1000, Duration: 200 * time.Millisecond, Workers: 2,
+		Progress: func(LoadReport) { progress++ }, ProgressEvery: 50 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Log(report)
+	if report.Vouchers == 0 || report.Errors != 0 || report.FirstError != nil {
+		t.Fatalf("report = %+v", report)
+	}
+	if n := report.ByKind[KindInvoice] + report.ByKind[KindPayment] + report.ByKind[KindJournal]; n != report.Vouchers {
+		t.Errorf("by kind %v, %d vouchers", report.ByKind, report.Vouchers)
+	}
+	if report.P50 > report.P99 || report.P99 > report.Max || report.Max == 0 {
+		t.Errorf("latencies p50 %s, p99 %s, max %s", report.P50, report.P99, report.Max)
+	}
+	if progress == 0 {
+		t.Error("no progress reported")
+	}
+
+	if _, err := RunLoad(t.Context(), engine, LoadConfig{Company: "ACME"}); !errors.Is(err, ErrInvalidLoad) {
+		t.Errorf("empty mix: %v", err)
+	}
+}
+
+func TestHistogram(t *testing.T) {
+	for _, v := range []uint64{0, 31, 32, 33, 1000, 1 << 40, 1<<63 - 1} {
+		i := bucketOf(v)
+		if v > bucketLimit(i) || i > 0 && v <= bucketLimit(i-1) {
+			t.Errorf("%d in bucket %d, up to %d", v, i, bucketLimit(i))
+		}
+	}
+
+	var h histogram
+	for ms := 1; ms <= 1000; ms++ {
+		h.add(time.Duration(ms) * time.Millisecond)
+	}
+	for p, want := range map[int]time.Duration{50: 500 * time.Millisecond, 99: 990 * time.Millisecond, 100: time.Second} {
+		if got := h.percentile(p); got < want || got > want+want/16 {
+			t.Errorf("p%d = %s, want about %s", p, got, want)
+		}
+	}
+	if h.max != time.Second {
+		t.Errorf("max = %s", h.max)
+	}
+}
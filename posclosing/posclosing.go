@@ -0,0 +1,284 @@
+// Package posclosing implements the POS Closing Entry: at the end of a
+// shift the cashier counts the till by note and coin, the count is
+// compared with what the shift's invoices say the till should hold, and
+// any difference is posted to a cash over/short account.
+//
+// Migrated from: erpnext/accounts/doctype/pos_closing_entry/pos_closing_entry.py
+//
+// ERPNext records the difference on the closing entry but leaves it to a
+// manual Journal Entry; here submitting the closing entry posts it.
+package posclosing
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/ledger"
+	"github.com/senguttuvang/erpnext-go/modeofpayment"
+	"github.com/senguttuvang/erpnext-go/salesinvoice"
+)
+
+// VoucherType is the ERPNext doctype name used on GL entries.
+const VoucherType = "POS Closing Entry"
+
+// Closing errors
+var (
+	ErrInvalidDenomination     = errors.New("denomination value and count must not be negative")
+	ErrMissingOverShortAccount = errors.New("cash over/short account is mandatory to post a variance")
+	ErrInvoiceOutOfScope       = errors.New("invoice does not belong to this closing entry")
+)
+
+// Status is the document state of a closing entry.
+type Status string
+
+const (
+	Draft     Status = "Draft"
+	Submitted Status = "Submitted"
+	Cancelled Status = "Cancelled"
+)
+
+// Denomination is one line of a cash count: so many notes or coins of a
+// value.
+type Denomination struct {
+	Value float64
+	Count int
+}
+
+// Total returns the amount counted over a set of denominations.
+func Total(denominations []Denomination) float64 {
+	total := 0.0
+	for _, d := range denominations {
+		total += d.Value * float64(d.Count)
+	}
+	return ledger.Flt(total, 2)
+}
+
+// PaymentReconciliation compares what a mode of payment should hold at the
+// end of the shift with what was counted.
+//
+// Maps to: POS Closing Entry Detail child table
+type PaymentReconciliation struct {
+	ModeOfPayment  string
+	Type           modeofpayment.PaymentType
+	Account        string  // Cash or bank account the payments went to
+	OpeningAmount  float64 // Float handed over at the start of the shift
+	ExpectedAmount float64 // Calculated: opening plus payments less change
+	ClosingAmount  float64 // Counted; Total(Denominations) when they are given
+	Difference     float64 // Calculated: ClosingAmount - ExpectedAmount
+
+	Denominations []Denomination // Optional cash count by note and coin
+}
+
+// ClosingEntry closes a POS shift.
+//
+// Maps to: erpnext/accounts/doctype/pos_closing_entry/pos_closing_entry.json
+type ClosingEntry struct {
+	Name        string
+	Company     string
+	POSProfile  string
+	User        string
+	PostingDate time.Time
+	PeriodStart time.Time
+	PeriodEnd   time.Time
+
+	CashOverShortAccount string // Receives the variance
+	CostCenter           string // Optional cost center for the variance
+
+	Invoices []string // POS invoices of the shift, set by Collect
+	Payments []*PaymentReconciliation
+
+	GrandTotal float64 // Of the shift's invoices, company currency
+	Status     Status
+}
+
+// Collect adds the payments of the shift's submitted POS invoices to the
+// expected amounts, starting from the opening amounts. Change handed back
+// is taken out of the mode that paid it, the one whose account is the
+// invoice's change account. Rows are added for modes without an opening
+// amount.
+//
+// Maps to: get_pos_invoices() and the payment_reconciliation rows built by
+// make_closing_entry_from_opening() in pos_closing_entry.py
+func (c *ClosingEntry) Collect(invoices []*salesinvoice.SalesInvoice) error {
+	c.Invoices, c.GrandTotal = nil, 0
+	for _, p := range c.Payments {
+		p.ExpectedAmount = p.OpeningAmount
+	}
+	for _, inv := range invoices {
+		if !inv.IsPOS || inv.Status != salesinvoice.Submitted {
+			continue
+		}
+		if inv.Company != c.Company || inv.PostingDate.Before(c.PeriodStart) || (!c.PeriodEnd.IsZero() && inv.PostingDate.After(c.PeriodEnd)) {
+			return fmt.Errorf("%w: %s", ErrInvoiceOutOfScope, inv.Name)
+		}
+		c.Invoices = append(c.Invoices, inv.Name)
+		if inv.BaseRoundedTotal != 0 {
+			c.GrandTotal += inv.BaseRoundedTotal
+		} else {
+			c.GrandTotal += inv.BaseGrandTotal
+		}
+		for _, p := range inv.Payments {
+			c.row(p.ModeOfPayment, p.Type, p.Account).ExpectedAmount += p.BaseAmount
+		}
+		if inv.BaseChangeAmount != 0 {
+			c.changeRow(inv).ExpectedAmount -= inv.BaseChangeAmount
+		}
+	}
+	c.GrandTotal = ledger.Flt(c.GrandTotal, 2)
+	for _, p := range c.Payments {
+		p.ExpectedAmount = ledger.Flt(p.ExpectedAmount, 2)
+	}
+	return nil
+}
+
+// row returns the reconciliation row of a mode of payment, adding it if
+// missing.
+func (c *ClosingEntry) row(mode string, typ modeofpayment.PaymentType, account string) *PaymentReconciliation {
+	for _, p := range c.Payments {
+		if p.ModeOfPayment == mode {
+			if p.Account == "" {
+				p.Account = account
+			}
+			if p.Type == "" {
+				p.Type = typ
+			}
+			return p
+		}
+	}
+	p := &PaymentReconciliation{ModeOfPayment: mode, Type: typ, Account: account}
+	c.Payments = append(c.Payments, p)
+	return p
+}
+
+// changeRow returns the row change was paid from: the mode whose account
+// is the change account, or else the first cash mode of the invoice.
+func (c *ClosingEntry) changeRow(inv *salesinvoice.SalesInvoice) *PaymentReconciliation {
+	i := slices.IndexFunc(inv.Payments, func(p *salesinvoice.Payment) bool {
+		return p.Account == inv.AccountForChangeAmount
+	})
+	if i < 0 {
+		i = slices.IndexFunc(inv.Payments, func(p *salesinvoice.Payment) bool { return p.Type == modeofpayment.Cash })
+	}
+	if i < 0 {
+		return c.row(string(modeofpayment.Cash), modeofpayment.Cash, inv.AccountForChangeAmount)
+	}
+	p := inv.Payments[i]
+	return c.row(p.ModeOfPayment, p.Type, p.Account)
+}
+
+// Reconcile takes the closing amount of each row from its cash count,
+// when one was entered, and works out the difference from the expected
+// amount.
+//
+// Python equivalent:
+//
+//	def validate_payment_reconciliation(self):
+//	    for d in self.payment_reconciliation:
+//	        d.difference = flt(d.closing_amount) - flt(d.expected_amount)
+func (c *ClosingEntry) Reconcile() error {
+	for _, p := range c.Payments {
+		for _, d := range p.Denominations {
+			if d.Value < 0 || d.Count < 0 {
+				return fmt.Errorf("%w: %s %.2f × %d", ErrInvalidDenomination, p.ModeOfPayment, d.Value, d.Count)
+			}
+		}
+		if len(p.Denominations) > 0 {
+			p.ClosingAmount = Total(p.Denominations)
+		}
+		p.Difference = ledger.Flt(p.ClosingAmount-p.ExpectedAmount, 2)
+	}
+	return nil
+}
+
+// Variance returns the total difference between counted and expected
+// amounts: positive when the tills hold more than expected.
+func (c *ClosingEntry) Variance() float64 {
+	total := 0.0
+	for _, p := range c.Payments {
+		total += p.Difference
+	}
+	return ledger.Flt(total, 2)
+}
+
+// GetGLEntries builds the GL map of the variance: a surplus is debited to
+// the mode's account and credited to the over/short account, a shortfall
+// the other way round.
+func (c *ClosingEntry) GetGLEntries() ([]ledger.GLEntry, error) {
+	var glMap []ledger.GLEntry
+	for _, p := range c.Payments {
+		if p.Difference == 0 {
+			continue
+		}
+		if c.CashOverShortAccount == "" {
+			return nil, ErrMissingOverShortAccount
+		}
+		if p.Account == "" {
+			return nil, fmt.Errorf("%w: %s", salesinvoice.ErrMissingPaymentAccount, p.ModeOfPayment)
+		}
+		till := c.newGLEntry(p.Account, c.CashOverShortAccount)
+		overShort := c.newGLEntry(c.CashOverShortAccount, p.Account)
+		overShort.CostCenter = c.CostCenter
+		if p.Difference > 0 {
+			till.Debit, overShort.Credit = p.Difference, p.Difference
+		} else {
+			till.Credit, overShort.Debit = -p.Difference, -p.Difference
+		}
+		till.DebitInAccountCurrency, till.CreditInAccountCurrency = till.Debit, till.Credit
+		overShort.DebitInAccountCurrency, overShort.CreditInAccountCurrency = overShort.Debit, overShort.Credit
+		glMap = append(glMap, till, overShort)
+	}
+	return glMap, nil
+}
+
+// newGLEntry returns an entry pre-filled with voucher-level fields.
+func (c *ClosingEntry) newGLEntry(account, against string) ledger.GLEntry {
+	return ledger.GLEntry{
+		PostingDate:     c.PostingDate,
+		TransactionDate: c.PostingDate,
+		VoucherType:     VoucherType,
+		VoucherNo:       c.Name,
+		Company:         c.Company,
+		Account:         account,
+		Against:         against,
+		Remarks:         fmt.Sprintf("Cash over/short of POS profile %s, %s", c.POSProfile, c.User),
+		IsOpening:       ledger.IsOpeningNo,
+		IsAdvance:       ledger.IsAdvanceNo,
+	}
+}
+
+// Submit reconciles the counts and posts the variance, if any.
+//
+// Maps to: on_submit() in pos_closing_entry.py
+func (c *ClosingEntry) Submit(engine *ledger.Engine) error {
+	if err := c.Reconcile(); err != nil {
+		return err
+	}
+	glMap, err := c.GetGLEntries()
+	if err != nil {
+		return err
+	}
+	if len(glMap) > 0 {
+		if err := engine.MakeGLEntries(glMap, ledger.DefaultPostingOptions()); err != nil {
+			return err
+		}
+	}
+	c.Status = Submitted
+	return nil
+}
+
+// Cancel reverses the variance posting.
+//
+// Maps to: on_cancel() in pos_closing_entry.py
+func (c *ClosingEntry) Cancel(engine *ledger.Engine) error {
+	if slices.ContainsFunc(c.Payments, func(p *PaymentReconciliation) bool { return p.Difference != 0 }) {
+		opts := ledger.DefaultPostingOptions()
+		opts.Cancel = true
+		if err := engine.MakeGLEntries([]ledger.GLEntry{c.newGLEntry("", "")}, opts); err != nil {
+			return err
+		}
+	}
+	c.Status = Cancelled
+	return nil
+}
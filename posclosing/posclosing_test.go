@@ -0,0 +1,108 @@
+package posclosing
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/ledger"
+	"github.com/senguttuvang/erpnext-go/ledger/memstore"
+	"github.com/senguttuvang/erpnext-go/modeofpayment"
+	"github.com/senguttuvang/erpnext-go/salesinvoice"
+	"github.com/senguttuvang/erpnext-go/taxcalc"
+)
+
+var shift = time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+// sale is a submitted POS invoice of a 21.50 CHF coffee order.
+func sale(t *testing.T, engine *ledger.Engine, name string, payments ...*salesinvoice.Payment) *salesinvoice.SalesInvoice {
+	t.Helper()
+	inv := &salesinvoice.SalesInvoice{
+		Name: name, Company: "Zurich Café GmbH", Customer: "Walk-in Customer", PostingDate: shift,
+		DebitTo: "Debtors - ZC", CompanyCurrency: "CHF", CostCenter: "Main - ZC",
+		IsPOS: true, AccountForChangeAmount: "Cash - ZC", Payments: payments,
+		Document: taxcalc.Document{
+			Currency: "CHF",
+			Items:    []*taxcalc.LineItem{{ItemCode: "COFFEE", Qty: 1, Rate: 21.50, IncomeAccount: "Sales - ZC"}},
+		},
+	}
+	if err := inv.Submit(engine, nil); err != nil {
+		t.Fatal(err)
+	}
+	return inv
+}
+
+func TestClosingEntry(t *testing.T) {
+	store := memstore.NewGLStore()
+	engine := &ledger.Engine{GLStore: store, PaymentStore: memstore.NewPaymentStore()}
+	invoices := []*salesinvoice.SalesInvoice{
+		sale(t, engine, "POS-1", &salesinvoice.Payment{ModeOfPayment: "Cash", Type: modeofpayment.Cash, Account: "Cash - ZC", Amount: 50}),
+		sale(t, engine, "POS-2", &salesinvoice.Payment{ModeOfPayment: "Card", Type: modeofpayment.Bank, Account: "Card Clearing - ZC", Amount: 21.50}),
+	}
+
+	c := &ClosingEntry{
+		Name: "POS-CLO-1", Company: "Zurich Café GmbH", POSProfile: "Café", User: "anna",
+		PostingDate: shift, PeriodStart: shift, CashOverShortAccount: "Cash Over/Short - ZC", CostCenter: "Main - ZC",
+		Payments: []*PaymentReconciliation{{ModeOfPayment: "Cash", OpeningAmount: 100}},
+	}
+	if err := c.Collect(invoices); err != nil {
+		t.Fatal(err)
+	}
+	if c.GrandTotal != 43 || len(c.Invoices) != 2 || len(c.Payments) != 2 {
+		t.Fatalf("collected %v, total %.2f", c.Invoices, c.GrandTotal)
+	}
+	cash, card := c.Payments[0], c.Payments[1]
+	if cash.ExpectedAmount != 121.50 || cash.Account != "Cash - ZC" || card.ExpectedAmount != 21.50 {
+		t.Fatalf("expected cash %.2f, card %.2f", cash.ExpectedAmount, card.ExpectedAmount)
+	}
+
+	// The till is 1.50 short; the card terminal agrees
+	cash.Denominations = []Denomination{{Value: 50, Count: 2}, {Value: 10, Count: 2}, {Value: 0, Count: 3}}
+	card.ClosingAmount = 21.50
+	if err := c.Submit(engine); err != nil {
+		t.Fatal(err)
+	}
+	if cash.ClosingAmount != 120 || cash.Difference != -1.50 || card.Difference != 0 || c.Variance() != -1.50 {
+		t.Fatalf("cash counted %.2f, difference %.2f; variance %.2f", cash.ClosingAmount, cash.Difference, c.Variance())
+	}
+
+	balances := map[string]float64{}
+	for _, e := range store.All() {
+		if !e.IsCancelled {
+			balances[e.Account] += e.Debit - e.Credit
+		}
+	}
+	if got := ledger.Flt(balances["Cash - ZC"], 2); got != 20 { // 50 taken less 28.50 change less 1.50 short
+		t.Errorf("cash balance = %.2f, want 20", got)
+	}
+	if got := balances["Cash Over/Short - ZC"]; got != 1.50 {
+		t.Errorf("over/short balance = %.2f, want 1.50", got)
+	}
+
+	if err := c.Cancel(engine); err != nil {
+		t.Fatal(err)
+	}
+	balances = map[string]float64{}
+	for _, e := range store.All() {
+		balances[e.Account] += e.Debit - e.Credit
+	}
+	if got := ledger.Flt(balances["Cash Over/Short - ZC"], 2); got != 0 || c.Status != Cancelled {
+		t.Errorf("over/short after cancel = %.2f, status %s", got, c.Status)
+	}
+}
+
+func TestClosingEntryValidation(t *testing.T) {
+	c := &ClosingEntry{Name: "POS-CLO-2", Company: "Zurich Café GmbH",
+		Payments: []*PaymentReconciliation{{ModeOfPayment: "Cash", Account: "Cash - ZC", ExpectedAmount: 10, ClosingAmount: 12}}}
+	if err := c.Submit(&ledger.Engine{GLStore: memstore.NewGLStore()}); !errors.Is(err, ErrMissingOverShortAccount) {
+		t.Errorf("Submit() without over/short account = %v", err)
+	}
+	c.Payments[0].Denominations = []Denomination{{Value: 5, Count: -1}}
+	if err := c.Reconcile(); !errors.Is(err, ErrInvalidDenomination) {
+		t.Errorf("Reconcile() with negative count = %v", err)
+	}
+	other := &salesinvoice.SalesInvoice{Name: "SINV-9", Company: "Other AG", IsPOS: true, Status: salesinvoice.Submitted}
+	if err := c.Collect([]*salesinvoice.SalesInvoice{other}); !errors.Is(err, ErrInvoiceOutOfScope) {
+		t.Errorf("Collect() with another company's invoice = %v", err)
+	}
+}
@@ -0,0 +1,256 @@
+// Package posmerge consolidates POS invoices into one Sales Invoice per
+// customer and handles returns raised against the consolidated invoice.
+//
+// Migrated from: erpnext/accounts/doctype/pos_invoice_merge_log/pos_invoice_merge_log.py
+//
+// In ERPNext POS Invoices post nothing until they are merged at the close
+// of the shift. Here each POS sales invoice posts its own GL entries when
+// submitted, so the consolidated invoice is the summary issued to the
+// customer and consolidating posts nothing. A return against the
+// consolidated invoice is allocated to the POS invoices it summarises and
+// posted as credit notes against them, which reverse the income, tax and
+// receivable and refund the payment modes.
+package posmerge
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/ledger"
+	"github.com/senguttuvang/erpnext-go/salesinvoice"
+	"github.com/senguttuvang/erpnext-go/taxcalc"
+)
+
+// Consolidation errors
+var (
+	ErrNoInvoices       = errors.New("no POS invoices to consolidate")
+	ErrNotMergeable     = errors.New("invoice cannot be consolidated")
+	ErrNotConsolidated  = errors.New("invoice is not part of the consolidated invoice")
+	ErrReturnExceedsQty = errors.New("return quantity exceeds the quantity left to return")
+	ErrNothingToReturn  = errors.New("nothing to return")
+)
+
+// Line is an item row of a consolidated POS invoice.
+type Line struct {
+	Invoice     string // POS invoice
+	Row         int    // Item row index in the POS invoice
+	ItemCode    string
+	Qty         float64
+	ReturnedQty float64 // Taken back by returns against the consolidated invoice
+}
+
+// Returnable returns the quantity still open to returns.
+func (l *Line) Returnable() float64 {
+	return ledger.Flt(l.Qty-l.ReturnedQty, 6)
+}
+
+// Consolidation is a consolidated sales invoice and the POS invoices it
+// summarises.
+//
+// Maps to: POS Invoice Merge Log and the consolidated Sales Invoice it
+// creates
+type Consolidation struct {
+	Name        string // Consolidated Sales Invoice
+	Company     string
+	Customer    string
+	PostingDate time.Time
+
+	Invoices []*salesinvoice.SalesInvoice // In posting order
+	Lines    []*Line
+
+	GrandTotal     float64  // Of the POS invoices, company currency
+	ReturnedAmount float64  // Of the credit notes, company currency
+	Returns        []string // Credit notes raised against the consolidated invoice
+}
+
+// Consolidate summarises a customer's submitted POS invoices as the
+// consolidated invoice name.
+//
+// Maps to: on_submit() -> process_merging_into_sales_invoice() in
+// pos_invoice_merge_log.py
+func Consolidate(name string, postingDate time.Time, invoices []*salesinvoice.SalesInvoice) (*Consolidation, error) {
+	if len(invoices) == 0 {
+		return nil, ErrNoInvoices
+	}
+	c := &Consolidation{
+		Name:        name,
+		Company:     invoices[0].Company,
+		Customer:    invoices[0].Customer,
+		PostingDate: postingDate,
+	}
+	for _, inv := range invoices {
+		switch {
+		case !inv.IsPOS || inv.IsReturn:
+			return nil, fmt.Errorf("%w: %s is not a POS sale", ErrNotMergeable, inv.Name)
+		case inv.Status != salesinvoice.Submitted:
+			return nil, fmt.Errorf("%w: %s is %s", ErrNotMergeable, inv.Name, inv.Status)
+		case inv.Company != c.Company || inv.Customer != c.Customer:
+			return nil, fmt.Errorf("%w: %s is for %s of %s", ErrNotMergeable, inv.Name, inv.Customer, inv.Company)
+		case slices.Contains(c.Invoices, inv):
+			return nil, fmt.Errorf("%w: %s is listed twice", ErrNotMergeable, inv.Name)
+		}
+		c.Invoices = append(c.Invoices, inv)
+	}
+	slices.SortStableFunc(c.Invoices, func(a, b *salesinvoice.SalesInvoice) int {
+		return a.PostingDate.Compare(b.PostingDate)
+	})
+	for _, inv := range c.Invoices {
+		for row, item := range inv.Items {
+			c.Lines = append(c.Lines, &Line{Invoice: inv.Name, Row: row, ItemCode: item.ItemCode, Qty: item.Qty})
+		}
+		c.GrandTotal += baseTotal(inv)
+	}
+	c.GrandTotal = ledger.Flt(c.GrandTotal, 2)
+	return c, nil
+}
+
+// NetTotal returns what the consolidated invoice stands at after returns.
+func (c *Consolidation) NetTotal() float64 {
+	return ledger.Flt(c.GrandTotal-c.ReturnedAmount, 2)
+}
+
+// ReturnItem is an item the customer brings back against the consolidated
+// invoice.
+type ReturnItem struct {
+	ItemCode string
+	Qty      float64
+	Invoice  string // The POS invoice on the customer's receipt; optional
+}
+
+// Allocation is the part of a return taken from one POS invoice row.
+type Allocation struct {
+	Invoice  string
+	Row      int
+	ItemCode string
+	Qty      float64
+}
+
+// Allocate works out which POS invoice rows a return comes from. An item
+// returned with its receipt is taken from that invoice; otherwise it is
+// taken from the latest sales of the item first. Nothing is changed until
+// the return is posted.
+func (c *Consolidation) Allocate(items []ReturnItem) ([]Allocation, error) {
+	if len(items) == 0 {
+		return nil, ErrNothingToReturn
+	}
+	taken := make(map[*Line]float64)
+	var allocations []Allocation
+	for _, item := range items {
+		if item.Qty <= 0 {
+			return nil, fmt.Errorf("%w: %s qty %g", salesinvoice.ErrInvalidReturnQty, item.ItemCode, item.Qty)
+		}
+		if item.Invoice != "" && c.invoice(item.Invoice) == nil {
+			return nil, fmt.Errorf("%w: %s", ErrNotConsolidated, item.Invoice)
+		}
+		remaining := item.Qty
+		for _, line := range slices.Backward(c.Lines) {
+			if remaining <= 0 {
+				break
+			}
+			if line.ItemCode != item.ItemCode || (item.Invoice != "" && line.Invoice != item.Invoice) {
+				continue
+			}
+			qty := min(remaining, ledger.Flt(line.Returnable()-taken[line], 6))
+			if qty <= 0 {
+				continue
+			}
+			taken[line] += qty
+			remaining = ledger.Flt(remaining-qty, 6)
+			allocations = append(allocations, Allocation{Invoice: line.Invoice, Row: line.Row, ItemCode: line.ItemCode, Qty: qty})
+		}
+		if remaining > 0 {
+			return nil, fmt.Errorf("%w: %g of %s", ErrReturnExceedsQty, remaining, item.ItemCode)
+		}
+	}
+	return allocations, nil
+}
+
+// Return is a return against the consolidated invoice.
+type Return struct {
+	Name          string
+	ReturnAgainst string // Consolidated invoice
+	PostingDate   time.Time
+	Allocations   []Allocation
+	CreditNotes   []*salesinvoice.SalesInvoice // One per POS invoice returned against
+}
+
+// Return allocates the returned items, then posts a credit note against
+// each POS invoice they came from and takes the return off the
+// consolidated invoice. With one POS invoice the credit note is named
+// name; with several they are numbered name-1, name-2 and so on.
+//
+// Maps to: make_sales_return() in sales_invoice.py, for a consolidated
+// invoice
+func (c *Consolidation) Return(engine *ledger.Engine, name string, postingDate time.Time, items []ReturnItem, precision taxcalc.PrecisionProvider) (*Return, error) {
+	allocations, err := c.Allocate(items)
+	if err != nil {
+		return nil, err
+	}
+	ret := &Return{Name: name, ReturnAgainst: c.Name, PostingDate: postingDate, Allocations: allocations}
+
+	var invoices []string
+	qty := make(map[string]map[int]float64)
+	for _, a := range allocations {
+		if qty[a.Invoice] == nil {
+			invoices = append(invoices, a.Invoice)
+			qty[a.Invoice] = make(map[int]float64)
+		}
+		qty[a.Invoice][a.Row] += a.Qty
+	}
+	for i, invoice := range invoices {
+		creditNote := name
+		if len(invoices) > 1 {
+			creditNote = fmt.Sprintf("%s-%d", name, i+1)
+		}
+		cn, err := c.invoice(invoice).MakeReturn(creditNote, postingDate, qty[invoice], precision)
+		if err != nil {
+			return nil, err
+		}
+		cn.Remarks = fmt.Sprintf("Return against %s of consolidated invoice %s", invoice, c.Name)
+		if _, err := cn.GetGLEntries(); err != nil {
+			return nil, err
+		}
+		ret.CreditNotes = append(ret.CreditNotes, cn)
+	}
+
+	for i, cn := range ret.CreditNotes {
+		if err := cn.Submit(engine, precision); err != nil {
+			return ret, err
+		}
+		if err := c.invoice(invoices[i]).AddReturn(cn); err != nil {
+			return ret, err
+		}
+		for row, q := range qty[invoices[i]] {
+			line := c.line(invoices[i], row)
+			line.ReturnedQty = ledger.Flt(line.ReturnedQty+q, 6)
+		}
+		c.ReturnedAmount = ledger.Flt(c.ReturnedAmount+baseTotal(cn), 2)
+		c.Returns = append(c.Returns, cn.Name)
+	}
+	return ret, nil
+}
+
+// invoice returns a consolidated POS invoice by name, or nil.
+func (c *Consolidation) invoice(name string) *salesinvoice.SalesInvoice {
+	i := slices.IndexFunc(c.Invoices, func(inv *salesinvoice.SalesInvoice) bool { return inv.Name == name })
+	if i < 0 {
+		return nil
+	}
+	return c.Invoices[i]
+}
+
+// line returns the line of a POS invoice row.
+func (c *Consolidation) line(invoice string, row int) *Line {
+	i := slices.IndexFunc(c.Lines, func(l *Line) bool { return l.Invoice == invoice && l.Row == row })
+	return c.Lines[i]
+}
+
+// baseTotal returns an invoice's receivable amount in company currency.
+func baseTotal(inv *salesinvoice.SalesInvoice) float64 {
+	if inv.BaseRoundedTotal != 0 {
+		return inv.BaseRoundedTotal
+	}
+	return inv.BaseGrandTotal
+}
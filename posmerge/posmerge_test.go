@@ -0,0 +1,133 @@
+package posmerge
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/ledger"
+	"github.com/senguttuvang/erpnext-go/ledger/memstore"
+	"github.com/senguttuvang/erpnext-go/modeofpayment"
+	"github.com/senguttuvang/erpnext-go/salesinvoice"
+	"github.com/senguttuvang/erpnext-go/taxcalc"
+)
+
+var day = time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+var (
+	cash = salesinvoice.Payment{ModeOfPayment: "Cash", Type: modeofpayment.Cash, Account: "Cash - ZC"}
+	card = salesinvoice.Payment{ModeOfPayment: "Card", Type: modeofpayment.Bank, Account: "Card Clearing - ZC"}
+)
+
+// sale is a submitted POS invoice with 10% VAT paid in one mode.
+func sale(t *testing.T, engine *ledger.Engine, name string, hour int, mode salesinvoice.Payment, paid float64, items ...*taxcalc.LineItem) *salesinvoice.SalesInvoice {
+	t.Helper()
+	mode.Amount = paid
+	for _, item := range items {
+		item.IncomeAccount = "Sales - ZC"
+	}
+	inv := &salesinvoice.SalesInvoice{
+		Name: name, Company: "Zurich Café GmbH", Customer: "Walk-in Customer", PostingDate: day.Add(time.Duration(hour) * time.Hour),
+		DebitTo: "Debtors - ZC", CompanyCurrency: "CHF", IsPOS: true, AccountForChangeAmount: "Cash - ZC",
+		Payments: []*salesinvoice.Payment{&mode},
+		Document: taxcalc.Document{
+			Currency: "CHF",
+			Items:    items,
+			Taxes:    []*taxcalc.TaxRow{{AccountHead: "VAT - ZC", ChargeType: taxcalc.OnNetTotal, Rate: 10}},
+		},
+	}
+	if err := inv.Submit(engine, nil); err != nil {
+		t.Fatal(err)
+	}
+	return inv
+}
+
+func TestReturn(t *testing.T) {
+	store := memstore.NewGLStore()
+	engine := &ledger.Engine{GLStore: store, PaymentStore: memstore.NewPaymentStore()}
+	invoices := []*salesinvoice.SalesInvoice{
+		sale(t, engine, "POS-2", 11, card, 22, &taxcalc.LineItem{ItemCode: "MUG", Qty: 1, Rate: 20}),
+		sale(t, engine, "POS-1", 9, cash, 50, &taxcalc.LineItem{ItemCode: "MUG", Qty: 2, Rate: 20}, &taxcalc.LineItem{ItemCode: "TEA", Qty: 1, Rate: 5}),
+	}
+	c, err := Consolidate("SINV-CONS-1", day, invoices)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Invoices[0].Name != "POS-1" || len(c.Lines) != 3 || c.GrandTotal != 71.50 {
+		t.Fatalf("consolidation = %+v", c)
+	}
+
+	// Three mugs come back: the one sold by card last, then two from the
+	// cash sale
+	ret, err := c.Return(engine, "CN-1", day.AddDate(0, 0, 1), []ReturnItem{{ItemCode: "MUG", Qty: 3}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Allocation{{Invoice: "POS-2", Row: 0, ItemCode: "MUG", Qty: 1}, {Invoice: "POS-1", Row: 0, ItemCode: "MUG", Qty: 2}}
+	if len(ret.Allocations) != len(want) || ret.Allocations[0] != want[0] || ret.Allocations[1] != want[1] {
+		t.Fatalf("allocations = %+v", ret.Allocations)
+	}
+	if len(ret.CreditNotes) != 2 || ret.CreditNotes[0].Name != "CN-1-1" || ret.CreditNotes[1].ReturnAgainst != "POS-1" {
+		t.Fatalf("credit notes = %+v", ret.CreditNotes)
+	}
+	if c.ReturnedAmount != 66 || c.NetTotal() != 5.50 || len(c.Returns) != 2 || c.Lines[0].Returnable() != 0 {
+		t.Errorf("returned %.2f, net %.2f, returns %v", c.ReturnedAmount, c.NetTotal(), c.Returns)
+	}
+
+	balances := map[string]float64{}
+	for _, e := range store.All() {
+		balances[e.Account] += e.Debit - e.Credit
+	}
+	for account, want := range map[string]float64{
+		"Debtors - ZC": 0, "Card Clearing - ZC": 0, "Cash - ZC": 5.50, "VAT - ZC": -0.50, "Sales - ZC": -5,
+	} {
+		if got := ledger.Flt(balances[account], 2); got != want {
+			t.Errorf("%s balance = %.2f, want %.2f", account, got, want)
+		}
+	}
+
+	// Nothing is posted when part of a return cannot be allocated
+	before := len(store.All())
+	for name, items := range map[string][]ReturnItem{
+		"sold out":      {{ItemCode: "TEA", Qty: 1}, {ItemCode: "MUG", Qty: 1}},
+		"other till":    {{ItemCode: "TEA", Qty: 1, Invoice: "POS-9"}},
+		"wrong receipt": {{ItemCode: "TEA", Qty: 1, Invoice: "POS-2"}},
+	} {
+		if _, err := c.Return(engine, "CN-2", day, items, nil); !errors.Is(err, ErrReturnExceedsQty) && !errors.Is(err, ErrNotConsolidated) {
+			t.Errorf("%s: Return() error = %v", name, err)
+		}
+	}
+	if len(store.All()) != before || c.Lines[1].ReturnedQty != 0 {
+		t.Error("a failed return changed the ledger or the consolidation")
+	}
+
+	// The tea comes back with its receipt
+	if _, err := c.Return(engine, "CN-3", day, []ReturnItem{{ItemCode: "TEA", Qty: 1, Invoice: "POS-1"}}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if c.NetTotal() != 0 || c.Returns[2] != "CN-3" {
+		t.Errorf("net %.2f after returning everything", c.NetTotal())
+	}
+}
+
+func TestConsolidate(t *testing.T) {
+	engine := &ledger.Engine{GLStore: memstore.NewGLStore(), PaymentStore: memstore.NewPaymentStore()}
+	inv := sale(t, engine, "POS-1", 9, cash, 10, &taxcalc.LineItem{ItemCode: "TEA", Qty: 1, Rate: 5})
+	other := sale(t, engine, "POS-2", 9, cash, 10, &taxcalc.LineItem{ItemCode: "TEA", Qty: 1, Rate: 5})
+	other.Customer = "Regular"
+	draft := *inv
+	draft.Name, draft.Status = "POS-3", salesinvoice.Draft
+
+	if _, err := Consolidate("SINV-CONS-1", day, nil); !errors.Is(err, ErrNoInvoices) {
+		t.Errorf("no invoices: %v", err)
+	}
+	for name, invoices := range map[string][]*salesinvoice.SalesInvoice{
+		"other customer": {inv, other},
+		"draft":          {inv, &draft},
+		"twice":          {inv, inv},
+	} {
+		if _, err := Consolidate("SINV-CONS-1", day, invoices); !errors.Is(err, ErrNotMergeable) {
+			t.Errorf("%s: Consolidate() error = %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package pricelist
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/ledger"
+)
+
+// BulkStore is a Store that can list and save the prices of a price list.
+type BulkStore interface {
+	Store
+
+	// PricesIn returns every item price of a price list.
+	PricesIn(priceList string) ([]ItemPrice, error)
+
+	// SaveItemPrices inserts prices without a Name and updates the others.
+	SaveItemPrices(prices []ItemPrice) error
+}
+
+// RateSource returns exchange rates.
+//
+// Maps to: get_exchange_rate() in erpnext/setup/utils.py (Currency Exchange)
+type RateSource interface {
+	ExchangeRate(from, to string, date time.Time) (float64, error)
+}
+
+// Formula computes a new rate for an item price.
+type Formula func(p ItemPrice) float64
+
+// Percentage raises (or, when negative, lowers) rates by pct percent.
+func Percentage(pct float64) Formula {
+	return func(p ItemPrice) float64 {
+		return p.PriceListRate * (1 + pct/100)
+	}
+}
+
+// BulkOptions controls a bulk price operation.
+type BulkOptions struct {
+	Filter    func(ItemPrice) bool // Optional; selects the prices to change
+	Precision int                  // Decimals of the new rates; 2 when zero
+	DryRun    bool                 // Compute the audit without saving
+	User      string               // Recorded in the audit
+	Date      time.Time            // Exchange rate date for Regenerate; today when zero
+}
+
+// Change is one item price changed by a bulk operation.
+type Change struct {
+	ItemPrice string // Empty for prices created by a dry run
+	ItemCode  string
+	UOM       string
+	Customer  string
+	Supplier  string
+	OldRate   float64
+	NewRate   float64
+	Created   bool
+}
+
+// Audit records the rows a bulk operation changed.
+type Audit struct {
+	Operation string
+	PriceList string
+	User      string
+	At        time.Time
+	DryRun    bool
+	Changes   []Change
+}
+
+// UpdatePrices applies a formula to the prices of a price list and saves
+// those whose rate changes.
+func UpdatePrices(store BulkStore, priceList string, formula Formula, opts BulkOptions) (*Audit, error) {
+	prices, err := store.PricesIn(priceList)
+	if err != nil {
+		return nil, err
+	}
+	audit := newAudit("Update Prices", priceList, opts)
+	var changed []ItemPrice
+	for _, p := range prices {
+		if opts.Filter != nil && !opts.Filter(p) {
+			continue
+		}
+		rate := ledger.Flt(formula(p), precision(opts))
+		if rate == p.PriceListRate {
+			continue
+		}
+		audit.Changes = append(audit.Changes, change(p, p.PriceListRate, rate, false))
+		p.PriceListRate = rate
+		changed = append(changed, p)
+	}
+	return audit, save(store, changed, opts)
+}
+
+// Regenerate rebuilds a foreign-currency price list from a base list: each
+// base price is converted at the current exchange rate and saved to the
+// target list, updating the matching price (same item, UOM, party and
+// validity) or creating one. Prices only in the target are left alone.
+func Regenerate(store BulkStore, rates RateSource, base, target string, opts BulkOptions) (*Audit, error) {
+	basePL, err := store.GetPriceList(base)
+	if err != nil {
+		return nil, err
+	}
+	targetPL, err := store.GetPriceList(target)
+	if err != nil {
+		return nil, err
+	}
+	date := opts.Date
+	if date.IsZero() {
+		date = time.Now()
+	}
+	rate, err := rates.ExchangeRate(basePL.Currency, targetPL.Currency, date)
+	if err != nil {
+		return nil, err
+	}
+	if rate <= 0 {
+		return nil, fmt.Errorf("%w: %s to %s", ErrNoExchangeRate, basePL.Currency, targetPL.Currency)
+	}
+
+	basePrices, err := store.PricesIn(base)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := store.PricesIn(target)
+	if err != nil {
+		return nil, err
+	}
+	byKey := make(map[priceKey]ItemPrice, len(existing))
+	for _, p := range existing {
+		byKey[keyOf(p)] = p
+	}
+
+	audit := newAudit(fmt.Sprintf("Regenerate from %s at %g", base, rate), target, opts)
+	var changed []ItemPrice
+	for _, bp := range basePrices {
+		if opts.Filter != nil && !opts.Filter(bp) {
+			continue
+		}
+		newRate := ledger.Flt(bp.PriceListRate*rate, precision(opts))
+		p, ok := byKey[keyOf(bp)]
+		if ok && p.PriceListRate == newRate {
+			continue
+		}
+		if !ok {
+			p = bp
+			p.Name, p.PriceList = "", target
+		}
+		audit.Changes = append(audit.Changes, change(p, p.PriceListRate, newRate, !ok))
+		p.PriceListRate = newRate
+		changed = append(changed, p)
+	}
+	return audit, save(store, changed, opts)
+}
+
+// priceKey identifies the same price across price lists.
+type priceKey struct {
+	itemCode, uom, customer, supplier string
+	validFrom, validUpto              time.Time
+}
+
+func keyOf(p ItemPrice) priceKey {
+	k := priceKey{itemCode: p.ItemCode, uom: p.UOM, customer: p.Customer, supplier: p.Supplier}
+	if p.ValidFrom != nil {
+		k.validFrom = *p.ValidFrom
+	}
+	if p.ValidUpto != nil {
+		k.validUpto = *p.ValidUpto
+	}
+	return k
+}
+
+func newAudit(operation, priceList string, opts BulkOptions) *Audit {
+	return &Audit{Operation: operation, PriceList: priceList, User: opts.User, At: time.Now(), DryRun: opts.DryRun}
+}
+
+func change(p ItemPrice, oldRate, newRate float64, created bool) Change {
+	return Change{
+		ItemPrice: p.Name, ItemCode: p.ItemCode, UOM: p.UOM, Customer: p.Customer, Supplier: p.Supplier,
+		OldRate: oldRate, NewRate: newRate, Created: created,
+	}
+}
+
+func save(store BulkStore, prices []ItemPrice, opts BulkOptions) error {
+	if opts.DryRun || len(prices) == 0 {
+		return nil
+	}
+	return store.SaveItemPrices(prices)
+}
+
+func precision(opts BulkOptions) int {
+	if opts.Precision > 0 {
+		return opts.Precision
+	}
+	return 2
+}
@@ -0,0 +1,294 @@
+// Package pricelist implements price lists and item prices from ERPNext.
+//
+// A price list is a named set of item prices in one currency, used for
+// buying, selling or both. Item prices may be limited to a customer or
+// supplier and to a validity window. The Resolver looks up the price for
+// each row of a document and fills LineItem.PriceListRate, from which the
+// tax calculator derives the rate.
+//
+// Migrated from: erpnext/stock/get_item_details.py (get_price_list_rate)
+package pricelist
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/ledger"
+	"github.com/senguttuvang/erpnext-go/taxcalc"
+)
+
+// Price list errors
+var (
+	ErrPriceListNotFound  = errors.New("price list not found")
+	ErrPriceListDisabled  = errors.New("price list is disabled")
+	ErrWrongPriceListType = errors.New("price list is not valid for this transaction")
+	ErrNoItemPrice        = errors.New("no item price")
+	ErrNoExchangeRate     = errors.New("price list exchange rate not set")
+)
+
+// PriceList is a named set of prices in one currency.
+// Maps to: erpnext/stock/doctype/price_list/price_list.json
+type PriceList struct {
+	Name     string
+	Currency string
+	Buying   bool
+	Selling  bool
+	Enabled  bool
+
+	// PriceNotUOMDependent converts stock UOM prices to any transaction
+	// UOM; otherwise prices apply only to the UOM they are set for.
+	PriceNotUOMDependent bool
+}
+
+// ItemPrice is the price of an item in a price list.
+// Maps to: erpnext/stock/doctype/item_price/item_price.json
+type ItemPrice struct {
+	Name          string
+	ItemCode      string
+	PriceList     string
+	UOM           string // Empty means the item's stock UOM
+	Customer      string // Limits a selling price to one customer
+	Supplier      string // Limits a buying price to one supplier
+	ValidFrom     *time.Time
+	ValidUpto     *time.Time
+	PriceListRate float64 // In the price list's currency
+}
+
+// ValidOn reports whether the price applies on a date. A zero date matches
+// every price.
+func (p *ItemPrice) ValidOn(date time.Time) bool {
+	if date.IsZero() {
+		return true
+	}
+	return (p.ValidFrom == nil || !date.Before(*p.ValidFrom)) && (p.ValidUpto == nil || !date.After(*p.ValidUpto))
+}
+
+// Store abstracts queries for price lists and item prices.
+type Store interface {
+	GetPriceList(name string) (*PriceList, error)
+
+	// ItemPrices returns every price of an item in a price list.
+	ItemPrices(priceList, itemCode string) ([]ItemPrice, error)
+}
+
+// Args describes the transaction prices are looked up for.
+type Args struct {
+	// PriceList is the selling or buying price list: the party's default,
+	// else its territory's (territory.Tree.PriceList), else the settings'.
+	PriceList string
+	Buying    bool // Purchase documents need a buying list, sales a selling one
+
+	Customer string
+	Supplier string
+	Date     time.Time // Transaction date; zero ignores validity
+
+	// PLCConversionRate converts the price list currency to company
+	// currency. It is required when the price list and document currencies
+	// differ.
+	PLCConversionRate float64
+}
+
+// Resolver looks up item prices.
+type Resolver struct {
+	Store Store
+}
+
+// PriceList returns a price list checked for use in a transaction.
+func (r *Resolver) PriceList(args Args) (*PriceList, error) {
+	pl, err := r.Store.GetPriceList(args.PriceList)
+	if err != nil {
+		return nil, err
+	}
+	if pl == nil {
+		return nil, fmt.Errorf("%w: %s", ErrPriceListNotFound, args.PriceList)
+	}
+	if !pl.Enabled {
+		return nil, fmt.Errorf("%w: %s", ErrPriceListDisabled, pl.Name)
+	}
+	if (args.Buying && !pl.Buying) || (!args.Buying && !pl.Selling) {
+		return nil, fmt.Errorf("%w: %s", ErrWrongPriceListType, pl.Name)
+	}
+	return pl, nil
+}
+
+// Rate returns an item's price in the price list's currency for a UOM.
+// A price for the customer or supplier wins over a general one; a price in
+// the transaction UOM wins over a stock UOM price, which is scaled by the
+// conversion factor. Among equal candidates the latest ValidFrom wins.
+//
+// Maps to: get_price_list_rate_for() in get_item_details.py
+//
+// Python equivalent:
+//
+//	price_list_rate = get_item_price(item_price_args, item_code)
+//	if not price_list_rate:
+//	    for field in ["customer", "supplier"]:
+//	        del item_price_args[field]
+//	    general_price_list_rate = get_item_price(item_price_args, item_code)
+//	    if not general_price_list_rate and args.get("uom") != args.get("stock_uom"):
+//	        item_price_args["uom"] = args.get("stock_uom")
+//	        general_price_list_rate = get_item_price(item_price_args, item_code)
+//	...
+//	if item_price_data[0][2] == args.get("uom"):
+//	    return item_price_data[0][1]
+//	elif not args.get("price_list_uom_dependant"):
+//	    return flt(item_price_data[0][1] * flt(args.get("conversion_factor", 1)))
+func (r *Resolver) Rate(pl *PriceList, args Args, itemCode, uom, stockUOM string, conversionFactor float64) (float64, error) {
+	prices, err := r.Store.ItemPrices(pl.Name, itemCode)
+	if err != nil {
+		return 0, err
+	}
+	if uom == "" {
+		uom = stockUOM
+	}
+	if conversionFactor == 0 {
+		conversionFactor = 1
+	}
+	uomOf := func(p *ItemPrice) string {
+		if p.UOM == "" {
+			return stockUOM
+		}
+		return p.UOM
+	}
+
+	party := args.Customer
+	if args.Buying {
+		party = args.Supplier
+	}
+	searches := []func(p *ItemPrice) bool{
+		func(p *ItemPrice) bool { return party != "" && p.party(args.Buying) == party && uomOf(p) == uom },
+		func(p *ItemPrice) bool { return p.Customer == "" && p.Supplier == "" && uomOf(p) == uom },
+	}
+	if uom != stockUOM && pl.PriceNotUOMDependent {
+		searches = append(searches, func(p *ItemPrice) bool {
+			return p.Customer == "" && p.Supplier == "" && uomOf(p) == stockUOM
+		})
+	}
+	for _, matches := range searches {
+		if p := latest(prices, args.Date, matches); p != nil {
+			if uomOf(p) == uom {
+				return p.PriceListRate, nil
+			}
+			return p.PriceListRate * conversionFactor, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: %s in %s", ErrNoItemPrice, itemCode, pl.Name)
+}
+
+func (p *ItemPrice) party(buying bool) string {
+	if buying {
+		return p.Supplier
+	}
+	return p.Customer
+}
+
+// latest returns the matching price valid on date with the latest
+// ValidFrom; prices without one rank oldest.
+func latest(prices []ItemPrice, date time.Time, matches func(*ItemPrice) bool) *ItemPrice {
+	var best *ItemPrice
+	for i := range prices {
+		p := &prices[i]
+		if !p.ValidOn(date) || !matches(p) {
+			continue
+		}
+		if best == nil || (p.ValidFrom != nil && (best.ValidFrom == nil || p.ValidFrom.After(*best.ValidFrom))) {
+			best = p
+		}
+	}
+	return best
+}
+
+// Apply fills PriceListRate on every row of a document that has none,
+// converted to the document's currency. Rows without an item price are
+// left for the user to price, as in ERPNext.
+//
+// Maps to: set_missing_item_details() -> get_price_list_rate() in
+// controllers/accounts_controller.py
+func (r *Resolver) Apply(doc *taxcalc.Document, args Args) error {
+	pl, err := r.PriceList(args)
+	if err != nil {
+		return err
+	}
+	factor := 1.0
+	if pl.Currency != doc.Currency {
+		if args.PLCConversionRate == 0 || doc.ConversionRate == 0 {
+			return fmt.Errorf("%w: %s to %s", ErrNoExchangeRate, pl.Currency, doc.Currency)
+		}
+		factor = args.PLCConversionRate / doc.ConversionRate
+	}
+
+	for _, item := range doc.Items {
+		if item.PriceListRate != 0 || item.ItemCode == "" {
+			continue
+		}
+		rate, err := r.Rate(pl, args, item.ItemCode, item.UOM, item.StockUOM, item.ConversionFactor)
+		if errors.Is(err, ErrNoItemPrice) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("item %s: %w", item.ItemCode, err)
+		}
+		item.PriceListRate = ledger.Flt(rate*factor, 2)
+	}
+	return nil
+}
+
+// MemoryStore is an in-memory BulkStore.
+type MemoryStore struct {
+	PriceLists map[string]*PriceList
+	Prices     []ItemPrice
+
+	next int
+}
+
+// GetPriceList implements Store.
+func (s *MemoryStore) GetPriceList(name string) (*PriceList, error) {
+	pl, ok := s.PriceLists[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrPriceListNotFound, name)
+	}
+	return pl, nil
+}
+
+// PricesIn implements BulkStore.
+func (s *MemoryStore) PricesIn(priceList string) ([]ItemPrice, error) {
+	var prices []ItemPrice
+	for _, p := range s.Prices {
+		if p.PriceList == priceList {
+			prices = append(prices, p)
+		}
+	}
+	return prices, nil
+}
+
+// SaveItemPrices implements BulkStore. New prices are named IP-00001,
+// IP-00002, ...
+func (s *MemoryStore) SaveItemPrices(prices []ItemPrice) error {
+	for _, p := range prices {
+		if p.Name == "" {
+			s.next++
+			p.Name = fmt.Sprintf("IP-%05d", s.next)
+			s.Prices = append(s.Prices, p)
+			continue
+		}
+		i := slices.IndexFunc(s.Prices, func(q ItemPrice) bool { return q.Name == p.Name })
+		if i < 0 {
+			return fmt.Errorf("%w: item price %s", ErrNoItemPrice, p.Name)
+		}
+		s.Prices[i] = p
+	}
+	return nil
+}
+
+// ItemPrices implements Store.
+func (s *MemoryStore) ItemPrices(priceList, itemCode string) ([]ItemPrice, error) {
+	var prices []ItemPrice
+	for _, p := range s.Prices {
+		if p.PriceList == priceList && p.ItemCode == itemCode {
+			prices = append(prices, p)
+		}
+	}
+	return prices, nil
+}
@@ -0,0 +1,206 @@
+package pricelist
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/taxcalc"
+)
+
+func date(y int, m time.Month, d int) *time.Time {
+	t := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	return &t
+}
+
+func testStore() *MemoryStore {
+	return &MemoryStore{
+		PriceLists: map[string]*PriceList{
+			"Standard Selling": {Name: "Standard Selling", Currency: "INR", Selling: true, Enabled: true, PriceNotUOMDependent: true},
+			"Export USD":       {Name: "Export USD", Currency: "USD", Selling: true, Enabled: true},
+			"Standard Buying":  {Name: "Standard Buying", Currency: "INR", Buying: true, Enabled: true},
+			"Retired":          {Name: "Retired", Currency: "INR", Selling: true},
+		},
+		Prices: []ItemPrice{
+			{ItemCode: "WIDGET", PriceList: "Standard Selling", UOM: "Nos", PriceListRate: 100},
+			{ItemCode: "WIDGET", PriceList: "Standard Selling", UOM: "Nos", PriceListRate: 110, ValidFrom: date(2024, 4, 1)},
+			{ItemCode: "WIDGET", PriceList: "Standard Selling", UOM: "Nos", PriceListRate: 90, Customer: "Acme"},
+			{ItemCode: "WIDGET", PriceList: "Standard Selling", UOM: "Box", PriceListRate: 1000},
+			{ItemCode: "GADGET", PriceList: "Standard Selling", PriceListRate: 50, ValidUpto: date(2024, 3, 31)},
+			{ItemCode: "WIDGET", PriceList: "Export USD", UOM: "Nos", PriceListRate: 2},
+			{ItemCode: "WIDGET", PriceList: "Standard Buying", UOM: "Nos", PriceListRate: 70},
+			{ItemCode: "WIDGET", PriceList: "Standard Buying", UOM: "Nos", PriceListRate: 65, Supplier: "Parts Co"},
+		},
+	}
+}
+
+func TestRate(t *testing.T) {
+	r := &Resolver{Store: testStore()}
+	selling, _ := r.Store.GetPriceList("Standard Selling")
+	buying, _ := r.Store.GetPriceList("Standard Buying")
+	onDate := func(args Args, d *time.Time) Args { args.Date = *d; return args }
+
+	tests := []struct {
+		name    string
+		pl      *PriceList
+		args    Args
+		item    string
+		uom     string
+		factor  float64
+		want    float64
+		wantErr error
+	}{
+		{"before the new price", selling, onDate(Args{}, date(2024, 3, 15)), "WIDGET", "Nos", 1, 100, nil},
+		{"latest valid price", selling, onDate(Args{}, date(2024, 5, 1)), "WIDGET", "Nos", 1, 110, nil},
+		{"customer price", selling, onDate(Args{Customer: "Acme"}, date(2024, 5, 1)), "WIDGET", "Nos", 1, 90, nil},
+		{"other customer gets the general price", selling, onDate(Args{Customer: "Beta"}, date(2024, 5, 1)), "WIDGET", "Nos", 1, 110, nil},
+		{"UOM price", selling, Args{}, "WIDGET", "Box", 12, 1000, nil},
+		{"stock UOM price scaled", selling, Args{}, "GADGET", "Box", 12, 600, nil},
+		{"expired price", selling, onDate(Args{}, date(2024, 4, 1)), "GADGET", "Nos", 1, 0, ErrNoItemPrice},
+		{"supplier price", buying, Args{Buying: true, Supplier: "Parts Co"}, "WIDGET", "Nos", 1, 65, nil},
+	}
+	for _, tt := range tests {
+		got, err := r.Rate(tt.pl, tt.args, tt.item, tt.uom, "Nos", tt.factor)
+		if !errors.Is(err, tt.wantErr) || got != tt.want {
+			t.Errorf("%s: got %v, %v; want %v, %v", tt.name, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestApply(t *testing.T) {
+	r := &Resolver{Store: testStore()}
+	doc := &taxcalc.Document{
+		Currency: "INR", ConversionRate: 1,
+		Items: []*taxcalc.LineItem{
+			{ItemCode: "WIDGET", Qty: 2, UOM: "Nos", StockUOM: "Nos", ConversionFactor: 1},
+			{ItemCode: "WIDGET", Qty: 1, UOM: "Nos", StockUOM: "Nos", PriceListRate: 80}, // Priced by the caller
+			{ItemCode: "UNPRICED", Qty: 1},
+		},
+	}
+	args := Args{PriceList: "Standard Selling", Customer: "Acme", Date: *date(2024, 5, 1)}
+	if err := r.Apply(doc, args); err != nil {
+		t.Fatal(err)
+	}
+	if got := []float64{doc.Items[0].PriceListRate, doc.Items[1].PriceListRate, doc.Items[2].PriceListRate}; got[0] != 90 || got[1] != 80 || got[2] != 0 {
+		t.Errorf("price list rates = %v", got)
+	}
+
+	// The calculator derives the rate from the price list rate
+	doc.Items[0].DiscountPercentage = 10
+	if err := taxcalc.NewCalculator(doc, nil).Calculate(); err != nil {
+		t.Fatal(err)
+	}
+	if doc.Items[0].Rate != 81 {
+		t.Errorf("rate = %v, want 81", doc.Items[0].Rate)
+	}
+
+	// USD price list on an EUR invoice: 2 USD * 83 / 90
+	eur := &taxcalc.Document{Currency: "EUR", ConversionRate: 90, Items: []*taxcalc.LineItem{{ItemCode: "WIDGET", Qty: 1, UOM: "Nos", StockUOM: "Nos"}}}
+	export := Args{PriceList: "Export USD"}
+	if err := r.Apply(eur, export); !errors.Is(err, ErrNoExchangeRate) {
+		t.Errorf("missing rate: got %v", err)
+	}
+	export.PLCConversionRate = 83
+	if err := r.Apply(eur, export); err != nil || eur.Items[0].PriceListRate != 1.84 {
+		t.Errorf("converted rate = %v, %v", eur.Items[0].PriceListRate, err)
+	}
+}
+
+func TestPriceListChecks(t *testing.T) {
+	r := &Resolver{Store: testStore()}
+	tests := []struct {
+		args Args
+		want error
+	}{
+		{Args{PriceList: "Missing"}, ErrPriceListNotFound},
+		{Args{PriceList: "Retired"}, ErrPriceListDisabled},
+		{Args{PriceList: "Standard Buying"}, ErrWrongPriceListType},
+		{Args{PriceList: "Standard Selling", Buying: true}, ErrWrongPriceListType},
+	}
+	for _, tt := range tests {
+		if _, err := r.PriceList(tt.args); !errors.Is(err, tt.want) {
+			t.Errorf("%+v: got %v, want %v", tt.args, err, tt.want)
+		}
+	}
+}
+
+type fixedRates map[string]float64
+
+func (f fixedRates) ExchangeRate(from, to string, date time.Time) (float64, error) {
+	return f[from+to], nil
+}
+
+func namedStore() *MemoryStore {
+	store := testStore()
+	for i := range store.Prices {
+		store.Prices[i].Name = fmt.Sprintf("IP-%d", i+1)
+	}
+	return store
+}
+
+func TestUpdatePrices(t *testing.T) {
+	store := namedStore()
+	opts := BulkOptions{Filter: func(p ItemPrice) bool { return p.Customer == "" }, DryRun: true, User: "pricing@acme"}
+
+	audit, err := UpdatePrices(store, "Standard Selling", Percentage(5), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(audit.Changes) != 4 || !audit.DryRun || audit.User != "pricing@acme" {
+		t.Fatalf("audit = %+v", audit)
+	}
+	if c := audit.Changes[1]; c.ItemPrice != "IP-2" || c.OldRate != 110 || c.NewRate != 115.5 {
+		t.Errorf("change = %+v", c)
+	}
+	if store.Prices[0].PriceListRate != 100 {
+		t.Error("dry run saved prices")
+	}
+
+	opts.DryRun = false
+	if _, err := UpdatePrices(store, "Standard Selling", Percentage(5), opts); err != nil {
+		t.Fatal(err)
+	}
+	if store.Prices[0].PriceListRate != 105 || store.Prices[2].PriceListRate != 90 || store.Prices[5].PriceListRate != 2 {
+		t.Errorf("prices = %+v", store.Prices)
+	}
+
+	// A formula that leaves rates alone changes nothing
+	audit, _ = UpdatePrices(store, "Standard Selling", func(p ItemPrice) float64 { return p.PriceListRate }, BulkOptions{})
+	if len(audit.Changes) != 0 {
+		t.Errorf("unchanged rows audited: %+v", audit.Changes)
+	}
+}
+
+func TestRegenerate(t *testing.T) {
+	store := namedStore() // Export USD holds WIDGET/Nos at 2
+	rates := fixedRates{"INRUSD": 0.012}
+
+	audit, err := Regenerate(store, rates, "Standard Selling", "Export USD", BulkOptions{Date: *date(2024, 5, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Five base prices: the general WIDGET/Nos price updates IP-6, four are new
+	if len(audit.Changes) != 5 {
+		t.Fatalf("changes = %+v", audit.Changes)
+	}
+	if c := audit.Changes[0]; c.ItemPrice != "IP-6" || c.Created || c.OldRate != 2 || c.NewRate != 1.2 {
+		t.Errorf("update = %+v", c)
+	}
+	if c := audit.Changes[2]; !c.Created || c.Customer != "Acme" || c.NewRate != 1.08 {
+		t.Errorf("create = %+v", c)
+	}
+	usd, _ := store.PricesIn("Export USD")
+	if len(usd) != 5 {
+		t.Fatalf("Export USD has %d prices, want 5", len(usd))
+	}
+
+	// Rerunning at the same rate changes nothing
+	audit, _ = Regenerate(store, rates, "Standard Selling", "Export USD", BulkOptions{})
+	if len(audit.Changes) != 0 {
+		t.Errorf("second run changed %+v", audit.Changes)
+	}
+	if _, err := Regenerate(store, fixedRates{}, "Standard Selling", "Export USD", BulkOptions{}); !errors.Is(err, ErrNoExchangeRate) {
+		t.Errorf("missing rate: got %v", err)
+	}
+}
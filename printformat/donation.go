@@ -0,0 +1,49 @@
+package printformat
+
+import (
+	"github.com/senguttuvang/erpnext-go/donation"
+)
+
+// DonationReceiptTemplate returns the default donation receipt layout.
+// Receipts eligible under section 80G also print the organisation's
+// registration, making them the donor's certificate.
+func DonationReceiptTemplate() *Template {
+	return &Template{
+		Title: "Donation Receipt {{.Number}}",
+		Header: []string{
+			"Received from: {{.Donor.DonorName}}",
+			`{{range $i, $line := .Donor.Address}}{{if $i}}, {{end}}{{$line}}{{end}}`,
+			"{{if .Donor.IDNumber}}{{.Donor.IDType}}: {{.Donor.IDNumber}}{{end}}",
+			"Date: {{date .Date}}{{if .ReferenceNo}}    Reference: {{.ReferenceNo}}{{end}}",
+			"{{if .Eligible80G}}Eligible for deduction under section 80G, URN {{.Organization.Registration80G}}" +
+				"{{if not .Organization.RegistrationDate.IsZero}} dated {{date .Organization.RegistrationDate}}{{end}}{{end}}",
+		},
+		Columns: []Column{
+			{Label: "Donation", Field: "donation", Width: 18},
+			{Label: "Purpose", Field: "purpose", Width: 16},
+			{Label: "Mode of Receipt", Field: "mode", Width: 26},
+			{Label: "Amount ({{.Currency}})", Field: "amount", Width: 16, Align: AlignRight},
+		},
+		Totals: []string{"Total ({{.Currency}})|{{number .Amount}}"},
+		Footer: "{{.Organization.Name}}{{if .Organization.PAN}} - PAN {{.Organization.PAN}}{{end}}" +
+			"{{if .Organization.CharityReference}} - Charity reference {{.Organization.CharityReference}}{{end}}",
+	}
+}
+
+// RenderDonationReceiptPDF renders a donation receipt as PDF.
+//
+// Maps to: frappe.get_print("Donation", name, as_pdf=True)
+func RenderDonationReceiptPDF(r *donation.Receipt, opts Options) ([]byte, error) {
+	tmpl := opts.Template
+	if tmpl == nil {
+		tmpl = DonationReceiptTemplate()
+	}
+	purpose := string(r.Purpose)
+	if r.Fund != "" {
+		purpose += " (" + r.Fund + ")"
+	}
+	rows := []map[string]string{{
+		"donation": r.Donation, "purpose": purpose, "mode": string(r.Mode), "amount": opts.Locale.FormatNumber(r.Amount),
+	}}
+	return render(tmpl, r, rows, opts)
+}
@@ -0,0 +1,56 @@
+package printformat
+
+import (
+	"strconv"
+
+	"github.com/senguttuvang/erpnext-go/salesinvoice"
+)
+
+// InvoiceTemplate returns the default sales invoice layout. Invoices in a
+// foreign currency also print the grand total in company currency.
+func InvoiceTemplate() *Template {
+	return &Template{
+		Title: "Sales Invoice {{.Name}}",
+		Header: []string{
+			"Customer: {{.Customer}}",
+			"Date: {{date .PostingDate}}{{if .DueDate}}    Due: {{date .DueDate}}{{end}}",
+		},
+		Columns: []Column{
+			{Label: "#", Field: "idx", Width: 4, Align: AlignRight},
+			{Label: "Item", Field: "item_code", Width: 16},
+			{Label: "Description", Field: "description", Width: 30},
+			{Label: "Qty", Field: "qty", Width: 8, Align: AlignRight},
+			{Label: "Rate ({{.Currency}})", Field: "rate", Width: 14, Align: AlignRight},
+			{Label: "Amount ({{.Currency}})", Field: "amount", Width: 16, Align: AlignRight},
+		},
+		Totals: []string{
+			"Net Total|{{number .NetTotal}}",
+			"{{range .Taxes}}{{.Description}}|{{number .TaxAmountAfterDiscountAmount}}\n{{end}}",
+			"{{if .DiscountAmount}}Discount|-{{number .DiscountAmount}}{{end}}",
+			"Grand Total ({{.Currency}})|{{number .GrandTotal}}",
+			"{{if .RoundedTotal}}Rounded Total|{{number .RoundedTotal}}{{end}}",
+			"{{if and .CompanyCurrency (ne .Currency .CompanyCurrency)}}Grand Total ({{.CompanyCurrency}})|{{number .BaseGrandTotal}}{{end}}",
+		},
+		Footer: "{{.Company}}{{if .Remarks}} - {{.Remarks}}{{end}}",
+	}
+}
+
+// RenderInvoicePDF renders a calculated sales invoice as PDF.
+//
+// Maps to: frappe.get_print("Sales Invoice", name, as_pdf=True)
+func RenderInvoicePDF(inv *salesinvoice.SalesInvoice, opts Options) ([]byte, error) {
+	tmpl := opts.Template
+	if tmpl == nil {
+		tmpl = InvoiceTemplate()
+	}
+	loc := opts.Locale
+	rows := make([]map[string]string, len(inv.Items))
+	for i, item := range inv.Items {
+		rows[i] = map[string]string{
+			"idx": strconv.Itoa(i + 1), "item_code": item.ItemCode, "description": item.Description,
+			"qty": strconv.FormatFloat(item.Qty, 'f', -1, 64), "uom": item.UOM,
+			"rate": loc.FormatNumber(item.Rate), "amount": loc.FormatNumber(item.Amount),
+		}
+	}
+	return render(tmpl, inv, rows, opts)
+}
@@ -0,0 +1,106 @@
+package printformat
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// NumberFormat is a Frappe number format: the grouping and separators
+// used to print amounts.
+type NumberFormat string
+
+const (
+	NumberFormatDefault  NumberFormat = "#,###.##"
+	NumberFormatEuropean NumberFormat = "#.###,##"
+	NumberFormatSpace    NumberFormat = "# ###.##"
+	NumberFormatSwiss    NumberFormat = "#'###.##"
+	NumberFormatIndian   NumberFormat = "#,##,###.##" // Lakh and crore grouping
+)
+
+// DateFormat is a Frappe date format such as "dd-mm-yyyy".
+type DateFormat string
+
+const (
+	DateFormatISO DateFormat = "yyyy-mm-dd"
+	DateFormatDMY DateFormat = "dd-mm-yyyy"
+	DateFormatMDY DateFormat = "mm/dd/yyyy"
+)
+
+// Locale controls how numbers and dates are printed.
+//
+// Maps to: System Settings (number_format, date_format, currency_precision)
+type Locale struct {
+	NumberFormat NumberFormat // NumberFormatDefault when empty
+	DateFormat   DateFormat   // DateFormatDMY when empty
+	Precision    int          // Decimals of amounts; 2 when zero
+}
+
+// FormatNumber prints a number with the locale's grouping and separators.
+//
+// Maps to: fmt_money() / number_format() in frappe/utils/data.py
+func (l Locale) FormatNumber(v float64) string {
+	precision := l.Precision
+	if precision <= 0 {
+		precision = 2
+	}
+	group, decimal := ",", "."
+	switch l.NumberFormat {
+	case NumberFormatEuropean:
+		group, decimal = ".", ","
+	case NumberFormatSpace:
+		group = " "
+	case NumberFormatSwiss:
+		group = "'"
+	}
+
+	text := strconv.FormatFloat(math.Abs(v), 'f', precision, 64)
+	whole, fraction, _ := strings.Cut(text, ".")
+	var b strings.Builder
+	if v < 0 && strings.Trim(text, "0.") != "" {
+		b.WriteByte('-')
+	}
+	b.WriteString(groupDigits(whole, group, l.NumberFormat == NumberFormatIndian))
+	if fraction != "" {
+		b.WriteString(decimal)
+		b.WriteString(fraction)
+	}
+	return b.String()
+}
+
+// groupDigits separates the digits of a whole number into groups of
+// three, or for Indian grouping a group of three then groups of two.
+func groupDigits(digits, sep string, indian bool) string {
+	if len(digits) <= 3 {
+		return digits
+	}
+	head, tail := digits[:len(digits)-3], digits[len(digits)-3:]
+	size := 3
+	if indian {
+		size = 2
+	}
+	var groups []string
+	for len(head) > size {
+		groups = append([]string{head[len(head)-size:]}, groups...)
+		head = head[:len(head)-size]
+	}
+	groups = append([]string{head}, groups...)
+	return strings.Join(append(groups, tail), sep)
+}
+
+// FormatDate prints a date in the locale's format. The zero time prints as
+// an empty string.
+//
+// Maps to: formatdate() in frappe/utils/data.py
+func (l Locale) FormatDate(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	format := l.DateFormat
+	if format == "" {
+		format = DateFormatDMY
+	}
+	layout := strings.NewReplacer("yyyy", "2006", "mm", "01", "dd", "02").Replace(string(format))
+	return t.Format(layout)
+}
@@ -0,0 +1,226 @@
+package printformat
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"image/color"
+	"image/jpeg"
+	"strings"
+)
+
+// Page sizes in points.
+var (
+	A4     = PageSize{Width: 595.28, Height: 841.89}
+	Letter = PageSize{Width: 612, Height: 792}
+)
+
+// PageSize is a page's width and height in points (1/72 inch).
+type PageSize struct {
+	Width, Height float64
+}
+
+// pdfDoc is a minimal PDF 1.4 writer: the standard Helvetica fonts with
+// WinAnsi encoding, lines, filled rectangles and JPEG images.
+type pdfDoc struct {
+	size   PageSize
+	pages  []*pdfPage
+	images []pdfImage
+}
+
+type pdfImage struct {
+	data          []byte
+	width, height int
+	colorSpace    string
+}
+
+// pdfPage collects a page's content stream. Coordinates passed to its
+// methods are measured from the top left corner.
+type pdfPage struct {
+	height float64
+	buf    bytes.Buffer
+}
+
+func (d *pdfDoc) addPage() *pdfPage {
+	p := &pdfPage{height: d.size.Height}
+	d.pages = append(d.pages, p)
+	return p
+}
+
+// addJPEG registers a JPEG image and returns its index.
+func (d *pdfDoc) addJPEG(data []byte) (int, error) {
+	cfg, err := jpeg.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return 0, fmt.Errorf("logo: %w", err)
+	}
+	colorSpace := "/DeviceRGB"
+	switch cfg.ColorModel {
+	case color.GrayModel:
+		colorSpace = "/DeviceGray"
+	case color.CMYKModel:
+		colorSpace = "/DeviceCMYK"
+	}
+	d.images = append(d.images, pdfImage{data: data, width: cfg.Width, height: cfg.Height, colorSpace: colorSpace})
+	return len(d.images) - 1, nil
+}
+
+func (p *pdfPage) text(x, y, size float64, bold bool, s string) {
+	font := "F1"
+	if bold {
+		font = "F2"
+	}
+	fmt.Fprintf(&p.buf, "BT /%s %.2f Tf %.2f %.2f Td (%s) Tj ET\n", font, size, x, p.height-y, pdfString(s))
+}
+
+func (p *pdfPage) line(x1, y1, x2, y2, width float64) {
+	fmt.Fprintf(&p.buf, "%.2f w %.2f %.2f m %.2f %.2f l S\n", width, x1, p.height-y1, x2, p.height-y2)
+}
+
+func (p *pdfPage) fillRect(x, y, w, h, gray float64) {
+	fmt.Fprintf(&p.buf, "q %.2f g %.2f %.2f %.2f %.2f re f Q\n", gray, x, p.height-y-h, w, h)
+}
+
+func (p *pdfPage) image(index int, x, y, w, h float64) {
+	fmt.Fprintf(&p.buf, "q %.2f 0 0 %.2f %.2f %.2f cm /Im%d Do Q\n", w, h, x, p.height-y-h, index)
+}
+
+// bytes serializes the document.
+func (d *pdfDoc) bytes() []byte {
+	var out bytes.Buffer
+	var offsets []int
+	obj := func(body string, stream []byte) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s", len(offsets), body)
+		if stream != nil {
+			out.WriteString("\nstream\n")
+			out.Write(stream)
+			out.WriteString("\nendstream")
+		}
+		out.WriteString("\nendobj\n")
+	}
+
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	// Objects 1-4: catalog, page tree, fonts. Images follow, then each
+	// page and its content stream.
+	firstImage := 5
+	firstPage := firstImage + len(d.images)
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", firstPage+2*i)
+	}
+	obj("<< /Type /Catalog /Pages 2 0 R >>", nil)
+	obj(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)), nil)
+	obj("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>", nil)
+	obj("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>", nil)
+
+	var xobjects []string
+	for i, img := range d.images {
+		obj(fmt.Sprintf("<< /Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace %s /BitsPerComponent 8 /Filter /DCTDecode /Length %d >>",
+			img.width, img.height, img.colorSpace, len(img.data)), img.data)
+		xobjects = append(xobjects, fmt.Sprintf("/Im%d %d 0 R", i, firstImage+i))
+	}
+	resources := "<< /Font << /F1 3 0 R /F2 4 0 R >>"
+	if len(xobjects) > 0 {
+		resources += " /XObject << " + strings.Join(xobjects, " ") + " >>"
+	}
+	resources += " >>"
+
+	for i, p := range d.pages {
+		obj(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.2f %.2f] /Resources %s /Contents %d 0 R >>",
+			d.size.Width, d.size.Height, resources, firstPage+2*i+1), nil)
+		content := deflate(p.buf.Bytes())
+		obj(fmt.Sprintf("<< /Length %d /Filter /FlateDecode >>", len(content)), content)
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	return out.Bytes()
+}
+
+func deflate(data []byte) []byte {
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+	zw.Write(data)
+	zw.Close()
+	return buf.Bytes()
+}
+
+// pdfString encodes text as a WinAnsi string literal body. Characters
+// outside WinAnsi become "?".
+func pdfString(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		c, ok := winAnsi(r)
+		if !ok {
+			c = '?'
+		}
+		switch c {
+		case '\\', '(', ')':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		default:
+			if c < 32 || c > 126 {
+				fmt.Fprintf(&b, "\\%03o", c)
+			} else {
+				b.WriteByte(c)
+			}
+		}
+	}
+	return b.String()
+}
+
+// winAnsiExtra maps the non-Latin-1 characters of WinAnsiEncoding that
+// statements use.
+var winAnsiExtra = map[rune]byte{'€': 0x80, '‘': 0x91, '’': 0x92, '“': 0x93, '”': 0x94, '–': 0x96, '—': 0x97}
+
+func winAnsi(r rune) (byte, bool) {
+	if c, ok := winAnsiExtra[r]; ok {
+		return c, true
+	}
+	if r >= 32 && r <= 126 || r >= 160 && r <= 255 {
+		return byte(r), true
+	}
+	return 0, false
+}
+
+// textWidth returns the width of text in points at a font size.
+func textWidth(s string, size float64, bold bool) float64 {
+	widths := &helvetica
+	if bold {
+		widths = &helveticaBold
+	}
+	var units int
+	for _, r := range s {
+		if r >= 32 && r <= 126 {
+			units += int(widths[r-32])
+		} else {
+			units += 556
+		}
+	}
+	return float64(units) * size / 1000
+}
+
+// Glyph widths of characters 32-126 in 1/1000 em, from the Adobe font
+// metrics of the standard fonts.
+var helvetica = [95]uint16{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+}
+
+var helveticaBold = [95]uint16{
+	278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611,
+	975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556,
+	333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611,
+	611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584,
+}
@@ -0,0 +1,274 @@
+package printformat
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/donation"
+	"github.com/senguttuvang/erpnext-go/ledger"
+	"github.com/senguttuvang/erpnext-go/party"
+	"github.com/senguttuvang/erpnext-go/salesinvoice"
+	"github.com/senguttuvang/erpnext-go/taxcalc"
+)
+
+func day(y int, m time.Month, d int) time.Time {
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+func TestLocale(t *testing.T) {
+	tests := []struct {
+		loc  Locale
+		v    float64
+		want string
+	}{
+		{Locale{}, 1234567.891, "1,234,567.89"},
+		{Locale{NumberFormat: NumberFormatEuropean}, -1234.5, "-1.234,50"},
+		{Locale{NumberFormat: NumberFormatIndian}, 12345678.9, "1,23,45,678.90"},
+		{Locale{NumberFormat: NumberFormatSpace, Precision: 3}, 1000, "1 000.000"},
+		{Locale{NumberFormat: NumberFormatSwiss}, 999.999, "1'000.00"},
+		{Locale{}, -0.001, "0.00"},
+	}
+	for _, tt := range tests {
+		if got := tt.loc.FormatNumber(tt.v); got != tt.want {
+			t.Errorf("%+v.FormatNumber(%v) = %q, want %q", tt.loc, tt.v, got, tt.want)
+		}
+	}
+
+	d := day(2024, 4, 5)
+	for format, want := range map[DateFormat]string{"": "05-04-2024", DateFormatISO: "2024-04-05", DateFormatMDY: "04/05/2024", "dd.mm.yyyy": "05.04.2024"} {
+		if got := (Locale{DateFormat: format}).FormatDate(d); got != want {
+			t.Errorf("FormatDate(%q) = %q, want %q", format, got, want)
+		}
+	}
+}
+
+func statementEntries() []ledger.GLEntry {
+	entry := func(d time.Time, no string, debit, credit, rate float64) ledger.GLEntry {
+		return ledger.GLEntry{
+			PostingDate: d, Account: "Debtors USD - ACME", AccountCurrency: "USD", PartyType: "Customer", Party: "Globex",
+			VoucherType: "Sales Invoice", VoucherNo: no, Debit: debit * rate, Credit: credit * rate,
+			DebitInAccountCurrency: debit, CreditInAccountCurrency: credit, Remarks: "Order " + no,
+		}
+	}
+	cancelled := entry(day(2024, 4, 20), "SINV-9", 500, 0, 83)
+	cancelled.IsCancelled = true
+	return []ledger.GLEntry{
+		entry(day(2024, 4, 10), "SINV-2", 100, 0, 83),
+		entry(day(2024, 3, 1), "SINV-1", 1000, 0, 82),
+		entry(day(2024, 4, 15), "PE-1", 0, 600, 83),
+		cancelled,
+		entry(day(2024, 4, 20), "SINV-9", 0, 500, 83), // Its reversal, kept live
+		entry(day(2024, 5, 2), "SINV-3", 50, 0, 83),
+	}
+}
+
+func TestBuildStatement(t *testing.T) {
+	s := BuildStatement(statementEntries(), "Customer", "Globex", day(2024, 4, 1), day(2024, 4, 30), ledger.ReportFlags{})
+	if s.Opening != 82000 || s.OpeningInAccountCurrency != 1000 {
+		t.Errorf("opening = %v / %v", s.Opening, s.OpeningInAccountCurrency)
+	}
+	if len(s.Lines) != 2 || s.Lines[0].VoucherNo != "SINV-2" || s.Lines[1].Balance != 82000+8300-49800 {
+		t.Fatalf("lines = %+v", s.Lines)
+	}
+	if s.ClosingInAccountCurrency != 500 || s.Closing != 40500 || s.AccountCurrency != "USD" {
+		t.Errorf("closing = %v / %v %s", s.Closing, s.ClosingInAccountCurrency, s.AccountCurrency)
+	}
+
+	// An opening invoice dated within the period is carried in the opening
+	// balance unless opening entries are included
+	entries := statementEntries()
+	entries[0].IsOpening = ledger.IsOpeningYes
+	s = BuildStatement(entries, "Customer", "Globex", day(2024, 4, 1), day(2024, 4, 30), ledger.ReportFlags{})
+	if s.Opening != 90300 || len(s.Lines) != 1 || s.Closing != 40500 {
+		t.Errorf("opening excluded: opening %v, %d lines, closing %v", s.Opening, len(s.Lines), s.Closing)
+	}
+	s = BuildStatement(entries, "Customer", "Globex", day(2024, 4, 1), day(2024, 4, 30), ledger.ReportFlags{IncludeOpening: true})
+	if s.Opening != 82000 || len(s.Lines) != 2 {
+		t.Errorf("opening included: opening %v, %d lines", s.Opening, len(s.Lines))
+	}
+}
+
+// pdfText checks the structure of a PDF and returns its decompressed page
+// content.
+func pdfText(t *testing.T, data []byte) string {
+	t.Helper()
+	if !bytes.HasPrefix(data, []byte("%PDF-1.4")) || !bytes.HasSuffix(data, []byte("%%EOF\n")) {
+		t.Fatal("not a PDF")
+	}
+	m := regexp.MustCompile(`startxref\n(\d+)`).FindSubmatch(data)
+	xref, _ := strconv.Atoi(string(m[1]))
+	if !bytes.HasPrefix(data[xref:], []byte("xref\n")) {
+		t.Fatal("startxref does not point at the xref table")
+	}
+	offsets := regexp.MustCompile(`(\d{10}) 00000 n`).FindAllSubmatch(data[xref:], -1)
+	for i, o := range offsets {
+		off, _ := strconv.Atoi(string(o[1]))
+		if !bytes.HasPrefix(data[off:], []byte(fmt.Sprintf("%d 0 obj", i+1))) {
+			t.Fatalf("xref entry %d points at %q", i+1, data[off:off+10])
+		}
+	}
+
+	var text strings.Builder
+	for _, m := range regexp.MustCompile(`(?s)/FlateDecode >>\nstream\n(.*?)\nendstream`).FindAllSubmatch(data, -1) {
+		zr, err := zlib.NewReader(bytes.NewReader(m[1]))
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, _ := io.ReadAll(zr)
+		text.Write(content)
+	}
+	return text.String()
+}
+
+func TestRenderStatementPDF(t *testing.T) {
+	s := BuildStatement(statementEntries(), "Customer", "Globex", day(2024, 4, 1), day(2024, 4, 30), ledger.ReportFlags{})
+	s.Company, s.Currency, s.PartyName = "ACME India", "INR", "Globex (Europe)"
+	s.PartyAddress = []string{"1 Main St", "Berlin"}
+
+	var logo bytes.Buffer
+	if err := jpeg.Encode(&logo, image.NewRGBA(image.Rect(0, 0, 40, 20)), nil); err != nil {
+		t.Fatal(err)
+	}
+	opts := Options{
+		Letterhead: Letterhead{CompanyName: "ACME India Pvt Ltd", Lines: []string{"GSTIN 33AAACA1234A1Z5"}, Logo: logo.Bytes()},
+		Locale:     Locale{NumberFormat: NumberFormatIndian, DateFormat: "dd/mm/yyyy"},
+	}
+	data, err := RenderStatementPDF(s, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	text := pdfText(t, data)
+	for _, want := range []string{
+		"(ACME India Pvt Ltd)", "(Statement of Account)", "(Customer: Globex \\(Europe\\))", "(1 Main St, Berlin)",
+		"(Period: 01/04/2024 to 30/04/2024)", "(Debit)", "(\\(USD\\))", "(Balance)", "(\\(INR\\))",
+		"(82,000.00)", "(40,500.00)", "(Closing Balance \\(USD\\))", "(10/04/2024)", "(Page 1 of 1)", "/Im0 Do",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("PDF text lacks %s", want)
+		}
+	}
+	if !bytes.Contains(data, []byte("/Filter /DCTDecode")) {
+		t.Error("logo not embedded")
+	}
+
+	// Single-currency statements print one set of amounts
+	s.AccountCurrency = "INR"
+	data, _ = RenderStatementPDF(s, Options{})
+	if text := pdfText(t, data); strings.Contains(text, "(\\(USD\\))") || !strings.Contains(text, "(82,000.00)") {
+		t.Error("single-currency statement has account currency columns")
+	}
+
+	// Long statements continue on further pages
+	for i := range 120 {
+		s.Lines = append(s.Lines, StatementLine{PostingDate: day(2024, 4, 30), VoucherType: "Journal Entry", VoucherNo: fmt.Sprint("JV-", i)})
+	}
+	data, _ = RenderStatementPDF(s, Options{PageSize: Letter})
+	if text := pdfText(t, data); !strings.Contains(text, "(Page 3 of 3)") || strings.Count(text, "(Voucher)") != 3 {
+		t.Errorf("pagination: pages %q", regexp.MustCompile(`Page \d of \d`).FindAllString(text, -1))
+	}
+
+	if _, err := RenderStatementPDF(s, Options{Template: &Template{Title: "{{.Missing}}"}}); err == nil {
+		t.Error("bad template rendered")
+	}
+}
+
+func TestRenderInvoicePDF(t *testing.T) {
+	due := day(2024, 5, 5)
+	inv := &salesinvoice.SalesInvoice{
+		Name: "SINV-0001", Company: "ACME", Customer: "Globex", PostingDate: day(2024, 4, 5), DueDate: &due,
+		CompanyCurrency: "INR",
+		Document: taxcalc.Document{
+			Currency: "EUR", ConversionRate: 90,
+			Items: []*taxcalc.LineItem{{ItemCode: "WIDGET", Description: "Widget, blue – large", Qty: 2, PriceListRate: 50}},
+			Taxes: []*taxcalc.TaxRow{{Description: "VAT 19%", ChargeType: taxcalc.OnNetTotal, Rate: 19}},
+		},
+	}
+	if err := inv.Calculate(nil); err != nil {
+		t.Fatal(err)
+	}
+	data, err := RenderInvoicePDF(inv, Options{Locale: Locale{NumberFormat: NumberFormatEuropean}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	text := pdfText(t, data)
+	for _, want := range []string{
+		"(Sales Invoice SINV-0001)", "(Date: 05-04-2024    Due: 05-05-2024)", "(Widget, blue \\226 large)",
+		"(Rate \\(EUR\\))", "(100,00)", "(VAT 19%)", "(19,00)", "(Grand Total \\(EUR\\))", "(119,00)",
+		"(Grand Total \\(INR\\))", "(10.710,00)",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("PDF text lacks %s", want)
+		}
+	}
+}
+
+type overduePolicy party.OverduePolicy
+
+func (p *overduePolicy) OverduePolicy(party.PartyType, string) (*party.OverduePolicy, error) {
+	return (*party.OverduePolicy)(p), nil
+}
+
+func TestStatementOverdueInterest(t *testing.T) {
+	s := BuildStatement(statementEntries(), "Customer", "Globex", day(2024, 4, 1), day(2024, 4, 30), ledger.ReportFlags{})
+	s.Currency, s.AccountCurrency = "INR", "INR"
+	due, later := day(2024, 3, 31), day(2024, 6, 1)
+	invoice := func(d time.Time, no string, amount float64, due *time.Time) ledger.PaymentLedgerEntry {
+		return ledger.PaymentLedgerEntry{PostingDate: d, PartyType: "Customer", Party: "Globex", VoucherType: "Sales Invoice", VoucherNo: no,
+			AgainstVoucherType: "Sales Invoice", AgainstVoucherNo: no, Amount: amount, DueDate: due}
+	}
+	entries := []ledger.PaymentLedgerEntry{
+		invoice(day(2024, 3, 1), "SINV-1", 1000, &due),
+		{PostingDate: day(2024, 4, 15), PartyType: "Customer", Party: "Globex", VoucherType: "Payment Entry", VoucherNo: "PE-1",
+			AgainstVoucherType: "Sales Invoice", AgainstVoucherNo: "SINV-1", Amount: -400},
+		invoice(day(2024, 4, 10), "SINV-2", 100, &later), // Not yet due
+		invoice(day(2024, 5, 2), "SINV-3", 50, &due),     // After the statement
+	}
+	if err := s.AddOverdueInterest(entries, &overduePolicy{InterestRate: 12}); err != nil {
+		t.Fatal(err)
+	}
+	if len(s.Overdue) != 1 || s.Overdue[0].Invoice.VoucherNo != "SINV-1" || s.OverdueInterest != 5.92 {
+		t.Fatalf("overdue %+v, interest %v", s.Overdue, s.OverdueInterest)
+	}
+	data, err := RenderStatementPDF(s, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text := pdfText(t, data); !strings.Contains(text, "(Overdue Interest \\(INR\\))") || !strings.Contains(text, "(5.92)") {
+		t.Error("PDF lacks the overdue interest")
+	}
+}
+
+func TestRenderDonationReceiptPDF(t *testing.T) {
+	d := &donation.Donation{
+		Name: "DTN-0001", Company: "Seva", Donor: "Meera Iyer", Date: day(2024, 5, 1), Amount: 5000,
+		Mode: donation.Electronic, Purpose: donation.Corpus, Fund: "Schools", ReceiptNo: "NPO-RCPT-2024-25-00001",
+	}
+	donor := &donation.Donor{Name: "Meera Iyer", DonorName: "Meera Iyer", Address: []string{"12 MG Road", "Pune"}, IDType: donation.PAN, IDNumber: "ABCPI1234K"}
+	org := donation.Organization{Company: "Seva", Name: "Seva Trust", PAN: "AAATS0001A", Registration80G: "AAATS0001AF20214", RegistrationDate: day(2021, 5, 28)}
+	r, err := donation.NewReceipt(d, donor, org, "INR")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := RenderDonationReceiptPDF(r, Options{Locale: Locale{NumberFormat: NumberFormatIndian}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	text := pdfText(t, data)
+	for _, want := range []string{
+		"(Donation Receipt NPO-RCPT-2024-25-00001)", "(PAN: ABCPI1234K)", "(Corpus \\(Schools\\))", "(5,000.00)",
+		"(Eligible for deduction under section 80G, URN AAATS0001AF20214 dated 28-05-2021)", "(Seva Trust - PAN AAATS0001A)",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("PDF text lacks %s", want)
+		}
+	}
+}
@@ -0,0 +1,203 @@
+// Package printformat renders statements of account, invoices and donation
+// receipts as PDF.
+//
+// Documents are laid out by a Template: a title, header lines, a table and
+// totals, all text/template sources, under the company's Letterhead.
+// Numbers and dates follow a Locale. When a party's account is in a
+// foreign currency, statements print both currencies side by side.
+//
+// Migrated from: erpnext/accounts/doctype/process_statement_of_accounts/
+// and frappe/utils/pdf.py
+package printformat
+
+import (
+	"slices"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/daterange"
+	"github.com/senguttuvang/erpnext-go/ledger"
+	"github.com/senguttuvang/erpnext-go/party"
+)
+
+// Statement is a party's statement of account for a period.
+//
+// Maps to: the General Ledger report grouped by party, as printed by
+// process_statement_of_accounts.py
+type Statement struct {
+	Company         string
+	Currency        string // Company currency
+	PartyType       string
+	Party           string
+	PartyName       string
+	PartyAddress    []string
+	AccountCurrency string // Party account currency; empty means Currency
+	From, To        time.Time
+
+	Opening, Closing                                   float64 // Company currency
+	OpeningInAccountCurrency, ClosingInAccountCurrency float64
+
+	Lines []StatementLine
+
+	// Set by AddOverdueInterest
+	Overdue         []party.OverdueInvoice
+	OverdueInterest float64 // Company currency
+}
+
+// StatementLine is one posting on a statement.
+type StatementLine struct {
+	PostingDate time.Time
+	VoucherType string
+	VoucherNo   string
+	Remarks     string
+
+	Debit, Credit, Balance float64 // Company currency
+
+	DebitInAccountCurrency   float64
+	CreditInAccountCurrency  float64
+	BalanceInAccountCurrency float64
+}
+
+// MultiCurrency reports whether the party account is in a foreign currency.
+func (s *Statement) MultiCurrency() bool {
+	return s.AccountCurrency != "" && s.AccountCurrency != s.Currency
+}
+
+// BuildStatement builds a statement from a party's GL entries. Entries
+// before From make up the opening balance; entries after To, cancelled
+// entries and their reversals are ignored. Opening and period closing entries within the
+// period are listed only if flags include them; otherwise they are carried
+// in the opening balance, which they still belong to. Company, Currency
+// and PartyName are left for the caller.
+func BuildStatement(entries []ledger.GLEntry, partyType, party string, from, to time.Time, flags ledger.ReportFlags) *Statement {
+	s := &Statement{PartyType: partyType, Party: party, PartyName: party, From: from, To: to}
+	period := daterange.Inclusive(from, to)
+	var lines []ledger.GLEntry
+	cancelled := ledger.CancelledEntries(entries)
+	for i, e := range entries {
+		if cancelled[i] || e.PartyType != partyType || e.Party != party || period.Compare(e.PostingDate) > 0 {
+			continue
+		}
+		if s.AccountCurrency == "" {
+			s.AccountCurrency = e.AccountCurrency
+		}
+		if period.Compare(e.PostingDate) < 0 || !flags.Counts(&e) {
+			s.Opening += e.Debit - e.Credit
+			s.OpeningInAccountCurrency += e.DebitInAccountCurrency - e.CreditInAccountCurrency
+			continue
+		}
+		lines = append(lines, e)
+	}
+	slices.SortStableFunc(lines, func(a, b ledger.GLEntry) int { return a.PostingDate.Compare(b.PostingDate) })
+
+	balance, accountBalance := s.Opening, s.OpeningInAccountCurrency
+	for _, e := range lines {
+		balance += e.Debit - e.Credit
+		accountBalance += e.DebitInAccountCurrency - e.CreditInAccountCurrency
+		s.Lines = append(s.Lines, StatementLine{
+			PostingDate: e.PostingDate, VoucherType: e.VoucherType, VoucherNo: e.VoucherNo, Remarks: e.Remarks,
+			Debit: e.Debit, Credit: e.Credit, Balance: ledger.Flt(balance, 2),
+			DebitInAccountCurrency: e.DebitInAccountCurrency, CreditInAccountCurrency: e.CreditInAccountCurrency,
+			BalanceInAccountCurrency: ledger.Flt(accountBalance, 2),
+		})
+	}
+	s.Opening, s.OpeningInAccountCurrency = ledger.Flt(s.Opening, 2), ledger.Flt(s.OpeningInAccountCurrency, 2)
+	s.Closing, s.ClosingInAccountCurrency = ledger.Flt(balance, 2), ledger.Flt(accountBalance, 2)
+	return s
+}
+
+// AddOverdueInterest lists the party's invoices overdue at the end of the
+// statement period under its overdue policy, with the interest they bear,
+// from the payment ledger entries. The interest is informational: it is
+// not in the closing balance until an interest invoice is raised.
+//
+// Maps to: the interest printed on dunning letters from dunning.py
+func (s *Statement) AddOverdueInterest(entries []ledger.PaymentLedgerEntry, policies party.OverduePolicies) error {
+	var own []ledger.PaymentLedgerEntry
+	for _, e := range entries {
+		if e.PartyType == s.PartyType && e.Party == s.Party && !daterange.Civil(e.PostingDate).After(daterange.Civil(s.To)) {
+			own = append(own, e)
+		}
+	}
+	overdue, err := party.OverdueInvoices(own, s.To, policies)
+	if err != nil {
+		return err
+	}
+	s.Overdue, s.OverdueInterest = overdue, 0
+	for _, o := range overdue {
+		s.OverdueInterest += o.Interest
+	}
+	s.OverdueInterest = ledger.Flt(s.OverdueInterest, 2)
+	return nil
+}
+
+// StatementTemplate returns the default statement layout. Multi-currency
+// statements get debit, credit and balance columns in both currencies.
+func StatementTemplate(multiCurrency bool) *Template {
+	t := &Template{
+		Title: "Statement of Account",
+		Header: []string{
+			"{{.PartyType}}: {{.PartyName}}",
+			`{{range $i, $line := .PartyAddress}}{{if $i}}, {{end}}{{$line}}{{end}}`,
+			"Period: {{date .From}} to {{date .To}}",
+		},
+		Columns: []Column{
+			{Label: "Date", Field: "date", Width: 10},
+			{Label: "Voucher", Field: "voucher", Width: 18},
+			{Label: "Remarks", Field: "remarks", Width: 12},
+		},
+		Totals: []string{"Closing Balance ({{.Currency}})|{{number .Closing}}"},
+		Footer: "{{.Company}}",
+	}
+	if multiCurrency {
+		t.Columns = append(t.Columns,
+			Column{Label: "Debit ({{.AccountCurrency}})", Field: "debit_account", Width: 10, Align: AlignRight},
+			Column{Label: "Credit ({{.AccountCurrency}})", Field: "credit_account", Width: 10, Align: AlignRight},
+			Column{Label: "Balance ({{.AccountCurrency}})", Field: "balance_account", Width: 10, Align: AlignRight},
+		)
+		t.Totals = append(t.Totals, "Closing Balance ({{.AccountCurrency}})|{{number .ClosingInAccountCurrency}}")
+	}
+	t.Columns = append(t.Columns,
+		Column{Label: "Debit ({{.Currency}})", Field: "debit", Width: 10, Align: AlignRight},
+		Column{Label: "Credit ({{.Currency}})", Field: "credit", Width: 10, Align: AlignRight},
+		Column{Label: "Balance ({{.Currency}})", Field: "balance", Width: 10, Align: AlignRight},
+	)
+	t.Totals = append(t.Totals, "{{if .OverdueInterest}}Overdue Interest ({{.Currency}})|{{number .OverdueInterest}}{{end}}")
+	return t
+}
+
+// RenderStatementPDF renders a statement of account as PDF.
+//
+// Maps to: get_report_pdf() in process_statement_of_accounts.py
+func RenderStatementPDF(s *Statement, opts Options) ([]byte, error) {
+	tmpl := opts.Template
+	if tmpl == nil {
+		tmpl = StatementTemplate(s.MultiCurrency())
+	}
+	loc := opts.Locale
+	amount := func(v float64) string {
+		if v == 0 {
+			return ""
+		}
+		return loc.FormatNumber(v)
+	}
+
+	rows := make([]map[string]string, 0, len(s.Lines)+2)
+	rows = append(rows, map[string]string{
+		"remarks": "Opening Balance", "balance": loc.FormatNumber(s.Opening),
+		"balance_account": loc.FormatNumber(s.OpeningInAccountCurrency), boldRow: "1",
+	})
+	for _, line := range s.Lines {
+		rows = append(rows, map[string]string{
+			"date": loc.FormatDate(line.PostingDate), "voucher_type": line.VoucherType, "voucher_no": line.VoucherNo,
+			"voucher": line.VoucherType + " " + line.VoucherNo, "remarks": line.Remarks,
+			"debit": amount(line.Debit), "credit": amount(line.Credit), "balance": loc.FormatNumber(line.Balance),
+			"debit_account": amount(line.DebitInAccountCurrency), "credit_account": amount(line.CreditInAccountCurrency),
+			"balance_account": loc.FormatNumber(line.BalanceInAccountCurrency),
+		})
+	}
+	rows = append(rows, map[string]string{
+		"remarks": "Closing Balance", "balance": loc.FormatNumber(s.Closing),
+		"balance_account": loc.FormatNumber(s.ClosingInAccountCurrency), boldRow: "1",
+	})
+	return render(tmpl, s, rows, opts)
+}
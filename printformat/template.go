@@ -0,0 +1,318 @@
+package printformat
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Letterhead is the branding printed at the top of every page.
+//
+// Maps to: Letter Head doctype
+type Letterhead struct {
+	CompanyName string
+	Lines       []string // Address, phone, tax ID
+	Logo        []byte   // JPEG image; optional
+}
+
+// Align is the horizontal alignment of a table column.
+type Align int
+
+const (
+	AlignLeft Align = iota
+	AlignRight
+)
+
+// Column is a column of the printed table.
+type Column struct {
+	Label string  // Template, e.g. "Debit ({{.Currency}})"
+	Field string  // Key of the cell in each row
+	Width float64 // Relative to the other columns
+	Align Align
+}
+
+// Template lays out a document: a title, header lines, a table, total
+// lines and a footer. Text is a text/template source executed with the
+// document being printed; the functions "date" (time.Time or *time.Time)
+// and "number" format values with the Locale.
+//
+// Maps to: Print Format doctype (Jinja templates rendered by
+// frappe.get_print)
+type Template struct {
+	Title   string
+	Header  []string // Printed under the title
+	Columns []Column
+	Totals  []string // Printed right-aligned under the table as "Label|Value" lines
+	Footer  string   // Printed at the bottom of every page
+}
+
+// Options controls rendering.
+type Options struct {
+	Letterhead Letterhead
+	Locale     Locale
+	PageSize   PageSize  // A4 when zero
+	Template   *Template // Replaces the default template when set
+}
+
+// Layout in points
+const (
+	margin       = 40.0
+	rowHeight    = 14.0
+	bodySize     = 9.0
+	minSize      = 6.0 // Smallest size amounts shrink to
+	headerSize   = 10.0
+	titleSize    = 14.0
+	companySize  = 16.0
+	logoHeight   = 48.0
+	footerHeight = 30.0
+	cellPadding  = 3.0
+)
+
+// render executes a template against data and lays out rows.
+func render(tmpl *Template, data any, rows []map[string]string, opts Options) ([]byte, error) {
+	size := opts.PageSize
+	if size.Width == 0 {
+		size = A4
+	}
+	funcs := template.FuncMap{
+		"number": opts.Locale.FormatNumber,
+		"date": func(v any) string {
+			switch t := v.(type) {
+			case time.Time:
+				return opts.Locale.FormatDate(t)
+			case *time.Time:
+				if t != nil {
+					return opts.Locale.FormatDate(*t)
+				}
+			}
+			return ""
+		},
+	}
+	execute := func(name, text string) (string, error) {
+		t, err := template.New(name).Funcs(funcs).Parse(text)
+		if err != nil {
+			return "", fmt.Errorf("print format %s: %w", name, err)
+		}
+		var buf bytes.Buffer
+		if err := t.Execute(&buf, data); err != nil {
+			return "", fmt.Errorf("print format %s: %w", name, err)
+		}
+		return buf.String(), nil
+	}
+	// A text producing several lines prints them all; empty lines are
+	// dropped, so optional fields can be wrapped in {{if}}.
+	executeAll := func(name string, texts []string) ([]string, error) {
+		var out []string
+		for i, text := range texts {
+			result, err := execute(fmt.Sprintf("%s %d", name, i+1), text)
+			if err != nil {
+				return nil, err
+			}
+			for _, line := range strings.Split(result, "\n") {
+				if strings.TrimSpace(line) != "" {
+					out = append(out, line)
+				}
+			}
+		}
+		return out, nil
+	}
+
+	title, err := execute("title", tmpl.Title)
+	if err != nil {
+		return nil, err
+	}
+	header, err := executeAll("header", tmpl.Header)
+	if err != nil {
+		return nil, err
+	}
+	totals, err := executeAll("totals", tmpl.Totals)
+	if err != nil {
+		return nil, err
+	}
+	footer, err := execute("footer", tmpl.Footer)
+	if err != nil {
+		return nil, err
+	}
+	labels := make([]string, len(tmpl.Columns))
+	for i, c := range tmpl.Columns {
+		if labels[i], err = execute("column "+c.Field, c.Label); err != nil {
+			return nil, err
+		}
+	}
+
+	l := &layout{doc: &pdfDoc{size: size}, size: size, letterhead: opts.Letterhead, logo: -1}
+	if len(opts.Letterhead.Logo) > 0 {
+		if l.logo, err = l.doc.addJPEG(opts.Letterhead.Logo); err != nil {
+			return nil, err
+		}
+	}
+	l.columns(tmpl.Columns)
+
+	l.newPage()
+	l.page.text(margin, l.y+titleSize, titleSize, true, title)
+	l.y += titleSize + 10
+	for _, line := range header {
+		l.page.text(margin, l.y+headerSize, headerSize, false, line)
+		l.y += headerSize + 4
+	}
+	l.y += 8
+
+	l.tableHeader(tmpl.Columns, labels)
+	for _, row := range rows {
+		if l.y+rowHeight > l.bottom() {
+			l.newPage()
+			l.tableHeader(tmpl.Columns, labels)
+		}
+		bold := row[boldRow] != ""
+		for i, c := range tmpl.Columns {
+			l.cell(row[c.Field], i, c.Align, bold)
+		}
+		l.y += rowHeight
+	}
+	l.page.line(margin, l.y, size.Width-margin, l.y, 0.5)
+	l.y += 6
+
+	for _, line := range totals {
+		if l.y+rowHeight > l.bottom() {
+			l.newPage()
+		}
+		label, value := cut(line)
+		right := size.Width - margin
+		l.page.text(right-150-textWidth(label, headerSize, true), l.y+headerSize, headerSize, true, label)
+		l.page.text(right-textWidth(value, headerSize, false), l.y+headerSize, headerSize, false, value)
+		l.y += rowHeight
+	}
+
+	for i, p := range l.doc.pages {
+		y := size.Height - margin + 10
+		p.line(margin, y-12, size.Width-margin, y-12, 0.25)
+		if footer != "" {
+			p.text(margin, y, bodySize-1, false, footer)
+		}
+		pageNo := fmt.Sprintf("Page %d of %d", i+1, len(l.doc.pages))
+		p.text(size.Width-margin-textWidth(pageNo, bodySize-1, false), y, bodySize-1, false, pageNo)
+	}
+	return l.doc.bytes(), nil
+}
+
+// boldRow marks a row printed in bold, such as opening and closing
+// balances.
+const boldRow = "\x00bold"
+
+// cut splits a total line at its last "|".
+func cut(line string) (label, value string) {
+	if i := strings.LastIndexByte(line, '|'); i >= 0 {
+		return line[:i], line[i+1:]
+	}
+	return line, ""
+}
+
+// layout tracks the write position while pages are filled.
+type layout struct {
+	doc        *pdfDoc
+	page       *pdfPage
+	size       PageSize
+	letterhead Letterhead
+	logo       int // Image index, or -1
+	y          float64
+	x, widths  []float64
+}
+
+func (l *layout) bottom() float64 {
+	return l.size.Height - margin - footerHeight
+}
+
+// columns computes column positions from their relative widths.
+func (l *layout) columns(cols []Column) {
+	var total float64
+	for _, c := range cols {
+		total += max(c.Width, 1)
+	}
+	x := margin
+	for _, c := range cols {
+		w := max(c.Width, 1) / total * (l.size.Width - 2*margin)
+		l.x = append(l.x, x)
+		l.widths = append(l.widths, w)
+		x += w
+	}
+}
+
+// newPage starts a page with the letterhead.
+func (l *layout) newPage() {
+	l.page = l.doc.addPage()
+	l.y = margin
+	x := margin
+	height := companySize + 4 + float64(len(l.letterhead.Lines))*(bodySize+2)
+	if l.logo >= 0 {
+		img := l.doc.images[l.logo]
+		w := logoHeight * float64(img.width) / float64(img.height)
+		l.page.image(l.logo, margin, l.y, w, logoHeight)
+		x += w + 12
+		height = max(height, logoHeight)
+	}
+	l.page.text(x, l.y+companySize, companySize, true, l.letterhead.CompanyName)
+	ly := l.y + companySize + 4
+	for _, line := range l.letterhead.Lines {
+		l.page.text(x, ly+bodySize, bodySize, false, line)
+		ly += bodySize + 2
+	}
+	l.y += height + 8
+	l.page.line(margin, l.y, l.size.Width-margin, l.y, 1)
+	l.y += 12
+}
+
+func (l *layout) tableHeader(cols []Column, labels []string) {
+	// Labels too wide for their column wrap onto a second line
+	lines := make([][2]string, len(cols))
+	height := rowHeight
+	for i, label := range labels {
+		lines[i][0] = label
+		if textWidth(label, bodySize, true) > l.widths[i]-2*cellPadding {
+			if j := strings.LastIndexByte(label, ' '); j > 0 {
+				lines[i] = [2]string{label[:j], label[j+1:]}
+				height = 2 * rowHeight
+			}
+		}
+	}
+	l.page.fillRect(margin, l.y, l.size.Width-2*margin, height+2, 0.9)
+	for i, c := range cols {
+		l.cell(lines[i][0], i, c.Align, true)
+		if lines[i][1] != "" {
+			l.y += rowHeight
+			l.cell(lines[i][1], i, c.Align, true)
+			l.y -= rowHeight
+		}
+	}
+	l.y += height + 2
+}
+
+// cell writes text into a column of the current row. Right-aligned text
+// (amounts) is shrunk to fit; other text is cut.
+func (l *layout) cell(text string, col int, align Align, bold bool) {
+	width := l.widths[col] - 2*cellPadding
+	size := bodySize
+	x := l.x[col] + cellPadding
+	if align == AlignRight {
+		for size > minSize && textWidth(text, size, bold) > width {
+			size -= 0.5
+		}
+		x = l.x[col] + l.widths[col] - cellPadding - textWidth(text, size, bold)
+	} else {
+		text = fit(text, width, bold)
+	}
+	l.page.text(x, l.y+bodySize+2, size, bold, text)
+}
+
+// fit shortens text with "..." until it fits width.
+func fit(text string, width float64, bold bool) string {
+	if textWidth(text, bodySize, bold) <= width {
+		return text
+	}
+	runes := []rune(text)
+	for len(runes) > 0 && textWidth(string(runes)+"...", bodySize, bold) > width {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + "..."
+}
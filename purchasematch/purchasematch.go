@@ -0,0 +1,250 @@
+// Package purchasematch runs the three-way match of a purchase invoice
+// against the purchase order it bills and the purchase receipts that
+// delivered it, before the invoice is posted.
+//
+// Each invoice line is matched to its order line. Its quantity, with the
+// quantity billed by earlier invoices, must not exceed the quantity
+// received, and its rate must not exceed the order rate, each within a
+// percentage tolerance. Receipts must not exceed the order either. The
+// Policy decides whether a mismatch blocks posting or only warns; the
+// Report lists every line either way, for the buyer or the approver.
+//
+// Maps to: the over-billing and over-receipt allowances of Accounts and
+// Stock Settings (over_billing_allowance, over_delivery_receipt_allowance),
+// the "maintain same rate" check of Buying Settings and
+// validate_po_pr_against_invoice in
+// erpnext/accounts/doctype/purchase_invoice/purchase_invoice.py
+package purchasematch
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/senguttuvang/erpnext-go/ledger"
+)
+
+// Match errors
+var (
+	ErrMismatch      = errors.New("purchase invoice does not match its order and receipts")
+	ErrInvalidPolicy = errors.New("invalid three-way match policy")
+)
+
+// Action is what a mismatch does to the invoice.
+type Action string
+
+const (
+	Warn  Action = "Warn"  // Post, and keep the report
+	Block Action = "Block" // Refuse to post
+)
+
+// Tolerance is how far, in percent, a document may exceed the one before
+// it.
+type Tolerance struct {
+	Qty   float64 // Billed over received, and received over ordered
+	Price float64 // Invoice rate over order rate
+}
+
+// Policy configures the match.
+type Policy struct {
+	Tolerance  Tolerance
+	Items      map[string]Tolerance // Per item code, replacing Tolerance
+	OnMismatch Action               // Block when empty
+}
+
+// Validate checks the policy.
+func (p *Policy) Validate() error {
+	if p.OnMismatch != "" && p.OnMismatch != Warn && p.OnMismatch != Block {
+		return fmt.Errorf("%w: unknown action %q", ErrInvalidPolicy, p.OnMismatch)
+	}
+	for item, t := range p.Items {
+		if t.Qty < 0 || t.Price < 0 {
+			return fmt.Errorf("%w: %s: negative tolerance", ErrInvalidPolicy, item)
+		}
+	}
+	if p.Tolerance.Qty < 0 || p.Tolerance.Price < 0 {
+		return fmt.Errorf("%w: negative tolerance", ErrInvalidPolicy)
+	}
+	return nil
+}
+
+// tolerance returns the tolerance of an item.
+func (p *Policy) tolerance(itemCode string) Tolerance {
+	if t, ok := p.Items[itemCode]; ok {
+		return t
+	}
+	return p.Tolerance
+}
+
+// OrderLine is a purchase order item.
+type OrderLine struct {
+	Name     string // Row name, which receipt and invoice lines refer to
+	Order    string
+	ItemCode string
+	Qty      float64
+	Rate     float64
+	Billed   float64 // Quantity billed by submitted invoices
+}
+
+// ReceiptLine is a purchase receipt item delivered against an order line.
+type ReceiptLine struct {
+	Receipt   string
+	OrderLine string
+	Qty       float64 // Accepted quantity
+}
+
+// InvoiceLine is a purchase invoice item billing an order line.
+type InvoiceLine struct {
+	OrderLine string
+	ItemCode  string
+	Qty       float64
+	Rate      float64
+}
+
+// Status is the outcome of matching a line.
+type Status string
+
+const (
+	Matched       Status = "Matched"
+	OverBilled    Status = "Billed More Than Received"
+	OverReceived  Status = "Received More Than Ordered"
+	PriceVariance Status = "Rate Above Order"
+	NotReceived   Status = "Not Received"
+	NotOrdered    Status = "Not Ordered"
+)
+
+// LineMatch is the match of one invoice line.
+type LineMatch struct {
+	OrderLine string
+	Order     string
+	ItemCode  string
+
+	Ordered  float64
+	Received float64
+	Billed   float64 // By earlier invoices
+	Invoiced float64
+
+	OrderRate   float64
+	InvoiceRate float64
+
+	Statuses []Status // Matched alone when the line matches
+}
+
+// Matched reports whether the line is within tolerance.
+func (m *LineMatch) Matched() bool {
+	return len(m.Statuses) == 1 && m.Statuses[0] == Matched
+}
+
+// Report is the three-way match of an invoice.
+type Report struct {
+	Invoice string
+	Action  Action // What the policy does with a mismatch
+	Lines   []LineMatch
+}
+
+// Matched reports whether every line matches.
+func (r *Report) Matched() bool {
+	for i := range r.Lines {
+		if !r.Lines[i].Matched() {
+			return false
+		}
+	}
+	return true
+}
+
+// Blocked reports whether the invoice may not be posted.
+func (r *Report) Blocked() bool {
+	return r.Action == Block && !r.Matched()
+}
+
+// Match compares the lines of an invoice with the order lines they bill
+// and the receipts against those order lines. Quantities and rates are
+// compared after rounding to the precision of the documents, two places
+// for rates and three for quantities. An invoice line without an order
+// line is NotOrdered.
+//
+// Python equivalent:
+//
+//	validate_po_pr_against_invoice(); validate_multiple_billing()
+func Match(policy *Policy, invoice string, lines []InvoiceLine, orders []OrderLine, receipts []ReceiptLine) (*Report, error) {
+	if err := policy.Validate(); err != nil {
+		return nil, err
+	}
+	byName := make(map[string]*OrderLine, len(orders))
+	for i := range orders {
+		byName[orders[i].Name] = &orders[i]
+	}
+	received := map[string]float64{}
+	for _, r := range receipts {
+		received[r.OrderLine] += r.Qty
+	}
+	invoiced := map[string]float64{}
+	for _, l := range lines {
+		invoiced[l.OrderLine] += l.Qty
+	}
+
+	report := &Report{Invoice: invoice, Action: Block}
+	if policy.OnMismatch != "" {
+		report.Action = policy.OnMismatch
+	}
+	for _, l := range lines {
+		m := LineMatch{OrderLine: l.OrderLine, ItemCode: l.ItemCode, Invoiced: l.Qty, InvoiceRate: l.Rate}
+		o, ok := byName[l.OrderLine]
+		if !ok {
+			m.Statuses = []Status{NotOrdered}
+			report.Lines = append(report.Lines, m)
+			continue
+		}
+		m.Order, m.Ordered, m.OrderRate, m.Billed = o.Order, o.Qty, o.Rate, o.Billed
+		m.Received = ledger.Flt(received[o.Name], 3)
+		t := policy.tolerance(o.ItemCode)
+
+		switch billed := ledger.Flt(o.Billed+invoiced[o.Name], 3); {
+		case m.Received == 0:
+			m.Statuses = append(m.Statuses, NotReceived)
+		case billed > ledger.Flt(m.Received*(1+t.Qty/100), 3):
+			m.Statuses = append(m.Statuses, OverBilled)
+		}
+		if m.Received > ledger.Flt(o.Qty*(1+t.Qty/100), 3) {
+			m.Statuses = append(m.Statuses, OverReceived)
+		}
+		if ledger.Flt(l.Rate, 2) > ledger.Flt(o.Rate*(1+t.Price/100), 2) {
+			m.Statuses = append(m.Statuses, PriceVariance)
+		}
+		if len(m.Statuses) == 0 {
+			m.Statuses = []Status{Matched}
+		}
+		report.Lines = append(report.Lines, m)
+	}
+	return report, nil
+}
+
+// Check matches an invoice as Match does and returns ErrMismatch, with
+// the report, when the policy blocks it. Call it before posting the
+// invoice; with Warn the report is returned with a nil error and the
+// caller keeps it with the invoice.
+func Check(policy *Policy, invoice string, lines []InvoiceLine, orders []OrderLine, receipts []ReceiptLine) (*Report, error) {
+	report, err := Match(policy, invoice, lines, orders, receipts)
+	if err != nil {
+		return nil, err
+	}
+	if report.Blocked() {
+		var mismatched []string
+		for _, m := range report.Lines {
+			if !m.Matched() {
+				mismatched = append(mismatched, fmt.Sprintf("%s (%s)", cmp.Or(m.OrderLine, m.ItemCode), joinStatuses(m.Statuses)))
+			}
+		}
+		return report, fmt.Errorf("%w: %s: %s", ErrMismatch, invoice, strings.Join(mismatched, ", "))
+	}
+	return report, nil
+}
+
+func joinStatuses(statuses []Status) string {
+	s := make([]string, len(statuses))
+	for i, st := range statuses {
+		s[i] = string(st)
+	}
+	return strings.Join(s, "; ")
+}
@@ -0,0 +1,66 @@
+package purchasematch
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/senguttuvang/erpnext-go/reportio"
+)
+
+func TestMatch(t *testing.T) {
+	orders := []OrderLine{
+		{Name: "PO-1/1", Order: "PO-1", ItemCode: "Bolt", Qty: 100, Rate: 2},
+		{Name: "PO-1/2", Order: "PO-1", ItemCode: "Nut", Qty: 50, Rate: 1, Billed: 20},
+		{Name: "PO-1/3", Order: "PO-1", ItemCode: "Washer", Qty: 10, Rate: 0.5},
+	}
+	receipts := []ReceiptLine{
+		{Receipt: "PR-1", OrderLine: "PO-1/1", Qty: 60},
+		{Receipt: "PR-2", OrderLine: "PO-1/1", Qty: 40},
+		{Receipt: "PR-1", OrderLine: "PO-1/2", Qty: 50},
+	}
+	lines := []InvoiceLine{
+		{OrderLine: "PO-1/1", ItemCode: "Bolt", Qty: 100, Rate: 2.04}, // 2% over the order rate
+		{OrderLine: "PO-1/2", ItemCode: "Nut", Qty: 31, Rate: 1},      // 51 billed, 50 received
+		{OrderLine: "PO-1/3", ItemCode: "Washer", Qty: 10, Rate: 0.5},
+		{ItemCode: "Freight", Qty: 1, Rate: 30},
+	}
+	policy := &Policy{Tolerance: Tolerance{Qty: 2, Price: 5}, Items: map[string]Tolerance{"Bolt": {Price: 1}}}
+
+	report, err := Check(policy, "PINV-1", lines, orders, receipts)
+	if !errors.Is(err, ErrMismatch) || report == nil || !report.Blocked() {
+		t.Fatalf("Check() = %v, %v", report, err)
+	}
+	want := []Status{PriceVariance, Matched, NotReceived, NotOrdered}
+	for i, m := range report.Lines {
+		if len(m.Statuses) != 1 || m.Statuses[0] != want[i] {
+			t.Errorf("line %d: %v, want %v", i, m.Statuses, want[i])
+		}
+	}
+	if m := report.Lines[1]; m.Received != 50 || m.Billed != 20 || m.Invoiced != 31 {
+		t.Errorf("nut line %+v", m)
+	}
+
+	// Without the quantity tolerance the nut line is over-billed
+	policy.Tolerance.Qty = 0
+	report, _ = Match(policy, "PINV-1", lines[1:2], orders, receipts)
+	if s := report.Lines[0].Statuses; len(s) != 1 || s[0] != OverBilled {
+		t.Errorf("nut line %v", s)
+	}
+
+	// Warn posts, and keeps the report
+	policy.OnMismatch = Warn
+	report, err = Check(policy, "PINV-1", lines, orders, receipts)
+	if err != nil || report.Matched() || report.Blocked() {
+		t.Errorf("Check() with Warn = %v, %v", report, err)
+	}
+	var rows int
+	if err := report.Rows(func([]any) error { rows++; return nil }); err != nil || rows != 4 {
+		t.Errorf("%d rows, %v", rows, err)
+	}
+	var _ reportio.Report = report
+
+	policy.OnMismatch = "Hold"
+	if _, err := Match(policy, "PINV-1", lines, orders, receipts); !errors.Is(err, ErrInvalidPolicy) {
+		t.Errorf("Match() = %v", err)
+	}
+}
@@ -0,0 +1,36 @@
+package purchasematch
+
+import (
+	"github.com/senguttuvang/erpnext-go/reportio"
+)
+
+// Title is the name the report is exported under.
+func (r *Report) Title() string { return "Three-Way Match: " + r.Invoice }
+
+// Columns implements reportio.Report.
+func (r *Report) Columns() []reportio.Column {
+	return []reportio.Column{
+		{Fieldname: "purchase_order", Label: "Purchase Order", Fieldtype: reportio.Link, Options: "Purchase Order"},
+		{Fieldname: "po_detail", Label: "Order Line", Fieldtype: reportio.Data},
+		{Fieldname: "item_code", Label: "Item", Fieldtype: reportio.Link, Options: "Item"},
+		{Fieldname: "ordered_qty", Label: "Ordered Qty", Fieldtype: reportio.Float},
+		{Fieldname: "received_qty", Label: "Received Qty", Fieldtype: reportio.Float},
+		{Fieldname: "billed_qty", Label: "Previously Billed Qty", Fieldtype: reportio.Float},
+		{Fieldname: "qty", Label: "Invoiced Qty", Fieldtype: reportio.Float},
+		{Fieldname: "po_rate", Label: "Order Rate", Fieldtype: reportio.Currency},
+		{Fieldname: "rate", Label: "Invoice Rate", Fieldtype: reportio.Currency},
+		{Fieldname: "status", Label: "Status", Fieldtype: reportio.Data},
+	}
+}
+
+// Rows implements reportio.Report with a row per invoice line.
+func (r *Report) Rows(fn func([]any) error) error {
+	for _, m := range r.Lines {
+		row := []any{m.Order, m.OrderLine, m.ItemCode, m.Ordered, m.Received, m.Billed, m.Invoiced,
+			m.OrderRate, m.InvoiceRate, joinStatuses(m.Statuses)}
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
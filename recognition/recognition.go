@@ -0,0 +1,265 @@
+// Package recognition recognises the revenue of project contracts as the
+// work is done, rather than as it is billed.
+//
+// A project's invoices credit a deferred revenue account. A Contract
+// earns its value either by percentage of completion, as progress is
+// measured, or by milestones, as each is delivered. Recognize posts the
+// difference between what the contract has earned and what was
+// recognised before from deferred revenue to income; when progress is
+// revised down, the same voucher runs the other way. Revenue earned ahead
+// of billing leaves a debit on the deferred account, the unbilled
+// contract asset; billing ahead of the work leaves a credit, the contract
+// liability. Reconcile reports both per contract.
+//
+// Maps to: the Deferred Revenue journal entries of
+// erpnext/accounts/deferred_revenue.py (book_deferred_income_or_expense),
+// driven by project progress instead of service dates, as in IFRS 15
+// revenue over time
+package recognition
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/ledger"
+)
+
+// Voucher type and subtype of recognition postings
+const (
+	VoucherType    = "Journal Entry"
+	VoucherSubtype = "Deferred Revenue"
+)
+
+// Recognition errors
+var (
+	ErrInvalidContract = errors.New("invalid contract")
+	ErrNotConfigured   = errors.New("recognizer is missing its engine or GL entries")
+)
+
+// Method is how a contract earns its value.
+type Method string
+
+const (
+	PercentOfCompletion Method = "Percentage of Completion"
+	Milestones          Method = "Milestones"
+)
+
+// Milestone is a deliverable of a contract and the revenue it earns.
+type Milestone struct {
+	Name      string
+	Amount    float64
+	Delivered time.Time // Zero until delivered
+}
+
+// Progress is the completion of a contract measured on a date.
+type Progress struct {
+	Date    time.Time
+	Percent float64
+}
+
+// Contract is a customer project whose revenue is recognised over time.
+type Contract struct {
+	Name     string
+	Company  string
+	Customer string
+	Project  string // Project set on the invoices and the recognition entries
+	Method   Method
+	Value    float64 // Total revenue of the contract
+
+	DeferredAccount string // Credited by the project's invoices
+	IncomeAccount   string
+	CostCenter      string
+
+	Milestones []Milestone // Milestones method; their amounts add up to Value
+	Progress   []Progress  // Percentage of completion method, in date order
+}
+
+// Validate checks the contract's accounts, method and schedule.
+func (c *Contract) Validate() error {
+	switch {
+	case c.Name == "" || c.Company == "" || c.Project == "":
+		return fmt.Errorf("%w: name, company and project are required", ErrInvalidContract)
+	case c.DeferredAccount == "" || c.IncomeAccount == "":
+		return fmt.Errorf("%w: %s: deferred revenue and income accounts are required", ErrInvalidContract, c.Name)
+	case c.Value <= 0:
+		return fmt.Errorf("%w: %s: value must be greater than zero", ErrInvalidContract, c.Name)
+	}
+	switch c.Method {
+	case PercentOfCompletion:
+		for i, p := range c.Progress {
+			if p.Percent < 0 || p.Percent > 100 {
+				return fmt.Errorf("%w: %s: progress of %v%%", ErrInvalidContract, c.Name, p.Percent)
+			}
+			if i > 0 && p.Date.Before(c.Progress[i-1].Date) {
+				return fmt.Errorf("%w: %s: progress is not in date order", ErrInvalidContract, c.Name)
+			}
+		}
+	case Milestones:
+		var total float64
+		for _, m := range c.Milestones {
+			if m.Amount <= 0 {
+				return fmt.Errorf("%w: %s: milestone %s has no amount", ErrInvalidContract, c.Name, m.Name)
+			}
+			total += m.Amount
+		}
+		if ledger.Flt(total, 2) != ledger.Flt(c.Value, 2) {
+			return fmt.Errorf("%w: %s: milestones add up to %.2f, not %.2f", ErrInvalidContract, c.Name, total, c.Value)
+		}
+	default:
+		return fmt.Errorf("%w: %s: unknown method %q", ErrInvalidContract, c.Name, c.Method)
+	}
+	return nil
+}
+
+// Complete returns the percentage of the contract complete on date: the
+// latest progress measured by then, or the share of the value of the
+// milestones delivered.
+func (c *Contract) Complete(date time.Time) float64 {
+	date = ledger.CivilDate(date)
+	if c.Method == Milestones {
+		return ledger.Flt(c.Earned(date)/c.Value*100, 2)
+	}
+	var percent float64
+	for _, p := range c.Progress {
+		if ledger.CivilDate(p.Date).After(date) {
+			break
+		}
+		percent = p.Percent
+	}
+	return percent
+}
+
+// Earned returns the revenue the contract has earned by date.
+func (c *Contract) Earned(date time.Time) float64 {
+	date = ledger.CivilDate(date)
+	if c.Method == PercentOfCompletion {
+		return ledger.Flt(c.Value*c.Complete(date)/100, 2)
+	}
+	var earned float64
+	for _, m := range c.Milestones {
+		if !m.Delivered.IsZero() && !ledger.CivilDate(m.Delivered).After(date) {
+			earned += m.Amount
+		}
+	}
+	return ledger.Flt(earned, 2)
+}
+
+// Amounts are a contract's billing and recognition on a date.
+type Amounts struct {
+	Billed     float64 // Credited to deferred revenue by the project's invoices
+	Recognized float64 // Moved to income by recognition vouchers
+}
+
+// Measure sums the billing and recognition of a contract in GL entries up
+// to date. Cancelled entries do not count.
+func (c *Contract) Measure(gl []ledger.GLEntry, date time.Time) Amounts {
+	date = ledger.CivilDate(date)
+	var a Amounts
+	cancelled := ledger.CancelledEntries(gl)
+	for i, e := range gl {
+		if cancelled[i] || e.Company != c.Company || e.Project != c.Project || ledger.CivilDate(e.PostingDate).After(date) {
+			continue
+		}
+		switch {
+		case e.VoucherSubtype == VoucherSubtype && e.Account == c.IncomeAccount:
+			a.Recognized += e.Credit - e.Debit
+		case e.VoucherSubtype != VoucherSubtype && e.Account == c.DeferredAccount:
+			a.Billed += e.Credit - e.Debit
+		}
+	}
+	a.Billed, a.Recognized = ledger.Flt(a.Billed, 2), ledger.Flt(a.Recognized, 2)
+	return a
+}
+
+// Posting is a recognition voucher posted.
+type Posting struct {
+	VoucherNo string
+	Date      time.Time
+	Amount    float64 // Recognised; negative when revenue was reversed
+}
+
+// Recognizer posts the recognition of contracts.
+type Recognizer struct {
+	Engine *ledger.Engine
+	GL     func() []ledger.GLEntry // GL entries to measure, such as memstore.GLStore.All
+}
+
+// RecognitionVoucher returns the voucher number of a contract's
+// recognition on date.
+func RecognitionVoucher(c *Contract, date time.Time) string {
+	return c.Name + "/" + date.Format(ledger.DateLayout)
+}
+
+// Recognize posts the revenue a contract earned by date and has not yet
+// recognised, debiting deferred revenue and crediting income, or the
+// other way when progress was revised down. It returns nil when there is
+// nothing to post or the voucher of date is already posted.
+//
+// Maps to: book_revenue_via_journal_entry() in deferred_revenue.py
+func (r *Recognizer) Recognize(c *Contract, date time.Time) (*Posting, error) {
+	if r.Engine == nil || r.GL == nil {
+		return nil, ErrNotConfigured
+	}
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	date = ledger.CivilDate(date)
+	voucherNo := RecognitionVoucher(c, date)
+	entries, err := r.Engine.GLStore.GetByVoucher(VoucherType, voucherNo)
+	if err != nil {
+		return nil, err
+	}
+	for _, cancelled := range ledger.CancelledEntries(entries) {
+		if !cancelled {
+			return nil, nil
+		}
+	}
+
+	amount := ledger.Flt(c.Earned(date)-c.Measure(r.GL(), date).Recognized, 2)
+	if amount == 0 {
+		return nil, nil
+	}
+	remarks := fmt.Sprintf("Revenue of %s at %.2f%% complete", c.Name, c.Complete(date))
+	glMap, err := c.glMap(date, voucherNo, amount, remarks)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.Engine.MakeGLEntries(glMap, ledger.DefaultPostingOptions()); err != nil {
+		return nil, fmt.Errorf("%s: %w", voucherNo, err)
+	}
+	return &Posting{VoucherNo: voucherNo, Date: date, Amount: amount}, nil
+}
+
+// RecognizeAll recognises every contract on date. Errors are collected;
+// one failing contract does not stop the others.
+func (r *Recognizer) RecognizeAll(contracts []*Contract, date time.Time) ([]Posting, error) {
+	var posted []Posting
+	var errs []error
+	for _, c := range contracts {
+		p, err := r.Recognize(c, date)
+		if err != nil {
+			errs = append(errs, err)
+		} else if p != nil {
+			posted = append(posted, *p)
+		}
+	}
+	return posted, errors.Join(errs...)
+}
+
+// glMap returns the entries of a recognition voucher moving amount from
+// deferred revenue to income, or back when amount is negative.
+func (c *Contract) glMap(date time.Time, voucherNo string, amount float64, remarks string) ([]ledger.GLEntry, error) {
+	glMap, err := ledger.NewEntryBuilder().
+		Company(c.Company).PostingDate(date).ForVoucher(VoucherType, voucherNo).Remarks(remarks).
+		Account(c.DeferredAccount).Debit(max(amount, 0)).Credit(max(-amount, 0)).CostCenter(c.CostCenter).Project(c.Project).
+		Account(c.IncomeAccount).Debit(max(-amount, 0)).Credit(max(amount, 0)).CostCenter(c.CostCenter).Project(c.Project).
+		Build()
+	if err != nil {
+		return nil, err
+	}
+	for i := range glMap {
+		glMap[i].VoucherSubtype = VoucherSubtype
+	}
+	return glMap, nil
+}
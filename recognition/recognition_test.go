@@ -0,0 +1,184 @@
+package recognition
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/ledger"
+	"github.com/senguttuvang/erpnext-go/ledger/memstore"
+	"github.com/senguttuvang/erpnext-go/reportio"
+)
+
+const (
+	deferred = "Deferred Revenue - ACME"
+	income   = "Service Income - ACME"
+)
+
+func date(m time.Month, d int) time.Time {
+	return time.Date(2024, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// bill posts a project invoice crediting deferred revenue.
+func bill(t *testing.T, engine *ledger.Engine, name, project string, amount float64, on time.Time) {
+	t.Helper()
+	glMap := []ledger.GLEntry{
+		{PostingDate: on, Account: "Debtors - ACME", PartyType: "Customer", Party: "Tata Motors", VoucherType: "Sales Invoice", VoucherNo: name,
+			Company: "ACME", Project: project, Debit: amount, DebitInAccountCurrency: amount},
+		{PostingDate: on, Account: deferred, VoucherType: "Sales Invoice", VoucherNo: name,
+			Company: "ACME", Project: project, Credit: amount, CreditInAccountCurrency: amount},
+	}
+	if err := engine.MakeGLEntries(glMap, ledger.DefaultPostingOptions()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func balance(gl []ledger.GLEntry, account, project string) float64 {
+	var b float64
+	for _, e := range gl {
+		if e.Account == account && e.Project == project {
+			b += e.Debit - e.Credit
+		}
+	}
+	return ledger.Flt(b, 2)
+}
+
+func TestPercentOfCompletion(t *testing.T) {
+	glStore := memstore.NewGLStore()
+	engine := &ledger.Engine{GLStore: glStore}
+	r := &Recognizer{Engine: engine, GL: glStore.All}
+	plant := &Contract{
+		Name: "CTR-0001", Company: "ACME", Customer: "Tata Motors", Project: "PROJ-PLANT", Method: PercentOfCompletion,
+		Value: 100000, DeferredAccount: deferred, IncomeAccount: income, CostCenter: "Main - ACME",
+		Progress: []Progress{{date(1, 31), 25}, {date(2, 29), 60}, {date(3, 31), 50}},
+	}
+	bill(t, engine, "SINV-1", "PROJ-PLANT", 40000, date(1, 15))
+
+	p, err := r.Recognize(plant, date(1, 31))
+	if err != nil || p == nil || p.Amount != 25000 || p.VoucherNo != "CTR-0001/2024-01-31" {
+		t.Fatalf("Recognize() = %+v, %v", p, err)
+	}
+	if p, err := r.Recognize(plant, date(1, 31)); p != nil || err != nil {
+		t.Errorf("second Recognize() = %+v, %v", p, err)
+	}
+	if balance(glStore.All(), income, "PROJ-PLANT") != -25000 || balance(glStore.All(), deferred, "PROJ-PLANT") != -15000 {
+		t.Errorf("balances after January: income %.2f, deferred %.2f",
+			balance(glStore.All(), income, "PROJ-PLANT"), balance(glStore.All(), deferred, "PROJ-PLANT"))
+	}
+
+	// February works ahead of billing; March revises progress down
+	if p, err := r.Recognize(plant, date(2, 29)); err != nil || p.Amount != 35000 {
+		t.Fatalf("February = %+v, %v", p, err)
+	}
+	row := Reconcile([]*Contract{plant}, glStore.All(), date(2, 29))[0]
+	if row.Earned != 60000 || row.Billed != 40000 || row.Recognized != 60000 || row.Unbilled != 20000 || row.Deferred != 0 || row.Pending != 0 {
+		t.Errorf("February row = %+v", row)
+	}
+	if p, err := r.Recognize(plant, date(3, 31)); err != nil || p.Amount != -10000 {
+		t.Fatalf("March = %+v, %v", p, err)
+	}
+	if balance(glStore.All(), income, "PROJ-PLANT") != -50000 {
+		t.Errorf("income after March = %.2f", balance(glStore.All(), income, "PROJ-PLANT"))
+	}
+	if e := glStore.All()[len(glStore.All())-1]; e.Account != income || e.Debit != 10000 || e.VoucherSubtype != VoucherSubtype || e.Remarks != "Revenue of CTR-0001 at 50.00% complete" {
+		t.Errorf("reversal entry = %+v", e)
+	}
+
+	// Nothing earned since
+	if p, err := r.Recognize(plant, date(4, 30)); p != nil || err != nil {
+		t.Errorf("April = %+v, %v", p, err)
+	}
+}
+
+func TestMilestones(t *testing.T) {
+	glStore := memstore.NewGLStore()
+	engine := &ledger.Engine{GLStore: glStore}
+	r := &Recognizer{Engine: engine, GL: glStore.All}
+	erp := &Contract{
+		Name: "CTR-0002", Company: "ACME", Customer: "Tata Motors", Project: "PROJ-ERP", Method: Milestones,
+		Value: 90000, DeferredAccount: deferred, IncomeAccount: income,
+		Milestones: []Milestone{
+			{Name: "Design", Amount: 20000, Delivered: date(2, 10)},
+			{Name: "Build", Amount: 50000, Delivered: date(4, 5)},
+			{Name: "Go-live", Amount: 20000},
+		},
+	}
+	bill(t, engine, "SINV-2", "PROJ-ERP", 45000, date(1, 5))
+
+	posted, err := r.RecognizeAll([]*Contract{erp}, date(3, 31))
+	if err != nil || len(posted) != 1 || posted[0].Amount != 20000 {
+		t.Fatalf("RecognizeAll() = %+v, %v", posted, err)
+	}
+	rows := Reconcile([]*Contract{erp}, glStore.All(), date(4, 30))
+	if row := rows[0]; row.Complete != 77.78 || row.Earned != 70000 || row.Recognized != 20000 || row.Pending != 50000 || row.Deferred != 25000 {
+		t.Errorf("April row = %+v", row)
+	}
+
+	var buf bytes.Buffer
+	if err := reportio.WriteCSV(&buf, Report(rows)); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "CTR-0002,Tata Motors,PROJ-ERP,90000.00,77.78,70000.00,45000.00,20000.00,50000.00,0.00,25000.00") {
+		t.Errorf("CSV = %s", buf.String())
+	}
+}
+
+func TestCancelledRecognition(t *testing.T) {
+	glStore := memstore.NewGLStore()
+	engine := &ledger.Engine{GLStore: glStore}
+	r := &Recognizer{Engine: engine, GL: glStore.All}
+	plant := &Contract{
+		Name: "CTR-0001", Company: "ACME", Customer: "Tata Motors", Project: "PROJ-PLANT", Method: PercentOfCompletion,
+		Value: 100000, DeferredAccount: deferred, IncomeAccount: income, CostCenter: "Main - ACME",
+		Progress: []Progress{{date(1, 31), 25}},
+	}
+	// Cancelled, with the reversals saved live as older stores hold them
+	no := RecognitionVoucher(plant, date(1, 31))
+	recognized, err := plant.glMap(date(1, 31), no, 25000, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	reversals, err := plant.glMap(date(1, 31), no, -25000, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	recognized[0].IsCancelled, recognized[1].IsCancelled = true, true
+	if err := glStore.SaveBatch(append(recognized, reversals...)); err != nil {
+		t.Fatal(err)
+	}
+
+	if a := plant.Measure(glStore.All(), date(1, 31)); a.Recognized != 0 {
+		t.Errorf("recognized = %v, want 0", a.Recognized)
+	}
+	p, err := r.Recognize(plant, date(1, 31))
+	if err != nil || p == nil || p.Amount != 25000 {
+		t.Fatalf("Recognize() = %+v, %v after the cancellation", p, err)
+	}
+	if a := plant.Measure(glStore.All(), date(1, 31)); a.Recognized != 25000 {
+		t.Errorf("recognized = %v, want 25000", a.Recognized)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	for name, change := range map[string]func(*Contract){
+		"no project":       func(c *Contract) { c.Project = "" },
+		"no income":        func(c *Contract) { c.IncomeAccount = "" },
+		"unknown method":   func(c *Contract) { c.Method = "Completed Contract" },
+		"milestones short": func(c *Contract) { c.Milestones[0].Amount = 10 },
+		"percent over 100": func(c *Contract) { c.Method, c.Progress = PercentOfCompletion, []Progress{{date(1, 1), 120}} },
+		"progress order": func(c *Contract) {
+			c.Method, c.Progress = PercentOfCompletion, []Progress{{date(2, 1), 10}, {date(1, 1), 20}}
+		},
+	} {
+		c := &Contract{
+			Name: "CTR-0003", Company: "ACME", Project: "PROJ-X", Method: Milestones, Value: 100,
+			DeferredAccount: deferred, IncomeAccount: income, Milestones: []Milestone{{Name: "All", Amount: 100}},
+		}
+		change(c)
+		if err := c.Validate(); !errors.Is(err, ErrInvalidContract) {
+			t.Errorf("%s: Validate() = %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package recognition
+
+import (
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/ledger"
+	"github.com/senguttuvang/erpnext-go/reportio"
+)
+
+// Row reconciles what a contract billed with what it recognised.
+type Row struct {
+	Contract   string
+	Customer   string
+	Project    string
+	Value      float64
+	Complete   float64 // Percent
+	Earned     float64
+	Billed     float64
+	Recognized float64
+	Pending    float64 // Earned but not yet recognised; negative to reverse
+	Unbilled   float64 // Recognised ahead of billing: the contract asset
+	Deferred   float64 // Billed ahead of recognition: the contract liability
+}
+
+// Reconcile compares the billing and recognition of contracts in GL
+// entries up to date.
+//
+// Maps to: the Deferred Revenue and Expense report of
+// erpnext/accounts/report/deferred_revenue_and_expense, per contract
+func Reconcile(contracts []*Contract, gl []ledger.GLEntry, date time.Time) []Row {
+	rows := make([]Row, 0, len(contracts))
+	for _, c := range contracts {
+		a := c.Measure(gl, date)
+		earned := c.Earned(date)
+		diff := ledger.Flt(a.Recognized-a.Billed, 2)
+		rows = append(rows, Row{
+			Contract: c.Name, Customer: c.Customer, Project: c.Project, Value: c.Value,
+			Complete: c.Complete(date), Earned: earned, Billed: a.Billed, Recognized: a.Recognized,
+			Pending:  ledger.Flt(earned-a.Recognized, 2),
+			Unbilled: max(diff, 0), Deferred: max(-diff, 0),
+		})
+	}
+	return rows
+}
+
+// Report presents reconciliation rows as a downloadable report.
+func Report(rows []Row) reportio.Report {
+	return report(rows)
+}
+
+type report []Row
+
+func (report) Title() string { return "Contract Revenue Recognition" }
+
+func (report) Columns() []reportio.Column {
+	return []reportio.Column{
+		{Fieldname: "contract", Label: "Contract", Fieldtype: reportio.Data},
+		{Fieldname: "customer", Label: "Customer", Fieldtype: reportio.Link, Options: "Customer"},
+		{Fieldname: "project", Label: "Project", Fieldtype: reportio.Link, Options: "Project"},
+		{Fieldname: "value", Label: "Contract Value", Fieldtype: reportio.Currency},
+		{Fieldname: "percent_complete", Label: "% Complete", Fieldtype: reportio.Float},
+		{Fieldname: "earned", Label: "Earned", Fieldtype: reportio.Currency},
+		{Fieldname: "billed", Label: "Billed", Fieldtype: reportio.Currency},
+		{Fieldname: "recognized", Label: "Recognized", Fieldtype: reportio.Currency},
+		{Fieldname: "pending", Label: "To Recognize", Fieldtype: reportio.Currency},
+		{Fieldname: "unbilled", Label: "Unbilled Revenue", Fieldtype: reportio.Currency},
+		{Fieldname: "deferred", Label: "Deferred Revenue", Fieldtype: reportio.Currency},
+	}
+}
+
+func (r report) Rows(fn func([]any) error) error {
+	for _, row := range r {
+		if err := fn([]any{
+			row.Contract, row.Customer, row.Project, row.Value, row.Complete, row.Earned,
+			row.Billed, row.Recognized, row.Pending, row.Unbilled, row.Deferred,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
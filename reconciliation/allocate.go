@@ -0,0 +1,324 @@
+package reconciliation
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/daterange"
+	"github.com/senguttuvang/erpnext-go/ledger"
+)
+
+// Allocation errors
+var (
+	ErrUnknownVoucher = errors.New("voucher is not open for allocation")
+	ErrOverAllocated  = errors.New("allocation exceeds the amount left")
+)
+
+// Open is an invoice, or a debit note, with an amount outstanding.
+type Open struct {
+	Company     string
+	Account     string
+	PartyType   string
+	Party       string
+	VoucherType string
+	VoucherNo   string
+	PostingDate time.Time
+	DueDate     time.Time // The posting date when the invoice has none
+	Outstanding float64
+}
+
+// Outstanding lists the vouchers with an amount outstanding on the
+// invoice side of the party's account, the counterpart of Unallocated.
+// Rows are ordered oldest first.
+//
+// Maps to: PaymentReconciliation.get_invoice_entries()
+func Outstanding(entries []ledger.PaymentLedgerEntry, filter Filter) []Open {
+	var rows []Open
+	for _, g := range groupByAgainst(entries, filter) {
+		outstanding := ledger.Flt(-g.outstanding*advanceSign(g.row.PartyType), 2)
+		if outstanding <= 0 {
+			continue
+		}
+		r := g.row
+		o := Open{
+			Company: r.Company, Account: r.Account, PartyType: r.PartyType, Party: r.Party,
+			VoucherType: r.VoucherType, VoucherNo: r.VoucherNo, PostingDate: r.PostingDate,
+			DueDate: daterange.Civil(r.PostingDate), Outstanding: outstanding,
+		}
+		if g.dueDate != nil {
+			o.DueDate = daterange.Civil(*g.dueDate)
+		}
+		rows = append(rows, o)
+	}
+	slices.SortStableFunc(rows, func(a, b Open) int {
+		return byPosting(a.PostingDate, a.VoucherType, a.VoucherNo, b.PostingDate, b.VoucherType, b.VoucherNo)
+	})
+	return rows
+}
+
+// byPosting orders vouchers by posting date, then voucher type and number:
+// the tie-break of every strategy, so that allocations do not depend on
+// the order entries were read in.
+func byPosting(aDate time.Time, aType, aNo string, bDate time.Time, bType, bNo string) int {
+	return cmp.Or(
+		daterange.Civil(aDate).Compare(daterange.Civil(bDate)),
+		strings.Compare(aType, bType),
+		strings.Compare(aNo, bNo),
+	)
+}
+
+// Allocation applies part of a payment to an invoice.
+type Allocation struct {
+	Company     string
+	Account     string
+	PartyType   string
+	Party       string
+	PaymentType string
+	PaymentNo   string
+	InvoiceType string
+	InvoiceNo   string
+	Amount      float64
+}
+
+// Strategy decides how the unallocated payments of one party account are
+// applied to its open invoices. Payments and invoices come oldest first;
+// an allocation may not exceed what is unallocated on the payment or
+// outstanding on the invoice.
+//
+// Maps to: PaymentReconciliation.allocate_entries(), which applies
+// payments to invoices in order
+type Strategy interface {
+	Allocate(payments []Unreconciled, invoices []Open) ([]Allocation, error)
+}
+
+// FIFO applies payments to invoices in the order they were posted.
+type FIFO struct{}
+
+// Allocate implements Strategy.
+func (FIFO) Allocate(payments []Unreconciled, invoices []Open) ([]Allocation, error) {
+	return inOrder(payments, invoices), nil
+}
+
+// OldestDue applies payments to the invoices due first; invoices due on
+// the same day are taken in the order they were posted.
+type OldestDue struct{}
+
+// Allocate implements Strategy.
+func (OldestDue) Allocate(payments []Unreconciled, invoices []Open) ([]Allocation, error) {
+	invoices = slices.Clone(invoices)
+	slices.SortStableFunc(invoices, func(a, b Open) int {
+		return cmp.Or(a.DueDate.Compare(b.DueDate), byPosting(a.PostingDate, a.VoucherType, a.VoucherNo, b.PostingDate, b.VoucherType, b.VoucherNo))
+	})
+	return inOrder(payments, invoices), nil
+}
+
+// Proportional spreads each payment over the open invoices in proportion
+// to what is outstanding on them. Rounding differences go to the oldest
+// invoices.
+type Proportional struct{}
+
+// Allocate implements Strategy.
+func (Proportional) Allocate(payments []Unreconciled, invoices []Open) ([]Allocation, error) {
+	left := outstandings(invoices)
+	var allocations []Allocation
+	for _, p := range payments {
+		var total float64
+		for _, l := range left {
+			total += l
+		}
+		amount := ledger.Flt(min(p.Unallocated, total), 2)
+		if amount <= 0 {
+			break
+		}
+		shares := make([]float64, len(invoices))
+		var spread float64
+		for i := range invoices {
+			shares[i] = min(ledger.Flt(amount*left[i]/total, 2), left[i])
+			spread += shares[i]
+		}
+		// Pennies lost or gained in rounding
+		for i := 0; i < len(invoices) && ledger.Flt(amount-spread, 2) != 0; i++ {
+			diff := ledger.Flt(amount-spread, 2)
+			adjusted := ledger.Flt(min(max(shares[i]+diff, 0), left[i]), 2)
+			spread += adjusted - shares[i]
+			shares[i] = adjusted
+		}
+		for i, inv := range invoices {
+			if shares[i] > 0 {
+				allocations = append(allocations, allocation(p, inv, shares[i]))
+				left[i] = ledger.Flt(left[i]-shares[i], 2)
+			}
+		}
+	}
+	return allocations, nil
+}
+
+// Manual applies only the allocations a user made. Those of other party
+// accounts are ignored.
+type Manual struct {
+	Allocations []Allocation
+}
+
+// Allocate implements Strategy. It fails when an allocation names a
+// voucher that is not open, or takes more than is left on either side.
+func (m Manual) Allocate(payments []Unreconciled, invoices []Open) ([]Allocation, error) {
+	if len(payments) == 0 || len(invoices) == 0 {
+		return nil, nil
+	}
+	unallocated := make([]float64, len(payments))
+	for i, p := range payments {
+		unallocated[i] = p.Unallocated
+	}
+	left := outstandings(invoices)
+	var allocations []Allocation
+	for _, a := range m.Allocations {
+		if a.Company != payments[0].Company || a.Account != payments[0].Account || a.PartyType != payments[0].PartyType || a.Party != payments[0].Party {
+			continue
+		}
+		i := slices.IndexFunc(payments, func(p Unreconciled) bool { return p.VoucherType == a.PaymentType && p.VoucherNo == a.PaymentNo })
+		j := slices.IndexFunc(invoices, func(o Open) bool { return o.VoucherType == a.InvoiceType && o.VoucherNo == a.InvoiceNo })
+		switch {
+		case i < 0:
+			return nil, fmt.Errorf("%w: %s %s", ErrUnknownVoucher, a.PaymentType, a.PaymentNo)
+		case j < 0:
+			return nil, fmt.Errorf("%w: %s %s", ErrUnknownVoucher, a.InvoiceType, a.InvoiceNo)
+		case a.Amount <= 0 || ledger.Flt(a.Amount, 2) > unallocated[i] || ledger.Flt(a.Amount, 2) > left[j]:
+			return nil, fmt.Errorf("%w: %.2f of %s to %s", ErrOverAllocated, a.Amount, a.PaymentNo, a.InvoiceNo)
+		}
+		amount := ledger.Flt(a.Amount, 2)
+		unallocated[i] = ledger.Flt(unallocated[i]-amount, 2)
+		left[j] = ledger.Flt(left[j]-amount, 2)
+		allocations = append(allocations, allocation(payments[i], invoices[j], amount))
+	}
+	return allocations, nil
+}
+
+// inOrder applies the payments in turn to the invoices in the order given.
+func inOrder(payments []Unreconciled, invoices []Open) []Allocation {
+	left := outstandings(invoices)
+	var allocations []Allocation
+	j := 0
+	for _, p := range payments {
+		unallocated := p.Unallocated
+		for ; j < len(invoices) && unallocated > 0; j++ {
+			amount := min(unallocated, left[j])
+			allocations = append(allocations, allocation(p, invoices[j], amount))
+			unallocated = ledger.Flt(unallocated-amount, 2)
+			if left[j] = ledger.Flt(left[j]-amount, 2); left[j] > 0 {
+				break
+			}
+		}
+	}
+	return allocations
+}
+
+func outstandings(invoices []Open) []float64 {
+	left := make([]float64, len(invoices))
+	for i, inv := range invoices {
+		left[i] = inv.Outstanding
+	}
+	return left
+}
+
+func allocation(p Unreconciled, inv Open, amount float64) Allocation {
+	return Allocation{
+		Company: p.Company, Account: p.Account, PartyType: p.PartyType, Party: p.Party,
+		PaymentType: p.VoucherType, PaymentNo: p.VoucherNo, InvoiceType: inv.VoucherType, InvoiceNo: inv.VoucherNo,
+		Amount: amount,
+	}
+}
+
+// Policy is a company's choice of strategy, with overrides for the
+// parties that want theirs applied differently.
+type Policy struct {
+	Default   Strategy            // FIFO when nil
+	Overrides map[string]Strategy // By party name
+}
+
+// For returns the strategy of a party.
+func (p Policy) For(party string) Strategy {
+	if s, ok := p.Overrides[party]; ok && s != nil {
+		return s
+	}
+	if p.Default != nil {
+		return p.Default
+	}
+	return FIFO{}
+}
+
+// Allocate applies the unallocated payments of each party account
+// selected by filter to its outstanding invoices, with the strategy the
+// policy has for the party. Party accounts are taken in order of party
+// type, party and account. Errors are collected; a strategy failing for
+// one party does not stop the others.
+//
+// Maps to: PaymentReconciliation.get_unreconciled_entries() followed by
+// allocate_entries() for each party
+func Allocate(entries []ledger.PaymentLedgerEntry, filter Filter, policy Policy) ([]Allocation, error) {
+	type account struct{ company, account, partyType, party string }
+	payments := map[account][]Unreconciled{}
+	invoices := map[account][]Open{}
+	var accounts []account
+	for _, p := range Unallocated(entries, filter) {
+		k := account{p.Company, p.Account, p.PartyType, p.Party}
+		payments[k] = append(payments[k], p)
+	}
+	for _, o := range Outstanding(entries, filter) {
+		k := account{o.Company, o.Account, o.PartyType, o.Party}
+		if _, ok := payments[k]; ok {
+			if _, seen := invoices[k]; !seen {
+				accounts = append(accounts, k)
+			}
+			invoices[k] = append(invoices[k], o)
+		}
+	}
+	slices.SortFunc(accounts, func(a, b account) int {
+		return cmp.Or(strings.Compare(a.partyType, b.partyType), strings.Compare(a.party, b.party), strings.Compare(a.account, b.account))
+	})
+
+	var allocations []Allocation
+	var errs []error
+	for _, k := range accounts {
+		ps := payments[k]
+		slices.SortStableFunc(ps, func(a, b Unreconciled) int {
+			return byPosting(a.PostingDate, a.VoucherType, a.VoucherNo, b.PostingDate, b.VoucherType, b.VoucherNo)
+		})
+		a, err := policy.For(k.party).Allocate(ps, invoices[k])
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s %s: %w", k.partyType, k.party, err))
+			continue
+		}
+		allocations = append(allocations, a...)
+	}
+	return allocations, errors.Join(errs...)
+}
+
+// Entries returns the payment ledger entries that book allocations on
+// date: each moves the amount of the payment booked against itself to the
+// invoice. Amounts are in company currency, for party accounts kept in
+// it. Save the entries to the payment ledger to reconcile.
+//
+// Maps to: reconcile_against_document() in erpnext/accounts/utils.py
+func Entries(allocations []Allocation, date time.Time) []ledger.PaymentLedgerEntry {
+	date = daterange.Civil(date)
+	entries := make([]ledger.PaymentLedgerEntry, 0, 2*len(allocations))
+	for _, a := range allocations {
+		sign := advanceSign(a.PartyType)
+		e := ledger.PaymentLedgerEntry{
+			PostingDate: date, Company: a.Company, Account: a.Account, PartyType: a.PartyType, Party: a.Party,
+			VoucherType: a.PaymentType, VoucherNo: a.PaymentNo,
+		}
+		released, applied := e, e
+		released.AgainstVoucherType, released.AgainstVoucherNo = a.PaymentType, a.PaymentNo
+		released.Amount = -sign * a.Amount
+		applied.AgainstVoucherType, applied.AgainstVoucherNo = a.InvoiceType, a.InvoiceNo
+		applied.Amount = sign * a.Amount
+		released.AmountInAccountCurrency, applied.AmountInAccountCurrency = released.Amount, applied.Amount
+		entries = append(entries, released, applied)
+	}
+	return entries
+}
@@ -0,0 +1,207 @@
+package reconciliation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/senguttuvang/erpnext-go/ledger"
+	"github.com/senguttuvang/erpnext-go/reportio"
+)
+
+// ErrInvalidThreshold is returned for a consistency threshold that is not
+// a non-negative amount.
+var ErrInvalidThreshold = errors.New("invalid consistency threshold")
+
+// ConsistencyReportName is the name the consistency check is registered
+// under with autoreport, so it can run nightly and mail its divergences.
+const ConsistencyReportName = "GL and Payment Ledger Consistency"
+
+// ConsistencyFilter selects what CheckConsistency compares.
+type ConsistencyFilter struct {
+	Company   string
+	PartyType string // Optional
+	Party     string // Optional
+
+	// Threshold is the difference in company currency tolerated before a
+	// voucher is flagged. Zero flags every difference that survives
+	// rounding to the cent.
+	Threshold float64
+}
+
+// Divergence is a party's voucher whose GL entries and payment ledger
+// entries disagree. Amounts are debit minus credit in company currency,
+// summed over the entries booked against the voucher.
+type Divergence struct {
+	Company     string
+	Account     string
+	PartyType   string
+	Party       string
+	VoucherType string
+	VoucherNo   string
+
+	GL            float64
+	PaymentLedger float64
+	Difference    float64 // GL - PaymentLedger
+
+	// The entries summed, for drill-down
+	GLEntries            []ledger.GLEntry
+	PaymentLedgerEntries []ledger.PaymentLedgerEntry
+}
+
+// CheckConsistency compares, per party account and per voucher booked
+// against, the GL entries posted with a party to the payment ledger. The
+// GL side counts cancelled entries with their reversals, so a cancelled
+// voucher nets to nothing there, as it does in the payment ledger once
+// its entries are delinked. Period closing vouchers, which have no
+// payment ledger entries, are skipped. Divergences are ordered by
+// account, party and voucher.
+//
+// Maps to: the GL vs Payment Ledger consistency checks of ERPNext's
+// patches and of the "General and Payment Ledger Comparison" report
+func CheckConsistency(gl []ledger.GLEntry, ple []ledger.PaymentLedgerEntry, filter ConsistencyFilter) []Divergence {
+	groups := map[key]*Divergence{}
+	group := func(company, account, partyType, party, voucherType, voucherNo, againstType, againstNo string) *Divergence {
+		if againstNo != "" {
+			voucherType, voucherNo = againstType, againstNo
+		}
+		k := key{company, account, partyType, party, voucherType, voucherNo}
+		d, ok := groups[k]
+		if !ok {
+			d = &Divergence{
+				Company: company, Account: account, PartyType: partyType, Party: party,
+				VoucherType: voucherType, VoucherNo: voucherNo,
+			}
+			groups[k] = d
+		}
+		return d
+	}
+	selected := func(company, partyType, party string) bool {
+		return company == filter.Company && party != "" &&
+			(filter.PartyType == "" || partyType == filter.PartyType) &&
+			(filter.Party == "" || party == filter.Party)
+	}
+
+	for _, e := range gl {
+		if !selected(e.Company, e.PartyType, e.Party) || e.VoucherType == ledger.PeriodClosingVoucher {
+			continue
+		}
+		d := group(e.Company, e.Account, e.PartyType, e.Party, e.VoucherType, e.VoucherNo, e.AgainstVoucherType, e.AgainstVoucher)
+		d.GL += e.Debit - e.Credit
+		d.GLEntries = append(d.GLEntries, e)
+	}
+	for _, e := range ple {
+		if e.Delinked || !selected(e.Company, e.PartyType, e.Party) {
+			continue
+		}
+		d := group(e.Company, e.Account, e.PartyType, e.Party, e.VoucherType, e.VoucherNo, e.AgainstVoucherType, e.AgainstVoucherNo)
+		d.PaymentLedger += e.Amount
+		d.PaymentLedgerEntries = append(d.PaymentLedgerEntries, e)
+	}
+
+	var out []Divergence
+	for _, d := range groups {
+		d.GL, d.PaymentLedger = ledger.Flt(d.GL, 2), ledger.Flt(d.PaymentLedger, 2)
+		d.Difference = ledger.Flt(d.GL-d.PaymentLedger, 2)
+		if d.Difference != 0 && math.Abs(d.Difference) > filter.Threshold {
+			out = append(out, *d)
+		}
+	}
+	slices.SortFunc(out, func(a, b Divergence) int {
+		if c := strings.Compare(a.Account, b.Account); c != 0 {
+			return c
+		}
+		if c := strings.Compare(a.Party, b.Party); c != 0 {
+			return c
+		}
+		if c := strings.Compare(a.VoucherType, b.VoucherType); c != 0 {
+			return c
+		}
+		return strings.Compare(a.VoucherNo, b.VoucherNo)
+	})
+	return out
+}
+
+// ConsistencySource reads the entries of a company for the nightly check.
+type ConsistencySource interface {
+	GLEntries(ctx context.Context, company string) ([]ledger.GLEntry, error)
+	PaymentLedgerEntries(ctx context.Context, company string) ([]ledger.PaymentLedgerEntry, error)
+}
+
+// ConsistencyCheck returns the report function of the consistency check,
+// to be registered in autoreport.Generators under ConsistencyReportName.
+// Scheduled nightly with SendIfData, it mails the divergences only when
+// there are some. The filters "party_type", "party" and "threshold" map
+// to ConsistencyFilter.
+func ConsistencyCheck(source ConsistencySource) func(ctx context.Context, company string, filters map[string]string) (reportio.Report, error) {
+	return func(ctx context.Context, company string, filters map[string]string) (reportio.Report, error) {
+		filter := ConsistencyFilter{Company: company, PartyType: filters["party_type"], Party: filters["party"]}
+		if v := filters["threshold"]; v != "" {
+			threshold, err := strconv.ParseFloat(v, 64)
+			if err != nil || threshold < 0 {
+				return nil, fmt.Errorf("%w: %q", ErrInvalidThreshold, v)
+			}
+			filter.Threshold = threshold
+		}
+		gl, err := source.GLEntries(ctx, company)
+		if err != nil {
+			return nil, err
+		}
+		ple, err := source.PaymentLedgerEntries(ctx, company)
+		if err != nil {
+			return nil, err
+		}
+		return ConsistencyReport(CheckConsistency(gl, ple, filter)), nil
+	}
+}
+
+// ConsistencyReport presents divergences as a downloadable report. Each
+// divergence is followed by its drill-down rows, one per GL and payment
+// ledger entry summed, naming the entry and the voucher that posted it.
+func ConsistencyReport(divergences []Divergence) reportio.Report {
+	return consistencyReport(divergences)
+}
+
+type consistencyReport []Divergence
+
+func (consistencyReport) Title() string { return ConsistencyReportName }
+
+func (consistencyReport) Columns() []reportio.Column {
+	return []reportio.Column{
+		{Fieldname: "account", Label: "Account", Fieldtype: reportio.Link, Options: "Account"},
+		{Fieldname: "party_type", Label: "Party Type", Fieldtype: reportio.Data},
+		{Fieldname: "party", Label: "Party", Fieldtype: reportio.Data},
+		{Fieldname: "voucher_type", Label: "Voucher Type", Fieldtype: reportio.Data},
+		{Fieldname: "voucher_no", Label: "Voucher No", Fieldtype: reportio.Data},
+		{Fieldname: "gl_amount", Label: "GL Amount", Fieldtype: reportio.Currency},
+		{Fieldname: "pl_amount", Label: "Payment Ledger Amount", Fieldtype: reportio.Currency},
+		{Fieldname: "difference", Label: "Difference", Fieldtype: reportio.Currency},
+		{Fieldname: "entry_type", Label: "Entry Type", Fieldtype: reportio.Data},
+		{Fieldname: "entry", Label: "Entry", Fieldtype: reportio.DynamicLink, Options: "entry_type"},
+	}
+}
+
+func (r consistencyReport) Rows(fn func([]any) error) error {
+	for _, d := range r {
+		if err := fn([]any{d.Account, d.PartyType, d.Party, d.VoucherType, d.VoucherNo, d.GL, d.PaymentLedger, d.Difference, nil, nil}); err != nil {
+			return err
+		}
+		for _, e := range d.GLEntries {
+			row := []any{d.Account, d.PartyType, d.Party, e.VoucherType, e.VoucherNo, ledger.Flt(e.Debit-e.Credit, 2), nil, nil, "GL Entry", e.Name}
+			if err := fn(row); err != nil {
+				return err
+			}
+		}
+		for _, e := range d.PaymentLedgerEntries {
+			row := []any{d.Account, d.PartyType, d.Party, e.VoucherType, e.VoucherNo, nil, ledger.Flt(e.Amount, 2), nil, "Payment Ledger Entry", e.Name}
+			if err := fn(row); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,232 @@
+// Package reconciliation finds the payments and advances that are not yet
+// matched with the invoices they pay, and allocates them to the open
+// invoices with the Strategy a company, or a party, chooses. It also
+// checks that the payment ledger still agrees with the GL, voucher by
+// voucher, so the outstanding amounts it works from can be trusted.
+//
+// Migrated from: erpnext/accounts/doctype/payment_reconciliation/payment_reconciliation.py
+package reconciliation
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/daterange"
+	"github.com/senguttuvang/erpnext-go/ledger"
+	"github.com/senguttuvang/erpnext-go/party"
+	"github.com/senguttuvang/erpnext-go/reportio"
+)
+
+// DefaultAgeing are the upper bounds in days of the ageing buckets, as in
+// the range1..range4 filters of the receivable reports: 0-30, 31-60,
+// 61-90, 91-120 and 121 days or more.
+var DefaultAgeing = []int{30, 60, 90, 120}
+
+// Filter selects the entries of the report.
+type Filter struct {
+	Company   string
+	PartyType string    // Optional
+	Party     string    // Optional
+	Account   string    // Optional receivable or payable account
+	AsOf      time.Time // Entries posted later are ignored; ages are counted to it
+	Ageing    []int     // Bucket bounds in days; DefaultAgeing when nil
+}
+
+// Unreconciled is a payment, advance or credit whose amount is not fully
+// allocated to invoices.
+type Unreconciled struct {
+	Company     string
+	Account     string
+	PartyType   string
+	Party       string
+	VoucherType string
+	VoucherNo   string
+	PostingDate time.Time
+
+	Amount      float64   // Booked by the voucher itself, company currency
+	Allocated   float64   // Allocated to invoices since
+	Unallocated float64   // Still to allocate
+	Age         int       // Days from the posting date to AsOf
+	Ageing      []float64 // Unallocated amount in its ageing bucket; one more than the bounds
+}
+
+// key identifies the against voucher of an entry within a party account.
+type key struct {
+	company, account, partyType, party, voucherType, voucherNo string
+}
+
+// advanceSign returns the sign of an unallocated amount of a party type:
+// payments received on receivable accounts are credits (negative), while
+// advances paid on payable accounts, to suppliers and employees, are
+// debits (positive).
+func advanceSign(partyType string) float64 {
+	if party.PartyType(partyType).AccountType() == party.Receivable {
+		return -1
+	}
+	return 1
+}
+
+// Unallocated lists the payment ledger entries booked against a voucher
+// whose total is on the payment side of the party's account: receipts
+// from customers and payments to suppliers that no invoice has taken, as
+// well as credit notes and overpaid invoices. Delinked entries are
+// ignored. Each is aged by the posting date of the voucher; rows are
+// ordered oldest first.
+//
+// Maps to: PaymentReconciliation.get_payment_entries() and
+// get_dr_or_cr_notes(), which query the unallocated payment ledger
+// entries for the payment reconciliation tool
+func Unallocated(entries []ledger.PaymentLedgerEntry, filter Filter) []Unreconciled {
+	bounds := filter.Ageing
+	if bounds == nil {
+		bounds = DefaultAgeing
+	}
+	var rows []Unreconciled
+	for _, g := range groupByAgainst(entries, filter) {
+		sign := advanceSign(g.row.PartyType)
+		unallocated := ledger.Flt(g.outstanding*sign, 2)
+		if unallocated <= 0 {
+			continue
+		}
+		r := g.row
+		r.Amount = ledger.Flt(r.Amount*sign, 2)
+		r.Unallocated = unallocated
+		r.Allocated = ledger.Flt(max(r.Amount-unallocated, 0), 2)
+		r.Age = daterange.New(r.PostingDate, filter.AsOf).Days()
+		r.Ageing = make([]float64, len(bounds)+1)
+		r.Ageing[ageingBucket(r.Age, bounds)] = unallocated
+		rows = append(rows, r)
+	}
+	slices.SortStableFunc(rows, func(a, b Unreconciled) int {
+		if c := a.PostingDate.Compare(b.PostingDate); c != 0 {
+			return c
+		}
+		if c := strings.Compare(a.Party, b.Party); c != 0 {
+			return c
+		}
+		return strings.Compare(a.VoucherNo, b.VoucherNo)
+	})
+	return rows
+}
+
+// against is the balance of the entries booked against one voucher.
+type against struct {
+	row         Unreconciled // Amount is what the voucher itself booked
+	outstanding float64
+	booked      bool       // An entry of the voucher itself was seen
+	dueDate     *time.Time // Earliest due date of the voucher's own entries
+}
+
+// groupByAgainst sums the payment ledger entries selected by filter by the
+// voucher they are booked against, in the order first seen.
+func groupByAgainst(entries []ledger.PaymentLedgerEntry, filter Filter) []*against {
+	period := daterange.Inclusive(time.Time{}, filter.AsOf)
+	groups := map[key]*against{}
+	var order []*against
+	for i := range entries {
+		e := &entries[i]
+		if e.Delinked || e.Company != filter.Company || !period.Contains(e.PostingDate) ||
+			(filter.PartyType != "" && e.PartyType != filter.PartyType) ||
+			(filter.Party != "" && e.Party != filter.Party) ||
+			(filter.Account != "" && e.Account != filter.Account) {
+			continue
+		}
+		voucherType, voucherNo := e.AgainstVoucherType, e.AgainstVoucherNo
+		if voucherNo == "" {
+			voucherType, voucherNo = e.VoucherType, e.VoucherNo
+		}
+		k := key{e.Company, e.Account, e.PartyType, e.Party, voucherType, voucherNo}
+		g, ok := groups[k]
+		if !ok {
+			g = &against{row: Unreconciled{
+				Company: e.Company, Account: e.Account, PartyType: e.PartyType, Party: e.Party,
+				VoucherType: voucherType, VoucherNo: voucherNo, PostingDate: e.PostingDate,
+			}}
+			groups[k] = g
+			order = append(order, g)
+		}
+		g.outstanding += e.Amount
+		if e.VoucherType == voucherType && e.VoucherNo == voucherNo {
+			g.row.Amount += e.Amount
+			if !g.booked || e.PostingDate.Before(g.row.PostingDate) {
+				g.row.PostingDate, g.booked = e.PostingDate, true
+			}
+			if e.DueDate != nil && (g.dueDate == nil || e.DueDate.Before(*g.dueDate)) {
+				g.dueDate = e.DueDate
+			}
+		}
+	}
+	return order
+}
+
+// ageingBucket returns the index of the bucket an age falls in.
+//
+// Python equivalent:
+//
+//	def get_ageing_data(self, entry_date, row):
+//	    for i, days in enumerate(self.ageing_range):
+//	        if age <= days: index = i; break
+//	    if index is None: index = 4
+func ageingBucket(age int, bounds []int) int {
+	i, _ := slices.BinarySearch(bounds, age)
+	return i
+}
+
+// Report presents unallocated entries as a downloadable report.
+func Report(rows []Unreconciled, ageing []int) reportio.Report {
+	if ageing == nil {
+		ageing = DefaultAgeing
+	}
+	return report{rows, ageing}
+}
+
+type report struct {
+	rows   []Unreconciled
+	ageing []int
+}
+
+func (report) Title() string { return "Unreconciled Payments" }
+
+func (r report) Columns() []reportio.Column {
+	cols := []reportio.Column{
+		{Fieldname: "posting_date", Label: "Posting Date", Fieldtype: reportio.Date},
+		{Fieldname: "party_type", Label: "Party Type", Fieldtype: reportio.Data},
+		{Fieldname: "party", Label: "Party", Fieldtype: reportio.Data},
+		{Fieldname: "account", Label: "Account", Fieldtype: reportio.Link, Options: "Account"},
+		{Fieldname: "voucher_type", Label: "Voucher Type", Fieldtype: reportio.Data},
+		{Fieldname: "voucher_no", Label: "Voucher No", Fieldtype: reportio.Data},
+		{Fieldname: "amount", Label: "Amount", Fieldtype: reportio.Currency},
+		{Fieldname: "allocated", Label: "Allocated Amount", Fieldtype: reportio.Currency},
+		{Fieldname: "unallocated", Label: "Unallocated Amount", Fieldtype: reportio.Currency},
+		{Fieldname: "age", Label: "Age (Days)", Fieldtype: reportio.Int},
+	}
+	lower := 0
+	for i, upper := range r.ageing {
+		cols = append(cols, reportio.Column{
+			Fieldname: "range" + strconv.Itoa(i+1), Label: strconv.Itoa(lower) + "-" + strconv.Itoa(upper), Fieldtype: reportio.Currency,
+		})
+		lower = upper + 1
+	}
+	return append(cols, reportio.Column{
+		Fieldname: "range" + strconv.Itoa(len(r.ageing)+1), Label: strconv.Itoa(lower) + "-Above", Fieldtype: reportio.Currency,
+	})
+}
+
+func (r report) Rows(fn func([]any) error) error {
+	for _, u := range r.rows {
+		row := []any{u.PostingDate, u.PartyType, u.Party, u.Account, u.VoucherType, u.VoucherNo, u.Amount, u.Allocated, u.Unallocated, u.Age}
+		for i := range len(r.ageing) + 1 {
+			v := 0.0
+			if i < len(u.Ageing) {
+				v = u.Ageing[i]
+			}
+			row = append(row, v)
+		}
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,231 @@
+package reconciliation
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/ledger"
+	"github.com/senguttuvang/erpnext-go/reportio"
+)
+
+func date(month time.Month, day int) time.Time {
+	return time.Date(2024, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func ple(voucherType, voucherNo, againstType, againstNo, partyType, party string, posted time.Time, amount float64) ledger.PaymentLedgerEntry {
+	account := "Debtors - AC"
+	if partyType == "Supplier" {
+		account = "Creditors - AC"
+	}
+	return ledger.PaymentLedgerEntry{
+		PostingDate: posted, Company: "ACME", Account: account, PartyType: partyType, Party: party,
+		VoucherType: voucherType, VoucherNo: voucherNo, AgainstVoucherType: againstType, AgainstVoucherNo: againstNo,
+		Amount: amount, AmountInAccountCurrency: amount,
+	}
+}
+
+func TestUnallocated(t *testing.T) {
+	entries := []ledger.PaymentLedgerEntry{
+		// Invoice of 1180 paid in full by a receipt of 1500, leaving 320 on the receipt
+		ple("Sales Invoice", "SINV-1", "Sales Invoice", "SINV-1", "Customer", "Globex", date(4, 1), 1180),
+		ple("Payment Entry", "PE-1", "Sales Invoice", "SINV-1", "Customer", "Globex", date(4, 5), -1180),
+		ple("Payment Entry", "PE-1", "Payment Entry", "PE-1", "Customer", "Globex", date(4, 5), -320),
+		// An advance later allocated in full by a reconciliation
+		ple("Payment Entry", "PE-2", "Payment Entry", "PE-2", "Customer", "Initech", date(3, 1), -500),
+		ple("Journal Entry", "JV-1", "Payment Entry", "PE-2", "Customer", "Initech", date(4, 10), 500),
+		// An advance to a supplier, half used
+		ple("Payment Entry", "PE-3", "Payment Entry", "PE-3", "Supplier", "Paper Mill", date(1, 15), 2000),
+		ple("Journal Entry", "JV-2", "Payment Entry", "PE-3", "Supplier", "Paper Mill", date(2, 1), -1000),
+		// Delinked: the payment was cancelled
+		{PostingDate: date(4, 6), Company: "ACME", Account: "Debtors - AC", PartyType: "Customer", Party: "Globex",
+			VoucherType: "Payment Entry", VoucherNo: "PE-4", AgainstVoucherType: "Payment Entry", AgainstVoucherNo: "PE-4", Amount: -50, Delinked: true},
+		// After the report date
+		ple("Payment Entry", "PE-5", "Payment Entry", "PE-5", "Customer", "Globex", date(5, 2), -75),
+	}
+	rows := Unallocated(entries, Filter{Company: "ACME", AsOf: date(4, 30)})
+	if len(rows) != 2 {
+		t.Fatalf("rows = %+v", rows)
+	}
+	if r := rows[0]; r.VoucherNo != "PE-3" || r.Amount != 2000 || r.Allocated != 1000 || r.Unallocated != 1000 || r.Age != 106 || r.Ageing[3] != 1000 {
+		t.Errorf("supplier advance = %+v", r)
+	}
+	if r := rows[1]; r.VoucherNo != "PE-1" || r.Amount != 320 || r.Unallocated != 320 || r.Age != 25 || r.Ageing[0] != 320 {
+		t.Errorf("customer receipt = %+v", r)
+	}
+
+	if rows := Unallocated(entries, Filter{Company: "ACME", PartyType: "Customer", AsOf: date(5, 31), Ageing: []int{15, 45}}); len(rows) != 2 || rows[1].VoucherNo != "PE-5" || rows[0].Ageing[2] != 320 {
+		t.Errorf("customers in May = %+v", rows)
+	}
+
+	var buf bytes.Buffer
+	if err := reportio.Write(&buf, reportio.CSV, Report(rows, nil)); err != nil {
+		t.Fatal(err)
+	}
+	header := strings.SplitN(buf.String(), "\n", 2)[0]
+	if !strings.HasSuffix(strings.TrimSpace(header), "0-30,31-60,61-90,91-120,121-Above") {
+		t.Errorf("header = %s", header)
+	}
+}
+
+func TestAllocate(t *testing.T) {
+	due := func(e ledger.PaymentLedgerEntry, d time.Time) ledger.PaymentLedgerEntry {
+		e.DueDate = &d
+		return e
+	}
+	entries := []ledger.PaymentLedgerEntry{
+		due(ple("Sales Invoice", "SINV-1", "Sales Invoice", "SINV-1", "Customer", "Globex", date(1, 10), 1000), date(3, 10)),
+		due(ple("Sales Invoice", "SINV-3", "Sales Invoice", "SINV-3", "Customer", "Globex", date(2, 1), 400), date(2, 20)),
+		due(ple("Sales Invoice", "SINV-2", "Sales Invoice", "SINV-2", "Customer", "Globex", date(2, 1), 600), date(2, 20)),
+		ple("Payment Entry", "PE-1", "Payment Entry", "PE-1", "Customer", "Globex", date(3, 1), -1200),
+		ple("Sales Invoice", "SINV-4", "Sales Invoice", "SINV-4", "Customer", "Initech", date(1, 5), 300),
+		ple("Sales Invoice", "SINV-5", "Sales Invoice", "SINV-5", "Customer", "Initech", date(1, 6), 300),
+		ple("Payment Entry", "PE-2", "Payment Entry", "PE-2", "Customer", "Initech", date(2, 1), -250),
+		ple("Purchase Invoice", "PINV-1", "Purchase Invoice", "PINV-1", "Supplier", "Paper Mill", date(1, 3), -90),
+		ple("Payment Entry", "PE-3", "Payment Entry", "PE-3", "Supplier", "Paper Mill", date(1, 20), 100),
+	}
+	filter := Filter{Company: "ACME", AsOf: date(3, 31)}
+	amounts := func(allocations []Allocation) string {
+		var parts []string
+		for _, a := range allocations {
+			parts = append(parts, a.PaymentNo+">"+a.InvoiceNo+":"+strconv.FormatFloat(a.Amount, 'f', -1, 64))
+		}
+		return strings.Join(parts, " ")
+	}
+
+	for name, tc := range map[string]struct {
+		policy Policy
+		want   string
+	}{
+		"fifo":         {Policy{}, "PE-1>SINV-1:1000 PE-1>SINV-2:200 PE-2>SINV-4:250 PE-3>PINV-1:90"},
+		"oldest due":   {Policy{Default: OldestDue{}}, "PE-1>SINV-2:600 PE-1>SINV-3:400 PE-1>SINV-1:200 PE-2>SINV-4:250 PE-3>PINV-1:90"},
+		"proportional": {Policy{Default: Proportional{}}, "PE-1>SINV-1:600 PE-1>SINV-2:360 PE-1>SINV-3:240 PE-2>SINV-4:125 PE-2>SINV-5:125 PE-3>PINV-1:90"},
+		"override": {
+			Policy{Default: Proportional{}, Overrides: map[string]Strategy{"Initech": Manual{}, "Paper Mill": FIFO{}}},
+			"PE-1>SINV-1:600 PE-1>SINV-2:360 PE-1>SINV-3:240 PE-3>PINV-1:90",
+		},
+	} {
+		allocations, err := Allocate(entries, filter, tc.policy)
+		if err != nil || amounts(allocations) != tc.want {
+			t.Errorf("%s: Allocate() = %s, %v", name, amounts(allocations), err)
+		}
+	}
+
+	// Booking the allocations leaves what FIFO did not reach
+	allocations, _ := Allocate(entries, filter, Policy{})
+	booked := append(slices.Clone(entries), Entries(allocations, date(3, 31))...)
+	if rows := Unallocated(booked, filter); len(rows) != 1 || rows[0].VoucherNo != "PE-3" || rows[0].Unallocated != 10 {
+		t.Errorf("Unallocated() after = %+v", rows)
+	}
+	open := Outstanding(booked, filter)
+	if len(open) != 4 || open[0].VoucherNo != "SINV-4" || open[0].Outstanding != 50 || open[1].Outstanding != 300 ||
+		open[2].VoucherNo != "SINV-2" || open[2].Outstanding != 400 || open[3].VoucherNo != "SINV-3" {
+		t.Errorf("Outstanding() after = %+v", open)
+	}
+}
+
+func TestAllocateRoundingAndManual(t *testing.T) {
+	payment := Unreconciled{Company: "ACME", Account: "Debtors - AC", PartyType: "Customer", Party: "Globex", VoucherType: "Payment Entry", VoucherNo: "PE-1", Unallocated: 100}
+	var invoices []Open
+	for _, no := range []string{"SINV-1", "SINV-2", "SINV-3"} {
+		invoices = append(invoices, Open{Company: "ACME", Account: "Debtors - AC", PartyType: "Customer", Party: "Globex", VoucherType: "Sales Invoice", VoucherNo: no, Outstanding: 50})
+	}
+	allocations, _ := Proportional{}.Allocate([]Unreconciled{payment}, invoices)
+	if len(allocations) != 3 || allocations[0].Amount != 33.34 || allocations[1].Amount != 33.33 || allocations[2].Amount != 33.33 {
+		t.Errorf("Proportional = %+v", allocations)
+	}
+
+	manual := Manual{Allocations: []Allocation{
+		{Company: "ACME", Account: "Debtors - AC", PartyType: "Customer", Party: "Globex", PaymentType: "Payment Entry", PaymentNo: "PE-1", InvoiceType: "Sales Invoice", InvoiceNo: "SINV-3", Amount: 50},
+		{Company: "ACME", Account: "Debtors - AC", PartyType: "Customer", Party: "Initech", PaymentType: "Payment Entry", PaymentNo: "PE-9", InvoiceType: "Sales Invoice", InvoiceNo: "SINV-9", Amount: 10},
+	}}
+	if allocations, err := manual.Allocate([]Unreconciled{payment}, invoices); err != nil || len(allocations) != 1 || allocations[0].InvoiceNo != "SINV-3" {
+		t.Errorf("Manual = %+v, %v", allocations, err)
+	}
+	manual.Allocations[0].Amount = 60
+	if _, err := manual.Allocate([]Unreconciled{payment}, invoices); !errors.Is(err, ErrOverAllocated) {
+		t.Errorf("Manual over the outstanding = %v", err)
+	}
+	manual.Allocations[0].InvoiceNo = "SINV-7"
+	if _, err := manual.Allocate([]Unreconciled{payment}, invoices); !errors.Is(err, ErrUnknownVoucher) {
+		t.Errorf("Manual unknown invoice = %v", err)
+	}
+}
+
+func TestCheckConsistency(t *testing.T) {
+	gle := func(name, voucherType, voucherNo, againstType, against, party string, debit, credit float64) ledger.GLEntry {
+		return ledger.GLEntry{
+			Name: name, PostingDate: date(4, 1), Company: "ACME", Account: "Debtors - AC", PartyType: "Customer", Party: party,
+			VoucherType: voucherType, VoucherNo: voucherNo, AgainstVoucherType: againstType, AgainstVoucher: against,
+			Debit: debit, Credit: credit,
+		}
+	}
+	gl := []ledger.GLEntry{
+		gle("GLE-1", "Sales Invoice", "SINV-1", "Sales Invoice", "SINV-1", "Globex", 1180, 0),
+		gle("GLE-2", "Payment Entry", "PE-1", "Sales Invoice", "SINV-1", "Globex", 0, 1180),
+		// Off by a penny in the payment ledger
+		gle("GLE-3", "Sales Invoice", "SINV-2", "", "", "Initech", 100.01, 0),
+		// Missing from the payment ledger
+		gle("GLE-4", "Journal Entry", "JV-1", "", "", "Initech", 250, 0),
+		// Cancelled, reversed and delinked
+		gle("GLE-5", "Sales Invoice", "SINV-3", "", "", "Globex", 400, 0),
+		gle("GLE-6", "Sales Invoice", "SINV-3", "", "", "Globex", 0, 400),
+		{Name: "GLE-7", Company: "ACME", Account: "Sales - AC", VoucherType: "Sales Invoice", VoucherNo: "SINV-1", Credit: 1180},
+	}
+	gl[4].IsCancelled = true
+	entries := []ledger.PaymentLedgerEntry{
+		ple("Sales Invoice", "SINV-1", "Sales Invoice", "SINV-1", "Customer", "Globex", date(4, 1), 1180),
+		ple("Payment Entry", "PE-1", "Sales Invoice", "SINV-1", "Customer", "Globex", date(4, 1), -1180),
+		ple("Sales Invoice", "SINV-2", "", "", "Customer", "Initech", date(4, 1), 100),
+		ple("Sales Invoice", "SINV-3", "", "", "Customer", "Globex", date(4, 1), 400),
+	}
+	entries[3].Delinked = true
+	entries[2].Name = "PLE-3"
+
+	got := CheckConsistency(gl, entries, ConsistencyFilter{Company: "ACME"})
+	if len(got) != 2 {
+		t.Fatalf("CheckConsistency() = %+v", got)
+	}
+	if d := got[0]; d.VoucherNo != "JV-1" || d.GL != 250 || d.PaymentLedger != 0 || d.Difference != 250 || len(d.GLEntries) != 1 {
+		t.Errorf("missing entries = %+v", d)
+	}
+	if d := got[1]; d.VoucherNo != "SINV-2" || d.Difference != 0.01 || len(d.GLEntries) != 1 || len(d.PaymentLedgerEntries) != 1 {
+		t.Errorf("penny = %+v", d)
+	}
+	if got := CheckConsistency(gl, entries, ConsistencyFilter{Company: "ACME", Threshold: 0.01}); len(got) != 1 || got[0].VoucherNo != "JV-1" {
+		t.Errorf("above threshold = %+v", got)
+	}
+	if got := CheckConsistency(gl, entries, ConsistencyFilter{Company: "ACME", Party: "Globex"}); len(got) != 0 {
+		t.Errorf("Globex = %+v", got)
+	}
+
+	var buf bytes.Buffer
+	if err := reportio.Write(&buf, reportio.CSV, ConsistencyReport(got)); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 1+2+3 || !strings.Contains(lines[5], "Payment Ledger Entry,PLE-3") {
+		t.Errorf("report =\n%s", buf.String())
+	}
+
+	check := ConsistencyCheck(source{gl, entries})
+	if _, err := check(t.Context(), "ACME", map[string]string{"threshold": "-1"}); !errors.Is(err, ErrInvalidThreshold) {
+		t.Errorf("negative threshold: %v", err)
+	}
+}
+
+type source struct {
+	gl  []ledger.GLEntry
+	ple []ledger.PaymentLedgerEntry
+}
+
+func (s source) GLEntries(context.Context, string) ([]ledger.GLEntry, error) { return s.gl, nil }
+
+func (s source) PaymentLedgerEntries(context.Context, string) ([]ledger.PaymentLedgerEntry, error) {
+	return s.ple, nil
+}
@@ -0,0 +1,450 @@
+package ksa
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/ledger"
+	"github.com/senguttuvang/erpnext-go/modeofpayment"
+	"github.com/senguttuvang/erpnext-go/salesinvoice"
+)
+
+// InitialHash is the previous invoice hash of a device's first invoice:
+// the Base64 of the hex SHA-256 of "0".
+const InitialHash = "NWZlY2ViNjZmZmM4NmYzOGQ5NTI3ODZjNmQ2OTZjNzljMmRiYzIzOWRkNGU5MWI0NjcyOWQ3M2EyN2ZiNTdlOQ=="
+
+// SignaturePlaceholder is the content of the signature extension until
+// the signing service replaces it with the XAdES signature.
+const SignaturePlaceholder = "<!-- cryptographic stamp -->"
+
+// Chain is the invoice counter and hash chain of one e-invoicing device
+// (EGS unit). Every invoice the device issues must go through the same
+// Chain in order; persist it after each invoice. The zero value starts a
+// new chain.
+type Chain struct {
+	Counter  int64  // Invoice counter value (ICV) of the last invoice
+	LastHash string // Hash of the last invoice; InitialHash when empty
+}
+
+// Options are the details of an e-invoice that are not on the sales
+// invoice.
+type Options struct {
+	Buyer    *Party    // With a VAT number for a standard (B2B) invoice; simplified otherwise
+	IssuedAt time.Time // Issue date and time
+	UUID     string    // Generated when empty
+}
+
+// EInvoice is a sales invoice in ZATCA's UBL format.
+type EInvoice struct {
+	Invoice      string // Sales invoice
+	UUID         string
+	Counter      int64  // ICV
+	PreviousHash string // PIH
+	Hash         string // Base64 SHA-256 of the XML without stamp and QR code
+	Simplified   bool   // B2C: reported after issue rather than cleared before
+
+	QR  QRFields
+	XML []byte
+}
+
+// EInvoice validates a sales invoice and renders it as the next invoice
+// of chain. The chain only advances when the e-invoice is generated.
+//
+// The hash covers the XML without the signature extension, the
+// cac:Signature and the QR code reference, as ZATCA computes it. The XML
+// is written in canonical form, so no canonicalisation step is needed.
+//
+// Spec: ZATCA E-Invoicing XML Implementation Standard and Security
+// Features Implementation Standards, Phase 2
+func (p *Pack) EInvoice(chain *Chain, inv *salesinvoice.SalesInvoice, opts Options) (*EInvoice, error) {
+	if err := p.ValidateInvoice(inv); err != nil {
+		return nil, err
+	}
+	buyer := opts.Buyer
+	simplified := buyer == nil || buyer.VATNumber == ""
+	if !simplified {
+		if err := buyer.validate("buyer", true); err != nil {
+			return nil, err
+		}
+	}
+	if opts.IssuedAt.IsZero() {
+		return nil, fmt.Errorf("%w: issue time of %s", ErrMissingField, inv.Name)
+	}
+
+	e := &EInvoice{
+		Invoice:      inv.Name,
+		UUID:         opts.UUID,
+		Counter:      chain.Counter + 1,
+		PreviousHash: chain.LastHash,
+		Simplified:   simplified,
+	}
+	if e.PreviousHash == "" {
+		e.PreviousHash = InitialHash
+	}
+	if e.UUID == "" {
+		var err error
+		if e.UUID, err = newUUID(); err != nil {
+			return nil, err
+		}
+	}
+
+	doc, err := p.newDocument(e, inv, buyer, opts.IssuedAt)
+	if err != nil {
+		return nil, err
+	}
+	if booked := ledger.Flt(inv.Taxes[0].TaxAmountAfterDiscountAmount, 2); booked != doc.vatTotal {
+		return nil, fmt.Errorf("%w: %s books VAT of %s, ZATCA rounds it to %s", ErrUnsupportedInvoice, inv.Name, amount(booked), amount(doc.vatTotal))
+	}
+	digest := sha256.Sum256(doc.render(false))
+	e.Hash = base64.StdEncoding.EncodeToString(digest[:])
+
+	e.QR = QRFields{
+		SellerName: p.Seller.Name,
+		VATNumber:  p.Seller.VATNumber,
+		Timestamp:  doc.issued.Format("2006-01-02T15:04:05"),
+		Total:      amount(doc.taxInclusive),
+		VATTotal:   amount(doc.vatTotal),
+		Hash:       e.Hash,
+	}
+	if doc.qr, err = e.QR.Encode(); err != nil {
+		return nil, err
+	}
+	e.XML = doc.render(true)
+
+	chain.Counter, chain.LastHash = e.Counter, e.Hash
+	return e, nil
+}
+
+// line is an invoice row with its VAT.
+type line struct {
+	name     string
+	qty      float64
+	unit     string
+	price    float64
+	net      float64
+	rate     float64
+	vat      float64
+	category string
+}
+
+// subtotal is the VAT of one category.
+type subtotal struct {
+	category string
+	rate     float64
+	taxable  float64
+	vat      float64
+}
+
+// document holds what the XML is rendered from.
+type document struct {
+	pack   *Pack
+	e      *EInvoice
+	inv    *salesinvoice.SalesInvoice
+	buyer  *Party
+	issued time.Time
+
+	lines        []line
+	subtotals    []subtotal
+	vatTotal     float64
+	taxInclusive float64
+	qr           string
+}
+
+func (p *Pack) newDocument(e *EInvoice, inv *salesinvoice.SalesInvoice, buyer *Party, issued time.Time) (*document, error) {
+	d := &document{pack: p, e: e, inv: inv, buyer: buyer, issued: issued}
+	for _, item := range inv.Items {
+		rate, err := vatRate(item, inv.Taxes[0])
+		if err != nil {
+			return nil, err
+		}
+		l := line{
+			name: item.Description, qty: item.Qty, unit: item.UOM, price: item.NetRate,
+			net: item.NetAmount, rate: rate, vat: ledger.Flt(item.NetAmount*rate/100, 2), category: category(rate),
+		}
+		if l.name == "" {
+			l.name = item.ItemCode
+		}
+		if l.unit == "" {
+			l.unit = "PCE"
+		}
+		d.lines = append(d.lines, l)
+
+		i := 0
+		for i < len(d.subtotals) && d.subtotals[i].category != l.category {
+			i++
+		}
+		if i == len(d.subtotals) {
+			d.subtotals = append(d.subtotals, subtotal{category: l.category, rate: rate})
+		}
+		d.subtotals[i].taxable += l.net
+	}
+	for i := range d.subtotals {
+		s := &d.subtotals[i]
+		s.taxable = ledger.Flt(s.taxable, 2)
+		s.vat = ledger.Flt(s.taxable*s.rate/100, 2)
+		d.vatTotal += s.vat
+	}
+	d.vatTotal = ledger.Flt(d.vatTotal, 2)
+	d.taxInclusive = ledger.Flt(inv.NetTotal+d.vatTotal, 2)
+	return d, nil
+}
+
+// render writes the UBL XML. Without the stamp it leaves out the
+// signature extension, the QR code and cac:Signature, for hashing.
+func (d *document) render(stamped bool) []byte {
+	inv, e := d.inv, d.e
+	w := &ubl{}
+	w.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	w.open("Invoice",
+		"xmlns", "urn:oasis:names:specification:ubl:schema:xsd:Invoice-2",
+		"xmlns:cac", "urn:oasis:names:specification:ubl:schema:xsd:CommonAggregateComponents-2",
+		"xmlns:cbc", "urn:oasis:names:specification:ubl:schema:xsd:CommonBasicComponents-2",
+		"xmlns:ext", "urn:oasis:names:specification:ubl:schema:xsd:CommonExtensionComponents-2")
+	if stamped {
+		w.open("ext:UBLExtensions")
+		w.open("ext:UBLExtension")
+		w.elem("ext:ExtensionURI", "urn:oasis:names:specification:ubl:dsig:enveloped:xades")
+		w.WriteString("<ext:ExtensionContent>" + SignaturePlaceholder + "</ext:ExtensionContent>")
+		w.close("ext:UBLExtension")
+		w.close("ext:UBLExtensions")
+	}
+
+	typeCode, subtype := "388", "0100000"
+	if inv.IsReturn {
+		typeCode = "381"
+	}
+	if e.Simplified {
+		subtype = "0200000"
+	}
+	w.elem("cbc:ProfileID", "reporting:1.0")
+	w.elem("cbc:ID", inv.Name)
+	w.elem("cbc:UUID", e.UUID)
+	w.elem("cbc:IssueDate", d.issued.Format(ledger.DateLayout))
+	w.elem("cbc:IssueTime", d.issued.Format("15:04:05"))
+	w.elem("cbc:InvoiceTypeCode", typeCode, "name", subtype)
+	w.elem("cbc:DocumentCurrencyCode", Currency)
+	w.elem("cbc:TaxCurrencyCode", Currency)
+	if inv.IsReturn {
+		w.open("cac:BillingReference")
+		w.open("cac:InvoiceDocumentReference")
+		w.elem("cbc:ID", inv.ReturnAgainst)
+		w.close("cac:InvoiceDocumentReference")
+		w.close("cac:BillingReference")
+	}
+
+	w.open("cac:AdditionalDocumentReference")
+	w.elem("cbc:ID", "ICV")
+	w.elem("cbc:UUID", strconv.FormatInt(e.Counter, 10))
+	w.close("cac:AdditionalDocumentReference")
+	w.attachment("PIH", e.PreviousHash)
+	if stamped {
+		w.attachment("QR", d.qr)
+		w.open("cac:Signature")
+		w.elem("cbc:ID", "urn:oasis:names:specification:ubl:signature:Invoice")
+		w.elem("cbc:SignatureMethod", "urn:oasis:names:specification:ubl:dsig:enveloped:xades")
+		w.close("cac:Signature")
+	}
+
+	w.open("cac:AccountingSupplierParty")
+	w.party(&d.pack.Seller)
+	w.close("cac:AccountingSupplierParty")
+	w.open("cac:AccountingCustomerParty")
+	buyer := Party{Name: inv.Customer}
+	if d.buyer != nil {
+		buyer = *d.buyer
+	}
+	w.party(&buyer)
+	w.close("cac:AccountingCustomerParty")
+
+	w.open("cac:Delivery")
+	w.elem("cbc:ActualDeliveryDate", inv.PostingDate.Format(ledger.DateLayout))
+	w.close("cac:Delivery")
+	w.open("cac:PaymentMeans")
+	w.elem("cbc:PaymentMeansCode", paymentMeans(inv))
+	if inv.IsReturn {
+		w.elem("cbc:InstructionNote", inv.Remarks)
+	}
+	w.close("cac:PaymentMeans")
+
+	// The VAT total in the tax currency, then the breakdown by category
+	w.open("cac:TaxTotal")
+	w.amount("cbc:TaxAmount", d.vatTotal)
+	w.close("cac:TaxTotal")
+	w.open("cac:TaxTotal")
+	w.amount("cbc:TaxAmount", d.vatTotal)
+	for _, s := range d.subtotals {
+		w.open("cac:TaxSubtotal")
+		w.amount("cbc:TaxableAmount", s.taxable)
+		w.amount("cbc:TaxAmount", s.vat)
+		w.taxCategory("cac:TaxCategory", s.category, s.rate, d.pack.ZeroRated)
+		w.close("cac:TaxSubtotal")
+	}
+	w.close("cac:TaxTotal")
+
+	w.open("cac:LegalMonetaryTotal")
+	w.amount("cbc:LineExtensionAmount", inv.NetTotal)
+	w.amount("cbc:TaxExclusiveAmount", inv.NetTotal)
+	w.amount("cbc:TaxInclusiveAmount", d.taxInclusive)
+	w.amount("cbc:AllowanceTotalAmount", 0)
+	w.amount("cbc:PrepaidAmount", 0)
+	if inv.RoundingAdjustment != 0 {
+		w.amount("cbc:PayableRoundingAmount", inv.RoundingAdjustment)
+	}
+	w.amount("cbc:PayableAmount", d.taxInclusive+inv.RoundingAdjustment)
+	w.close("cac:LegalMonetaryTotal")
+
+	for i, l := range d.lines {
+		w.open("cac:InvoiceLine")
+		w.elem("cbc:ID", strconv.Itoa(i+1))
+		w.elem("cbc:InvoicedQuantity", strconv.FormatFloat(l.qty, 'f', -1, 64), "unitCode", l.unit)
+		w.amount("cbc:LineExtensionAmount", l.net)
+		w.open("cac:TaxTotal")
+		w.amount("cbc:TaxAmount", l.vat)
+		w.amount("cbc:RoundingAmount", l.net+l.vat)
+		w.close("cac:TaxTotal")
+		w.open("cac:Item")
+		w.elem("cbc:Name", l.name)
+		w.taxCategory("cac:ClassifiedTaxCategory", l.category, l.rate, Exemption{})
+		w.close("cac:Item")
+		w.open("cac:Price")
+		w.amount("cbc:PriceAmount", l.price)
+		w.close("cac:Price")
+		w.close("cac:InvoiceLine")
+	}
+	w.close("Invoice")
+	return []byte(w.String())
+}
+
+// paymentMeans returns the UNTDID 4461 code of how the invoice is paid:
+// in cash or by card at the point of sale, otherwise by credit transfer.
+func paymentMeans(inv *salesinvoice.SalesInvoice) string {
+	switch {
+	case !inv.IsPOS || len(inv.Payments) == 0:
+		return "30"
+	case inv.Payments[0].Type == modeofpayment.Cash:
+		return "10"
+	}
+	return "48"
+}
+
+// ubl writes XML elements without indentation.
+type ubl struct {
+	strings.Builder
+}
+
+// open writes a start tag with attribute name and value pairs.
+func (w *ubl) open(tag string, attrs ...string) {
+	w.WriteString("<" + tag)
+	for i := 0; i+1 < len(attrs); i += 2 {
+		w.WriteString(" " + attrs[i] + `="` + escape(attrs[i+1]) + `"`)
+	}
+	w.WriteString(">")
+}
+
+func (w *ubl) close(tag string) {
+	w.WriteString("</" + tag + ">")
+}
+
+// elem writes an element with text content.
+func (w *ubl) elem(tag, value string, attrs ...string) {
+	w.open(tag, attrs...)
+	w.WriteString(escape(value))
+	w.close(tag)
+}
+
+// amount writes an amount in SAR.
+func (w *ubl) amount(tag string, v float64) {
+	w.elem(tag, amount(v), "currencyID", Currency)
+}
+
+// attachment writes an AdditionalDocumentReference carrying text.
+func (w *ubl) attachment(id, text string) {
+	w.open("cac:AdditionalDocumentReference")
+	w.elem("cbc:ID", id)
+	w.open("cac:Attachment")
+	w.elem("cbc:EmbeddedDocumentBinaryObject", text, "mimeCode", "text/plain")
+	w.close("cac:Attachment")
+	w.close("cac:AdditionalDocumentReference")
+}
+
+// party writes a cac:Party.
+func (w *ubl) party(p *Party) {
+	w.open("cac:Party")
+	if p.CRN != "" {
+		w.open("cac:PartyIdentification")
+		w.elem("cbc:ID", p.CRN, "schemeID", "CRN")
+		w.close("cac:PartyIdentification")
+	}
+	if a := p.Address; a != (Address{}) {
+		country := a.Country
+		if country == "" {
+			country = "SA"
+		}
+		w.open("cac:PostalAddress")
+		w.elem("cbc:StreetName", a.Street)
+		w.elem("cbc:BuildingNumber", a.Building)
+		w.elem("cbc:CitySubdivisionName", a.District)
+		w.elem("cbc:CityName", a.City)
+		w.elem("cbc:PostalZone", a.PostalCode)
+		w.open("cac:Country")
+		w.elem("cbc:IdentificationCode", country)
+		w.close("cac:Country")
+		w.close("cac:PostalAddress")
+	}
+	if p.VATNumber != "" {
+		w.open("cac:PartyTaxScheme")
+		w.elem("cbc:CompanyID", p.VATNumber)
+		w.open("cac:TaxScheme")
+		w.elem("cbc:ID", "VAT")
+		w.close("cac:TaxScheme")
+		w.close("cac:PartyTaxScheme")
+	}
+	w.open("cac:PartyLegalEntity")
+	w.elem("cbc:RegistrationName", p.Name)
+	w.close("cac:PartyLegalEntity")
+	w.close("cac:Party")
+}
+
+// taxCategory writes a VAT category, with the exemption reason for zero
+// rating when given.
+func (w *ubl) taxCategory(tag, id string, rate float64, exemption Exemption) {
+	w.open(tag)
+	w.elem("cbc:ID", id)
+	w.elem("cbc:Percent", amount(rate))
+	if id == "Z" && exemption.Code != "" {
+		w.elem("cbc:TaxExemptionReasonCode", exemption.Code)
+		w.elem("cbc:TaxExemptionReason", exemption.Reason)
+	}
+	w.open("cac:TaxScheme")
+	w.elem("cbc:ID", "VAT")
+	w.close("cac:TaxScheme")
+	w.close(tag)
+}
+
+// amount formats an amount with two decimals.
+func amount(v float64) string {
+	return strconv.FormatFloat(ledger.Flt(v, 2), 'f', 2, 64)
+}
+
+func escape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+// newUUID returns a random (version 4) UUID.
+func newUUID() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]), nil
+}
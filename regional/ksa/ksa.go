@@ -0,0 +1,182 @@
+// Package ksa is the Saudi Arabia country pack: VAT at 15% and ZATCA
+// Phase 2 e-invoicing.
+//
+// Each submitted sales invoice becomes a UBL 2.1 XML document chained to
+// the previous one by its hash, with the Base64 TLV QR code ZATCA
+// requires. The cryptographic stamp is left to the signing service holding
+// the device certificate: the XML carries an empty signature extension
+// and the QR code the first six tags until it is stamped.
+//
+// Invoices must be in SAR, with a single VAT row on the net total.
+//
+// Maps to: the KSA regional module and the ZATCA integration apps built
+// on it (ksa_compliance, zatca_erpgulf)
+package ksa
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/senguttuvang/erpnext-go/regional"
+	"github.com/senguttuvang/erpnext-go/salesinvoice"
+	"github.com/senguttuvang/erpnext-go/taxcalc"
+	"github.com/senguttuvang/erpnext-go/taxsetup"
+	"github.com/senguttuvang/erpnext-go/vatreturn"
+)
+
+// Country is the name the pack is registered under.
+const Country = "Saudi Arabia"
+
+// Currency is the currency invoices and their VAT must be reported in.
+const Currency = "SAR"
+
+// Validation errors
+var (
+	ErrInvalidVATNumber   = errors.New("VAT number must be 15 digits starting and ending with 3")
+	ErrMissingField       = errors.New("field required by ZATCA is missing")
+	ErrUnsupportedInvoice = errors.New("invoice cannot be reported to ZATCA")
+	ErrUnsupportedRate    = errors.New("VAT rate is not a KSA rate")
+)
+
+// StandardRate is the VAT rate in percent.
+const StandardRate = 15.0
+
+// Address is a national address.
+type Address struct {
+	Street     string
+	Building   string // Four digits
+	District   string
+	City       string
+	PostalCode string // Five digits
+	Country    string // ISO 3166 alpha-2; "SA" when empty
+}
+
+// Party is the seller or buyer of an invoice.
+type Party struct {
+	Name      string // Registration name
+	VATNumber string // Empty for an unregistered buyer
+	CRN       string // Commercial registration number
+	Address   Address
+}
+
+// Exemption is the reason zero-rated lines carry, as listed by ZATCA.
+type Exemption struct {
+	Code   string // e.g. "VATEX-SA-32" for exports of goods
+	Reason string
+}
+
+// Pack is the KSA country pack of one seller.
+type Pack struct {
+	Seller    Party
+	ZeroRated Exemption // Required for invoices with zero-rated lines
+}
+
+var _ regional.CountryPack = (*Pack)(nil)
+
+// Country implements regional.CountryPack.
+func (p *Pack) Country() string {
+	return Country
+}
+
+// TaxSetup implements regional.CountryPack with the taxsetup defaults.
+func (p *Pack) TaxSetup(company taxsetup.Company) (*taxsetup.Bootstrap, error) {
+	return taxsetup.For(company, Country)
+}
+
+// Reports implements regional.CountryPack. The VAT return form is not
+// configured yet.
+func (p *Pack) Reports(company taxsetup.Company) []vatreturn.Layout {
+	return nil
+}
+
+// Rounding implements regional.CountryPack. ZATCA rounds the VAT of each
+// category once, which with one VAT row is rounding per tax row.
+func (p *Pack) Rounding() regional.Rounding {
+	return regional.Rounding{Tax: taxcalc.RoundPerTaxRow}
+}
+
+// ValidateInvoice implements regional.CountryPack. It checks what the
+// e-invoice needs from the seller and the invoice; the buyer is checked
+// when the e-invoice is generated.
+func (p *Pack) ValidateInvoice(inv *salesinvoice.SalesInvoice) error {
+	if err := p.Seller.validate("seller", true); err != nil {
+		return err
+	}
+	if inv.Currency != Currency || (inv.CompanyCurrency != "" && inv.CompanyCurrency != Currency) {
+		return fmt.Errorf("%w: %s is in %s", ErrUnsupportedInvoice, inv.Name, inv.Currency)
+	}
+	if len(inv.Items) == 0 {
+		return fmt.Errorf("%w: %s has no items", ErrMissingField, inv.Name)
+	}
+	if len(inv.Taxes) != 1 || inv.Taxes[0].ChargeType != taxcalc.OnNetTotal || inv.Taxes[0].AddDeductTax == taxcalc.Deduct {
+		return fmt.Errorf("%w: %s must have one VAT row on the net total", ErrUnsupportedInvoice, inv.Name)
+	}
+	if inv.IsReturn && (inv.ReturnAgainst == "" || inv.Remarks == "") {
+		return fmt.Errorf("%w: credit note %s needs the invoice it returns and a reason", ErrMissingField, inv.Name)
+	}
+	for i, item := range inv.Items {
+		rate, err := vatRate(item, inv.Taxes[0])
+		if err != nil {
+			return err
+		}
+		if rate != StandardRate && rate != 0 {
+			return fmt.Errorf("%w: %g%% on row %d", ErrUnsupportedRate, rate, i+1)
+		}
+		if rate == 0 && p.ZeroRated.Code == "" {
+			return fmt.Errorf("%w: exemption reason for zero-rated row %d", ErrMissingField, i+1)
+		}
+	}
+	return nil
+}
+
+// validate checks a party's details. The seller, and the buyer of a
+// standard invoice, need a full address.
+func (p *Party) validate(role string, full bool) error {
+	if p.Name == "" {
+		return fmt.Errorf("%w: %s name", ErrMissingField, role)
+	}
+	if p.VATNumber != "" || role == "seller" {
+		if !ValidVATNumber(p.VATNumber) {
+			return fmt.Errorf("%w: %s %q", ErrInvalidVATNumber, role, p.VATNumber)
+		}
+	}
+	if !full {
+		return nil
+	}
+	a := p.Address
+	for _, f := range []struct{ name, value string }{
+		{"street", a.Street}, {"building number", a.Building}, {"district", a.District}, {"city", a.City}, {"postal code", a.PostalCode},
+	} {
+		if f.value == "" {
+			return fmt.Errorf("%w: %s %s", ErrMissingField, role, f.name)
+		}
+	}
+	return nil
+}
+
+// ValidVATNumber reports whether s is a KSA VAT registration number.
+func ValidVATNumber(s string) bool {
+	return len(s) == 15 && s[0] == '3' && s[14] == '3' && !strings.ContainsFunc(s, func(r rune) bool { return r < '0' || r > '9' })
+}
+
+// vatRate returns the VAT rate of an item: its item tax rate for the VAT
+// account, or the row's rate.
+func vatRate(item *taxcalc.LineItem, vat *taxcalc.TaxRow) (float64, error) {
+	taxMap, err := item.TaxMap()
+	if err != nil {
+		return 0, err
+	}
+	if rate, ok := taxMap[vat.AccountHead]; ok {
+		return rate, nil
+	}
+	return vat.Rate, nil
+}
+
+// category returns the VAT category of a rate: standard or zero rated.
+func category(rate float64) string {
+	if rate == 0 {
+		return "Z"
+	}
+	return "S"
+}
@@ -0,0 +1,202 @@
+package ksa
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/salesinvoice"
+	"github.com/senguttuvang/erpnext-go/taxcalc"
+)
+
+var riyadh = Address{Street: "King Fahd Road", Building: "1234", District: "Al Olaya", City: "Riyadh", PostalCode: "12211"}
+
+func newPack() *Pack {
+	return &Pack{
+		Seller:    Party{Name: "Najd Trading Co", VATNumber: "300000000000003", CRN: "1010010000", Address: riyadh},
+		ZeroRated: Exemption{Code: "VATEX-SA-35", Reason: "Medicines and medical equipment"},
+	}
+}
+
+// invoice is a calculated invoice: 2 lamps at 100 standard rated and a
+// box of medicine at 50 zero rated.
+func invoice(t *testing.T) *salesinvoice.SalesInvoice {
+	t.Helper()
+	inv := &salesinvoice.SalesInvoice{
+		Name: "ACC-SINV-2024-00001", Company: "Najd Trading", Customer: "Walk-in Customer",
+		PostingDate: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), CompanyCurrency: "SAR",
+		Document: taxcalc.Document{
+			Currency: "SAR",
+			Items: []*taxcalc.LineItem{
+				{ItemCode: "LAMP", Description: "Desk lamp", Qty: 2, Rate: 100},
+				{ItemCode: "MED", Qty: 1, Rate: 50, UOM: "BX", ItemTaxMap: map[string]float64{"VAT 15% - NT": 0}},
+			},
+			Taxes: []*taxcalc.TaxRow{{AccountHead: "VAT 15% - NT", ChargeType: taxcalc.OnNetTotal, Rate: 15}},
+		},
+	}
+	if err := inv.Calculate(nil); err != nil {
+		t.Fatal(err)
+	}
+	return inv
+}
+
+// tlv decodes a QR code into its values by tag.
+func tlv(t *testing.T, qr string) map[byte]string {
+	t.Helper()
+	raw, err := base64.StdEncoding.DecodeString(qr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	values := map[byte]string{}
+	for len(raw) >= 2 {
+		n := int(raw[1])
+		values[raw[0]] = string(raw[2 : 2+n])
+		raw = raw[2+n:]
+	}
+	return values
+}
+
+func TestEInvoice(t *testing.T) {
+	pack := newPack()
+	var chain Chain
+	issued := time.Date(2024, 3, 1, 14, 5, 0, 0, time.UTC)
+	e, err := pack.EInvoice(&chain, invoice(t), Options{IssuedAt: issued, UUID: "3cf5ee18-ee25-44ea-a444-2c37ba7f28be"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !e.Simplified || e.Counter != 1 || e.PreviousHash != InitialHash || chain.Counter != 1 || chain.LastHash != e.Hash {
+		t.Fatalf("e-invoice = %+v, chain %+v", e, chain)
+	}
+
+	// The XML is well formed and carries the totals: 250 net, 30 VAT
+	dec := xml.NewDecoder(bytes.NewReader(e.XML))
+	for {
+		if _, err := dec.Token(); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatalf("XML: %v\n%s", err, e.XML)
+		}
+	}
+	doc := string(e.XML)
+	for _, want := range []string{
+		`<cbc:InvoiceTypeCode name="0200000">388</cbc:InvoiceTypeCode>`,
+		`<cbc:IssueDate>2024-03-01</cbc:IssueDate><cbc:IssueTime>14:05:00</cbc:IssueTime>`,
+		`<cbc:ID>ICV</cbc:ID><cbc:UUID>1</cbc:UUID>`,
+		`<cbc:TaxExclusiveAmount currencyID="SAR">250.00</cbc:TaxExclusiveAmount><cbc:TaxInclusiveAmount currencyID="SAR">280.00</cbc:TaxInclusiveAmount>`,
+		`<cbc:TaxableAmount currencyID="SAR">50.00</cbc:TaxableAmount><cbc:TaxAmount currencyID="SAR">0.00</cbc:TaxAmount><cac:TaxCategory><cbc:ID>Z</cbc:ID><cbc:Percent>0.00</cbc:Percent><cbc:TaxExemptionReasonCode>VATEX-SA-35</cbc:TaxExemptionReasonCode>`,
+		`<cbc:InvoicedQuantity unitCode="BX">1</cbc:InvoicedQuantity>`,
+		`<cbc:Name>Desk lamp</cbc:Name>`,
+		SignaturePlaceholder,
+	} {
+		if !strings.Contains(doc, want) {
+			t.Errorf("XML lacks %s", want)
+		}
+	}
+
+	// The hash covers the XML without the stamp and the QR code
+	stripped := doc
+	for _, part := range []string{"<ext:UBLExtensions>", "<cac:AdditionalDocumentReference><cbc:ID>QR</cbc:ID>", "<cac:Signature>"} {
+		start := strings.Index(stripped, part)
+		closing := "</" + part[1:strings.IndexByte(part, '>')+1]
+		end := strings.Index(stripped[start:], closing) + len(closing)
+		stripped = stripped[:start] + stripped[start+end:]
+	}
+	if digest := sha256.Sum256([]byte(stripped)); base64.StdEncoding.EncodeToString(digest[:]) != e.Hash {
+		t.Error("hash does not match the XML")
+	}
+
+	qr := tlv(t, e.QR.mustEncode(t))
+	if qr[1] != "Najd Trading Co" || qr[2] != "300000000000003" || qr[3] != "2024-03-01T14:05:00" || qr[4] != "280.00" || qr[5] != "30.00" || qr[6] != e.Hash || len(qr) != 6 {
+		t.Errorf("QR = %q", qr)
+	}
+	if !strings.Contains(doc, e.QR.mustEncode(t)) {
+		t.Error("QR code not embedded")
+	}
+
+	// A standard credit note chains to the invoice
+	ret := invoice(t)
+	ret.Name, ret.IsReturn, ret.ReturnAgainst, ret.Remarks = "ACC-SINV-2024-00002", true, "ACC-SINV-2024-00001", "Damaged in transit"
+	buyer := &Party{Name: "Red Sea Hotels", VATNumber: "310000000000003", Address: Address{Street: "Corniche", Building: "4321", District: "Al Hamra", City: "Jeddah", PostalCode: "23323"}}
+	cn, err := pack.EInvoice(&chain, ret, Options{Buyer: buyer, IssuedAt: issued.Add(time.Hour)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cn.Simplified || cn.Counter != 2 || cn.PreviousHash != e.Hash || len(cn.UUID) != 36 || cn.UUID[14] != '4' {
+		t.Errorf("credit note = %+v", cn)
+	}
+	for _, want := range []string{
+		`<cbc:InvoiceTypeCode name="0100000">381</cbc:InvoiceTypeCode>`,
+		`<cac:BillingReference><cac:InvoiceDocumentReference><cbc:ID>ACC-SINV-2024-00001</cbc:ID>`,
+		`<cbc:InstructionNote>Damaged in transit</cbc:InstructionNote>`,
+		`<cbc:CompanyID>310000000000003</cbc:CompanyID>`,
+	} {
+		if !strings.Contains(string(cn.XML), want) {
+			t.Errorf("credit note XML lacks %s", want)
+		}
+	}
+
+	// A failed e-invoice leaves the chain alone
+	buyer.Address.City = ""
+	if _, err := pack.EInvoice(&chain, ret, Options{Buyer: buyer, IssuedAt: issued}); !errors.Is(err, ErrMissingField) {
+		t.Errorf("EInvoice() without buyer city = %v", err)
+	}
+	if chain.Counter != 2 || chain.LastHash != cn.Hash {
+		t.Errorf("chain = %+v", chain)
+	}
+}
+
+func (q *QRFields) mustEncode(t *testing.T) string {
+	t.Helper()
+	s, err := q.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestValidateInvoice(t *testing.T) {
+	for name, tt := range map[string]struct {
+		change func(*Pack, *salesinvoice.SalesInvoice)
+		want   error
+	}{
+		"valid":            {func(*Pack, *salesinvoice.SalesInvoice) {}, nil},
+		"seller VAT":       {func(p *Pack, _ *salesinvoice.SalesInvoice) { p.Seller.VATNumber = "300000000000004" }, ErrInvalidVATNumber},
+		"seller address":   {func(p *Pack, _ *salesinvoice.SalesInvoice) { p.Seller.Address.PostalCode = "" }, ErrMissingField},
+		"currency":         {func(_ *Pack, inv *salesinvoice.SalesInvoice) { inv.Currency = "USD" }, ErrUnsupportedInvoice},
+		"two tax rows":     {func(_ *Pack, inv *salesinvoice.SalesInvoice) { inv.Taxes = append(inv.Taxes, inv.Taxes[0]) }, ErrUnsupportedInvoice},
+		"rate":             {func(_ *Pack, inv *salesinvoice.SalesInvoice) { inv.Taxes[0].Rate = 5 }, ErrUnsupportedRate},
+		"no reason":        {func(p *Pack, _ *salesinvoice.SalesInvoice) { p.ZeroRated = Exemption{} }, ErrMissingField},
+		"return no reason": {func(_ *Pack, inv *salesinvoice.SalesInvoice) { inv.IsReturn, inv.ReturnAgainst = true, "X" }, ErrMissingField},
+	} {
+		pack, inv := newPack(), invoice(t)
+		tt.change(pack, inv)
+		if err := pack.ValidateInvoice(inv); !errors.Is(err, tt.want) || (tt.want == nil && err != nil) {
+			t.Errorf("%s: ValidateInvoice() = %v, want %v", name, err, tt.want)
+		}
+	}
+
+	// VAT booked other than ZATCA rounds it is rejected
+	pack, inv := newPack(), invoice(t)
+	inv.Taxes[0].TaxAmountAfterDiscountAmount += 0.01
+	if _, err := pack.EInvoice(&Chain{}, inv, Options{IssuedAt: time.Now()}); !errors.Is(err, ErrUnsupportedInvoice) {
+		t.Errorf("EInvoice() with other VAT = %v", err)
+	}
+}
+
+func TestQRFieldsEncode(t *testing.T) {
+	q := QRFields{SellerName: "شركة نجد", VATNumber: "300000000000003", Signature: []byte{1, 2}}
+	got := tlv(t, q.mustEncode(t))
+	if got[1] != "شركة نجد" || got[7] != "\x01\x02" || len(got) != 7 {
+		t.Errorf("TLV = %q", got)
+	}
+	q.SellerName = strings.Repeat("x", 256)
+	if _, err := q.Encode(); !errors.Is(err, ErrQRFieldTooLong) {
+		t.Errorf("Encode() = %v", err)
+	}
+}
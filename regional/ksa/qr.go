@@ -0,0 +1,48 @@
+package ksa
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+)
+
+// ErrQRFieldTooLong is returned for a QR value longer than 255 bytes.
+var ErrQRFieldTooLong = errors.New("QR code field longer than 255 bytes")
+
+// QRFields are the tags of the ZATCA QR code. Tags 7 to 9 come from the
+// cryptographic stamp and are left out while they are empty.
+//
+// Spec: ZATCA E-Invoicing Security Features Implementation Standards,
+// section 4 (QR code)
+type QRFields struct {
+	SellerName string // Tag 1
+	VATNumber  string // Tag 2
+	Timestamp  string // Tag 3: issue date and time, as 2024-01-31T14:05:00
+	Total      string // Tag 4: total with VAT
+	VATTotal   string // Tag 5
+
+	Hash                 string // Tag 6: Base64 SHA-256 hash of the invoice
+	Signature            []byte // Tag 7: ECDSA signature of the hash
+	PublicKey            []byte // Tag 8: ECDSA public key
+	CertificateSignature []byte // Tag 9: the stamp certificate's signature; simplified invoices only
+}
+
+// Encode returns the Base64 TLV payload of the QR code: for each tag, the
+// tag number and value length as one byte each, then the UTF-8 value.
+func (q *QRFields) Encode() (string, error) {
+	var tlv []byte
+	for i, value := range [][]byte{
+		[]byte(q.SellerName), []byte(q.VATNumber), []byte(q.Timestamp), []byte(q.Total), []byte(q.VATTotal),
+		[]byte(q.Hash), q.Signature, q.PublicKey, q.CertificateSignature,
+	} {
+		if i >= 6 && len(value) == 0 {
+			continue
+		}
+		if len(value) > 255 {
+			return "", fmt.Errorf("%w: tag %d", ErrQRFieldTooLong, i+1)
+		}
+		tlv = append(tlv, byte(i+1), byte(len(value)))
+		tlv = append(tlv, value...)
+	}
+	return base64.StdEncoding.EncodeToString(tlv), nil
+}
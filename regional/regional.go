@@ -0,0 +1,169 @@
+// Package regional defines country packs: the tax templates, statutory
+// returns, invoice checks and rounding rules a country's compliance
+// requires, kept out of the core engine.
+//
+// A regional module, such as India GST, UAE VAT or KSA ZATCA, implements
+// CountryPack in its own package and is registered with a Registry when
+// the application starts. Countries without a module of their own get a
+// Basic pack over the taxsetup defaults from RegisterDefaults.
+//
+// Maps to: erpnext/regional and the regional_overrides hooks in hooks.py
+package regional
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/senguttuvang/erpnext-go/salesinvoice"
+	"github.com/senguttuvang/erpnext-go/taxcalc"
+	"github.com/senguttuvang/erpnext-go/taxsetup"
+	"github.com/senguttuvang/erpnext-go/vatreturn"
+)
+
+// Registry errors
+var (
+	ErrUnknownCountry = errors.New("no country pack registered for country")
+	ErrInvalidPack    = errors.New("country pack has no country")
+)
+
+// CountryPack is the compliance module of one country.
+type CountryPack interface {
+	// Country returns the country name, as in taxsetup, e.g. "India".
+	Country() string
+
+	// TaxSetup returns the tax accounts and templates to create for a
+	// new company.
+	TaxSetup(company taxsetup.Company) (*taxsetup.Bootstrap, error)
+
+	// Reports returns the statutory return forms over the company's tax
+	// accounts.
+	Reports(company taxsetup.Company) []vatreturn.Layout
+
+	// ValidateInvoice checks the fields the country requires on a sales
+	// invoice before it is submitted.
+	ValidateInvoice(inv *salesinvoice.SalesInvoice) error
+
+	// Rounding returns the country's rounding rules.
+	Rounding() Rounding
+}
+
+// Rounding is how a country rounds taxes and totals.
+type Rounding struct {
+	Tax  taxcalc.TaxRounding // When tax amounts are rounded; per tax row when empty
+	Cash float64             // Fraction grand totals are rounded to, e.g. 0.05; zero for none
+}
+
+// Apply sets the rounding of a document that does not set its own.
+func (r Rounding) Apply(doc *taxcalc.Document) {
+	if doc.TaxRounding == "" {
+		doc.TaxRounding = r.Tax
+	}
+	if doc.CashRounding == 0 {
+		doc.CashRounding = r.Cash
+	}
+}
+
+// Basic is a CountryPack built from configuration: the taxsetup defaults
+// of the country, optional return forms and invoice checks, and rounding
+// rules.
+type Basic struct {
+	Name          string
+	Layouts       func(company taxsetup.Company) []vatreturn.Layout // Optional
+	Checks        []func(inv *salesinvoice.SalesInvoice) error      // Run in order; the first failure stops
+	RoundingRules Rounding
+}
+
+// Country implements CountryPack.
+func (b *Basic) Country() string {
+	return b.Name
+}
+
+// TaxSetup implements CountryPack with the taxsetup defaults.
+func (b *Basic) TaxSetup(company taxsetup.Company) (*taxsetup.Bootstrap, error) {
+	return taxsetup.For(company, b.Name)
+}
+
+// Reports implements CountryPack.
+func (b *Basic) Reports(company taxsetup.Company) []vatreturn.Layout {
+	if b.Layouts == nil {
+		return nil
+	}
+	return b.Layouts(company)
+}
+
+// ValidateInvoice implements CountryPack.
+func (b *Basic) ValidateInvoice(inv *salesinvoice.SalesInvoice) error {
+	for _, check := range b.Checks {
+		if err := check(inv); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Rounding implements CountryPack.
+func (b *Basic) Rounding() Rounding {
+	return b.RoundingRules
+}
+
+// Registry holds the country pack of each country. It is safe for
+// concurrent use.
+type Registry struct {
+	mu    sync.RWMutex
+	packs map[string]CountryPack
+}
+
+// NewRegistry creates an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{packs: make(map[string]CountryPack)}
+}
+
+// Register makes a pack the one for its country, replacing any registered
+// before.
+func (r *Registry) Register(pack CountryPack) error {
+	country := pack.Country()
+	if country == "" {
+		return ErrInvalidPack
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.packs[country] = pack
+	return nil
+}
+
+// RegisterDefaults registers a Basic pack for each country with taxsetup
+// defaults that has no pack yet.
+func (r *Registry) RegisterDefaults() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, country := range taxsetup.Countries() {
+		if _, ok := r.packs[country]; !ok {
+			r.packs[country] = &Basic{Name: country}
+		}
+	}
+}
+
+// For returns the pack of a country.
+func (r *Registry) For(country string) (CountryPack, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	pack, ok := r.packs[country]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownCountry, country)
+	}
+	return pack, nil
+}
+
+// Countries returns the countries with a pack, sorted.
+func (r *Registry) Countries() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.packs))
+	for name := range r.packs {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
@@ -0,0 +1,104 @@
+package regional
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/senguttuvang/erpnext-go/salesinvoice"
+	"github.com/senguttuvang/erpnext-go/taxcalc"
+	"github.com/senguttuvang/erpnext-go/taxsetup"
+	"github.com/senguttuvang/erpnext-go/vatreturn"
+)
+
+var errNoHSN = errors.New("HSN code is required")
+
+// india is a regional module as a separate package would write it.
+var india = &Basic{
+	Name: "India",
+	Layouts: func(company taxsetup.Company) []vatreturn.Layout {
+		b, _ := taxsetup.For(company, "India")
+		return []vatreturn.Layout{{Name: "GSTR-3B", Boxes: []vatreturn.Box{
+			{Code: "3.1", Label: "Outward taxable supplies", Accounts: vatreturn.TemplateAccounts(vatreturn.CreditSide, b.SalesTemplates...)},
+		}}}
+	},
+	Checks: []func(*salesinvoice.SalesInvoice) error{
+		func(inv *salesinvoice.SalesInvoice) error {
+			for i, item := range inv.Items {
+				if item.HSNCode == "" {
+					return fmt.Errorf("%w: row %d (%s)", errNoHSN, i+1, item.ItemCode)
+				}
+			}
+			return nil
+		},
+	},
+	RoundingRules: Rounding{Tax: taxcalc.RoundPerItem},
+}
+
+func TestRegistry(t *testing.T) {
+	r := NewRegistry()
+	if err := r.Register(india); err != nil {
+		t.Fatal(err)
+	}
+	r.RegisterDefaults()
+	if err := r.Register(&Basic{}); !errors.Is(err, ErrInvalidPack) {
+		t.Errorf("Register() without a country = %v", err)
+	}
+	if got := r.Countries(); !slices.Equal(got, taxsetup.Countries()) {
+		t.Errorf("Countries() = %v", got)
+	}
+	if _, err := r.For("Japan"); !errors.Is(err, ErrUnknownCountry) {
+		t.Errorf("For() = %v", err)
+	}
+
+	// The registered module is kept over the default
+	pack, err := r.For("India")
+	if err != nil || pack != india {
+		t.Fatalf("For(India) = %v, %v", pack, err)
+	}
+	company := taxsetup.Company{Name: "ACME India", Abbr: "AI", Currency: "INR"}
+	reports := pack.Reports(company)
+	if len(reports) != 1 || reports[0].Validate() != nil || len(reports[0].Boxes[0].Accounts) != 3 {
+		t.Errorf("reports = %+v", reports)
+	}
+
+	inv := &salesinvoice.SalesInvoice{Document: taxcalc.Document{Items: []*taxcalc.LineItem{
+		{ItemCode: "WIDGET", HSNCode: "8471"}, {ItemCode: "SERVICE"},
+	}}}
+	if err := pack.ValidateInvoice(inv); !errors.Is(err, errNoHSN) {
+		t.Errorf("ValidateInvoice() = %v", err)
+	}
+	inv.Items[1].HSNCode = "9983"
+	if err := pack.ValidateInvoice(inv); err != nil {
+		t.Errorf("ValidateInvoice() = %v", err)
+	}
+	pack.Rounding().Apply(&inv.Document)
+	if inv.TaxRounding != taxcalc.RoundPerItem {
+		t.Errorf("tax rounding = %q", inv.TaxRounding)
+	}
+
+	// Defaults set up taxes and check nothing
+	uae, _ := r.For("United Arab Emirates")
+	b, err := uae.TaxSetup(taxsetup.Company{Name: "ACME FZE", Abbr: "AF", Currency: "AED"})
+	if err != nil || len(b.SalesTemplates) != 1 || b.SalesTemplates[0].Name != "UAE VAT 5% - AF" {
+		t.Errorf("TaxSetup() = %+v, %v", b, err)
+	}
+	if uae.Reports(company) != nil || uae.ValidateInvoice(&salesinvoice.SalesInvoice{}) != nil || uae.Rounding() != (Rounding{}) {
+		t.Error("default pack is not empty")
+	}
+}
+
+func TestRoundingApply(t *testing.T) {
+	swiss := Rounding{Cash: 0.05}
+	doc := &taxcalc.Document{TaxRounding: taxcalc.RoundPerDocument}
+	swiss.Apply(doc)
+	if doc.CashRounding != 0.05 || doc.TaxRounding != taxcalc.RoundPerDocument {
+		t.Errorf("rounding = %v, %q", doc.CashRounding, doc.TaxRounding)
+	}
+	doc.CashRounding = 0.10
+	swiss.Apply(doc)
+	if doc.CashRounding != 0.10 {
+		t.Errorf("document rounding overridden: %v", doc.CashRounding)
+	}
+}
@@ -0,0 +1,299 @@
+// Package register builds the Sales Register and Purchase Register: one
+// row per invoice with its net total, a column for each tax account head,
+// the grand total and what was paid at the invoice by mode of payment.
+//
+// Registers implement reportio.Report, so they download as CSV, XLSX or
+// JSON Lines like every other report.
+//
+// Migrated from: erpnext/accounts/report/sales_register/sales_register.py
+// and erpnext/accounts/report/purchase_register/purchase_register.py
+package register
+
+import (
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/daterange"
+	"github.com/senguttuvang/erpnext-go/ledger"
+	"github.com/senguttuvang/erpnext-go/reportio"
+	"github.com/senguttuvang/erpnext-go/salesinvoice"
+	"github.com/senguttuvang/erpnext-go/taxcalc"
+)
+
+// Kind selects the sales or the purchase register.
+type Kind string
+
+const (
+	Sales    Kind = "Sales Register"
+	Purchase Kind = "Purchase Register"
+)
+
+// partyType returns the party type of the register's invoices.
+func (k Kind) partyType() string {
+	if k == Purchase {
+		return "Supplier"
+	}
+	return "Customer"
+}
+
+// Invoice is a sales or purchase invoice as the register reads it. Items
+// and taxes come from the calculated taxcalc.Document.
+type Invoice struct {
+	VoucherType string // "Sales Invoice" or "Purchase Invoice"
+	Name        string
+	Company     string
+	PostingDate time.Time
+	Party       string
+	Account     string // Receivable or payable account
+	Cancelled   bool
+	Payments    []Payment // Paid with the invoice, as at the point of sale
+
+	taxcalc.Document
+}
+
+// Payment is an amount paid with an invoice.
+type Payment struct {
+	ModeOfPayment string
+	BaseAmount    float64 // Company currency
+}
+
+// FromSalesInvoice returns the register view of a sales invoice. Drafts
+// are returned as cancelled, since neither appears in the register.
+func FromSalesInvoice(inv *salesinvoice.SalesInvoice) Invoice {
+	r := Invoice{
+		VoucherType: salesinvoice.VoucherType,
+		Name:        inv.Name,
+		Company:     inv.Company,
+		PostingDate: inv.PostingDate,
+		Party:       inv.Customer,
+		Account:     inv.DebitTo,
+		Cancelled:   inv.Status != salesinvoice.Submitted,
+		Document:    inv.Document,
+	}
+	if inv.IsPOS {
+		for _, p := range inv.Payments {
+			r.Payments = append(r.Payments, Payment{ModeOfPayment: p.ModeOfPayment, BaseAmount: p.BaseAmount})
+		}
+		if inv.BaseChangeAmount != 0 {
+			r.Payments = append(r.Payments, Payment{ModeOfPayment: changeMode(inv), BaseAmount: -inv.BaseChangeAmount})
+		}
+	}
+	return r
+}
+
+// changeMode returns the mode of payment change was given in: that of the
+// payment into the change account, or else the first.
+func changeMode(inv *salesinvoice.SalesInvoice) string {
+	for _, p := range inv.Payments {
+		if p.Account == inv.AccountForChangeAmount {
+			return p.ModeOfPayment
+		}
+	}
+	if len(inv.Payments) > 0 {
+		return inv.Payments[0].ModeOfPayment
+	}
+	return ""
+}
+
+// Filter selects the invoices of a register.
+type Filter struct {
+	Company string
+	Period  daterange.Range
+	Party   string // Optional
+}
+
+// Row is one invoice of a register. Amounts are in company currency.
+type Row struct {
+	PostingDate  time.Time
+	VoucherType  string
+	VoucherNo    string
+	Party        string
+	Account      string
+	Currency     string
+	NetTotal     float64
+	Taxes        map[string]float64 // Tax account head -> amount; deducted taxes are negative
+	TotalTax     float64
+	GrandTotal   float64
+	RoundedTotal float64
+	Payments     map[string]float64 // Mode of payment -> amount
+}
+
+// Register is a sales or purchase register.
+type Register struct {
+	Kind        Kind
+	TaxAccounts []string // Tax column heads, sorted
+	Modes       []string // Mode of payment columns, sorted
+	Lines       []Row    // By posting date, then voucher number
+}
+
+// Build lists the submitted invoices matching the filter.
+//
+// Tax columns are aggregated from each tax row's item-wise tax detail, so
+// they agree with the item-wise register; taxes on an account head
+// appearing on several rows are summed.
+//
+// Maps to: execute() in sales_register.py and purchase_register.py
+func Build(kind Kind, invoices []Invoice, filter Filter) *Register {
+	reg := &Register{Kind: kind}
+	taxAccounts, modes := map[string]bool{}, map[string]bool{}
+	for i := range invoices {
+		inv := &invoices[i]
+		if inv.Cancelled || inv.Company != filter.Company || !filter.Period.Contains(inv.PostingDate) ||
+			(filter.Party != "" && inv.Party != filter.Party) {
+			continue
+		}
+		row := Row{
+			PostingDate:  inv.PostingDate,
+			VoucherType:  inv.VoucherType,
+			VoucherNo:    inv.Name,
+			Party:        inv.Party,
+			Account:      inv.Account,
+			Currency:     inv.Currency,
+			NetTotal:     inv.BaseNetTotal,
+			GrandTotal:   inv.BaseGrandTotal,
+			RoundedTotal: inv.BaseRoundedTotal,
+			Taxes:        itemWiseTaxes(&inv.Document),
+			Payments:     map[string]float64{},
+		}
+		for account, amount := range row.Taxes {
+			taxAccounts[account] = true
+			row.TotalTax += amount
+		}
+		row.TotalTax = ledger.Flt(row.TotalTax, 2)
+		for _, p := range inv.Payments {
+			modes[p.ModeOfPayment] = true
+			row.Payments[p.ModeOfPayment] = ledger.Flt(row.Payments[p.ModeOfPayment]+p.BaseAmount, 2)
+		}
+		reg.Lines = append(reg.Lines, row)
+	}
+	slices.SortStableFunc(reg.Lines, func(a, b Row) int {
+		if c := a.PostingDate.Compare(b.PostingDate); c != 0 {
+			return c
+		}
+		return strings.Compare(a.VoucherNo, b.VoucherNo)
+	})
+	reg.TaxAccounts = slices.Sorted(maps.Keys(taxAccounts))
+	reg.Modes = slices.Sorted(maps.Keys(modes))
+	return reg
+}
+
+// itemWiseTaxes sums each tax row's item-wise tax detail into company
+// currency by account head. Rows without a detail, such as those of
+// documents not calculated here, fall back to their base tax amount.
+//
+// Maps to: get_tax_accounts() in sales_register.py
+func itemWiseTaxes(doc *taxcalc.Document) map[string]float64 {
+	rate := doc.ConversionRate
+	if rate == 0 {
+		rate = 1
+	}
+	taxes := map[string]float64{}
+	for _, tax := range doc.Taxes {
+		amount := tax.BaseTaxAmountAfterDiscountAmount
+		if len(tax.ItemWiseTaxDetail) > 0 {
+			amount = 0
+			for _, v := range tax.ItemWiseTaxDetail {
+				amount += v * rate
+			}
+		}
+		if tax.AddDeductTax == taxcalc.Deduct {
+			amount = -amount
+		}
+		taxes[tax.AccountHead] += amount
+	}
+	for account, amount := range taxes {
+		taxes[account] = ledger.Flt(amount, 2)
+	}
+	return taxes
+}
+
+// Total returns the column totals of the register as a row.
+func (r *Register) Total() Row {
+	total := Row{Taxes: map[string]float64{}, Payments: map[string]float64{}}
+	for _, row := range r.Lines {
+		total.NetTotal += row.NetTotal
+		total.TotalTax += row.TotalTax
+		total.GrandTotal += row.GrandTotal
+		total.RoundedTotal += row.RoundedTotal
+		for account, amount := range row.Taxes {
+			total.Taxes[account] += amount
+		}
+		for mode, amount := range row.Payments {
+			total.Payments[mode] += amount
+		}
+	}
+	total.NetTotal, total.TotalTax = ledger.Flt(total.NetTotal, 2), ledger.Flt(total.TotalTax, 2)
+	total.GrandTotal, total.RoundedTotal = ledger.Flt(total.GrandTotal, 2), ledger.Flt(total.RoundedTotal, 2)
+	for account, amount := range total.Taxes {
+		total.Taxes[account] = ledger.Flt(amount, 2)
+	}
+	for mode, amount := range total.Payments {
+		total.Payments[mode] = ledger.Flt(amount, 2)
+	}
+	return total
+}
+
+// Title implements reportio.Titled.
+func (r *Register) Title() string { return string(r.Kind) }
+
+// Columns implements reportio.Report: the fixed invoice columns, a column
+// per tax account head and one per mode of payment.
+func (r *Register) Columns() []reportio.Column {
+	voucherType, accountLabel := salesinvoice.VoucherType, "Receivable Account"
+	if r.Kind == Purchase {
+		voucherType, accountLabel = "Purchase Invoice", "Payable Account"
+	}
+	cols := []reportio.Column{
+		{Fieldname: "posting_date", Label: "Posting Date", Fieldtype: reportio.Date},
+		{Fieldname: "voucher_no", Label: "Invoice", Fieldtype: reportio.Link, Options: voucherType},
+		{Fieldname: "party", Label: r.Kind.partyType(), Fieldtype: reportio.Link, Options: r.Kind.partyType()},
+		{Fieldname: "account", Label: accountLabel, Fieldtype: reportio.Link, Options: "Account"},
+		{Fieldname: "currency", Label: "Currency", Fieldtype: reportio.Link, Options: "Currency"},
+		{Fieldname: "net_total", Label: "Net Total", Fieldtype: reportio.Currency},
+	}
+	for _, account := range r.TaxAccounts {
+		cols = append(cols, reportio.Column{Fieldname: fieldname(account), Label: account, Fieldtype: reportio.Currency})
+	}
+	cols = append(cols,
+		reportio.Column{Fieldname: "total_tax", Label: "Total Tax", Fieldtype: reportio.Currency},
+		reportio.Column{Fieldname: "grand_total", Label: "Grand Total", Fieldtype: reportio.Currency},
+		reportio.Column{Fieldname: "rounded_total", Label: "Rounded Total", Fieldtype: reportio.Currency},
+	)
+	for _, mode := range r.Modes {
+		cols = append(cols, reportio.Column{Fieldname: fieldname("mop " + mode), Label: mode, Fieldtype: reportio.Currency})
+	}
+	return cols
+}
+
+// Rows implements reportio.Report.
+func (r *Register) Rows(fn func([]any) error) error {
+	for _, row := range r.Lines {
+		values := []any{row.PostingDate, row.VoucherNo, row.Party, row.Account, row.Currency, row.NetTotal}
+		for _, account := range r.TaxAccounts {
+			values = append(values, row.Taxes[account])
+		}
+		values = append(values, row.TotalTax, row.GrandTotal, row.RoundedTotal)
+		for _, mode := range r.Modes {
+			values = append(values, row.Payments[mode])
+		}
+		if err := fn(values); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fieldname turns a label into a column fieldname, as frappe.scrub does.
+func fieldname(label string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'A' && r <= 'Z':
+			return r + 'a' - 'A'
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			return r
+		}
+		return '_'
+	}, label)
+}
@@ -0,0 +1,115 @@
+package register
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/daterange"
+	"github.com/senguttuvang/erpnext-go/modeofpayment"
+	"github.com/senguttuvang/erpnext-go/reportio"
+	"github.com/senguttuvang/erpnext-go/salesinvoice"
+	"github.com/senguttuvang/erpnext-go/taxcalc"
+)
+
+func date(day int) time.Time { return time.Date(2024, 4, day, 0, 0, 0, 0, time.UTC) }
+
+func gst(items ...*taxcalc.LineItem) taxcalc.Document {
+	return taxcalc.Document{
+		Currency: "INR",
+		Items:    items,
+		Taxes: []*taxcalc.TaxRow{
+			{AccountHead: "CGST - AC", ChargeType: taxcalc.OnNetTotal, Rate: 9},
+			{AccountHead: "SGST - AC", ChargeType: taxcalc.OnNetTotal, Rate: 9},
+		},
+	}
+}
+
+func salesInvoice(t *testing.T, name string, day int, status salesinvoice.Status, doc taxcalc.Document) *salesinvoice.SalesInvoice {
+	t.Helper()
+	inv := &salesinvoice.SalesInvoice{
+		Name: name, Company: "ACME", Customer: "Globex", PostingDate: date(day),
+		DebitTo: "Debtors - AC", CompanyCurrency: "INR", Status: status, Document: doc,
+	}
+	if err := inv.Calculate(nil); err != nil {
+		t.Fatal(err)
+	}
+	return inv
+}
+
+func TestSalesRegister(t *testing.T) {
+	pos := salesInvoice(t, "SINV-3", 2, salesinvoice.Submitted, gst(&taxcalc.LineItem{ItemCode: "PEN", Qty: 2, Rate: 50}))
+	pos.IsPOS, pos.AccountForChangeAmount = true, "Cash - AC"
+	pos.Payments = []*salesinvoice.Payment{
+		{ModeOfPayment: "Cash", Type: modeofpayment.Cash, Account: "Cash - AC", Amount: 100},
+		{ModeOfPayment: "UPI", Type: modeofpayment.Bank, Account: "Bank - AC", Amount: 50},
+	}
+	if err := pos.Calculate(nil); err != nil {
+		t.Fatal(err)
+	}
+	igst := gst(&taxcalc.LineItem{ItemCode: "BOOK", Qty: 1, Rate: 1000}, &taxcalc.LineItem{ItemCode: "BOOK", Qty: 1, Rate: 500})
+	igst.Taxes = []*taxcalc.TaxRow{{AccountHead: "IGST - AC", ChargeType: taxcalc.OnNetTotal, Rate: 18}}
+
+	invoices := []Invoice{
+		FromSalesInvoice(pos),
+		FromSalesInvoice(salesInvoice(t, "SINV-1", 1, salesinvoice.Submitted, gst(&taxcalc.LineItem{ItemCode: "PEN", Qty: 10, Rate: 50}))),
+		FromSalesInvoice(salesInvoice(t, "SINV-2", 1, salesinvoice.Submitted, igst)),
+		FromSalesInvoice(salesInvoice(t, "SINV-4", 1, salesinvoice.Cancelled, gst(&taxcalc.LineItem{ItemCode: "PEN", Qty: 1, Rate: 50}))),
+		FromSalesInvoice(salesInvoice(t, "SINV-5", 30, salesinvoice.Submitted, gst(&taxcalc.LineItem{ItemCode: "PEN", Qty: 1, Rate: 50}))),
+	}
+	reg := Build(Sales, invoices, Filter{Company: "ACME", Period: daterange.Inclusive(date(1), date(29))})
+
+	var names []string
+	for _, row := range reg.Lines {
+		names = append(names, row.VoucherNo)
+	}
+	if strings.Join(names, ",") != "SINV-1,SINV-2,SINV-3" {
+		t.Fatalf("rows = %v", names)
+	}
+	if strings.Join(reg.TaxAccounts, ",") != "CGST - AC,IGST - AC,SGST - AC" || strings.Join(reg.Modes, ",") != "Cash,UPI" {
+		t.Fatalf("columns: taxes %v, modes %v", reg.TaxAccounts, reg.Modes)
+	}
+	if r := reg.Lines[1]; r.Taxes["IGST - AC"] != 270 || r.TotalTax != 270 || r.GrandTotal != 1770 {
+		t.Errorf("SINV-2 = %+v", r)
+	}
+	if r := reg.Lines[2]; r.Taxes["CGST - AC"] != 9 || r.Payments["Cash"] != 68 || r.Payments["UPI"] != 50 {
+		t.Errorf("SINV-3 = %+v", r) // 150 paid on 118, 32 change from the cash
+	}
+	total := reg.Total()
+	if total.NetTotal != 2100 || total.TotalTax != 378 || total.Taxes["SGST - AC"] != 54 {
+		t.Errorf("total = %+v", total)
+	}
+
+	var buf bytes.Buffer
+	if err := reportio.Write(&buf, reportio.CSV, reg); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 4 || !strings.Contains(lines[0], "CGST - AC,IGST - AC,SGST - AC,Total Tax,Grand Total,Rounded Total,Cash,UPI") {
+		t.Errorf("CSV:\n%s", buf.String())
+	}
+}
+
+func TestPurchaseRegister(t *testing.T) {
+	doc := gst(&taxcalc.LineItem{ItemCode: "PAPER", Qty: 4, Rate: 250})
+	doc.Taxes = append(doc.Taxes, &taxcalc.TaxRow{AccountHead: "TDS Payable - AC", ChargeType: taxcalc.OnNetTotal, Rate: 2, AddDeductTax: taxcalc.Deduct})
+	if err := taxcalc.NewCalculator(&doc, nil).Calculate(); err != nil {
+		t.Fatal(err)
+	}
+	reg := Build(Purchase, []Invoice{{
+		VoucherType: "Purchase Invoice", Name: "PINV-1", Company: "ACME", PostingDate: date(3),
+		Party: "Paper Mill", Account: "Creditors - AC", Document: doc,
+	}}, Filter{Company: "ACME", Period: daterange.Inclusive(date(1), date(30)), Party: "Paper Mill"})
+
+	if len(reg.Lines) != 1 {
+		t.Fatalf("rows = %+v", reg.Lines)
+	}
+	if r := reg.Lines[0]; r.Taxes["TDS Payable - AC"] != -20 || r.TotalTax != 160 || r.GrandTotal != 1160 {
+		t.Errorf("PINV-1 = %+v", r)
+	}
+	cols := reg.Columns()
+	if cols[2].Label != "Supplier" || cols[3].Label != "Payable Account" || cols[8].Fieldname != "tds_payable___ac" {
+		t.Errorf("columns = %+v", cols)
+	}
+}
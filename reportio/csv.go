@@ -0,0 +1,42 @@
+package reportio
+
+import (
+	"encoding/csv"
+	"io"
+)
+
+// WriteCSV writes a header row of column labels followed by the rows.
+// Amounts are written with their column's precision and dates as
+// YYYY-MM-DD.
+func WriteCSV(w io.Writer, r Report) error {
+	cols := r.Columns()
+	cw := csv.NewWriter(w)
+
+	record := make([]string, len(cols))
+	for i, c := range cols {
+		record[i] = c.Label
+	}
+	if err := cw.Write(record); err != nil {
+		return err
+	}
+
+	err := r.Rows(func(row []any) error {
+		for i, c := range cols {
+			record[i] = formatValue(c, cell(row, i))
+		}
+		return cw.Write(record)
+	})
+	if err != nil {
+		return err
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// cell returns a row value, or nil for short rows.
+func cell(row []any, i int) any {
+	if i < len(row) {
+		return row[i]
+	}
+	return nil
+}
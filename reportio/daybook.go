@@ -0,0 +1,101 @@
+package reportio
+
+import (
+	"slices"
+
+	"github.com/senguttuvang/erpnext-go/ledger"
+)
+
+// DayBookVoucher is one voucher of the Day Book with its GL lines.
+type DayBookVoucher struct {
+	VoucherType string
+	VoucherNo   string
+	Lines       []ledger.GLEntry
+	Debit       float64
+	Credit      float64
+}
+
+// DayBookVouchers groups GL entries by voucher, in order of posting date
+// and, within a day, of posting. Cancelled entries and their reversals
+// are skipped, and opening and period closing entries unless flags include them. Select
+// the day or period with ledger.QueryGL first.
+//
+// Maps to: the General Ledger report with group_by = "Group by Voucher",
+// which totals each voucher (general_ledger.py)
+func DayBookVouchers(entries []ledger.GLEntry, flags ledger.ReportFlags) []DayBookVoucher {
+	type voucher struct{ voucherType, voucherNo string }
+	index := map[voucher]int{}
+	var vouchers []DayBookVoucher
+	cancelled := ledger.CancelledEntries(entries)
+	for i, e := range entries {
+		if cancelled[i] || !flags.Counts(&e) {
+			continue
+		}
+		k := voucher{e.VoucherType, e.VoucherNo}
+		i, ok := index[k]
+		if !ok {
+			i = len(vouchers)
+			index[k] = i
+			vouchers = append(vouchers, DayBookVoucher{VoucherType: e.VoucherType, VoucherNo: e.VoucherNo})
+		}
+		v := &vouchers[i]
+		v.Lines = append(v.Lines, e)
+		v.Debit = ledger.Flt(v.Debit+e.Debit, 2)
+		v.Credit = ledger.Flt(v.Credit+e.Credit, 2)
+	}
+	slices.SortStableFunc(vouchers, func(a, b DayBookVoucher) int {
+		return ledger.CivilDate(a.Lines[0].PostingDate).Compare(ledger.CivilDate(b.Lines[0].PostingDate))
+	})
+	return vouchers
+}
+
+// DayBook presents GL entries as the Day Book: every voucher's lines
+// followed by a subtotal row for the voucher, and a grand total at the
+// end. Voucher and against-voucher columns are dynamic links, so a viewer
+// can open the document behind each line.
+func DayBook(entries []ledger.GLEntry, flags ledger.ReportFlags) Report {
+	return dayBook{DayBookVouchers(entries, flags)}
+}
+
+type dayBook struct {
+	vouchers []DayBookVoucher
+}
+
+func (dayBook) Title() string { return "Day Book" }
+
+func (dayBook) Columns() []Column {
+	return []Column{
+		{Fieldname: "posting_date", Label: "Posting Date", Fieldtype: Date},
+		{Fieldname: "voucher_type", Label: "Voucher Type", Fieldtype: Link, Options: "DocType"},
+		{Fieldname: "voucher_no", Label: "Voucher No", Fieldtype: DynamicLink, Options: "voucher_type"},
+		{Fieldname: "account", Label: "Account", Fieldtype: Link, Options: "Account"},
+		{Fieldname: "party_type", Label: "Party Type", Fieldtype: Data},
+		{Fieldname: "party", Label: "Party", Fieldtype: DynamicLink, Options: "party_type"},
+		{Fieldname: "debit", Label: "Debit", Fieldtype: Currency},
+		{Fieldname: "credit", Label: "Credit", Fieldtype: Currency},
+		{Fieldname: "cost_center", Label: "Cost Center", Fieldtype: Link, Options: "Cost Center"},
+		{Fieldname: "against_voucher_type", Label: "Against Voucher Type", Fieldtype: Link, Options: "DocType"},
+		{Fieldname: "against_voucher", Label: "Against Voucher", Fieldtype: DynamicLink, Options: "against_voucher_type"},
+		{Fieldname: "remarks", Label: "Remarks", Fieldtype: Data},
+	}
+}
+
+func (d dayBook) Rows(fn func([]any) error) error {
+	var debit, credit float64
+	for _, v := range d.vouchers {
+		for _, e := range v.Lines {
+			row := []any{
+				e.PostingDate, e.VoucherType, e.VoucherNo, e.Account, e.PartyType, e.Party, e.Debit, e.Credit,
+				e.CostCenter, e.AgainstVoucherType, e.AgainstVoucher, e.Remarks,
+			}
+			if err := fn(row); err != nil {
+				return err
+			}
+		}
+		if err := fn([]any{nil, v.VoucherType, v.VoucherNo, "Total", nil, nil, v.Debit, v.Credit, nil, nil, nil, nil}); err != nil {
+			return err
+		}
+		debit, credit = debit+v.Debit, credit+v.Credit
+	}
+	return fn([]any{nil, nil, nil, "Grand Total", nil, nil, ledger.Flt(debit, 2), ledger.Flt(credit, 2), nil, nil, nil, nil})
+}
@@ -0,0 +1,36 @@
+package reportio
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// WriteJSONL writes the column metadata as the first line, then one JSON
+// object per row keyed by fieldname. Dates are written as YYYY-MM-DD.
+func WriteJSONL(w io.Writer, r Report) error {
+	cols := r.Columns()
+	enc := json.NewEncoder(w)
+	if err := enc.Encode(struct {
+		Columns []Column `json:"columns"`
+	}{cols}); err != nil {
+		return err
+	}
+
+	return r.Rows(func(row []any) error {
+		obj := make(map[string]any, len(cols))
+		for i, c := range cols {
+			v := cell(row, i)
+			switch v.(type) {
+			case time.Time, *time.Time:
+				if s := formatValue(c, v); s != "" {
+					v = s
+				} else {
+					v = nil
+				}
+			}
+			obj[c.Fieldname] = v
+		}
+		return enc.Encode(obj)
+	})
+}
@@ -0,0 +1,58 @@
+package reportio
+
+import "github.com/senguttuvang/erpnext-go/ledger"
+
+// GeneralLedger presents GL entries as the General Ledger report, with a
+// running balance. Entries are listed in the order given; cancelled
+// entries and their reversals are skipped, as with the report's default
+// filters, and opening and period closing entries unless flags include
+// them.
+//
+// Maps to: erpnext/accounts/report/general_ledger/general_ledger.py
+func GeneralLedger(entries []ledger.GLEntry, flags ledger.ReportFlags) Report {
+	return generalLedger{entries, flags}
+}
+
+type generalLedger struct {
+	entries []ledger.GLEntry
+	flags   ledger.ReportFlags
+}
+
+func (generalLedger) Title() string { return "General Ledger" }
+
+func (generalLedger) Columns() []Column {
+	return []Column{
+		{Fieldname: "posting_date", Label: "Posting Date", Fieldtype: Date},
+		{Fieldname: "account", Label: "Account", Fieldtype: Link, Options: "Account"},
+		{Fieldname: "debit", Label: "Debit", Fieldtype: Currency},
+		{Fieldname: "credit", Label: "Credit", Fieldtype: Currency},
+		{Fieldname: "balance", Label: "Balance", Fieldtype: Currency},
+		{Fieldname: "voucher_type", Label: "Voucher Type", Fieldtype: Data},
+		{Fieldname: "voucher_no", Label: "Voucher No", Fieldtype: Data},
+		{Fieldname: "against", Label: "Against Account", Fieldtype: Data},
+		{Fieldname: "party_type", Label: "Party Type", Fieldtype: Data},
+		{Fieldname: "party", Label: "Party", Fieldtype: Data},
+		{Fieldname: "cost_center", Label: "Cost Center", Fieldtype: Link, Options: "Cost Center"},
+		{Fieldname: "against_voucher", Label: "Against Voucher", Fieldtype: Data},
+		{Fieldname: "remarks", Label: "Remarks", Fieldtype: Data},
+	}
+}
+
+func (g generalLedger) Rows(fn func([]any) error) error {
+	var balance float64
+	cancelled := ledger.CancelledEntries(g.entries)
+	for i, e := range g.entries {
+		if cancelled[i] || !g.flags.Counts(&e) {
+			continue
+		}
+		balance = ledger.Flt(balance+e.Debit-e.Credit, 2)
+		row := []any{
+			e.PostingDate, e.Account, e.Debit, e.Credit, balance, e.VoucherType, e.VoucherNo,
+			e.Against, e.PartyType, e.Party, e.CostCenter, e.AgainstVoucher, e.Remarks,
+		}
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,177 @@
+// Package reportio serializes report results for download.
+//
+// Any report that describes its columns and streams its rows implements
+// Report and can be written as CSV, XLSX or JSON Lines, so the CLI and the
+// HTTP API offer downloads without per-report code.
+//
+// Maps to: frappe/desk/query_report.py (export_query) and the column
+// definitions returned by every script report's execute()
+package reportio
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/ledger"
+)
+
+// ErrUnknownFormat is returned for an unsupported output format.
+var ErrUnknownFormat = errors.New("unknown report format")
+
+// FieldType is the type of a column, as in Frappe report columns.
+type FieldType string
+
+const (
+	Data     FieldType = "Data"
+	Link     FieldType = "Link" // Options holds the linked doctype
+	Currency FieldType = "Currency"
+	Float    FieldType = "Float"
+	Int      FieldType = "Int"
+	Check    FieldType = "Check"
+	Date     FieldType = "Date"
+
+	// DynamicLink links to a document whose doctype is in another column;
+	// Options holds that column's fieldname.
+	DynamicLink FieldType = "Dynamic Link"
+)
+
+// Column describes one column of a report.
+type Column struct {
+	Fieldname string    `json:"fieldname"`
+	Label     string    `json:"label"`
+	Fieldtype FieldType `json:"fieldtype"`
+	Options   string    `json:"options,omitempty"`
+	Precision int       `json:"precision,omitempty"` // Decimals for Currency and Float; 2 when zero
+}
+
+// Report is a report result.
+type Report interface {
+	Columns() []Column
+
+	// Rows calls fn for each row, with values in column order. Values are
+	// strings, numbers, bools, time.Time (or *time.Time) or nil.
+	Rows(fn func(row []any) error) error
+}
+
+// Titled is implemented by reports with a title, used as the sheet name.
+type Titled interface {
+	Title() string
+}
+
+// Table is an in-memory Report.
+type Table struct {
+	Name string
+	Cols []Column
+	Data [][]any
+}
+
+// Title implements Titled.
+func (t *Table) Title() string { return t.Name }
+
+// Columns implements Report.
+func (t *Table) Columns() []Column { return t.Cols }
+
+// Rows implements Report.
+func (t *Table) Rows(fn func([]any) error) error {
+	for _, row := range t.Data {
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Format is an output format.
+type Format string
+
+const (
+	CSV   Format = "csv"
+	XLSX  Format = "xlsx"
+	JSONL Format = "jsonl"
+)
+
+// ContentType returns the MIME type of a format.
+func (f Format) ContentType() string {
+	switch f {
+	case CSV:
+		return "text/csv; charset=utf-8"
+	case XLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	case JSONL:
+		return "application/jsonl"
+	}
+	return "application/octet-stream"
+}
+
+// Write serializes a report in a format.
+func Write(w io.Writer, format Format, r Report) error {
+	switch format {
+	case CSV:
+		return WriteCSV(w, r)
+	case XLSX:
+		return WriteXLSX(w, r)
+	case JSONL:
+		return WriteJSONL(w, r)
+	}
+	return fmt.Errorf("%w: %s", ErrUnknownFormat, format)
+}
+
+// formatValue renders a value as text for CSV and string cells.
+func formatValue(col Column, v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case time.Time:
+		if v.IsZero() {
+			return ""
+		}
+		return v.Format(ledger.DateLayout)
+	case *time.Time:
+		if v == nil {
+			return ""
+		}
+		return formatValue(col, *v)
+	case bool:
+		if v {
+			return "1"
+		}
+		return "0"
+	}
+	if f, ok := number(v); ok {
+		switch col.Fieldtype {
+		case Currency, Float:
+			return strconv.FormatFloat(ledger.Flt(f, precision(col)), 'f', precision(col), 64)
+		}
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	return fmt.Sprint(v)
+}
+
+// number converts numeric values to float64.
+func number(v any) (float64, bool) {
+	switch v := v.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	}
+	return 0, false
+}
+
+func precision(col Column) int {
+	if col.Precision > 0 {
+		return col.Precision
+	}
+	return 2
+}
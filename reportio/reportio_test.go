@@ -0,0 +1,222 @@
+package reportio
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/ledger"
+)
+
+func testTable() *Table {
+	due := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	return &Table{
+		Name: "Accounts Receivable: Ageing",
+		Cols: []Column{
+			{Fieldname: "party", Label: "Customer", Fieldtype: Link, Options: "Customer"},
+			{Fieldname: "due_date", Label: "Due Date", Fieldtype: Date},
+			{Fieldname: "outstanding", Label: "Outstanding", Fieldtype: Currency},
+			{Fieldname: "age", Label: "Age (Days)", Fieldtype: Int},
+			{Fieldname: "on_hold", Label: "On Hold", Fieldtype: Check},
+		},
+		Data: [][]any{
+			{"Acme <Corp> & Co", time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC), 1180.5, 45, false},
+			{"Zenith, Ltd", &due, 99.999, 15, true},
+			{"Nil Row"},
+		},
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, CSV, testTable()); err != nil {
+		t.Fatal(err)
+	}
+	want := "Customer,Due Date,Outstanding,Age (Days),On Hold\n" +
+		"Acme <Corp> & Co,2024-04-01,1180.50,45,0\n" +
+		"\"Zenith, Ltd\",2024-05-01,100.00,15,1\n" +
+		"Nil Row,,,,\n"
+	if buf.String() != want {
+		t.Errorf("WriteCSV() =\n%s\nwant\n%s", buf.String(), want)
+	}
+}
+
+func TestWriteJSONL(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, JSONL, testTable()); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("got %d lines, want 4", len(lines))
+	}
+
+	var meta struct{ Columns []Column }
+	if err := json.Unmarshal([]byte(lines[0]), &meta); err != nil {
+		t.Fatal(err)
+	}
+	if len(meta.Columns) != 5 || meta.Columns[0].Options != "Customer" || meta.Columns[2].Fieldtype != Currency {
+		t.Errorf("unexpected metadata %+v", meta.Columns)
+	}
+
+	var row map[string]any
+	if err := json.Unmarshal([]byte(lines[2]), &row); err != nil {
+		t.Fatal(err)
+	}
+	if row["party"] != "Zenith, Ltd" || row["due_date"] != "2024-05-01" || row["outstanding"] != 99.999 || row["on_hold"] != true {
+		t.Errorf("unexpected row %v", row)
+	}
+	if err := json.Unmarshal([]byte(lines[3]), &row); err != nil || row["due_date"] != nil {
+		t.Errorf("short row: %v, %v", row, err)
+	}
+}
+
+// readPart unzips a workbook and returns the named part.
+func readPart(t *testing.T, data []byte, name string) string {
+	t.Helper()
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := zr.Open(name)
+	if err != nil {
+		t.Fatalf("open %s: %v", name, err)
+	}
+	defer f.Close()
+	body, err := io.ReadAll(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Every part must be well-formed XML
+	dec := xml.NewDecoder(bytes.NewReader(body))
+	for {
+		if _, err := dec.Token(); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+	}
+	return string(body)
+}
+
+func TestWriteXLSX(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, XLSX, testTable()); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+
+	for _, part := range []string{"[Content_Types].xml", "_rels/.rels", "xl/_rels/workbook.xml.rels", "xl/styles.xml"} {
+		readPart(t, data, part)
+	}
+	if wb := readPart(t, data, "xl/workbook.xml"); !strings.Contains(wb, `name="Accounts Receivable- Ageing"`) {
+		t.Errorf("sheet name not sanitized: %s", wb)
+	}
+
+	sheet := readPart(t, data, "xl/worksheets/sheet1.xml")
+	for _, want := range []string{
+		`<c r="A1" t="inlineStr" s="1"><is><t xml:space="preserve">Customer</t></is></c>`,
+		`<t xml:space="preserve">Acme &lt;Corp&gt; &amp; Co</t>`,
+		`<c r="B2" s="3"><v>45383</v></c>`, // 2024-04-01
+		`<c r="C2" s="2"><v>1180.5</v></c>`,
+		`<c r="D2" s="4"><v>45</v></c>`,
+		`<c r="E3" t="b"><v>1</v></c>`,
+		`<row r="4"><c r="A4"`,
+	} {
+		if !strings.Contains(sheet, want) {
+			t.Errorf("sheet missing %s", want)
+		}
+	}
+}
+
+func TestWriteUnknownFormat(t *testing.T) {
+	if err := Write(io.Discard, "pdf", testTable()); !errors.Is(err, ErrUnknownFormat) {
+		t.Errorf("Expected ErrUnknownFormat, got %v", err)
+	}
+}
+
+func TestCellRef(t *testing.T) {
+	tests := map[int]string{0: "A1", 25: "Z1", 26: "AA1", 51: "AZ1", 52: "BA1", 701: "ZZ1", 702: "AAA1"}
+	for col, want := range tests {
+		if got := cellRef(col, 1); got != want {
+			t.Errorf("cellRef(%d) = %s, want %s", col, got, want)
+		}
+	}
+}
+
+func TestGeneralLedger(t *testing.T) {
+	date := time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)
+	entries := []ledger.GLEntry{
+		{PostingDate: date, Account: "Debtors", VoucherType: "Sales Invoice", VoucherNo: "SINV-1", Debit: 100.10},
+		{PostingDate: date, Account: "Debtors", VoucherType: "Sales Invoice", VoucherNo: "SINV-2", Debit: 50, IsCancelled: true},
+		// The reversal of SINV-2, as stores kept them live
+		{PostingDate: date, Account: "Debtors", VoucherType: "Sales Invoice", VoucherNo: "SINV-2", Credit: 50},
+		{PostingDate: date, Account: "Debtors", VoucherType: "Payment Entry", VoucherNo: "PE-1", Credit: 40.05},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteCSV(&buf, GeneralLedger(entries, ledger.ReportFlags{})); err != nil {
+		t.Fatal(err)
+	}
+	sc := bufio.NewScanner(&buf)
+	var lines []string
+	for sc.Scan() {
+		lines = append(lines, sc.Text())
+	}
+	if len(lines) != 3 {
+		t.Fatalf("got %d lines, want header and 2 rows", len(lines))
+	}
+	if !strings.HasPrefix(lines[2], "2024-04-01,Debtors,0.00,40.05,60.05,Payment Entry,PE-1") {
+		t.Errorf("unexpected row %s", lines[2])
+	}
+}
+
+func TestDayBook(t *testing.T) {
+	march31 := time.Date(2024, 3, 31, 18, 0, 0, 0, time.UTC)
+	march30 := time.Date(2024, 3, 30, 0, 0, 0, 0, time.UTC)
+	entries := []ledger.GLEntry{
+		{PostingDate: march31, Account: "Debtors", PartyType: "Customer", Party: "Globex", VoucherType: "Sales Invoice", VoucherNo: "SINV-9", Debit: 118},
+		{PostingDate: march31, Account: "Sales", VoucherType: "Journal Entry", VoucherNo: "JV-4", Debit: 10},
+		{PostingDate: march31, Account: "Sales", VoucherType: "Sales Invoice", VoucherNo: "SINV-9", Credit: 100},
+		{PostingDate: march31, Account: "VAT", VoucherType: "Sales Invoice", VoucherNo: "SINV-9", Credit: 18},
+		{PostingDate: march31, Account: "Cash", VoucherType: "Journal Entry", VoucherNo: "JV-4", Credit: 10},
+		{PostingDate: march30, Account: "Bank", VoucherType: "Payment Entry", VoucherNo: "PE-2", Debit: 5, IsCancelled: true},
+		{PostingDate: march30, Account: "Bank", VoucherType: "Payment Entry", VoucherNo: "PE-2", Credit: 5},
+		{PostingDate: march30, Account: "Cash", VoucherType: "Payment Entry", VoucherNo: "PE-3", Debit: 7, AgainstVoucherType: "Sales Invoice", AgainstVoucher: "SINV-1"},
+		{PostingDate: march30, Account: "Debtors", VoucherType: "Payment Entry", VoucherNo: "PE-3", Credit: 7},
+	}
+
+	vouchers := DayBookVouchers(entries, ledger.ReportFlags{})
+	var names []string
+	for _, v := range vouchers {
+		names = append(names, v.VoucherNo)
+	}
+	if strings.Join(names, ",") != "PE-3,SINV-9,JV-4" {
+		t.Fatalf("vouchers = %v", names)
+	}
+	if v := vouchers[1]; len(v.Lines) != 3 || v.Debit != 118 || v.Credit != 118 {
+		t.Errorf("SINV-9 = %+v", v)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteCSV(&buf, DayBook(entries, ledger.ReportFlags{})); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 1+7+3+1 {
+		t.Fatalf("got %d lines:\n%s", len(lines), buf.String())
+	}
+	if lines[1] != "2024-03-30,Payment Entry,PE-3,Cash,,,7.00,0.00,,Sales Invoice,SINV-1," {
+		t.Errorf("line = %s", lines[1])
+	}
+	if lines[3] != ",Payment Entry,PE-3,Total,,,7.00,7.00,,,," || lines[11] != ",,,Grand Total,,,135.00,135.00,,,," {
+		t.Errorf("totals = %s / %s", lines[3], lines[11])
+	}
+}
@@ -0,0 +1,212 @@
+package reportio
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Cell styles defined in xlsxStyles, by index into cellXfs.
+const (
+	styleDefault = 0
+	styleHeader  = 1
+	styleAmount  = 2 // #,##0.00
+	styleDate    = 3 // yyyy-mm-dd
+	styleInt     = 4 // 0
+)
+
+// WriteXLSX writes a single-sheet workbook: a bold header row of labels,
+// then the rows, with numbers and dates as typed cells. Rows are streamed
+// into the archive, so large reports are never held in memory.
+func WriteXLSX(w io.Writer, r Report) error {
+	zw := zip.NewWriter(w)
+
+	sheetName := "Report"
+	if t, ok := r.(Titled); ok && t.Title() != "" {
+		sheetName = sheetTitle(t.Title())
+	}
+	parts := []struct{ name, body string }{
+		{"[Content_Types].xml", xlsxContentTypes},
+		{"_rels/.rels", xlsxRootRels},
+		{"xl/workbook.xml", strings.Replace(xlsxWorkbook, "{{sheet}}", escape(sheetName), 1)},
+		{"xl/_rels/workbook.xml.rels", xlsxWorkbookRels},
+		{"xl/styles.xml", xlsxStyles},
+	}
+	for _, p := range parts {
+		f, err := zw.Create(p.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(f, xmlHeader+p.body); err != nil {
+			return err
+		}
+	}
+
+	f, err := zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return err
+	}
+	if err := writeSheet(bufio.NewWriter(f), r); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+func writeSheet(bw *bufio.Writer, r Report) error {
+	cols := r.Columns()
+	bw.WriteString(xmlHeader + `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+
+	rowNum := 1
+	bw.WriteString(`<row r="1">`)
+	for i, c := range cols {
+		writeStringCell(bw, cellRef(i, rowNum), c.Label, styleHeader)
+	}
+	bw.WriteString(`</row>`)
+
+	err := r.Rows(func(row []any) error {
+		rowNum++
+		bw.WriteString(`<row r="` + strconv.Itoa(rowNum) + `">`)
+		for i, c := range cols {
+			writeCell(bw, cellRef(i, rowNum), c, cell(row, i))
+		}
+		bw.WriteString(`</row>`)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	bw.WriteString(`</sheetData></worksheet>`)
+	return bw.Flush()
+}
+
+func writeCell(bw *bufio.Writer, ref string, col Column, v any) {
+	switch v := v.(type) {
+	case nil:
+		return
+	case time.Time:
+		if !v.IsZero() {
+			writeNumberCell(bw, ref, excelDate(v), styleDate)
+		}
+		return
+	case *time.Time:
+		if v != nil {
+			writeCell(bw, ref, col, *v)
+		}
+		return
+	case bool:
+		bw.WriteString(`<c r="` + ref + `" t="b"><v>` + formatValue(col, v) + `</v></c>`)
+		return
+	}
+	if f, ok := number(v); ok {
+		style := styleDefault
+		switch col.Fieldtype {
+		case Currency, Float:
+			style = styleAmount
+		case Int:
+			style = styleInt
+		}
+		writeNumberCell(bw, ref, f, style)
+		return
+	}
+	writeStringCell(bw, ref, formatValue(col, v), styleDefault)
+}
+
+func writeNumberCell(bw *bufio.Writer, ref string, f float64, style int) {
+	bw.WriteString(`<c r="` + ref + `"` + styleAttr(style) + `><v>` + strconv.FormatFloat(f, 'f', -1, 64) + `</v></c>`)
+}
+
+// writeStringCell writes an inline string, which needs no shared string
+// table and so can be streamed.
+func writeStringCell(bw *bufio.Writer, ref, s string, style int) {
+	bw.WriteString(`<c r="` + ref + `" t="inlineStr"` + styleAttr(style) + `><is><t xml:space="preserve">` + escape(s) + `</t></is></c>`)
+}
+
+func styleAttr(style int) string {
+	if style == styleDefault {
+		return ""
+	}
+	return ` s="` + strconv.Itoa(style) + `"`
+}
+
+// cellRef returns the A1 reference of a zero-based column and row number.
+func cellRef(col, row int) string {
+	name := ""
+	for col++; col > 0; col = (col - 1) / 26 {
+		name = string(rune('A'+(col-1)%26)) + name
+	}
+	return name + strconv.Itoa(row)
+}
+
+// excelDate converts a date to a spreadsheet serial number (days since
+// 1899-12-30).
+func excelDate(t time.Time) float64 {
+	y, m, d := t.Date()
+	epoch := time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+	return float64(time.Date(y, m, d, 0, 0, 0, 0, time.UTC).Sub(epoch) / (24 * time.Hour))
+}
+
+// sheetTitle makes a title a valid sheet name: at most 31 characters and
+// none of : \ / ? * [ ].
+func sheetTitle(title string) string {
+	title = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`:\/?*[]`, r) {
+			return '-'
+		}
+		return r
+	}, title)
+	for utf8.RuneCountInString(title) > 31 {
+		_, size := utf8.DecodeLastRuneInString(title)
+		title = title[:len(title)-size]
+	}
+	return title
+}
+
+func escape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+const xmlHeader = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n"
+
+const xlsxContentTypes = `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+	`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+	`<Default Extension="xml" ContentType="application/xml"/>` +
+	`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+	`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+	`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>` +
+	`</Types>`
+
+const xlsxRootRels = `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+	`</Relationships>`
+
+const xlsxWorkbook = `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+	`<sheets><sheet name="{{sheet}}" sheetId="1" r:id="rId1"/></sheets>` +
+	`</workbook>`
+
+const xlsxWorkbookRels = `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+	`<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>` +
+	`</Relationships>`
+
+const xlsxStyles = `<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+	`<numFmts count="2"><numFmt numFmtId="164" formatCode="#,##0.00"/><numFmt numFmtId="165" formatCode="yyyy-mm-dd"/></numFmts>` +
+	`<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>` +
+	`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+	`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+	`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+	`<cellXfs count="5">` +
+	`<xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>` +
+	`<xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/>` +
+	`<xf numFmtId="164" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+	`<xf numFmtId="165" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+	`<xf numFmtId="1" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+	`</cellXfs>` +
+	`</styleSheet>`
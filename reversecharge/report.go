@@ -0,0 +1,108 @@
+package reversecharge
+
+import (
+	"github.com/senguttuvang/erpnext-go/ledger"
+	"github.com/senguttuvang/erpnext-go/reportio"
+)
+
+// LiabilityRow is the movement of one RCM payable account over a period.
+type LiabilityRow struct {
+	Account string
+	Opening float64 // Owed at the start of the period
+	Accrued float64 // Raised by self-invoices in the period, net of cancellations
+	Paid    float64 // Paid in cash or otherwise discharged in the period
+	Closing float64 // Still owed at the end of the period
+}
+
+// LiabilityReport tracks the reverse charge tax a company owes.
+type LiabilityReport struct {
+	Company  string
+	From     string
+	To       string
+	Accounts []LiabilityRow // In tax head order
+}
+
+// Liability builds the liability report of a company's RCM payable
+// accounts, the output accounts of its tax heads. Self-invoice entries
+// accrue the liability and every other entry on the accounts, such as a
+// tax payment, discharges it. Opening and period closing entries of the
+// period count only as flags say; the opening liability is a balance as
+// at the start, which every earlier entry makes up. Cancelled vouchers net
+// to zero with their reversing entries, so every entry is summed as stored.
+func Liability(entries []ledger.GLEntry, company string, heads []TaxHead, period ledger.PeriodDefinition, flags ledger.ReportFlags) *LiabilityReport {
+	rows := make(map[string]*LiabilityRow)
+	var order []string
+	for _, h := range heads {
+		if h.Company == company && h.OutputAccount != "" && rows[h.OutputAccount] == nil {
+			rows[h.OutputAccount] = &LiabilityRow{Account: h.OutputAccount}
+			order = append(order, h.OutputAccount)
+		}
+	}
+
+	for _, e := range entries {
+		row := rows[e.Account]
+		if row == nil || e.Company != company || ledger.CivilDate(e.PostingDate).After(ledger.CivilDate(period.EndDate)) ||
+			period.Contains(e.PostingDate) && !flags.Counts(&e) {
+			continue
+		}
+		owed := e.Credit - e.Debit
+		switch {
+		case !period.Contains(e.PostingDate):
+			row.Opening += owed
+		case e.VoucherType == VoucherType:
+			row.Accrued += owed
+		default:
+			row.Paid -= owed
+		}
+	}
+
+	report := &LiabilityReport{
+		Company: company,
+		From:    period.StartDate.Format(ledger.DateLayout),
+		To:      period.EndDate.Format(ledger.DateLayout),
+	}
+	for _, account := range order {
+		row := rows[account]
+		row.Opening = ledger.Flt(row.Opening, 2)
+		row.Accrued = ledger.Flt(row.Accrued, 2)
+		row.Paid = ledger.Flt(row.Paid, 2)
+		row.Closing = ledger.Flt(row.Opening+row.Accrued-row.Paid, 2)
+		report.Accounts = append(report.Accounts, *row)
+	}
+	return report
+}
+
+// Outstanding returns the total still owed at the end of the period.
+func (r *LiabilityReport) Outstanding() float64 {
+	total := 0.0
+	for _, row := range r.Accounts {
+		total += row.Closing
+	}
+	return ledger.Flt(total, 2)
+}
+
+// Title implements reportio.Titled.
+func (r *LiabilityReport) Title() string {
+	return "Reverse Charge Liability " + r.From + " to " + r.To
+}
+
+// Columns implements reportio.Report.
+func (r *LiabilityReport) Columns() []reportio.Column {
+	return []reportio.Column{
+		{Fieldname: "account", Label: "Account", Fieldtype: reportio.Link, Options: "Account"},
+		{Fieldname: "opening", Label: "Opening", Fieldtype: reportio.Currency},
+		{Fieldname: "accrued", Label: "Accrued", Fieldtype: reportio.Currency},
+		{Fieldname: "paid", Label: "Paid", Fieldtype: reportio.Currency},
+		{Fieldname: "closing", Label: "Closing", Fieldtype: reportio.Currency},
+	}
+}
+
+// Rows implements reportio.Report.
+func (r *LiabilityReport) Rows(fn func([]any) error) error {
+	for _, row := range r.Accounts {
+		if err := fn([]any{row.Account, row.Opening, row.Accrued, row.Paid, row.Closing}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,188 @@
+// Package reversecharge implements self-invoicing of purchases on which
+// the buyer pays the tax under reverse charge (RCM).
+//
+// When a registered business buys a notified supply from an unregistered
+// supplier, the supplier charges no tax and the buyer must issue an
+// invoice to itself and pay the tax. The self-invoice books the tax twice:
+// as a liability on an output (RCM payable) account, and as input tax
+// the buyer may claim, so the net effect is nil once the liability is paid
+// and the credit taken. LiabilityReport tracks what is accrued, paid and
+// still owed on the RCM payable accounts.
+//
+// Maps to: the reverse charge handling of Purchase Invoice
+// (is_reverse_charge) in make_regional_gl_entries() of
+// erpnext/regional/india/utils.py, and the self-invoice of GST Rule 46(f)
+package reversecharge
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/ledger"
+)
+
+// VoucherType is the voucher type used on self-invoice GL entries.
+const VoucherType = "Self Invoice"
+
+// Reverse charge errors
+var (
+	ErrNotReverseCharge  = errors.New("purchase is not subject to reverse charge")
+	ErrNoTaxHeads        = errors.New("no reverse charge tax heads for company")
+	ErrMissingTaxAccount = errors.New("reverse charge tax head needs input and output accounts")
+	ErrNothingToInvoice  = errors.New("purchase has no taxable value")
+)
+
+// Status is the document state of a self-invoice.
+type Status string
+
+const (
+	Draft     Status = "Draft"
+	Submitted Status = "Submitted"
+	Cancelled Status = "Cancelled"
+)
+
+// Item is a purchased line.
+type Item struct {
+	ItemCode    string
+	Description string
+	HSNCode     string
+	Amount      float64 // Taxable value in company currency
+}
+
+// Purchase is the purchase invoice a self-invoice is raised for.
+type Purchase struct {
+	Name          string
+	Company       string
+	Supplier      string
+	SupplierTaxID string // GSTIN or VAT number; empty for unregistered suppliers
+	PostingDate   time.Time
+	ReverseCharge bool // The supply is notified for reverse charge
+	Items         []Item
+}
+
+// SelfInvoiced reports whether the purchase needs a self-invoice: a
+// reverse charge supply from a supplier without a tax registration.
+// Registered suppliers issue the invoice themselves.
+func (p *Purchase) SelfInvoiced() bool {
+	return p.ReverseCharge && p.SupplierTaxID == ""
+}
+
+// TaxHead is a tax levied under reverse charge, such as CGST 9%.
+type TaxHead struct {
+	Company       string
+	Description   string
+	Rate          float64 // Percent of the taxable value
+	InputAccount  string  // Input tax credit (asset)
+	OutputAccount string  // RCM tax payable (liability)
+}
+
+// Tax is a tax line of a self-invoice.
+type Tax struct {
+	TaxHead
+	Amount float64
+}
+
+// SelfInvoice is the invoice a buyer issues to itself for a reverse
+// charge purchase.
+type SelfInvoice struct {
+	Name            string
+	Company         string
+	Supplier        string
+	PurchaseInvoice string
+	PostingDate     time.Time
+	CostCenter      string
+
+	Items        []Item
+	TaxableValue float64
+	Taxes        []Tax
+	TotalTax     float64
+
+	Status Status
+}
+
+// NewSelfInvoice raises a self-invoice named name for a purchase, with a
+// tax line for each of the company's tax heads.
+func NewSelfInvoice(name string, p *Purchase, heads []TaxHead) (*SelfInvoice, error) {
+	if !p.SelfInvoiced() {
+		return nil, fmt.Errorf("%w: %s", ErrNotReverseCharge, p.Name)
+	}
+	inv := &SelfInvoice{
+		Name:            name,
+		Company:         p.Company,
+		Supplier:        p.Supplier,
+		PurchaseInvoice: p.Name,
+		PostingDate:     p.PostingDate,
+		Items:           append([]Item(nil), p.Items...),
+		Status:          Draft,
+	}
+	for _, item := range p.Items {
+		inv.TaxableValue += item.Amount
+	}
+	inv.TaxableValue = ledger.Flt(inv.TaxableValue, 2)
+	if inv.TaxableValue <= 0 {
+		return nil, fmt.Errorf("%w: %s", ErrNothingToInvoice, p.Name)
+	}
+
+	for _, h := range heads {
+		if h.Company != p.Company {
+			continue
+		}
+		if h.InputAccount == "" || h.OutputAccount == "" {
+			return nil, fmt.Errorf("%w: %s", ErrMissingTaxAccount, h.Description)
+		}
+		amount := ledger.Flt(inv.TaxableValue*h.Rate/100, 2)
+		inv.Taxes = append(inv.Taxes, Tax{TaxHead: h, Amount: amount})
+		inv.TotalTax += amount
+	}
+	if len(inv.Taxes) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrNoTaxHeads, p.Company)
+	}
+	inv.TotalTax = ledger.Flt(inv.TotalTax, 2)
+	return inv, nil
+}
+
+// GetGLEntries returns the paired tax entries of the self-invoice: each
+// tax debits its input account and credits its RCM payable account.
+//
+// Maps to: the reverse charge branch of make_regional_gl_entries() in
+// erpnext/regional/india/utils.py
+func (inv *SelfInvoice) GetGLEntries() ([]ledger.GLEntry, error) {
+	b := ledger.NewEntryBuilder().
+		Company(inv.Company).PostingDate(inv.PostingDate).ForVoucher(VoucherType, inv.Name).
+		Remarks(fmt.Sprintf("Reverse charge on %s from %s", inv.PurchaseInvoice, inv.Supplier))
+	for _, tax := range inv.Taxes {
+		if tax.Amount == 0 {
+			continue
+		}
+		b.Account(tax.InputAccount).Debit(tax.Amount).CostCenter(inv.CostCenter).Against(tax.OutputAccount).
+			Account(tax.OutputAccount).Credit(tax.Amount).CostCenter(inv.CostCenter).Against(tax.InputAccount)
+	}
+	return b.Build()
+}
+
+// Submit posts the self-invoice's GL entries through the engine.
+func (inv *SelfInvoice) Submit(engine *ledger.Engine) error {
+	glMap, err := inv.GetGLEntries()
+	if err != nil {
+		return err
+	}
+	if err := engine.MakeGLEntries(glMap, ledger.DefaultPostingOptions()); err != nil {
+		return err
+	}
+	inv.Status = Submitted
+	return nil
+}
+
+// Cancel reverses the self-invoice's GL entries, as when the purchase is
+// cancelled.
+func (inv *SelfInvoice) Cancel(engine *ledger.Engine) error {
+	opts := ledger.DefaultPostingOptions()
+	opts.Cancel = true
+	voucher := ledger.GLEntry{PostingDate: inv.PostingDate, VoucherType: VoucherType, VoucherNo: inv.Name, Company: inv.Company}
+	if err := engine.MakeGLEntries([]ledger.GLEntry{voucher}, opts); err != nil {
+		return err
+	}
+	inv.Status = Cancelled
+	return nil
+}
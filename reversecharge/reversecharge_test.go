@@ -0,0 +1,139 @@
+package reversecharge
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/ledger"
+	"github.com/senguttuvang/erpnext-go/ledger/memstore"
+)
+
+func date(s string) time.Time {
+	t, err := ledger.ParseDate(s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+var heads = []TaxHead{
+	{Company: "ACME", Description: "CGST", Rate: 9, InputAccount: "Input CGST RCM - ACME", OutputAccount: "Output CGST RCM - ACME"},
+	{Company: "ACME", Description: "SGST", Rate: 9, InputAccount: "Input SGST RCM - ACME", OutputAccount: "Output SGST RCM - ACME"},
+	{Company: "Other", Description: "IGST", Rate: 18, InputAccount: "Input IGST", OutputAccount: "Output IGST"},
+}
+
+func newPurchase(name, posted string, amounts ...float64) *Purchase {
+	p := &Purchase{Name: name, Company: "ACME", Supplier: "Local Transport", PostingDate: date(posted), ReverseCharge: true}
+	for _, a := range amounts {
+		p.Items = append(p.Items, Item{ItemCode: "FREIGHT", HSNCode: "9965", Amount: a})
+	}
+	return p
+}
+
+func TestSelfInvoice(t *testing.T) {
+	inv, err := NewSelfInvoice("SLF-0001", newPurchase("PINV-0001", "2024-05-10", 6000, 4000.5), heads)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inv.TaxableValue != 10000.5 || len(inv.Taxes) != 2 || inv.Taxes[0].Amount != 900.05 || inv.TotalTax != 1800.1 {
+		t.Errorf("self-invoice = %+v", inv)
+	}
+
+	glMap, err := inv.GetGLEntries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct {
+		account       string
+		debit, credit float64
+	}{
+		{"Input CGST RCM - ACME", 900.05, 0},
+		{"Output CGST RCM - ACME", 0, 900.05},
+		{"Input SGST RCM - ACME", 900.05, 0},
+		{"Output SGST RCM - ACME", 0, 900.05},
+	}
+	if len(glMap) != len(want) {
+		t.Fatalf("%d GL entries, want %d", len(glMap), len(want))
+	}
+	for i, w := range want {
+		e := glMap[i]
+		if e.Account != w.account || e.Debit != w.debit || e.Credit != w.credit || e.VoucherNo != "SLF-0001" {
+			t.Errorf("entry %d = %s %v/%v, want %+v", i, e.Account, e.Debit, e.Credit, w)
+		}
+	}
+
+	registered := newPurchase("PINV-0002", "2024-05-10", 100)
+	registered.SupplierTaxID = "29ABCDE1234F1Z5"
+	if _, err := NewSelfInvoice("SLF-0002", registered, heads); !errors.Is(err, ErrNotReverseCharge) {
+		t.Errorf("registered supplier: %v", err)
+	}
+	other := newPurchase("PINV-0003", "2024-05-10", 100)
+	other.Company = "Elsewhere"
+	if _, err := NewSelfInvoice("SLF-0003", other, heads); !errors.Is(err, ErrNoTaxHeads) {
+		t.Errorf("company without heads: %v", err)
+	}
+}
+
+func TestLiability(t *testing.T) {
+	glStore := memstore.NewGLStore()
+	engine := &ledger.Engine{GLStore: glStore, PaymentStore: memstore.NewPaymentStore()}
+	submit := func(name, purchase, posted string, amount float64) *SelfInvoice {
+		inv, err := NewSelfInvoice(name, newPurchase(purchase, posted, amount), heads)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := inv.Submit(engine); err != nil {
+			t.Fatal(err)
+		}
+		return inv
+	}
+	submit("SLF-0001", "PINV-0001", "2024-04-20", 5000) // 450 per head
+	submit("SLF-0002", "PINV-0002", "2024-05-10", 10000)
+	cancelled := submit("SLF-0003", "PINV-0003", "2024-05-12", 2000)
+	if err := cancelled.Cancel(engine); err != nil || cancelled.Status != Cancelled {
+		t.Fatalf("Cancel() = %v", err)
+	}
+	// The April liability is paid in cash in May
+	payment := []ledger.GLEntry{
+		{PostingDate: date("2024-05-20"), Account: "Output CGST RCM - ACME", Debit: 450, DebitInAccountCurrency: 450, Company: "ACME", VoucherType: "Journal Entry", VoucherNo: "JV-1"},
+		{PostingDate: date("2024-05-20"), Account: "Bank - ACME", Credit: 450, CreditInAccountCurrency: 450, Company: "ACME", VoucherType: "Journal Entry", VoucherNo: "JV-1"},
+	}
+	if err := engine.MakeGLEntries(payment, ledger.DefaultPostingOptions()); err != nil {
+		t.Fatal(err)
+	}
+	// An opening balance booked in May is no payment of the period
+	opening := []ledger.GLEntry{
+		{PostingDate: date("2024-05-01"), Account: "Output SGST RCM - ACME", Debit: 300, DebitInAccountCurrency: 300, Company: "ACME", VoucherType: "Journal Entry", VoucherNo: "JV-2", IsOpening: ledger.IsOpeningYes},
+		{PostingDate: date("2024-05-01"), Account: "Temporary Opening - ACME", Credit: 300, CreditInAccountCurrency: 300, Company: "ACME", VoucherType: "Journal Entry", VoucherNo: "JV-2", IsOpening: ledger.IsOpeningYes},
+	}
+	if err := engine.MakeGLEntries(opening, ledger.DefaultPostingOptions()); err != nil {
+		t.Fatal(err)
+	}
+
+	may := ledger.PeriodDefinition{StartDate: date("2024-05-01"), EndDate: date("2024-05-31")}
+	if r := Liability(glStore.All(), "ACME", heads, may, ledger.ReportFlags{IncludeOpening: true}); r.Accounts[1].Paid != 300 {
+		t.Errorf("with opening entries, SGST paid = %v", r.Accounts[1].Paid)
+	}
+	report := Liability(glStore.All(), "ACME", heads, may, ledger.ReportFlags{})
+	want := []LiabilityRow{
+		{Account: "Output CGST RCM - ACME", Opening: 450, Accrued: 900, Paid: 450, Closing: 900},
+		{Account: "Output SGST RCM - ACME", Opening: 450, Accrued: 900, Paid: 0, Closing: 1350},
+	}
+	if len(report.Accounts) != len(want) {
+		t.Fatalf("rows = %+v", report.Accounts)
+	}
+	for i, w := range want {
+		if report.Accounts[i] != w {
+			t.Errorf("row %d = %+v, want %+v", i, report.Accounts[i], w)
+		}
+	}
+	if report.Outstanding() != 2250 {
+		t.Errorf("Outstanding() = %v", report.Outstanding())
+	}
+
+	var rows int
+	if err := report.Rows(func([]any) error { rows++; return nil }); err != nil || rows != 2 || len(report.Columns()) != 5 {
+		t.Errorf("Rows() gave %d rows, %v", rows, err)
+	}
+}
@@ -0,0 +1,39 @@
+package salesinvoice
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/senguttuvang/erpnext-go/naming"
+)
+
+// Amend returns a draft copy of a cancelled invoice, named as its
+// amendment ("SINV-0001" becomes "SINV-0001-1"). Submitting the copy posts
+// it afresh and moves payments allocated to the original onto it.
+//
+// Maps to: the Amend action (frappe.model.copy_doc with amended_from) and
+// _get_amended_name() in frappe/model/naming.py
+func (inv *SalesInvoice) Amend() (*SalesInvoice, error) {
+	if inv.Status != Cancelled {
+		return nil, fmt.Errorf("%w: %s is %s", ErrNotCancelled, inv.Name, inv.Status)
+	}
+
+	amended := *inv
+	amended.Document = inv.Document.Copy()
+	if inv.DueDate != nil {
+		d := *inv.DueDate
+		amended.DueDate = &d
+	}
+	amended.Payments = make([]*Payment, len(inv.Payments))
+	for i, p := range inv.Payments {
+		copied := *p
+		amended.Payments[i] = &copied
+	}
+	amended.ReturnRows = slices.Clone(inv.ReturnRows)
+	amended.ReturnedQty = nil // Returns were made against the original
+
+	amended.Name = naming.AmendedName(inv.Name, inv.AmendedFrom != "")
+	amended.AmendedFrom = inv.Name
+	amended.Status = Draft
+	return &amended, nil
+}
@@ -0,0 +1,169 @@
+package salesinvoice
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/ledger"
+	"github.com/senguttuvang/erpnext-go/ledger/memstore"
+)
+
+// postPayment receives amount from the customer against an invoice.
+func postPayment(t *testing.T, engine *ledger.Engine, invoice string, amount float64) {
+	t.Helper()
+	date := time.Date(2024, 1, 20, 0, 0, 0, 0, time.UTC)
+	glMap := []ledger.GLEntry{
+		{
+			PostingDate: date, Account: "Bank - ACME", VoucherType: "Payment Entry", VoucherNo: "PE-0001",
+			Company: "ACME Industries Pvt Ltd", Debit: amount, DebitInAccountCurrency: amount,
+		},
+		{
+			PostingDate: date, Account: "Debtors - ACME", PartyType: "Customer", Party: "Acme Corporation",
+			VoucherType: "Payment Entry", VoucherNo: "PE-0001", AgainstVoucherType: VoucherType, AgainstVoucher: invoice,
+			Company: "ACME Industries Pvt Ltd", Credit: amount, CreditInAccountCurrency: amount,
+		},
+	}
+	if err := engine.MakeGLEntries(glMap, ledger.DefaultPostingOptions()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAmend(t *testing.T) {
+	glStore := memstore.NewGLStore()
+	paymentStore := memstore.NewPaymentStore()
+	engine := &ledger.Engine{GLStore: glStore, PaymentStore: paymentStore}
+
+	original := newGSTInvoice()
+	if _, err := original.Amend(); !errors.Is(err, ErrNotCancelled) {
+		t.Errorf("amending a draft: got %v, want ErrNotCancelled", err)
+	}
+	if err := original.Submit(engine, nil); err != nil {
+		t.Fatal(err)
+	}
+	postPayment(t, engine, original.Name, 10000)
+	if err := original.Cancel(engine); err != nil {
+		t.Fatal(err)
+	}
+
+	amended, err := original.Amend()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if amended.Name != "SINV-2024-00001-1" || amended.AmendedFrom != original.Name || amended.Status != Draft {
+		t.Errorf("unexpected amendment %s from %s (%s)", amended.Name, amended.AmendedFrom, amended.Status)
+	}
+
+	// Correct the quantity on the amendment only
+	amended.Items[0].Qty = 2
+	if original.Items[0].Qty != 1 {
+		t.Fatal("amendment shares items with the original")
+	}
+	if err := amended.Submit(engine, nil); err != nil {
+		t.Fatal(err)
+	}
+	if amended.Status != Submitted {
+		t.Errorf("Status = %s, want Submitted", amended.Status)
+	}
+
+	entries := paymentStore.All()
+	if got := ledger.OutstandingAmount(entries, VoucherType, original.Name); got != 0 {
+		t.Errorf("original outstanding = %.2f, want 0", got)
+	}
+	if got := ledger.OutstandingAmount(entries, VoucherType, amended.Name); got != 23600-10000 {
+		t.Errorf("amended outstanding = %.2f, want 13600", got)
+	}
+
+	payment, _ := glStore.GetByVoucher("Payment Entry", "PE-0001")
+	for _, e := range payment {
+		if e.PartyType != "" && e.AgainstVoucher != amended.Name {
+			t.Errorf("payment still booked against %s", e.AgainstVoucher)
+		}
+	}
+
+	// Amending an amendment increments the suffix
+	if err := amended.Cancel(engine); err != nil {
+		t.Fatal(err)
+	}
+	again, err := amended.Amend()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.Name != "SINV-2024-00001-2" {
+		t.Errorf("second amendment named %s", again.Name)
+	}
+}
+
+// plainGLStore is a GL store that cannot relink against vouchers.
+type plainGLStore struct{ ledger.GLEntryStore }
+
+func TestAmendWithoutRelink(t *testing.T) {
+	glStore := memstore.NewGLStore()
+	engine := &ledger.Engine{GLStore: plainGLStore{glStore}, PaymentStore: memstore.NewPaymentStore()}
+	original := newGSTInvoice()
+	if err := original.Submit(engine, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := original.Cancel(engine); err != nil {
+		t.Fatal(err)
+	}
+	amended, err := original.Amend()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := amended.Submit(engine, nil); !errors.Is(err, ledger.ErrRelinkNotSupported) {
+		t.Fatalf("Submit() error = %v, want ErrRelinkNotSupported", err)
+	}
+	if entries, _ := glStore.GetByVoucher(VoucherType, amended.Name); len(entries) != 0 || amended.Status != Draft {
+		t.Errorf("amendment posted %d entries (%s) before failing", len(entries), amended.Status)
+	}
+}
+
+// bankMatches links bank transactions to the vouchers they settled.
+type bankMatches map[string]ledger.VoucherRef
+
+func (m bankMatches) ClearReferences(voucherType, voucherNo string) ([]ledger.VoucherRef, error) {
+	var changed []ledger.VoucherRef
+	for txn, ref := range m {
+		if ref.VoucherType == voucherType && ref.VoucherNo == voucherNo {
+			delete(m, txn)
+			changed = append(changed, ledger.VoucherRef{VoucherType: "Bank Transaction", VoucherNo: txn})
+		}
+	}
+	return changed, nil
+}
+
+func TestCancelUnlinksReferences(t *testing.T) {
+	glStore := memstore.NewGLStore()
+	paymentStore := memstore.NewPaymentStore()
+	engine := &ledger.Engine{GLStore: glStore, PaymentStore: paymentStore}
+	matches := bankMatches{"BT-1": {VoucherType: VoucherType, VoucherNo: "SINV-2024-00001"}}
+	engine.References = ledger.NewReferenceManager(engine, matches)
+
+	inv := newGSTInvoice()
+	if err := inv.Submit(engine, nil); err != nil {
+		t.Fatal(err)
+	}
+	postPayment(t, engine, inv.Name, 10000)
+	if err := inv.Cancel(engine); err != nil {
+		t.Fatal(err)
+	}
+
+	// The payment is now an unallocated advance
+	entries := paymentStore.All()
+	if got := ledger.OutstandingAmount(entries, "Payment Entry", "PE-0001"); got != -10000 {
+		t.Errorf("payment outstanding = %.2f, want -10000", got)
+	}
+	if got := ledger.OutstandingAmount(entries, VoucherType, inv.Name); got != 0 {
+		t.Errorf("invoice outstanding = %.2f, want 0", got)
+	}
+	payment, _ := glStore.GetByVoucher("Payment Entry", "PE-0001")
+	for _, e := range payment {
+		if e.AgainstVoucher != "" {
+			t.Errorf("payment GL entry still booked against %s", e.AgainstVoucher)
+		}
+	}
+	if len(matches) != 0 {
+		t.Errorf("bank match not cleared: %v", matches)
+	}
+}
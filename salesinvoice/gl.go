@@ -0,0 +1,380 @@
+package salesinvoice
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/senguttuvang/erpnext-go/custom"
+	"github.com/senguttuvang/erpnext-go/ledger"
+	"github.com/senguttuvang/erpnext-go/taxcalc"
+)
+
+// GetGLEntries builds the GL map for a calculated invoice.
+//
+// Maps to: get_gl_entries() in sales_invoice.py
+//
+// Python equivalent:
+//
+//	def get_gl_entries(self):
+//	    gl_entries = []
+//	    self.make_customer_gl_entry(gl_entries)
+//	    self.make_tax_gl_entries(gl_entries)
+//	    self.make_item_gl_entries(gl_entries)
+//	    self.make_discount_gl_entries(gl_entries)
+//	    self.make_pos_gl_entries(gl_entries)
+//	    self.make_gle_for_rounding_adjustment(gl_entries)
+//	    return gl_entries
+func (inv *SalesInvoice) GetGLEntries() ([]ledger.GLEntry, error) {
+	if err := inv.validateAccounts(); err != nil {
+		return nil, err
+	}
+
+	var glMap []ledger.GLEntry
+	glMap = inv.makeCustomerGLEntry(glMap)
+	glMap = inv.makeTaxGLEntries(glMap)
+	glMap = inv.makeItemGLEntries(glMap)
+	glMap = inv.makeDiscountGLEntries(glMap)
+	glMap = inv.makePOSGLEntries(glMap)
+	glMap = inv.makeGLEForRoundingAdjustment(glMap)
+	if inv.IsReturn {
+		glMap = mirror(glMap)
+	}
+	return glMap, nil
+}
+
+// mirror swaps the debit and credit side of every entry, turning a sale's
+// GL map into its return's.
+func mirror(glMap []ledger.GLEntry) []ledger.GLEntry {
+	for i := range glMap {
+		e := &glMap[i]
+		e.Debit, e.Credit = e.Credit, e.Debit
+		e.DebitInAccountCurrency, e.CreditInAccountCurrency = e.CreditInAccountCurrency, e.DebitInAccountCurrency
+		e.DebitInTransactionCurrency, e.CreditInTransactionCurrency = e.CreditInTransactionCurrency, e.DebitInTransactionCurrency
+	}
+	return glMap
+}
+
+// Submit calculates the invoice and posts its GL entries through the engine.
+// An amendment also takes over the payments allocated to the invoice it
+// amends.
+//
+// Maps to: on_submit() -> make_gl_entries() in sales_invoice.py
+func (inv *SalesInvoice) Submit(engine *ledger.Engine, precision taxcalc.PrecisionProvider) error {
+	if err := inv.Calculate(precision); err != nil {
+		return err
+	}
+	glMap, err := inv.GetGLEntries()
+	if err != nil {
+		return err
+	}
+	if inv.AmendedFrom != "" {
+		if err := engine.CanRelinkAgainstVoucher(); err != nil {
+			return err
+		}
+	}
+	if err := engine.MakeGLEntries(glMap, ledger.DefaultPostingOptions()); err != nil {
+		return err
+	}
+	if inv.AmendedFrom != "" {
+		if err := engine.RelinkAgainstVoucher(VoucherType, inv.AmendedFrom, inv.Name); err != nil {
+			return err
+		}
+	}
+	inv.Status = Submitted
+	return nil
+}
+
+// Cancel reverses the invoice's GL entries and delinks its payment ledger
+// entries, so it no longer counts as outstanding.
+//
+// Maps to: on_cancel() -> make_gl_entries(cancel=True) in sales_invoice.py
+func (inv *SalesInvoice) Cancel(engine *ledger.Engine) error {
+	opts := ledger.DefaultPostingOptions()
+	opts.Cancel = true
+	if err := engine.MakeGLEntries([]ledger.GLEntry{inv.newGLEntry()}, opts); err != nil {
+		return err
+	}
+	if engine.PaymentStore != nil {
+		if err := engine.PaymentStore.Delink(VoucherType, inv.Name); err != nil {
+			return err
+		}
+	}
+	inv.Status = Cancelled
+	return nil
+}
+
+// validateAccounts checks mandatory accounting fields.
+func (inv *SalesInvoice) validateAccounts() error {
+	if inv.Customer == "" {
+		return ErrMissingCustomer
+	}
+	if inv.DebitTo == "" {
+		return ErrMissingDebitTo
+	}
+	for i, item := range inv.Items {
+		if item.IncomeAccount == "" {
+			return fmt.Errorf("%w: row %d (%s)", ErrMissingIncomeAccount, i+1, item.ItemCode)
+		}
+	}
+	return inv.validatePOS()
+}
+
+// newGLEntry returns an entry pre-filled with voucher-level fields.
+//
+// Maps to: get_gl_dict() in controllers/accounts_controller.py
+func (inv *SalesInvoice) newGLEntry() ledger.GLEntry {
+	rate := inv.ConversionRate
+	if rate == 0 {
+		rate = 1
+	}
+	return ledger.GLEntry{
+		PostingDate:             inv.PostingDate,
+		TransactionDate:         inv.PostingDate,
+		VoucherType:             VoucherType,
+		VoucherNo:               inv.Name,
+		Company:                 inv.Company,
+		CostCenter:              inv.CostCenter,
+		Project:                 inv.Project,
+		Remarks:                 inv.Remarks,
+		AccountCurrency:         inv.CompanyCurrency,
+		TransactionCurrency:     inv.Currency,
+		TransactionExchangeRate: rate,
+		IsOpening:               ledger.IsOpeningNo,
+		IsAdvance:               ledger.IsAdvanceNo,
+		Custom:                  inv.Custom.Clone(),
+	}
+}
+
+// partyAccountCurrency returns the currency of the receivable account.
+func (inv *SalesInvoice) partyAccountCurrency() string {
+	if inv.PartyAccountCurrency != "" {
+		return inv.PartyAccountCurrency
+	}
+	return inv.CompanyCurrency
+}
+
+// againstVoucher returns the invoice the receivable entries are booked
+// against: the returned invoice for a return, else this one.
+func (inv *SalesInvoice) againstVoucher() string {
+	if inv.IsReturn && inv.ReturnAgainst != "" {
+		return inv.ReturnAgainst
+	}
+	return inv.Name
+}
+
+// grandTotals returns the receivable amount in transaction and company currency.
+// The rounded total is used when rounding has been applied.
+func (inv *SalesInvoice) grandTotals() (total, baseTotal float64) {
+	if inv.RoundedTotal != 0 {
+		return inv.RoundedTotal, inv.BaseRoundedTotal
+	}
+	return inv.GrandTotal, inv.BaseGrandTotal
+}
+
+// makeCustomerGLEntry debits the receivable account with the grand total.
+//
+// Maps to: make_customer_gl_entry() in sales_invoice.py
+func (inv *SalesInvoice) makeCustomerGLEntry(glMap []ledger.GLEntry) []ledger.GLEntry {
+	total, baseTotal := inv.grandTotals()
+	if total == 0 {
+		return glMap
+	}
+
+	entry := inv.newGLEntry()
+	entry.Account = inv.DebitTo
+	entry.PartyType = "Customer"
+	entry.Party = inv.Customer
+	entry.DueDate = inv.DueDate
+	entry.Against = strings.Join(inv.againstIncomeAccounts(), ", ")
+	entry.AgainstVoucherType = VoucherType
+	entry.AgainstVoucher = inv.againstVoucher()
+	entry.Debit = baseTotal
+	entry.DebitInTransactionCurrency = total
+	entry.AccountCurrency = inv.partyAccountCurrency()
+	if entry.AccountCurrency == inv.CompanyCurrency {
+		entry.DebitInAccountCurrency = baseTotal
+	} else {
+		entry.DebitInAccountCurrency = total
+	}
+
+	return append(glMap, entry)
+}
+
+// againstIncomeAccounts lists the distinct income and tax accounts in row order.
+//
+// Maps to: set_against_income_account() in sales_invoice.py
+func (inv *SalesInvoice) againstIncomeAccounts() []string {
+	var accounts []string
+	seen := make(map[string]bool)
+	add := func(account string) {
+		if account != "" && !seen[account] {
+			seen[account] = true
+			accounts = append(accounts, account)
+		}
+	}
+	for _, item := range inv.Items {
+		add(item.IncomeAccount)
+	}
+	for _, tax := range inv.Taxes {
+		add(tax.AccountHead)
+	}
+	return accounts
+}
+
+// makeTaxGLEntries credits each tax account (debits deducted taxes).
+//
+// Maps to: make_tax_gl_entries() in sales_invoice.py
+func (inv *SalesInvoice) makeTaxGLEntries(glMap []ledger.GLEntry) []ledger.GLEntry {
+	for _, tax := range inv.Taxes {
+		txnAmount, amount := inv.taxAmounts(tax)
+		if tax.Category == taxcalc.Valuation || amount == 0 {
+			continue
+		}
+
+		entry := inv.newGLEntry()
+		entry.Account = tax.AccountHead
+		entry.Against = inv.Customer
+		if tax.CostCenter != "" {
+			entry.CostCenter = tax.CostCenter
+		}
+
+		if tax.AddDeductTax == taxcalc.Deduct {
+			entry.Debit = amount
+			entry.DebitInAccountCurrency = amount
+			entry.DebitInTransactionCurrency = txnAmount
+		} else {
+			entry.Credit = amount
+			entry.CreditInAccountCurrency = amount
+			entry.CreditInTransactionCurrency = txnAmount
+		}
+
+		glMap = append(glMap, entry)
+	}
+	return glMap
+}
+
+// taxAmounts returns the tax to book for a tax row. A discount on the
+// grand total that posts to the additional discount account comes off the
+// tax as well as the income, so the tax is booked before it; a discount on
+// the net total leaves the tax as charged on the discounted net.
+//
+// Maps to: get_tax_amounts() in controllers/accounts_controller.py
+func (inv *SalesInvoice) taxAmounts(tax *taxcalc.TaxRow) (amount, baseAmount float64) {
+	if inv.EnableDiscountAccounting && inv.DiscountAmount != 0 && inv.AdditionalDiscountAccount != "" &&
+		inv.ApplyDiscountOn != taxcalc.DiscountOnNetTotal {
+		return tax.TaxAmount, tax.BaseTaxAmount
+	}
+	return tax.TaxAmountAfterDiscountAmount, tax.BaseTaxAmountAfterDiscountAmount
+}
+
+// makeItemGLEntries credits the income account of each item.
+//
+// Maps to: make_item_gl_entries() in sales_invoice.py
+func (inv *SalesInvoice) makeItemGLEntries(glMap []ledger.GLEntry) []ledger.GLEntry {
+	for _, item := range inv.Items {
+		if item.BaseNetAmount == 0 {
+			continue
+		}
+
+		entry := inv.newGLEntry()
+		entry.Account = item.IncomeAccount
+		entry.Against = inv.Customer
+		if item.CostCenter != "" {
+			entry.CostCenter = item.CostCenter
+		}
+		entry.Custom = custom.Merge(inv.Custom, item.Custom)
+		amount, baseAmount := inv.itemAmounts(item)
+		entry.Credit = baseAmount
+		entry.CreditInAccountCurrency = baseAmount
+		entry.CreditInTransactionCurrency = amount
+
+		glMap = append(glMap, entry)
+	}
+	return glMap
+}
+
+// itemAmounts returns the income to book for an item. Under discount
+// accounting with an additional discount account, income is booked before
+// the document discount, which then posts separately.
+//
+// Maps to: get_amount_and_base_amount() in controllers/accounts_controller.py
+func (inv *SalesInvoice) itemAmounts(item *taxcalc.LineItem) (amount, baseAmount float64) {
+	if inv.EnableDiscountAccounting && inv.DiscountAmount != 0 && inv.AdditionalDiscountAccount != "" {
+		return item.Amount, item.BaseAmount
+	}
+	return item.NetAmount, item.BaseNetAmount
+}
+
+// makeDiscountGLEntries books discounts to their own accounts when discount
+// accounting is enabled. Item discounts debit the item's discount account and
+// gross up its income account; the document discount debits the additional
+// discount account, and makeTaxGLEntries books the tax a grand total
+// discount took off.
+//
+// Maps to: make_discount_gl_entries() in controllers/accounts_controller.py
+func (inv *SalesInvoice) makeDiscountGLEntries(glMap []ledger.GLEntry) []ledger.GLEntry {
+	if !inv.EnableDiscountAccounting {
+		return glMap
+	}
+
+	precision := taxcalc.DefaultPrecision{}.GetPrecision("amount")
+	for _, item := range inv.Items {
+		if item.DiscountAmount == 0 || item.DiscountAccount == "" {
+			continue
+		}
+		amount := taxcalc.Flt(item.DiscountAmount*item.Qty, precision)
+		baseAmount := taxcalc.Flt(amount*inv.ConversionRate, precision)
+
+		discount := inv.newGLEntry()
+		discount.Account = item.DiscountAccount
+		discount.Against = inv.Customer
+		if item.CostCenter != "" {
+			discount.CostCenter = item.CostCenter
+		}
+		discount.Custom = custom.Merge(inv.Custom, item.Custom)
+		discount.Debit = baseAmount
+		discount.DebitInAccountCurrency = baseAmount
+		discount.DebitInTransactionCurrency = amount
+
+		income := discount
+		income.Custom = discount.Custom.Clone()
+		income.Account = item.IncomeAccount
+		income.Debit, income.DebitInAccountCurrency, income.DebitInTransactionCurrency = 0, 0, 0
+		income.Credit = baseAmount
+		income.CreditInAccountCurrency = baseAmount
+		income.CreditInTransactionCurrency = amount
+
+		glMap = append(glMap, discount, income)
+	}
+
+	if inv.DiscountAmount != 0 && inv.AdditionalDiscountAccount != "" {
+		entry := inv.newGLEntry()
+		entry.Account = inv.AdditionalDiscountAccount
+		entry.Against = inv.Customer
+		entry.Debit = inv.BaseDiscountAmount
+		entry.DebitInAccountCurrency = inv.BaseDiscountAmount
+		entry.DebitInTransactionCurrency = inv.DiscountAmount
+		glMap = append(glMap, entry)
+	}
+
+	return glMap
+}
+
+// makeGLEForRoundingAdjustment posts the rounding adjustment to the
+// round-off account.
+//
+// Maps to: make_gle_for_rounding_adjustment() in sales_invoice.py
+func (inv *SalesInvoice) makeGLEForRoundingAdjustment(glMap []ledger.GLEntry) []ledger.GLEntry {
+	if inv.BaseRoundingAdjustment == 0 || inv.RoundOffAccount == "" {
+		return glMap
+	}
+
+	entry := inv.newGLEntry()
+	entry.Account = inv.RoundOffAccount
+	entry.Against = inv.Customer
+	entry.Credit = inv.BaseRoundingAdjustment
+	entry.CreditInAccountCurrency = inv.BaseRoundingAdjustment
+	entry.CreditInTransactionCurrency = inv.RoundingAdjustment
+
+	return append(glMap, entry)
+}
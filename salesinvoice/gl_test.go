@@ -0,0 +1,293 @@
+package salesinvoice
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/custom"
+	"github.com/senguttuvang/erpnext-go/ledger"
+	"github.com/senguttuvang/erpnext-go/ledger/memstore"
+	"github.com/senguttuvang/erpnext-go/taxcalc"
+)
+
+// newGSTInvoice mirrors TestRealisticSalesInvoiceGLEntries in the ledger
+// package: Widget @ ₹10,000 with CGST 9% and SGST 9%.
+func newGSTInvoice() *SalesInvoice {
+	return &SalesInvoice{
+		Name:            "SINV-2024-00001",
+		Company:         "ACME Industries Pvt Ltd",
+		Customer:        "Acme Corporation",
+		PostingDate:     time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC),
+		DebitTo:         "Debtors - ACME",
+		CompanyCurrency: "INR",
+		CostCenter:      "Main - ACME",
+		Document: taxcalc.Document{
+			Currency: "INR",
+			Items: []*taxcalc.LineItem{
+				{ItemCode: "WIDGET-001", Qty: 1, Rate: 10000, IncomeAccount: "Sales - ACME"},
+			},
+			Taxes: []*taxcalc.TaxRow{
+				{AccountHead: "CGST Payable - ACME", ChargeType: taxcalc.OnNetTotal, Rate: 9},
+				{AccountHead: "SGST Payable - ACME", ChargeType: taxcalc.OnNetTotal, Rate: 9},
+			},
+		},
+	}
+}
+
+func TestGetGLEntries(t *testing.T) {
+	inv := newGSTInvoice()
+	if err := inv.Calculate(nil); err != nil {
+		t.Fatalf("Calculate() error = %v", err)
+	}
+
+	glMap, err := inv.GetGLEntries()
+	if err != nil {
+		t.Fatalf("GetGLEntries() error = %v", err)
+	}
+
+	expected := []struct {
+		account string
+		debit   float64
+		credit  float64
+	}{
+		{"Debtors - ACME", 11800, 0},
+		{"CGST Payable - ACME", 0, 900},
+		{"SGST Payable - ACME", 0, 900},
+		{"Sales - ACME", 0, 10000},
+	}
+
+	if len(glMap) != len(expected) {
+		t.Fatalf("Expected %d GL entries, got %d", len(expected), len(glMap))
+	}
+	for i, exp := range expected {
+		got := glMap[i]
+		if got.Account != exp.account || got.Debit != exp.debit || got.Credit != exp.credit {
+			t.Errorf("entry %d = %s %.2f/%.2f, want %s %.2f/%.2f",
+				i, got.Account, got.Debit, got.Credit, exp.account, exp.debit, exp.credit)
+		}
+	}
+
+	receivable := glMap[0]
+	if receivable.PartyType != "Customer" || receivable.Party != "Acme Corporation" {
+		t.Errorf("Receivable party = %s/%s", receivable.PartyType, receivable.Party)
+	}
+	if receivable.Against != "Sales - ACME, CGST Payable - ACME, SGST Payable - ACME" {
+		t.Errorf("Receivable against = %q", receivable.Against)
+	}
+	if receivable.AgainstVoucher != inv.Name {
+		t.Errorf("Receivable against voucher = %q, want %q", receivable.AgainstVoucher, inv.Name)
+	}
+	if !ledger.GLMap(glMap).IsBalanced() {
+		t.Errorf("GL map not balanced")
+	}
+}
+
+func TestGetGLEntries_CustomFields(t *testing.T) {
+	inv := newGSTInvoice()
+	inv.Custom = custom.Fields{"sales_channel": "Web", "region": "North"}
+	inv.Items[0].Custom = custom.Fields{"region": "South"}
+	if err := inv.Calculate(nil); err != nil {
+		t.Fatal(err)
+	}
+	glMap, err := inv.GetGLEntries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range glMap {
+		want := "North"
+		if e.Account == "Sales - ACME" {
+			want = "South"
+		}
+		if e.Custom.String("sales_channel") != "Web" || e.Custom.String("region") != want {
+			t.Errorf("%s custom fields = %v", e.Account, e.Custom)
+		}
+	}
+	glMap[0].Custom["region"] = "East"
+	if inv.Custom.String("region") != "North" {
+		t.Error("GL entry shares the invoice's custom fields")
+	}
+}
+
+func TestGetGLEntries_ForeignCurrency(t *testing.T) {
+	inv := newGSTInvoice()
+	inv.Currency = "USD"
+	inv.ConversionRate = 83.5
+	inv.PartyAccountCurrency = "USD"
+	inv.Items[0].Rate = 100
+	inv.Calculate(nil)
+
+	glMap, err := inv.GetGLEntries()
+	if err != nil {
+		t.Fatalf("GetGLEntries() error = %v", err)
+	}
+
+	receivable := glMap[0]
+	if receivable.Debit != 9853 {
+		t.Errorf("Receivable debit = %.2f, want 9853", receivable.Debit)
+	}
+	if receivable.DebitInAccountCurrency != 118 || receivable.AccountCurrency != "USD" {
+		t.Errorf("Receivable in account currency = %.2f %s, want 118 USD",
+			receivable.DebitInAccountCurrency, receivable.AccountCurrency)
+	}
+	if glMap[3].CreditInTransactionCurrency != 100 {
+		t.Errorf("Income in transaction currency = %.2f, want 100", glMap[3].CreditInTransactionCurrency)
+	}
+}
+
+func TestGetGLEntries_Validation(t *testing.T) {
+	inv := newGSTInvoice()
+	inv.Items[0].IncomeAccount = ""
+	if _, err := inv.GetGLEntries(); !errors.Is(err, ErrMissingIncomeAccount) {
+		t.Errorf("Expected ErrMissingIncomeAccount, got %v", err)
+	}
+
+	inv = newGSTInvoice()
+	inv.DebitTo = ""
+	if _, err := inv.GetGLEntries(); !errors.Is(err, ErrMissingDebitTo) {
+		t.Errorf("Expected ErrMissingDebitTo, got %v", err)
+	}
+}
+
+func TestSubmitAndCancel(t *testing.T) {
+	glStore := memstore.NewGLStore()
+	paymentStore := memstore.NewPaymentStore()
+	engine := &ledger.Engine{GLStore: glStore, PaymentStore: paymentStore}
+
+	inv := newGSTInvoice()
+	if err := inv.Submit(engine, nil); err != nil {
+		t.Fatalf("Submit() error = %v", err)
+	}
+	if n := len(glStore.All()); n != 4 {
+		t.Errorf("Expected 4 GL entries after submit, got %d", n)
+	}
+	if n := len(paymentStore.All()); n != 1 {
+		t.Errorf("Expected 1 payment ledger entry, got %d", n)
+	}
+
+	if err := inv.Cancel(engine); err != nil {
+		t.Fatalf("Cancel() error = %v", err)
+	}
+	entries := glStore.All()
+	if len(entries) != 8 {
+		t.Fatalf("Expected 8 GL entries after cancel, got %d", len(entries))
+	}
+	if !ledger.GLMap(entries).IsBalanced() || ledger.GLMap(entries).TotalDebit() != 23600 {
+		t.Errorf("Cancelled ledger should contain balanced original and reversal entries")
+	}
+}
+
+func TestSetTaxes(t *testing.T) {
+	templates := []*taxcalc.TaxTemplate{
+		{Name: "In-State GST - ACME", Company: "ACME Industries Pvt Ltd", IsDefault: true, Taxes: []taxcalc.TaxRow{
+			{AccountHead: "CGST Payable - ACME", ChargeType: taxcalc.OnNetTotal, Rate: 9},
+			{AccountHead: "SGST Payable - ACME", ChargeType: taxcalc.OnNetTotal, Rate: 9},
+		}},
+		{Name: "Out-State GST - ACME", Company: "ACME Industries Pvt Ltd", TaxCategory: "Out-State", Taxes: []taxcalc.TaxRow{
+			{AccountHead: "IGST Payable - ACME", ChargeType: taxcalc.OnNetTotal, Rate: 18},
+		}},
+	}
+
+	inv := newGSTInvoice()
+	inv.TaxCategory = "Out-State"
+	inv.SetTaxes(templates)
+	if inv.TaxesAndCharges != "Out-State GST - ACME" || len(inv.Taxes) != 1 {
+		t.Fatalf("SetTaxes() = %s with %d rows", inv.TaxesAndCharges, len(inv.Taxes))
+	}
+
+	if err := inv.Calculate(nil); err != nil {
+		t.Fatalf("Calculate() error = %v", err)
+	}
+	glMap, err := inv.GetGLEntries()
+	if err != nil {
+		t.Fatalf("GetGLEntries() error = %v", err)
+	}
+	if glMap[1].Account != "IGST Payable - ACME" || glMap[1].Credit != 1800 {
+		t.Errorf("tax entry = %s %.2f, want IGST Payable - ACME 1800", glMap[1].Account, glMap[1].Credit)
+	}
+}
+
+func TestGetGLEntries_DiscountAccounting(t *testing.T) {
+	inv := newGSTInvoice()
+	inv.EnableDiscountAccounting = true
+	inv.AdditionalDiscountAccount = "Additional Discount - ACME"
+	inv.AdditionalDiscountPercentage = 10
+	inv.ApplyDiscountOn = taxcalc.DiscountOnNetTotal
+	inv.Taxes = inv.Taxes[:1]
+	inv.Items[0] = &taxcalc.LineItem{
+		ItemCode: "WIDGET-001", Qty: 2, PriceListRate: 1000, DiscountPercentage: 10,
+		IncomeAccount: "Sales - ACME", DiscountAccount: "Discount Allowed - ACME",
+	}
+	if err := inv.Calculate(nil); err != nil {
+		t.Fatalf("Calculate() error = %v", err)
+	}
+
+	glMap, err := inv.GetGLEntries()
+	if err != nil {
+		t.Fatalf("GetGLEntries() error = %v", err)
+	}
+
+	// Net 1800 - 180 = 1620, CGST 9% = 145.80; income is booked at list price
+	balances := map[string]float64{}
+	for _, e := range glMap {
+		balances[e.Account] += e.Debit - e.Credit
+	}
+	expected := map[string]float64{
+		"Debtors - ACME":             1765.80,
+		"CGST Payable - ACME":        -145.80,
+		"Sales - ACME":               -2000,
+		"Discount Allowed - ACME":    200,
+		"Additional Discount - ACME": 180,
+	}
+	for account, want := range expected {
+		if got := balances[account]; got < want-0.001 || got > want+0.001 {
+			t.Errorf("%s balance = %.2f, want %.2f", account, got, want)
+		}
+	}
+	if !ledger.GLMap(glMap).IsBalanced() {
+		t.Errorf("GL map not balanced")
+	}
+
+	// Without discount accounting income is booked net of all discounts
+	inv.EnableDiscountAccounting = false
+	glMap, _ = inv.GetGLEntries()
+	if len(glMap) != 3 || glMap[2].Credit != 1620 {
+		t.Errorf("expected 3 entries with net income 1620, got %d entries", len(glMap))
+	}
+}
+
+func TestGetGLEntries_DiscountAccountingOnGrandTotal(t *testing.T) {
+	inv := newGSTInvoice()
+	inv.EnableDiscountAccounting = true
+	inv.AdditionalDiscountAccount = "Additional Discount - ACME"
+	inv.DiscountAmount = 1180
+	if err := inv.Calculate(nil); err != nil {
+		t.Fatalf("Calculate() error = %v", err)
+	}
+
+	glMap, err := inv.GetGLEntries()
+	if err != nil {
+		t.Fatalf("GetGLEntries() error = %v", err)
+	}
+
+	// Grand total 11800 - 1180; income and taxes are booked before the discount
+	balances := map[string]float64{}
+	for _, e := range glMap {
+		balances[e.Account] += e.Debit - e.Credit
+	}
+	expected := map[string]float64{
+		"Debtors - ACME":             10620,
+		"Additional Discount - ACME": 1180,
+		"Sales - ACME":               -10000,
+		"CGST Payable - ACME":        -900,
+		"SGST Payable - ACME":        -900,
+	}
+	for account, want := range expected {
+		if got := balances[account]; got < want-0.001 || got > want+0.001 {
+			t.Errorf("%s balance = %.2f, want %.2f", account, got, want)
+		}
+	}
+	if !ledger.GLMap(glMap).IsBalanced() {
+		t.Errorf("GL map not balanced")
+	}
+}
@@ -0,0 +1,129 @@
+// Package salesinvoice implements the accounting side of ERPNext's
+// Sales Invoice: totals calculation and GL map construction.
+// Migrated from: erpnext/accounts/doctype/sales_invoice/sales_invoice.py
+//
+// Totals come from taxcalc; the resulting GL map is posted through
+// ledger.Engine exactly like every other voucher.
+package salesinvoice
+
+import (
+	"errors"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/taxcalc"
+)
+
+// VoucherType is the ERPNext doctype name used on GL entries.
+const VoucherType = "Sales Invoice"
+
+// Validation errors matching frappe.throw() calls in sales_invoice.py
+var (
+	ErrMissingDebitTo        = errors.New("debit to account is mandatory")
+	ErrMissingIncomeAccount  = errors.New("income account is mandatory for item")
+	ErrMissingCustomer       = errors.New("customer is mandatory")
+	ErrMissingChangeAccount  = errors.New("account for change amount is mandatory")
+	ErrMissingPaymentAccount = errors.New("account is mandatory for payment mode")
+	ErrNotCancelled          = errors.New("only a cancelled invoice can be amended")
+	ErrNotSubmitted          = errors.New("only a submitted invoice can be returned")
+	ErrInvalidReturnQty      = errors.New("return quantity must be positive and not exceed the quantity sold")
+)
+
+// Status is the document state of an invoice.
+type Status string
+
+const (
+	Draft     Status = "Draft"
+	Submitted Status = "Submitted"
+	Cancelled Status = "Cancelled"
+)
+
+// SalesInvoice is a customer invoice.
+// Items, taxes and totals live in the embedded taxcalc.Document.
+//
+// Maps to: erpnext/accounts/doctype/sales_invoice/sales_invoice.json
+type SalesInvoice struct {
+	Name        string
+	Company     string
+	Customer    string
+	PostingDate time.Time
+	DueDate     *time.Time
+
+	// Receivable account and its currency
+	DebitTo              string
+	PartyAccountCurrency string // Defaults to CompanyCurrency
+
+	CompanyCurrency string
+	CostCenter      string // Default for rows without a cost center
+	Project         string
+	RoundOffAccount string // Receives RoundingAdjustment, if any
+	Remarks         string
+
+	TaxesAndCharges string // Sales Taxes and Charges Template applied to Taxes
+
+	Status      Status
+	AmendedFrom string // Cancelled invoice this one amends
+
+	// Returns (credit notes) are entered and calculated with positive
+	// quantities, like the invoice they return; their GL entries are the
+	// mirror image of a sale's
+	IsReturn      bool
+	ReturnAgainst string          // Invoice whose outstanding the return reduces
+	ReturnRows    []int           // Of a return: the row of ReturnAgainst each item takes back
+	ReturnedQty   map[int]float64 // Of an invoice: quantity per item row taken back by submitted returns
+
+	// Discount accounting (Selling Settings.enable_discount_accounting):
+	// income is booked gross and discounts post to their own accounts.
+	// Taxes are booked as charged: on the discounted net for a net total
+	// discount, before the discount for a grand total one, as ERPNext
+	// does. Purchases have no counterpart, discount received, as the
+	// module builds no Purchase Invoice GL map.
+	EnableDiscountAccounting  bool
+	AdditionalDiscountAccount string // Receives the document discount
+
+	// Point of sale: payments received with the invoice
+	IsPOS                  bool
+	Payments               []*Payment
+	PaidAmount             float64
+	BasePaidAmount         float64
+	ChangeAmount           float64 // Cash returned to the customer
+	BaseChangeAmount       float64
+	AccountForChangeAmount string // Cash account the change is paid from
+
+	taxcalc.Document
+}
+
+// Calculate runs the tax and totals calculation on the invoice.
+//
+// Maps to: calculate_taxes_and_totals() called from validate()
+func (inv *SalesInvoice) Calculate(precision taxcalc.PrecisionProvider) error {
+	if err := taxcalc.NewCalculator(&inv.Document, precision).Calculate(); err != nil {
+		return err
+	}
+	if inv.IsPOS {
+		inv.calculatePaidAndChangeAmount(precision)
+	}
+	return nil
+}
+
+// SetMissingItemDetails fills item rows from the Item master: income account,
+// cost center, HSN code, UOM conversion and item tax rates for the invoice's
+// company.
+//
+// Maps to: set_missing_item_details() called from set_missing_values()
+func (inv *SalesInvoice) SetMissingItemDetails(master taxcalc.ItemMaster) error {
+	return taxcalc.ApplyItemDefaults(&inv.Document, inv.Company, master)
+}
+
+// SetTaxes replaces the tax rows with the template selected for the
+// invoice's company and tax category. Existing rows are kept when no
+// template applies.
+//
+// Maps to: set_taxes() / append_taxes_from_master() in accounts_controller.py
+func (inv *SalesInvoice) SetTaxes(templates []*taxcalc.TaxTemplate) {
+	template := taxcalc.SelectTaxTemplate(templates, inv.Company, inv.TaxCategory)
+	if template == nil {
+		return
+	}
+	inv.TaxesAndCharges = template.Name
+	inv.Taxes = template.Rows()
+}
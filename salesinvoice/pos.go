@@ -0,0 +1,204 @@
+package salesinvoice
+
+import (
+	"fmt"
+
+	"github.com/senguttuvang/erpnext-go/ledger"
+	"github.com/senguttuvang/erpnext-go/modeofpayment"
+	"github.com/senguttuvang/erpnext-go/taxcalc"
+)
+
+// Payment is a payment received at the point of sale.
+// Maps to: Sales Invoice Payment child table
+type Payment struct {
+	ModeOfPayment string
+	Type          modeofpayment.PaymentType
+	Account       string // Cash or bank account receiving the payment
+	Amount        float64
+	BaseAmount    float64 // Defaults to Amount × conversion rate
+
+	// Processing fee withheld by the provider, set by SetPaymentFees. The
+	// account receives BaseAmount less the fee, which is debited to
+	// FeeAccount.
+	Fee           modeofpayment.Fee
+	FeeAccount    string
+	FeeCostCenter string
+	FeeAmount     float64 // Calculated; transaction currency
+	BaseFeeAmount float64 // Calculated; company currency
+}
+
+// SetPaymentFees copies the fee of each payment's mode of payment, with
+// the company's fee account, onto the payment. Calculate then works out
+// the fee amounts.
+//
+// Maps to: the Mode of Payment lookup of set_pos_fields() in
+// sales_invoice.py
+func (inv *SalesInvoice) SetPaymentFees(modes modeofpayment.Lookup) error {
+	for _, p := range inv.Payments {
+		mode, err := modes.GetModeOfPayment(p.ModeOfPayment)
+		if err != nil {
+			return err
+		}
+		p.Fee, p.FeeAccount, p.FeeCostCenter = mode.Fee, "", ""
+		if mode.Fee.IsZero() {
+			continue
+		}
+		account, ok := mode.AccountFor(inv.Company)
+		if !ok || account.FeeAccount == "" {
+			return fmt.Errorf("%w: mode %s, company %s", modeofpayment.ErrMissingFeeAccount, mode.Name, inv.Company)
+		}
+		p.FeeAccount, p.FeeCostCenter = account.FeeAccount, account.FeeCostCenter
+	}
+	return nil
+}
+
+// calculatePaidAndChangeAmount totals the payments and, when cash paid
+// exceeds the invoice total, records the difference as change.
+//
+// Maps to: calculate_paid_amount() and calculate_change_amount() in
+// taxes_and_totals.py
+//
+// Python equivalent:
+//
+//	def calculate_change_amount(self):
+//	    grand_total = self.doc.rounded_total or self.doc.grand_total
+//	    if (self.doc.paid_amount > grand_total and not self.doc.is_return
+//	        and any(d.type == "Cash" for d in self.doc.payments)):
+//	        self.doc.change_amount = flt(self.doc.paid_amount - grand_total)
+//	        self.doc.base_change_amount = flt(self.doc.base_paid_amount - base_grand_total)
+func (inv *SalesInvoice) calculatePaidAndChangeAmount(precision taxcalc.PrecisionProvider) {
+	if precision == nil {
+		precision = taxcalc.DefaultPrecision{}
+	}
+	amountPrecision := precision.GetPrecision("paid_amount")
+
+	inv.PaidAmount, inv.BasePaidAmount = 0, 0
+	hasCash := false
+	for _, p := range inv.Payments {
+		p.Amount = taxcalc.Flt(p.Amount, amountPrecision)
+		if p.BaseAmount == 0 {
+			p.BaseAmount = taxcalc.Flt(p.Amount*inv.ConversionRate, amountPrecision)
+		}
+		p.BaseFeeAmount, p.FeeAmount = p.Fee.On(p.BaseAmount), 0
+		if p.BaseFeeAmount != 0 {
+			// The fixed part of a fee is in company currency
+			p.FeeAmount = taxcalc.Flt(p.Amount*p.BaseFeeAmount/p.BaseAmount, amountPrecision)
+		}
+		inv.PaidAmount += p.Amount
+		inv.BasePaidAmount += p.BaseAmount
+		if p.Type == modeofpayment.Cash {
+			hasCash = true
+		}
+	}
+	inv.PaidAmount = taxcalc.Flt(inv.PaidAmount, amountPrecision)
+	inv.BasePaidAmount = taxcalc.Flt(inv.BasePaidAmount, amountPrecision)
+
+	inv.ChangeAmount, inv.BaseChangeAmount = 0, 0
+	total, baseTotal := inv.grandTotals()
+	if inv.PaidAmount > total && hasCash && !inv.IsReturn {
+		changePrecision := precision.GetPrecision("change_amount")
+		inv.ChangeAmount = taxcalc.Flt(inv.PaidAmount-total, changePrecision)
+		inv.BaseChangeAmount = taxcalc.Flt(inv.BasePaidAmount-baseTotal, changePrecision)
+	}
+}
+
+// validatePOS checks the accounts needed to post POS payments.
+func (inv *SalesInvoice) validatePOS() error {
+	if !inv.IsPOS {
+		return nil
+	}
+	for i, p := range inv.Payments {
+		if p.Amount != 0 && p.Account == "" {
+			return fmt.Errorf("%w: row %d (%s)", ErrMissingPaymentAccount, i+1, p.ModeOfPayment)
+		}
+		if p.BaseFeeAmount != 0 && p.FeeAccount == "" {
+			return fmt.Errorf("%w: row %d (%s)", modeofpayment.ErrMissingFeeAccount, i+1, p.ModeOfPayment)
+		}
+	}
+	if inv.ChangeAmount != 0 && inv.AccountForChangeAmount == "" {
+		return ErrMissingChangeAccount
+	}
+	return nil
+}
+
+// makePOSGLEntries settles the receivable with the payments received and
+// pays the change back from the change account. A payment's fee is
+// debited to its fee account and the rest to the payment account.
+//
+// Maps to: make_pos_gl_entries() and get_gle_for_change_amount() in
+// sales_invoice.py
+func (inv *SalesInvoice) makePOSGLEntries(glMap []ledger.GLEntry) []ledger.GLEntry {
+	if !inv.IsPOS {
+		return glMap
+	}
+
+	for _, p := range inv.Payments {
+		if p.BaseAmount == 0 {
+			continue
+		}
+		glMap = append(glMap,
+			inv.newReceivableEntry(p.Account, 0, p.BaseAmount, p.Amount),
+			inv.newAccountEntry(p.Account, p.BaseAmount-p.BaseFeeAmount, 0, p.Amount-p.FeeAmount))
+		if p.BaseFeeAmount != 0 {
+			fee := inv.newAccountEntry(p.FeeAccount, p.BaseFeeAmount, 0, p.FeeAmount)
+			if p.FeeCostCenter != "" {
+				fee.CostCenter = p.FeeCostCenter
+			}
+			glMap = append(glMap, fee)
+		}
+	}
+
+	if inv.BaseChangeAmount != 0 {
+		glMap = append(glMap,
+			inv.newReceivableEntry(inv.AccountForChangeAmount, inv.BaseChangeAmount, 0, inv.ChangeAmount),
+			inv.newAccountEntry(inv.AccountForChangeAmount, 0, inv.BaseChangeAmount, inv.ChangeAmount))
+	}
+
+	return glMap
+}
+
+// newReceivableEntry returns a customer entry on the receivable account
+// against this invoice.
+func (inv *SalesInvoice) newReceivableEntry(against string, debit, credit, txnAmount float64) ledger.GLEntry {
+	entry := inv.newGLEntry()
+	entry.Account = inv.DebitTo
+	entry.PartyType = "Customer"
+	entry.Party = inv.Customer
+	entry.Against = against
+	entry.AgainstVoucherType = VoucherType
+	entry.AgainstVoucher = inv.againstVoucher()
+	entry.AccountCurrency = inv.partyAccountCurrency()
+	setAmounts(&entry, debit, credit, txnAmount, entry.AccountCurrency != inv.CompanyCurrency)
+	return entry
+}
+
+// newAccountEntry returns an entry on a company-currency account against
+// the customer.
+func (inv *SalesInvoice) newAccountEntry(account string, debit, credit, txnAmount float64) ledger.GLEntry {
+	entry := inv.newGLEntry()
+	entry.Account = account
+	entry.Against = inv.Customer
+	setAmounts(&entry, debit, credit, txnAmount, false)
+	return entry
+}
+
+// setAmounts fills the company, account and transaction currency amounts.
+// Account currency amounts equal the transaction amount when the account is
+// in the transaction currency, otherwise the company amount.
+func setAmounts(entry *ledger.GLEntry, debit, credit, txnAmount float64, inTxnCurrency bool) {
+	accountDebit, accountCredit := debit, credit
+	if inTxnCurrency {
+		if debit != 0 {
+			accountDebit = txnAmount
+		} else {
+			accountCredit = txnAmount
+		}
+	}
+	entry.Debit, entry.Credit = debit, credit
+	entry.DebitInAccountCurrency, entry.CreditInAccountCurrency = accountDebit, accountCredit
+	if debit != 0 {
+		entry.DebitInTransactionCurrency = txnAmount
+	} else {
+		entry.CreditInTransactionCurrency = txnAmount
+	}
+}
@@ -0,0 +1,140 @@
+package salesinvoice
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/ledger"
+	"github.com/senguttuvang/erpnext-go/ledger/memstore"
+	"github.com/senguttuvang/erpnext-go/modeofpayment"
+	"github.com/senguttuvang/erpnext-go/taxcalc"
+)
+
+// newPOSInvoice is a café sale paid in cash, rounded to 0.05.
+func newPOSInvoice() *SalesInvoice {
+	return &SalesInvoice{
+		Name:                   "ACC-PSINV-2024-00001",
+		Company:                "Zurich Café GmbH",
+		Customer:               "Walk-in Customer",
+		PostingDate:            time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+		DebitTo:                "Debtors - ZC",
+		CompanyCurrency:        "CHF",
+		CostCenter:             "Main - ZC",
+		RoundOffAccount:        "Round Off - ZC",
+		IsPOS:                  true,
+		AccountForChangeAmount: "Cash - ZC",
+		Payments: []*Payment{
+			{ModeOfPayment: "Cash", Type: modeofpayment.Cash, Account: "Cash - ZC", Amount: 50},
+		},
+		Document: taxcalc.Document{
+			Currency:     "CHF",
+			CashRounding: 0.05,
+			Items: []*taxcalc.LineItem{
+				{ItemCode: "COFFEE", Qty: 1, Rate: 19.97, IncomeAccount: "Sales - ZC"},
+			},
+			Taxes: []*taxcalc.TaxRow{
+				{AccountHead: "VAT - ZC", ChargeType: taxcalc.OnNetTotal, Rate: 7.7},
+			},
+		},
+	}
+}
+
+func TestPOS_ChangeAndRounding(t *testing.T) {
+	inv := newPOSInvoice()
+	if err := inv.Calculate(nil); err != nil {
+		t.Fatalf("Calculate() error = %v", err)
+	}
+	if inv.RoundedTotal != 21.50 || inv.PaidAmount != 50 || inv.ChangeAmount != 28.50 {
+		t.Fatalf("rounded %.2f paid %.2f change %.2f, want 21.50/50/28.50",
+			inv.RoundedTotal, inv.PaidAmount, inv.ChangeAmount)
+	}
+
+	store := memstore.NewGLStore()
+	engine := &ledger.Engine{GLStore: store, PaymentStore: memstore.NewPaymentStore()}
+	if err := inv.Submit(engine, nil); err != nil {
+		t.Fatalf("Submit() error = %v", err)
+	}
+
+	balances := map[string]float64{}
+	for _, e := range store.All() {
+		balances[e.Account] += e.Debit - e.Credit
+	}
+	expected := map[string]float64{
+		"Debtors - ZC":   0,
+		"Cash - ZC":      21.50,
+		"VAT - ZC":       -1.54,
+		"Sales - ZC":     -19.97,
+		"Round Off - ZC": 0.01, // Rounding loss
+	}
+	for account, want := range expected {
+		if got := balances[account]; got < want-0.001 || got > want+0.001 {
+			t.Errorf("%s balance = %.2f, want %.2f", account, got, want)
+		}
+	}
+}
+
+type modes map[string]*modeofpayment.ModeOfPayment
+
+func (m modes) GetModeOfPayment(name string) (*modeofpayment.ModeOfPayment, error) {
+	if mode, ok := m[name]; ok {
+		return mode, nil
+	}
+	return nil, errors.New("mode of payment not found")
+}
+
+func TestPOS_CardFee(t *testing.T) {
+	inv := newPOSInvoice()
+	inv.Payments = []*Payment{{ModeOfPayment: "Card", Type: modeofpayment.Bank, Account: "Card Clearing - ZC", Amount: 21.50}}
+	card := &modeofpayment.ModeOfPayment{
+		Name: "Card", Type: modeofpayment.Bank, Enabled: true, Fee: modeofpayment.Fee{Percentage: 2},
+		Accounts: []modeofpayment.ModeOfPaymentAccount{{Company: inv.Company, DefaultAccount: "Card Clearing - ZC", FeeAccount: "Card Fees - ZC"}},
+	}
+	if err := inv.SetPaymentFees(modes{"Card": card}); err != nil {
+		t.Fatal(err)
+	}
+	if err := inv.Calculate(nil); err != nil {
+		t.Fatal(err)
+	}
+	if p := inv.Payments[0]; p.BaseFeeAmount != 0.43 || p.FeeAmount != 0.43 {
+		t.Fatalf("fee = %.2f / %.2f, want 0.43", p.FeeAmount, p.BaseFeeAmount)
+	}
+
+	store := memstore.NewGLStore()
+	engine := &ledger.Engine{GLStore: store, PaymentStore: memstore.NewPaymentStore()}
+	if err := inv.Submit(engine, nil); err != nil {
+		t.Fatal(err)
+	}
+	balances := map[string]float64{}
+	for _, e := range store.All() {
+		balances[e.Account] += e.Debit - e.Credit
+	}
+	for account, want := range map[string]float64{"Debtors - ZC": 0, "Card Clearing - ZC": 21.07, "Card Fees - ZC": 0.43} {
+		if got := ledger.Flt(balances[account], 2); got != want {
+			t.Errorf("%s balance = %.2f, want %.2f", account, got, want)
+		}
+	}
+
+	card.Accounts[0].FeeAccount = ""
+	if err := inv.SetPaymentFees(modes{"Card": card}); !errors.Is(err, modeofpayment.ErrMissingFeeAccount) {
+		t.Errorf("SetPaymentFees() without fee account = %v", err)
+	}
+}
+
+func TestPOS_NoChangeWithoutCash(t *testing.T) {
+	inv := newPOSInvoice()
+	inv.Payments[0].Type = modeofpayment.Bank
+	inv.Calculate(nil)
+	if inv.ChangeAmount != 0 {
+		t.Errorf("change amount = %.2f, want 0 for non-cash payments", inv.ChangeAmount)
+	}
+}
+
+func TestPOS_MissingChangeAccount(t *testing.T) {
+	inv := newPOSInvoice()
+	inv.AccountForChangeAmount = ""
+	inv.Calculate(nil)
+	if _, err := inv.GetGLEntries(); !errors.Is(err, ErrMissingChangeAccount) {
+		t.Errorf("Expected ErrMissingChangeAccount, got %v", err)
+	}
+}
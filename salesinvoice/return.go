@@ -0,0 +1,170 @@
+package salesinvoice
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/modeofpayment"
+	"github.com/senguttuvang/erpnext-go/taxcalc"
+)
+
+// MakeReturn returns a calculated draft credit note for part of a
+// submitted invoice. qty maps item row indexes to the quantity returned
+// from that row, which may not exceed what earlier returns recorded with
+// AddReturn left. Actual-amount taxes and a fixed document discount are
+// taken over in proportion to the amount returned.
+//
+// A POS return refunds the customer in the modes the invoice was paid in,
+// in proportion to what each mode kept after change; processing fees are
+// not refunded.
+//
+// Maps to: make_return_doc() in controllers/sales_and_purchase_return.py
+func (inv *SalesInvoice) MakeReturn(name string, postingDate time.Time, qty map[int]float64, precision taxcalc.PrecisionProvider) (*SalesInvoice, error) {
+	if inv.Status != Submitted {
+		return nil, fmt.Errorf("%w: %s is %s", ErrNotSubmitted, inv.Name, inv.Status)
+	}
+	if len(qty) == 0 {
+		return nil, fmt.Errorf("%w: nothing returned from %s", ErrInvalidReturnQty, inv.Name)
+	}
+
+	doc := inv.Document.Copy()
+	doc.Items = nil
+	var rows []int
+	returned := 0.0
+	for _, row := range slices.Sorted(maps.Keys(qty)) {
+		if row < 0 || row >= len(inv.Items) || qty[row] <= 0 || qty[row] > inv.returnable(row) {
+			return nil, fmt.Errorf("%w: row %d of %s", ErrInvalidReturnQty, row+1, inv.Name)
+		}
+		rows = append(rows, row)
+		item := *inv.Items[row]
+		item.ItemTaxMap = maps.Clone(item.ItemTaxMap)
+		item.Custom = item.Custom.Clone()
+		item.Qty, item.StockQty = qty[row], 0
+		doc.Items = append(doc.Items, &item)
+		returned += inv.Items[row].Amount * qty[row] / inv.Items[row].Qty
+	}
+	share := 1.0
+	if inv.Total != 0 {
+		share = returned / inv.Total
+	}
+	for _, tax := range doc.Taxes {
+		if tax.ChargeType == taxcalc.Actual {
+			tax.Rate *= share
+		}
+	}
+	if doc.AdditionalDiscountPercentage == 0 {
+		doc.DiscountAmount *= share
+	}
+	doc.BaseDiscountAmount = 0
+	doc.Custom = inv.Custom.Clone()
+
+	ret := &SalesInvoice{
+		Name:                      name,
+		Company:                   inv.Company,
+		Customer:                  inv.Customer,
+		PostingDate:               postingDate,
+		DebitTo:                   inv.DebitTo,
+		PartyAccountCurrency:      inv.PartyAccountCurrency,
+		CompanyCurrency:           inv.CompanyCurrency,
+		CostCenter:                inv.CostCenter,
+		Project:                   inv.Project,
+		RoundOffAccount:           inv.RoundOffAccount,
+		Remarks:                   "Return against " + inv.Name,
+		TaxesAndCharges:           inv.TaxesAndCharges,
+		Status:                    Draft,
+		IsReturn:                  true,
+		ReturnAgainst:             inv.Name,
+		ReturnRows:                rows,
+		EnableDiscountAccounting:  inv.EnableDiscountAccounting,
+		AdditionalDiscountAccount: inv.AdditionalDiscountAccount,
+		IsPOS:                     inv.IsPOS,
+		AccountForChangeAmount:    inv.AccountForChangeAmount,
+		Document:                  doc,
+	}
+	if err := ret.Calculate(precision); err != nil {
+		return nil, err
+	}
+	if ret.IsPOS {
+		ret.Payments = inv.refunds(ret, precision)
+		ret.calculatePaidAndChangeAmount(precision)
+	}
+	return ret, nil
+}
+
+// AddReturn records the quantities a submitted return made by MakeReturn
+// takes back from the invoice, so later returns cannot take them again.
+//
+// Maps to: get_already_returned_items() in
+// controllers/sales_and_purchase_return.py
+func (inv *SalesInvoice) AddReturn(ret *SalesInvoice) error {
+	if ret.Status != Submitted || !ret.IsReturn || ret.ReturnAgainst != inv.Name || len(ret.ReturnRows) != len(ret.Items) {
+		return fmt.Errorf("%w: %s is not a submitted return against %s", ErrInvalidReturnQty, ret.Name, inv.Name)
+	}
+	if inv.ReturnedQty == nil {
+		inv.ReturnedQty = make(map[int]float64)
+	}
+	for i, row := range ret.ReturnRows {
+		inv.ReturnedQty[row] = taxcalc.Flt(inv.ReturnedQty[row]+ret.Items[i].Qty, 6)
+	}
+	return nil
+}
+
+// returnable returns the quantity of item row still open to returns.
+func (inv *SalesInvoice) returnable(row int) float64 {
+	return taxcalc.Flt(inv.Items[row].Qty-inv.ReturnedQty[row], 6)
+}
+
+// refunds splits a POS return's total over the modes the invoice was paid
+// in. The last mode takes the rounding difference.
+func (inv *SalesInvoice) refunds(ret *SalesInvoice, precision taxcalc.PrecisionProvider) []*Payment {
+	if precision == nil {
+		precision = taxcalc.DefaultPrecision{}
+	}
+	amountPrecision := precision.GetPrecision("paid_amount")
+
+	// What each mode kept: change went back out of the change account
+	kept := make([]float64, len(inv.Payments))
+	for i, p := range inv.Payments {
+		kept[i] = p.Amount
+	}
+	change := inv.ChangeAmount
+	takeChange := func(paidOut func(p *Payment) bool) {
+		for i, p := range inv.Payments {
+			if change > 0 && paidOut(p) {
+				taken := min(change, kept[i])
+				kept[i] -= taken
+				change -= taken
+			}
+		}
+	}
+	takeChange(func(p *Payment) bool { return p.Account == inv.AccountForChangeAmount })
+	takeChange(func(p *Payment) bool { return p.Type == modeofpayment.Cash })
+	keptTotal := 0.0
+	for _, k := range kept {
+		keptTotal += k
+	}
+	if keptTotal == 0 {
+		return nil
+	}
+
+	total, _ := ret.grandTotals()
+	var refunds []*Payment
+	remaining := total
+	for i, p := range inv.Payments {
+		if kept[i] <= 0 {
+			continue
+		}
+		refunds = append(refunds, &Payment{
+			ModeOfPayment: p.ModeOfPayment,
+			Type:          p.Type,
+			Account:       p.Account,
+			Amount:        taxcalc.Flt(total*kept[i]/keptTotal, amountPrecision),
+		})
+		remaining -= refunds[len(refunds)-1].Amount
+	}
+	last := refunds[len(refunds)-1]
+	last.Amount = taxcalc.Flt(last.Amount+remaining, amountPrecision)
+	return refunds
+}
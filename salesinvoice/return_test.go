@@ -0,0 +1,98 @@
+package salesinvoice
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/ledger"
+	"github.com/senguttuvang/erpnext-go/ledger/memstore"
+	"github.com/senguttuvang/erpnext-go/modeofpayment"
+	"github.com/senguttuvang/erpnext-go/taxcalc"
+)
+
+func TestMakeReturn(t *testing.T) {
+	// Two coffees and a tea with 10% VAT, 27.50 paid 20 by card and 10 in
+	// cash, 2.50 change
+	inv := &SalesInvoice{
+		Name: "ACC-PSINV-2024-00002", Company: "Zurich Café GmbH", Customer: "Walk-in Customer",
+		PostingDate: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), DebitTo: "Debtors - ZC", CompanyCurrency: "CHF",
+		IsPOS: true, AccountForChangeAmount: "Cash - ZC",
+		Payments: []*Payment{
+			{ModeOfPayment: "Card", Type: modeofpayment.Bank, Account: "Card Clearing - ZC", Amount: 20},
+			{ModeOfPayment: "Cash", Type: modeofpayment.Cash, Account: "Cash - ZC", Amount: 10},
+		},
+		Document: taxcalc.Document{
+			Currency: "CHF",
+			Items: []*taxcalc.LineItem{
+				{ItemCode: "COFFEE", Qty: 2, Rate: 10, IncomeAccount: "Sales - ZC"},
+				{ItemCode: "TEA", Qty: 1, Rate: 5, IncomeAccount: "Sales - ZC"},
+			},
+			Taxes: []*taxcalc.TaxRow{{AccountHead: "VAT - ZC", ChargeType: taxcalc.OnNetTotal, Rate: 10}},
+		},
+	}
+	store := memstore.NewGLStore()
+	engine := &ledger.Engine{GLStore: store, PaymentStore: memstore.NewPaymentStore()}
+	if _, err := inv.MakeReturn("CN-1", inv.PostingDate, map[int]float64{0: 1}, nil); !errors.Is(err, ErrNotSubmitted) {
+		t.Errorf("MakeReturn() of a draft = %v", err)
+	}
+	if err := inv.Submit(engine, nil); err != nil {
+		t.Fatal(err)
+	}
+	if inv.ChangeAmount != 2.50 {
+		t.Fatalf("change = %.2f", inv.ChangeAmount)
+	}
+
+	ret, err := inv.MakeReturn("CN-1", inv.PostingDate.AddDate(0, 0, 1), map[int]float64{0: 1}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ret.IsReturn || ret.ReturnAgainst != inv.Name || len(ret.Items) != 1 || ret.GrandTotal != 11 || ret.ChangeAmount != 0 {
+		t.Fatalf("return = %+v", ret)
+	}
+	// Refunded in proportion to what each mode kept after change: card
+	// 20, cash 7.50
+	if len(ret.Payments) != 2 || ret.Payments[0].Amount != 8 || ret.Payments[1].Amount != 3 {
+		t.Fatalf("refunds = %+v, %+v", ret.Payments[0], ret.Payments[1])
+	}
+	if err := ret.Submit(engine, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	balances := map[string]float64{}
+	for _, e := range store.All() {
+		balances[e.Account] += e.Debit - e.Credit
+		if e.Account == "Debtors - ZC" && e.AgainstVoucher != inv.Name {
+			t.Errorf("%s receivable entry against %q", e.VoucherNo, e.AgainstVoucher)
+		}
+	}
+	for account, want := range map[string]float64{
+		"Debtors - ZC": 0, "Card Clearing - ZC": 12, "Cash - ZC": 4.50, "VAT - ZC": -1.50, "Sales - ZC": -15,
+	} {
+		if got := ledger.Flt(balances[account], 2); got != want {
+			t.Errorf("%s balance = %.2f, want %.2f", account, got, want)
+		}
+	}
+
+	if err := inv.AddReturn(ret); err != nil {
+		t.Fatal(err)
+	}
+	if err := inv.AddReturn(inv); !errors.Is(err, ErrInvalidReturnQty) {
+		t.Errorf("AddReturn() of the invoice itself = %v", err)
+	}
+
+	for name, qty := range map[string]map[int]float64{
+		"returned": {0: 2}, // One coffee is already back
+		"too many": {1: 2},
+		"no row":   {5: 1},
+		"zero":     {0: 0},
+		"none":     {},
+	} {
+		if _, err := inv.MakeReturn("CN-2", inv.PostingDate, qty, nil); !errors.Is(err, ErrInvalidReturnQty) {
+			t.Errorf("%s: MakeReturn() error = %v", name, err)
+		}
+	}
+	if rest, err := inv.MakeReturn("CN-2", inv.PostingDate, map[int]float64{0: 1}, nil); err != nil || rest.ReturnRows[0] != 0 {
+		t.Errorf("returning the other coffee: %v", err)
+	}
+}
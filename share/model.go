@@ -0,0 +1,124 @@
+// Package share implements share management: issuing shares to
+// shareholders, transfers between them and buybacks by the company, with
+// the equity postings and a shareholder register.
+//
+// Shares of a type are numbered; every transfer names the range of share
+// numbers it moves, and the register checks the seller holds them.
+//
+// Migrated from: erpnext/accounts/doctype/share_transfer/share_transfer.py
+package share
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// VoucherType is the voucher type used on share transfer GL entries.
+const VoucherType = "Share Transfer"
+
+// Share transfer errors
+var (
+	ErrInvalidRange    = errors.New("invalid share number range")
+	ErrAlreadyIssued   = errors.New("shares are already issued")
+	ErrNotHeld         = errors.New("shareholder does not hold the shares")
+	ErrSameShareholder = errors.New("from and to shareholder cannot be the same")
+	ErrMissingParty    = errors.New("shareholder is mandatory")
+	ErrMissingAccount  = errors.New("asset and equity accounts are mandatory")
+	ErrInvalidRate     = errors.New("rate cannot be negative")
+	ErrUnknownTransfer = errors.New("unknown transfer type")
+	ErrPremiumNotSet   = errors.New("securities premium account is mandatory when rate differs from face value")
+	ErrCompanyMismatch = errors.New("shareholder belongs to another company")
+)
+
+// TransferType is the kind of share movement.
+type TransferType string
+
+const (
+	Issue    TransferType = "Issue"    // Company allots new shares
+	Purchase TransferType = "Purchase" // Company buys shares back
+	Transfer TransferType = "Transfer" // Shareholder sells to shareholder
+)
+
+// Shareholder is a holder of the company's shares.
+//
+// Maps to: Shareholder doctype
+type Shareholder struct {
+	Name    string
+	Title   string
+	Company string
+	FolioNo string
+}
+
+// ShareTransfer is one issue, transfer or buyback of a range of shares.
+//
+// Maps to: Share Transfer doctype
+type ShareTransfer struct {
+	Name            string
+	Type            TransferType
+	Date            time.Time
+	Company         string
+	FromShareholder string // Seller; empty for an issue
+	ToShareholder   string // Buyer; empty for a buyback
+	ShareType       string // e.g. "Equity"
+	FromNo          int
+	ToNo            int
+	Rate            float64 // Price per share
+	FaceValue       float64 // Nominal value per share; 0 books the whole price to share capital
+
+	AssetAccount   string // Bank or cash account receiving or paying the price
+	EquityAccount  string // Share capital
+	PremiumAccount string // Securities premium, for the price above face value
+	CostCenter     string
+	Remarks        string
+}
+
+// NoOfShares returns the number of shares moved.
+func (t *ShareTransfer) NoOfShares() int {
+	return t.ToNo - t.FromNo + 1
+}
+
+// Amount returns the price of the shares moved.
+func (t *ShareTransfer) Amount() float64 {
+	return float64(t.NoOfShares()) * t.Rate
+}
+
+// Validate checks the transfer on its own, without the register.
+//
+// Maps to: ShareTransfer.basic_validations()
+func (t *ShareTransfer) Validate() error {
+	if t.FromNo <= 0 || t.ToNo < t.FromNo {
+		return fmt.Errorf("%w: %d to %d", ErrInvalidRange, t.FromNo, t.ToNo)
+	}
+	if t.Rate < 0 || t.FaceValue < 0 {
+		return ErrInvalidRate
+	}
+	switch t.Type {
+	case Issue:
+		if t.ToShareholder == "" {
+			return fmt.Errorf("%w: to shareholder", ErrMissingParty)
+		}
+	case Purchase:
+		if t.FromShareholder == "" {
+			return fmt.Errorf("%w: from shareholder", ErrMissingParty)
+		}
+	case Transfer:
+		if t.FromShareholder == "" || t.ToShareholder == "" {
+			return fmt.Errorf("%w: from and to shareholder", ErrMissingParty)
+		}
+		if t.FromShareholder == t.ToShareholder {
+			return ErrSameShareholder
+		}
+	default:
+		return fmt.Errorf("%w: %q", ErrUnknownTransfer, t.Type)
+	}
+	if t.Type != Transfer {
+		if t.AssetAccount == "" || t.EquityAccount == "" {
+			return ErrMissingAccount
+		}
+		if t.FaceValue > 0 && t.FaceValue != t.Rate && t.PremiumAccount == "" {
+			return ErrPremiumNotSet
+		}
+	}
+	return nil
+}
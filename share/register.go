@@ -0,0 +1,195 @@
+package share
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+// Company is the holder key for shares bought back by the company.
+const Company = ""
+
+// Holding is a contiguous range of shares held at one rate.
+//
+// Maps to: Share Balance child table of Shareholder
+type Holding struct {
+	ShareType string
+	FromNo    int
+	ToNo      int
+	Rate      float64
+}
+
+// NoOfShares returns the number of shares in the range.
+func (h Holding) NoOfShares() int {
+	return h.ToNo - h.FromNo + 1
+}
+
+// Amount returns the value of the range at its rate.
+func (h Holding) Amount() float64 {
+	return float64(h.NoOfShares()) * h.Rate
+}
+
+// Register tracks who holds which share numbers. Applying a transfer
+// moves the range from the seller to the buyer.
+type Register struct {
+	Shareholders map[string]Shareholder // Optional; checked against transfer companies
+	Transfers    []ShareTransfer        // Applied transfers, in order
+
+	holdings map[string][]Holding // Shareholder -> ranges, sorted by share type and number
+}
+
+// NewRegister returns an empty register for the shareholders.
+func NewRegister(shareholders ...Shareholder) *Register {
+	r := &Register{Shareholders: make(map[string]Shareholder, len(shareholders))}
+	for _, s := range shareholders {
+		r.Shareholders[s.Name] = s
+	}
+	return r
+}
+
+// Replay rebuilds a register from the transfers dated on or before asOf,
+// in date order.
+func Replay(transfers []ShareTransfer, asOf time.Time, shareholders ...Shareholder) (*Register, error) {
+	sorted := slices.Clone(transfers)
+	slices.SortStableFunc(sorted, func(a, b ShareTransfer) int { return a.Date.Compare(b.Date) })
+	r := NewRegister(shareholders...)
+	for _, t := range sorted {
+		if t.Date.After(asOf) {
+			break
+		}
+		if err := r.Apply(t); err != nil {
+			return nil, fmt.Errorf("%s: %w", t.Name, err)
+		}
+	}
+	return r, nil
+}
+
+// Holdings returns the ranges a shareholder holds; Company for shares
+// bought back.
+func (r *Register) Holdings(shareholder string) []Holding {
+	return slices.Clone(r.holdings[shareholder])
+}
+
+// Holders returns the shareholders currently holding shares, sorted,
+// without the company.
+func (r *Register) Holders() []string {
+	var names []string
+	for name, h := range r.holdings {
+		if name != Company && len(h) > 0 {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Apply validates a transfer against the register and records it.
+//
+// Maps to: ShareTransfer.validate() and on_submit()
+func (r *Register) Apply(t ShareTransfer) error {
+	if err := r.Check(t); err != nil {
+		return err
+	}
+	from, to := t.FromShareholder, t.ToShareholder
+	if t.Type == Issue {
+		from = ""
+	}
+	if t.Type == Purchase {
+		to = Company
+	}
+	if t.Type != Issue {
+		r.holdings[from] = subtract(r.holdings[from], t.ShareType, t.FromNo, t.ToNo)
+	}
+	if r.holdings == nil {
+		r.holdings = make(map[string][]Holding)
+	}
+	r.holdings[to] = insert(r.holdings[to], Holding{ShareType: t.ShareType, FromNo: t.FromNo, ToNo: t.ToNo, Rate: t.Rate})
+	r.Transfers = append(r.Transfers, t)
+	return nil
+}
+
+// Check validates a transfer against the register without recording it:
+// issued numbers must be new, and sold numbers must be held by the seller.
+//
+// Maps to: ShareTransfer.share_exists() / folio_no_validation()
+func (r *Register) Check(t ShareTransfer) error {
+	if err := t.Validate(); err != nil {
+		return err
+	}
+	for _, name := range []string{t.FromShareholder, t.ToShareholder} {
+		if s, ok := r.Shareholders[name]; ok && s.Company != "" && t.Company != "" && s.Company != t.Company {
+			return fmt.Errorf("%w: %s", ErrCompanyMismatch, name)
+		}
+	}
+	if t.Type == Issue {
+		for holder, holdings := range r.holdings {
+			if covered(holdings, t.ShareType, t.FromNo, t.ToNo) > 0 {
+				if holder == Company {
+					holder = "the company"
+				}
+				return fmt.Errorf("%w: %s %d to %d overlap shares held by %s", ErrAlreadyIssued, t.ShareType, t.FromNo, t.ToNo, holder)
+			}
+		}
+		return nil
+	}
+	if covered(r.holdings[t.FromShareholder], t.ShareType, t.FromNo, t.ToNo) != t.NoOfShares() {
+		return fmt.Errorf("%w: %s %s %d to %d", ErrNotHeld, t.FromShareholder, t.ShareType, t.FromNo, t.ToNo)
+	}
+	return nil
+}
+
+// covered counts the numbers from..to of a share type within holdings.
+func covered(holdings []Holding, shareType string, from, to int) int {
+	n := 0
+	for _, h := range holdings {
+		if h.ShareType == shareType {
+			if lo, hi := max(h.FromNo, from), min(h.ToNo, to); lo <= hi {
+				n += hi - lo + 1
+			}
+		}
+	}
+	return n
+}
+
+// subtract removes from..to of a share type, splitting ranges.
+func subtract(holdings []Holding, shareType string, from, to int) []Holding {
+	var out []Holding
+	for _, h := range holdings {
+		if h.ShareType != shareType || h.ToNo < from || h.FromNo > to {
+			out = append(out, h)
+			continue
+		}
+		if h.FromNo < from {
+			out = append(out, Holding{ShareType: shareType, FromNo: h.FromNo, ToNo: from - 1, Rate: h.Rate})
+		}
+		if h.ToNo > to {
+			out = append(out, Holding{ShareType: shareType, FromNo: to + 1, ToNo: h.ToNo, Rate: h.Rate})
+		}
+	}
+	return out
+}
+
+// insert adds a range, keeping holdings sorted and merging it with an
+// adjacent range at the same rate.
+func insert(holdings []Holding, h Holding) []Holding {
+	holdings = append(holdings, h)
+	slices.SortFunc(holdings, func(a, b Holding) int {
+		if a.ShareType != b.ShareType {
+			if a.ShareType < b.ShareType {
+				return -1
+			}
+			return 1
+		}
+		return a.FromNo - b.FromNo
+	})
+	merged := holdings[:1]
+	for _, h := range holdings[1:] {
+		last := &merged[len(merged)-1]
+		if last.ShareType == h.ShareType && last.Rate == h.Rate && last.ToNo+1 == h.FromNo {
+			last.ToNo = h.ToNo
+			continue
+		}
+		merged = append(merged, h)
+	}
+	return merged
+}
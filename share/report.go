@@ -0,0 +1,98 @@
+package share
+
+import (
+	"github.com/senguttuvang/erpnext-go/reportio"
+)
+
+// BalanceReport is the shareholder register: the shares each shareholder
+// holds, one row per share type.
+//
+// Maps to: erpnext/accounts/report/share_balance/share_balance.py
+type BalanceReport struct {
+	Register *Register
+}
+
+// Title implements reportio.Titled.
+func (r *BalanceReport) Title() string { return "Share Balance" }
+
+// Columns implements reportio.Report.
+func (r *BalanceReport) Columns() []reportio.Column {
+	return []reportio.Column{
+		{Fieldname: "shareholder", Label: "Shareholder", Fieldtype: reportio.Link, Options: "Shareholder"},
+		{Fieldname: "share_type", Label: "Share Type", Fieldtype: reportio.Data},
+		{Fieldname: "no_of_shares", Label: "No of Shares", Fieldtype: reportio.Int},
+		{Fieldname: "rate", Label: "Average Rate", Fieldtype: reportio.Currency},
+		{Fieldname: "amount", Label: "Amount", Fieldtype: reportio.Currency},
+	}
+}
+
+// Rows implements reportio.Report.
+func (r *BalanceReport) Rows(fn func([]any) error) error {
+	for _, holder := range r.Register.Holders() {
+		holdings := r.Register.holdings[holder]
+		for i := 0; i < len(holdings); {
+			shareType := holdings[i].ShareType
+			shares, amount := 0, 0.0
+			for ; i < len(holdings) && holdings[i].ShareType == shareType; i++ {
+				shares += holdings[i].NoOfShares()
+				amount += holdings[i].Amount()
+			}
+			if err := fn([]any{holder, shareType, shares, amount / float64(shares), amount}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// LedgerReport lists the transfers in a register, once for each
+// shareholder on either side.
+//
+// Maps to: erpnext/accounts/report/share_ledger/share_ledger.py
+type LedgerReport struct {
+	Register    *Register
+	Shareholder string // Optional filter
+}
+
+// Title implements reportio.Titled.
+func (r *LedgerReport) Title() string { return "Share Ledger" }
+
+// Columns implements reportio.Report.
+func (r *LedgerReport) Columns() []reportio.Column {
+	return []reportio.Column{
+		{Fieldname: "shareholder", Label: "Shareholder", Fieldtype: reportio.Link, Options: "Shareholder"},
+		{Fieldname: "date", Label: "Date", Fieldtype: reportio.Date},
+		{Fieldname: "transfer_type", Label: "Transfer Type", Fieldtype: reportio.Data},
+		{Fieldname: "share_type", Label: "Share Type", Fieldtype: reportio.Data},
+		{Fieldname: "from_no", Label: "From No", Fieldtype: reportio.Int},
+		{Fieldname: "to_no", Label: "To No", Fieldtype: reportio.Int},
+		{Fieldname: "no_of_shares", Label: "No of Shares", Fieldtype: reportio.Int},
+		{Fieldname: "rate", Label: "Rate", Fieldtype: reportio.Currency},
+		{Fieldname: "amount", Label: "Amount", Fieldtype: reportio.Currency},
+		{Fieldname: "counterparty", Label: "Counterparty", Fieldtype: reportio.Data},
+	}
+}
+
+// Rows implements reportio.Report. Shares leaving a shareholder are
+// negative.
+func (r *LedgerReport) Rows(fn func([]any) error) error {
+	row := func(t ShareTransfer, holder, counterparty string, sign int) error {
+		if holder == "" || (r.Shareholder != "" && holder != r.Shareholder) {
+			return nil
+		}
+		if counterparty == "" {
+			counterparty = t.Company
+		}
+		return fn([]any{holder, t.Date, string(t.Type), t.ShareType, t.FromNo, t.ToNo,
+			sign * t.NoOfShares(), t.Rate, float64(sign) * t.Amount(), counterparty})
+	}
+	for _, t := range r.Register.Transfers {
+		if err := row(t, t.FromShareholder, t.ToShareholder, -1); err != nil {
+			return err
+		}
+		if err := row(t, t.ToShareholder, t.FromShareholder, 1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package share
+
+import (
+	"github.com/senguttuvang/erpnext-go/ledger"
+)
+
+// Service submits share transfers: it checks them against the register,
+// posts the equity entries and records them.
+type Service struct {
+	Register *Register
+	Engine   *ledger.Engine
+}
+
+// Submit validates, posts and records a transfer. Transfers between
+// shareholders change the register only.
+//
+// Maps to: ShareTransfer.on_submit() and make_jv_entry()
+func (s *Service) Submit(t ShareTransfer) error {
+	if err := s.Register.Check(t); err != nil {
+		return err
+	}
+	if glMap := t.GetGLEntries(); len(glMap) > 0 {
+		if err := s.Engine.MakeGLEntries(glMap, ledger.DefaultPostingOptions()); err != nil {
+			return err
+		}
+	}
+	return s.Register.Apply(t)
+}
+
+// GetGLEntries builds the GL map of an issue or buyback. The price moves
+// through the asset account; share capital takes the face value and the
+// securities premium the difference. The share capital line carries the
+// shareholder as party.
+//
+//	Issue:    Dr Asset   Cr Share Capital, Cr Securities Premium
+//	Purchase: Dr Share Capital, Dr Securities Premium   Cr Asset
+func (t *ShareTransfer) GetGLEntries() []ledger.GLEntry {
+	if t.Type == Transfer {
+		return nil
+	}
+	amount := ledger.Flt(t.Amount(), 2)
+	capital := amount
+	if t.FaceValue > 0 {
+		capital = ledger.Flt(float64(t.NoOfShares())*t.FaceValue, 2)
+	}
+	premium := ledger.Flt(amount-capital, 2)
+
+	shareholder := t.ToShareholder
+	sign := 1.0 // Issue: cash in
+	if t.Type == Purchase {
+		shareholder = t.FromShareholder
+		sign = -1
+	}
+
+	glMap := []ledger.GLEntry{
+		t.newGLEntry(t.AssetAccount, sign*amount),
+		t.newGLEntry(t.EquityAccount, -sign*capital),
+	}
+	glMap[1].PartyType, glMap[1].Party = "Shareholder", shareholder
+	if premium != 0 {
+		glMap = append(glMap, t.newGLEntry(t.PremiumAccount, -sign*premium))
+	}
+	for i := range glMap {
+		glMap[i].Against = against(glMap, glMap[i].Debit > 0)
+	}
+	return glMap
+}
+
+// newGLEntry returns a company-currency entry; positive amounts debit.
+func (t *ShareTransfer) newGLEntry(account string, amount float64) ledger.GLEntry {
+	debit, credit := max(amount, 0), max(-amount, 0)
+	remarks := t.Remarks
+	if remarks == "" {
+		remarks = "Share " + string(t.Type) + " " + t.Name
+	}
+	return ledger.GLEntry{
+		PostingDate:             t.Date,
+		TransactionDate:         t.Date,
+		Account:                 account,
+		VoucherType:             VoucherType,
+		VoucherNo:               t.Name,
+		Company:                 t.Company,
+		CostCenter:              t.CostCenter,
+		Debit:                   debit,
+		Credit:                  credit,
+		DebitInAccountCurrency:  debit,
+		CreditInAccountCurrency: credit,
+		IsOpening:               ledger.IsOpeningNo,
+		IsAdvance:               ledger.IsAdvanceNo,
+		Remarks:                 remarks,
+	}
+}
+
+// against lists the accounts on the other side of an entry.
+func against(glMap []ledger.GLEntry, debit bool) string {
+	s := ""
+	for _, e := range glMap {
+		if (e.Debit > 0) != debit {
+			if s != "" {
+				s += ", "
+			}
+			s += e.Account
+		}
+	}
+	return s
+}
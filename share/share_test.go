@@ -0,0 +1,140 @@
+package share
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/ledger"
+	"github.com/senguttuvang/erpnext-go/ledger/memstore"
+	"github.com/senguttuvang/erpnext-go/reportio"
+)
+
+func date(y int, m time.Month, d int) time.Time {
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+func transfer(name string, typ TransferType, from, to string, fromNo, toNo int, rate float64) ShareTransfer {
+	return ShareTransfer{
+		Name: name, Type: typ, Date: date(2024, 4, 1), Company: "ACME",
+		FromShareholder: from, ToShareholder: to, ShareType: "Equity",
+		FromNo: fromNo, ToNo: toNo, Rate: rate, FaceValue: 10,
+		AssetAccount: "Bank - ACME", EquityAccount: "Share Capital - ACME", PremiumAccount: "Securities Premium - ACME",
+	}
+}
+
+func TestRegister(t *testing.T) {
+	r := NewRegister(Shareholder{Name: "SH-1", Company: "ACME"}, Shareholder{Name: "SH-2", Company: "ACME"}, Shareholder{Name: "SH-X", Company: "Other"})
+	steps := []ShareTransfer{
+		transfer("ST-1", Issue, "", "SH-1", 1, 1000, 25),
+		transfer("ST-2", Transfer, "SH-1", "SH-2", 201, 300, 40),
+		transfer("ST-3", Purchase, "SH-1", "", 901, 1000, 30),
+	}
+	for _, st := range steps {
+		if err := r.Apply(st); err != nil {
+			t.Fatalf("Apply(%s) error = %v", st.Name, err)
+		}
+	}
+	want := []Holding{
+		{ShareType: "Equity", FromNo: 1, ToNo: 200, Rate: 25},
+		{ShareType: "Equity", FromNo: 301, ToNo: 900, Rate: 25},
+	}
+	if got := r.Holdings("SH-1"); len(got) != 2 || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("Holdings(SH-1) = %+v", got)
+	}
+	if got := r.Holdings(Company); len(got) != 1 || got[0].NoOfShares() != 100 {
+		t.Errorf("Holdings(Company) = %+v", got)
+	}
+
+	tests := []struct {
+		name string
+		st   ShareTransfer
+		want error
+	}{
+		{"reissue", transfer("X", Issue, "", "SH-2", 950, 1100, 10), ErrAlreadyIssued},
+		{"not held", transfer("X", Transfer, "SH-2", "SH-1", 250, 350, 10), ErrNotHeld},
+		{"same holder", transfer("X", Transfer, "SH-1", "SH-1", 1, 10, 10), ErrSameShareholder},
+		{"bad range", transfer("X", Issue, "", "SH-1", 10, 5, 10), ErrInvalidRange},
+		{"other company", transfer("X", Issue, "", "SH-X", 2001, 2100, 10), ErrCompanyMismatch},
+	}
+	for _, tt := range tests {
+		if err := r.Apply(tt.st); !errors.Is(err, tt.want) {
+			t.Errorf("%s: error = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+
+	replayed, err := Replay(steps, date(2024, 4, 1))
+	if err != nil || len(replayed.Holders()) != 2 {
+		t.Errorf("Replay() = %v, %v", replayed.Holders(), err)
+	}
+}
+
+func TestSubmitPostsEquity(t *testing.T) {
+	glStore := memstore.NewGLStore()
+	svc := &Service{Register: NewRegister(), Engine: &ledger.Engine{GLStore: glStore}}
+	if err := svc.Submit(transfer("ST-1", Issue, "", "SH-1", 1, 1000, 25)); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.Submit(transfer("ST-2", Transfer, "SH-1", "SH-2", 1, 100, 40)); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.Submit(transfer("ST-3", Purchase, "SH-1", "", 901, 1000, 30)); err != nil {
+		t.Fatal(err)
+	}
+
+	got := make(map[string]float64)
+	for _, e := range glStore.All() {
+		got[e.Account] += e.Debit - e.Credit
+		if e.Account == "Share Capital - ACME" && e.PartyType != "Shareholder" {
+			t.Errorf("share capital entry without shareholder: %+v", e)
+		}
+	}
+	want := map[string]float64{
+		"Bank - ACME":               25000 - 3000,
+		"Share Capital - ACME":      -10000 + 1000,
+		"Securities Premium - ACME": -15000 + 2000,
+	}
+	for account, balance := range want {
+		if got[account] != balance {
+			t.Errorf("balance of %s = %v, want %v", account, got[account], balance)
+		}
+	}
+	if entries, _ := glStore.GetByVoucher(VoucherType, "ST-2"); len(entries) != 0 {
+		t.Errorf("transfer between shareholders posted %d entries", len(entries))
+	}
+}
+
+func TestReports(t *testing.T) {
+	r := NewRegister()
+	for _, st := range []ShareTransfer{
+		transfer("ST-1", Issue, "", "SH-1", 1, 100, 10),
+		transfer("ST-2", Issue, "", "SH-1", 101, 200, 20),
+		transfer("ST-3", Transfer, "SH-1", "SH-2", 1, 50, 15),
+	} {
+		if err := r.Apply(st); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := reportio.WriteCSV(&buf, &BalanceReport{Register: r}); err != nil {
+		t.Fatal(err)
+	}
+	want := "Shareholder,Share Type,No of Shares,Average Rate,Amount\n" +
+		"SH-1,Equity,150,16.67,2500.00\n" +
+		"SH-2,Equity,50,15.00,750.00\n"
+	if buf.String() != want {
+		t.Errorf("balance CSV =\n%s\nwant\n%s", buf.String(), want)
+	}
+
+	buf.Reset()
+	if err := reportio.WriteCSV(&buf, &LedgerReport{Register: r, Shareholder: "SH-1"}); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 4 || !strings.HasPrefix(lines[3], "SH-1,2024-04-01,Transfer,Equity,1,50,-50,15.00,-750.00,SH-2") {
+		t.Errorf("ledger CSV =\n%s", buf.String())
+	}
+}
@@ -0,0 +1,29 @@
+package statement
+
+import (
+	"bytes"
+	"embed"
+)
+
+//go:embed layouts/*.json
+var layouts embed.FS
+
+// BalanceSheet returns the standard Balance Sheet layout. Income and
+// expense not yet closed to equity show as provisional profit, as in
+// ERPNext, so that the difference row is zero for a balanced ledger.
+func BalanceSheet() *Layout { return mustLoad("layouts/balance_sheet.json") }
+
+// ProfitAndLoss returns the standard Profit and Loss layout.
+func ProfitAndLoss() *Layout { return mustLoad("layouts/profit_and_loss.json") }
+
+func mustLoad(name string) *Layout {
+	data, err := layouts.ReadFile(name)
+	if err != nil {
+		panic(err)
+	}
+	l, err := Load(bytes.NewReader(data))
+	if err != nil {
+		panic(err)
+	}
+	return l
+}
@@ -0,0 +1,317 @@
+package statement
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// expr is a parsed formula.
+type expr interface {
+	eval(env env) (float64, error)
+}
+
+// env supplies the values of the rows a formula refers to.
+type env interface {
+	value(id string) (float64, error)
+	between(from, to string) ([]string, error) // Row IDs from from to to in layout order
+}
+
+type number float64
+
+type ref string
+
+// rangeRef is A:C, the rows from A to C in layout order. It is only
+// valid as a function argument.
+type rangeRef struct{ from, to string }
+
+type unary struct{ x expr }
+
+type binary struct {
+	op   byte
+	x, y expr
+}
+
+type call struct {
+	fn   string
+	args []expr
+}
+
+func (n number) eval(env) (float64, error) { return float64(n), nil }
+
+func (r ref) eval(env env) (float64, error) { return env.value(string(r)) }
+
+func (r rangeRef) eval(env) (float64, error) {
+	return 0, fmt.Errorf("%w: range %s:%s outside a function", ErrInvalidFormula, r.from, r.to)
+}
+
+func (u unary) eval(env env) (float64, error) {
+	x, err := u.x.eval(env)
+	return -x, err
+}
+
+func (b binary) eval(env env) (float64, error) {
+	x, err := b.x.eval(env)
+	if err != nil {
+		return 0, err
+	}
+	y, err := b.y.eval(env)
+	if err != nil {
+		return 0, err
+	}
+	switch b.op {
+	case '+':
+		return x + y, nil
+	case '-':
+		return x - y, nil
+	case '*':
+		return x * y, nil
+	}
+	if y == 0 {
+		return 0, nil // As IFERROR(x/y, 0): a margin on an empty period is 0
+	}
+	return x / y, nil
+}
+
+// functions are the spreadsheet functions formulas may call.
+var functions = map[string]func(args []float64) (float64, error){
+	"SUM": func(args []float64) (float64, error) {
+		var s float64
+		for _, a := range args {
+			s += a
+		}
+		return s, nil
+	},
+	"ABS": func(args []float64) (float64, error) {
+		if len(args) != 1 {
+			return 0, fmt.Errorf("%w: ABS takes 1 argument", ErrInvalidFormula)
+		}
+		return math.Abs(args[0]), nil
+	},
+	"MIN": func(args []float64) (float64, error) {
+		if len(args) == 0 {
+			return 0, nil
+		}
+		return slices.Min(args), nil
+	},
+	"MAX": func(args []float64) (float64, error) {
+		if len(args) == 0 {
+			return 0, nil
+		}
+		return slices.Max(args), nil
+	},
+	"ROUND": func(args []float64) (float64, error) {
+		if len(args) != 2 {
+			return 0, fmt.Errorf("%w: ROUND takes 2 arguments", ErrInvalidFormula)
+		}
+		p := math.Pow(10, args[1])
+		return math.Round(args[0]*p) / p, nil
+	},
+}
+
+func (c call) eval(env env) (float64, error) {
+	var args []float64
+	for _, a := range c.args {
+		if r, ok := a.(rangeRef); ok {
+			ids, err := env.between(r.from, r.to)
+			if err != nil {
+				return 0, err
+			}
+			for _, id := range ids {
+				v, err := env.value(id)
+				if err != nil {
+					return 0, err
+				}
+				args = append(args, v)
+			}
+			continue
+		}
+		v, err := a.eval(env)
+		if err != nil {
+			return 0, err
+		}
+		args = append(args, v)
+	}
+	return functions[c.fn](args)
+}
+
+// parseFormula parses a formula in spreadsheet syntax: an optional
+// leading "=", numbers, row IDs, + - * /, parentheses, ranges of rows
+// (A:C) and the functions SUM, ABS, MIN, MAX and ROUND. Function names are
+// case-insensitive.
+func parseFormula(src string) (expr, error) {
+	p := &parser{src: strings.TrimPrefix(strings.TrimSpace(src), "=")}
+	e, err := p.sum()
+	if err != nil {
+		return nil, err
+	}
+	if p.skipSpace(); p.pos < len(p.src) {
+		return nil, p.errorf("unexpected %q", p.src[p.pos:])
+	}
+	return e, nil
+}
+
+type parser struct {
+	src string
+	pos int
+}
+
+func (p *parser) errorf(format string, args ...any) error {
+	return fmt.Errorf("%w: %s in %q", ErrInvalidFormula, fmt.Sprintf(format, args...), p.src)
+}
+
+func (p *parser) skipSpace() {
+	for p.pos < len(p.src) && p.src[p.pos] == ' ' {
+		p.pos++
+	}
+}
+
+// peek returns the next non-space byte, or 0 at the end.
+func (p *parser) peek() byte {
+	p.skipSpace()
+	if p.pos == len(p.src) {
+		return 0
+	}
+	return p.src[p.pos]
+}
+
+func (p *parser) sum() (expr, error) {
+	x, err := p.product()
+	for err == nil && (p.peek() == '+' || p.peek() == '-') {
+		op := p.src[p.pos]
+		p.pos++
+		var y expr
+		if y, err = p.product(); err == nil {
+			x = binary{op, x, y}
+		}
+	}
+	return x, err
+}
+
+func (p *parser) product() (expr, error) {
+	x, err := p.operand()
+	for err == nil && (p.peek() == '*' || p.peek() == '/') {
+		op := p.src[p.pos]
+		p.pos++
+		var y expr
+		if y, err = p.operand(); err == nil {
+			x = binary{op, x, y}
+		}
+	}
+	return x, err
+}
+
+func (p *parser) operand() (expr, error) {
+	switch c := p.peek(); {
+	case c == '-':
+		p.pos++
+		x, err := p.operand()
+		return unary{x}, err
+	case c == '+':
+		p.pos++
+		return p.operand()
+	case c == '(':
+		p.pos++
+		x, err := p.sum()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ')' {
+			return nil, p.errorf("missing )")
+		}
+		p.pos++
+		return x, nil
+	case c >= '0' && c <= '9' || c == '.':
+		start := p.pos
+		for p.pos < len(p.src) && (p.src[p.pos] >= '0' && p.src[p.pos] <= '9' || p.src[p.pos] == '.') {
+			p.pos++
+		}
+		v, err := strconv.ParseFloat(p.src[start:p.pos], 64)
+		if err != nil {
+			return nil, p.errorf("bad number %q", p.src[start:p.pos])
+		}
+		return number(v), nil
+	case isIdent(rune(c)):
+		name := p.ident()
+		if p.peek() == '(' {
+			return p.call(strings.ToUpper(name))
+		}
+		return ref(name), nil
+	case c == 0:
+		return nil, p.errorf("unexpected end")
+	default:
+		return nil, p.errorf("unexpected %q", c)
+	}
+}
+
+func (p *parser) call(fn string) (expr, error) {
+	if _, ok := functions[fn]; !ok {
+		return nil, p.errorf("unknown function %s", fn)
+	}
+	p.pos++ // (
+	c := call{fn: fn}
+	if p.peek() == ')' {
+		p.pos++
+		return c, nil
+	}
+	for {
+		arg, err := p.sum()
+		if err != nil {
+			return nil, err
+		}
+		if from, ok := arg.(ref); ok && p.peek() == ':' {
+			p.pos++
+			p.skipSpace()
+			to := p.ident()
+			if to == "" {
+				return nil, p.errorf("range %s: needs an end row", from)
+			}
+			arg = rangeRef{string(from), to}
+		}
+		c.args = append(c.args, arg)
+		switch p.peek() {
+		case ',':
+			p.pos++
+		case ')':
+			p.pos++
+			return c, nil
+		default:
+			return nil, p.errorf("missing ) after %s arguments", fn)
+		}
+	}
+}
+
+func (p *parser) ident() string {
+	start := p.pos
+	for p.pos < len(p.src) && isIdent(rune(p.src[p.pos])) {
+		p.pos++
+	}
+	return p.src[start:p.pos]
+}
+
+func isIdent(r rune) bool {
+	return r == '_' || r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r))
+}
+
+// refs appends the row IDs a formula refers to, with ranges as their end
+// rows.
+func refs(e expr, out []string) []string {
+	switch e := e.(type) {
+	case ref:
+		out = append(out, string(e))
+	case rangeRef:
+		out = append(out, e.from, e.to)
+	case unary:
+		out = refs(e.x, out)
+	case binary:
+		out = refs(e.y, refs(e.x, out))
+	case call:
+		for _, a := range e.args {
+			out = refs(a, out)
+		}
+	}
+	return out
+}
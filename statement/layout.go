@@ -0,0 +1,222 @@
+// Package statement generates financial statements from layouts that
+// companies define as data.
+//
+// A Layout is a list of rows. An account row sums the balances of a set of
+// accounts, a formula row computes from other rows in spreadsheet syntax
+// ("=Income - COGS", "=SUM(CA1:CA3)") and a heading row only carries a
+// label. Layouts are loaded from JSON, so the Balance Sheet and Profit and
+// Loss statement can be restructured without code changes:
+//
+//	{"name": "Profit and Loss", "rows": [
+//	  {"id": "Income", "label": "Income", "accounts": {"root_types": ["Income"]}, "credit": true},
+//	  {"id": "Expenses", "label": "Expenses", "accounts": {"root_types": ["Expense"]}},
+//	  {"id": "Net", "label": "Net Profit", "formula": "=Income - Expenses", "bold": true}
+//	]}
+//
+// Maps to: the Financial Report Template of ERPNext v15 and
+// erpnext/accounts/report/financial_statements.py
+package statement
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Layout errors
+var (
+	ErrInvalidLayout     = errors.New("invalid statement layout")
+	ErrInvalidFormula    = errors.New("invalid formula")
+	ErrUnknownRow        = errors.New("formula refers to unknown row")
+	ErrCircularReference = errors.New("circular reference between statement rows")
+)
+
+// RowType says how a row gets its value.
+type RowType string
+
+const (
+	RowAccounts RowType = "accounts" // Sum of an account set
+	RowFormula  RowType = "formula"  // Computed from other rows
+	RowHeading  RowType = "heading"  // Label only
+)
+
+// AccountSet selects accounts by root type or by name. A group account
+// selects every account below it.
+type AccountSet struct {
+	RootTypes []string `json:"root_types,omitempty"` // "Asset", "Liability", "Equity", "Income", "Expense"
+	Accounts  []string `json:"accounts,omitempty"`
+	Exclude   []string `json:"exclude,omitempty"` // Accounts, or groups, left out of the above
+}
+
+// Row is a line of a statement.
+type Row struct {
+	ID       string     `json:"id,omitempty"` // Referred to by formulas; optional for headings
+	Label    string     `json:"label"`
+	Type     RowType    `json:"type,omitempty"` // Inferred from Accounts or Formula when empty
+	Accounts AccountSet `json:"accounts,omitzero"`
+	Formula  string     `json:"formula,omitempty"`
+	Credit   bool       `json:"credit,omitempty"` // Show credit balances as positive, as for income and liabilities
+	Bold     bool       `json:"bold,omitempty"`
+	Hidden   bool       `json:"hidden,omitempty"` // Computed for formulas but not shown
+
+	expr expr
+}
+
+// Layout is the structure of a statement.
+type Layout struct {
+	Name string `json:"name"`
+	Rows []Row  `json:"rows"`
+
+	index map[string]int // Row position by ID
+}
+
+// Load reads a layout from JSON and validates it.
+func Load(r io.Reader) (*Layout, error) {
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	var l Layout
+	if err := dec.Decode(&l); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidLayout, err)
+	}
+	if err := l.Validate(); err != nil {
+		return nil, err
+	}
+	return &l, nil
+}
+
+// Validate infers row types, parses the formulas and checks that every
+// row they refer to exists and that no row depends on itself.
+func (l *Layout) Validate() error {
+	l.index = make(map[string]int, len(l.Rows))
+	for i := range l.Rows {
+		row := &l.Rows[i]
+		if row.Type == "" {
+			switch {
+			case row.Formula != "":
+				row.Type = RowFormula
+			case len(row.Accounts.RootTypes) > 0 || len(row.Accounts.Accounts) > 0:
+				row.Type = RowAccounts
+			default:
+				row.Type = RowHeading
+			}
+		}
+		switch row.Type {
+		case RowFormula:
+			e, err := parseFormula(row.Formula)
+			if err != nil {
+				return fmt.Errorf("row %s: %w", row.ID, err)
+			}
+			row.expr = e
+		case RowAccounts:
+			if len(row.Accounts.RootTypes) == 0 && len(row.Accounts.Accounts) == 0 {
+				return fmt.Errorf("%w: row %s selects no accounts", ErrInvalidLayout, row.ID)
+			}
+		case RowHeading:
+		default:
+			return fmt.Errorf("%w: row %s has type %q", ErrInvalidLayout, row.ID, row.Type)
+		}
+		if row.ID == "" {
+			if row.Type != RowHeading {
+				return fmt.Errorf("%w: row %q needs an id", ErrInvalidLayout, row.Label)
+			}
+			continue
+		}
+		if _, dup := l.index[row.ID]; dup {
+			return fmt.Errorf("%w: duplicate row id %s", ErrInvalidLayout, row.ID)
+		}
+		l.index[row.ID] = i
+	}
+
+	for _, row := range l.Rows {
+		for _, id := range refs(row.expr, nil) {
+			i, ok := l.index[id]
+			if !ok {
+				return fmt.Errorf("%w: %s in row %s", ErrUnknownRow, id, row.ID)
+			}
+			if l.Rows[i].Type == RowHeading {
+				return fmt.Errorf("%w: row %s refers to heading %s", ErrInvalidFormula, row.ID, id)
+			}
+		}
+	}
+	state := make(map[string]int) // 1 visiting, 2 done
+	var visit func(id string) error
+	visit = func(id string) error {
+		switch state[id] {
+		case 1:
+			return fmt.Errorf("%w: %s", ErrCircularReference, id)
+		case 2:
+			return nil
+		}
+		state[id] = 1
+		deps, err := l.deps(l.Rows[l.index[id]])
+		if err != nil {
+			return err
+		}
+		for _, d := range deps {
+			if err := visit(d); err != nil {
+				return err
+			}
+		}
+		state[id] = 2
+		return nil
+	}
+	for _, row := range l.Rows {
+		if row.ID != "" {
+			if err := visit(row.ID); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// deps returns the rows a row's formula depends on, with ranges expanded.
+func (l *Layout) deps(row Row) ([]string, error) {
+	var out []string
+	var walk func(e expr) error
+	walk = func(e expr) error {
+		switch e := e.(type) {
+		case ref:
+			out = append(out, string(e))
+		case rangeRef:
+			ids, err := l.between(e.from, e.to)
+			if err != nil {
+				return err
+			}
+			out = append(out, ids...)
+		case unary:
+			return walk(e.x)
+		case binary:
+			if err := walk(e.x); err != nil {
+				return err
+			}
+			return walk(e.y)
+		case call:
+			for _, a := range e.args {
+				if err := walk(a); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	err := walk(row.expr)
+	return out, err
+}
+
+// between returns the IDs of the rows from one row to another in layout
+// order, skipping headings.
+func (l *Layout) between(from, to string) ([]string, error) {
+	i, j := l.index[from], l.index[to]
+	if j < i {
+		return nil, fmt.Errorf("%w: range %s:%s runs backwards", ErrInvalidFormula, from, to)
+	}
+	var ids []string
+	for _, row := range l.Rows[i : j+1] {
+		if row.ID != "" && row.Type != RowHeading {
+			ids = append(ids, row.ID)
+		}
+	}
+	return ids, nil
+}
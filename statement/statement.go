@@ -0,0 +1,174 @@
+package statement
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/jobs"
+	"github.com/senguttuvang/erpnext-go/ledger"
+)
+
+// Line is a row of a generated statement.
+type Line struct {
+	ID      string  `json:"id,omitempty"`
+	Label   string  `json:"label"`
+	Value   float64 `json:"value"`
+	Bold    bool    `json:"bold,omitempty"`
+	Heading bool    `json:"heading,omitempty"`
+}
+
+// Statement is a layout filled in with figures.
+type Statement struct {
+	Name     string    `json:"name"`
+	From, To time.Time `json:"-"`
+	Lines    []Line    `json:"lines"`
+}
+
+// Line returns the line with an ID, or false.
+func (s *Statement) Line(id string) (Line, bool) {
+	i := slices.IndexFunc(s.Lines, func(l Line) bool { return l.ID == id })
+	if i < 0 {
+		return Line{}, false
+	}
+	return s.Lines[i], true
+}
+
+// Generate fills a layout with the balances of the entries matching
+// filter. A balance sheet leaves filter.From zero to take balances as at
+// filter.To, counting opening and period closing entries whatever the
+// filter's flags; a profit and loss statement sets both ends of the
+// period. filter.Account is ignored. Account rows show debit minus credit, or
+// credit minus debit when the row is marked credit.
+//
+// Maps to: get_data() in financial_statements.py, with the account rows
+// and formulas of a Financial Report Template
+func Generate(layout *Layout, accounts []ledger.Account, entries []ledger.GLEntry, filter ledger.GLFilter) (*Statement, error) {
+	return GenerateContext(context.Background(), layout, accounts, entries, filter)
+}
+
+// scanBlock is the number of entries GenerateContext scans between checks
+// of its context.
+const scanBlock = 10000
+
+// GenerateContext is Generate for ledgers big enough to run as a job: it
+// stops with the context's error when ctx is done, and reports the
+// entries scanned to the job running it through jobs.ReportProgress.
+func GenerateContext(ctx context.Context, layout *Layout, accounts []ledger.Account, entries []ledger.GLEntry, filter ledger.GLFilter) (*Statement, error) {
+	filter.Account = ""
+	if filter.From.IsZero() {
+		filter.ReportFlags = ledger.AsOfBalance
+	}
+	balances := make(map[string]float64)
+	for start := 0; start < len(entries); start += scanBlock {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		end := min(start+scanBlock, len(entries))
+		matched, err := ledger.QueryGL(entries[start:end], filter, nil)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range matched {
+			balances[e.Account] += e.Debit - e.Credit
+		}
+		jobs.ReportProgress(ctx, int64(end), int64(len(entries)))
+	}
+	return FromBalances(layout, accounts, balances, filter)
+}
+
+// FromBalances fills a layout with account balances (debit minus credit)
+// already aggregated for the filter, such as those of a columnar.Ledger.
+// Only the filter's company and period are used.
+func FromBalances(layout *Layout, accounts []ledger.Account, balances map[string]float64, filter ledger.GLFilter) (*Statement, error) {
+	if layout.index == nil {
+		if err := layout.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	if filter.Company != "" {
+		accounts = slices.DeleteFunc(slices.Clone(accounts), func(a ledger.Account) bool {
+			return a.Company != filter.Company
+		})
+	}
+	tree, err := ledger.NewAccountTree(accounts...)
+	if err != nil {
+		return nil, err
+	}
+	for _, account := range slices.Sorted(maps.Keys(balances)) {
+		if !tree.Has(account) {
+			return nil, fmt.Errorf("%w: %s", ledger.ErrAccountNotInTree, account)
+		}
+	}
+
+	g := &generator{layout: layout, tree: tree, accounts: accounts, balances: balances, values: make(map[string]float64)}
+	s := &Statement{Name: layout.Name, From: filter.From, To: filter.To}
+	for _, row := range layout.Rows {
+		if row.Hidden {
+			continue
+		}
+		line := Line{ID: row.ID, Label: row.Label, Bold: row.Bold, Heading: row.Type == RowHeading}
+		if row.Type != RowHeading {
+			v, err := g.value(row.ID)
+			if err != nil {
+				return nil, err
+			}
+			line.Value = v
+		}
+		s.Lines = append(s.Lines, line)
+	}
+	return s, nil
+}
+
+// generator evaluates the rows of a layout, each once.
+type generator struct {
+	layout   *Layout
+	tree     *ledger.AccountTree
+	accounts []ledger.Account
+	balances map[string]float64 // Debit - credit by account
+	values   map[string]float64 // By row ID
+}
+
+func (g *generator) value(id string) (float64, error) {
+	if v, ok := g.values[id]; ok {
+		return v, nil
+	}
+	row := g.layout.Rows[g.layout.index[id]]
+	var v float64
+	switch row.Type {
+	case RowAccounts:
+		for _, acc := range g.accounts {
+			if g.selects(row.Accounts, acc) {
+				v += g.balances[acc.Name]
+			}
+		}
+		if row.Credit {
+			v = -v
+		}
+	case RowFormula:
+		var err error
+		if v, err = row.expr.eval(g); err != nil {
+			return 0, fmt.Errorf("row %s: %w", id, err)
+		}
+	}
+	v = ledger.Flt(v, 2)
+	g.values[id] = v
+	return v, nil
+}
+
+func (g *generator) between(from, to string) ([]string, error) {
+	return g.layout.between(from, to)
+}
+
+// selects reports whether an account set includes an account.
+func (g *generator) selects(set AccountSet, acc ledger.Account) bool {
+	under := func(names []string) bool {
+		return slices.ContainsFunc(names, func(n string) bool { return g.tree.IsDescendant(acc.Name, n) })
+	}
+	if under(set.Exclude) {
+		return false
+	}
+	return slices.Contains(set.RootTypes, acc.RootType) || under(set.Accounts)
+}
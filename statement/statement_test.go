@@ -0,0 +1,236 @@
+package statement
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/jobs"
+	"github.com/senguttuvang/erpnext-go/ledger"
+)
+
+func date(s string) time.Time {
+	t, _ := time.Parse(ledger.DateLayout, s)
+	return t
+}
+
+var accounts = []ledger.Account{
+	{Name: "Assets", Company: "ABC", IsGroup: true, RootType: "Asset"},
+	{Name: "Cash", Company: "ABC", RootType: "Asset", ParentAccount: "Assets"},
+	{Name: "Debtors", Company: "ABC", RootType: "Asset", ParentAccount: "Assets"},
+	{Name: "Creditors", Company: "ABC", RootType: "Liability"},
+	{Name: "Capital", Company: "ABC", RootType: "Equity"},
+	{Name: "Income", Company: "ABC", IsGroup: true, RootType: "Income"},
+	{Name: "Sales", Company: "ABC", RootType: "Income", ParentAccount: "Income"},
+	{Name: "Service", Company: "ABC", RootType: "Income", ParentAccount: "Income"},
+	{Name: "COGS", Company: "ABC", RootType: "Expense"},
+	{Name: "Rent", Company: "ABC", RootType: "Expense"},
+}
+
+func entry(day, account string, debit, credit float64) ledger.GLEntry {
+	return ledger.GLEntry{Company: "ABC", Account: account, Debit: debit, Credit: credit,
+		PostingDate: date(day), VoucherType: "Journal Entry", VoucherNo: "JV-" + day}
+}
+
+var entries = []ledger.GLEntry{
+	entry("2026-01-01", "Cash", 5000, 0), entry("2026-01-01", "Capital", 0, 5000),
+	entry("2026-02-01", "Debtors", 3000, 0), entry("2026-02-01", "Sales", 0, 2000), entry("2026-02-01", "Service", 0, 1000),
+	entry("2026-02-02", "COGS", 1200, 0), entry("2026-02-02", "Creditors", 0, 1200),
+	entry("2026-02-03", "Rent", 300, 0), entry("2026-02-03", "Cash", 0, 300),
+}
+
+func value(t *testing.T, s *Statement, id string) float64 {
+	t.Helper()
+	l, ok := s.Line(id)
+	if !ok {
+		t.Fatalf("no line %s", id)
+	}
+	return l.Value
+}
+
+func TestCustomLayout(t *testing.T) {
+	layout, err := Load(strings.NewReader(`{
+		"name": "Management P&L",
+		"rows": [
+			{"label": "Revenue"},
+			{"id": "Goods", "label": "Goods", "accounts": {"accounts": ["Sales"]}, "credit": true},
+			{"id": "Services", "label": "Services", "accounts": {"accounts": ["Service"]}, "credit": true},
+			{"id": "Revenue", "label": "Total Revenue", "formula": "=sum(Goods:Services)", "bold": true},
+			{"id": "COGS", "label": "Cost of Goods Sold", "accounts": {"accounts": ["COGS"]}},
+			{"id": "GrossProfit", "label": "Gross Profit", "formula": "=Revenue - COGS"},
+			{"id": "GrossMargin", "label": "Gross Margin %", "formula": "=ROUND(GrossProfit / Revenue * 100, 1)"},
+			{"id": "Opex", "label": "Operating Expenses", "accounts": {"root_types": ["Expense"], "exclude": ["COGS"]}, "hidden": true},
+			{"id": "Net", "label": "Net Profit", "formula": "=GrossProfit - Opex"}
+		]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := Generate(layout, accounts, entries, ledger.GLFilter{Company: "ABC", From: date("2026-02-01"), To: date("2026-02-28")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s.Lines) != 8 || !s.Lines[0].Heading {
+		t.Fatalf("lines = %+v", s.Lines)
+	}
+	for id, want := range map[string]float64{"Goods": 2000, "Services": 1000, "Revenue": 3000, "GrossProfit": 1800, "GrossMargin": 60, "Net": 1500} {
+		if got := value(t, s, id); got != want {
+			t.Errorf("%s = %v, want %v", id, got, want)
+		}
+	}
+	if _, ok := s.Line("Opex"); ok {
+		t.Error("hidden row shown")
+	}
+}
+
+func TestDefaultLayouts(t *testing.T) {
+	bs, err := Generate(BalanceSheet(), accounts, entries, ledger.GLFilter{To: date("2026-12-31")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := value(t, bs, "Assets"); got != 7700 {
+		t.Errorf("assets = %v", got)
+	}
+	if got := value(t, bs, "ProvisionalProfit"); got != 1500 {
+		t.Errorf("provisional profit = %v", got)
+	}
+	if got := value(t, bs, "Difference"); got != 0 {
+		t.Errorf("difference = %v", got)
+	}
+
+	// A cancelled sale changes nothing, reversal and all
+	sale, reversal := entry("2026-03-01", "Debtors", 900, 0), entry("2026-03-01", "Debtors", 0, 900)
+	sale.IsCancelled = true
+	withCancelled := append(slices.Clone(entries), sale, reversal)
+	bs, err = Generate(BalanceSheet(), accounts, withCancelled, ledger.GLFilter{To: date("2026-12-31")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := value(t, bs, "Assets"); got != 7700 {
+		t.Errorf("assets with a cancelled sale = %v", got)
+	}
+
+	// Opening entries count in a balance as at a date
+	opening := []ledger.GLEntry{entry("2025-12-31", "Cash", 800, 0), entry("2025-12-31", "Capital", 0, 800)}
+	opening[0].IsOpening, opening[1].IsOpening = ledger.IsOpeningYes, ledger.IsOpeningYes
+	bs, err = Generate(BalanceSheet(), accounts, append(slices.Clone(entries), opening...), ledger.GLFilter{To: date("2026-12-31")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := value(t, bs, "Assets"); got != 8500 {
+		t.Errorf("assets with an opening balance = %v, want 8500", got)
+	}
+	if got := value(t, bs, "Difference"); got != 0 {
+		t.Errorf("difference with an opening balance = %v", got)
+	}
+
+	pl, err := Generate(ProfitAndLoss(), accounts, entries, ledger.GLFilter{From: date("2026-03-01"), To: date("2026-03-31")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := value(t, pl, "NetMargin"); got != 0 {
+		t.Errorf("margin of an empty period = %v", got)
+	}
+}
+
+func TestFromBalances(t *testing.T) {
+	balances := map[string]float64{"Cash": 4700, "Debtors": 3000, "Creditors": -1200, "Capital": -5000, "Sales": -2000, "Service": -1000, "COGS": 1200, "Rent": 300}
+	bs, err := FromBalances(BalanceSheet(), accounts, balances, ledger.GLFilter{To: date("2026-12-31")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := value(t, bs, "Assets"); got != 7700 {
+		t.Errorf("assets = %v", got)
+	}
+	balances["Suspense"] = 10
+	if _, err := FromBalances(BalanceSheet(), accounts, balances, ledger.GLFilter{}); !errors.Is(err, ledger.ErrAccountNotInTree) {
+		t.Errorf("unknown account: %v", err)
+	}
+}
+
+func TestInvalidLayouts(t *testing.T) {
+	for _, tc := range []struct {
+		name, json string
+		want       error
+	}{
+		{"unknown field", `{"rows": [{"id": "A", "label": "A", "formla": "1"}]}`, ErrInvalidLayout},
+		{"duplicate id", `{"rows": [{"id": "A", "formula": "1"}, {"id": "A", "formula": "2"}]}`, ErrInvalidLayout},
+		{"syntax", `{"rows": [{"id": "A", "formula": "=(1 + 2"}]}`, ErrInvalidFormula},
+		{"unknown function", `{"rows": [{"id": "A", "formula": "=VLOOKUP(1)"}]}`, ErrInvalidFormula},
+		{"unknown row", `{"rows": [{"id": "A", "formula": "=B * 2"}]}`, ErrUnknownRow},
+		{"cycle", `{"rows": [{"id": "A", "formula": "=B"}, {"id": "B", "formula": "=SUM(A:B)"}]}`, ErrCircularReference},
+		{"backwards range", `{"rows": [{"id": "A", "formula": "1"}, {"id": "B", "formula": "=SUM(B:A)"}]}`, ErrInvalidFormula},
+	} {
+		if _, err := Load(strings.NewReader(tc.json)); !errors.Is(err, tc.want) {
+			t.Errorf("%s: err = %v, want %v", tc.name, err, tc.want)
+		}
+	}
+}
+
+// bigLedger is a Source of the test entries repeated n times, cancelling a
+// job when it is read if cancel is set.
+type bigLedger struct {
+	n      int
+	cancel func()
+}
+
+func (b *bigLedger) Accounts([]string) ([]ledger.Account, error) { return accounts, nil }
+
+func (b *bigLedger) GLEntries(context.Context, []string, time.Time) ([]ledger.GLEntry, error) {
+	if b.cancel != nil {
+		b.cancel()
+	}
+	var out []ledger.GLEntry
+	for range b.n {
+		out = append(out, entries...)
+	}
+	return out, nil
+}
+
+func TestTask(t *testing.T) {
+	source := &bigLedger{n: 3000} // 27,000 entries, scanned in three blocks
+	results := &MemoryResults{}
+	g := &Generator{Layouts: map[string]*Layout{"Balance Sheet": BalanceSheet()}, Source: source, Results: results}
+	runner := &jobs.Runner{Queue: jobs.NewMemoryQueue(10), Store: &jobs.MemoryStore{}, Tasks: map[string]jobs.Task{Task: g}}
+	ctx := context.Background()
+
+	job, err := Submit(ctx, runner, Params{Name: "BS 2026", Layout: "Balance Sheet", Companies: []string{"ABC"}, To: "2026-12-31"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := runner.Run(ctx, job.ID); err != nil {
+		t.Fatal(err)
+	}
+	job, _ = runner.Store.Get(job.ID)
+	if p := job.Progress(); job.Status != jobs.Completed || p.Rows != 27000 || job.Chunks[0].RowsTotal != 27000 {
+		t.Errorf("job = %s %+v", job.Status, p)
+	}
+	if bs := results.Get("BS 2026"); bs == nil || value(t, bs, "Assets") != 7700*3000 {
+		t.Errorf("result = %+v", bs)
+	}
+
+	// Cancelled while it runs, the scan stops and the chunk stays pending
+	job, _ = Submit(ctx, runner, Params{Name: "BS again", Layout: "Balance Sheet", Companies: []string{"ABC"}, To: "2026-12-31"})
+	source.cancel = func() { runner.Cancel(job.ID) }
+	if err := runner.Run(ctx, job.ID); err != nil {
+		t.Fatal(err)
+	}
+	job, _ = runner.Store.Get(job.ID)
+	if job.Status != jobs.Cancelled || job.Chunks[0].Status != jobs.ChunkPending || results.Get("BS again") != nil {
+		t.Errorf("cancelled job = %s %+v", job.Status, job.Chunks)
+	}
+
+	job, _ = Submit(ctx, runner, Params{Name: "X", Layout: "Cash Flow", Companies: []string{"ABC"}, To: "2026-12-31"})
+	runner.Run(ctx, job.ID)
+	if job, _ = runner.Store.Get(job.ID); job.Status != jobs.Failed || !strings.Contains(job.Error, "unknown layout") {
+		t.Errorf("unknown layout = %s %s", job.Status, job.Error)
+	}
+
+	cancelled, stop := context.WithCancel(ctx)
+	stop()
+	if _, err := GenerateContext(cancelled, BalanceSheet(), accounts, entries, ledger.GLFilter{}); !errors.Is(err, context.Canceled) {
+		t.Errorf("GenerateContext() cancelled = %v", err)
+	}
+}
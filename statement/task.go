@@ -0,0 +1,144 @@
+package statement
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/jobs"
+	"github.com/senguttuvang/erpnext-go/ledger"
+)
+
+// ErrInvalidParams is returned for a statement job that cannot run.
+var ErrInvalidParams = errors.New("invalid statement job")
+
+// Task is the job type of statements generated in the background, such as
+// the consolidated statements of a group on a ledger too big to wait on.
+// The application registers a Generator under it with the jobs.Runner and
+// queues statements with Submit; the job reports the entries scanned and
+// can be cancelled while it scans.
+//
+// Maps to: the "prepared report" mode of ERPNext's financial statements,
+// which runs them in a background job
+const Task = "financial-statement"
+
+// Params are the parameters of a Task job. Dates are YYYY-MM-DD.
+type Params struct {
+	Name      string   `json:"name"`      // Key of the result in Results
+	Layout    string   `json:"layout"`    // Key in Generator.Layouts
+	Companies []string `json:"companies"` // Several for a consolidated statement
+	From      string   `json:"from"`      // Empty for a balance sheet
+	To        string   `json:"to"`
+}
+
+// Source gives background statements their chart and entries.
+type Source interface {
+	// Accounts returns the accounts of the companies.
+	Accounts(companies []string) ([]ledger.Account, error)
+
+	// GLEntries returns the entries of the companies posted up to a date.
+	GLEntries(ctx context.Context, companies []string, to time.Time) ([]ledger.GLEntry, error)
+}
+
+// Results keeps the statements of finished jobs.
+type Results interface {
+	Save(name string, s *Statement) error
+}
+
+// Generator generates statements as jobs.
+type Generator struct {
+	Layouts map[string]*Layout
+	Source  Source
+	Results Results
+}
+
+// Submit queues a statement.
+func Submit(ctx context.Context, runner *jobs.Runner, params Params) (*jobs.Job, error) {
+	return runner.Submit(ctx, Task, params)
+}
+
+// Plan implements jobs.Task with a single chunk: the progress of the
+// statement is that of its scan.
+func (g *Generator) Plan(_ context.Context, params json.RawMessage) ([]string, error) {
+	p, _, _, err := g.parseParams(params)
+	if err != nil {
+		return nil, err
+	}
+	return []string{p.Name}, nil
+}
+
+// Run implements jobs.Task.
+func (g *Generator) Run(ctx context.Context, params json.RawMessage, _ string) error {
+	p, layout, filter, err := g.parseParams(params)
+	if err != nil {
+		return err
+	}
+	accounts, err := g.Source.Accounts(p.Companies)
+	if err != nil {
+		return err
+	}
+	entries, err := g.Source.GLEntries(ctx, p.Companies, filter.To)
+	if err != nil {
+		return err
+	}
+	s, err := GenerateContext(ctx, layout, accounts, entries, filter)
+	if err != nil {
+		return err
+	}
+	return g.Results.Save(p.Name, s)
+}
+
+func (g *Generator) parseParams(raw json.RawMessage) (Params, *Layout, ledger.GLFilter, error) {
+	var p Params
+	if err := json.Unmarshal(raw, &p); err != nil {
+		return p, nil, ledger.GLFilter{}, err
+	}
+	layout := g.Layouts[p.Layout]
+	if layout == nil {
+		return p, nil, ledger.GLFilter{}, fmt.Errorf("%w: unknown layout %q", ErrInvalidParams, p.Layout)
+	}
+	if p.Name == "" || len(p.Companies) == 0 {
+		return p, nil, ledger.GLFilter{}, fmt.Errorf("%w: name and companies are required", ErrInvalidParams)
+	}
+	var filter ledger.GLFilter
+	if len(p.Companies) == 1 {
+		filter.Company = p.Companies[0]
+	}
+	var err error
+	if p.From != "" {
+		if filter.From, err = ledger.ParseDate(p.From); err != nil {
+			return p, nil, filter, fmt.Errorf("%w: date %q", ErrInvalidParams, p.From)
+		}
+	}
+	if filter.To, err = ledger.ParseDate(p.To); err != nil {
+		return p, nil, filter, fmt.Errorf("%w: date %q", ErrInvalidParams, p.To)
+	}
+	return p, layout, filter, nil
+}
+
+// MemoryResults is an in-memory Results.
+type MemoryResults struct {
+	mu         sync.Mutex
+	statements map[string]*Statement
+}
+
+// Save implements Results.
+func (r *MemoryResults) Save(name string, s *Statement) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.statements == nil {
+		r.statements = make(map[string]*Statement)
+	}
+	r.statements[name] = s
+	return nil
+}
+
+// Get returns a saved statement, or nil.
+func (r *MemoryResults) Get(name string) *Statement {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.statements[name]
+}
@@ -0,0 +1,147 @@
+package taxcalc
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+)
+
+// DefaultCacheSize is the number of documents a Cache keeps when its size
+// is not set.
+const DefaultCacheSize = 1024
+
+// Hash returns a key of everything in the document the calculation reads.
+// The calculator reads some fields it also writes, such as a row's rate
+// when it has no price list rate, so the key covers every field: a
+// document carrying totals from an earlier state hashes apart from the
+// same document without them.
+func Hash(doc *Document) (string, error) {
+	b, err := json.Marshal(doc)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// CacheStats counts the lookups of a Cache.
+type CacheStats struct {
+	Hits   int
+	Misses int
+	Size   int // Documents held
+}
+
+// Cache remembers calculated documents by the hash of the document before
+// calculation, so that recalculating the same document, as a point of
+// sale does on every keystroke, returns the totals computed the first
+// time. The least recently used documents are dropped beyond Size. A
+// Cache is safe for concurrent use; each one calculates with a single
+// precision.
+type Cache struct {
+	Precision PrecisionProvider // DefaultPrecision when nil
+	Size      int               // DefaultCacheSize when zero
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List // Of *cacheEntry, most recently used first
+	stats   CacheStats
+}
+
+type cacheEntry struct {
+	key string
+	doc Document
+}
+
+// Calculate calculates the document in place, as Calculator.Calculate
+// does, taking the result from the cache when the same document was
+// calculated before. Errors are not cached. A document that cannot be
+// hashed, such as one with a NaN amount, is calculated without the cache.
+func (c *Cache) Calculate(doc *Document) error {
+	key, err := Hash(doc)
+	if err != nil {
+		return NewCalculator(doc, c.Precision).Calculate()
+	}
+	if cached, ok := c.get(key); ok {
+		restore(doc, cached)
+		return nil
+	}
+	if err := NewCalculator(doc, c.Precision).Calculate(); err != nil {
+		return err
+	}
+	c.put(key, doc.Copy())
+	return nil
+}
+
+// Stats returns the hits and misses so far.
+func (c *Cache) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s := c.stats
+	if c.order != nil {
+		s.Size = c.order.Len()
+	}
+	return s
+}
+
+// Reset drops every cached document, as when the tax templates or
+// precision settings it was computed with change.
+func (c *Cache) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries, c.order = nil, nil
+}
+
+// get returns a copy of the document cached under key. It copies under
+// the lock, as put may replace the entry's document once it is released.
+func (c *Cache) get(key string) (Document, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.entries[key]
+	if !ok {
+		c.stats.Misses++
+		return Document{}, false
+	}
+	c.stats.Hits++
+	c.order.MoveToFront(el)
+	return el.Value.(*cacheEntry).doc.Copy(), true
+}
+
+func (c *Cache) put(key string, doc Document) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.entries == nil {
+		c.entries, c.order = make(map[string]*list.Element), list.New()
+	}
+	if el, ok := c.entries[key]; ok {
+		el.Value.(*cacheEntry).doc = doc
+		c.order.MoveToFront(el)
+		return
+	}
+	c.entries[key] = c.order.PushFront(&cacheEntry{key: key, doc: doc})
+	size := c.Size
+	if size <= 0 {
+		size = DefaultCacheSize
+	}
+	for c.order.Len() > size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+// restore copies a cached result, a copy of its own, into doc. The
+// document's own item and tax rows are overwritten rather than replaced,
+// so callers holding them see the result; a matching hash means there
+// are as many of each.
+func restore(doc *Document, result Document) {
+	for i, item := range doc.Items {
+		*item = *result.Items[i]
+	}
+	for i, tax := range doc.Taxes {
+		*tax = *result.Taxes[i]
+	}
+	result.Items, result.Taxes = doc.Items, doc.Taxes
+	*doc = result
+}
@@ -0,0 +1,108 @@
+package taxcalc
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/senguttuvang/erpnext-go/custom"
+)
+
+func cart(qty float64) *Document {
+	return &Document{
+		ConversionRate: 1,
+		Items: []*LineItem{
+			{ItemCode: "TEA", Qty: qty, PriceListRate: 120, DiscountPercentage: 10},
+			{ItemCode: "BUN", Qty: 2, Rate: 35},
+		},
+		Taxes:                        []*TaxRow{{AccountHead: "VAT", ChargeType: OnNetTotal, Rate: 5}},
+		AdditionalDiscountPercentage: 5,
+		CashRounding:                 0.05,
+	}
+}
+
+func TestCache(t *testing.T) {
+	cache := &Cache{Size: 2}
+	want := cart(3)
+	if err := NewCalculator(want, nil).Calculate(); err != nil {
+		t.Fatal(err)
+	}
+
+	first := cart(3)
+	if err := cache.Calculate(first); err != nil {
+		t.Fatal(err)
+	}
+	second := cart(3)
+	tea := second.Items[0]
+	if err := cache.Calculate(second); err != nil {
+		t.Fatal(err)
+	}
+	if s := cache.Stats(); s.Hits != 1 || s.Misses != 1 || s.Size != 1 {
+		t.Errorf("Stats() = %+v", s)
+	}
+	for _, doc := range []*Document{first, second} {
+		if doc.GrandTotal != want.GrandTotal || doc.RoundedTotal != want.RoundedTotal || doc.Taxes[0].TaxAmount != want.Taxes[0].TaxAmount {
+			t.Errorf("totals = %v %v %v, want %v %v %v", doc.GrandTotal, doc.RoundedTotal, doc.Taxes[0].TaxAmount,
+				want.GrandTotal, want.RoundedTotal, want.Taxes[0].TaxAmount)
+		}
+	}
+	// The caller's rows hold the result, and share nothing with the cache
+	if second.Items[0] != tea || tea.NetAmount != want.Items[0].NetAmount {
+		t.Errorf("item = %+v", second.Items[0])
+	}
+	second.Taxes[0].ItemWiseTaxDetail["TEA"] = 0
+	third := cart(3)
+	cache.Calculate(third)
+	if third.Taxes[0].ItemWiseTaxDetail["TEA"] != want.Taxes[0].ItemWiseTaxDetail["TEA"] {
+		t.Error("cached document was changed through a restored one")
+	}
+
+	// Another quantity misses; the least recently used is dropped
+	cache.Calculate(cart(4))
+	cache.Calculate(cart(5))
+	if s := cache.Stats(); s.Hits != 2 || s.Misses != 3 || s.Size != 2 {
+		t.Errorf("Stats() = %+v", s)
+	}
+	cache.Calculate(cart(3))
+	if s := cache.Stats(); s.Misses != 4 {
+		t.Errorf("evicted document was a hit: %+v", s)
+	}
+
+	// Errors are not cached, and unhashable documents still calculate
+	if err := cache.Calculate(&Document{}); !errors.Is(err, ErrNoItems) {
+		t.Errorf("Calculate() empty = %v", err)
+	}
+	odd := cart(3)
+	odd.Items[1].ChargeWeight = math.NaN()
+	if err := cache.Calculate(odd); err != nil || odd.GrandTotal != want.GrandTotal {
+		t.Errorf("Calculate() NaN = %v, %v", err, odd.GrandTotal)
+	}
+	cache.Reset()
+	if s := cache.Stats(); s.Size != 0 {
+		t.Errorf("Stats() after Reset = %+v", s)
+	}
+}
+
+func TestDocumentCopy(t *testing.T) {
+	doc := cart(1)
+	doc.Custom = custom.Fields{"channel": "web"}
+	doc.Items[0].Custom = custom.Fields{"batch": "B1"}
+	c := doc.Copy()
+	c.Custom["channel"] = "store"
+	c.Items[0].Custom["batch"] = "B2"
+	if doc.Custom.String("channel") != "web" || doc.Items[0].Custom.String("batch") != "B1" {
+		t.Errorf("copy shares custom fields: %v, %v", doc.Custom, doc.Items[0].Custom)
+	}
+}
+
+func TestCacheGetCopies(t *testing.T) {
+	// A document taken from the cache stays as it was when the entry is
+	// refilled, as by another goroutine that missed the same key
+	cache := &Cache{}
+	cache.put("k", *cart(3))
+	got, _ := cache.get("k")
+	cache.put("k", *cart(4))
+	if got.Items[0].Qty != 3 {
+		t.Errorf("cached document changed under the caller: qty %v", got.Items[0].Qty)
+	}
+}
@@ -20,6 +20,10 @@ var (
 type Calculator struct {
 	doc       *Document
 	precision PrecisionProvider
+
+	// discountAmountApplied is set once the document discount has been
+	// distributed to items, for the second calculation pass.
+	discountAmountApplied bool
 }
 
 // NewCalculator creates a new calculator for a document.
@@ -51,6 +55,19 @@ func (c *Calculator) Calculate() error {
 		return err
 	}
 
+	c.discountAmountApplied = false
+	if err := c.calculate(); err != nil {
+		return err
+	}
+
+	// Distribute the document discount and recalculate
+	c.setDiscountAmount()
+	return c.applyDiscountAmount()
+}
+
+// calculate runs one calculation pass.
+// Maps to: _calculate() in Python
+func (c *Calculator) calculate() error {
 	// Calculate item values (rate, amount, net_amount)
 	if err := c.calculateItemValues(); err != nil {
 		return err
@@ -102,6 +119,11 @@ func (c *Calculator) validateConversionRate() error {
 //           item.amount = flt(item.rate * item.qty)
 //           item.net_amount = item.amount
 func (c *Calculator) calculateItemValues() error {
+	// Net values already carry the distributed document discount
+	if c.discountAmountApplied {
+		return nil
+	}
+
 	ratePrecision := c.precision.GetPrecision("rate")
 	amountPrecision := c.precision.GetPrecision("amount")
 
@@ -175,8 +197,15 @@ func (c *Calculator) setInCompanyCurrency(item *LineItem) {
 //           for fieldname in tax_fields:
 //               tax.set(fieldname, 0.0)
 func (c *Calculator) initializeTaxes() {
+	keepTaxAmount := c.discountAmountApplied && c.doc.ApplyDiscountOn != DiscountOnNetTotal
+
 	for _, tax := range c.doc.Taxes {
-		tax.TaxAmount = 0.0
+		// With a discount on the grand total, tax_amount keeps the value
+		// before discount and only tax_amount_after_discount_amount changes
+		if !keepTaxAmount {
+			tax.TaxAmount = 0.0
+			tax.BaseTaxAmount = 0.0
+		}
 		tax.TaxAmountAfterDiscountAmount = 0.0
 		tax.Total = 0.0
 		tax.NetAmount = 0.0
@@ -184,9 +213,9 @@ func (c *Calculator) initializeTaxes() {
 		tax.GrandTotalForCurrentItem = 0.0
 		tax.TaxFractionForCurrentItem = 0.0
 		tax.GrandTotalFractionForCurrentItem = 0.0
-		tax.BaseTaxAmount = 0.0
 		tax.BaseTaxAmountAfterDiscountAmount = 0.0
 		tax.BaseTotal = 0.0
+		tax.ItemWiseTaxDetail = make(map[string]float64)
 	}
 }
 
@@ -238,6 +267,7 @@ func (c *Calculator) calculateTaxes() error {
 	}
 
 	taxPrecision := c.precision.GetPrecision("tax_amount")
+	keepTaxAmount := c.discountAmountApplied && c.doc.ApplyDiscountOn != DiscountOnNetTotal
 
 	// Track actual tax amounts for proportional distribution
 	actualTaxAmounts := make(map[int]float64)
@@ -249,14 +279,19 @@ func (c *Calculator) calculateTaxes() error {
 
 	// Process each item
 	for itemIdx, item := range c.doc.Items {
-		itemTaxMap, _ := ParseItemTaxRate(item.ItemTaxRate)
+		itemTaxMap, _ := item.TaxMap()
+		// Net amount plus the item's share of taxable charges so far
+		taxableAmount := item.NetAmount
 
 		for taxIdx, tax := range c.doc.Taxes {
 			// Calculate tax amount for this item
-			currentTaxAmount, err := c.getCurrentTaxAmount(item, tax, taxIdx, itemTaxMap)
+			currentTaxAmount, err := c.getCurrentTaxAmount(item, tax, taxIdx, itemTaxMap, taxableAmount)
 			if err != nil {
 				return err
 			}
+			if c.doc.TaxRounding == RoundPerItem {
+				currentTaxAmount = Flt(currentTaxAmount, taxPrecision)
+			}
 
 			// Adjust for actual tax distribution
 			if tax.ChargeType == Actual {
@@ -265,14 +300,26 @@ func (c *Calculator) calculateTaxes() error {
 				if itemIdx == len(c.doc.Items)-1 {
 					currentTaxAmount += actualTaxAmounts[taxIdx]
 				}
+				if tax.IncludeInTaxableAmount {
+					taxableAmount += c.getAdjustedTaxAmount(currentTaxAmount, tax)
+				}
+			}
+			if tax.ChargeType == OnNetTotal {
+				tax.NetAmount += taxableAmount
 			}
 
 			// Accumulate tax amount
-			tax.TaxAmount += currentTaxAmount
+			if !keepTaxAmount {
+				tax.TaxAmount += currentTaxAmount
+			}
 			tax.TaxAmountAfterDiscountAmount += currentTaxAmount
 
 			// Track for current item (used by OnPreviousRow*)
 			tax.TaxAmountForCurrentItem = currentTaxAmount
+			if tax.ItemWiseTaxDetail == nil {
+				tax.ItemWiseTaxDetail = make(map[string]float64)
+			}
+			tax.ItemWiseTaxDetail[item.ItemCode] += currentTaxAmount
 
 			// Calculate running total for current item
 			adjustedTaxAmount := c.getAdjustedTaxAmount(currentTaxAmount, tax)
@@ -284,11 +331,19 @@ func (c *Calculator) calculateTaxes() error {
 		}
 	}
 
-	// Round and calculate cumulative totals
-	for taxIdx, tax := range c.doc.Taxes {
+	// Round, then calculate cumulative totals
+	var exact, rounded float64
+	for _, tax := range c.doc.Taxes {
+		exact += c.getAdjustedTaxAmount(tax.TaxAmountAfterDiscountAmount, tax)
 		tax.TaxAmount = Flt(tax.TaxAmount, taxPrecision)
 		tax.TaxAmountAfterDiscountAmount = Flt(tax.TaxAmountAfterDiscountAmount, taxPrecision)
-
+		tax.NetAmount = Flt(tax.NetAmount, c.precision.GetPrecision("amount"))
+		rounded += c.getAdjustedTaxAmount(tax.TaxAmountAfterDiscountAmount, tax)
+	}
+	if c.doc.TaxRounding == RoundPerDocument {
+		c.adjustDocumentRounding(Flt(Flt(exact, taxPrecision)-rounded, taxPrecision), keepTaxAmount)
+	}
+	for taxIdx, tax := range c.doc.Taxes {
 		// Set cumulative total
 		c.setCumulativeTotal(taxIdx, tax)
 
@@ -302,9 +357,40 @@ func (c *Calculator) calculateTaxes() error {
 	return nil
 }
 
+// adjustDocumentRounding books the difference between the rounded tax
+// total of the document and the sum of its rounded tax rows to the last
+// row that adds to the total, and to that row's tax on the last item, so
+// that the taxes add up to the tax total rounded once.
+func (c *Calculator) adjustDocumentRounding(diff float64, keepTaxAmount bool) {
+	if diff == 0 {
+		return
+	}
+	taxPrecision := c.precision.GetPrecision("tax_amount")
+	for i := len(c.doc.Taxes) - 1; i >= 0; i-- {
+		tax := c.doc.Taxes[i]
+		if tax.Category == Valuation {
+			continue
+		}
+		if tax.AddDeductTax == Deduct {
+			diff = -diff
+		}
+		if !keepTaxAmount {
+			tax.TaxAmount = Flt(tax.TaxAmount+diff, taxPrecision)
+		}
+		tax.TaxAmountAfterDiscountAmount = Flt(tax.TaxAmountAfterDiscountAmount+diff, taxPrecision)
+		last := c.doc.Items[len(c.doc.Items)-1].ItemCode
+		tax.ItemWiseTaxDetail[last] += diff
+		return
+	}
+}
+
 // getCurrentTaxAmount calculates tax for a single item.
 // Maps to: get_current_tax_amount() in Python (lines 566-594)
 //
+// On Net Total rows are calculated on taxableAmount: the item's net amount
+// plus its share of the Actual charges before the row that are included
+// in the taxable amount.
+//
 // Python equivalent:
 //   def get_current_tax_amount(self, item, tax, item_tax_map):
 //       tax_rate = self._get_tax_rate(tax, item_tax_map)
@@ -318,7 +404,7 @@ func (c *Calculator) calculateTaxes() error {
 //           current_tax_amount = (tax_rate / 100.0) * prev_row.grand_total_for_current_item
 //       elif tax.charge_type == "On Item Quantity":
 //           current_tax_amount = tax_rate * item.qty
-func (c *Calculator) getCurrentTaxAmount(item *LineItem, tax *TaxRow, taxIdx int, itemTaxMap map[string]float64) (float64, error) {
+func (c *Calculator) getCurrentTaxAmount(item *LineItem, tax *TaxRow, taxIdx int, itemTaxMap map[string]float64, taxableAmount float64) (float64, error) {
 	// Get applicable tax rate (item-specific or default)
 	taxRate := c.getTaxRate(tax, itemTaxMap)
 
@@ -335,8 +421,8 @@ func (c *Calculator) getCurrentTaxAmount(item *LineItem, tax *TaxRow, taxIdx int
 		}
 
 	case OnNetTotal:
-		// Percentage of item's net amount
-		currentTaxAmount = (taxRate / 100.0) * item.NetAmount
+		// Percentage of item's net amount and taxable charges
+		currentTaxAmount = (taxRate / 100.0) * taxableAmount
 
 	case OnPreviousRowAmount:
 		// Percentage of previous tax row's tax amount
@@ -430,6 +516,8 @@ func (c *Calculator) calculateTotals() {
 		c.doc.GrandTotal = Flt(c.doc.NetTotal, precision)
 		c.doc.BaseGrandTotal = Flt(c.doc.BaseNetTotal, precision)
 	}
+
+	c.calculateRoundedTotal()
 }
 
 // GetTaxBreakup returns tax amounts by account for display.
@@ -608,3 +608,47 @@ func TestCalculate_ItemSpecificTaxRate(t *testing.T) {
 		t.Errorf("tax_amount: got %.2f, want %.2f", gst.TaxAmount, 18.0)
 	}
 }
+
+func TestCalculate_TaxableCharge(t *testing.T) {
+	// Test: Freight included in the taxable amount of the GST after it
+	newDoc := func(taxable bool) *Document {
+		return &Document{
+			ConversionRate: 1.0,
+			Items: []*LineItem{
+				{ItemCode: "A", Qty: 2, Rate: 300},
+				{ItemCode: "B", Qty: 1, Rate: 400},
+			},
+			Taxes: []*TaxRow{
+				{AccountHead: "Freight", ChargeType: Actual, Rate: 50, IncludeInTaxableAmount: taxable},
+				{AccountHead: "GST", ChargeType: OnNetTotal, Rate: 18},
+			},
+		}
+	}
+
+	doc := newDoc(true)
+	calc := NewCalculator(doc, nil)
+	if err := calc.Calculate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// 18% of 1000 net plus 50 freight = 189; the freight is split 30/20
+	gst := doc.Taxes[1]
+	if gst.TaxAmount != 189 || gst.NetAmount != 1050 || doc.GrandTotal != 1239 {
+		t.Errorf("gst %.2f on %.2f, grand total %.2f, want 189/1050/1239", gst.TaxAmount, gst.NetAmount, doc.GrandTotal)
+	}
+	if !almostEqual(gst.ItemWiseTaxDetail["A"], 113.4, 0.001) || !almostEqual(gst.ItemWiseTaxDetail["B"], 75.6, 0.001) {
+		t.Errorf("item-wise gst: %v", gst.ItemWiseTaxDetail)
+	}
+	if err := calc.CheckInvariants(); err != nil {
+		t.Errorf("invariants: %v", err)
+	}
+
+	// Without the flag the freight is not taxed
+	doc = newDoc(false)
+	if err := NewCalculator(doc, nil).Calculate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if doc.Taxes[1].TaxAmount != 180 || doc.GrandTotal != 1230 {
+		t.Errorf("gst %.2f, grand total %.2f, want 180/1230", doc.Taxes[1].TaxAmount, doc.GrandTotal)
+	}
+}
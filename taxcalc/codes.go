@@ -0,0 +1,37 @@
+package taxcalc
+
+import "github.com/senguttuvang/erpnext-go/errcode"
+
+// Error codes of the taxcalc package. Codes are part of the API: add new
+// ones, never renumber.
+const (
+	CodeNoItems                 = "ACC-TAX-0001"
+	CodeInvalidRowID            = "ACC-TAX-0002"
+	CodeZeroNetTotal            = "ACC-TAX-0003"
+	CodeNegativeQuantity        = "ACC-TAX-0004"
+	CodeInvalidDiscount         = "ACC-TAX-0005"
+	CodeInvalidConversion       = "ACC-TAX-0006"
+	CodeInvariantViolation      = "ACC-TAX-0007"
+	CodeItemNotFound            = "ACC-TAX-0008"
+	CodeUOMConversionNotFound   = "ACC-TAX-0009"
+	CodeItemTaxTemplateNotFound = "ACC-TAX-0010"
+	CodeItemTaxTemplateDisabled = "ACC-TAX-0011"
+	CodeNoChargeWeights         = "ACC-TAX-0012"
+	CodeInvalidRequest          = "ACC-TAX-0013"
+)
+
+func init() {
+	errcode.Register(CodeNoItems, ErrNoItems, "No items to calculate")
+	errcode.Register(CodeInvalidRowID, ErrInvalidRowID, "Tax row refers to an invalid row")
+	errcode.Register(CodeZeroNetTotal, ErrZeroNetTotal, "Actual tax on a zero net total")
+	errcode.Register(CodeNegativeQuantity, ErrNegativeQuantity, "Negative quantity")
+	errcode.Register(CodeInvalidDiscount, ErrInvalidDiscount, "Discount percentage out of range")
+	errcode.Register(CodeInvalidConversion, ErrInvalidConversion, "Conversion rate not positive")
+	errcode.Register(CodeInvariantViolation, ErrInvariantViolation, "Calculation invariant violated")
+	errcode.Register(CodeItemNotFound, ErrItemNotFound, "Item not found")
+	errcode.Register(CodeUOMConversionNotFound, ErrUOMConversionNotFound, "UOM conversion factor not found")
+	errcode.Register(CodeItemTaxTemplateNotFound, ErrItemTaxTemplateNotFound, "Item tax template not found")
+	errcode.Register(CodeItemTaxTemplateDisabled, ErrItemTaxTemplateDisabled, "Item tax template disabled")
+	errcode.Register(CodeNoChargeWeights, ErrNoChargeWeights, "No charge weights for manual distribution")
+	errcode.Register(CodeInvalidRequest, ErrInvalidRequest, "Calculation request could not be read")
+}
@@ -0,0 +1,135 @@
+package taxcalc
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DecisionKind is what a Decision chose.
+type DecisionKind string
+
+// Decision kinds
+const (
+	DecisionTaxRule     DecisionKind = "Tax Rule"
+	DecisionTaxTemplate DecisionKind = "Tax Template"
+)
+
+// Decision records how a resolver chose for a document: what it chose,
+// why, and why every other candidate lost. It answers "why did this
+// invoice get that tax" without re-running the selection against masters
+// that may have changed since.
+type Decision struct {
+	Document   string // Name of the document the choice was made for
+	Kind       DecisionKind
+	Chosen     string // Empty when nothing applied
+	Reason     string
+	Candidates []Candidate // In the order the resolver saw them
+	At         time.Time
+}
+
+// Candidate is one rule or template a resolver considered.
+type Candidate struct {
+	Name     string
+	Matched  bool // Every condition held, whether or not it was chosen
+	Keys     int  // Conditions set, for tax rules that matched
+	Priority int
+	Reason   string // Why it was skipped; empty for the chosen one
+}
+
+// ExplainTaxRule selects a tax rule as SelectTaxRule does and returns the
+// decision behind it. The decision's document is left for the caller.
+func ExplainTaxRule(rules []*TaxRule, args TaxRuleArgs) (*TaxRule, Decision) {
+	best := SelectTaxRule(rules, args)
+	d := Decision{Kind: DecisionTaxRule, Reason: "no tax rule matches"}
+	if best != nil {
+		bestKeys, _ := best.check(args)
+		d.Chosen = best.Name
+		d.Reason = fmt.Sprintf("matches %d conditions with priority %d", bestKeys, best.Priority)
+	}
+	for _, r := range rules {
+		keys, reason := r.check(args)
+		c := Candidate{Name: r.Name, Matched: reason == "", Keys: keys, Priority: r.Priority, Reason: reason}
+		if c.Matched && r != best {
+			bestKeys, _ := best.check(args)
+			switch {
+			case keys < bestKeys:
+				c.Reason = fmt.Sprintf("matches %d conditions, %s matches %d", keys, best.Name, bestKeys)
+			case r.Priority < best.Priority:
+				c.Reason = fmt.Sprintf("priority %d, %s has %d", r.Priority, best.Name, best.Priority)
+			default:
+				c.Reason = "ties with " + best.Name + ", which comes first"
+			}
+		}
+		d.Candidates = append(d.Candidates, c)
+	}
+	return best, d
+}
+
+// ExplainTaxTemplate selects a document tax template as SelectTaxTemplate
+// does and returns the decision behind it.
+func ExplainTaxTemplate(templates []*TaxTemplate, company, taxCategory string) (*TaxTemplate, Decision) {
+	chosen := SelectTaxTemplate(templates, company, taxCategory)
+	d := Decision{Kind: DecisionTaxTemplate, Reason: "no template for the company's tax category and no default"}
+	if chosen != nil {
+		d.Chosen = chosen.Name
+		d.Reason = "company default"
+		if taxCategory != "" && chosen.TaxCategory == taxCategory {
+			d.Reason = fmt.Sprintf("tax category %q", taxCategory)
+		}
+	}
+	byCategory := chosen != nil && taxCategory != "" && chosen.TaxCategory == taxCategory
+	for _, t := range templates {
+		c := Candidate{Name: t.Name}
+		switch {
+		case t.Company != company:
+			c.Reason = fmt.Sprintf("company %s, not %s", t.Company, company)
+		case t.Disabled:
+			c.Reason = "disabled"
+		case t == chosen:
+			c.Matched = true
+		case byCategory && t.TaxCategory == taxCategory:
+			c.Matched, c.Reason = true, chosen.Name+" is preferred within the tax category"
+		case byCategory:
+			c.Reason = fmt.Sprintf("tax category %q, not %q", t.TaxCategory, taxCategory)
+		case t.IsDefault:
+			c.Matched, c.Reason = true, chosen.Name+" is the first default"
+		default:
+			c.Reason = "not the company default"
+		}
+		d.Candidates = append(d.Candidates, c)
+	}
+	return chosen, d
+}
+
+// DecisionLog keeps the decisions made for documents.
+type DecisionLog interface {
+	Record(decisions ...Decision) error
+}
+
+// MemoryDecisionLog is an in-memory DecisionLog.
+type MemoryDecisionLog struct {
+	mu        sync.Mutex
+	decisions map[string][]Decision
+}
+
+// Record implements DecisionLog.
+func (l *MemoryDecisionLog) Record(decisions ...Decision) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.decisions == nil {
+		l.decisions = make(map[string][]Decision)
+	}
+	for _, d := range decisions {
+		l.decisions[d.Document] = append(l.decisions[d.Document], d)
+	}
+	return nil
+}
+
+// Decisions returns the decisions made for a document, in the order they
+// were recorded.
+func (l *MemoryDecisionLog) Decisions(document string) []Decision {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]Decision(nil), l.decisions[document]...)
+}
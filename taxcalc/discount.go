@@ -0,0 +1,137 @@
+package taxcalc
+
+// Values of Document.ApplyDiscountOn
+const (
+	DiscountOnNetTotal   = "Net Total"
+	DiscountOnGrandTotal = "Grand Total"
+)
+
+// setDiscountAmount derives the document discount from the additional
+// discount percentage, if one is set.
+// Maps to: set_discount_amount() in Python
+//
+// Python equivalent:
+//
+//	def set_discount_amount(self):
+//	    if self.doc.additional_discount_percentage:
+//	        self.doc.discount_amount = flt(
+//	            flt(self.doc.get(scrub(self.doc.apply_discount_on)))
+//	            * self.doc.additional_discount_percentage / 100,
+//	            self.doc.precision("discount_amount"))
+func (c *Calculator) setDiscountAmount() {
+	if c.doc.AdditionalDiscountPercentage == 0 {
+		return
+	}
+
+	base := c.doc.GrandTotal
+	if c.doc.ApplyDiscountOn == DiscountOnNetTotal {
+		base = c.doc.NetTotal
+	}
+	c.doc.DiscountAmount = Flt(base*c.doc.AdditionalDiscountPercentage/100, c.precision.GetPrecision("discount_amount"))
+}
+
+// applyDiscountAmount distributes the document discount over the items in
+// proportion to their net amounts and recalculates taxes and totals.
+// Maps to: apply_discount_amount() in Python
+//
+// Python equivalent:
+//
+//	def apply_discount_amount(self):
+//	    if self.doc.discount_amount:
+//	        self.doc.base_discount_amount = flt(self.doc.discount_amount * self.doc.conversion_rate)
+//	        total_for_discount_amount = self.get_total_for_discount_amount()
+//	        if total_for_discount_amount:
+//	            for i, item in enumerate(self._items):
+//	                distributed_amount = flt(self.doc.discount_amount) * item.net_amount / total_for_discount_amount
+//	                item.net_amount = flt(item.net_amount - distributed_amount)
+//	                ...
+//	            self.discount_amount_applied = True
+//	            self._calculate()
+//	    else:
+//	        self.doc.base_discount_amount = 0
+func (c *Calculator) applyDiscountAmount() error {
+	doc := c.doc
+	if doc.DiscountAmount == 0 {
+		doc.BaseDiscountAmount = 0
+		return nil
+	}
+
+	doc.BaseDiscountAmount = Flt(doc.DiscountAmount*doc.ConversionRate, c.precision.GetPrecision("discount_amount"))
+
+	totalForDiscount := c.getTotalForDiscountAmount()
+	if totalForDiscount == 0 {
+		return nil
+	}
+
+	netAmountPrecision := c.precision.GetPrecision("net_amount")
+	netRatePrecision := c.precision.GetPrecision("net_rate")
+	adjustLoss := doc.ApplyDiscountOn == DiscountOnNetTotal || len(doc.Taxes) == 0 || totalForDiscount == doc.NetTotal
+
+	var netTotal float64
+	for i, item := range doc.Items {
+		distributed := doc.DiscountAmount * item.NetAmount / totalForDiscount
+		item.NetAmount = Flt(item.NetAmount-distributed, netAmountPrecision)
+		netTotal += item.NetAmount
+
+		// Discount amount rounding loss goes to the last item
+		if adjustLoss && i == len(doc.Items)-1 {
+			loss := Flt(doc.NetTotal-netTotal-doc.DiscountAmount, c.precision.GetPrecision("net_total"))
+			item.NetAmount = Flt(item.NetAmount+loss, netAmountPrecision)
+		}
+
+		item.NetRate = 0
+		if item.Qty != 0 {
+			item.NetRate = Flt(item.NetAmount/item.Qty, netRatePrecision)
+		}
+		c.setInCompanyCurrency(item)
+	}
+
+	c.discountAmountApplied = true
+	return c.calculate()
+}
+
+// getTotalForDiscountAmount returns the total the discount is distributed
+// over. For a discount on the grand total, fixed (Actual and On Item
+// Quantity) charges and the rows computed from them are excluded, as is
+// the tax On Net Total rows charge on taxable charges.
+// Maps to: get_total_for_discount_amount() in Python
+func (c *Calculator) getTotalForDiscountAmount() float64 {
+	if c.doc.ApplyDiscountOn == DiscountOnNetTotal {
+		return c.doc.NetTotal
+	}
+
+	actualTaxes := make(map[int]float64) // 1-based row index -> amount
+	var sum, taxableCharges float64
+	for i, tax := range c.doc.Taxes {
+		var amount float64
+		switch {
+		case tax.ChargeType == Actual || tax.ChargeType == OnItemQuantity:
+			if tax.Category != Valuation {
+				amount = tax.TaxAmount
+			}
+			if tax.AddDeductTax == Deduct {
+				amount = -amount
+			}
+			if tax.ChargeType == Actual && tax.IncludeInTaxableAmount {
+				taxableCharges += amount
+			}
+		case tax.ChargeType == OnNetTotal && tax.Category != Valuation && taxableCharges != 0:
+			amount = taxableCharges * tax.Rate / 100
+			if tax.AddDeductTax == Deduct {
+				amount = -amount
+			}
+		case tax.RowID > 0:
+			prev, ok := actualTaxes[tax.RowID]
+			if !ok {
+				continue
+			}
+			amount = prev * tax.Rate / 100
+		default:
+			continue
+		}
+		actualTaxes[i+1] = amount
+		sum += amount
+	}
+
+	return Flt(c.doc.GrandTotal-sum, c.precision.GetPrecision("grand_total"))
+}
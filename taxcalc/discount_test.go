@@ -0,0 +1,98 @@
+package taxcalc
+
+import "testing"
+
+func newDiscountDoc(applyOn string) *Document {
+	return &Document{
+		ConversionRate:  1.0,
+		ApplyDiscountOn: applyOn,
+		Items: []*LineItem{
+			{ItemCode: "A", Qty: 2, Rate: 300},
+			{ItemCode: "B", Qty: 1, Rate: 400},
+		},
+		Taxes: []*TaxRow{
+			{AccountHead: "GST", ChargeType: OnNetTotal, Rate: 18},
+		},
+	}
+}
+
+func TestApplyDiscount_NetTotal(t *testing.T) {
+	doc := newDiscountDoc(DiscountOnNetTotal)
+	doc.AdditionalDiscountPercentage = 10
+
+	calc := NewCalculator(doc, nil)
+	if err := calc.Calculate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// 10% of 1000 = 100, split 60/40 by net amount
+	if doc.DiscountAmount != 100 || doc.BaseDiscountAmount != 100 {
+		t.Errorf("discount_amount: got %.2f/%.2f, want 100", doc.DiscountAmount, doc.BaseDiscountAmount)
+	}
+	if doc.Items[0].NetAmount != 540 || doc.Items[1].NetAmount != 360 || doc.Items[0].NetRate != 270 {
+		t.Errorf("net amounts: got %.2f (rate %.2f), %.2f", doc.Items[0].NetAmount, doc.Items[0].NetRate, doc.Items[1].NetAmount)
+	}
+	if doc.Items[0].Amount != 600 {
+		t.Errorf("amount must stay before discount: got %.2f", doc.Items[0].Amount)
+	}
+	if doc.NetTotal != 900 || doc.Taxes[0].TaxAmount != 162 || doc.GrandTotal != 1062 {
+		t.Errorf("totals: net %.2f tax %.2f grand %.2f, want 900/162/1062",
+			doc.NetTotal, doc.Taxes[0].TaxAmount, doc.GrandTotal)
+	}
+	if err := calc.CheckInvariants(); err != nil {
+		t.Errorf("invariants: %v", err)
+	}
+}
+
+func TestApplyDiscount_GrandTotal(t *testing.T) {
+	doc := newDiscountDoc("")
+	doc.DiscountAmount = 118
+
+	calc := NewCalculator(doc, nil)
+	if err := calc.Calculate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// 118 off a grand total of 1180 removes 100 of net and 18 of tax
+	gst := doc.Taxes[0]
+	if doc.NetTotal != 900 || doc.GrandTotal != 1062 {
+		t.Errorf("totals: net %.2f grand %.2f, want 900/1062", doc.NetTotal, doc.GrandTotal)
+	}
+	if gst.TaxAmount != 180 || gst.TaxAmountAfterDiscountAmount != 162 {
+		t.Errorf("tax: %.2f after discount %.2f, want 180/162", gst.TaxAmount, gst.TaxAmountAfterDiscountAmount)
+	}
+	if err := calc.CheckInvariants(); err != nil {
+		t.Errorf("invariants: %v", err)
+	}
+}
+
+func TestApplyDiscount_ExcludesActualCharges(t *testing.T) {
+	doc := newDiscountDoc(DiscountOnGrandTotal)
+	doc.Taxes = append(doc.Taxes, &TaxRow{AccountHead: "Freight", ChargeType: Actual, Rate: 50})
+	doc.DiscountAmount = 118
+
+	if err := NewCalculator(doc, nil).Calculate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Freight is not discounted: 1230 - 118 = 1112
+	if doc.GrandTotal != 1112 || doc.Taxes[1].TaxAmountAfterDiscountAmount != 50 {
+		t.Errorf("grand total %.2f freight %.2f, want 1112/50", doc.GrandTotal, doc.Taxes[1].TaxAmountAfterDiscountAmount)
+	}
+}
+
+func TestApplyDiscount_ExcludesTaxOnTaxableCharges(t *testing.T) {
+	doc := newDiscountDoc(DiscountOnGrandTotal)
+	doc.Taxes = append([]*TaxRow{{AccountHead: "Freight", ChargeType: Actual, Rate: 50, IncludeInTaxableAmount: true}}, doc.Taxes...)
+	doc.DiscountAmount = 118
+
+	if err := NewCalculator(doc, nil).Calculate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Neither the freight nor its 9 of GST is discounted: the items lose
+	// 100 of net and 18 of tax, 1239 - 118 = 1121
+	if doc.NetTotal != 900 || doc.Taxes[1].TaxAmountAfterDiscountAmount != 171 || doc.GrandTotal != 1121 {
+		t.Errorf("net %.2f gst %.2f grand %.2f, want 900/171/1121", doc.NetTotal, doc.Taxes[1].TaxAmountAfterDiscountAmount, doc.GrandTotal)
+	}
+}
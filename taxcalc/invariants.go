@@ -0,0 +1,88 @@
+package taxcalc
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// ErrInvariantViolation indicates that calculated totals are inconsistent.
+var ErrInvariantViolation = errors.New("calculation invariant violated")
+
+// CheckInvariants verifies the internal consistency of a calculated document.
+// It is meant to run after Calculate, in tests, fuzz targets and optionally
+// in production as a cheap safety net before totals are posted.
+//
+// Checked invariants:
+//   - every item's base amounts equal its amounts × conversion rate
+//   - the item-wise tax breakup of each tax row sums to its amount after discount
+//   - each row's running total equals the previous total plus its adjusted tax
+//   - grand total equals net total plus all adjusted taxes
+//   - base totals equal transaction totals × conversion rate
+//
+// Comparisons allow for the rounding of each intermediate value to field
+// precision. All violations are reported, joined into a single error.
+func (c *Calculator) CheckInvariants() error {
+	doc := c.doc
+	amountPrecision := c.precision.GetPrecision("amount")
+	totalPrecision := c.precision.GetPrecision("total")
+	taxPrecision := c.precision.GetPrecision("tax_amount")
+
+	var errs []error
+	check := func(name string, expected, actual, tolerance float64) {
+		// Allow for float error on large values in addition to rounding
+		tolerance += math.Max(math.Abs(expected), math.Abs(actual)) * 1e-12
+		if math.Abs(expected-actual) > tolerance {
+			errs = append(errs, fmt.Errorf("%w: %s is %v, expected %v", ErrInvariantViolation, name, actual, expected))
+		}
+	}
+
+	rate := doc.ConversionRate
+	itemTolerance := halfUnit(amountPrecision)*(1+rate) + 1e-9
+	for i, item := range doc.Items {
+		check(fmt.Sprintf("items[%d].base_amount", i), item.Amount*rate, item.BaseAmount, itemTolerance)
+		check(fmt.Sprintf("items[%d].base_net_amount", i), item.NetAmount*rate, item.BaseNetAmount, itemTolerance)
+	}
+
+	// Sums of individually rounded values drift by up to half a unit each
+	sumTolerance := float64(len(doc.Items)+1) * halfUnit(totalPrecision)
+
+	var netTotal float64
+	for _, item := range doc.Items {
+		netTotal += item.NetAmount
+	}
+	check("net_total", netTotal, doc.NetTotal, sumTolerance)
+
+	var adjustedTaxes float64
+	for i, tax := range doc.Taxes {
+		var itemWise float64
+		for _, amount := range tax.ItemWiseTaxDetail {
+			itemWise += amount
+		}
+		check(fmt.Sprintf("taxes[%d].item_wise_tax_detail", i), tax.TaxAmountAfterDiscountAmount, itemWise, halfUnit(taxPrecision)+1e-9)
+
+		adjusted := c.getAdjustedTaxAmount(tax.TaxAmountAfterDiscountAmount, tax)
+		previous := doc.NetTotal
+		if i > 0 {
+			previous = doc.Taxes[i-1].Total
+		}
+		check(fmt.Sprintf("taxes[%d].total", i), previous+adjusted, tax.Total, halfUnit(totalPrecision)+1e-9)
+
+		adjustedTaxes += adjusted
+	}
+
+	taxTolerance := sumTolerance + float64(len(doc.Taxes))*halfUnit(totalPrecision)
+	check("grand_total", doc.NetTotal+adjustedTaxes, doc.GrandTotal, taxTolerance)
+
+	baseTolerance := (taxTolerance + halfUnit(totalPrecision)) * (1 + rate)
+	check("base_net_total", doc.NetTotal*rate, doc.BaseNetTotal, baseTolerance)
+	check("base_grand_total", doc.GrandTotal*rate, doc.BaseGrandTotal, baseTolerance)
+
+	return errors.Join(errs...)
+}
+
+// halfUnit returns half of the smallest representable step at a precision,
+// the maximum error introduced by rounding once.
+func halfUnit(precision int) float64 {
+	return 0.5 / math.Pow(10, float64(precision))
+}
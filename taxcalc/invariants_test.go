@@ -0,0 +1,115 @@
+package taxcalc
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func newInvariantTestDoc() *Document {
+	return &Document{
+		ConversionRate: 83.5,
+		Items: []*LineItem{
+			{ItemCode: "ITEM-001", PriceListRate: 333.33, DiscountPercentage: 7, Qty: 3},
+			{ItemCode: "ITEM-002", Rate: 19.99, Qty: 7},
+			{ItemCode: "ITEM-001", Rate: 0.01, Qty: 1},
+		},
+		Taxes: []*TaxRow{
+			{AccountHead: "CGST", ChargeType: OnNetTotal, Rate: 9},
+			{AccountHead: "SGST", ChargeType: OnNetTotal, Rate: 9},
+			{AccountHead: "Cess", ChargeType: OnPreviousRowAmount, Rate: 1, RowID: 1},
+			{AccountHead: "Freight", ChargeType: Actual, Rate: 50},
+			{AccountHead: "TDS", ChargeType: OnPreviousRowTotal, Rate: 2, RowID: 4, AddDeductTax: Deduct},
+			{AccountHead: "Customs", ChargeType: OnNetTotal, Rate: 5, Category: Valuation},
+		},
+	}
+}
+
+func TestCheckInvariants(t *testing.T) {
+	doc := newInvariantTestDoc()
+	calc := NewCalculator(doc, nil)
+	if err := calc.Calculate(); err != nil {
+		t.Fatalf("Calculate() error = %v", err)
+	}
+
+	if err := calc.CheckInvariants(); err != nil {
+		t.Errorf("CheckInvariants() error = %v", err)
+	}
+
+	t.Run("item_wise_detail_sums_duplicate_items", func(t *testing.T) {
+		detail := doc.Taxes[0].ItemWiseTaxDetail
+		if len(detail) != 2 {
+			t.Errorf("Expected 2 item codes in breakup, got %d", len(detail))
+		}
+	})
+
+	t.Run("detects_tampered_grand_total", func(t *testing.T) {
+		doc.GrandTotal += 1
+		err := calc.CheckInvariants()
+		if !errors.Is(err, ErrInvariantViolation) {
+			t.Errorf("Expected ErrInvariantViolation, got %v", err)
+		}
+		doc.GrandTotal -= 1
+	})
+
+	t.Run("detects_tampered_tax_breakup", func(t *testing.T) {
+		doc.Taxes[1].ItemWiseTaxDetail["ITEM-002"] += 0.1
+		if err := calc.CheckInvariants(); !errors.Is(err, ErrInvariantViolation) {
+			t.Errorf("Expected ErrInvariantViolation, got %v", err)
+		}
+	})
+}
+
+// FuzzCalculate feeds random documents through the calculator and requires
+// every successfully calculated document to satisfy CheckInvariants.
+//
+// Run with: go test -fuzz=FuzzCalculate ./taxcalc
+func FuzzCalculate(f *testing.F) {
+	f.Add(100.0, 5.0, 10.0, 18.0, 50.0, 1.0, uint8(0))
+	f.Add(333.33, 3.0, 7.0, 9.0, 0.0, 83.5, uint8(3))
+	f.Add(0.01, 1000.0, 0.0, 12.5, 99.99, 0.012, uint8(7))
+	f.Add(19.99, 0.0, 100.0, 5.0, 10.0, 1.0, uint8(12))
+
+	chargeTypes := []ChargeType{Actual, OnNetTotal, OnPreviousRowAmount, OnPreviousRowTotal, OnItemQuantity}
+
+	f.Fuzz(func(t *testing.T, rate, qty, discount, taxRate, actual, conversion float64, shape uint8) {
+		for _, v := range []float64{rate, qty, discount, taxRate, actual, conversion} {
+			if math.IsNaN(v) || math.IsInf(v, 0) || math.Abs(v) > 1e9 {
+				t.Skip()
+			}
+		}
+
+		doc := &Document{
+			ConversionRate: conversion,
+			Items: []*LineItem{
+				{ItemCode: "A", PriceListRate: math.Abs(rate), DiscountPercentage: discount, Qty: qty},
+				{ItemCode: "B", Rate: math.Abs(rate) / 3, Qty: qty / 2},
+			},
+			Taxes: []*TaxRow{
+				{AccountHead: "Tax 1", ChargeType: OnNetTotal, Rate: taxRate},
+				{AccountHead: "Tax 2", ChargeType: chargeTypes[int(shape)%len(chargeTypes)], Rate: actual, RowID: 1},
+				{AccountHead: "Tax 3", ChargeType: chargeTypes[int(shape>>3)%len(chargeTypes)], Rate: taxRate / 2, RowID: 2},
+			},
+		}
+		if shape&0x40 != 0 {
+			doc.Taxes[2].AddDeductTax = Deduct
+		}
+		if shape&0x80 != 0 {
+			doc.Taxes[1].Category = Valuation
+		}
+		if shape&0x20 != 0 {
+			doc.AdditionalDiscountPercentage = math.Mod(math.Abs(discount), 100)
+			if shape&0x04 != 0 {
+				doc.ApplyDiscountOn = DiscountOnNetTotal
+			}
+		}
+
+		calc := NewCalculator(doc, nil)
+		if err := calc.Calculate(); err != nil {
+			return
+		}
+		if err := calc.CheckInvariants(); err != nil {
+			t.Errorf("invariants violated: %v", err)
+		}
+	})
+}
@@ -0,0 +1,118 @@
+package taxcalc
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Item master errors
+var (
+	ErrItemNotFound          = errors.New("item not found")
+	ErrUOMConversionNotFound = errors.New("UOM conversion factor not found")
+)
+
+// ItemDefaults holds the Item master values that a transaction row inherits.
+// Maps to: the item_defaults child table and the fields returned by
+// get_item_details() in erpnext/stock/get_item_details.py
+type ItemDefaults struct {
+	ItemCode    string
+	Description string
+	StockUOM    string
+	HSNCode     string // gst_hsn_code
+	IsStockItem bool
+
+	// Per-company defaults (Item Default child table)
+	IncomeAccount  string
+	ExpenseAccount string
+	CostCenter     string
+
+	// Item Tax: the applicable template and its account -> rate rows
+	ItemTaxTemplate string
+	ItemTaxRates    map[string]float64
+
+	// Item Tax rows by tax category, resolved by ApplyItemTaxTemplates
+	Taxes []ItemTax
+
+	// UOM Conversion Detail: uom -> factor to stock UOM
+	UOMConversions map[string]float64
+}
+
+// ItemMaster abstracts queries for Item master data.
+// Production implementations query Item, Item Default and Item Tax.
+//
+// Maps to: get_item_details() in erpnext/stock/get_item_details.py
+type ItemMaster interface {
+	// GetItemDefaults returns the defaults of an item for a company.
+	GetItemDefaults(itemCode, company string) (*ItemDefaults, error)
+}
+
+// ApplyItemDefaults fills the empty fields of every item row from the Item
+// master: description, accounts, cost center, HSN code, UOM conversion and
+// the item tax rate map. Values already set on a row are kept, so callers
+// can still override defaults per transaction.
+//
+// Maps to: set_missing_item_details() in controllers/accounts_controller.py
+func ApplyItemDefaults(doc *Document, company string, master ItemMaster) error {
+	for _, item := range doc.Items {
+		defaults, err := master.GetItemDefaults(item.ItemCode, company)
+		if err != nil {
+			return fmt.Errorf("item %s: %w", item.ItemCode, err)
+		}
+		if err := applyItemDefaults(item, defaults); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyItemDefaults copies defaults into a single row.
+func applyItemDefaults(item *LineItem, d *ItemDefaults) error {
+	setIfEmpty(&item.Description, d.Description)
+	setIfEmpty(&item.IncomeAccount, d.IncomeAccount)
+	setIfEmpty(&item.ExpenseAccount, d.ExpenseAccount)
+	setIfEmpty(&item.CostCenter, d.CostCenter)
+	setIfEmpty(&item.HSNCode, d.HSNCode)
+	setIfEmpty(&item.StockUOM, d.StockUOM)
+	setIfEmpty(&item.UOM, d.StockUOM)
+	item.IsStockItem = d.IsStockItem
+
+	if item.ConversionFactor == 0 {
+		factor, err := d.ConversionFactor(item.UOM)
+		if err != nil {
+			return err
+		}
+		item.ConversionFactor = factor
+	}
+	item.StockQty = item.Qty * item.ConversionFactor
+
+	// A template overridden on the row is resolved by ApplyItemTaxTemplates
+	if item.ItemTaxRate == "" && item.ItemTaxMap == nil && item.ItemTaxTemplateOverride == "" && len(d.ItemTaxRates) > 0 {
+		item.ItemTaxMap = make(map[string]float64, len(d.ItemTaxRates))
+		for account, rate := range d.ItemTaxRates {
+			item.ItemTaxMap[account] = rate
+		}
+		item.ItemTaxTemplate = d.ItemTaxTemplate
+		item.ItemTaxSource = ItemTaxFromItem
+	}
+
+	return nil
+}
+
+// ConversionFactor returns the factor converting uom into the stock UOM.
+//
+// Maps to: get_conversion_factor() in erpnext/stock/get_item_details.py
+func (d *ItemDefaults) ConversionFactor(uom string) (float64, error) {
+	if uom == "" || uom == d.StockUOM {
+		return 1.0, nil
+	}
+	if factor, ok := d.UOMConversions[uom]; ok && factor > 0 {
+		return factor, nil
+	}
+	return 0, fmt.Errorf("%w: %s to %s for item %s", ErrUOMConversionNotFound, uom, d.StockUOM, d.ItemCode)
+}
+
+func setIfEmpty(field *string, value string) {
+	if *field == "" {
+		*field = value
+	}
+}
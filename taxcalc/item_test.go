@@ -0,0 +1,92 @@
+package taxcalc
+
+import (
+	"errors"
+	"testing"
+)
+
+// mockItemMaster serves item defaults keyed by item code.
+type mockItemMaster map[string]*ItemDefaults
+
+func (m mockItemMaster) GetItemDefaults(itemCode, company string) (*ItemDefaults, error) {
+	d, ok := m[itemCode]
+	if !ok {
+		return nil, ErrItemNotFound
+	}
+	return d, nil
+}
+
+var testItems = mockItemMaster{
+	"WIDGET": {
+		ItemCode:      "WIDGET",
+		Description:   "Widget",
+		StockUOM:      "Nos",
+		HSNCode:       "8471",
+		IncomeAccount: "Sales - ACME",
+		UOMConversions: map[string]float64{
+			"Box": 12,
+		},
+	},
+	"BOOK": {
+		ItemCode:        "BOOK",
+		StockUOM:        "Nos",
+		IncomeAccount:   "Sales - ACME",
+		ItemTaxTemplate: "GST Exempt - ACME",
+		ItemTaxRates:    map[string]float64{"GST Account": 0},
+	},
+}
+
+func TestApplyItemDefaults(t *testing.T) {
+	doc := &Document{
+		ConversionRate: 1.0,
+		Items: []*LineItem{
+			{ItemCode: "WIDGET", Qty: 2, UOM: "Box", PriceListRate: 100},
+			{ItemCode: "BOOK", Qty: 1, PriceListRate: 100, IncomeAccount: "Book Sales - ACME"},
+		},
+		Taxes: []*TaxRow{
+			{AccountHead: "GST Account", ChargeType: OnNetTotal, Rate: 18},
+		},
+	}
+
+	if err := ApplyItemDefaults(doc, "ACME", testItems); err != nil {
+		t.Fatalf("ApplyItemDefaults() error = %v", err)
+	}
+
+	widget, book := doc.Items[0], doc.Items[1]
+	if widget.IncomeAccount != "Sales - ACME" || widget.HSNCode != "8471" || widget.Description != "Widget" {
+		t.Errorf("widget defaults not applied: %+v", widget)
+	}
+	if widget.ConversionFactor != 12 || widget.StockQty != 24 || widget.StockUOM != "Nos" {
+		t.Errorf("widget stock qty = %.2f %s (factor %.2f), want 24 Nos (factor 12)",
+			widget.StockQty, widget.StockUOM, widget.ConversionFactor)
+	}
+	if book.IncomeAccount != "Book Sales - ACME" {
+		t.Errorf("explicit income account overwritten: %s", book.IncomeAccount)
+	}
+	if book.UOM != "Nos" || book.ConversionFactor != 1 {
+		t.Errorf("book UOM = %s (factor %.2f), want Nos (factor 1)", book.UOM, book.ConversionFactor)
+	}
+	if book.ItemTaxTemplate != "GST Exempt - ACME" {
+		t.Errorf("book item tax template = %q", book.ItemTaxTemplate)
+	}
+
+	// The generated item tax rate must be honoured by the calculator
+	if err := NewCalculator(doc, nil).Calculate(); err != nil {
+		t.Fatalf("Calculate() error = %v", err)
+	}
+	if !almostEqual(doc.Taxes[0].TaxAmount, 36.0, 0.01) {
+		t.Errorf("tax_amount: got %.2f, want %.2f", doc.Taxes[0].TaxAmount, 36.0)
+	}
+}
+
+func TestApplyItemDefaults_Errors(t *testing.T) {
+	doc := &Document{Items: []*LineItem{{ItemCode: "UNKNOWN", Qty: 1}}}
+	if err := ApplyItemDefaults(doc, "ACME", testItems); !errors.Is(err, ErrItemNotFound) {
+		t.Errorf("Expected ErrItemNotFound, got %v", err)
+	}
+
+	doc = &Document{Items: []*LineItem{{ItemCode: "WIDGET", Qty: 1, UOM: "Pallet"}}}
+	if err := ApplyItemDefaults(doc, "ACME", testItems); !errors.Is(err, ErrUOMConversionNotFound) {
+		t.Errorf("Expected ErrUOMConversionNotFound, got %v", err)
+	}
+}
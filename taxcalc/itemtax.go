@@ -0,0 +1,279 @@
+package taxcalc
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/daterange"
+)
+
+// Item tax template errors
+var (
+	ErrItemTaxTemplateNotFound = errors.New("item tax template not found")
+	ErrItemTaxTemplateDisabled = errors.New("item tax template is disabled")
+	ErrItemTaxTemplateNotValid = errors.New("item tax template is not valid for the row")
+)
+
+// ItemTaxSource records where a row's item tax template was chosen.
+type ItemTaxSource string
+
+const (
+	// ItemTaxFromItem - Selected from the Item Tax rows of the item master
+	ItemTaxFromItem ItemTaxSource = "Item"
+	// ItemTaxFromOverride - Chosen on the row when the document was entered
+	ItemTaxFromOverride ItemTaxSource = "Override"
+)
+
+// ItemTaxTemplate is a named set of tax rates applied to specific items.
+// Maps to: erpnext/accounts/doctype/item_tax_template/item_tax_template.json
+type ItemTaxTemplate struct {
+	Name     string
+	Title    string
+	Company  string
+	Disabled bool
+	Taxes    []ItemTaxTemplateDetail
+}
+
+// ItemTaxTemplateDetail is one account -> rate row of a template.
+// Maps to: Item Tax Template Detail child table
+type ItemTaxTemplateDetail struct {
+	TaxType string  // Tax account head
+	TaxRate float64 // Rate that replaces the tax row's rate for the item
+}
+
+// TaxMap returns the template as the account -> rate map used by the
+// calculator. Rows for the same account are summed, as in ERPNext.
+//
+// Maps to: get_item_tax_map() in erpnext/stock/get_item_details.py
+func (t *ItemTaxTemplate) TaxMap() map[string]float64 {
+	taxMap := make(map[string]float64, len(t.Taxes))
+	for _, d := range t.Taxes {
+		taxMap[d.TaxType] += d.TaxRate
+	}
+	return taxMap
+}
+
+// ItemTax links an item (or item group) to a template under conditions.
+// Maps to: Item Tax child table on Item and Item Group
+type ItemTax struct {
+	ItemTaxTemplate string
+	TaxCategory     string     // Empty matches transactions without a category
+	ValidFrom       *time.Time // Nil means always valid
+	MinimumNetRate  float64
+	MaximumNetRate  float64 // Zero disables the net rate range
+}
+
+// ItemTaxTemplateLookup abstracts queries for Item Tax Templates.
+type ItemTaxTemplateLookup interface {
+	// GetItemTaxTemplate returns a template by name.
+	GetItemTaxTemplate(name string) (*ItemTaxTemplate, error)
+}
+
+// ItemTaxContext carries the transaction values a template is selected by.
+type ItemTaxContext struct {
+	Company         string
+	TransactionDate time.Time // Posting date, or bill date for purchases
+	TaxCategory     string
+	NetRate         float64
+	Current         string // Template already on the row, kept if still valid
+}
+
+// inNetRateRange reports whether the net rate falls inside the row's range.
+//
+// Maps to: is_within_valid_range() in erpnext/stock/get_item_details.py
+func (t ItemTax) inNetRateRange(netRate float64) bool {
+	if t.MaximumNetRate == 0 {
+		return true
+	}
+	return t.MinimumNetRate <= netRate && netRate <= t.MaximumNetRate
+}
+
+// SelectItemTaxTemplate picks the template that applies to a transaction.
+// Rows of another company are ignored. Rows with a validity date or net rate
+// range win over unconditional rows when their conditions hold, newest
+// valid_from first. Among the candidates, the current template is kept if
+// present; otherwise the first whose tax category matches is returned.
+// An empty name means no template applies.
+//
+// Maps to: _get_item_tax_template() in erpnext/stock/get_item_details.py
+func SelectItemTaxTemplate(taxes []ItemTax, ctx ItemTaxContext, lookup ItemTaxTemplateLookup) (string, error) {
+	var withValidity, withoutValidity []ItemTax
+
+	for _, tax := range taxes {
+		template, err := lookup.GetItemTaxTemplate(tax.ItemTaxTemplate)
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", tax.ItemTaxTemplate, err)
+		}
+		if template.Company != ctx.Company || template.Disabled {
+			continue
+		}
+
+		if tax.ValidFrom != nil || tax.MaximumNetRate != 0 {
+			validFrom := time.Time{}
+			if tax.ValidFrom != nil {
+				validFrom = daterange.Civil(*tax.ValidFrom)
+			}
+			if !validFrom.After(daterange.Civil(ctx.TransactionDate)) && tax.inNetRateRange(ctx.NetRate) {
+				withValidity = append(withValidity, tax)
+			}
+		} else {
+			withoutValidity = append(withoutValidity, tax)
+		}
+	}
+
+	candidates := withoutValidity
+	if len(withValidity) > 0 {
+		candidates = withValidity
+		sort.SliceStable(candidates, func(i, j int) bool {
+			return validFromOf(candidates[i]).After(validFromOf(candidates[j]))
+		})
+	}
+
+	if ctx.Current != "" {
+		for _, tax := range candidates {
+			if tax.ItemTaxTemplate == ctx.Current {
+				return ctx.Current, nil
+			}
+		}
+	}
+
+	for _, tax := range candidates {
+		if tax.TaxCategory == ctx.TaxCategory {
+			return tax.ItemTaxTemplate, nil
+		}
+	}
+
+	return "", nil
+}
+
+// ResolveItemTaxMap selects the applicable template and returns its name and
+// account -> rate map. A nil map means no template applies and the tax rows'
+// own rates are used.
+func ResolveItemTaxMap(taxes []ItemTax, ctx ItemTaxContext, lookup ItemTaxTemplateLookup) (string, map[string]float64, error) {
+	name, err := SelectItemTaxTemplate(taxes, ctx, lookup)
+	if err != nil || name == "" {
+		return "", nil, err
+	}
+
+	template, err := lookup.GetItemTaxTemplate(name)
+	if err != nil {
+		return "", nil, fmt.Errorf("%s: %w", name, err)
+	}
+	if template.Disabled {
+		return "", nil, fmt.Errorf("%w: %s", ErrItemTaxTemplateDisabled, name)
+	}
+
+	return name, template.TaxMap(), nil
+}
+
+// ResolveItemTaxOverride checks a template chosen on a row and returns its
+// account -> rate map. The template must be enabled and belong to the
+// company. When the item's Item Tax rows list it, one of them must hold
+// for the transaction: valid from on or before its date, with the row's
+// net rate inside the range, so an override cannot apply a rate before it
+// takes effect or outside the price band it is capped to. Templates the
+// item does not list are accepted as they are.
+//
+// Maps to: an item_tax_template set by hand on a transaction row, held to
+// the conditions _get_item_tax_template() in get_item_details.py applies
+func ResolveItemTaxOverride(name string, taxes []ItemTax, ctx ItemTaxContext, lookup ItemTaxTemplateLookup) (map[string]float64, error) {
+	template, err := lookup.GetItemTaxTemplate(name)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	if template.Disabled {
+		return nil, fmt.Errorf("%w: %s", ErrItemTaxTemplateDisabled, name)
+	}
+	if template.Company != ctx.Company {
+		return nil, fmt.Errorf("%w: %s belongs to %s, not %s", ErrItemTaxTemplateNotValid, name, template.Company, ctx.Company)
+	}
+
+	listed, valid := false, false
+	for _, tax := range taxes {
+		if tax.ItemTaxTemplate != name {
+			continue
+		}
+		listed = true
+		if !daterange.Civil(validFromOf(tax)).After(daterange.Civil(ctx.TransactionDate)) && tax.inNetRateRange(ctx.NetRate) {
+			valid = true
+			break
+		}
+	}
+	if listed && !valid {
+		return nil, fmt.Errorf("%w: %s on %s at net rate %.2f", ErrItemTaxTemplateNotValid, name, daterange.Civil(ctx.TransactionDate).Format(time.DateOnly), ctx.NetRate)
+	}
+
+	return template.TaxMap(), nil
+}
+
+func validFromOf(t ItemTax) time.Time {
+	if t.ValidFrom == nil {
+		return time.Time{}
+	}
+	return *t.ValidFrom
+}
+
+// ApplyItemTaxTemplates resolves the item tax template of every row for the
+// document's tax category and replaces the row's item tax map with it. Rows
+// of items without Item Tax rows are left unchanged; rows whose item has no
+// template for the category are cleared so the document rates apply. A row
+// with an ItemTaxTemplateOverride gets that template instead, checked by
+// ResolveItemTaxOverride, whatever the item master holds.
+//
+// ctx supplies the company and transaction date; the tax category comes from
+// the document and the net rate from each row (its rate before calculation).
+// The row's current template is not preserved, since it may belong to the
+// previous category.
+//
+// Maps to: the item_tax_template refresh done by set_missing_item_details()
+// when the tax category changes
+func ApplyItemTaxTemplates(doc *Document, ctx ItemTaxContext, master ItemMaster, templates ItemTaxTemplateLookup) error {
+	ctx.TaxCategory = doc.TaxCategory
+
+	for _, item := range doc.Items {
+		defaults, err := master.GetItemDefaults(item.ItemCode, ctx.Company)
+		if err != nil {
+			return fmt.Errorf("item %s: %w", item.ItemCode, err)
+		}
+		rowCtx := ctx
+		rowCtx.NetRate = item.NetRate
+		if rowCtx.NetRate == 0 {
+			rowCtx.NetRate = item.Rate
+		}
+		rowCtx.Current = ""
+
+		if item.ItemTaxTemplateOverride != "" {
+			taxMap, err := ResolveItemTaxOverride(item.ItemTaxTemplateOverride, defaults.Taxes, rowCtx, templates)
+			if err != nil {
+				return fmt.Errorf("item %s: %w", item.ItemCode, err)
+			}
+			item.ItemTaxTemplate = item.ItemTaxTemplateOverride
+			item.ItemTaxSource = ItemTaxFromOverride
+			item.ItemTaxMap = taxMap
+			item.ItemTaxRate = ""
+			continue
+		}
+		if len(defaults.Taxes) == 0 {
+			continue
+		}
+
+		name, taxMap, err := ResolveItemTaxMap(defaults.Taxes, rowCtx, templates)
+		if err != nil {
+			return fmt.Errorf("item %s: %w", item.ItemCode, err)
+		}
+		if taxMap == nil {
+			taxMap = map[string]float64{}
+		}
+		item.ItemTaxTemplate = name
+		item.ItemTaxSource = ""
+		if name != "" {
+			item.ItemTaxSource = ItemTaxFromItem
+		}
+		item.ItemTaxMap = taxMap
+		item.ItemTaxRate = ""
+	}
+
+	return nil
+}
@@ -0,0 +1,221 @@
+package taxcalc
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// mockTemplates serves item tax templates keyed by name.
+type mockTemplates map[string]*ItemTaxTemplate
+
+func (m mockTemplates) GetItemTaxTemplate(name string) (*ItemTaxTemplate, error) {
+	t, ok := m[name]
+	if !ok {
+		return nil, ErrItemTaxTemplateNotFound
+	}
+	return t, nil
+}
+
+var testTemplates = mockTemplates{
+	"GST 5%": {Name: "GST 5%", Company: "ACME", Taxes: []ItemTaxTemplateDetail{
+		{TaxType: "CGST - ACME", TaxRate: 2.5}, {TaxType: "SGST - ACME", TaxRate: 2.5},
+	}},
+	"GST 12%": {Name: "GST 12%", Company: "ACME", Taxes: []ItemTaxTemplateDetail{
+		{TaxType: "CGST - ACME", TaxRate: 6}, {TaxType: "SGST - ACME", TaxRate: 6},
+	}},
+	"IGST 12%": {Name: "IGST 12%", Company: "ACME", Taxes: []ItemTaxTemplateDetail{
+		{TaxType: "IGST - ACME", TaxRate: 12},
+	}},
+	"Other Co": {Name: "Other Co", Company: "OTHER"},
+}
+
+func TestSelectItemTaxTemplate(t *testing.T) {
+	jul2024 := time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)
+
+	// Apparel: 5% up to ₹1000, 12% above, from July 2024; IGST for out of state
+	taxes := []ItemTax{
+		{ItemTaxTemplate: "Other Co"},
+		{ItemTaxTemplate: "GST 12%"},
+		{ItemTaxTemplate: "IGST 12%", TaxCategory: "Out-State"},
+		{ItemTaxTemplate: "GST 5%", ValidFrom: &jul2024, MaximumNetRate: 1000},
+		{ItemTaxTemplate: "GST 12%", ValidFrom: &jul2024, MinimumNetRate: 1000.01, MaximumNetRate: 1e9},
+	}
+
+	tests := []struct {
+		name string
+		ctx  ItemTaxContext
+		want string
+	}{
+		{"before validity uses unconditional row",
+			ItemTaxContext{Company: "ACME", TransactionDate: time.Date(2024, 6, 30, 0, 0, 0, 0, time.UTC), NetRate: 500}, "GST 12%"},
+		{"tax category on unconditional rows",
+			ItemTaxContext{Company: "ACME", TransactionDate: time.Date(2024, 6, 30, 0, 0, 0, 0, time.UTC), TaxCategory: "Out-State"}, "IGST 12%"},
+		{"low net rate",
+			ItemTaxContext{Company: "ACME", TransactionDate: jul2024.Add(15 * time.Hour), NetRate: 800}, "GST 5%"},
+		{"high net rate",
+			ItemTaxContext{Company: "ACME", TransactionDate: jul2024, NetRate: 1500}, "GST 12%"},
+		{"current template kept while valid",
+			ItemTaxContext{Company: "ACME", TransactionDate: jul2024, NetRate: 800, Current: "GST 5%"}, "GST 5%"},
+		{"other company has no template",
+			ItemTaxContext{Company: "OTHER", TransactionDate: jul2024, TaxCategory: "Export"}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SelectItemTaxTemplate(taxes, tt.ctx, testTemplates)
+			if err != nil {
+				t.Fatalf("SelectItemTaxTemplate() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("SelectItemTaxTemplate() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveItemTaxMap(t *testing.T) {
+	taxes := []ItemTax{{ItemTaxTemplate: "GST 5%"}}
+	name, taxMap, err := ResolveItemTaxMap(taxes, ItemTaxContext{Company: "ACME"}, testTemplates)
+	if err != nil {
+		t.Fatalf("ResolveItemTaxMap() error = %v", err)
+	}
+	if name != "GST 5%" || taxMap["CGST - ACME"] != 2.5 || taxMap["SGST - ACME"] != 2.5 {
+		t.Errorf("ResolveItemTaxMap() = %q %v", name, taxMap)
+	}
+
+	// The resolved map drives the calculator without any JSON
+	doc := &Document{
+		ConversionRate: 1.0,
+		Items: []*LineItem{
+			{ItemCode: "SHIRT", Qty: 1, PriceListRate: 800, ItemTaxMap: taxMap, ItemTaxTemplate: name},
+		},
+		Taxes: []*TaxRow{
+			{AccountHead: "CGST - ACME", ChargeType: OnNetTotal, Rate: 9},
+			{AccountHead: "SGST - ACME", ChargeType: OnNetTotal, Rate: 9},
+		},
+	}
+	if err := NewCalculator(doc, nil).Calculate(); err != nil {
+		t.Fatalf("Calculate() error = %v", err)
+	}
+	if !almostEqual(doc.GrandTotal-doc.NetTotal, 40, 0.01) {
+		t.Errorf("total taxes: got %.2f, want 40.00", doc.GrandTotal-doc.NetTotal)
+	}
+
+	if _, _, err := ResolveItemTaxMap([]ItemTax{{ItemTaxTemplate: "Missing"}}, ItemTaxContext{Company: "ACME"}, testTemplates); !errors.Is(err, ErrItemTaxTemplateNotFound) {
+		t.Errorf("Expected ErrItemTaxTemplateNotFound, got %v", err)
+	}
+}
+
+func TestApplyItemTaxTemplates(t *testing.T) {
+	master := mockItemMaster{
+		"SHIRT": {ItemCode: "SHIRT", Taxes: []ItemTax{
+			{ItemTaxTemplate: "GST 5%"},
+			{ItemTaxTemplate: "IGST 12%", TaxCategory: "Out-State"},
+		}},
+		"SERVICE": {ItemCode: "SERVICE"},
+	}
+	doc := &Document{
+		TaxCategory: "Out-State",
+		Items: []*LineItem{
+			{ItemCode: "SHIRT", Qty: 1, Rate: 800, ItemTaxTemplate: "GST 5%", ItemTaxRate: `{"CGST - ACME": 2.5}`},
+			{ItemCode: "SERVICE", Qty: 1, Rate: 100, ItemTaxRate: `{"IGST - ACME": 18}`},
+		},
+	}
+	ctx := ItemTaxContext{Company: "ACME", TransactionDate: time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)}
+
+	if err := ApplyItemTaxTemplates(doc, ctx, master, testTemplates); err != nil {
+		t.Fatalf("ApplyItemTaxTemplates() error = %v", err)
+	}
+
+	shirt, service := doc.Items[0], doc.Items[1]
+	if shirt.ItemTaxTemplate != "IGST 12%" || shirt.ItemTaxMap["IGST - ACME"] != 12 || shirt.ItemTaxRate != "" {
+		t.Errorf("shirt = %q %v %q, want IGST 12%%", shirt.ItemTaxTemplate, shirt.ItemTaxMap, shirt.ItemTaxRate)
+	}
+	if service.ItemTaxRate != `{"IGST - ACME": 18}` || service.ItemTaxMap != nil {
+		t.Errorf("row without Item Tax rows should be unchanged: %+v", service)
+	}
+
+	// Without a matching template the row falls back to document rates
+	doc.TaxCategory = "Export"
+	if err := ApplyItemTaxTemplates(doc, ctx, master, testTemplates); err != nil {
+		t.Fatalf("ApplyItemTaxTemplates() error = %v", err)
+	}
+	if shirt.ItemTaxTemplate != "" || len(shirt.ItemTaxMap) != 0 || shirt.ItemTaxMap == nil {
+		t.Errorf("shirt = %q %v, want cleared", shirt.ItemTaxTemplate, shirt.ItemTaxMap)
+	}
+}
+
+func TestItemTaxTemplateOverride(t *testing.T) {
+	jul2024 := time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)
+	master := mockItemMaster{
+		"SHIRT": {ItemCode: "SHIRT", Taxes: []ItemTax{
+			{ItemTaxTemplate: "GST 12%"},
+			{ItemTaxTemplate: "GST 5%", ValidFrom: &jul2024, MaximumNetRate: 1000},
+		}},
+		"SERVICE": {ItemCode: "SERVICE"},
+	}
+	doc := &Document{
+		ConversionRate: 1.0,
+		Items: []*LineItem{
+			{ItemCode: "SHIRT", Qty: 1, Rate: 800, ItemTaxTemplateOverride: "GST 5%"},
+			{ItemCode: "SERVICE", Qty: 1, Rate: 100, ItemTaxTemplateOverride: "GST 12%"}, // Not listed on the item
+			{ItemCode: "SHIRT", Qty: 1, Rate: 1200},
+		},
+		Taxes: []*TaxRow{
+			{AccountHead: "CGST - ACME", ChargeType: OnNetTotal, Rate: 9},
+			{AccountHead: "SGST - ACME", ChargeType: OnNetTotal, Rate: 9},
+		},
+	}
+	ctx := ItemTaxContext{Company: "ACME", TransactionDate: jul2024}
+
+	if err := ApplyItemTaxTemplates(doc, ctx, master, testTemplates); err != nil {
+		t.Fatalf("ApplyItemTaxTemplates() error = %v", err)
+	}
+	for i, want := range []struct {
+		template string
+		source   ItemTaxSource
+	}{{"GST 5%", ItemTaxFromOverride}, {"GST 12%", ItemTaxFromOverride}, {"GST 12%", ItemTaxFromItem}} {
+		if item := doc.Items[i]; item.ItemTaxTemplate != want.template || item.ItemTaxSource != want.source {
+			t.Errorf("row %d = %q from %q, want %q from %q", i, item.ItemTaxTemplate, item.ItemTaxSource, want.template, want.source)
+		}
+	}
+
+	calc := NewCalculator(doc, nil)
+	if err := calc.Calculate(); err != nil {
+		t.Fatalf("Calculate() error = %v", err)
+	}
+	trace := calc.ItemTaxTrace()
+	if len(trace) != 3 || trace[0].Source != ItemTaxFromOverride || len(trace[0].Taxes) != 2 {
+		t.Fatalf("ItemTaxTrace() = %+v", trace)
+	}
+	if line := trace[0].Taxes[0]; line.AccountHead != "CGST - ACME" || line.Rate != 2.5 || !line.ItemRate {
+		t.Errorf("SHIRT CGST = %+v, want 2.5%% from the template", line)
+	}
+	if line := trace[1].Taxes[1]; line.Rate != 6 || line.TaxAmount != 6 {
+		t.Errorf("SERVICE SGST = %+v, want 6%% of 100", line)
+	}
+
+	// The override is held to the validity and net rate cap of the item
+	tests := []struct {
+		name     string
+		override string
+		rate     float64
+		on       time.Time
+		want     error
+	}{
+		{"above the net rate cap", "GST 5%", 1500, jul2024, ErrItemTaxTemplateNotValid},
+		{"before valid from", "GST 5%", 800, jul2024.AddDate(0, 0, -1), ErrItemTaxTemplateNotValid},
+		{"other company", "Other Co", 800, jul2024, ErrItemTaxTemplateNotValid},
+		{"missing", "GST 28%", 800, jul2024, ErrItemTaxTemplateNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := &Document{Items: []*LineItem{{ItemCode: "SHIRT", Qty: 1, Rate: tt.rate, ItemTaxTemplateOverride: tt.override}}}
+			err := ApplyItemTaxTemplates(doc, ItemTaxContext{Company: "ACME", TransactionDate: tt.on}, master, testTemplates)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("ApplyItemTaxTemplates() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
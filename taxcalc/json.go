@@ -0,0 +1,69 @@
+package taxcalc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/senguttuvang/erpnext-go/errcode"
+)
+
+// ErrInvalidRequest is returned by CalculateJSON for input it cannot read.
+var ErrInvalidRequest = errors.New("invalid calculation request")
+
+// CalculationRequest is the input of CalculateJSON.
+type CalculationRequest struct {
+	Document  *Document
+	Precision PrecisionMap // Optional; DefaultPrecision for the fields it leaves out
+}
+
+// CalculationResponse is the output of CalculateJSON: the calculated
+// document, or the error and its code.
+type CalculationResponse struct {
+	Document *Document `json:",omitempty"`
+	Error    string    `json:",omitempty"`
+	Code     string    `json:",omitempty"` // errcode code of the error
+}
+
+// PrecisionMap sets the decimal places of fields by name.
+type PrecisionMap map[string]int
+
+// GetPrecision implements PrecisionProvider.
+func (p PrecisionMap) GetPrecision(fieldName string) int {
+	if n, ok := p[fieldName]; ok {
+		return n
+	}
+	return DefaultPrecision{}.GetPrecision(fieldName)
+}
+
+// CalculateJSON calculates a document given as a JSON CalculationRequest
+// and returns a JSON CalculationResponse. It is the calculator's boundary
+// for callers outside Go, such as a browser running the WebAssembly build
+// in taxcalc/wasm, which then computes the same totals as the server. It
+// never fails: errors are reported in the response.
+func CalculateJSON(input []byte) []byte {
+	var req CalculationRequest
+	resp := CalculationResponse{}
+	err := json.Unmarshal(input, &req)
+	switch {
+	case err != nil:
+		err = fmt.Errorf("%w: %v", ErrInvalidRequest, err)
+	case req.Document == nil:
+		err = fmt.Errorf("%w: no document", ErrInvalidRequest)
+	default:
+		var precision PrecisionProvider
+		if req.Precision != nil {
+			precision = req.Precision
+		}
+		err = NewCalculator(req.Document, precision).Calculate()
+		resp.Document = req.Document
+	}
+	if err != nil {
+		resp.Document, resp.Error, resp.Code = nil, err.Error(), errcode.Of(err)
+	}
+	out, err := json.Marshal(resp)
+	if err != nil {
+		out, _ = json.Marshal(CalculationResponse{Error: err.Error()})
+	}
+	return out
+}
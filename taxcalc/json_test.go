@@ -0,0 +1,41 @@
+package taxcalc
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestCalculateJSON(t *testing.T) {
+	want := cart(3)
+	if err := NewCalculator(want, nil).Calculate(); err != nil {
+		t.Fatal(err)
+	}
+	input, _ := json.Marshal(CalculationRequest{Document: cart(3)})
+	var resp CalculationResponse
+	if err := json.Unmarshal(CalculateJSON(input), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Error != "" || resp.Document.GrandTotal != want.GrandTotal || resp.Document.RoundedTotal != want.RoundedTotal {
+		t.Errorf("CalculateJSON() = %+v, want grand total %v", resp, want.GrandTotal)
+	}
+
+	// Precision is the caller's to set
+	input, _ = json.Marshal(CalculationRequest{Document: cart(3), Precision: PrecisionMap{"tax_amount": 0}})
+	json.Unmarshal(CalculateJSON(input), &resp)
+	if tax := resp.Document.Taxes[0].TaxAmount; tax != Round(tax, 0) {
+		t.Errorf("tax amount at precision 0 = %v", tax)
+	}
+
+	for input, code := range map[string]string{
+		`{"Document": `:                          CodeInvalidRequest,
+		`{}`:                                     CodeInvalidRequest,
+		`{"Document": {"Items": []}}`:            CodeNoItems,
+		`{"Document": {"Items": [{"Qty": -1}]}}`: CodeNegativeQuantity,
+	} {
+		resp = CalculationResponse{}
+		json.Unmarshal(CalculateJSON([]byte(input)), &resp)
+		if resp.Code != code || resp.Error == "" || resp.Document != nil {
+			t.Errorf("CalculateJSON(%s) = %+v, want code %s", input, resp, code)
+		}
+	}
+}
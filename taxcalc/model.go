@@ -9,7 +9,10 @@ package taxcalc
 
 import (
 	"encoding/json"
-	"math"
+	"maps"
+
+	"github.com/senguttuvang/erpnext-go/custom"
+	"github.com/senguttuvang/erpnext-go/numeric"
 )
 
 // ChargeType defines how tax is calculated.
@@ -33,8 +36,9 @@ const (
 type TaxCategory string
 
 const (
-	Total     TaxCategory = "Total"
-	Valuation TaxCategory = "Valuation"
+	Total             TaxCategory = "Total"
+	Valuation         TaxCategory = "Valuation"
+	ValuationAndTotal TaxCategory = "Valuation and Total"
 )
 
 // AddDeduct defines whether tax is added or deducted.
@@ -45,6 +49,23 @@ const (
 	Deduct AddDeduct = "Deduct"
 )
 
+// TaxRounding is the point at which tax amounts are rounded. Jurisdictions
+// differ: some require the tax of every invoice line to be rounded, others
+// only the tax total of the invoice.
+type TaxRounding string
+
+const (
+	// RoundPerTaxRow - Round each tax row once, after adding up the
+	// unrounded tax of its items (the default)
+	RoundPerTaxRow TaxRounding = "Tax Row"
+	// RoundPerItem - Round the tax of each item before adding it up
+	// Maps to: round_row_wise_tax in Accounts Settings
+	RoundPerItem TaxRounding = "Item Row"
+	// RoundPerDocument - Round the tax total of the document once; the
+	// last tax row absorbs the difference from rounding each row
+	RoundPerDocument TaxRounding = "Document"
+)
+
 // LineItem represents a single item in an invoice/order.
 // Maps to: Sales Invoice Item, Purchase Invoice Item, etc.
 type LineItem struct {
@@ -53,6 +74,11 @@ type LineItem struct {
 	Qty         float64 // Quantity
 	UOM         string  // Unit of measure
 
+	// Stock units (filled from the Item master)
+	StockUOM         string  // Item's stock unit of measure
+	ConversionFactor float64 // UOM -> stock UOM
+	StockQty         float64 // Qty * ConversionFactor
+
 	// Pricing
 	PriceListRate      float64 // Original price from price list
 	DiscountPercentage float64 // Discount as percentage (0-100)
@@ -71,8 +97,31 @@ type LineItem struct {
 	BaseNetAmount float64
 
 	// Tax info
-	ItemTaxRate   string  // JSON map of account -> rate
-	ItemTaxAmount float64 // Total tax for this item
+	ItemTaxRate     string             // JSON map of account -> rate
+	ItemTaxMap      map[string]float64 // Resolved account -> rate; takes precedence over ItemTaxRate
+	ItemTaxAmount   float64            // Total tax for this item
+	ItemTaxTemplate string             // Item Tax Template the rates came from
+	ItemTaxSource   ItemTaxSource      // Where ItemTaxTemplate was chosen
+
+	// ItemTaxTemplateOverride is an Item Tax Template chosen on the row
+	// when the document is entered; ApplyItemTaxTemplates applies it in
+	// place of the item master's.
+	ItemTaxTemplateOverride string
+	HSNCode         string             // HSN/SAC classification code
+
+	// Valuation (purchase documents, set by AllocateValuationCharges)
+	IsStockItem        bool    // Only stock items absorb valuation charges
+	ChargeWeight       float64 // Weight for manual charge distribution
+	ValuationTaxAmount float64 // Share of valuation charges (item_tax_amount)
+	ValuationRate      float64 // Landed cost per stock unit, company currency
+
+	// Accounting (used by GL builders, ignored by the calculator)
+	IncomeAccount   string // Income account for sales documents
+	ExpenseAccount  string // Expense account for purchase documents
+	DiscountAccount string // Receives the item discount of a sales document under discount accounting
+	CostCenter      string
+
+	Custom custom.Fields // Custom fields; the item's GL entries take them over the document's
 }
 
 // TaxRow represents a single tax/charge line.
@@ -85,6 +134,13 @@ type TaxRow struct {
 	RowID       int        // Reference to previous row (1-indexed, for OnPreviousRow*)
 	Category    TaxCategory
 	AddDeductTax AddDeduct
+	CostCenter  string     // Cost center for the tax GL entry
+
+	// IncludeInTaxableAmount makes an Actual charge, such as freight,
+	// itself taxable: its share of each item is added to the amount the
+	// On Net Total rows after it are calculated on. Ignored on other
+	// charge types.
+	IncludeInTaxableAmount bool
 
 	// Calculated values
 	TaxAmount                     float64 // Total tax amount
@@ -98,6 +154,10 @@ type TaxRow struct {
 	TaxFractionForCurrentItem    float64
 	GrandTotalFractionForCurrentItem float64
 
+	// Item-wise breakup: item code -> tax amount (before rounding)
+	// Maps to: item_wise_tax_detail in ERPNext
+	ItemWiseTaxDetail map[string]float64
+
 	// Base currency
 	BaseTaxAmount                    float64
 	BaseTaxAmountAfterDiscountAmount float64
@@ -110,6 +170,7 @@ type Document struct {
 	// Currency
 	Currency       string  // Transaction currency
 	ConversionRate float64 // Exchange rate to company currency
+	TaxCategory    string  // Selects item and document tax templates
 
 	// Items
 	Items []*LineItem
@@ -118,9 +179,10 @@ type Document struct {
 	Taxes []*TaxRow
 
 	// Discount
-	DiscountAmount               float64
+	DiscountAmount               float64 // Document-level (additional) discount
+	BaseDiscountAmount           float64
 	AdditionalDiscountPercentage float64
-	ApplyDiscountOn              string // "Net Total" or "Grand Total"
+	ApplyDiscountOn              string // "Net Total" or "Grand Total" (default)
 
 	// Totals
 	TotalQty     float64 // Sum of item quantities
@@ -132,10 +194,15 @@ type Document struct {
 	BaseGrandTotal float64
 
 	// Rounding
+	CashRounding           float64     // Fraction to round the grand total to (e.g. 0.05); zero disables rounding
+	CashRoundingMode       RoundingMode // Direction of cash rounding; to the nearest when empty
+	TaxRounding            TaxRounding // RoundPerTaxRow when empty
 	RoundingAdjustment     float64
 	BaseRoundingAdjustment float64
 	RoundedTotal           float64
 	BaseRoundedTotal       float64
+
+	Custom custom.Fields // Custom fields, copied to every GL entry of the document
 }
 
 // PrecisionProvider defines precision settings for calculations.
@@ -172,17 +239,44 @@ func ParseItemTaxRate(itemTaxRate string) (map[string]float64, error) {
 	return result, err
 }
 
-// Round rounds a value to the specified precision.
+// TaxMap returns the item's account -> rate overrides, preferring the
+// resolved ItemTaxMap over the legacy JSON string.
+func (item *LineItem) TaxMap() (map[string]float64, error) {
+	if item.ItemTaxMap != nil {
+		return item.ItemTaxMap, nil
+	}
+	return ParseItemTaxRate(item.ItemTaxRate)
+}
+
+// Copy returns a deep copy of the document, its items and tax rows,
+// custom fields included.
+func (d *Document) Copy() Document {
+	c := *d
+	c.Custom = d.Custom.Clone()
+	c.Items = make([]*LineItem, len(d.Items))
+	for i, item := range d.Items {
+		copied := *item
+		copied.ItemTaxMap = maps.Clone(item.ItemTaxMap)
+		copied.Custom = item.Custom.Clone()
+		c.Items[i] = &copied
+	}
+	c.Taxes = make([]*TaxRow, len(d.Taxes))
+	for i, tax := range d.Taxes {
+		copied := *tax
+		copied.ItemWiseTaxDetail = maps.Clone(tax.ItemWiseTaxDetail)
+		c.Taxes[i] = &copied
+	}
+	return c
+}
+
+// Round rounds a value to the specified precision, halves away from zero,
+// as numeric.Round does.
 func Round(value float64, precision int) float64 {
-	multiplier := math.Pow(10, float64(precision))
-	return math.Round(value*multiplier) / multiplier
+	return numeric.Round(value, precision)
 }
 
 // Flt converts to float and optionally rounds.
 // Maps to: frappe.utils.flt() in Python
 func Flt(value float64, precision ...int) float64 {
-	if len(precision) > 0 {
-		return Round(value, precision[0])
-	}
-	return value
+	return numeric.Flt(value, precision...)
 }
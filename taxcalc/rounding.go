@@ -0,0 +1,131 @@
+package taxcalc
+
+import "math"
+
+// RoundToFraction rounds value to a multiple of fraction, e.g. 0.05 for
+// cash rounding in currencies without small coins. As in ERPNext, a value
+// exactly halfway between two multiples rounds down.
+// A zero fraction rounds to a whole number, as ERPNext does for currencies
+// without a smallest fraction.
+//
+// Maps to: round_based_on_smallest_currency_fraction() in
+// erpnext/controllers/taxes_and_totals.py
+//
+// Python equivalent:
+//
+//	def round_based_on_smallest_currency_fraction(value, currency, precision=2):
+//	    if smallest_currency_fraction_value:
+//	        remainder_val = remainder(value, smallest_currency_fraction_value, precision)
+//	        if remainder_val > (smallest_currency_fraction_value / 2):
+//	            value += smallest_currency_fraction_value - remainder_val
+//	        else:
+//	            value -= remainder_val
+//	    else:
+//	        value = rounded(value)
+//	    return flt(value, precision)
+func RoundToFraction(value, fraction float64, precision int) float64 {
+	if fraction <= 0 {
+		return Flt(math.Round(value), precision)
+	}
+
+	// Python's % takes the sign of the divisor, so the remainder of a
+	// negative value is still positive
+	multiplier := math.Pow(10, float64(precision))
+	rem := math.Mod(value*multiplier, fraction*multiplier) / multiplier
+	if rem < 0 {
+		rem += fraction
+	}
+	rem = Flt(rem, precision)
+
+	if rem > fraction/2 {
+		value += fraction - rem
+	} else {
+		value -= rem
+	}
+	return Flt(value, precision)
+}
+
+// RoundingMode is the direction a total is rounded in.
+type RoundingMode string
+
+const (
+	// RoundNearest - To the nearest multiple, halves down (the default)
+	RoundNearest RoundingMode = "Nearest"
+	// RoundUp - Away from zero, to the next multiple
+	RoundUp RoundingMode = "Up"
+	// RoundDown - Toward zero, so the customer never pays more
+	RoundDown RoundingMode = "Down"
+)
+
+// RoundToFractionMode is RoundToFraction in a direction. Up and down are
+// by size, so a return's negative total rounds as its invoice would.
+func RoundToFractionMode(value, fraction float64, mode RoundingMode, precision int) float64 {
+	if mode != RoundUp && mode != RoundDown {
+		return RoundToFraction(value, fraction, precision)
+	}
+	if fraction <= 0 {
+		fraction = 1
+	}
+
+	// Multiples are counted on the rounded quotient, so that a value
+	// already on a multiple is not moved by float error
+	multiples := Flt(math.Abs(value)/fraction, 9)
+	if mode == RoundUp {
+		multiples = math.Ceil(multiples)
+	} else {
+		multiples = math.Floor(multiples)
+	}
+	return Flt(math.Copysign(multiples*fraction, value), precision)
+}
+
+// CurrencyRounding is how customer-facing totals in a currency are
+// rounded: to the coins in use, such as 0.05 CHF, or to a round price,
+// such as 100 IDR.
+type CurrencyRounding struct {
+	Currency string
+	Fraction float64      // Multiple the grand total is rounded to
+	Mode     RoundingMode // To the nearest when empty
+}
+
+// CurrencyRoundings is the rounding configured for each transaction
+// currency.
+//
+// Maps to: Currency.smallest_currency_fraction_value, with a direction
+// ERPNext does not have
+type CurrencyRoundings []CurrencyRounding
+
+// Apply sets the cash rounding of a document that does not set its own
+// from the rounding of its currency. Apply it before a country's rounding,
+// which applies to every currency alike.
+func (r CurrencyRoundings) Apply(doc *Document) {
+	if doc.CashRounding != 0 {
+		return
+	}
+	for _, cr := range r {
+		if cr.Currency == doc.Currency {
+			doc.CashRounding, doc.CashRoundingMode = cr.Fraction, cr.Mode
+			return
+		}
+	}
+}
+
+// calculateRoundedTotal rounds the grand total to the document's cash
+// rounding fraction, in its direction, and records the difference as
+// rounding adjustment. The rounding is in the transaction currency; the
+// base adjustment is converted from it, and sales invoices post it to
+// their round-off account.
+// Maps to: the rounded_total block of calculate_totals() in Python
+func (c *Calculator) calculateRoundedTotal() {
+	doc := c.doc
+	if doc.CashRounding <= 0 {
+		doc.RoundedTotal, doc.BaseRoundedTotal = 0, 0
+		doc.RoundingAdjustment, doc.BaseRoundingAdjustment = 0, 0
+		return
+	}
+
+	precision := c.precision.GetPrecision("rounded_total")
+	doc.RoundedTotal = RoundToFractionMode(doc.GrandTotal, doc.CashRounding, doc.CashRoundingMode, precision)
+	doc.RoundingAdjustment = Flt(doc.RoundedTotal-doc.GrandTotal, precision)
+	doc.BaseRoundedTotal = Flt(doc.RoundedTotal*doc.ConversionRate, precision)
+	doc.BaseRoundingAdjustment = Flt(doc.RoundingAdjustment*doc.ConversionRate, precision)
+}
@@ -0,0 +1,141 @@
+package taxcalc
+
+import "testing"
+
+func TestRoundToFraction(t *testing.T) {
+	tests := []struct {
+		value, fraction, want float64
+	}{
+		{21.51, 0.05, 21.50},
+		{21.53, 0.05, 21.55},
+		{10.25, 0.50, 10.00}, // exact half rounds down
+		{-21.53, 0.05, -21.55},
+		{99.99, 0.10, 100.00},
+		{1234.49, 0, 1234},
+		{1234.50, 0, 1235},
+		{0.03, 0.05, 0.05},
+	}
+
+	for _, tt := range tests {
+		if got := RoundToFraction(tt.value, tt.fraction, 2); got != tt.want {
+			t.Errorf("RoundToFraction(%v, %v) = %v, want %v", tt.value, tt.fraction, got, tt.want)
+		}
+	}
+}
+
+func TestCalculate_CashRounding(t *testing.T) {
+	doc := &Document{
+		ConversionRate: 2.0,
+		CashRounding:   0.05,
+		Items:          []*LineItem{{ItemCode: "COFFEE", Qty: 1, Rate: 19.97}},
+		Taxes:          []*TaxRow{{AccountHead: "VAT", ChargeType: OnNetTotal, Rate: 7.7}},
+	}
+	if err := NewCalculator(doc, nil).Calculate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if doc.GrandTotal != 21.51 || doc.RoundedTotal != 21.50 || doc.RoundingAdjustment != -0.01 {
+		t.Errorf("grand %.2f rounded %.2f adjustment %.2f, want 21.51/21.50/-0.01",
+			doc.GrandTotal, doc.RoundedTotal, doc.RoundingAdjustment)
+	}
+	if doc.BaseRoundedTotal != 43 || doc.BaseRoundingAdjustment != -0.02 {
+		t.Errorf("base rounded %.2f adjustment %.2f, want 43.00/-0.02", doc.BaseRoundedTotal, doc.BaseRoundingAdjustment)
+	}
+}
+
+func TestTaxRounding(t *testing.T) {
+	tests := []struct {
+		mode      TaxRounding
+		items     []float64 // Rates of single units
+		taxes     []float64 // Percentages on net total
+		wantTaxes []float64
+		wantGrand float64
+	}{
+		// Three lines of 0.10 at 7.5%: 0.0075 tax each
+		{RoundPerTaxRow, []float64{0.10, 0.10, 0.10}, []float64{7.5}, []float64{0.02}, 0.32},
+		{RoundPerItem, []float64{0.10, 0.10, 0.10}, []float64{7.5}, []float64{0.03}, 0.33},
+		{RoundPerDocument, []float64{0.10, 0.10, 0.10}, []float64{7.5}, []float64{0.02}, 0.32},
+
+		// Central and state tax of 9% each on 1.05: 0.0945 each, 0.189 together
+		{"", []float64{1.05}, []float64{9, 9}, []float64{0.09, 0.09}, 1.23},
+		{RoundPerDocument, []float64{1.05}, []float64{9, 9}, []float64{0.09, 0.10}, 1.24},
+	}
+	for _, tt := range tests {
+		doc := &Document{ConversionRate: 1, TaxRounding: tt.mode}
+		for i, rate := range tt.items {
+			doc.Items = append(doc.Items, &LineItem{ItemCode: string(rune('A' + i)), Qty: 1, Rate: rate})
+		}
+		for _, rate := range tt.taxes {
+			doc.Taxes = append(doc.Taxes, &TaxRow{AccountHead: "Tax", ChargeType: OnNetTotal, Rate: rate})
+		}
+		calc := NewCalculator(doc, nil)
+		if err := calc.Calculate(); err != nil {
+			t.Fatal(err)
+		}
+		for i, want := range tt.wantTaxes {
+			if got := doc.Taxes[i].TaxAmount; !almostEqual(got, want, 1e-9) {
+				t.Errorf("%q %v: tax %d = %v, want %v", tt.mode, tt.taxes, i, got, want)
+			}
+		}
+		if !almostEqual(doc.GrandTotal, tt.wantGrand, 1e-9) {
+			t.Errorf("%q %v: grand total = %v, want %v", tt.mode, tt.taxes, doc.GrandTotal, tt.wantGrand)
+		}
+		if err := calc.CheckInvariants(); err != nil {
+			t.Errorf("%q %v: %v", tt.mode, tt.taxes, err)
+		}
+	}
+}
+
+func TestRoundToFractionMode(t *testing.T) {
+	tests := []struct {
+		value, fraction float64
+		mode            RoundingMode
+		want            float64
+	}{
+		{21.51, 0.05, RoundUp, 21.55},
+		{21.51, 0.05, RoundDown, 21.50},
+		{21.55, 0.05, RoundUp, 21.55}, // Already a multiple
+		{-21.51, 0.05, RoundDown, -21.50},
+		{154_321, 100, RoundDown, 154_300},
+		{154_321, 100, RoundUp, 154_400},
+		{154_321, 100, "", 154_300},
+		{10.2, 0, RoundUp, 11},
+	}
+	for _, tt := range tests {
+		if got := RoundToFractionMode(tt.value, tt.fraction, tt.mode, 2); got != tt.want {
+			t.Errorf("RoundToFractionMode(%v, %v, %q) = %v, want %v", tt.value, tt.fraction, tt.mode, got, tt.want)
+		}
+	}
+}
+
+func TestCurrencyRoundings(t *testing.T) {
+	roundings := CurrencyRoundings{
+		{Currency: "CHF", Fraction: 0.05},
+		{Currency: "IDR", Fraction: 100, Mode: RoundDown},
+	}
+	doc := &Document{
+		Currency:       "IDR",
+		ConversionRate: 0.0055,
+		Items:          []*LineItem{{ItemCode: "NASI", Qty: 3, Rate: 46_350}},
+		Taxes:          []*TaxRow{{AccountHead: "PPN", ChargeType: OnNetTotal, Rate: 11}},
+	}
+	roundings.Apply(doc)
+	if err := NewCalculator(doc, nil).Calculate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// 139,050 + 15,295.50 tax = 154,345.50, rounded down to 154,300
+	if doc.GrandTotal != 154_345.5 || doc.RoundedTotal != 154_300 || doc.RoundingAdjustment != -45.5 || doc.BaseRoundingAdjustment != -0.25 {
+		t.Errorf("grand %.2f rounded %.2f adjustment %.2f (base %.2f), want 154345.50/154300.00/-45.50/-0.25",
+			doc.GrandTotal, doc.RoundedTotal, doc.RoundingAdjustment, doc.BaseRoundingAdjustment)
+	}
+
+	// A document's own rounding and other currencies are left alone
+	own := &Document{Currency: "CHF", CashRounding: 0.10}
+	roundings.Apply(own)
+	other := &Document{Currency: "EUR"}
+	roundings.Apply(other)
+	if own.CashRounding != 0.10 || other.CashRounding != 0 {
+		t.Errorf("cash rounding = %v, %v", own.CashRounding, other.CashRounding)
+	}
+}
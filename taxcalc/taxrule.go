@@ -0,0 +1,160 @@
+package taxcalc
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Tax rule types
+const (
+	TaxRuleSales    = "Sales"
+	TaxRulePurchase = "Purchase"
+)
+
+// TaxRule picks a tax template from a transaction's party, territory and
+// addresses. Empty condition fields match anything; group and territory
+// conditions also match everything below them in their trees.
+//
+// Maps to: erpnext/accounts/doctype/tax_rule/tax_rule.json
+type TaxRule struct {
+	Name    string
+	TaxType string // TaxRuleSales or TaxRulePurchase
+	Company string
+
+	Customer        string
+	CustomerGroup   string
+	Supplier        string
+	SupplierGroup   string
+	Territory       string
+	BillingCountry  string
+	ShippingCountry string
+	TaxCategory     string // Must equal the transaction's, empty included
+
+	FromDate *time.Time // Nil means no lower bound
+	ToDate   *time.Time // Nil means no upper bound
+	Priority int        // Higher wins among rules matching as many fields
+
+	TaxTemplate string // Sales or purchase taxes and charges template
+}
+
+// TaxRuleArgs describes a transaction for tax rule matching. The group and
+// territory lists hold the transaction's node followed by its ancestors,
+// as returned by territory.Tree.Ancestors.
+type TaxRuleArgs struct {
+	TaxType string
+	Company string
+	Date    time.Time
+
+	Customer        string
+	CustomerGroups  []string
+	Supplier        string
+	SupplierGroups  []string
+	Territories     []string
+	BillingCountry  string
+	ShippingCountry string
+	TaxCategory     string
+}
+
+// matches reports whether every condition of the rule holds, and how many
+// conditions the rule sets.
+func (r *TaxRule) matches(args TaxRuleArgs) (bool, int) {
+	keys, reason := r.check(args)
+	return reason == "", keys
+}
+
+// check returns how many conditions the rule sets, and the first one that
+// does not hold for the transaction, described for the decision log. An
+// empty reason means the rule matches.
+func (r *TaxRule) check(args TaxRuleArgs) (int, string) {
+	switch {
+	case r.TaxType != args.TaxType:
+		return 0, fmt.Sprintf("tax type %s, not %s", r.TaxType, args.TaxType)
+	case r.Company != "" && r.Company != args.Company:
+		return 0, fmt.Sprintf("company %s, not %s", r.Company, args.Company)
+	case r.TaxCategory != args.TaxCategory:
+		return 0, fmt.Sprintf("tax category %q, not %q", r.TaxCategory, args.TaxCategory)
+	}
+	if !args.Date.IsZero() && ((r.FromDate != nil && args.Date.Before(*r.FromDate)) || (r.ToDate != nil && args.Date.After(*r.ToDate))) {
+		return 0, "not valid on " + args.Date.Format(time.DateOnly)
+	}
+
+	keys := 0
+	conditions := []struct {
+		field string
+		rule  string
+		tx    []string
+	}{
+		{"customer", r.Customer, []string{args.Customer}},
+		{"customer group", r.CustomerGroup, args.CustomerGroups},
+		{"supplier", r.Supplier, []string{args.Supplier}},
+		{"supplier group", r.SupplierGroup, args.SupplierGroups},
+		{"territory", r.Territory, args.Territories},
+		{"billing country", r.BillingCountry, []string{args.BillingCountry}},
+		{"shipping country", r.ShippingCountry, []string{args.ShippingCountry}},
+	}
+	for _, c := range conditions {
+		if c.rule == "" {
+			continue
+		}
+		if !slices.Contains(c.tx, c.rule) {
+			return 0, fmt.Sprintf("%s %s, not %s", c.field, c.rule, cmp.Or(strings.Join(c.tx, " < "), `""`))
+		}
+		keys++
+	}
+	return keys, ""
+}
+
+// SelectTaxRule returns the rule that applies to a transaction: among the
+// matching rules, the one setting the most conditions, then the one with
+// the highest priority, then the first. Nil means no rule applies.
+//
+// Maps to: get_tax_template() in erpnext/accounts/doctype/tax_rule/tax_rule.py
+//
+// Python equivalent:
+//
+//	tax_rule = frappe.db.sql("select * from `tabTax Rule` where {}".format(" and ".join(conditions)))
+//	for rule in tax_rule:
+//	    rule.no_of_keys_matched = 0
+//	    for key in args:
+//	        if rule.get(key):
+//	            rule.no_of_keys_matched += 1
+//	rule = sorted(tax_rule, key=functools.cmp_to_key(lambda b, a:
+//	    cmp(a.no_of_keys_matched, b.no_of_keys_matched) or cmp(a.priority, b.priority)))[0]
+func SelectTaxRule(rules []*TaxRule, args TaxRuleArgs) *TaxRule {
+	var best *TaxRule
+	bestKeys := -1
+	for _, r := range rules {
+		ok, keys := r.matches(args)
+		if !ok {
+			continue
+		}
+		if keys > bestKeys || (keys == bestKeys && r.Priority > best.Priority) {
+			best, bestKeys = r, keys
+		}
+	}
+	return best
+}
+
+// Template returns the rule's template when it exists and is enabled.
+func (r *TaxRule) Template(templates []*TaxTemplate) *TaxTemplate {
+	for _, t := range templates {
+		if t.Name == r.TaxTemplate && !t.Disabled {
+			return t
+		}
+	}
+	return nil
+}
+
+// TaxTemplateByRule returns the template of the rule that applies to a
+// transaction. Nil means no rule applies or its template is missing or
+// disabled, in which case SelectTaxTemplate's defaults apply.
+func TaxTemplateByRule(rules []*TaxRule, args TaxRuleArgs, templates []*TaxTemplate) *TaxTemplate {
+	rule := SelectTaxRule(rules, args)
+	if rule == nil {
+		return nil
+	}
+	return rule.Template(templates)
+}
@@ -0,0 +1,129 @@
+package taxcalc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/territory"
+)
+
+func TestSelectTaxRuleByTerritory(t *testing.T) {
+	tree, err := territory.NewTree(
+		territory.Territory{Name: "All Territories"},
+		territory.Territory{Name: "India", Parent: "All Territories"},
+		territory.Territory{Name: "Rest Of The World", Parent: "All Territories", TaxCategory: "Export"},
+		territory.Territory{Name: "Germany", Parent: "Rest Of The World"},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	until := time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC)
+	rules := []*TaxRule{
+		{Name: "Domestic", TaxType: TaxRuleSales, Company: "ACME", Priority: 1, TaxTemplate: "GST 18%"},
+		{Name: "Export", TaxType: TaxRuleSales, Company: "ACME", Territory: "Rest Of The World", TaxCategory: "Export", TaxTemplate: "Zero Rated"},
+		{Name: "Old Export", TaxType: TaxRuleSales, Company: "ACME", Territory: "Rest Of The World", TaxCategory: "Export", Priority: 5, ToDate: &until, TaxTemplate: "Legacy Export"},
+		{Name: "Wholesale", TaxType: TaxRuleSales, Company: "ACME", CustomerGroup: "Commercial", Territory: "India", TaxTemplate: "GST Wholesale"},
+		{Name: "Inputs", TaxType: TaxRulePurchase, Company: "ACME", TaxTemplate: "Input GST"},
+	}
+	templates := []*TaxTemplate{
+		{Name: "GST 18%", Company: "ACME"},
+		{Name: "Zero Rated", Company: "ACME", TaxCategory: "Export"},
+		{Name: "GST Wholesale", Company: "ACME", Disabled: true},
+	}
+
+	args := func(terr string, groups ...string) TaxRuleArgs {
+		return TaxRuleArgs{
+			TaxType: TaxRuleSales, Company: "ACME", Date: time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC),
+			Territories: tree.Ancestors(terr), TaxCategory: tree.TaxCategory(terr), CustomerGroups: groups,
+		}
+	}
+	tests := []struct {
+		name string
+		args TaxRuleArgs
+		want string
+	}{
+		{"export territory is zero-rated", args("Germany"), "Export"},
+		{"domestic falls back to the priority rule", args("India"), "Domestic"},
+		{"more conditions win over priority", args("India", "Commercial", "All Customer Groups"), "Wholesale"},
+	}
+	for _, tt := range tests {
+		got := SelectTaxRule(rules, tt.args)
+		if got == nil || got.Name != tt.want {
+			t.Errorf("%s: got %+v, want %s", tt.name, got, tt.want)
+		}
+	}
+
+	old := args("Germany")
+	old.Date = time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	if got := SelectTaxRule(rules, old); got == nil || got.Name != "Old Export" {
+		t.Errorf("dated rule: got %+v", got)
+	}
+
+	if tmpl := TaxTemplateByRule(rules, args("Germany"), templates); tmpl == nil || tmpl.Name != "Zero Rated" {
+		t.Errorf("TaxTemplateByRule(Germany) = %+v", tmpl)
+	}
+	if tmpl := TaxTemplateByRule(rules, args("India", "Commercial"), templates); tmpl != nil {
+		t.Errorf("disabled template selected: %+v", tmpl)
+	}
+	purchase := TaxRuleArgs{TaxType: TaxRulePurchase, Company: "Other"}
+	if got := SelectTaxRule(rules, purchase); got != nil {
+		t.Errorf("other company matched %+v", got)
+	}
+}
+
+func TestExplainTaxRule(t *testing.T) {
+	rules := []*TaxRule{
+		{Name: "Domestic", TaxType: TaxRuleSales, Company: "ACME", Priority: 1, TaxTemplate: "GST 18%"},
+		{Name: "Globex", TaxType: TaxRuleSales, Company: "ACME", Customer: "Globex", TaxTemplate: "GST Globex"},
+		{Name: "Fallback", TaxType: TaxRuleSales, Company: "ACME", TaxTemplate: "GST 18%"},
+		{Name: "Export", TaxType: TaxRuleSales, Company: "ACME", TaxCategory: "Export", TaxTemplate: "Zero Rated"},
+		{Name: "Germany", TaxType: TaxRuleSales, Company: "ACME", Territory: "Germany", TaxTemplate: "Zero Rated"},
+	}
+	args := TaxRuleArgs{TaxType: TaxRuleSales, Company: "ACME", Customer: "Initech", Territories: []string{"India", "All Territories"}}
+
+	rule, d := ExplainTaxRule(rules, args)
+	if rule != SelectTaxRule(rules, args) || d.Kind != DecisionTaxRule || d.Chosen != "Domestic" || len(d.Candidates) != len(rules) {
+		t.Fatalf("ExplainTaxRule() = %v, %+v", rule, d)
+	}
+	want := map[string]string{
+		"Domestic": "",
+		"Globex":   "customer Globex, not Initech",
+		"Fallback": "priority 0, Domestic has 1",
+		"Export":   `tax category "Export", not ""`,
+		"Germany":  "territory Germany, not India < All Territories",
+	}
+	for _, c := range d.Candidates {
+		if c.Reason != want[c.Name] {
+			t.Errorf("%s: reason %q, want %q", c.Name, c.Reason, want[c.Name])
+		}
+	}
+
+	args.Customer = "Globex"
+	if _, d := ExplainTaxRule(rules, args); d.Chosen != "Globex" || d.Candidates[0].Reason != "matches 0 conditions, Globex matches 1" {
+		t.Errorf("more conditions: %+v", d)
+	}
+	args.TaxType = TaxRulePurchase
+	if rule, d := ExplainTaxRule(rules, args); rule != nil || d.Chosen != "" || d.Candidates[0].Reason != "tax type Sales, not Purchase" {
+		t.Errorf("no rule: %v, %+v", rule, d)
+	}
+
+	templates := []*TaxTemplate{
+		{Name: "IGST", Company: "ACME", IsDefault: true},
+		{Name: "GST In-State", Company: "ACME", TaxCategory: "In-State"},
+		{Name: "Old GST", Company: "ACME", TaxCategory: "In-State", Disabled: true},
+		{Name: "VAT", Company: "Globex", IsDefault: true},
+	}
+	tmpl, d := ExplainTaxTemplate(templates, "ACME", "In-State")
+	if tmpl == nil || tmpl.Name != "GST In-State" || d.Reason != `tax category "In-State"` {
+		t.Fatalf("ExplainTaxTemplate() = %v, %+v", tmpl, d)
+	}
+	reasons := []string{`tax category "", not "In-State"`, "", "disabled", "company Globex, not ACME"}
+	for i, c := range d.Candidates {
+		if c.Reason != reasons[i] {
+			t.Errorf("%s: reason %q, want %q", c.Name, c.Reason, reasons[i])
+		}
+	}
+	if tmpl, d := ExplainTaxTemplate(templates, "ACME", "Export"); tmpl == nil || tmpl.Name != "IGST" || d.Reason != "company default" {
+		t.Errorf("default: %v, %+v", tmpl, d)
+	}
+}
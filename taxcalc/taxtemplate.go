@@ -0,0 +1,64 @@
+package taxcalc
+
+// TaxTemplate is a reusable set of document tax rows.
+// Maps to: Sales Taxes and Charges Template, Purchase Taxes and Charges Template
+type TaxTemplate struct {
+	Name        string
+	Title       string
+	Company     string
+	TaxCategory string
+	IsDefault   bool
+	Disabled    bool
+	Taxes       []TaxRow
+}
+
+// Rows returns fresh copies of the template's tax rows for a document.
+// Calculated fields are reset so the rows can be fed to the Calculator.
+func (t *TaxTemplate) Rows() []*TaxRow {
+	rows := make([]*TaxRow, len(t.Taxes))
+	for i, tax := range t.Taxes {
+		rows[i] = &TaxRow{
+			AccountHead:  tax.AccountHead,
+			Description:  tax.Description,
+			ChargeType:   tax.ChargeType,
+			Rate:         tax.Rate,
+			RowID:        tax.RowID,
+			Category:     tax.Category,
+			AddDeductTax: tax.AddDeductTax,
+			CostCenter:   tax.CostCenter,
+
+			IncludeInTaxableAmount: tax.IncludeInTaxableAmount,
+		}
+	}
+	return rows
+}
+
+// SelectTaxTemplate picks the document tax template for a company and tax
+// category. Templates of the category are preferred, the default among them
+// first; without a match the company's default template applies. Nil means
+// no template applies.
+//
+// Maps to: the tax_category filter on taxes_and_charges together with
+// get_default_taxes_and_charges() in controllers/accounts_controller.py
+func SelectTaxTemplate(templates []*TaxTemplate, company, taxCategory string) *TaxTemplate {
+	var categoryMatch, companyDefault *TaxTemplate
+
+	for _, t := range templates {
+		if t.Company != company || t.Disabled {
+			continue
+		}
+		if taxCategory != "" && t.TaxCategory == taxCategory {
+			if categoryMatch == nil || (t.IsDefault && !categoryMatch.IsDefault) {
+				categoryMatch = t
+			}
+		}
+		if t.IsDefault && companyDefault == nil {
+			companyDefault = t
+		}
+	}
+
+	if categoryMatch != nil {
+		return categoryMatch
+	}
+	return companyDefault
+}
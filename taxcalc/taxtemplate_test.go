@@ -0,0 +1,59 @@
+package taxcalc
+
+import "testing"
+
+func TestSelectTaxTemplate(t *testing.T) {
+	inState := &TaxTemplate{Name: "In-State GST - ACME", Company: "ACME", IsDefault: true, Taxes: []TaxRow{
+		{AccountHead: "CGST - ACME", ChargeType: OnNetTotal, Rate: 9},
+		{AccountHead: "SGST - ACME", ChargeType: OnNetTotal, Rate: 9},
+	}}
+	outState := &TaxTemplate{Name: "Out-State GST - ACME", Company: "ACME", TaxCategory: "Out-State", Taxes: []TaxRow{
+		{AccountHead: "IGST - ACME", ChargeType: OnNetTotal, Rate: 18},
+	}}
+	outStateDefault := &TaxTemplate{Name: "Out-State IGST - ACME", Company: "ACME", TaxCategory: "Out-State", IsDefault: true}
+	disabled := &TaxTemplate{Name: "Export - ACME", Company: "ACME", TaxCategory: "Export", Disabled: true}
+	other := &TaxTemplate{Name: "Other", Company: "OTHER", TaxCategory: "Out-State", IsDefault: true}
+
+	templates := []*TaxTemplate{other, inState, outState, disabled}
+
+	tests := []struct {
+		name      string
+		templates []*TaxTemplate
+		company   string
+		category  string
+		want      *TaxTemplate
+	}{
+		{"no category uses company default", templates, "ACME", "", inState},
+		{"category match", templates, "ACME", "Out-State", outState},
+		{"default within category preferred", append(templates, outStateDefault), "ACME", "Out-State", outStateDefault},
+		{"disabled template skipped", templates, "ACME", "Export", inState},
+		{"unknown company", templates, "NONE", "Out-State", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SelectTaxTemplate(tt.templates, tt.company, tt.category); got != tt.want {
+				t.Errorf("SelectTaxTemplate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTaxTemplateRows(t *testing.T) {
+	template := &TaxTemplate{Taxes: []TaxRow{
+		{AccountHead: "IGST - ACME", ChargeType: OnNetTotal, Rate: 18, TaxAmount: 99},
+	}}
+
+	doc := &Document{
+		ConversionRate: 1.0,
+		Items:          []*LineItem{{ItemCode: "WIDGET", Qty: 1, Rate: 100}},
+		Taxes:          template.Rows(),
+	}
+	if err := NewCalculator(doc, nil).Calculate(); err != nil {
+		t.Fatalf("Calculate() error = %v", err)
+	}
+	if doc.Taxes[0].TaxAmount != 18 || template.Taxes[0].TaxAmount != 99 {
+		t.Errorf("document row = %.2f, template row = %.2f; template must not be modified",
+			doc.Taxes[0].TaxAmount, template.Taxes[0].TaxAmount)
+	}
+}
@@ -0,0 +1,46 @@
+package taxcalc
+
+// ItemTaxTrace shows how the taxes of one row were arrived at: the item
+// tax template applied and where it was chosen, and for each tax row the
+// rate used and the tax it gave.
+type ItemTaxTrace struct {
+	ItemCode string
+	Template string        // Empty when the document rates applied
+	Source   ItemTaxSource // Empty when no template was applied
+	Taxes    []TaxTraceLine
+}
+
+// TaxTraceLine is one tax row as applied to an item.
+type TaxTraceLine struct {
+	AccountHead string
+	ChargeType  ChargeType
+	Rate        float64 // The item's rate for the account, or the row's own
+	ItemRate    bool    // Rate came from the item tax template
+	TaxAmount   float64 // The item's share of the row's tax after discount
+}
+
+// ItemTaxTrace returns the trace of every row of a calculated document, in
+// row order. Tax amounts are the unrounded item-wise breakup, so rows
+// sharing an item code share a line of it.
+func (c *Calculator) ItemTaxTrace() []ItemTaxTrace {
+	traces := make([]ItemTaxTrace, len(c.doc.Items))
+	for i, item := range c.doc.Items {
+		itemTaxMap, _ := item.TaxMap()
+		trace := ItemTaxTrace{ItemCode: item.ItemCode, Template: item.ItemTaxTemplate, Source: item.ItemTaxSource}
+		for _, tax := range c.doc.Taxes {
+			rate, ok := itemTaxMap[tax.AccountHead]
+			if !ok {
+				rate = tax.Rate
+			}
+			trace.Taxes = append(trace.Taxes, TaxTraceLine{
+				AccountHead: tax.AccountHead,
+				ChargeType:  tax.ChargeType,
+				Rate:        rate,
+				ItemRate:    ok,
+				TaxAmount:   tax.ItemWiseTaxDetail[item.ItemCode],
+			})
+		}
+		traces[i] = trace
+	}
+	return traces
+}
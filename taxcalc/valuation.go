@@ -0,0 +1,144 @@
+package taxcalc
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrNoChargeWeights indicates a manual distribution without any weights.
+var ErrNoChargeWeights = errors.New("no charge weights set for manual distribution")
+
+// DistributionBasis defines how charges are spread over items.
+// Maps to: distribute_charges_based_on in Landed Cost Voucher
+type DistributionBasis string
+
+const (
+	DistributeByAmount DistributionBasis = "Amount"
+	DistributeByQty    DistributionBasis = "Qty"
+	DistributeManually DistributionBasis = "Distribute Manually"
+)
+
+// IsValuation reports whether a tax row's amount is added to item cost.
+func (t *TaxRow) IsValuation() bool {
+	return t.Category == Valuation || t.Category == ValuationAndTotal
+}
+
+// AllocateValuationCharges distributes the document's valuation charges
+// (tax rows of category Valuation or Valuation and Total, typically Actual
+// freight and insurance) over its stock items, then sets each item's
+// valuation rate so that it reflects the landed cost. It must run after
+// Calculate.
+//
+// With DistributeByAmount the basis is the item's base net amount, falling
+// back to quantity when all amounts are zero; DistributeManually uses each
+// item's ChargeWeight. Rounding differences go to the last stock item, so
+// the allocations always sum to the charges.
+//
+// Maps to: update_valuation_rate() in controllers/buying_controller.py and
+// distribute_landed_cost_on_items() in landed_cost_voucher.py
+//
+// Python equivalent:
+//
+//	total_valuation_amount = sum(flt(d.base_tax_amount_after_discount_amount)
+//	    for d in self.get("taxes") if d.category in ["Valuation", "Valuation and Total"])
+//	for i, item in enumerate(self.get("items")):
+//	    item_proportion = flt(item.base_net_amount) / stock_and_asset_items_amount
+//	    if i == (last_item_idx - 1):
+//	        item.item_tax_amount = flt(valuation_amount_adjustment)
+//	    else:
+//	        item.item_tax_amount = flt(item_proportion * total_valuation_amount)
+//	        valuation_amount_adjustment -= item.item_tax_amount
+//	    item.valuation_rate = (item.base_net_amount + item.item_tax_amount
+//	        + flt(item.landed_cost_voucher_amount)) / qty_in_stock_uom
+func AllocateValuationCharges(doc *Document, basis DistributionBasis, precision PrecisionProvider) error {
+	if precision == nil {
+		precision = DefaultPrecision{}
+	}
+	amountPrecision := precision.GetPrecision("item_tax_amount")
+	ratePrecision := precision.GetPrecision("valuation_rate")
+
+	var charges float64
+	for _, tax := range doc.Taxes {
+		if !tax.IsValuation() {
+			continue
+		}
+		if tax.AddDeductTax == Deduct {
+			charges -= tax.BaseTaxAmountAfterDiscountAmount
+		} else {
+			charges += tax.BaseTaxAmountAfterDiscountAmount
+		}
+	}
+
+	weights, err := chargeWeights(doc.Items, basis)
+	if err != nil {
+		return err
+	}
+
+	var totalWeight float64
+	last := -1
+	for i, w := range weights {
+		totalWeight += w
+		if doc.Items[i].IsStockItem && doc.Items[i].Qty != 0 {
+			last = i
+		}
+	}
+
+	remaining := charges
+	for i, item := range doc.Items {
+		item.ValuationTaxAmount = 0
+		item.ValuationRate = 0
+		if !item.IsStockItem || item.Qty == 0 {
+			continue
+		}
+
+		switch {
+		case i == last:
+			item.ValuationTaxAmount = Flt(remaining, amountPrecision)
+		case totalWeight != 0:
+			item.ValuationTaxAmount = Flt(charges*weights[i]/totalWeight, amountPrecision)
+			remaining -= item.ValuationTaxAmount
+		}
+
+		factor := item.ConversionFactor
+		if factor == 0 {
+			factor = 1.0
+		}
+		item.ValuationRate = Flt((item.BaseNetAmount+item.ValuationTaxAmount)/(item.Qty*factor), ratePrecision)
+	}
+
+	return nil
+}
+
+// chargeWeights returns the distribution weight of each item; non-stock
+// items weigh zero.
+func chargeWeights(items []*LineItem, basis DistributionBasis) ([]float64, error) {
+	weights := make([]float64, len(items))
+	weigh := func(value func(*LineItem) float64) float64 {
+		var total float64
+		for i, item := range items {
+			if item.IsStockItem && item.Qty != 0 {
+				weights[i] = value(item)
+				total += weights[i]
+			}
+		}
+		return total
+	}
+	qty := func(item *LineItem) float64 { return item.Qty }
+
+	switch basis {
+	case DistributeByQty:
+		weigh(qty)
+	case DistributeManually:
+		if weigh(func(item *LineItem) float64 { return item.ChargeWeight }) == 0 {
+			return nil, ErrNoChargeWeights
+		}
+	case DistributeByAmount, "":
+		if weigh(func(item *LineItem) float64 { return item.BaseNetAmount }) == 0 {
+			weigh(qty)
+		}
+	default:
+		return nil, fmt.Errorf("unknown distribution basis %q", basis)
+	}
+
+	return weights, nil
+}
@@ -0,0 +1,98 @@
+package taxcalc
+
+import (
+	"errors"
+	"testing"
+)
+
+func newPurchaseDoc() *Document {
+	return &Document{
+		ConversionRate: 1.0,
+		Items: []*LineItem{
+			{ItemCode: "STEEL", Qty: 10, Rate: 100, IsStockItem: true, ChargeWeight: 1},
+			{ItemCode: "BOLTS", Qty: 40, Rate: 50, IsStockItem: true, ChargeWeight: 2},
+			{ItemCode: "INSTALLATION", Qty: 1, Rate: 500},
+		},
+		Taxes: []*TaxRow{
+			{AccountHead: "Input GST", ChargeType: OnNetTotal, Rate: 18, Category: Total},
+			{AccountHead: "Freight", ChargeType: Actual, Rate: 200, Category: Valuation},
+			{AccountHead: "Insurance", ChargeType: Actual, Rate: 100, Category: ValuationAndTotal},
+		},
+	}
+}
+
+func TestAllocateValuationCharges(t *testing.T) {
+	tests := []struct {
+		basis      DistributionBasis
+		wantCharge [3]float64
+		wantRate   [3]float64
+	}{
+		{DistributeByAmount, [3]float64{100, 200, 0}, [3]float64{110, 55, 0}},
+		{DistributeByQty, [3]float64{60, 240, 0}, [3]float64{106, 56, 0}},
+		{DistributeManually, [3]float64{100, 200, 0}, [3]float64{110, 55, 0}},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.basis), func(t *testing.T) {
+			doc := newPurchaseDoc()
+			if err := NewCalculator(doc, nil).Calculate(); err != nil {
+				t.Fatalf("Calculate() error = %v", err)
+			}
+
+			// Freight is valuation only; insurance also counts towards the total
+			if doc.GrandTotal != 4230 {
+				t.Errorf("grand total = %.2f, want 4230", doc.GrandTotal)
+			}
+
+			if err := AllocateValuationCharges(doc, tt.basis, nil); err != nil {
+				t.Fatalf("AllocateValuationCharges() error = %v", err)
+			}
+			for i, item := range doc.Items {
+				if item.ValuationTaxAmount != tt.wantCharge[i] || item.ValuationRate != tt.wantRate[i] {
+					t.Errorf("%s: charge %.2f rate %.2f, want %.2f/%.2f",
+						item.ItemCode, item.ValuationTaxAmount, item.ValuationRate, tt.wantCharge[i], tt.wantRate[i])
+				}
+			}
+		})
+	}
+}
+
+func TestAllocateValuationCharges_RoundingGoesToLastItem(t *testing.T) {
+	doc := &Document{
+		ConversionRate: 1.0,
+		Items: []*LineItem{
+			{ItemCode: "A", Qty: 1, Rate: 100, IsStockItem: true},
+			{ItemCode: "B", Qty: 1, Rate: 100, IsStockItem: true},
+			{ItemCode: "C", Qty: 1, Rate: 300, IsStockItem: true, ConversionFactor: 12},
+		},
+		Taxes: []*TaxRow{{AccountHead: "Freight", ChargeType: Actual, Rate: 100, Category: Valuation}},
+	}
+	NewCalculator(doc, nil).Calculate()
+
+	if err := AllocateValuationCharges(doc, DistributeByQty, nil); err != nil {
+		t.Fatalf("AllocateValuationCharges() error = %v", err)
+	}
+	var total float64
+	for _, item := range doc.Items {
+		total += item.ValuationTaxAmount
+	}
+	if !almostEqual(total, 100, 1e-9) || doc.Items[2].ValuationTaxAmount != 33.34 {
+		t.Errorf("allocated %.2f (last item %.2f), want 100 (33.34)", total, doc.Items[2].ValuationTaxAmount)
+	}
+	// (300 + 33.34) / (1 × 12)
+	if doc.Items[2].ValuationRate != 27.78 {
+		t.Errorf("valuation rate per stock unit = %.2f, want 27.78", doc.Items[2].ValuationRate)
+	}
+}
+
+func TestAllocateValuationCharges_ManualWithoutWeights(t *testing.T) {
+	doc := newPurchaseDoc()
+	for _, item := range doc.Items {
+		item.ChargeWeight = 0
+	}
+	NewCalculator(doc, nil).Calculate()
+
+	if err := AllocateValuationCharges(doc, DistributeManually, nil); !errors.Is(err, ErrNoChargeWeights) {
+		t.Errorf("Expected ErrNoChargeWeights, got %v", err)
+	}
+}
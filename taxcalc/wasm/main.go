@@ -0,0 +1,34 @@
+//go:build js && wasm
+
+// Command wasm runs the tax calculator in the browser, so that point of
+// sale and invoice screens compute totals exactly as the server does,
+// without a round trip. It sets a global taxcalc object whose
+// calculateJSON function takes a CalculationRequest as a JSON string and
+// returns a CalculationResponse as one:
+//
+//	const res = JSON.parse(taxcalc.calculateJSON(JSON.stringify({Document: doc})))
+//
+// Build it with
+//
+//	GOOS=js GOARCH=wasm go build -o taxcalc.wasm ./taxcalc/wasm
+//
+// and load it with the wasm_exec.js shipped in $(go env GOROOT)/lib/wasm.
+package main
+
+import (
+	"syscall/js"
+
+	"github.com/senguttuvang/erpnext-go/taxcalc"
+)
+
+func main() {
+	calculate := js.FuncOf(func(_ js.Value, args []js.Value) any {
+		var input string
+		if len(args) > 0 && args[0].Type() == js.TypeString {
+			input = args[0].String()
+		}
+		return string(taxcalc.CalculateJSON([]byte(input)))
+	})
+	js.Global().Set("taxcalc", js.ValueOf(map[string]any{"calculateJSON": calculate}))
+	select {} // Keep the functions callable
+}
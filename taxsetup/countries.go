@@ -0,0 +1,90 @@
+package taxsetup
+
+import "strconv"
+
+// defaults holds the tax defaults by country name. The United States has
+// a skeleton only: sales tax rates are set per state after setup.
+//
+// Maps to: setup_wizard/data/country_wise_tax.json
+var defaults = map[string]Country{
+	"India": {
+		Accounts: []string{
+			"Output Tax CGST", "Output Tax SGST", "Output Tax IGST",
+			"Input Tax CGST", "Input Tax SGST", "Input Tax IGST",
+		},
+		TaxCategories: []string{"In-State", "Out-State"},
+		Templates: []Template{
+			{Title: "Output GST In-state", Kind: Sales, TaxCategory: "In-State", IsDefault: true, Taxes: []Tax{
+				{Account: "Output Tax CGST", Rate: 9, Description: "CGST"},
+				{Account: "Output Tax SGST", Rate: 9, Description: "SGST"},
+			}},
+			{Title: "Output GST Out-state", Kind: Sales, TaxCategory: "Out-State", Taxes: []Tax{
+				{Account: "Output Tax IGST", Rate: 18, Description: "IGST"},
+			}},
+			{Title: "Input GST In-state", Kind: Purchase, TaxCategory: "In-State", IsDefault: true, Taxes: []Tax{
+				{Account: "Input Tax CGST", Rate: 9, Description: "CGST"},
+				{Account: "Input Tax SGST", Rate: 9, Description: "SGST"},
+			}},
+			{Title: "Input GST Out-state", Kind: Purchase, TaxCategory: "Out-State", Taxes: []Tax{
+				{Account: "Input Tax IGST", Rate: 18, Description: "IGST"},
+			}},
+		},
+		ItemTemplates: []ItemTemplate{gstSlab(5), gstSlab(12), gstSlab(18), gstSlab(28)},
+	},
+	"United Kingdom": {
+		Accounts: []string{"VAT"},
+		Templates: []Template{
+			{Title: "UK VAT 20%", Kind: Sales, IsDefault: true, Taxes: []Tax{{Account: "VAT", Rate: 20}}},
+			{Title: "UK VAT 20%", Kind: Purchase, IsDefault: true, Taxes: []Tax{{Account: "VAT", Rate: 20}}},
+		},
+		ItemTemplates: []ItemTemplate{
+			{Title: "UK VAT Standard 20%", Taxes: []Tax{{Account: "VAT", Rate: 20}}},
+			{Title: "UK VAT Reduced 5%", Taxes: []Tax{{Account: "VAT", Rate: 5}}},
+			{Title: "UK VAT Zero 0%", Taxes: []Tax{{Account: "VAT", Rate: 0}}},
+		},
+	},
+	"Saudi Arabia": {
+		Accounts: []string{"VAT 15%"},
+		Templates: []Template{
+			{Title: "KSA VAT 15%", Kind: Sales, IsDefault: true, Taxes: []Tax{{Account: "VAT 15%", Rate: 15}}},
+			{Title: "KSA VAT 15%", Kind: Purchase, IsDefault: true, Taxes: []Tax{{Account: "VAT 15%", Rate: 15}}},
+		},
+		ItemTemplates: []ItemTemplate{
+			{Title: "KSA VAT Standard 15%", Taxes: []Tax{{Account: "VAT 15%", Rate: 15}}},
+			{Title: "KSA VAT Zero 0%", Taxes: []Tax{{Account: "VAT 15%", Rate: 0}}},
+		},
+	},
+	"United Arab Emirates": {
+		Accounts: []string{"VAT 5%"},
+		Templates: []Template{
+			{Title: "UAE VAT 5%", Kind: Sales, IsDefault: true, Taxes: []Tax{{Account: "VAT 5%", Rate: 5}}},
+			{Title: "UAE VAT 5%", Kind: Purchase, IsDefault: true, Taxes: []Tax{{Account: "VAT 5%", Rate: 5}}},
+		},
+		ItemTemplates: []ItemTemplate{
+			{Title: "UAE VAT Standard 5%", Taxes: []Tax{{Account: "VAT 5%", Rate: 5}}},
+			{Title: "UAE VAT Zero 0%", Taxes: []Tax{{Account: "VAT 5%", Rate: 0}}},
+		},
+	},
+	"United States": {
+		Accounts: []string{"Sales Tax Payable"},
+		Templates: []Template{
+			{Title: "US Sales Tax", Kind: Sales, IsDefault: true, Taxes: []Tax{{Account: "Sales Tax Payable", Rate: 0, Description: "Sales Tax"}}},
+		},
+	},
+}
+
+// gstSlab is the Item Tax Template of an Indian GST rate: CGST and SGST at
+// half the rate within the state, IGST at the full rate across states.
+func gstSlab(rate float64) ItemTemplate {
+	return ItemTemplate{
+		Title: "GST " + formatRate(rate) + "%",
+		Taxes: []Tax{
+			{Account: "Output Tax CGST", Rate: rate / 2}, {Account: "Output Tax SGST", Rate: rate / 2}, {Account: "Output Tax IGST", Rate: rate},
+			{Account: "Input Tax CGST", Rate: rate / 2}, {Account: "Input Tax SGST", Rate: rate / 2}, {Account: "Input Tax IGST", Rate: rate},
+		},
+	}
+}
+
+func formatRate(rate float64) string {
+	return strconv.FormatFloat(rate, 'f', -1, 64)
+}
@@ -0,0 +1,179 @@
+// Package taxsetup creates a new company's tax accounts and templates from
+// per-country defaults.
+//
+// Each supported country has a set of tax accounts, Sales and Purchase
+// Taxes and Charges Templates and Item Tax Templates. Bootstrap names them
+// for a company and returns the masters to create; company setup saves
+// them along with the rest of the chart of accounts.
+//
+// Migrated from: erpnext/setup/setup_wizard/operations/taxes_setup.py and
+// setup_wizard/data/country_wise_tax.json
+package taxsetup
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/senguttuvang/erpnext-go/ledger"
+	"github.com/senguttuvang/erpnext-go/taxcalc"
+)
+
+// ErrUnsupportedCountry is returned for a country without tax defaults.
+var ErrUnsupportedCountry = errors.New("no tax defaults for country")
+
+// Kind says which documents a template applies to.
+type Kind string
+
+const (
+	Sales    Kind = "Sales"
+	Purchase Kind = "Purchase"
+)
+
+// Tax is one row of a template: an account and its rate.
+type Tax struct {
+	Account     string // Account name without the company abbreviation
+	Rate        float64
+	Description string // Defaults to the account name
+}
+
+// Template is a Sales or Purchase Taxes and Charges Template.
+type Template struct {
+	Title       string
+	Kind        Kind
+	TaxCategory string
+	IsDefault   bool
+	Taxes       []Tax
+}
+
+// ItemTemplate is an Item Tax Template: the rate each tax account charges
+// on items of one class.
+type ItemTemplate struct {
+	Title string
+	Taxes []Tax
+}
+
+// Country holds the tax defaults of one country.
+type Country struct {
+	Accounts      []string // Tax accounts, created under the company's tax group
+	TaxCategories []string
+	Templates     []Template
+	ItemTemplates []ItemTemplate
+}
+
+// Company identifies the company being set up.
+type Company struct {
+	Name     string
+	Abbr     string // Appended to account and template names, as in "VAT - AC"
+	Currency string
+
+	// TaxGroup is the group account the tax accounts are created under.
+	// Defaults to "Duties and Taxes - <Abbr>" from the standard chart.
+	TaxGroup string
+}
+
+// Bootstrap is the set of masters to create for a company.
+type Bootstrap struct {
+	Accounts          []ledger.Account
+	TaxCategories     []string
+	SalesTemplates    []*taxcalc.TaxTemplate
+	PurchaseTemplates []*taxcalc.TaxTemplate
+	ItemTaxTemplates  []*taxcalc.ItemTaxTemplate
+}
+
+// Countries returns the countries with tax defaults, sorted.
+func Countries() []string {
+	names := make([]string, 0, len(defaults))
+	for name := range defaults {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// For returns the tax masters of a country, named for the company.
+//
+// Maps to: setup_taxes_and_charges(company_name, country) in
+// taxes_setup.py
+func For(company Company, country string) (*Bootstrap, error) {
+	c, ok := defaults[country]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedCountry, country)
+	}
+	group := company.TaxGroup
+	if group == "" {
+		group = company.named("Duties and Taxes")
+	}
+
+	b := &Bootstrap{TaxCategories: slices.Clone(c.TaxCategories)}
+	for _, name := range c.Accounts {
+		b.Accounts = append(b.Accounts, ledger.Account{
+			Name:            company.named(name),
+			AccountName:     name,
+			Company:         company.Name,
+			AccountCurrency: company.Currency,
+			RootType:        "Liability",
+			ParentAccount:   group,
+		})
+	}
+	for _, t := range c.Templates {
+		if err := c.check(t.Title, t.Taxes); err != nil {
+			return nil, err
+		}
+		template := &taxcalc.TaxTemplate{
+			Name:        company.named(t.Title),
+			Title:       t.Title,
+			Company:     company.Name,
+			TaxCategory: t.TaxCategory,
+			IsDefault:   t.IsDefault,
+		}
+		for _, tax := range t.Taxes {
+			description := tax.Description
+			if description == "" {
+				description = tax.Account
+			}
+			template.Taxes = append(template.Taxes, taxcalc.TaxRow{
+				AccountHead:  company.named(tax.Account),
+				Description:  description,
+				ChargeType:   taxcalc.OnNetTotal,
+				Rate:         tax.Rate,
+				Category:     taxcalc.Total,
+				AddDeductTax: taxcalc.Add,
+			})
+		}
+		if t.Kind == Purchase {
+			b.PurchaseTemplates = append(b.PurchaseTemplates, template)
+		} else {
+			b.SalesTemplates = append(b.SalesTemplates, template)
+		}
+	}
+	for _, t := range c.ItemTemplates {
+		if err := c.check(t.Title, t.Taxes); err != nil {
+			return nil, err
+		}
+		template := &taxcalc.ItemTaxTemplate{Name: company.named(t.Title), Title: t.Title, Company: company.Name}
+		for _, tax := range t.Taxes {
+			template.Taxes = append(template.Taxes, taxcalc.ItemTaxTemplateDetail{TaxType: company.named(tax.Account), TaxRate: tax.Rate})
+		}
+		b.ItemTaxTemplates = append(b.ItemTaxTemplates, template)
+	}
+	return b, nil
+}
+
+// check verifies that a template only uses the country's accounts.
+func (c Country) check(title string, taxes []Tax) error {
+	for _, tax := range taxes {
+		if !slices.Contains(c.Accounts, tax.Account) {
+			return fmt.Errorf("%w: %s in template %s", ledger.ErrAccountNotInTree, tax.Account, title)
+		}
+	}
+	return nil
+}
+
+// named appends the company abbreviation to a name.
+func (c Company) named(name string) string {
+	if c.Abbr == "" {
+		return name
+	}
+	return name + " - " + c.Abbr
+}
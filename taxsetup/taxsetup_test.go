@@ -0,0 +1,76 @@
+package taxsetup
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/senguttuvang/erpnext-go/ledger"
+	"github.com/senguttuvang/erpnext-go/taxcalc"
+)
+
+func TestForIndia(t *testing.T) {
+	b, err := For(Company{Name: "ACME India", Abbr: "AI", Currency: "INR"}, "India")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(b.Accounts) != 6 || b.Accounts[0].Name != "Output Tax CGST - AI" || b.Accounts[0].ParentAccount != "Duties and Taxes - AI" ||
+		b.Accounts[0].AccountCurrency != "INR" || b.Accounts[0].RootType != "Liability" {
+		t.Errorf("accounts = %+v", b.Accounts)
+	}
+	if len(b.SalesTemplates) != 2 || len(b.PurchaseTemplates) != 2 || len(b.ItemTaxTemplates) != 4 {
+		t.Fatalf("templates: %d sales, %d purchase, %d item", len(b.SalesTemplates), len(b.PurchaseTemplates), len(b.ItemTaxTemplates))
+	}
+
+	// The default in-state template charges 18% split between CGST and SGST
+	template := taxcalc.SelectTaxTemplate(b.SalesTemplates, "ACME India", "In-State")
+	if template == nil || template.Name != "Output GST In-state - AI" {
+		t.Fatalf("in-state template = %+v", template)
+	}
+	doc := &taxcalc.Document{ConversionRate: 1, Items: []*taxcalc.LineItem{{ItemCode: "WIDGET", Qty: 1, Rate: 100}}, Taxes: template.Rows()}
+	if err := taxcalc.NewCalculator(doc, nil).Calculate(); err != nil {
+		t.Fatal(err)
+	}
+	if doc.GrandTotal != 118 || doc.Taxes[0].AccountHead != "Output Tax CGST - AI" {
+		t.Errorf("grand total = %v, first tax %s", doc.GrandTotal, doc.Taxes[0].AccountHead)
+	}
+
+	// Item tax templates refer to the created accounts
+	slab := b.ItemTaxTemplates[0]
+	if slab.Name != "GST 5% - AI" || slab.TaxMap()["Output Tax SGST - AI"] != 2.5 || slab.TaxMap()["Input Tax IGST - AI"] != 5 {
+		t.Errorf("GST 5%% = %+v", slab)
+	}
+	names := make([]string, len(b.Accounts))
+	for i, a := range b.Accounts {
+		names[i] = a.Name
+	}
+	for _, item := range b.ItemTaxTemplates {
+		for _, tax := range item.Taxes {
+			if !slices.Contains(names, tax.TaxType) {
+				t.Errorf("%s uses unknown account %s", item.Name, tax.TaxType)
+			}
+		}
+	}
+}
+
+func TestForCountries(t *testing.T) {
+	if got := Countries(); !slices.Equal(got, []string{"India", "Saudi Arabia", "United Arab Emirates", "United Kingdom", "United States"}) {
+		t.Errorf("Countries() = %v", got)
+	}
+	for _, country := range Countries() {
+		b, err := For(Company{Name: "ACME", Abbr: "AC", TaxGroup: "Taxes - AC"}, country)
+		if err != nil {
+			t.Errorf("%s: %v", country, err)
+			continue
+		}
+		if len(b.Accounts) == 0 || len(b.SalesTemplates) == 0 || b.Accounts[0].ParentAccount != "Taxes - AC" {
+			t.Errorf("%s: %+v", country, b)
+		}
+		if _, err := ledger.NewAccountTree(append(b.Accounts, ledger.Account{Name: "Taxes - AC", IsGroup: true})...); err != nil {
+			t.Errorf("%s: %v", country, err)
+		}
+	}
+	if _, err := For(Company{Name: "ACME"}, "Atlantis"); !errors.Is(err, ErrUnsupportedCountry) {
+		t.Errorf("err = %v", err)
+	}
+}
@@ -0,0 +1,79 @@
+package taxwithholding
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/ledger"
+)
+
+// ErrCertificateExceeded indicates consumption beyond a certificate's limit.
+var ErrCertificateExceeded = errors.New("lower deduction certificate limit exceeded")
+
+// LowerDeductionCertificate lets a supplier be withheld at a lower (or nil)
+// rate, up to a limit of taxable amount, while it is valid.
+//
+// Maps to: erpnext/regional/doctype/lower_deduction_certificate/lower_deduction_certificate.json
+type LowerDeductionCertificate struct {
+	CertificateNo          string
+	Supplier               string
+	TaxWithholdingCategory string
+	TaxID                  string // PAN the certificate was issued to
+	ValidFrom              time.Time
+	ValidUpto              time.Time
+	Rate                   float64 // Zero for a nil deduction certificate
+	CertificateLimit       float64
+
+	Utilized    float64 // Taxable amount already covered
+	Utilization []CertificateUsage
+}
+
+// CertificateUsage records a transaction covered by a certificate.
+type CertificateUsage struct {
+	VoucherType string
+	VoucherNo   string
+	Date        time.Time
+	Amount      float64
+}
+
+// CertificateLookup abstracts queries for a supplier's certificates.
+type CertificateLookup interface {
+	// GetLowerDeductionCertificates returns the certificates of a supplier.
+	GetLowerDeductionCertificates(supplier string) ([]*LowerDeductionCertificate, error)
+}
+
+// IsValid reports whether the certificate covers a date and has limit left.
+//
+// Maps to: is_valid_certificate() in tax_withholding_category.py
+func (c *LowerDeductionCertificate) IsValid(date time.Time) bool {
+	period := ledger.PeriodDefinition{StartDate: c.ValidFrom, EndDate: c.ValidUpto}
+	return period.Contains(date) && c.Remaining() > 0
+}
+
+// Remaining returns the taxable amount the certificate can still cover.
+func (c *LowerDeductionCertificate) Remaining() float64 {
+	return ledger.Flt(c.CertificateLimit-c.Utilized, 2)
+}
+
+// Consume records the part of a transaction's taxable amount covered by
+// the certificate, as returned in Withholding.CertificateAmount.
+func (c *LowerDeductionCertificate) Consume(voucherType, voucherNo string, date time.Time, amount float64) error {
+	if amount > c.Remaining() {
+		return fmt.Errorf("%w: %s has %.2f left, %.2f requested", ErrCertificateExceeded, c.CertificateNo, c.Remaining(), amount)
+	}
+	c.Utilized = ledger.Flt(c.Utilized+amount, 2)
+	c.Utilization = append(c.Utilization, CertificateUsage{VoucherType: voucherType, VoucherNo: voucherNo, Date: date, Amount: amount})
+	return nil
+}
+
+// validCertificate returns the first certificate for the category valid on
+// the date, or nil.
+func validCertificate(certificates []*LowerDeductionCertificate, category string, date time.Time) *LowerDeductionCertificate {
+	for _, c := range certificates {
+		if c.TaxWithholdingCategory == category && c.IsValid(date) {
+			return c
+		}
+	}
+	return nil
+}
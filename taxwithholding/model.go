@@ -0,0 +1,168 @@
+// Package taxwithholding implements ERPNext's Tax Withholding Category:
+// tax deducted at source (TDS) from supplier payments, and the period-end
+// summaries behind withholding certificates such as India's Form 16A and
+// the US Form 1099.
+// Migrated from: erpnext/accounts/doctype/tax_withholding_category/tax_withholding_category.py
+package taxwithholding
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/ledger"
+	"github.com/senguttuvang/erpnext-go/paymententry"
+)
+
+// Tax withholding errors
+var (
+	ErrCategoryNotFound = errors.New("tax withholding category not found")
+	ErrNoRate           = errors.New("no tax withholding rate for date")
+	ErrNoAccount        = errors.New("no tax withholding account for company")
+)
+
+// Category defines how much tax is withheld from a class of payments.
+// Maps to: erpnext/accounts/doctype/tax_withholding_category/tax_withholding_category.json
+type Category struct {
+	Name        string
+	SectionCode string // Statutory section or box, e.g. "194C" or "NEC-1"
+	Form        string // Certificate the section is reported on, e.g. "Form 16A" or "1099-NEC"
+	Rates       []Rate
+	Accounts    []Account
+
+	RoundOffTaxAmount bool // Round the withheld amount to whole units
+}
+
+// Rate is the withholding rate and thresholds for a date range.
+// Maps to: Tax Withholding Rate child table
+type Rate struct {
+	FromDate            time.Time
+	ToDate              time.Time
+	TaxWithholdingRate  float64
+	SingleThreshold     float64 // Withhold when one transaction reaches this
+	CumulativeThreshold float64 // Withhold once the period total exceeds this
+}
+
+// Account is the company's payable account for the withheld tax.
+// Maps to: Tax Withholding Account child table
+type Account struct {
+	Company string
+	Account string
+}
+
+// CategoryLookup abstracts queries for Tax Withholding Categories.
+type CategoryLookup interface {
+	// GetCategory returns a category by name.
+	GetCategory(name string) (*Category, error)
+}
+
+// RateOn returns the rate in force on a date.
+//
+// Maps to: get_tax_withholding_details() in tax_withholding_category.py
+func (c *Category) RateOn(date time.Time) (*Rate, error) {
+	for i := range c.Rates {
+		r := &c.Rates[i]
+		period := ledger.PeriodDefinition{StartDate: r.FromDate, EndDate: r.ToDate}
+		if period.Contains(date) {
+			return r, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s on %s", ErrNoRate, c.Name, date.Format(ledger.DateLayout))
+}
+
+// AccountFor returns the withholding account of a company.
+func (c *Category) AccountFor(company string) (string, error) {
+	for _, a := range c.Accounts {
+		if a.Company == company {
+			return a.Account, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %s, %s", ErrNoAccount, c.Name, company)
+}
+
+// Withholding is the tax withheld from one transaction.
+type Withholding struct {
+	TaxableAmount float64 // Amount the tax is computed on
+	TaxAmount     float64
+
+	// Lower deduction certificate applied, and the part of the taxable
+	// amount it covered
+	Certificate       string
+	CertificateAmount float64
+}
+
+// TaxAmount returns the tax to withhold from a transaction, given the
+// supplier's earlier transactions in the rate's period. Nothing is withheld
+// below the thresholds. When the cumulative threshold is first crossed,
+// tax is also withheld on the earlier transactions that escaped it.
+//
+// Maps to: get_tds_amount() in tax_withholding_category.py
+//
+// Python equivalent:
+//
+//	if (tax_details.threshold and inv.tax_withholding_net_total >= tax_details.threshold) or (
+//	    tax_details.cumulative_threshold and supp_credit_amt >= tax_details.cumulative_threshold):
+//	    if tds_deducted:
+//	        tds_amount = inv.tax_withholding_net_total * tax_details.rate / 100
+//	    else:
+//	        tds_amount = supp_credit_amt * tax_details.rate / 100
+//	if cint(tax_details.round_off_tax_amount):
+//	    tds_amount = round(tds_amount)
+func (c *Category) TaxAmount(date time.Time, amount, previous float64, previouslyWithheld bool) (float64, error) {
+	w, err := c.Compute(date, amount, previous, previouslyWithheld, nil)
+	return w.TaxAmount, err
+}
+
+// Compute works out the withholding on a transaction like TaxAmount, and
+// applies the first of the supplier's lower deduction certificates valid on
+// the date: the certificate rate applies up to its remaining limit and the
+// category rate to the rest.
+//
+// Maps to: get_tds_amount() with get_lower_deduction_amount() in
+// tax_withholding_category.py
+func (c *Category) Compute(date time.Time, amount, previous float64, previouslyWithheld bool, certificates []*LowerDeductionCertificate) (Withholding, error) {
+	rate, err := c.RateOn(date)
+	if err != nil {
+		return Withholding{}, err
+	}
+
+	cumulative := previous + amount
+	singleCrossed := rate.SingleThreshold != 0 && amount >= rate.SingleThreshold
+	cumulativeCrossed := rate.CumulativeThreshold != 0 && cumulative >= rate.CumulativeThreshold
+	noThreshold := rate.SingleThreshold == 0 && rate.CumulativeThreshold == 0
+	if !noThreshold && !singleCrossed && !cumulativeCrossed {
+		return Withholding{}, nil
+	}
+
+	w := Withholding{TaxableAmount: amount}
+	if cumulativeCrossed && !previouslyWithheld {
+		w.TaxableAmount = cumulative
+	}
+
+	tax := w.TaxableAmount * rate.TaxWithholdingRate / 100
+	if cert := validCertificate(certificates, c.Name, date); cert != nil {
+		w.Certificate = cert.CertificateNo
+		w.CertificateAmount = min(w.TaxableAmount, cert.Remaining())
+		tax = w.CertificateAmount*cert.Rate/100 + (w.TaxableAmount-w.CertificateAmount)*rate.TaxWithholdingRate/100
+	}
+
+	if c.RoundOffTaxAmount {
+		w.TaxAmount = ledger.Flt(tax, 0)
+	} else {
+		w.TaxAmount = ledger.Flt(tax, 2)
+	}
+	return w, nil
+}
+
+// PaymentDeduction returns the withheld tax as a deduction on a payment to
+// the supplier: the supplier is settled for the full amount while the bank
+// pays the net and the tax is credited to the withholding account.
+//
+// Maps to: set_tax_withholding() in payment_entry.py
+func (c *Category) PaymentDeduction(company string, tax float64) (paymententry.Deduction, error) {
+	account, err := c.AccountFor(company)
+	if err != nil {
+		return paymententry.Deduction{}, err
+	}
+	return paymententry.Deduction{Account: account, Amount: -tax}, nil
+}
@@ -0,0 +1,156 @@
+package taxwithholding
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/senguttuvang/erpnext-go/ledger"
+	"github.com/senguttuvang/erpnext-go/party"
+	"github.com/senguttuvang/erpnext-go/paymententry"
+)
+
+// PartyLookup abstracts queries for Customer and Supplier masters.
+type PartyLookup interface {
+	// GetParty returns a party by type and name.
+	GetParty(partyType party.PartyType, name string) (*party.Party, error)
+}
+
+// CertificateRow is one supplier's payments and tax withheld under one
+// section for a period: the data printed on a Form 16A or filed on a 1099.
+type CertificateRow struct {
+	Supplier     string
+	SupplierName string
+	TaxID        string // PAN or TIN
+	Category     string
+	SectionCode  string
+	Form         string
+
+	TotalPaid   float64 // Gross payments from the payment ledger
+	TaxWithheld float64 // Net credits to the withholding account
+	Vouchers    []string
+}
+
+// CertificateSummary aggregates, for a company and period, the payments made
+// to every supplier with a tax withholding category and the tax withheld
+// from them. Payments come from the payment ledger (delinked entries are
+// ignored); withheld tax from the GL entries of the category's account,
+// attributed to the supplier of the same voucher. Cancelled entries are
+// ignored along with their reversals, and opening and period
+// closing GL entries unless flags include them. Rows are ordered by
+// section code, then supplier.
+//
+// Maps to: tds_computation_summary.py and tds_payable_monthly.py in
+// erpnext/accounts/report
+func CertificateSummary(company string, period ledger.PeriodDefinition, gl []ledger.GLEntry, ple []ledger.PaymentLedgerEntry, parties PartyLookup, categories CategoryLookup, flags ledger.ReportFlags) ([]CertificateRow, error) {
+	rows := make(map[string]*CertificateRow) // supplier -> row
+	accounts := make(map[string]string)      // withholding account -> category
+
+	row := func(supplier string) (*CertificateRow, error) {
+		if r, ok := rows[supplier]; ok {
+			return r, nil
+		}
+		p, err := parties.GetParty(party.Supplier, supplier)
+		if err != nil {
+			return nil, fmt.Errorf("supplier %s: %w", supplier, err)
+		}
+		if p.TaxWithholdingCategory == "" {
+			rows[supplier] = nil
+			return nil, nil
+		}
+		category, err := categories.GetCategory(p.TaxWithholdingCategory)
+		if err != nil {
+			return nil, fmt.Errorf("supplier %s: %w", supplier, err)
+		}
+		account, err := category.AccountFor(company)
+		if err != nil {
+			return nil, err
+		}
+		accounts[account] = category.Name
+
+		r := &CertificateRow{
+			Supplier: supplier, SupplierName: p.PartyName, TaxID: p.TaxID,
+			Category: category.Name, SectionCode: category.SectionCode, Form: category.Form,
+		}
+		rows[supplier] = r
+		return r, nil
+	}
+
+	for _, e := range ple {
+		if e.Delinked || e.Company != company || e.PartyType != string(party.Supplier) ||
+			e.VoucherType != paymententry.VoucherType || !period.Contains(e.PostingDate) {
+			continue
+		}
+		r, err := row(e.Party)
+		if err != nil {
+			return nil, err
+		}
+		if r != nil {
+			r.TotalPaid += e.Amount
+			r.addVoucher(e.VoucherNo)
+		}
+	}
+
+	// Supplier of each voucher, from its party entries
+	suppliers := make(map[ledger.VoucherRef]string)
+	for _, e := range gl {
+		ref := ledger.VoucherRef{VoucherType: e.VoucherType, VoucherNo: e.VoucherNo}
+		if e.Company == company && e.PartyType == string(party.Supplier) {
+			suppliers[ref] = e.Party
+			if _, err := row(e.Party); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	cancelled := ledger.CancelledEntries(gl)
+	for i, e := range gl {
+		ref := ledger.VoucherRef{VoucherType: e.VoucherType, VoucherNo: e.VoucherNo}
+		if cancelled[i] || e.Company != company || accounts[e.Account] == "" || !flags.Counts(&e) || !period.Contains(e.PostingDate) {
+			continue
+		}
+		supplier := suppliers[ref]
+		r := rows[supplier]
+		if r == nil || accounts[e.Account] != r.Category {
+			continue
+		}
+		r.TaxWithheld += e.Credit - e.Debit
+		r.addVoucher(e.VoucherNo)
+	}
+
+	var result []CertificateRow
+	for _, r := range rows {
+		if r == nil || (r.TotalPaid == 0 && r.TaxWithheld == 0) {
+			continue
+		}
+		r.TotalPaid = ledger.Flt(r.TotalPaid, 2)
+		r.TaxWithheld = ledger.Flt(r.TaxWithheld, 2)
+		result = append(result, *r)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].SectionCode != result[j].SectionCode {
+			return result[i].SectionCode < result[j].SectionCode
+		}
+		return result[i].Supplier < result[j].Supplier
+	})
+	return result, nil
+}
+
+// CertificateSummaryFor builds the summary with the GL entries a user may
+// see, as scoped by the Authorizer. Payments carry no cost center, so
+// only the user's access to the company applies to them.
+func CertificateSummaryFor(auth ledger.Authorizer, user, company string, period ledger.PeriodDefinition, gl []ledger.GLEntry, ple []ledger.PaymentLedgerEntry, parties PartyLookup, categories CategoryLookup, flags ledger.ReportFlags) ([]CertificateRow, error) {
+	gl, err := ledger.AuthorizedEntries(auth, user, company, gl)
+	if err != nil {
+		return nil, err
+	}
+	return CertificateSummary(company, period, gl, ple, parties, categories, flags)
+}
+
+func (r *CertificateRow) addVoucher(voucherNo string) {
+	for _, v := range r.Vouchers {
+		if v == voucherNo {
+			return
+		}
+	}
+	r.Vouchers = append(r.Vouchers, voucherNo)
+}
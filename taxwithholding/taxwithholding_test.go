@@ -0,0 +1,227 @@
+package taxwithholding
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/ledger"
+	"github.com/senguttuvang/erpnext-go/ledger/memstore"
+	"github.com/senguttuvang/erpnext-go/party"
+	"github.com/senguttuvang/erpnext-go/paymententry"
+)
+
+func date(y int, m time.Month, d int) time.Time {
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+type mockCategories map[string]*Category
+
+func (m mockCategories) GetCategory(name string) (*Category, error) {
+	c, ok := m[name]
+	if !ok {
+		return nil, ErrCategoryNotFound
+	}
+	return c, nil
+}
+
+type mockParties map[string]*party.Party
+
+func (m mockParties) GetParty(partyType party.PartyType, name string) (*party.Party, error) {
+	p, ok := m[name]
+	if !ok {
+		return nil, errors.New("party not found")
+	}
+	return p, nil
+}
+
+var fy2024 = Rate{FromDate: date(2024, 4, 1), ToDate: date(2025, 3, 31), TaxWithholdingRate: 1, SingleThreshold: 30000, CumulativeThreshold: 100000}
+
+var testCategories = mockCategories{
+	"Contractor": {Name: "Contractor", SectionCode: "194C", Form: "Form 16A", Rates: []Rate{fy2024},
+		Accounts: []Account{{Company: "ACME", Account: "TDS Payable - ACME"}}},
+	"Nonemployee": {Name: "Nonemployee", SectionCode: "NEC-1", Form: "1099-NEC",
+		Rates:    []Rate{{FromDate: date(2024, 1, 1), ToDate: date(2024, 12, 31)}},
+		Accounts: []Account{{Company: "ACME", Account: "Backup Withholding - ACME"}}},
+}
+
+var testParties = mockParties{
+	"Build Co": {Name: "Build Co", PartyType: party.Supplier, PartyName: "Build Co Pvt Ltd", TaxID: "AAACB1234C", TaxWithholdingCategory: "Contractor"},
+	"Jane Doe": {Name: "Jane Doe", PartyType: party.Supplier, TaxID: "123-45-6789", TaxWithholdingCategory: "Nonemployee"},
+	"Paper Co": {Name: "Paper Co", PartyType: party.Supplier},
+}
+
+func TestTaxAmount(t *testing.T) {
+	c := testCategories["Contractor"]
+	tests := []struct {
+		name     string
+		amount   float64
+		previous float64
+		withheld bool
+		want     float64
+	}{
+		{"below thresholds", 20000, 50000, false, 0},
+		{"single threshold", 40000, 0, false, 400},
+		{"cumulative crossed catches up", 20000, 85000, false, 1050},
+		{"already withheld", 20000, 120000, true, 200},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := c.TaxAmount(date(2024, 6, 1), tt.amount, tt.previous, tt.withheld)
+			if err != nil {
+				t.Fatalf("TaxAmount() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("TaxAmount() = %.2f, want %.2f", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := c.TaxAmount(date(2025, 4, 1), 50000, 0, false); !errors.Is(err, ErrNoRate) {
+		t.Errorf("Expected ErrNoRate, got %v", err)
+	}
+}
+
+func pay(t *testing.T, engine *ledger.Engine, name, supplier string, posted time.Time, gross float64) *paymententry.PaymentEntry {
+	t.Helper()
+	p := testParties[supplier]
+	pe := &paymententry.PaymentEntry{
+		Name: name, Company: "ACME", PostingDate: posted, PaymentType: paymententry.Pay,
+		PartyType: string(party.Supplier), Party: supplier, PaidFrom: "Bank - ACME", PaidTo: "Creditors - ACME",
+		PaidAmount: gross,
+	}
+	if p.TaxWithholdingCategory != "" {
+		category := testCategories[p.TaxWithholdingCategory]
+		tax, err := category.TaxAmount(posted, gross, 0, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		deduction, err := category.PaymentDeduction("ACME", tax)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pe.PaidAmount = gross - tax
+		pe.Deductions = []paymententry.Deduction{deduction}
+	}
+	if err := pe.Submit(engine); err != nil {
+		t.Fatalf("Submit(%s) error = %v", name, err)
+	}
+	return pe
+}
+
+func TestCertificateSummary(t *testing.T) {
+	glStore := memstore.NewGLStore()
+	paymentStore := memstore.NewPaymentStore()
+	engine := &ledger.Engine{GLStore: glStore, PaymentStore: paymentStore}
+
+	pay(t, engine, "PE-0001", "Build Co", date(2024, 4, 10), 50000)
+	pay(t, engine, "PE-0002", "Build Co", date(2024, 5, 20), 35000)
+	pay(t, engine, "PE-0003", "Jane Doe", date(2024, 5, 2), 1000)
+	pay(t, engine, "PE-0004", "Paper Co", date(2024, 5, 3), 5000)
+	pay(t, engine, "PE-0005", "Build Co", date(2024, 7, 1), 40000) // Next quarter
+	cancelled := pay(t, engine, "PE-0006", "Build Co", date(2024, 6, 1), 60000)
+	if err := cancelled.Cancel(engine); err != nil {
+		t.Fatal(err)
+	}
+
+	q1 := ledger.PeriodDefinition{Name: "Q1", StartDate: date(2024, 4, 1), EndDate: date(2024, 6, 30)}
+	rows, err := CertificateSummary("ACME", q1, glStore.All(), paymentStore.All(), testParties, testCategories, ledger.ReportFlags{})
+	if err != nil {
+		t.Fatalf("CertificateSummary() error = %v", err)
+	}
+
+	if len(rows) != 2 {
+		t.Fatalf("got %d rows, want 2: %+v", len(rows), rows)
+	}
+	build, jane := rows[0], rows[1]
+	if build.Supplier != "Build Co" || build.SectionCode != "194C" || build.TaxID != "AAACB1234C" {
+		t.Errorf("first row = %+v", build)
+	}
+	if build.TotalPaid != 85000 || build.TaxWithheld != 850 || len(build.Vouchers) != 2 {
+		t.Errorf("Build Co paid %.2f withheld %.2f vouchers %v, want 85000/850/2", build.TotalPaid, build.TaxWithheld, build.Vouchers)
+	}
+	if jane.Form != "1099-NEC" || jane.TotalPaid != 1000 || jane.TaxWithheld != 0 {
+		t.Errorf("second row = %+v", jane)
+	}
+}
+
+func TestCertificateSummaryAmendedVoucher(t *testing.T) {
+	glStore := memstore.NewGLStore()
+	paymentStore := memstore.NewPaymentStore()
+	engine := &ledger.Engine{GLStore: glStore, PaymentStore: paymentStore}
+
+	pay(t, engine, "PE-0001", "Build Co", date(2024, 4, 10), 50000)
+	pe := pay(t, engine, "PE-0002", "Build Co", date(2024, 5, 20), 35000)
+	// Reposted in place: cancelled and posted again under the same number
+	glMap, err := pe.GetGLEntries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := engine.Amend(glMap, ledger.DefaultPostingOptions()); err != nil {
+		t.Fatal(err)
+	}
+
+	q1 := ledger.PeriodDefinition{Name: "Q1", StartDate: date(2024, 4, 1), EndDate: date(2024, 6, 30)}
+	rows, err := CertificateSummary("ACME", q1, glStore.All(), paymentStore.All(), testParties, testCategories, ledger.ReportFlags{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 1 || rows[0].TaxWithheld != 850 {
+		t.Errorf("rows = %+v, want 850 withheld from Build Co", rows)
+	}
+}
+
+func TestComputeWithCertificate(t *testing.T) {
+	c := testCategories["Contractor"]
+	cert := &LowerDeductionCertificate{
+		CertificateNo: "LDC-2024-001", Supplier: "Build Co", TaxWithholdingCategory: "Contractor",
+		ValidFrom: date(2024, 4, 1), ValidUpto: date(2024, 9, 30), Rate: 0.5, CertificateLimit: 60000,
+	}
+	certs := []*LowerDeductionCertificate{cert}
+
+	steps := []struct {
+		name     string
+		date     time.Time
+		amount   float64
+		wantTax  float64
+		wantCert float64
+	}{
+		{"within limit", date(2024, 4, 10), 50000, 250, 50000},
+		{"limit runs out", date(2024, 5, 20), 35000, 300, 10000},
+		{"limit exhausted", date(2024, 6, 1), 40000, 400, 0},
+	}
+	for i, s := range steps {
+		w, err := c.Compute(s.date, s.amount, 0, i > 0, certs)
+		if err != nil {
+			t.Fatalf("%s: Compute() error = %v", s.name, err)
+		}
+		if w.TaxAmount != s.wantTax || w.CertificateAmount != s.wantCert {
+			t.Errorf("%s: tax %.2f on certificate %.2f, want %.2f on %.2f", s.name, w.TaxAmount, w.CertificateAmount, s.wantTax, s.wantCert)
+		}
+		if w.Certificate != "" {
+			if err := cert.Consume("Payment Entry", fmt.Sprintf("PE-%04d", i+1), s.date, w.CertificateAmount); err != nil {
+				t.Fatalf("%s: Consume() error = %v", s.name, err)
+			}
+		}
+	}
+
+	if cert.Utilized != 60000 || len(cert.Utilization) != 2 || cert.IsValid(date(2024, 6, 1)) {
+		t.Errorf("certificate = %+v", cert)
+	}
+	if err := cert.Consume("Payment Entry", "PE-0009", date(2024, 6, 1), 1); !errors.Is(err, ErrCertificateExceeded) {
+		t.Errorf("Expected ErrCertificateExceeded, got %v", err)
+	}
+
+	// A nil certificate outside its validity has no effect
+	nilCert := &LowerDeductionCertificate{CertificateNo: "LDC-NIL", TaxWithholdingCategory: "Contractor",
+		ValidFrom: date(2024, 10, 1), ValidUpto: date(2025, 3, 31), CertificateLimit: 1e6}
+	w, _ := c.Compute(date(2024, 9, 30), 40000, 0, true, []*LowerDeductionCertificate{nilCert})
+	if w.TaxAmount != 400 || w.Certificate != "" {
+		t.Errorf("before validity = %+v", w)
+	}
+	w, _ = c.Compute(date(2024, 10, 1), 40000, 0, true, []*LowerDeductionCertificate{nilCert})
+	if w.TaxAmount != 0 || w.Certificate != "LDC-NIL" {
+		t.Errorf("nil certificate = %+v", w)
+	}
+}
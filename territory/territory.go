@@ -0,0 +1,116 @@
+// Package territory implements the Territory tree that sales documents
+// inherit price list and tax defaults from.
+//
+// Territories form a tree ("All Territories" > "India" > "Tamil Nadu").
+// A customer belongs to one territory; rules written for a territory apply
+// to every territory below it.
+//
+// Migrated from: erpnext/setup/doctype/territory/territory.py
+package territory
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// Territory errors
+var (
+	ErrUnknownTerritory = errors.New("territory not found")
+	ErrCycle            = errors.New("territory tree has a cycle")
+	ErrDuplicate        = errors.New("duplicate territory")
+)
+
+// Territory is a node of the tree.
+// Maps to: Territory doctype (a NestedSet)
+type Territory struct {
+	Name             string
+	Parent           string // Empty for the root
+	DefaultPriceList string // Selling price list for customers in the territory
+	TaxCategory      string // Tax category for customers in the territory, e.g. "Export"
+}
+
+// Tree is an immutable territory tree.
+type Tree struct {
+	nodes map[string]Territory
+}
+
+// NewTree builds a tree. Every parent must be one of the territories and
+// the parent links must not form a cycle.
+func NewTree(territories ...Territory) (*Tree, error) {
+	t := &Tree{nodes: make(map[string]Territory, len(territories))}
+	for _, n := range territories {
+		if _, ok := t.nodes[n.Name]; ok {
+			return nil, fmt.Errorf("%w: %s", ErrDuplicate, n.Name)
+		}
+		t.nodes[n.Name] = n
+	}
+	for _, n := range territories {
+		if n.Parent != "" {
+			if _, ok := t.nodes[n.Parent]; !ok {
+				return nil, fmt.Errorf("%w: %s (parent of %s)", ErrUnknownTerritory, n.Parent, n.Name)
+			}
+		}
+		if slices.Contains(t.parents(n.Parent, len(territories)), n.Name) {
+			return nil, fmt.Errorf("%w: %s", ErrCycle, n.Name)
+		}
+	}
+	return t, nil
+}
+
+// parents returns name and its ancestors, stopping after limit steps.
+func (t *Tree) parents(name string, limit int) []string {
+	var chain []string
+	for name != "" && len(chain) <= limit {
+		chain = append(chain, name)
+		name = t.nodes[name].Parent
+	}
+	return chain
+}
+
+// Get returns a territory by name.
+func (t *Tree) Get(name string) (Territory, bool) {
+	n, ok := t.nodes[name]
+	return n, ok
+}
+
+// Ancestors returns a territory followed by its ancestors up to the root,
+// nearest first. It returns nil for an unknown territory.
+//
+// Maps to: get_ancestors_of("Territory", name), with name prepended
+func (t *Tree) Ancestors(name string) []string {
+	if _, ok := t.nodes[name]; !ok {
+		return nil
+	}
+	return t.parents(name, len(t.nodes))
+}
+
+// IsDescendant reports whether name is ancestor or lies below it. Rules
+// written for a territory use it to match transactions.
+//
+// Maps to: _get_tree_conditions() in accounts/doctype/pricing_rule/utils.py
+func (t *Tree) IsDescendant(name, ancestor string) bool {
+	return slices.Contains(t.Ancestors(name), ancestor)
+}
+
+// PriceList returns the default price list of a territory, inherited from
+// the nearest ancestor that sets one.
+func (t *Tree) PriceList(name string) string {
+	for _, n := range t.Ancestors(name) {
+		if pl := t.nodes[n].DefaultPriceList; pl != "" {
+			return pl
+		}
+	}
+	return ""
+}
+
+// TaxCategory returns the tax category of a territory, inherited from the
+// nearest ancestor that sets one.
+func (t *Tree) TaxCategory(name string) string {
+	for _, n := range t.Ancestors(name) {
+		if c := t.nodes[n].TaxCategory; c != "" {
+			return c
+		}
+	}
+	return ""
+}
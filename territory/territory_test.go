@@ -0,0 +1,66 @@
+package territory
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func testTree(t *testing.T) *Tree {
+	t.Helper()
+	tree, err := NewTree(
+		Territory{Name: "All Territories", DefaultPriceList: "Standard Selling"},
+		Territory{Name: "India", Parent: "All Territories"},
+		Territory{Name: "Tamil Nadu", Parent: "India", TaxCategory: "In-State"},
+		Territory{Name: "Rest Of The World", Parent: "All Territories", DefaultPriceList: "Export USD", TaxCategory: "Export"},
+		Territory{Name: "Germany", Parent: "Rest Of The World", DefaultPriceList: "Export EUR"},
+		Territory{Name: "France", Parent: "Rest Of The World"},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tree
+}
+
+func TestTree(t *testing.T) {
+	tree := testTree(t)
+
+	if got, want := tree.Ancestors("Germany"), []string{"Germany", "Rest Of The World", "All Territories"}; !slices.Equal(got, want) {
+		t.Errorf("Ancestors(Germany) = %q, want %q", got, want)
+	}
+	if tree.Ancestors("Mars") != nil {
+		t.Error("Ancestors of an unknown territory should be nil")
+	}
+	if !tree.IsDescendant("France", "Rest Of The World") || !tree.IsDescendant("India", "India") || tree.IsDescendant("India", "Rest Of The World") {
+		t.Error("IsDescendant mismatch")
+	}
+
+	for name, want := range map[string]string{"Germany": "Export EUR", "France": "Export USD", "Tamil Nadu": "Standard Selling"} {
+		if got := tree.PriceList(name); got != want {
+			t.Errorf("PriceList(%s) = %q, want %q", name, got, want)
+		}
+	}
+	for name, want := range map[string]string{"Germany": "Export", "Tamil Nadu": "In-State", "India": ""} {
+		if got := tree.TaxCategory(name); got != want {
+			t.Errorf("TaxCategory(%s) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestNewTreeErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		nodes []Territory
+		want  error
+	}{
+		{"unknown parent", []Territory{{Name: "India", Parent: "Asia"}}, ErrUnknownTerritory},
+		{"duplicate", []Territory{{Name: "India"}, {Name: "India"}}, ErrDuplicate},
+		{"cycle", []Territory{{Name: "A", Parent: "B"}, {Name: "B", Parent: "A"}}, ErrCycle},
+		{"self parent", []Territory{{Name: "A", Parent: "A"}}, ErrCycle},
+	}
+	for _, tt := range tests {
+		if _, err := NewTree(tt.nodes...); !errors.Is(err, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
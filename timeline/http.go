@@ -0,0 +1,120 @@
+package timeline
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+)
+
+// Handler serves the timeline API:
+//
+//	GET  /documents/{doctype}/{name}/timeline      comments and attachments, oldest first
+//	GET  /documents/{doctype}/{name}/comments      comments
+//	POST /documents/{doctype}/{name}/comments      add {"content": ..., "type": ...}
+//	GET  /documents/{doctype}/{name}/attachments   attachment metadata
+//	POST /documents/{doctype}/{name}/attachments?file_name=...
+//	                                               attach the request body
+//	GET  /attachments/{id}                         download an attachment
+//
+// user returns the authenticated user of a request, as set by the
+// authentication middleware in front of the handler; requests it returns
+// no user for, and every request when user is nil, get 401. The handler
+// does not check who may read a document: put it behind middleware that
+// does. Downloads are served as attachments the browser must not sniff
+// or render. Mount the handler under a prefix with http.StripPrefix.
+func Handler(s *Service, user func(*http.Request) string) http.Handler {
+	who := func(req *http.Request) string {
+		if user == nil {
+			return ""
+		}
+		return user(req)
+	}
+	ref := func(req *http.Request) Ref {
+		return Ref{DocType: req.PathValue("doctype"), Name: req.PathValue("name")}
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /documents/{doctype}/{name}/timeline", func(w http.ResponseWriter, req *http.Request) {
+		events, err := s.Timeline(ref(req))
+		respond(w, http.StatusOK, events, err)
+	})
+	mux.HandleFunc("GET /documents/{doctype}/{name}/comments", func(w http.ResponseWriter, req *http.Request) {
+		comments, err := s.Comments.Comments(ref(req))
+		respond(w, http.StatusOK, comments, err)
+	})
+	mux.HandleFunc("POST /documents/{doctype}/{name}/comments", func(w http.ResponseWriter, req *http.Request) {
+		var body struct {
+			Content string      `json:"content"`
+			Type    CommentType `json:"type"`
+		}
+		if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		c, err := s.Comment(ref(req), body.Type, body.Content, who(req))
+		respond(w, http.StatusCreated, c, err)
+	})
+	mux.HandleFunc("GET /documents/{doctype}/{name}/attachments", func(w http.ResponseWriter, req *http.Request) {
+		attachments, err := s.Attachments.Attachments(ref(req))
+		respond(w, http.StatusOK, attachments, err)
+	})
+	mux.HandleFunc("POST /documents/{doctype}/{name}/attachments", func(w http.ResponseWriter, req *http.Request) {
+		limit := cmp.Or(s.MaxFileSize, DefaultMaxFileSize)
+		content, err := io.ReadAll(io.LimitReader(req.Body, int64(limit)+1))
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		a, err := s.Attach(ref(req), req.URL.Query().Get("file_name"), req.Header.Get("Content-Type"), content, who(req))
+		respond(w, http.StatusCreated, a, err)
+	})
+	mux.HandleFunc("GET /attachments/{id}", func(w http.ResponseWriter, req *http.Request) {
+		a, content, err := s.Attachments.Attachment(req.PathValue("id"))
+		if err != nil {
+			respond(w, 0, nil, err)
+			return
+		}
+		w.Header().Set("Content-Type", a.ContentType)
+		w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+		w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": a.FileName}))
+		w.Header().Set("X-Content-SHA256", a.SHA256)
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		w.Header().Set("Content-Security-Policy", "sandbox")
+		w.Write(content)
+	})
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if who(req) == "" {
+			writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "not authenticated"})
+			return
+		}
+		mux.ServeHTTP(w, req)
+	})
+}
+
+// respond writes v with status, or the error with a matching status.
+func respond(w http.ResponseWriter, status int, v any, err error) {
+	if err == nil {
+		writeJSON(w, status, v)
+		return
+	}
+	status = http.StatusInternalServerError
+	switch {
+	case errors.Is(err, ErrNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, ErrTooLarge):
+		status = http.StatusRequestEntityTooLarge
+	case errors.Is(err, ErrNoReference), errors.Is(err, ErrEmptyComment), errors.Is(err, ErrEmptyFile):
+		status = http.StatusBadRequest
+	}
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
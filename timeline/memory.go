@@ -0,0 +1,76 @@
+package timeline
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+)
+
+// MemoryStore is an in-memory AttachmentStore and CommentStore.
+type MemoryStore struct {
+	mu          sync.Mutex
+	attachments []Attachment
+	contents    map[string][]byte
+	comments    []Comment
+}
+
+// SaveAttachment implements AttachmentStore. IDs are numbered
+// FILE-00001, FILE-00002, ...
+func (s *MemoryStore) SaveAttachment(a *Attachment, content []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.contents == nil {
+		s.contents = make(map[string][]byte)
+	}
+	a.ID = fmt.Sprintf("FILE-%05d", len(s.attachments)+1)
+	s.attachments = append(s.attachments, *a)
+	s.contents[a.ID] = slices.Clone(content)
+	return nil
+}
+
+// Attachments implements AttachmentStore.
+func (s *MemoryStore) Attachments(ref Ref) ([]Attachment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []Attachment
+	for _, a := range s.attachments {
+		if a.Ref == ref {
+			out = append(out, a)
+		}
+	}
+	return out, nil
+}
+
+// Attachment implements AttachmentStore.
+func (s *MemoryStore) Attachment(id string) (*Attachment, []byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, a := range s.attachments {
+		if a.ID == id {
+			return &a, slices.Clone(s.contents[id]), nil
+		}
+	}
+	return nil, nil, fmt.Errorf("%w: attachment %s", ErrNotFound, id)
+}
+
+// SaveComment implements CommentStore. IDs are numbered CMT-00001, ...
+func (s *MemoryStore) SaveComment(c *Comment) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c.ID = fmt.Sprintf("CMT-%05d", len(s.comments)+1)
+	s.comments = append(s.comments, *c)
+	return nil
+}
+
+// Comments implements CommentStore.
+func (s *MemoryStore) Comments(ref Ref) ([]Comment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []Comment
+	for _, c := range s.comments {
+		if c.Ref == ref {
+			out = append(out, c)
+		}
+	}
+	return out, nil
+}
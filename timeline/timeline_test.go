@@ -0,0 +1,119 @@
+package timeline
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testService() *Service {
+	store := &MemoryStore{}
+	now := time.Date(2024, 4, 1, 9, 0, 0, 0, time.UTC)
+	return &Service{Attachments: store, Comments: store, MaxFileSize: 64, Now: func() time.Time {
+		now = now.Add(time.Minute)
+		return now
+	}}
+}
+
+func TestService(t *testing.T) {
+	s := testService()
+	jv := Ref{DocType: "Journal Entry", Name: "JV-0001"}
+
+	a, err := s.Attach(jv, `C:\scans\invoice.pdf`, "", []byte("%PDF-1.4 scan"), "auditor@acme")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.ID != "FILE-00001" || a.FileName != "invoice.pdf" || a.ContentType != "application/octet-stream" || a.Size != 13 {
+		t.Errorf("attachment = %+v", a)
+	}
+	if !a.Verify([]byte("%PDF-1.4 scan")) || a.Verify([]byte("tampered")) {
+		t.Error("Verify does not check the content")
+	}
+	if _, err := s.Comment(jv, Correction, "  Reverses JV-0000 posted to the wrong cost center ", "accounts@acme"); err != nil {
+		t.Fatal(err)
+	}
+	s.Comment(Ref{DocType: "Journal Entry", Name: "JV-0002"}, "", "Other voucher", "")
+
+	events, err := s.Timeline(jv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 2 || events[0].Attachment == nil || events[1].Comment == nil ||
+		events[1].Comment.Content != "Reverses JV-0000 posted to the wrong cost center" || events[1].Comment.Type != Correction {
+		t.Fatalf("timeline = %+v", events)
+	}
+
+	for _, tt := range []struct {
+		err  error
+		want error
+	}{
+		{func() error { _, err := s.Attach(Ref{}, "a.txt", "", []byte("x"), ""); return err }(), ErrNoReference},
+		{func() error { _, err := s.Attach(jv, "a.txt", "", nil, ""); return err }(), ErrEmptyFile},
+		{func() error { _, err := s.Attach(jv, "a.txt", "", make([]byte, 65), ""); return err }(), ErrTooLarge},
+		{func() error { _, err := s.Comment(jv, Remark, " ", ""); return err }(), ErrEmptyComment},
+	} {
+		if !errors.Is(tt.err, tt.want) {
+			t.Errorf("err = %v, want %v", tt.err, tt.want)
+		}
+	}
+}
+
+func TestHandler(t *testing.T) {
+	s := testService()
+	srv := httptest.NewServer(Handler(s, func(req *http.Request) string { return req.Header.Get("X-User") }))
+	defer srv.Close()
+
+	do := func(method, path, contentType, body string) *http.Response {
+		req, _ := http.NewRequest(method, srv.URL+path, strings.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		req.Header.Set("X-User", "auditor@acme")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	resp := do("POST", "/documents/Sales%20Invoice/SINV-1/attachments?file_name=po.txt", "text/plain", "PO 4711")
+	var a Attachment
+	json.NewDecoder(resp.Body).Decode(&a)
+	if resp.StatusCode != http.StatusCreated || a.Ref.DocType != "Sales Invoice" || a.Owner != "auditor@acme" {
+		t.Fatalf("attach: %d %+v", resp.StatusCode, a)
+	}
+	if resp := do("POST", "/documents/Sales%20Invoice/SINV-1/comments", "application/json", `{"content":"PO checked"}`); resp.StatusCode != http.StatusCreated {
+		t.Errorf("comment: %d", resp.StatusCode)
+	}
+	if resp := do("POST", "/documents/Sales%20Invoice/SINV-1/attachments", "text/plain", strings.Repeat("x", 65)); resp.StatusCode != http.StatusRequestEntityTooLarge {
+		t.Errorf("large file: %d", resp.StatusCode)
+	}
+
+	resp = do("GET", "/documents/Sales%20Invoice/SINV-1/timeline", "", "")
+	var events []Event
+	json.NewDecoder(resp.Body).Decode(&events)
+	if len(events) != 2 || events[1].Comment.Owner != "auditor@acme" {
+		t.Errorf("timeline = %+v", events)
+	}
+
+	resp = do("GET", "/attachments/"+a.ID, "", "")
+	body, _ := io.ReadAll(resp.Body)
+	if string(body) != "PO 4711" || resp.Header.Get("Content-Type") != "text/plain" || resp.Header.Get("X-Content-SHA256") != a.SHA256 ||
+		!strings.Contains(resp.Header.Get("Content-Disposition"), "po.txt") || resp.Header.Get("X-Content-Type-Options") != "nosniff" {
+		t.Errorf("download: %q %v", body, resp.Header)
+	}
+	if resp := do("GET", "/attachments/FILE-99999", "", ""); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("missing attachment: %d", resp.StatusCode)
+	}
+
+	anonymous, err := http.Get(srv.URL + "/attachments/" + a.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if anonymous.StatusCode != http.StatusUnauthorized {
+		t.Errorf("download without a user: %d", anonymous.StatusCode)
+	}
+}
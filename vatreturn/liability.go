@@ -0,0 +1,266 @@
+package vatreturn
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/daterange"
+	"github.com/senguttuvang/erpnext-go/ledger"
+	"github.com/senguttuvang/erpnext-go/reportio"
+)
+
+// Liability reconciliation errors
+var (
+	ErrInvalidTaxPeriods = errors.New("tax periods must be bounded and consecutive")
+	ErrSharedTaxAccount  = errors.New("account belongs to more than one tax head")
+)
+
+// TaxHead is a tax collected into its own output accounts and deposited
+// with the authority on its own, such as CGST, SGST and IGST under GST,
+// or VAT.
+type TaxHead struct {
+	Name     string
+	Accounts []string // Output tax accounts
+}
+
+// LiabilitySetup configures the reconciliation of tax collected with tax
+// deposited.
+type LiabilitySetup struct {
+	Heads []TaxHead
+
+	// DepositAccounts are the bank and cash accounts tax is paid from. A
+	// voucher crediting them and debiting a head's accounts deposits that
+	// tax; every other movement of the accounts is tax collected, net of
+	// credit notes, reversals and input tax set off.
+	DepositAccounts []string
+
+	// DueDays is how many days after the end of a period its tax must be
+	// deposited, such as 20 for GSTR-3B filers.
+	DueDays int
+}
+
+// PeriodLiability is the tax of one head for one period.
+type PeriodLiability struct {
+	Period    daterange.Range
+	Due       time.Time // Last day to deposit the period's tax
+	Collected float64   // Tax of the period, net of credit notes and set-offs
+	Deposited float64   // Deposits applied to the period
+	Late      float64   // Of Deposited, paid after Due
+	Unpaid    float64   // Collected less Deposited
+}
+
+// TaxDeposit is a payment of tax to the authority, and the periods it
+// paid. Deposits pay the oldest unpaid liability first.
+type TaxDeposit struct {
+	VoucherType string
+	VoucherNo   string
+	PostingDate time.Time
+	Amount      float64
+
+	// For is the period the deposit was expected to pay: the last one
+	// ended by its posting date. It is the zero Range, standing for the
+	// opening liability, for a deposit posted in the first period.
+	For daterange.Range
+
+	// Paid are the periods it was applied to, the zero Range standing
+	// for the opening liability.
+	Paid []daterange.Range
+
+	// Mismatched is set when the deposit paid a period other than For,
+	// or more than the liability outstanding.
+	Mismatched bool
+}
+
+// HeadLiability reconciles one tax head over the periods.
+type HeadLiability struct {
+	Head     string
+	Opening  float64 // Liability unpaid before the first period; negative when paid ahead
+	Periods  []PeriodLiability
+	Deposits []TaxDeposit // In date order
+	Advance  float64      // Deposited beyond all liability
+	Unpaid   float64      // Liability still unpaid at the end of the last period
+}
+
+// LiabilityReport reconciles tax collected with tax deposited, by head and
+// period, as is needed before filing a GST or VAT return.
+type LiabilityReport struct {
+	Company string
+	Heads   []HeadLiability
+}
+
+// ReconcileLiability builds the report for a company over consecutive
+// periods from GL entries. Entries before the first period make up the
+// opening liability; entries after the last are ignored. Opening and
+// period closing entries within the periods count only as flags say.
+// Cancelled vouchers net to zero with their reversing entries, so every
+// entry is summed as stored.
+//
+// Maps to: the tax liability and the cash ledger payments of the GSTR-3B
+// report in India Compliance, and the "VAT due" and payments of an HMRC
+// VAT account, from the GL of the tax accounts
+func ReconcileLiability(setup LiabilitySetup, company string, periods []daterange.Range, entries []ledger.GLEntry, flags ledger.ReportFlags) (*LiabilityReport, error) {
+	for i, p := range periods {
+		if !p.Bounded() || p.Empty() || (i > 0 && !p.Start.Equal(periods[i-1].End)) {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidTaxPeriods, p)
+		}
+	}
+	headOf := map[string]int{}
+	for i, h := range setup.Heads {
+		for _, acc := range h.Accounts {
+			if _, ok := headOf[acc]; ok {
+				return nil, fmt.Errorf("%w: %s", ErrSharedTaxAccount, acc)
+			}
+			headOf[acc] = i
+		}
+	}
+	report := &LiabilityReport{Company: company}
+	if len(periods) == 0 {
+		return report, nil
+	}
+	end := periods[len(periods)-1].End
+
+	// Net movement of each voucher on each head, and on the deposit
+	// accounts, by posting date
+	type voucher struct {
+		voucherType, voucherNo string
+		date                   time.Time
+	}
+	var order []voucher
+	heads := map[voucher][]float64{} // Credit minus debit, by head
+	paid := map[voucher]float64{}    // Credit minus debit of the deposit accounts
+	for _, e := range entries {
+		if e.Company != company || !daterange.Civil(e.PostingDate).Before(end) ||
+			periodIndex(periods, e.PostingDate) >= 0 && !flags.Counts(&e) {
+			continue
+		}
+		v := voucher{e.VoucherType, e.VoucherNo, daterange.Civil(e.PostingDate)}
+		if _, ok := heads[v]; !ok {
+			heads[v] = make([]float64, len(setup.Heads))
+			order = append(order, v)
+		}
+		if h, ok := headOf[e.Account]; ok {
+			heads[v][h] += e.Credit - e.Debit
+		} else if slices.Contains(setup.DepositAccounts, e.Account) {
+			paid[v] += e.Credit - e.Debit
+		}
+	}
+	slices.SortStableFunc(order, func(a, b voucher) int { return a.date.Compare(b.date) })
+
+	for h, head := range setup.Heads {
+		hl := HeadLiability{Head: head.Name}
+		for _, p := range periods {
+			hl.Periods = append(hl.Periods, PeriodLiability{Period: p, Due: p.Last().AddDate(0, 0, setup.DueDays)})
+		}
+		for _, v := range order {
+			amount := heads[v][h]
+			switch i := periodIndex(periods, v.date); {
+			case i < 0:
+				hl.Opening += amount
+			case amount < 0 && ledger.Flt(paid[v], 2) > 0:
+				hl.Deposits = append(hl.Deposits, TaxDeposit{
+					VoucherType: v.voucherType, VoucherNo: v.voucherNo, PostingDate: v.date, Amount: -amount,
+				})
+			default:
+				hl.Periods[i].Collected += amount
+			}
+		}
+		hl.allocate(periods)
+		report.Heads = append(report.Heads, hl)
+	}
+	return report, nil
+}
+
+// periodIndex returns the index of the period containing date, or -1 for
+// a date before the first.
+func periodIndex(periods []daterange.Range, date time.Time) int {
+	return slices.IndexFunc(periods, func(p daterange.Range) bool { return p.Contains(date) })
+}
+
+// allocate applies the deposits to the opening liability and then to the
+// periods, oldest first, and works out what is unpaid. Tax paid ahead
+// before the first period is applied first.
+func (hl *HeadLiability) allocate(periods []daterange.Range) {
+	hl.Opening = ledger.Flt(hl.Opening, 2)
+	unpaidOpening := max(hl.Opening, 0)
+	ahead := max(-hl.Opening, 0)
+	for i := range hl.Periods {
+		p := &hl.Periods[i]
+		p.Collected = ledger.Flt(p.Collected, 2)
+		applied := min(ahead, max(p.Collected, 0))
+		p.Deposited = applied
+		p.Unpaid = ledger.Flt(p.Collected-applied, 2)
+		ahead = ledger.Flt(ahead-applied, 2)
+	}
+	hl.Advance = ahead
+	for d := range hl.Deposits {
+		dep := &hl.Deposits[d]
+		dep.Amount = ledger.Flt(dep.Amount, 2)
+		if i := periodIndex(periods, dep.PostingDate); i > 0 {
+			dep.For = periods[i-1]
+		}
+		left := dep.Amount
+		if unpaidOpening > 0 {
+			applied := min(left, unpaidOpening)
+			unpaidOpening = ledger.Flt(unpaidOpening-applied, 2)
+			left = ledger.Flt(left-applied, 2)
+			dep.Paid = append(dep.Paid, daterange.Range{})
+		}
+		for i := range hl.Periods {
+			p := &hl.Periods[i]
+			if left <= 0 {
+				break
+			}
+			if p.Unpaid <= 0 {
+				continue
+			}
+			applied := min(left, p.Unpaid)
+			p.Unpaid = ledger.Flt(p.Unpaid-applied, 2)
+			p.Deposited = ledger.Flt(p.Deposited+applied, 2)
+			if dep.PostingDate.After(p.Due) {
+				p.Late = ledger.Flt(p.Late+applied, 2)
+			}
+			left = ledger.Flt(left-applied, 2)
+			dep.Paid = append(dep.Paid, p.Period)
+		}
+		hl.Advance = ledger.Flt(hl.Advance+left, 2)
+		dep.Mismatched = left > 0 || slices.ContainsFunc(dep.Paid, func(r daterange.Range) bool { return r != dep.For })
+	}
+	hl.Unpaid = unpaidOpening
+	for _, p := range hl.Periods {
+		hl.Unpaid += p.Unpaid
+	}
+	hl.Unpaid = ledger.Flt(hl.Unpaid, 2)
+}
+
+// Title implements reportio.Titled.
+func (r *LiabilityReport) Title() string {
+	return "Tax Liability " + r.Company
+}
+
+// Columns implements reportio.Report, for XLSX and JSON Lines downloads.
+func (r *LiabilityReport) Columns() []reportio.Column {
+	return []reportio.Column{
+		{Fieldname: "tax_head", Label: "Tax Head", Fieldtype: reportio.Data},
+		{Fieldname: "from_date", Label: "From Date", Fieldtype: reportio.Date},
+		{Fieldname: "to_date", Label: "To Date", Fieldtype: reportio.Date},
+		{Fieldname: "due_date", Label: "Due Date", Fieldtype: reportio.Date},
+		{Fieldname: "collected", Label: "Collected", Fieldtype: reportio.Currency},
+		{Fieldname: "deposited", Label: "Deposited", Fieldtype: reportio.Currency},
+		{Fieldname: "late", Label: "Deposited Late", Fieldtype: reportio.Currency},
+		{Fieldname: "unpaid", Label: "Unpaid", Fieldtype: reportio.Currency},
+	}
+}
+
+// Rows implements reportio.Report: a row per head and period.
+func (r *LiabilityReport) Rows(fn func([]any) error) error {
+	for _, h := range r.Heads {
+		for _, p := range h.Periods {
+			if err := fn([]any{h.Head, p.Period.Start, p.Period.Last(), p.Due, p.Collected, p.Deposited, p.Late, p.Unpaid}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
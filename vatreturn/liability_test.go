@@ -0,0 +1,118 @@
+package vatreturn
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/senguttuvang/erpnext-go/daterange"
+	"github.com/senguttuvang/erpnext-go/ledger"
+	"github.com/senguttuvang/erpnext-go/reportio"
+)
+
+var (
+	_ reportio.Report = (*LiabilityReport)(nil)
+	_ reportio.Titled = (*LiabilityReport)(nil)
+)
+
+func TestReconcileLiability(t *testing.T) {
+	setup := LiabilitySetup{
+		Heads: []TaxHead{
+			{Name: "CGST", Accounts: []string{"Output CGST - ACME"}},
+			{Name: "SGST", Accounts: []string{"Output SGST - ACME"}},
+		},
+		DepositAccounts: []string{"Bank - ACME"},
+		DueDays:         20,
+	}
+	jan := daterange.Month(date(2024, 1, 1))
+	feb := daterange.Month(date(2024, 2, 1))
+	mar := daterange.Month(date(2024, 3, 1))
+	entries := []ledger.GLEntry{
+		entry(date(2023, 12, 20), "SINV-0", "Output CGST - ACME", 0, 50),
+		entry(date(2024, 1, 10), "SINV-1", "Output CGST - ACME", 0, 90),
+		entry(date(2024, 1, 10), "SINV-1", "Output SGST - ACME", 0, 90),
+		entry(date(2024, 1, 15), "PAY-0", "Output CGST - ACME", 50, 0),
+		entry(date(2024, 1, 15), "PAY-0", "Bank - ACME", 0, 50),
+		entry(date(2024, 2, 5), "SINV-2", "Output CGST - ACME", 0, 100),
+		entry(date(2024, 2, 5), "SINV-2", "Output SGST - ACME", 0, 100),
+		entry(date(2024, 2, 12), "PAY-1", "Output CGST - ACME", 90, 0),
+		entry(date(2024, 2, 12), "PAY-1", "Output SGST - ACME", 90, 0),
+		entry(date(2024, 2, 12), "PAY-1", "Bank - ACME", 0, 180),
+		entry(date(2024, 2, 20), "SRET-1", "Output CGST - ACME", 10, 0), // Credit note
+		entry(date(2024, 2, 20), "SRET-1", "Output SGST - ACME", 10, 0),
+		entry(date(2024, 3, 5), "PAY-3", "Output SGST - ACME", 150, 0),
+		entry(date(2024, 3, 5), "PAY-3", "Bank - ACME", 0, 150),
+		entry(date(2024, 3, 10), "SINV-3", "Output CGST - ACME", 0, 40),
+		entry(date(2024, 3, 25), "PAY-2", "Output CGST - ACME", 60, 0),
+		entry(date(2024, 3, 25), "PAY-2", "Bank - ACME", 0, 60),
+		entry(date(2024, 4, 1), "SINV-4", "Output CGST - ACME", 0, 999), // After the last period
+	}
+	opening := entry(date(2024, 3, 1), "JV-1", "Output SGST - ACME", 0, 25)
+	opening.IsOpening = ledger.IsOpeningYes
+	entries = append(entries, opening)
+
+	r, err := ReconcileLiability(setup, "ACME UK", []daterange.Range{jan, feb, mar}, entries, ledger.ReportFlags{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Heads) != 2 {
+		t.Fatalf("heads = %+v", r.Heads)
+	}
+
+	cgst := r.Heads[0]
+	want := []PeriodLiability{
+		{Period: jan, Due: date(2024, 2, 20), Collected: 90, Deposited: 90},
+		{Period: feb, Due: date(2024, 3, 20), Collected: 90, Deposited: 60, Late: 60, Unpaid: 30},
+		{Period: mar, Due: date(2024, 4, 20), Collected: 40, Unpaid: 40},
+	}
+	for i, p := range cgst.Periods {
+		if p != want[i] {
+			t.Errorf("CGST %s = %+v, want %+v", p.Period, p, want[i])
+		}
+	}
+	if cgst.Opening != 50 || cgst.Unpaid != 70 || cgst.Advance != 0 || len(cgst.Deposits) != 3 {
+		t.Errorf("CGST = %+v", cgst)
+	}
+	for _, d := range cgst.Deposits {
+		if d.Mismatched {
+			t.Errorf("CGST deposit %s mismatched: for %s, paid %v", d.VoucherNo, d.For, d.Paid)
+		}
+	}
+	if d := cgst.Deposits[0]; d.VoucherNo != "PAY-0" || len(d.Paid) != 1 || d.Paid[0] != (daterange.Range{}) {
+		t.Errorf("PAY-0 should pay the opening liability: %+v", d)
+	}
+
+	// A deposit for February that runs on into March, beyond all liability
+	sgst := r.Heads[1]
+	if sgst.Unpaid != 0 || sgst.Advance != 60 || sgst.Periods[1].Deposited != 90 || sgst.Periods[1].Late != 0 {
+		t.Errorf("SGST = %+v", sgst)
+	}
+	if d := sgst.Deposits[1]; d.VoucherNo != "PAY-3" || d.For != feb || !d.Mismatched {
+		t.Errorf("PAY-3 = %+v", d)
+	}
+
+	// An opening balance booked in March is tax of the period only when
+	// asked for
+	with, err := ReconcileLiability(setup, "ACME UK", []daterange.Range{jan, feb, mar}, entries, ledger.ReportFlags{IncludeOpening: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sgst := with.Heads[1]; sgst.Periods[2].Collected != 25 || sgst.Advance != 35 {
+		t.Errorf("SGST with opening entries = %+v", sgst)
+	}
+
+	var rows int
+	if err := r.Rows(func([]any) error { rows++; return nil }); err != nil || rows != 6 {
+		t.Errorf("Rows() = %d rows, %v", rows, err)
+	}
+}
+
+func TestReconcileLiabilityErrors(t *testing.T) {
+	jan, mar := daterange.Month(date(2024, 1, 1)), daterange.Month(date(2024, 3, 1))
+	if _, err := ReconcileLiability(LiabilitySetup{}, "ACME UK", []daterange.Range{jan, mar}, nil, ledger.ReportFlags{}); !errors.Is(err, ErrInvalidTaxPeriods) {
+		t.Errorf("gap between periods: err = %v", err)
+	}
+	shared := LiabilitySetup{Heads: []TaxHead{{Name: "A", Accounts: []string{"Tax"}}, {Name: "B", Accounts: []string{"Tax"}}}}
+	if _, err := ReconcileLiability(shared, "ACME UK", []daterange.Range{jan}, nil, ledger.ReportFlags{}); !errors.Is(err, ErrSharedTaxAccount) {
+		t.Errorf("shared account: err = %v", err)
+	}
+}
@@ -0,0 +1,125 @@
+// Package vatreturn builds periodic VAT returns from the general ledger.
+//
+// A Layout lists the boxes of a return form (the UK VAT100, the UAE
+// VAT201, ...). Each box sums the GL movement of its accounts over the
+// period, or totals earlier boxes, so a country's form is configuration
+// rather than code. ReconcileLiability checks the tax collected against
+// the tax deposited with the authority before a return is filed.
+//
+// Maps to: erpnext/regional/report/uae_vat_201/uae_vat_201.py, generalised
+// from fixed queries to configured boxes
+package vatreturn
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/senguttuvang/erpnext-go/taxcalc"
+)
+
+// Layout errors
+var (
+	ErrUnknownBox   = errors.New("box refers to an unknown or later box")
+	ErrDuplicateBox = errors.New("duplicate box code")
+)
+
+// Side selects which direction of GL movement counts as positive.
+type Side string
+
+const (
+	CreditSide Side = "Credit" // Output VAT and sales: credit minus debit
+	DebitSide  Side = "Debit"  // Input VAT and purchases: debit minus credit
+)
+
+// AccountSource adds an account's movement over the period to a box.
+type AccountSource struct {
+	Account string
+	Side    Side
+}
+
+// BoxTerm adds (Sign 1) or subtracts (Sign -1) an earlier box.
+type BoxTerm struct {
+	Box  string
+	Sign float64
+}
+
+// Box is one line of a return form. A box either sums accounts or totals
+// earlier boxes.
+type Box struct {
+	Code       string // As printed on the form, e.g. "1" or "1a"
+	Label      string
+	Accounts   []AccountSource
+	Terms      []BoxTerm
+	WholeUnits bool // Drop the fractional part, as for VAT100 boxes 6 to 9
+}
+
+// Layout is a return form.
+type Layout struct {
+	Name  string
+	Boxes []Box
+}
+
+// Validate checks that box codes are unique and that totals only refer to
+// earlier boxes.
+func (l *Layout) Validate() error {
+	seen := make(map[string]bool, len(l.Boxes))
+	for _, box := range l.Boxes {
+		if seen[box.Code] {
+			return fmt.Errorf("%w: %s", ErrDuplicateBox, box.Code)
+		}
+		for _, term := range box.Terms {
+			if !seen[term.Box] {
+				return fmt.Errorf("%w: box %s refers to %s", ErrUnknownBox, box.Code, term.Box)
+			}
+		}
+		seen[box.Code] = true
+	}
+	return nil
+}
+
+// TemplateAccounts returns the tax accounts of templates as sources on one
+// side, so a box can be defined as "all VAT charged by these templates".
+// Accounts shared by several templates are listed once.
+func TemplateAccounts(side Side, templates ...*taxcalc.TaxTemplate) []AccountSource {
+	var sources []AccountSource
+	seen := make(map[string]bool)
+	for _, t := range templates {
+		for _, tax := range t.Taxes {
+			if tax.AccountHead == "" || seen[tax.AccountHead] {
+				continue
+			}
+			seen[tax.AccountHead] = true
+			sources = append(sources, AccountSource{Account: tax.AccountHead, Side: side})
+		}
+	}
+	return sources
+}
+
+// VAT100Accounts configures the UK VAT100 layout.
+type VAT100Accounts struct {
+	OutputVAT      []AccountSource // Box 1: VAT due on sales
+	AcquisitionVAT []AccountSource // Box 2: VAT due on acquisitions from the EU (Northern Ireland)
+	InputVAT       []AccountSource // Box 4: VAT reclaimed on purchases
+	Sales          []AccountSource // Box 6: sales excluding VAT
+	Purchases      []AccountSource // Box 7: purchases excluding VAT
+	EUSupplies     []AccountSource // Box 8: supplies of goods to the EU
+	EUAcquisitions []AccountSource // Box 9: acquisitions of goods from the EU
+}
+
+// VAT100 returns the UK VAT100 layout over the given accounts.
+func VAT100(a VAT100Accounts) Layout {
+	return Layout{
+		Name: "VAT100",
+		Boxes: []Box{
+			{Code: "1", Label: "VAT due on sales and other outputs", Accounts: a.OutputVAT},
+			{Code: "2", Label: "VAT due on acquisitions from EU member states", Accounts: a.AcquisitionVAT},
+			{Code: "3", Label: "Total VAT due", Terms: []BoxTerm{{"1", 1}, {"2", 1}}},
+			{Code: "4", Label: "VAT reclaimed on purchases and other inputs", Accounts: a.InputVAT},
+			{Code: "5", Label: "Net VAT to pay or reclaim", Terms: []BoxTerm{{"3", 1}, {"4", -1}}},
+			{Code: "6", Label: "Total value of sales excluding VAT", Accounts: a.Sales, WholeUnits: true},
+			{Code: "7", Label: "Total value of purchases excluding VAT", Accounts: a.Purchases, WholeUnits: true},
+			{Code: "8", Label: "Total value of supplies of goods to EU member states", Accounts: a.EUSupplies, WholeUnits: true},
+			{Code: "9", Label: "Total value of acquisitions of goods from EU member states", Accounts: a.EUAcquisitions, WholeUnits: true},
+		},
+	}
+}
@@ -0,0 +1,140 @@
+package mtd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/ledger"
+)
+
+// HTTPDoer is the part of *http.Client the client needs.
+type HTTPDoer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// APIError is an error response from HMRC.
+type APIError struct {
+	StatusCode int
+	Code       string `json:"code"`
+	Message    string `json:"message"`
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("hmrc: %d %s: %s", e.StatusCode, e.Code, e.Message)
+}
+
+// Client implements Service over the HMRC VAT (MTD) API.
+type Client struct {
+	BaseURL string // SandboxURL or ProductionURL
+	HTTP    HTTPDoer
+	Tokens  *TokenSource
+	Fraud   FraudPrevention
+}
+
+var _ Service = (*Client)(nil)
+
+// Obligations implements Service.
+func (c *Client) Obligations(ctx context.Context, vrn string, from, to time.Time, status ObligationStatus) ([]Obligation, error) {
+	q := dateRange(from, to)
+	if status != "" {
+		q.Set("status", string(status))
+	}
+	var body struct {
+		Obligations []Obligation `json:"obligations"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/organisations/vat/"+url.PathEscape(vrn)+"/obligations", q, nil, &body); err != nil {
+		return nil, err
+	}
+	return body.Obligations, nil
+}
+
+// SubmitReturn implements Service.
+func (c *Client) SubmitReturn(ctx context.Context, vrn string, ret VATReturn) (*Receipt, error) {
+	if ret.PeriodKey == "" {
+		return nil, ErrMissingPeriodKey
+	}
+	var receipt Receipt
+	if err := c.do(ctx, http.MethodPost, "/organisations/vat/"+url.PathEscape(vrn)+"/returns", nil, ret, &receipt); err != nil {
+		return nil, err
+	}
+	return &receipt, nil
+}
+
+// Liabilities implements Service.
+func (c *Client) Liabilities(ctx context.Context, vrn string, from, to time.Time) ([]Liability, error) {
+	var body struct {
+		Liabilities []Liability `json:"liabilities"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/organisations/vat/"+url.PathEscape(vrn)+"/liabilities", dateRange(from, to), nil, &body); err != nil {
+		return nil, err
+	}
+	return body.Liabilities, nil
+}
+
+// do sends an authorised request with fraud prevention headers and decodes
+// the JSON response into out.
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, in, out any) error {
+	if err := c.Fraud.Validate(); err != nil {
+		return err
+	}
+	token, err := c.Tokens.AccessToken(ctx)
+	if err != nil {
+		return err
+	}
+
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+
+	u := c.BaseURL + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.hmrc.1.0+json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	c.Fraud.Apply(req.Header)
+
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		return ErrNotAuthorized
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		apiErr := &APIError{StatusCode: resp.StatusCode}
+		_ = json.NewDecoder(resp.Body).Decode(apiErr)
+		return apiErr
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+func dateRange(from, to time.Time) url.Values {
+	return url.Values{
+		"from": {from.Format(ledger.DateLayout)},
+		"to":   {to.Format(ledger.DateLayout)},
+	}
+}
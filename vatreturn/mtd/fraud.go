@@ -0,0 +1,108 @@
+package mtd
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// FraudPrevention holds the client and vendor details HMRC requires as
+// Gov-Client-* and Gov-Vendor-* headers on every API call.
+//
+// Reference: https://developer.service.hmrc.gov.uk/guides/fraud-prevention/
+type FraudPrevention struct {
+	ConnectionMethod  string            // e.g. "DESKTOP_APP_DIRECT" or "WEB_APP_VIA_SERVER"
+	DeviceID          string            // Persistent identifier of the device
+	UserIDs           map[string]string // e.g. {"os": "jdoe"}
+	Timezone          string            // e.g. "UTC+01:00"
+	LocalIPs          []string
+	LocalIPsTimestamp time.Time
+	MACAddresses      []string
+	Screens           map[string]string // width, height, scaling-factor, colour-depth
+	WindowSize        map[string]string // width, height
+	UserAgent         map[string]string // os-family, os-version, device-manufacturer, device-model
+	MultiFactor       string
+	VendorVersion     map[string]string // product name -> version
+	VendorProductName string
+	VendorLicenseIDs  map[string]string
+}
+
+// Validate checks the headers HMRC rejects calls without.
+func (f *FraudPrevention) Validate() error {
+	required := []struct {
+		header  string
+		missing bool
+	}{
+		{"Gov-Client-Connection-Method", f.ConnectionMethod == ""},
+		{"Gov-Client-Device-ID", f.DeviceID == ""},
+		{"Gov-Client-User-IDs", len(f.UserIDs) == 0},
+		{"Gov-Client-Timezone", f.Timezone == ""},
+		{"Gov-Client-User-Agent", len(f.UserAgent) == 0},
+		{"Gov-Vendor-Version", len(f.VendorVersion) == 0},
+		{"Gov-Vendor-Product-Name", f.VendorProductName == ""},
+	}
+	for _, r := range required {
+		if r.missing {
+			return fmt.Errorf("%w: %s", ErrMissingFraudHeader, r.header)
+		}
+	}
+	return nil
+}
+
+// Apply sets the headers on a request. Empty values are omitted.
+func (f *FraudPrevention) Apply(h http.Header) {
+	set := func(name, value string) {
+		if value != "" {
+			h.Set(name, value)
+		}
+	}
+	set("Gov-Client-Connection-Method", f.ConnectionMethod)
+	set("Gov-Client-Device-ID", f.DeviceID)
+	set("Gov-Client-User-IDs", encodePairs(f.UserIDs))
+	set("Gov-Client-Timezone", f.Timezone)
+	set("Gov-Client-Local-IPs", encodeList(f.LocalIPs))
+	if !f.LocalIPsTimestamp.IsZero() {
+		set("Gov-Client-Local-IPs-Timestamp", f.LocalIPsTimestamp.UTC().Format("2006-01-02T15:04:05.000Z"))
+	}
+	set("Gov-Client-MAC-Addresses", encodeList(f.MACAddresses))
+	set("Gov-Client-Screens", encodePairs(f.Screens))
+	set("Gov-Client-Window-Size", encodePairs(f.WindowSize))
+	set("Gov-Client-User-Agent", encodePairs(f.UserAgent))
+	set("Gov-Client-Multi-Factor", f.MultiFactor)
+	set("Gov-Vendor-Version", encodePairs(f.VendorVersion))
+	set("Gov-Vendor-Product-Name", percentEncode(f.VendorProductName))
+	set("Gov-Vendor-License-IDs", encodePairs(f.VendorLicenseIDs))
+}
+
+// encodePairs renders key=value pairs joined by "&", percent-encoded and
+// sorted by key so headers are stable.
+func encodePairs(pairs map[string]string) string {
+	keys := make([]string, 0, len(pairs))
+	for k := range pairs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		parts[i] = percentEncode(k) + "=" + percentEncode(pairs[k])
+	}
+	return strings.Join(parts, "&")
+}
+
+// encodeList renders a comma-separated list of percent-encoded values.
+func encodeList(values []string) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		parts[i] = percentEncode(v)
+	}
+	return strings.Join(parts, ",")
+}
+
+// percentEncode encodes as RFC 3986 requires: spaces become %20, not "+".
+func percentEncode(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
@@ -0,0 +1,127 @@
+// Package mtd submits VAT returns to HMRC under Making Tax Digital.
+//
+// Service is the port used by the rest of the application; Client
+// implements it over the HMRC VAT (MTD) REST API, including the OAuth 2.0
+// token refresh and the fraud prevention headers HMRC requires on every
+// call. Tests and offline deployments substitute their own Service.
+//
+// API reference: https://developer.service.hmrc.gov.uk/api-documentation/docs/api/service/vat-api/1.0
+package mtd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/ledger"
+	"github.com/senguttuvang/erpnext-go/vatreturn"
+)
+
+// Base URLs of the HMRC APIs
+const (
+	SandboxURL    = "https://test-api.service.hmrc.gov.uk"
+	ProductionURL = "https://api.service.hmrc.gov.uk"
+)
+
+// MTD errors
+var (
+	ErrNotVAT100          = errors.New("return is not a VAT100 return")
+	ErrMissingPeriodKey   = errors.New("period key is mandatory")
+	ErrNotAuthorized      = errors.New("not authorised with HMRC")
+	ErrMissingFraudHeader = errors.New("fraud prevention header is missing")
+)
+
+// ObligationStatus is the state of a VAT obligation.
+type ObligationStatus string
+
+const (
+	Open      ObligationStatus = "O"
+	Fulfilled ObligationStatus = "F"
+)
+
+// Obligation is a VAT period HMRC expects a return for.
+type Obligation struct {
+	PeriodKey string           `json:"periodKey"`
+	Start     string           `json:"start"`
+	End       string           `json:"end"`
+	Due       string           `json:"due"`
+	Status    ObligationStatus `json:"status"`
+	Received  string           `json:"received,omitempty"`
+}
+
+// Liability is an amount owed to HMRC.
+type Liability struct {
+	TaxPeriod struct {
+		From string `json:"from"`
+		To   string `json:"to"`
+	} `json:"taxPeriod"`
+	Type              string  `json:"type"`
+	OriginalAmount    float64 `json:"originalAmount"`
+	OutstandingAmount float64 `json:"outstandingAmount"`
+	Due               string  `json:"due"`
+}
+
+// VATReturn is the payload of a return submission.
+type VATReturn struct {
+	PeriodKey                    string  `json:"periodKey"`
+	VATDueSales                  float64 `json:"vatDueSales"`
+	VATDueAcquisitions           float64 `json:"vatDueAcquisitions"`
+	TotalVATDue                  float64 `json:"totalVatDue"`
+	VATReclaimedCurrPeriod       float64 `json:"vatReclaimedCurrPeriod"`
+	NetVATDue                    float64 `json:"netVatDue"`
+	TotalValueSalesExVAT         float64 `json:"totalValueSalesExVAT"`
+	TotalValuePurchasesExVAT     float64 `json:"totalValuePurchasesExVAT"`
+	TotalValueGoodsSuppliedExVAT float64 `json:"totalValueGoodsSuppliedExVAT"`
+	TotalAcquisitionsExVAT       float64 `json:"totalAcquisitionsExVAT"`
+	Finalised                    bool    `json:"finalised"`
+}
+
+// Receipt is HMRC's acknowledgement of a submitted return.
+type Receipt struct {
+	ProcessingDate   string `json:"processingDate"`
+	PaymentIndicator string `json:"paymentIndicator,omitempty"`
+	FormBundleNumber string `json:"formBundleNumber"`
+	ChargeRefNumber  string `json:"chargeRefNumber,omitempty"`
+}
+
+// Service is the MTD VAT port.
+type Service interface {
+	// Obligations returns the obligations between two dates; an empty
+	// status returns all of them.
+	Obligations(ctx context.Context, vrn string, from, to time.Time, status ObligationStatus) ([]Obligation, error)
+
+	// SubmitReturn files a finalised return.
+	SubmitReturn(ctx context.Context, vrn string, ret VATReturn) (*Receipt, error)
+
+	// Liabilities returns the amounts owed between two dates.
+	Liabilities(ctx context.Context, vrn string, from, to time.Time) ([]Liability, error)
+}
+
+// NewVATReturn maps a computed VAT100 return to the submission payload.
+// HMRC expects the net VAT as a positive amount whichever way it is due,
+// and boxes 6 to 9 in whole pounds.
+func NewVATReturn(ret *vatreturn.Return, periodKey string) (VATReturn, error) {
+	if ret.Layout != "VAT100" {
+		return VATReturn{}, fmt.Errorf("%w: %s", ErrNotVAT100, ret.Layout)
+	}
+	if periodKey == "" {
+		return VATReturn{}, ErrMissingPeriodKey
+	}
+
+	box := func(code string) float64 { return ledger.Flt(ret.Amount(code), 2) }
+	return VATReturn{
+		PeriodKey:                    periodKey,
+		VATDueSales:                  box("1"),
+		VATDueAcquisitions:           box("2"),
+		TotalVATDue:                  box("3"),
+		VATReclaimedCurrPeriod:       box("4"),
+		NetVATDue:                    math.Abs(box("5")),
+		TotalValueSalesExVAT:         math.Trunc(box("6")),
+		TotalValuePurchasesExVAT:     math.Trunc(box("7")),
+		TotalValueGoodsSuppliedExVAT: math.Trunc(box("8")),
+		TotalAcquisitionsExVAT:       math.Trunc(box("9")),
+		Finalised:                    true,
+	}, nil
+}
@@ -0,0 +1,145 @@
+package mtd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/vatreturn"
+)
+
+var testFraud = FraudPrevention{
+	ConnectionMethod:  "DESKTOP_APP_DIRECT",
+	DeviceID:          "beec798b-b366-47fa-b1f8-92cede14a1ce",
+	UserIDs:           map[string]string{"os": "jane doe"},
+	Timezone:          "UTC+00:00",
+	UserAgent:         map[string]string{"os-family": "Linux", "os-version": "6.1"},
+	VendorVersion:     map[string]string{"erpnext-go": "1.0.0"},
+	VendorProductName: "erpnext-go",
+}
+
+// newHMRC fakes the token and VAT endpoints.
+func newHMRC(t *testing.T) (*httptest.Server, *[]VATReturn) {
+	t.Helper()
+	var submitted []VATReturn
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /oauth/token", func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("grant_type") != "refresh_token" || r.FormValue("refresh_token") != "refresh-1" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{"access_token": "access-2", "refresh_token": "refresh-2", "expires_in": 14400})
+	})
+	mux.HandleFunc("GET /organisations/vat/{vrn}/obligations", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer access-2" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.Header.Get("Gov-Client-User-IDs") != "os=jane%20doe" || r.URL.Query().Get("status") != "O" {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(APIError{Code: "INVALID_REQUEST", Message: "bad headers or query"})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{"obligations": []Obligation{
+			{PeriodKey: "24A1", Start: "2024-01-01", End: "2024-03-31", Due: "2024-05-07", Status: Open},
+		}})
+	})
+	mux.HandleFunc("POST /organisations/vat/{vrn}/returns", func(w http.ResponseWriter, r *http.Request) {
+		var ret VATReturn
+		json.NewDecoder(r.Body).Decode(&ret)
+		if ret.PeriodKey == "24A2" {
+			w.WriteHeader(http.StatusForbidden)
+			json.NewEncoder(w).Encode(APIError{Code: "DUPLICATE_SUBMISSION", Message: "already submitted"})
+			return
+		}
+		submitted = append(submitted, ret)
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(Receipt{ProcessingDate: "2024-04-20T10:00:00Z", FormBundleNumber: "256660290587"})
+	})
+	mux.HandleFunc("GET /organisations/vat/{vrn}/liabilities", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"liabilities":[{"taxPeriod":{"from":"2024-01-01","to":"2024-03-31"},"type":"VAT Return Debit Charge","originalAmount":100.04,"outstandingAmount":100.04,"due":"2024-05-07"}]}`))
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server, &submitted
+}
+
+func TestClient(t *testing.T) {
+	server, submitted := newHMRC(t)
+	config := OAuthConfig{BaseURL: server.URL, ClientID: "id", ClientSecret: "secret", RedirectURI: "http://localhost/callback"}
+
+	var persisted *Token
+	tokens := NewTokenSource(config, server.Client(), &Token{AccessToken: "access-1", RefreshToken: "refresh-1", ExpiresAt: time.Now()})
+	tokens.OnRefresh = func(tok *Token) error { persisted = tok; return nil }
+
+	client := &Client{BaseURL: server.URL, HTTP: server.Client(), Tokens: tokens, Fraud: testFraud}
+	ctx := context.Background()
+	from, to := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC)
+
+	obligations, err := client.Obligations(ctx, "123456789", from, to, Open)
+	if err != nil {
+		t.Fatalf("Obligations() error = %v", err)
+	}
+	if len(obligations) != 1 || obligations[0].PeriodKey != "24A1" {
+		t.Errorf("Obligations() = %+v", obligations)
+	}
+	if persisted == nil || persisted.RefreshToken != "refresh-2" {
+		t.Errorf("refreshed token not persisted: %+v", persisted)
+	}
+
+	ret := &vatreturn.Return{Layout: "VAT100", Boxes: []vatreturn.BoxAmount{
+		{Code: "1", Amount: 180.12}, {Code: "3", Amount: 180.12}, {Code: "4", Amount: 280.08},
+		{Code: "5", Amount: -99.96}, {Code: "6", Amount: 900}, {Code: "7", Amount: 1400},
+	}}
+	payload, err := NewVATReturn(ret, "24A1")
+	if err != nil {
+		t.Fatalf("NewVATReturn() error = %v", err)
+	}
+	receipt, err := client.SubmitReturn(ctx, "123456789", payload)
+	if err != nil {
+		t.Fatalf("SubmitReturn() error = %v", err)
+	}
+	if receipt.FormBundleNumber != "256660290587" || len(*submitted) != 1 {
+		t.Errorf("receipt = %+v", receipt)
+	}
+	if got := (*submitted)[0]; got.NetVATDue != 99.96 || !got.Finalised || got.TotalValuePurchasesExVAT != 1400 {
+		t.Errorf("submitted = %+v", got)
+	}
+
+	payload.PeriodKey = "24A2"
+	var apiErr *APIError
+	if _, err := client.SubmitReturn(ctx, "123456789", payload); !errors.As(err, &apiErr) || apiErr.Code != "DUPLICATE_SUBMISSION" {
+		t.Errorf("Expected DUPLICATE_SUBMISSION, got %v", err)
+	}
+
+	liabilities, err := client.Liabilities(ctx, "123456789", from, to)
+	if err != nil || len(liabilities) != 1 || liabilities[0].OutstandingAmount != 100.04 {
+		t.Errorf("Liabilities() = %+v, %v", liabilities, err)
+	}
+
+	client.Fraud.DeviceID = ""
+	if _, err := client.Liabilities(ctx, "123456789", from, to); !errors.Is(err, ErrMissingFraudHeader) {
+		t.Errorf("Expected ErrMissingFraudHeader, got %v", err)
+	}
+}
+
+func TestNewVATReturn(t *testing.T) {
+	if _, err := NewVATReturn(&vatreturn.Return{Layout: "VAT201"}, "24A1"); !errors.Is(err, ErrNotVAT100) {
+		t.Errorf("Expected ErrNotVAT100, got %v", err)
+	}
+	if _, err := NewVATReturn(&vatreturn.Return{Layout: "VAT100"}, ""); !errors.Is(err, ErrMissingPeriodKey) {
+		t.Errorf("Expected ErrMissingPeriodKey, got %v", err)
+	}
+}
+
+func TestAuthCodeURL(t *testing.T) {
+	got := OAuthConfig{BaseURL: SandboxURL, ClientID: "id", RedirectURI: "http://localhost/cb"}.AuthCodeURL("xyz")
+	if !strings.HasPrefix(got, SandboxURL+"/oauth/authorize?") || !strings.Contains(got, "scope=read%3Avat+write%3Avat") {
+		t.Errorf("AuthCodeURL() = %s", got)
+	}
+}
@@ -0,0 +1,136 @@
+package mtd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Scopes needed to read obligations and submit returns.
+var Scopes = []string{"read:vat", "write:vat"}
+
+// Token is an OAuth 2.0 user access token.
+type Token struct {
+	AccessToken  string
+	RefreshToken string
+	ExpiresAt    time.Time
+}
+
+// OAuthConfig identifies the application registered with HMRC.
+type OAuthConfig struct {
+	BaseURL      string // SandboxURL or ProductionURL
+	ClientID     string
+	ClientSecret string
+	RedirectURI  string
+}
+
+// AuthCodeURL returns the page where the user grants the application
+// access; HMRC redirects back to RedirectURI with a code.
+func (c OAuthConfig) AuthCodeURL(state string) string {
+	q := url.Values{
+		"response_type": {"code"},
+		"client_id":     {c.ClientID},
+		"scope":         {strings.Join(Scopes, " ")},
+		"redirect_uri":  {c.RedirectURI},
+		"state":         {state},
+	}
+	return c.BaseURL + "/oauth/authorize?" + q.Encode()
+}
+
+// Exchange trades an authorisation code for a token.
+func (c OAuthConfig) Exchange(ctx context.Context, client HTTPDoer, code string) (*Token, error) {
+	return c.token(ctx, client, url.Values{
+		"grant_type":   {"authorization_code"},
+		"code":         {code},
+		"redirect_uri": {c.RedirectURI},
+	})
+}
+
+// Refresh obtains a new token with a refresh token.
+func (c OAuthConfig) Refresh(ctx context.Context, client HTTPDoer, refreshToken string) (*Token, error) {
+	return c.token(ctx, client, url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {refreshToken},
+	})
+}
+
+func (c OAuthConfig) token(ctx context.Context, client HTTPDoer, form url.Values) (*Token, error) {
+	form.Set("client_id", c.ClientID)
+	form.Set("client_secret", c.ClientSecret)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.BaseURL+"/oauth/token", strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: token request returned %s", ErrNotAuthorized, resp.Status)
+	}
+
+	var body struct {
+		AccessToken  string `json:"access_token"`
+		RefreshToken string `json:"refresh_token"`
+		ExpiresIn    int    `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, err
+	}
+	return &Token{
+		AccessToken:  body.AccessToken,
+		RefreshToken: body.RefreshToken,
+		ExpiresAt:    time.Now().Add(time.Duration(body.ExpiresIn) * time.Second),
+	}, nil
+}
+
+// TokenSource hands out a valid access token, refreshing it shortly before
+// it expires. OnRefresh, if set, is called with every new token so it can
+// be persisted; HMRC refresh tokens are single use.
+type TokenSource struct {
+	Config    OAuthConfig
+	HTTP      HTTPDoer
+	OnRefresh func(*Token) error
+
+	mu    sync.Mutex
+	token *Token
+}
+
+// NewTokenSource returns a source starting from a stored token.
+func NewTokenSource(config OAuthConfig, client HTTPDoer, token *Token) *TokenSource {
+	return &TokenSource{Config: config, HTTP: client, token: token}
+}
+
+// AccessToken returns a token valid for at least another minute.
+func (s *TokenSource) AccessToken(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.token == nil {
+		return "", ErrNotAuthorized
+	}
+	if time.Until(s.token.ExpiresAt) > time.Minute {
+		return s.token.AccessToken, nil
+	}
+
+	token, err := s.Config.Refresh(ctx, s.HTTP, s.token.RefreshToken)
+	if err != nil {
+		return "", err
+	}
+	if s.OnRefresh != nil {
+		if err := s.OnRefresh(token); err != nil {
+			return "", err
+		}
+	}
+	s.token = token
+	return token.AccessToken, nil
+}
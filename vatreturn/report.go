@@ -0,0 +1,156 @@
+package vatreturn
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+
+	"github.com/senguttuvang/erpnext-go/ledger"
+	"github.com/senguttuvang/erpnext-go/reportio"
+)
+
+// BoxAmount is the computed value of a box.
+type BoxAmount struct {
+	Code   string  `json:"box"`
+	Label  string  `json:"label"`
+	Amount float64 `json:"amount"`
+}
+
+// Return is a computed VAT return.
+type Return struct {
+	Layout  string      `json:"layout"`
+	Company string      `json:"company"`
+	From    string      `json:"from"`
+	To      string      `json:"to"`
+	Boxes   []BoxAmount `json:"boxes"`
+}
+
+// Compute builds a return for a company and period from GL entries.
+// Opening and period closing entries are excluded unless flags include
+// them. Cancelled vouchers net to zero with their reversing entries, so
+// every entry is summed as stored.
+func Compute(layout Layout, company string, period ledger.PeriodDefinition, entries []ledger.GLEntry, flags ledger.ReportFlags) (*Return, error) {
+	if err := layout.Validate(); err != nil {
+		return nil, err
+	}
+
+	movement := make(map[string]float64) // account -> debit minus credit
+	for _, e := range entries {
+		if e.Company != company || !flags.Counts(&e) || !period.Contains(e.PostingDate) {
+			continue
+		}
+		movement[e.Account] += e.Debit - e.Credit
+	}
+
+	ret := &Return{
+		Layout:  layout.Name,
+		Company: company,
+		From:    period.StartDate.Format(ledger.DateLayout),
+		To:      period.EndDate.Format(ledger.DateLayout),
+	}
+	amounts := make(map[string]float64, len(layout.Boxes))
+	for _, box := range layout.Boxes {
+		var amount float64
+		for _, src := range box.Accounts {
+			if src.Side == CreditSide {
+				amount -= movement[src.Account]
+			} else {
+				amount += movement[src.Account]
+			}
+		}
+		for _, term := range box.Terms {
+			amount += term.Sign * amounts[term.Box]
+		}
+
+		amount = ledger.Flt(amount, 2)
+		if box.WholeUnits {
+			amount = math.Trunc(amount)
+		}
+		amounts[box.Code] = amount
+		ret.Boxes = append(ret.Boxes, BoxAmount{Code: box.Code, Label: box.Label, Amount: amount})
+	}
+
+	return ret, nil
+}
+
+// ComputeFor builds a return from the entries a user may see, as scoped
+// by the Authorizer.
+func ComputeFor(auth ledger.Authorizer, user string, layout Layout, company string, period ledger.PeriodDefinition, entries []ledger.GLEntry, flags ledger.ReportFlags) (*Return, error) {
+	entries, err := ledger.AuthorizedEntries(auth, user, company, entries)
+	if err != nil {
+		return nil, err
+	}
+	return Compute(layout, company, period, entries, flags)
+}
+
+// Amount returns the value of a box, or zero if the layout has no such box.
+func (r *Return) Amount(code string) float64 {
+	for _, b := range r.Boxes {
+		if b.Code == code {
+			return b.Amount
+		}
+	}
+	return 0
+}
+
+// Summary renders the return as an aligned text table for review.
+func (r *Return) Summary() string {
+	width := 0
+	for _, b := range r.Boxes {
+		width = max(width, len(b.Label))
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s return for %s, %s to %s\n", r.Layout, r.Company, r.From, r.To)
+	for _, b := range r.Boxes {
+		fmt.Fprintf(&sb, "%-4s %-*s %14.2f\n", b.Code, width, b.Label, b.Amount)
+	}
+	return sb.String()
+}
+
+// JSON returns the return as indented JSON for submission tooling.
+func (r *Return) JSON() ([]byte, error) {
+	return json.MarshalIndent(r, "", "  ")
+}
+
+// WriteCSV writes one row per box with a header row.
+func (r *Return) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"Box", "Label", "Amount"}); err != nil {
+		return err
+	}
+	for _, b := range r.Boxes {
+		if err := cw.Write([]string{b.Code, b.Label, fmt.Sprintf("%.2f", b.Amount)}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// Title implements reportio.Titled.
+func (r *Return) Title() string {
+	return r.Layout + " " + r.From + " to " + r.To
+}
+
+// Columns implements reportio.Report, for XLSX and JSON Lines downloads.
+func (r *Return) Columns() []reportio.Column {
+	return []reportio.Column{
+		{Fieldname: "box", Label: "Box", Fieldtype: reportio.Data},
+		{Fieldname: "label", Label: "Label", Fieldtype: reportio.Data},
+		{Fieldname: "amount", Label: "Amount", Fieldtype: reportio.Currency},
+	}
+}
+
+// Rows implements reportio.Report.
+func (r *Return) Rows(fn func([]any) error) error {
+	for _, b := range r.Boxes {
+		if err := fn([]any{b.Code, b.Label, b.Amount}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+package vatreturn
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/ledger"
+	"github.com/senguttuvang/erpnext-go/reportio"
+	"github.com/senguttuvang/erpnext-go/taxcalc"
+)
+
+var (
+	_ reportio.Report = (*Return)(nil)
+	_ reportio.Titled = (*Return)(nil)
+)
+
+func date(y int, m time.Month, d int) time.Time {
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+func entry(posted time.Time, voucherNo, account string, debit, credit float64) ledger.GLEntry {
+	return ledger.GLEntry{
+		PostingDate: posted, Company: "ACME UK", VoucherType: "Journal Entry", VoucherNo: voucherNo,
+		Account: account, Debit: debit, Credit: credit, IsOpening: ledger.IsOpeningNo,
+	}
+}
+
+func testLayout() Layout {
+	salesVAT := &taxcalc.TaxTemplate{Name: "UK VAT 20%", Taxes: []taxcalc.TaxRow{{AccountHead: "VAT Output - ACME"}}}
+	reducedVAT := &taxcalc.TaxTemplate{Name: "UK VAT 5%", Taxes: []taxcalc.TaxRow{{AccountHead: "VAT Output - ACME"}}}
+
+	return VAT100(VAT100Accounts{
+		OutputVAT: TemplateAccounts(CreditSide, salesVAT, reducedVAT),
+		InputVAT:  []AccountSource{{Account: "VAT Input - ACME", Side: DebitSide}},
+		Sales:     []AccountSource{{Account: "Sales - ACME", Side: CreditSide}},
+		Purchases: []AccountSource{{Account: "Purchases - ACME", Side: DebitSide}},
+	})
+}
+
+func TestCompute(t *testing.T) {
+	q1 := ledger.PeriodDefinition{StartDate: date(2024, 1, 1), EndDate: date(2024, 3, 31)}
+	entries := []ledger.GLEntry{
+		entry(date(2024, 1, 15), "SINV-1", "Sales - ACME", 0, 1000.60),
+		entry(date(2024, 1, 15), "SINV-1", "VAT Output - ACME", 0, 200.12),
+		entry(date(2024, 2, 10), "PINV-1", "Purchases - ACME", 400.40, 0),
+		entry(date(2024, 2, 10), "PINV-1", "VAT Input - ACME", 80.08, 0),
+		entry(date(2024, 3, 5), "SRET-1", "Sales - ACME", 100, 0),
+		entry(date(2024, 3, 5), "SRET-1", "VAT Output - ACME", 20, 0),
+		entry(date(2024, 4, 1), "SINV-2", "VAT Output - ACME", 0, 999), // Next quarter
+	}
+	opening := entry(date(2024, 1, 1), "OPEN-1", "VAT Output - ACME", 0, 500)
+	opening.IsOpening = ledger.IsOpeningYes
+	entries = append(entries, opening)
+
+	ret, err := Compute(testLayout(), "ACME UK", q1, entries, ledger.ReportFlags{})
+	if err != nil {
+		t.Fatalf("Compute() error = %v", err)
+	}
+
+	want := map[string]float64{"1": 180.12, "2": 0, "3": 180.12, "4": 80.08, "5": 100.04, "6": 900, "7": 400}
+	for code, amount := range want {
+		if got := ret.Amount(code); got != amount {
+			t.Errorf("box %s = %.2f, want %.2f", code, got, amount)
+		}
+	}
+
+	if !strings.Contains(ret.Summary(), "Net VAT to pay or reclaim") {
+		t.Errorf("Summary() missing box 5:\n%s", ret.Summary())
+	}
+
+	data, err := ret.JSON()
+	if err != nil {
+		t.Fatalf("JSON() error = %v", err)
+	}
+	var decoded Return
+	if err := json.Unmarshal(data, &decoded); err != nil || decoded.Amount("5") != 100.04 || decoded.From != "2024-01-01" {
+		t.Errorf("JSON round trip = %+v, %v", decoded, err)
+	}
+
+	var buf bytes.Buffer
+	if err := ret.WriteCSV(&buf); err != nil {
+		t.Fatalf("WriteCSV() error = %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 10 || lines[5] != "5,Net VAT to pay or reclaim,100.04" {
+		t.Errorf("CSV = %q", lines)
+	}
+}
+
+func TestLayoutValidate(t *testing.T) {
+	forward := Layout{Boxes: []Box{{Code: "3", Terms: []BoxTerm{{Box: "1", Sign: 1}}}, {Code: "1"}}}
+	if err := forward.Validate(); !errors.Is(err, ErrUnknownBox) {
+		t.Errorf("Expected ErrUnknownBox, got %v", err)
+	}
+	duplicate := Layout{Boxes: []Box{{Code: "1"}, {Code: "1"}}}
+	if err := duplicate.Validate(); !errors.Is(err, ErrDuplicateBox) {
+		t.Errorf("Expected ErrDuplicateBox, got %v", err)
+	}
+}
+
+// denyAll grants nothing.
+type denyAll struct{}
+
+func (denyAll) Authorize(user string, action ledger.Action, voucherType, company string) error {
+	return ledger.ErrNotPermitted
+}
+
+func (denyAll) ReportScope(user, company string) (ledger.ReportScope, error) {
+	return ledger.ReportScope{}, ledger.ErrNotPermitted
+}
+
+func TestComputeFor(t *testing.T) {
+	q1 := ledger.PeriodDefinition{StartDate: date(2024, 1, 1), EndDate: date(2024, 3, 31)}
+	entries := []ledger.GLEntry{entry(date(2024, 1, 15), "SINV-1", "VAT Output - ACME", 0, 200)}
+
+	if _, err := ComputeFor(denyAll{}, "guest", testLayout(), "ACME UK", q1, entries, ledger.ReportFlags{}); !errors.Is(err, ledger.ErrNotPermitted) {
+		t.Errorf("Expected ErrNotPermitted, got %v", err)
+	}
+	ret, err := ComputeFor(nil, "", testLayout(), "ACME UK", q1, entries, ledger.ReportFlags{})
+	if err != nil || ret.Amount("1") != 200 {
+		t.Errorf("ComputeFor() = %+v, %v", ret, err)
+	}
+}
+
+func TestReturnReport(t *testing.T) {
+	ret := &Return{Layout: "VAT100", From: "2024-01-01", To: "2024-03-31", Boxes: []BoxAmount{{Code: "1", Label: "VAT due on sales", Amount: 180.1}}}
+	var buf bytes.Buffer
+	if err := reportio.Write(&buf, reportio.JSONL, ret); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), `{"amount":180.1,"box":"1","label":"VAT due on sales"}`) {
+		t.Errorf("unexpected JSON Lines:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,329 @@
+// Package webhooks delivers accounting events to external systems.
+//
+// Each Endpoint subscribes to event types (gl.posted, invoice.paid, ...).
+// The Dispatcher receives events from the ledger's EventPublisher, posts
+// them as signed JSON to every subscribed endpoint, retries failed
+// deliveries with exponential backoff and records every attempt in a
+// DeliveryLog.
+//
+// Migrated from: frappe/integrations/doctype/webhook/webhook.py
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/ledger"
+)
+
+// Webhook errors
+var (
+	ErrDeliveryFailed = errors.New("webhook delivery failed")
+	ErrNoURL          = errors.New("webhook endpoint has no URL")
+)
+
+// Request headers sent with every delivery.
+const (
+	HeaderSignature = "X-Frappe-Webhook-Signature" // Base64 HMAC-SHA256 of the body
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderDelivery  = "X-Webhook-Delivery" // Same ID on every retry of a delivery
+)
+
+// Delivery defaults
+const (
+	DefaultMaxAttempts = 5
+	DefaultBaseDelay   = time.Second
+	DefaultMaxDelay    = 5 * time.Minute
+	DefaultTimeout     = 10 * time.Second
+)
+
+// Endpoint is an external URL subscribed to event types.
+//
+// Maps to: Webhook doctype (request_url, webhook_secret, webhook_docevent,
+// webhook_headers, enabled)
+type Endpoint struct {
+	Name    string
+	URL     string
+	Secret  string   // Signs the body when set
+	Events  []string // Event types delivered; all events when empty
+	Headers map[string]string
+	Enabled bool
+}
+
+// Subscribed reports whether the endpoint receives an event type.
+func (e Endpoint) Subscribed(eventType string) bool {
+	return e.Enabled && (len(e.Events) == 0 || slices.Contains(e.Events, eventType))
+}
+
+// Payload is the JSON body posted to endpoints.
+type Payload struct {
+	ID    string    `json:"id"`
+	Event string    `json:"event"`
+	Time  time.Time `json:"time"`
+	Data  EventData `json:"data"`
+}
+
+// EventData carries the event's fields.
+type EventData struct {
+	Company     string         `json:"company,omitempty"`
+	VoucherType string         `json:"voucher_type,omitempty"`
+	VoucherNo   string         `json:"voucher_no,omitempty"`
+	PostingDate string         `json:"posting_date,omitempty"`
+	PartyType   string         `json:"party_type,omitempty"`
+	Party       string         `json:"party,omitempty"`
+	Amount      float64        `json:"amount"`
+	Details     map[string]any `json:"details,omitempty"`
+}
+
+// NewPayload builds the payload for an event with a fresh delivery ID.
+func NewPayload(event ledger.Event) Payload {
+	p := Payload{
+		ID: newID(), Event: event.Type, Time: event.Time,
+		Data: EventData{
+			Company: event.Company, VoucherType: event.VoucherType, VoucherNo: event.VoucherNo,
+			PartyType: event.PartyType, Party: event.Party, Amount: event.Amount, Details: event.Data,
+		},
+	}
+	if !event.PostingDate.IsZero() {
+		p.Data.PostingDate = event.PostingDate.Format(ledger.DateLayout)
+	}
+	return p
+}
+
+func newID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// Sign returns the signature of a body: base64 HMAC-SHA256 keyed by secret.
+//
+// Maps to: get_webhook_headers() in webhook.py
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether signature is the body's signature, for receivers.
+func Verify(secret string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, body)), []byte(signature))
+}
+
+// Attempt is one delivery attempt.
+type Attempt struct {
+	DeliveryID string
+	Endpoint   string
+	Event      string
+	Attempt    int // 1 for the first try
+	At         time.Time
+	Duration   time.Duration
+	StatusCode int    // 0 when no response was received
+	Error      string // Empty on success
+	Payload    []byte
+}
+
+// Succeeded reports whether the endpoint accepted the delivery.
+func (a Attempt) Succeeded() bool {
+	return a.Error == ""
+}
+
+// DeliveryLog records delivery attempts.
+//
+// Maps to: Webhook Request Log doctype
+type DeliveryLog interface {
+	Record(attempt Attempt) error
+}
+
+// MemoryLog is an in-memory DeliveryLog.
+type MemoryLog struct {
+	mu       sync.Mutex
+	attempts []Attempt
+}
+
+// Record implements DeliveryLog.
+func (l *MemoryLog) Record(attempt Attempt) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.attempts = append(l.attempts, attempt)
+	return nil
+}
+
+// Attempts returns the attempts recorded for an endpoint, or all attempts
+// when endpoint is empty, oldest first.
+func (l *MemoryLog) Attempts(endpoint string) []Attempt {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var result []Attempt
+	for _, a := range l.attempts {
+		if endpoint == "" || a.Endpoint == endpoint {
+			result = append(result, a)
+		}
+	}
+	return result
+}
+
+// Dispatcher delivers events to endpoints.
+type Dispatcher struct {
+	Endpoints []Endpoint
+	Client    *http.Client // Uses DefaultTimeout when nil
+	Log       DeliveryLog  // Optional
+
+	MaxAttempts int           // DefaultMaxAttempts when zero
+	BaseDelay   time.Duration // Delay before the first retry; DefaultBaseDelay when zero
+	MaxDelay    time.Duration // Cap on the retry delay; DefaultMaxDelay when zero
+
+	OnError func(endpoint string, err error) // Optional; receives failures from Publish
+
+	wg sync.WaitGroup
+}
+
+// Publish implements ledger.EventPublisher. Deliveries run in the
+// background; Wait blocks until they finish.
+func (d *Dispatcher) Publish(event ledger.Event) {
+	for _, endpoint := range d.Endpoints {
+		if !endpoint.Subscribed(event.Type) {
+			continue
+		}
+		d.wg.Add(1)
+		go func() {
+			defer d.wg.Done()
+			if err := d.Deliver(context.Background(), endpoint, event); err != nil && d.OnError != nil {
+				d.OnError(endpoint.Name, err)
+			}
+		}()
+	}
+}
+
+// Wait blocks until background deliveries finish.
+func (d *Dispatcher) Wait() {
+	d.wg.Wait()
+}
+
+// Deliver posts an event to one endpoint, retrying with exponential
+// backoff on network errors, 429 and 5xx responses. Other 4xx responses
+// are not retried.
+//
+// Maps to: enqueue_webhook() in webhook.py
+func (d *Dispatcher) Deliver(ctx context.Context, endpoint Endpoint, event ledger.Event) error {
+	if endpoint.URL == "" {
+		return fmt.Errorf("%w: %s", ErrNoURL, endpoint.Name)
+	}
+	payload := NewPayload(event)
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	maxAttempts := d.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = DefaultMaxAttempts
+	}
+	var lastErr error
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		if attempt > 1 {
+			select {
+			case <-ctx.Done():
+				return errors.Join(lastErr, ctx.Err())
+			case <-time.After(d.backoff(attempt - 1)):
+			}
+		}
+
+		record := Attempt{
+			DeliveryID: payload.ID, Endpoint: endpoint.Name, Event: event.Type,
+			Attempt: attempt, At: time.Now(), Payload: body,
+		}
+		status, err := d.post(ctx, endpoint, payload, body)
+		record.Duration = time.Since(record.At)
+		record.StatusCode = status
+		if err != nil {
+			record.Error = err.Error()
+		}
+		if d.Log != nil {
+			if logErr := d.Log.Record(record); logErr != nil {
+				return logErr
+			}
+		}
+		if err == nil {
+			return nil
+		}
+		lastErr = err
+		if !retryable(status) {
+			break
+		}
+	}
+	return fmt.Errorf("%w: %s: %v", ErrDeliveryFailed, endpoint.Name, lastErr)
+}
+
+// backoff returns the delay before retry n (1-based): BaseDelay doubled
+// for each earlier retry, capped at MaxDelay.
+func (d *Dispatcher) backoff(n int) time.Duration {
+	base, limit := d.BaseDelay, d.MaxDelay
+	if base <= 0 {
+		base = DefaultBaseDelay
+	}
+	if limit <= 0 {
+		limit = DefaultMaxDelay
+	}
+	delay := base
+	for i := 1; i < n && delay < limit; i++ {
+		delay *= 2
+	}
+	return min(delay, limit)
+}
+
+func (d *Dispatcher) post(ctx context.Context, endpoint Endpoint, payload Payload, body []byte) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	for k, v := range endpoint.Headers {
+		req.Header.Set(k, v)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, payload.Event)
+	req.Header.Set(HeaderDelivery, payload.ID)
+	if endpoint.Secret != "" {
+		req.Header.Set(HeaderSignature, Sign(endpoint.Secret, body))
+	}
+
+	client := d.Client
+	if client == nil {
+		client = &http.Client{Timeout: DefaultTimeout}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("POST %s: %s", endpoint.URL, resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// retryable reports whether a failed attempt may succeed later: a
+// transport error, rate limiting or a server error.
+func retryable(status int) bool {
+	switch {
+	case status == 0: // No response: refused, reset or timed out
+		return true
+	case status == http.StatusTooManyRequests, status >= 500:
+		return true
+	}
+	return false
+}
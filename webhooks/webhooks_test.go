@@ -0,0 +1,162 @@
+package webhooks
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/ledger"
+	"github.com/senguttuvang/erpnext-go/ledger/memstore"
+)
+
+// receiver answers with the queued status codes, then 200. A queued 0
+// drops the connection without a response.
+type receiver struct {
+	mu       sync.Mutex
+	statuses []int
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func (r *receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.requests = append(r.requests, req)
+	r.bodies = append(r.bodies, body)
+	if len(r.statuses) > 0 {
+		status := r.statuses[0]
+		r.statuses = r.statuses[1:]
+		if status == 0 {
+			if conn, _, err := http.NewResponseController(w).Hijack(); err == nil {
+				conn.Close()
+			}
+			return
+		}
+		w.WriteHeader(status)
+	}
+}
+
+func postingDate() time.Time {
+	return time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+}
+
+func TestDispatcherFromEngine(t *testing.T) {
+	recv := &receiver{}
+	srv := httptest.NewServer(recv)
+	defer srv.Close()
+
+	log := &MemoryLog{}
+	d := &Dispatcher{
+		Endpoints: []Endpoint{
+			{Name: "books", URL: srv.URL, Secret: "s3cret", Events: []string{ledger.EventGLPosted}, Enabled: true},
+			{Name: "paid-only", URL: srv.URL, Events: []string{ledger.EventInvoicePaid}, Enabled: true},
+			{Name: "off", URL: srv.URL, Enabled: false},
+		},
+		Log:     log,
+		OnError: func(name string, err error) { t.Errorf("%s: %v", name, err) },
+	}
+	engine := &ledger.Engine{GLStore: memstore.NewGLStore(), Events: d}
+	glMap := []ledger.GLEntry{
+		{Company: "ABC", Account: "Debtors - ABC", PartyType: "Customer", Party: "Acme", Debit: 500, DebitInAccountCurrency: 500,
+			VoucherType: "Sales Invoice", VoucherNo: "SINV-1", PostingDate: postingDate()},
+		{Company: "ABC", Account: "Sales - ABC", Credit: 500, CreditInAccountCurrency: 500,
+			VoucherType: "Sales Invoice", VoucherNo: "SINV-1", PostingDate: postingDate()},
+	}
+	if err := engine.MakeGLEntries(glMap, ledger.DefaultPostingOptions()); err != nil {
+		t.Fatal(err)
+	}
+	d.Wait()
+
+	if len(recv.requests) != 1 {
+		t.Fatalf("got %d requests, want 1", len(recv.requests))
+	}
+	req, body := recv.requests[0], recv.bodies[0]
+	if !Verify("s3cret", body, req.Header.Get(HeaderSignature)) {
+		t.Error("signature does not verify")
+	}
+	if Verify("other", body, req.Header.Get(HeaderSignature)) {
+		t.Error("signature verifies with the wrong secret")
+	}
+	if req.Header.Get(HeaderEvent) != ledger.EventGLPosted {
+		t.Errorf("event header = %q", req.Header.Get(HeaderEvent))
+	}
+
+	var p Payload
+	if err := json.Unmarshal(body, &p); err != nil {
+		t.Fatal(err)
+	}
+	if p.ID != req.Header.Get(HeaderDelivery) || p.Data.VoucherNo != "SINV-1" || p.Data.Amount != 500 ||
+		p.Data.Party != "Acme" || p.Data.PostingDate != "2026-03-01" {
+		t.Errorf("payload = %+v", p)
+	}
+
+	attempts := log.Attempts("books")
+	if len(attempts) != 1 || !attempts[0].Succeeded() || attempts[0].StatusCode != 200 {
+		t.Errorf("log = %+v", attempts)
+	}
+}
+
+func TestDeliverRetries(t *testing.T) {
+	recv := &receiver{statuses: []int{503, 429, 200}}
+	srv := httptest.NewServer(recv)
+	defer srv.Close()
+
+	log := &MemoryLog{}
+	d := &Dispatcher{Log: log, BaseDelay: time.Millisecond}
+	endpoint := Endpoint{Name: "erp", URL: srv.URL, Enabled: true}
+	event := ledger.Event{Type: ledger.EventInvoicePaid, VoucherType: "Sales Invoice", VoucherNo: "SINV-1"}
+	if err := d.Deliver(t.Context(), endpoint, event); err != nil {
+		t.Fatal(err)
+	}
+	attempts := log.Attempts("")
+	if len(attempts) != 3 {
+		t.Fatalf("got %d attempts, want 3", len(attempts))
+	}
+	for i, a := range attempts {
+		if a.Attempt != i+1 || a.DeliveryID != attempts[0].DeliveryID {
+			t.Errorf("attempt %d = %+v", i, a)
+		}
+	}
+	if attempts[0].Succeeded() || attempts[0].StatusCode != 503 || !attempts[2].Succeeded() {
+		t.Errorf("attempts = %+v", attempts)
+	}
+
+	// Transport errors are retried
+	recv.statuses = []int{0, 200}
+	if err := d.Deliver(t.Context(), endpoint, event); err != nil {
+		t.Fatal(err)
+	}
+	if attempts = log.Attempts(""); len(attempts) != 5 || attempts[3].StatusCode != 0 || attempts[3].Error == "" || !attempts[4].Succeeded() {
+		t.Errorf("transport error: attempts = %+v", attempts[3:])
+	}
+
+	// Client errors are permanent
+	recv.statuses = []int{400, 200}
+	err := d.Deliver(t.Context(), endpoint, event)
+	if !errors.Is(err, ErrDeliveryFailed) || len(log.Attempts("")) != 6 {
+		t.Errorf("400: err = %v, %d attempts", err, len(log.Attempts("")))
+	}
+
+	// Attempts run out
+	recv.statuses = []int{500, 500, 500}
+	d.MaxAttempts = 2
+	if err := d.Deliver(t.Context(), endpoint, event); !errors.Is(err, ErrDeliveryFailed) || len(recv.statuses) != 1 {
+		t.Errorf("500: err = %v, %d statuses left", err, len(recv.statuses))
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	d := &Dispatcher{BaseDelay: time.Second, MaxDelay: 10 * time.Second}
+	want := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 10 * time.Second, 10 * time.Second}
+	for i, w := range want {
+		if got := d.backoff(i + 1); got != w {
+			t.Errorf("backoff(%d) = %v, want %v", i+1, got, w)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package workflow
+
+// Approval state and action names used by Approval workflows.
+const (
+	StateDraft           = "Draft"
+	StatePendingApproval = "Pending Approval"
+	StateApproved        = "Approved"
+	StateRejected        = "Rejected"
+	StateCancelled       = "Cancelled"
+
+	ActionSubmit          = "Submit"
+	ActionRequestApproval = "Request Approval"
+	ActionApprove         = "Approve"
+	ActionReject          = "Reject"
+	ActionCancel          = "Cancel"
+)
+
+// Approval describes the common "approve above a threshold" workflow:
+// documents up to Threshold are submitted directly, larger ones wait for
+// an approver.
+type Approval struct {
+	DocumentType string
+	Threshold    float64
+	Amount       func(doc any) float64 // e.g. the journal entry's total debit
+
+	SubmitRole   string // e.g. "Accounts User"
+	ApproverRole string // e.g. "Accounts Manager"
+}
+
+// Workflow builds the workflow:
+//
+//	Draft --Submit (amount <= threshold)--> Approved
+//	Draft --Request Approval (amount > threshold)--> Pending Approval
+//	Pending Approval --Approve--> Approved
+//	Pending Approval --Reject--> Rejected
+//	Approved --Cancel--> Cancelled
+func (a Approval) Workflow() *Workflow {
+	small := func(doc any) bool { return a.Amount(doc) <= a.Threshold }
+	large := func(doc any) bool { return a.Amount(doc) > a.Threshold }
+
+	return &Workflow{
+		Name:         a.DocumentType + " Approval",
+		DocumentType: a.DocumentType,
+		States: []State{
+			{Name: StateDraft, DocStatus: Draft, AllowEdit: a.SubmitRole},
+			{Name: StatePendingApproval, DocStatus: Draft, AllowEdit: a.ApproverRole},
+			{Name: StateRejected, DocStatus: Draft, AllowEdit: a.SubmitRole},
+			{Name: StateApproved, DocStatus: Submitted, AllowEdit: a.ApproverRole},
+			{Name: StateCancelled, DocStatus: Cancelled, AllowEdit: a.ApproverRole},
+		},
+		Transitions: []Transition{
+			{State: StateDraft, Action: ActionSubmit, NextState: StateApproved, Allowed: a.SubmitRole, Condition: small},
+			{State: StateDraft, Action: ActionRequestApproval, NextState: StatePendingApproval, Allowed: a.SubmitRole, Condition: large},
+			{State: StatePendingApproval, Action: ActionApprove, NextState: StateApproved, Allowed: a.ApproverRole},
+			{State: StatePendingApproval, Action: ActionReject, NextState: StateRejected, Allowed: a.ApproverRole},
+			{State: StateApproved, Action: ActionCancel, NextState: StateCancelled, Allowed: a.ApproverRole},
+		},
+	}
+}
@@ -0,0 +1,251 @@
+// Package workflow implements approval workflows for financial documents.
+//
+// A workflow lists the states a document moves through and the actions
+// (transitions) that move it, each limited to a role and optionally to a
+// condition on the document. Every state maps to a document status, so a
+// document can only be submitted once it reaches a state with status
+// Submitted: a journal entry above a threshold, for example, waits in
+// "Pending Approval" until an approver acts on it.
+//
+// Migrated from: frappe/workflow/doctype/workflow/workflow.py and
+// frappe/model/workflow.py (get_transitions, apply_workflow,
+// validate_workflow)
+package workflow
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+)
+
+// Workflow errors
+var (
+	ErrInvalidWorkflow   = errors.New("invalid workflow")
+	ErrUnknownState      = errors.New("workflow state not found")
+	ErrActionNotAllowed  = errors.New("workflow action not allowed")
+	ErrNotApproved       = errors.New("document is not in a state that allows this")
+	ErrWorkflowNotActive = errors.New("no workflow for document type")
+)
+
+// DocStatus is the document status a state corresponds to.
+type DocStatus int
+
+const (
+	Draft     DocStatus = 0
+	Submitted DocStatus = 1
+	Cancelled DocStatus = 2
+)
+
+func (s DocStatus) String() string {
+	switch s {
+	case Draft:
+		return "Draft"
+	case Submitted:
+		return "Submitted"
+	case Cancelled:
+		return "Cancelled"
+	}
+	return fmt.Sprintf("DocStatus(%d)", int(s))
+}
+
+// State is a workflow state.
+//
+// Maps to: Workflow Document State
+type State struct {
+	Name      string
+	DocStatus DocStatus
+	AllowEdit string // Role that may edit the document in this state
+}
+
+// Transition is an action that moves a document between states.
+//
+// Maps to: Workflow Transition
+type Transition struct {
+	State     string
+	Action    string // e.g. "Approve", "Reject"
+	NextState string
+	Allowed   string // Role that may take the action
+
+	// Condition, if set, must hold for the document. It replaces the
+	// Python expression of the transition's condition field.
+	Condition func(doc any) bool
+}
+
+// User is the person taking an action.
+type User struct {
+	Name  string
+	Roles []string
+}
+
+// HasRole reports whether the user has a role.
+func (u User) HasRole(role string) bool {
+	return slices.Contains(u.Roles, role)
+}
+
+// Workflow is the workflow of one document type.
+//
+// Maps to: frappe/workflow/doctype/workflow/workflow.json
+type Workflow struct {
+	Name         string
+	DocumentType string
+	States       []State // The first state is where new documents start
+	Transitions  []Transition
+}
+
+// Validate checks that states are unique and transitions link known
+// states, and that a cancelled state is only reached from a submitted one.
+//
+// Maps to: Workflow.validate() -> validate_docstatus()
+func (w *Workflow) Validate() error {
+	if w.DocumentType == "" || len(w.States) == 0 {
+		return fmt.Errorf("%w: %s needs a document type and states", ErrInvalidWorkflow, w.Name)
+	}
+	seen := make(map[string]bool)
+	for _, s := range w.States {
+		if seen[s.Name] {
+			return fmt.Errorf("%w: state %s repeated", ErrInvalidWorkflow, s.Name)
+		}
+		seen[s.Name] = true
+	}
+	for _, t := range w.Transitions {
+		from, ok := w.State(t.State)
+		if !ok {
+			return fmt.Errorf("%w: %s", ErrUnknownState, t.State)
+		}
+		to, ok := w.State(t.NextState)
+		if !ok {
+			return fmt.Errorf("%w: %s", ErrUnknownState, t.NextState)
+		}
+		if from.DocStatus > to.DocStatus || (from.DocStatus == Draft && to.DocStatus == Cancelled) {
+			return fmt.Errorf("%w: cannot change status from %s to %s (%s -> %s)",
+				ErrInvalidWorkflow, from.DocStatus, to.DocStatus, t.State, t.NextState)
+		}
+	}
+	return nil
+}
+
+// InitialState returns the state new documents start in.
+func (w *Workflow) InitialState() string {
+	return w.States[0].Name
+}
+
+// State returns a state by name.
+func (w *Workflow) State(name string) (State, bool) {
+	for _, s := range w.States {
+		if s.Name == name {
+			return s, true
+		}
+	}
+	return State{}, false
+}
+
+// AvailableTransitions returns the actions a user may take on a document in a
+// state.
+//
+// Maps to: get_transitions() in frappe/model/workflow.py
+func (w *Workflow) AvailableTransitions(state string, doc any, user User) []Transition {
+	var result []Transition
+	for _, t := range w.Transitions {
+		if t.State == state && user.HasRole(t.Allowed) && (t.Condition == nil || t.Condition(doc)) {
+			result = append(result, t)
+		}
+	}
+	return result
+}
+
+// Apply takes an action on a document in a state and returns the state it
+// moves to. The caller stores the new state, and submits or cancels the
+// document when the new state's DocStatus says so.
+//
+// Maps to: apply_workflow() in frappe/model/workflow.py
+func (w *Workflow) Apply(state, action string, doc any, user User) (State, error) {
+	for _, t := range w.AvailableTransitions(state, doc, user) {
+		if t.Action == action {
+			next, _ := w.State(t.NextState)
+			return next, nil
+		}
+	}
+	return State{}, fmt.Errorf("%w: %s by %s on %s in state %s", ErrActionNotAllowed, action, user.Name, w.DocumentType, state)
+}
+
+// StateLookup returns the current workflow state of a document.
+//
+// Maps to: the workflow_state field of the document
+type StateLookup interface {
+	WorkflowState(doctype, name string) (string, error)
+}
+
+// Registry holds the active workflow of each document type and gates
+// postings on it. It implements ledger.PostingGate.
+type Registry struct {
+	States StateLookup
+
+	mu        sync.RWMutex
+	workflows map[string]*Workflow
+}
+
+// NewRegistry creates a registry reading document states from states.
+func NewRegistry(states StateLookup) *Registry {
+	return &Registry{States: states, workflows: make(map[string]*Workflow)}
+}
+
+// Register makes a workflow the active one for its document type.
+func (r *Registry) Register(w *Workflow) error {
+	if err := w.Validate(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.workflows[w.DocumentType] = w
+	return nil
+}
+
+// For returns the active workflow of a document type.
+func (r *Registry) For(doctype string) (*Workflow, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	w, ok := r.workflows[doctype]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrWorkflowNotActive, doctype)
+	}
+	return w, nil
+}
+
+// Check returns an error unless a document in a state may move to the
+// given status. Document types without a workflow are always allowed.
+//
+// Maps to: validate_workflow() in frappe/model/workflow.py
+func (r *Registry) Check(doctype, state string, status DocStatus) error {
+	w, err := r.For(doctype)
+	if err != nil {
+		return nil
+	}
+	s, ok := w.State(state)
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownState, state)
+	}
+	if s.DocStatus != status {
+		return fmt.Errorf("%w: %s is %s, which does not allow %s", ErrNotApproved, doctype, state, status)
+	}
+	return nil
+}
+
+// AllowPosting implements ledger.PostingGate.
+func (r *Registry) AllowPosting(voucherType, voucherNo string, cancel bool) error {
+	if _, err := r.For(voucherType); err != nil {
+		return nil
+	}
+	state, err := r.States.WorkflowState(voucherType, voucherNo)
+	if err != nil {
+		return err
+	}
+	status := Submitted
+	if cancel {
+		status = Cancelled
+	}
+	if err := r.Check(voucherType, state, status); err != nil {
+		return fmt.Errorf("%s: %w", voucherNo, err)
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+package workflow
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/senguttuvang/erpnext-go/ledger"
+	"github.com/senguttuvang/erpnext-go/ledger/memstore"
+)
+
+var _ ledger.PostingGate = (*Registry)(nil)
+
+// mockStates keeps workflow states by document name.
+type mockStates map[string]string
+
+func (m mockStates) WorkflowState(doctype, name string) (string, error) {
+	return m[name], nil
+}
+
+type journal struct {
+	Name  string
+	Total float64
+}
+
+var (
+	clerk   = User{Name: "clerk", Roles: []string{"Accounts User"}}
+	manager = User{Name: "manager", Roles: []string{"Accounts User", "Accounts Manager"}}
+)
+
+func journalApproval() *Workflow {
+	return Approval{
+		DocumentType: "Journal Entry",
+		Threshold:    10000,
+		Amount:       func(doc any) float64 { return doc.(*journal).Total },
+		SubmitRole:   "Accounts User",
+		ApproverRole: "Accounts Manager",
+	}.Workflow()
+}
+
+func journalGLMap(name string, amount float64) []ledger.GLEntry {
+	date := time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)
+	return []ledger.GLEntry{
+		{PostingDate: date, Account: "Rent - ACME", VoucherType: "Journal Entry", VoucherNo: name, Company: "ACME", Debit: amount, DebitInAccountCurrency: amount},
+		{PostingDate: date, Account: "Bank - ACME", VoucherType: "Journal Entry", VoucherNo: name, Company: "ACME", Credit: amount, CreditInAccountCurrency: amount},
+	}
+}
+
+func TestApply(t *testing.T) {
+	w := journalApproval()
+	if err := w.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	small := &journal{Name: "JV-0001", Total: 5000}
+	large := &journal{Name: "JV-0002", Total: 50000}
+
+	tests := []struct {
+		name   string
+		state  string
+		action string
+		doc    *journal
+		user   User
+		want   string
+		err    error
+	}{
+		{"small submits directly", StateDraft, ActionSubmit, small, clerk, StateApproved, nil},
+		{"large cannot submit directly", StateDraft, ActionSubmit, large, clerk, "", ErrActionNotAllowed},
+		{"large requests approval", StateDraft, ActionRequestApproval, large, clerk, StatePendingApproval, nil},
+		{"clerk cannot approve", StatePendingApproval, ActionApprove, large, clerk, "", ErrActionNotAllowed},
+		{"manager approves", StatePendingApproval, ActionApprove, large, manager, StateApproved, nil},
+		{"manager rejects", StatePendingApproval, ActionReject, large, manager, StateRejected, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := w.Apply(tt.state, tt.action, tt.doc, tt.user)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Apply() error = %v, want %v", err, tt.err)
+			}
+			if got.Name != tt.want {
+				t.Errorf("Apply() = %s, want %s", got.Name, tt.want)
+			}
+		})
+	}
+
+	actions := w.AvailableTransitions(StateDraft, large, clerk)
+	if len(actions) != 1 || actions[0].Action != ActionRequestApproval {
+		t.Errorf("AvailableTransitions() = %+v", actions)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	w := journalApproval()
+	w.Transitions = append(w.Transitions, Transition{State: StateApproved, Action: "Reopen", NextState: StateDraft, Allowed: "Accounts Manager"})
+	if err := w.Validate(); !errors.Is(err, ErrInvalidWorkflow) {
+		t.Errorf("submitted -> draft: got %v, want ErrInvalidWorkflow", err)
+	}
+
+	w = journalApproval()
+	w.Transitions[0].NextState = "Posted"
+	if err := w.Validate(); !errors.Is(err, ErrUnknownState) {
+		t.Errorf("unknown state: got %v, want ErrUnknownState", err)
+	}
+}
+
+func TestPostingGate(t *testing.T) {
+	states := mockStates{"JV-0002": StatePendingApproval}
+	registry := NewRegistry(states)
+	if err := registry.Register(journalApproval()); err != nil {
+		t.Fatal(err)
+	}
+	glStore := memstore.NewGLStore()
+	engine := &ledger.Engine{GLStore: glStore, PaymentStore: memstore.NewPaymentStore(), Gate: registry}
+
+	// Waiting for approval: nothing is posted
+	err := engine.MakeGLEntries(journalGLMap("JV-0002", 50000), ledger.DefaultPostingOptions())
+	if !errors.Is(err, ErrNotApproved) {
+		t.Fatalf("pending journal: got %v, want ErrNotApproved", err)
+	}
+	if n := len(glStore.All()); n != 0 {
+		t.Fatalf("%d entries posted before approval", n)
+	}
+
+	doc := &journal{Name: "JV-0002", Total: 50000}
+	w, _ := registry.For("Journal Entry")
+	next, err := w.Apply(states["JV-0002"], ActionApprove, doc, manager)
+	if err != nil {
+		t.Fatal(err)
+	}
+	states["JV-0002"] = next.Name
+	if err := engine.MakeGLEntries(journalGLMap("JV-0002", 50000), ledger.DefaultPostingOptions()); err != nil {
+		t.Fatalf("approved journal: %v", err)
+	}
+
+	// Cancelling needs the Cancelled state
+	cancel := ledger.DefaultPostingOptions()
+	cancel.Cancel = true
+	if err := engine.MakeGLEntries(journalGLMap("JV-0002", 50000), cancel); !errors.Is(err, ErrNotApproved) {
+		t.Errorf("cancel while approved: got %v, want ErrNotApproved", err)
+	}
+	states["JV-0002"] = StateCancelled
+	if err := engine.MakeGLEntries(journalGLMap("JV-0002", 50000), cancel); err != nil {
+		t.Errorf("cancel: %v", err)
+	}
+
+	// Document types without a workflow are not gated
+	glMap := journalGLMap("SINV-0001", 100)
+	for i := range glMap {
+		glMap[i].VoucherType = "Sales Invoice"
+	}
+	if err := engine.MakeGLEntries(glMap, ledger.DefaultPostingOptions()); err != nil {
+		t.Errorf("ungated voucher: %v", err)
+	}
+}